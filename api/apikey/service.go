@@ -57,6 +57,11 @@ func (a *apiKeyService) HashRaw(rawKey string) string {
 // GenerateApiKey generates a raw API key for a user (for one-time display).
 // The generated API key is stored in the cache and database.
 func (a *apiKeyService) GenerateApiKey(user portainer.User, description string) (string, *portainer.APIKey, error) {
+	return a.GenerateScopedApiKey(user, description, "")
+}
+
+// GenerateScopedApiKey generates a raw API key for a user, restricted to the endpoints of the given scope.
+func (a *apiKeyService) GenerateScopedApiKey(user portainer.User, description string, scope portainer.APIKeyScope) (string, *portainer.APIKey, error) {
 	randKey := GenerateRandomKey(32)
 	encodedRawAPIKey := base64.StdEncoding.EncodeToString(randKey)
 	prefixedAPIKey := portainerAPIKeyPrefix + encodedRawAPIKey
@@ -68,6 +73,7 @@ func (a *apiKeyService) GenerateApiKey(user portainer.User, description string)
 		Prefix:      prefixedAPIKey[:7],
 		DateCreated: time.Now().Unix(),
 		Digest:      hashDigest,
+		Scope:       scope,
 	}
 
 	if err := a.apiKeyRepository.Create(apiKey); err != nil {
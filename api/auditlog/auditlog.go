@@ -0,0 +1,249 @@
+// Package auditlog records the state-changing API calls, who made them, from where and on which resource, into an
+// append-only store. The records can be forwarded to a syslog server or an HTTP sink, they are purged past their
+// retention by the retention service
+package auditlog
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+	"github.com/portainer/portainer/api/http/security"
+
+	"github.com/pmezard/go-difflib/difflib"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// maxPayloadSize is the size above which the request payloads are not recorded
+	maxPayloadSize = 64 * 1024
+	// forwardQueueSize is the number of audit logs waiting to be forwarded above which the new ones are dropped
+	forwardQueueSize = 1024
+)
+
+// Service records the state-changing API calls
+type Service struct {
+	dataStore dataservices.DataStore
+	forwarder *forwarder
+
+	mu sync.Mutex
+	// lastPayloads holds the last payload recorded for every resource, the payloads are diffed against it. It is
+	// loaded from the datastore on first use
+	lastPayloads map[string]string
+}
+
+// NewService creates a new audit log service
+func NewService(dataStore dataservices.DataStore) *Service {
+	return &Service{
+		dataStore: dataStore,
+		forwarder: newForwarder(dataStore),
+	}
+}
+
+// Start forwards the new audit logs until ctx is done
+func (service *Service) Start(ctx context.Context) {
+	service.forwarder.run(ctx)
+}
+
+// Middleware records the state-changing calls to the API served by next, once they have been served
+func (service *Service) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !isStateChanging(r.Method) || !strings.HasPrefix(r.URL.Path, "/api/") {
+			next.ServeHTTP(w, r)
+
+			return
+		}
+
+		payload := capturePayload(r)
+
+		var tokenData *portainer.TokenData
+		r = r.WithContext(security.WithTokenDataRecorder(r.Context(), &tokenData))
+
+		recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(recorder, r)
+
+		resource := resourceFromPath(r.URL.Path)
+
+		auditLog := &portainer.AuditLog{
+			Timestamp:    time.Now().Unix(),
+			SourceIP:     security.StripAddrPort(r.RemoteAddr),
+			Method:       r.Method,
+			Path:         r.URL.Path,
+			ResourceType: resource.resourceType,
+			ResourceID:   resource.resourceID,
+			EndpointID:   resource.endpointID,
+			StatusCode:   recorder.status,
+			Payload:      payload,
+		}
+
+		if tokenData != nil {
+			auditLog.UserID = tokenData.ID
+			auditLog.Username = tokenData.Username
+			auditLog.ImpersonatorID = tokenData.ImpersonatorID
+		}
+
+		if err := service.record(auditLog); err != nil {
+			log.Error().Err(err).Str("path", r.URL.Path).Msg("unable to record the audit log")
+		}
+	})
+}
+
+// record stores an audit log, diffing its payload against the last one recorded for the same resource when the
+// call is an update of the resource
+func (service *Service) record(auditLog *portainer.AuditLog) error {
+	if err := service.diffPayload(auditLog); err != nil {
+		log.Warn().Err(err).Msg("unable to diff the payload of the audit log")
+	}
+
+	if err := service.dataStore.AuditLog().Create(auditLog); err != nil {
+		return err
+	}
+
+	service.forwarder.enqueue(*auditLog)
+
+	return nil
+}
+
+func (service *Service) diffPayload(auditLog *portainer.AuditLog) error {
+	if auditLog.ResourceID == "" || auditLog.Payload == "" {
+		return nil
+	}
+
+	service.mu.Lock()
+	defer service.mu.Unlock()
+
+	if service.lastPayloads == nil {
+		auditLogs, err := service.dataStore.AuditLog().ReadAll()
+		if err != nil {
+			return err
+		}
+
+		service.lastPayloads = make(map[string]string)
+		for _, previous := range auditLogs {
+			if previous.ResourceID != "" && previous.Payload != "" && isSuccessful(previous.StatusCode) {
+				service.lastPayloads[resourceKey(previous)] = previous.Payload
+			}
+		}
+	}
+
+	key := resourceKey(*auditLog)
+
+	if previous, ok := service.lastPayloads[key]; ok && isUpdate(auditLog.Method) && previous != auditLog.Payload {
+		diff, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+			A:        difflib.SplitLines(previous),
+			B:        difflib.SplitLines(auditLog.Payload),
+			FromFile: "previous",
+			ToFile:   "current",
+			Context:  1,
+		})
+		if err != nil {
+			return err
+		}
+
+		auditLog.PayloadDiff = diff
+	}
+
+	if isSuccessful(auditLog.StatusCode) {
+		service.lastPayloads[key] = auditLog.Payload
+	}
+
+	return nil
+}
+
+func resourceKey(auditLog portainer.AuditLog) string {
+	return auditLog.ResourceType + "/" + auditLog.ResourceID
+}
+
+// capturePayload returns the redacted JSON payload of a request, leaving its body readable by the handlers. The
+// payloads which are not JSON or are too large are not captured
+func capturePayload(r *http.Request) string {
+	if r.Body == nil || r.ContentLength > maxPayloadSize {
+		return ""
+	}
+
+	if contentType := r.Header.Get("Content-Type"); contentType != "" && !strings.HasPrefix(contentType, "application/json") {
+		return ""
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxPayloadSize+1))
+	r.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(body), r.Body), r.Body}
+
+	if err != nil || len(body) == 0 || len(body) > maxPayloadSize {
+		return ""
+	}
+
+	return redactPayload(body)
+}
+
+func isStateChanging(method string) bool {
+	switch method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
+	}
+
+	return false
+}
+
+func isUpdate(method string) bool {
+	return method == http.MethodPut || method == http.MethodPatch
+}
+
+func isSuccessful(status int) bool {
+	return status >= 200 && status < 300
+}
+
+// statusRecorder records the status of a response, the hijacked connections being recorded as switching protocols
+type statusRecorder struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+}
+
+func (recorder *statusRecorder) WriteHeader(status int) {
+	if !recorder.wroteHeader {
+		recorder.status = status
+		recorder.wroteHeader = true
+	}
+
+	recorder.ResponseWriter.WriteHeader(status)
+}
+
+func (recorder *statusRecorder) Write(b []byte) (int, error) {
+	recorder.wroteHeader = true
+
+	return recorder.ResponseWriter.Write(b)
+}
+
+func (recorder *statusRecorder) Unwrap() http.ResponseWriter {
+	return recorder.ResponseWriter
+}
+
+func (recorder *statusRecorder) Flush() {
+	if flusher, ok := recorder.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func (recorder *statusRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := recorder.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("the response writer does not support hijacking")
+	}
+
+	recorder.status = http.StatusSwitchingProtocols
+	recorder.wroteHeader = true
+
+	return hijacker.Hijack()
+}
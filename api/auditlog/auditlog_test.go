@@ -0,0 +1,91 @@
+package auditlog
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/datastore"
+	"github.com/portainer/portainer/api/http/security"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMiddleware(t *testing.T) {
+	is := require.New(t)
+
+	_, store := datastore.MustNewTestStore(t, true, false)
+
+	service := NewService(store)
+
+	handler := service.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		is.NoError(err)
+
+		// the handlers still read the whole payload
+		if r.Method == http.MethodPut {
+			is.Contains(string(body), "hunter2")
+		}
+
+		security.StoreTokenData(r, &portainer.TokenData{ID: 2, Username: "bob"})
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	serve := func(method, path, body string) {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.RemoteAddr = "10.0.0.1:12345"
+
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	serve(http.MethodGet, "/api/stacks/1", "")
+	serve(http.MethodPut, "/api/stacks/1", `{"Env":[{"name":"A","value":"1"}],"Password":"hunter2"}`)
+	serve(http.MethodPut, "/api/stacks/1", `{"Env":[{"name":"A","value":"2"}],"Password":"hunter2"}`)
+
+	auditLogs, err := store.AuditLog().ReadAll()
+	is.NoError(err)
+	is.Len(auditLogs, 2, "the GET requests are not recorded")
+
+	auditLog := auditLogs[1]
+	is.Equal(portainer.UserID(2), auditLog.UserID)
+	is.Equal("bob", auditLog.Username)
+	is.Equal("10.0.0.1", auditLog.SourceIP)
+	is.Equal("stacks", auditLog.ResourceType)
+	is.Equal("1", auditLog.ResourceID)
+	is.Equal(http.StatusNoContent, auditLog.StatusCode)
+	is.NotContains(auditLog.Payload, "hunter2")
+	is.Contains(auditLog.Payload, redactedValue)
+	is.Contains(auditLog.PayloadDiff, `-      "value": "1"`)
+	is.Contains(auditLog.PayloadDiff, `+      "value": "2"`)
+	is.Empty(auditLogs[0].PayloadDiff)
+}
+
+func TestResourceFromPath(t *testing.T) {
+	is := require.New(t)
+
+	cases := []struct {
+		path     string
+		expected resource
+	}{
+		{"/api/stacks/12/start", resource{resourceType: "stacks", resourceID: "12"}},
+		{"/api/stacks/create/swarm/repository", resource{resourceType: "stacks"}},
+		{"/api/endpoints/3", resource{resourceType: "endpoints", resourceID: "3", endpointID: 3}},
+		{"/api/endpoints/3/docker/v1.41/containers/abc/stop", resource{resourceType: "docker/containers", resourceID: "abc", endpointID: 3}},
+		{"/api/endpoints/3/docker/containers/create", resource{resourceType: "docker/containers", endpointID: 3}},
+		{"/api/endpoints/3/docker/images/registry.local/app/push", resource{resourceType: "docker/images", resourceID: "registry.local/app", endpointID: 3}},
+		{"/api/endpoints/3/kubernetes/api/v1/namespaces/default/pods/web", resource{resourceType: "kubernetes/pods", resourceID: "default/web", endpointID: 3}},
+		{"/api/endpoints/3/kubernetes/apis/apps/v1/namespaces/default/deployments", resource{resourceType: "kubernetes/deployments", endpointID: 3}},
+		{"/api/endpoints/3/kubernetes/api/v1/namespaces/staging", resource{resourceType: "kubernetes/namespaces", resourceID: "staging", endpointID: 3}},
+		{"/api/kubernetes/3/nodes/worker-1/drain", resource{resourceType: "kubernetes/nodes", resourceID: "worker-1", endpointID: 3}},
+		{"/api/kubernetes/3/nodes/worker-1", resource{resourceType: "kubernetes/nodes", resourceID: "worker-1", endpointID: 3}},
+		{"/api/kubernetes/config", resource{resourceType: "kubernetes"}},
+	}
+
+	for _, c := range cases {
+		is.Equal(c.expected, resourceFromPath(c.path), c.path)
+	}
+}
@@ -0,0 +1,172 @@
+package auditlog
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// SignatureHeader is the header holding the HMAC-SHA256 signature of the audit logs posted to the HTTP sink
+	SignatureHeader = "X-Portainer-Signature"
+	// syslogPriority is the priority of the syslog messages, the info severity of the authpriv facility
+	syslogPriority = 10*8 + 6
+	forwardTimeout = 10 * time.Second
+)
+
+// forwarder sends the audit logs to the syslog server and the HTTP sink of the settings, in the order they were
+// recorded
+type forwarder struct {
+	dataStore dataservices.DataStore
+	client    *http.Client
+	queue     chan portainer.AuditLog
+}
+
+func newForwarder(dataStore dataservices.DataStore) *forwarder {
+	return &forwarder{
+		dataStore: dataStore,
+		client:    &http.Client{Timeout: forwardTimeout},
+		queue:     make(chan portainer.AuditLog, forwardQueueSize),
+	}
+}
+
+func (f *forwarder) enqueue(auditLog portainer.AuditLog) {
+	select {
+	case f.queue <- auditLog:
+	default:
+		log.Warn().Int("id", int(auditLog.ID)).Msg("the audit log forwarding queue is full, the audit log is not forwarded")
+	}
+}
+
+func (f *forwarder) run(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case auditLog := <-f.queue:
+			f.forward(auditLog)
+		}
+	}
+}
+
+func (f *forwarder) forward(auditLog portainer.AuditLog) {
+	settings, err := f.dataStore.Settings().Settings()
+	if err != nil {
+		log.Error().Err(err).Msg("unable to retrieve the settings to forward the audit log")
+
+		return
+	}
+
+	auditLogSettings := settings.AuditLogSettings
+	if auditLogSettings.SyslogAddress == "" && auditLogSettings.HTTPSinkURL == "" {
+		return
+	}
+
+	body, err := json.Marshal(auditLog)
+	if err != nil {
+		log.Error().Err(err).Msg("unable to encode the audit log")
+
+		return
+	}
+
+	if auditLogSettings.SyslogAddress != "" {
+		if err := sendSyslog(auditLogSettings.SyslogAddress, time.Unix(auditLog.Timestamp, 0), body); err != nil {
+			log.Warn().Err(err).Str("address", auditLogSettings.SyslogAddress).Msg("unable to forward the audit log to syslog")
+		}
+	}
+
+	if auditLogSettings.HTTPSinkURL != "" {
+		if err := postHTTPSink(f.client, auditLogSettings, body); err != nil {
+			log.Warn().Err(err).Str("url", auditLogSettings.HTTPSinkURL).Msg("unable to forward the audit log to the HTTP sink")
+		}
+	}
+}
+
+// ParseSyslogAddress returns the network and the address of a syslog server written as udp://host:port or
+// tcp://host:port
+func ParseSyslogAddress(address string) (string, string, error) {
+	u, err := url.Parse(address)
+	if err != nil {
+		return "", "", err
+	}
+
+	if u.Scheme != "udp" && u.Scheme != "tcp" {
+		return "", "", fmt.Errorf("unsupported syslog network %q, must be udp or tcp", u.Scheme)
+	}
+
+	if _, _, err := net.SplitHostPort(u.Host); err != nil {
+		return "", "", err
+	}
+
+	return u.Scheme, u.Host, nil
+}
+
+// sendSyslog sends a message in the RFC 5424 format to a syslog server
+func sendSyslog(address string, timestamp time.Time, message []byte) error {
+	network, host, err := ParseSyslogAddress(address)
+	if err != nil {
+		return err
+	}
+
+	conn, err := net.DialTimeout(network, host, forwardTimeout)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	hostname, err := os.Hostname()
+	if err != nil || hostname == "" {
+		hostname = "-"
+	}
+
+	if err := conn.SetWriteDeadline(time.Now().Add(forwardTimeout)); err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(conn, "<%d>1 %s %s portainer - audit - %s\n", syslogPriority, timestamp.UTC().Format(time.RFC3339), hostname, message)
+
+	return err
+}
+
+// postHTTPSink posts an audit log to the HTTP sink, signing it when a secret is set
+func postHTTPSink(client *http.Client, settings portainer.AuditLogSettings, body []byte) error {
+	req, err := http.NewRequest(http.MethodPost, settings.HTTPSinkURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	if settings.HTTPSinkSecret != "" {
+		mac := hmac.New(sha256.New, []byte(settings.HTTPSinkSecret))
+		mac.Write(body)
+
+		req.Header.Set(SignatureHeader, "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("the HTTP sink returned the status %d", resp.StatusCode)
+	}
+
+	return nil
+}
@@ -0,0 +1,68 @@
+package auditlog
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// redactedValue replaces the values of the secret fields in the recorded payloads
+const redactedValue = "[REDACTED]"
+
+// secretFieldNames are the lowercase substrings of the names of the payload fields holding secrets
+var secretFieldNames = []string{
+	"password",
+	"passphrase",
+	"secret",
+	"token",
+	"apikey",
+	"privatekey",
+	"tlskey",
+	"credential",
+}
+
+// redactPayload returns the indented JSON of a payload with the values of its secret fields redacted, the payloads
+// which are not valid JSON are not recorded
+func redactPayload(body []byte) string {
+	var payload any
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return ""
+	}
+
+	redacted, err := json.MarshalIndent(redact(payload), "", "  ")
+	if err != nil {
+		return ""
+	}
+
+	return string(redacted)
+}
+
+func redact(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		for key, field := range v {
+			if isSecretField(key) && field != nil {
+				v[key] = redactedValue
+			} else {
+				v[key] = redact(field)
+			}
+		}
+	case []any:
+		for i, item := range v {
+			v[i] = redact(item)
+		}
+	}
+
+	return value
+}
+
+func isSecretField(name string) bool {
+	name = strings.ToLower(name)
+
+	for _, secret := range secretFieldNames {
+		if strings.Contains(name, secret) {
+			return true
+		}
+	}
+
+	return false
+}
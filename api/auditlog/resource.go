@@ -0,0 +1,146 @@
+package auditlog
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	portainer "github.com/portainer/portainer/api"
+)
+
+var (
+	dockerVersionSegment = regexp.MustCompile(`^v[0-9]+\.[0-9]+$`)
+	uuidSegment          = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+)
+
+// dockerCollectionActions are the segments following the type of a Docker object which are not an identifier
+var dockerCollectionActions = map[string]bool{
+	"create": true,
+	"prune":  true,
+	"json":   true,
+	"load":   true,
+	"search": true,
+	"get":    true,
+	"pull":   true,
+}
+
+// dockerImageActions are the segments following the name of an image which are not part of the name
+var dockerImageActions = map[string]bool{
+	"get":     true,
+	"history": true,
+	"json":    true,
+	"push":    true,
+	"tag":     true,
+}
+
+type resource struct {
+	resourceType string
+	resourceID   string
+	endpointID   portainer.EndpointID
+}
+
+// resourceFromPath returns the resource targeted by a call to the API, the calls proxied to the Docker and
+// Kubernetes APIs of an environment(endpoint) targeting their Docker objects and Kubernetes resources
+func resourceFromPath(path string) resource {
+	segments := strings.Split(strings.Trim(strings.TrimPrefix(path, "/api"), "/"), "/")
+
+	if len(segments) >= 3 && segments[0] == "endpoints" {
+		if endpointID, err := strconv.Atoi(segments[1]); err == nil {
+			switch segments[2] {
+			case "docker":
+				return dockerResource(portainer.EndpointID(endpointID), segments[3:])
+			case "kubernetes":
+				return kubernetesResource(portainer.EndpointID(endpointID), segments[3:])
+			}
+		}
+	}
+
+	// the Kubernetes API of Portainer, such as /api/kubernetes/{id}/nodes/{name}/drain
+	if len(segments) >= 3 && segments[0] == "kubernetes" {
+		if endpointID, err := strconv.Atoi(segments[1]); err == nil {
+			return kubernetesResource(portainer.EndpointID(endpointID), segments[2:])
+		}
+	}
+
+	r := resource{resourceType: segments[0]}
+	if len(segments) > 1 && isIdentifier(segments[1]) {
+		r.resourceID = segments[1]
+	}
+
+	if r.resourceType == "endpoints" && r.resourceID != "" {
+		endpointID, _ := strconv.Atoi(r.resourceID)
+		r.endpointID = portainer.EndpointID(endpointID)
+	}
+
+	return r
+}
+
+func dockerResource(endpointID portainer.EndpointID, segments []string) resource {
+	if len(segments) > 0 && dockerVersionSegment.MatchString(segments[0]) {
+		segments = segments[1:]
+	}
+
+	r := resource{resourceType: "docker", endpointID: endpointID}
+	if len(segments) == 0 {
+		return r
+	}
+
+	r.resourceType += "/" + segments[0]
+
+	if len(segments) > 1 && !dockerCollectionActions[segments[1]] {
+		r.resourceID = segments[1]
+
+		// the names of the images can contain slashes
+		if segments[0] == "images" {
+			names := segments[1:]
+			if len(names) > 1 && dockerImageActions[names[len(names)-1]] {
+				names = names[:len(names)-1]
+			}
+
+			r.resourceID = strings.Join(names, "/")
+		}
+	}
+
+	return r
+}
+
+func kubernetesResource(endpointID portainer.EndpointID, segments []string) resource {
+	// skip the /api/{version} and /apis/{group}/{version} prefixes
+	switch {
+	case len(segments) >= 2 && segments[0] == "api":
+		segments = segments[2:]
+	case len(segments) >= 3 && segments[0] == "apis":
+		segments = segments[3:]
+	}
+
+	r := resource{resourceType: "kubernetes", endpointID: endpointID}
+
+	namespace := ""
+	if len(segments) > 2 && segments[0] == "namespaces" {
+		namespace = segments[1]
+		segments = segments[2:]
+	}
+
+	if len(segments) == 0 {
+		return r
+	}
+
+	r.resourceType += "/" + segments[0]
+
+	if len(segments) > 1 {
+		r.resourceID = segments[1]
+		if namespace != "" {
+			r.resourceID = namespace + "/" + r.resourceID
+		}
+	}
+
+	return r
+}
+
+func isIdentifier(segment string) bool {
+	if _, err := strconv.Atoi(segment); err == nil {
+		return true
+	}
+
+	return uuidSegment.MatchString(segment)
+}
@@ -0,0 +1,153 @@
+package authprovider
+
+import (
+	"errors"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+	httperrors "github.com/portainer/portainer/api/http/errors"
+)
+
+// RegisterBuiltins registers the providers shipped with Portainer in the default registry
+func RegisterBuiltins(dataStore dataservices.DataStore, cryptoService portainer.CryptoService, ldapService portainer.LDAPService) error {
+	for _, provider := range []Provider{
+		&internalProvider{dataStore: dataStore, cryptoService: cryptoService},
+		&ldapProvider{dataStore: dataStore, ldapService: ldapService},
+		&redirectProvider{method: portainer.AuthenticationOAuth, name: "OAuth"},
+		&redirectProvider{method: portainer.AuthenticationSAML, name: "SAML"},
+	} {
+		if err := Register(provider); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// internalProvider authenticates the users against the passwords stored by Portainer
+type internalProvider struct {
+	dataStore     dataservices.DataStore
+	cryptoService portainer.CryptoService
+}
+
+func (provider *internalProvider) Info() Info {
+	return Info{
+		Method:       portainer.AuthenticationInternal,
+		Name:         "Internal",
+		Capabilities: Capabilities{PasswordLogin: true},
+	}
+}
+
+func (provider *internalProvider) Authenticate(username, password string) error {
+	user, err := provider.dataStore.User().UserByUsername(username)
+	if err != nil {
+		if provider.dataStore.IsErrObjectNotFound(err) {
+			return ErrInvalidCredentials
+		}
+
+		return err
+	}
+
+	if err := provider.cryptoService.CompareHashAndData(user.Password, password); err != nil {
+		return ErrInvalidCredentials
+	}
+
+	return nil
+}
+
+func (provider *internalProvider) GetUserGroups(username string) ([]string, error) {
+	return nil, ErrNotSupported
+}
+
+func (provider *internalProvider) TestConnectivity() error {
+	return nil
+}
+
+// ldapProvider authenticates the users against a LDAP server
+type ldapProvider struct {
+	dataStore   dataservices.DataStore
+	ldapService portainer.LDAPService
+}
+
+func (provider *ldapProvider) Info() Info {
+	info := Info{
+		Method: portainer.AuthenticationLDAP,
+		Name:   "LDAP",
+		Capabilities: Capabilities{
+			PasswordLogin:    true,
+			ConnectivityTest: true,
+		},
+	}
+
+	if settings, err := provider.dataStore.Settings().Settings(); err == nil {
+		ldapSettings := settings.LDAPSettings
+
+		info.Capabilities.AutoCreateUsers = ldapSettings.AutoCreateUsers
+		// the teams are only synchronized when a group base DN is configured
+		info.Capabilities.GroupSync = len(ldapSettings.GroupSearchSettings) > 0 && len(ldapSettings.GroupSearchSettings[0].GroupBaseDN) > 0
+	}
+
+	return info
+}
+
+func (provider *ldapProvider) Authenticate(username, password string) error {
+	settings, err := provider.dataStore.Settings().Settings()
+	if err != nil {
+		return err
+	}
+
+	if err := provider.ldapService.AuthenticateUser(username, password, &settings.LDAPSettings); err != nil {
+		if errors.Is(err, httperrors.ErrUnauthorized) {
+			return ErrInvalidCredentials
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+func (provider *ldapProvider) GetUserGroups(username string) ([]string, error) {
+	settings, err := provider.dataStore.Settings().Settings()
+	if err != nil {
+		return nil, err
+	}
+
+	return provider.ldapService.GetUserGroups(username, &settings.LDAPSettings)
+}
+
+func (provider *ldapProvider) TestConnectivity() error {
+	settings, err := provider.dataStore.Settings().Settings()
+	if err != nil {
+		return err
+	}
+
+	return provider.ldapService.TestConnectivity(&settings.LDAPSettings)
+}
+
+// redirectProvider represents the providers redirecting the users to an external identity provider,
+// the login flow is handled by their dedicated handlers
+type redirectProvider struct {
+	method portainer.AuthenticationMethod
+	name   string
+}
+
+func (provider *redirectProvider) Info() Info {
+	return Info{
+		Method:       provider.method,
+		Name:         provider.name,
+		Capabilities: Capabilities{RedirectLogin: true},
+	}
+}
+
+func (provider *redirectProvider) Authenticate(username, password string) error {
+	return ErrNotSupported
+}
+
+func (provider *redirectProvider) GetUserGroups(username string) ([]string, error) {
+	return nil, ErrNotSupported
+}
+
+func (provider *redirectProvider) TestConnectivity() error {
+	return ErrNotSupported
+}
@@ -0,0 +1,154 @@
+// Package authprovider defines the interface implemented by the authentication providers and the registry
+// used to plug them. The handlers only rely on the registry, adding a provider does not require to change them
+package authprovider
+
+import (
+	"fmt"
+	"slices"
+	"sync"
+
+	portainer "github.com/portainer/portainer/api"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	// ErrInvalidCredentials is returned by Authenticate when the credentials are wrong
+	ErrInvalidCredentials = errors.New("invalid credentials")
+	// ErrNotSupported is returned when the provider does not support the operation
+	ErrNotSupported = errors.New("operation not supported by the authentication provider")
+)
+
+// Capabilities describes the operations supported by a provider, they are exposed to the UI
+type Capabilities struct {
+	// PasswordLogin is true when the users authenticate with a username and a password sent to Portainer
+	PasswordLogin bool `json:"PasswordLogin"`
+	// RedirectLogin is true when the users are redirected to an external identity provider
+	RedirectLogin bool `json:"RedirectLogin"`
+	// GroupSync is true when the teams of the users are synchronized with their groups
+	GroupSync bool `json:"GroupSync"`
+	// ConnectivityTest is true when the connection to the backend of the provider can be tested
+	ConnectivityTest bool `json:"ConnectivityTest"`
+	// AutoCreateUsers is true when unknown users are created on their first login
+	AutoCreateUsers bool `json:"AutoCreateUsers"`
+}
+
+// Info describes a provider
+type Info struct {
+	Method       portainer.AuthenticationMethod `json:"Method" example:"2"`
+	Name         string                         `json:"Name" example:"LDAP"`
+	Capabilities Capabilities                   `json:"Capabilities"`
+}
+
+// Provider represents an authentication provider. The providers read their configuration themselves,
+// the capabilities can change along with it
+type Provider interface {
+	// Info describes the provider and its current capabilities
+	Info() Info
+	// Authenticate checks the credentials of a user, it returns ErrInvalidCredentials when they are wrong
+	Authenticate(username, password string) error
+	// GetUserGroups returns the groups of a user, used to synchronize its teams
+	GetUserGroups(username string) ([]string, error)
+	// TestConnectivity checks that the provider can reach its backend
+	TestConnectivity() error
+}
+
+// Registry holds the registered providers, indexed by authentication method
+type Registry struct {
+	mu        sync.RWMutex
+	providers map[portainer.AuthenticationMethod]Provider
+}
+
+// NewRegistry returns a pointer to a new empty registry
+func NewRegistry() *Registry {
+	return &Registry{providers: map[portainer.AuthenticationMethod]Provider{}}
+}
+
+// Register adds a provider, the authentication method must not be used by another provider
+func (registry *Registry) Register(provider Provider) error {
+	method := provider.Info().Method
+	if method <= 0 {
+		return fmt.Errorf("invalid authentication method %d", method)
+	}
+
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+
+	if existing, ok := registry.providers[method]; ok {
+		return fmt.Errorf("authentication method %d is already registered by the %s provider", method, existing.Info().Name)
+	}
+
+	registry.providers[method] = provider
+
+	return nil
+}
+
+// Lookup returns the provider of an authentication method
+func (registry *Registry) Lookup(method portainer.AuthenticationMethod) (Provider, bool) {
+	registry.mu.RLock()
+	defer registry.mu.RUnlock()
+
+	provider, ok := registry.providers[method]
+
+	return provider, ok
+}
+
+// Providers returns the registered providers, sorted by authentication method
+func (registry *Registry) Providers() []Provider {
+	registry.mu.RLock()
+	defer registry.mu.RUnlock()
+
+	methods := make([]portainer.AuthenticationMethod, 0, len(registry.providers))
+	for method := range registry.providers {
+		methods = append(methods, method)
+	}
+
+	slices.Sort(methods)
+
+	providers := make([]Provider, 0, len(methods))
+	for _, method := range methods {
+		providers = append(providers, registry.providers[method])
+	}
+
+	return providers
+}
+
+// defaultRegistry is the registry used by Portainer
+var defaultRegistry = NewRegistry()
+
+// Register adds a provider to the default registry
+func Register(provider Provider) error {
+	return defaultRegistry.Register(provider)
+}
+
+// Lookup returns the provider of an authentication method from the default registry
+func Lookup(method portainer.AuthenticationMethod) (Provider, bool) {
+	return defaultRegistry.Lookup(method)
+}
+
+// Providers returns the providers of the default registry, sorted by authentication method
+func Providers() []Provider {
+	return defaultRegistry.Providers()
+}
+
+// IsValidMethod returns true when the authentication method is a built-in one or is registered
+func IsValidMethod(method portainer.AuthenticationMethod) bool {
+	if method >= portainer.AuthenticationInternal && method <= portainer.AuthenticationSAML {
+		return true
+	}
+
+	_, ok := Lookup(method)
+
+	return ok
+}
+
+// IsPasswordMethod returns true when the users of the authentication method log in with a password
+func IsPasswordMethod(method portainer.AuthenticationMethod) bool {
+	if method == portainer.AuthenticationInternal || method == portainer.AuthenticationLDAP {
+		return true
+	}
+
+	provider, ok := Lookup(method)
+
+	return ok && provider.Info().Capabilities.PasswordLogin
+}
@@ -0,0 +1,71 @@
+package authprovider
+
+import (
+	"testing"
+
+	portainer "github.com/portainer/portainer/api"
+
+	"github.com/stretchr/testify/require"
+)
+
+type fakeProvider struct {
+	info Info
+}
+
+func (provider *fakeProvider) Info() Info {
+	return provider.info
+}
+
+func (provider *fakeProvider) Authenticate(username, password string) error {
+	return ErrInvalidCredentials
+}
+
+func (provider *fakeProvider) GetUserGroups(username string) ([]string, error) {
+	return nil, ErrNotSupported
+}
+
+func (provider *fakeProvider) TestConnectivity() error {
+	return nil
+}
+
+func TestRegistry(t *testing.T) {
+	is := require.New(t)
+
+	registry := NewRegistry()
+
+	radius := &fakeProvider{info: Info{Method: 10, Name: "RADIUS", Capabilities: Capabilities{PasswordLogin: true}}}
+	internal := &fakeProvider{info: Info{Method: portainer.AuthenticationInternal, Name: "Internal"}}
+
+	is.NoError(registry.Register(radius))
+	is.NoError(registry.Register(internal))
+
+	is.Error(registry.Register(&fakeProvider{info: Info{Method: 10, Name: "Other"}}), "a method can only be registered once")
+	is.Error(registry.Register(&fakeProvider{info: Info{Method: 0, Name: "Invalid"}}))
+
+	provider, ok := registry.Lookup(10)
+	is.True(ok)
+	is.Equal(radius, provider)
+
+	_, ok = registry.Lookup(11)
+	is.False(ok)
+
+	is.Equal([]Provider{internal, radius}, registry.Providers())
+}
+
+func TestIsPasswordMethod(t *testing.T) {
+	is := require.New(t)
+
+	is.NoError(Register(&fakeProvider{info: Info{Method: 20, Name: "Password", Capabilities: Capabilities{PasswordLogin: true}}}))
+	is.NoError(Register(&fakeProvider{info: Info{Method: 21, Name: "Redirect", Capabilities: Capabilities{RedirectLogin: true}}}))
+
+	is.True(IsPasswordMethod(portainer.AuthenticationInternal))
+	is.True(IsPasswordMethod(portainer.AuthenticationLDAP))
+	is.False(IsPasswordMethod(portainer.AuthenticationOAuth))
+	is.True(IsPasswordMethod(20))
+	is.False(IsPasswordMethod(21))
+	is.False(IsPasswordMethod(22))
+
+	is.True(IsValidMethod(portainer.AuthenticationSAML))
+	is.True(IsValidMethod(21))
+	is.False(IsValidMethod(22))
+}
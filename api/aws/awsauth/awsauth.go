@@ -0,0 +1,58 @@
+// Package awsauth builds the AWS credentials used by the AWS clients of Portainer, from an access key or from the
+// default credentials of the host, optionally assuming a role
+package awsauth
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/credentials/stscreds"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+)
+
+// Credentials represents how an AWS client authenticates against AWS
+type Credentials struct {
+	AccessKey string
+	SecretKey string
+	// UseDefault uses the default credentials of the AWS SDK (environment variables, shared configuration,
+	// instance or task role) instead of the access key
+	UseDefault bool
+	// RoleARN is an IAM role assumed with the access key or the default credentials
+	RoleARN string
+	// ExternalID is the external ID required by the trust policy of the role
+	ExternalID string
+}
+
+// NewCredentialsProvider returns a cached provider of the credentials, the sessions of the assumed role being named
+// after sessionName
+func NewCredentialsProvider(creds Credentials, region, sessionName string) (aws.CredentialsProvider, error) {
+	var provider aws.CredentialsProvider = credentials.NewStaticCredentialsProvider(creds.AccessKey, creds.SecretKey, "")
+
+	if creds.UseDefault {
+		cfg, err := config.LoadDefaultConfig(context.TODO(), config.WithRegion(region))
+		if err != nil {
+			return nil, err
+		}
+
+		provider = cfg.Credentials
+	}
+
+	if creds.RoleARN != "" {
+		stsClient := sts.New(sts.Options{
+			Region:      region,
+			Credentials: aws.NewCredentialsCache(provider),
+		})
+
+		provider = stscreds.NewAssumeRoleProvider(stsClient, creds.RoleARN, func(options *stscreds.AssumeRoleOptions) {
+			options.RoleSessionName = sessionName
+
+			if creds.ExternalID != "" {
+				options.ExternalID = aws.String(creds.ExternalID)
+			}
+		})
+	}
+
+	return aws.NewCredentialsCache(provider), nil
+}
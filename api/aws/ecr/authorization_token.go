@@ -43,7 +43,8 @@ func (s *Service) GetAuthorizationToken() (token *string, expiry *time.Time, err
 	return
 }
 
-func (s *Service) ParseAuthorizationToken(token string) (username string, password string, err error) {
+// ParseAuthorizationToken splits a decoded authorization token into the username and the password of the registry
+func ParseAuthorizationToken(token string) (username string, password string, err error) {
 	if len(token) == 0 {
 		return
 	}
@@ -1,32 +1,31 @@
 package ecr
 
 import (
-	"github.com/aws/aws-sdk-go-v2/aws"
-	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/portainer/portainer/api/aws/awsauth"
+
 	"github.com/aws/aws-sdk-go-v2/service/ecr"
 )
 
-type (
-	Service struct {
-		accessKey string
-		secretKey string
-		region    string
-		client    *ecr.Client
+const roleSessionName = "portainer-ecr"
+
+type Service struct {
+	region string
+	client *ecr.Client
+}
+
+func NewService(creds awsauth.Credentials, region string) (*Service, error) {
+	provider, err := awsauth.NewCredentialsProvider(creds, region, roleSessionName)
+	if err != nil {
+		return nil, err
 	}
-)
 
-func NewService(accessKey, secretKey, region string) *Service {
 	options := ecr.Options{
 		Region:      region,
-		Credentials: aws.NewCredentialsCache(credentials.NewStaticCredentialsProvider(accessKey, secretKey, "")),
+		Credentials: provider,
 	}
 
-	client := ecr.New(options)
-
 	return &Service{
-		accessKey: accessKey,
-		secretKey: secretKey,
-		region:    region,
-		client:    client,
-	}
+		region: region,
+		client: ecr.New(options),
+	}, nil
 }
@@ -22,20 +22,12 @@ var filesToRestore = append(filesToBackup, "portainer.db")
 
 // Restores system state from backup archive, will trigger system shutdown, when finished.
 func RestoreArchive(archive io.Reader, password string, filestorePath string, gate *offlinegate.OfflineGate, datastore dataservices.DataStore, shutdownTrigger context.CancelFunc) error {
-	var err error
-	if password != "" {
-		archive, err = decrypt(archive, password)
-		if err != nil {
-			return errors.Wrap(err, "failed to decrypt the archive. Please ensure the password is correct and try again")
-		}
-	}
-
 	restorePath := filepath.Join(filestorePath, "restore", time.Now().Format("20060102150405"))
 	defer os.RemoveAll(filepath.Dir(restorePath))
 
-	err = extractArchive(archive, restorePath)
+	restorePath, err := extractBackup(archive, password, restorePath)
 	if err != nil {
-		return errors.Wrap(err, "cannot extract files from the archive. Please ensure the password is correct and try again")
+		return err
 	}
 
 	unlock := gate.Lock()
@@ -45,12 +37,6 @@ func RestoreArchive(archive io.Reader, password string, filestorePath string, ga
 		return errors.Wrap(err, "Failed to stop db")
 	}
 
-	// At some point, backups were created containing a subdirectory, now we need to handle both
-	restorePath, err = getRestoreSourcePath(restorePath)
-	if err != nil {
-		return errors.Wrap(err, "failed to restore from backup. Portainer database missing from backup file")
-	}
-
 	if err = restoreFiles(restorePath, filestorePath); err != nil {
 		return errors.Wrap(err, "failed to restore the system state")
 	}
@@ -59,6 +45,44 @@ func RestoreArchive(archive io.Reader, password string, filestorePath string, ga
 	return nil
 }
 
+// RestoreFiles restores the system state from a backup archive while the database is not opened, when Portainer
+// cannot start on its current data
+func RestoreFiles(archive io.Reader, password string, filestorePath string) error {
+	restorePath := filepath.Join(filestorePath, "restore", time.Now().Format("20060102150405"))
+	defer os.RemoveAll(filepath.Dir(restorePath))
+
+	restorePath, err := extractBackup(archive, password, restorePath)
+	if err != nil {
+		return err
+	}
+
+	return errors.Wrap(restoreFiles(restorePath, filestorePath), "failed to restore the system state")
+}
+
+// extractBackup extracts the archive to the restore path and returns the directory holding the backed up files
+func extractBackup(archive io.Reader, password string, restorePath string) (string, error) {
+	var err error
+	if password != "" {
+		archive, err = decrypt(archive, password)
+		if err != nil {
+			return "", errors.Wrap(err, "failed to decrypt the archive. Please ensure the password is correct and try again")
+		}
+	}
+
+	err = extractArchive(archive, restorePath)
+	if err != nil {
+		return "", errors.Wrap(err, "cannot extract files from the archive. Please ensure the password is correct and try again")
+	}
+
+	// At some point, backups were created containing a subdirectory, now we need to handle both
+	restorePath, err = getRestoreSourcePath(restorePath)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to restore from backup. Portainer database missing from backup file")
+	}
+
+	return restorePath, nil
+}
+
 func decrypt(r io.Reader, password string) (io.Reader, error) {
 	return crypto.AesDecrypt(r, []byte(password))
 }
@@ -0,0 +1,234 @@
+// Package chaos simulates the failures of the environments(endpoints) at the proxy layer: latency, timeouts and agent
+// disconnects, so that the operators can validate their alerting and the degraded mode of Portainer before real
+// incidents. The faults are kept in memory and expire, a restart of Portainer clears them. It is only served when the
+// chaos feature flag is enabled
+package chaos
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/pkg/featureflags"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+)
+
+const (
+	// Feature is the feature flag enabling the fault injection, e.g. --feat chaos
+	Feature featureflags.Feature = "chaos"
+
+	// MaxDuration is the maximum duration of a fault
+	MaxDuration = 24 * time.Hour
+	// MaxLatency is the maximum latency added to the requests
+	MaxLatency = 5 * time.Minute
+	// DefaultTimeout is the time the requests hang for before they time out, when the fault does not set it
+	DefaultTimeout = 30 * time.Second
+)
+
+const (
+	// FaultLatency delays the requests
+	FaultLatency = "latency"
+	// FaultTimeout makes the requests hang, then fail with a 504 Gateway Timeout
+	FaultTimeout = "timeout"
+	// FaultDisconnect makes the requests fail at once with a 502 Bad Gateway, as when the agent is unreachable. The
+	// snapshots of the environment(endpoint) fail too, which marks it as down
+	FaultDisconnect = "disconnect"
+)
+
+var (
+	// ErrSimulatedDisconnect is the error of the requests failed by a disconnect fault
+	ErrSimulatedDisconnect = errors.New("simulated agent disconnect")
+	// ErrSimulatedTimeout is the error of the requests failed by a timeout fault
+	ErrSimulatedTimeout = errors.New("simulated timeout")
+)
+
+// Fault represents a failure simulated on the requests to an environment(endpoint)
+type Fault struct {
+	EndpointID portainer.EndpointID `json:"EndpointId" example:"1"`
+	// Type of the fault: latency, timeout or disconnect
+	Type string `json:"Type" example:"latency"`
+	// Latency added to the requests, for the latency faults
+	Latency time.Duration `json:"Latency" swaggertype:"integer" example:"2000000000"`
+	// Time the requests hang for before they time out, for the timeout faults
+	Timeout time.Duration `json:"Timeout" swaggertype:"integer" example:"30000000000"`
+	// Fraction of the requests affected, between 0 and 1
+	Probability float64 `json:"Probability" example:"0.5"`
+	// Unix timestamp of the expiry of the fault
+	ExpiresAt int64 `json:"ExpiresAt" example:"1700003600"`
+	// Username of the administrator who injected the fault
+	CreatedBy string `json:"CreatedBy" example:"admin"`
+}
+
+// Validate returns an error when the fault cannot be simulated
+func (fault *Fault) Validate() error {
+	switch fault.Type {
+	case FaultLatency:
+		if fault.Latency <= 0 || fault.Latency > MaxLatency {
+			return fmt.Errorf("the latency must be positive and of %s at most", MaxLatency)
+		}
+	case FaultTimeout:
+		if fault.Timeout < 0 || fault.Timeout > MaxLatency {
+			return fmt.Errorf("the timeout must be positive and of %s at most", MaxLatency)
+		}
+	case FaultDisconnect:
+	default:
+		return errors.New("the type of the fault must be latency, timeout or disconnect")
+	}
+
+	if fault.Probability < 0 || fault.Probability > 1 {
+		return errors.New("the probability must be between 0 and 1")
+	}
+
+	return nil
+}
+
+// Registry holds the faults injected into the environments(endpoints)
+type Registry struct {
+	mu     sync.Mutex
+	faults map[portainer.EndpointID]Fault
+	rand   func() float64
+	sleep  func(ctx context.Context, d time.Duration) error
+	now    func() time.Time
+}
+
+// NewRegistry creates an empty registry
+func NewRegistry() *Registry {
+	return &Registry{
+		faults: map[portainer.EndpointID]Fault{},
+		rand:   rand.Float64,
+		sleep:  sleep,
+		now:    time.Now,
+	}
+}
+
+// DefaultRegistry is the registry the proxies and the snapshots check
+var DefaultRegistry = NewRegistry()
+
+// Set injects a fault into an environment(endpoint) for the given duration, it replaces its previous fault
+func (registry *Registry) Set(fault Fault, duration time.Duration) (Fault, error) {
+	if err := fault.Validate(); err != nil {
+		return Fault{}, err
+	}
+
+	if duration <= 0 || duration > MaxDuration {
+		return Fault{}, fmt.Errorf("the duration of the fault must be positive and of %s at most", MaxDuration)
+	}
+
+	if fault.Probability == 0 {
+		fault.Probability = 1
+	}
+
+	if fault.Type == FaultTimeout && fault.Timeout == 0 {
+		fault.Timeout = DefaultTimeout
+	}
+
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+
+	fault.ExpiresAt = registry.now().Add(duration).Unix()
+	registry.faults[fault.EndpointID] = fault
+
+	return fault, nil
+}
+
+// Delete removes the fault of an environment(endpoint)
+func (registry *Registry) Delete(endpointID portainer.EndpointID) {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+
+	delete(registry.faults, endpointID)
+}
+
+// Get returns the active fault of an environment(endpoint)
+func (registry *Registry) Get(endpointID portainer.EndpointID) (Fault, bool) {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+
+	fault, ok := registry.faults[endpointID]
+	if ok && fault.ExpiresAt <= registry.now().Unix() {
+		delete(registry.faults, endpointID)
+
+		return Fault{}, false
+	}
+
+	return fault, ok
+}
+
+// List returns the active faults, by environment(endpoint)
+func (registry *Registry) List() []Fault {
+	registry.mu.Lock()
+	ids := make([]portainer.EndpointID, 0, len(registry.faults))
+	for id := range registry.faults {
+		ids = append(ids, id)
+	}
+	registry.mu.Unlock()
+
+	slices.Sort(ids)
+
+	faults := make([]Fault, 0, len(ids))
+	for _, id := range ids {
+		if fault, ok := registry.Get(id); ok {
+			faults = append(faults, fault)
+		}
+	}
+
+	return faults
+}
+
+// Apply simulates the active fault of an environment(endpoint) on a request: it waits for the latency, or it hangs
+// then returns ErrSimulatedTimeout, or it returns ErrSimulatedDisconnect. It returns nil when the request is not
+// affected
+func (registry *Registry) Apply(ctx context.Context, endpointID portainer.EndpointID) error {
+	fault, ok := registry.Get(endpointID)
+	if !ok || registry.rand() >= fault.Probability {
+		return nil
+	}
+
+	switch fault.Type {
+	case FaultLatency:
+		return registry.sleep(ctx, fault.Latency)
+	case FaultTimeout:
+		if err := registry.sleep(ctx, fault.Timeout); err != nil {
+			return err
+		}
+
+		return ErrSimulatedTimeout
+	case FaultDisconnect:
+		return ErrSimulatedDisconnect
+	}
+
+	return nil
+}
+
+// Middleware simulates the fault of an environment(endpoint) on the requests proxied to it by next
+func (registry *Registry) Middleware(endpointID portainer.EndpointID, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch err := registry.Apply(r.Context(), endpointID); {
+		case err == nil:
+			next.ServeHTTP(w, r)
+		case errors.Is(err, ErrSimulatedTimeout):
+			httperror.WriteError(w, http.StatusGatewayTimeout, "Timeout while waiting for the environment", err)
+		case errors.Is(err, ErrSimulatedDisconnect):
+			httperror.WriteError(w, http.StatusBadGateway, "Unable to reach the environment", err)
+		}
+		// otherwise the client went away during the simulated latency
+	})
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
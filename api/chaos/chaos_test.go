@@ -0,0 +1,117 @@
+package chaos
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newTestRegistry(now *time.Time, slept *time.Duration) *Registry {
+	registry := NewRegistry()
+	registry.rand = func() float64 { return 0.5 }
+	registry.now = func() time.Time { return *now }
+	registry.sleep = func(ctx context.Context, d time.Duration) error {
+		*slept += d
+
+		return nil
+	}
+
+	return registry
+}
+
+func TestRegistry(t *testing.T) {
+	is := require.New(t)
+
+	now := time.Unix(1_000_000, 0)
+	var slept time.Duration
+	registry := newTestRegistry(&now, &slept)
+
+	_, err := registry.Set(Fault{EndpointID: 1, Type: "unknown"}, time.Hour)
+	is.Error(err)
+	_, err = registry.Set(Fault{EndpointID: 1, Type: FaultDisconnect}, 2*MaxDuration)
+	is.Error(err)
+	_, err = registry.Set(Fault{EndpointID: 1, Type: FaultLatency}, time.Hour)
+	is.Error(err)
+
+	fault, err := registry.Set(Fault{EndpointID: 2, Type: FaultTimeout}, time.Hour)
+	is.NoError(err)
+	is.Equal(DefaultTimeout, fault.Timeout)
+	is.InDelta(1, fault.Probability, 0)
+
+	_, err = registry.Set(Fault{EndpointID: 1, Type: FaultLatency, Latency: time.Second}, 2*time.Hour)
+	is.NoError(err)
+
+	faults := registry.List()
+	is.Len(faults, 2)
+	is.EqualValues(1, faults[0].EndpointID)
+
+	is.NoError(registry.Apply(context.Background(), 1))
+	is.Equal(time.Second, slept)
+
+	is.ErrorIs(registry.Apply(context.Background(), 2), ErrSimulatedTimeout)
+	is.NoError(registry.Apply(context.Background(), 3))
+
+	// the faults expire
+	now = now.Add(90 * time.Minute)
+	is.NoError(registry.Apply(context.Background(), 2))
+	is.Len(registry.List(), 1)
+
+	registry.Delete(1)
+	is.Empty(registry.List())
+}
+
+func TestRegistryProbability(t *testing.T) {
+	is := require.New(t)
+
+	now := time.Now()
+	var slept time.Duration
+	registry := newTestRegistry(&now, &slept)
+
+	_, err := registry.Set(Fault{EndpointID: 1, Type: FaultDisconnect, Probability: 0.4}, time.Hour)
+	is.NoError(err)
+	is.NoError(registry.Apply(context.Background(), 1))
+
+	_, err = registry.Set(Fault{EndpointID: 1, Type: FaultDisconnect, Probability: 0.6}, time.Hour)
+	is.NoError(err)
+	is.ErrorIs(registry.Apply(context.Background(), 1), ErrSimulatedDisconnect)
+}
+
+func TestMiddleware(t *testing.T) {
+	is := require.New(t)
+
+	now := time.Now()
+	var slept time.Duration
+	registry := newTestRegistry(&now, &slept)
+
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	})
+	handler := registry.Middleware(1, next)
+
+	serve := func() int {
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/containers/json", nil))
+
+		return rr.Code
+	}
+
+	is.Equal(http.StatusTeapot, serve())
+
+	_, err := registry.Set(Fault{EndpointID: 1, Type: FaultDisconnect}, time.Hour)
+	is.NoError(err)
+	is.Equal(http.StatusBadGateway, serve())
+
+	_, err = registry.Set(Fault{EndpointID: 1, Type: FaultTimeout, Timeout: time.Minute}, time.Hour)
+	is.NoError(err)
+	is.Equal(http.StatusGatewayTimeout, serve())
+	is.Equal(time.Minute, slept)
+
+	_, err = registry.Set(Fault{EndpointID: 1, Type: FaultLatency, Latency: time.Second}, time.Hour)
+	is.NoError(err)
+	is.Equal(http.StatusTeapot, serve())
+	is.Equal(time.Minute+time.Second, slept)
+}
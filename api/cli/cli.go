@@ -21,6 +21,7 @@ var (
 	ErrSocketOrNamedPipeNotFound     = errors.New("Unable to locate Unix socket or named pipe")
 	ErrInvalidSnapshotInterval       = errors.New("Invalid snapshot interval")
 	ErrAdminPassExcludeAdminPassFile = errors.New("Cannot use --admin-password with --admin-password-file")
+	ErrInvalidProxyTransport         = errors.New("The proxy connection pooling flags cannot be negative")
 )
 
 func CLIFlags() *portainer.CLIFlags {
@@ -50,6 +51,7 @@ func CLIFlags() *portainer.CLIFlags {
 		AdminPassword:             kingpin.Flag("admin-password", "Set admin password with provided hash").String(),
 		AdminPasswordFile:         kingpin.Flag("admin-password-file", "Path to the file containing the password for the admin user").String(),
 		Labels:                    pairs(kingpin.Flag("hide-label", "Hide containers with a specific label in the UI").Short('l')),
+		Plugins:                   pairs(kingpin.Flag("plugin", "Serve the routes of a plugin running as a sidecar service under /api/plugins/<name>, in the form name=url")),
 		Logo:                      kingpin.Flag("logo", "URL for the logo displayed in the UI").String(),
 		Templates:                 kingpin.Flag("templates", "URL to the templates definitions.").Short('t').String(),
 		BaseURL:                   kingpin.Flag("base-url", "Base URL parameter such as portainer if running portainer as http://yourdomain.com/portainer/.").Short('b').Default(defaultBaseURL).String(),
@@ -60,6 +62,10 @@ func CLIFlags() *portainer.CLIFlags {
 		LogLevel:                  kingpin.Flag("log-level", "Set the minimum logging level to show").Default("INFO").Enum("DEBUG", "INFO", "WARN", "ERROR"),
 		LogMode:                   kingpin.Flag("log-mode", "Set the logging output mode").Default("PRETTY").Enum("NOCOLOR", "PRETTY", "JSON"),
 		KubectlShellImage:         kingpin.Flag("kubectl-shell-image", "Kubectl shell image").Envar(portainer.KubectlShellImageEnvVar).Default(portainer.DefaultKubectlShellImage).String(),
+		ProxyMaxIdleConns:         kingpin.Flag("proxy-max-idle-conns", "Maximum number of idle upstream connections kept per environment by the proxy").Default("100").Int(),
+		ProxyMaxIdleConnsPerHost:  kingpin.Flag("proxy-max-idle-conns-per-host", "Maximum number of idle upstream connections kept per host by the proxy").Default("20").Int(),
+		ProxyIdleConnTimeout:      kingpin.Flag("proxy-idle-conn-timeout", "Duration an idle upstream connection of the proxy is kept open").Default("90s").Duration(),
+		ProxyTLSSessionCacheSize:  kingpin.Flag("proxy-tls-session-cache-size", "Number of TLS sessions cached per environment by the proxy, 0 disables the cache").Default("64").Int(),
 	}
 }
 
@@ -99,6 +105,10 @@ func (*Service) ValidateFlags(flags *portainer.CLIFlags) error {
 		return ErrAdminPassExcludeAdminPassFile
 	}
 
+	if *flags.ProxyMaxIdleConns < 0 || *flags.ProxyMaxIdleConnsPerHost < 0 || *flags.ProxyIdleConnTimeout < 0 || *flags.ProxyTLSSessionCacheSize < 0 {
+		return ErrInvalidProxyTransport
+	}
+
 	return nil
 }
 
@@ -0,0 +1,71 @@
+package cloud
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+)
+
+// Civo creates the clusters with Civo Kubernetes
+type Civo struct {
+	client
+}
+
+// NewCivo returns the Civo provider
+func NewCivo() *Civo {
+	return &Civo{client: newClient("https://api.civo.com/v2")}
+}
+
+type civoCluster struct {
+	ID         string `json:"id"`
+	Status     string `json:"status"`
+	Ready      bool   `json:"ready"`
+	Kubeconfig string `json:"kubeconfig"`
+}
+
+// CreateCluster starts the creation of a cluster and returns its identifier
+func (c *Civo) CreateCluster(ctx context.Context, apiKey string, request ClusterRequest) (string, error) {
+	type pool struct {
+		Size  string `json:"size"`
+		Count int    `json:"count"`
+	}
+
+	body := struct {
+		Name              string `json:"name"`
+		Region            string `json:"region"`
+		Pools             []pool `json:"pools"`
+		KubernetesVersion string `json:"kubernetes_version,omitempty"`
+	}{
+		Name:              request.Name,
+		Region:            request.Region,
+		Pools:             []pool{{Size: request.NodeSize, Count: request.NodeCount}},
+		KubernetesVersion: request.KubernetesVersion,
+	}
+
+	var cluster civoCluster
+	if _, err := c.do(ctx, apiKey, http.MethodPost, "/kubernetes/clusters", body, &cluster); err != nil {
+		return "", err
+	}
+
+	return cluster.ID, nil
+}
+
+// Cluster returns the state of a cluster, with its kubeconfig once it is ready
+func (c *Civo) Cluster(ctx context.Context, apiKey, region, clusterID string) (*Cluster, error) {
+	var cluster civoCluster
+	if _, err := c.do(ctx, apiKey, http.MethodGet, "/kubernetes/clusters/"+url.PathEscape(clusterID)+"?region="+url.QueryEscape(region), nil, &cluster); err != nil {
+		return nil, err
+	}
+
+	result := &Cluster{
+		Ready:  cluster.Ready && cluster.Status == "ACTIVE" && cluster.Kubeconfig != "",
+		Failed: cluster.Status == "ERROR",
+		Status: cluster.Status,
+	}
+
+	if result.Ready {
+		result.Kubeconfig = []byte(cluster.Kubeconfig)
+	}
+
+	return result, nil
+}
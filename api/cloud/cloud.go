@@ -0,0 +1,167 @@
+// Package cloud provisions managed Kubernetes clusters through the APIs of Kubernetes-as-a-Service providers and
+// registers them as environments(endpoints) once they are ready
+package cloud
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/segmentio/encoding/json"
+)
+
+const (
+	// ProviderCivo provisions the clusters with Civo Kubernetes
+	ProviderCivo = "civo"
+	// ProviderLinode provisions the clusters with the Linode Kubernetes Engine
+	ProviderLinode = "linode"
+	// ProviderDigitalOcean provisions the clusters with the DigitalOcean Kubernetes service
+	ProviderDigitalOcean = "digitalocean"
+
+	// StateProvisioning is the state of a cluster being created by its provider
+	StateProvisioning = "provisioning"
+	// StateDeploying is the state of a cluster the agent is being deployed in
+	StateDeploying = "deploying"
+	// StateReady is the state of a cluster registered as an environment(endpoint)
+	StateReady = "ready"
+	// StateFailed is the state of a cluster whose provisioning failed
+	StateFailed = "failed"
+
+	requestTimeout = 30 * time.Second
+)
+
+// ErrUnknownProvider is returned for the providers other than civo, linode and digitalocean
+var ErrUnknownProvider = errors.New("unknown cloud provider, must be civo, linode or digitalocean")
+
+// ClusterRequest represents the cluster to create
+type ClusterRequest struct {
+	Name   string
+	Region string
+	// Size of the nodes, as named by the provider
+	NodeSize  string
+	NodeCount int
+	// Version of Kubernetes, the default version of the provider when empty
+	KubernetesVersion string
+}
+
+// Cluster represents the state of a cluster
+type Cluster struct {
+	Ready  bool
+	Failed bool
+	// Status of the cluster, as named by the provider
+	Status string
+	// Kubeconfig of the cluster, once it is ready
+	Kubeconfig []byte
+}
+
+// Provider creates the clusters of a Kubernetes-as-a-Service provider
+type Provider interface {
+	// CreateCluster starts the creation of a cluster and returns its identifier
+	CreateCluster(ctx context.Context, apiKey string, request ClusterRequest) (string, error)
+	// Cluster returns the state of a cluster, with its kubeconfig once it is ready
+	Cluster(ctx context.Context, apiKey, region, clusterID string) (*Cluster, error)
+}
+
+// Providers returns the supported providers, by name
+func Providers() map[string]Provider {
+	return map[string]Provider{
+		ProviderCivo:         NewCivo(),
+		ProviderLinode:       NewLinode(),
+		ProviderDigitalOcean: NewDigitalOcean(),
+	}
+}
+
+// IsProvider returns whether the provider is supported
+func IsProvider(name string) bool {
+	return slices.Contains([]string{ProviderCivo, ProviderLinode, ProviderDigitalOcean}, name)
+}
+
+// ValidateClusterRequest returns an error when the cluster to create is incomplete
+func ValidateClusterRequest(request ClusterRequest) error {
+	switch {
+	case request.Name == "":
+		return errors.New("the cluster has no name")
+	case request.Region == "":
+		return errors.New("the cluster has no region")
+	case request.NodeSize == "":
+		return errors.New("the cluster has no node size")
+	case request.NodeCount < 1:
+		return errors.New("the cluster must have at least one node")
+	}
+
+	return nil
+}
+
+// APIError is returned when the API of a provider answers with an error status
+type APIError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("the provider API answered with status %d: %s", e.StatusCode, e.Message)
+}
+
+// client calls the JSON API of a provider with a bearer token
+type client struct {
+	httpClient *http.Client
+	baseURL    string
+}
+
+func newClient(baseURL string) client {
+	return client{
+		httpClient: &http.Client{Timeout: requestTimeout},
+		baseURL:    baseURL,
+	}
+}
+
+// do sends the request and decodes the response into out unless it is nil, it returns the raw response body
+func (c client) do(ctx context.Context, apiKey, method, path string, body, out any) ([]byte, error) {
+	var reader io.Reader
+	if body != nil {
+		content, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+
+		reader = bytes.NewReader(content)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, reader)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Authorization", "Bearer "+apiKey)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	content, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode >= 300 {
+		return nil, &APIError{StatusCode: resp.StatusCode, Message: string(bytes.TrimSpace(content))}
+	}
+
+	if out != nil {
+		if err := json.Unmarshal(content, out); err != nil {
+			return nil, fmt.Errorf("unable to parse the response of the provider API: %w", err)
+		}
+	}
+
+	return content, nil
+}
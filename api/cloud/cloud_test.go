@@ -0,0 +1,233 @@
+package cloud
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+	"github.com/portainer/portainer/api/datastore"
+
+	"github.com/segmentio/encoding/json"
+	"github.com/stretchr/testify/require"
+)
+
+func testClient(srv *httptest.Server) client {
+	return client{httpClient: srv.Client(), baseURL: srv.URL}
+}
+
+func TestCivo(t *testing.T) {
+	is := require.New(t)
+
+	ready := false
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /kubernetes/clusters", func(w http.ResponseWriter, r *http.Request) {
+		is.Equal("Bearer key", r.Header.Get("Authorization"))
+
+		var body map[string]any
+		is.NoError(json.NewDecoder(r.Body).Decode(&body))
+		is.Equal("production", body["name"])
+
+		w.Write([]byte(`{"id":"c1","status":"BUILDING"}`))
+	})
+	mux.HandleFunc("GET /kubernetes/clusters/c1", func(w http.ResponseWriter, r *http.Request) {
+		is.Equal("LON1", r.URL.Query().Get("region"))
+
+		if ready {
+			w.Write([]byte(`{"id":"c1","status":"ACTIVE","ready":true,"kubeconfig":"apiVersion: v1"}`))
+
+			return
+		}
+
+		w.Write([]byte(`{"id":"c1","status":"BUILDING"}`))
+	})
+
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	civo := &Civo{client: testClient(srv)}
+
+	id, err := civo.CreateCluster(context.Background(), "key", ClusterRequest{Name: "production", Region: "LON1", NodeSize: "g4s.kube.medium", NodeCount: 3})
+	is.NoError(err)
+	is.Equal("c1", id)
+
+	cluster, err := civo.Cluster(context.Background(), "key", "LON1", id)
+	is.NoError(err)
+	is.False(cluster.Ready)
+
+	ready = true
+
+	cluster, err = civo.Cluster(context.Background(), "key", "LON1", id)
+	is.NoError(err)
+	is.True(cluster.Ready)
+	is.Equal("apiVersion: v1", string(cluster.Kubeconfig))
+}
+
+func TestLinode(t *testing.T) {
+	is := require.New(t)
+
+	nodeStatus := "not_ready"
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /lke/versions", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"data":[{"id":"1.31"},{"id":"1.30"}]}`))
+	})
+	mux.HandleFunc("POST /lke/clusters", func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		is.NoError(json.NewDecoder(r.Body).Decode(&body))
+		is.Equal("1.31", body["k8s_version"])
+
+		w.Write([]byte(`{"id":42}`))
+	})
+	mux.HandleFunc("GET /lke/clusters/42/pools", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"data":[{"nodes":[{"status":"ready"},{"status":"` + nodeStatus + `"}]}]}`))
+	})
+	mux.HandleFunc("GET /lke/clusters/42/kubeconfig", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"kubeconfig":"` + base64.StdEncoding.EncodeToString([]byte("apiVersion: v1")) + `"}`))
+	})
+
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	linode := &Linode{client: testClient(srv)}
+
+	id, err := linode.CreateCluster(context.Background(), "key", ClusterRequest{Name: "production", Region: "eu-west", NodeSize: "g6-standard-2", NodeCount: 2})
+	is.NoError(err)
+	is.Equal("42", id)
+
+	cluster, err := linode.Cluster(context.Background(), "key", "eu-west", id)
+	is.NoError(err)
+	is.False(cluster.Ready)
+	is.Equal("1/2 nodes ready", cluster.Status)
+
+	nodeStatus = "ready"
+
+	cluster, err = linode.Cluster(context.Background(), "key", "eu-west", id)
+	is.NoError(err)
+	is.True(cluster.Ready)
+	is.Equal("apiVersion: v1", string(cluster.Kubeconfig))
+}
+
+func TestDigitalOcean(t *testing.T) {
+	is := require.New(t)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /kubernetes/clusters", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		w.Write([]byte(`{"id":"unprocessable_entity","message":"invalid size"}`))
+	})
+	mux.HandleFunc("GET /kubernetes/clusters/d1", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"kubernetes_cluster":{"id":"d1","status":{"state":"errored","message":"quota exceeded"}}}`))
+	})
+
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	digitalOcean := &DigitalOcean{client: testClient(srv)}
+
+	_, err := digitalOcean.CreateCluster(context.Background(), "key", ClusterRequest{Name: "production", Region: "ams3", NodeSize: "huge", NodeCount: 1})
+
+	var apiErr *APIError
+	is.True(errors.As(err, &apiErr))
+	is.Equal(http.StatusUnprocessableEntity, apiErr.StatusCode)
+
+	cluster, err := digitalOcean.Cluster(context.Background(), "key", "ams3", "d1")
+	is.NoError(err)
+	is.True(cluster.Failed)
+	is.Equal("quota exceeded", cluster.Status)
+}
+
+type fakeProvider struct {
+	ready bool
+}
+
+func (p *fakeProvider) CreateCluster(ctx context.Context, apiKey string, request ClusterRequest) (string, error) {
+	if apiKey != "secret" {
+		return "", &APIError{StatusCode: http.StatusUnauthorized, Message: "invalid API key"}
+	}
+
+	return "cluster-1", nil
+}
+
+func (p *fakeProvider) Cluster(ctx context.Context, apiKey, region, clusterID string) (*Cluster, error) {
+	return &Cluster{Ready: p.ready, Kubeconfig: []byte("apiVersion: v1")}, nil
+}
+
+type fakeDeployer struct {
+	agentSecret string
+}
+
+func (d *fakeDeployer) DeployAgent(ctx context.Context, kubeconfig []byte, agentSecret string) (string, error) {
+	d.agentSecret = agentSecret
+
+	return "10.0.0.1:9001", nil
+}
+
+func TestProvisioner(t *testing.T) {
+	is := require.New(t)
+
+	_, store := datastore.MustNewTestStore(t, true, false)
+
+	credential := &portainer.CloudCredential{Name: "production", Provider: ProviderCivo}
+	is.NoError(store.UpdateTx(func(tx dataservices.DataStoreTx) error {
+		var err error
+		if credential.EncryptedAPIKey, err = EncryptAPIKey(tx, "secret"); err != nil {
+			return err
+		}
+
+		return tx.CloudCredential().Create(credential)
+	}))
+	is.NotContains(string(credential.EncryptedAPIKey), "secret")
+
+	provider := &fakeProvider{}
+
+	deployer := &fakeDeployer{}
+
+	provisioner := NewProvisioner(context.Background(), store, deployer)
+	provisioner.providers = map[string]Provider{ProviderCivo: provider}
+
+	_, err := provisioner.CreateCluster(credential.ID, ClusterRequest{Name: "production", Region: "LON1", NodeSize: "g4s.kube.medium"})
+	is.Error(err)
+
+	provisioning, err := provisioner.CreateCluster(credential.ID, ClusterRequest{Name: "production", Region: "LON1", NodeSize: "g4s.kube.medium", NodeCount: 3})
+	is.NoError(err)
+	is.Equal("cluster-1", provisioning.ClusterID)
+	is.Equal(StateProvisioning, provisioning.State)
+
+	endpoint := &portainer.Endpoint{ID: 1, Name: "production", Type: portainer.AgentOnKubernetesEnvironment, Status: portainer.EndpointStatusDown, CloudProvisioning: provisioning}
+	is.NoError(store.Endpoint().Create(endpoint))
+
+	done, err := provisioner.step(endpoint.ID)
+	is.NoError(err)
+	is.False(done)
+
+	provider.ready = true
+
+	done, err = provisioner.step(endpoint.ID)
+	is.NoError(err)
+	is.True(done)
+
+	endpoint, err = store.Endpoint().Endpoint(endpoint.ID)
+	is.NoError(err)
+	is.Equal("10.0.0.1:9001", endpoint.URL)
+	is.Equal(portainer.EndpointStatusUp, endpoint.Status)
+	is.Equal(StateReady, endpoint.CloudProvisioning.State)
+
+	// the agent is deployed with the secret of the environment
+	is.NotEmpty(endpoint.AgentSecret)
+	is.Equal(endpoint.AgentSecret, deployer.agentSecret)
+
+	// the clusters not ready in time fail
+	provider.ready = false
+	provisioning.StartedAt = time.Now().Add(-2 * ProvisioningTimeout).Unix()
+	is.NoError(store.Endpoint().Create(&portainer.Endpoint{ID: 2, Name: "staging", CloudProvisioning: provisioning}))
+
+	_, err = provisioner.step(2)
+	is.Error(err)
+}
@@ -0,0 +1,56 @@
+package cloud
+
+import (
+	"errors"
+	"fmt"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/apikey"
+	"github.com/portainer/portainer/api/dataservices"
+	"github.com/portainer/portainer/pkg/libcrypto"
+)
+
+const credentialKeyLen = 32
+
+// EncryptAPIKey encrypts the API key of a credential with the cloud credential key of the settings, the key is
+// generated on its first use
+func EncryptAPIKey(tx dataservices.DataStoreTx, apiKey string) ([]byte, error) {
+	settings, err := tx.Settings().Settings()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(settings.CloudCredentialKey) == 0 {
+		key := apikey.GenerateRandomKey(credentialKeyLen)
+		if key == nil {
+			return nil, errors.New("unable to generate the cloud credential encryption key")
+		}
+
+		settings.CloudCredentialKey = key
+
+		if err := tx.Settings().UpdateSettings(settings); err != nil {
+			return nil, err
+		}
+	}
+
+	return libcrypto.Encrypt([]byte(apiKey), settings.CloudCredentialKey)
+}
+
+// DecryptAPIKey returns the API key of a credential encrypted with EncryptAPIKey
+func DecryptAPIKey(tx dataservices.DataStoreTx, credential *portainer.CloudCredential) (string, error) {
+	settings, err := tx.Settings().Settings()
+	if err != nil {
+		return "", err
+	}
+
+	if len(settings.CloudCredentialKey) == 0 {
+		return "", errors.New("missing cloud credential encryption key")
+	}
+
+	apiKey, err := libcrypto.Decrypt(credential.EncryptedAPIKey, settings.CloudCredentialKey)
+	if err != nil {
+		return "", fmt.Errorf("unable to decrypt the API key of the cloud credential: %w", err)
+	}
+
+	return string(apiKey), nil
+}
@@ -0,0 +1,87 @@
+package cloud
+
+import (
+	"cmp"
+	"context"
+	"net/http"
+	"net/url"
+)
+
+// DigitalOcean creates the clusters with the DigitalOcean Kubernetes service
+type DigitalOcean struct {
+	client
+}
+
+// NewDigitalOcean returns the DigitalOcean provider
+func NewDigitalOcean() *DigitalOcean {
+	return &DigitalOcean{client: newClient("https://api.digitalocean.com/v2")}
+}
+
+type digitalOceanCluster struct {
+	KubernetesCluster struct {
+		ID     string `json:"id"`
+		Status struct {
+			State   string `json:"state"`
+			Message string `json:"message"`
+		} `json:"status"`
+	} `json:"kubernetes_cluster"`
+}
+
+// CreateCluster starts the creation of a cluster and returns its identifier
+func (d *DigitalOcean) CreateCluster(ctx context.Context, apiKey string, request ClusterRequest) (string, error) {
+	type nodePool struct {
+		Name  string `json:"name"`
+		Size  string `json:"size"`
+		Count int    `json:"count"`
+	}
+
+	body := struct {
+		Name      string     `json:"name"`
+		Region    string     `json:"region"`
+		Version   string     `json:"version"`
+		NodePools []nodePool `json:"node_pools"`
+	}{
+		Name:      request.Name,
+		Region:    request.Region,
+		Version:   cmp.Or(request.KubernetesVersion, "latest"),
+		NodePools: []nodePool{{Name: request.Name + "-default", Size: request.NodeSize, Count: request.NodeCount}},
+	}
+
+	var cluster digitalOceanCluster
+	if _, err := d.do(ctx, apiKey, http.MethodPost, "/kubernetes/clusters", body, &cluster); err != nil {
+		return "", err
+	}
+
+	return cluster.KubernetesCluster.ID, nil
+}
+
+// Cluster returns the state of a cluster, with its kubeconfig once it is ready
+func (d *DigitalOcean) Cluster(ctx context.Context, apiKey, region, clusterID string) (*Cluster, error) {
+	path := "/kubernetes/clusters/" + url.PathEscape(clusterID)
+
+	var cluster digitalOceanCluster
+	if _, err := d.do(ctx, apiKey, http.MethodGet, path, nil, &cluster); err != nil {
+		return nil, err
+	}
+
+	state := cluster.KubernetesCluster.Status.State
+
+	result := &Cluster{
+		Failed: state == "errored",
+		Status: cmp.Or(cluster.KubernetesCluster.Status.Message, state),
+	}
+
+	if state != "running" {
+		return result, nil
+	}
+
+	kubeconfig, err := d.do(ctx, apiKey, http.MethodGet, path+"/kubeconfig", nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	result.Ready = true
+	result.Kubeconfig = kubeconfig
+
+	return result, nil
+}
@@ -0,0 +1,126 @@
+package cloud
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// Linode creates the clusters with the Linode Kubernetes Engine
+type Linode struct {
+	client
+}
+
+// NewLinode returns the Linode provider
+func NewLinode() *Linode {
+	return &Linode{client: newClient("https://api.linode.com/v4")}
+}
+
+// CreateCluster starts the creation of a cluster and returns its identifier
+func (l *Linode) CreateCluster(ctx context.Context, apiKey string, request ClusterRequest) (string, error) {
+	version := request.KubernetesVersion
+	if version == "" {
+		// the version is required by the API, the versions are listed from the most recent one
+		var versions struct {
+			Data []struct {
+				ID string `json:"id"`
+			} `json:"data"`
+		}
+
+		if _, err := l.do(ctx, apiKey, http.MethodGet, "/lke/versions", nil, &versions); err != nil {
+			return "", err
+		}
+
+		if len(versions.Data) == 0 {
+			return "", errors.New("the provider has no Kubernetes version available")
+		}
+
+		version = versions.Data[0].ID
+	}
+
+	type nodePool struct {
+		Type  string `json:"type"`
+		Count int    `json:"count"`
+	}
+
+	body := struct {
+		Label      string     `json:"label"`
+		Region     string     `json:"region"`
+		K8sVersion string     `json:"k8s_version"`
+		NodePools  []nodePool `json:"node_pools"`
+	}{
+		Label:      request.Name,
+		Region:     request.Region,
+		K8sVersion: version,
+		NodePools:  []nodePool{{Type: request.NodeSize, Count: request.NodeCount}},
+	}
+
+	var cluster struct {
+		ID int `json:"id"`
+	}
+
+	if _, err := l.do(ctx, apiKey, http.MethodPost, "/lke/clusters", body, &cluster); err != nil {
+		return "", err
+	}
+
+	return strconv.Itoa(cluster.ID), nil
+}
+
+// Cluster returns the state of a cluster, with its kubeconfig once it is ready. The cluster is ready once all its
+// nodes are
+func (l *Linode) Cluster(ctx context.Context, apiKey, region, clusterID string) (*Cluster, error) {
+	var pools struct {
+		Data []struct {
+			Nodes []struct {
+				Status string `json:"status"`
+			} `json:"nodes"`
+		} `json:"data"`
+	}
+
+	path := "/lke/clusters/" + url.PathEscape(clusterID)
+
+	if _, err := l.do(ctx, apiKey, http.MethodGet, path+"/pools", nil, &pools); err != nil {
+		return nil, err
+	}
+
+	total, ready := 0, 0
+	for _, pool := range pools.Data {
+		for _, node := range pool.Nodes {
+			total++
+			if node.Status == "ready" {
+				ready++
+			}
+		}
+	}
+
+	result := &Cluster{Status: fmt.Sprintf("%d/%d nodes ready", ready, total)}
+	if total == 0 || ready < total {
+		return result, nil
+	}
+
+	var kubeconfig struct {
+		Kubeconfig string `json:"kubeconfig"`
+	}
+
+	// the kubeconfig is unavailable until the control plane is ready
+	var apiErr *APIError
+	if _, err := l.do(ctx, apiKey, http.MethodGet, path+"/kubeconfig", nil, &kubeconfig); errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusServiceUnavailable {
+		return result, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	content, err := base64.StdEncoding.DecodeString(kubeconfig.Kubeconfig)
+	if err != nil {
+		return nil, fmt.Errorf("unable to decode the kubeconfig of the cluster: %w", err)
+	}
+
+	result.Ready = true
+	result.Kubeconfig = content
+
+	return result, nil
+}
@@ -0,0 +1,288 @@
+package cloud
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// ProvisioningTimeout is how long a cluster has to be ready and to run the agent before its provisioning fails
+	ProvisioningTimeout = 30 * time.Minute
+
+	pollInterval = 15 * time.Second
+)
+
+// AgentDeployer deploys the Portainer agent in a cluster
+type AgentDeployer interface {
+	// DeployAgent deploys the agent in the cluster of the kubeconfig and returns the address it is reachable at, the
+	// agent only accepting the requests signed with its secret
+	DeployAgent(ctx context.Context, kubeconfig []byte, agentSecret string) (string, error)
+}
+
+// Provisioner creates the clusters of the environments(endpoints) through their provider, waits for them to be ready
+// and deploys the agent they are managed through
+type Provisioner struct {
+	dataStore    dataservices.DataStore
+	providers    map[string]Provider
+	deployer     AgentDeployer
+	shutdownCtx  context.Context
+	pollInterval time.Duration
+	timeout      time.Duration
+}
+
+// NewProvisioner returns a provisioner of the clusters of the supported providers
+func NewProvisioner(shutdownCtx context.Context, dataStore dataservices.DataStore, deployer AgentDeployer) *Provisioner {
+	return &Provisioner{
+		dataStore:    dataStore,
+		providers:    Providers(),
+		deployer:     deployer,
+		shutdownCtx:  shutdownCtx,
+		pollInterval: pollInterval,
+		timeout:      ProvisioningTimeout,
+	}
+}
+
+// CreateCluster starts the creation of a cluster with the account of the credential, and returns the provisioning
+// to set on the environment(endpoint) it is registered as
+func (p *Provisioner) CreateCluster(credentialID portainer.CloudCredentialID, request ClusterRequest) (*portainer.CloudProvisioning, error) {
+	if err := ValidateClusterRequest(request); err != nil {
+		return nil, err
+	}
+
+	credential, apiKey, err := p.credential(credentialID)
+	if err != nil {
+		return nil, err
+	}
+
+	provider, ok := p.providers[credential.Provider]
+	if !ok {
+		return nil, ErrUnknownProvider
+	}
+
+	ctx, cancel := context.WithTimeout(p.shutdownCtx, requestTimeout)
+	defer cancel()
+
+	clusterID, err := provider.CreateCluster(ctx, apiKey, request)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create the cluster: %w", err)
+	}
+
+	return &portainer.CloudProvisioning{
+		Provider:          credential.Provider,
+		CredentialID:      credential.ID,
+		ClusterID:         clusterID,
+		Region:            request.Region,
+		NodeSize:          request.NodeSize,
+		NodeCount:         request.NodeCount,
+		KubernetesVersion: request.KubernetesVersion,
+		State:             StateProvisioning,
+		StartedAt:         time.Now().Unix(),
+	}, nil
+}
+
+// Watch waits in the background for the cluster of the environment(endpoint) to be ready, then deploys the agent
+// and points the environment to it
+func (p *Provisioner) Watch(endpointID portainer.EndpointID) {
+	go p.watch(endpointID)
+}
+
+// Resume watches the clusters whose provisioning was interrupted by a restart
+func (p *Provisioner) Resume() {
+	endpoints, err := p.dataStore.Endpoint().Endpoints()
+	if err != nil {
+		log.Error().Err(err).Msg("unable to retrieve the environments being provisioned")
+
+		return
+	}
+
+	for _, endpoint := range endpoints {
+		if isInProgress(endpoint.CloudProvisioning) {
+			p.Watch(endpoint.ID)
+		}
+	}
+}
+
+func isInProgress(provisioning *portainer.CloudProvisioning) bool {
+	return provisioning != nil && (provisioning.State == StateProvisioning || provisioning.State == StateDeploying)
+}
+
+func (p *Provisioner) watch(endpointID portainer.EndpointID) {
+	ticker := time.NewTicker(p.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		done, err := p.step(endpointID)
+		if err != nil {
+			log.Error().Err(err).Int("endpoint_id", int(endpointID)).Msg("unable to provision the cluster of the environment")
+
+			p.update(endpointID, func(endpoint *portainer.Endpoint) {
+				endpoint.CloudProvisioning.State = StateFailed
+				endpoint.CloudProvisioning.Error = err.Error()
+				endpoint.CloudProvisioning.FinishedAt = time.Now().Unix()
+			})
+
+			return
+		} else if done {
+			return
+		}
+
+		select {
+		case <-p.shutdownCtx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// step checks the cluster once and deploys the agent when it is ready, it returns whether the provisioning is over
+func (p *Provisioner) step(endpointID portainer.EndpointID) (bool, error) {
+	endpoint, err := p.dataStore.Endpoint().Endpoint(endpointID)
+	if p.dataStore.IsErrObjectNotFound(err) {
+		return true, nil
+	} else if err != nil {
+		return false, err
+	}
+
+	provisioning := endpoint.CloudProvisioning
+	if !isInProgress(provisioning) {
+		return true, nil
+	}
+
+	deadline := time.Unix(provisioning.StartedAt, 0).Add(p.timeout)
+	if time.Now().After(deadline) {
+		return false, fmt.Errorf("the cluster was not ready after %s", p.timeout)
+	}
+
+	provider, ok := p.providers[provisioning.Provider]
+	if !ok {
+		return false, ErrUnknownProvider
+	}
+
+	_, apiKey, err := p.credential(provisioning.CredentialID)
+	if err != nil {
+		return false, err
+	}
+
+	ctx, cancel := context.WithDeadline(p.shutdownCtx, deadline)
+	defer cancel()
+
+	cluster, err := provider.Cluster(ctx, apiKey, provisioning.Region, provisioning.ClusterID)
+	if err != nil {
+		// the provider API may be briefly unavailable, the cluster is checked again until the deadline
+		log.Warn().Err(err).Int("endpoint_id", int(endpointID)).Msg("unable to retrieve the state of the cluster")
+
+		return false, nil
+	}
+
+	if cluster.Failed {
+		return false, fmt.Errorf("the provider failed to create the cluster: %s", cluster.Status)
+	}
+
+	if !cluster.Ready {
+		return false, nil
+	}
+
+	p.update(endpointID, func(endpoint *portainer.Endpoint) {
+		endpoint.CloudProvisioning.State = StateDeploying
+	})
+
+	agentSecret, err := p.agentSecret(endpoint)
+	if err != nil {
+		return false, err
+	}
+
+	address, err := p.deployer.DeployAgent(ctx, cluster.Kubeconfig, agentSecret)
+	if err != nil {
+		return false, fmt.Errorf("unable to deploy the agent in the cluster: %w", err)
+	}
+
+	p.update(endpointID, func(endpoint *portainer.Endpoint) {
+		endpoint.URL = address
+		endpoint.Status = portainer.EndpointStatusUp
+		endpoint.CloudProvisioning.State = StateReady
+		endpoint.CloudProvisioning.FinishedAt = time.Now().Unix()
+	})
+
+	log.Info().Int("endpoint_id", int(endpointID)).Str("url", address).Msg("the cluster of the environment is ready")
+
+	return true, nil
+}
+
+func (p *Provisioner) credential(credentialID portainer.CloudCredentialID) (*portainer.CloudCredential, string, error) {
+	var credential *portainer.CloudCredential
+	var apiKey string
+
+	err := p.dataStore.ViewTx(func(tx dataservices.DataStoreTx) error {
+		var err error
+		if credential, err = tx.CloudCredential().Read(credentialID); err != nil {
+			return err
+		}
+
+		apiKey, err = DecryptAPIKey(tx, credential)
+
+		return err
+	})
+	if p.dataStore.IsErrObjectNotFound(err) {
+		return nil, "", errors.New("the cloud credential does not exist anymore")
+	}
+
+	return credential, apiKey, err
+}
+
+// agentSecret returns the secret of the agent of the environment, it is generated and stored before the agent is
+// deployed so that a resumed provisioning deploys the same one
+func (p *Provisioner) agentSecret(endpoint *portainer.Endpoint) (string, error) {
+	if endpoint.AgentSecret != "" {
+		return endpoint.AgentSecret, nil
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", fmt.Errorf("unable to generate the secret of the agent: %w", err)
+	}
+
+	agentSecret := base64.RawURLEncoding.EncodeToString(secret)
+
+	if err := p.dataStore.UpdateTx(func(tx dataservices.DataStoreTx) error {
+		endpoint, err := tx.Endpoint().Endpoint(endpoint.ID)
+		if err != nil {
+			return err
+		}
+
+		endpoint.AgentSecret = agentSecret
+
+		return tx.Endpoint().UpdateEndpoint(endpoint.ID, endpoint)
+	}); err != nil {
+		return "", fmt.Errorf("unable to store the secret of the agent: %w", err)
+	}
+
+	return agentSecret, nil
+}
+
+func (p *Provisioner) update(endpointID portainer.EndpointID, updateFunc func(endpoint *portainer.Endpoint)) {
+	if err := p.dataStore.UpdateTx(func(tx dataservices.DataStoreTx) error {
+		endpoint, err := tx.Endpoint().Endpoint(endpointID)
+		if err != nil {
+			return err
+		}
+
+		if endpoint.CloudProvisioning == nil {
+			return nil
+		}
+
+		updateFunc(endpoint)
+
+		return tx.Endpoint().UpdateEndpoint(endpointID, endpoint)
+	}); err != nil {
+		log.Error().Err(err).Int("endpoint_id", int(endpointID)).Msg("unable to update the provisioning of the environment")
+	}
+}
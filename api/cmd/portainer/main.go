@@ -7,9 +7,11 @@ import (
 	"os"
 	"path"
 	"strings"
+	"time"
 
 	portainer "github.com/portainer/portainer/api"
 	"github.com/portainer/portainer/api/apikey"
+	"github.com/portainer/portainer/api/authprovider"
 	"github.com/portainer/portainer/api/chisel"
 	"github.com/portainer/portainer/api/cli"
 	"github.com/portainer/portainer/api/crypto"
@@ -22,12 +24,19 @@ import (
 	"github.com/portainer/portainer/api/datastore/postinit"
 	"github.com/portainer/portainer/api/docker"
 	dockerclient "github.com/portainer/portainer/api/docker/client"
+	"github.com/portainer/portainer/api/docker/containerjobs"
+	"github.com/portainer/portainer/api/docker/imageupdates"
+	"github.com/portainer/portainer/api/docker/orphans"
+	"github.com/portainer/portainer/api/docker/prunepolicies"
+	"github.com/portainer/portainer/api/docker/quarantine"
 	"github.com/portainer/portainer/api/exec"
 	"github.com/portainer/portainer/api/filesystem"
+	"github.com/portainer/portainer/api/fleetreport"
 	"github.com/portainer/portainer/api/git"
 	"github.com/portainer/portainer/api/hostmanagement/openamt"
 	"github.com/portainer/portainer/api/http"
 	"github.com/portainer/portainer/api/http/proxy"
+	"github.com/portainer/portainer/api/http/proxy/factory"
 	kubeproxy "github.com/portainer/portainer/api/http/proxy/factory/kubernetes"
 	"github.com/portainer/portainer/api/internal/authorization"
 	"github.com/portainer/portainer/api/internal/edge/edgestacks"
@@ -44,8 +53,16 @@ import (
 	"github.com/portainer/portainer/api/pendingactions/actions"
 	"github.com/portainer/portainer/api/pendingactions/handlers"
 	"github.com/portainer/portainer/api/platform"
+	"github.com/portainer/portainer/api/plugins"
+	"github.com/portainer/portainer/api/registrycredentials"
+	"github.com/portainer/portainer/api/retention"
+	"github.com/portainer/portainer/api/saml"
 	"github.com/portainer/portainer/api/scheduler"
+	"github.com/portainer/portainer/api/sessionrecording"
 	"github.com/portainer/portainer/api/stacks/deployments"
+	"github.com/portainer/portainer/api/stacks/drift"
+	"github.com/portainer/portainer/api/stacks/fleetstacks"
+	"github.com/portainer/portainer/api/update"
 	"github.com/portainer/portainer/pkg/build"
 	"github.com/portainer/portainer/pkg/featureflags"
 	"github.com/portainer/portainer/pkg/libhelm"
@@ -321,6 +338,43 @@ func loadEncryptionSecretKey(keyfilename string) []byte {
 	return hash[:]
 }
 
+// rollbackCrashedUpgrade rolls back an upgrade whose version keeps crashing before passing its health check. It runs
+// before the data are opened, only with the services needed to switch the image of the Portainer container
+func rollbackCrashedUpgrade(flags *portainer.CLIFlags, fileService portainer.FileService) {
+	attempt, err := update.CrashedUpgrade(*flags.Data)
+	if err != nil {
+		log.Fatal().Err(err).Msg("failed reading the upgrade in progress")
+	} else if attempt == nil {
+		return
+	}
+
+	log.Error().
+		Str("from_version", attempt.FromVersion).
+		Str("to_version", attempt.ToVersion).
+		Int("starts", attempt.Starts).
+		Msg("the upgraded instance keeps failing to start, rolling back")
+
+	signatureService := initDigitalSignatureService()
+	if err := initKeyPair(fileService, signatureService); err != nil {
+		log.Fatal().Err(err).Msg("failed initializing key pair")
+	}
+
+	dockerClientFactory := dockerclient.NewClientFactory(signatureService, nil)
+	composeStackManager := exec.NewComposeStackManager(compose.NewComposeDeployer(), proxy.NewManager(nil), nil)
+
+	upgradeService, err := upgrade.NewService(*flags.Assets, nil, dockerClientFactory, composeStackManager, nil, fileService, nil)
+	if err != nil {
+		log.Fatal().Err(err).Msg("failed initializing upgrade service")
+	}
+
+	if err := update.RollbackCrashedUpgrade(*flags.Data, attempt, upgradeService); err != nil {
+		log.Fatal().Err(err).Msg("failed rolling the upgrade back")
+	}
+
+	log.Info().Msg("exiting upgrade rollback")
+	os.Exit(0)
+}
+
 func buildServer(flags *portainer.CLIFlags) portainer.Server {
 	shutdownCtx, shutdownTrigger := context.WithCancel(context.Background())
 
@@ -334,6 +388,8 @@ func buildServer(flags *portainer.CLIFlags) portainer.Server {
 		log.Info().Msg("proceeding without encryption key")
 	}
 
+	rollbackCrashedUpgrade(flags, fileService)
+
 	dataStore := initDataStore(flags, encryptionKey, fileService, shutdownCtx)
 
 	if err := dataStore.CheckCurrentEdition(); err != nil {
@@ -366,12 +422,29 @@ func buildServer(flags *portainer.CLIFlags) portainer.Server {
 
 	oauthService := oauth.NewService()
 
+	samlService := saml.NewService()
+
 	gitService := git.NewService(shutdownCtx)
 
 	openAMTService := openamt.NewService()
 
 	cryptoService := &crypto.Service{}
 
+	if err := authprovider.RegisterBuiltins(dataStore, cryptoService, ldapService); err != nil {
+		log.Fatal().Err(err).Msg("failed registering the authentication providers")
+	}
+
+	for _, pair := range *flags.Plugins {
+		plugin, err := plugins.NewSidecar(pair.Name, pair.Value)
+		if err != nil {
+			log.Fatal().Err(err).Msg("failed creating the plugin")
+		}
+
+		if err := plugins.Register(plugin); err != nil {
+			log.Fatal().Err(err).Msg("failed registering the plugin")
+		}
+	}
+
 	signatureService := initDigitalSignatureService()
 
 	edgeStacksService := edgestacks.NewService(dataStore)
@@ -436,6 +509,14 @@ func buildServer(flags *portainer.CLIFlags) portainer.Server {
 	snapshotService.Start()
 
 	proxyManager.NewProxyFactory(dataStore, signatureService, reverseTunnelService, dockerClientFactory, kubernetesClientFactory, kubernetesTokenCacheManager, gitService, snapshotService)
+	if err := proxyManager.SetTransportSettings(factory.TransportSettings{
+		MaxIdleConns:        *flags.ProxyMaxIdleConns,
+		MaxIdleConnsPerHost: *flags.ProxyMaxIdleConnsPerHost,
+		IdleConnTimeout:     *flags.ProxyIdleConnTimeout,
+		TLSSessionCacheSize: *flags.ProxyTLSSessionCacheSize,
+	}); err != nil {
+		log.Fatal().Err(err).Msg("failed tuning the proxy transports")
+	}
 
 	helmPackageManager, err := initHelmPackageManager(*flags.Assets)
 	if err != nil {
@@ -499,6 +580,50 @@ func buildServer(flags *portainer.CLIFlags) portainer.Server {
 	stackDeployer := deployments.NewStackDeployer(swarmStackManager, composeStackManager, kubernetesDeployer, dockerClientFactory, dataStore)
 	deployments.StartStackSchedules(scheduler, stackDeployer, dataStore, gitService)
 
+	fleetStacksService := fleetstacks.NewService(dataStore, fileService, stackDeployer, gitService)
+	if err := fleetStacksService.ResumeRollouts(); err != nil {
+		log.Error().Err(err).Msg("unable to resume the rollouts of the fleet stacks")
+	}
+	scheduler.StartJobEvery(time.Minute, edgeStacksService.ActivatePendingPrePulls)
+
+	orphanService := orphans.NewService(dataStore, dockerClientFactory, scheduler)
+	if err := orphanService.SetSchedule(settings.OrphanCleanupSettings); err != nil {
+		log.Error().Err(err).Msg("unable to schedule the cleanup of the orphaned resources")
+	}
+
+	imageUpdateService := imageupdates.NewService(dataStore, dockerClientFactory)
+	scheduler.StartJobEvery(imageupdates.CheckInterval, imageUpdateService.CheckAll)
+
+	containerJobService := containerjobs.NewService(dataStore, dockerClientFactory, scheduler)
+	if err := containerJobService.Start(); err != nil {
+		log.Error().Err(err).Msg("unable to schedule the container jobs")
+	}
+
+	prunePolicyService := prunepolicies.NewService(dataStore, dockerClientFactory, scheduler)
+	if err := prunePolicyService.Start(); err != nil {
+		log.Error().Err(err).Msg("unable to schedule the prune policies")
+	}
+
+	driftService := drift.NewService(dataStore, fileService, dockerClientFactory, scheduler)
+	if err := driftService.SetSchedule(settings.StackDriftCheckInterval); err != nil {
+		log.Error().Err(err).Msg("unable to schedule the drift check of the stacks")
+	}
+
+	fleetReportService := fleetreport.NewService(dataStore)
+	scheduler.StartJobEvery(fleetreport.CheckInterval, fleetReportService.SendIfDue)
+
+	registryCredentialService := registrycredentials.NewService(dataStore, kubernetesClientFactory)
+	scheduler.StartJobEvery(registrycredentials.CheckInterval, registryCredentialService.RefreshIfDue)
+
+	retentionService := retention.NewService(dataStore)
+	scheduler.StartJobEvery(retention.PurgeInterval, retentionService.Purge)
+
+	sessionRecordingService := sessionrecording.NewService(dataStore, fileService)
+	if err := sessionRecordingService.Start(); err != nil {
+		log.Error().Err(err).Msg("unable to end the interrupted session recordings")
+	}
+	scheduler.StartJobEvery(sessionrecording.CleanupInterval, sessionRecordingService.Cleanup)
+
 	sslDBSettings, err := dataStore.SSLSettings().Settings()
 	if err != nil {
 		log.Fatal().Msg("failed to fetch SSL settings from DB")
@@ -557,6 +682,7 @@ func buildServer(flags *portainer.CLIFlags) portainer.Server {
 		FileService:                 fileService,
 		LDAPService:                 ldapService,
 		OAuthService:                oauthService,
+		SAMLService:                 samlService,
 		GitService:                  gitService,
 		OpenAMTService:              openAMTService,
 		ProxyManager:                proxyManager,
@@ -575,6 +701,16 @@ func buildServer(flags *portainer.CLIFlags) portainer.Server {
 		AdminCreationDone:           adminCreationDone,
 		PendingActionsService:       pendingActionsService,
 		PlatformService:             platformService,
+		OrphanService:               orphanService,
+		ImageUpdateService:          imageUpdateService,
+		ContainerJobService:         containerJobService,
+		PrunePolicyService:          prunePolicyService,
+		SessionRecordingService:     sessionRecordingService,
+		QuarantineService:           quarantine.NewService(dataStore, dockerClientFactory),
+		FleetReportService:          fleetReportService,
+		RegistryCredentialService:   registryCredentialService,
+		FleetStacksService:          fleetStacksService,
+		DriftService:                driftService,
 	}
 }
 
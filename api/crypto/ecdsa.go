@@ -8,6 +8,7 @@ import (
 	"encoding/base64"
 	"encoding/hex"
 
+	portainer "github.com/portainer/portainer/api"
 	"github.com/portainer/portainer/pkg/libcrypto"
 )
 
@@ -112,6 +113,21 @@ func (service *ECDSAService) CreateSignature(message string) (string, error) {
 		message = service.secret
 	}
 
+	return service.sign(message)
+}
+
+// CreateAgentSignature creates the digital signature of the requests sent to an agent.
+// The secret of the agent is signed when it has one, overriding the secret associated to the service.
+// Otherwise, the signature is the one of CreateSignature.
+func (service *ECDSAService) CreateAgentSignature(agentSecret string) (string, error) {
+	if agentSecret == "" {
+		return service.CreateSignature(portainer.PortainerAgentSignatureMessage)
+	}
+
+	return service.sign(agentSecret)
+}
+
+func (service *ECDSAService) sign(message string) (string, error) {
 	hash := libcrypto.HashFromBytes([]byte(message))
 
 	r, s, err := ecdsa.Sign(rand.Reader, service.privateKey, hash)
@@ -0,0 +1,395 @@
+// Package dashboard computes the widgets of the home dashboards from the environments(endpoints), their snapshots
+// and the stacks, so a dashboard is fetched in one call
+package dashboard
+
+import (
+	"cmp"
+	"errors"
+	"slices"
+	"strconv"
+	"strings"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+	"github.com/portainer/portainer/api/internal/endpointutils"
+)
+
+const (
+	// WidgetEndpointStatus counts the environments by status and type, and their containers
+	WidgetEndpointStatus = "endpointStatus"
+	// WidgetRecentDeployments lists the last deployed stacks and edge stacks
+	WidgetRecentDeployments = "recentDeployments"
+	// WidgetAlerts lists the environments which are down, their unhealthy containers and failed edge stacks
+	WidgetAlerts = "alerts"
+	// WidgetTopConsumers ranks the environments by a metric of their last snapshot
+	WidgetTopConsumers = "topConsumers"
+)
+
+const (
+	MetricContainers        = "containers"
+	MetricRunningContainers = "runningContainers"
+	MetricImages            = "images"
+	MetricVolumes           = "volumes"
+	MetricStacks            = "stacks"
+	MetricCPU               = "cpu"
+	MetricMemory            = "memory"
+)
+
+// DefaultLimit is the number of items of a widget without limit
+const DefaultLimit = 5
+
+const maxLimit = 100
+
+var metrics = []string{MetricContainers, MetricRunningContainers, MetricImages, MetricVolumes, MetricStacks, MetricCPU, MetricMemory}
+
+// Scope represents the resources the user fetching a dashboard can access
+type Scope struct {
+	Endpoints []portainer.Endpoint
+	Stacks    []portainer.Stack
+	// Edge stacks are only in the scope of the administrators
+	EdgeStacks []portainer.EdgeStack
+}
+
+// EndpointStatus represents the data of an endpoint status widget
+type EndpointStatus struct {
+	Total int `json:"Total" example:"12"`
+	Up    int `json:"Up" example:"11"`
+	Down  int `json:"Down" example:"1"`
+	// Number of environments per type: docker, kubernetes, edge and azure
+	Types               map[string]int `json:"Types"`
+	Containers          int            `json:"Containers" example:"120"`
+	RunningContainers   int            `json:"RunningContainers" example:"110"`
+	StoppedContainers   int            `json:"StoppedContainers" example:"10"`
+	UnhealthyContainers int            `json:"UnhealthyContainers" example:"2"`
+}
+
+// Deployment represents a stack or an edge stack of a recent deployments widget
+type Deployment struct {
+	// Either "stack" or "edgeStack"
+	Kind         string               `json:"Kind" example:"stack"`
+	ID           int                  `json:"Id" example:"1"`
+	Name         string               `json:"Name" example:"myapp"`
+	EndpointID   portainer.EndpointID `json:"EndpointId,omitempty" example:"1"`
+	EndpointName string               `json:"EndpointName,omitempty" example:"production"`
+	Time         int64                `json:"Time" example:"1587399600"`
+	By           string               `json:"By,omitempty" example:"admin"`
+}
+
+// Alert represents an item of an alerts widget
+type Alert struct {
+	// Either "critical" or "warning"
+	Severity string `json:"Severity" example:"critical"`
+	// Either "endpointDown", "unhealthyContainers" or "edgeStackError"
+	Kind         string               `json:"Kind" example:"endpointDown"`
+	EndpointID   portainer.EndpointID `json:"EndpointId" example:"1"`
+	EndpointName string               `json:"EndpointName" example:"production"`
+	Message      string               `json:"Message" example:"The environment is down"`
+	Time         int64                `json:"Time,omitempty" example:"1587399600"`
+}
+
+// Consumer represents an environment(endpoint) of a top consumers widget
+type Consumer struct {
+	EndpointID   portainer.EndpointID `json:"EndpointId" example:"1"`
+	EndpointName string               `json:"EndpointName" example:"production"`
+	// Value of the metric of the widget, the memory being in bytes
+	Value int64 `json:"Value" example:"42"`
+}
+
+// WidgetData represents a widget of a dashboard with its data, only the field of its type being set
+type WidgetData struct {
+	portainer.DashboardWidget
+	EndpointStatus    *EndpointStatus `json:"EndpointStatus,omitempty"`
+	RecentDeployments []Deployment    `json:"RecentDeployments,omitempty"`
+	Alerts            []Alert         `json:"Alerts,omitempty"`
+	TopConsumers      []Consumer      `json:"TopConsumers,omitempty"`
+}
+
+// ValidateWidget checks the type, the metric and the layout of a widget
+func ValidateWidget(widget portainer.DashboardWidget) error {
+	switch widget.Type {
+	case WidgetEndpointStatus, WidgetRecentDeployments, WidgetAlerts:
+	case WidgetTopConsumers:
+		if !slices.Contains(metrics, widget.Metric) {
+			return errors.New("invalid metric, must be one of " + strings.Join(metrics, ", "))
+		}
+	default:
+		return errors.New("invalid widget type, must be endpointStatus, recentDeployments, alerts or topConsumers")
+	}
+
+	if widget.Limit < 0 || widget.Limit > maxLimit {
+		return errors.New("invalid limit, must be between 0 and 100")
+	}
+
+	if widget.Width < 0 || widget.Width > 12 {
+		return errors.New("invalid width, must be between 0 and 12 columns")
+	}
+
+	return nil
+}
+
+// Compute computes the data of every widget of a dashboard, from the resources of the scope
+func Compute(tx dataservices.DataStoreTx, dashboard *portainer.Dashboard, scope Scope) ([]WidgetData, error) {
+	settings, err := tx.Settings().Settings()
+	if err != nil {
+		return nil, err
+	}
+
+	snapshots := make(map[portainer.EndpointID]*portainer.Snapshot, len(scope.Endpoints))
+	for i := range scope.Endpoints {
+		endpoint := &scope.Endpoints[i]
+		endpointutils.UpdateEdgeEndpointHeartbeat(endpoint, settings)
+
+		snapshot, err := tx.Snapshot().Read(endpoint.ID)
+		if err != nil && !tx.IsErrObjectNotFound(err) {
+			return nil, err
+		}
+
+		snapshots[endpoint.ID] = snapshot
+	}
+
+	data := make([]WidgetData, 0, len(dashboard.Widgets))
+
+	for _, widget := range dashboard.Widgets {
+		widgetData := WidgetData{DashboardWidget: widget}
+		limit := cmp.Or(widget.Limit, DefaultLimit)
+
+		switch widget.Type {
+		case WidgetEndpointStatus:
+			widgetData.EndpointStatus = endpointStatus(scope.Endpoints, snapshots)
+		case WidgetRecentDeployments:
+			widgetData.RecentDeployments = recentDeployments(scope, limit)
+		case WidgetAlerts:
+			widgetData.Alerts = alerts(scope, snapshots, limit)
+		case WidgetTopConsumers:
+			widgetData.TopConsumers = topConsumers(scope.Endpoints, snapshots, widget.Metric, limit)
+		}
+
+		data = append(data, widgetData)
+	}
+
+	return data, nil
+}
+
+func isUp(endpoint *portainer.Endpoint) bool {
+	if endpointutils.IsEdgeEndpoint(endpoint) {
+		return endpoint.Heartbeat
+	}
+
+	return endpoint.Status == portainer.EndpointStatusUp
+}
+
+func endpointType(endpoint *portainer.Endpoint) string {
+	switch {
+	case endpointutils.IsEdgeEndpoint(endpoint):
+		return "edge"
+	case endpointutils.IsKubernetesEndpoint(endpoint):
+		return "kubernetes"
+	case endpoint.Type == portainer.AzureEnvironment:
+		return "azure"
+	}
+
+	return "docker"
+}
+
+func endpointStatus(endpoints []portainer.Endpoint, snapshots map[portainer.EndpointID]*portainer.Snapshot) *EndpointStatus {
+	status := &EndpointStatus{Types: map[string]int{}}
+
+	for i := range endpoints {
+		endpoint := &endpoints[i]
+
+		status.Total++
+		status.Types[endpointType(endpoint)]++
+
+		if isUp(endpoint) {
+			status.Up++
+		} else {
+			status.Down++
+		}
+
+		if snapshot := snapshots[endpoint.ID]; snapshot != nil && snapshot.Docker != nil {
+			status.Containers += snapshot.Docker.ContainerCount
+			status.RunningContainers += snapshot.Docker.RunningContainerCount
+			status.StoppedContainers += snapshot.Docker.StoppedContainerCount
+			status.UnhealthyContainers += snapshot.Docker.UnhealthyContainerCount
+		}
+	}
+
+	return status
+}
+
+func endpointNames(endpoints []portainer.Endpoint) map[portainer.EndpointID]string {
+	names := make(map[portainer.EndpointID]string, len(endpoints))
+	for _, endpoint := range endpoints {
+		names[endpoint.ID] = endpoint.Name
+	}
+
+	return names
+}
+
+func recentDeployments(scope Scope, limit int) []Deployment {
+	names := endpointNames(scope.Endpoints)
+	deployments := []Deployment{}
+
+	for _, stack := range scope.Stacks {
+		deployment := Deployment{
+			Kind:         "stack",
+			ID:           int(stack.ID),
+			Name:         stack.Name,
+			EndpointID:   stack.EndpointID,
+			EndpointName: names[stack.EndpointID],
+			Time:         stack.CreationDate,
+			By:           stack.CreatedBy,
+		}
+
+		if stack.UpdateDate > stack.CreationDate {
+			deployment.Time = stack.UpdateDate
+			deployment.By = stack.UpdatedBy
+		}
+
+		deployments = append(deployments, deployment)
+	}
+
+	for _, edgeStack := range scope.EdgeStacks {
+		deployments = append(deployments, Deployment{
+			Kind: "edgeStack",
+			ID:   int(edgeStack.ID),
+			Name: edgeStack.Name,
+			Time: edgeStack.CreationDate,
+		})
+	}
+
+	slices.SortStableFunc(deployments, func(a, b Deployment) int { return cmp.Compare(b.Time, a.Time) })
+
+	return deployments[:min(limit, len(deployments))]
+}
+
+func alerts(scope Scope, snapshots map[portainer.EndpointID]*portainer.Snapshot, limit int) []Alert {
+	names := endpointNames(scope.Endpoints)
+	alerts := []Alert{}
+
+	for i := range scope.Endpoints {
+		endpoint := &scope.Endpoints[i]
+
+		if !isUp(endpoint) {
+			alerts = append(alerts, Alert{
+				Severity:     "critical",
+				Kind:         "endpointDown",
+				EndpointID:   endpoint.ID,
+				EndpointName: endpoint.Name,
+				Message:      "The environment is down",
+				Time:         endpoint.LastCheckInDate,
+			})
+
+			continue
+		}
+
+		if snapshot := snapshots[endpoint.ID]; snapshot != nil && snapshot.Docker != nil && snapshot.Docker.UnhealthyContainerCount > 0 {
+			alerts = append(alerts, Alert{
+				Severity:     "warning",
+				Kind:         "unhealthyContainers",
+				EndpointID:   endpoint.ID,
+				EndpointName: endpoint.Name,
+				Message:      pluralize(snapshot.Docker.UnhealthyContainerCount, "unhealthy container"),
+				Time:         snapshot.Docker.Time,
+			})
+		}
+	}
+
+	for _, edgeStack := range scope.EdgeStacks {
+		for endpointID, status := range edgeStack.Status {
+			if len(status.Status) == 0 {
+				continue
+			}
+
+			last := status.Status[len(status.Status)-1]
+			if last.Type != portainer.EdgeStackStatusError {
+				continue
+			}
+
+			alerts = append(alerts, Alert{
+				Severity:     "critical",
+				Kind:         "edgeStackError",
+				EndpointID:   endpointID,
+				EndpointName: names[endpointID],
+				Message:      "The edge stack " + edgeStack.Name + " failed to deploy: " + last.Error,
+				Time:         last.Time,
+			})
+		}
+	}
+
+	slices.SortStableFunc(alerts, func(a, b Alert) int {
+		return cmp.Or(strings.Compare(a.Severity, b.Severity), cmp.Compare(b.Time, a.Time))
+	})
+
+	return alerts[:min(limit, len(alerts))]
+}
+
+func pluralize(count int, noun string) string {
+	if count > 1 {
+		noun += "s"
+	}
+
+	return strconv.Itoa(count) + " " + noun
+}
+
+func metricValue(snapshot *portainer.Snapshot, metric string) (int64, bool) {
+	if snapshot == nil {
+		return 0, false
+	}
+
+	if snapshot.Kubernetes != nil {
+		switch metric {
+		case MetricCPU:
+			return snapshot.Kubernetes.TotalCPU, true
+		case MetricMemory:
+			return snapshot.Kubernetes.TotalMemory, true
+		}
+
+		return 0, false
+	}
+
+	docker := snapshot.Docker
+	if docker == nil {
+		return 0, false
+	}
+
+	switch metric {
+	case MetricContainers:
+		return int64(docker.ContainerCount), true
+	case MetricRunningContainers:
+		return int64(docker.RunningContainerCount), true
+	case MetricImages:
+		return int64(docker.ImageCount), true
+	case MetricVolumes:
+		return int64(docker.VolumeCount), true
+	case MetricStacks:
+		return int64(docker.StackCount), true
+	case MetricCPU:
+		return int64(docker.TotalCPU), true
+	case MetricMemory:
+		return docker.TotalMemory, true
+	}
+
+	return 0, false
+}
+
+func topConsumers(endpoints []portainer.Endpoint, snapshots map[portainer.EndpointID]*portainer.Snapshot, metric string, limit int) []Consumer {
+	consumers := []Consumer{}
+
+	for i := range endpoints {
+		endpoint := &endpoints[i]
+
+		value, ok := metricValue(snapshots[endpoint.ID], metric)
+		if !ok {
+			continue
+		}
+
+		consumers = append(consumers, Consumer{EndpointID: endpoint.ID, EndpointName: endpoint.Name, Value: value})
+	}
+
+	slices.SortStableFunc(consumers, func(a, b Consumer) int {
+		return cmp.Or(cmp.Compare(b.Value, a.Value), strings.Compare(a.EndpointName, b.EndpointName))
+	})
+
+	return consumers[:min(limit, len(consumers))]
+}
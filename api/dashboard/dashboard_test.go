@@ -0,0 +1,89 @@
+package dashboard
+
+import (
+	"testing"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+	"github.com/portainer/portainer/api/datastore"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateWidget(t *testing.T) {
+	is := require.New(t)
+
+	is.NoError(ValidateWidget(portainer.DashboardWidget{Type: WidgetAlerts}))
+	is.NoError(ValidateWidget(portainer.DashboardWidget{Type: WidgetTopConsumers, Metric: MetricMemory, Limit: 10, Width: 6}))
+	is.Error(ValidateWidget(portainer.DashboardWidget{Type: WidgetTopConsumers}))
+	is.Error(ValidateWidget(portainer.DashboardWidget{Type: "weather"}))
+	is.Error(ValidateWidget(portainer.DashboardWidget{Type: WidgetAlerts, Width: 13}))
+	is.Error(ValidateWidget(portainer.DashboardWidget{Type: WidgetAlerts, Limit: -1}))
+}
+
+func TestCompute(t *testing.T) {
+	is := require.New(t)
+
+	_, store := datastore.MustNewTestStore(t, true, false)
+
+	endpoints := []portainer.Endpoint{
+		{ID: 1, Name: "production", Type: portainer.DockerEnvironment, Status: portainer.EndpointStatusUp},
+		{ID: 2, Name: "staging", Type: portainer.DockerEnvironment, Status: portainer.EndpointStatusDown},
+		{ID: 3, Name: "cluster", Type: portainer.KubernetesLocalEnvironment, Status: portainer.EndpointStatusUp},
+	}
+
+	is.NoError(store.Snapshot().Create(&portainer.Snapshot{EndpointID: 1, Docker: &portainer.DockerSnapshot{
+		Time: 100, ContainerCount: 4, RunningContainerCount: 3, StoppedContainerCount: 1, UnhealthyContainerCount: 2, TotalMemory: 1024,
+	}}))
+	is.NoError(store.Snapshot().Create(&portainer.Snapshot{EndpointID: 3, Kubernetes: &portainer.KubernetesSnapshot{TotalMemory: 4096}}))
+
+	scope := Scope{
+		Endpoints: endpoints,
+		Stacks: []portainer.Stack{
+			{ID: 1, Name: "old", EndpointID: 1, CreationDate: 10, CreatedBy: "alice"},
+			{ID: 2, Name: "updated", EndpointID: 1, CreationDate: 5, UpdateDate: 50, UpdatedBy: "bob"},
+		},
+		EdgeStacks: []portainer.EdgeStack{{ID: 1, Name: "edge-app", CreationDate: 20, Status: map[portainer.EndpointID]portainer.EdgeStackStatus{
+			1: {Status: []portainer.EdgeStackDeploymentStatus{{Time: 30, Type: portainer.EdgeStackStatusError, Error: "pull failed"}}},
+		}}},
+	}
+
+	dashboard := &portainer.Dashboard{Widgets: []portainer.DashboardWidget{
+		{Type: WidgetEndpointStatus},
+		{Type: WidgetRecentDeployments, Limit: 2},
+		{Type: WidgetAlerts},
+		{Type: WidgetTopConsumers, Metric: MetricMemory},
+	}}
+
+	var data []WidgetData
+	is.NoError(store.ViewTx(func(tx dataservices.DataStoreTx) error {
+		var err error
+		data, err = Compute(tx, dashboard, scope)
+
+		return err
+	}))
+
+	is.Len(data, 4)
+
+	status := data[0].EndpointStatus
+	is.Equal(3, status.Total)
+	is.Equal(1, status.Down)
+	is.Equal(map[string]int{"docker": 2, "kubernetes": 1}, status.Types)
+	is.Equal(2, status.UnhealthyContainers)
+
+	is.Equal([]Deployment{
+		{Kind: "stack", ID: 2, Name: "updated", EndpointID: 1, EndpointName: "production", Time: 50, By: "bob"},
+		{Kind: "edgeStack", ID: 1, Name: "edge-app", Time: 20},
+	}, data[1].RecentDeployments)
+
+	alerts := data[2].Alerts
+	is.Len(alerts, 3)
+	is.Equal("edgeStackError", alerts[0].Kind)
+	is.Equal("endpointDown", alerts[1].Kind)
+	is.Equal(Alert{Severity: "warning", Kind: "unhealthyContainers", EndpointID: 1, EndpointName: "production", Message: "2 unhealthy containers", Time: 100}, alerts[2])
+
+	is.Equal([]Consumer{
+		{EndpointID: 3, EndpointName: "cluster", Value: 4096},
+		{EndpointID: 1, EndpointName: "production", Value: 1024},
+	}, data[3].TopConsumers)
+}
@@ -0,0 +1,57 @@
+package auditlog
+
+import (
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+)
+
+// BucketName represents the name of the bucket where this service stores data.
+const BucketName = "audit_log"
+
+// Service represents a service for recording the state-changing API calls.
+type Service struct {
+	dataservices.BaseDataService[portainer.AuditLog, portainer.AuditLogID]
+}
+
+// NewService creates a new instance of a service.
+func NewService(connection portainer.Connection) (*Service, error) {
+	if err := connection.SetServiceName(BucketName); err != nil {
+		return nil, err
+	}
+
+	return &Service{
+		BaseDataService: dataservices.BaseDataService[portainer.AuditLog, portainer.AuditLogID]{
+			Bucket:     BucketName,
+			Connection: connection,
+		},
+	}, nil
+}
+
+func (service *Service) Tx(tx portainer.Transaction) ServiceTx {
+	return ServiceTx{
+		BaseDataServiceTx: dataservices.BaseDataServiceTx[portainer.AuditLog, portainer.AuditLogID]{
+			Bucket:     BucketName,
+			Connection: service.Connection,
+			Tx:         tx,
+		},
+	}
+}
+
+// Create records a new audit log entry.
+func (service *Service) Create(auditLog *portainer.AuditLog) error {
+	return service.Connection.CreateObject(
+		BucketName,
+		func(id uint64) (int, any) {
+			auditLog.ID = portainer.AuditLogID(id)
+
+			return int(auditLog.ID), auditLog
+		},
+	)
+}
+
+// DeleteBefore deletes the audit log entries recorded before a Unix timestamp.
+func (service *Service) DeleteBefore(timestamp int64) error {
+	return service.Connection.UpdateTx(func(tx portainer.Transaction) error {
+		return service.Tx(tx).DeleteBefore(timestamp)
+	})
+}
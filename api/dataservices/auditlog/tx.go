@@ -0,0 +1,45 @@
+package auditlog
+
+import (
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+)
+
+type ServiceTx struct {
+	dataservices.BaseDataServiceTx[portainer.AuditLog, portainer.AuditLogID]
+}
+
+// Create records a new audit log entry.
+func (service ServiceTx) Create(auditLog *portainer.AuditLog) error {
+	return service.Tx.CreateObject(
+		BucketName,
+		func(id uint64) (int, any) {
+			auditLog.ID = portainer.AuditLogID(id)
+
+			return int(auditLog.ID), auditLog
+		},
+	)
+}
+
+// DeleteBefore deletes the audit log entries recorded before a Unix timestamp.
+func (service ServiceTx) DeleteBefore(timestamp int64) error {
+	var expired = make([]portainer.AuditLog, 0)
+
+	if err := service.Tx.GetAll(
+		BucketName,
+		&portainer.AuditLog{},
+		dataservices.FilterFn(&expired, func(e portainer.AuditLog) bool {
+			return e.Timestamp < timestamp
+		}),
+	); err != nil {
+		return err
+	}
+
+	for _, auditLog := range expired {
+		if err := service.Delete(auditLog.ID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
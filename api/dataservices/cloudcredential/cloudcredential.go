@@ -0,0 +1,50 @@
+package cloudcredential
+
+import (
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+)
+
+// BucketName represents the name of the bucket where this service stores data.
+const BucketName = "cloud_credentials"
+
+// Service represents a service for managing the cloud provider credentials.
+type Service struct {
+	dataservices.BaseDataService[portainer.CloudCredential, portainer.CloudCredentialID]
+}
+
+// NewService creates a new instance of a service.
+func NewService(connection portainer.Connection) (*Service, error) {
+	if err := connection.SetServiceName(BucketName); err != nil {
+		return nil, err
+	}
+
+	return &Service{
+		BaseDataService: dataservices.BaseDataService[portainer.CloudCredential, portainer.CloudCredentialID]{
+			Bucket:     BucketName,
+			Connection: connection,
+		},
+	}, nil
+}
+
+func (service *Service) Tx(tx portainer.Transaction) ServiceTx {
+	return ServiceTx{
+		BaseDataServiceTx: dataservices.BaseDataServiceTx[portainer.CloudCredential, portainer.CloudCredentialID]{
+			Bucket:     BucketName,
+			Connection: service.Connection,
+			Tx:         tx,
+		},
+	}
+}
+
+// Create creates a new cloud provider credential.
+func (service *Service) Create(credential *portainer.CloudCredential) error {
+	return service.Connection.CreateObject(
+		BucketName,
+		func(id uint64) (int, any) {
+			credential.ID = portainer.CloudCredentialID(id)
+
+			return int(credential.ID), credential
+		},
+	)
+}
@@ -0,0 +1,22 @@
+package cloudcredential
+
+import (
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+)
+
+type ServiceTx struct {
+	dataservices.BaseDataServiceTx[portainer.CloudCredential, portainer.CloudCredentialID]
+}
+
+// Create creates a new cloud provider credential.
+func (service ServiceTx) Create(credential *portainer.CloudCredential) error {
+	return service.Tx.CreateObject(
+		BucketName,
+		func(id uint64) (int, any) {
+			credential.ID = portainer.CloudCredentialID(id)
+
+			return int(credential.ID), credential
+		},
+	)
+}
@@ -0,0 +1,50 @@
+package containerjob
+
+import (
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+)
+
+// BucketName represents the name of the bucket where this service stores data.
+const BucketName = "container_jobs"
+
+// Service represents a service for managing the jobs running a container on a schedule.
+type Service struct {
+	dataservices.BaseDataService[portainer.ContainerJob, portainer.ContainerJobID]
+}
+
+// NewService creates a new instance of a service.
+func NewService(connection portainer.Connection) (*Service, error) {
+	if err := connection.SetServiceName(BucketName); err != nil {
+		return nil, err
+	}
+
+	return &Service{
+		BaseDataService: dataservices.BaseDataService[portainer.ContainerJob, portainer.ContainerJobID]{
+			Bucket:     BucketName,
+			Connection: connection,
+		},
+	}, nil
+}
+
+func (service *Service) Tx(tx portainer.Transaction) ServiceTx {
+	return ServiceTx{
+		BaseDataServiceTx: dataservices.BaseDataServiceTx[portainer.ContainerJob, portainer.ContainerJobID]{
+			Bucket:     BucketName,
+			Connection: service.Connection,
+			Tx:         tx,
+		},
+	}
+}
+
+// Create creates a new container job.
+func (service *Service) Create(containerJob *portainer.ContainerJob) error {
+	return service.Connection.CreateObject(
+		BucketName,
+		func(id uint64) (int, any) {
+			containerJob.ID = portainer.ContainerJobID(id)
+
+			return int(containerJob.ID), containerJob
+		},
+	)
+}
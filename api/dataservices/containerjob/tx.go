@@ -0,0 +1,22 @@
+package containerjob
+
+import (
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+)
+
+type ServiceTx struct {
+	dataservices.BaseDataServiceTx[portainer.ContainerJob, portainer.ContainerJobID]
+}
+
+// Create creates a new container job.
+func (service ServiceTx) Create(containerJob *portainer.ContainerJob) error {
+	return service.Tx.CreateObject(
+		BucketName,
+		func(id uint64) (int, any) {
+			containerJob.ID = portainer.ContainerJobID(id)
+
+			return int(containerJob.ID), containerJob
+		},
+	)
+}
@@ -0,0 +1,70 @@
+package containerjobrun
+
+import (
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+)
+
+// BucketName represents the name of the bucket where this service stores data.
+const BucketName = "container_job_runs"
+
+// Service represents a service for recording the runs of the container jobs.
+type Service struct {
+	dataservices.BaseDataService[portainer.ContainerJobRun, portainer.ContainerJobRunID]
+}
+
+// NewService creates a new instance of a service.
+func NewService(connection portainer.Connection) (*Service, error) {
+	if err := connection.SetServiceName(BucketName); err != nil {
+		return nil, err
+	}
+
+	return &Service{
+		BaseDataService: dataservices.BaseDataService[portainer.ContainerJobRun, portainer.ContainerJobRunID]{
+			Bucket:     BucketName,
+			Connection: connection,
+		},
+	}, nil
+}
+
+func (service *Service) Tx(tx portainer.Transaction) ServiceTx {
+	return ServiceTx{
+		BaseDataServiceTx: dataservices.BaseDataServiceTx[portainer.ContainerJobRun, portainer.ContainerJobRunID]{
+			Bucket:     BucketName,
+			Connection: service.Connection,
+			Tx:         tx,
+		},
+	}
+}
+
+// Create records a new run of a container job.
+func (service *Service) Create(run *portainer.ContainerJobRun) error {
+	return service.Connection.CreateObject(
+		BucketName,
+		func(id uint64) (int, any) {
+			run.ID = portainer.ContainerJobRunID(id)
+
+			return int(run.ID), run
+		},
+	)
+}
+
+// RunsByJobID returns the recorded runs of a container job.
+func (service *Service) RunsByJobID(jobID portainer.ContainerJobID) ([]portainer.ContainerJobRun, error) {
+	var runs = make([]portainer.ContainerJobRun, 0)
+
+	return runs, service.Connection.GetAll(
+		BucketName,
+		&portainer.ContainerJobRun{},
+		dataservices.FilterFn(&runs, func(e portainer.ContainerJobRun) bool {
+			return e.JobID == jobID
+		}),
+	)
+}
+
+// DeleteByJobID deletes the recorded runs of a container job.
+func (service *Service) DeleteByJobID(jobID portainer.ContainerJobID) error {
+	return service.Connection.UpdateTx(func(tx portainer.Transaction) error {
+		return service.Tx(tx).DeleteByJobID(jobID)
+	})
+}
@@ -0,0 +1,51 @@
+package containerjobrun
+
+import (
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+)
+
+type ServiceTx struct {
+	dataservices.BaseDataServiceTx[portainer.ContainerJobRun, portainer.ContainerJobRunID]
+}
+
+// Create records a new run of a container job.
+func (service ServiceTx) Create(run *portainer.ContainerJobRun) error {
+	return service.Tx.CreateObject(
+		BucketName,
+		func(id uint64) (int, any) {
+			run.ID = portainer.ContainerJobRunID(id)
+
+			return int(run.ID), run
+		},
+	)
+}
+
+// RunsByJobID returns the recorded runs of a container job.
+func (service ServiceTx) RunsByJobID(jobID portainer.ContainerJobID) ([]portainer.ContainerJobRun, error) {
+	var runs = make([]portainer.ContainerJobRun, 0)
+
+	return runs, service.Tx.GetAll(
+		BucketName,
+		&portainer.ContainerJobRun{},
+		dataservices.FilterFn(&runs, func(e portainer.ContainerJobRun) bool {
+			return e.JobID == jobID
+		}),
+	)
+}
+
+// DeleteByJobID deletes the recorded runs of a container job.
+func (service ServiceTx) DeleteByJobID(jobID portainer.ContainerJobID) error {
+	runs, err := service.RunsByJobID(jobID)
+	if err != nil {
+		return err
+	}
+
+	for _, run := range runs {
+		if err := service.Delete(run.ID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,50 @@
+package dashboard
+
+import (
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+)
+
+// BucketName represents the name of the bucket where this service stores data.
+const BucketName = "dashboards"
+
+// Service represents a service for managing the home dashboards.
+type Service struct {
+	dataservices.BaseDataService[portainer.Dashboard, portainer.DashboardID]
+}
+
+// NewService creates a new instance of a service.
+func NewService(connection portainer.Connection) (*Service, error) {
+	if err := connection.SetServiceName(BucketName); err != nil {
+		return nil, err
+	}
+
+	return &Service{
+		BaseDataService: dataservices.BaseDataService[portainer.Dashboard, portainer.DashboardID]{
+			Bucket:     BucketName,
+			Connection: connection,
+		},
+	}, nil
+}
+
+func (service *Service) Tx(tx portainer.Transaction) ServiceTx {
+	return ServiceTx{
+		BaseDataServiceTx: dataservices.BaseDataServiceTx[portainer.Dashboard, portainer.DashboardID]{
+			Bucket:     BucketName,
+			Connection: service.Connection,
+			Tx:         tx,
+		},
+	}
+}
+
+// Create creates a new dashboard.
+func (service *Service) Create(dashboard *portainer.Dashboard) error {
+	return service.Connection.CreateObject(
+		BucketName,
+		func(id uint64) (int, any) {
+			dashboard.ID = portainer.DashboardID(id)
+
+			return int(dashboard.ID), dashboard
+		},
+	)
+}
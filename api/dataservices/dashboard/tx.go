@@ -0,0 +1,22 @@
+package dashboard
+
+import (
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+)
+
+type ServiceTx struct {
+	dataservices.BaseDataServiceTx[portainer.Dashboard, portainer.DashboardID]
+}
+
+// Create creates a new dashboard.
+func (service ServiceTx) Create(dashboard *portainer.Dashboard) error {
+	return service.Tx.CreateObject(
+		BucketName,
+		func(id uint64) (int, any) {
+			dashboard.ID = portainer.DashboardID(id)
+
+			return int(dashboard.ID), dashboard
+		},
+	)
+}
@@ -0,0 +1,70 @@
+package edgejobexecution
+
+import (
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+)
+
+// BucketName represents the name of the bucket where this service stores data.
+const BucketName = "edge_job_executions"
+
+// Service represents a service for recording the executions of the Edge jobs.
+type Service struct {
+	dataservices.BaseDataService[portainer.EdgeJobExecution, portainer.EdgeJobExecutionID]
+}
+
+// NewService creates a new instance of a service.
+func NewService(connection portainer.Connection) (*Service, error) {
+	if err := connection.SetServiceName(BucketName); err != nil {
+		return nil, err
+	}
+
+	return &Service{
+		BaseDataService: dataservices.BaseDataService[portainer.EdgeJobExecution, portainer.EdgeJobExecutionID]{
+			Bucket:     BucketName,
+			Connection: connection,
+		},
+	}, nil
+}
+
+func (service *Service) Tx(tx portainer.Transaction) ServiceTx {
+	return ServiceTx{
+		BaseDataServiceTx: dataservices.BaseDataServiceTx[portainer.EdgeJobExecution, portainer.EdgeJobExecutionID]{
+			Bucket:     BucketName,
+			Connection: service.Connection,
+			Tx:         tx,
+		},
+	}
+}
+
+// Create records a new execution of an Edge job.
+func (service *Service) Create(execution *portainer.EdgeJobExecution) error {
+	return service.Connection.CreateObject(
+		BucketName,
+		func(id uint64) (int, any) {
+			execution.ID = portainer.EdgeJobExecutionID(id)
+
+			return int(execution.ID), execution
+		},
+	)
+}
+
+// ExecutionsByJobID returns the recorded executions of an Edge job.
+func (service *Service) ExecutionsByJobID(jobID portainer.EdgeJobID) ([]portainer.EdgeJobExecution, error) {
+	var executions = make([]portainer.EdgeJobExecution, 0)
+
+	return executions, service.Connection.GetAll(
+		BucketName,
+		&portainer.EdgeJobExecution{},
+		dataservices.FilterFn(&executions, func(e portainer.EdgeJobExecution) bool {
+			return e.JobID == jobID
+		}),
+	)
+}
+
+// DeleteByJobID deletes the recorded executions of an Edge job.
+func (service *Service) DeleteByJobID(jobID portainer.EdgeJobID) error {
+	return service.Connection.UpdateTx(func(tx portainer.Transaction) error {
+		return service.Tx(tx).DeleteByJobID(jobID)
+	})
+}
@@ -0,0 +1,51 @@
+package edgejobexecution
+
+import (
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+)
+
+type ServiceTx struct {
+	dataservices.BaseDataServiceTx[portainer.EdgeJobExecution, portainer.EdgeJobExecutionID]
+}
+
+// Create records a new execution of an Edge job.
+func (service ServiceTx) Create(execution *portainer.EdgeJobExecution) error {
+	return service.Tx.CreateObject(
+		BucketName,
+		func(id uint64) (int, any) {
+			execution.ID = portainer.EdgeJobExecutionID(id)
+
+			return int(execution.ID), execution
+		},
+	)
+}
+
+// ExecutionsByJobID returns the recorded executions of an Edge job.
+func (service ServiceTx) ExecutionsByJobID(jobID portainer.EdgeJobID) ([]portainer.EdgeJobExecution, error) {
+	var executions = make([]portainer.EdgeJobExecution, 0)
+
+	return executions, service.Tx.GetAll(
+		BucketName,
+		&portainer.EdgeJobExecution{},
+		dataservices.FilterFn(&executions, func(e portainer.EdgeJobExecution) bool {
+			return e.JobID == jobID
+		}),
+	)
+}
+
+// DeleteByJobID deletes the recorded executions of an Edge job.
+func (service ServiceTx) DeleteByJobID(jobID portainer.EdgeJobID) error {
+	executions, err := service.ExecutionsByJobID(jobID)
+	if err != nil {
+		return err
+	}
+
+	for _, execution := range executions {
+		if err := service.Delete(execution.ID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
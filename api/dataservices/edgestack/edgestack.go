@@ -14,6 +14,7 @@ const BucketName = "edge_stack"
 type Service struct {
 	connection          portainer.Connection
 	idxVersion          map[portainer.EdgeStackID]int
+	idxRollout          map[portainer.EdgeStackID]map[portainer.EndpointID]int
 	mu                  sync.RWMutex
 	cacheInvalidationFn func(portainer.EdgeStackID)
 }
@@ -32,6 +33,7 @@ func NewService(connection portainer.Connection, cacheInvalidationFn func(portai
 	s := &Service{
 		connection:          connection,
 		idxVersion:          make(map[portainer.EdgeStackID]int),
+		idxRollout:          make(map[portainer.EdgeStackID]map[portainer.EndpointID]int),
 		cacheInvalidationFn: cacheInvalidationFn,
 	}
 
@@ -45,7 +47,7 @@ func NewService(connection portainer.Connection, cacheInvalidationFn func(portai
 	}
 
 	for _, e := range es {
-		s.idxVersion[e.ID] = e.Version
+		s.indexEdgeStack(e.ID, &e)
 	}
 
 	return s, nil
@@ -91,6 +93,41 @@ func (service *Service) EdgeStackVersion(ID portainer.EdgeStackID) (int, bool) {
 	return v, ok
 }
 
+// EdgeStackEndpointVersion returns the version of the given edge stack ID released to an environment directly from an
+// in-memory index, 0 when the staggered rollout of the stack did not release any version to the environment yet
+func (service *Service) EdgeStackEndpointVersion(ID portainer.EdgeStackID, endpointID portainer.EndpointID) (int, bool) {
+	service.mu.RLock()
+	defer service.mu.RUnlock()
+
+	v, ok := service.idxVersion[ID]
+	if !ok {
+		return 0, false
+	}
+
+	if released, ok := service.idxRollout[ID][endpointID]; ok {
+		return released, true
+	}
+
+	return v, true
+}
+
+// indexEdgeStack updates the in-memory indexes with an edge stack, the lock must be held
+func (service *Service) indexEdgeStack(ID portainer.EdgeStackID, edgeStack *portainer.EdgeStack) {
+	service.idxVersion[ID] = edgeStack.Version
+	delete(service.idxRollout, ID)
+
+	if edgeStack.RolloutState == nil {
+		return
+	}
+
+	released := make(map[portainer.EndpointID]int, len(edgeStack.RolloutState.Endpoints))
+	for endpointID, endpoint := range edgeStack.RolloutState.Endpoints {
+		released[endpointID] = endpoint.Version
+	}
+
+	service.idxRollout[ID] = released
+}
+
 // CreateEdgeStack saves an Edge stack object to db.
 func (service *Service) Create(id portainer.EdgeStackID, edgeStack *portainer.EdgeStack) error {
 	edgeStack.ID = id
@@ -105,7 +142,7 @@ func (service *Service) Create(id portainer.EdgeStackID, edgeStack *portainer.Ed
 	}
 
 	service.mu.Lock()
-	service.idxVersion[id] = edgeStack.Version
+	service.indexEdgeStack(id, edgeStack)
 	service.cacheInvalidationFn(id)
 	service.mu.Unlock()
 
@@ -124,7 +161,7 @@ func (service *Service) UpdateEdgeStack(ID portainer.EdgeStackID, edgeStack *por
 		return err
 	}
 
-	service.idxVersion[ID] = edgeStack.Version
+	service.indexEdgeStack(ID, edgeStack)
 	service.cacheInvalidationFn(ID)
 
 	return nil
@@ -141,7 +178,7 @@ func (service *Service) UpdateEdgeStackFunc(ID portainer.EdgeStackID, updateFunc
 	return service.connection.UpdateObjectFunc(BucketName, id, edgeStack, func() {
 		updateFunc(edgeStack)
 
-		service.idxVersion[ID] = edgeStack.Version
+		service.indexEdgeStack(ID, edgeStack)
 		service.cacheInvalidationFn(ID)
 	})
 }
@@ -164,6 +201,7 @@ func (service *Service) DeleteEdgeStack(ID portainer.EdgeStackID) error {
 	}
 
 	delete(service.idxVersion, ID)
+	delete(service.idxRollout, ID)
 
 	service.cacheInvalidationFn(ID)
 
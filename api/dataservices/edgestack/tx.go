@@ -61,6 +61,12 @@ func (service ServiceTx) EdgeStackVersion(ID portainer.EdgeStackID) (int, bool)
 	return v, ok
 }
 
+// EdgeStackEndpointVersion returns the version of the given edge stack ID released to an environment directly from an
+// in-memory index, 0 when the staggered rollout of the stack did not release any version to the environment yet
+func (service ServiceTx) EdgeStackEndpointVersion(ID portainer.EdgeStackID, endpointID portainer.EndpointID) (int, bool) {
+	return service.service.EdgeStackEndpointVersion(ID, endpointID)
+}
+
 // CreateEdgeStack saves an Edge stack object to db.
 func (service ServiceTx) Create(id portainer.EdgeStackID, edgeStack *portainer.EdgeStack) error {
 	edgeStack.ID = id
@@ -75,7 +81,7 @@ func (service ServiceTx) Create(id portainer.EdgeStackID, edgeStack *portainer.E
 	}
 
 	service.service.mu.Lock()
-	service.service.idxVersion[id] = edgeStack.Version
+	service.service.indexEdgeStack(id, edgeStack)
 	service.service.cacheInvalidationFn(id)
 	service.service.mu.Unlock()
 
@@ -94,7 +100,7 @@ func (service ServiceTx) UpdateEdgeStack(ID portainer.EdgeStackID, edgeStack *po
 		return err
 	}
 
-	service.service.idxVersion[ID] = edgeStack.Version
+	service.service.indexEdgeStack(ID, edgeStack)
 	service.service.cacheInvalidationFn(ID)
 
 	return nil
@@ -125,6 +131,7 @@ func (service ServiceTx) DeleteEdgeStack(ID portainer.EdgeStackID) error {
 	}
 
 	delete(service.service.idxVersion, ID)
+	delete(service.service.idxRollout, ID)
 
 	service.service.cacheInvalidationFn(ID)
 
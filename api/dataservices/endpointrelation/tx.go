@@ -1,9 +1,12 @@
 package endpointrelation
 
 import (
+	"time"
+
 	portainer "github.com/portainer/portainer/api"
 	"github.com/portainer/portainer/api/dataservices"
 	"github.com/portainer/portainer/api/internal/edge/cache"
+	"github.com/portainer/portainer/api/internal/edge/edgestacks"
 
 	"github.com/rs/zerolog/log"
 )
@@ -143,6 +146,11 @@ func (service ServiceTx) updateEdgeStacksAfterRelationChange(previousRelationSta
 
 		if err := service.service.updateStackFnTx(service.tx, refStackId, func(edgeStack *portainer.EdgeStack) {
 			edgeStack.NumDeployments = numDeployments
+
+			// an environment that left the stack must not hold its rollout
+			if previousRelationState != nil && (updatedRelationState == nil || !updatedRelationState.EdgeStacks[refStackId]) {
+				edgestacks.RemoveRolloutEndpoint(edgeStack, previousRelationState.EndpointID, time.Now().Unix())
+			}
 		}); err != nil {
 			log.Error().Err(err).Msg("could not update the number of deployments")
 		}
@@ -0,0 +1,50 @@
+package fleetstack
+
+import (
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+)
+
+// BucketName represents the name of the bucket where this service stores data.
+const BucketName = "fleet_stacks"
+
+// Service represents a service for managing the stacks deployed to several environments.
+type Service struct {
+	dataservices.BaseDataService[portainer.FleetStack, portainer.FleetStackID]
+}
+
+// NewService creates a new instance of a service.
+func NewService(connection portainer.Connection) (*Service, error) {
+	if err := connection.SetServiceName(BucketName); err != nil {
+		return nil, err
+	}
+
+	return &Service{
+		BaseDataService: dataservices.BaseDataService[portainer.FleetStack, portainer.FleetStackID]{
+			Bucket:     BucketName,
+			Connection: connection,
+		},
+	}, nil
+}
+
+func (service *Service) Tx(tx portainer.Transaction) ServiceTx {
+	return ServiceTx{
+		BaseDataServiceTx: dataservices.BaseDataServiceTx[portainer.FleetStack, portainer.FleetStackID]{
+			Bucket:     BucketName,
+			Connection: service.Connection,
+			Tx:         tx,
+		},
+	}
+}
+
+// Create creates a new fleet stack.
+func (service *Service) Create(fleetStack *portainer.FleetStack) error {
+	return service.Connection.CreateObject(
+		BucketName,
+		func(id uint64) (int, any) {
+			fleetStack.ID = portainer.FleetStackID(id)
+
+			return int(fleetStack.ID), fleetStack
+		},
+	)
+}
@@ -0,0 +1,22 @@
+package fleetstack
+
+import (
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+)
+
+type ServiceTx struct {
+	dataservices.BaseDataServiceTx[portainer.FleetStack, portainer.FleetStackID]
+}
+
+// Create creates a new fleet stack.
+func (service ServiceTx) Create(fleetStack *portainer.FleetStack) error {
+	return service.Tx.CreateObject(
+		BucketName,
+		func(id uint64) (int, any) {
+			fleetStack.ID = portainer.FleetStackID(id)
+
+			return int(fleetStack.ID), fleetStack
+		},
+	)
+}
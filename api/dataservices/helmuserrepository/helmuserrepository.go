@@ -41,6 +41,19 @@ func (service *Service) HelmUserRepositoryByUserID(userID portainer.UserID) ([]p
 	)
 }
 
+// HelmUserRepositoryByTeamID return an array containing all the HelmUserRepository objects of the specified team.
+func (service *Service) HelmUserRepositoryByTeamID(teamID portainer.TeamID) ([]portainer.HelmUserRepository, error) {
+	var result = make([]portainer.HelmUserRepository, 0)
+
+	return result, service.Connection.GetAll(
+		BucketName,
+		&portainer.HelmUserRepository{},
+		dataservices.FilterFn(&result, func(e portainer.HelmUserRepository) bool {
+			return e.TeamID == teamID
+		}),
+	)
+}
+
 // CreateHelmUserRepository creates a new HelmUserRepository object.
 func (service *Service) Create(record *portainer.HelmUserRepository) error {
 	return service.Connection.CreateObject(
@@ -8,30 +8,49 @@ import (
 type (
 	DataStoreTx interface {
 		IsErrObjectNotFound(err error) bool
+		AuditLog() AuditLogService
+		CloudCredential() CloudCredentialService
+		ContainerJob() ContainerJobService
+		ContainerJobRun() ContainerJobRunService
 		CustomTemplate() CustomTemplateService
+		Dashboard() DashboardService
 		EdgeGroup() EdgeGroupService
 		EdgeJob() EdgeJobService
+		EdgeJobExecution() EdgeJobExecutionService
 		EdgeStack() EdgeStackService
 		Endpoint() EndpointService
 		EndpointGroup() EndpointGroupService
 		EndpointRelation() EndpointRelationService
+		FleetStack() FleetStackService
 		HelmUserRepository() HelmUserRepositoryService
+		JWTSigningKey() JWTSigningKeyService
+		LoginAttempt() LoginAttemptService
+		RegistrationToken() RegistrationTokenService
 		Registry() RegistryService
 		ResourceControl() ResourceControlService
 		Role() RoleService
+		Session() SessionService
+		SessionRecording() SessionRecordingService
 		APIKeyRepository() APIKeyRepository
 		Settings() SettingsService
 		Snapshot() SnapshotService
+		SnapshotHistory() SnapshotHistoryService
 		SSLSettings() SSLSettingsService
 		Stack() StackService
+		StackVersion() StackVersionService
 		Tag() TagService
 		TeamMembership() TeamMembershipService
 		Team() TeamService
 		TunnelServer() TunnelServerService
+		TwoFactor() TwoFactorService
 		User() UserService
 		Version() VersionService
+		WebAuthnCredential() WebAuthnCredentialService
 		Webhook() WebhookService
+		WebhookLog() WebhookLogService
 		PendingActions() PendingActionsService
+		PrunePolicy() PrunePolicyService
+		PruneReport() PruneReportService
 	}
 
 	DataStore interface {
@@ -50,12 +69,34 @@ type (
 		DataStoreTx
 	}
 
+	// CloudCredentialService represents a service to manage the cloud provider credentials
+	CloudCredentialService interface {
+		BaseCRUD[portainer.CloudCredential, portainer.CloudCredentialID]
+	}
+
+	// ContainerJobService represents a service for managing the jobs running a container on a schedule
+	ContainerJobService interface {
+		BaseCRUD[portainer.ContainerJob, portainer.ContainerJobID]
+	}
+
+	// ContainerJobRunService represents a service for recording the runs of the container jobs
+	ContainerJobRunService interface {
+		BaseCRUD[portainer.ContainerJobRun, portainer.ContainerJobRunID]
+		RunsByJobID(jobID portainer.ContainerJobID) ([]portainer.ContainerJobRun, error)
+		DeleteByJobID(jobID portainer.ContainerJobID) error
+	}
+
 	// CustomTemplateService represents a service to manage custom templates
 	CustomTemplateService interface {
 		BaseCRUD[portainer.CustomTemplate, portainer.CustomTemplateID]
 		GetNextIdentifier() int
 	}
 
+	// DashboardService represents a service to manage the home dashboards
+	DashboardService interface {
+		BaseCRUD[portainer.Dashboard, portainer.DashboardID]
+	}
+
 	// EdgeGroupService represents a service to manage Edge groups
 	EdgeGroupService interface {
 		BaseCRUD[portainer.EdgeGroup, portainer.EdgeGroupID]
@@ -70,6 +111,13 @@ type (
 		GetNextIdentifier() int
 	}
 
+	// EdgeJobExecutionService represents a service for recording the executions of the Edge jobs
+	EdgeJobExecutionService interface {
+		BaseCRUD[portainer.EdgeJobExecution, portainer.EdgeJobExecutionID]
+		ExecutionsByJobID(jobID portainer.EdgeJobID) ([]portainer.EdgeJobExecution, error)
+		DeleteByJobID(jobID portainer.EdgeJobID) error
+	}
+
 	PendingActionsService interface {
 		BaseCRUD[portainer.PendingAction, portainer.PendingActionID]
 		GetNextIdentifier() int
@@ -81,6 +129,7 @@ type (
 		EdgeStacks() ([]portainer.EdgeStack, error)
 		EdgeStack(ID portainer.EdgeStackID) (*portainer.EdgeStack, error)
 		EdgeStackVersion(ID portainer.EdgeStackID) (int, bool)
+		EdgeStackEndpointVersion(ID portainer.EdgeStackID, endpointID portainer.EndpointID) (int, bool)
 		Create(id portainer.EdgeStackID, edgeStack *portainer.EdgeStack) error
 		UpdateEdgeStack(ID portainer.EdgeStackID, edgeStack *portainer.EdgeStack) error
 		UpdateEdgeStackFunc(ID portainer.EdgeStackID, updateFunc func(edgeStack *portainer.EdgeStack)) error
@@ -119,10 +168,28 @@ type (
 		BucketName() string
 	}
 
+	// FleetStackService represents a service for managing the stacks deployed to several environments
+	FleetStackService interface {
+		BaseCRUD[portainer.FleetStack, portainer.FleetStackID]
+	}
+
 	// HelmUserRepositoryService represents a service to manage HelmUserRepositories
 	HelmUserRepositoryService interface {
 		BaseCRUD[portainer.HelmUserRepository, portainer.HelmUserRepositoryID]
 		HelmUserRepositoryByUserID(userID portainer.UserID) ([]portainer.HelmUserRepository, error)
+		HelmUserRepositoryByTeamID(teamID portainer.TeamID) ([]portainer.HelmUserRepository, error)
+	}
+
+	// TwoFactorService represents a service for managing the second authentication factor of the users
+	TwoFactorService interface {
+		BaseCRUD[portainer.TwoFactorConfiguration, portainer.UserID]
+	}
+
+	// WebAuthnCredentialService represents a service for managing the WebAuthn credentials of the users
+	WebAuthnCredentialService interface {
+		BaseCRUD[portainer.WebAuthnCredential, portainer.WebAuthnCredentialID]
+		CredentialsByUserID(userID portainer.UserID) ([]portainer.WebAuthnCredential, error)
+		CredentialByCredentialID(credentialID []byte) (*portainer.WebAuthnCredential, error)
 	}
 
 	// RegistryService represents a service for managing registry data
@@ -136,6 +203,55 @@ type (
 		ResourceControlByResourceIDAndType(resourceID string, resourceType portainer.ResourceControlType) (*portainer.ResourceControl, error)
 	}
 
+	// AuditLogService represents a service for recording the state-changing API calls
+	AuditLogService interface {
+		BaseCRUD[portainer.AuditLog, portainer.AuditLogID]
+		DeleteBefore(timestamp int64) error
+	}
+
+	// JWTSigningKeyService represents a service for managing the keys signing the JWTs of the user sessions
+	JWTSigningKeyService interface {
+		Keys() ([]portainer.JWTSigningKey, error)
+		UpdateKeys(keys []portainer.JWTSigningKey) error
+		BucketName() string
+	}
+
+	// LoginAttemptService represents a service for tracking the failed logins
+	LoginAttemptService interface {
+		BaseCRUD[portainer.LoginAttempt, portainer.LoginAttemptID]
+		LoginAttemptBySubject(subject string, ipAddress bool) (*portainer.LoginAttempt, error)
+	}
+
+	// PrunePolicyService represents a service for managing the scheduled prune policies of the Docker environments
+	PrunePolicyService interface {
+		BaseCRUD[portainer.PrunePolicy, portainer.PrunePolicyID]
+	}
+
+	// PruneReportService represents a service for recording the reports of the prune policies
+	PruneReportService interface {
+		BaseCRUD[portainer.PruneReport, portainer.PruneReportID]
+		ReportsByPolicyID(policyID portainer.PrunePolicyID) ([]portainer.PruneReport, error)
+		DeleteByPolicyID(policyID portainer.PrunePolicyID) error
+	}
+
+	// RegistrationTokenService represents a service for managing the registration tokens of the agents
+	RegistrationTokenService interface {
+		BaseCRUD[portainer.RegistrationToken, portainer.RegistrationTokenID]
+		RegistrationTokenByDigest(digest string) (*portainer.RegistrationToken, error)
+	}
+
+	// SessionService represents a service for managing the sessions of the users
+	SessionService interface {
+		BaseCRUD[portainer.Session, portainer.SessionID]
+		SessionsByUserID(userID portainer.UserID) ([]portainer.Session, error)
+		SessionByTokenID(tokenID string) (*portainer.Session, error)
+	}
+
+	// SessionRecordingService represents a service for managing the recordings of the console sessions
+	SessionRecordingService interface {
+		BaseCRUD[portainer.SessionRecording, portainer.SessionRecordingID]
+	}
+
 	// RoleService represents a service for managing user roles
 	RoleService interface {
 		BaseCRUD[portainer.Role, portainer.RoleID]
@@ -159,6 +275,12 @@ type (
 		BaseCRUD[portainer.Snapshot, portainer.EndpointID]
 	}
 
+	// SnapshotHistoryService represents a service for managing the most recent snapshots of the environments(endpoints)
+	SnapshotHistoryService interface {
+		BaseCRUD[portainer.SnapshotHistoryEntry, portainer.SnapshotHistoryID]
+		EntriesByEndpointID(endpointID portainer.EndpointID) ([]portainer.SnapshotHistoryEntry, error)
+	}
+
 	// SSLSettingsService represents a service for managing application settings
 	SSLSettingsService interface {
 		Settings() (*portainer.SSLSettings, error)
@@ -176,6 +298,12 @@ type (
 		RefreshableStacks() ([]portainer.Stack, error)
 	}
 
+	// StackVersionService represents a service for managing the version history of the stacks
+	StackVersionService interface {
+		BaseCRUD[portainer.StackVersion, portainer.StackVersionID]
+		VersionsByStackID(stackID portainer.StackID) ([]portainer.StackVersion, error)
+	}
+
 	// TagService represents a service for managing tag data
 	TagService interface {
 		BaseCRUD[portainer.Tag, portainer.TagID]
@@ -227,4 +355,11 @@ type (
 		WebhookByResourceID(resourceID string) (*portainer.Webhook, error)
 		WebhookByToken(token string) (*portainer.Webhook, error)
 	}
+
+	// WebhookLogService represents a service for recording the invocations of the webhooks
+	WebhookLogService interface {
+		BaseCRUD[portainer.WebhookLog, portainer.WebhookLogID]
+		WebhookLogsByWebhookID(webhookID portainer.WebhookID) ([]portainer.WebhookLog, error)
+		DeleteByWebhookID(webhookID portainer.WebhookID) error
+	}
 )
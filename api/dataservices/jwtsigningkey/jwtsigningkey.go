@@ -0,0 +1,57 @@
+package jwtsigningkey
+
+import (
+	portainer "github.com/portainer/portainer/api"
+)
+
+const (
+	// BucketName represents the name of the bucket where this service stores data.
+	BucketName = "jwt_signing_keys"
+	keysKey    = "KEYS"
+)
+
+// Service represents a service for managing the keys signing the JWTs of the user sessions. They are kept out of
+// the settings, so that the writers of the settings cannot drop a rotated key.
+type Service struct {
+	connection portainer.Connection
+}
+
+func (service *Service) BucketName() string {
+	return BucketName
+}
+
+// NewService creates a new instance of a service.
+func NewService(connection portainer.Connection) (*Service, error) {
+	err := connection.SetServiceName(BucketName)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Service{
+		connection: connection,
+	}, nil
+}
+
+func (service *Service) Tx(tx portainer.Transaction) ServiceTx {
+	return ServiceTx{
+		service: service,
+		tx:      tx,
+	}
+}
+
+// Keys retrieves the signing keys, the current one first.
+func (service *Service) Keys() ([]portainer.JWTSigningKey, error) {
+	var keys []portainer.JWTSigningKey
+
+	err := service.connection.GetObject(BucketName, []byte(keysKey), &keys)
+	if err != nil {
+		return nil, err
+	}
+
+	return keys, nil
+}
+
+// UpdateKeys persists the signing keys, the current one first.
+func (service *Service) UpdateKeys(keys []portainer.JWTSigningKey) error {
+	return service.connection.UpdateObject(BucketName, []byte(keysKey), keys)
+}
@@ -0,0 +1,31 @@
+package jwtsigningkey
+
+import (
+	portainer "github.com/portainer/portainer/api"
+)
+
+type ServiceTx struct {
+	service *Service
+	tx      portainer.Transaction
+}
+
+func (service ServiceTx) BucketName() string {
+	return BucketName
+}
+
+// Keys retrieves the signing keys, the current one first.
+func (service ServiceTx) Keys() ([]portainer.JWTSigningKey, error) {
+	var keys []portainer.JWTSigningKey
+
+	err := service.tx.GetObject(BucketName, []byte(keysKey), &keys)
+	if err != nil {
+		return nil, err
+	}
+
+	return keys, nil
+}
+
+// UpdateKeys persists the signing keys, the current one first.
+func (service ServiceTx) UpdateKeys(keys []portainer.JWTSigningKey) error {
+	return service.tx.UpdateObject(BucketName, []byte(keysKey), keys)
+}
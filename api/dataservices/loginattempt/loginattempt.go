@@ -0,0 +1,72 @@
+package loginattempt
+
+import (
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+	dserrors "github.com/portainer/portainer/api/dataservices/errors"
+)
+
+// BucketName represents the name of the bucket where this service stores data.
+const BucketName = "login_attempt"
+
+// Service represents a service for tracking the failed logins.
+type Service struct {
+	dataservices.BaseDataService[portainer.LoginAttempt, portainer.LoginAttemptID]
+}
+
+// NewService creates a new instance of a service.
+func NewService(connection portainer.Connection) (*Service, error) {
+	if err := connection.SetServiceName(BucketName); err != nil {
+		return nil, err
+	}
+
+	return &Service{
+		BaseDataService: dataservices.BaseDataService[portainer.LoginAttempt, portainer.LoginAttemptID]{
+			Bucket:     BucketName,
+			Connection: connection,
+		},
+	}, nil
+}
+
+func (service *Service) Tx(tx portainer.Transaction) ServiceTx {
+	return ServiceTx{
+		BaseDataServiceTx: dataservices.BaseDataServiceTx[portainer.LoginAttempt, portainer.LoginAttemptID]{
+			Bucket:     BucketName,
+			Connection: service.Connection,
+			Tx:         tx,
+		},
+	}
+}
+
+// LoginAttemptBySubject returns the failed logins tracked for a username or an IP address.
+func (service *Service) LoginAttemptBySubject(subject string, ipAddress bool) (*portainer.LoginAttempt, error) {
+	var attempts = make([]portainer.LoginAttempt, 0)
+
+	if err := service.Connection.GetAll(
+		BucketName,
+		&portainer.LoginAttempt{},
+		dataservices.FilterFn(&attempts, func(e portainer.LoginAttempt) bool {
+			return e.Subject == subject && e.IPAddress == ipAddress
+		}),
+	); err != nil {
+		return nil, err
+	}
+
+	if len(attempts) == 0 {
+		return nil, dserrors.ErrObjectNotFound
+	}
+
+	return &attempts[0], nil
+}
+
+// Create creates a new login attempt tracker.
+func (service *Service) Create(attempt *portainer.LoginAttempt) error {
+	return service.Connection.CreateObject(
+		BucketName,
+		func(id uint64) (int, any) {
+			attempt.ID = portainer.LoginAttemptID(id)
+
+			return int(attempt.ID), attempt
+		},
+	)
+}
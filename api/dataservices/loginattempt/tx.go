@@ -0,0 +1,44 @@
+package loginattempt
+
+import (
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+	dserrors "github.com/portainer/portainer/api/dataservices/errors"
+)
+
+type ServiceTx struct {
+	dataservices.BaseDataServiceTx[portainer.LoginAttempt, portainer.LoginAttemptID]
+}
+
+// LoginAttemptBySubject returns the failed logins tracked for a username or an IP address.
+func (service ServiceTx) LoginAttemptBySubject(subject string, ipAddress bool) (*portainer.LoginAttempt, error) {
+	var attempts = make([]portainer.LoginAttempt, 0)
+
+	if err := service.Tx.GetAll(
+		BucketName,
+		&portainer.LoginAttempt{},
+		dataservices.FilterFn(&attempts, func(e portainer.LoginAttempt) bool {
+			return e.Subject == subject && e.IPAddress == ipAddress
+		}),
+	); err != nil {
+		return nil, err
+	}
+
+	if len(attempts) == 0 {
+		return nil, dserrors.ErrObjectNotFound
+	}
+
+	return &attempts[0], nil
+}
+
+// Create creates a new login attempt tracker.
+func (service ServiceTx) Create(attempt *portainer.LoginAttempt) error {
+	return service.Tx.CreateObject(
+		BucketName,
+		func(id uint64) (int, any) {
+			attempt.ID = portainer.LoginAttemptID(id)
+
+			return int(attempt.ID), attempt
+		},
+	)
+}
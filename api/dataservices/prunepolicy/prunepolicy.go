@@ -0,0 +1,50 @@
+package prunepolicy
+
+import (
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+)
+
+// BucketName represents the name of the bucket where this service stores data.
+const BucketName = "prune_policies"
+
+// Service represents a service for managing the scheduled prune policies of the Docker environments.
+type Service struct {
+	dataservices.BaseDataService[portainer.PrunePolicy, portainer.PrunePolicyID]
+}
+
+// NewService creates a new instance of a service.
+func NewService(connection portainer.Connection) (*Service, error) {
+	if err := connection.SetServiceName(BucketName); err != nil {
+		return nil, err
+	}
+
+	return &Service{
+		BaseDataService: dataservices.BaseDataService[portainer.PrunePolicy, portainer.PrunePolicyID]{
+			Bucket:     BucketName,
+			Connection: connection,
+		},
+	}, nil
+}
+
+func (service *Service) Tx(tx portainer.Transaction) ServiceTx {
+	return ServiceTx{
+		BaseDataServiceTx: dataservices.BaseDataServiceTx[portainer.PrunePolicy, portainer.PrunePolicyID]{
+			Bucket:     BucketName,
+			Connection: service.Connection,
+			Tx:         tx,
+		},
+	}
+}
+
+// Create creates a new prune policy.
+func (service *Service) Create(prunePolicy *portainer.PrunePolicy) error {
+	return service.Connection.CreateObject(
+		BucketName,
+		func(id uint64) (int, any) {
+			prunePolicy.ID = portainer.PrunePolicyID(id)
+
+			return int(prunePolicy.ID), prunePolicy
+		},
+	)
+}
@@ -0,0 +1,22 @@
+package prunepolicy
+
+import (
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+)
+
+type ServiceTx struct {
+	dataservices.BaseDataServiceTx[portainer.PrunePolicy, portainer.PrunePolicyID]
+}
+
+// Create creates a new prune policy.
+func (service ServiceTx) Create(prunePolicy *portainer.PrunePolicy) error {
+	return service.Tx.CreateObject(
+		BucketName,
+		func(id uint64) (int, any) {
+			prunePolicy.ID = portainer.PrunePolicyID(id)
+
+			return int(prunePolicy.ID), prunePolicy
+		},
+	)
+}
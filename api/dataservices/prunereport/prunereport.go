@@ -0,0 +1,70 @@
+package prunereport
+
+import (
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+)
+
+// BucketName represents the name of the bucket where this service stores data.
+const BucketName = "prune_reports"
+
+// Service represents a service for recording the reports of the prune policies.
+type Service struct {
+	dataservices.BaseDataService[portainer.PruneReport, portainer.PruneReportID]
+}
+
+// NewService creates a new instance of a service.
+func NewService(connection portainer.Connection) (*Service, error) {
+	if err := connection.SetServiceName(BucketName); err != nil {
+		return nil, err
+	}
+
+	return &Service{
+		BaseDataService: dataservices.BaseDataService[portainer.PruneReport, portainer.PruneReportID]{
+			Bucket:     BucketName,
+			Connection: connection,
+		},
+	}, nil
+}
+
+func (service *Service) Tx(tx portainer.Transaction) ServiceTx {
+	return ServiceTx{
+		BaseDataServiceTx: dataservices.BaseDataServiceTx[portainer.PruneReport, portainer.PruneReportID]{
+			Bucket:     BucketName,
+			Connection: service.Connection,
+			Tx:         tx,
+		},
+	}
+}
+
+// Create records a new report of a prune policy.
+func (service *Service) Create(report *portainer.PruneReport) error {
+	return service.Connection.CreateObject(
+		BucketName,
+		func(id uint64) (int, any) {
+			report.ID = portainer.PruneReportID(id)
+
+			return int(report.ID), report
+		},
+	)
+}
+
+// ReportsByPolicyID returns the recorded reports of a prune policy.
+func (service *Service) ReportsByPolicyID(policyID portainer.PrunePolicyID) ([]portainer.PruneReport, error) {
+	var reports = make([]portainer.PruneReport, 0)
+
+	return reports, service.Connection.GetAll(
+		BucketName,
+		&portainer.PruneReport{},
+		dataservices.FilterFn(&reports, func(e portainer.PruneReport) bool {
+			return e.PolicyID == policyID
+		}),
+	)
+}
+
+// DeleteByPolicyID deletes the recorded reports of a prune policy.
+func (service *Service) DeleteByPolicyID(policyID portainer.PrunePolicyID) error {
+	return service.Connection.UpdateTx(func(tx portainer.Transaction) error {
+		return service.Tx(tx).DeleteByPolicyID(policyID)
+	})
+}
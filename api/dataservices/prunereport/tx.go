@@ -0,0 +1,51 @@
+package prunereport
+
+import (
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+)
+
+type ServiceTx struct {
+	dataservices.BaseDataServiceTx[portainer.PruneReport, portainer.PruneReportID]
+}
+
+// Create records a new report of a prune policy.
+func (service ServiceTx) Create(report *portainer.PruneReport) error {
+	return service.Tx.CreateObject(
+		BucketName,
+		func(id uint64) (int, any) {
+			report.ID = portainer.PruneReportID(id)
+
+			return int(report.ID), report
+		},
+	)
+}
+
+// ReportsByPolicyID returns the recorded reports of a prune policy.
+func (service ServiceTx) ReportsByPolicyID(policyID portainer.PrunePolicyID) ([]portainer.PruneReport, error) {
+	var reports = make([]portainer.PruneReport, 0)
+
+	return reports, service.Tx.GetAll(
+		BucketName,
+		&portainer.PruneReport{},
+		dataservices.FilterFn(&reports, func(e portainer.PruneReport) bool {
+			return e.PolicyID == policyID
+		}),
+	)
+}
+
+// DeleteByPolicyID deletes the recorded reports of a prune policy.
+func (service ServiceTx) DeleteByPolicyID(policyID portainer.PrunePolicyID) error {
+	reports, err := service.ReportsByPolicyID(policyID)
+	if err != nil {
+		return err
+	}
+
+	for _, report := range reports {
+		if err := service.Delete(report.ID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
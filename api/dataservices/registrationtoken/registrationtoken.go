@@ -0,0 +1,72 @@
+package registrationtoken
+
+import (
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+	dserrors "github.com/portainer/portainer/api/dataservices/errors"
+)
+
+// BucketName represents the name of the bucket where this service stores data.
+const BucketName = "registration_token"
+
+// Service represents a service for managing the registration tokens of the agents.
+type Service struct {
+	dataservices.BaseDataService[portainer.RegistrationToken, portainer.RegistrationTokenID]
+}
+
+// NewService creates a new instance of a service.
+func NewService(connection portainer.Connection) (*Service, error) {
+	if err := connection.SetServiceName(BucketName); err != nil {
+		return nil, err
+	}
+
+	return &Service{
+		BaseDataService: dataservices.BaseDataService[portainer.RegistrationToken, portainer.RegistrationTokenID]{
+			Bucket:     BucketName,
+			Connection: connection,
+		},
+	}, nil
+}
+
+func (service *Service) Tx(tx portainer.Transaction) ServiceTx {
+	return ServiceTx{
+		BaseDataServiceTx: dataservices.BaseDataServiceTx[portainer.RegistrationToken, portainer.RegistrationTokenID]{
+			Bucket:     BucketName,
+			Connection: service.Connection,
+			Tx:         tx,
+		},
+	}
+}
+
+// RegistrationTokenByDigest returns the registration token with the given digest.
+func (service *Service) RegistrationTokenByDigest(digest string) (*portainer.RegistrationToken, error) {
+	var tokens = make([]portainer.RegistrationToken, 0)
+
+	if err := service.Connection.GetAll(
+		BucketName,
+		&portainer.RegistrationToken{},
+		dataservices.FilterFn(&tokens, func(e portainer.RegistrationToken) bool {
+			return e.Digest == digest
+		}),
+	); err != nil {
+		return nil, err
+	}
+
+	if len(tokens) == 0 {
+		return nil, dserrors.ErrObjectNotFound
+	}
+
+	return &tokens[0], nil
+}
+
+// Create creates a new registration token.
+func (service *Service) Create(token *portainer.RegistrationToken) error {
+	return service.Connection.CreateObject(
+		BucketName,
+		func(id uint64) (int, any) {
+			token.ID = portainer.RegistrationTokenID(id)
+
+			return int(token.ID), token
+		},
+	)
+}
@@ -0,0 +1,44 @@
+package registrationtoken
+
+import (
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+	dserrors "github.com/portainer/portainer/api/dataservices/errors"
+)
+
+type ServiceTx struct {
+	dataservices.BaseDataServiceTx[portainer.RegistrationToken, portainer.RegistrationTokenID]
+}
+
+// RegistrationTokenByDigest returns the registration token with the given digest.
+func (service ServiceTx) RegistrationTokenByDigest(digest string) (*portainer.RegistrationToken, error) {
+	var tokens = make([]portainer.RegistrationToken, 0)
+
+	if err := service.Tx.GetAll(
+		BucketName,
+		&portainer.RegistrationToken{},
+		dataservices.FilterFn(&tokens, func(e portainer.RegistrationToken) bool {
+			return e.Digest == digest
+		}),
+	); err != nil {
+		return nil, err
+	}
+
+	if len(tokens) == 0 {
+		return nil, dserrors.ErrObjectNotFound
+	}
+
+	return &tokens[0], nil
+}
+
+// Create creates a new registration token.
+func (service ServiceTx) Create(token *portainer.RegistrationToken) error {
+	return service.Tx.CreateObject(
+		BucketName,
+		func(id uint64) (int, any) {
+			token.ID = portainer.RegistrationTokenID(id)
+
+			return int(token.ID), token
+		},
+	)
+}
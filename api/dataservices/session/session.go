@@ -0,0 +1,85 @@
+package session
+
+import (
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+	dserrors "github.com/portainer/portainer/api/dataservices/errors"
+)
+
+// BucketName represents the name of the bucket where this service stores data.
+const BucketName = "session"
+
+// Service represents a service for managing the sessions of the users.
+type Service struct {
+	dataservices.BaseDataService[portainer.Session, portainer.SessionID]
+}
+
+// NewService creates a new instance of a service.
+func NewService(connection portainer.Connection) (*Service, error) {
+	if err := connection.SetServiceName(BucketName); err != nil {
+		return nil, err
+	}
+
+	return &Service{
+		BaseDataService: dataservices.BaseDataService[portainer.Session, portainer.SessionID]{
+			Bucket:     BucketName,
+			Connection: connection,
+		},
+	}, nil
+}
+
+func (service *Service) Tx(tx portainer.Transaction) ServiceTx {
+	return ServiceTx{
+		BaseDataServiceTx: dataservices.BaseDataServiceTx[portainer.Session, portainer.SessionID]{
+			Bucket:     BucketName,
+			Connection: service.Connection,
+			Tx:         tx,
+		},
+	}
+}
+
+// SessionsByUserID returns the sessions opened by a user.
+func (service *Service) SessionsByUserID(userID portainer.UserID) ([]portainer.Session, error) {
+	var sessions = make([]portainer.Session, 0)
+
+	return sessions, service.Connection.GetAll(
+		BucketName,
+		&portainer.Session{},
+		dataservices.FilterFn(&sessions, func(e portainer.Session) bool {
+			return e.UserID == userID
+		}),
+	)
+}
+
+// SessionByTokenID returns the session of the JWT with the given identifier.
+func (service *Service) SessionByTokenID(tokenID string) (*portainer.Session, error) {
+	var sessions = make([]portainer.Session, 0)
+
+	if err := service.Connection.GetAll(
+		BucketName,
+		&portainer.Session{},
+		dataservices.FilterFn(&sessions, func(e portainer.Session) bool {
+			return e.TokenID == tokenID
+		}),
+	); err != nil {
+		return nil, err
+	}
+
+	if len(sessions) == 0 {
+		return nil, dserrors.ErrObjectNotFound
+	}
+
+	return &sessions[0], nil
+}
+
+// Create creates a new session.
+func (service *Service) Create(session *portainer.Session) error {
+	return service.Connection.CreateObject(
+		BucketName,
+		func(id uint64) (int, any) {
+			session.ID = portainer.SessionID(id)
+
+			return int(session.ID), session
+		},
+	)
+}
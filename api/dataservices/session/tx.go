@@ -0,0 +1,57 @@
+package session
+
+import (
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+	dserrors "github.com/portainer/portainer/api/dataservices/errors"
+)
+
+type ServiceTx struct {
+	dataservices.BaseDataServiceTx[portainer.Session, portainer.SessionID]
+}
+
+// SessionsByUserID returns the sessions opened by a user.
+func (service ServiceTx) SessionsByUserID(userID portainer.UserID) ([]portainer.Session, error) {
+	var sessions = make([]portainer.Session, 0)
+
+	return sessions, service.Tx.GetAll(
+		BucketName,
+		&portainer.Session{},
+		dataservices.FilterFn(&sessions, func(e portainer.Session) bool {
+			return e.UserID == userID
+		}),
+	)
+}
+
+// SessionByTokenID returns the session of the JWT with the given identifier.
+func (service ServiceTx) SessionByTokenID(tokenID string) (*portainer.Session, error) {
+	var sessions = make([]portainer.Session, 0)
+
+	if err := service.Tx.GetAll(
+		BucketName,
+		&portainer.Session{},
+		dataservices.FilterFn(&sessions, func(e portainer.Session) bool {
+			return e.TokenID == tokenID
+		}),
+	); err != nil {
+		return nil, err
+	}
+
+	if len(sessions) == 0 {
+		return nil, dserrors.ErrObjectNotFound
+	}
+
+	return &sessions[0], nil
+}
+
+// Create creates a new session.
+func (service ServiceTx) Create(session *portainer.Session) error {
+	return service.Tx.CreateObject(
+		BucketName,
+		func(id uint64) (int, any) {
+			session.ID = portainer.SessionID(id)
+
+			return int(session.ID), session
+		},
+	)
+}
@@ -0,0 +1,50 @@
+package sessionrecording
+
+import (
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+)
+
+// BucketName represents the name of the bucket where this service stores data.
+const BucketName = "session_recordings"
+
+// Service represents a service for managing the recordings of the console sessions.
+type Service struct {
+	dataservices.BaseDataService[portainer.SessionRecording, portainer.SessionRecordingID]
+}
+
+// NewService creates a new instance of a service.
+func NewService(connection portainer.Connection) (*Service, error) {
+	if err := connection.SetServiceName(BucketName); err != nil {
+		return nil, err
+	}
+
+	return &Service{
+		BaseDataService: dataservices.BaseDataService[portainer.SessionRecording, portainer.SessionRecordingID]{
+			Bucket:     BucketName,
+			Connection: connection,
+		},
+	}, nil
+}
+
+func (service *Service) Tx(tx portainer.Transaction) ServiceTx {
+	return ServiceTx{
+		BaseDataServiceTx: dataservices.BaseDataServiceTx[portainer.SessionRecording, portainer.SessionRecordingID]{
+			Bucket:     BucketName,
+			Connection: service.Connection,
+			Tx:         tx,
+		},
+	}
+}
+
+// Create records a new console session recording.
+func (service *Service) Create(recording *portainer.SessionRecording) error {
+	return service.Connection.CreateObject(
+		BucketName,
+		func(id uint64) (int, any) {
+			recording.ID = portainer.SessionRecordingID(id)
+
+			return int(recording.ID), recording
+		},
+	)
+}
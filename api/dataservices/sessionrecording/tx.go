@@ -0,0 +1,22 @@
+package sessionrecording
+
+import (
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+)
+
+type ServiceTx struct {
+	dataservices.BaseDataServiceTx[portainer.SessionRecording, portainer.SessionRecordingID]
+}
+
+// Create records a new console session recording.
+func (service ServiceTx) Create(recording *portainer.SessionRecording) error {
+	return service.Tx.CreateObject(
+		BucketName,
+		func(id uint64) (int, any) {
+			recording.ID = portainer.SessionRecordingID(id)
+
+			return int(recording.ID), recording
+		},
+	)
+}
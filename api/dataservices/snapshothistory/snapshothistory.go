@@ -0,0 +1,80 @@
+package snapshothistory
+
+import (
+	"slices"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+)
+
+// BucketName represents the name of the bucket where this service stores data.
+const BucketName = "snapshot_history"
+
+// Service represents a service for managing the most recent snapshots of the environments(endpoints).
+type Service struct {
+	dataservices.BaseDataService[portainer.SnapshotHistoryEntry, portainer.SnapshotHistoryID]
+}
+
+// NewService creates a new instance of a service.
+func NewService(connection portainer.Connection) (*Service, error) {
+	if err := connection.SetServiceName(BucketName); err != nil {
+		return nil, err
+	}
+
+	return &Service{
+		BaseDataService: dataservices.BaseDataService[portainer.SnapshotHistoryEntry, portainer.SnapshotHistoryID]{
+			Bucket:     BucketName,
+			Connection: connection,
+		},
+	}, nil
+}
+
+func (service *Service) Tx(tx portainer.Transaction) ServiceTx {
+	return ServiceTx{
+		BaseDataServiceTx: dataservices.BaseDataServiceTx[portainer.SnapshotHistoryEntry, portainer.SnapshotHistoryID]{
+			Bucket:     BucketName,
+			Connection: service.Connection,
+			Tx:         tx,
+		},
+	}
+}
+
+// EntriesByEndpointID returns the snapshots of an environment(endpoint), the most recent first.
+func (service *Service) EntriesByEndpointID(endpointID portainer.EndpointID) ([]portainer.SnapshotHistoryEntry, error) {
+	var entries = make([]portainer.SnapshotHistoryEntry, 0)
+
+	err := service.Connection.GetAll(
+		BucketName,
+		&portainer.SnapshotHistoryEntry{},
+		dataservices.FilterFn(&entries, func(e portainer.SnapshotHistoryEntry) bool {
+			return e.EndpointID == endpointID
+		}),
+	)
+
+	sortEntries(entries)
+
+	return entries, err
+}
+
+// Create adds a snapshot to the history of its environment(endpoint).
+func (service *Service) Create(entry *portainer.SnapshotHistoryEntry) error {
+	return service.Connection.CreateObject(
+		BucketName,
+		func(id uint64) (int, any) {
+			entry.ID = portainer.SnapshotHistoryID(id)
+
+			return int(entry.ID), entry
+		},
+	)
+}
+
+// sortEntries sorts the snapshots from the most recent to the oldest one
+func sortEntries(entries []portainer.SnapshotHistoryEntry) {
+	slices.SortFunc(entries, func(a, b portainer.SnapshotHistoryEntry) int {
+		if a.Time != b.Time {
+			return int(b.Time - a.Time)
+		}
+
+		return int(b.ID - a.ID)
+	})
+}
@@ -0,0 +1,39 @@
+package snapshothistory
+
+import (
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+)
+
+type ServiceTx struct {
+	dataservices.BaseDataServiceTx[portainer.SnapshotHistoryEntry, portainer.SnapshotHistoryID]
+}
+
+// EntriesByEndpointID returns the snapshots of an environment(endpoint), the most recent first.
+func (service ServiceTx) EntriesByEndpointID(endpointID portainer.EndpointID) ([]portainer.SnapshotHistoryEntry, error) {
+	var entries = make([]portainer.SnapshotHistoryEntry, 0)
+
+	err := service.Tx.GetAll(
+		BucketName,
+		&portainer.SnapshotHistoryEntry{},
+		dataservices.FilterFn(&entries, func(e portainer.SnapshotHistoryEntry) bool {
+			return e.EndpointID == endpointID
+		}),
+	)
+
+	sortEntries(entries)
+
+	return entries, err
+}
+
+// Create adds a snapshot to the history of its environment(endpoint).
+func (service ServiceTx) Create(entry *portainer.SnapshotHistoryEntry) error {
+	return service.Tx.CreateObject(
+		BucketName,
+		func(id uint64) (int, any) {
+			entry.ID = portainer.SnapshotHistoryID(id)
+
+			return int(entry.ID), entry
+		},
+	)
+}
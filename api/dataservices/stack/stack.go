@@ -116,7 +116,7 @@ func (service *Service) RefreshableStacks() ([]portainer.Stack, error) {
 		BucketName,
 		&portainer.Stack{},
 		dataservices.FilterFn(&stacks, func(e portainer.Stack) bool {
-			return e.AutoUpdate != nil && e.AutoUpdate.Interval != ""
+			return e.AutoUpdate != nil && e.AutoUpdate.Interval != "" && !e.AutoUpdate.Paused
 		}),
 	)
 }
@@ -0,0 +1,76 @@
+package stackversion
+
+import (
+	"slices"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+)
+
+// BucketName represents the name of the bucket where this service stores data.
+const BucketName = "stack_versions"
+
+// Service represents a service for managing the version history of the stacks.
+type Service struct {
+	dataservices.BaseDataService[portainer.StackVersion, portainer.StackVersionID]
+}
+
+// NewService creates a new instance of a service.
+func NewService(connection portainer.Connection) (*Service, error) {
+	if err := connection.SetServiceName(BucketName); err != nil {
+		return nil, err
+	}
+
+	return &Service{
+		BaseDataService: dataservices.BaseDataService[portainer.StackVersion, portainer.StackVersionID]{
+			Bucket:     BucketName,
+			Connection: connection,
+		},
+	}, nil
+}
+
+func (service *Service) Tx(tx portainer.Transaction) ServiceTx {
+	return ServiceTx{
+		BaseDataServiceTx: dataservices.BaseDataServiceTx[portainer.StackVersion, portainer.StackVersionID]{
+			Bucket:     BucketName,
+			Connection: service.Connection,
+			Tx:         tx,
+		},
+	}
+}
+
+// VersionsByStackID returns the versions of a stack, the most recent first.
+func (service *Service) VersionsByStackID(stackID portainer.StackID) ([]portainer.StackVersion, error) {
+	var versions = make([]portainer.StackVersion, 0)
+
+	err := service.Connection.GetAll(
+		BucketName,
+		&portainer.StackVersion{},
+		dataservices.FilterFn(&versions, func(e portainer.StackVersion) bool {
+			return e.StackID == stackID
+		}),
+	)
+
+	sortVersions(versions)
+
+	return versions, err
+}
+
+// Create adds a version to the history of its stack.
+func (service *Service) Create(version *portainer.StackVersion) error {
+	return service.Connection.CreateObject(
+		BucketName,
+		func(id uint64) (int, any) {
+			version.ID = portainer.StackVersionID(id)
+
+			return int(version.ID), version
+		},
+	)
+}
+
+// sortVersions sorts the versions from the most recent to the oldest one
+func sortVersions(versions []portainer.StackVersion) {
+	slices.SortFunc(versions, func(a, b portainer.StackVersion) int {
+		return b.Version - a.Version
+	})
+}
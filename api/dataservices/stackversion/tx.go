@@ -0,0 +1,39 @@
+package stackversion
+
+import (
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+)
+
+type ServiceTx struct {
+	dataservices.BaseDataServiceTx[portainer.StackVersion, portainer.StackVersionID]
+}
+
+// VersionsByStackID returns the versions of a stack, the most recent first.
+func (service ServiceTx) VersionsByStackID(stackID portainer.StackID) ([]portainer.StackVersion, error) {
+	var versions = make([]portainer.StackVersion, 0)
+
+	err := service.Tx.GetAll(
+		BucketName,
+		&portainer.StackVersion{},
+		dataservices.FilterFn(&versions, func(e portainer.StackVersion) bool {
+			return e.StackID == stackID
+		}),
+	)
+
+	sortVersions(versions)
+
+	return versions, err
+}
+
+// Create adds a version to the history of its stack.
+func (service ServiceTx) Create(version *portainer.StackVersion) error {
+	return service.Tx.CreateObject(
+		BucketName,
+		func(id uint64) (int, any) {
+			version.ID = portainer.StackVersionID(id)
+
+			return int(version.ID), version
+		},
+	)
+}
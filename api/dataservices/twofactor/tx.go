@@ -0,0 +1,15 @@
+package twofactor
+
+import (
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+)
+
+type ServiceTx struct {
+	dataservices.BaseDataServiceTx[portainer.TwoFactorConfiguration, portainer.UserID]
+}
+
+// Create creates the configuration of a user, it is stored under the identifier of the user.
+func (service ServiceTx) Create(configuration *portainer.TwoFactorConfiguration) error {
+	return service.Tx.CreateObjectWithId(BucketName, int(configuration.UserID), configuration)
+}
@@ -0,0 +1,59 @@
+package webauthncredential
+
+import (
+	"bytes"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+	dserrors "github.com/portainer/portainer/api/dataservices/errors"
+)
+
+type ServiceTx struct {
+	dataservices.BaseDataServiceTx[portainer.WebAuthnCredential, portainer.WebAuthnCredentialID]
+}
+
+// CredentialsByUserID returns the credentials registered by a user.
+func (service ServiceTx) CredentialsByUserID(userID portainer.UserID) ([]portainer.WebAuthnCredential, error) {
+	var credentials = make([]portainer.WebAuthnCredential, 0)
+
+	return credentials, service.Tx.GetAll(
+		BucketName,
+		&portainer.WebAuthnCredential{},
+		dataservices.FilterFn(&credentials, func(e portainer.WebAuthnCredential) bool {
+			return e.UserID == userID
+		}),
+	)
+}
+
+// CredentialByCredentialID returns the credential with the given identifier, as generated by the authenticator.
+func (service ServiceTx) CredentialByCredentialID(credentialID []byte) (*portainer.WebAuthnCredential, error) {
+	var credentials = make([]portainer.WebAuthnCredential, 0)
+
+	if err := service.Tx.GetAll(
+		BucketName,
+		&portainer.WebAuthnCredential{},
+		dataservices.FilterFn(&credentials, func(e portainer.WebAuthnCredential) bool {
+			return bytes.Equal(e.CredentialID, credentialID)
+		}),
+	); err != nil {
+		return nil, err
+	}
+
+	if len(credentials) == 0 {
+		return nil, dserrors.ErrObjectNotFound
+	}
+
+	return &credentials[0], nil
+}
+
+// Create creates a new credential.
+func (service ServiceTx) Create(credential *portainer.WebAuthnCredential) error {
+	return service.Tx.CreateObject(
+		BucketName,
+		func(id uint64) (int, any) {
+			credential.ID = portainer.WebAuthnCredentialID(id)
+
+			return int(credential.ID), credential
+		},
+	)
+}
@@ -0,0 +1,87 @@
+package webauthncredential
+
+import (
+	"bytes"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+	dserrors "github.com/portainer/portainer/api/dataservices/errors"
+)
+
+// BucketName represents the name of the bucket where this service stores data.
+const BucketName = "webauthn_credential"
+
+// Service represents a service for managing the WebAuthn credentials of the users.
+type Service struct {
+	dataservices.BaseDataService[portainer.WebAuthnCredential, portainer.WebAuthnCredentialID]
+}
+
+// NewService creates a new instance of a service.
+func NewService(connection portainer.Connection) (*Service, error) {
+	if err := connection.SetServiceName(BucketName); err != nil {
+		return nil, err
+	}
+
+	return &Service{
+		BaseDataService: dataservices.BaseDataService[portainer.WebAuthnCredential, portainer.WebAuthnCredentialID]{
+			Bucket:     BucketName,
+			Connection: connection,
+		},
+	}, nil
+}
+
+func (service *Service) Tx(tx portainer.Transaction) ServiceTx {
+	return ServiceTx{
+		BaseDataServiceTx: dataservices.BaseDataServiceTx[portainer.WebAuthnCredential, portainer.WebAuthnCredentialID]{
+			Bucket:     BucketName,
+			Connection: service.Connection,
+			Tx:         tx,
+		},
+	}
+}
+
+// CredentialsByUserID returns the credentials registered by a user.
+func (service *Service) CredentialsByUserID(userID portainer.UserID) ([]portainer.WebAuthnCredential, error) {
+	var credentials = make([]portainer.WebAuthnCredential, 0)
+
+	return credentials, service.Connection.GetAll(
+		BucketName,
+		&portainer.WebAuthnCredential{},
+		dataservices.FilterFn(&credentials, func(e portainer.WebAuthnCredential) bool {
+			return e.UserID == userID
+		}),
+	)
+}
+
+// CredentialByCredentialID returns the credential with the given identifier, as generated by the authenticator.
+func (service *Service) CredentialByCredentialID(credentialID []byte) (*portainer.WebAuthnCredential, error) {
+	var credentials = make([]portainer.WebAuthnCredential, 0)
+
+	if err := service.Connection.GetAll(
+		BucketName,
+		&portainer.WebAuthnCredential{},
+		dataservices.FilterFn(&credentials, func(e portainer.WebAuthnCredential) bool {
+			return bytes.Equal(e.CredentialID, credentialID)
+		}),
+	); err != nil {
+		return nil, err
+	}
+
+	if len(credentials) == 0 {
+		return nil, dserrors.ErrObjectNotFound
+	}
+
+	return &credentials[0], nil
+}
+
+// Create creates a new credential.
+func (service *Service) Create(credential *portainer.WebAuthnCredential) error {
+	return service.Connection.CreateObject(
+		BucketName,
+		func(id uint64) (int, any) {
+			credential.ID = portainer.WebAuthnCredentialID(id)
+
+			return int(credential.ID), credential
+		},
+	)
+}
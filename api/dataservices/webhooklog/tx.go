@@ -0,0 +1,51 @@
+package webhooklog
+
+import (
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+)
+
+type ServiceTx struct {
+	dataservices.BaseDataServiceTx[portainer.WebhookLog, portainer.WebhookLogID]
+}
+
+// Create records a new invocation of a webhook.
+func (service ServiceTx) Create(webhookLog *portainer.WebhookLog) error {
+	return service.Tx.CreateObject(
+		BucketName,
+		func(id uint64) (int, any) {
+			webhookLog.ID = portainer.WebhookLogID(id)
+
+			return int(webhookLog.ID), webhookLog
+		},
+	)
+}
+
+// WebhookLogsByWebhookID returns the recorded invocations of a webhook.
+func (service ServiceTx) WebhookLogsByWebhookID(webhookID portainer.WebhookID) ([]portainer.WebhookLog, error) {
+	var webhookLogs = make([]portainer.WebhookLog, 0)
+
+	return webhookLogs, service.Tx.GetAll(
+		BucketName,
+		&portainer.WebhookLog{},
+		dataservices.FilterFn(&webhookLogs, func(e portainer.WebhookLog) bool {
+			return e.WebhookID == webhookID
+		}),
+	)
+}
+
+// DeleteByWebhookID deletes the recorded invocations of a webhook.
+func (service ServiceTx) DeleteByWebhookID(webhookID portainer.WebhookID) error {
+	webhookLogs, err := service.WebhookLogsByWebhookID(webhookID)
+	if err != nil {
+		return err
+	}
+
+	for _, webhookLog := range webhookLogs {
+		if err := service.Delete(webhookLog.ID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
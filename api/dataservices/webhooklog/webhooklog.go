@@ -0,0 +1,70 @@
+package webhooklog
+
+import (
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+)
+
+// BucketName represents the name of the bucket where this service stores data.
+const BucketName = "webhook_log"
+
+// Service represents a service for recording the invocations of the webhooks.
+type Service struct {
+	dataservices.BaseDataService[portainer.WebhookLog, portainer.WebhookLogID]
+}
+
+// NewService creates a new instance of a service.
+func NewService(connection portainer.Connection) (*Service, error) {
+	if err := connection.SetServiceName(BucketName); err != nil {
+		return nil, err
+	}
+
+	return &Service{
+		BaseDataService: dataservices.BaseDataService[portainer.WebhookLog, portainer.WebhookLogID]{
+			Bucket:     BucketName,
+			Connection: connection,
+		},
+	}, nil
+}
+
+func (service *Service) Tx(tx portainer.Transaction) ServiceTx {
+	return ServiceTx{
+		BaseDataServiceTx: dataservices.BaseDataServiceTx[portainer.WebhookLog, portainer.WebhookLogID]{
+			Bucket:     BucketName,
+			Connection: service.Connection,
+			Tx:         tx,
+		},
+	}
+}
+
+// Create records a new invocation of a webhook.
+func (service *Service) Create(webhookLog *portainer.WebhookLog) error {
+	return service.Connection.CreateObject(
+		BucketName,
+		func(id uint64) (int, any) {
+			webhookLog.ID = portainer.WebhookLogID(id)
+
+			return int(webhookLog.ID), webhookLog
+		},
+	)
+}
+
+// WebhookLogsByWebhookID returns the recorded invocations of a webhook.
+func (service *Service) WebhookLogsByWebhookID(webhookID portainer.WebhookID) ([]portainer.WebhookLog, error) {
+	var webhookLogs = make([]portainer.WebhookLog, 0)
+
+	return webhookLogs, service.Connection.GetAll(
+		BucketName,
+		&portainer.WebhookLog{},
+		dataservices.FilterFn(&webhookLogs, func(e portainer.WebhookLog) bool {
+			return e.WebhookID == webhookID
+		}),
+	)
+}
+
+// DeleteByWebhookID deletes the recorded invocations of a webhook.
+func (service *Service) DeleteByWebhookID(webhookID portainer.WebhookID) error {
+	return service.Connection.UpdateTx(func(tx portainer.Transaction) error {
+		return service.Tx(tx).DeleteByWebhookID(webhookID)
+	})
+}
@@ -4,6 +4,7 @@ import (
 	"os"
 
 	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/internal/authorization"
 )
 
 // Init creates the default data set.
@@ -18,7 +19,12 @@ func (store *Store) Init() error {
 		return err
 	}
 
-	return store.checkOrCreateDefaultData()
+	err = store.checkOrCreateDefaultData()
+	if err != nil {
+		return err
+	}
+
+	return store.checkOrCreateDefaultRoles()
 }
 
 func (store *Store) checkOrCreateDefaultSettings() error {
@@ -37,6 +43,9 @@ func (store *Store) checkOrCreateDefaultSettings() error {
 			InternalAuthSettings: portainer.InternalAuthSettings{
 				RequiredPasswordLength: 12,
 			},
+			AuditLogSettings: portainer.AuditLogSettings{
+				RetentionDays: 90,
+			},
 			LDAPSettings: portainer.LDAPSettings{
 				AnonymousMode:   true,
 				AutoCreateUsers: true,
@@ -113,3 +122,25 @@ func (store *Store) checkOrCreateDefaultData() error {
 
 	return nil
 }
+
+// checkOrCreateDefaultRoles creates the default roles of a new instance, the roles of the existing instances are
+// updated by their migrations
+func (store *Store) checkOrCreateDefaultRoles() error {
+	roles, err := store.RoleService.ReadAll()
+	if err != nil || len(roles) > 0 {
+		return err
+	}
+
+	settings, err := store.SettingsService.Settings()
+	if err != nil {
+		return err
+	}
+
+	for _, role := range authorization.DefaultRoles(settings.AllowVolumeBrowserForRegularUsers) {
+		if err := store.RoleService.Update(role.ID, &role); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,31 @@
+package datastore
+
+import (
+	"testing"
+
+	"github.com/portainer/portainer/api/internal/authorization"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestInitKeepsRoles(t *testing.T) {
+	is := require.New(t)
+
+	_, store := MustNewTestStore(t, true, false)
+
+	roles, err := store.Role().ReadAll()
+	is.NoError(err)
+	is.Len(roles, len(authorization.DefaultRoles(false)))
+
+	role, err := store.Role().Read(authorization.HelpDeskRoleID)
+	is.NoError(err)
+
+	role.Priority = 42
+	is.NoError(store.Role().Update(role.ID, role))
+
+	is.NoError(store.Init())
+
+	role, err = store.Role().Read(authorization.HelpDeskRoleID)
+	is.NoError(err)
+	is.Equal(42, role.Priority)
+}
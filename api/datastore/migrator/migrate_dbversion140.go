@@ -0,0 +1,40 @@
+package migrator
+
+import (
+	"github.com/portainer/portainer/api/dataservices"
+	"github.com/portainer/portainer/api/internal/authorization"
+
+	"github.com/rs/zerolog/log"
+)
+
+// updateDefaultRolesForDB140 creates the operator role and grants the Kubernetes operations to the existing default
+// roles. The names and priorities of the existing roles are kept
+func (m *Migrator) updateDefaultRolesForDB140() error {
+	log.Info().Msg("updating the default roles")
+
+	settings, err := m.settingsService.Settings()
+	if err != nil {
+		return err
+	}
+
+	for _, defaultRole := range authorization.DefaultRoles(settings.AllowVolumeBrowserForRegularUsers) {
+		role, err := m.roleService.Read(defaultRole.ID)
+		if dataservices.IsErrObjectNotFound(err) {
+			if err := m.roleService.Update(defaultRole.ID, &defaultRole); err != nil {
+				return err
+			}
+
+			continue
+		} else if err != nil {
+			return err
+		}
+
+		role.Authorizations = defaultRole.Authorizations
+
+		if err := m.roleService.Update(role.ID, role); err != nil {
+			return err
+		}
+	}
+
+	return m.authorizationService.UpdateUsersAuthorizations()
+}
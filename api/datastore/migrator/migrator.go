@@ -241,6 +241,9 @@ func (m *Migrator) initMigrations() {
 	m.addMigrations("2.22.0",
 		m.migratePendingActionsDataForDB130,
 	)
+	m.addMigrations("2.25.0",
+		m.updateDefaultRolesForDB140,
+	)
 
 	// Add new migrations above...
 	// One function per migration, each versions migration funcs in the same file.
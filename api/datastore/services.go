@@ -8,32 +8,51 @@ import (
 	"github.com/portainer/portainer/api/database/models"
 	"github.com/portainer/portainer/api/dataservices"
 	"github.com/portainer/portainer/api/dataservices/apikeyrepository"
+	"github.com/portainer/portainer/api/dataservices/auditlog"
+	"github.com/portainer/portainer/api/dataservices/cloudcredential"
+	"github.com/portainer/portainer/api/dataservices/containerjob"
+	"github.com/portainer/portainer/api/dataservices/containerjobrun"
 	"github.com/portainer/portainer/api/dataservices/customtemplate"
+	"github.com/portainer/portainer/api/dataservices/dashboard"
 	"github.com/portainer/portainer/api/dataservices/dockerhub"
 	"github.com/portainer/portainer/api/dataservices/edgegroup"
 	"github.com/portainer/portainer/api/dataservices/edgejob"
+	"github.com/portainer/portainer/api/dataservices/edgejobexecution"
 	"github.com/portainer/portainer/api/dataservices/edgestack"
 	"github.com/portainer/portainer/api/dataservices/endpoint"
 	"github.com/portainer/portainer/api/dataservices/endpointgroup"
 	"github.com/portainer/portainer/api/dataservices/endpointrelation"
 	"github.com/portainer/portainer/api/dataservices/extension"
+	"github.com/portainer/portainer/api/dataservices/fleetstack"
 	"github.com/portainer/portainer/api/dataservices/helmuserrepository"
+	"github.com/portainer/portainer/api/dataservices/jwtsigningkey"
+	"github.com/portainer/portainer/api/dataservices/loginattempt"
 	"github.com/portainer/portainer/api/dataservices/pendingactions"
+	"github.com/portainer/portainer/api/dataservices/prunepolicy"
+	"github.com/portainer/portainer/api/dataservices/prunereport"
+	"github.com/portainer/portainer/api/dataservices/registrationtoken"
 	"github.com/portainer/portainer/api/dataservices/registry"
 	"github.com/portainer/portainer/api/dataservices/resourcecontrol"
 	"github.com/portainer/portainer/api/dataservices/role"
 	"github.com/portainer/portainer/api/dataservices/schedule"
+	"github.com/portainer/portainer/api/dataservices/session"
+	"github.com/portainer/portainer/api/dataservices/sessionrecording"
 	"github.com/portainer/portainer/api/dataservices/settings"
 	"github.com/portainer/portainer/api/dataservices/snapshot"
+	"github.com/portainer/portainer/api/dataservices/snapshothistory"
 	"github.com/portainer/portainer/api/dataservices/ssl"
 	"github.com/portainer/portainer/api/dataservices/stack"
+	"github.com/portainer/portainer/api/dataservices/stackversion"
 	"github.com/portainer/portainer/api/dataservices/tag"
 	"github.com/portainer/portainer/api/dataservices/team"
 	"github.com/portainer/portainer/api/dataservices/teammembership"
 	"github.com/portainer/portainer/api/dataservices/tunnelserver"
+	"github.com/portainer/portainer/api/dataservices/twofactor"
 	"github.com/portainer/portainer/api/dataservices/user"
 	"github.com/portainer/portainer/api/dataservices/version"
+	"github.com/portainer/portainer/api/dataservices/webauthncredential"
 	"github.com/portainer/portainer/api/dataservices/webhook"
+	"github.com/portainer/portainer/api/dataservices/webhooklog"
 
 	"github.com/rs/zerolog/log"
 	"github.com/segmentio/encoding/json"
@@ -46,32 +65,51 @@ type Store struct {
 	connection portainer.Connection
 
 	fileService               portainer.FileService
+	AuditLogService           *auditlog.Service
+	CloudCredentialService    *cloudcredential.Service
+	ContainerJobService       *containerjob.Service
+	ContainerJobRunService    *containerjobrun.Service
 	CustomTemplateService     *customtemplate.Service
+	DashboardService          *dashboard.Service
 	DockerHubService          *dockerhub.Service
 	EdgeGroupService          *edgegroup.Service
 	EdgeJobService            *edgejob.Service
+	EdgeJobExecutionService   *edgejobexecution.Service
 	EdgeStackService          *edgestack.Service
 	EndpointGroupService      *endpointgroup.Service
 	EndpointService           *endpoint.Service
 	EndpointRelationService   *endpointrelation.Service
 	ExtensionService          *extension.Service
+	FleetStackService         *fleetstack.Service
 	HelmUserRepositoryService *helmuserrepository.Service
+	JWTSigningKeyService      *jwtsigningkey.Service
+	LoginAttemptService       *loginattempt.Service
+	PrunePolicyService        *prunepolicy.Service
+	PruneReportService        *prunereport.Service
+	RegistrationTokenService  *registrationtoken.Service
 	RegistryService           *registry.Service
 	ResourceControlService    *resourcecontrol.Service
 	RoleService               *role.Service
 	APIKeyRepositoryService   *apikeyrepository.Service
 	ScheduleService           *schedule.Service
+	SessionService            *session.Service
+	SessionRecordingService   *sessionrecording.Service
 	SettingsService           *settings.Service
 	SnapshotService           *snapshot.Service
+	SnapshotHistoryService    *snapshothistory.Service
 	SSLSettingsService        *ssl.Service
 	StackService              *stack.Service
+	StackVersionService       *stackversion.Service
 	TagService                *tag.Service
 	TeamMembershipService     *teammembership.Service
 	TeamService               *team.Service
 	TunnelServerService       *tunnelserver.Service
+	TwoFactorService          *twofactor.Service
 	UserService               *user.Service
 	VersionService            *version.Service
+	WebAuthnCredentialService *webauthncredential.Service
 	WebhookService            *webhook.Service
+	WebhookLogService         *webhooklog.Service
 	PendingActionsService     *pendingactions.Service
 }
 
@@ -82,12 +120,42 @@ func (store *Store) initServices() error {
 	}
 	store.RoleService = authorizationsetService
 
+	auditLogService, err := auditlog.NewService(store.connection)
+	if err != nil {
+		return err
+	}
+	store.AuditLogService = auditLogService
+
+	cloudCredentialService, err := cloudcredential.NewService(store.connection)
+	if err != nil {
+		return err
+	}
+	store.CloudCredentialService = cloudCredentialService
+
+	containerJobService, err := containerjob.NewService(store.connection)
+	if err != nil {
+		return err
+	}
+	store.ContainerJobService = containerJobService
+
+	containerJobRunService, err := containerjobrun.NewService(store.connection)
+	if err != nil {
+		return err
+	}
+	store.ContainerJobRunService = containerJobRunService
+
 	customTemplateService, err := customtemplate.NewService(store.connection)
 	if err != nil {
 		return err
 	}
 	store.CustomTemplateService = customTemplateService
 
+	dashboardService, err := dashboard.NewService(store.connection)
+	if err != nil {
+		return err
+	}
+	store.DashboardService = dashboardService
+
 	dockerhubService, err := dockerhub.NewService(store.connection)
 	if err != nil {
 		return err
@@ -119,6 +187,12 @@ func (store *Store) initServices() error {
 	}
 	store.EdgeJobService = edgeJobService
 
+	edgeJobExecutionService, err := edgejobexecution.NewService(store.connection)
+	if err != nil {
+		return err
+	}
+	store.EdgeJobExecutionService = edgeJobExecutionService
+
 	endpointgroupService, err := endpointgroup.NewService(store.connection)
 	if err != nil {
 		return err
@@ -137,12 +211,36 @@ func (store *Store) initServices() error {
 	}
 	store.ExtensionService = extensionService
 
+	fleetStackService, err := fleetstack.NewService(store.connection)
+	if err != nil {
+		return err
+	}
+	store.FleetStackService = fleetStackService
+
 	helmUserRepositoryService, err := helmuserrepository.NewService(store.connection)
 	if err != nil {
 		return err
 	}
 	store.HelmUserRepositoryService = helmUserRepositoryService
 
+	jwtSigningKeyService, err := jwtsigningkey.NewService(store.connection)
+	if err != nil {
+		return err
+	}
+	store.JWTSigningKeyService = jwtSigningKeyService
+
+	loginAttemptService, err := loginattempt.NewService(store.connection)
+	if err != nil {
+		return err
+	}
+	store.LoginAttemptService = loginAttemptService
+
+	registrationTokenService, err := registrationtoken.NewService(store.connection)
+	if err != nil {
+		return err
+	}
+	store.RegistrationTokenService = registrationTokenService
+
 	registryService, err := registry.NewService(store.connection)
 	if err != nil {
 		return err
@@ -167,6 +265,12 @@ func (store *Store) initServices() error {
 	}
 	store.SnapshotService = snapshotService
 
+	snapshotHistoryService, err := snapshothistory.NewService(store.connection)
+	if err != nil {
+		return err
+	}
+	store.SnapshotHistoryService = snapshotHistoryService
+
 	sslSettingsService, err := ssl.NewService(store.connection)
 	if err != nil {
 		return err
@@ -179,6 +283,12 @@ func (store *Store) initServices() error {
 	}
 	store.StackService = stackService
 
+	stackVersionService, err := stackversion.NewService(store.connection)
+	if err != nil {
+		return err
+	}
+	store.StackVersionService = stackVersionService
+
 	tagService, err := tag.NewService(store.connection)
 	if err != nil {
 		return err
@@ -203,6 +313,12 @@ func (store *Store) initServices() error {
 	}
 	store.TunnelServerService = tunnelServerService
 
+	twoFactorService, err := twofactor.NewService(store.connection)
+	if err != nil {
+		return err
+	}
+	store.TwoFactorService = twoFactorService
+
 	userService, err := user.NewService(store.connection)
 	if err != nil {
 		return err
@@ -215,18 +331,54 @@ func (store *Store) initServices() error {
 	}
 	store.APIKeyRepositoryService = apiKeyService
 
+	sessionService, err := session.NewService(store.connection)
+	if err != nil {
+		return err
+	}
+	store.SessionService = sessionService
+
+	sessionRecordingService, err := sessionrecording.NewService(store.connection)
+	if err != nil {
+		return err
+	}
+	store.SessionRecordingService = sessionRecordingService
+
+	prunePolicyService, err := prunepolicy.NewService(store.connection)
+	if err != nil {
+		return err
+	}
+	store.PrunePolicyService = prunePolicyService
+
+	pruneReportService, err := prunereport.NewService(store.connection)
+	if err != nil {
+		return err
+	}
+	store.PruneReportService = pruneReportService
+
 	versionService, err := version.NewService(store.connection)
 	if err != nil {
 		return err
 	}
 	store.VersionService = versionService
 
+	webAuthnCredentialService, err := webauthncredential.NewService(store.connection)
+	if err != nil {
+		return err
+	}
+	store.WebAuthnCredentialService = webAuthnCredentialService
+
 	webhookService, err := webhook.NewService(store.connection)
 	if err != nil {
 		return err
 	}
 	store.WebhookService = webhookService
 
+	webhookLogService, err := webhooklog.NewService(store.connection)
+	if err != nil {
+		return err
+	}
+	store.WebhookLogService = webhookLogService
+
 	scheduleService, err := schedule.NewService(store.connection)
 	if err != nil {
 		return err
@@ -247,11 +399,36 @@ func (store *Store) PendingActions() dataservices.PendingActionsService {
 	return store.PendingActionsService
 }
 
+// AuditLog gives access to the AuditLog data management layer
+func (store *Store) AuditLog() dataservices.AuditLogService {
+	return store.AuditLogService
+}
+
+// CloudCredential gives access to the CloudCredential data management layer
+func (store *Store) CloudCredential() dataservices.CloudCredentialService {
+	return store.CloudCredentialService
+}
+
+// ContainerJob gives access to the ContainerJob data management layer
+func (store *Store) ContainerJob() dataservices.ContainerJobService {
+	return store.ContainerJobService
+}
+
+// ContainerJobRun gives access to the ContainerJobRun data management layer
+func (store *Store) ContainerJobRun() dataservices.ContainerJobRunService {
+	return store.ContainerJobRunService
+}
+
 // CustomTemplate gives access to the CustomTemplate data management layer
 func (store *Store) CustomTemplate() dataservices.CustomTemplateService {
 	return store.CustomTemplateService
 }
 
+// Dashboard gives access to the Dashboard data management layer
+func (store *Store) Dashboard() dataservices.DashboardService {
+	return store.DashboardService
+}
+
 // EdgeGroup gives access to the EdgeGroup data management layer
 func (store *Store) EdgeGroup() dataservices.EdgeGroupService {
 	return store.EdgeGroupService
@@ -262,6 +439,11 @@ func (store *Store) EdgeJob() dataservices.EdgeJobService {
 	return store.EdgeJobService
 }
 
+// EdgeJobExecution gives access to the EdgeJobExecution data management layer
+func (store *Store) EdgeJobExecution() dataservices.EdgeJobExecutionService {
+	return store.EdgeJobExecutionService
+}
+
 // EdgeStack gives access to the EdgeStack data management layer
 func (store *Store) EdgeStack() dataservices.EdgeStackService {
 	return store.EdgeStackService
@@ -282,11 +464,41 @@ func (store *Store) EndpointRelation() dataservices.EndpointRelationService {
 	return store.EndpointRelationService
 }
 
+// FleetStack gives access to the FleetStack data management layer
+func (store *Store) FleetStack() dataservices.FleetStackService {
+	return store.FleetStackService
+}
+
 // HelmUserRepository access the helm user repository settings
 func (store *Store) HelmUserRepository() dataservices.HelmUserRepositoryService {
 	return store.HelmUserRepositoryService
 }
 
+// JWTSigningKey gives access to the keys signing the JWTs of the user sessions
+func (store *Store) JWTSigningKey() dataservices.JWTSigningKeyService {
+	return store.JWTSigningKeyService
+}
+
+// LoginAttempt gives access to the LoginAttempt data management layer
+func (store *Store) LoginAttempt() dataservices.LoginAttemptService {
+	return store.LoginAttemptService
+}
+
+// PrunePolicy gives access to the PrunePolicy data management layer
+func (store *Store) PrunePolicy() dataservices.PrunePolicyService {
+	return store.PrunePolicyService
+}
+
+// PruneReport gives access to the PruneReport data management layer
+func (store *Store) PruneReport() dataservices.PruneReportService {
+	return store.PruneReportService
+}
+
+// RegistrationToken gives access to the RegistrationToken data management layer
+func (store *Store) RegistrationToken() dataservices.RegistrationTokenService {
+	return store.RegistrationTokenService
+}
+
 // Registry gives access to the Registry data management layer
 func (store *Store) Registry() dataservices.RegistryService {
 	return store.RegistryService
@@ -302,6 +514,16 @@ func (store *Store) Role() dataservices.RoleService {
 	return store.RoleService
 }
 
+// Session gives access to the Session data management layer
+func (store *Store) Session() dataservices.SessionService {
+	return store.SessionService
+}
+
+// SessionRecording gives access to the SessionRecording data management layer
+func (store *Store) SessionRecording() dataservices.SessionRecordingService {
+	return store.SessionRecordingService
+}
+
 // APIKeyRepository gives access to the api-key data management layer
 func (store *Store) APIKeyRepository() dataservices.APIKeyRepository {
 	return store.APIKeyRepositoryService
@@ -316,6 +538,11 @@ func (store *Store) Snapshot() dataservices.SnapshotService {
 	return store.SnapshotService
 }
 
+// SnapshotHistory gives access to the SnapshotHistory data management layer
+func (store *Store) SnapshotHistory() dataservices.SnapshotHistoryService {
+	return store.SnapshotHistoryService
+}
+
 // SSLSettings gives access to the SSL Settings data management layer
 func (store *Store) SSLSettings() dataservices.SSLSettingsService {
 	return store.SSLSettingsService
@@ -326,6 +553,11 @@ func (store *Store) Stack() dataservices.StackService {
 	return store.StackService
 }
 
+// StackVersion gives access to the StackVersion data management layer
+func (store *Store) StackVersion() dataservices.StackVersionService {
+	return store.StackVersionService
+}
+
 // Tag gives access to the Tag data management layer
 func (store *Store) Tag() dataservices.TagService {
 	return store.TagService
@@ -346,6 +578,11 @@ func (store *Store) TunnelServer() dataservices.TunnelServerService {
 	return store.TunnelServerService
 }
 
+// TwoFactor gives access to the TwoFactor data management layer
+func (store *Store) TwoFactor() dataservices.TwoFactorService {
+	return store.TwoFactorService
+}
+
 // User gives access to the User data management layer
 func (store *Store) User() dataservices.UserService {
 	return store.UserService
@@ -356,42 +593,103 @@ func (store *Store) Version() dataservices.VersionService {
 	return store.VersionService
 }
 
+// WebAuthnCredential gives access to the WebAuthnCredential data management layer
+func (store *Store) WebAuthnCredential() dataservices.WebAuthnCredentialService {
+	return store.WebAuthnCredentialService
+}
+
 // Webhook gives access to the Webhook data management layer
 func (store *Store) Webhook() dataservices.WebhookService {
 	return store.WebhookService
 }
 
+// WebhookLog gives access to the WebhookLog data management layer
+func (store *Store) WebhookLog() dataservices.WebhookLogService {
+	return store.WebhookLogService
+}
+
 type storeExport struct {
-	CustomTemplate     []portainer.CustomTemplate     `json:"customtemplates,omitempty"`
-	EdgeGroup          []portainer.EdgeGroup          `json:"edgegroups,omitempty"`
-	EdgeJob            []portainer.EdgeJob            `json:"edgejobs,omitempty"`
-	EdgeStack          []portainer.EdgeStack          `json:"edge_stack,omitempty"`
-	Endpoint           []portainer.Endpoint           `json:"endpoints,omitempty"`
-	EndpointGroup      []portainer.EndpointGroup      `json:"endpoint_groups,omitempty"`
-	EndpointRelation   []portainer.EndpointRelation   `json:"endpoint_relations,omitempty"`
-	Extensions         []portainer.Extension          `json:"extension,omitempty"`
-	HelmUserRepository []portainer.HelmUserRepository `json:"helm_user_repository,omitempty"`
-	Registry           []portainer.Registry           `json:"registries,omitempty"`
-	ResourceControl    []portainer.ResourceControl    `json:"resource_control,omitempty"`
-	Role               []portainer.Role               `json:"roles,omitempty"`
-	Schedules          []portainer.Schedule           `json:"schedules,omitempty"`
-	Settings           portainer.Settings             `json:"settings,omitempty"`
-	Snapshot           []portainer.Snapshot           `json:"snapshots,omitempty"`
-	SSLSettings        portainer.SSLSettings          `json:"ssl,omitempty"`
-	Stack              []portainer.Stack              `json:"stacks,omitempty"`
-	Tag                []portainer.Tag                `json:"tags,omitempty"`
-	TeamMembership     []portainer.TeamMembership     `json:"team_membership,omitempty"`
-	Team               []portainer.Team               `json:"teams,omitempty"`
-	TunnelServer       portainer.TunnelServerInfo     `json:"tunnel_server,omitempty"`
-	User               []portainer.User               `json:"users,omitempty"`
-	Version            models.Version                 `json:"version,omitempty"`
-	Webhook            []portainer.Webhook            `json:"webhooks,omitempty"`
-	Metadata           map[string]any                 `json:"metadata,omitempty"`
+	AuditLog           []portainer.AuditLog               `json:"audit_log,omitempty"`
+	CloudCredential    []portainer.CloudCredential        `json:"cloud_credentials,omitempty"`
+	ContainerJob       []portainer.ContainerJob           `json:"container_jobs,omitempty"`
+	ContainerJobRun    []portainer.ContainerJobRun        `json:"container_job_runs,omitempty"`
+	CustomTemplate     []portainer.CustomTemplate         `json:"customtemplates,omitempty"`
+	Dashboard          []portainer.Dashboard              `json:"dashboards,omitempty"`
+	EdgeGroup          []portainer.EdgeGroup              `json:"edgegroups,omitempty"`
+	EdgeJob            []portainer.EdgeJob                `json:"edgejobs,omitempty"`
+	EdgeJobExecution   []portainer.EdgeJobExecution       `json:"edge_job_executions,omitempty"`
+	EdgeStack          []portainer.EdgeStack              `json:"edge_stack,omitempty"`
+	Endpoint           []portainer.Endpoint               `json:"endpoints,omitempty"`
+	EndpointGroup      []portainer.EndpointGroup          `json:"endpoint_groups,omitempty"`
+	EndpointRelation   []portainer.EndpointRelation       `json:"endpoint_relations,omitempty"`
+	Extensions         []portainer.Extension              `json:"extension,omitempty"`
+	FleetStack         []portainer.FleetStack             `json:"fleet_stacks,omitempty"`
+	HelmUserRepository []portainer.HelmUserRepository     `json:"helm_user_repository,omitempty"`
+	JWTSigningKeys     []portainer.JWTSigningKey          `json:"jwt_signing_keys,omitempty"`
+	LoginAttempt       []portainer.LoginAttempt           `json:"login_attempts,omitempty"`
+	PrunePolicy        []portainer.PrunePolicy            `json:"prune_policies,omitempty"`
+	PruneReport        []portainer.PruneReport            `json:"prune_reports,omitempty"`
+	RegistrationToken  []portainer.RegistrationToken      `json:"registration_tokens,omitempty"`
+	Registry           []portainer.Registry               `json:"registries,omitempty"`
+	ResourceControl    []portainer.ResourceControl        `json:"resource_control,omitempty"`
+	Role               []portainer.Role                   `json:"roles,omitempty"`
+	Schedules          []portainer.Schedule               `json:"schedules,omitempty"`
+	Session            []portainer.Session                `json:"sessions,omitempty"`
+	SessionRecording   []portainer.SessionRecording       `json:"session_recordings,omitempty"`
+	Settings           portainer.Settings                 `json:"settings,omitempty"`
+	Snapshot           []portainer.Snapshot               `json:"snapshots,omitempty"`
+	SnapshotHistory    []portainer.SnapshotHistoryEntry   `json:"snapshot_history,omitempty"`
+	SSLSettings        portainer.SSLSettings              `json:"ssl,omitempty"`
+	Stack              []portainer.Stack                  `json:"stacks,omitempty"`
+	StackVersion       []portainer.StackVersion           `json:"stack_versions,omitempty"`
+	Tag                []portainer.Tag                    `json:"tags,omitempty"`
+	TeamMembership     []portainer.TeamMembership         `json:"team_membership,omitempty"`
+	Team               []portainer.Team                   `json:"teams,omitempty"`
+	TunnelServer       portainer.TunnelServerInfo         `json:"tunnel_server,omitempty"`
+	TwoFactor          []portainer.TwoFactorConfiguration `json:"two_factor,omitempty"`
+	User               []portainer.User                   `json:"users,omitempty"`
+	Version            models.Version                     `json:"version,omitempty"`
+	WebAuthnCredential []portainer.WebAuthnCredential     `json:"webauthn_credential,omitempty"`
+	Webhook            []portainer.Webhook                `json:"webhooks,omitempty"`
+	WebhookLog         []portainer.WebhookLog             `json:"webhook_logs,omitempty"`
+	Metadata           map[string]any                     `json:"metadata,omitempty"`
 }
 
 func (store *Store) Export(filename string) (err error) {
 	backup := storeExport{}
 
+	if a, err := store.AuditLog().ReadAll(); err != nil {
+		if !store.IsErrObjectNotFound(err) {
+			log.Error().Err(err).Msg("exporting Audit Logs")
+		}
+	} else {
+		backup.AuditLog = a
+	}
+
+	if c, err := store.CloudCredential().ReadAll(); err != nil {
+		if !store.IsErrObjectNotFound(err) {
+			log.Error().Err(err).Msg("exporting Cloud Credentials")
+		}
+	} else {
+		backup.CloudCredential = c
+	}
+
+	if c, err := store.ContainerJob().ReadAll(); err != nil {
+		if !store.IsErrObjectNotFound(err) {
+			log.Error().Err(err).Msg("exporting Container Jobs")
+		}
+	} else {
+		backup.ContainerJob = c
+	}
+
+	if c, err := store.ContainerJobRun().ReadAll(); err != nil {
+		if !store.IsErrObjectNotFound(err) {
+			log.Error().Err(err).Msg("exporting Container Job Runs")
+		}
+	} else {
+		backup.ContainerJobRun = c
+	}
+
 	if c, err := store.CustomTemplate().ReadAll(); err != nil {
 		if !store.IsErrObjectNotFound(err) {
 			log.Error().Err(err).Msg("exporting Custom Templates")
@@ -400,6 +698,14 @@ func (store *Store) Export(filename string) (err error) {
 		backup.CustomTemplate = c
 	}
 
+	if d, err := store.Dashboard().ReadAll(); err != nil {
+		if !store.IsErrObjectNotFound(err) {
+			log.Error().Err(err).Msg("exporting Dashboards")
+		}
+	} else {
+		backup.Dashboard = d
+	}
+
 	if e, err := store.EdgeGroup().ReadAll(); err != nil {
 		if !store.IsErrObjectNotFound(err) {
 			log.Error().Err(err).Msg("exporting Edge Groups")
@@ -416,6 +722,14 @@ func (store *Store) Export(filename string) (err error) {
 		backup.EdgeJob = e
 	}
 
+	if e, err := store.EdgeJobExecution().ReadAll(); err != nil {
+		if !store.IsErrObjectNotFound(err) {
+			log.Error().Err(err).Msg("exporting Edge Job Executions")
+		}
+	} else {
+		backup.EdgeJobExecution = e
+	}
+
 	if e, err := store.EdgeStack().EdgeStacks(); err != nil {
 		if !store.IsErrObjectNotFound(err) {
 			log.Error().Err(err).Msg("exporting Edge Stacks")
@@ -456,6 +770,14 @@ func (store *Store) Export(filename string) (err error) {
 		backup.Extensions = r
 	}
 
+	if r, err := store.FleetStack().ReadAll(); err != nil {
+		if !store.IsErrObjectNotFound(err) {
+			log.Error().Err(err).Msg("exporting Fleet Stacks")
+		}
+	} else {
+		backup.FleetStack = r
+	}
+
 	if r, err := store.HelmUserRepository().ReadAll(); err != nil {
 		if !store.IsErrObjectNotFound(err) {
 			log.Error().Err(err).Msg("exporting Helm User Repositories")
@@ -464,6 +786,30 @@ func (store *Store) Export(filename string) (err error) {
 		backup.HelmUserRepository = r
 	}
 
+	if keys, err := store.JWTSigningKey().Keys(); err != nil {
+		if !store.IsErrObjectNotFound(err) {
+			log.Error().Err(err).Msg("exporting JWT Signing Keys")
+		}
+	} else {
+		backup.JWTSigningKeys = keys
+	}
+
+	if r, err := store.LoginAttempt().ReadAll(); err != nil {
+		if !store.IsErrObjectNotFound(err) {
+			log.Error().Err(err).Msg("exporting Login Attempts")
+		}
+	} else {
+		backup.LoginAttempt = r
+	}
+
+	if r, err := store.RegistrationToken().ReadAll(); err != nil {
+		if !store.IsErrObjectNotFound(err) {
+			log.Error().Err(err).Msg("exporting Registration Tokens")
+		}
+	} else {
+		backup.RegistrationToken = r
+	}
+
 	if r, err := store.Registry().ReadAll(); err != nil {
 		if !store.IsErrObjectNotFound(err) {
 			log.Error().Err(err).Msg("exporting Registries")
@@ -496,6 +842,38 @@ func (store *Store) Export(filename string) (err error) {
 		backup.Schedules = r
 	}
 
+	if sessions, err := store.Session().ReadAll(); err != nil {
+		if !store.IsErrObjectNotFound(err) {
+			log.Error().Err(err).Msg("exporting Sessions")
+		}
+	} else {
+		backup.Session = sessions
+	}
+
+	if recordings, err := store.SessionRecording().ReadAll(); err != nil {
+		if !store.IsErrObjectNotFound(err) {
+			log.Error().Err(err).Msg("exporting Session Recordings")
+		}
+	} else {
+		backup.SessionRecording = recordings
+	}
+
+	if policies, err := store.PrunePolicy().ReadAll(); err != nil {
+		if !store.IsErrObjectNotFound(err) {
+			log.Error().Err(err).Msg("exporting Prune Policies")
+		}
+	} else {
+		backup.PrunePolicy = policies
+	}
+
+	if reports, err := store.PruneReport().ReadAll(); err != nil {
+		if !store.IsErrObjectNotFound(err) {
+			log.Error().Err(err).Msg("exporting Prune Reports")
+		}
+	} else {
+		backup.PruneReport = reports
+	}
+
 	if settings, err := store.Settings().Settings(); err != nil {
 		if !store.IsErrObjectNotFound(err) {
 			log.Error().Err(err).Msg("exporting Settings")
@@ -512,6 +890,14 @@ func (store *Store) Export(filename string) (err error) {
 		backup.Snapshot = snapshot
 	}
 
+	if r, err := store.SnapshotHistory().ReadAll(); err != nil {
+		if !store.IsErrObjectNotFound(err) {
+			log.Error().Err(err).Msg("exporting Snapshot History")
+		}
+	} else {
+		backup.SnapshotHistory = r
+	}
+
 	if settings, err := store.SSLSettings().Settings(); err != nil {
 		if !store.IsErrObjectNotFound(err) {
 			log.Error().Err(err).Msg("exporting SSL Settings")
@@ -528,6 +914,14 @@ func (store *Store) Export(filename string) (err error) {
 		backup.Stack = t
 	}
 
+	if v, err := store.StackVersion().ReadAll(); err != nil {
+		if !store.IsErrObjectNotFound(err) {
+			log.Error().Err(err).Msg("exporting Stack Versions")
+		}
+	} else {
+		backup.StackVersion = v
+	}
+
 	if t, err := store.Tag().ReadAll(); err != nil {
 		if !store.IsErrObjectNotFound(err) {
 			log.Error().Err(err).Msg("exporting Tags")
@@ -560,6 +954,14 @@ func (store *Store) Export(filename string) (err error) {
 		backup.TunnelServer = *info
 	}
 
+	if twoFactor, err := store.TwoFactor().ReadAll(); err != nil {
+		if !store.IsErrObjectNotFound(err) {
+			log.Error().Err(err).Msg("exporting Two Factor")
+		}
+	} else {
+		backup.TwoFactor = twoFactor
+	}
+
 	if users, err := store.User().ReadAll(); err != nil {
 		if !store.IsErrObjectNotFound(err) {
 			log.Error().Err(err).Msg("exporting Users")
@@ -568,6 +970,14 @@ func (store *Store) Export(filename string) (err error) {
 		backup.User = users
 	}
 
+	if credentials, err := store.WebAuthnCredential().ReadAll(); err != nil {
+		if !store.IsErrObjectNotFound(err) {
+			log.Error().Err(err).Msg("exporting WebAuthn credentials")
+		}
+	} else {
+		backup.WebAuthnCredential = credentials
+	}
+
 	if webhooks, err := store.Webhook().ReadAll(); err != nil {
 		if !store.IsErrObjectNotFound(err) {
 			log.Error().Err(err).Msg("exporting Webhooks")
@@ -576,6 +986,14 @@ func (store *Store) Export(filename string) (err error) {
 		backup.Webhook = webhooks
 	}
 
+	if webhookLogs, err := store.WebhookLog().ReadAll(); err != nil {
+		if !store.IsErrObjectNotFound(err) {
+			log.Error().Err(err).Msg("exporting Webhook Logs")
+		}
+	} else {
+		backup.WebhookLog = webhookLogs
+	}
+
 	if version, err := store.Version().Version(); err != nil {
 		if !store.IsErrObjectNotFound(err) {
 			log.Error().Err(err).Msg("exporting Version")
@@ -611,10 +1029,30 @@ func (store *Store) Import(filename string) (err error) {
 
 	store.Version().UpdateVersion(&backup.Version)
 
+	for _, v := range backup.AuditLog {
+		store.AuditLog().Update(v.ID, &v)
+	}
+
+	for _, v := range backup.CloudCredential {
+		store.CloudCredential().Update(v.ID, &v)
+	}
+
+	for _, v := range backup.ContainerJob {
+		store.ContainerJob().Update(v.ID, &v)
+	}
+
+	for _, v := range backup.ContainerJobRun {
+		store.ContainerJobRun().Update(v.ID, &v)
+	}
+
 	for _, v := range backup.CustomTemplate {
 		store.CustomTemplate().Update(v.ID, &v)
 	}
 
+	for _, v := range backup.Dashboard {
+		store.Dashboard().Update(v.ID, &v)
+	}
+
 	for _, v := range backup.EdgeGroup {
 		store.EdgeGroup().Update(v.ID, &v)
 	}
@@ -623,6 +1061,10 @@ func (store *Store) Import(filename string) (err error) {
 		store.EdgeJob().Update(v.ID, &v)
 	}
 
+	for _, v := range backup.EdgeJobExecution {
+		store.EdgeJobExecution().Update(v.ID, &v)
+	}
+
 	for _, v := range backup.EdgeStack {
 		store.EdgeStack().UpdateEdgeStack(v.ID, &v)
 	}
@@ -639,10 +1081,34 @@ func (store *Store) Import(filename string) (err error) {
 		store.EndpointRelation().UpdateEndpointRelation(v.EndpointID, &v)
 	}
 
+	for _, v := range backup.FleetStack {
+		store.FleetStack().Update(v.ID, &v)
+	}
+
 	for _, v := range backup.HelmUserRepository {
 		store.HelmUserRepository().Update(v.ID, &v)
 	}
 
+	if len(backup.JWTSigningKeys) > 0 {
+		store.JWTSigningKey().UpdateKeys(backup.JWTSigningKeys)
+	}
+
+	for _, v := range backup.LoginAttempt {
+		store.LoginAttempt().Update(v.ID, &v)
+	}
+
+	for _, v := range backup.PrunePolicy {
+		store.PrunePolicy().Update(v.ID, &v)
+	}
+
+	for _, v := range backup.PruneReport {
+		store.PruneReport().Update(v.ID, &v)
+	}
+
+	for _, v := range backup.RegistrationToken {
+		store.RegistrationToken().Update(v.ID, &v)
+	}
+
 	for _, v := range backup.Registry {
 		store.Registry().Update(v.ID, &v)
 	}
@@ -655,6 +1121,14 @@ func (store *Store) Import(filename string) (err error) {
 		store.Role().Update(v.ID, &v)
 	}
 
+	for _, v := range backup.Session {
+		store.Session().Update(v.ID, &v)
+	}
+
+	for _, v := range backup.SessionRecording {
+		store.SessionRecording().Update(v.ID, &v)
+	}
+
 	store.Settings().UpdateSettings(&backup.Settings)
 	store.SSLSettings().UpdateSettings(&backup.SSLSettings)
 
@@ -662,10 +1136,18 @@ func (store *Store) Import(filename string) (err error) {
 		store.Snapshot().Update(v.EndpointID, &v)
 	}
 
+	for _, v := range backup.SnapshotHistory {
+		store.SnapshotHistory().Update(v.ID, &v)
+	}
+
 	for _, v := range backup.Stack {
 		store.Stack().Update(v.ID, &v)
 	}
 
+	for _, v := range backup.StackVersion {
+		store.StackVersion().Update(v.ID, &v)
+	}
+
 	for _, v := range backup.Tag {
 		store.Tag().Update(v.ID, &v)
 	}
@@ -680,15 +1162,27 @@ func (store *Store) Import(filename string) (err error) {
 
 	store.TunnelServer().UpdateInfo(&backup.TunnelServer)
 
+	for _, v := range backup.TwoFactor {
+		store.TwoFactor().Update(v.UserID, &v)
+	}
+
 	for _, user := range backup.User {
 		if err := store.User().Update(user.ID, &user); err != nil {
 			log.Debug().Str("user", fmt.Sprintf("%+v", user)).Err(err).Msg("failed to update the user in the database")
 		}
 	}
 
+	for _, v := range backup.WebAuthnCredential {
+		store.WebAuthnCredential().Update(v.ID, &v)
+	}
+
 	for _, v := range backup.Webhook {
 		store.Webhook().Update(v.ID, &v)
 	}
 
+	for _, v := range backup.WebhookLog {
+		store.WebhookLog().Update(v.ID, &v)
+	}
+
 	return store.connection.RestoreMetadata(backup.Metadata)
 }
@@ -14,8 +14,28 @@ func (tx *StoreTx) IsErrObjectNotFound(err error) bool {
 	return tx.store.IsErrObjectNotFound(err)
 }
 
+func (tx *StoreTx) AuditLog() dataservices.AuditLogService {
+	return tx.store.AuditLogService.Tx(tx.tx)
+}
+
+func (tx *StoreTx) CloudCredential() dataservices.CloudCredentialService {
+	return tx.store.CloudCredentialService.Tx(tx.tx)
+}
+
+func (tx *StoreTx) ContainerJob() dataservices.ContainerJobService {
+	return tx.store.ContainerJobService.Tx(tx.tx)
+}
+
+func (tx *StoreTx) ContainerJobRun() dataservices.ContainerJobRunService {
+	return tx.store.ContainerJobRunService.Tx(tx.tx)
+}
+
 func (tx *StoreTx) CustomTemplate() dataservices.CustomTemplateService { return nil }
 
+func (tx *StoreTx) Dashboard() dataservices.DashboardService {
+	return tx.store.DashboardService.Tx(tx.tx)
+}
+
 func (tx *StoreTx) PendingActions() dataservices.PendingActionsService {
 	return tx.store.PendingActionsService.Tx(tx.tx)
 }
@@ -28,6 +48,10 @@ func (tx *StoreTx) EdgeJob() dataservices.EdgeJobService {
 	return tx.store.EdgeJobService.Tx(tx.tx)
 }
 
+func (tx *StoreTx) EdgeJobExecution() dataservices.EdgeJobExecutionService {
+	return tx.store.EdgeJobExecutionService.Tx(tx.tx)
+}
+
 func (tx *StoreTx) EdgeStack() dataservices.EdgeStackService {
 	return tx.store.EdgeStackService.Tx(tx.tx)
 }
@@ -44,6 +68,10 @@ func (tx *StoreTx) EndpointRelation() dataservices.EndpointRelationService {
 	return tx.store.EndpointRelationService.Tx(tx.tx)
 }
 
+func (tx *StoreTx) FleetStack() dataservices.FleetStackService {
+	return tx.store.FleetStackService.Tx(tx.tx)
+}
+
 func (tx *StoreTx) HelmUserRepository() dataservices.HelmUserRepositoryService { return nil }
 
 func (tx *StoreTx) Registry() dataservices.RegistryService {
@@ -58,6 +86,34 @@ func (tx *StoreTx) Role() dataservices.RoleService {
 	return tx.store.RoleService.Tx(tx.tx)
 }
 
+func (tx *StoreTx) JWTSigningKey() dataservices.JWTSigningKeyService {
+	return tx.store.JWTSigningKeyService.Tx(tx.tx)
+}
+
+func (tx *StoreTx) LoginAttempt() dataservices.LoginAttemptService {
+	return tx.store.LoginAttemptService.Tx(tx.tx)
+}
+
+func (tx *StoreTx) PrunePolicy() dataservices.PrunePolicyService {
+	return tx.store.PrunePolicyService.Tx(tx.tx)
+}
+
+func (tx *StoreTx) PruneReport() dataservices.PruneReportService {
+	return tx.store.PruneReportService.Tx(tx.tx)
+}
+
+func (tx *StoreTx) RegistrationToken() dataservices.RegistrationTokenService {
+	return tx.store.RegistrationTokenService.Tx(tx.tx)
+}
+
+func (tx *StoreTx) Session() dataservices.SessionService {
+	return tx.store.SessionService.Tx(tx.tx)
+}
+
+func (tx *StoreTx) SessionRecording() dataservices.SessionRecordingService {
+	return tx.store.SessionRecordingService.Tx(tx.tx)
+}
+
 func (tx *StoreTx) APIKeyRepository() dataservices.APIKeyRepository { return nil }
 
 func (tx *StoreTx) Settings() dataservices.SettingsService {
@@ -68,12 +124,20 @@ func (tx *StoreTx) Snapshot() dataservices.SnapshotService {
 	return tx.store.SnapshotService.Tx(tx.tx)
 }
 
+func (tx *StoreTx) SnapshotHistory() dataservices.SnapshotHistoryService {
+	return tx.store.SnapshotHistoryService.Tx(tx.tx)
+}
+
 func (tx *StoreTx) SSLSettings() dataservices.SSLSettingsService { return nil }
 
 func (tx *StoreTx) Stack() dataservices.StackService {
 	return tx.store.StackService.Tx(tx.tx)
 }
 
+func (tx *StoreTx) StackVersion() dataservices.StackVersionService {
+	return tx.store.StackVersionService.Tx(tx.tx)
+}
+
 func (tx *StoreTx) Tag() dataservices.TagService {
 	return tx.store.TagService.Tx(tx.tx)
 }
@@ -88,9 +152,22 @@ func (tx *StoreTx) Team() dataservices.TeamService {
 
 func (tx *StoreTx) TunnelServer() dataservices.TunnelServerService { return nil }
 
+func (tx *StoreTx) TwoFactor() dataservices.TwoFactorService {
+	return tx.store.TwoFactorService.Tx(tx.tx)
+}
+
 func (tx *StoreTx) User() dataservices.UserService {
 	return tx.store.UserService.Tx(tx.tx)
 }
 
 func (tx *StoreTx) Version() dataservices.VersionService { return nil }
+
+func (tx *StoreTx) WebAuthnCredential() dataservices.WebAuthnCredentialService {
+	return tx.store.WebAuthnCredentialService.Tx(tx.tx)
+}
+
 func (tx *StoreTx) Webhook() dataservices.WebhookService { return nil }
+
+func (tx *StoreTx) WebhookLog() dataservices.WebhookLogService {
+	return tx.store.WebhookLogService.Tx(tx.tx)
+}
@@ -0,0 +1,261 @@
+// Package devicecode implements the device authorization grant (RFC 8628), which lets scripts and command line
+// tools obtain a token once a user approved the request from a browser where they are already logged in
+package devicecode
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"strings"
+	"sync"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// DefaultExpiry is the lifetime of a device authorization
+	DefaultExpiry = 10 * time.Minute
+	// DefaultInterval is the minimum time the devices must wait between two polls
+	DefaultInterval = 5 * time.Second
+
+	// slowDownIncrement is added to the interval of a device polling too fast
+	slowDownIncrement = 5 * time.Second
+	// maxPendingAuthorizations bounds the memory used by the pending authorizations
+	maxPendingAuthorizations = 1000
+
+	// userCodeCharset excludes the vowels to avoid forming words, and the characters that are easily confused
+	userCodeCharset = "BCDFGHJKLMNPQRSTVWXZ"
+	userCodeLength  = 8
+)
+
+var (
+	// ErrAuthorizationPending is returned while the user has not approved or denied the authorization yet
+	ErrAuthorizationPending = errors.New("authorization_pending")
+	// ErrSlowDown is returned when the device polls more often than the interval allows
+	ErrSlowDown = errors.New("slow_down")
+	// ErrAccessDenied is returned when the user denied the authorization
+	ErrAccessDenied = errors.New("access_denied")
+	// ErrExpiredToken is returned when the authorization expired or is unknown
+	ErrExpiredToken = errors.New("expired_token")
+	// ErrInvalidUserCode is returned when no pending authorization matches the user code
+	ErrInvalidUserCode = errors.New("invalid or expired user code")
+	// ErrTooManyRequests is returned when too many authorizations are pending
+	ErrTooManyRequests = errors.New("too many pending device authorizations")
+)
+
+// Authorization represents a pending device authorization
+type Authorization struct {
+	DeviceCode string
+	UserCode   string
+	ExpiresAt  time.Time
+	Interval   time.Duration
+}
+
+type pendingAuthorization struct {
+	Authorization
+	lastPoll time.Time
+	approved bool
+	denied   bool
+	userID   portainer.UserID
+}
+
+// Service holds the pending device authorizations in memory
+type Service struct {
+	mu sync.Mutex
+	// authorizations are indexed by device code
+	authorizations map[string]*pendingAuthorization
+	// userCodes maps the user codes to the device codes
+	userCodes map[string]string
+	now       func() time.Time
+}
+
+// NewService returns a pointer to a new instance of this service
+func NewService() *Service {
+	return &Service{
+		authorizations: map[string]*pendingAuthorization{},
+		userCodes:      map[string]string{},
+		now:            time.Now,
+	}
+}
+
+// Create starts a new device authorization
+func (service *Service) Create() (*Authorization, error) {
+	service.mu.Lock()
+	defer service.mu.Unlock()
+
+	now := service.now()
+	service.purge(now)
+
+	if len(service.authorizations) >= maxPendingAuthorizations {
+		return nil, ErrTooManyRequests
+	}
+
+	deviceCode, err := newDeviceCode()
+	if err != nil {
+		return nil, err
+	}
+
+	var userCode string
+	for {
+		if userCode, err = newUserCode(); err != nil {
+			return nil, err
+		}
+
+		if _, exists := service.userCodes[userCode]; !exists {
+			break
+		}
+	}
+
+	authorization := &pendingAuthorization{
+		Authorization: Authorization{
+			DeviceCode: deviceCode,
+			UserCode:   FormatUserCode(userCode),
+			ExpiresAt:  now.Add(DefaultExpiry),
+			Interval:   DefaultInterval,
+		},
+	}
+
+	service.authorizations[deviceCode] = authorization
+	service.userCodes[userCode] = deviceCode
+
+	result := authorization.Authorization
+
+	return &result, nil
+}
+
+// Approve grants the authorization matching the user code to the user
+func (service *Service) Approve(userCode string, userID portainer.UserID) error {
+	return service.resolve(userCode, func(authorization *pendingAuthorization) {
+		authorization.approved = true
+		authorization.userID = userID
+	})
+}
+
+// Deny rejects the authorization matching the user code
+func (service *Service) Deny(userCode string) error {
+	return service.resolve(userCode, func(authorization *pendingAuthorization) {
+		authorization.denied = true
+	})
+}
+
+func (service *Service) resolve(userCode string, apply func(authorization *pendingAuthorization)) error {
+	service.mu.Lock()
+	defer service.mu.Unlock()
+
+	service.purge(service.now())
+
+	code := normalizeUserCode(userCode)
+
+	deviceCode, ok := service.userCodes[code]
+	if !ok {
+		return ErrInvalidUserCode
+	}
+
+	// a user code can only be used once
+	delete(service.userCodes, code)
+
+	apply(service.authorizations[deviceCode])
+
+	return nil
+}
+
+// Poll returns the user who approved the authorization. The authorization is consumed once it was approved or denied
+func (service *Service) Poll(deviceCode string) (portainer.UserID, error) {
+	service.mu.Lock()
+	defer service.mu.Unlock()
+
+	now := service.now()
+	service.purge(now)
+
+	authorization, ok := service.authorizations[deviceCode]
+	if !ok {
+		return 0, ErrExpiredToken
+	}
+
+	switch {
+	case authorization.denied:
+		delete(service.authorizations, deviceCode)
+
+		return 0, ErrAccessDenied
+	case authorization.approved:
+		delete(service.authorizations, deviceCode)
+
+		return authorization.userID, nil
+	}
+
+	if !authorization.lastPoll.IsZero() && now.Sub(authorization.lastPoll) < authorization.Interval {
+		authorization.lastPoll = now
+		authorization.Interval += slowDownIncrement
+
+		return 0, ErrSlowDown
+	}
+
+	authorization.lastPoll = now
+
+	return 0, ErrAuthorizationPending
+}
+
+// purge removes the expired authorizations, the caller must hold the lock
+func (service *Service) purge(now time.Time) {
+	for deviceCode, authorization := range service.authorizations {
+		if now.Before(authorization.ExpiresAt) {
+			continue
+		}
+
+		delete(service.authorizations, deviceCode)
+		delete(service.userCodes, normalizeUserCode(authorization.UserCode))
+	}
+}
+
+// FormatUserCode splits the user code in two groups to make it easier to read and type
+func FormatUserCode(code string) string {
+	code = normalizeUserCode(code)
+	if len(code) != userCodeLength {
+		return code
+	}
+
+	return code[:userCodeLength/2] + "-" + code[userCodeLength/2:]
+}
+
+// normalizeUserCode makes the user input case insensitive and ignores the separators
+func normalizeUserCode(code string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '-' || r == ' ' {
+			return -1
+		}
+
+		return r
+	}, strings.ToUpper(code))
+}
+
+func newDeviceCode() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", errors.Wrap(err, "unable to generate the device code")
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func newUserCode() (string, error) {
+	// the random bytes above the largest multiple of the charset size are discarded to avoid a modulo bias
+	limit := 256 - 256%len(userCodeCharset)
+
+	code := make([]byte, 0, userCodeLength)
+	b := make([]byte, userCodeLength)
+	for len(code) < userCodeLength {
+		if _, err := rand.Read(b); err != nil {
+			return "", errors.Wrap(err, "unable to generate the user code")
+		}
+
+		for _, v := range b {
+			if int(v) < limit && len(code) < userCodeLength {
+				code = append(code, userCodeCharset[int(v)%len(userCodeCharset)])
+			}
+		}
+	}
+
+	return string(code), nil
+}
@@ -0,0 +1,107 @@
+package devicecode
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestService() (*Service, *time.Time) {
+	now := time.Now()
+	service := NewService()
+	service.now = func() time.Time { return now }
+
+	return service, &now
+}
+
+func TestDeviceAuthorizationApproved(t *testing.T) {
+	service, now := newTestService()
+
+	authorization, err := service.Create()
+	require.NoError(t, err)
+	assert.Len(t, authorization.UserCode, userCodeLength+1)
+	assert.Equal(t, DefaultInterval, authorization.Interval)
+
+	_, err = service.Poll(authorization.DeviceCode)
+	require.ErrorIs(t, err, ErrAuthorizationPending)
+
+	// the user code is case insensitive and the separator is optional
+	userCode := strings.ToLower(strings.ReplaceAll(authorization.UserCode, "-", ""))
+	require.NoError(t, service.Approve(userCode, 7))
+
+	// a user code can only be used once
+	require.ErrorIs(t, service.Approve(authorization.UserCode, 8), ErrInvalidUserCode)
+
+	*now = now.Add(DefaultInterval)
+	userID, err := service.Poll(authorization.DeviceCode)
+	require.NoError(t, err)
+	assert.Equal(t, portainer.UserID(7), userID)
+
+	// the authorization is consumed
+	_, err = service.Poll(authorization.DeviceCode)
+	require.ErrorIs(t, err, ErrExpiredToken)
+}
+
+func TestDeviceAuthorizationDenied(t *testing.T) {
+	service, _ := newTestService()
+
+	authorization, err := service.Create()
+	require.NoError(t, err)
+
+	require.NoError(t, service.Deny(authorization.UserCode))
+
+	_, err = service.Poll(authorization.DeviceCode)
+	require.ErrorIs(t, err, ErrAccessDenied)
+}
+
+func TestDeviceAuthorizationSlowDown(t *testing.T) {
+	service, now := newTestService()
+
+	authorization, err := service.Create()
+	require.NoError(t, err)
+
+	_, err = service.Poll(authorization.DeviceCode)
+	require.ErrorIs(t, err, ErrAuthorizationPending)
+
+	*now = now.Add(time.Second)
+	_, err = service.Poll(authorization.DeviceCode)
+	require.ErrorIs(t, err, ErrSlowDown)
+
+	// the interval was increased
+	*now = now.Add(DefaultInterval)
+	_, err = service.Poll(authorization.DeviceCode)
+	require.ErrorIs(t, err, ErrSlowDown)
+
+	*now = now.Add(DefaultInterval + slowDownIncrement*2)
+	_, err = service.Poll(authorization.DeviceCode)
+	require.ErrorIs(t, err, ErrAuthorizationPending)
+}
+
+func TestDeviceAuthorizationExpired(t *testing.T) {
+	service, now := newTestService()
+
+	authorization, err := service.Create()
+	require.NoError(t, err)
+
+	*now = now.Add(DefaultExpiry)
+
+	require.ErrorIs(t, service.Approve(authorization.UserCode, 1), ErrInvalidUserCode)
+
+	_, err = service.Poll(authorization.DeviceCode)
+	require.ErrorIs(t, err, ErrExpiredToken)
+}
+
+func TestNewUserCode(t *testing.T) {
+	code, err := newUserCode()
+	require.NoError(t, err)
+	require.Len(t, code, userCodeLength)
+
+	for _, c := range code {
+		assert.Contains(t, userCodeCharset, string(c))
+	}
+}
@@ -11,6 +11,8 @@ import (
 
 	portainer "github.com/portainer/portainer/api"
 	"github.com/portainer/portainer/api/crypto"
+	"github.com/portainer/portainer/api/docker/volumefiles"
+	"github.com/portainer/portainer/pkg/snapshot"
 
 	"github.com/docker/docker/api/types/image"
 	"github.com/docker/docker/client"
@@ -66,6 +68,76 @@ func (factory *ClientFactory) CreateClient(endpoint *portainer.Endpoint, nodeNam
 	return createTCPClient(endpoint, timeout)
 }
 
+// CreateLibpodClient returns a client of the libpod API of a Podman environment(endpoint), reached the same way as its
+// Docker compatible API by the given Docker client
+func (factory *ClientFactory) CreateLibpodClient(cli *client.Client, endpoint *portainer.Endpoint) (*snapshot.LibpodClient, error) {
+	scheme := "http"
+	if endpoint.TLSConfig.TLS {
+		scheme = "https"
+	}
+
+	var headers map[string]string
+	if isAgentEndpoint(endpoint) {
+		var err error
+		if headers, err = factory.agentHeaders(); err != nil {
+			return nil, err
+		}
+	}
+
+	return snapshot.NewLibpodClient(cli, scheme, headers)
+}
+
+// CreateVolumeFilesClient returns a client of the file browser API of the agent of an environment(endpoint), reached
+// the same way as its Docker API by the given Docker client. The nodeName parameter targets a specific node in an
+// agent cluster
+func (factory *ClientFactory) CreateVolumeFilesClient(cli *client.Client, endpoint *portainer.Endpoint, nodeName string) (*volumefiles.Client, error) {
+	if !isAgentEndpoint(endpoint) {
+		return nil, errUnsupportedEnvironmentType
+	}
+
+	headers, err := factory.agentHeaders()
+	if err != nil {
+		return nil, err
+	}
+
+	if nodeName != "" {
+		headers[portainer.PortainerAgentTargetHeader] = nodeName
+	}
+
+	hostURL, err := client.ParseHostURL(cli.DaemonHost())
+	if err != nil {
+		return nil, err
+	}
+
+	scheme := "http"
+	if endpoint.TLSConfig.TLS {
+		scheme = "https"
+	}
+
+	return &volumefiles.Client{
+		HTTPClient: cli.HTTPClient(),
+		URL:        scheme + "://" + hostURL.Host + hostURL.Path,
+		Headers:    headers,
+	}, nil
+}
+
+// agentHeaders returns the headers signing the requests sent to the agents
+func (factory *ClientFactory) agentHeaders() (map[string]string, error) {
+	signature, err := factory.signatureService.CreateSignature(portainer.PortainerAgentSignatureMessage)
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]string{
+		portainer.PortainerAgentPublicKeyHeader: factory.signatureService.EncodedPublicKey(),
+		portainer.PortainerAgentSignatureHeader: signature,
+	}, nil
+}
+
+func isAgentEndpoint(endpoint *portainer.Endpoint) bool {
+	return endpoint.Type == portainer.AgentOnDockerEnvironment || endpoint.Type == portainer.EdgeAgentOnDockerEnvironment
+}
+
 func createLocalClient(endpoint *portainer.Endpoint) (*client.Client, error) {
 	return client.NewClientWithOpts(
 		client.WithHost(endpoint.URL),
@@ -4,6 +4,8 @@ const (
 	ComposeStackNameLabel = "com.docker.compose.project"
 	SwarmStackNameLabel   = "com.docker.stack.namespace"
 	SwarmServiceIDLabel   = "com.docker.swarm.service.id"
+	SwarmServiceNameLabel = "com.docker.swarm.service.name"
 	SwarmNodeIDLabel      = "com.docker.swarm.node.id"
 	HideStackLabel        = "io.portainer.hideStack"
+	SystemContainerLabel  = "io.portainer.system"
 )
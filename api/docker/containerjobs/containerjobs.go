@@ -0,0 +1,190 @@
+// Package containerjobs runs the container jobs, containers run on a cron schedule on the regular Docker
+// environments(endpoints), and records the outcome and the output of each run
+package containerjobs
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"sync"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/image"
+	"github.com/docker/docker/api/types/network"
+	"github.com/docker/docker/pkg/stdcopy"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/pkg/errors"
+	"github.com/robfig/cron/v3"
+)
+
+const (
+	// DefaultTimeout is the duration in seconds after which a run is stopped when the job does not define one
+	DefaultTimeout = 3600
+	// JobIDLabel is the label holding the identifier of the job on the containers of its runs
+	JobIDLabel = "io.portainer.containerjob.id"
+	// RunIDLabel is the label holding the identifier of the run on its container
+	RunIDLabel = "io.portainer.containerjob.run"
+	// maxLogsSize is the size of the end of the output of a container kept with its run
+	maxLogsSize = 64 * 1024
+	// cleanupTimeout bounds the collection of the output and the removal of the container of a run
+	cleanupTimeout = time.Minute
+)
+
+// Client is the part of the Docker client used to run a container job
+type Client interface {
+	ImagePull(ctx context.Context, refStr string, options image.PullOptions) (io.ReadCloser, error)
+	ContainerCreate(ctx context.Context, config *container.Config, hostConfig *container.HostConfig, networkingConfig *network.NetworkingConfig, platform *ocispec.Platform, containerName string) (container.CreateResponse, error)
+	ContainerStart(ctx context.Context, containerID string, options container.StartOptions) error
+	ContainerWait(ctx context.Context, containerID string, condition container.WaitCondition) (<-chan container.WaitResponse, <-chan error)
+	ContainerLogs(ctx context.Context, container string, options container.LogsOptions) (io.ReadCloser, error)
+	ContainerRemove(ctx context.Context, containerID string, options container.RemoveOptions) error
+}
+
+// ParseSchedule parses the standard cron expression of a job
+func ParseSchedule(cronExpression string) (cron.Schedule, error) {
+	return cron.ParseStandard(cronExpression)
+}
+
+// Execute pulls the image of a job, runs its container until it exits or the context is done and removes it. The run
+// is updated with the outcome and the end of the output of the container
+func Execute(ctx context.Context, cli Client, job *portainer.ContainerJob, registryAuth string, run *portainer.ContainerJobRun) {
+	if err := execute(ctx, cli, job, registryAuth, run); err != nil {
+		run.Status = portainer.ContainerJobRunFailed
+		run.Error = err.Error()
+	} else if run.ExitCode != 0 {
+		run.Status = portainer.ContainerJobRunFailed
+		run.Error = fmt.Sprintf("the container exited with code %d", run.ExitCode)
+	} else {
+		run.Status = portainer.ContainerJobRunSucceeded
+	}
+
+	run.FinishedAt = time.Now().Unix()
+}
+
+func execute(ctx context.Context, cli Client, job *portainer.ContainerJob, registryAuth string, run *portainer.ContainerJobRun) error {
+	// the image is run from the local one when it cannot be pulled, e.g. when it was built on the environment
+	pullErr := pull(ctx, cli, job.Image, registryAuth)
+
+	env := make([]string, 0, len(job.Env))
+	for _, pair := range job.Env {
+		env = append(env, pair.Name+"="+pair.Value)
+	}
+
+	created, err := cli.ContainerCreate(ctx, &container.Config{
+		Image: job.Image,
+		Cmd:   job.Command,
+		Env:   env,
+		Labels: map[string]string{
+			JobIDLabel: strconv.Itoa(int(job.ID)),
+			RunIDLabel: strconv.Itoa(int(run.ID)),
+		},
+	}, &container.HostConfig{}, nil, nil, fmt.Sprintf("portainer-job-%d-%d", job.ID, run.ID))
+	if err != nil {
+		if pullErr != nil {
+			return errors.Wrapf(err, "unable to create the container, the image could not be pulled: %s", pullErr)
+		}
+
+		return errors.Wrap(err, "unable to create the container")
+	}
+
+	// the output is collected and the container removed even when the run timed out
+	cleanupCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), cleanupTimeout)
+	defer cancel()
+
+	defer cli.ContainerRemove(cleanupCtx, created.ID, container.RemoveOptions{Force: true})
+	defer func() {
+		run.Logs = logs(cleanupCtx, cli, created.ID)
+	}()
+
+	waitCh, errCh := cli.ContainerWait(ctx, created.ID, container.WaitConditionNextExit)
+
+	if err := cli.ContainerStart(ctx, created.ID, container.StartOptions{}); err != nil {
+		return errors.Wrap(err, "unable to start the container")
+	}
+
+	select {
+	case result := <-waitCh:
+		if result.Error != nil {
+			return errors.New(result.Error.Message)
+		}
+
+		run.ExitCode = int(result.StatusCode)
+
+		return nil
+	case err := <-errCh:
+		if ctx.Err() != nil {
+			return timeoutError(job)
+		}
+
+		return errors.Wrap(err, "unable to wait for the container")
+	case <-ctx.Done():
+		return timeoutError(job)
+	}
+}
+
+func pull(ctx context.Context, cli Client, imageName, registryAuth string) error {
+	out, err := cli.ImagePull(ctx, imageName, image.PullOptions{RegistryAuth: registryAuth})
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	_, err = io.Copy(io.Discard, out)
+
+	return err
+}
+
+// logs returns the end of the output of a container
+func logs(ctx context.Context, cli Client, containerID string) string {
+	out, err := cli.ContainerLogs(ctx, containerID, container.LogsOptions{ShowStdout: true, ShowStderr: true})
+	if err != nil {
+		return ""
+	}
+	defer out.Close()
+
+	buffer := &tailBuffer{}
+	stdcopy.StdCopy(buffer, buffer, out)
+
+	return buffer.String()
+}
+
+func timeoutError(job *portainer.ContainerJob) error {
+	return fmt.Errorf("the container did not exit within %d seconds and was stopped", timeout(job))
+}
+
+func timeout(job *portainer.ContainerJob) int {
+	if job.Timeout <= 0 {
+		return DefaultTimeout
+	}
+
+	return job.Timeout
+}
+
+// tailBuffer keeps the end of what is written to it
+type tailBuffer struct {
+	mu  sync.Mutex
+	buf []byte
+}
+
+func (buffer *tailBuffer) Write(p []byte) (int, error) {
+	buffer.mu.Lock()
+	defer buffer.mu.Unlock()
+
+	buffer.buf = append(buffer.buf, p...)
+	if len(buffer.buf) > maxLogsSize {
+		buffer.buf = buffer.buf[len(buffer.buf)-maxLogsSize:]
+	}
+
+	return len(p), nil
+}
+
+func (buffer *tailBuffer) String() string {
+	buffer.mu.Lock()
+	defer buffer.mu.Unlock()
+
+	return string(buffer.buf)
+}
@@ -0,0 +1,157 @@
+package containerjobs
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/docker/quarantine"
+	"github.com/portainer/portainer/api/internal/immutable"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/image"
+	"github.com/docker/docker/api/types/network"
+	"github.com/docker/docker/pkg/stdcopy"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/stretchr/testify/require"
+)
+
+type testClient struct {
+	pullErr  error
+	exitCode int64
+	// the container never exits when set
+	hang   bool
+	output string
+
+	config  *container.Config
+	name    string
+	removed []string
+}
+
+func (c *testClient) ImagePull(ctx context.Context, refStr string, options image.PullOptions) (io.ReadCloser, error) {
+	if c.pullErr != nil {
+		return nil, c.pullErr
+	}
+
+	return io.NopCloser(strings.NewReader("{}")), nil
+}
+
+func (c *testClient) ContainerCreate(ctx context.Context, config *container.Config, hostConfig *container.HostConfig, networkingConfig *network.NetworkingConfig, platform *ocispec.Platform, containerName string) (container.CreateResponse, error) {
+	c.config = config
+	c.name = containerName
+
+	return container.CreateResponse{ID: "abc"}, nil
+}
+
+func (c *testClient) ContainerStart(ctx context.Context, containerID string, options container.StartOptions) error {
+	return nil
+}
+
+func (c *testClient) ContainerWait(ctx context.Context, containerID string, condition container.WaitCondition) (<-chan container.WaitResponse, <-chan error) {
+	waitCh := make(chan container.WaitResponse, 1)
+	errCh := make(chan error, 1)
+
+	if c.hang {
+		go func() {
+			<-ctx.Done()
+			errCh <- ctx.Err()
+		}()
+	} else {
+		waitCh <- container.WaitResponse{StatusCode: c.exitCode}
+	}
+
+	return waitCh, errCh
+}
+
+func (c *testClient) ContainerLogs(ctx context.Context, container string, options container.LogsOptions) (io.ReadCloser, error) {
+	buf := &bytes.Buffer{}
+	stdcopy.NewStdWriter(buf, stdcopy.Stdout).Write([]byte(c.output))
+
+	return io.NopCloser(buf), nil
+}
+
+func (c *testClient) ContainerRemove(ctx context.Context, containerID string, options container.RemoveOptions) error {
+	c.removed = append(c.removed, containerID)
+
+	return nil
+}
+
+func TestExecute(t *testing.T) {
+	is := require.New(t)
+
+	job := &portainer.ContainerJob{
+		ID:      3,
+		Image:   "alpine:3.20",
+		Command: []string{"echo", "hello"},
+		Env:     []portainer.Pair{{Name: "LEVEL", Value: "debug"}},
+	}
+
+	// the local image is run when the image cannot be pulled
+	cli := &testClient{pullErr: errors.New("no network"), output: "hello\n"}
+	run := &portainer.ContainerJobRun{ID: 7}
+	Execute(context.Background(), cli, job, "", run)
+
+	is.Equal(portainer.ContainerJobRunSucceeded, run.Status)
+	is.Equal("hello\n", run.Logs)
+	is.NotZero(run.FinishedAt)
+	is.Equal("portainer-job-3-7", cli.name)
+	is.Equal([]string{"LEVEL=debug"}, cli.config.Env)
+	is.Equal("3", cli.config.Labels[JobIDLabel])
+	is.Equal([]string{"abc"}, cli.removed)
+
+	cli = &testClient{exitCode: 2, output: "boom\n"}
+	run = &portainer.ContainerJobRun{ID: 8}
+	Execute(context.Background(), cli, job, "", run)
+
+	is.Equal(portainer.ContainerJobRunFailed, run.Status)
+	is.Equal(2, run.ExitCode)
+	is.Contains(run.Error, "exited with code 2")
+	is.Equal("boom\n", run.Logs)
+
+	// the container is removed when the run times out
+	cli = &testClient{hang: true}
+	run = &portainer.ContainerJobRun{ID: 9}
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	Execute(ctx, cli, job, "", run)
+
+	is.Equal(portainer.ContainerJobRunFailed, run.Status)
+	is.Contains(run.Error, "did not exit within 3600 seconds")
+	is.Equal([]string{"abc"}, cli.removed)
+}
+
+func TestTailBuffer(t *testing.T) {
+	is := require.New(t)
+
+	buffer := &tailBuffer{}
+	buffer.Write(bytes.Repeat([]byte("a"), maxLogsSize))
+	buffer.Write([]byte("end"))
+
+	is.Len(buffer.String(), maxLogsSize)
+	is.True(strings.HasSuffix(buffer.String(), "aend"))
+}
+
+func TestCheckEndpoint(t *testing.T) {
+	is := require.New(t)
+
+	endpoint := &portainer.Endpoint{ID: 1, Type: portainer.DockerEnvironment}
+	is.NoError(CheckEndpoint(endpoint))
+
+	is.ErrorIs(CheckEndpoint(&portainer.Endpoint{ID: 2, Type: portainer.EdgeAgentOnDockerEnvironment}), ErrUnsupportedEndpoint)
+	is.ErrorIs(CheckEndpoint(&portainer.Endpoint{ID: 3, Type: portainer.KubernetesLocalEnvironment}), ErrUnsupportedEndpoint)
+
+	endpoint.Immutable = true
+	is.ErrorIs(CheckEndpoint(endpoint), immutable.ErrImmutable)
+
+	// the jobs run again during a break-glass access
+	endpoint.BreakGlass = &portainer.EndpointBreakGlass{ExpiresAt: time.Now().Add(time.Hour).Unix()}
+	is.NoError(CheckEndpoint(endpoint))
+
+	endpoint.Quarantine = &portainer.EndpointQuarantine{Action: "stop"}
+	is.ErrorIs(CheckEndpoint(endpoint), quarantine.ErrQuarantined)
+}
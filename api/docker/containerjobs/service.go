@@ -0,0 +1,264 @@
+package containerjobs
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+	dockerclient "github.com/portainer/portainer/api/docker/client"
+	"github.com/portainer/portainer/api/docker/images"
+	"github.com/portainer/portainer/api/docker/quarantine"
+	"github.com/portainer/portainer/api/internal/endpointutils"
+	"github.com/portainer/portainer/api/internal/immutable"
+	"github.com/portainer/portainer/api/scheduler"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+)
+
+var (
+	// ErrUnsupportedEndpoint is returned when a job targets an environment(endpoint) which is not a regular Docker
+	// environment, the Edge environments running the Edge jobs instead
+	ErrUnsupportedEndpoint = errors.New("the container jobs can only run on the regular Docker environments")
+	// errInterrupted is recorded on the runs interrupted by a restart of Portainer
+	errInterrupted = errors.New("the run was interrupted by a restart of Portainer")
+)
+
+// runKey identifies the runs of a job on an environment(endpoint), which never overlap
+type runKey struct {
+	jobID      portainer.ContainerJobID
+	endpointID portainer.EndpointID
+}
+
+// Service schedules the container jobs and runs them on their environments(endpoints)
+type Service struct {
+	dataStore      dataservices.DataStore
+	clientFactory  *dockerclient.ClientFactory
+	scheduler      *scheduler.Scheduler
+	registryClient *images.RegistryClient
+
+	mu        sync.Mutex
+	schedules map[portainer.ContainerJobID]string
+	running   map[runKey]bool
+}
+
+// NewService creates a new container job service
+func NewService(dataStore dataservices.DataStore, clientFactory *dockerclient.ClientFactory, scheduler *scheduler.Scheduler) *Service {
+	return &Service{
+		dataStore:      dataStore,
+		clientFactory:  clientFactory,
+		scheduler:      scheduler,
+		registryClient: images.NewRegistryClient(dataStore),
+		schedules:      map[portainer.ContainerJobID]string{},
+		running:        map[runKey]bool{},
+	}
+}
+
+// Start marks the runs interrupted by the last restart as failed and schedules the jobs
+func (service *Service) Start() error {
+	if err := service.dataStore.UpdateTx(func(tx dataservices.DataStoreTx) error {
+		runs, err := tx.ContainerJobRun().ReadAll()
+		if err != nil {
+			return err
+		}
+
+		for _, run := range runs {
+			if run.Status != portainer.ContainerJobRunRunning {
+				continue
+			}
+
+			run.Status = portainer.ContainerJobRunFailed
+			run.Error = errInterrupted.Error()
+			run.FinishedAt = time.Now().Unix()
+
+			if err := tx.ContainerJobRun().Update(run.ID, &run); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}); err != nil {
+		return errors.Wrap(err, "unable to update the interrupted runs")
+	}
+
+	jobs, err := service.dataStore.ContainerJob().ReadAll()
+	if err != nil {
+		return err
+	}
+
+	for i := range jobs {
+		if err := service.Schedule(&jobs[i]); err != nil {
+			log.Error().Err(err).Int("job_id", int(jobs[i].ID)).Msg("unable to schedule the container job")
+		}
+	}
+
+	return nil
+}
+
+// Schedule replaces the schedule of a job with the one of its cron expression, a paused job is not scheduled
+func (service *Service) Schedule(job *portainer.ContainerJob) error {
+	service.Unschedule(job.ID)
+
+	if job.Paused {
+		return nil
+	}
+
+	schedule, err := ParseSchedule(job.CronExpression)
+	if err != nil {
+		return err
+	}
+
+	jobID := job.ID
+
+	service.mu.Lock()
+	defer service.mu.Unlock()
+
+	service.schedules[jobID] = service.scheduler.StartJobOnSchedule(schedule, func() error {
+		_, err := service.Run(jobID, false)
+		if service.dataStore.IsErrObjectNotFound(err) {
+			return scheduler.NewPermanentError(err)
+		}
+
+		return err
+	})
+
+	return nil
+}
+
+// Unschedule stops the scheduled runs of a job, the ones in progress are not stopped
+func (service *Service) Unschedule(jobID portainer.ContainerJobID) {
+	service.mu.Lock()
+	defer service.mu.Unlock()
+
+	scheduleID, ok := service.schedules[jobID]
+	if !ok {
+		return
+	}
+
+	if err := service.scheduler.StopJob(scheduleID); err != nil {
+		log.Warn().Err(err).Int("job_id", int(jobID)).Msg("unable to stop the schedule of the container job")
+	}
+
+	delete(service.schedules, jobID)
+}
+
+// Run starts a run of a job on each of its environments(endpoints) and returns the runs, which complete in the
+// background. An environment still running the previous run of the job is skipped
+func (service *Service) Run(jobID portainer.ContainerJobID, manual bool) ([]portainer.ContainerJobRun, error) {
+	job, err := service.dataStore.ContainerJob().Read(jobID)
+	if err != nil {
+		return nil, err
+	}
+
+	runs := []portainer.ContainerJobRun{}
+
+	for _, endpointID := range job.Endpoints {
+		endpoint, err := service.dataStore.Endpoint().Endpoint(endpointID)
+		if service.dataStore.IsErrObjectNotFound(err) {
+			continue
+		} else if err != nil {
+			return runs, err
+		}
+
+		key := runKey{jobID: job.ID, endpointID: endpointID}
+
+		service.mu.Lock()
+		if service.running[key] {
+			service.mu.Unlock()
+			log.Warn().Int("job_id", int(job.ID)).Int("endpoint_id", int(endpointID)).Msg("the previous run of the container job is still in progress, skipping")
+
+			continue
+		}
+		service.running[key] = true
+		service.mu.Unlock()
+
+		run := &portainer.ContainerJobRun{
+			JobID:      job.ID,
+			EndpointID: endpointID,
+			Manual:     manual,
+			Status:     portainer.ContainerJobRunRunning,
+			StartedAt:  time.Now().Unix(),
+		}
+
+		if err := service.dataStore.ContainerJobRun().Create(run); err != nil {
+			service.done(key)
+
+			return runs, err
+		}
+
+		runs = append(runs, *run)
+
+		go service.execute(key, job, endpoint, run)
+	}
+
+	return runs, nil
+}
+
+func (service *Service) execute(key runKey, job *portainer.ContainerJob, endpoint *portainer.Endpoint, run *portainer.ContainerJobRun) {
+	defer service.done(key)
+
+	if err := service.executeOnEndpoint(job, endpoint, run); err != nil {
+		run.Status = portainer.ContainerJobRunFailed
+		run.Error = err.Error()
+		run.FinishedAt = time.Now().Unix()
+	}
+
+	if err := service.dataStore.UpdateTx(func(tx dataservices.DataStoreTx) error {
+		// the runs of a job removed meanwhile are not recorded again
+		if _, err := tx.ContainerJobRun().Read(run.ID); err != nil {
+			return err
+		}
+
+		return tx.ContainerJobRun().Update(run.ID, run)
+	}); err != nil && !service.dataStore.IsErrObjectNotFound(err) {
+		log.Error().Err(err).Int("job_id", int(job.ID)).Int("run_id", int(run.ID)).Msg("unable to record the run of the container job")
+	}
+}
+
+func (service *Service) executeOnEndpoint(job *portainer.ContainerJob, endpoint *portainer.Endpoint, run *portainer.ContainerJobRun) error {
+	if err := CheckEndpoint(endpoint); err != nil {
+		return err
+	}
+
+	cli, err := service.clientFactory.CreateClient(endpoint, "", nil)
+	if err != nil {
+		return errors.Wrap(err, "unable to create the Docker client")
+	}
+	defer cli.Close()
+
+	var registryAuth string
+	if img, err := images.ParseImage(images.ParseImageOptions{Name: job.Image}); err == nil {
+		// the image is pulled anonymously when no registry matches it
+		registryAuth, _ = service.registryClient.EncodedRegistryAuth(img)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(timeout(job))*time.Second)
+	defer cancel()
+
+	Execute(ctx, cli, job, registryAuth, run)
+
+	return nil
+}
+
+func (service *Service) done(key runKey) {
+	service.mu.Lock()
+	defer service.mu.Unlock()
+
+	delete(service.running, key)
+}
+
+// CheckEndpoint checks that a job can run on an environment(endpoint), the jobs running containers outside of the
+// stacks like any other change
+func CheckEndpoint(endpoint *portainer.Endpoint) error {
+	if !endpointutils.IsDockerEndpoint(endpoint) || endpointutils.IsEdgeEndpoint(endpoint) {
+		return ErrUnsupportedEndpoint
+	}
+
+	if err := immutable.Check(endpoint); err != nil {
+		return err
+	}
+
+	return quarantine.Check(endpoint)
+}
@@ -0,0 +1,330 @@
+// Package containerstats samples the resource usage of the running containers of the environments at a regular
+// interval, keeping a short history in memory so that a single request returns the usage of all the containers
+package containerstats
+
+import (
+	"context"
+	"encoding/json"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/portainer/portainer/api/docker"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/rs/zerolog/log"
+	"golang.org/x/sync/errgroup"
+)
+
+const (
+	// samplingConcurrency is the number of containers whose stats are retrieved at once, every retrieval takes about a
+	// second for the daemon to sample the CPU usage
+	samplingConcurrency = 8
+)
+
+// Client is the part of the Docker client used to sample the containers
+type Client interface {
+	ContainerList(ctx context.Context, options container.ListOptions) ([]types.Container, error)
+	ContainerStats(ctx context.Context, containerID string, stream bool) (container.StatsResponseReader, error)
+	Close() error
+}
+
+// Container describes a sampled container
+type Container struct {
+	ID     string            `json:"Id" example:"d2b8a4e2f1c3"`
+	Name   string            `json:"Name" example:"web"`
+	Image  string            `json:"Image" example:"nginx:latest"`
+	Labels map[string]string `json:"Labels"`
+}
+
+// Usage is the resource usage of one or several containers
+type Usage struct {
+	// CPU usage, 100 being one CPU fully used
+	CPUPercent float64 `json:"CPUPercent" example:"12.5"`
+	// Memory used without the page cache, in bytes
+	MemoryUsage uint64 `json:"MemoryUsage" example:"52428800"`
+	// Memory limit, in bytes
+	MemoryLimit uint64 `json:"MemoryLimit" example:"2147483648"`
+	// Bytes received and sent on the networks since the containers started
+	NetworkRx uint64 `json:"NetworkRx" example:"1048576"`
+	NetworkTx uint64 `json:"NetworkTx" example:"524288"`
+	// Bytes read from and written to the block devices since the containers started
+	BlockRead  uint64 `json:"BlockRead" example:"4096"`
+	BlockWrite uint64 `json:"BlockWrite" example:"8192"`
+}
+
+func (usage *Usage) add(other Usage) {
+	usage.CPUPercent += other.CPUPercent
+	usage.MemoryUsage += other.MemoryUsage
+	usage.MemoryLimit += other.MemoryLimit
+	usage.NetworkRx += other.NetworkRx
+	usage.NetworkTx += other.NetworkTx
+	usage.BlockRead += other.BlockRead
+	usage.BlockWrite += other.BlockWrite
+}
+
+// ContainerUsage is the resource usage of a container in a sample
+type ContainerUsage struct {
+	ID string `json:"Id" example:"d2b8a4e2f1c3"`
+	Usage
+}
+
+// Sample is the resource usage of the containers at a point in time
+type Sample struct {
+	// Unix timestamp of the sample
+	Time int64 `json:"Time" example:"1587399600"`
+	// Usage of all the containers of the sample
+	Total      Usage            `json:"Total"`
+	Containers []ContainerUsage `json:"Containers"`
+}
+
+// Report is the history of the samples of the containers of an environment
+type Report struct {
+	// Sampling interval, in seconds
+	Interval int `json:"Interval" example:"5"`
+	// Containers of the samples
+	Containers []Container `json:"Containers"`
+	// Samples, from the oldest to the latest
+	Samples []Sample `json:"Samples"`
+}
+
+// Filter returns the report restricted to the containers kept by a function, the totals of the samples only counting
+// the kept containers
+func (report *Report) Filter(keep func(Container) bool) *Report {
+	filtered := &Report{Interval: report.Interval, Containers: []Container{}, Samples: make([]Sample, 0, len(report.Samples))}
+
+	kept := make(map[string]bool, len(report.Containers))
+	for _, c := range report.Containers {
+		if keep(c) {
+			kept[c.ID] = true
+			filtered.Containers = append(filtered.Containers, c)
+		}
+	}
+
+	for _, sample := range report.Samples {
+		filteredSample := Sample{Time: sample.Time, Containers: []ContainerUsage{}}
+
+		for _, usage := range sample.Containers {
+			if kept[usage.ID] {
+				filteredSample.Containers = append(filteredSample.Containers, usage)
+				filteredSample.Total.add(usage.Usage)
+			}
+		}
+
+		filtered.Samples = append(filtered.Samples, filteredSample)
+	}
+
+	return filtered
+}
+
+// sampler samples the running containers matching filters through a client at a regular interval
+type sampler struct {
+	cli        Client
+	filters    filters.Args
+	interval   time.Duration
+	maxHistory int
+	// ready is closed once the first sample is taken
+	ready chan struct{}
+
+	mu         sync.Mutex
+	samples    []Sample
+	containers map[string]Container
+	// err is the error of the latest sample
+	err      error
+	lastRead time.Time
+}
+
+func newSampler(cli Client, args filters.Args, interval time.Duration, maxHistory int) *sampler {
+	return &sampler{
+		cli:        cli,
+		filters:    args,
+		interval:   interval,
+		maxHistory: maxHistory,
+		ready:      make(chan struct{}),
+		containers: map[string]Container{},
+		lastRead:   time.Now(),
+	}
+}
+
+// run samples the containers until the context is done or no report is requested for the idle timeout
+func (s *sampler) run(ctx context.Context, idleTimeout time.Duration) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		s.sample(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		s.mu.Lock()
+		idle := time.Since(s.lastRead) > idleTimeout
+		s.mu.Unlock()
+
+		if idle {
+			return
+		}
+	}
+}
+
+// sample records the usage of the running containers, the containers stopped while being sampled are left out
+func (s *sampler) sample(ctx context.Context) {
+	defer func() {
+		select {
+		case <-s.ready:
+		default:
+			close(s.ready)
+		}
+	}()
+
+	args := s.filters.Clone()
+	args.Add("status", "running")
+
+	containers, err := s.cli.ContainerList(ctx, container.ListOptions{Filters: args})
+	if err != nil {
+		s.mu.Lock()
+		s.err = err
+		s.mu.Unlock()
+
+		return
+	}
+
+	usages := make([]*ContainerUsage, len(containers))
+
+	g, gCtx := errgroup.WithContext(ctx)
+	g.SetLimit(samplingConcurrency)
+
+	for i, c := range containers {
+		g.Go(func() error {
+			usage, err := containerUsage(gCtx, s.cli, c.ID)
+			if err != nil {
+				log.Debug().Err(err).Str("container_id", c.ID).Msg("unable to sample the container")
+
+				return nil
+			}
+
+			usages[i] = &ContainerUsage{ID: c.ID, Usage: usage}
+
+			return nil
+		})
+	}
+
+	_ = g.Wait()
+
+	sample := Sample{Time: time.Now().Unix(), Containers: []ContainerUsage{}}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, c := range containers {
+		if usages[i] == nil {
+			continue
+		}
+
+		sample.Containers = append(sample.Containers, *usages[i])
+		sample.Total.add(usages[i].Usage)
+
+		name := ""
+		if len(c.Names) > 0 {
+			name = strings.TrimPrefix(c.Names[0], "/")
+		}
+
+		s.containers[c.ID] = Container{ID: c.ID, Name: name, Image: c.Image, Labels: c.Labels}
+	}
+
+	s.err = nil
+	s.samples = append(s.samples, sample)
+	if len(s.samples) > s.maxHistory {
+		s.samples = slices.Clone(s.samples[len(s.samples)-s.maxHistory:])
+	}
+
+	// forget the containers no longer in the history
+	sampled := sampledContainers(s.samples)
+	maps.DeleteFunc(s.containers, func(id string, _ Container) bool {
+		return !sampled[id]
+	})
+}
+
+// report returns the latest samples, all of them when history is 0, and marks the sampler as read
+func (s *sampler) report(history int) (*Report, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.lastRead = time.Now()
+
+	if s.err != nil {
+		return nil, s.err
+	}
+
+	samples := s.samples
+	if history > 0 && history < len(samples) {
+		samples = samples[len(samples)-history:]
+	}
+
+	report := &Report{
+		Interval:   int(s.interval / time.Second),
+		Containers: []Container{},
+		Samples:    slices.Clone(samples),
+	}
+
+	for _, id := range slices.Sorted(maps.Keys(sampledContainers(report.Samples))) {
+		report.Containers = append(report.Containers, s.containers[id])
+	}
+
+	return report, nil
+}
+
+// sampledContainers returns the identifiers of the containers of samples
+func sampledContainers(samples []Sample) map[string]bool {
+	sampled := map[string]bool{}
+	for _, sample := range samples {
+		for _, usage := range sample.Containers {
+			sampled[usage.ID] = true
+		}
+	}
+
+	return sampled
+}
+
+// containerUsage retrieves the resource usage of a running container
+func containerUsage(ctx context.Context, cli Client, containerID string) (Usage, error) {
+	var usage Usage
+
+	reader, err := cli.ContainerStats(ctx, containerID, false)
+	if err != nil {
+		return usage, err
+	}
+	defer reader.Body.Close()
+
+	var stats container.StatsResponse
+	if err := json.NewDecoder(reader.Body).Decode(&stats); err != nil {
+		return usage, err
+	}
+
+	usage.CPUPercent = docker.CPUPercent(stats)
+	usage.MemoryUsage = docker.MemoryUsage(stats.MemoryStats)
+	usage.MemoryLimit = stats.MemoryStats.Limit
+
+	for _, network := range stats.Networks {
+		usage.NetworkRx += network.RxBytes
+		usage.NetworkTx += network.TxBytes
+	}
+
+	for _, entry := range stats.BlkioStats.IoServiceBytesRecursive {
+		switch strings.ToLower(entry.Op) {
+		case "read":
+			usage.BlockRead += entry.Value
+		case "write":
+			usage.BlockWrite += entry.Value
+		}
+	}
+
+	return usage, nil
+}
@@ -0,0 +1,191 @@
+package containerstats
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeClient struct {
+	mu         sync.Mutex
+	containers []types.Container
+	stats      map[string]container.StatsResponse
+	filters    []filters.Args
+}
+
+func (cli *fakeClient) ContainerList(ctx context.Context, options container.ListOptions) ([]types.Container, error) {
+	cli.mu.Lock()
+	defer cli.mu.Unlock()
+
+	cli.filters = append(cli.filters, options.Filters)
+
+	return cli.containers, nil
+}
+
+func (cli *fakeClient) ContainerStats(ctx context.Context, containerID string, stream bool) (container.StatsResponseReader, error) {
+	stats, ok := cli.stats[containerID]
+	if !ok {
+		return container.StatsResponseReader{}, errors.New("no such container")
+	}
+
+	body, err := json.Marshal(stats)
+	if err != nil {
+		return container.StatsResponseReader{}, err
+	}
+
+	return container.StatsResponseReader{Body: io.NopCloser(bytes.NewReader(body))}, nil
+}
+
+func (cli *fakeClient) Close() error {
+	return nil
+}
+
+func newFakeClient() *fakeClient {
+	stats := container.StatsResponse{}
+	stats.CPUStats.CPUUsage.TotalUsage = 300
+	stats.CPUStats.SystemUsage = 2000
+	stats.CPUStats.OnlineCPUs = 2
+	stats.PreCPUStats.CPUUsage.TotalUsage = 200
+	stats.PreCPUStats.SystemUsage = 1000
+	stats.MemoryStats = container.MemoryStats{Usage: 1000, Limit: 4000, Stats: map[string]uint64{"inactive_file": 200}}
+	stats.Networks = map[string]container.NetworkStats{"eth0": {RxBytes: 10, TxBytes: 20}, "eth1": {RxBytes: 1, TxBytes: 2}}
+	stats.BlkioStats.IoServiceBytesRecursive = []container.BlkioStatEntry{{Op: "read", Value: 30}, {Op: "Write", Value: 40}}
+
+	return &fakeClient{
+		containers: []types.Container{
+			{ID: "web", Names: []string{"/web"}, Image: "nginx", Labels: map[string]string{"app": "web"}},
+			{ID: "gone", Names: []string{"/gone"}, Image: "busybox"},
+		},
+		stats: map[string]container.StatsResponse{"web": stats},
+	}
+}
+
+func TestSample(t *testing.T) {
+	is := require.New(t)
+
+	cli := newFakeClient()
+	s := newSampler(cli, filters.NewArgs(filters.Arg("label", "app")), time.Second, 2)
+
+	s.sample(context.Background())
+
+	is.Len(cli.filters, 1)
+	is.Equal([]string{"running"}, cli.filters[0].Get("status"))
+	is.Equal([]string{"app"}, cli.filters[0].Get("label"))
+	is.False(s.filters.Contains("status"))
+
+	report, err := s.report(0)
+	is.NoError(err)
+	is.Equal(1, report.Interval)
+	is.Equal([]Container{{ID: "web", Name: "web", Image: "nginx", Labels: map[string]string{"app": "web"}}}, report.Containers)
+	is.Len(report.Samples, 1)
+
+	usage := Usage{CPUPercent: 20, MemoryUsage: 800, MemoryLimit: 4000, NetworkRx: 11, NetworkTx: 22, BlockRead: 30, BlockWrite: 40}
+	is.Equal([]ContainerUsage{{ID: "web", Usage: usage}}, report.Samples[0].Containers)
+	is.Equal(usage, report.Samples[0].Total)
+
+	// the history is trimmed and the containers no longer sampled are forgotten
+	cli.containers = nil
+	s.sample(context.Background())
+	s.sample(context.Background())
+
+	report, err = s.report(0)
+	is.NoError(err)
+	is.Len(report.Samples, 2)
+	is.Empty(report.Containers)
+	is.Empty(s.containers)
+}
+
+func TestReportHistory(t *testing.T) {
+	is := require.New(t)
+
+	cli := newFakeClient()
+	s := newSampler(cli, filters.NewArgs(), time.Second, 10)
+
+	s.sample(context.Background())
+	cli.containers = nil
+	s.sample(context.Background())
+
+	report, err := s.report(1)
+	is.NoError(err)
+	is.Len(report.Samples, 1)
+	is.Empty(report.Containers)
+
+	report, err = s.report(0)
+	is.NoError(err)
+	is.Len(report.Samples, 2)
+	is.Len(report.Containers, 1)
+}
+
+func TestReportFilter(t *testing.T) {
+	is := require.New(t)
+
+	report := &Report{
+		Interval:   5,
+		Containers: []Container{{ID: "a"}, {ID: "b"}},
+		Samples: []Sample{{
+			Time:       1,
+			Total:      Usage{CPUPercent: 3, MemoryUsage: 30},
+			Containers: []ContainerUsage{{ID: "a", Usage: Usage{CPUPercent: 1, MemoryUsage: 10}}, {ID: "b", Usage: Usage{CPUPercent: 2, MemoryUsage: 20}}},
+		}},
+	}
+
+	filtered := report.Filter(func(c Container) bool { return c.ID == "b" })
+
+	is.Equal(&Report{
+		Interval:   5,
+		Containers: []Container{{ID: "b"}},
+		Samples: []Sample{{
+			Time:       1,
+			Total:      Usage{CPUPercent: 2, MemoryUsage: 20},
+			Containers: []ContainerUsage{{ID: "b", Usage: Usage{CPUPercent: 2, MemoryUsage: 20}}},
+		}},
+	}, filtered)
+}
+
+func TestServiceReport(t *testing.T) {
+	is := require.New(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	clients := 0
+	service := &Service{
+		shutdownCtx: ctx,
+		createClient: func(endpoint *portainer.Endpoint) (Client, error) {
+			clients++
+			return newFakeClient(), nil
+		},
+		samplers: map[samplerKey]*sampler{},
+	}
+
+	endpoint := &portainer.Endpoint{ID: 1}
+
+	report, err := service.Report(context.Background(), endpoint, Query{})
+	is.NoError(err)
+	is.Equal(5, report.Interval)
+	is.Len(report.Samples, 1)
+
+	// the same query reuses the running sampler
+	_, err = service.Report(context.Background(), endpoint, Query{Filters: filters.NewArgs()})
+	is.NoError(err)
+	is.Equal(1, clients)
+
+	_, err = service.Report(context.Background(), endpoint, Query{Filters: filters.NewArgs(filters.Arg("label", "app"))})
+	is.NoError(err)
+	is.Equal(2, clients)
+
+	_, err = service.Report(context.Background(), endpoint, Query{Interval: time.Second})
+	is.ErrorIs(err, ErrInvalidInterval)
+}
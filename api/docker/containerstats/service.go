@@ -0,0 +1,143 @@
+package containerstats
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+	dockerclient "github.com/portainer/portainer/api/docker/client"
+
+	"github.com/docker/docker/api/types/filters"
+)
+
+const (
+	DefaultInterval = 5 * time.Second
+	MinInterval     = 2 * time.Second
+	MaxInterval     = time.Minute
+	// MaxHistory is the number of samples kept by a sampler
+	MaxHistory = 60
+	// idleTimeout stops the samplers for which no report was requested for a while
+	idleTimeout = 2 * time.Minute
+	// maxSamplers is the number of samplers running at once, one per environment(endpoint), filters and interval
+	maxSamplers = 64
+)
+
+var (
+	// ErrInvalidInterval is returned when the sampling interval is out of bounds
+	ErrInvalidInterval = fmt.Errorf("the sampling interval must be between %s and %s", MinInterval, MaxInterval)
+	// ErrTooManySamplers is returned when a new sampler is required while the maximum number of them are running
+	ErrTooManySamplers = errors.New("too many container stats samplers are running, retry later or reuse the filters and the interval of a running one")
+)
+
+// Query selects the sampled containers of an environment(endpoint) and the sampling interval
+type Query struct {
+	Filters  filters.Args
+	Interval time.Duration
+	// History is the number of samples returned, all the samples kept when 0
+	History int
+}
+
+// samplerKey identifies the sampler of a query, shared by the queries with the same filters and interval
+type samplerKey struct {
+	endpointID portainer.EndpointID
+	filters    string
+	interval   time.Duration
+}
+
+// Service samples the containers of the environments(endpoints) on demand. A sampler starts with the first report
+// requested for a query and stops once no report is requested for a while
+type Service struct {
+	shutdownCtx  context.Context
+	createClient func(endpoint *portainer.Endpoint) (Client, error)
+
+	mu       sync.Mutex
+	samplers map[samplerKey]*sampler
+}
+
+// NewService creates a new container stats service
+func NewService(shutdownCtx context.Context, clientFactory *dockerclient.ClientFactory) *Service {
+	return &Service{
+		shutdownCtx: shutdownCtx,
+		createClient: func(endpoint *portainer.Endpoint) (Client, error) {
+			return clientFactory.CreateClient(endpoint, "", nil)
+		},
+		samplers: map[samplerKey]*sampler{},
+	}
+}
+
+// Report returns the latest samples of the running containers of an environment(endpoint) matching a query, waiting
+// for the first sample when the sampling starts
+func (service *Service) Report(ctx context.Context, endpoint *portainer.Endpoint, query Query) (*Report, error) {
+	if query.Interval == 0 {
+		query.Interval = DefaultInterval
+	}
+
+	if query.Interval < MinInterval || query.Interval > MaxInterval {
+		return nil, ErrInvalidInterval
+	}
+
+	key, err := newSamplerKey(endpoint.ID, query)
+	if err != nil {
+		return nil, err
+	}
+
+	s, err := service.sampler(endpoint, key, query)
+	if err != nil {
+		return nil, err
+	}
+
+	select {
+	case <-s.ready:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	return s.report(query.History)
+}
+
+// sampler returns the running sampler of a query, starting it when required
+func (service *Service) sampler(endpoint *portainer.Endpoint, key samplerKey, query Query) (*sampler, error) {
+	service.mu.Lock()
+	defer service.mu.Unlock()
+
+	if s, ok := service.samplers[key]; ok {
+		return s, nil
+	}
+
+	if len(service.samplers) >= maxSamplers {
+		return nil, ErrTooManySamplers
+	}
+
+	cli, err := service.createClient(endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create a Docker client: %w", err)
+	}
+
+	s := newSampler(cli, query.Filters, query.Interval, MaxHistory)
+	service.samplers[key] = s
+
+	go func() {
+		defer cli.Close()
+
+		s.run(service.shutdownCtx, idleTimeout)
+
+		service.mu.Lock()
+		delete(service.samplers, key)
+		service.mu.Unlock()
+	}()
+
+	return s, nil
+}
+
+func newSamplerKey(endpointID portainer.EndpointID, query Query) (samplerKey, error) {
+	// the filters are encoded with their keys sorted, the same filters always have the same key
+	args, err := filters.ToJSON(query.Filters)
+	if err != nil {
+		return samplerKey{}, fmt.Errorf("invalid filters: %w", err)
+	}
+
+	return samplerKey{endpointID: endpointID, filters: args, interval: query.Interval}, nil
+}
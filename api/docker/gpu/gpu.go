@@ -0,0 +1,150 @@
+// Package gpu discovers the GPUs of the Docker environments(endpoints) and validates the GPU requests of the
+// containers against them
+package gpu
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/swarm"
+)
+
+const (
+	// NvidiaDriver is the driver of the NVIDIA GPU requests
+	NvidiaDriver = "nvidia"
+	// Capability is the capability of the GPU requests, which select the NVIDIA driver when it has none
+	Capability = "gpu"
+)
+
+var (
+	// ErrNoGPU is returned when GPUs are requested on an environment(endpoint) without any known GPU
+	ErrNoGPU = errors.New("the environment has no GPU")
+	// ErrUnknownGPU is returned when a requested GPU is not one of the environment(endpoint)
+	ErrUnknownGPU = errors.New("the GPU is not one of the environment")
+	// ErrTooManyGPUs is returned when more GPUs are requested than the environment(endpoint) has
+	ErrTooManyGPUs = errors.New("more GPUs are requested than the environment has")
+	// ErrCountAndIDs is returned when a request has both a count and GPU identifiers, which Docker rejects
+	ErrCountAndIDs = errors.New("a GPU request cannot have both a count and device identifiers")
+)
+
+// IsRequest returns whether a device request of a container asks for GPUs
+func IsRequest(request container.DeviceRequest) bool {
+	if request.Driver == NvidiaDriver {
+		return true
+	}
+
+	for _, capabilities := range request.Capabilities {
+		if slices.Contains(capabilities, Capability) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// FromGenericResources returns the GPUs advertised by a Docker engine as generic resources, the GPUs which are only
+// counted are identified by their index
+func FromGenericResources(resources []swarm.GenericResource) []portainer.DockerGpu {
+	gpus := []portainer.DockerGpu{}
+
+	for _, resource := range resources {
+		switch {
+		case resource.NamedResourceSpec != nil && isGPUKind(resource.NamedResourceSpec.Kind):
+			gpus = append(gpus, portainer.DockerGpu{
+				ID:   resource.NamedResourceSpec.Value,
+				Kind: resource.NamedResourceSpec.Kind,
+			})
+		case resource.DiscreteResourceSpec != nil && isGPUKind(resource.DiscreteResourceSpec.Kind):
+			for i := range resource.DiscreteResourceSpec.Value {
+				gpus = append(gpus, portainer.DockerGpu{
+					ID:   strconv.FormatInt(i, 10),
+					Kind: resource.DiscreteResourceSpec.Kind,
+				})
+			}
+		}
+	}
+
+	return gpus
+}
+
+func isGPUKind(kind string) bool {
+	return strings.Contains(strings.ToLower(kind), Capability)
+}
+
+// Inventory returns the identifiers of the GPUs of an environment(endpoint), the ones declared on the environment and
+// the ones advertised by its engine in its last snapshot
+func Inventory(endpoint *portainer.Endpoint, snapshot *portainer.DockerSnapshot) []string {
+	inventory := []string{}
+
+	for _, gpu := range endpoint.Gpus {
+		if gpu.Value != "" && !slices.Contains(inventory, gpu.Value) {
+			inventory = append(inventory, gpu.Value)
+		}
+	}
+
+	if snapshot != nil {
+		for _, gpu := range snapshot.Gpus {
+			if !slices.Contains(inventory, gpu.ID) {
+				inventory = append(inventory, gpu.ID)
+			}
+		}
+	}
+
+	return inventory
+}
+
+// Validate checks the GPU requests of a container against the GPUs of the inventory of an environment(endpoint). The
+// GPUs can be shared by the containers, only the ones which do not exist are rejected
+func Validate(requests []container.DeviceRequest, inventory []string) error {
+	for _, request := range requests {
+		if !IsRequest(request) {
+			continue
+		}
+
+		if len(inventory) == 0 {
+			return ErrNoGPU
+		}
+
+		if request.Count != 0 && len(request.DeviceIDs) > 0 {
+			return ErrCountAndIDs
+		}
+
+		// a count of -1 requests all the GPUs
+		if request.Count > len(inventory) {
+			return fmt.Errorf("%w: %d requested, %d available", ErrTooManyGPUs, request.Count, len(inventory))
+		}
+
+		for _, id := range request.DeviceIDs {
+			if !slices.Contains(inventory, id) {
+				return fmt.Errorf("%w: %s", ErrUnknownGPU, id)
+			}
+		}
+	}
+
+	return nil
+}
+
+// Requested returns whether some device requests of a container ask for GPUs
+func Requested(requests []container.DeviceRequest) bool {
+	return slices.ContainsFunc(requests, IsRequest)
+}
+
+// EndpointInventory returns the identifiers of the GPUs of an environment(endpoint), with the ones advertised in its
+// last snapshot when it has one
+func EndpointInventory(tx dataservices.DataStoreTx, endpoint *portainer.Endpoint) ([]string, error) {
+	snapshot, err := tx.Snapshot().Read(endpoint.ID)
+	if tx.IsErrObjectNotFound(err) {
+		return Inventory(endpoint, nil), nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	return Inventory(endpoint, snapshot.Docker), nil
+}
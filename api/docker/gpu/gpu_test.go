@@ -0,0 +1,101 @@
+package gpu
+
+import (
+	"testing"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/datastore"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/swarm"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsRequest(t *testing.T) {
+	require.True(t, IsRequest(container.DeviceRequest{Driver: NvidiaDriver}))
+	require.True(t, IsRequest(container.DeviceRequest{Capabilities: [][]string{{"compute", "gpu"}}}))
+	require.False(t, IsRequest(container.DeviceRequest{Driver: "cdi", DeviceIDs: []string{"vendor.com/device=foo"}}))
+	require.False(t, IsRequest(container.DeviceRequest{}))
+}
+
+func TestFromGenericResources(t *testing.T) {
+	gpus := FromGenericResources([]swarm.GenericResource{
+		{NamedResourceSpec: &swarm.NamedGenericResource{Kind: "NVIDIA-GPU", Value: "GPU-8f1c4a3e"}},
+		{NamedResourceSpec: &swarm.NamedGenericResource{Kind: "SSD", Value: "sda"}},
+		{DiscreteResourceSpec: &swarm.DiscreteGenericResource{Kind: "gpu", Value: 2}},
+	})
+
+	require.Equal(t, []portainer.DockerGpu{
+		{ID: "GPU-8f1c4a3e", Kind: "NVIDIA-GPU"},
+		{ID: "0", Kind: "gpu"},
+		{ID: "1", Kind: "gpu"},
+	}, gpus)
+}
+
+func TestValidate(t *testing.T) {
+	inventory := []string{"0", "GPU-8f1c4a3e"}
+
+	for _, test := range []struct {
+		name     string
+		requests []container.DeviceRequest
+		err      error
+	}{
+		{name: "no request"},
+		{
+			name:     "other device",
+			requests: []container.DeviceRequest{{Driver: "cdi", DeviceIDs: []string{"vendor.com/device=foo"}}},
+		},
+		{
+			name:     "known devices",
+			requests: []container.DeviceRequest{{Driver: NvidiaDriver, DeviceIDs: []string{"0", "GPU-8f1c4a3e"}}},
+		},
+		{
+			name:     "all devices",
+			requests: []container.DeviceRequest{{Count: -1, Capabilities: [][]string{{"gpu"}}}},
+		},
+		{
+			name:     "available count",
+			requests: []container.DeviceRequest{{Count: 2, Capabilities: [][]string{{"gpu"}}}},
+		},
+		{
+			name:     "too many devices",
+			requests: []container.DeviceRequest{{Count: 3, Capabilities: [][]string{{"gpu"}}}},
+			err:      ErrTooManyGPUs,
+		},
+		{
+			name:     "unknown device",
+			requests: []container.DeviceRequest{{Driver: NvidiaDriver, DeviceIDs: []string{"1"}}},
+			err:      ErrUnknownGPU,
+		},
+		{
+			name:     "count and devices",
+			requests: []container.DeviceRequest{{Driver: NvidiaDriver, Count: 1, DeviceIDs: []string{"0"}}},
+			err:      ErrCountAndIDs,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			require.ErrorIs(t, Validate(test.requests, inventory), test.err)
+		})
+	}
+
+	require.ErrorIs(t, Validate([]container.DeviceRequest{{Driver: NvidiaDriver, Count: -1}}, nil), ErrNoGPU)
+}
+
+func TestEndpointInventory(t *testing.T) {
+	_, store := datastore.MustNewTestStore(t, true, false)
+
+	endpoint := &portainer.Endpoint{ID: 1, Gpus: []portainer.Pair{{Name: "GPU 0", Value: "0"}, {Name: "empty"}}}
+
+	inventory, err := EndpointInventory(store, endpoint)
+	require.NoError(t, err)
+	require.Equal(t, []string{"0"}, inventory)
+
+	require.NoError(t, store.Snapshot().Create(&portainer.Snapshot{
+		EndpointID: endpoint.ID,
+		Docker:     &portainer.DockerSnapshot{Gpus: []portainer.DockerGpu{{ID: "0", Kind: "gpu"}, {ID: "1", Kind: "gpu"}}},
+	}))
+
+	inventory, err = EndpointInventory(store, endpoint)
+	require.NoError(t, err)
+	require.Equal(t, []string{"0", "1"}, inventory)
+}
@@ -51,23 +51,8 @@ func (c *DigestClient) RemoteDigest(image Image) (digest.Digest, error) {
 		return "", errors.Wrap(err, "Cannot parse the image reference")
 	}
 
-	sysCtx := c.sysCtx
-	if c.registryClient != nil {
-		username, password, err := c.registryClient.RegistryAuth(image)
-		if err != nil {
-			log.Info().Str("image up to date indicator", image.String()).Msg("No environment registry credentials found, using anonymous access")
-		} else {
-			sysCtx = &imagetypes.SystemContext{
-				DockerAuthConfig: &imagetypes.DockerAuthConfig{
-					Username: username,
-					Password: password,
-				},
-			}
-		}
-	}
-
 	// Retrieve remote digest through HEAD request
-	rmDigest, err := docker.GetDigest(ctx, sysCtx, rmRef)
+	rmDigest, err := docker.GetDigest(ctx, c.authSystemContext(image), rmRef)
 	if err != nil {
 		// fallback to public registry for hub
 		if image.HubLink != "" {
@@ -85,6 +70,28 @@ func (c *DigestClient) RemoteDigest(image Image) (digest.Digest, error) {
 	return rmDigest, nil
 }
 
+// authSystemContext returns the system context authenticating to the registry of an image with the credentials of
+// the matching registry, or the anonymous one when there is none
+func (c *DigestClient) authSystemContext(image Image) *imagetypes.SystemContext {
+	if c.registryClient == nil {
+		return c.sysCtx
+	}
+
+	username, password, err := c.registryClient.RegistryAuth(image)
+	if err != nil {
+		log.Info().Str("image up to date indicator", image.String()).Msg("No environment registry credentials found, using anonymous access")
+
+		return c.sysCtx
+	}
+
+	return &imagetypes.SystemContext{
+		DockerAuthConfig: &imagetypes.DockerAuthConfig{
+			Username: username,
+			Password: password,
+		},
+	}
+}
+
 func ParseLocalImage(inspect types.ImageInspect) (*Image, error) {
 	if IsLocalImage(inspect) || IsDanglingImage(inspect) {
 		return nil, errors.New("the image is not regular")
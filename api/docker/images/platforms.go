@@ -0,0 +1,293 @@
+package images
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/containers/image/v5/manifest"
+	"github.com/containers/image/v5/pkg/blobinfocache/none"
+	imagetypes "github.com/containers/image/v5/types"
+	"github.com/docker/docker/api/types/swarm"
+	"github.com/opencontainers/go-digest"
+	"github.com/pkg/errors"
+	"golang.org/x/sync/errgroup"
+)
+
+// platformConcurrency is the number of variant manifests of a manifest list retrieved at once
+const platformConcurrency = 4
+
+// Platform is the variant of an image for an operating system and an architecture
+type Platform struct {
+	OS           string `json:"OS" example:"linux"`
+	Architecture string `json:"Architecture" example:"arm64"`
+	Variant      string `json:"Variant,omitempty" example:"v8"`
+	// Digest of the manifest of the variant
+	Digest string `json:"Digest" example:"sha256:8f1c4a3e5b0d2e6f9a7c1b3d5e7f9a1c3e5b7d9f1a3c5e7b9d1f3a5c7e9b1d3f"`
+	// Size of the config and the compressed layers of the variant, in bytes
+	Size int64 `json:"Size" example:"67108864"`
+}
+
+// String returns the platform in the os/architecture/variant format of the Docker CLI
+func (platform Platform) String() string {
+	s := platform.OS + "/" + platform.Architecture
+	if platform.Variant != "" {
+		s += "/" + platform.Variant
+	}
+
+	return s
+}
+
+// Manifest describes the variants of an image in its registry
+type Manifest struct {
+	Image string `json:"Image" example:"nginx:latest"`
+	// Digest of the manifest, or of the manifest list of a multi-architecture image
+	Digest    string `json:"Digest" example:"sha256:2e6f9a7c1b3d5e7f9a1c3e5b7d9f1a3c5e7b9d1f3a5c7e9b1d3f8f1c4a3e5b0d"`
+	MediaType string `json:"MediaType" example:"application/vnd.oci.image.index.v1+json"`
+	// Whether the manifest is a manifest list or an OCI index with a variant per platform
+	MultiArch bool       `json:"MultiArch" example:"true"`
+	Platforms []Platform `json:"Platforms"`
+}
+
+// PlatformFor returns the variant of the image running on a platform, nil when there is none. The platform can be
+// the one of a Docker engine, such as linux and x86_64, or of an image, such as linux and amd64
+func (m *Manifest) PlatformFor(os, architecture, variant string) *Platform {
+	os, architecture, variant = normalizePlatform(os, architecture, variant)
+
+	for i, platform := range m.Platforms {
+		pOS, pArchitecture, pVariant := normalizePlatform(platform.OS, platform.Architecture, platform.Variant)
+		if pOS != os || pArchitecture != architecture {
+			continue
+		}
+
+		if variant == "" || pVariant == "" || variant == pVariant {
+			return &m.Platforms[i]
+		}
+	}
+
+	return nil
+}
+
+// normalizePlatform returns a platform with the names of the image manifests, the Docker engines reporting the
+// architecture of their kernel
+func normalizePlatform(os, architecture, variant string) (string, string, string) {
+	os = strings.ToLower(os)
+	if os == "" {
+		os = "linux"
+	}
+
+	architecture = strings.ToLower(architecture)
+	variant = strings.ToLower(variant)
+
+	switch architecture {
+	case "x86_64", "x86-64":
+		architecture = "amd64"
+	case "aarch64":
+		architecture = "arm64"
+	case "armv7l", "armhf":
+		architecture, variant = "arm", "v7"
+	case "armv6l", "armel":
+		architecture, variant = "arm", "v6"
+	case "i386", "i686":
+		architecture = "386"
+	}
+
+	// v8 is the only variant of arm64
+	if architecture == "arm64" && variant == "v8" {
+		variant = ""
+	}
+
+	return os, architecture, variant
+}
+
+// PlatformWarnings returns a warning per platform of the available nodes of a Swarm cluster for which an image has no
+// variant, listing the nodes which cannot run it
+func PlatformWarnings(m *Manifest, nodes []swarm.Node) []string {
+	unsupported := map[string][]string{}
+
+	for _, node := range nodes {
+		if node.Status.State != swarm.NodeStateReady || node.Spec.Availability != swarm.NodeAvailabilityActive {
+			continue
+		}
+
+		platform := node.Description.Platform
+		if m.PlatformFor(platform.OS, platform.Architecture, "") != nil {
+			continue
+		}
+
+		os, architecture, _ := normalizePlatform(platform.OS, platform.Architecture, "")
+		name := os + "/" + architecture
+		unsupported[name] = append(unsupported[name], node.Description.Hostname)
+	}
+
+	warnings := []string{}
+	for _, name := range slices.Sorted(maps.Keys(unsupported)) {
+		warnings = append(warnings, fmt.Sprintf("the image %s has no variant for %s, it cannot run on the nodes %s", m.Image, name, strings.Join(unsupported[name], ", ")))
+	}
+
+	return warnings
+}
+
+// RemoteManifest retrieves the manifest of an image from its registry and resolves its variants, the manifest of
+// each variant being retrieved to compute its size
+func (c *DigestClient) RemoteManifest(ctx context.Context, image Image) (*Manifest, error) {
+	// Docker references with both a tag and digest are currently not supported
+	if image.Tag != "" && image.Digest != "" {
+		if err := image.trimDigest(); err != nil {
+			return nil, err
+		}
+	}
+
+	ref, err := ParseReference(image.String())
+	if err != nil {
+		return nil, errors.Wrap(err, "Cannot parse the image reference")
+	}
+
+	src, err := ref.NewImageSource(ctx, c.authSystemContext(image))
+	if err != nil && image.HubLink != "" {
+		// fallback to public registry for hub
+		src, err = ref.NewImageSource(ctx, c.sysCtx)
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "Cannot reach the image in its registry")
+	}
+	defer src.Close()
+
+	return readManifest(ctx, image.FullName(), src)
+}
+
+// manifestSource is the part of an image source used to read the manifests of an image
+type manifestSource interface {
+	GetManifest(ctx context.Context, instanceDigest *digest.Digest) ([]byte, string, error)
+	GetBlob(ctx context.Context, info imagetypes.BlobInfo, cache imagetypes.BlobInfoCache) (io.ReadCloser, int64, error)
+}
+
+func readManifest(ctx context.Context, name string, src manifestSource) (*Manifest, error) {
+	blob, mimeType, err := src.GetManifest(ctx, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "Cannot retrieve the image manifest")
+	}
+
+	manifestDigest, err := manifest.Digest(blob)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &Manifest{
+		Image:     name,
+		Digest:    manifestDigest.String(),
+		MediaType: mimeType,
+		MultiArch: manifest.MIMETypeIsMultiImage(mimeType),
+		Platforms: []Platform{},
+	}
+
+	if !m.MultiArch {
+		platform, err := singlePlatform(ctx, src, blob, mimeType)
+		if err != nil {
+			return nil, err
+		}
+
+		platform.Digest = m.Digest
+		m.Platforms = append(m.Platforms, *platform)
+
+		return m, nil
+	}
+
+	list, err := manifest.ListFromBlob(blob, mimeType)
+	if err != nil {
+		return nil, errors.Wrap(err, "Cannot parse the manifest list")
+	}
+
+	for _, instance := range list.Instances() {
+		info, err := list.Instance(instance)
+		if err != nil {
+			return nil, err
+		}
+
+		platform := info.ReadOnly.Platform
+		// the attestations of the images built by buildx are listed with an unknown platform
+		if platform == nil || platform.OS == "unknown" || platform.Architecture == "unknown" {
+			continue
+		}
+
+		m.Platforms = append(m.Platforms, Platform{
+			OS:           platform.OS,
+			Architecture: platform.Architecture,
+			Variant:      platform.Variant,
+			Digest:       instance.String(),
+		})
+	}
+
+	g, gCtx := errgroup.WithContext(ctx)
+	g.SetLimit(platformConcurrency)
+
+	for i := range m.Platforms {
+		g.Go(func() error {
+			instance := digest.Digest(m.Platforms[i].Digest)
+
+			blob, mimeType, err := src.GetManifest(gCtx, &instance)
+			if err != nil {
+				return errors.Wrapf(err, "Cannot retrieve the manifest of the variant %s", m.Platforms[i])
+			}
+
+			m.Platforms[i].Size, err = imageSize(blob, mimeType)
+
+			return err
+		})
+	}
+
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
+
+// singlePlatform returns the platform of a single architecture image, read from its config
+func singlePlatform(ctx context.Context, src manifestSource, blob []byte, mimeType string) (*Platform, error) {
+	parsed, err := manifest.FromBlob(blob, mimeType)
+	if err != nil {
+		return nil, errors.Wrap(err, "Cannot parse the image manifest")
+	}
+
+	size, err := imageSize(blob, mimeType)
+	if err != nil {
+		return nil, err
+	}
+
+	reader, _, err := src.GetBlob(ctx, parsed.ConfigInfo(), none.NoCache)
+	if err != nil {
+		return nil, errors.Wrap(err, "Cannot retrieve the image config")
+	}
+	defer reader.Close()
+
+	var config struct {
+		OS           string `json:"os"`
+		Architecture string `json:"architecture"`
+		Variant      string `json:"variant"`
+	}
+	if err := json.NewDecoder(reader).Decode(&config); err != nil {
+		return nil, errors.Wrap(err, "Cannot parse the image config")
+	}
+
+	return &Platform{OS: config.OS, Architecture: config.Architecture, Variant: config.Variant, Size: size}, nil
+}
+
+// imageSize returns the size of the config and the compressed layers of an image manifest
+func imageSize(blob []byte, mimeType string) (int64, error) {
+	parsed, err := manifest.FromBlob(blob, mimeType)
+	if err != nil {
+		return 0, errors.Wrap(err, "Cannot parse the image manifest")
+	}
+
+	size := parsed.ConfigInfo().Size
+	for _, layer := range parsed.LayerInfos() {
+		size += layer.Size
+	}
+
+	return size, nil
+}
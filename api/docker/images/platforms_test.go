@@ -0,0 +1,162 @@
+package images
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+
+	imagetypes "github.com/containers/image/v5/types"
+	"github.com/docker/docker/api/types/swarm"
+	"github.com/opencontainers/go-digest"
+	imgspecv1 "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeManifestSource struct {
+	index     []byte
+	indexType string
+	manifests map[digest.Digest][]byte
+	blobs     map[digest.Digest][]byte
+}
+
+func (src *fakeManifestSource) GetManifest(ctx context.Context, instanceDigest *digest.Digest) ([]byte, string, error) {
+	if instanceDigest == nil {
+		return src.index, src.indexType, nil
+	}
+
+	blob, ok := src.manifests[*instanceDigest]
+	if !ok {
+		return nil, "", errors.New("manifest unknown")
+	}
+
+	return blob, imgspecv1.MediaTypeImageManifest, nil
+}
+
+func (src *fakeManifestSource) GetBlob(ctx context.Context, info imagetypes.BlobInfo, cache imagetypes.BlobInfoCache) (io.ReadCloser, int64, error) {
+	blob, ok := src.blobs[info.Digest]
+	if !ok {
+		return nil, 0, errors.New("blob unknown")
+	}
+
+	return io.NopCloser(bytes.NewReader(blob)), int64(len(blob)), nil
+}
+
+func imageManifest(configSize, layerSize int64) []byte {
+	return []byte(fmt.Sprintf(`{
+		"schemaVersion": 2,
+		"mediaType": "application/vnd.oci.image.manifest.v1+json",
+		"config": {"mediaType": "application/vnd.oci.image.config.v1+json", "digest": "%s", "size": %d},
+		"layers": [{"mediaType": "application/vnd.oci.image.layer.v1.tar+gzip", "digest": "%s", "size": %d}]
+	}`, digest.FromString("config"), configSize, digest.FromString("layer"), layerSize))
+}
+
+func TestReadManifestList(t *testing.T) {
+	is := require.New(t)
+
+	amd64 := imageManifest(100, 1000)
+	arm64 := imageManifest(200, 2000)
+	attestation := imageManifest(1, 1)
+
+	index := []byte(fmt.Sprintf(`{
+		"schemaVersion": 2,
+		"mediaType": "application/vnd.oci.image.index.v1+json",
+		"manifests": [
+			{"mediaType": "application/vnd.oci.image.manifest.v1+json", "digest": "%s", "size": %d, "platform": {"os": "linux", "architecture": "amd64"}},
+			{"mediaType": "application/vnd.oci.image.manifest.v1+json", "digest": "%s", "size": %d, "platform": {"os": "linux", "architecture": "arm64", "variant": "v8"}},
+			{"mediaType": "application/vnd.oci.image.manifest.v1+json", "digest": "%s", "size": %d, "platform": {"os": "unknown", "architecture": "unknown"}}
+		]
+	}`, digest.FromBytes(amd64), len(amd64), digest.FromBytes(arm64), len(arm64), digest.FromBytes(attestation), len(attestation)))
+
+	src := &fakeManifestSource{
+		index:     index,
+		indexType: imgspecv1.MediaTypeImageIndex,
+		manifests: map[digest.Digest][]byte{
+			digest.FromBytes(amd64):       amd64,
+			digest.FromBytes(arm64):       arm64,
+			digest.FromBytes(attestation): attestation,
+		},
+	}
+
+	m, err := readManifest(context.Background(), "nginx:latest", src)
+	is.NoError(err)
+	is.True(m.MultiArch)
+	is.Equal(digest.FromBytes(index).String(), m.Digest)
+	is.Equal([]Platform{
+		{OS: "linux", Architecture: "amd64", Digest: digest.FromBytes(amd64).String(), Size: 1100},
+		{OS: "linux", Architecture: "arm64", Variant: "v8", Digest: digest.FromBytes(arm64).String(), Size: 2200},
+	}, m.Platforms)
+
+	// the variant of an unreachable manifest fails the resolution
+	delete(src.manifests, digest.FromBytes(arm64))
+	_, err = readManifest(context.Background(), "nginx:latest", src)
+	is.ErrorContains(err, "linux/arm64/v8")
+}
+
+func TestReadSingleManifest(t *testing.T) {
+	is := require.New(t)
+
+	config := []byte(`{"os": "linux", "architecture": "arm", "variant": "v7"}`)
+	blob := imageManifest(int64(len(config)), 500)
+
+	src := &fakeManifestSource{
+		index:     blob,
+		indexType: imgspecv1.MediaTypeImageManifest,
+		blobs:     map[digest.Digest][]byte{digest.FromString("config"): config},
+	}
+
+	m, err := readManifest(context.Background(), "nginx:latest", src)
+	is.NoError(err)
+	is.False(m.MultiArch)
+	is.Equal([]Platform{
+		{OS: "linux", Architecture: "arm", Variant: "v7", Digest: digest.FromBytes(blob).String(), Size: int64(len(config)) + 500},
+	}, m.Platforms)
+}
+
+func TestPlatformFor(t *testing.T) {
+	is := require.New(t)
+
+	m := &Manifest{Platforms: []Platform{
+		{OS: "linux", Architecture: "amd64"},
+		{OS: "linux", Architecture: "arm64", Variant: "v8"},
+		{OS: "linux", Architecture: "arm", Variant: "v7"},
+	}}
+
+	is.Equal("linux/amd64", m.PlatformFor("linux", "x86_64", "").String())
+	is.Equal("linux/arm64/v8", m.PlatformFor("linux", "aarch64", "").String())
+	is.Equal("linux/arm64/v8", m.PlatformFor("linux", "arm64", "").String())
+	is.Equal("linux/arm/v7", m.PlatformFor("linux", "armv7l", "").String())
+	is.Nil(m.PlatformFor("linux", "armv6l", ""))
+	is.Nil(m.PlatformFor("windows", "x86_64", ""))
+}
+
+func TestPlatformWarnings(t *testing.T) {
+	is := require.New(t)
+
+	node := func(hostname, architecture string, state swarm.NodeState, availability swarm.NodeAvailability) swarm.Node {
+		n := swarm.Node{}
+		n.Description.Hostname = hostname
+		n.Description.Platform = swarm.Platform{OS: "linux", Architecture: architecture}
+		n.Status.State = state
+		n.Spec.Availability = availability
+
+		return n
+	}
+
+	m := &Manifest{Image: "nginx:latest", Platforms: []Platform{{OS: "linux", Architecture: "amd64"}}}
+
+	nodes := []swarm.Node{
+		node("manager", "x86_64", swarm.NodeStateReady, swarm.NodeAvailabilityActive),
+		node("pi-1", "aarch64", swarm.NodeStateReady, swarm.NodeAvailabilityActive),
+		node("pi-2", "aarch64", swarm.NodeStateReady, swarm.NodeAvailabilityActive),
+		node("pi-3", "aarch64", swarm.NodeStateDown, swarm.NodeAvailabilityActive),
+		node("pi-4", "armv7l", swarm.NodeStateReady, swarm.NodeAvailabilityDrain),
+	}
+
+	is.Equal([]string{"the image nginx:latest has no variant for linux/arm64, it cannot run on the nodes pi-1, pi-2"}, PlatformWarnings(m, nodes))
+
+	m.Platforms = append(m.Platforms, Platform{OS: "linux", Architecture: "arm64"})
+	is.Empty(PlatformWarnings(m, nodes))
+}
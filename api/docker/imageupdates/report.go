@@ -0,0 +1,178 @@
+// Package imageupdates compares the images of the running containers with the current digests of their tags in the
+// registries, and reports the containers, services and stacks for which an update is available
+package imageupdates
+
+import (
+	"slices"
+	"strings"
+
+	portainer "github.com/portainer/portainer/api"
+	consts "github.com/portainer/portainer/api/docker/consts"
+	"github.com/portainer/portainer/api/docker/images"
+
+	"github.com/docker/docker/api/types"
+)
+
+// Container represents a running container and the status of its image
+type Container struct {
+	ID    string `json:"Id" example:"5c3b...1f2a"`
+	Name  string `json:"Name" example:"web-1"`
+	Image string `json:"Image" example:"nginx:latest"`
+	// Identifier of the Swarm service of the container
+	ServiceID string `json:"ServiceId,omitempty" example:"k3b9x8y2l0c7"`
+	// Name of the compose project or Swarm stack of the container
+	StackName string        `json:"StackName,omitempty" example:"web"`
+	Status    images.Status `json:"Status" example:"outdated"`
+	// Whether the registry has a newer image for the tag of the container
+	UpdateAvailable bool `json:"UpdateAvailable" example:"true"`
+}
+
+// Workload represents a Swarm service or a stack and the status of the images of its running containers
+type Workload struct {
+	// Identifier of the Swarm service, empty for a stack
+	ID   string `json:"Id,omitempty" example:"k3b9x8y2l0c7"`
+	Name string `json:"Name" example:"web"`
+	// Identifier of the Portainer stack, 0 for a service or a stack not deployed through Portainer
+	StackID         portainer.StackID `json:"StackId,omitempty" example:"1"`
+	Containers      int               `json:"Containers" example:"2"`
+	Status          images.Status     `json:"Status" example:"outdated"`
+	UpdateAvailable bool              `json:"UpdateAvailable" example:"true"`
+}
+
+// Report represents the image updates available on a Docker environment(endpoint) at the time of its last check
+type Report struct {
+	EndpointID portainer.EndpointID `json:"EndpointId" example:"1"`
+	CheckedAt  int64                `json:"CheckedAt" example:"1587399600"`
+	Containers []Container          `json:"Containers"`
+	Services   []Workload           `json:"Services"`
+	Stacks     []Workload           `json:"Stacks"`
+}
+
+// NewReport builds the report of an environment(endpoint) from its running containers and the status of their images,
+// indexed by container identifier. The stacks are matched by name with the Portainer stacks of the environment
+func NewReport(endpointID portainer.EndpointID, checkedAt int64, containers []types.Container, statuses map[string]images.Status, stacks []portainer.Stack) *Report {
+	report := &Report{
+		EndpointID: endpointID,
+		CheckedAt:  checkedAt,
+		Containers: []Container{},
+		Services:   []Workload{},
+		Stacks:     []Workload{},
+	}
+
+	stackIDs := map[string]portainer.StackID{}
+	for _, stack := range stacks {
+		if stack.EndpointID == endpointID {
+			stackIDs[stack.Name] = stack.ID
+		}
+	}
+
+	services := map[string]*Workload{}
+	serviceStatuses := map[string][]images.Status{}
+	stackWorkloads := map[string]*Workload{}
+	stackStatuses := map[string][]images.Status{}
+
+	for _, ct := range containers {
+		status, ok := statuses[ct.ID]
+		if !ok {
+			status = images.Skipped
+		}
+
+		container := Container{
+			ID:              ct.ID,
+			Name:            containerName(ct),
+			Image:           ct.Image,
+			ServiceID:       ct.Labels[consts.SwarmServiceIDLabel],
+			StackName:       stackName(ct),
+			Status:          status,
+			UpdateAvailable: status == images.Outdated,
+		}
+		report.Containers = append(report.Containers, container)
+
+		if container.ServiceID != "" {
+			service, ok := services[container.ServiceID]
+			if !ok {
+				service = &Workload{ID: container.ServiceID, Name: ct.Labels[consts.SwarmServiceNameLabel]}
+				services[container.ServiceID] = service
+			}
+
+			service.Containers++
+			serviceStatuses[container.ServiceID] = append(serviceStatuses[container.ServiceID], status)
+		}
+
+		if container.StackName != "" {
+			stack, ok := stackWorkloads[container.StackName]
+			if !ok {
+				stack = &Workload{Name: container.StackName, StackID: stackIDs[container.StackName]}
+				stackWorkloads[container.StackName] = stack
+			}
+
+			stack.Containers++
+			stackStatuses[container.StackName] = append(stackStatuses[container.StackName], status)
+		}
+	}
+
+	for id, service := range services {
+		service.Status = images.FigureOut(serviceStatuses[id])
+		service.UpdateAvailable = service.Status == images.Outdated
+		report.Services = append(report.Services, *service)
+	}
+
+	for name, stack := range stackWorkloads {
+		stack.Status = images.FigureOut(stackStatuses[name])
+		stack.UpdateAvailable = stack.Status == images.Outdated
+		report.Stacks = append(report.Stacks, *stack)
+	}
+
+	slices.SortFunc(report.Containers, func(a, b Container) int { return strings.Compare(a.Name, b.Name) })
+	slices.SortFunc(report.Services, func(a, b Workload) int { return strings.Compare(a.Name, b.Name) })
+	slices.SortFunc(report.Stacks, func(a, b Workload) int { return strings.Compare(a.Name, b.Name) })
+
+	return report
+}
+
+// Outdated returns a copy of the report only keeping the containers, services and stacks having an update available
+func (report *Report) Outdated() *Report {
+	outdated := &Report{
+		EndpointID: report.EndpointID,
+		CheckedAt:  report.CheckedAt,
+		Containers: []Container{},
+		Services:   []Workload{},
+		Stacks:     []Workload{},
+	}
+
+	for _, container := range report.Containers {
+		if container.UpdateAvailable {
+			outdated.Containers = append(outdated.Containers, container)
+		}
+	}
+
+	for _, service := range report.Services {
+		if service.UpdateAvailable {
+			outdated.Services = append(outdated.Services, service)
+		}
+	}
+
+	for _, stack := range report.Stacks {
+		if stack.UpdateAvailable {
+			outdated.Stacks = append(outdated.Stacks, stack)
+		}
+	}
+
+	return outdated
+}
+
+func containerName(ct types.Container) string {
+	if len(ct.Names) == 0 {
+		return ct.ID
+	}
+
+	return strings.TrimPrefix(ct.Names[0], "/")
+}
+
+func stackName(ct types.Container) string {
+	if name := ct.Labels[consts.ComposeStackNameLabel]; name != "" {
+		return name
+	}
+
+	return ct.Labels[consts.SwarmStackNameLabel]
+}
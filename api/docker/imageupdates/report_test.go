@@ -0,0 +1,66 @@
+package imageupdates
+
+import (
+	"testing"
+
+	portainer "github.com/portainer/portainer/api"
+	consts "github.com/portainer/portainer/api/docker/consts"
+	"github.com/portainer/portainer/api/docker/images"
+
+	"github.com/docker/docker/api/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewReport(t *testing.T) {
+	is := require.New(t)
+
+	containers := []types.Container{
+		{ID: "1", Names: []string{"/web-1"}, Image: "nginx:latest", Labels: map[string]string{consts.ComposeStackNameLabel: "web"}},
+		{ID: "2", Names: []string{"/web-2"}, Image: "redis:7", Labels: map[string]string{consts.ComposeStackNameLabel: "web"}},
+		{ID: "3", Names: []string{"/api.1.x"}, Image: "api:1", Labels: map[string]string{
+			consts.SwarmStackNameLabel:   "backend",
+			consts.SwarmServiceIDLabel:   "s1",
+			consts.SwarmServiceNameLabel: "backend_api",
+		}},
+		{ID: "4", Names: []string{"/api.2.y"}, Image: "api:1", Labels: map[string]string{
+			consts.SwarmStackNameLabel:   "backend",
+			consts.SwarmServiceIDLabel:   "s1",
+			consts.SwarmServiceNameLabel: "backend_api",
+		}},
+		{ID: "5", Names: []string{"/standalone"}, Image: "local"},
+	}
+
+	statuses := map[string]images.Status{
+		"1": images.Outdated,
+		"2": images.Updated,
+		"3": images.Updated,
+		"4": images.Error,
+	}
+
+	stacks := []portainer.Stack{
+		{ID: 7, Name: "web", EndpointID: 1},
+		{ID: 8, Name: "backend", EndpointID: 2},
+	}
+
+	report := NewReport(1, 100, containers, statuses, stacks)
+	is.Equal(portainer.EndpointID(1), report.EndpointID)
+	is.Len(report.Containers, 5)
+
+	// the containers are sorted by name, and the ones without a status are skipped
+	is.Equal(Container{ID: "5", Name: "standalone", Image: "local", Status: images.Skipped}, report.Containers[2])
+
+	is.Equal([]Workload{{ID: "s1", Name: "backend_api", Containers: 2, Status: images.Error}}, report.Services)
+
+	// the backend stack of the environment was not deployed through Portainer
+	is.Equal([]Workload{
+		{Name: "backend", Containers: 2, Status: images.Error},
+		{Name: "web", StackID: 7, Containers: 2, Status: images.Outdated, UpdateAvailable: true},
+	}, report.Stacks)
+
+	outdated := report.Outdated()
+	is.Len(outdated.Containers, 1)
+	is.Equal("web-1", outdated.Containers[0].Name)
+	is.Empty(outdated.Services)
+	is.Len(outdated.Stacks, 1)
+	is.Equal(portainer.StackID(7), outdated.Stacks[0].StackID)
+}
@@ -0,0 +1,184 @@
+package imageupdates
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+	dockerclient "github.com/portainer/portainer/api/docker/client"
+	"github.com/portainer/portainer/api/docker/images"
+	"github.com/portainer/portainer/api/internal/endpointutils"
+	endpointsutils "github.com/portainer/portainer/pkg/endpoints"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// CheckInterval is the interval at which the images of the running containers are compared with the registries
+	CheckInterval = time.Hour
+	// checkTimeout bounds the check of the images of an environment(endpoint)
+	checkTimeout = 10 * time.Minute
+)
+
+var (
+	// ErrNotChecked is returned when the report of an environment(endpoint) is requested before its first check
+	ErrNotChecked = errors.New("the images of the environment have not been checked yet")
+	// ErrNoConnectivity is returned when the images of an environment(endpoint) cannot be checked because Portainer
+	// cannot reach it
+	ErrNoConnectivity = errors.New("the environment cannot be reached by Portainer")
+)
+
+// Service checks on a schedule or on demand whether the registries have newer images for the tags of the running
+// containers, and keeps the report of the last check of each Docker environment(endpoint)
+type Service struct {
+	dataStore     dataservices.DataStore
+	clientFactory *dockerclient.ClientFactory
+	digestClient  *images.DigestClient
+
+	mu      sync.RWMutex
+	reports map[portainer.EndpointID]*Report
+}
+
+// NewService creates a new image update service
+func NewService(dataStore dataservices.DataStore, clientFactory *dockerclient.ClientFactory) *Service {
+	return &Service{
+		dataStore:     dataStore,
+		clientFactory: clientFactory,
+		digestClient:  images.NewClientWithRegistry(images.NewRegistryClient(dataStore), clientFactory),
+		reports:       map[portainer.EndpointID]*Report{},
+	}
+}
+
+// Report returns the report of the last check of an environment(endpoint)
+func (service *Service) Report(endpointID portainer.EndpointID) (*Report, error) {
+	service.mu.RLock()
+	defer service.mu.RUnlock()
+
+	report, ok := service.reports[endpointID]
+	if !ok {
+		return nil, ErrNotChecked
+	}
+
+	return report, nil
+}
+
+// Reports returns the reports of the last check of every Docker environment(endpoint) still existing
+func (service *Service) Reports() ([]Report, error) {
+	endpoints, err := service.dataStore.Endpoint().Endpoints()
+	if err != nil {
+		return nil, err
+	}
+
+	service.mu.RLock()
+	defer service.mu.RUnlock()
+
+	reports := []Report{}
+
+	for _, endpoint := range endpoints {
+		if report, ok := service.reports[endpoint.ID]; ok {
+			reports = append(reports, *report)
+		}
+	}
+
+	return reports, nil
+}
+
+// Check compares the images of the running containers of an environment(endpoint) with the registries and records
+// the report
+func (service *Service) Check(endpoint *portainer.Endpoint) (*Report, error) {
+	if !endpointsutils.HasDirectConnectivity(endpoint) {
+		return nil, ErrNoConnectivity
+	}
+
+	cli, err := service.clientFactory.CreateClient(endpoint, "", nil)
+	if err != nil {
+		return nil, err
+	}
+	defer cli.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), checkTimeout)
+	defer cancel()
+
+	containers, err := cli.ContainerList(ctx, container.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	stacks, err := service.dataStore.Stack().ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	// the containers running the same image share its status, so that each tag is only resolved once
+	imageStatuses := map[string]images.Status{}
+	statuses := make(map[string]images.Status, len(containers))
+
+	for _, ct := range containers {
+		key := ct.Image + "@" + ct.ImageID
+
+		status, ok := imageStatuses[key]
+		if !ok {
+			status = service.digestClient.ContainersImageStatus(ctx, []types.Container{ct}, endpoint)
+			imageStatuses[key] = status
+		}
+
+		statuses[ct.ID] = status
+	}
+
+	report := NewReport(endpoint.ID, time.Now().Unix(), containers, statuses, stacks)
+
+	service.mu.Lock()
+	service.reports[endpoint.ID] = report
+	service.mu.Unlock()
+
+	return report, nil
+}
+
+// CheckAll checks the images of the reachable Docker environments(endpoints) and forgets the reports of the deleted
+// ones
+func (service *Service) CheckAll() error {
+	endpoints, err := service.dataStore.Endpoint().Endpoints()
+	if err != nil {
+		return err
+	}
+
+	existing := map[portainer.EndpointID]bool{}
+	checked, outdated := 0, 0
+
+	for i := range endpoints {
+		endpoint := &endpoints[i]
+		existing[endpoint.ID] = true
+
+		if !endpointutils.IsDockerEndpoint(endpoint) || !endpointsutils.HasDirectConnectivity(endpoint) ||
+			endpoint.Status != portainer.EndpointStatusUp {
+			continue
+		}
+
+		report, err := service.Check(endpoint)
+		if err != nil {
+			log.Warn().Err(err).Int("endpoint_id", int(endpoint.ID)).Msg("unable to check the image updates of the environment")
+
+			continue
+		}
+
+		checked++
+		outdated += len(report.Outdated().Containers)
+	}
+
+	service.mu.Lock()
+	for endpointID := range service.reports {
+		if !existing[endpointID] {
+			delete(service.reports, endpointID)
+		}
+	}
+	service.mu.Unlock()
+
+	log.Info().Int("environments", checked).Int("outdated_containers", outdated).Msg("image updates checked")
+
+	return nil
+}
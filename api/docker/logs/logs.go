@@ -0,0 +1,247 @@
+// Package logs reads the output of the containers and filters it on the server, so that the clients only receive the
+// lines they are looking for
+package logs
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/pkg/stdcopy"
+	"github.com/pkg/errors"
+)
+
+const (
+	// Stdout is the stream of the lines written to the standard output of a container
+	Stdout = "stdout"
+	// Stderr is the stream of the lines written to the standard error of a container
+	Stderr = "stderr"
+	// TailAll reads the whole output of a container
+	TailAll = -1
+	// maxLineSize is the size after which a line without an end is split
+	maxLineSize = 256 * 1024
+)
+
+// Line is a line of the output of a container
+type Line struct {
+	// Time at which the line was written, zero when the Docker daemon did not record it
+	Timestamp time.Time `json:"Timestamp"`
+	// Stream the line was written to, stdout or stderr
+	Stream string `json:"Stream" example:"stdout"`
+	// Content of the line, without its end
+	Text string `json:"Text" example:"Listening on port 8080"`
+}
+
+// Options selects the part of the output of a container to read
+type Options struct {
+	// Unix timestamps bounding the lines, zero when unbounded
+	Since int64
+	Until int64
+	// Number of lines to read from the end of the output, TailAll for the whole output
+	Tail   int
+	Stdout bool
+	Stderr bool
+	// Keep reading the new lines until the container stops or the context is done
+	Follow bool
+}
+
+// Client is the part of the Docker client used to read the output of a container
+type Client interface {
+	ContainerLogs(ctx context.Context, container string, options container.LogsOptions) (io.ReadCloser, error)
+}
+
+// Open opens the output of a container, which is read with Read
+func Open(ctx context.Context, cli Client, containerID string, options Options) (io.ReadCloser, error) {
+	if !options.Stdout && !options.Stderr {
+		return nil, errors.New("at least one of the stdout and stderr streams must be read")
+	}
+
+	logsOptions := container.LogsOptions{
+		ShowStdout: options.Stdout,
+		ShowStderr: options.Stderr,
+		Follow:     options.Follow,
+		Timestamps: true,
+		Tail:       "all",
+	}
+
+	if options.Tail >= 0 {
+		logsOptions.Tail = strconv.Itoa(options.Tail)
+	}
+
+	if options.Since > 0 {
+		logsOptions.Since = strconv.FormatInt(options.Since, 10)
+	}
+
+	if options.Until > 0 {
+		logsOptions.Until = strconv.FormatInt(options.Until, 10)
+	}
+
+	return cli.ContainerLogs(ctx, containerID, logsOptions)
+}
+
+// Filter selects the lines containing a substring, ignoring the case, or matching a regular expression
+type Filter struct {
+	substring string
+	regex     *regexp.Regexp
+}
+
+// NewFilter creates a filter from a search, which is a regular expression when regex is set. An empty search matches
+// every line
+func NewFilter(search string, regex bool) (*Filter, error) {
+	if !regex {
+		return &Filter{substring: strings.ToLower(search)}, nil
+	}
+
+	expression, err := regexp.Compile(search)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid regular expression")
+	}
+
+	return &Filter{regex: expression}, nil
+}
+
+// Match returns whether a line is selected by the filter
+func (filter *Filter) Match(line Line) bool {
+	if filter == nil {
+		return true
+	}
+
+	if filter.regex != nil {
+		return filter.regex.MatchString(line.Text)
+	}
+
+	return filter.substring == "" || strings.Contains(strings.ToLower(line.Text), filter.substring)
+}
+
+// Read reads the output opened by Open and calls fn for each line selected by the filter, until the end of the output
+// or an error returned by fn. The output of a container with a TTY has a single stream
+func Read(r io.Reader, tty bool, filter *Filter, fn func(Line) error) error {
+	stdout := &lineWriter{stream: Stdout, filter: filter, fn: fn}
+	stderr := &lineWriter{stream: Stderr, filter: filter, fn: fn}
+
+	var err error
+	if tty {
+		_, err = io.Copy(stdout, r)
+	} else {
+		_, err = stdcopy.StdCopy(stdout, stderr, r)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if err := stdout.flush(); err != nil {
+		return err
+	}
+
+	return stderr.flush()
+}
+
+// SearchResult holds the last lines selected by a search
+type SearchResult struct {
+	Lines []Line `json:"Lines"`
+	// Number of lines selected by the search, more than the returned ones when the result was truncated
+	Matches int `json:"Matches" example:"42"`
+	// Whether only the last selected lines were returned
+	Truncated bool `json:"Truncated" example:"false"`
+}
+
+// Search reads the output opened by Open and returns the last lines selected by the filter, at most limit of them
+func Search(r io.Reader, tty bool, filter *Filter, limit int) (*SearchResult, error) {
+	result := &SearchResult{Lines: []Line{}}
+
+	err := Read(r, tty, filter, func(line Line) error {
+		result.Matches++
+
+		result.Lines = append(result.Lines, line)
+		if len(result.Lines) > limit {
+			result.Lines = result.Lines[1:]
+			result.Truncated = true
+		}
+
+		return nil
+	})
+
+	return result, err
+}
+
+// lineWriter splits a stream into lines
+type lineWriter struct {
+	stream string
+	filter *Filter
+	fn     func(Line) error
+	buf    []byte
+}
+
+func (writer *lineWriter) Write(p []byte) (int, error) {
+	writer.buf = append(writer.buf, p...)
+
+	rest := writer.buf
+	for {
+		i := bytes.IndexByte(rest, '\n')
+		if i < 0 {
+			break
+		}
+
+		if err := writer.emit(rest[:i]); err != nil {
+			return 0, err
+		}
+
+		rest = rest[i+1:]
+	}
+
+	if len(rest) > maxLineSize {
+		if err := writer.emit(rest); err != nil {
+			return 0, err
+		}
+
+		rest = nil
+	}
+
+	writer.buf = append(writer.buf[:0], rest...)
+
+	return len(p), nil
+}
+
+func (writer *lineWriter) flush() error {
+	if len(writer.buf) == 0 {
+		return nil
+	}
+
+	err := writer.emit(writer.buf)
+	writer.buf = nil
+
+	return err
+}
+
+func (writer *lineWriter) emit(raw []byte) error {
+	line := parseLine(writer.stream, string(bytes.TrimSuffix(raw, []byte("\r"))))
+	if !writer.filter.Match(line) {
+		return nil
+	}
+
+	return writer.fn(line)
+}
+
+// parseLine separates the timestamp added by the Docker daemon from the content of a line
+func parseLine(stream, raw string) Line {
+	line := Line{Stream: stream, Text: raw}
+
+	timestamp, text, found := strings.Cut(raw, " ")
+	if !found {
+		timestamp = raw
+		text = ""
+	}
+
+	if t, err := time.Parse(time.RFC3339Nano, timestamp); err == nil {
+		line.Timestamp = t
+		line.Text = text
+	}
+
+	return line
+}
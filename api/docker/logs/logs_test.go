@@ -0,0 +1,125 @@
+package logs
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/pkg/stdcopy"
+	"github.com/stretchr/testify/require"
+)
+
+type testClient struct {
+	options container.LogsOptions
+}
+
+func (c *testClient) ContainerLogs(ctx context.Context, container string, options container.LogsOptions) (io.ReadCloser, error) {
+	c.options = options
+
+	return io.NopCloser(strings.NewReader("")), nil
+}
+
+func multiplexed(frames ...[2]string) io.Reader {
+	buf := &bytes.Buffer{}
+	for _, frame := range frames {
+		stream := stdcopy.Stdout
+		if frame[0] == Stderr {
+			stream = stdcopy.Stderr
+		}
+
+		stdcopy.NewStdWriter(buf, stream).Write([]byte(frame[1]))
+	}
+
+	return buf
+}
+
+func TestOpen(t *testing.T) {
+	is := require.New(t)
+
+	cli := &testClient{}
+	_, err := Open(context.Background(), cli, "abc", Options{Tail: TailAll, Stdout: true, Since: 100})
+	is.NoError(err)
+	is.Equal(container.LogsOptions{ShowStdout: true, Timestamps: true, Tail: "all", Since: "100"}, cli.options)
+
+	_, err = Open(context.Background(), cli, "abc", Options{Tail: 10, Stdout: true, Stderr: true, Follow: true})
+	is.NoError(err)
+	is.Equal("10", cli.options.Tail)
+	is.True(cli.options.Follow)
+
+	_, err = Open(context.Background(), cli, "abc", Options{})
+	is.Error(err)
+}
+
+func TestRead(t *testing.T) {
+	is := require.New(t)
+
+	out := multiplexed(
+		[2]string{Stdout, "2024-05-01T10:00:00.5Z starting\n2024-05-01T10:00:01Z list"},
+		[2]string{Stderr, "2024-05-01T10:00:02Z warning: low memory\r\n"},
+		[2]string{Stdout, "ening on :80\n2024-05-01T10:00:03Z done"},
+	)
+
+	var lines []Line
+	err := Read(out, false, nil, func(line Line) error {
+		lines = append(lines, line)
+		return nil
+	})
+	is.NoError(err)
+
+	// the lines split across frames are joined, and the last line is kept without its end
+	is.Equal([]Line{
+		{Timestamp: time.Date(2024, 5, 1, 10, 0, 0, 500000000, time.UTC), Stream: Stdout, Text: "starting"},
+		{Timestamp: time.Date(2024, 5, 1, 10, 0, 2, 0, time.UTC), Stream: Stderr, Text: "warning: low memory"},
+		{Timestamp: time.Date(2024, 5, 1, 10, 0, 1, 0, time.UTC), Stream: Stdout, Text: "listening on :80"},
+		{Timestamp: time.Date(2024, 5, 1, 10, 0, 3, 0, time.UTC), Stream: Stdout, Text: "done"},
+	}, lines)
+
+	// the output of a container with a TTY is not multiplexed
+	lines = nil
+	err = Read(strings.NewReader("no timestamp\n"), true, nil, func(line Line) error {
+		lines = append(lines, line)
+		return nil
+	})
+	is.NoError(err)
+	is.Equal([]Line{{Stream: Stdout, Text: "no timestamp"}}, lines)
+}
+
+func TestFilter(t *testing.T) {
+	is := require.New(t)
+
+	filter, err := NewFilter("ERROR", false)
+	is.NoError(err)
+	is.True(filter.Match(Line{Text: "an error occurred"}))
+	is.False(filter.Match(Line{Text: "all good"}))
+
+	filter, err = NewFilter(`status=5\d\d`, true)
+	is.NoError(err)
+	is.True(filter.Match(Line{Text: "GET / status=503"}))
+	is.False(filter.Match(Line{Text: "GET / status=200"}))
+
+	_, err = NewFilter("(", true)
+	is.Error(err)
+
+	filter, err = NewFilter("", false)
+	is.NoError(err)
+	is.True(filter.Match(Line{Text: "anything"}))
+}
+
+func TestSearch(t *testing.T) {
+	is := require.New(t)
+
+	filter, err := NewFilter("request", false)
+	is.NoError(err)
+
+	out := strings.NewReader("request 1\nidle\nrequest 2\nrequest 3\n")
+
+	result, err := Search(out, true, filter, 2)
+	is.NoError(err)
+	is.Equal(3, result.Matches)
+	is.True(result.Truncated)
+	is.Equal([]Line{{Stream: Stdout, Text: "request 2"}, {Stream: Stdout, Text: "request 3"}}, result.Lines)
+}
@@ -0,0 +1,274 @@
+package orphans
+
+import (
+	"context"
+	"slices"
+	"strings"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/image"
+	"github.com/docker/docker/api/types/mount"
+	"github.com/docker/docker/api/types/swarm"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	ResourceVolume  = "volume"
+	ResourceImage   = "image"
+	ResourceNetwork = "network"
+)
+
+// CleanupClient is the part of the Docker client used to remove the orphaned resources
+type CleanupClient interface {
+	ServiceList(ctx context.Context, options types.ServiceListOptions) ([]swarm.Service, error)
+	VolumeRemove(ctx context.Context, volumeID string, force bool) error
+	ImageRemove(ctx context.Context, imageID string, options image.RemoveOptions) ([]image.DeleteResponse, error)
+	NetworkRemove(ctx context.Context, networkID string) error
+}
+
+// Selection represents the orphaned resources of a report to remove
+type Selection struct {
+	// Names of the volumes
+	Volumes []string `json:"Volumes" example:"myapp_data"`
+	// Identifiers of the images
+	Images []string `json:"Images" example:"sha256:4c94a3a8d8fb1ae1a6c8f5ff3b5ab8bfa0e2c7a1c1c7e0e9c2c9b6a4a1f2d3e4"`
+	// Identifiers of the networks
+	Networks []string `json:"Networks" example:"0a3f2b1c4d5e"`
+}
+
+// CleanupError represents a resource which could not be removed
+type CleanupError struct {
+	ResourceType string `json:"ResourceType" example:"volume"`
+	ResourceID   string `json:"ResourceId" example:"myapp_data"`
+	Error        string `json:"Error" example:"volume is in use"`
+}
+
+// CleanupResult represents the outcome of the removal of the orphaned resources of an environment(endpoint)
+type CleanupResult struct {
+	Volumes  []string       `json:"Volumes"`
+	Images   []string       `json:"Images"`
+	Networks []string       `json:"Networks"`
+	Errors   []CleanupError `json:"Errors"`
+	// Disk space used by the removed images, in bytes
+	ReclaimedSize int64 `json:"ReclaimedSize" example:"187000000"`
+}
+
+// Cleanup removes the resources of the selection which are orphaned according to the report. The resources still
+// used by a Swarm service are skipped, the Docker daemon refusing to remove the ones used by a container
+func Cleanup(ctx context.Context, cli CleanupClient, report *Report, selection Selection, isSwarm bool) (*CleanupResult, error) {
+	return cleanup(ctx, cli, report, selection, isSwarm, false)
+}
+
+// Preview returns the resources Cleanup would remove, without removing them
+func Preview(ctx context.Context, cli CleanupClient, report *Report, selection Selection, isSwarm bool) (*CleanupResult, error) {
+	return cleanup(ctx, cli, report, selection, isSwarm, true)
+}
+
+func cleanup(ctx context.Context, cli CleanupClient, report *Report, selection Selection, isSwarm, dryRun bool) (*CleanupResult, error) {
+	result := &CleanupResult{
+		Volumes:  []string{},
+		Images:   []string{},
+		Networks: []string{},
+		Errors:   []CleanupError{},
+	}
+
+	used := serviceResources{}
+	if isSwarm {
+		services, err := cli.ServiceList(ctx, types.ServiceListOptions{})
+		if err != nil {
+			return nil, err
+		}
+
+		used = newServiceResources(services)
+	}
+
+	for _, volume := range report.Volumes {
+		if !slices.Contains(selection.Volumes, volume.Name) {
+			continue
+		}
+
+		if _, ok := used.volumes[volume.Name]; ok {
+			continue
+		}
+
+		if dryRun {
+			result.Volumes = append(result.Volumes, volume.Name)
+
+			continue
+		}
+
+		if err := cli.VolumeRemove(ctx, volume.Name, false); err != nil {
+			result.addError(ResourceVolume, volume.Name, err)
+
+			continue
+		}
+
+		result.Volumes = append(result.Volumes, volume.Name)
+	}
+
+	for _, img := range report.Images {
+		if !slices.Contains(selection.Images, img.ID) || used.usesImage(img) {
+			continue
+		}
+
+		if dryRun {
+			result.Images = append(result.Images, img.ID)
+			result.ReclaimedSize += img.Size
+
+			continue
+		}
+
+		if err := removeImage(ctx, cli, img); err != nil {
+			result.addError(ResourceImage, img.ID, err)
+
+			continue
+		}
+
+		result.Images = append(result.Images, img.ID)
+		result.ReclaimedSize += img.Size
+	}
+
+	for _, network := range report.Networks {
+		if !slices.Contains(selection.Networks, network.ID) {
+			continue
+		}
+
+		_, usedByID := used.networks[network.ID]
+		_, usedByName := used.networks[network.Name]
+
+		if usedByID || usedByName {
+			continue
+		}
+
+		if dryRun {
+			result.Networks = append(result.Networks, network.ID)
+
+			continue
+		}
+
+		if err := cli.NetworkRemove(ctx, network.ID); err != nil {
+			result.addError(ResourceNetwork, network.ID, err)
+
+			continue
+		}
+
+		result.Networks = append(result.Networks, network.ID)
+	}
+
+	return result, nil
+}
+
+// SelectAll returns the selection of all the resources of the given types of a report
+func SelectAll(report *Report, volumes, images, networks bool) Selection {
+	var selection Selection
+
+	if volumes {
+		for _, volume := range report.Volumes {
+			selection.Volumes = append(selection.Volumes, volume.Name)
+		}
+	}
+
+	if images {
+		for _, img := range report.Images {
+			selection.Images = append(selection.Images, img.ID)
+		}
+	}
+
+	if networks {
+		for _, network := range report.Networks {
+			selection.Networks = append(selection.Networks, network.ID)
+		}
+	}
+
+	return selection
+}
+
+// removeImage removes an image through its tags, the daemon refusing to untag an image used by a container, or
+// through its identifier when it is untagged
+func removeImage(ctx context.Context, cli CleanupClient, img Image) error {
+	if len(img.Tags) == 0 {
+		_, err := cli.ImageRemove(ctx, img.ID, image.RemoveOptions{PruneChildren: true})
+
+		return err
+	}
+
+	for _, tag := range img.Tags {
+		if _, err := cli.ImageRemove(ctx, tag, image.RemoveOptions{PruneChildren: true}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (result *CleanupResult) addError(resourceType, resourceID string, err error) {
+	log.Warn().Err(err).Str("type", resourceType).Str("id", resourceID).Msg("unable to remove the orphaned resource")
+
+	result.Errors = append(result.Errors, CleanupError{ResourceType: resourceType, ResourceID: resourceID, Error: err.Error()})
+}
+
+// serviceResources holds the volumes, images and networks referenced by the Swarm services
+type serviceResources struct {
+	volumes  map[string]struct{}
+	images   map[string]struct{}
+	networks map[string]struct{}
+}
+
+func newServiceResources(services []swarm.Service) serviceResources {
+	used := serviceResources{
+		volumes:  make(map[string]struct{}),
+		images:   make(map[string]struct{}),
+		networks: make(map[string]struct{}),
+	}
+
+	for _, service := range services {
+		for _, network := range service.Spec.TaskTemplate.Networks {
+			used.networks[network.Target] = struct{}{}
+		}
+
+		for _, vip := range service.Endpoint.VirtualIPs {
+			used.networks[vip.NetworkID] = struct{}{}
+		}
+
+		spec := service.Spec.TaskTemplate.ContainerSpec
+		if spec == nil {
+			continue
+		}
+
+		// the image of a service is pinned to its digest, e.g. nginx:1.25@sha256:...
+		ref, digest, _ := strings.Cut(spec.Image, "@")
+		used.images[ref] = struct{}{}
+		if digest != "" {
+			used.images[digest] = struct{}{}
+		}
+
+		for _, m := range spec.Mounts {
+			if m.Type == mount.TypeVolume {
+				used.volumes[m.Source] = struct{}{}
+			}
+		}
+	}
+
+	return used
+}
+
+func (used serviceResources) usesImage(img Image) bool {
+	if _, ok := used.images[img.ID]; ok {
+		return true
+	}
+
+	for _, tag := range img.Tags {
+		if _, ok := used.images[tag]; ok {
+			return true
+		}
+	}
+
+	for _, repoDigest := range img.Digests {
+		_, digest, _ := strings.Cut(repoDigest, "@")
+		if _, ok := used.images[digest]; ok {
+			return true
+		}
+	}
+
+	return false
+}
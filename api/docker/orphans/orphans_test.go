@@ -0,0 +1,142 @@
+package orphans
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	portainer "github.com/portainer/portainer/api"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/image"
+	"github.com/docker/docker/api/types/mount"
+	"github.com/docker/docker/api/types/network"
+	"github.com/docker/docker/api/types/swarm"
+	"github.com/docker/docker/api/types/volume"
+	"github.com/stretchr/testify/require"
+)
+
+type cleanupTestClient struct {
+	services []swarm.Service
+	removed  []string
+	inUse    map[string]bool
+}
+
+func (c *cleanupTestClient) ServiceList(ctx context.Context, options types.ServiceListOptions) ([]swarm.Service, error) {
+	return c.services, nil
+}
+
+func (c *cleanupTestClient) remove(id string) error {
+	if c.inUse[id] {
+		return errors.New("in use")
+	}
+
+	c.removed = append(c.removed, id)
+
+	return nil
+}
+
+func (c *cleanupTestClient) VolumeRemove(ctx context.Context, volumeID string, force bool) error {
+	return c.remove(volumeID)
+}
+
+func (c *cleanupTestClient) ImageRemove(ctx context.Context, imageID string, options image.RemoveOptions) ([]image.DeleteResponse, error) {
+	return nil, c.remove(imageID)
+}
+
+func (c *cleanupTestClient) NetworkRemove(ctx context.Context, networkID string) error {
+	return c.remove(networkID)
+}
+
+func testSnapshot() *portainer.DockerSnapshot {
+	container := portainer.DockerContainerSnapshot{}
+	container.ImageID = "sha256:used"
+	container.Mounts = []types.MountPoint{{Type: mount.TypeVolume, Name: "data"}, {Type: mount.TypeBind, Source: "/srv"}}
+	container.NetworkSettings = &types.SummaryNetworkSettings{Networks: map[string]*network.EndpointSettings{
+		"app_default": {NetworkID: "net-app"},
+	}}
+
+	return &portainer.DockerSnapshot{
+		Time: 100,
+		SnapshotRaw: portainer.DockerSnapshotRaw{
+			Containers: []portainer.DockerContainerSnapshot{container},
+			Volumes: volume.ListResponse{Volumes: []*volume.Volume{
+				{Name: "data", Driver: "local"},
+				{Name: "old", Driver: "local"},
+			}},
+			Images: []image.Summary{
+				{ID: "sha256:used", RepoTags: []string{"app:1"}, Size: 10},
+				{ID: "sha256:parent", Size: 5},
+				{ID: "sha256:child", ParentID: "sha256:parent", RepoTags: []string{"<none>:<none>"}, Size: 20},
+				{ID: "sha256:service", RepoTags: []string{"worker:2"}, RepoDigests: []string{"worker@sha256:abc"}, Size: 30},
+			},
+			Networks: []network.Summary{
+				{ID: "net-bridge", Name: "bridge"},
+				{ID: "net-app", Name: "app_default"},
+				{ID: "net-old", Name: "old_default"},
+			},
+		},
+	}
+}
+
+func TestNewReport(t *testing.T) {
+	is := require.New(t)
+
+	report := NewReport(&portainer.Endpoint{ID: 1, Name: "local"}, testSnapshot())
+
+	is.Equal(int64(100), report.SnapshotTime)
+	is.Equal([]Volume{{Name: "old", Driver: "local"}}, report.Volumes)
+	is.Equal([]Network{{ID: "net-old", Name: "old_default"}}, report.Networks)
+
+	is.Len(report.Images, 2, "the used and the parent images are not orphaned")
+	is.Equal("sha256:service", report.Images[0].ID)
+	is.Equal("sha256:child", report.Images[1].ID)
+	is.Empty(report.Images[1].Tags)
+	is.Equal(int64(50), report.ReclaimableSize)
+}
+
+func TestCleanup(t *testing.T) {
+	is := require.New(t)
+
+	report := NewReport(&portainer.Endpoint{ID: 1}, testSnapshot())
+
+	cli := &cleanupTestClient{
+		services: []swarm.Service{{Spec: swarm.ServiceSpec{TaskTemplate: swarm.TaskSpec{
+			ContainerSpec: &swarm.ContainerSpec{Image: "worker:3@sha256:abc"},
+		}}}},
+		inUse: map[string]bool{"net-old": true},
+	}
+
+	// the resources which are not orphaned are never removed
+	selection := SelectAll(report, true, true, true)
+	selection.Volumes = append(selection.Volumes, "data")
+
+	result, err := Cleanup(context.Background(), cli, report, selection, true)
+	is.NoError(err)
+
+	is.Equal([]string{"old"}, result.Volumes)
+	is.Equal([]string{"sha256:child"}, result.Images, "the image of the service is kept")
+	is.Empty(result.Networks)
+	is.Equal([]CleanupError{{ResourceType: ResourceNetwork, ResourceID: "net-old", Error: "in use"}}, result.Errors)
+	is.Equal(int64(20), result.ReclaimedSize)
+	is.Equal([]string{"old", "sha256:child"}, cli.removed)
+}
+
+func TestPreview(t *testing.T) {
+	is := require.New(t)
+
+	report := NewReport(&portainer.Endpoint{ID: 1}, testSnapshot())
+	cli := &cleanupTestClient{
+		services: []swarm.Service{{Spec: swarm.ServiceSpec{TaskTemplate: swarm.TaskSpec{
+			ContainerSpec: &swarm.ContainerSpec{Image: "worker:3@sha256:abc"},
+		}}}},
+	}
+
+	result, err := Preview(context.Background(), cli, report, SelectAll(report, true, true, true), true)
+	is.NoError(err)
+	is.Equal([]string{"old"}, result.Volumes)
+	is.Equal([]string{"sha256:child"}, result.Images, "the image of the service is kept")
+	is.Equal([]string{"net-old"}, result.Networks)
+	is.Equal(int64(20), result.ReclaimedSize)
+	is.Empty(cli.removed)
+}
@@ -0,0 +1,165 @@
+// Package orphans finds the volumes, images and networks of the Docker environments(endpoints) which are not used
+// anymore, from their snapshots, and removes them
+package orphans
+
+import (
+	"cmp"
+	"slices"
+	"strings"
+
+	portainer "github.com/portainer/portainer/api"
+
+	"github.com/docker/docker/api/types/mount"
+)
+
+// predefinedNetworks are the networks created by the Docker daemon, which cannot be removed
+var predefinedNetworks = []string{"bridge", "host", "none", "ingress", "docker_gwbridge"}
+
+// Volume represents a volume not mounted by any container
+type Volume struct {
+	Name      string `json:"Name" example:"myapp_data"`
+	Driver    string `json:"Driver" example:"local"`
+	CreatedAt string `json:"CreatedAt" example:"2024-01-01T00:00:00Z"`
+}
+
+// Image represents an image not used by any container
+type Image struct {
+	ID      string   `json:"Id" example:"sha256:4c94a3a8d8fb1ae1a6c8f5ff3b5ab8bfa0e2c7a1c1c7e0e9c2c9b6a4a1f2d3e4"`
+	Tags    []string `json:"Tags" example:"nginx:1.25"`
+	Digests []string `json:"Digests" example:"nginx@sha256:a484819eb60211f5299034ac80f6a681b06f89e65866ce91f356ed7c72af059c"`
+	Size    int64    `json:"Size" example:"187000000"`
+	Created int64    `json:"Created" example:"1704067200"`
+}
+
+// Network represents a network without any container attached
+type Network struct {
+	ID     string `json:"Id" example:"0a3f2b1c4d5e"`
+	Name   string `json:"Name" example:"myapp_default"`
+	Driver string `json:"Driver" example:"bridge"`
+	Scope  string `json:"Scope" example:"local"`
+	// Unix timestamp of the creation of the network, 0 when it is unknown
+	Created int64 `json:"Created" example:"1704067200"`
+}
+
+// Report represents the orphaned resources of a Docker environment(endpoint) at the time of its last snapshot.
+// The services of a Swarm environment are not part of the snapshots, the resources they use are only excluded when
+// they are cleaned up
+type Report struct {
+	EndpointID   portainer.EndpointID `json:"EndpointId" example:"1"`
+	EndpointName string               `json:"EndpointName" example:"production"`
+	SnapshotTime int64                `json:"SnapshotTime" example:"1704067200"`
+	Volumes      []Volume             `json:"Volumes"`
+	Images       []Image              `json:"Images"`
+	Networks     []Network            `json:"Networks"`
+	// Disk space used by the orphaned images, in bytes. The size of the volumes is not part of the snapshots
+	ReclaimableSize int64 `json:"ReclaimableSize" example:"187000000"`
+}
+
+// NewReport cross-references the containers of a snapshot with its volumes, images and networks to find the ones
+// that nothing uses
+func NewReport(endpoint *portainer.Endpoint, snapshot *portainer.DockerSnapshot) *Report {
+	report := &Report{
+		EndpointID:   endpoint.ID,
+		EndpointName: endpoint.Name,
+		SnapshotTime: snapshot.Time,
+		Volumes:      []Volume{},
+		Images:       []Image{},
+		Networks:     []Network{},
+	}
+
+	usedVolumes := make(map[string]struct{})
+	usedImages := make(map[string]struct{})
+	usedNetworks := make(map[string]struct{})
+
+	for _, container := range snapshot.SnapshotRaw.Containers {
+		usedImages[container.ImageID] = struct{}{}
+
+		for _, m := range container.Mounts {
+			if m.Type == mount.TypeVolume {
+				usedVolumes[m.Name] = struct{}{}
+			}
+		}
+
+		if container.NetworkSettings == nil {
+			continue
+		}
+
+		for name, settings := range container.NetworkSettings.Networks {
+			usedNetworks[name] = struct{}{}
+
+			if settings != nil {
+				usedNetworks[settings.NetworkID] = struct{}{}
+			}
+		}
+	}
+
+	for _, volume := range snapshot.SnapshotRaw.Volumes.Volumes {
+		if volume == nil {
+			continue
+		}
+
+		if _, ok := usedVolumes[volume.Name]; !ok {
+			report.Volumes = append(report.Volumes, Volume{Name: volume.Name, Driver: volume.Driver, CreatedAt: volume.CreatedAt})
+		}
+	}
+
+	// the parent images cannot be removed while their children exist
+	parentImages := make(map[string]struct{})
+	for _, image := range snapshot.SnapshotRaw.Images {
+		if image.ParentID != "" {
+			parentImages[image.ParentID] = struct{}{}
+		}
+	}
+
+	for _, image := range snapshot.SnapshotRaw.Images {
+		_, used := usedImages[image.ID]
+		_, parent := parentImages[image.ID]
+
+		if used || parent {
+			continue
+		}
+
+		report.Images = append(report.Images, Image{
+			ID:      image.ID,
+			Tags:    withoutNone(image.RepoTags, "<none>:<none>"),
+			Digests: withoutNone(image.RepoDigests, "<none>@<none>"),
+			Size:    image.Size,
+			Created: image.Created,
+		})
+		report.ReclaimableSize += image.Size
+	}
+
+	for _, network := range snapshot.SnapshotRaw.Networks {
+		_, usedByName := usedNetworks[network.Name]
+		_, usedByID := usedNetworks[network.ID]
+
+		if usedByName || usedByID || len(network.Containers) > 0 || slices.Contains(predefinedNetworks, network.Name) {
+			continue
+		}
+
+		var created int64
+		if !network.Created.IsZero() {
+			created = network.Created.Unix()
+		}
+
+		report.Networks = append(report.Networks, Network{ID: network.ID, Name: network.Name, Driver: network.Driver, Scope: network.Scope, Created: created})
+	}
+
+	slices.SortFunc(report.Volumes, func(a, b Volume) int { return strings.Compare(a.Name, b.Name) })
+	slices.SortFunc(report.Networks, func(a, b Network) int { return strings.Compare(a.Name, b.Name) })
+	slices.SortFunc(report.Images, func(a, b Image) int { return cmp.Compare(b.Size, a.Size) })
+
+	return report
+}
+
+// IsEmpty returns whether the environment(endpoint) has no orphaned resource
+func (report *Report) IsEmpty() bool {
+	return len(report.Volumes) == 0 && len(report.Images) == 0 && len(report.Networks) == 0
+}
+
+// withoutNone returns the references of an image without the placeholder the daemon uses for the dangling images
+func withoutNone(references []string, none string) []string {
+	return slices.DeleteFunc(append([]string{}, references...), func(reference string) bool {
+		return reference == none
+	})
+}
@@ -0,0 +1,181 @@
+package orphans
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+	dockerclient "github.com/portainer/portainer/api/docker/client"
+	"github.com/portainer/portainer/api/internal/endpointutils"
+	"github.com/portainer/portainer/api/scheduler"
+	endpointsutils "github.com/portainer/portainer/pkg/endpoints"
+
+	"github.com/rs/zerolog/log"
+)
+
+// cleanupTimeout bounds the removal of the orphaned resources of an environment(endpoint)
+const cleanupTimeout = 5 * time.Minute
+
+var (
+	// ErrNoSnapshot is returned when the report of an environment(endpoint) is requested before its first snapshot
+	ErrNoSnapshot = errors.New("the environment has no Docker snapshot")
+	// ErrNoConnectivity is returned when the resources of an environment(endpoint) cannot be removed because
+	// Portainer cannot reach it
+	ErrNoConnectivity = errors.New("the environment cannot be reached by Portainer")
+)
+
+// Service reports the orphaned resources of the Docker environments(endpoints) and removes them, on demand or on
+// the schedule of the settings
+type Service struct {
+	dataStore     dataservices.DataStore
+	clientFactory *dockerclient.ClientFactory
+	scheduler     *scheduler.Scheduler
+
+	mu    sync.Mutex
+	jobID string
+}
+
+// NewService creates a new orphaned resources service
+func NewService(dataStore dataservices.DataStore, clientFactory *dockerclient.ClientFactory, scheduler *scheduler.Scheduler) *Service {
+	return &Service{
+		dataStore:     dataStore,
+		clientFactory: clientFactory,
+		scheduler:     scheduler,
+	}
+}
+
+// Report returns the orphaned resources of an environment(endpoint) at the time of its last snapshot
+func (service *Service) Report(endpoint *portainer.Endpoint) (*Report, error) {
+	snapshot, err := service.dataStore.Snapshot().Read(endpoint.ID)
+	if dataservices.IsErrObjectNotFound(err) || (err == nil && snapshot.Docker == nil) {
+		return nil, ErrNoSnapshot
+	} else if err != nil {
+		return nil, err
+	}
+
+	return NewReport(endpoint, snapshot.Docker), nil
+}
+
+// Reports returns the orphaned resources of all the Docker environments(endpoints) having a snapshot
+func (service *Service) Reports() ([]Report, error) {
+	endpoints, err := service.dataStore.Endpoint().Endpoints()
+	if err != nil {
+		return nil, err
+	}
+
+	reports := []Report{}
+
+	for i := range endpoints {
+		if !endpointutils.IsDockerEndpoint(&endpoints[i]) {
+			continue
+		}
+
+		report, err := service.Report(&endpoints[i])
+		if errors.Is(err, ErrNoSnapshot) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+
+		reports = append(reports, *report)
+	}
+
+	return reports, nil
+}
+
+// Cleanup removes the selected orphaned resources of an environment(endpoint)
+func (service *Service) Cleanup(endpoint *portainer.Endpoint, selection Selection) (*CleanupResult, error) {
+	if !endpointsutils.HasDirectConnectivity(endpoint) {
+		return nil, ErrNoConnectivity
+	}
+
+	snapshot, err := service.dataStore.Snapshot().Read(endpoint.ID)
+	if dataservices.IsErrObjectNotFound(err) || (err == nil && snapshot.Docker == nil) {
+		return nil, ErrNoSnapshot
+	} else if err != nil {
+		return nil, err
+	}
+
+	cli, err := service.clientFactory.CreateClient(endpoint, "", nil)
+	if err != nil {
+		return nil, err
+	}
+	defer cli.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), cleanupTimeout)
+	defer cancel()
+
+	return Cleanup(ctx, cli, NewReport(endpoint, snapshot.Docker), selection, snapshot.Docker.Swarm)
+}
+
+// SetSchedule replaces the scheduled cleanup with the one of the settings
+func (service *Service) SetSchedule(settings portainer.OrphanCleanupSettings) error {
+	service.mu.Lock()
+	defer service.mu.Unlock()
+
+	if service.jobID != "" {
+		if err := service.scheduler.StopJob(service.jobID); err != nil {
+			return err
+		}
+
+		service.jobID = ""
+	}
+
+	if settings.Interval == "" {
+		return nil
+	}
+
+	interval, err := time.ParseDuration(settings.Interval)
+	if err != nil {
+		return err
+	}
+
+	service.jobID = service.scheduler.StartJobEvery(interval, func() error {
+		return service.cleanupAll(settings)
+	})
+
+	return nil
+}
+
+// cleanupAll removes the orphaned resources of the types enabled in the settings from all the reachable Docker
+// environments(endpoints)
+func (service *Service) cleanupAll(settings portainer.OrphanCleanupSettings) error {
+	reports, err := service.Reports()
+	if err != nil {
+		return err
+	}
+
+	for i := range reports {
+		selection := SelectAll(&reports[i], settings.Volumes, settings.Images, settings.Networks)
+
+		endpoint, err := service.dataStore.Endpoint().Endpoint(reports[i].EndpointID)
+		if err != nil {
+			return err
+		}
+
+		if !endpointsutils.HasDirectConnectivity(endpoint) || endpoint.Status != portainer.EndpointStatusUp {
+			continue
+		}
+
+		result, err := service.Cleanup(endpoint, selection)
+		if err != nil {
+			log.Warn().Err(err).Int("endpoint_id", int(endpoint.ID)).Msg("unable to clean up the orphaned resources")
+
+			continue
+		}
+
+		log.Info().
+			Int("endpoint_id", int(endpoint.ID)).
+			Int("volumes", len(result.Volumes)).
+			Int("images", len(result.Images)).
+			Int("networks", len(result.Networks)).
+			Int("errors", len(result.Errors)).
+			Int64("reclaimed_size", result.ReclaimedSize).
+			Msg("orphaned resources cleaned up")
+	}
+
+	return nil
+}
@@ -0,0 +1,167 @@
+// Package prunepolicies runs the prune policies, which remove the unused images, volumes and networks of the Docker
+// environments(endpoints) on a cron schedule, and records what each run removed
+package prunepolicies
+
+import (
+	"context"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/docker/orphans"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/image"
+	"github.com/docker/docker/api/types/network"
+	"github.com/docker/docker/api/types/system"
+	"github.com/docker/docker/api/types/volume"
+	"github.com/pkg/errors"
+	"github.com/robfig/cron/v3"
+)
+
+// Client is the part of the Docker client used to run a prune policy
+type Client interface {
+	orphans.CleanupClient
+	Info(ctx context.Context) (system.Info, error)
+	ContainerList(ctx context.Context, options container.ListOptions) ([]types.Container, error)
+	VolumeList(ctx context.Context, options volume.ListOptions) (volume.ListResponse, error)
+	ImageList(ctx context.Context, options image.ListOptions) ([]image.Summary, error)
+	NetworkList(ctx context.Context, options network.ListOptions) ([]network.Summary, error)
+}
+
+// ParseSchedule parses the standard cron expression of a policy
+func ParseSchedule(cronExpression string) (cron.Schedule, error) {
+	return cron.ParseStandard(cronExpression)
+}
+
+// Select returns the orphaned resources of a report which a policy removes at the given time. The resources whose
+// creation time is unknown are kept when the policy has an age limit
+func Select(report *orphans.Report, policy *portainer.PrunePolicy, now time.Time) (orphans.Selection, error) {
+	selection := orphans.Selection{Volumes: []string{}, Images: []string{}, Networks: []string{}}
+
+	var olderThan time.Duration
+	if policy.OlderThan != "" {
+		var err error
+		if olderThan, err = time.ParseDuration(policy.OlderThan); err != nil {
+			return selection, err
+		}
+	}
+
+	old := func(created time.Time) bool {
+		return olderThan == 0 || (!created.IsZero() && created.Before(now.Add(-olderThan)))
+	}
+
+	if policy.Volumes {
+		for _, volume := range report.Volumes {
+			created, _ := time.Parse(time.RFC3339, volume.CreatedAt)
+			if old(created) {
+				selection.Volumes = append(selection.Volumes, volume.Name)
+			}
+		}
+	}
+
+	if policy.Images {
+		for _, img := range report.Images {
+			dangling := len(img.Tags) == 0
+			if (dangling || policy.AllImages) && old(unix(img.Created)) {
+				selection.Images = append(selection.Images, img.ID)
+			}
+		}
+	}
+
+	if policy.Networks {
+		for _, network := range report.Networks {
+			if old(unix(network.Created)) {
+				selection.Networks = append(selection.Networks, network.ID)
+			}
+		}
+	}
+
+	return selection, nil
+}
+
+// Execute removes the resources of an environment(endpoint) selected by a policy, or only lists them for a dry run,
+// and records them in the report
+func Execute(ctx context.Context, cli Client, policy *portainer.PrunePolicy, report *portainer.PruneReport) error {
+	info, err := cli.Info(ctx)
+	if err != nil {
+		return errors.Wrap(err, "unable to retrieve the information of the Docker environment")
+	}
+
+	snapshot, err := snapshot(ctx, cli)
+	if err != nil {
+		return err
+	}
+
+	orphansReport := orphans.NewReport(&portainer.Endpoint{ID: report.EndpointID}, snapshot)
+
+	selection, err := Select(orphansReport, policy, time.Now())
+	if err != nil {
+		return err
+	}
+
+	// only the managers can list the services whose resources are kept
+	isSwarm := info.Swarm.ControlAvailable
+
+	cleanup := orphans.Cleanup
+	if report.DryRun {
+		cleanup = orphans.Preview
+	}
+
+	result, err := cleanup(ctx, cli, orphansReport, selection, isSwarm)
+	if err != nil {
+		return errors.Wrap(err, "unable to remove the unused resources")
+	}
+
+	report.Volumes = result.Volumes
+	report.Images = result.Images
+	report.Networks = result.Networks
+	report.ReclaimedSize = result.ReclaimedSize
+
+	for _, cleanupErr := range result.Errors {
+		report.Errors = append(report.Errors, portainer.PruneReportError{
+			ResourceType: cleanupErr.ResourceType,
+			ResourceID:   cleanupErr.ResourceID,
+			Error:        cleanupErr.Error,
+		})
+	}
+
+	return nil
+}
+
+// snapshot lists the containers, volumes, images and networks of an environment(endpoint) the way they are recorded
+// in its snapshots, the stopped containers using resources as well
+func snapshot(ctx context.Context, cli Client) (*portainer.DockerSnapshot, error) {
+	snapshot := &portainer.DockerSnapshot{Time: time.Now().Unix()}
+
+	containers, err := cli.ContainerList(ctx, container.ListOptions{All: true})
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to list the containers")
+	}
+
+	for _, c := range containers {
+		snapshot.SnapshotRaw.Containers = append(snapshot.SnapshotRaw.Containers, portainer.DockerContainerSnapshot{Container: c})
+	}
+
+	if snapshot.SnapshotRaw.Volumes, err = cli.VolumeList(ctx, volume.ListOptions{}); err != nil {
+		return nil, errors.Wrap(err, "unable to list the volumes")
+	}
+
+	if snapshot.SnapshotRaw.Images, err = cli.ImageList(ctx, image.ListOptions{}); err != nil {
+		return nil, errors.Wrap(err, "unable to list the images")
+	}
+
+	if snapshot.SnapshotRaw.Networks, err = cli.NetworkList(ctx, network.ListOptions{}); err != nil {
+		return nil, errors.Wrap(err, "unable to list the networks")
+	}
+
+	return snapshot, nil
+}
+
+func unix(timestamp int64) time.Time {
+	if timestamp == 0 {
+		return time.Time{}
+	}
+
+	return time.Unix(timestamp, 0)
+}
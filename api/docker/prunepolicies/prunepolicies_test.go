@@ -0,0 +1,139 @@
+package prunepolicies
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/docker/orphans"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/image"
+	"github.com/docker/docker/api/types/mount"
+	"github.com/docker/docker/api/types/network"
+	"github.com/docker/docker/api/types/swarm"
+	"github.com/docker/docker/api/types/system"
+	"github.com/docker/docker/api/types/volume"
+	"github.com/stretchr/testify/require"
+)
+
+var now = time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+
+type testClient struct {
+	removed []string
+}
+
+func (c *testClient) Info(ctx context.Context) (system.Info, error) {
+	return system.Info{}, nil
+}
+
+func (c *testClient) ContainerList(ctx context.Context, options container.ListOptions) ([]types.Container, error) {
+	return []types.Container{{
+		ImageID: "sha256:used",
+		State:   "exited",
+		Mounts:  []types.MountPoint{{Type: mount.TypeVolume, Name: "data"}},
+	}}, nil
+}
+
+func (c *testClient) VolumeList(ctx context.Context, options volume.ListOptions) (volume.ListResponse, error) {
+	return volume.ListResponse{Volumes: []*volume.Volume{
+		{Name: "data", CreatedAt: now.AddDate(0, 0, -30).Format(time.RFC3339)},
+		{Name: "old", CreatedAt: now.AddDate(0, 0, -30).Format(time.RFC3339)},
+	}}, nil
+}
+
+func (c *testClient) ImageList(ctx context.Context, options image.ListOptions) ([]image.Summary, error) {
+	return []image.Summary{
+		{ID: "sha256:used", RepoTags: []string{"app:1"}, Created: now.AddDate(0, 0, -30).Unix(), Size: 10},
+		{ID: "sha256:dangling", RepoTags: []string{"<none>:<none>"}, Created: now.AddDate(0, 0, -30).Unix(), Size: 20},
+	}, nil
+}
+
+func (c *testClient) NetworkList(ctx context.Context, options network.ListOptions) ([]network.Summary, error) {
+	return []network.Summary{{ID: "net-bridge", Name: "bridge"}, {ID: "net-old", Name: "old_default"}}, nil
+}
+
+func (c *testClient) ServiceList(ctx context.Context, options types.ServiceListOptions) ([]swarm.Service, error) {
+	return nil, nil
+}
+
+func (c *testClient) VolumeRemove(ctx context.Context, volumeID string, force bool) error {
+	c.removed = append(c.removed, volumeID)
+	return nil
+}
+
+func (c *testClient) ImageRemove(ctx context.Context, imageID string, options image.RemoveOptions) ([]image.DeleteResponse, error) {
+	c.removed = append(c.removed, imageID)
+	return nil, nil
+}
+
+func (c *testClient) NetworkRemove(ctx context.Context, networkID string) error {
+	c.removed = append(c.removed, networkID)
+	return nil
+}
+
+func TestSelect(t *testing.T) {
+	is := require.New(t)
+
+	report := &orphans.Report{
+		Volumes: []orphans.Volume{
+			{Name: "recent", CreatedAt: now.Add(-time.Hour).Format(time.RFC3339)},
+			{Name: "old", CreatedAt: now.AddDate(0, 0, -10).Format(time.RFC3339)},
+			{Name: "unknown"},
+		},
+		Images: []orphans.Image{
+			{ID: "sha256:dangling-old", Created: now.AddDate(0, 0, -10).Unix()},
+			{ID: "sha256:dangling-recent", Created: now.Add(-time.Hour).Unix()},
+			{ID: "sha256:tagged-old", Tags: []string{"app:1"}, Created: now.AddDate(0, 0, -10).Unix()},
+		},
+		Networks: []orphans.Network{
+			{ID: "net-old", Created: now.AddDate(0, 0, -10).Unix()},
+			{ID: "net-unknown"},
+		},
+	}
+
+	selection, err := Select(report, &portainer.PrunePolicy{Images: true}, now)
+	is.NoError(err)
+	is.Equal(orphans.Selection{Volumes: []string{}, Images: []string{"sha256:dangling-old", "sha256:dangling-recent"}, Networks: []string{}}, selection)
+
+	// the resources whose age is unknown are kept when the policy has an age limit
+	selection, err = Select(report, &portainer.PrunePolicy{Images: true, AllImages: true, Volumes: true, Networks: true, OlderThan: "168h"}, now)
+	is.NoError(err)
+	is.Equal([]string{"old"}, selection.Volumes)
+	is.Equal([]string{"sha256:dangling-old", "sha256:tagged-old"}, selection.Images)
+	is.Equal([]string{"net-old"}, selection.Networks)
+
+	selection, err = Select(report, &portainer.PrunePolicy{Volumes: true, Networks: true}, now)
+	is.NoError(err)
+	is.Equal([]string{"recent", "old", "unknown"}, selection.Volumes)
+	is.Equal([]string{"net-old", "net-unknown"}, selection.Networks)
+
+	_, err = Select(report, &portainer.PrunePolicy{Images: true, OlderThan: "7d"}, now)
+	is.Error(err)
+}
+
+func TestExecute(t *testing.T) {
+	is := require.New(t)
+
+	policy := &portainer.PrunePolicy{Images: true, Volumes: true, Networks: true}
+
+	// a dry run only reports the resources
+	cli := &testClient{}
+	report := &portainer.PruneReport{EndpointID: 1, DryRun: true}
+	is.NoError(Execute(context.Background(), cli, policy, report))
+	is.Empty(cli.removed)
+	is.Equal([]string{"old"}, report.Volumes)
+	is.Equal([]string{"sha256:dangling"}, report.Images)
+	is.Equal([]string{"net-old"}, report.Networks)
+	is.Equal(int64(20), report.ReclaimedSize)
+
+	// the volume and the image of the stopped container are kept
+	report = &portainer.PruneReport{EndpointID: 1}
+	is.NoError(Execute(context.Background(), cli, policy, report))
+	is.Equal([]string{"old", "sha256:dangling", "net-old"}, cli.removed)
+	is.Equal([]string{"old"}, report.Volumes)
+	is.Equal(int64(20), report.ReclaimedSize)
+	is.Empty(report.Errors)
+}
@@ -0,0 +1,293 @@
+package prunepolicies
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+	dockerclient "github.com/portainer/portainer/api/docker/client"
+	"github.com/portainer/portainer/api/internal/endpointutils"
+	"github.com/portainer/portainer/api/scheduler"
+	endpointsutils "github.com/portainer/portainer/pkg/endpoints"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+)
+
+// executeTimeout bounds the run of a policy on an environment(endpoint)
+const executeTimeout = 30 * time.Minute
+
+var (
+	// ErrUnsupportedEndpoint is returned when a policy targets an environment(endpoint) which is not a Docker
+	// environment reachable by Portainer
+	ErrUnsupportedEndpoint = errors.New("the prune policies can only run on the Docker environments reachable by Portainer")
+	// errInterrupted is recorded on the runs interrupted by a restart of Portainer
+	errInterrupted = errors.New("the run was interrupted by a restart of Portainer")
+)
+
+// runKey identifies the runs of a policy on an environment(endpoint), which never overlap
+type runKey struct {
+	policyID   portainer.PrunePolicyID
+	endpointID portainer.EndpointID
+}
+
+// Service schedules the prune policies and runs them on their environments(endpoints)
+type Service struct {
+	dataStore     dataservices.DataStore
+	clientFactory *dockerclient.ClientFactory
+	scheduler     *scheduler.Scheduler
+
+	mu        sync.Mutex
+	schedules map[portainer.PrunePolicyID]string
+	running   map[runKey]bool
+}
+
+// NewService creates a new prune policy service
+func NewService(dataStore dataservices.DataStore, clientFactory *dockerclient.ClientFactory, scheduler *scheduler.Scheduler) *Service {
+	return &Service{
+		dataStore:     dataStore,
+		clientFactory: clientFactory,
+		scheduler:     scheduler,
+		schedules:     map[portainer.PrunePolicyID]string{},
+		running:       map[runKey]bool{},
+	}
+}
+
+// Start marks the runs interrupted by the last restart as failed and schedules the policies
+func (service *Service) Start() error {
+	if err := service.dataStore.UpdateTx(func(tx dataservices.DataStoreTx) error {
+		reports, err := tx.PruneReport().ReadAll()
+		if err != nil {
+			return err
+		}
+
+		for _, report := range reports {
+			if report.Status != portainer.PruneReportRunning {
+				continue
+			}
+
+			report.Status = portainer.PruneReportFailed
+			report.Error = errInterrupted.Error()
+			report.FinishedAt = time.Now().Unix()
+
+			if err := tx.PruneReport().Update(report.ID, &report); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}); err != nil {
+		return errors.Wrap(err, "unable to update the interrupted runs")
+	}
+
+	policies, err := service.dataStore.PrunePolicy().ReadAll()
+	if err != nil {
+		return err
+	}
+
+	for i := range policies {
+		if err := service.Schedule(&policies[i]); err != nil {
+			log.Error().Err(err).Int("policy_id", int(policies[i].ID)).Msg("unable to schedule the prune policy")
+		}
+	}
+
+	return nil
+}
+
+// Schedule replaces the schedule of a policy with the one of its cron expression, a paused policy is not scheduled
+func (service *Service) Schedule(policy *portainer.PrunePolicy) error {
+	service.Unschedule(policy.ID)
+
+	if policy.Paused {
+		return nil
+	}
+
+	schedule, err := ParseSchedule(policy.CronExpression)
+	if err != nil {
+		return err
+	}
+
+	policyID := policy.ID
+
+	service.mu.Lock()
+	defer service.mu.Unlock()
+
+	service.schedules[policyID] = service.scheduler.StartJobOnSchedule(schedule, func() error {
+		_, err := service.Run(policyID, false, nil)
+		if service.dataStore.IsErrObjectNotFound(err) {
+			return scheduler.NewPermanentError(err)
+		}
+
+		return err
+	})
+
+	return nil
+}
+
+// Unschedule stops the scheduled runs of a policy, the ones in progress are not stopped
+func (service *Service) Unschedule(policyID portainer.PrunePolicyID) {
+	service.mu.Lock()
+	defer service.mu.Unlock()
+
+	scheduleID, ok := service.schedules[policyID]
+	if !ok {
+		return
+	}
+
+	if err := service.scheduler.StopJob(scheduleID); err != nil {
+		log.Warn().Err(err).Int("policy_id", int(policyID)).Msg("unable to stop the schedule of the prune policy")
+	}
+
+	delete(service.schedules, policyID)
+}
+
+// Run starts a run of a policy on each of its environments(endpoints) and returns their reports, which complete in
+// the background. The dry run mode of the policy is used when dryRun is nil. An environment still running the
+// previous run of the policy is skipped
+func (service *Service) Run(policyID portainer.PrunePolicyID, manual bool, dryRun *bool) ([]portainer.PruneReport, error) {
+	policy, err := service.dataStore.PrunePolicy().Read(policyID)
+	if err != nil {
+		return nil, err
+	}
+
+	endpoints, err := service.endpoints(policy)
+	if err != nil {
+		return nil, err
+	}
+
+	reports := []portainer.PruneReport{}
+
+	for i := range endpoints {
+		endpoint := &endpoints[i]
+		key := runKey{policyID: policy.ID, endpointID: endpoint.ID}
+
+		service.mu.Lock()
+		if service.running[key] {
+			service.mu.Unlock()
+			log.Warn().Int("policy_id", int(policy.ID)).Int("endpoint_id", int(endpoint.ID)).Msg("the previous run of the prune policy is still in progress, skipping")
+
+			continue
+		}
+		service.running[key] = true
+		service.mu.Unlock()
+
+		report := &portainer.PruneReport{
+			PolicyID:   policy.ID,
+			EndpointID: endpoint.ID,
+			Manual:     manual,
+			DryRun:     policy.DryRun,
+			Status:     portainer.PruneReportRunning,
+			StartedAt:  time.Now().Unix(),
+			Volumes:    []string{},
+			Images:     []string{},
+			Networks:   []string{},
+		}
+
+		if dryRun != nil {
+			report.DryRun = *dryRun
+		}
+
+		if err := service.dataStore.PruneReport().Create(report); err != nil {
+			service.done(key)
+
+			return reports, err
+		}
+
+		reports = append(reports, *report)
+
+		go service.execute(key, policy, endpoint, report)
+	}
+
+	return reports, nil
+}
+
+// endpoints returns the environments(endpoints) of a policy, the ones of its groups which it cannot run on are
+// left out
+func (service *Service) endpoints(policy *portainer.PrunePolicy) ([]portainer.Endpoint, error) {
+	all, err := service.dataStore.Endpoint().Endpoints()
+	if err != nil {
+		return nil, err
+	}
+
+	endpoints := []portainer.Endpoint{}
+
+	for _, endpoint := range all {
+		if slices.Contains(policy.Endpoints, endpoint.ID) ||
+			(slices.Contains(policy.EndpointGroups, endpoint.GroupID) && CheckEndpoint(&endpoint) == nil) {
+			endpoints = append(endpoints, endpoint)
+		}
+	}
+
+	return endpoints, nil
+}
+
+func (service *Service) execute(key runKey, policy *portainer.PrunePolicy, endpoint *portainer.Endpoint, report *portainer.PruneReport) {
+	defer service.done(key)
+
+	if err := service.executeOnEndpoint(policy, endpoint, report); err != nil {
+		report.Status = portainer.PruneReportFailed
+		report.Error = err.Error()
+	} else {
+		report.Status = portainer.PruneReportSucceeded
+	}
+
+	report.FinishedAt = time.Now().Unix()
+
+	if err := service.dataStore.UpdateTx(func(tx dataservices.DataStoreTx) error {
+		// the reports of a policy removed meanwhile are not recorded again
+		if _, err := tx.PruneReport().Read(report.ID); err != nil {
+			return err
+		}
+
+		return tx.PruneReport().Update(report.ID, report)
+	}); err != nil && !service.dataStore.IsErrObjectNotFound(err) {
+		log.Error().Err(err).Int("policy_id", int(policy.ID)).Int("report_id", int(report.ID)).Msg("unable to record the report of the prune policy")
+	}
+
+	log.Info().
+		Int("policy_id", int(policy.ID)).
+		Int("endpoint_id", int(endpoint.ID)).
+		Bool("dry_run", report.DryRun).
+		Int("volumes", len(report.Volumes)).
+		Int("images", len(report.Images)).
+		Int("networks", len(report.Networks)).
+		Int("errors", len(report.Errors)).
+		Int64("reclaimed_size", report.ReclaimedSize).
+		Msg("prune policy run")
+}
+
+func (service *Service) executeOnEndpoint(policy *portainer.PrunePolicy, endpoint *portainer.Endpoint, report *portainer.PruneReport) error {
+	if err := CheckEndpoint(endpoint); err != nil {
+		return err
+	}
+
+	cli, err := service.clientFactory.CreateClient(endpoint, "", nil)
+	if err != nil {
+		return errors.Wrap(err, "unable to create the Docker client")
+	}
+	defer cli.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), executeTimeout)
+	defer cancel()
+
+	return Execute(ctx, cli, policy, report)
+}
+
+func (service *Service) done(key runKey) {
+	service.mu.Lock()
+	defer service.mu.Unlock()
+
+	delete(service.running, key)
+}
+
+// CheckEndpoint checks that a policy can run on an environment(endpoint)
+func CheckEndpoint(endpoint *portainer.Endpoint) error {
+	if !endpointutils.IsDockerEndpoint(endpoint) || !endpointsutils.HasDirectConnectivity(endpoint) {
+		return ErrUnsupportedEndpoint
+	}
+
+	return nil
+}
@@ -0,0 +1,282 @@
+// Package quarantine stops or isolates the workloads of a compromised Docker environment(endpoint) and blocks its
+// redeploys until it is released
+package quarantine
+
+import (
+	"context"
+	"errors"
+	"path"
+	"slices"
+	"strings"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/docker/consts"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/network"
+	"github.com/docker/docker/api/types/swarm"
+	"github.com/docker/docker/api/types/system"
+)
+
+const (
+	// ActionStop stops every non-system container and scales down the replicated Swarm services
+	ActionStop = "stop"
+	// ActionIsolate disconnects every non-system container from the networks reaching outside of the host
+	ActionIsolate = "isolate"
+)
+
+// ErrQuarantined is returned when a deployment targets a quarantined environment(endpoint)
+var ErrQuarantined = errors.New("the environment is quarantined, deployments are blocked until it is released")
+
+// systemImages are the repositories of the Portainer containers, which are never stopped nor isolated
+var systemImages = []string{"portainer/agent", "portainer/portainer", "portainer/portainer-ce", "portainer/portainer-ee"}
+
+// Client is the part of the Docker client used to quarantine an environment(endpoint)
+type Client interface {
+	Info(ctx context.Context) (system.Info, error)
+	ContainerList(ctx context.Context, options container.ListOptions) ([]types.Container, error)
+	ContainerStop(ctx context.Context, containerID string, options container.StopOptions) error
+	NetworkList(ctx context.Context, options network.ListOptions) ([]network.Summary, error)
+	NetworkDisconnect(ctx context.Context, networkID, containerID string, force bool) error
+	ServiceList(ctx context.Context, options types.ServiceListOptions) ([]swarm.Service, error)
+	ServiceUpdate(ctx context.Context, serviceID string, version swarm.Version, service swarm.ServiceSpec, options types.ServiceUpdateOptions) (swarm.ServiceUpdateResponse, error)
+}
+
+// Options represents how the workloads of an environment(endpoint) are quarantined
+type Options struct {
+	// Action applied to the workloads, stop or isolate
+	Action string
+	// Networks the containers stay connected to when they are isolated, in addition to the internal ones
+	KeepNetworks []string
+}
+
+// Disconnection represents a container disconnected from a network
+type Disconnection struct {
+	Container string `json:"Container" example:"myapp-web-1"`
+	Network   string `json:"Network" example:"bridge"`
+}
+
+// Error represents a workload which could not be stopped or isolated
+type Error struct {
+	Resource string `json:"Resource" example:"myapp-web-1"`
+	Error    string `json:"Error" example:"container is restarting"`
+}
+
+// Result represents the outcome of the quarantine of the workloads of an environment(endpoint)
+type Result struct {
+	StoppedContainers      []string        `json:"StoppedContainers" example:"myapp-web-1"`
+	ScaledDownServices     []string        `json:"ScaledDownServices" example:"myapp_web"`
+	DisconnectedContainers []Disconnection `json:"DisconnectedContainers"`
+	Errors                 []Error         `json:"Errors"`
+}
+
+// deployRequests are the Docker API requests which run or reconnect workloads, as method and path patterns
+var deployRequests = []string{
+	"POST /containers/create",
+	"POST /containers/*/start",
+	"POST /containers/*/restart",
+	"POST /containers/*/unpause",
+	"POST /services/create",
+	"POST /services/*/update",
+	"POST /networks/*/connect",
+}
+
+// Check returns ErrQuarantined when the environment(endpoint) is quarantined
+func Check(endpoint *portainer.Endpoint) error {
+	if endpoint != nil && endpoint.Quarantine != nil {
+		return ErrQuarantined
+	}
+
+	return nil
+}
+
+// IsDeployRequest returns true for the Docker API requests, without the API version, which run or reconnect
+// workloads and are refused on a quarantined environment(endpoint)
+func IsDeployRequest(method, requestPath string) bool {
+	for _, pattern := range deployRequests {
+		if match, _ := path.Match(pattern, method+" "+requestPath); match {
+			return true
+		}
+	}
+
+	return false
+}
+
+// IsSystemContainer returns true for the Portainer server and agent containers and for the ones labeled as system
+// containers, which keep running so that the environment(endpoint) stays manageable
+func IsSystemContainer(image string, labels map[string]string) bool {
+	if labels[consts.SystemContainerLabel] == "true" {
+		return true
+	}
+
+	return slices.Contains(systemImages, imageRepository(image))
+}
+
+// imageRepository strips the registry of Docker Hub, the tag and the digest of an image reference
+func imageRepository(image string) string {
+	image, _, _ = strings.Cut(image, "@")
+	if i := strings.LastIndex(image, ":"); i > strings.LastIndex(image, "/") {
+		image = image[:i]
+	}
+
+	image = strings.TrimPrefix(image, "docker.io/")
+
+	return strings.TrimPrefix(image, "index.docker.io/")
+}
+
+// Apply stops or isolates the non-system workloads of a Docker environment(endpoint). It keeps going when a workload
+// cannot be handled, the failures are part of the result
+func Apply(ctx context.Context, cli Client, options Options) (*Result, error) {
+	result := &Result{
+		StoppedContainers:      []string{},
+		ScaledDownServices:     []string{},
+		DisconnectedContainers: []Disconnection{},
+		Errors:                 []Error{},
+	}
+
+	containers, err := cli.ContainerList(ctx, container.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	switch options.Action {
+	case ActionStop:
+		info, err := cli.Info(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		if info.Swarm.ControlAvailable {
+			if err := scaleDownServices(ctx, cli, result); err != nil {
+				return nil, err
+			}
+		}
+
+		stopContainers(ctx, cli, containers, result)
+	case ActionIsolate:
+		if err := isolateContainers(ctx, cli, containers, options.KeepNetworks, result); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, errors.New("invalid quarantine action")
+	}
+
+	return result, nil
+}
+
+// scaleDownServices scales the replicated services to zero, the orchestrator would otherwise restart their stopped
+// tasks. The global services cannot be scaled down and are reported as errors
+func scaleDownServices(ctx context.Context, cli Client, result *Result) error {
+	services, err := cli.ServiceList(ctx, types.ServiceListOptions{})
+	if err != nil {
+		return err
+	}
+
+	for _, service := range services {
+		if service.Spec.TaskTemplate.ContainerSpec != nil &&
+			IsSystemContainer(service.Spec.TaskTemplate.ContainerSpec.Image, service.Spec.Labels) {
+			continue
+		}
+
+		if service.Spec.Mode.Replicated == nil {
+			result.Errors = append(result.Errors, Error{Resource: service.Spec.Name, Error: "global services cannot be scaled down"})
+
+			continue
+		}
+
+		if service.Spec.Mode.Replicated.Replicas != nil && *service.Spec.Mode.Replicated.Replicas == 0 {
+			continue
+		}
+
+		spec := service.Spec
+		replicas := uint64(0)
+		spec.Mode.Replicated = &swarm.ReplicatedService{Replicas: &replicas}
+
+		if _, err := cli.ServiceUpdate(ctx, service.ID, service.Version, spec, types.ServiceUpdateOptions{}); err != nil {
+			result.Errors = append(result.Errors, Error{Resource: service.Spec.Name, Error: err.Error()})
+
+			continue
+		}
+
+		result.ScaledDownServices = append(result.ScaledDownServices, service.Spec.Name)
+	}
+
+	return nil
+}
+
+// stopContainers stops the running non-system containers which are not tasks of a Swarm service
+func stopContainers(ctx context.Context, cli Client, containers []types.Container, result *Result) {
+	for _, c := range containers {
+		if IsSystemContainer(c.Image, c.Labels) || c.Labels[consts.SwarmServiceIDLabel] != "" {
+			continue
+		}
+
+		if err := cli.ContainerStop(ctx, c.ID, container.StopOptions{}); err != nil {
+			result.Errors = append(result.Errors, Error{Resource: containerName(c), Error: err.Error()})
+
+			continue
+		}
+
+		result.StoppedContainers = append(result.StoppedContainers, containerName(c))
+	}
+}
+
+// isolateContainers disconnects the running non-system containers from every network which is neither internal nor
+// kept by the options
+func isolateContainers(ctx context.Context, cli Client, containers []types.Container, keepNetworks []string, result *Result) error {
+	networks, err := cli.NetworkList(ctx, network.ListOptions{})
+	if err != nil {
+		return err
+	}
+
+	internal := map[string]bool{}
+	for _, n := range networks {
+		internal[n.Name] = n.Internal
+	}
+
+	for _, c := range containers {
+		if IsSystemContainer(c.Image, c.Labels) || c.NetworkSettings == nil {
+			continue
+		}
+
+		for name, settings := range c.NetworkSettings.Networks {
+			if internal[name] || slices.Contains(keepNetworks, name) || name == "none" {
+				continue
+			}
+
+			if name == "host" {
+				result.Errors = append(result.Errors, Error{Resource: containerName(c), Error: "containers cannot be disconnected from the host network"})
+
+				continue
+			}
+
+			networkID := name
+			if settings != nil && settings.NetworkID != "" {
+				networkID = settings.NetworkID
+			}
+
+			if err := cli.NetworkDisconnect(ctx, networkID, c.ID, true); err != nil {
+				result.Errors = append(result.Errors, Error{Resource: containerName(c), Error: err.Error()})
+
+				continue
+			}
+
+			result.DisconnectedContainers = append(result.DisconnectedContainers, Disconnection{Container: containerName(c), Network: name})
+		}
+	}
+
+	slices.SortFunc(result.DisconnectedContainers, func(a, b Disconnection) int {
+		return strings.Compare(a.Container+"/"+a.Network, b.Container+"/"+b.Network)
+	})
+
+	return nil
+}
+
+func containerName(c types.Container) string {
+	if len(c.Names) == 0 {
+		return c.ID
+	}
+
+	return path.Base(c.Names[0])
+}
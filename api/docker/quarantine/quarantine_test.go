@@ -0,0 +1,164 @@
+package quarantine
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/portainer/portainer/api/docker/consts"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/network"
+	"github.com/docker/docker/api/types/swarm"
+	"github.com/docker/docker/api/types/system"
+	"github.com/stretchr/testify/require"
+)
+
+type testClient struct {
+	manager    bool
+	containers []types.Container
+	networks   []network.Summary
+	services   []swarm.Service
+
+	stopped      []string
+	disconnected []string
+	scaled       map[string]uint64
+}
+
+func (c *testClient) Info(ctx context.Context) (system.Info, error) {
+	info := system.Info{}
+	info.Swarm.ControlAvailable = c.manager
+
+	return info, nil
+}
+
+func (c *testClient) ContainerList(ctx context.Context, options container.ListOptions) ([]types.Container, error) {
+	return c.containers, nil
+}
+
+func (c *testClient) ContainerStop(ctx context.Context, containerID string, options container.StopOptions) error {
+	if containerID == "failing" {
+		return errors.New("container is restarting")
+	}
+
+	c.stopped = append(c.stopped, containerID)
+
+	return nil
+}
+
+func (c *testClient) NetworkList(ctx context.Context, options network.ListOptions) ([]network.Summary, error) {
+	return c.networks, nil
+}
+
+func (c *testClient) NetworkDisconnect(ctx context.Context, networkID, containerID string, force bool) error {
+	c.disconnected = append(c.disconnected, containerID+"/"+networkID)
+
+	return nil
+}
+
+func (c *testClient) ServiceList(ctx context.Context, options types.ServiceListOptions) ([]swarm.Service, error) {
+	return c.services, nil
+}
+
+func (c *testClient) ServiceUpdate(ctx context.Context, serviceID string, version swarm.Version, service swarm.ServiceSpec, options types.ServiceUpdateOptions) (swarm.ServiceUpdateResponse, error) {
+	c.scaled[serviceID] = *service.Mode.Replicated.Replicas
+
+	return swarm.ServiceUpdateResponse{}, nil
+}
+
+func testContainer(id, image string, networks ...string) types.Container {
+	c := types.Container{ID: id, Names: []string{"/" + id}, Image: image, Labels: map[string]string{}}
+	c.NetworkSettings = &types.SummaryNetworkSettings{Networks: map[string]*network.EndpointSettings{}}
+
+	for _, name := range networks {
+		c.NetworkSettings.Networks[name] = &network.EndpointSettings{NetworkID: "id-" + name}
+	}
+
+	return c
+}
+
+func TestIsSystemContainer(t *testing.T) {
+	is := require.New(t)
+
+	is.True(IsSystemContainer("portainer/agent:2.21.0", nil))
+	is.True(IsSystemContainer("docker.io/portainer/portainer-ce:latest@sha256:abc", nil))
+	is.True(IsSystemContainer("portainer/portainer-ee", nil))
+	is.True(IsSystemContainer("nginx", map[string]string{consts.SystemContainerLabel: "true"}))
+	is.False(IsSystemContainer("nginx", nil))
+	is.False(IsSystemContainer("registry.example.com:5000/portainer/agent", nil))
+}
+
+func TestIsDeployRequest(t *testing.T) {
+	is := require.New(t)
+
+	is.True(IsDeployRequest("POST", "/containers/create"))
+	is.True(IsDeployRequest("POST", "/containers/abc/start"))
+	is.True(IsDeployRequest("POST", "/services/abc/update"))
+	is.True(IsDeployRequest("POST", "/networks/abc/connect"))
+	is.False(IsDeployRequest("POST", "/containers/abc/stop"))
+	is.False(IsDeployRequest("GET", "/containers/json"))
+	is.False(IsDeployRequest("DELETE", "/containers/abc"))
+}
+
+func TestApplyStop(t *testing.T) {
+	is := require.New(t)
+
+	task := testContainer("task", "nginx")
+	task.Labels[consts.SwarmServiceIDLabel] = "web"
+
+	replicas := uint64(3)
+	cli := &testClient{
+		manager: true,
+		containers: []types.Container{
+			testContainer("web", "nginx:latest"),
+			testContainer("agent", "portainer/agent:2.21.0"),
+			testContainer("failing", "redis"),
+			task,
+		},
+		services: []swarm.Service{
+			{ID: "web", Spec: swarm.ServiceSpec{Annotations: swarm.Annotations{Name: "myapp_web"}, Mode: swarm.ServiceMode{Replicated: &swarm.ReplicatedService{Replicas: &replicas}}}},
+			{ID: "exporter", Spec: swarm.ServiceSpec{Annotations: swarm.Annotations{Name: "exporter"}, Mode: swarm.ServiceMode{Global: &swarm.GlobalService{}}}},
+			{ID: "agent", Spec: swarm.ServiceSpec{Annotations: swarm.Annotations{Name: "portainer_agent"}, TaskTemplate: swarm.TaskSpec{ContainerSpec: &swarm.ContainerSpec{Image: "portainer/agent:2.21.0"}}, Mode: swarm.ServiceMode{Global: &swarm.GlobalService{}}}},
+		},
+		scaled: map[string]uint64{},
+	}
+
+	result, err := Apply(context.Background(), cli, Options{Action: ActionStop})
+	is.NoError(err)
+
+	is.Equal([]string{"web"}, cli.stopped)
+	is.Equal(map[string]uint64{"web": 0}, cli.scaled)
+	is.Equal([]string{"web"}, result.StoppedContainers)
+	is.Equal([]string{"myapp_web"}, result.ScaledDownServices)
+	is.Equal([]Error{
+		{Resource: "exporter", Error: "global services cannot be scaled down"},
+		{Resource: "failing", Error: "container is restarting"},
+	}, result.Errors)
+}
+
+func TestApplyIsolate(t *testing.T) {
+	is := require.New(t)
+
+	cli := &testClient{
+		containers: []types.Container{
+			testContainer("web", "nginx", "bridge", "backend", "monitoring"),
+			testContainer("agent", "portainer/agent", "bridge"),
+			testContainer("sidecar", "envoy", "host"),
+		},
+		networks: []network.Summary{
+			{Name: "bridge"},
+			{Name: "backend", Internal: true},
+			{Name: "monitoring"},
+			{Name: "host"},
+		},
+	}
+
+	result, err := Apply(context.Background(), cli, Options{Action: ActionIsolate, KeepNetworks: []string{"monitoring"}})
+	is.NoError(err)
+
+	is.Equal([]string{"web/id-bridge"}, cli.disconnected)
+	is.Equal([]Disconnection{{Container: "web", Network: "bridge"}}, result.DisconnectedContainers)
+	is.Equal([]Error{{Resource: "sidecar", Error: "containers cannot be disconnected from the host network"}}, result.Errors)
+	is.Empty(result.StoppedContainers)
+}
@@ -0,0 +1,102 @@
+package quarantine
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+	dockerclient "github.com/portainer/portainer/api/docker/client"
+	"github.com/portainer/portainer/api/internal/endpointutils"
+	endpointsutils "github.com/portainer/portainer/pkg/endpoints"
+
+	"github.com/rs/zerolog/log"
+)
+
+// applyTimeout bounds the quarantine of the workloads of an environment(endpoint)
+const applyTimeout = 5 * time.Minute
+
+var (
+	// ErrNotDocker is returned when the environment(endpoint) is not a Docker environment
+	ErrNotDocker = errors.New("only the Docker environments can be quarantined")
+	// ErrNoConnectivity is returned when the workloads of an environment(endpoint) cannot be quarantined because
+	// Portainer cannot reach it
+	ErrNoConnectivity = errors.New("the environment cannot be reached by Portainer")
+)
+
+// Service quarantines the Docker environments(endpoints) and releases them
+type Service struct {
+	dataStore     dataservices.DataStore
+	clientFactory *dockerclient.ClientFactory
+}
+
+// NewService creates a new quarantine service
+func NewService(dataStore dataservices.DataStore, clientFactory *dockerclient.ClientFactory) *Service {
+	return &Service{
+		dataStore:     dataStore,
+		clientFactory: clientFactory,
+	}
+}
+
+// Quarantine marks the environment(endpoint) as quarantined, which blocks its deployments, then stops or isolates
+// its workloads. The environment stays quarantined when its workloads cannot be reached, the action can be retried
+func (service *Service) Quarantine(endpoint *portainer.Endpoint, options Options, reason, username string) (*Result, error) {
+	if !endpointutils.IsDockerEndpoint(endpoint) {
+		return nil, ErrNotDocker
+	}
+
+	if !endpointsutils.HasDirectConnectivity(endpoint) {
+		return nil, ErrNoConnectivity
+	}
+
+	endpoint.Quarantine = &portainer.EndpointQuarantine{
+		Action:        options.Action,
+		Reason:        reason,
+		QuarantinedBy: username,
+		QuarantinedAt: time.Now().Unix(),
+	}
+
+	if err := service.dataStore.Endpoint().UpdateEndpoint(endpoint.ID, endpoint); err != nil {
+		return nil, err
+	}
+
+	log.Warn().
+		Int("endpoint_id", int(endpoint.ID)).
+		Str("action", options.Action).
+		Str("username", username).
+		Str("reason", reason).
+		Msg("environment quarantined")
+
+	cli, err := service.clientFactory.CreateClient(endpoint, "", nil)
+	if err != nil {
+		return nil, err
+	}
+	defer cli.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), applyTimeout)
+	defer cancel()
+
+	return Apply(ctx, cli, options)
+}
+
+// Release lifts the quarantine of the environment(endpoint). Its stopped workloads are not restarted, they are
+// redeployed or started again once the incident is resolved
+func (service *Service) Release(endpoint *portainer.Endpoint, username string) error {
+	if endpoint.Quarantine == nil {
+		return nil
+	}
+
+	endpoint.Quarantine = nil
+
+	if err := service.dataStore.Endpoint().UpdateEndpoint(endpoint.ID, endpoint); err != nil {
+		return err
+	}
+
+	log.Info().
+		Int("endpoint_id", int(endpoint.ID)).
+		Str("username", username).
+		Msg("environment released from quarantine")
+
+	return nil
+}
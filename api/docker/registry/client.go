@@ -0,0 +1,435 @@
+// Package registry is a client of the Docker Registry HTTP API V2, used to browse the repositories and the tags of
+// the registries without pulling their images
+package registry
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	requestTimeout = 30 * time.Second
+	// tokenExpiryMargin is subtracted from the lifetime of the bearer tokens so that they are renewed before the
+	// registry rejects them
+	tokenExpiryMargin = 10 * time.Second
+	defaultTokenTTL   = 60 * time.Second
+	catalogPageSize   = 100
+	// maxRepositories is the number of repositories after which the listing of a whole catalog stops
+	maxRepositories = 10000
+)
+
+// ErrUnauthorized is returned when the registry rejects the credentials
+var ErrUnauthorized = errors.New("the registry rejected the credentials")
+
+type (
+	// Client is a client of the Docker Registry HTTP API V2 of a registry, authenticated with basic authentication or
+	// with the bearer tokens of the token authentication of the registry
+	Client struct {
+		baseURL    string
+		username   string
+		password   string
+		httpClient *http.Client
+
+		mu     sync.Mutex
+		tokens map[string]bearerToken
+	}
+
+	// statusError is an error response of the registry
+	statusError struct {
+		statusCode int
+		message    string
+	}
+
+	bearerToken struct {
+		token  string
+		expiry time.Time
+	}
+
+	// CatalogPage is a page of the repositories of a registry
+	CatalogPage struct {
+		Repositories []string `json:"Repositories" example:"portainer/agent"`
+		// Last repository of the page, to pass as last to retrieve the next page. Empty on the last page
+		Next string `json:"Next,omitempty" example:"portainer/agent"`
+	}
+
+	// TagsPage is a page of the tags of a repository
+	TagsPage struct {
+		Tags []Tag `json:"Tags"`
+		// Last tag of the page, to pass as last to retrieve the next page. Empty on the last page
+		Next string `json:"Next,omitempty" example:"2.19.0"`
+	}
+
+	// Tag represents a tag of a repository and the metadata of its manifest
+	Tag struct {
+		Name string `json:"Name" example:"latest"`
+		// Digest of the manifest, or of the index of a multi-architecture image
+		Digest    string `json:"Digest,omitempty" example:"sha256:5d3d5d0b2d1e1b2f0a5d7e1a4c3b2a1f0e9d8c7b6a5f4e3d2c1b0a9f8e7d6c5b"`
+		MediaType string `json:"MediaType,omitempty" example:"application/vnd.oci.image.index.v1+json"`
+		// Compressed size of the image in bytes, of the first architecture of a multi-architecture image
+		Size int64 `json:"Size" example:"52428800"`
+		// Creation date of the image, of the first architecture of a multi-architecture image
+		Created *time.Time `json:"Created,omitempty"`
+		// Architectures of the image, for example amd64 or arm64/v8
+		Architectures []string `json:"Architectures"`
+		// Error that prevented the retrieval of the metadata of the tag
+		Error string `json:"Error,omitempty"`
+	}
+)
+
+// NewClient creates a client of the registry reachable at baseURL, e.g. https://registry.example.com:5000. The
+// username and password are optional
+func NewClient(baseURL, username, password string, tlsConfig *tls.Config) *Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if tlsConfig != nil {
+		transport.TLSClientConfig = tlsConfig
+	}
+
+	return &Client{
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+		username:   username,
+		password:   password,
+		httpClient: &http.Client{Transport: transport, Timeout: requestTimeout},
+		tokens:     make(map[string]bearerToken),
+	}
+}
+
+// Catalog returns up to n repositories of the registry following last, in lexical order
+func (c *Client) Catalog(ctx context.Context, n int, last string) (*CatalogPage, error) {
+	var body struct {
+		Repositories []string `json:"repositories"`
+	}
+
+	next, err := c.getList(ctx, "/v2/_catalog", "registry:catalog:*", n, last, &body)
+	if err != nil {
+		return nil, err
+	}
+
+	return &CatalogPage{Repositories: nonNil(body.Repositories), Next: next}, nil
+}
+
+// Repositories returns every repository of the catalog of the registry, following the next page links whatever their
+// parameters, for the registries whose catalog pages cannot be resumed from a repository
+func (c *Client) Repositories(ctx context.Context) ([]string, error) {
+	var repositories []string
+
+	path := "/v2/_catalog?n=" + strconv.Itoa(catalogPageSize)
+	for path != "" && len(repositories) < maxRepositories {
+		resp, err := c.get(ctx, path, "registry:catalog:*", nil)
+		if err != nil {
+			return nil, err
+		}
+
+		var body struct {
+			Repositories []string `json:"repositories"`
+		}
+		err = json.NewDecoder(resp.Body).Decode(&body)
+		resp.Body.Close()
+
+		if err != nil {
+			return nil, errors.Wrap(err, "unable to decode the response of the registry")
+		}
+
+		repositories = append(repositories, body.Repositories...)
+		path = nextLink(resp.Header.Get("Link"))
+	}
+
+	return repositories, nil
+}
+
+// PageRepositories returns up to n repositories following last of a list of repositories, in lexical order
+func PageRepositories(repositories []string, n int, last string) *CatalogPage {
+	repositories = slices.Clone(repositories)
+	slices.Sort(repositories)
+	repositories = slices.Compact(repositories)
+
+	start, found := slices.BinarySearch(repositories, last)
+	if found {
+		start++
+	}
+
+	page := &CatalogPage{Repositories: nonNil(repositories[start:min(start+n, len(repositories))])}
+	if start+n < len(repositories) {
+		page.Next = page.Repositories[len(page.Repositories)-1]
+	}
+
+	return page
+}
+
+// TagNames returns up to n tags of a repository following last, without their metadata
+func (c *Client) TagNames(ctx context.Context, repository string, n int, last string) ([]string, string, error) {
+	var body struct {
+		Tags []string `json:"tags"`
+	}
+
+	next, err := c.getList(ctx, "/v2/"+repository+"/tags/list", repositoryScope(repository), n, last, &body)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return nonNil(body.Tags), next, nil
+}
+
+// getList retrieves a page of a paginated list of the registry API, the next page being given by its Link header
+func (c *Client) getList(ctx context.Context, path, scope string, n int, last string, body any) (string, error) {
+	query := url.Values{}
+	if n > 0 {
+		query.Set("n", strconv.Itoa(n))
+	}
+	if last != "" {
+		query.Set("last", last)
+	}
+
+	if len(query) > 0 {
+		path += "?" + query.Encode()
+	}
+
+	resp, err := c.get(ctx, path, scope, nil)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if err := json.NewDecoder(resp.Body).Decode(body); err != nil {
+		return "", errors.Wrap(err, "unable to decode the response of the registry")
+	}
+
+	return nextLast(resp.Header.Get("Link")), nil
+}
+
+// get sends a GET request to the registry, authenticating it when the registry requires it
+func (c *Client) get(ctx context.Context, path, scope string, header http.Header) (*http.Response, error) {
+	return c.do(ctx, http.MethodGet, path, scope, header, nil)
+}
+
+// do sends a request to the registry, authenticating it when the registry requires it. The response is returned
+// when its status is successful
+func (c *Client) do(ctx context.Context, method, path, scope string, header http.Header, body []byte) (*http.Response, error) {
+	resp, err := c.send(ctx, method, path, scope, header, body)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode == http.StatusUnauthorized {
+		challenge := resp.Header.Get("WWW-Authenticate")
+		resp.Body.Close()
+
+		if err := c.authenticate(ctx, challenge, scope); err != nil {
+			return nil, err
+		}
+
+		if resp, err = c.send(ctx, method, path, scope, header, body); err != nil {
+			return nil, err
+		}
+	}
+
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		defer resp.Body.Close()
+
+		return nil, responseError(resp)
+	}
+
+	return resp, nil
+}
+
+func (c *Client) send(ctx context.Context, method, path, scope string, header http.Header, body []byte) (*http.Response, error) {
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, reader)
+	if err != nil {
+		return nil, err
+	}
+
+	for name, values := range header {
+		req.Header[name] = values
+	}
+
+	c.mu.Lock()
+	token, ok := c.tokens[scope]
+	c.mu.Unlock()
+
+	if ok && time.Now().Before(token.expiry) {
+		req.Header.Set("Authorization", "Bearer "+token.token)
+	} else if c.username != "" {
+		req.SetBasicAuth(c.username, c.password)
+	}
+
+	return c.httpClient.Do(req)
+}
+
+// authenticate answers the authentication challenge of the registry, retrieving a bearer token for the scope from the
+// token service of the registry
+func (c *Client) authenticate(ctx context.Context, challenge, scope string) error {
+	scheme, params := parseChallenge(challenge)
+	if scheme != "bearer" || params["realm"] == "" {
+		return ErrUnauthorized
+	}
+
+	query := url.Values{}
+	if service := params["service"]; service != "" {
+		query.Set("service", service)
+	}
+	query.Set("scope", scope)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, params["realm"]+"?"+query.Encode(), nil)
+	if err != nil {
+		return err
+	}
+
+	if c.username != "" {
+		req.SetBasicAuth(c.username, c.password)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return errors.Wrap(err, "unable to reach the token service of the registry")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden {
+		return ErrUnauthorized
+	} else if resp.StatusCode != http.StatusOK {
+		return responseError(resp)
+	}
+
+	var body struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return errors.Wrap(err, "unable to decode the token of the registry")
+	}
+
+	token := body.Token
+	if token == "" {
+		token = body.AccessToken
+	}
+
+	if token == "" {
+		return ErrUnauthorized
+	}
+
+	ttl := defaultTokenTTL
+	if body.ExpiresIn > 0 {
+		ttl = time.Duration(body.ExpiresIn) * time.Second
+	}
+
+	c.mu.Lock()
+	c.tokens[scope] = bearerToken{token: token, expiry: time.Now().Add(ttl - tokenExpiryMargin)}
+	c.mu.Unlock()
+
+	return nil
+}
+
+// parseChallenge parses a WWW-Authenticate header, e.g. Bearer realm="https://auth.docker.io/token",service="registry.docker.io"
+func parseChallenge(challenge string) (string, map[string]string) {
+	scheme, rest, _ := strings.Cut(strings.TrimSpace(challenge), " ")
+	params := make(map[string]string)
+
+	for rest != "" {
+		var key, value string
+
+		key, rest, _ = strings.Cut(strings.TrimLeft(rest, " ,"), "=")
+		if strings.HasPrefix(rest, `"`) {
+			value, rest, _ = strings.Cut(rest[1:], `"`)
+		} else {
+			value, rest, _ = strings.Cut(rest, ",")
+		}
+
+		if key != "" {
+			params[strings.ToLower(strings.TrimSpace(key))] = value
+		}
+	}
+
+	return strings.ToLower(scheme), params
+}
+
+// nextLast returns the last parameter of the next page link of a Link header, e.g. </v2/_catalog?last=b&n=2>; rel="next"
+func nextLast(link string) string {
+	next, err := url.Parse(nextLink(link))
+	if err != nil {
+		return ""
+	}
+
+	return next.Query().Get("last")
+}
+
+// nextLink returns the path and query of the next page link of a Link header
+func nextLink(link string) string {
+	target, params, ok := strings.Cut(link, ";")
+	if !ok || !strings.Contains(params, `rel="next"`) {
+		return ""
+	}
+
+	next, err := url.Parse(strings.Trim(strings.TrimSpace(target), "<>"))
+	if err != nil {
+		return ""
+	}
+
+	return next.RequestURI()
+}
+
+func repositoryScope(repository string) string {
+	return "repository:" + repository + ":pull"
+}
+
+func repositoryDeleteScope(repository string) string {
+	return "repository:" + repository + ":delete"
+}
+
+func responseError(resp *http.Response) error {
+	var body struct {
+		Errors []struct {
+			Code    string `json:"code"`
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+
+	if resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden {
+		return ErrUnauthorized
+	}
+
+	message := fmt.Sprintf("registry error %d", resp.StatusCode)
+
+	content, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+	if err := json.Unmarshal(content, &body); err == nil && len(body.Errors) > 0 {
+		message = fmt.Sprintf("registry error %d: %s: %s", resp.StatusCode, body.Errors[0].Code, body.Errors[0].Message)
+	}
+
+	return &statusError{statusCode: resp.StatusCode, message: message}
+}
+
+func (err *statusError) Error() string {
+	return err.message
+}
+
+// IsNotFound reports whether the registry answered that the resource of the request does not exist
+func IsNotFound(err error) bool {
+	var statusErr *statusError
+
+	return errors.As(err, &statusErr) && statusErr.statusCode == http.StatusNotFound
+}
+
+func nonNil(values []string) []string {
+	if values == nil {
+		return []string{}
+	}
+
+	return values
+}
@@ -0,0 +1,199 @@
+package registry
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/opencontainers/go-digest"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestRegistry starts a registry serving a multi-architecture image as latest and a single architecture image as
+// 1.0, authenticated with the token authentication for the user admin
+func newTestRegistry(t *testing.T) *httptest.Server {
+	created := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	config, _ := json.Marshal(map[string]any{"created": created, "architecture": "amd64", "os": "linux"})
+	configDigest := digest.FromBytes(config)
+
+	image, _ := json.Marshal(manifest{
+		MediaType: mediaTypeOCIManifest,
+		Config:    &descriptor{Digest: configDigest, Size: 100},
+		Layers:    []descriptor{{Size: 1000}, {Size: 2000}},
+	})
+	imageDigest := digest.FromBytes(image)
+
+	index, _ := json.Marshal(manifest{
+		MediaType: mediaTypeOCIIndex,
+		Manifests: []descriptor{
+			{Digest: imageDigest, Platform: &platform{Architecture: "amd64", OS: "linux"}},
+			{Digest: "sha256:arm", Platform: &platform{Architecture: "arm64", OS: "linux", Variant: "v8"}},
+			{Digest: "sha256:attestation", Platform: &platform{Architecture: "unknown", OS: "unknown"}},
+		},
+	})
+
+	mux := http.NewServeMux()
+	var server *httptest.Server
+
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		if username, password, ok := r.BasicAuth(); !ok || username != "admin" || password != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		json.NewEncoder(w).Encode(map[string]any{"token": "token:" + r.URL.Query().Get("scope"), "expires_in": 300})
+	})
+
+	mux.HandleFunc("/v2/", func(w http.ResponseWriter, r *http.Request) {
+		path := strings.TrimPrefix(r.URL.Path, "/v2/")
+
+		scope := "repository:" + strings.SplitN(path, "/tags/", 2)[0] + ":pull"
+		if i := strings.Index(path, "/manifests/"); i >= 0 {
+			scope = "repository:" + path[:i] + ":pull"
+		} else if i := strings.Index(path, "/blobs/"); i >= 0 {
+			scope = "repository:" + path[:i] + ":pull"
+		} else if path == "_catalog" {
+			scope = "registry:catalog:*"
+		}
+
+		if r.Method == http.MethodDelete {
+			scope = strings.TrimSuffix(scope, ":pull") + ":delete"
+		}
+
+		if r.Header.Get("Authorization") != "Bearer token:"+scope {
+			w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm="%s/token",service="test",scope="%s"`, server.URL, scope))
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		switch path {
+		case "app/api/manifests/" + imageDigest.String():
+			if r.Method == http.MethodDelete {
+				w.WriteHeader(http.StatusAccepted)
+				return
+			}
+
+			w.Header().Set("Content-Type", mediaTypeOCIManifest)
+			w.Write(image)
+		case "_catalog":
+			repositories := []string{"app/api", "app/web", "tools/cli"}
+			if r.URL.Query().Get("last") == "app/web" {
+				json.NewEncoder(w).Encode(map[string]any{"repositories": repositories[2:]})
+				return
+			}
+
+			w.Header().Set("Link", `</v2/_catalog?last=app%2Fweb&n=2>; rel="next"`)
+			json.NewEncoder(w).Encode(map[string]any{"repositories": repositories[:2]})
+		case "app/api/tags/list":
+			json.NewEncoder(w).Encode(map[string]any{"name": "app/api", "tags": []string{"1.0", "latest", "broken"}})
+		case "app/api/manifests/latest":
+			w.Header().Set("Content-Type", mediaTypeOCIIndex)
+			w.Header().Set("Docker-Content-Digest", digest.FromBytes(index).String())
+			w.Write(index)
+		case "app/api/manifests/1.0":
+			w.Header().Set("Content-Type", mediaTypeOCIManifest)
+			w.Write(image)
+		case "app/api/blobs/" + configDigest.String():
+			w.Write(config)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(map[string]any{"errors": []map[string]string{{"code": "MANIFEST_UNKNOWN", "message": "manifest unknown"}}})
+		}
+	})
+
+	server = httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func TestCatalog(t *testing.T) {
+	is := require.New(t)
+
+	server := newTestRegistry(t)
+	client := NewClient(server.URL, "admin", "secret", nil)
+
+	page, err := client.Catalog(context.Background(), 2, "")
+	is.NoError(err)
+	is.Equal([]string{"app/api", "app/web"}, page.Repositories)
+	is.Equal("app/web", page.Next)
+
+	page, err = client.Catalog(context.Background(), 2, page.Next)
+	is.NoError(err)
+	is.Equal([]string{"tools/cli"}, page.Repositories)
+	is.Empty(page.Next)
+
+	_, err = NewClient(server.URL, "admin", "wrong", nil).Catalog(context.Background(), 2, "")
+	is.ErrorIs(err, ErrUnauthorized)
+}
+
+func TestRepositories(t *testing.T) {
+	is := require.New(t)
+
+	server := newTestRegistry(t)
+	client := NewClient(server.URL, "admin", "secret", nil)
+
+	repositories, err := client.Repositories(context.Background())
+	is.NoError(err)
+	is.Equal([]string{"app/api", "app/web", "tools/cli"}, repositories)
+
+	page := PageRepositories([]string{"tools/cli", "app/web", "app/api"}, 2, "")
+	is.Equal([]string{"app/api", "app/web"}, page.Repositories)
+	is.Equal("app/web", page.Next)
+
+	page = PageRepositories(repositories, 2, page.Next)
+	is.Equal([]string{"tools/cli"}, page.Repositories)
+	is.Empty(page.Next)
+
+	page = PageRepositories(repositories, 2, "b")
+	is.Equal([]string{"tools/cli"}, page.Repositories)
+
+	is.Empty(PageRepositories(nil, 2, "").Repositories)
+}
+
+func TestTags(t *testing.T) {
+	is := require.New(t)
+
+	server := newTestRegistry(t)
+	client := NewClient(server.URL, "admin", "secret", nil)
+
+	page, err := client.Tags(context.Background(), "app/api", 10, "")
+	is.NoError(err)
+	is.Len(page.Tags, 3)
+
+	single := page.Tags[0]
+	is.Equal("1.0", single.Name)
+	is.Equal(mediaTypeOCIManifest, single.MediaType)
+	is.Equal(int64(3100), single.Size)
+	is.Equal([]string{"amd64"}, single.Architectures)
+	is.Equal(time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC), single.Created.UTC())
+
+	multi := page.Tags[1]
+	is.Equal("latest", multi.Name)
+	is.Equal(mediaTypeOCIIndex, multi.MediaType)
+	is.Equal([]string{"amd64", "arm64/v8"}, multi.Architectures)
+	is.Equal(int64(3100), multi.Size)
+	is.NotEmpty(multi.Digest)
+
+	is.Equal("broken", page.Tags[2].Name)
+	is.Contains(page.Tags[2].Error, "MANIFEST_UNKNOWN")
+}
+
+func TestParseChallenge(t *testing.T) {
+	is := require.New(t)
+
+	scheme, params := parseChallenge(`Bearer realm="https://auth.docker.io/token",service="registry.docker.io",scope="repository:library/nginx:pull"`)
+	is.Equal("bearer", scheme)
+	is.Equal("https://auth.docker.io/token", params["realm"])
+	is.Equal("registry.docker.io", params["service"])
+	is.Equal("repository:library/nginx:pull", params["scope"])
+
+	scheme, params = parseChallenge(`Basic realm="Registry"`)
+	is.Equal("basic", scheme)
+	is.Equal("Registry", params["realm"])
+}
@@ -0,0 +1,54 @@
+package registry
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/opencontainers/go-digest"
+	"github.com/pkg/errors"
+)
+
+// ManifestDigest returns the digest of the manifest, or of the index, of a tag of a repository. The digest is read
+// from the headers of a HEAD request, the manifest being only retrieved when the registry does not return them
+func (c *Client) ManifestDigest(ctx context.Context, repository, tag string) (digest.Digest, error) {
+	resp, err := c.do(ctx, http.MethodHead, "/v2/"+repository+"/manifests/"+tag, repositoryScope(repository), http.Header{"Accept": {manifestAccept}}, nil)
+	if err != nil {
+		return "", err
+	}
+	resp.Body.Close()
+
+	if manifestDigest, err := digest.Parse(resp.Header.Get("Docker-Content-Digest")); err == nil {
+		return manifestDigest, nil
+	}
+
+	_, _, manifestDigest, err := c.manifest(ctx, repository, tag)
+
+	return manifestDigest, err
+}
+
+// DeleteManifest deletes a manifest of a repository by digest, which untags every tag referencing it. The registry
+// must allow the deletions, e.g. with REGISTRY_STORAGE_DELETE_ENABLED for the distribution registry, and the space
+// of the layers is only reclaimed by its garbage collection
+func (c *Client) DeleteManifest(ctx context.Context, repository string, manifestDigest digest.Digest) error {
+	if err := manifestDigest.Validate(); err != nil {
+		return errors.Wrap(err, "invalid manifest digest")
+	}
+
+	resp, err := c.do(ctx, http.MethodDelete, "/v2/"+repository+"/manifests/"+manifestDigest.String(), repositoryDeleteScope(repository), nil, nil)
+	if err != nil {
+		return err
+	}
+
+	return resp.Body.Close()
+}
+
+// DeleteTag deletes a tag of a repository through the Docker Registry HTTP API V2, which only deletes manifests. The
+// manifest of the tag is deleted, along with the other tags referencing it, and its digest is returned
+func (c *Client) DeleteTag(ctx context.Context, repository, tag string) (digest.Digest, error) {
+	manifestDigest, err := c.ManifestDigest(ctx, repository, tag)
+	if err != nil {
+		return "", errors.Wrap(err, "unable to resolve the digest of the tag")
+	}
+
+	return manifestDigest, c.DeleteManifest(ctx, repository, manifestDigest)
+}
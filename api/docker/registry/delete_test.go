@@ -0,0 +1,109 @@
+package registry
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/opencontainers/go-digest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeleteTag(t *testing.T) {
+	is := require.New(t)
+
+	server := newTestRegistry(t)
+	client := NewClient(server.URL, "admin", "secret", nil)
+
+	tag, err := client.TagMetadata(context.Background(), "app/api", "1.0")
+	is.NoError(err)
+
+	deleted, err := client.DeleteTag(context.Background(), "app/api", "1.0")
+	is.NoError(err)
+	is.Equal(tag.Digest, deleted.String())
+
+	_, err = client.DeleteTag(context.Background(), "app/api", "missing")
+	is.ErrorContains(err, "registry error 404")
+
+	is.Error(client.DeleteManifest(context.Background(), "app/api", "sha256:invalid"))
+}
+
+func TestHarbor(t *testing.T) {
+	is := require.New(t)
+
+	var deleted []string
+	schedule := map[string]any{}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v2.0/", func(w http.ResponseWriter, r *http.Request) {
+		if username, password, ok := r.BasicAuth(); !ok || username != "admin" || password != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		switch {
+		case r.URL.Path == "/api/v2.0/ping":
+			w.Write([]byte("Pong"))
+		case r.Method == http.MethodDelete:
+			deleted = append(deleted, r.URL.EscapedPath())
+		case r.URL.Path == "/api/v2.0/system/gc/schedule" && r.Method == http.MethodGet:
+			json.NewEncoder(w).Encode(schedule)
+		case r.URL.Path == "/api/v2.0/system/gc/schedule":
+			var body harborGCRequest
+			is.NoError(json.NewDecoder(r.Body).Decode(&body))
+
+			if body.Schedule.Type == harborScheduleManual {
+				is.Equal(http.MethodPost, r.Method)
+				w.WriteHeader(http.StatusCreated)
+				return
+			}
+
+			is.Equal(len(schedule) == 0, r.Method == http.MethodPost)
+
+			parameters, _ := json.Marshal(body.Parameters)
+			schedule = map[string]any{"schedule": body.Schedule, "job_parameters": string(parameters)}
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	client := NewClient(server.URL, "admin", "secret", nil)
+	is.True(client.IsHarbor(context.Background()))
+	is.False(newTestRegistryClient(t).IsHarbor(context.Background()))
+
+	is.NoError(client.HarborDeleteTag(context.Background(), "library/app/api", "1.0"))
+	is.NoError(client.HarborDeleteArtifact(context.Background(), "library/app/api", digest.FromString("artifact")))
+	is.Equal([]string{
+		"/api/v2.0/projects/library/repositories/app%252Fapi/artifacts/1.0/tags/1.0",
+		"/api/v2.0/projects/library/repositories/app%252Fapi/artifacts/" + digest.FromString("artifact").String(),
+	}, deleted)
+
+	is.Error(client.HarborDeleteTag(context.Background(), "api", "1.0"))
+
+	is.NoError(client.HarborRunGC(context.Background(), true))
+
+	current, err := client.HarborGCSchedule(context.Background())
+	is.NoError(err)
+	is.Equal(harborScheduleNone, current.Type)
+
+	is.NoError(client.HarborSetGCSchedule(context.Background(), "0 0 2 * * *", true))
+	is.NoError(client.HarborSetGCSchedule(context.Background(), "0 0 3 * * *", true))
+
+	current, err = client.HarborGCSchedule(context.Background())
+	is.NoError(err)
+	is.Equal(harborScheduleCustom, current.Type)
+	is.Equal("0 0 3 * * *", current.Cron)
+	is.True(current.DeleteUntagged)
+
+	_, err = NewClient(server.URL, "admin", "wrong", nil).HarborGCSchedule(context.Background())
+	is.ErrorIs(err, ErrUnauthorized)
+}
+
+func newTestRegistryClient(t *testing.T) *Client {
+	return NewClient(newTestRegistry(t).URL, "admin", "secret", nil)
+}
@@ -0,0 +1,87 @@
+package registry
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// GitHubAPIURL is the URL of the API of github.com
+const GitHubAPIURL = "https://api.github.com"
+
+const githubPageSize = 100
+
+// GitHubRepositories returns the repositories of the container packages of a GitHub Container Registry owned by an
+// organization or a user, through the packages API of GitHub as the registry has no catalog. The password of the
+// client must be a personal access token with the read:packages scope
+func (c *Client) GitHubRepositories(ctx context.Context, apiURL, owner string) ([]string, error) {
+	path := "/user/packages"
+	if owner != "" && !strings.EqualFold(owner, c.username) {
+		path = "/orgs/" + url.PathEscape(owner) + "/packages"
+	}
+
+	var repositories []string
+	for page := 1; len(repositories) < maxRepositories; page++ {
+		query := url.Values{
+			"package_type": {"container"},
+			"per_page":     {strconv.Itoa(githubPageSize)},
+			"page":         {strconv.Itoa(page)},
+		}
+
+		var packages []struct {
+			Name  string `json:"name"`
+			Owner struct {
+				Login string `json:"login"`
+			} `json:"owner"`
+		}
+		if err := c.githubRequest(ctx, strings.TrimSuffix(apiURL, "/")+path+"?"+query.Encode(), &packages); err != nil {
+			return nil, err
+		}
+
+		for _, pkg := range packages {
+			repositories = append(repositories, strings.ToLower(pkg.Owner.Login+"/"+pkg.Name))
+		}
+
+		if len(packages) < githubPageSize {
+			break
+		}
+	}
+
+	return repositories, nil
+}
+
+func (c *Client) githubRequest(ctx context.Context, url string, out any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+	if c.password != "" {
+		req.Header.Set("Authorization", "Bearer "+c.password)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return errors.Wrap(err, "unable to reach the GitHub API")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden {
+		return ErrUnauthorized
+	} else if resp.StatusCode != http.StatusOK {
+		return &statusError{statusCode: resp.StatusCode, message: "GitHub API error " + strconv.Itoa(resp.StatusCode)}
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return errors.Wrap(err, "unable to decode the response of the GitHub API")
+	}
+
+	return nil
+}
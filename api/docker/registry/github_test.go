@@ -0,0 +1,77 @@
+package registry
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGitHubRepositories(t *testing.T) {
+	is := require.New(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer ghp_token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		if r.URL.Query().Get("package_type") != "container" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		type pkg struct {
+			Name  string         `json:"name"`
+			Owner map[string]any `json:"owner"`
+		}
+
+		switch r.URL.Path {
+		case "/user/packages":
+			json.NewEncoder(w).Encode([]pkg{{Name: "dotfiles", Owner: map[string]any{"login": "Octocat"}}})
+		case "/orgs/portainer/packages":
+			page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+
+			packages := []pkg{}
+			count := githubPageSize
+			if page == 2 {
+				count = 1
+			}
+
+			for i := range count {
+				packages = append(packages, pkg{Name: fmt.Sprintf("app-%d-%d", page, i), Owner: map[string]any{"login": "portainer"}})
+			}
+
+			json.NewEncoder(w).Encode(packages)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	client := NewClient("https://ghcr.io", "octocat", "ghp_token", nil)
+
+	repositories, err := client.GitHubRepositories(context.Background(), server.URL, "")
+	is.NoError(err)
+	is.Equal([]string{"octocat/dotfiles"}, repositories)
+
+	repositories, err = client.GitHubRepositories(context.Background(), server.URL, "OctoCat")
+	is.NoError(err)
+	is.Equal([]string{"octocat/dotfiles"}, repositories)
+
+	repositories, err = client.GitHubRepositories(context.Background(), server.URL, "portainer")
+	is.NoError(err)
+	is.Len(repositories, githubPageSize+1)
+	is.Equal("portainer/app-2-0", repositories[githubPageSize])
+
+	_, err = client.GitHubRepositories(context.Background(), server.URL, "unknown")
+	is.True(IsNotFound(err))
+
+	_, err = NewClient("https://ghcr.io", "octocat", "wrong", nil).GitHubRepositories(context.Background(), server.URL, "")
+	is.ErrorIs(err, ErrUnauthorized)
+}
@@ -0,0 +1,203 @@
+package registry
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/opencontainers/go-digest"
+	"github.com/pkg/errors"
+)
+
+const harborAPIPath = "/api/v2.0"
+
+const (
+	harborScheduleNone   = "None"
+	harborScheduleManual = "Manual"
+	harborScheduleCustom = "Custom"
+)
+
+type (
+	// GCSchedule is the garbage collection schedule of a Harbor registry
+	GCSchedule struct {
+		// Type of the schedule: None, Hourly, Daily, Weekly or Custom
+		Type string `json:"Type" example:"Custom"`
+		// Cron expression of the schedule, with a seconds field
+		Cron string `json:"Cron,omitempty" example:"0 0 2 * * *"`
+		// Next run of the garbage collection
+		NextRun *time.Time `json:"NextRun,omitempty"`
+		// Delete the manifests that are not tagged anymore
+		DeleteUntagged bool `json:"DeleteUntagged"`
+	}
+
+	harborSchedule struct {
+		Type              string     `json:"type"`
+		Cron              string     `json:"cron,omitempty"`
+		NextScheduledTime *time.Time `json:"next_scheduled_time,omitempty"`
+	}
+
+	harborGCParameters struct {
+		DeleteUntagged bool `json:"delete_untagged"`
+	}
+
+	harborGCRequest struct {
+		Schedule   harborSchedule      `json:"schedule"`
+		Parameters *harborGCParameters `json:"parameters,omitempty"`
+	}
+)
+
+// IsHarbor reports whether the registry is a Harbor registry, by calling the ping endpoint of the Harbor API
+func (c *Client) IsHarbor(ctx context.Context) bool {
+	resp, err := c.send(ctx, http.MethodGet, harborAPIPath+"/ping", "", nil, nil)
+	if err != nil {
+		return false
+	}
+	defer resp.Body.Close()
+
+	content, _ := io.ReadAll(io.LimitReader(resp.Body, 64))
+
+	return resp.StatusCode == http.StatusOK && strings.TrimSpace(string(content)) == "Pong"
+}
+
+// HarborDeleteTag deletes a tag of a repository of a Harbor registry, the artifact and its other tags being kept
+func (c *Client) HarborDeleteTag(ctx context.Context, repository, tag string) error {
+	path, err := harborArtifactPath(repository, url.PathEscape(tag))
+	if err != nil {
+		return err
+	}
+
+	return c.harborRequest(ctx, http.MethodDelete, path+"/tags/"+url.PathEscape(tag), nil, nil)
+}
+
+// HarborDeleteArtifact deletes an artifact of a repository of a Harbor registry by digest, along with its tags
+func (c *Client) HarborDeleteArtifact(ctx context.Context, repository string, artifactDigest digest.Digest) error {
+	if err := artifactDigest.Validate(); err != nil {
+		return errors.Wrap(err, "invalid artifact digest")
+	}
+
+	path, err := harborArtifactPath(repository, artifactDigest.String())
+	if err != nil {
+		return err
+	}
+
+	return c.harborRequest(ctx, http.MethodDelete, path, nil, nil)
+}
+
+// HarborRunGC starts a garbage collection of a Harbor registry, which runs in the background
+func (c *Client) HarborRunGC(ctx context.Context, deleteUntagged bool) error {
+	return c.harborRequest(ctx, http.MethodPost, harborAPIPath+"/system/gc/schedule", harborGCRequest{
+		Schedule:   harborSchedule{Type: harborScheduleManual},
+		Parameters: &harborGCParameters{DeleteUntagged: deleteUntagged},
+	}, nil)
+}
+
+// HarborGCSchedule returns the garbage collection schedule of a Harbor registry
+func (c *Client) HarborGCSchedule(ctx context.Context) (*GCSchedule, error) {
+	var body struct {
+		Schedule      *harborSchedule `json:"schedule"`
+		JobParameters string          `json:"job_parameters"`
+	}
+
+	if err := c.harborRequest(ctx, http.MethodGet, harborAPIPath+"/system/gc/schedule", nil, &body); err != nil {
+		return nil, err
+	}
+
+	schedule := &GCSchedule{Type: harborScheduleNone}
+	if body.Schedule == nil || body.Schedule.Type == "" {
+		return schedule, nil
+	}
+
+	schedule.Type = body.Schedule.Type
+	schedule.Cron = body.Schedule.Cron
+	schedule.NextRun = body.Schedule.NextScheduledTime
+
+	var parameters harborGCParameters
+	if err := json.Unmarshal([]byte(body.JobParameters), &parameters); err == nil {
+		schedule.DeleteUntagged = parameters.DeleteUntagged
+	}
+
+	return schedule, nil
+}
+
+// HarborSetGCSchedule schedules the garbage collection of a Harbor registry with a cron expression including a
+// seconds field, an empty expression removing the schedule
+func (c *Client) HarborSetGCSchedule(ctx context.Context, cron string, deleteUntagged bool) error {
+	body := harborGCRequest{Schedule: harborSchedule{Type: harborScheduleNone}}
+	if cron != "" {
+		body = harborGCRequest{
+			Schedule:   harborSchedule{Type: harborScheduleCustom, Cron: cron},
+			Parameters: &harborGCParameters{DeleteUntagged: deleteUntagged},
+		}
+	}
+
+	current, err := c.HarborGCSchedule(ctx)
+	if err != nil {
+		return err
+	}
+
+	// the schedule is created the first time and updated afterwards
+	method := http.MethodPut
+	if current.Type == harborScheduleNone {
+		if cron == "" {
+			return nil
+		}
+
+		method = http.MethodPost
+	}
+
+	return c.harborRequest(ctx, method, harborAPIPath+"/system/gc/schedule", body, nil)
+}
+
+// harborRequest sends a request to the Harbor API, authenticated with basic authentication, encoding in and
+// decoding the response into out when they are set
+func (c *Client) harborRequest(ctx context.Context, method, path string, in, out any) error {
+	var body []byte
+	// the projects are referenced by name, even when their name is numeric
+	header := http.Header{"Accept": {"application/json"}, "X-Is-Resource-Name": {"true"}}
+
+	if in != nil {
+		var err error
+		if body, err = json.Marshal(in); err != nil {
+			return err
+		}
+
+		header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.do(ctx, method, path, "", header, body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if out == nil {
+		return nil
+	}
+
+	content, err := io.ReadAll(io.LimitReader(resp.Body, maxManifestSize))
+	if err != nil {
+		return err
+	}
+
+	if len(bytes.TrimSpace(content)) == 0 {
+		return nil
+	}
+
+	return errors.Wrap(json.Unmarshal(content, out), "unable to decode the response of the Harbor API")
+}
+
+// harborArtifactPath returns the API path of an artifact of a repository, the first component of the repository
+// being the Harbor project and the rest the repository name, escaped twice as required by the Harbor API
+func harborArtifactPath(repository, reference string) (string, error) {
+	project, name, ok := strings.Cut(repository, "/")
+	if !ok || project == "" || name == "" {
+		return "", errors.New("the repository of a Harbor registry must be prefixed by its project")
+	}
+
+	return harborProjectPath(project) + "/repositories/" + url.PathEscape(url.PathEscape(name)) + "/artifacts/" + reference, nil
+}
@@ -0,0 +1,205 @@
+package registry
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/pkg/errors"
+)
+
+const harborPageSize = 100
+
+type (
+	// HarborProject is a project of a Harbor registry with its storage quota and usage
+	HarborProject struct {
+		ID              int64  `json:"Id" example:"1"`
+		Name            string `json:"Name" example:"library"`
+		Public          bool   `json:"Public" example:"false"`
+		RepositoryCount int64  `json:"RepositoryCount" example:"12"`
+		// Storage quota of the project in bytes, -1 when unlimited
+		StorageLimit int64 `json:"StorageLimit" example:"10737418240"`
+		// Storage used by the project in bytes
+		StorageUsed int64 `json:"StorageUsed" example:"52428800"`
+	}
+
+	// HarborMember is a member of a Harbor project
+	HarborMember struct {
+		ID         int64  `json:"id"`
+		EntityName string `json:"entity_name"`
+		// EntityType is u for a user and g for a group
+		EntityType string `json:"entity_type"`
+		RoleID     int    `json:"role_id"`
+	}
+
+	// HarborRobot is a robot account of a Harbor registry, its secret being only returned on creation
+	HarborRobot struct {
+		ID     int64  `json:"id"`
+		Name   string `json:"name"`
+		Secret string `json:"secret"`
+	}
+
+	// HarborRobotAccess is the access of a robot account to a project
+	HarborRobotAccess struct {
+		Project string
+		Push    bool
+	}
+
+	harborProject struct {
+		ProjectID int64  `json:"project_id"`
+		Name      string `json:"name"`
+		RepoCount int64  `json:"repo_count"`
+		Metadata  struct {
+			Public string `json:"public"`
+		} `json:"metadata"`
+	}
+
+	harborQuota struct {
+		Ref struct {
+			ID int64 `json:"id"`
+		} `json:"ref"`
+		Hard struct {
+			Storage int64 `json:"storage"`
+		} `json:"hard"`
+		Used struct {
+			Storage int64 `json:"storage"`
+		} `json:"used"`
+	}
+
+	harborRobotPermission struct {
+		Kind      string              `json:"kind"`
+		Namespace string              `json:"namespace"`
+		Access    []harborRobotAction `json:"access"`
+	}
+
+	harborRobotAction struct {
+		Resource string `json:"resource"`
+		Action   string `json:"action"`
+	}
+)
+
+// HarborProjects returns the projects of a Harbor registry visible to the user, with their storage quota and usage
+func (c *Client) HarborProjects(ctx context.Context) ([]HarborProject, error) {
+	projects, err := harborList[harborProject](ctx, c, harborAPIPath+"/projects", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	quotas, err := harborList[harborQuota](ctx, c, harborAPIPath+"/quotas", url.Values{"reference": {"project"}})
+	if err != nil && !errors.Is(err, ErrUnauthorized) {
+		return nil, err
+	}
+
+	// the quotas are only visible to the administrators of the registry
+	quotasByProject := make(map[int64]harborQuota, len(quotas))
+	for _, quota := range quotas {
+		quotasByProject[quota.Ref.ID] = quota
+	}
+
+	result := make([]HarborProject, 0, len(projects))
+	for _, project := range projects {
+		p := HarborProject{
+			ID:              project.ProjectID,
+			Name:            project.Name,
+			Public:          project.Metadata.Public == "true",
+			RepositoryCount: project.RepoCount,
+			StorageLimit:    -1,
+		}
+
+		if quota, ok := quotasByProject[project.ProjectID]; ok {
+			p.StorageLimit = quota.Hard.Storage
+			p.StorageUsed = quota.Used.Storage
+		}
+
+		result = append(result, p)
+	}
+
+	return result, nil
+}
+
+// HarborProjectMembers returns the members of a Harbor project
+func (c *Client) HarborProjectMembers(ctx context.Context, project string) ([]HarborMember, error) {
+	return harborList[HarborMember](ctx, c, harborProjectPath(project)+"/members", nil)
+}
+
+// HarborAddProjectMember adds a user of a Harbor registry to a project with a role
+func (c *Client) HarborAddProjectMember(ctx context.Context, project, username string, roleID int) error {
+	return c.harborRequest(ctx, http.MethodPost, harborProjectPath(project)+"/members", map[string]any{
+		"role_id":     roleID,
+		"member_user": map[string]string{"username": username},
+	}, nil)
+}
+
+// HarborUpdateProjectMember changes the role of a member of a Harbor project
+func (c *Client) HarborUpdateProjectMember(ctx context.Context, project string, memberID int64, roleID int) error {
+	return c.harborRequest(ctx, http.MethodPut, harborProjectPath(project)+"/members/"+strconv.FormatInt(memberID, 10), map[string]int{"role_id": roleID}, nil)
+}
+
+// HarborDeleteProjectMember removes a member of a Harbor project
+func (c *Client) HarborDeleteProjectMember(ctx context.Context, project string, memberID int64) error {
+	return c.harborRequest(ctx, http.MethodDelete, harborProjectPath(project)+"/members/"+strconv.FormatInt(memberID, 10), nil, nil)
+}
+
+// HarborCreateRobot creates a system robot account of a Harbor registry that never expires, allowed to pull the
+// images of the projects and to push the images of the projects it can push to
+func (c *Client) HarborCreateRobot(ctx context.Context, name, description string, accesses []HarborRobotAccess) (*HarborRobot, error) {
+	permissions := make([]harborRobotPermission, 0, len(accesses))
+	for _, access := range accesses {
+		actions := []harborRobotAction{{Resource: "repository", Action: "pull"}}
+		if access.Push {
+			actions = append(actions, harborRobotAction{Resource: "repository", Action: "push"})
+		}
+
+		permissions = append(permissions, harborRobotPermission{Kind: "project", Namespace: access.Project, Access: actions})
+	}
+
+	var robot HarborRobot
+	if err := c.harborRequest(ctx, http.MethodPost, harborAPIPath+"/robots", map[string]any{
+		"name":        name,
+		"description": description,
+		"duration":    -1,
+		"level":       "system",
+		"permissions": permissions,
+	}, &robot); err != nil {
+		return nil, err
+	}
+
+	return &robot, nil
+}
+
+// HarborDeleteRobot deletes a robot account of a Harbor registry
+func (c *Client) HarborDeleteRobot(ctx context.Context, id int64) error {
+	return c.harborRequest(ctx, http.MethodDelete, harborAPIPath+"/robots/"+strconv.FormatInt(id, 10), nil, nil)
+}
+
+// harborList retrieves every page of a list of the Harbor API
+func harborList[T any](ctx context.Context, c *Client, path string, query url.Values) ([]T, error) {
+	if query == nil {
+		query = url.Values{}
+	}
+	query.Set("page_size", strconv.Itoa(harborPageSize))
+
+	var items []T
+	for page := 1; ; page++ {
+		query.Set("page", strconv.Itoa(page))
+
+		var pageItems []T
+		if err := c.harborRequest(ctx, http.MethodGet, path+"?"+query.Encode(), nil, &pageItems); err != nil {
+			return nil, err
+		}
+
+		items = append(items, pageItems...)
+		if len(pageItems) < harborPageSize {
+			return items, nil
+		}
+
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func harborProjectPath(project string) string {
+	return harborAPIPath + "/projects/" + url.PathEscape(project)
+}
@@ -0,0 +1,198 @@
+package registry
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/opencontainers/go-digest"
+	"github.com/pkg/errors"
+	"golang.org/x/sync/errgroup"
+)
+
+const (
+	mediaTypeDockerManifest     = "application/vnd.docker.distribution.manifest.v2+json"
+	mediaTypeDockerManifestList = "application/vnd.docker.distribution.manifest.list.v2+json"
+	mediaTypeOCIManifest        = "application/vnd.oci.image.manifest.v1+json"
+	mediaTypeOCIIndex           = "application/vnd.oci.image.index.v1+json"
+
+	// tagMetadataConcurrency is the number of tags whose metadata is retrieved at once
+	tagMetadataConcurrency = 8
+	maxManifestSize        = 4 * 1024 * 1024
+)
+
+var manifestAccept = strings.Join([]string{mediaTypeOCIIndex, mediaTypeDockerManifestList, mediaTypeOCIManifest, mediaTypeDockerManifest}, ", ")
+
+type (
+	descriptor struct {
+		MediaType string        `json:"mediaType"`
+		Digest    digest.Digest `json:"digest"`
+		Size      int64         `json:"size"`
+		Platform  *platform     `json:"platform,omitempty"`
+	}
+
+	platform struct {
+		Architecture string `json:"architecture"`
+		OS           string `json:"os"`
+		Variant      string `json:"variant,omitempty"`
+	}
+
+	// manifest holds the fields of the image manifests and of the image indexes
+	manifest struct {
+		MediaType string       `json:"mediaType"`
+		Config    *descriptor  `json:"config,omitempty"`
+		Layers    []descriptor `json:"layers,omitempty"`
+		Manifests []descriptor `json:"manifests,omitempty"`
+	}
+
+	imageConfig struct {
+		Created      *time.Time `json:"created,omitempty"`
+		Architecture string     `json:"architecture"`
+		Variant      string     `json:"variant,omitempty"`
+	}
+)
+
+// Tags returns up to n tags of a repository following last with the metadata of their manifest. The tags whose
+// metadata cannot be retrieved are returned with an error rather than failing the page
+func (c *Client) Tags(ctx context.Context, repository string, n int, last string) (*TagsPage, error) {
+	names, next, err := c.TagNames(ctx, repository, n, last)
+	if err != nil {
+		return nil, err
+	}
+
+	tags := make([]Tag, len(names))
+
+	g, gCtx := errgroup.WithContext(ctx)
+	g.SetLimit(tagMetadataConcurrency)
+
+	for i, name := range names {
+		g.Go(func() error {
+			tag, err := c.TagMetadata(gCtx, repository, name)
+			if err != nil {
+				tag = &Tag{Name: name, Architectures: []string{}, Error: err.Error()}
+			}
+
+			tags[i] = *tag
+
+			return nil
+		})
+	}
+
+	_ = g.Wait()
+
+	return &TagsPage{Tags: tags, Next: next}, nil
+}
+
+// TagMetadata returns the digest, size, creation date and architectures of a tag of a repository. The size and the
+// creation date of a multi-architecture image are the ones of its first architecture
+func (c *Client) TagMetadata(ctx context.Context, repository, tag string) (*Tag, error) {
+	m, mediaType, manifestDigest, err := c.manifest(ctx, repository, tag)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &Tag{Name: tag, Digest: manifestDigest.String(), MediaType: mediaType, Architectures: []string{}}
+
+	if len(m.Manifests) > 0 {
+		var first *descriptor
+
+		for i, entry := range m.Manifests {
+			// the attestation manifests of the BuildKit images have an unknown platform
+			if entry.Platform == nil || entry.Platform.Architecture == "unknown" {
+				continue
+			}
+
+			result.Architectures = append(result.Architectures, architecture(entry.Platform.Architecture, entry.Platform.Variant))
+
+			if first == nil {
+				first = &m.Manifests[i]
+			}
+		}
+
+		if first == nil {
+			return result, nil
+		}
+
+		if m, _, _, err = c.manifest(ctx, repository, first.Digest.String()); err != nil {
+			return nil, err
+		}
+	}
+
+	if m.Config == nil {
+		return nil, errors.Errorf("unsupported manifest media type %s", mediaType)
+	}
+
+	result.Size = m.Config.Size
+	for _, layer := range m.Layers {
+		result.Size += layer.Size
+	}
+
+	config, err := c.imageConfig(ctx, repository, m.Config.Digest)
+	if err != nil {
+		return nil, err
+	}
+
+	result.Created = config.Created
+	if len(result.Architectures) == 0 && config.Architecture != "" {
+		result.Architectures = append(result.Architectures, architecture(config.Architecture, config.Variant))
+	}
+
+	return result, nil
+}
+
+// manifest retrieves the manifest or the index of a reference, a tag or a digest, of a repository
+func (c *Client) manifest(ctx context.Context, repository, reference string) (*manifest, string, digest.Digest, error) {
+	resp, err := c.get(ctx, "/v2/"+repository+"/manifests/"+reference, repositoryScope(repository), http.Header{"Accept": {manifestAccept}})
+	if err != nil {
+		return nil, "", "", err
+	}
+	defer resp.Body.Close()
+
+	content, err := io.ReadAll(io.LimitReader(resp.Body, maxManifestSize))
+	if err != nil {
+		return nil, "", "", err
+	}
+
+	var m manifest
+	if err := json.Unmarshal(content, &m); err != nil {
+		return nil, "", "", errors.Wrap(err, "unable to decode the manifest")
+	}
+
+	mediaType := m.MediaType
+	if contentType, _, _ := strings.Cut(resp.Header.Get("Content-Type"), ";"); contentType != "" && contentType != "application/json" {
+		mediaType = contentType
+	}
+
+	manifestDigest, err := digest.Parse(resp.Header.Get("Docker-Content-Digest"))
+	if err != nil {
+		manifestDigest = digest.FromBytes(content)
+	}
+
+	return &m, mediaType, manifestDigest, nil
+}
+
+func (c *Client) imageConfig(ctx context.Context, repository string, configDigest digest.Digest) (*imageConfig, error) {
+	resp, err := c.get(ctx, "/v2/"+repository+"/blobs/"+configDigest.String(), repositoryScope(repository), nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var config imageConfig
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxManifestSize)).Decode(&config); err != nil {
+		return nil, errors.Wrap(err, "unable to decode the image configuration")
+	}
+
+	return &config, nil
+}
+
+func architecture(arch, variant string) string {
+	if variant == "" {
+		return arch
+	}
+
+	return arch + "/" + variant
+}
@@ -0,0 +1,323 @@
+// Package rotation rotates the Swarm configs and secrets: it creates a new version of an object, moves the services
+// using it to the new version and removes the previous one, which Docker requires since the objects are immutable
+package rotation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"strconv"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/swarm"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// NameLabel is the label holding the name shared by the versions of a rotated object
+	NameLabel = "io.portainer.rotation.name"
+	// VersionLabel is the label holding the version of a rotated object, the objects without it are the first version
+	VersionLabel = "io.portainer.rotation.version"
+)
+
+// Kind is the kind of the Swarm objects which can be rotated
+type Kind string
+
+const (
+	ConfigKind Kind = "config"
+	SecretKind Kind = "secret"
+)
+
+var (
+	// ErrExternalSecret is returned when the rotated secret is provided by a secret driver, its content is not stored
+	// by Swarm
+	ErrExternalSecret = errors.New("the secrets provided by a secret driver cannot be rotated")
+	// ErrEmptyData is returned when the new version has no content
+	ErrEmptyData = errors.New("the new version must have a content")
+)
+
+// Client is the part of the Docker client used to rotate the configs and the secrets
+type Client interface {
+	ConfigInspectWithRaw(ctx context.Context, name string) (swarm.Config, []byte, error)
+	ConfigCreate(ctx context.Context, config swarm.ConfigSpec) (types.ConfigCreateResponse, error)
+	ConfigRemove(ctx context.Context, id string) error
+	SecretInspectWithRaw(ctx context.Context, name string) (swarm.Secret, []byte, error)
+	SecretCreate(ctx context.Context, secret swarm.SecretSpec) (types.SecretCreateResponse, error)
+	SecretRemove(ctx context.Context, id string) error
+	ServiceList(ctx context.Context, options types.ServiceListOptions) ([]swarm.Service, error)
+	ServiceInspectWithRaw(ctx context.Context, serviceID string, options types.ServiceInspectOptions) (swarm.Service, []byte, error)
+	ServiceUpdate(ctx context.Context, serviceID string, version swarm.Version, service swarm.ServiceSpec, options types.ServiceUpdateOptions) (swarm.ServiceUpdateResponse, error)
+}
+
+// Options are the options of a rotation
+type Options struct {
+	// Content of the new version
+	Data []byte
+	// Name of the new version, generated from the name and the version of the rotated object when empty
+	Name string
+	// KeepPrevious keeps the previous version instead of removing it once the services use the new one
+	KeepPrevious bool
+}
+
+// Result describes a rotation
+type Result struct {
+	// Identifier of the new version
+	ID string `json:"Id" example:"ktnbskdj3tkmtv74xazngaqoh"`
+	// Name of the new version
+	Name string `json:"Name" example:"nginx.conf_v2"`
+	// Version number of the new version
+	Version int `json:"Version" example:"2"`
+	// Identifier of the previous version
+	PreviousID string `json:"PreviousId" example:"9a0br7y5mlv1j5hdrtbnndwf4"`
+	// Whether the previous version was removed
+	PreviousRemoved bool `json:"PreviousRemoved" example:"true"`
+	// Identifiers of the services moved to the new version
+	Services []string `json:"Services"`
+}
+
+// object is a Swarm config or secret
+type object struct {
+	ID     string
+	Name   string
+	Labels map[string]string
+	// create creates a version of the object with the given annotations and content
+	create func(ctx context.Context, annotations swarm.Annotations, data []byte) (string, error)
+	remove func(ctx context.Context, id string) error
+}
+
+// Rotate creates a new version of a config or a secret, moves the services using it to the new version, keeping the
+// path at which it is mounted, and removes the previous version. When a service cannot be moved, the services already
+// moved are moved back and the new version is removed
+func Rotate(ctx context.Context, cli Client, kind Kind, id string, options Options) (*Result, error) {
+	if len(options.Data) == 0 {
+		return nil, ErrEmptyData
+	}
+
+	previous, err := inspect(ctx, cli, kind, id)
+	if err != nil {
+		return nil, err
+	}
+
+	name, version := NextVersion(previous.Name, previous.Labels)
+	if options.Name != "" {
+		name = options.Name
+	}
+
+	labels := maps.Clone(previous.Labels)
+	if labels == nil {
+		labels = map[string]string{}
+	}
+
+	if labels[NameLabel] == "" {
+		labels[NameLabel] = previous.Name
+	}
+	labels[VersionLabel] = strconv.Itoa(version)
+
+	newID, err := previous.create(ctx, swarm.Annotations{Name: name, Labels: labels}, options.Data)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create the new version: %w", err)
+	}
+
+	result := &Result{
+		ID:         newID,
+		Name:       name,
+		Version:    version,
+		PreviousID: previous.ID,
+		Services:   []string{},
+	}
+
+	services, err := DependentServices(ctx, cli, kind, previous.ID)
+	if err != nil {
+		removeVersion(previous, newID)
+
+		return nil, err
+	}
+
+	for _, service := range services {
+		spec := service.Spec
+		replaceReferences(&spec, kind, previous.ID, newID, name)
+
+		if _, err := cli.ServiceUpdate(ctx, service.ID, service.Version, spec, types.ServiceUpdateOptions{}); err != nil {
+			restoreServices(cli, kind, result.Services, newID, previous)
+			removeVersion(previous, newID)
+
+			return nil, fmt.Errorf("unable to update the service %s: %w", service.Spec.Name, err)
+		}
+
+		result.Services = append(result.Services, service.ID)
+	}
+
+	if !options.KeepPrevious {
+		if err := previous.remove(ctx, previous.ID); err != nil {
+			log.Warn().Err(err).Str("kind", string(kind)).Str("id", previous.ID).Msg("unable to remove the previous version")
+		} else {
+			result.PreviousRemoved = true
+		}
+	}
+
+	return result, nil
+}
+
+// NextVersion returns the name and the version number of the version following an object
+func NextVersion(name string, labels map[string]string) (string, int) {
+	version, err := strconv.Atoi(labels[VersionLabel])
+	if err != nil || version < 1 {
+		version = 1
+	}
+
+	base := labels[NameLabel]
+	if base == "" {
+		base = name
+	}
+
+	return fmt.Sprintf("%s_v%d", base, version+1), version + 1
+}
+
+// DependentServices returns the services using a config or a secret
+func DependentServices(ctx context.Context, cli Client, kind Kind, id string) ([]swarm.Service, error) {
+	services, err := cli.ServiceList(ctx, types.ServiceListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("unable to list the services: %w", err)
+	}
+
+	dependents := []swarm.Service{}
+	for _, service := range services {
+		if references(&service.Spec, kind, id) {
+			dependents = append(dependents, service)
+		}
+	}
+
+	return dependents, nil
+}
+
+func inspect(ctx context.Context, cli Client, kind Kind, id string) (*object, error) {
+	switch kind {
+	case ConfigKind:
+		config, _, err := cli.ConfigInspectWithRaw(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+
+		return &object{
+			ID:     config.ID,
+			Name:   config.Spec.Name,
+			Labels: config.Spec.Labels,
+			create: func(ctx context.Context, annotations swarm.Annotations, data []byte) (string, error) {
+				response, err := cli.ConfigCreate(ctx, swarm.ConfigSpec{Annotations: annotations, Data: data, Templating: config.Spec.Templating})
+				return response.ID, err
+			},
+			remove: cli.ConfigRemove,
+		}, nil
+	case SecretKind:
+		secret, _, err := cli.SecretInspectWithRaw(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+
+		if secret.Spec.Driver != nil {
+			return nil, ErrExternalSecret
+		}
+
+		return &object{
+			ID:     secret.ID,
+			Name:   secret.Spec.Name,
+			Labels: secret.Spec.Labels,
+			create: func(ctx context.Context, annotations swarm.Annotations, data []byte) (string, error) {
+				response, err := cli.SecretCreate(ctx, swarm.SecretSpec{Annotations: annotations, Data: data, Templating: secret.Spec.Templating})
+				return response.ID, err
+			},
+			remove: cli.SecretRemove,
+		}, nil
+	}
+
+	return nil, fmt.Errorf("unsupported kind: %s", kind)
+}
+
+func references(spec *swarm.ServiceSpec, kind Kind, id string) bool {
+	containerSpec := spec.TaskTemplate.ContainerSpec
+	if containerSpec == nil {
+		return false
+	}
+
+	switch kind {
+	case ConfigKind:
+		for _, config := range containerSpec.Configs {
+			if config.ConfigID == id {
+				return true
+			}
+		}
+	case SecretKind:
+		for _, secret := range containerSpec.Secrets {
+			if secret.SecretID == id {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// replaceReferences moves the references of a service spec from a version to another, the copied spec does not share
+// the references of the service
+func replaceReferences(spec *swarm.ServiceSpec, kind Kind, fromID, toID, toName string) {
+	containerSpec := *spec.TaskTemplate.ContainerSpec
+	spec.TaskTemplate.ContainerSpec = &containerSpec
+
+	switch kind {
+	case ConfigKind:
+		configs := make([]*swarm.ConfigReference, 0, len(containerSpec.Configs))
+		for _, config := range containerSpec.Configs {
+			if config.ConfigID == fromID {
+				reference := *config
+				reference.ConfigID, reference.ConfigName = toID, toName
+				config = &reference
+			}
+
+			configs = append(configs, config)
+		}
+
+		containerSpec.Configs = configs
+	case SecretKind:
+		secrets := make([]*swarm.SecretReference, 0, len(containerSpec.Secrets))
+		for _, secret := range containerSpec.Secrets {
+			if secret.SecretID == fromID {
+				reference := *secret
+				reference.SecretID, reference.SecretName = toID, toName
+				secret = &reference
+			}
+
+			secrets = append(secrets, secret)
+		}
+
+		containerSpec.Secrets = secrets
+	}
+}
+
+// restoreServices moves the services back to the previous version after a failed rotation
+func restoreServices(cli Client, kind Kind, serviceIDs []string, newID string, previous *object) {
+	// the request may have been canceled, the services are restored anyway
+	ctx := context.Background()
+
+	for _, serviceID := range serviceIDs {
+		service, _, err := cli.ServiceInspectWithRaw(ctx, serviceID, types.ServiceInspectOptions{})
+		if err == nil {
+			spec := service.Spec
+			replaceReferences(&spec, kind, newID, previous.ID, previous.Name)
+
+			_, err = cli.ServiceUpdate(ctx, service.ID, service.Version, spec, types.ServiceUpdateOptions{})
+		}
+
+		if err != nil {
+			log.Error().Err(err).Str("service_id", serviceID).Str("kind", string(kind)).Msg("unable to move the service back to the previous version")
+		}
+	}
+}
+
+// removeVersion removes a new version which is no longer used after a failed rotation
+func removeVersion(previous *object, id string) {
+	// the request may have been canceled, the new version is removed anyway
+	if err := previous.remove(context.Background(), id); err != nil {
+		log.Warn().Err(err).Str("id", id).Msg("unable to remove the new version after a failed rotation")
+	}
+}
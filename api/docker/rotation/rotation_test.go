@@ -0,0 +1,199 @@
+package rotation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/swarm"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeClient keeps the configs, the secrets and the services of a Swarm cluster in memory
+type fakeClient struct {
+	configs   map[string]swarm.Config
+	secrets   map[string]swarm.Secret
+	services  map[string]swarm.Service
+	nextID    int
+	failOnIDs map[string]bool
+}
+
+func newFakeClient() *fakeClient {
+	return &fakeClient{
+		configs:   map[string]swarm.Config{},
+		secrets:   map[string]swarm.Secret{},
+		services:  map[string]swarm.Service{},
+		failOnIDs: map[string]bool{},
+	}
+}
+
+func (c *fakeClient) id() string {
+	c.nextID++
+	return fmt.Sprintf("id%d", c.nextID)
+}
+
+func (c *fakeClient) ConfigInspectWithRaw(ctx context.Context, id string) (swarm.Config, []byte, error) {
+	config, ok := c.configs[id]
+	if !ok {
+		return config, nil, errors.New("not found")
+	}
+
+	return config, nil, nil
+}
+
+func (c *fakeClient) ConfigCreate(ctx context.Context, spec swarm.ConfigSpec) (types.ConfigCreateResponse, error) {
+	id := c.id()
+	c.configs[id] = swarm.Config{ID: id, Spec: spec}
+
+	return types.ConfigCreateResponse{ID: id}, nil
+}
+
+func (c *fakeClient) ConfigRemove(ctx context.Context, id string) error {
+	delete(c.configs, id)
+	return nil
+}
+
+func (c *fakeClient) SecretInspectWithRaw(ctx context.Context, id string) (swarm.Secret, []byte, error) {
+	secret, ok := c.secrets[id]
+	if !ok {
+		return secret, nil, errors.New("not found")
+	}
+
+	return secret, nil, nil
+}
+
+func (c *fakeClient) SecretCreate(ctx context.Context, spec swarm.SecretSpec) (types.SecretCreateResponse, error) {
+	id := c.id()
+	c.secrets[id] = swarm.Secret{ID: id, Spec: spec}
+
+	return types.SecretCreateResponse{ID: id}, nil
+}
+
+func (c *fakeClient) SecretRemove(ctx context.Context, id string) error {
+	delete(c.secrets, id)
+	return nil
+}
+
+func (c *fakeClient) ServiceList(ctx context.Context, options types.ServiceListOptions) ([]swarm.Service, error) {
+	services := []swarm.Service{}
+	for _, service := range c.services {
+		services = append(services, service)
+	}
+
+	return services, nil
+}
+
+func (c *fakeClient) ServiceInspectWithRaw(ctx context.Context, id string, options types.ServiceInspectOptions) (swarm.Service, []byte, error) {
+	return c.services[id], nil, nil
+}
+
+func (c *fakeClient) ServiceUpdate(ctx context.Context, id string, version swarm.Version, spec swarm.ServiceSpec, options types.ServiceUpdateOptions) (swarm.ServiceUpdateResponse, error) {
+	service := c.services[id]
+	if c.failOnIDs[id] || service.Version != version {
+		return swarm.ServiceUpdateResponse{}, errors.New("update failed")
+	}
+
+	service.Spec = spec
+	service.Version.Index++
+	c.services[id] = service
+
+	return swarm.ServiceUpdateResponse{}, nil
+}
+
+func (c *fakeClient) addService(id string, configs []*swarm.ConfigReference, secrets []*swarm.SecretReference) {
+	c.services[id] = swarm.Service{
+		ID:   id,
+		Meta: swarm.Meta{Version: swarm.Version{Index: 1}},
+		Spec: swarm.ServiceSpec{
+			Annotations:  swarm.Annotations{Name: id},
+			TaskTemplate: swarm.TaskSpec{ContainerSpec: &swarm.ContainerSpec{Configs: configs, Secrets: secrets}},
+		},
+	}
+}
+
+func TestNextVersion(t *testing.T) {
+	name, version := NextVersion("nginx.conf", nil)
+	require.Equal(t, "nginx.conf_v2", name)
+	require.Equal(t, 2, version)
+
+	name, version = NextVersion("custom", map[string]string{NameLabel: "nginx.conf", VersionLabel: "4"})
+	require.Equal(t, "nginx.conf_v5", name)
+	require.Equal(t, 5, version)
+}
+
+func TestRotateConfig(t *testing.T) {
+	cli := newFakeClient()
+	cli.configs["old"] = swarm.Config{ID: "old", Spec: swarm.ConfigSpec{
+		Annotations: swarm.Annotations{Name: "nginx.conf", Labels: map[string]string{"com.docker.stack.namespace": "web"}},
+		Data:        []byte("old"),
+	}}
+
+	file := &swarm.ConfigReferenceFileTarget{Name: "/etc/nginx/nginx.conf", Mode: 0444}
+	cli.addService("web", []*swarm.ConfigReference{{ConfigID: "old", ConfigName: "nginx.conf", File: file}, {ConfigID: "other", ConfigName: "other"}}, nil)
+	cli.addService("unrelated", []*swarm.ConfigReference{{ConfigID: "other", ConfigName: "other"}}, nil)
+
+	result, err := Rotate(context.Background(), cli, ConfigKind, "old", Options{Data: []byte("new")})
+	require.NoError(t, err)
+	require.Equal(t, &Result{ID: "id1", Name: "nginx.conf_v2", Version: 2, PreviousID: "old", PreviousRemoved: true, Services: []string{"web"}}, result)
+
+	require.NotContains(t, cli.configs, "old")
+	require.Equal(t, []byte("new"), cli.configs["id1"].Spec.Data)
+	require.Equal(t, map[string]string{
+		"com.docker.stack.namespace": "web",
+		NameLabel:                    "nginx.conf",
+		VersionLabel:                 "2",
+	}, cli.configs["id1"].Spec.Labels)
+
+	configs := cli.services["web"].Spec.TaskTemplate.ContainerSpec.Configs
+	require.Equal(t, &swarm.ConfigReference{ConfigID: "id1", ConfigName: "nginx.conf_v2", File: file}, configs[0])
+	require.Equal(t, "other", configs[1].ConfigID)
+	require.Equal(t, uint64(1), cli.services["unrelated"].Version.Index)
+}
+
+func TestRotateSecretRollback(t *testing.T) {
+	cli := newFakeClient()
+	cli.secrets["old"] = swarm.Secret{ID: "old", Spec: swarm.SecretSpec{Annotations: swarm.Annotations{Name: "password"}}}
+
+	cli.addService("a", nil, []*swarm.SecretReference{{SecretID: "old", SecretName: "password"}})
+	cli.addService("b", nil, []*swarm.SecretReference{{SecretID: "old", SecretName: "password"}})
+	cli.failOnIDs["b"] = true
+
+	_, err := Rotate(context.Background(), cli, SecretKind, "old", Options{Data: []byte("new")})
+	require.Error(t, err)
+
+	require.Len(t, cli.secrets, 1)
+	require.Contains(t, cli.secrets, "old")
+
+	for _, id := range []string{"a", "b"} {
+		require.Equal(t, "old", cli.services[id].Spec.TaskTemplate.ContainerSpec.Secrets[0].SecretID)
+		require.Equal(t, "password", cli.services[id].Spec.TaskTemplate.ContainerSpec.Secrets[0].SecretName)
+	}
+}
+
+func TestRotateKeepPrevious(t *testing.T) {
+	cli := newFakeClient()
+	cli.secrets["old"] = swarm.Secret{ID: "old", Spec: swarm.SecretSpec{Annotations: swarm.Annotations{Name: "password"}}}
+
+	result, err := Rotate(context.Background(), cli, SecretKind, "old", Options{Data: []byte("new"), Name: "password-2025", KeepPrevious: true})
+	require.NoError(t, err)
+	require.Equal(t, "password-2025", result.Name)
+	require.False(t, result.PreviousRemoved)
+	require.Empty(t, result.Services)
+	require.Len(t, cli.secrets, 2)
+}
+
+func TestRotateErrors(t *testing.T) {
+	cli := newFakeClient()
+	cli.secrets["external"] = swarm.Secret{ID: "external", Spec: swarm.SecretSpec{Driver: &swarm.Driver{Name: "vault"}}}
+
+	_, err := Rotate(context.Background(), cli, SecretKind, "external", Options{Data: []byte("new")})
+	require.ErrorIs(t, err, ErrExternalSecret)
+
+	_, err = Rotate(context.Background(), cli, SecretKind, "external", Options{})
+	require.ErrorIs(t, err, ErrEmptyData)
+
+	_, err = Rotate(context.Background(), cli, ConfigKind, "missing", Options{Data: []byte("new")})
+	require.Error(t, err)
+}
@@ -26,5 +26,10 @@ func (snapshotter *Snapshotter) CreateSnapshot(endpoint *portainer.Endpoint) (*p
 	}
 	defer cli.Close()
 
-	return snapshot.CreateDockerSnapshot(cli)
+	libpod, err := snapshotter.clientFactory.CreateLibpodClient(cli, endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	return snapshot.CreateDockerSnapshotWithLibpod(cli, libpod)
 }
@@ -0,0 +1,224 @@
+package docker
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+	"strings"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/docker/consts"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/api/types/swarm"
+	"golang.org/x/sync/errgroup"
+)
+
+const (
+	composeServiceLabel = "com.docker.compose.service"
+	swarmServiceLabel   = "com.docker.swarm.service.name"
+	// stackUsageConcurrency is the number of containers whose stats are retrieved at once, every retrieval takes
+	// about a second for the daemon to sample the CPU usage
+	stackUsageConcurrency = 8
+)
+
+// StackUsageClient is the part of the Docker client used to compute the resource usage of a stack
+type StackUsageClient interface {
+	ContainerList(ctx context.Context, options container.ListOptions) ([]types.Container, error)
+	ContainerInspect(ctx context.Context, containerID string) (types.ContainerJSON, error)
+	ContainerStats(ctx context.Context, containerID string, stream bool) (container.StatsResponseReader, error)
+	ServiceList(ctx context.Context, options types.ServiceListOptions) ([]swarm.Service, error)
+	TaskList(ctx context.Context, options types.TaskListOptions) ([]swarm.Task, error)
+}
+
+// CalculateStackUsage aggregates the CPU, memory and network usage and the restarts of the containers of a Compose or
+// Swarm stack. The containers of a Swarm stack are the ones reachable through the client, all the nodes when it targets
+// an agent
+func CalculateStackUsage(ctx context.Context, cli StackUsageClient, stack *portainer.Stack) (*portainer.StackUsage, error) {
+	stackLabel, serviceLabel := consts.ComposeStackNameLabel, composeServiceLabel
+	if stack.Type == portainer.DockerSwarmStack {
+		stackLabel, serviceLabel = consts.SwarmStackNameLabel, swarmServiceLabel
+	}
+
+	containers, err := cli.ContainerList(ctx, container.ListOptions{
+		All:     true,
+		Filters: filters.NewArgs(filters.Arg("label", stackLabel+"="+stack.Name)),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	usages := make([]portainer.StackResourceUsage, len(containers))
+
+	g, gCtx := errgroup.WithContext(ctx)
+	g.SetLimit(stackUsageConcurrency)
+
+	for i, c := range containers {
+		g.Go(func() error {
+			usage, err := containerUsage(gCtx, cli, c, stack.Type != portainer.DockerSwarmStack)
+			usages[i] = usage
+
+			return err
+		})
+	}
+
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+
+	stackUsage := &portainer.StackUsage{Services: []portainer.StackServiceUsage{}}
+	services := make(map[string]*portainer.StackServiceUsage)
+
+	for i, c := range containers {
+		name := c.Labels[serviceLabel]
+		if stack.Type == portainer.DockerSwarmStack {
+			name = strings.TrimPrefix(name, stack.Name+"_")
+		}
+
+		service, ok := services[name]
+		if !ok {
+			service = &portainer.StackServiceUsage{Name: name}
+			services[name] = service
+		}
+
+		service.Add(usages[i])
+		stackUsage.Add(usages[i])
+	}
+
+	if stack.Type == portainer.DockerSwarmStack {
+		if err := addSwarmRestarts(ctx, cli, stack, services, stackUsage); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, service := range services {
+		stackUsage.Services = append(stackUsage.Services, *service)
+	}
+
+	slices.SortFunc(stackUsage.Services, func(a, b portainer.StackServiceUsage) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+
+	return stackUsage, nil
+}
+
+// containerUsage returns the usage of a single container, the stats being only sampled for the running containers
+func containerUsage(ctx context.Context, cli StackUsageClient, c types.Container, countRestarts bool) (portainer.StackResourceUsage, error) {
+	usage := portainer.StackResourceUsage{Containers: 1}
+
+	if countRestarts {
+		inspect, err := cli.ContainerInspect(ctx, c.ID)
+		if err != nil {
+			return usage, err
+		}
+
+		usage.Restarts = inspect.RestartCount
+	}
+
+	if c.State != "running" {
+		return usage, nil
+	}
+
+	usage.Running = 1
+
+	reader, err := cli.ContainerStats(ctx, c.ID, false)
+	if err != nil {
+		return usage, err
+	}
+	defer reader.Body.Close()
+
+	var stats container.StatsResponse
+	if err := json.NewDecoder(reader.Body).Decode(&stats); err != nil {
+		return usage, err
+	}
+
+	usage.CPUPercent = CPUPercent(stats)
+	usage.MemoryUsage = MemoryUsage(stats.MemoryStats)
+	usage.MemoryLimit = stats.MemoryStats.Limit
+
+	for _, network := range stats.Networks {
+		usage.NetworkRx += network.RxBytes
+		usage.NetworkTx += network.TxBytes
+	}
+
+	return usage, nil
+}
+
+// CPUPercent computes the CPU usage of a container between two samples, the same way the docker stats command does
+func CPUPercent(stats container.StatsResponse) float64 {
+	cpuDelta := float64(stats.CPUStats.CPUUsage.TotalUsage) - float64(stats.PreCPUStats.CPUUsage.TotalUsage)
+	systemDelta := float64(stats.CPUStats.SystemUsage) - float64(stats.PreCPUStats.SystemUsage)
+
+	onlineCPUs := float64(stats.CPUStats.OnlineCPUs)
+	if onlineCPUs == 0 {
+		onlineCPUs = float64(len(stats.CPUStats.CPUUsage.PercpuUsage))
+	}
+
+	if cpuDelta <= 0 || systemDelta <= 0 {
+		return 0
+	}
+
+	return cpuDelta / systemDelta * onlineCPUs * 100
+}
+
+// MemoryUsage returns the memory used by a container without the page cache, the same way the docker stats
+// command does
+func MemoryUsage(stats container.MemoryStats) uint64 {
+	// cgroup v1
+	if cache, ok := stats.Stats["total_inactive_file"]; ok && cache < stats.Usage {
+		return stats.Usage - cache
+	}
+
+	// cgroup v2
+	if cache, ok := stats.Stats["inactive_file"]; ok && cache < stats.Usage {
+		return stats.Usage - cache
+	}
+
+	return stats.Usage
+}
+
+// addSwarmRestarts counts the failed tasks of the services of a Swarm stack, Swarm replacing the failed containers
+// rather than restarting them
+func addSwarmRestarts(ctx context.Context, cli StackUsageClient, stack *portainer.Stack, services map[string]*portainer.StackServiceUsage, stackUsage *portainer.StackUsage) error {
+	swarmServices, err := cli.ServiceList(ctx, types.ServiceListOptions{
+		Filters: filters.NewArgs(filters.Arg("label", consts.SwarmStackNameLabel+"="+stack.Name)),
+	})
+	if err != nil || len(swarmServices) == 0 {
+		return err
+	}
+
+	serviceNames := make(map[string]string, len(swarmServices))
+	taskFilters := filters.NewArgs()
+
+	for _, service := range swarmServices {
+		name := strings.TrimPrefix(service.Spec.Name, stack.Name+"_")
+		if _, ok := services[name]; !ok {
+			// the service has no container reachable through the client
+			services[name] = &portainer.StackServiceUsage{Name: name}
+		}
+
+		serviceNames[service.ID] = name
+		taskFilters.Add("service", service.ID)
+	}
+
+	tasks, err := cli.TaskList(ctx, types.TaskListOptions{Filters: taskFilters})
+	if err != nil {
+		return err
+	}
+
+	for _, task := range tasks {
+		if task.Status.State != swarm.TaskStateFailed && task.Status.State != swarm.TaskStateRejected {
+			continue
+		}
+
+		if name, ok := serviceNames[task.ServiceID]; ok {
+			services[name].Restarts++
+		}
+
+		stackUsage.Restarts++
+	}
+
+	return nil
+}
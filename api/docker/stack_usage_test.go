@@ -0,0 +1,132 @@
+package docker
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+
+	portainer "github.com/portainer/portainer/api"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/swarm"
+	"github.com/stretchr/testify/require"
+)
+
+type stackUsageTestClient struct {
+	containers []types.Container
+	restarts   map[string]int
+	stats      map[string]container.StatsResponse
+	services   []swarm.Service
+	tasks      []swarm.Task
+}
+
+func (c *stackUsageTestClient) ContainerList(ctx context.Context, options container.ListOptions) ([]types.Container, error) {
+	return c.containers, nil
+}
+
+func (c *stackUsageTestClient) ContainerInspect(ctx context.Context, containerID string) (types.ContainerJSON, error) {
+	return types.ContainerJSON{ContainerJSONBase: &types.ContainerJSONBase{RestartCount: c.restarts[containerID]}}, nil
+}
+
+func (c *stackUsageTestClient) ContainerStats(ctx context.Context, containerID string, stream bool) (container.StatsResponseReader, error) {
+	body, err := json.Marshal(c.stats[containerID])
+
+	return container.StatsResponseReader{Body: io.NopCloser(strings.NewReader(string(body)))}, err
+}
+
+func (c *stackUsageTestClient) ServiceList(ctx context.Context, options types.ServiceListOptions) ([]swarm.Service, error) {
+	return c.services, nil
+}
+
+func (c *stackUsageTestClient) TaskList(ctx context.Context, options types.TaskListOptions) ([]swarm.Task, error) {
+	return c.tasks, nil
+}
+
+func containerStats(cpuDelta, systemDelta, memory uint64) container.StatsResponse {
+	var stats container.StatsResponse
+	stats.CPUStats.CPUUsage.TotalUsage = 1000 + cpuDelta
+	stats.CPUStats.SystemUsage = 10000 + systemDelta
+	stats.CPUStats.OnlineCPUs = 2
+	stats.PreCPUStats.CPUUsage.TotalUsage = 1000
+	stats.PreCPUStats.SystemUsage = 10000
+	stats.MemoryStats.Usage = memory + 100
+	stats.MemoryStats.Stats = map[string]uint64{"inactive_file": 100}
+	stats.MemoryStats.Limit = 1000
+	stats.Networks = map[string]container.NetworkStats{
+		"eth0": {RxBytes: 10, TxBytes: 5},
+		"eth1": {RxBytes: 1, TxBytes: 1},
+	}
+
+	return stats
+}
+
+func TestCalculateStackUsage(t *testing.T) {
+	is := require.New(t)
+
+	cli := &stackUsageTestClient{
+		containers: []types.Container{
+			{ID: "web1", State: "running", Labels: map[string]string{composeServiceLabel: "web"}},
+			{ID: "web2", State: "running", Labels: map[string]string{composeServiceLabel: "web"}},
+			{ID: "db", State: "exited", Labels: map[string]string{composeServiceLabel: "db"}},
+		},
+		restarts: map[string]int{"web1": 1, "db": 4},
+		stats: map[string]container.StatsResponse{
+			"web1": containerStats(50, 1000, 200),
+			"web2": containerStats(25, 1000, 300),
+		},
+	}
+
+	usage, err := CalculateStackUsage(context.Background(), cli, &portainer.Stack{Name: "app", Type: portainer.DockerComposeStack})
+	is.NoError(err)
+
+	is.Equal(3, usage.Containers)
+	is.Equal(2, usage.Running)
+	is.InDelta(15, usage.CPUPercent, 0.001)
+	is.Equal(uint64(500), usage.MemoryUsage)
+	is.Equal(uint64(2000), usage.MemoryLimit)
+	is.Equal(uint64(22), usage.NetworkRx)
+	is.Equal(uint64(12), usage.NetworkTx)
+	is.Equal(5, usage.Restarts)
+
+	is.Len(usage.Services, 2)
+	is.Equal("db", usage.Services[0].Name)
+	is.Equal(4, usage.Services[0].Restarts)
+	is.Equal("web", usage.Services[1].Name)
+	is.Equal(2, usage.Services[1].Running)
+	is.Equal(uint64(22), usage.Services[1].NetworkRx)
+	is.Zero(usage.Services[0].NetworkRx)
+}
+
+func TestCalculateSwarmStackUsage(t *testing.T) {
+	is := require.New(t)
+
+	cli := &stackUsageTestClient{
+		containers: []types.Container{
+			{ID: "web1", State: "running", Labels: map[string]string{swarmServiceLabel: "app_web"}},
+		},
+		stats: map[string]container.StatsResponse{"web1": containerStats(10, 1000, 100)},
+		services: []swarm.Service{
+			{ID: "s1", Spec: swarm.ServiceSpec{Annotations: swarm.Annotations{Name: "app_web"}}},
+			{ID: "s2", Spec: swarm.ServiceSpec{Annotations: swarm.Annotations{Name: "app_worker"}}},
+		},
+		tasks: []swarm.Task{
+			{ServiceID: "s1", Status: swarm.TaskStatus{State: swarm.TaskStateRunning}},
+			{ServiceID: "s2", Status: swarm.TaskStatus{State: swarm.TaskStateFailed}},
+			{ServiceID: "s2", Status: swarm.TaskStatus{State: swarm.TaskStateFailed}},
+		},
+	}
+
+	usage, err := CalculateStackUsage(context.Background(), cli, &portainer.Stack{Name: "app", Type: portainer.DockerSwarmStack})
+	is.NoError(err)
+
+	is.Equal(1, usage.Containers)
+	is.Equal(2, usage.Restarts)
+	is.Len(usage.Services, 2)
+	is.Equal("web", usage.Services[0].Name)
+	is.Equal(1, usage.Services[0].Running)
+	is.Equal("worker", usage.Services[1].Name)
+	is.Equal(2, usage.Services[1].Restarts)
+}
@@ -0,0 +1,124 @@
+package volumefiles
+
+import (
+	"archive/tar"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"time"
+)
+
+// errFileChanged is returned when a file is shorter than listed, its archive cannot be completed
+var errFileChanged = errors.New("the file changed while it was archived")
+
+// WriteTar writes the content of a directory of a volume as a tar archive, the paths of the archive being relative to
+// the directory
+func WriteTar(ctx context.Context, c *Client, volume, dir string, w io.Writer) error {
+	tw := tar.NewWriter(w)
+
+	if err := writeDir(ctx, c, volume, CleanPath(dir), "", tw); err != nil {
+		return err
+	}
+
+	return tw.Close()
+}
+
+func writeDir(ctx context.Context, c *Client, volume, dir, prefix string, tw *tar.Writer) error {
+	files, err := c.List(ctx, volume, dir)
+	if err != nil {
+		return fmt.Errorf("unable to list the directory %s: %w", dir, err)
+	}
+
+	for _, file := range files {
+		name := prefix + file.Name
+		header := &tar.Header{
+			Name:    name,
+			ModTime: time.Unix(file.ModTime, 0),
+			Mode:    0644,
+		}
+
+		if file.Dir {
+			header.Typeflag = tar.TypeDir
+			header.Name += "/"
+			header.Mode = 0755
+
+			if err := tw.WriteHeader(header); err != nil {
+				return err
+			}
+
+			if err := writeDir(ctx, c, volume, path.Join(dir, file.Name), name+"/", tw); err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		header.Typeflag = tar.TypeReg
+		header.Size = file.Size
+
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+
+		if err := writeFile(ctx, c, volume, path.Join(dir, file.Name), file.Size, tw); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// writeFile writes the listed size of a file, the bytes appended since it was listed are left out
+func writeFile(ctx context.Context, c *Client, volume, p string, size int64, w io.Writer) error {
+	content, err := c.Open(ctx, volume, p)
+	if err != nil {
+		return fmt.Errorf("unable to read the file %s: %w", p, err)
+	}
+	defer content.Close()
+
+	if _, err := io.CopyN(w, content, size); err != nil {
+		if errors.Is(err, io.EOF) {
+			return fmt.Errorf("unable to archive the file %s: %w", p, errFileChanged)
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+// ExtractTar writes the regular files of a tar archive in a directory of a volume and returns their number. The
+// directories are created with the files they contain, the other entries such as the links are skipped
+func ExtractTar(ctx context.Context, c *Client, volume, dir string, r io.Reader) (int, error) {
+	tr := tar.NewReader(r)
+	dir = CleanPath(dir)
+	count := 0
+
+	for {
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return count, nil
+		} else if err != nil {
+			return count, fmt.Errorf("unable to read the archive: %w", err)
+		}
+
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		// the entries cannot be written out of the directory
+		name := strings.TrimPrefix(CleanPath(header.Name), "/")
+		if name == "" {
+			continue
+		}
+
+		if err := c.Upload(ctx, volume, path.Join(dir, path.Dir(name)), path.Base(name), tr); err != nil {
+			return count, fmt.Errorf("unable to write the file %s: %w", name, err)
+		}
+
+		count++
+	}
+}
@@ -0,0 +1,206 @@
+// Package volumefiles lists, reads, writes and removes the files of the Docker volumes through the file browser API
+// of the agents, and transfers the directories as tar archives
+package volumefiles
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+)
+
+// browseAPIPath is the prefix of the file browser API of the agents
+const browseAPIPath = "/v2/browse"
+
+// ErrNotFound is returned when a file does not exist in a volume
+var ErrNotFound = errors.New("the file does not exist in the volume")
+
+// File is a file or a directory of a volume, as listed by the agents
+type File struct {
+	Name string `json:"Name"`
+	// Size in bytes, of the regular files only
+	Size int64 `json:"Size"`
+	Dir  bool  `json:"Dir"`
+	// Unix timestamp of the last modification
+	ModTime int64 `json:"ModTime"`
+}
+
+// Client is a client of the file browser API of an agent
+type Client struct {
+	HTTPClient *http.Client
+	URL        string
+	Headers    map[string]string
+}
+
+// StatusError is returned when the agent refuses a request
+type StatusError struct {
+	StatusCode int
+	Message    string
+}
+
+func (err *StatusError) Error() string {
+	if err.Message == "" {
+		return fmt.Sprintf("unexpected status code %d from the agent", err.StatusCode)
+	}
+
+	return fmt.Sprintf("unexpected status code %d from the agent: %s", err.StatusCode, err.Message)
+}
+
+// CleanPath returns the absolute path of a file inside its volume, the paths leading out of the volume are brought
+// back to its root
+func CleanPath(p string) string {
+	return path.Clean("/" + p)
+}
+
+// List lists the files of a directory of a volume
+func (c *Client) List(ctx context.Context, volume, dir string) ([]File, error) {
+	resp, err := c.do(ctx, http.MethodGet, "/ls", volume, dir, nil, "")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	files := []File{}
+	if err := json.NewDecoder(resp.Body).Decode(&files); err != nil {
+		return nil, err
+	}
+
+	return files, nil
+}
+
+// Stat returns the file of a volume at a path, the root of the volume being a directory
+func (c *Client) Stat(ctx context.Context, volume, p string) (*File, error) {
+	p = CleanPath(p)
+	if p == "/" {
+		return &File{Name: "/", Dir: true}, nil
+	}
+
+	files, err := c.List(ctx, volume, path.Dir(p))
+	if err != nil {
+		return nil, err
+	}
+
+	for _, file := range files {
+		if file.Name == path.Base(p) {
+			return &file, nil
+		}
+	}
+
+	return nil, ErrNotFound
+}
+
+// Open reads the content of a file of a volume, the reader must be closed
+func (c *Client) Open(ctx context.Context, volume, p string) (io.ReadCloser, error) {
+	resp, err := c.do(ctx, http.MethodGet, "/get", volume, p, nil, "")
+	if err != nil {
+		return nil, err
+	}
+
+	return resp.Body, nil
+}
+
+// Upload writes a file in a directory of a volume, replacing the existing one. The agents create the missing
+// directories and read the whole content before writing it
+func (c *Client) Upload(ctx context.Context, volume, dir, name string, content io.Reader) error {
+	body, writer := io.Pipe()
+	form := multipart.NewWriter(writer)
+
+	go func() {
+		writer.CloseWithError(writeUploadForm(form, CleanPath(dir), name, content))
+	}()
+
+	resp, err := c.do(ctx, http.MethodPost, "/put", volume, "", body, form.FormDataContentType())
+	// the form is no longer read when the request fails
+	body.Close()
+	if err != nil {
+		return err
+	}
+
+	return resp.Body.Close()
+}
+
+func writeUploadForm(form *multipart.Writer, dir, name string, content io.Reader) error {
+	if err := form.WriteField("Path", dir); err != nil {
+		return err
+	}
+
+	part, err := form.CreateFormFile("file", name)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(part, content); err != nil {
+		return err
+	}
+
+	return form.Close()
+}
+
+// Delete removes a file or a directory of a volume with its content
+func (c *Client) Delete(ctx context.Context, volume, p string) error {
+	resp, err := c.do(ctx, http.MethodDelete, "/delete", volume, p, nil, "")
+	if err != nil {
+		return err
+	}
+
+	return resp.Body.Close()
+}
+
+// do sends a request to the file browser API and returns its response when it succeeds
+func (c *Client) do(ctx context.Context, method, action, volume, p string, body io.Reader, contentType string) (*http.Response, error) {
+	query := url.Values{"volumeID": {volume}}
+	if p != "" {
+		query.Set("path", CleanPath(p))
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.URL+browseAPIPath+action+"?"+query.Encode(), body)
+	if err != nil {
+		return nil, err
+	}
+
+	for name, value := range c.Headers {
+		req.Header.Set(name, value)
+	}
+
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode >= http.StatusOK && resp.StatusCode < http.StatusMultipleChoices {
+		return resp, nil
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, ErrNotFound
+	}
+
+	var agentErr struct {
+		Message string `json:"message"`
+		Details string `json:"details"`
+	}
+
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+	if err := json.Unmarshal(data, &agentErr); err != nil {
+		agentErr.Message = strings.TrimSpace(string(data))
+	}
+
+	message := agentErr.Message
+	if agentErr.Details != "" {
+		message += ": " + agentErr.Details
+	}
+
+	return nil, &StatusError{StatusCode: resp.StatusCode, Message: message}
+}
@@ -0,0 +1,229 @@
+package volumefiles
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// fakeAgent serves the file browser API of an agent on the files of a single volume
+type fakeAgent struct {
+	mu    sync.Mutex
+	files map[string][]byte
+}
+
+func (agent *fakeAgent) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	agent.mu.Lock()
+	defer agent.mu.Unlock()
+
+	if r.Header.Get("X-Test") != "signed" {
+		w.WriteHeader(http.StatusForbidden)
+		json.NewEncoder(w).Encode(map[string]string{"message": "Unauthorized", "details": "invalid signature"})
+
+		return
+	}
+
+	if r.URL.Query().Get("volumeID") != "data" {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	p := r.URL.Query().Get("path")
+
+	switch r.URL.Path {
+	case browseAPIPath + "/ls":
+		files, ok := agent.list(p)
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		json.NewEncoder(w).Encode(files)
+	case browseAPIPath + "/get":
+		content, ok := agent.files[p]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		w.Write(content)
+	case browseAPIPath + "/put":
+		file, header, err := r.FormFile("file")
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		content, _ := io.ReadAll(file)
+		agent.files[path.Join(r.FormValue("Path"), header.Filename)] = content
+	case browseAPIPath + "/delete":
+		for name := range agent.files {
+			if name == p || strings.HasPrefix(name, p+"/") {
+				delete(agent.files, name)
+			}
+		}
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func (agent *fakeAgent) list(dir string) ([]File, bool) {
+	files := []File{}
+	seen := map[string]bool{}
+	prefix := strings.TrimSuffix(dir, "/") + "/"
+
+	for name, content := range agent.files {
+		if !strings.HasPrefix(name, prefix) {
+			continue
+		}
+
+		rest := strings.TrimPrefix(name, prefix)
+		child, _, isDir := strings.Cut(rest, "/")
+		if seen[child] {
+			continue
+		}
+		seen[child] = true
+
+		file := File{Name: child, Dir: isDir, ModTime: 1700000000}
+		if !isDir {
+			file.Size = int64(len(content))
+		}
+
+		files = append(files, file)
+	}
+
+	slices.SortFunc(files, func(a, b File) int { return strings.Compare(a.Name, b.Name) })
+
+	return files, len(files) > 0 || dir == "/"
+}
+
+func newTestClient(t *testing.T, files map[string][]byte) (*Client, *fakeAgent) {
+	agent := &fakeAgent{files: files}
+
+	server := httptest.NewServer(agent)
+	t.Cleanup(server.Close)
+
+	return &Client{HTTPClient: server.Client(), URL: server.URL, Headers: map[string]string{"X-Test": "signed"}}, agent
+}
+
+func TestCleanPath(t *testing.T) {
+	require.Equal(t, "/", CleanPath(""))
+	require.Equal(t, "/a/b", CleanPath("a/b/"))
+	require.Equal(t, "/etc/passwd", CleanPath("../../etc/passwd"))
+	require.Equal(t, "/b", CleanPath("/a/../b"))
+}
+
+func TestClient(t *testing.T) {
+	ctx := context.Background()
+	client, agent := newTestClient(t, map[string][]byte{
+		"/config/app.yml": []byte("port: 80\n"),
+		"/README":         []byte("hello"),
+	})
+
+	files, err := client.List(ctx, "data", "/")
+	require.NoError(t, err)
+	require.Equal(t, []File{
+		{Name: "README", Size: 5, ModTime: 1700000000},
+		{Name: "config", Dir: true, ModTime: 1700000000},
+	}, files)
+
+	file, err := client.Stat(ctx, "data", "config/app.yml")
+	require.NoError(t, err)
+	require.Equal(t, int64(9), file.Size)
+
+	file, err = client.Stat(ctx, "data", "/")
+	require.NoError(t, err)
+	require.True(t, file.Dir)
+
+	_, err = client.Stat(ctx, "data", "/config/missing")
+	require.ErrorIs(t, err, ErrNotFound)
+
+	_, err = client.List(ctx, "missing", "/")
+	require.ErrorIs(t, err, ErrNotFound)
+
+	content, err := client.Open(ctx, "data", "/README")
+	require.NoError(t, err)
+	data, err := io.ReadAll(content)
+	require.NoError(t, err)
+	require.NoError(t, content.Close())
+	require.Equal(t, "hello", string(data))
+
+	require.NoError(t, client.Upload(ctx, "data", "../new", "file.txt", strings.NewReader("seeded")))
+	require.Equal(t, "seeded", string(agent.files["/new/file.txt"]))
+
+	require.NoError(t, client.Delete(ctx, "data", "/config"))
+	require.NotContains(t, agent.files, "/config/app.yml")
+	require.Contains(t, agent.files, "/README")
+}
+
+func TestClientStatusError(t *testing.T) {
+	client, _ := newTestClient(t, map[string][]byte{})
+	client.Headers = nil
+
+	_, err := client.List(context.Background(), "data", "/")
+
+	var statusErr *StatusError
+	require.ErrorAs(t, err, &statusErr)
+	require.Equal(t, http.StatusForbidden, statusErr.StatusCode)
+	require.Equal(t, "Unauthorized: invalid signature", statusErr.Message)
+}
+
+func TestTar(t *testing.T) {
+	ctx := context.Background()
+	client, agent := newTestClient(t, map[string][]byte{
+		"/site/index.html":     []byte("<html></html>"),
+		"/site/assets/app.css": []byte("body {}"),
+		"/other":               []byte("left out"),
+	})
+
+	var archive bytes.Buffer
+	require.NoError(t, WriteTar(ctx, client, "data", "/site", &archive))
+
+	entries := map[string]string{}
+	tr := tar.NewReader(bytes.NewReader(archive.Bytes()))
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+
+		content, err := io.ReadAll(tr)
+		require.NoError(t, err)
+		entries[header.Name] = string(content)
+	}
+
+	require.Equal(t, map[string]string{
+		"assets/":        "",
+		"assets/app.css": "body {}",
+		"index.html":     "<html></html>",
+	}, entries)
+
+	var upload bytes.Buffer
+	tw := tar.NewWriter(&upload)
+	for name, content := range map[string]string{"../../escape.txt": "inside", "copy/index.html": "copied"} {
+		require.NoError(t, tw.WriteHeader(&tar.Header{Name: name, Typeflag: tar.TypeReg, Mode: 0644, Size: int64(len(content))}))
+		_, err := tw.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, tw.WriteHeader(&tar.Header{Name: "link", Typeflag: tar.TypeSymlink, Linkname: "/etc/passwd"}))
+	require.NoError(t, tw.Close())
+
+	count, err := ExtractTar(ctx, client, "data", "/restore", &upload)
+	require.NoError(t, err)
+	require.Equal(t, 2, count)
+	require.Equal(t, "inside", string(agent.files["/restore/escape.txt"]))
+	require.Equal(t, "copied", string(agent.files["/restore/copy/index.html"]))
+	require.NotContains(t, agent.files, "/restore/link")
+}
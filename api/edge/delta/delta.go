@@ -0,0 +1,228 @@
+// Package delta implements the content-addressed chunking used to send edge stack files to the agents.
+// The files are split into chunks whose boundaries depend on their content, so that a small change in a file
+// only changes the chunks around it. The agents keep the chunks they already received and only download the
+// missing ones.
+package delta
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/portainer/portainer/api/filesystem"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// MinChunkSize is the minimum size of a chunk, except for the last chunk of a file
+	MinChunkSize = 2 << 10
+	// MaxChunkSize is the maximum size of a chunk
+	MaxChunkSize = 64 << 10
+
+	// chunkMask gives an average chunk size of 8KiB
+	chunkMask = (8 << 10) - 1
+)
+
+// ErrIntegrity is returned when the assembled content does not match the manifest
+var ErrIntegrity = errors.New("content does not match its digest")
+
+// Chunk represents a piece of a file, identified by the SHA-256 digest of its content
+type Chunk struct {
+	Hash string
+	Size int
+}
+
+// Entry represents a file or a folder of a manifest
+type Entry struct {
+	Name        string
+	IsFile      bool
+	Permissions os.FileMode
+	// Size of the file
+	Size int
+	// Digest is the SHA-256 digest of the file content
+	Digest string
+	Chunks []Chunk `json:",omitempty"`
+}
+
+// Manifest describes the content of a set of files as a list of chunks
+type Manifest struct {
+	// Digest is the SHA-256 digest of the manifest entries, it changes whenever a file changes
+	Digest  string
+	Entries []Entry
+}
+
+// gearTable is the table of the rolling hash, it is derived from a fixed seed so that the agents compute
+// the same chunk boundaries
+var gearTable = func() [256]uint64 {
+	var table [256]uint64
+
+	// splitmix64
+	state := uint64(0x9e3779b97f4a7c15)
+	for i := range table {
+		state += 0x9e3779b97f4a7c15
+		z := state
+		z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+		z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+		table[i] = z ^ (z >> 31)
+	}
+
+	return table
+}()
+
+// Split cuts the data into content-defined chunks
+func Split(data []byte) [][]byte {
+	var chunks [][]byte
+
+	for len(data) > 0 {
+		size := cutPoint(data)
+		chunks = append(chunks, data[:size])
+		data = data[size:]
+	}
+
+	return chunks
+}
+
+func cutPoint(data []byte) int {
+	if len(data) <= MinChunkSize {
+		return len(data)
+	}
+
+	limit := min(len(data), MaxChunkSize)
+
+	var hash uint64
+	for i := MinChunkSize; i < limit; i++ {
+		hash = (hash << 1) + gearTable[data[i]]
+		if hash&chunkMask == 0 {
+			return i + 1
+		}
+	}
+
+	return limit
+}
+
+// Hash returns the hex encoded SHA-256 digest of the data
+func Hash(data []byte) string {
+	sum := sha256.Sum256(data)
+
+	return hex.EncodeToString(sum[:])
+}
+
+// BuildManifest splits the files of the directory entries, whose content is base64 encoded, and returns the
+// manifest along with the content of every chunk indexed by its hash
+func BuildManifest(dirEntries []filesystem.DirEntry) (*Manifest, map[string][]byte, error) {
+	manifest := &Manifest{Entries: make([]Entry, 0, len(dirEntries))}
+	chunks := map[string][]byte{}
+
+	for _, dirEntry := range dirEntries {
+		entry := Entry{
+			Name:        dirEntry.Name,
+			IsFile:      dirEntry.IsFile,
+			Permissions: dirEntry.Permissions,
+		}
+
+		if dirEntry.IsFile {
+			content, err := base64.StdEncoding.DecodeString(dirEntry.Content)
+			if err != nil {
+				return nil, nil, errors.Wrapf(err, "unable to decode the content of %s", dirEntry.Name)
+			}
+
+			entry.Size = len(content)
+			entry.Digest = Hash(content)
+			entry.Chunks = []Chunk{}
+
+			for _, chunk := range Split(content) {
+				hash := Hash(chunk)
+				chunks[hash] = chunk
+				entry.Chunks = append(entry.Chunks, Chunk{Hash: hash, Size: len(chunk)})
+			}
+		}
+
+		manifest.Entries = append(manifest.Entries, entry)
+	}
+
+	sort.Slice(manifest.Entries, func(i, j int) bool {
+		return manifest.Entries[i].Name < manifest.Entries[j].Name
+	})
+
+	manifest.Digest = manifest.computeDigest()
+
+	return manifest, chunks, nil
+}
+
+func (manifest *Manifest) computeDigest() string {
+	h := sha256.New()
+
+	for _, entry := range manifest.Entries {
+		fmt.Fprintf(h, "%q %t %o %d %s\n", entry.Name, entry.IsFile, uint32(entry.Permissions), entry.Size, entry.Digest)
+	}
+
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// MissingChunks returns the hashes of the chunks of the manifest that are not in the given set, without duplicates
+func (manifest *Manifest) MissingChunks(available map[string][]byte) []string {
+	missing := []string{}
+	seen := map[string]bool{}
+
+	for _, entry := range manifest.Entries {
+		for _, chunk := range entry.Chunks {
+			if _, ok := available[chunk.Hash]; ok || seen[chunk.Hash] {
+				continue
+			}
+
+			seen[chunk.Hash] = true
+			missing = append(missing, chunk.Hash)
+		}
+	}
+
+	return missing
+}
+
+// Assemble rebuilds the directory entries of the manifest from the chunks, the file contents are base64 encoded.
+// The digests of the chunks, the files and the manifest are verified
+func Assemble(manifest *Manifest, chunks map[string][]byte) ([]filesystem.DirEntry, error) {
+	if manifest.computeDigest() != manifest.Digest {
+		return nil, errors.Wrap(ErrIntegrity, "invalid manifest")
+	}
+
+	dirEntries := make([]filesystem.DirEntry, 0, len(manifest.Entries))
+
+	for _, entry := range manifest.Entries {
+		dirEntry := filesystem.DirEntry{
+			Name:        entry.Name,
+			IsFile:      entry.IsFile,
+			Permissions: entry.Permissions,
+		}
+
+		if entry.IsFile {
+			content := make([]byte, 0, entry.Size)
+
+			for _, chunk := range entry.Chunks {
+				data, ok := chunks[chunk.Hash]
+				if !ok {
+					return nil, errors.Errorf("missing chunk %s of %s", chunk.Hash, entry.Name)
+				}
+
+				if len(data) != chunk.Size || Hash(data) != chunk.Hash {
+					return nil, errors.Wrapf(ErrIntegrity, "invalid chunk %s of %s", chunk.Hash, entry.Name)
+				}
+
+				content = append(content, data...)
+			}
+
+			if len(content) != entry.Size || Hash(content) != entry.Digest {
+				return nil, errors.Wrapf(ErrIntegrity, "invalid content of %s", entry.Name)
+			}
+
+			dirEntry.Content = base64.StdEncoding.EncodeToString(content)
+		}
+
+		dirEntries = append(dirEntries, dirEntry)
+	}
+
+	return dirEntries, nil
+}
@@ -0,0 +1,141 @@
+package delta
+
+import (
+	"bytes"
+	"encoding/base64"
+	"math/rand"
+	"testing"
+
+	"github.com/portainer/portainer/api/filesystem"
+
+	"github.com/stretchr/testify/require"
+)
+
+func randomContent(size int) []byte {
+	data := make([]byte, size)
+	rand.New(rand.NewSource(42)).Read(data)
+
+	return data
+}
+
+func TestSplit(t *testing.T) {
+	data := randomContent(512 << 10)
+
+	chunks := Split(data)
+	require.Greater(t, len(chunks), 1)
+	require.Equal(t, data, bytes.Join(chunks, nil))
+
+	for _, chunk := range chunks[:len(chunks)-1] {
+		require.GreaterOrEqual(t, len(chunk), MinChunkSize)
+		require.LessOrEqual(t, len(chunk), MaxChunkSize)
+	}
+
+	require.Len(t, Split(data[:100]), 1)
+	require.Empty(t, Split(nil))
+}
+
+func TestSplitLocalChange(t *testing.T) {
+	data := randomContent(512 << 10)
+
+	// inserting a few bytes must only change the chunks around the insertion
+	modified := append(append(append([]byte{}, data[:200<<10]...), []byte("changed")...), data[200<<10:]...)
+
+	original := map[string]bool{}
+	for _, chunk := range Split(data) {
+		original[Hash(chunk)] = true
+	}
+
+	changed := 0
+	chunks := Split(modified)
+	for _, chunk := range chunks {
+		if !original[Hash(chunk)] {
+			changed++
+		}
+	}
+
+	require.LessOrEqual(t, changed, 2)
+}
+
+func dirEntries(files map[string][]byte) []filesystem.DirEntry {
+	entries := []filesystem.DirEntry{{Name: "dir", Permissions: 0755}}
+
+	for name, content := range files {
+		entries = append(entries, filesystem.DirEntry{
+			Name:        name,
+			IsFile:      true,
+			Permissions: 0644,
+			Content:     base64.StdEncoding.EncodeToString(content),
+		})
+	}
+
+	return entries
+}
+
+func TestBuildManifestAndAssemble(t *testing.T) {
+	content := randomContent(100 << 10)
+
+	manifest, chunks, err := BuildManifest(dirEntries(map[string][]byte{
+		"dir/docker-compose.yml": content,
+		"dir/.env":               []byte("A=1"),
+		"dir/empty":              {},
+	}))
+	require.NoError(t, err)
+	require.Len(t, manifest.Entries, 4)
+	require.NotEmpty(t, manifest.Digest)
+
+	// an agent that already has every chunk doesn't need to download anything
+	require.Empty(t, manifest.MissingChunks(chunks))
+
+	assembled, err := Assemble(manifest, chunks)
+	require.NoError(t, err)
+	require.Len(t, assembled, 4)
+
+	for _, entry := range assembled {
+		if entry.Name == "dir/docker-compose.yml" {
+			require.Equal(t, base64.StdEncoding.EncodeToString(content), entry.Content)
+		}
+	}
+
+	// a small change only requires the affected chunks
+	updated := append([]byte{}, content...)
+	updated[50<<10] ^= 0xff
+
+	updatedManifest, updatedChunks, err := BuildManifest(dirEntries(map[string][]byte{
+		"dir/docker-compose.yml": updated,
+		"dir/.env":               []byte("A=1"),
+		"dir/empty":              {},
+	}))
+	require.NoError(t, err)
+	require.NotEqual(t, manifest.Digest, updatedManifest.Digest)
+
+	missing := updatedManifest.MissingChunks(chunks)
+	require.NotEmpty(t, missing)
+	require.Less(t, len(missing), len(updatedChunks))
+
+	for _, hash := range missing {
+		chunks[hash] = updatedChunks[hash]
+	}
+
+	_, err = Assemble(updatedManifest, chunks)
+	require.NoError(t, err)
+}
+
+func TestAssembleIntegrity(t *testing.T) {
+	manifest, chunks, err := BuildManifest(dirEntries(map[string][]byte{"dir/file": randomContent(20 << 10)}))
+	require.NoError(t, err)
+
+	for hash, data := range chunks {
+		corrupted := append([]byte{}, data...)
+		corrupted[0] ^= 0xff
+		chunks[hash] = corrupted
+
+		break
+	}
+
+	_, err = Assemble(manifest, chunks)
+	require.ErrorIs(t, err, ErrIntegrity)
+
+	manifest.Entries[0].Permissions = 0777
+	_, err = Assemble(manifest, chunks)
+	require.ErrorIs(t, err, ErrIntegrity)
+}
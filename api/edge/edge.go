@@ -2,6 +2,7 @@ package edge
 
 import (
 	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/edge/delta"
 	"github.com/portainer/portainer/api/filesystem"
 )
 
@@ -19,6 +20,9 @@ type (
 
 		// Content of stack folder
 		DirEntries []filesystem.DirEntry
+		// Manifest lists the chunks of the stack folder files, it replaces DirEntries and StackFileContent when the agent
+		// requested a delta update
+		Manifest *delta.Manifest `json:",omitempty"`
 		// Name of the stack entry file
 		EntryFileName string
 		// Namespace to use for kubernetes stack. Keep empty to use the manifest namespace.
@@ -34,6 +38,9 @@ type (
 		// PrePullImage is a flag indicating if the agent must pull the image before deploying the stack.
 		// Used only for EE
 		PrePullImage bool
+		// PrePullOnly is a flag indicating that the stack is in its pre-pull phase: the agent must only pull
+		// the images and report them as pulled, the stack is deployed once a newer version is activated
+		PrePullOnly bool
 		// RePullImage is a flag indicating if the agent must pull the image if it is already present on the node.
 		// Used only for EE
 		RePullImage bool
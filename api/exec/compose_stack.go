@@ -10,6 +10,7 @@ import (
 
 	portainer "github.com/portainer/portainer/api"
 	"github.com/portainer/portainer/api/dataservices"
+	"github.com/portainer/portainer/api/docker/gpu"
 	"github.com/portainer/portainer/api/http/proxy"
 	"github.com/portainer/portainer/api/http/proxy/factory"
 	"github.com/portainer/portainer/api/internal/registryutils"
@@ -17,6 +18,7 @@ import (
 	"github.com/portainer/portainer/pkg/libstack"
 
 	"github.com/docker/cli/cli/config/types"
+	"github.com/docker/docker/api/types/container"
 	"github.com/pkg/errors"
 	"github.com/rs/zerolog/log"
 )
@@ -53,6 +55,11 @@ func (manager *ComposeStackManager) Up(ctx context.Context, stack *portainer.Sta
 		defer proxy.Close()
 	}
 
+	stack, err = stackutils.WithEndpointVariables(manager.dataStore, stack, endpoint)
+	if err != nil {
+		return errors.Wrap(err, "failed to merge the variables of the environment")
+	}
+
 	envFilePath, err := createEnvFile(stack)
 	if err != nil {
 		return errors.Wrap(err, "failed to create env file")
@@ -67,12 +74,40 @@ func (manager *ComposeStackManager) Up(ctx context.Context, stack *portainer.Sta
 			ProjectName: stack.Name,
 			Registries:  portainerRegistriesToAuthConfigs(manager.dataStore, options.Registries),
 		},
-		ForceRecreate:        options.ForceRecreate,
-		AbortOnContainerExit: options.AbortOnContainerExit,
+		ForceRecreate:          options.ForceRecreate,
+		AbortOnContainerExit:   options.AbortOnContainerExit,
+		Scales:                 stack.ServiceScales,
+		ValidateDeviceRequests: manager.gpuValidator(endpoint),
 	})
 	return errors.Wrap(err, "failed to deploy a stack")
 }
 
+// gpuValidator returns the validation of the GPU requests of the services against the GPUs of an
+// environment(endpoint), nil when the GPU management is disabled on the environment
+func (manager *ComposeStackManager) gpuValidator(endpoint *portainer.Endpoint) func([]container.DeviceRequest) error {
+	if !endpoint.EnableGPUManagement {
+		return nil
+	}
+
+	return func(requests []container.DeviceRequest) error {
+		if !gpu.Requested(requests) {
+			return nil
+		}
+
+		var inventory []string
+		if err := manager.dataStore.ViewTx(func(tx dataservices.DataStoreTx) error {
+			var err error
+			inventory, err = gpu.EndpointInventory(tx, endpoint)
+
+			return err
+		}); err != nil {
+			return err
+		}
+
+		return gpu.Validate(requests, inventory)
+	}
+}
+
 // Run runs a one-off command on a service. Wraps `docker-compose run` command
 func (manager *ComposeStackManager) Run(ctx context.Context, stack *portainer.Stack, endpoint *portainer.Endpoint, serviceName string, options portainer.ComposeRunOptions) error {
 	url, proxy, err := manager.fetchEndpointProxy(endpoint)
@@ -134,6 +169,11 @@ func (manager *ComposeStackManager) Pull(ctx context.Context, stack *portainer.S
 		defer proxy.Close()
 	}
 
+	stack, err = stackutils.WithEndpointVariables(manager.dataStore, stack, endpoint)
+	if err != nil {
+		return errors.Wrap(err, "failed to merge the variables of the environment")
+	}
+
 	envFilePath, err := createEnvFile(stack)
 	if err != nil {
 		return errors.Wrap(err, "failed to create env file")
@@ -150,6 +190,73 @@ func (manager *ComposeStackManager) Pull(ctx context.Context, stack *portainer.S
 	return errors.Wrap(err, "failed to pull images of the stack")
 }
 
+// Build builds the images of the services of a docker-compose.yml or docker-stack.yml file that have a build section,
+// on the environment. Wraps `docker-compose build` command
+func (manager *ComposeStackManager) Build(ctx context.Context, stack *portainer.Stack, endpoint *portainer.Endpoint, options portainer.ComposeBuildOptions) error {
+	url, proxy, err := manager.fetchEndpointProxy(endpoint)
+	if err != nil {
+		return err
+	} else if proxy != nil {
+		defer proxy.Close()
+	}
+
+	stack, err = stackutils.WithEndpointVariables(manager.dataStore, stack, endpoint)
+	if err != nil {
+		return errors.Wrap(err, "failed to merge the variables of the environment")
+	}
+
+	envFilePath, err := createEnvFile(stack)
+	if err != nil {
+		return errors.Wrap(err, "failed to create env file")
+	}
+
+	filePaths := stackutils.GetStackFilePaths(stack, true)
+	err = manager.deployer.Build(ctx, filePaths, libstack.BuildOptions{
+		Options: libstack.Options{
+			WorkingDir:  stack.ProjectPath,
+			EnvFilePath: envFilePath,
+			Host:        url,
+			ProjectName: stack.Name,
+			Registries:  portainerRegistriesToAuthConfigs(manager.dataStore, options.Registries),
+		},
+		Output:  options.Output,
+		Pull:    options.Pull,
+		NoCache: options.NoCache,
+	})
+	return errors.Wrap(err, "failed to build the images of the stack")
+}
+
+// Scale creates or removes the containers of a service of a stack so that it runs the given number of them, without
+// redeploying the other services. Wraps `docker-compose up --scale` command
+func (manager *ComposeStackManager) Scale(ctx context.Context, stack *portainer.Stack, endpoint *portainer.Endpoint, serviceName string, replicas int, options portainer.ComposeOptions) error {
+	url, proxy, err := manager.fetchEndpointProxy(endpoint)
+	if err != nil {
+		return err
+	} else if proxy != nil {
+		defer proxy.Close()
+	}
+
+	stack, err = stackutils.WithEndpointVariables(manager.dataStore, stack, endpoint)
+	if err != nil {
+		return errors.Wrap(err, "failed to merge the variables of the environment")
+	}
+
+	envFilePath, err := createEnvFile(stack)
+	if err != nil {
+		return errors.Wrap(err, "failed to create env file")
+	}
+
+	filePaths := stackutils.GetStackFilePaths(stack, true)
+	err = manager.deployer.Scale(ctx, filePaths, serviceName, replicas, libstack.Options{
+		WorkingDir:  stack.ProjectPath,
+		EnvFilePath: envFilePath,
+		Host:        url,
+		ProjectName: stack.Name,
+		Registries:  portainerRegistriesToAuthConfigs(manager.dataStore, options.Registries),
+	})
+	return errors.Wrap(err, "failed to scale the service of the stack")
+}
+
 // NormalizeStackName returns a new stack name with unsupported characters replaced
 func (manager *ComposeStackManager) NormalizeStackName(name string) string {
 	return stackNameNormalizeRegex.ReplaceAllString(strings.ToLower(name), "")
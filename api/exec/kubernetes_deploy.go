@@ -79,25 +79,56 @@ func (deployer *KubernetesDeployer) getToken(userID portainer.UserID, endpoint *
 
 // Deploy upserts Kubernetes resources defined in manifest(s)
 func (deployer *KubernetesDeployer) Deploy(userID portainer.UserID, endpoint *portainer.Endpoint, manifestFiles []string, namespace string) (string, error) {
-	return deployer.command("apply", userID, endpoint, manifestFiles, namespace)
+	return deployer.command([]string{"apply"}, userID, endpoint, manifestFiles, namespace)
 }
 
 // Remove deletes Kubernetes resources defined in manifest(s)
 func (deployer *KubernetesDeployer) Remove(userID portainer.UserID, endpoint *portainer.Endpoint, manifestFiles []string, namespace string) (string, error) {
-	return deployer.command("delete", userID, endpoint, manifestFiles, namespace)
+	return deployer.command([]string{"delete", "--ignore-not-found=true"}, userID, endpoint, manifestFiles, namespace)
 }
 
-func (deployer *KubernetesDeployer) command(operation string, userID portainer.UserID, endpoint *portainer.Endpoint, manifestFiles []string, namespace string) (string, error) {
-	token, err := deployer.getToken(userID, endpoint, endpoint.Type == portainer.KubernetesLocalEnvironment)
+// DryRun returns as YAML the resources defined in manifest(s) as they would be applied, after their validation,
+// defaulting and merge with the live resources by the server
+func (deployer *KubernetesDeployer) DryRun(userID portainer.UserID, endpoint *portainer.Endpoint, manifestFiles []string, namespace string) (string, error) {
+	return deployer.command([]string{"apply", "--dry-run=server", "--output=yaml"}, userID, endpoint, manifestFiles, namespace)
+}
+
+// Get returns as YAML the live state of the resources defined in manifest(s), the resources that do not exist yet
+// being ignored
+func (deployer *KubernetesDeployer) Get(userID portainer.UserID, endpoint *portainer.Endpoint, manifestFiles []string, namespace string) (string, error) {
+	return deployer.command([]string{"get", "--ignore-not-found=true", "--output=yaml"}, userID, endpoint, manifestFiles, namespace)
+}
+
+// Kustomize renders the manifests of a kustomization directory
+func (deployer *KubernetesDeployer) Kustomize(directory string) ([]byte, error) {
+	var stderr bytes.Buffer
+	cmd := exec.Command(deployer.kubectlPath(), "kustomize", directory)
+	cmd.Stderr = &stderr
+
+	output, err := cmd.Output()
 	if err != nil {
-		return "", errors.Wrap(err, "failed generating a user token")
+		return nil, errors.Wrapf(err, "failed to build the kustomization: %q", stderr.String())
 	}
 
-	command := path.Join(deployer.binaryPath, "kubectl")
+	return output, nil
+}
+
+func (deployer *KubernetesDeployer) kubectlPath() string {
 	if runtime.GOOS == "windows" {
-		command = path.Join(deployer.binaryPath, "kubectl.exe")
+		return path.Join(deployer.binaryPath, "kubectl.exe")
 	}
 
+	return path.Join(deployer.binaryPath, "kubectl")
+}
+
+func (deployer *KubernetesDeployer) command(operation []string, userID portainer.UserID, endpoint *portainer.Endpoint, manifestFiles []string, namespace string) (string, error) {
+	token, err := deployer.getToken(userID, endpoint, endpoint.Type == portainer.KubernetesLocalEnvironment)
+	if err != nil {
+		return "", errors.Wrap(err, "failed generating a user token")
+	}
+
+	command := deployer.kubectlPath()
+
 	args := []string{"--token", token}
 	if namespace != "" {
 		args = append(args, "--namespace", namespace)
@@ -114,11 +145,7 @@ func (deployer *KubernetesDeployer) command(operation string, userID portainer.U
 		args = append(args, "--insecure-skip-tls-verify")
 	}
 
-	if operation == "delete" {
-		args = append(args, "--ignore-not-found=true")
-	}
-
-	args = append(args, operation)
+	args = append(args, operation...)
 	for _, path := range manifestFiles {
 		args = append(args, "-f", strings.TrimSpace(path))
 	}
@@ -99,6 +99,11 @@ func (manager *SwarmStackManager) Deploy(stack *portainer.Stack, prune bool, pul
 		return err
 	}
 
+	stack, err = stackutils.WithEndpointVariables(manager.dataStore, stack, endpoint)
+	if err != nil {
+		return err
+	}
+
 	if prune {
 		args = append(args, "stack", "deploy", "--prune", "--with-registry-auth")
 	} else {
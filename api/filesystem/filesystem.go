@@ -35,6 +35,8 @@ const (
 	ComposeFileDefaultName = "docker-compose.yml"
 	// ManifestFileDefaultName represents the default name of a k8s manifest file.
 	ManifestFileDefaultName = "k8s-deployment.yml"
+	// NomadJobFileDefaultName represents the default name of a Nomad jobspec.
+	NomadJobFileDefaultName = "nomad-job.hcl"
 	// EdgeStackStorePath represents the subfolder where edge stack files are stored in the file store folder.
 	EdgeStackStorePath = "edge_stacks"
 	// PrivateKeyFile represents the name on disk of the file containing the private key.
@@ -45,6 +47,8 @@ const (
 	BinaryStorePath = "bin"
 	// EdgeJobStorePath represents the subfolder where schedule files are stored.
 	EdgeJobStorePath = "edge_jobs"
+	// SessionRecordingStorePath represents the subfolder where the recordings of the console sessions are stored.
+	SessionRecordingStorePath = "session_recordings"
 	// DockerConfigPath represents the subfolder where docker configuration is stored.
 	DockerConfigPath = "docker_config"
 	// ExtensionRegistryManagementStorePath represents the subfolder where files related to the
@@ -745,6 +749,12 @@ func (service *Service) StoreCustomTemplateFileFromBytes(identifier, fileName st
 	return service.wrapFileStore(customTemplateStorePath), nil
 }
 
+// GetSessionRecordingFolder returns the absolute path on the filesystem of the folder storing the recordings of the
+// console sessions.
+func (service *Service) GetSessionRecordingFolder() string {
+	return service.wrapFileStore(SessionRecordingStorePath)
+}
+
 // GetEdgeJobFolder returns the absolute path on the filesystem for an Edge job based
 // on its identifier.
 func (service *Service) GetEdgeJobFolder(identifier string) string {
@@ -0,0 +1,447 @@
+// Package fixtures generates synthetic environments(endpoints), users, teams and stacks with realistic snapshots, so
+// that the performance work and the development of the UI against large fleets do not require real Docker hosts. It is
+// only served when the fixtures feature flag is enabled
+package fixtures
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"math/rand"
+	"strings"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+	"github.com/portainer/portainer/api/docker/consts"
+	"github.com/portainer/portainer/api/internal/authorization"
+	"github.com/portainer/portainer/api/stacks/stackutils"
+	"github.com/portainer/portainer/pkg/featureflags"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/image"
+	"github.com/docker/docker/api/types/system"
+	"github.com/docker/docker/api/types/volume"
+)
+
+const (
+	// Feature is the feature flag enabling the fixtures API, e.g. --feat fixtures
+	Feature featureflags.Feature = "fixtures"
+	// Prefix is the prefix of the names of the generated objects, which are removed by it
+	Prefix = "fixture-"
+	// MaxCount is the maximum number of objects of each kind generated at once
+	MaxCount = 10000
+)
+
+// ErrTooMany is returned when more objects than MaxCount of a kind are requested
+var ErrTooMany = fmt.Errorf("at most %d objects of each kind can be generated at once", MaxCount)
+
+var (
+	images = []string{
+		"nginx:1.25", "redis:7.2", "postgres:16", "mysql:8.0", "node:20-alpine", "traefik:v3.0",
+		"grafana/grafana:10.4", "prom/prometheus:v2.51", "rabbitmq:3.13-management", "busybox:1.36",
+	}
+	dockerVersions = []string{"24.0.9", "25.0.5", "26.1.4", "27.3.1"}
+	cpus           = []int{2, 4, 8, 16, 32}
+)
+
+// Options represents the number of objects to generate
+type Options struct {
+	Endpoints             int
+	ContainersPerEndpoint int
+	StacksPerEndpoint     int
+	Users                 int
+	Teams                 int
+	// Seed of the random generation, the same seed generates the same fleet
+	Seed int64
+}
+
+// Validate returns an error when a number is negative or above MaxCount
+func (options Options) Validate() error {
+	for _, count := range []int{options.Endpoints, options.ContainersPerEndpoint, options.StacksPerEndpoint, options.Users, options.Teams} {
+		if count < 0 {
+			return errors.New("the numbers of objects cannot be negative")
+		}
+
+		if count > MaxCount {
+			return ErrTooMany
+		}
+	}
+
+	if options.StacksPerEndpoint > options.ContainersPerEndpoint {
+		return errors.New("the stacks of an environment cannot outnumber its containers")
+	}
+
+	return nil
+}
+
+// Result represents the number of objects generated or removed
+type Result struct {
+	Endpoints int `json:"endpoints"`
+	Users     int `json:"users"`
+	Teams     int `json:"teams"`
+	Stacks    int `json:"stacks"`
+}
+
+// Seed generates the objects of the options. The users are standard users logging in with the password of the given
+// hash, each one is a member of a team. The stacks are restricted to a team, or to the administrators when no team is
+// generated. The environments(endpoints) have no URL so that they are never snapshotted and stay up
+func Seed(tx dataservices.DataStoreTx, options Options, passwordHash string) (*Result, error) {
+	if err := options.Validate(); err != nil {
+		return nil, err
+	}
+
+	r := rand.New(rand.NewSource(options.Seed))
+	result := &Result{}
+
+	teams, err := tx.Team().ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	offset := countFixtures(teams, func(team portainer.Team) string { return team.Name })
+	teamIDs := make([]portainer.TeamID, 0, options.Teams)
+
+	for i := range options.Teams {
+		team := &portainer.Team{Name: fmt.Sprintf("%steam-%d", Prefix, offset+i+1)}
+		if err := tx.Team().Create(team); err != nil {
+			return nil, err
+		}
+
+		teamIDs = append(teamIDs, team.ID)
+		result.Teams++
+	}
+
+	users, err := tx.User().ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	offset = countFixtures(users, func(user portainer.User) string { return user.Username })
+
+	for i := range options.Users {
+		user := &portainer.User{
+			Username: fmt.Sprintf("%suser-%d", Prefix, offset+i+1),
+			Password: passwordHash,
+			Role:     portainer.StandardUserRole,
+		}
+
+		if err := tx.User().Create(user); err != nil {
+			return nil, err
+		}
+
+		if len(teamIDs) > 0 {
+			if err := tx.TeamMembership().Create(&portainer.TeamMembership{
+				UserID: user.ID,
+				TeamID: teamIDs[r.Intn(len(teamIDs))],
+				Role:   portainer.TeamMember,
+			}); err != nil {
+				return nil, err
+			}
+		}
+
+		result.Users++
+	}
+
+	endpoints, err := tx.Endpoint().Endpoints()
+	if err != nil {
+		return nil, err
+	}
+
+	offset = countFixtures(endpoints, func(endpoint portainer.Endpoint) string { return endpoint.Name })
+	now := time.Now()
+
+	for i := range options.Endpoints {
+		endpoint := &portainer.Endpoint{
+			ID:                 portainer.EndpointID(tx.Endpoint().GetNextIdentifier()),
+			Name:               fmt.Sprintf("%sendpoint-%d", Prefix, offset+i+1),
+			Type:               portainer.DockerEnvironment,
+			ContainerEngine:    portainer.ContainerEngineDocker,
+			GroupID:            portainer.EndpointGroupID(1),
+			UserAccessPolicies: portainer.UserAccessPolicies{},
+			TeamAccessPolicies: portainer.TeamAccessPolicies{},
+			TagIDs:             []portainer.TagID{},
+			Status:             portainer.EndpointStatusUp,
+			Snapshots:          []portainer.DockerSnapshot{},
+			Kubernetes:         portainer.KubernetesDefault(),
+		}
+
+		// the teams are given access to the environments, like the administrators would do
+		if len(teamIDs) > 0 {
+			endpoint.TeamAccessPolicies[teamIDs[r.Intn(len(teamIDs))]] = portainer.AccessPolicy{}
+		}
+
+		if err := tx.Endpoint().Create(endpoint); err != nil {
+			return nil, err
+		}
+
+		if err := tx.EndpointRelation().Create(&portainer.EndpointRelation{
+			EndpointID: endpoint.ID,
+			EdgeStacks: map[portainer.EdgeStackID]bool{},
+		}); err != nil {
+			return nil, err
+		}
+
+		stackNames := make([]string, 0, options.StacksPerEndpoint)
+		for j := range options.StacksPerEndpoint {
+			stack := &portainer.Stack{
+				ID:           portainer.StackID(tx.Stack().GetNextIdentifier()),
+				Name:         fmt.Sprintf("%sstack-%d-%d", Prefix, endpoint.ID, j+1),
+				Type:         portainer.DockerComposeStack,
+				EndpointID:   endpoint.ID,
+				EntryPoint:   "docker-compose.yml",
+				Env:          []portainer.Pair{},
+				Status:       portainer.StackStatusActive,
+				CreationDate: now.Unix(),
+				CreatedBy:    "admin",
+			}
+
+			if err := tx.Stack().Create(stack); err != nil {
+				return nil, err
+			}
+
+			resourceID := stackutils.ResourceControlID(endpoint.ID, stack.Name)
+			resourceControl := authorization.NewAdministratorsOnlyResourceControl(resourceID, portainer.StackResourceControl)
+			if len(teamIDs) > 0 {
+				resourceControl = authorization.NewRestrictedResourceControl(resourceID, portainer.StackResourceControl, nil, []portainer.TeamID{teamIDs[r.Intn(len(teamIDs))]})
+			}
+
+			if err := tx.ResourceControl().Create(resourceControl); err != nil {
+				return nil, err
+			}
+
+			stackNames = append(stackNames, stack.Name)
+			result.Stacks++
+		}
+
+		snapshot := NewDockerSnapshot(r, endpoint, options.ContainersPerEndpoint, stackNames, now)
+		if err := tx.Snapshot().Create(&portainer.Snapshot{EndpointID: endpoint.ID, Docker: snapshot}); err != nil {
+			return nil, err
+		}
+
+		result.Endpoints++
+	}
+
+	return result, nil
+}
+
+// Clear removes the generated objects, with their snapshots, team memberships and resource controls
+func Clear(tx dataservices.DataStoreTx) (*Result, error) {
+	result := &Result{}
+
+	stacks, err := tx.Stack().ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, stack := range stacks {
+		if !strings.HasPrefix(stack.Name, Prefix) {
+			continue
+		}
+
+		resourceControl, err := tx.ResourceControl().ResourceControlByResourceIDAndType(stackutils.ResourceControlID(stack.EndpointID, stack.Name), portainer.StackResourceControl)
+		if err != nil && !tx.IsErrObjectNotFound(err) {
+			return nil, err
+		}
+
+		if resourceControl != nil {
+			if err := tx.ResourceControl().Delete(resourceControl.ID); err != nil {
+				return nil, err
+			}
+		}
+
+		if err := tx.Stack().Delete(stack.ID); err != nil {
+			return nil, err
+		}
+
+		result.Stacks++
+	}
+
+	endpoints, err := tx.Endpoint().Endpoints()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, endpoint := range endpoints {
+		if !strings.HasPrefix(endpoint.Name, Prefix) {
+			continue
+		}
+
+		if err := tx.Snapshot().Delete(endpoint.ID); err != nil && !tx.IsErrObjectNotFound(err) {
+			return nil, err
+		}
+
+		if err := tx.EndpointRelation().DeleteEndpointRelation(endpoint.ID); err != nil && !tx.IsErrObjectNotFound(err) {
+			return nil, err
+		}
+
+		if err := tx.Endpoint().DeleteEndpoint(endpoint.ID); err != nil {
+			return nil, err
+		}
+
+		result.Endpoints++
+	}
+
+	users, err := tx.User().ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, user := range users {
+		if !strings.HasPrefix(user.Username, Prefix) {
+			continue
+		}
+
+		if err := tx.TeamMembership().DeleteTeamMembershipByUserID(user.ID); err != nil {
+			return nil, err
+		}
+
+		if err := tx.User().Delete(user.ID); err != nil {
+			return nil, err
+		}
+
+		result.Users++
+	}
+
+	teams, err := tx.Team().ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, team := range teams {
+		if !strings.HasPrefix(team.Name, Prefix) {
+			continue
+		}
+
+		if err := tx.TeamMembership().DeleteTeamMembershipByTeamID(team.ID); err != nil {
+			return nil, err
+		}
+
+		if err := tx.Team().Delete(team.ID); err != nil {
+			return nil, err
+		}
+
+		result.Teams++
+	}
+
+	return result, nil
+}
+
+// NewDockerSnapshot returns the snapshot of a standalone Docker host running the given number of containers, the first
+// ones belonging to the stacks. Most of the containers are running, some of them are stopped or unhealthy
+func NewDockerSnapshot(r *rand.Rand, endpoint *portainer.Endpoint, containerCount int, stackNames []string, now time.Time) *portainer.DockerSnapshot {
+	snapshot := &portainer.DockerSnapshot{
+		Time:          now.Unix(),
+		DockerVersion: dockerVersions[r.Intn(len(dockerVersions))],
+		TotalCPU:      cpus[r.Intn(len(cpus))],
+		StackCount:    len(stackNames),
+		GpuUseList:    []string{},
+	}
+	snapshot.TotalMemory = int64(snapshot.TotalCPU) * 4 * 1024 * 1024 * 1024
+
+	usedImages := map[string]bool{}
+
+	for i := range containerCount {
+		img := images[r.Intn(len(images))]
+		usedImages[img] = true
+
+		container := portainer.DockerContainerSnapshot{
+			Container: types.Container{
+				ID:      randomID(r),
+				Names:   []string{fmt.Sprintf("/%s-%d", strings.SplitN(strings.ReplaceAll(img, "/", "-"), ":", 2)[0], i+1)},
+				Image:   img,
+				ImageID: imageID(img),
+				Created: now.Add(-time.Duration(r.Intn(90*24)) * time.Hour).Unix(),
+				State:   "running",
+				Status:  "Up 3 days",
+				Labels:  map[string]string{},
+			},
+		}
+
+		if len(stackNames) > 0 && i < len(stackNames)*2 {
+			container.Labels[consts.ComposeStackNameLabel] = stackNames[i%len(stackNames)]
+		}
+
+		switch n := r.Intn(100); {
+		case n < 8:
+			container.State = "exited"
+			container.Status = "Exited (0) 2 hours ago"
+			snapshot.StoppedContainerCount++
+		case n < 12:
+			container.Status = "Up 3 days (unhealthy)"
+			snapshot.RunningContainerCount++
+			snapshot.UnhealthyContainerCount++
+		case n < 50:
+			container.Status = "Up 3 days (healthy)"
+			snapshot.RunningContainerCount++
+			snapshot.HealthyContainerCount++
+		default:
+			snapshot.RunningContainerCount++
+		}
+
+		snapshot.SnapshotRaw.Containers = append(snapshot.SnapshotRaw.Containers, container)
+	}
+
+	snapshot.ContainerCount = containerCount
+
+	for _, img := range images {
+		if !usedImages[img] {
+			continue
+		}
+
+		snapshot.SnapshotRaw.Images = append(snapshot.SnapshotRaw.Images, image.Summary{
+			ID:       imageID(img),
+			RepoTags: []string{img},
+			Size:     int64(20+r.Intn(500)) * 1024 * 1024,
+			Created:  now.AddDate(0, -r.Intn(12), 0).Unix(),
+		})
+	}
+
+	snapshot.ImageCount = len(snapshot.SnapshotRaw.Images)
+
+	for i := range containerCount / 2 {
+		snapshot.SnapshotRaw.Volumes.Volumes = append(snapshot.SnapshotRaw.Volumes.Volumes, &volume.Volume{
+			Name:       fmt.Sprintf("%svolume-%d", Prefix, i+1),
+			Driver:     "local",
+			Mountpoint: fmt.Sprintf("/var/lib/docker/volumes/%svolume-%d/_data", Prefix, i+1),
+			Labels:     map[string]string{},
+		})
+	}
+
+	snapshot.VolumeCount = len(snapshot.SnapshotRaw.Volumes.Volumes)
+
+	snapshot.SnapshotRaw.Info = system.Info{
+		ID:                randomID(r)[:36],
+		Name:              endpoint.Name,
+		NCPU:              snapshot.TotalCPU,
+		MemTotal:          snapshot.TotalMemory,
+		ServerVersion:     snapshot.DockerVersion,
+		OperatingSystem:   "Ubuntu 22.04.4 LTS",
+		Containers:        snapshot.ContainerCount,
+		ContainersRunning: snapshot.RunningContainerCount,
+		ContainersStopped: snapshot.StoppedContainerCount,
+		Images:            snapshot.ImageCount,
+	}
+	snapshot.SnapshotRaw.Version = types.Version{Version: snapshot.DockerVersion, APIVersion: "1.45"}
+
+	return snapshot
+}
+
+func countFixtures[T any](elements []T, name func(T) string) int {
+	count := 0
+	for _, element := range elements {
+		if strings.HasPrefix(name(element), Prefix) {
+			count++
+		}
+	}
+
+	return count
+}
+
+func randomID(r *rand.Rand) string {
+	return fmt.Sprintf("%016x%016x%016x%016x", r.Uint64(), r.Uint64(), r.Uint64(), r.Uint64())
+}
+
+func imageID(img string) string {
+	return fmt.Sprintf("sha256:%x", sha256.Sum256([]byte(img)))
+}
@@ -0,0 +1,102 @@
+package fixtures
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+	"github.com/portainer/portainer/api/datastore"
+	"github.com/portainer/portainer/api/docker/consts"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSeedAndClear(t *testing.T) {
+	is := require.New(t)
+
+	_, store := datastore.MustNewTestStore(t, true, false)
+
+	options := Options{Endpoints: 3, ContainersPerEndpoint: 10, StacksPerEndpoint: 2, Users: 5, Teams: 2, Seed: 42}
+
+	var result *Result
+	is.NoError(store.UpdateTx(func(tx dataservices.DataStoreTx) (err error) {
+		result, err = Seed(tx, options, "hash")
+
+		return err
+	}))
+	is.Equal(&Result{Endpoints: 3, Users: 5, Teams: 2, Stacks: 6}, result)
+
+	endpoints, err := store.Endpoint().Endpoints()
+	is.NoError(err)
+	is.Len(endpoints, 3)
+	is.Equal("fixture-endpoint-1", endpoints[0].Name)
+	is.Empty(endpoints[0].URL)
+
+	snapshot, err := store.Snapshot().Read(endpoints[0].ID)
+	is.NoError(err)
+	is.Equal(10, snapshot.Docker.ContainerCount)
+	is.Len(snapshot.Docker.SnapshotRaw.Containers, 10)
+	is.Equal(snapshot.Docker.ContainerCount, snapshot.Docker.RunningContainerCount+snapshot.Docker.StoppedContainerCount)
+
+	memberships, err := store.TeamMembership().ReadAll()
+	is.NoError(err)
+	is.Len(memberships, 5)
+
+	// a second batch does not reuse the names of the first one
+	is.NoError(store.UpdateTx(func(tx dataservices.DataStoreTx) error {
+		_, err := Seed(tx, Options{Users: 1}, "hash")
+
+		return err
+	}))
+
+	_, err = store.User().UserByUsername("fixture-user-6")
+	is.NoError(err)
+
+	is.NoError(store.UpdateTx(func(tx dataservices.DataStoreTx) (err error) {
+		result, err = Clear(tx)
+
+		return err
+	}))
+	is.Equal(&Result{Endpoints: 3, Users: 6, Teams: 2, Stacks: 6}, result)
+
+	endpoints, err = store.Endpoint().Endpoints()
+	is.NoError(err)
+	is.Empty(endpoints)
+
+	resourceControls, err := store.ResourceControl().ReadAll()
+	is.NoError(err)
+	is.Empty(resourceControls)
+
+	memberships, err = store.TeamMembership().ReadAll()
+	is.NoError(err)
+	is.Empty(memberships)
+}
+
+func TestNewDockerSnapshot(t *testing.T) {
+	is := require.New(t)
+
+	endpoint := &portainer.Endpoint{ID: 1, Name: "fixture-endpoint-1"}
+	now := time.Now()
+
+	snapshot := NewDockerSnapshot(rand.New(rand.NewSource(1)), endpoint, 20, []string{"web", "db"}, now)
+	is.Equal(20, snapshot.ContainerCount)
+	is.Equal(2, snapshot.StackCount)
+	is.Equal(10, snapshot.VolumeCount)
+	is.Equal(snapshot.ImageCount, len(snapshot.SnapshotRaw.Images))
+	is.Equal("web", snapshot.SnapshotRaw.Containers[0].Labels[consts.ComposeStackNameLabel])
+	is.Equal("db", snapshot.SnapshotRaw.Containers[1].Labels[consts.ComposeStackNameLabel])
+
+	// the same seed generates the same snapshot
+	is.Equal(snapshot, NewDockerSnapshot(rand.New(rand.NewSource(1)), endpoint, 20, []string{"web", "db"}, now))
+}
+
+func TestOptionsValidate(t *testing.T) {
+	is := require.New(t)
+
+	is.NoError(Options{Endpoints: 100, ContainersPerEndpoint: 10, StacksPerEndpoint: 2}.Validate())
+	is.ErrorIs(Options{Endpoints: MaxCount + 1}.Validate(), ErrTooMany)
+	is.Error(Options{Users: -1}.Validate())
+	is.Error(Options{StacksPerEndpoint: 2}.Validate())
+}
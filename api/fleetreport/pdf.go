@@ -0,0 +1,132 @@
+package fleetreport
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+const (
+	pdfPageWidth    = 595 // A4 in points
+	pdfPageHeight   = 842
+	pdfMargin       = 50
+	pdfFontSize     = 10
+	pdfLineHeight   = 14
+	pdfTitleSize    = 16
+	pdfLinesPerPage = (pdfPageHeight - 2*pdfMargin - 2*pdfLineHeight) / pdfLineHeight
+	// pdfMaxLineLength is the number of characters after which a line is wrapped, for the Helvetica font
+	pdfMaxLineLength = 95
+)
+
+// writePDF renders lines of text as a PDF document with the standard Helvetica font, the title being repeated on
+// every page
+func writePDF(title string, lines []string) []byte {
+	var wrapped []string
+	for _, line := range lines {
+		wrapped = append(wrapped, wrapLine(line, pdfMaxLineLength)...)
+	}
+
+	var pages [][]string
+	for len(wrapped) > 0 || len(pages) == 0 {
+		n := min(pdfLinesPerPage, len(wrapped))
+		pages = append(pages, wrapped[:n])
+		wrapped = wrapped[n:]
+	}
+
+	// objects: 1 catalog, 2 pages, 3 font, then a page and its content for every page
+	var objects []string
+
+	kids := make([]string, len(pages))
+	for i := range pages {
+		kids[i] = fmt.Sprintf("%d 0 R", 4+2*i)
+	}
+
+	objects = append(objects,
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(pages)),
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>",
+	)
+
+	for i, page := range pages {
+		var content bytes.Buffer
+
+		y := pdfPageHeight - pdfMargin - pdfTitleSize
+		fmt.Fprintf(&content, "BT /F1 %d Tf %d %d Td (%s) Tj ET\n", pdfTitleSize, pdfMargin, y, pdfEscape(title))
+
+		y -= 2 * pdfLineHeight
+		for _, line := range page {
+			fmt.Fprintf(&content, "BT /F1 %d Tf %d %d Td (%s) Tj ET\n", pdfFontSize, pdfMargin, y, pdfEscape(line))
+			y -= pdfLineHeight
+		}
+
+		fmt.Fprintf(&content, "BT /F1 8 Tf %d %d Td (%d / %d) Tj ET\n", pdfPageWidth-pdfMargin-30, pdfMargin/2, i+1, len(pages))
+
+		objects = append(objects,
+			fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %d %d] /Resources << /Font << /F1 3 0 R >> >> /Contents %d 0 R >>", pdfPageWidth, pdfPageHeight, 5+2*i),
+			fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", content.Len(), content.String()),
+		)
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("%PDF-1.4\n")
+
+	offsets := make([]int, len(objects))
+	for i, object := range objects {
+		offsets[i] = buf.Len()
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", i+1, object)
+	}
+
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", offset)
+	}
+
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+
+	return buf.Bytes()
+}
+
+// pdfEscape escapes a string for a PDF literal string, the characters outside of Latin-1 being replaced since the
+// standard fonts only cover it
+func pdfEscape(s string) string {
+	var b strings.Builder
+
+	for _, r := range s {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r < 32 || r > 255:
+			b.WriteByte('?')
+		case r > 126:
+			fmt.Fprintf(&b, "\\%03o", r)
+		default:
+			b.WriteRune(r)
+		}
+	}
+
+	return b.String()
+}
+
+// wrapLine splits a line longer than length characters at its spaces, the continuation lines being indented
+func wrapLine(line string, length int) []string {
+	var lines []string
+
+	runes := []rune(line)
+	for len(runes) > length {
+		cut := length
+		for i := length; i > length/2; i-- {
+			if runes[i] == ' ' {
+				cut = i
+
+				break
+			}
+		}
+
+		lines = append(lines, string(runes[:cut]))
+		runes = []rune("    " + strings.TrimLeft(string(runes[cut:]), " "))
+	}
+
+	return append(lines, string(runes))
+}
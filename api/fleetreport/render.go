@@ -0,0 +1,183 @@
+package fleetreport
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"html/template"
+	"strconv"
+	"time"
+)
+
+var htmlTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"date": formatDate,
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<style>
+body { font-family: Helvetica, Arial, sans-serif; color: #222; }
+table { border-collapse: collapse; margin-bottom: 24px; }
+th, td { border: 1px solid #ddd; padding: 4px 8px; text-align: left; }
+th { background: #f4f4f4; }
+</style>
+</head>
+<body>
+<h1>Portainer {{.Frequency}} fleet report</h1>
+<p>From {{date .From}} to {{date .To}}</p>
+
+<h2>Fleet health</h2>
+<table>
+<tr><th>Environments</th><th>Up</th><th>Down</th><th>Containers</th><th>Running</th><th>Stopped</th><th>Unhealthy</th></tr>
+<tr><td>{{.Health.Endpoints}}</td><td>{{.Health.Up}}</td><td>{{.Health.Down}}</td><td>{{.Health.Containers}}</td><td>{{.Health.RunningContainers}}</td><td>{{.Health.StoppedContainers}}</td><td>{{.Health.UnhealthyContainers}}</td></tr>
+</table>
+{{if .Health.Degraded}}
+<table>
+<tr><th>Degraded environment</th><th>Status</th><th>Unhealthy containers</th></tr>
+{{range .Health.Degraded}}<tr><td>{{.Name}}</td><td>{{if .Up}}up{{else}}down{{end}}</td><td>{{.UnhealthyContainers}}</td></tr>
+{{end}}</table>
+{{end}}
+
+<h2>New environments</h2>
+{{if .NewEndpoints}}<table>
+<tr><th>Environment</th><th>Created</th></tr>
+{{range .NewEndpoints}}<tr><td>{{.Name}}</td><td>{{date .CreationDate}}</td></tr>
+{{end}}</table>
+{{else}}<p>No new environment.</p>{{end}}
+
+<h2>Outdated images</h2>
+{{if .OutdatedImages}}<table>
+<tr><th>Environment</th><th>Container</th><th>Image</th><th>Newer image</th></tr>
+{{range .OutdatedImages}}<tr><td>{{.EndpointName}}</td><td>{{.Container}}</td><td>{{.Image}}</td><td>{{if eq .Reason "pulled"}}pulled on the environment{{else}}in the registry{{end}}</td></tr>
+{{end}}</table>
+{{else}}<p>No outdated image.</p>{{end}}
+
+<h2>Failed deployments</h2>
+{{if .FailedDeployments}}<table>
+<tr><th>Date</th><th>Kind</th><th>Name</th><th>Environment</th><th>Error</th></tr>
+{{range .FailedDeployments}}<tr><td>{{date .Time}}</td><td>{{.Kind}}</td><td>{{.Name}}</td><td>{{.EndpointName}}</td><td>{{.Error}}</td></tr>
+{{end}}</table>
+{{else}}<p>No failed deployment.</p>{{end}}
+
+<h2>User activity</h2>
+{{if .UserActivity}}<table>
+<tr><th>User</th><th>Changes</th><th>Failed</th><th>Last activity</th></tr>
+{{range .UserActivity}}<tr><td>{{.Username}}</td><td>{{.Calls}}</td><td>{{.FailedCalls}}</td><td>{{date .LastActivity}}</td></tr>
+{{end}}</table>
+{{else}}<p>No user activity recorded.</p>{{end}}
+</body>
+</html>
+`))
+
+// HTML renders the report as an HTML document, used as the body of the report emails
+func (report *Report) HTML() ([]byte, error) {
+	var buf bytes.Buffer
+	if err := htmlTemplate.Execute(&buf, report); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// CSV renders the report as CSV, one row per item of every section
+func (report *Report) CSV() ([]byte, error) {
+	var buf bytes.Buffer
+
+	w := csv.NewWriter(&buf)
+	w.Write([]string{"section", "environment", "name", "detail", "value", "date"})
+
+	health := report.Health
+	for _, field := range []struct {
+		name  string
+		value int
+	}{
+		{"environments", health.Endpoints},
+		{"environments up", health.Up},
+		{"environments down", health.Down},
+		{"containers", health.Containers},
+		{"running containers", health.RunningContainers},
+		{"stopped containers", health.StoppedContainers},
+		{"unhealthy containers", health.UnhealthyContainers},
+	} {
+		w.Write([]string{"health", "", field.name, "", strconv.Itoa(field.value), ""})
+	}
+
+	for _, degraded := range health.Degraded {
+		status := "up"
+		if !degraded.Up {
+			status = "down"
+		}
+
+		w.Write([]string{"degraded environment", degraded.Name, "", status, strconv.Itoa(degraded.UnhealthyContainers), ""})
+	}
+
+	for _, endpoint := range report.NewEndpoints {
+		w.Write([]string{"new environment", endpoint.Name, "", "", "", formatDate(endpoint.CreationDate)})
+	}
+
+	for _, image := range report.OutdatedImages {
+		w.Write([]string{"outdated image", image.EndpointName, image.Container, image.Image, image.Reason, ""})
+	}
+
+	for _, deployment := range report.FailedDeployments {
+		w.Write([]string{"failed deployment", deployment.EndpointName, deployment.Name, deployment.Kind, deployment.Error, formatDate(deployment.Time)})
+	}
+
+	for _, activity := range report.UserActivity {
+		w.Write([]string{"user activity", "", activity.Username, strconv.Itoa(activity.FailedCalls) + " failed", strconv.Itoa(activity.Calls), formatDate(activity.LastActivity)})
+	}
+
+	w.Flush()
+
+	return buf.Bytes(), w.Error()
+}
+
+// PDF renders the report as a PDF document
+func (report *Report) PDF() []byte {
+	lines := []string{
+		fmt.Sprintf("From %s to %s", formatDate(report.From), formatDate(report.To)),
+		"",
+		"FLEET HEALTH",
+		fmt.Sprintf("Environments: %d (%d up, %d down)", report.Health.Endpoints, report.Health.Up, report.Health.Down),
+		fmt.Sprintf("Containers: %d (%d running, %d stopped, %d unhealthy)", report.Health.Containers, report.Health.RunningContainers, report.Health.StoppedContainers, report.Health.UnhealthyContainers),
+	}
+
+	for _, degraded := range report.Health.Degraded {
+		status := "up"
+		if !degraded.Up {
+			status = "down"
+		}
+
+		lines = append(lines, fmt.Sprintf("  %s: %s, %d unhealthy containers", degraded.Name, status, degraded.UnhealthyContainers))
+	}
+
+	lines = append(lines, "", "NEW ENVIRONMENTS")
+	for _, endpoint := range report.NewEndpoints {
+		lines = append(lines, fmt.Sprintf("  %s, created %s", endpoint.Name, formatDate(endpoint.CreationDate)))
+	}
+
+	lines = append(lines, "", "OUTDATED IMAGES")
+	for _, image := range report.OutdatedImages {
+		lines = append(lines, fmt.Sprintf("  %s / %s: %s (newer image %s)", image.EndpointName, image.Container, image.Image, image.Reason))
+	}
+
+	lines = append(lines, "", "FAILED DEPLOYMENTS")
+	for _, deployment := range report.FailedDeployments {
+		lines = append(lines, fmt.Sprintf("  %s %s %s on %s: %s", formatDate(deployment.Time), deployment.Kind, deployment.Name, deployment.EndpointName, deployment.Error))
+	}
+
+	lines = append(lines, "", "USER ACTIVITY")
+	for _, activity := range report.UserActivity {
+		lines = append(lines, fmt.Sprintf("  %s: %d changes, %d failed, last %s", activity.Username, activity.Calls, activity.FailedCalls, formatDate(activity.LastActivity)))
+	}
+
+	return writePDF("Portainer "+report.Frequency+" fleet report", lines)
+}
+
+func formatDate(timestamp int64) string {
+	if timestamp == 0 {
+		return ""
+	}
+
+	return time.Unix(timestamp, 0).UTC().Format("2006-01-02 15:04 UTC")
+}
@@ -0,0 +1,342 @@
+// Package fleetreport summarizes the health of the environments(endpoints), their outdated images, the failed
+// deployments, the new environments and the activity of the users over a week or a month, and emails the summary
+// on schedule
+package fleetreport
+
+import (
+	"cmp"
+	"errors"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+	"github.com/portainer/portainer/api/docker/images"
+	"github.com/portainer/portainer/api/internal/endpointutils"
+)
+
+const (
+	FrequencyWeekly  = "weekly"
+	FrequencyMonthly = "monthly"
+)
+
+// ErrInvalidFrequency is returned for a frequency other than weekly or monthly
+var ErrInvalidFrequency = errors.New("invalid report frequency, must be weekly or monthly")
+
+// FleetHealth represents the state of the environments(endpoints) and their containers when the report is generated
+type FleetHealth struct {
+	Endpoints           int `json:"Endpoints" example:"12"`
+	Up                  int `json:"Up" example:"11"`
+	Down                int `json:"Down" example:"1"`
+	Containers          int `json:"Containers" example:"120"`
+	RunningContainers   int `json:"RunningContainers" example:"110"`
+	StoppedContainers   int `json:"StoppedContainers" example:"10"`
+	UnhealthyContainers int `json:"UnhealthyContainers" example:"2"`
+	// Environments which are down or have unhealthy containers
+	Degraded []EndpointHealth `json:"Degraded"`
+}
+
+// EndpointHealth represents the state of an environment(endpoint)
+type EndpointHealth struct {
+	EndpointID          portainer.EndpointID `json:"EndpointId" example:"1"`
+	Name                string               `json:"Name" example:"production"`
+	Up                  bool                 `json:"Up" example:"true"`
+	UnhealthyContainers int                  `json:"UnhealthyContainers" example:"2"`
+}
+
+// NewEndpoint represents an environment(endpoint) created during the period of the report
+type NewEndpoint struct {
+	EndpointID   portainer.EndpointID `json:"EndpointId" example:"1"`
+	Name         string               `json:"Name" example:"production"`
+	CreationDate int64                `json:"CreationDate" example:"1587399600"`
+}
+
+// OutdatedImage represents a container running an older image than the one of its tag
+type OutdatedImage struct {
+	EndpointID   portainer.EndpointID `json:"EndpointId" example:"1"`
+	EndpointName string               `json:"EndpointName" example:"production"`
+	Container    string               `json:"Container" example:"web"`
+	Image        string               `json:"Image" example:"nginx:latest"`
+	// Either "pulled" when a newer image was pulled on the environment, or "registry" when the registry has a
+	// newer image
+	Reason string `json:"Reason" example:"pulled"`
+}
+
+// FailedDeployment represents a deployment of a stack or an edge stack which failed during the period of the report
+type FailedDeployment struct {
+	Time int64 `json:"Time" example:"1587399600"`
+	// Either "stack" or "edge stack"
+	Kind         string               `json:"Kind" example:"stack"`
+	Name         string               `json:"Name" example:"myapp"`
+	EndpointID   portainer.EndpointID `json:"EndpointId,omitempty" example:"1"`
+	EndpointName string               `json:"EndpointName,omitempty" example:"production"`
+	Error        string               `json:"Error" example:"HTTP 500 on PUT /api/stacks/1"`
+}
+
+// UserActivity represents the state-changing calls of a user during the period of the report
+type UserActivity struct {
+	Username     string `json:"Username" example:"bob"`
+	Calls        int    `json:"Calls" example:"42"`
+	FailedCalls  int    `json:"FailedCalls" example:"1"`
+	LastActivity int64  `json:"LastActivity" example:"1587399600"`
+}
+
+// Report represents the fleet report of a period
+type Report struct {
+	Frequency         string             `json:"Frequency" example:"weekly"`
+	From              int64              `json:"From" example:"1587340800"`
+	To                int64              `json:"To" example:"1587945600"`
+	GeneratedAt       int64              `json:"GeneratedAt" example:"1587945600"`
+	Health            FleetHealth        `json:"Health"`
+	NewEndpoints      []NewEndpoint      `json:"NewEndpoints"`
+	OutdatedImages    []OutdatedImage    `json:"OutdatedImages"`
+	FailedDeployments []FailedDeployment `json:"FailedDeployments"`
+	UserActivity      []UserActivity     `json:"UserActivity"`
+}
+
+// Period returns the period covered by the last report of a frequency at the given time: the previous week, starting
+// on Monday, or the previous month
+func Period(frequency string, now time.Time) (time.Time, time.Time, error) {
+	now = now.UTC()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+
+	switch frequency {
+	case FrequencyWeekly:
+		to := today.AddDate(0, 0, -(int(today.Weekday())+6)%7)
+
+		return to.AddDate(0, 0, -7), to, nil
+	case FrequencyMonthly:
+		to := today.AddDate(0, 0, 1-today.Day())
+
+		return to.AddDate(0, -1, 0), to, nil
+	}
+
+	return time.Time{}, time.Time{}, ErrInvalidFrequency
+}
+
+// Generate generates the report of the period [from, to)
+func Generate(tx dataservices.DataStoreTx, frequency string, from, to time.Time) (*Report, error) {
+	report := &Report{
+		Frequency:         frequency,
+		From:              from.Unix(),
+		To:                to.Unix(),
+		GeneratedAt:       time.Now().Unix(),
+		Health:            FleetHealth{Degraded: []EndpointHealth{}},
+		NewEndpoints:      []NewEndpoint{},
+		OutdatedImages:    []OutdatedImage{},
+		FailedDeployments: []FailedDeployment{},
+		UserActivity:      []UserActivity{},
+	}
+
+	endpoints, err := tx.Endpoint().Endpoints()
+	if err != nil {
+		return nil, err
+	}
+
+	endpointNames := make(map[portainer.EndpointID]string, len(endpoints))
+
+	for i := range endpoints {
+		endpoint := &endpoints[i]
+		endpointNames[endpoint.ID] = endpoint.Name
+
+		if err := report.addEndpoint(tx, endpoint); err != nil {
+			return nil, err
+		}
+
+		if endpoint.CreationDate >= report.From && endpoint.CreationDate < report.To {
+			report.NewEndpoints = append(report.NewEndpoints, NewEndpoint{EndpointID: endpoint.ID, Name: endpoint.Name, CreationDate: endpoint.CreationDate})
+		}
+	}
+
+	if err := report.addEdgeStackFailures(tx, endpointNames); err != nil {
+		return nil, err
+	}
+
+	if err := report.addAuditLogs(tx, endpointNames); err != nil {
+		return nil, err
+	}
+
+	slices.SortFunc(report.FailedDeployments, func(a, b FailedDeployment) int { return cmp.Compare(a.Time, b.Time) })
+	slices.SortFunc(report.UserActivity, func(a, b UserActivity) int {
+		return cmp.Or(cmp.Compare(b.Calls, a.Calls), strings.Compare(a.Username, b.Username))
+	})
+
+	return report, nil
+}
+
+func (report *Report) addEndpoint(tx dataservices.DataStoreTx, endpoint *portainer.Endpoint) error {
+	health := EndpointHealth{EndpointID: endpoint.ID, Name: endpoint.Name, Up: endpoint.Status == portainer.EndpointStatusUp}
+
+	report.Health.Endpoints++
+	if health.Up {
+		report.Health.Up++
+	} else {
+		report.Health.Down++
+	}
+
+	if endpointutils.IsDockerEndpoint(endpoint) {
+		snapshot, err := tx.Snapshot().Read(endpoint.ID)
+		if err != nil && !tx.IsErrObjectNotFound(err) {
+			return err
+		}
+
+		if snapshot != nil && snapshot.Docker != nil {
+			docker := snapshot.Docker
+
+			report.Health.Containers += docker.ContainerCount
+			report.Health.RunningContainers += docker.RunningContainerCount
+			report.Health.StoppedContainers += docker.StoppedContainerCount
+			report.Health.UnhealthyContainers += docker.UnhealthyContainerCount
+			health.UnhealthyContainers = docker.UnhealthyContainerCount
+
+			report.OutdatedImages = append(report.OutdatedImages, outdatedImages(endpoint, docker)...)
+		}
+	}
+
+	if !health.Up || health.UnhealthyContainers > 0 {
+		report.Health.Degraded = append(report.Health.Degraded, health)
+	}
+
+	return nil
+}
+
+// outdatedImages returns the containers of a snapshot whose image tag now points to another image, and the ones
+// whose registry has a newer image according to the last image status check
+func outdatedImages(endpoint *portainer.Endpoint, snapshot *portainer.DockerSnapshot) []OutdatedImage {
+	tagged := make(map[string]string)
+	for _, image := range snapshot.SnapshotRaw.Images {
+		for _, tag := range image.RepoTags {
+			tagged[tag] = image.ID
+		}
+	}
+
+	outdated := []OutdatedImage{}
+
+	for _, container := range snapshot.SnapshotRaw.Containers {
+		name := container.ID
+		if len(container.Names) > 0 {
+			name = strings.TrimPrefix(container.Names[0], "/")
+		}
+
+		image := OutdatedImage{EndpointID: endpoint.ID, EndpointName: endpoint.Name, Container: name, Image: container.Image}
+
+		tag := container.Image
+		if !strings.Contains(tag, "@") && !strings.Contains(tag[strings.LastIndex(tag, "/")+1:], ":") {
+			tag += ":latest"
+		}
+
+		if imageID, ok := tagged[tag]; ok && imageID != container.ImageID {
+			image.Reason = "pulled"
+		} else if status, err := images.CachedResourceImageStatus(container.ImageID); err == nil && status == images.Outdated {
+			image.Reason = "registry"
+		} else {
+			continue
+		}
+
+		outdated = append(outdated, image)
+	}
+
+	return outdated
+}
+
+func (report *Report) addEdgeStackFailures(tx dataservices.DataStoreTx, endpointNames map[portainer.EndpointID]string) error {
+	edgeStacks, err := tx.EdgeStack().EdgeStacks()
+	if err != nil {
+		return err
+	}
+
+	for _, edgeStack := range edgeStacks {
+		for endpointID, status := range edgeStack.Status {
+			for _, deployment := range status.Status {
+				if deployment.Type != portainer.EdgeStackStatusError || deployment.Time < report.From || deployment.Time >= report.To {
+					continue
+				}
+
+				report.FailedDeployments = append(report.FailedDeployments, FailedDeployment{
+					Time:         deployment.Time,
+					Kind:         "edge stack",
+					Name:         edgeStack.Name,
+					EndpointID:   endpointID,
+					EndpointName: endpointNames[endpointID],
+					Error:        deployment.Error,
+				})
+			}
+		}
+	}
+
+	return nil
+}
+
+// addAuditLogs adds the activity of the users and the failed calls deploying the stacks, from the audit logs
+func (report *Report) addAuditLogs(tx dataservices.DataStoreTx, endpointNames map[portainer.EndpointID]string) error {
+	auditLogs, err := tx.AuditLog().ReadAll()
+	if err != nil {
+		return err
+	}
+
+	stacks := make(map[string]portainer.Stack)
+	activities := make(map[string]*UserActivity)
+
+	for _, auditLog := range auditLogs {
+		if auditLog.Timestamp < report.From || auditLog.Timestamp >= report.To {
+			continue
+		}
+
+		failed := auditLog.StatusCode >= 400
+
+		if auditLog.Username != "" {
+			activity, ok := activities[auditLog.Username]
+			if !ok {
+				activity = &UserActivity{Username: auditLog.Username}
+				activities[auditLog.Username] = activity
+			}
+
+			activity.Calls++
+			activity.LastActivity = max(activity.LastActivity, auditLog.Timestamp)
+
+			if failed {
+				activity.FailedCalls++
+			}
+		}
+
+		if !failed || auditLog.ResourceType != "stacks" || auditLog.Method == "DELETE" || auditLog.StatusCode == 404 {
+			continue
+		}
+
+		stack := auditedStack(tx, stacks, auditLog.ResourceID)
+		endpointID := cmp.Or(auditLog.EndpointID, stack.EndpointID)
+
+		report.FailedDeployments = append(report.FailedDeployments, FailedDeployment{
+			Time:         auditLog.Timestamp,
+			Kind:         "stack",
+			Name:         stack.Name,
+			EndpointID:   endpointID,
+			EndpointName: endpointNames[endpointID],
+			Error:        "HTTP " + strconv.Itoa(auditLog.StatusCode) + " on " + auditLog.Method + " " + auditLog.Path,
+		})
+	}
+
+	for _, activity := range activities {
+		report.UserActivity = append(report.UserActivity, *activity)
+	}
+
+	return nil
+}
+
+// auditedStack returns the stack an audit log refers to, an empty stack when it was removed since then or the call
+// created it
+func auditedStack(tx dataservices.DataStoreTx, stacks map[string]portainer.Stack, resourceID string) portainer.Stack {
+	if stack, ok := stacks[resourceID]; ok {
+		return stack
+	}
+
+	if id, err := strconv.Atoi(resourceID); err == nil {
+		if stack, err := tx.Stack().Read(portainer.StackID(id)); err == nil {
+			stacks[resourceID] = *stack
+		}
+	}
+
+	return stacks[resourceID]
+}
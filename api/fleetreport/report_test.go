@@ -0,0 +1,113 @@
+package fleetreport
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+	"github.com/portainer/portainer/api/datastore"
+
+	"github.com/docker/docker/api/types/image"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPeriod(t *testing.T) {
+	is := require.New(t)
+
+	// a Wednesday
+	now := time.Date(2024, time.March, 13, 15, 4, 5, 0, time.UTC)
+
+	from, to, err := Period(FrequencyWeekly, now)
+	is.NoError(err)
+	is.Equal(time.Date(2024, time.March, 4, 0, 0, 0, 0, time.UTC), from)
+	is.Equal(time.Date(2024, time.March, 11, 0, 0, 0, 0, time.UTC), to)
+
+	// on a Monday, the week which just ended
+	from, _, err = Period(FrequencyWeekly, time.Date(2024, time.March, 11, 0, 30, 0, 0, time.UTC))
+	is.NoError(err)
+	is.Equal(time.Date(2024, time.March, 4, 0, 0, 0, 0, time.UTC), from)
+
+	from, to, err = Period(FrequencyMonthly, now)
+	is.NoError(err)
+	is.Equal(time.Date(2024, time.February, 1, 0, 0, 0, 0, time.UTC), from)
+	is.Equal(time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC), to)
+
+	_, _, err = Period("daily", now)
+	is.ErrorIs(err, ErrInvalidFrequency)
+}
+
+func TestGenerate(t *testing.T) {
+	is := require.New(t)
+
+	_, store := datastore.MustNewTestStore(t, true, false)
+
+	from := time.Date(2024, time.March, 4, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 0, 7)
+	during := from.Add(time.Hour).Unix()
+
+	is.NoError(store.Endpoint().Create(&portainer.Endpoint{ID: 1, Name: "production", Type: portainer.DockerEnvironment, Status: portainer.EndpointStatusUp, CreationDate: during}))
+	is.NoError(store.Endpoint().Create(&portainer.Endpoint{ID: 2, Name: "staging", Type: portainer.DockerEnvironment, Status: portainer.EndpointStatusDown, CreationDate: from.Add(-time.Hour).Unix()}))
+
+	snapshot := &portainer.DockerSnapshot{ContainerCount: 2, RunningContainerCount: 2, UnhealthyContainerCount: 1}
+	snapshot.SnapshotRaw.Images = []image.Summary{{ID: "sha256:new", RepoTags: []string{"nginx:latest"}}}
+	snapshot.SnapshotRaw.Containers = []portainer.DockerContainerSnapshot{{}, {}}
+	snapshot.SnapshotRaw.Containers[0].Names = []string{"/web"}
+	snapshot.SnapshotRaw.Containers[0].Image = "nginx"
+	snapshot.SnapshotRaw.Containers[0].ImageID = "sha256:old"
+	snapshot.SnapshotRaw.Containers[1].Image = "nginx:latest"
+	snapshot.SnapshotRaw.Containers[1].ImageID = "sha256:new"
+	is.NoError(store.Snapshot().Create(&portainer.Snapshot{EndpointID: 1, Docker: snapshot}))
+
+	is.NoError(store.Stack().Create(&portainer.Stack{ID: 1, Name: "myapp", EndpointID: 1}))
+	is.NoError(store.EdgeStack().Create(1, &portainer.EdgeStack{ID: 1, Name: "edge-app", Status: map[portainer.EndpointID]portainer.EdgeStackStatus{
+		2: {Status: []portainer.EdgeStackDeploymentStatus{{Time: during, Type: portainer.EdgeStackStatusError, Error: "pull failed"}}},
+	}}))
+
+	for _, auditLog := range []portainer.AuditLog{
+		{Timestamp: during, Username: "bob", Method: "PUT", Path: "/api/stacks/1", ResourceType: "stacks", ResourceID: "1", StatusCode: 500},
+		{Timestamp: during, Username: "bob", Method: "POST", Path: "/api/tags", ResourceType: "tags", StatusCode: 200},
+		{Timestamp: during, Username: "alice", Method: "POST", Path: "/api/tags", ResourceType: "tags", StatusCode: 200},
+		{Timestamp: to.Unix(), Username: "alice", Method: "POST", Path: "/api/tags", ResourceType: "tags", StatusCode: 200},
+	} {
+		is.NoError(store.AuditLog().Create(&auditLog))
+	}
+
+	var report *Report
+	is.NoError(store.ViewTx(func(tx dataservices.DataStoreTx) error {
+		var err error
+		report, err = Generate(tx, FrequencyWeekly, from, to)
+
+		return err
+	}))
+
+	is.Equal(2, report.Health.Endpoints)
+	is.Equal(1, report.Health.Down)
+	is.Equal(1, report.Health.UnhealthyContainers)
+	is.Len(report.Health.Degraded, 2)
+
+	is.Equal([]NewEndpoint{{EndpointID: 1, Name: "production", CreationDate: during}}, report.NewEndpoints)
+	is.Equal([]OutdatedImage{{EndpointID: 1, EndpointName: "production", Container: "web", Image: "nginx", Reason: "pulled"}}, report.OutdatedImages)
+
+	is.Len(report.FailedDeployments, 2)
+	is.ElementsMatch([]string{"myapp", "edge-app"}, []string{report.FailedDeployments[0].Name, report.FailedDeployments[1].Name})
+
+	is.Equal([]UserActivity{
+		{Username: "bob", Calls: 2, FailedCalls: 1, LastActivity: during},
+		{Username: "alice", Calls: 1, LastActivity: during},
+	}, report.UserActivity)
+
+	html, err := report.HTML()
+	is.NoError(err)
+	is.Contains(string(html), "edge-app")
+
+	csv, err := report.CSV()
+	is.NoError(err)
+	is.Contains(string(csv), "outdated image,production,web,nginx,pulled,")
+
+	pdf := report.PDF()
+	is.True(bytes.HasPrefix(pdf, []byte("%PDF-1.4")))
+	is.True(bytes.HasSuffix(pdf, []byte("%%EOF\n")))
+	is.Contains(string(pdf), "(  production / web: nginx \\(newer image pulled\\)) Tj")
+}
@@ -0,0 +1,140 @@
+package fleetreport
+
+import (
+	"errors"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+	"github.com/portainer/portainer/api/smtp"
+
+	"github.com/rs/zerolog/log"
+)
+
+// CheckInterval is the interval between the checks of whether the scheduled report is due
+const CheckInterval = time.Hour
+
+// Service generates the fleet reports and emails them
+type Service struct {
+	dataStore dataservices.DataStore
+}
+
+// NewService creates a new fleet report service
+func NewService(dataStore dataservices.DataStore) *Service {
+	return &Service{dataStore: dataStore}
+}
+
+// Generate generates the report of the last complete week or month
+func (service *Service) Generate(frequency string, now time.Time) (*Report, error) {
+	from, to, err := Period(frequency, now)
+	if err != nil {
+		return nil, err
+	}
+
+	var report *Report
+
+	err = service.dataStore.ViewTx(func(tx dataservices.DataStoreTx) error {
+		report, err = Generate(tx, frequency, from, to)
+
+		return err
+	})
+
+	return report, err
+}
+
+// Send emails a report to the recipients, with the attachments of the report settings
+func (service *Service) Send(report *Report, recipients []string) error {
+	settings, err := service.dataStore.Settings().Settings()
+	if err != nil {
+		return err
+	}
+
+	if len(recipients) == 0 {
+		return errors.New("the report has no recipient")
+	}
+
+	html, err := report.HTML()
+	if err != nil {
+		return err
+	}
+
+	message := smtp.Message{
+		To:      recipients,
+		Subject: "Portainer " + report.Frequency + " fleet report " + time.Unix(report.From, 0).UTC().Format(time.DateOnly),
+		HTML:    string(html),
+	}
+
+	filename := "fleet-report-" + time.Unix(report.From, 0).UTC().Format(time.DateOnly)
+
+	if settings.FleetReportSettings.AttachPDF {
+		message.Attachments = append(message.Attachments, smtp.Attachment{Filename: filename + ".pdf", ContentType: "application/pdf", Content: report.PDF()})
+	}
+
+	if settings.FleetReportSettings.AttachCSV {
+		content, err := report.CSV()
+		if err != nil {
+			return err
+		}
+
+		message.Attachments = append(message.Attachments, smtp.Attachment{Filename: filename + ".csv", ContentType: "text/csv", Content: content})
+	}
+
+	return smtp.Send(settings.SMTPSettings, message)
+}
+
+// SendIfDue emails the report of the last complete period once it ends, according to the report settings
+func (service *Service) SendIfDue() error {
+	settings, err := service.dataStore.Settings().Settings()
+	if err != nil {
+		return err
+	}
+
+	reportSettings := settings.FleetReportSettings
+	if reportSettings.Frequency == "" || len(reportSettings.Recipients) == 0 {
+		return nil
+	}
+
+	now := time.Now()
+
+	_, to, err := Period(reportSettings.Frequency, now)
+	if err != nil {
+		return err
+	}
+
+	if reportSettings.LastSentAt >= to.Unix() {
+		return nil
+	}
+
+	report, err := service.Generate(reportSettings.Frequency, now)
+	if err != nil {
+		return err
+	}
+
+	if err := service.Send(report, reportSettings.Recipients); err != nil {
+		return err
+	}
+
+	log.Info().Str("frequency", report.Frequency).Int("recipients", len(reportSettings.Recipients)).Msg("fleet report sent")
+
+	return service.dataStore.UpdateTx(func(tx dataservices.DataStoreTx) error {
+		settings, err := tx.Settings().Settings()
+		if err != nil {
+			return err
+		}
+
+		settings.FleetReportSettings.LastSentAt = now.Unix()
+
+		return tx.Settings().UpdateSettings(settings)
+	})
+}
+
+// ValidateSettings checks the frequency of the report settings
+func ValidateSettings(settings portainer.FleetReportSettings) error {
+	if settings.Frequency == "" {
+		return nil
+	}
+
+	_, _, err := Period(settings.Frequency, time.Now())
+
+	return err
+}
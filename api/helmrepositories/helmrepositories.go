@@ -0,0 +1,173 @@
+// Package helmrepositories manages the Helm chart repositories of the users and of the teams, the private ones
+// being reached with basic authentication or TLS client certificates, and caches their indexes
+package helmrepositories
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/pkg/libhelm"
+	"github.com/portainer/portainer/pkg/libhelm/options"
+)
+
+// clientTimeout bounds the download of an index, the index of a large repository being several megabytes
+const clientTimeout = 300 * time.Second
+
+// NormalizeURL returns the URL of a repository in lowercase and without its trailing slash, the repositories being
+// compared on their normalized URLs
+func NormalizeURL(url string) string {
+	return strings.TrimSuffix(strings.ToLower(url), "/")
+}
+
+// Validate checks the settings of a repository and that its index can be reached with them
+func Validate(repository *portainer.HelmUserRepository) error {
+	if repository.Authentication && repository.Username == "" {
+		return errors.New("a username is required with the authentication")
+	}
+
+	if (repository.TLSCert == "") != (repository.TLSKey == "") {
+		return errors.New("the TLS client certificate and key must be set together")
+	}
+
+	client, err := HTTPClient(repository)
+	if err != nil {
+		return err
+	}
+
+	return libhelm.ValidateHelmRepositoryURL(repository.URL, client)
+}
+
+// HideSecrets removes the password and the TLS key of a repository before it is returned to the users
+func HideSecrets(repository *portainer.HelmUserRepository) {
+	repository.Password = ""
+	repository.TLSKey = ""
+}
+
+// HTTPClient returns a client downloading the index of a repository with its authentication and TLS settings
+func HTTPClient(repository *portainer.HelmUserRepository) (*http.Client, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+
+	if repository.TLSSkipVerify || repository.TLSCACert != "" || repository.TLSCert != "" {
+		tlsConfig := &tls.Config{InsecureSkipVerify: repository.TLSSkipVerify}
+
+		if repository.TLSCACert != "" {
+			pool := x509.NewCertPool()
+			if !pool.AppendCertsFromPEM([]byte(repository.TLSCACert)) {
+				return nil, errors.New("invalid TLS CA certificate")
+			}
+
+			tlsConfig.RootCAs = pool
+		}
+
+		if repository.TLSCert != "" {
+			cert, err := tls.X509KeyPair([]byte(repository.TLSCert), []byte(repository.TLSKey))
+			if err != nil {
+				return nil, errors.New("invalid TLS client certificate or key")
+			}
+
+			tlsConfig.Certificates = []tls.Certificate{cert}
+		}
+
+		transport.TLSClientConfig = tlsConfig
+	}
+
+	var roundTripper http.RoundTripper = transport
+	if repository.Authentication {
+		repositoryURL, err := url.Parse(repository.URL)
+		if err != nil {
+			return nil, err
+		}
+
+		roundTripper = &basicAuthTransport{host: repositoryURL.Host, username: repository.Username, password: repository.Password, next: transport}
+	}
+
+	return &http.Client{Timeout: clientTimeout, Transport: roundTripper}, nil
+}
+
+// basicAuthTransport authenticates the requests to the host of a repository, the credentials not being sent when the
+// index is redirected to another host, like helm does without --pass-credentials
+type basicAuthTransport struct {
+	host     string
+	username string
+	password string
+	next     http.RoundTripper
+}
+
+func (t *basicAuthTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	if r.URL.Host != t.host {
+		return t.next.RoundTrip(r)
+	}
+
+	r = r.Clone(r.Context())
+	r.SetBasicAuth(t.username, t.password)
+
+	return t.next.RoundTrip(r)
+}
+
+// AuthOptions returns the authentication of the helm commands to a repository, the certificates and the key being
+// written to temporary files removed by the returned cleanup function
+func AuthOptions(repository *portainer.HelmUserRepository) (*options.RepositoryAuthentication, func(), error) {
+	auth := &options.RepositoryAuthentication{InsecureSkipTLSVerify: repository.TLSSkipVerify}
+	if repository.Authentication {
+		auth.Username = repository.Username
+		auth.Password = repository.Password
+	}
+
+	files := []string{}
+	cleanup := func() {
+		for _, file := range files {
+			os.Remove(file)
+		}
+	}
+
+	for _, f := range []struct {
+		content string
+		path    *string
+	}{
+		{repository.TLSCACert, &auth.CAFile},
+		{repository.TLSCert, &auth.CertFile},
+		{repository.TLSKey, &auth.KeyFile},
+	} {
+		if f.content == "" {
+			continue
+		}
+
+		path, err := writeTempFile(f.content)
+		if err != nil {
+			cleanup()
+			return nil, nil, err
+		}
+
+		files = append(files, path)
+		*f.path = path
+	}
+
+	return auth, cleanup, nil
+}
+
+func writeTempFile(content string) (string, error) {
+	file, err := os.CreateTemp("", "helm-repository-*.pem")
+	if err != nil {
+		return "", err
+	}
+
+	if _, err := file.WriteString(content); err != nil {
+		file.Close()
+		os.Remove(file.Name())
+		return "", err
+	}
+
+	if err := file.Close(); err != nil {
+		os.Remove(file.Name())
+		return "", err
+	}
+
+	return file.Name(), nil
+}
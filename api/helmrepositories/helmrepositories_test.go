@@ -0,0 +1,76 @@
+package helmrepositories
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/pkg/libhelm/binary"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHTTPClient_BasicAuthIsSentToTheRepositoryHostOnly(t *testing.T) {
+	var other string
+
+	otherServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		other = r.Header.Get("Authorization")
+	}))
+	defer otherServer.Close()
+
+	var username, password string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		username, password, _ = r.BasicAuth()
+		http.Redirect(w, r, otherServer.URL+"/index.yaml", http.StatusFound)
+	}))
+	defer server.Close()
+
+	client, err := HTTPClient(&portainer.HelmUserRepository{URL: server.URL, Authentication: true, Username: "charts", Password: "secret"})
+	require.NoError(t, err)
+
+	resp, err := client.Get(server.URL + "/index.yaml")
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	assert.Equal(t, "charts", username)
+	assert.Equal(t, "secret", password)
+	assert.Empty(t, other)
+}
+
+func TestHTTPClient_InvalidCACert(t *testing.T) {
+	_, err := HTTPClient(&portainer.HelmUserRepository{URL: "https://charts.example.com", TLSCACert: "not a certificate"})
+	assert.Error(t, err)
+}
+
+func TestAuthOptions(t *testing.T) {
+	auth, cleanup, err := AuthOptions(&portainer.HelmUserRepository{Authentication: true, Username: "charts", Password: "secret", TLSCACert: "ca"})
+	require.NoError(t, err)
+
+	assert.Equal(t, "charts", auth.Username)
+	assert.Empty(t, auth.CertFile)
+
+	content, err := os.ReadFile(auth.CAFile)
+	require.NoError(t, err)
+	assert.Equal(t, "ca", string(content))
+
+	cleanup()
+
+	_, err = os.Stat(auth.CAFile)
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestMergeIndexes(t *testing.T) {
+	repositories := []portainer.HelmUserRepository{{URL: "https://a.example.com"}, {URL: "https://b.example.com"}, {URL: "https://c.example.com"}}
+	index := &binary.File{Entries: map[string][]binary.Entry{"nginx": {{Name: "nginx"}}}}
+
+	merged := mergeIndexes(repositories, []*binary.File{index, nil, index})
+
+	require.Len(t, merged.Entries["nginx"], 2)
+	assert.Equal(t, "https://a.example.com", merged.Entries["nginx"][0].Repo)
+	assert.Equal(t, "https://c.example.com", merged.Entries["nginx"][1].Repo)
+	assert.Empty(t, index.Entries["nginx"][0].Repo, "the cached index should be left untouched")
+}
@@ -0,0 +1,272 @@
+package helmrepositories
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+	"github.com/portainer/portainer/api/internal/authorization"
+	"github.com/portainer/portainer/pkg/libhelm"
+	"github.com/portainer/portainer/pkg/libhelm/binary"
+	"github.com/portainer/portainer/pkg/libhelm/options"
+
+	"github.com/rs/zerolog/log"
+	"github.com/segmentio/encoding/json"
+	"golang.org/x/sync/errgroup"
+	"golang.org/x/sync/singleflight"
+)
+
+const (
+	// RefreshInterval is the interval at which the cached indexes are downloaded again
+	RefreshInterval = time.Hour
+	// evictAfter removes the cached indexes which were not used for a while, such as the ones of deleted repositories
+	evictAfter = 24 * time.Hour
+	// fetchConcurrency is the number of indexes downloaded at once when the charts of several repositories are merged
+	fetchConcurrency = 4
+)
+
+// cachedIndex is the index of a repository downloaded with its settings
+type cachedIndex struct {
+	repository portainer.HelmUserRepository
+	file       *binary.File
+	lastUsed   time.Time
+}
+
+// Service caches the indexes of the repositories of the users and of the teams, and of the global repository
+type Service struct {
+	dataStore          dataservices.DataStore
+	helmPackageManager libhelm.HelmPackageManager
+	fetches            singleflight.Group
+
+	mu      sync.Mutex
+	indexes map[string]*cachedIndex
+}
+
+// NewService creates a new Helm repositories service
+func NewService(dataStore dataservices.DataStore, helmPackageManager libhelm.HelmPackageManager) *Service {
+	return &Service{
+		dataStore:          dataStore,
+		helmPackageManager: helmPackageManager,
+		indexes:            map[string]*cachedIndex{},
+	}
+}
+
+// Start refreshes the cached indexes until the context is done
+func (service *Service) Start(ctx context.Context) {
+	ticker := time.NewTicker(RefreshInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			service.refresh()
+		}
+	}
+}
+
+// refresh downloads the cached indexes again, an index failing to download being kept until the next refresh
+func (service *Service) refresh() {
+	service.mu.Lock()
+	repositories := map[string]portainer.HelmUserRepository{}
+	for key, index := range service.indexes {
+		if time.Since(index.lastUsed) > evictAfter {
+			delete(service.indexes, key)
+			continue
+		}
+
+		repositories[key] = index.repository
+	}
+	service.mu.Unlock()
+
+	for key, repository := range repositories {
+		if _, err := service.fetch(key, repository); err != nil {
+			log.Warn().Err(err).Str("url", repository.URL).Msg("unable to refresh the index of the Helm repository")
+		}
+	}
+}
+
+// cacheKey identifies the index of a repository, the same URL being reached with the settings of each repository
+func cacheKey(repository portainer.HelmUserRepository) string {
+	return strconv.Itoa(int(repository.ID)) + "|" + NormalizeURL(repository.URL)
+}
+
+// Index returns the index of a repository, downloading it when it is not cached or when refresh is true
+func (service *Service) Index(repository portainer.HelmUserRepository, refresh bool) (*binary.File, error) {
+	key := cacheKey(repository)
+
+	service.mu.Lock()
+	index, ok := service.indexes[key]
+	if ok {
+		index.lastUsed = time.Now()
+	}
+	service.mu.Unlock()
+
+	if ok && !refresh {
+		return index.file, nil
+	}
+
+	return service.fetch(key, repository)
+}
+
+func (service *Service) fetch(key string, repository portainer.HelmUserRepository) (*binary.File, error) {
+	file, err, _ := service.fetches.Do(key, func() (any, error) {
+		client, err := HTTPClient(&repository)
+		if err != nil {
+			return nil, err
+		}
+
+		result, err := service.helmPackageManager.SearchRepo(options.SearchRepoOptions{Repo: repository.URL, Client: client})
+		if err != nil {
+			return nil, err
+		}
+
+		var file binary.File
+		if err := json.Unmarshal(result, &file); err != nil {
+			return nil, fmt.Errorf("unable to parse the index of the repository: %w", err)
+		}
+
+		service.mu.Lock()
+		service.indexes[key] = &cachedIndex{repository: repository, file: &file, lastUsed: time.Now()}
+		service.mu.Unlock()
+
+		return &file, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return file.(*binary.File), nil
+}
+
+// Repositories returns the repositories of a user and of its teams, including the teams above them
+func (service *Service) Repositories(userID portainer.UserID) ([]portainer.HelmUserRepository, error) {
+	repositories, err := service.dataStore.HelmUserRepository().HelmUserRepositoryByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	memberships, err := authorization.EffectiveTeamMemberships(service.dataStore, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	teamIDs := map[portainer.TeamID]bool{}
+	for _, membership := range memberships {
+		teamIDs[membership.TeamID] = true
+	}
+
+	for _, teamID := range slices.Sorted(maps.Keys(teamIDs)) {
+		teamRepositories, err := service.dataStore.HelmUserRepository().HelmUserRepositoryByTeamID(teamID)
+		if err != nil {
+			return nil, err
+		}
+
+		repositories = append(repositories, teamRepositories...)
+	}
+
+	return repositories, nil
+}
+
+// Repository returns the repository of a user or of its teams with a URL, the one to reach the URL with, or an
+// anonymous repository when there is none
+func (service *Service) Repository(userID portainer.UserID, url string) (portainer.HelmUserRepository, error) {
+	repositories, err := service.Repositories(userID)
+	if err != nil {
+		return portainer.HelmUserRepository{}, err
+	}
+
+	for _, repository := range repositories {
+		if NormalizeURL(repository.URL) == NormalizeURL(url) {
+			return repository, nil
+		}
+	}
+
+	return portainer.HelmUserRepository{URL: url}, nil
+}
+
+// Charts returns the charts of the global repository and of the repositories of a user and of its teams, each chart
+// version having the URL of its repository. The repositories which cannot be reached are left out
+func (service *Service) Charts(userID portainer.UserID, refresh bool) (*binary.File, error) {
+	settings, err := service.dataStore.Settings().Settings()
+	if err != nil {
+		return nil, err
+	}
+
+	repositories, err := service.Repositories(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if settings.HelmRepositoryURL != "" {
+		repositories = append([]portainer.HelmUserRepository{{URL: settings.HelmRepositoryURL}}, repositories...)
+	}
+
+	// the same URL is listed once, with the first repository reaching it
+	urls := map[string]bool{}
+	repositories = slices.DeleteFunc(repositories, func(repository portainer.HelmUserRepository) bool {
+		url := NormalizeURL(repository.URL)
+		if urls[url] {
+			return true
+		}
+
+		urls[url] = true
+
+		return false
+	})
+
+	files := make([]*binary.File, len(repositories))
+
+	g := new(errgroup.Group)
+	g.SetLimit(fetchConcurrency)
+
+	for i, repository := range repositories {
+		g.Go(func() error {
+			file, err := service.Index(repository, refresh)
+			if err != nil {
+				log.Warn().Err(err).Str("url", repository.URL).Msg("unable to retrieve the index of the Helm repository")
+
+				return nil
+			}
+
+			files[i] = file
+
+			return nil
+		})
+	}
+
+	_ = g.Wait()
+
+	return mergeIndexes(repositories, files), nil
+}
+
+// mergeIndexes merges the indexes of repositories, the indexes being left untouched since they are cached
+func mergeIndexes(repositories []portainer.HelmUserRepository, files []*binary.File) *binary.File {
+	merged := &binary.File{
+		APIVersion: "v1",
+		Entries:    map[string][]binary.Entry{},
+		Generated:  time.Now().UTC().Format(time.RFC3339),
+	}
+
+	for i, file := range files {
+		if file == nil {
+			continue
+		}
+
+		for name, entries := range file.Entries {
+			for _, entry := range entries {
+				entry.Repo = repositories[i].URL
+				merged.Entries[name] = append(merged.Entries[name], entry)
+			}
+		}
+	}
+
+	return merged
+}
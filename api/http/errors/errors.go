@@ -9,4 +9,6 @@ var (
 	ErrUnauthorized = errors.New("Unauthorized")
 	// ErrResourceAccessDenied Access denied to resource error
 	ErrResourceAccessDenied = errors.New("Access denied to resource")
+	// ErrOperationNotAllowed Operation not allowed by the role of the user on the environment(endpoint) error
+	ErrOperationNotAllowed = errors.New("Operation not allowed by your role on the environment")
 )
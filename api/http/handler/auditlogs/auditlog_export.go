@@ -0,0 +1,99 @@
+package auditlogs
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/request"
+)
+
+var csvHeader = []string{"Id", "Timestamp", "UserId", "Username", "SourceIP", "Method", "Path", "ResourceType", "ResourceID", "EndpointId", "StatusCode", "Payload", "PayloadDiff"}
+
+// @id AuditLogExport
+// @summary Export the audit logs
+// @description Download the audit logs matching the filters as a JSON or CSV file, the most recent first.
+// @description **Access policy**: administrator
+// @tags audit_logs
+// @security ApiKeyAuth
+// @security jwt
+// @produce json,text/csv
+// @param format query string false "Format of the export" Enum("json", "csv")
+// @param userId query int false "Export the calls made by this user"
+// @param endpointId query int false "Export the calls made on this environment(endpoint)"
+// @param resourceType query string false "Export the calls targeting this type of resource"
+// @param resourceId query string false "Export the calls targeting this resource"
+// @param method query string false "Export the calls made with this HTTP method"
+// @param since query int false "Export the calls made after this Unix timestamp"
+// @param until query int false "Export the calls made before this Unix timestamp"
+// @success 200 "Success"
+// @failure 400 "Invalid format"
+// @failure 500 "Server error"
+// @router /audit_logs/export [get]
+func (handler *Handler) auditLogExport(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	format, _ := request.RetrieveQueryParameter(r, "format", true)
+	if format == "" {
+		format = "json"
+	}
+
+	if format != "json" && format != "csv" {
+		return httperror.BadRequest("Invalid format", errors.New("the format must be json or csv"))
+	}
+
+	auditLogs, err := handler.filteredAuditLogs(r)
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve the audit logs from the database", err)
+	}
+
+	filename := fmt.Sprintf("portainer-audit-logs-%s.%s", time.Now().UTC().Format("20060102T150405Z"), format)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+
+	if format == "json" {
+		w.Header().Set("Content-Type", "application/json")
+
+		if err := json.NewEncoder(w).Encode(auditLogs); err != nil {
+			return httperror.InternalServerError("Unable to write the audit logs", err)
+		}
+
+		return nil
+	}
+
+	w.Header().Set("Content-Type", "text/csv")
+
+	writer := csv.NewWriter(w)
+	if err := writer.Write(csvHeader); err != nil {
+		return httperror.InternalServerError("Unable to write the audit logs", err)
+	}
+
+	for _, auditLog := range auditLogs {
+		if err := writer.Write([]string{
+			strconv.Itoa(int(auditLog.ID)),
+			time.Unix(auditLog.Timestamp, 0).UTC().Format(time.RFC3339),
+			strconv.Itoa(int(auditLog.UserID)),
+			auditLog.Username,
+			auditLog.SourceIP,
+			auditLog.Method,
+			auditLog.Path,
+			auditLog.ResourceType,
+			auditLog.ResourceID,
+			strconv.Itoa(int(auditLog.EndpointID)),
+			strconv.Itoa(auditLog.StatusCode),
+			auditLog.Payload,
+			auditLog.PayloadDiff,
+		}); err != nil {
+			return httperror.InternalServerError("Unable to write the audit logs", err)
+		}
+	}
+
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return httperror.InternalServerError("Unable to write the audit logs", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,123 @@
+package auditlogs
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+
+	portainer "github.com/portainer/portainer/api"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/request"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+)
+
+type auditLogQuery struct {
+	userID       portainer.UserID
+	endpointID   portainer.EndpointID
+	resourceType string
+	resourceID   string
+	method       string
+	since        int64
+	until        int64
+}
+
+// @id AuditLogList
+// @summary List the audit logs
+// @description List the records of the state-changing API calls, the most recent first.
+// @description **Access policy**: administrator
+// @tags audit_logs
+// @security ApiKeyAuth
+// @security jwt
+// @produce json
+// @param start query int false "Start searching from"
+// @param limit query int false "Limit results to this value"
+// @param userId query int false "List the calls made by this user"
+// @param endpointId query int false "List the calls made on this environment(endpoint)"
+// @param resourceType query string false "List the calls targeting this type of resource, e.g. stacks or docker/containers"
+// @param resourceId query string false "List the calls targeting this resource"
+// @param method query string false "List the calls made with this HTTP method"
+// @param since query int false "List the calls made after this Unix timestamp"
+// @param until query int false "List the calls made before this Unix timestamp"
+// @success 200 {array} portainer.AuditLog "Success"
+// @failure 500 "Server error"
+// @router /audit_logs [get]
+func (handler *Handler) auditLogList(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	start, _ := request.RetrieveNumericQueryParameter(r, "start", true)
+	if start != 0 {
+		start--
+	}
+
+	limit, _ := request.RetrieveNumericQueryParameter(r, "limit", true)
+
+	auditLogs, err := handler.filteredAuditLogs(r)
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve the audit logs from the database", err)
+	}
+
+	w.Header().Set("X-Total-Count", strconv.Itoa(len(auditLogs)))
+
+	return response.JSON(w, paginate(auditLogs, start, limit))
+}
+
+// filteredAuditLogs returns the audit logs matching the query parameters of a request, the most recent first
+func (handler *Handler) filteredAuditLogs(r *http.Request) ([]portainer.AuditLog, error) {
+	query := parseQuery(r)
+
+	auditLogs, err := handler.DataStore.AuditLog().ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	auditLogs = slices.DeleteFunc(auditLogs, func(auditLog portainer.AuditLog) bool {
+		return !query.matches(auditLog)
+	})
+
+	slices.SortFunc(auditLogs, func(a, b portainer.AuditLog) int {
+		return int(b.ID) - int(a.ID)
+	})
+
+	return auditLogs, nil
+}
+
+func parseQuery(r *http.Request) auditLogQuery {
+	userID, _ := request.RetrieveNumericQueryParameter(r, "userId", true)
+	endpointID, _ := request.RetrieveNumericQueryParameter(r, "endpointId", true)
+	resourceType, _ := request.RetrieveQueryParameter(r, "resourceType", true)
+	resourceID, _ := request.RetrieveQueryParameter(r, "resourceId", true)
+	method, _ := request.RetrieveQueryParameter(r, "method", true)
+	since, _ := request.RetrieveNumericQueryParameter(r, "since", true)
+	until, _ := request.RetrieveNumericQueryParameter(r, "until", true)
+
+	return auditLogQuery{
+		userID:       portainer.UserID(userID),
+		endpointID:   portainer.EndpointID(endpointID),
+		resourceType: resourceType,
+		resourceID:   resourceID,
+		method:       method,
+		since:        int64(since),
+		until:        int64(until),
+	}
+}
+
+func (query auditLogQuery) matches(auditLog portainer.AuditLog) bool {
+	return (query.userID == 0 || auditLog.UserID == query.userID) &&
+		(query.endpointID == 0 || auditLog.EndpointID == query.endpointID) &&
+		(query.resourceType == "" || auditLog.ResourceType == query.resourceType) &&
+		(query.resourceID == "" || auditLog.ResourceID == query.resourceID) &&
+		(query.method == "" || auditLog.Method == query.method) &&
+		(query.since == 0 || auditLog.Timestamp >= query.since) &&
+		(query.until == 0 || auditLog.Timestamp < query.until)
+}
+
+func paginate(auditLogs []portainer.AuditLog, start, limit int) []portainer.AuditLog {
+	if start >= len(auditLogs) {
+		return []portainer.AuditLog{}
+	}
+
+	end := len(auditLogs)
+	if limit > 0 {
+		end = min(start+limit, end)
+	}
+
+	return auditLogs[start:end]
+}
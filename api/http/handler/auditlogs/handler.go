@@ -0,0 +1,32 @@
+package auditlogs
+
+import (
+	"net/http"
+
+	"github.com/portainer/portainer/api/dataservices"
+	"github.com/portainer/portainer/api/http/security"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+
+	"github.com/gorilla/mux"
+)
+
+// Handler is the HTTP handler used to query the audit logs.
+type Handler struct {
+	*mux.Router
+	DataStore dataservices.DataStore
+}
+
+// NewHandler creates a handler to query the audit logs.
+func NewHandler(bouncer security.BouncerService, dataStore dataservices.DataStore) *Handler {
+	h := &Handler{
+		Router:    mux.NewRouter(),
+		DataStore: dataStore,
+	}
+
+	h.Handle("/audit_logs",
+		bouncer.AdminAccess(httperror.LoggerHandler(h.auditLogList))).Methods(http.MethodGet)
+	h.Handle("/audit_logs/export",
+		bouncer.AdminAccess(httperror.LoggerHandler(h.auditLogExport))).Methods(http.MethodGet)
+
+	return h
+}
@@ -1,13 +1,19 @@
 package auth
 
 import (
+	"math"
 	"net/http"
+	"slices"
+	"strconv"
 	"strings"
+	"time"
 
 	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/authprovider"
 	httperrors "github.com/portainer/portainer/api/http/errors"
 	"github.com/portainer/portainer/api/http/security"
 	"github.com/portainer/portainer/api/internal/authorization"
+	"github.com/portainer/portainer/api/totp"
 	httperror "github.com/portainer/portainer/pkg/libhttp/error"
 	"github.com/portainer/portainer/pkg/libhttp/request"
 	"github.com/portainer/portainer/pkg/libhttp/response"
@@ -16,16 +22,40 @@ import (
 	"github.com/rs/zerolog/log"
 )
 
+var (
+	errLockedOut           = errors.New("too many failed logins")
+	errServiceAccountLogin = errors.New("service accounts cannot log in, they are authenticated by their API keys")
+	// errImpersonationNotAllowed prevents the time-limited impersonation tokens from being turned into sessions
+	errImpersonationNotAllowed = errors.New("This operation is not allowed while impersonating a user")
+	// errAPIKeyNotAllowed prevents the API keys from being turned into sessions
+	errAPIKeyNotAllowed = errors.New("This operation is not allowed with an API key")
+	// errPasswordChangeRequired prevents the users who must change their password from handing out sessions
+	errPasswordChangeRequired = errors.New("The password must be changed first")
+	// errInstanceURLNotConfigured is returned when a link to Portainer is needed but the instance URL is not set
+	errInstanceURLNotConfigured = errors.New("The instance URL is not configured")
+)
+
 type authenticatePayload struct {
 	// Username
 	Username string `example:"admin" validate:"required"`
 	// Password
 	Password string `example:"mypassword" validate:"required"`
+	// Authentication method to use, 1 for internal, 2 for LDAP or a registered password provider. When omitted, the method the user is bound to
+	// is used, otherwise every enabled method is tried starting with the default one
+	Provider portainer.AuthenticationMethod `example:"2"`
 }
 
 type authenticateResponse struct {
 	// JWT token used to authenticate against the API
-	JWT string `json:"jwt" example:"abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789abcdefghijklmnopqrstuvwxyzAB"`
+	JWT string `json:"jwt,omitempty" example:"abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789abcdefghijklmnopqrstuvwxyzAB"`
+	// Whether the user must provide its second factor to /auth/2fa/verify, the JWT is then only returned by it
+	TwoFactorRequired bool `json:"twoFactorRequired,omitempty" example:"false"`
+	// Whether the user must enroll a second factor through /auth/2fa/enroll before verifying it
+	TwoFactorEnrollmentRequired bool `json:"twoFactorEnrollmentRequired,omitempty" example:"false"`
+	// Short-lived token identifying the pending two-factor authentication
+	TwoFactorToken string `json:"twoFactorToken,omitempty"`
+	// Second factors the user can use, totp or webauthn
+	TwoFactorMethods []string `json:"twoFactorMethods,omitempty" example:"totp,webauthn"`
 }
 
 func (payload *authenticatePayload) Validate(r *http.Request) error {
@@ -37,6 +67,10 @@ func (payload *authenticatePayload) Validate(r *http.Request) error {
 		return errors.New("Invalid password")
 	}
 
+	if payload.Provider != 0 && !authprovider.IsPasswordMethod(payload.Provider) {
+		return errors.New("Invalid provider. Value must be 1 (internal), 2 (LDAP/AD) or a registered password provider")
+	}
+
 	return nil
 }
 
@@ -44,6 +78,7 @@ func (payload *authenticatePayload) Validate(r *http.Request) error {
 // @summary Authenticate
 // @description **Access policy**: public
 // @description Use this environment(endpoint) to authenticate against Portainer using a username and password.
+// @description When the user has to provide a second factor, no JWT is returned and the login continues with /auth/2fa/verify.
 // @tags auth
 // @accept json
 // @produce json
@@ -51,6 +86,7 @@ func (payload *authenticatePayload) Validate(r *http.Request) error {
 // @success 200 {object} authenticateResponse "Success"
 // @failure 400 "Invalid request"
 // @failure 422 "Invalid Credentials"
+// @failure 429 "Too many failed logins, the username or the IP address is locked out"
 // @failure 500 "Server error"
 // @router /auth [post]
 func (handler *Handler) authenticate(rw http.ResponseWriter, r *http.Request) *httperror.HandlerError {
@@ -59,6 +95,35 @@ func (handler *Handler) authenticate(rw http.ResponseWriter, r *http.Request) *h
 		return httperror.BadRequest("Invalid request payload", err)
 	}
 
+	ipAddress := security.StripAddrPort(r.RemoteAddr)
+
+	remaining, err := handler.LockoutService.Check(payload.Username, ipAddress)
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve the failed logins from the database", err)
+	} else if remaining > 0 {
+		rw.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(remaining.Seconds()))))
+
+		return httperror.NewError(http.StatusTooManyRequests, "Too many failed logins, try again later", errLockedOut)
+	}
+
+	httpErr := handler.authenticatePassword(rw, r, &payload)
+
+	switch {
+	case httpErr == nil:
+		err = handler.LockoutService.RecordSuccess(payload.Username)
+	case httpErr.StatusCode == http.StatusUnprocessableEntity:
+		err = handler.LockoutService.RecordFailure(payload.Username, ipAddress)
+	}
+
+	if err != nil {
+		log.Warn().Err(err).Msg("unable to record the login attempt")
+	}
+
+	return httpErr
+}
+
+// authenticatePassword authenticates the user with its password against the enabled methods, then writes its token
+func (handler *Handler) authenticatePassword(rw http.ResponseWriter, r *http.Request, payload *authenticatePayload) *httperror.HandlerError {
 	settings, err := handler.DataStore.Settings().Settings()
 	if err != nil {
 		return httperror.InternalServerError("Unable to retrieve settings from the database", err)
@@ -70,83 +135,265 @@ func (handler *Handler) authenticate(rw http.ResponseWriter, r *http.Request) *h
 			return httperror.InternalServerError("Unable to retrieve a user with the specified username from the database", err)
 		}
 
-		if settings.AuthenticationMethod == portainer.AuthenticationInternal ||
-			settings.AuthenticationMethod == portainer.AuthenticationOAuth ||
-			(settings.AuthenticationMethod == portainer.AuthenticationLDAP && !settings.LDAPSettings.AutoCreateUsers) {
-			// avoid username enumeration timing attack by creating a fake user
-			// https://en.wikipedia.org/wiki/Timing_attack
-			user = &portainer.User{
-				Username: "portainer-fake-username",
-				Password: "$2a$10$abcdefghijklmnopqrstuvwx..ABCDEFGHIJKLMNOPQRSTUVWXYZ12", // fake but valid format bcrypt hash
-			}
+		user = nil
+	}
+
+	if httpErr := rejectServiceAccount(user); httpErr != nil {
+		return httpErr
+	}
+
+	if user != nil && isUserInitialAdmin(user) {
+		forceChangePassword, httpErr := handler.authenticateInternal(user, payload.Password)
+		if httpErr != nil {
+			return httpErr
+		}
+
+		return handler.writePasswordToken(rw, r, user, forceChangePassword)
+	}
+
+	methods := passwordAuthenticationMethods(settings, user, payload.Provider)
+	if len(methods) == 0 {
+		if settings.AuthenticationMethod == portainer.AuthenticationOAuth {
+			return httperror.NewError(http.StatusUnprocessableEntity, "Only initial admin is allowed to login without oauth", httperrors.ErrUnauthorized)
 		}
+
+		if settings.AuthenticationMethod == portainer.AuthenticationSAML {
+			return httperror.NewError(http.StatusUnprocessableEntity, "Only initial admin is allowed to login without SAML", httperrors.ErrUnauthorized)
+		}
+
+		return httperror.NewError(http.StatusUnprocessableEntity, "Login method is not supported", httperrors.ErrUnauthorized)
 	}
 
-	if user != nil && isUserInitialAdmin(user) || settings.AuthenticationMethod == portainer.AuthenticationInternal {
-		return handler.authenticateInternal(rw, user, payload.Password)
+	// the providers are tried in order, the first one accepting the credentials authenticates the user
+	var httpErr *httperror.HandlerError
+	for _, method := range methods {
+		if method == portainer.AuthenticationInternal {
+			var forceChangePassword bool
+			if forceChangePassword, httpErr = handler.authenticateInternal(user, payload.Password); httpErr == nil {
+				return handler.writePasswordTokenForMethod(rw, r, user, method, forceChangePassword)
+			}
+		} else {
+			var providerUser *portainer.User
+			if providerUser, httpErr = handler.authenticateWithProvider(method, user, payload.Username, payload.Password); httpErr == nil {
+				return handler.writePasswordTokenForMethod(rw, r, providerUser, method, false)
+			}
+		}
+
+		if httpErr.StatusCode != http.StatusUnprocessableEntity {
+			return httpErr
+		}
 	}
 
-	if settings.AuthenticationMethod == portainer.AuthenticationOAuth {
-		return httperror.NewError(http.StatusUnprocessableEntity, "Only initial admin is allowed to login without oauth", httperrors.ErrUnauthorized)
+	return httpErr
+}
+
+// passwordAuthenticationMethods returns the enabled methods able to authenticate a user with a password, in the order
+// they must be tried. An existing user can only use the method it is bound to, or the default one when it is unbound
+func passwordAuthenticationMethods(settings *portainer.Settings, user *portainer.User, provider portainer.AuthenticationMethod) []portainer.AuthenticationMethod {
+	var candidates []portainer.AuthenticationMethod
+
+	switch {
+	case user != nil:
+		method := settings.UserAuthenticationMethod(user)
+		if provider != 0 && provider != method {
+			return nil
+		}
+
+		candidates = []portainer.AuthenticationMethod{method}
+	case provider != 0:
+		candidates = []portainer.AuthenticationMethod{provider}
+	default:
+		candidates = []portainer.AuthenticationMethod{settings.AuthenticationMethod, portainer.AuthenticationInternal, portainer.AuthenticationLDAP}
+		for _, provider := range authprovider.Providers() {
+			candidates = append(candidates, provider.Info().Method)
+		}
 	}
 
-	if settings.AuthenticationMethod == portainer.AuthenticationLDAP {
-		return handler.authenticateLDAP(rw, user, payload.Username, payload.Password, &settings.LDAPSettings)
+	var methods []portainer.AuthenticationMethod
+	for _, method := range candidates {
+		if !authprovider.IsPasswordMethod(method) {
+			continue
+		}
+
+		if !settings.IsAuthenticationMethodEnabled(method) || slices.Contains(methods, method) {
+			continue
+		}
+
+		methods = append(methods, method)
 	}
 
-	return httperror.NewError(http.StatusUnprocessableEntity, "Login method is not supported", httperrors.ErrUnauthorized)
+	return methods
 }
 
 func isUserInitialAdmin(user *portainer.User) bool {
 	return int(user.ID) == 1
 }
 
-func (handler *Handler) authenticateInternal(w http.ResponseWriter, user *portainer.User, password string) *httperror.HandlerError {
+func (handler *Handler) authenticateInternal(user *portainer.User, password string) (bool, *httperror.HandlerError) {
+	if user == nil {
+		// avoid username enumeration timing attack by comparing against a fake user
+		// https://en.wikipedia.org/wiki/Timing_attack
+		user = &portainer.User{
+			Username: "portainer-fake-username",
+			Password: "$2a$10$abcdefghijklmnopqrstuvwx..ABCDEFGHIJKLMNOPQRSTUVWXYZ12", // fake but valid format bcrypt hash
+		}
+	}
+
 	if err := handler.CryptoService.CompareHashAndData(user.Password, password); err != nil {
-		return httperror.NewError(http.StatusUnprocessableEntity, "Invalid credentials", httperrors.ErrUnauthorized)
+		return false, httperror.NewError(http.StatusUnprocessableEntity, "Invalid credentials", httperrors.ErrUnauthorized)
+	}
+
+	// the age of the passwords set before it was tracked starts at their next login
+	if user.PasswordUpdatedAt == 0 {
+		user.PasswordUpdatedAt = time.Now().Unix()
+
+		if err := handler.DataStore.User().Update(user.ID, user); err != nil {
+			return false, httperror.InternalServerError("Unable to persist user changes inside the database", err)
+		}
+	}
+
+	settings, err := handler.DataStore.Settings().Settings()
+	if err != nil {
+		return false, httperror.InternalServerError("Unable to retrieve settings from the database", err)
 	}
 
-	forceChangePassword := !handler.passwordStrengthChecker.Check(password)
+	expired := security.IsPasswordExpired(&settings.InternalAuthSettings.PasswordPolicy, user)
 
-	return handler.writeToken(w, user, forceChangePassword)
+	return expired || !handler.passwordStrengthChecker.Check(password), nil
 }
 
-func (handler *Handler) authenticateLDAP(w http.ResponseWriter, user *portainer.User, username, password string, ldapSettings *portainer.LDAPSettings) *httperror.HandlerError {
-	if err := handler.LDAPService.AuthenticateUser(username, password, ldapSettings); err != nil {
-		if errors.Is(err, httperrors.ErrUnauthorized) {
-			return httperror.NewError(http.StatusUnprocessableEntity, "Invalid credentials", httperrors.ErrUnauthorized)
+// authenticateWithProvider authenticates the user against a registered provider, unknown users are created when
+// the provider allows it
+func (handler *Handler) authenticateWithProvider(method portainer.AuthenticationMethod, user *portainer.User, username, password string) (*portainer.User, *httperror.HandlerError) {
+	provider, ok := authprovider.Lookup(method)
+	if !ok {
+		return nil, httperror.NewError(http.StatusUnprocessableEntity, "Login method is not supported", httperrors.ErrUnauthorized)
+	}
+
+	info := provider.Info()
+
+	if err := provider.Authenticate(username, password); err != nil {
+		if errors.Is(err, authprovider.ErrInvalidCredentials) {
+			return nil, httperror.NewError(http.StatusUnprocessableEntity, "Invalid credentials", httperrors.ErrUnauthorized)
 		}
 
-		return httperror.InternalServerError("Unable to authenticate user against LDAP", err)
+		return nil, httperror.InternalServerError("Unable to authenticate user against "+info.Name, err)
 	}
 
 	if user == nil {
+		if !info.Capabilities.AutoCreateUsers {
+			return nil, httperror.NewError(http.StatusUnprocessableEntity, "Account not created beforehand in Portainer and automatic user provisioning not enabled", httperrors.ErrUnauthorized)
+		}
+
 		user = &portainer.User{
 			Username:                username,
 			Role:                    portainer.StandardUserRole,
 			PortainerAuthorizations: authorization.DefaultPortainerAuthorizations(),
+			AuthenticationMethod:    method,
 		}
 
 		if err := handler.DataStore.User().Create(user); err != nil {
-			return httperror.InternalServerError("Unable to persist user inside the database", err)
+			return nil, httperror.InternalServerError("Unable to persist user inside the database", err)
+		}
+	}
+
+	if info.Capabilities.GroupSync {
+		if err := handler.syncUserTeamsWithProviderGroups(user, provider); err != nil {
+			log.Warn().Err(err).Str("provider", info.Name).Msg("unable to automatically sync user teams")
+		}
+	}
+
+	return user, nil
+}
+
+// writeTokenForMethod binds the user to the method it authenticated with, then writes its token
+func (handler *Handler) writeTokenForMethod(w http.ResponseWriter, r *http.Request, user *portainer.User, method portainer.AuthenticationMethod, forceChangePassword bool) *httperror.HandlerError {
+	if err := handler.bindAuthenticationMethod(user, method); err != nil {
+		return httperror.InternalServerError("Unable to persist user changes inside the database", err)
+	}
+
+	return handler.writeToken(w, r, user, forceChangePassword)
+}
+
+// writePasswordTokenForMethod binds the user to the method it authenticated with, then writes its token
+// or starts its two-factor authentication
+func (handler *Handler) writePasswordTokenForMethod(w http.ResponseWriter, r *http.Request, user *portainer.User, method portainer.AuthenticationMethod, forceChangePassword bool) *httperror.HandlerError {
+	if err := handler.bindAuthenticationMethod(user, method); err != nil {
+		return httperror.InternalServerError("Unable to persist user changes inside the database", err)
+	}
+
+	return handler.writePasswordToken(w, r, user, forceChangePassword)
+}
+
+// writePasswordToken writes the token of a user whose password was verified. When the user enabled a second
+// factor, a TOTP or a security key, or the settings enforce one, only the token of the pending two-factor
+// authentication is returned
+func (handler *Handler) writePasswordToken(w http.ResponseWriter, r *http.Request, user *portainer.User, forceChangePassword bool) *httperror.HandlerError {
+	settings, err := handler.DataStore.Settings().Settings()
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve settings from the database", err)
+	}
+
+	var methods []string
+
+	totpEnabled, err := totp.IsEnabled(handler.DataStore, user.ID)
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve the two-factor authentication of the user from the database", err)
+	} else if totpEnabled {
+		methods = append(methods, "totp")
+	}
+
+	if settings.WebAuthnSettings.Enabled {
+		credentials, err := handler.DataStore.WebAuthnCredential().CredentialsByUserID(user.ID)
+		if err != nil {
+			return httperror.InternalServerError("Unable to retrieve the WebAuthn credentials of the user from the database", err)
+		} else if len(credentials) > 0 {
+			methods = append(methods, "webauthn")
 		}
 	}
 
-	if err := handler.syncUserTeamsWithLDAPGroups(user, ldapSettings); err != nil {
-		log.Warn().Err(err).Msg("unable to automatically sync user teams with ldap")
+	enabled := len(methods) > 0
+	if !enabled && !totp.IsEnforced(settings, user) {
+		return handler.writeToken(w, r, user, forceChangePassword)
+	}
+
+	token, _, err := handler.JWTService.GenerateTwoFactorToken(composeTokenData(user, forceChangePassword))
+	if err != nil {
+		return httperror.InternalServerError("Unable to generate the two-factor authentication token", err)
 	}
 
-	return handler.writeToken(w, user, false)
+	return response.JSON(w, &authenticateResponse{
+		TwoFactorRequired:           true,
+		TwoFactorEnrollmentRequired: !enabled,
+		TwoFactorToken:              token,
+		TwoFactorMethods:            methods,
+	})
+}
+
+// bindAuthenticationMethod binds an unbound user to an authentication method, so that it cannot be
+// impersonated through another provider exposing the same username
+func (handler *Handler) bindAuthenticationMethod(user *portainer.User, method portainer.AuthenticationMethod) error {
+	if user.AuthenticationMethod != 0 || isUserInitialAdmin(user) {
+		return nil
+	}
+
+	user.AuthenticationMethod = method
+
+	return handler.DataStore.User().Update(user.ID, user)
 }
 
-func (handler *Handler) writeToken(w http.ResponseWriter, user *portainer.User, forceChangePassword bool) *httperror.HandlerError {
+func (handler *Handler) writeToken(w http.ResponseWriter, r *http.Request, user *portainer.User, forceChangePassword bool) *httperror.HandlerError {
+	if httpErr := rejectServiceAccount(user); httpErr != nil {
+		return httpErr
+	}
+
 	tokenData := composeTokenData(user, forceChangePassword)
 
-	return handler.persistAndWriteToken(w, tokenData)
+	return handler.persistAndWriteToken(w, r, tokenData)
 }
 
-func (handler *Handler) persistAndWriteToken(w http.ResponseWriter, tokenData *portainer.TokenData) *httperror.HandlerError {
-	token, expirationTime, err := handler.JWTService.GenerateToken(tokenData)
+func (handler *Handler) persistAndWriteToken(w http.ResponseWriter, r *http.Request, tokenData *portainer.TokenData) *httperror.HandlerError {
+	token, expirationTime, err := handler.generateSessionToken(r, tokenData)
 	if err != nil {
 		return httperror.InternalServerError("Unable to generate JWT token", err)
 	}
@@ -156,18 +403,28 @@ func (handler *Handler) persistAndWriteToken(w http.ResponseWriter, tokenData *p
 	return response.JSON(w, &authenticateResponse{JWT: token})
 }
 
-func (handler *Handler) syncUserTeamsWithLDAPGroups(user *portainer.User, settings *portainer.LDAPSettings) error {
-	// only sync if there is a group base DN
-	if len(settings.GroupSearchSettings) == 0 || len(settings.GroupSearchSettings[0].GroupBaseDN) == 0 {
+// rejectServiceAccount prevents the service accounts from opening a session, whatever the method they authenticate
+// with
+func rejectServiceAccount(user *portainer.User) *httperror.HandlerError {
+	if user == nil || !user.ServiceAccount {
 		return nil
 	}
 
+	return httperror.Forbidden("Service accounts cannot log in", errServiceAccountLogin)
+}
+
+// generateSessionToken generates the token of a new session, recording the client it is opened from
+func (handler *Handler) generateSessionToken(r *http.Request, tokenData *portainer.TokenData) (string, time.Time, error) {
+	return handler.JWTService.GenerateSessionToken(tokenData, security.StripAddrPort(r.RemoteAddr), r.UserAgent())
+}
+
+func (handler *Handler) syncUserTeamsWithProviderGroups(user *portainer.User, provider authprovider.Provider) error {
 	teams, err := handler.DataStore.Team().ReadAll()
 	if err != nil {
 		return err
 	}
 
-	userGroups, err := handler.LDAPService.GetUserGroups(user.Username, settings)
+	userGroups, err := provider.GetUserGroups(user.Username)
 	if err != nil {
 		return err
 	}
@@ -196,8 +453,8 @@ func (handler *Handler) syncUserTeamsWithLDAPGroups(user *portainer.User, settin
 	return nil
 }
 
-func teamExists(teamName string, ldapGroups []string) bool {
-	for _, group := range ldapGroups {
+func teamExists(teamName string, groups []string) bool {
+	for _, group := range groups {
 		if strings.EqualFold(group, teamName) {
 			return true
 		}
@@ -0,0 +1,252 @@
+package auth
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/devicecode"
+	"github.com/portainer/portainer/api/http/security"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/request"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	deviceCodeGrantType = "urn:ietf:params:oauth:grant-type:device_code"
+	// deviceVerificationPath is the page of the web interface where the users enter the user code
+	deviceVerificationPath = "/#!/auth/device"
+)
+
+type deviceAuthorizationResponse struct {
+	DeviceCode              string `json:"device_code"`
+	UserCode                string `json:"user_code" example:"BCDF-GHJK"`
+	VerificationURI         string `json:"verification_uri" example:"https://portainer.mydomain.tld/#!/auth/device"`
+	VerificationURIComplete string `json:"verification_uri_complete" example:"https://portainer.mydomain.tld/#!/auth/device?user_code=BCDF-GHJK"`
+	// Lifetime of the device code in seconds
+	ExpiresIn int `json:"expires_in" example:"600"`
+	// Minimum number of seconds to wait between two polls of the token endpoint
+	Interval int `json:"interval" example:"5"`
+}
+
+type deviceTokenPayload struct {
+	GrantType  string `json:"grant_type" example:"urn:ietf:params:oauth:grant-type:device_code"`
+	DeviceCode string `json:"device_code"`
+}
+
+type deviceTokenResponse struct {
+	// JWT token used to authenticate against the API
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type" example:"Bearer"`
+	// Lifetime of the token in seconds
+	ExpiresIn int `json:"expires_in" example:"28800"`
+	// JWT token, same as AccessToken for consistency with the other authentication endpoints
+	JWT string `json:"jwt"`
+}
+
+type deviceTokenErrorResponse struct {
+	Error string `json:"error" example:"authorization_pending"`
+}
+
+type deviceVerifyPayload struct {
+	// User code displayed by the device
+	UserCode string `validate:"required" example:"BCDF-GHJK"`
+	// Reject the authorization instead of approving it
+	Deny bool `example:"false"`
+}
+
+func (payload *deviceTokenPayload) Validate(r *http.Request) error {
+	return nil
+}
+
+func (payload *deviceVerifyPayload) Validate(r *http.Request) error {
+	if strings.TrimSpace(payload.UserCode) == "" {
+		return errors.New("Invalid user code")
+	}
+
+	return nil
+}
+
+// @id DeviceAuthorization
+// @summary Start a device authorization
+// @description Starts the device authorization grant (RFC 8628) used by scripts and command line tools to obtain a token.
+// @description The user must open the verification URI and approve the user code, while the device polls the token endpoint.
+// @description The verification URI is built from the instance URL of the settings, which must be configured.
+// @description **Access policy**: public
+// @tags auth
+// @produce json
+// @success 200 {object} deviceAuthorizationResponse "Success"
+// @failure 429 "Too many pending authorizations"
+// @failure 500 "Server error"
+// @failure 503 "The instance URL is not configured"
+// @router /auth/device/authorize [post]
+func (handler *Handler) deviceAuthorize(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	settings, err := handler.DataStore.Settings().Settings()
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve the settings from the database", err)
+	}
+
+	if settings.InstanceURL == "" {
+		return httperror.NewError(http.StatusServiceUnavailable, "The instance URL must be configured in the settings to authorize devices", errInstanceURLNotConfigured)
+	}
+
+	authorization, err := handler.DeviceCodeService.Create()
+	if errors.Is(err, devicecode.ErrTooManyRequests) {
+		return httperror.NewError(http.StatusTooManyRequests, "Too many pending device authorizations", err)
+	} else if err != nil {
+		return httperror.InternalServerError("Unable to create the device authorization", err)
+	}
+
+	verificationURI := instanceURL(settings) + deviceVerificationPath
+
+	return response.JSON(w, &deviceAuthorizationResponse{
+		DeviceCode:              authorization.DeviceCode,
+		UserCode:                authorization.UserCode,
+		VerificationURI:         verificationURI,
+		VerificationURIComplete: verificationURI + "?user_code=" + authorization.UserCode,
+		ExpiresIn:               int(time.Until(authorization.ExpiresAt).Seconds()),
+		Interval:                int(authorization.Interval.Seconds()),
+	})
+}
+
+// @id DeviceAuthorizationVerify
+// @summary Approve or deny a device authorization
+// @description Grants the device displaying the user code a token of the current user, or rejects its request.
+// @description **Access policy**: authenticated
+// @tags auth
+// @security ApiKeyAuth
+// @security jwt
+// @accept json
+// @param body body deviceVerifyPayload true "User code"
+// @success 204 "Success"
+// @failure 400 "Invalid request"
+// @failure 403 "Not allowed while impersonating a user or before changing the password"
+// @failure 404 "Unknown or expired user code"
+// @failure 500 "Server error"
+// @router /auth/device/verify [post]
+func (handler *Handler) deviceVerify(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	var payload deviceVerifyPayload
+	if err := request.DecodeAndValidateJSONPayload(r, &payload); err != nil {
+		return httperror.BadRequest("Invalid request payload", err)
+	}
+
+	tokenData, err := security.RetrieveTokenData(r)
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve user authentication token", err)
+	}
+
+	if tokenData.ImpersonatorID != 0 {
+		return httperror.Forbidden(errImpersonationNotAllowed.Error(), errImpersonationNotAllowed)
+	}
+
+	// the callers authenticated by an API key have no session to hand over to the device
+	if tokenData.Token == "" {
+		return httperror.Forbidden("Only a session can approve a device", errAPIKeyNotAllowed)
+	}
+
+	// the token of the device is a regular session, it must not bypass the password change of the approving user
+	if tokenData.ForceChangePassword && !payload.Deny {
+		return httperror.Forbidden("The password must be changed before approving a device", errPasswordChangeRequired)
+	}
+
+	if payload.Deny {
+		err = handler.DeviceCodeService.Deny(payload.UserCode)
+	} else {
+		err = handler.DeviceCodeService.Approve(payload.UserCode, tokenData.ID)
+	}
+
+	if errors.Is(err, devicecode.ErrInvalidUserCode) {
+		return httperror.NotFound("Unknown or expired user code", err)
+	} else if err != nil {
+		return httperror.InternalServerError("Unable to update the device authorization", err)
+	}
+
+	log.Info().
+		Str("username", tokenData.Username).
+		Bool("denied", payload.Deny).
+		Msg("device authorization resolved")
+
+	return response.Empty(w)
+}
+
+// @id DeviceAuthorizationToken
+// @summary Exchange a device code for a token
+// @description Polled by the device until the user approved or denied the authorization. The errors follow RFC 8628,
+// @description the device must wait for the interval between two polls and increase it when told to slow down.
+// @description **Access policy**: public
+// @tags auth
+// @accept json,x-www-form-urlencoded
+// @produce json
+// @param body body deviceTokenPayload true "Device code"
+// @success 200 {object} deviceTokenResponse "Success"
+// @failure 400 {object} deviceTokenErrorResponse "Authorization pending, denied or expired"
+// @failure 500 "Server error"
+// @router /auth/device/token [post]
+func (handler *Handler) deviceToken(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	var payload deviceTokenPayload
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "application/x-www-form-urlencoded") {
+		if err := r.ParseForm(); err != nil {
+			return deviceTokenError(w, "invalid_request")
+		}
+
+		payload.GrantType = r.PostForm.Get("grant_type")
+		payload.DeviceCode = r.PostForm.Get("device_code")
+	} else if err := request.DecodeAndValidateJSONPayload(r, &payload); err != nil {
+		return deviceTokenError(w, "invalid_request")
+	}
+
+	if payload.GrantType != deviceCodeGrantType {
+		return deviceTokenError(w, "unsupported_grant_type")
+	}
+
+	if payload.DeviceCode == "" {
+		return deviceTokenError(w, "invalid_request")
+	}
+
+	userID, err := handler.DeviceCodeService.Poll(payload.DeviceCode)
+	switch {
+	case errors.Is(err, devicecode.ErrAuthorizationPending),
+		errors.Is(err, devicecode.ErrSlowDown),
+		errors.Is(err, devicecode.ErrAccessDenied),
+		errors.Is(err, devicecode.ErrExpiredToken):
+		return deviceTokenError(w, err.Error())
+	case err != nil:
+		return httperror.InternalServerError("Unable to retrieve the device authorization", err)
+	}
+
+	user, err := handler.DataStore.User().Read(userID)
+	if handler.DataStore.IsErrObjectNotFound(err) || (err == nil && user.ServiceAccount) {
+		return deviceTokenError(w, devicecode.ErrAccessDenied.Error())
+	} else if err != nil {
+		return httperror.InternalServerError("Unable to retrieve a user with the specified identifier inside the database", err)
+	}
+
+	token, expirationTime, err := handler.generateSessionToken(r, composeTokenData(user, false))
+	if err != nil {
+		return httperror.InternalServerError("Unable to generate JWT token", err)
+	}
+
+	log.Info().
+		Str("username", user.Username).
+		Msg("token issued through a device authorization")
+
+	return response.JSON(w, &deviceTokenResponse{
+		AccessToken: token,
+		TokenType:   "Bearer",
+		ExpiresIn:   int(time.Until(expirationTime).Seconds()),
+		JWT:         token,
+	})
+}
+
+func deviceTokenError(w http.ResponseWriter, code string) *httperror.HandlerError {
+	return response.JSONWithStatus(w, &deviceTokenErrorResponse{Error: code}, http.StatusBadRequest)
+}
+
+// instanceURL returns the configured URL the users reach Portainer at, without its trailing slash
+func instanceURL(settings *portainer.Settings) string {
+	return strings.TrimSuffix(settings.InstanceURL, "/")
+}
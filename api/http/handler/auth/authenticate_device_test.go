@@ -0,0 +1,143 @@
+package auth
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/devicecode"
+	"github.com/portainer/portainer/api/internal/testhelpers"
+
+	"github.com/segmentio/encoding/json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeviceAuthorize_VerificationURI(t *testing.T) {
+	h, services := setupHandler(t)
+
+	authorize := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/auth/device/authorize", nil)
+		req.Host = "attacker.example.com"
+		req.Header.Set("X-Forwarded-Proto", "http")
+
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+
+		return rr
+	}
+
+	t.Run("instance URL not configured", func(t *testing.T) {
+		require.Equal(t, http.StatusServiceUnavailable, authorize().Code)
+	})
+
+	t.Run("built from the instance URL", func(t *testing.T) {
+		settings, err := services.store.Settings().Settings()
+		require.NoError(t, err)
+
+		settings.InstanceURL = "https://portainer.example.com/"
+		require.NoError(t, services.store.Settings().UpdateSettings(settings))
+
+		rr := authorize()
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		var resp deviceAuthorizationResponse
+		require.NoError(t, json.NewDecoder(rr.Body).Decode(&resp))
+
+		assert.Equal(t, "https://portainer.example.com/#!/auth/device", resp.VerificationURI)
+		assert.Equal(t, resp.VerificationURI+"?user_code="+resp.UserCode, resp.VerificationURIComplete)
+	})
+}
+
+func TestDeviceVerify_ForceChangePassword(t *testing.T) {
+	h, services := setupHandler(t)
+
+	user := &portainer.User{ID: 2, Username: "standard", Role: portainer.StandardUserRole}
+	require.NoError(t, services.store.User().Create(user))
+
+	authorization, err := h.DeviceCodeService.Create()
+	require.NoError(t, err)
+
+	token, _, err := services.jwtService.GenerateToken(&portainer.TokenData{ID: user.ID, Username: user.Username, Role: user.Role, ForceChangePassword: true})
+	require.NoError(t, err)
+
+	verify := func(deny bool) int {
+		payload, err := json.Marshal(deviceVerifyPayload{UserCode: authorization.UserCode, Deny: deny})
+		require.NoError(t, err)
+
+		req := httptest.NewRequest(http.MethodPost, "/auth/device/verify", bytes.NewReader(payload))
+		testhelpers.AddTestSecurityCookie(req, token)
+
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+
+		return rr.Code
+	}
+
+	require.Equal(t, http.StatusForbidden, verify(false))
+
+	_, err = h.DeviceCodeService.Poll(authorization.DeviceCode)
+	require.ErrorIs(t, err, devicecode.ErrAuthorizationPending)
+
+	// the request can still be rejected
+	require.Equal(t, http.StatusNoContent, verify(true))
+}
+
+func TestDeviceVerify_Impersonation(t *testing.T) {
+	h, services := setupHandler(t)
+
+	user := &portainer.User{ID: 2, Username: "standard", Role: portainer.StandardUserRole}
+	require.NoError(t, services.store.User().Create(user))
+
+	authorization, err := h.DeviceCodeService.Create()
+	require.NoError(t, err)
+
+	token, _, err := services.jwtService.GenerateImpersonationToken(&portainer.TokenData{ID: user.ID, Username: user.Username, Role: user.Role, ImpersonatorID: 1}, 15*time.Minute, "", "")
+	require.NoError(t, err)
+
+	payload, err := json.Marshal(deviceVerifyPayload{UserCode: authorization.UserCode})
+	require.NoError(t, err)
+
+	req := httptest.NewRequest(http.MethodPost, "/auth/device/verify", bytes.NewReader(payload))
+	testhelpers.AddTestSecurityCookie(req, token)
+
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusForbidden, rr.Code)
+
+	// the authorization is still pending, the impersonated user did not approve it
+	_, err = h.DeviceCodeService.Poll(authorization.DeviceCode)
+	require.Error(t, err)
+}
+
+func TestDeviceVerify_APIKey(t *testing.T) {
+	h, services := setupHandler(t)
+
+	user := &portainer.User{ID: 2, Username: "standard", Role: portainer.StandardUserRole}
+	require.NoError(t, services.store.User().Create(user))
+
+	authorization, err := h.DeviceCodeService.Create()
+	require.NoError(t, err)
+
+	rawAPIKey, _, err := services.apiKeyService.GenerateApiKey(*user, "test")
+	require.NoError(t, err)
+
+	payload, err := json.Marshal(deviceVerifyPayload{UserCode: authorization.UserCode})
+	require.NoError(t, err)
+
+	req := httptest.NewRequest(http.MethodPost, "/auth/device/verify", bytes.NewReader(payload))
+	req.Header.Set("X-API-KEY", rawAPIKey)
+
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusForbidden, rr.Code)
+
+	// the authorization is still pending, the API key did not approve it
+	_, err = h.DeviceCodeService.Poll(authorization.DeviceCode)
+	require.ErrorIs(t, err, devicecode.ErrAuthorizationPending)
+}
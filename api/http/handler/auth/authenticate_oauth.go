@@ -5,7 +5,9 @@ import (
 	"net/http"
 
 	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
 	httperrors "github.com/portainer/portainer/api/http/errors"
+	"github.com/portainer/portainer/api/oauth"
 	httperror "github.com/portainer/portainer/pkg/libhttp/error"
 	"github.com/portainer/portainer/pkg/libhttp/request"
 
@@ -25,21 +27,16 @@ func (payload *oauthPayload) Validate(r *http.Request) error {
 	return nil
 }
 
-func (handler *Handler) authenticateOAuth(code string, settings *portainer.OAuthSettings) (string, error) {
+func (handler *Handler) authenticateOAuth(code string, settings *portainer.OAuthSettings) (*portainer.OAuthIdentity, error) {
 	if code == "" {
-		return "", errors.New("Invalid OAuth authorization code")
+		return nil, errors.New("Invalid OAuth authorization code")
 	}
 
 	if settings == nil {
-		return "", errors.New("Invalid OAuth configuration")
+		return nil, errors.New("Invalid OAuth configuration")
 	}
 
-	username, err := handler.OAuthService.Authenticate(code, settings)
-	if err != nil {
-		return "", err
-	}
-
-	return username, nil
+	return handler.OAuthService.AuthenticateIdentity(code, settings)
 }
 
 // @id ValidateOAuth
@@ -66,30 +63,41 @@ func (handler *Handler) validateOAuth(w http.ResponseWriter, r *http.Request) *h
 		return httperror.InternalServerError("Unable to retrieve settings from the database", err)
 	}
 
-	if settings.AuthenticationMethod != portainer.AuthenticationOAuth {
+	if !settings.IsAuthenticationMethodEnabled(portainer.AuthenticationOAuth) {
 		return httperror.Forbidden("OAuth authentication is not enabled", errors.New("OAuth authentication is not enabled"))
 	}
 
-	username, err := handler.authenticateOAuth(payload.Code, &settings.OAuthSettings)
+	identity, err := handler.authenticateOAuth(payload.Code, &settings.OAuthSettings)
 	if err != nil {
 		log.Debug().Err(err).Msg("OAuth authentication error")
 
 		return httperror.InternalServerError("Unable to authenticate through OAuth", httperrors.ErrUnauthorized)
 	}
 
+	username := identity.Username
+
 	user, err := handler.DataStore.User().UserByUsername(username)
 	if err != nil && !handler.DataStore.IsErrObjectNotFound(err) {
 		return httperror.InternalServerError("Unable to retrieve a user with the specified username from the database", err)
 	}
 
+	if httpErr := rejectServiceAccount(user); httpErr != nil {
+		return httpErr
+	}
+
+	if user != nil && settings.UserAuthenticationMethod(user) != portainer.AuthenticationOAuth {
+		return httperror.Forbidden("The account is bound to another authentication method", httperrors.ErrUnauthorized)
+	}
+
 	if user == nil && !settings.OAuthSettings.OAuthAutoCreateUsers {
 		return httperror.Forbidden("Account not created beforehand in Portainer and automatic user provisioning not enabled", httperrors.ErrUnauthorized)
 	}
 
 	if user == nil {
 		user = &portainer.User{
-			Username: username,
-			Role:     portainer.StandardUserRole,
+			Username:             username,
+			Role:                 portainer.StandardUserRole,
+			AuthenticationMethod: portainer.AuthenticationOAuth,
 		}
 
 		err = handler.DataStore.User().Create(user)
@@ -112,5 +120,101 @@ func (handler *Handler) validateOAuth(w http.ResponseWriter, r *http.Request) *h
 
 	}
 
-	return handler.writeToken(w, user, false)
+	if len(settings.OAuthSettings.ClaimRules) > 0 {
+		rulesResult := oauth.EvaluateClaimRules(settings.OAuthSettings.ClaimRules, identity.Claims)
+		if err := handler.applyOAuthClaimRules(user, rulesResult); err != nil {
+			return httperror.InternalServerError("Unable to apply the OAuth claim rules", err)
+		}
+	}
+
+	if err := handler.persistRefreshToken(user, identity.RefreshToken); err != nil {
+		// the session still works, it just can't be renewed silently
+		log.Warn().Err(err).Msg("unable to persist the OAuth refresh token")
+	}
+
+	if err := handler.persistIDToken(user, identity.IDToken); err != nil {
+		// the user can still log out, the provider just won't know which session to end
+		log.Warn().Err(err).Msg("unable to persist the OAuth id_token")
+	}
+
+	return handler.writeTokenForMethod(w, r, user, portainer.AuthenticationOAuth, false)
+}
+
+// applyOAuthClaimRules updates the role of the user when it is managed by the claim rules, and adds the user to
+// the teams and environment groups granted by the rules. Memberships and accesses are never removed
+func (handler *Handler) applyOAuthClaimRules(user *portainer.User, result oauth.ClaimRulesResult) error {
+	return handler.DataStore.UpdateTx(func(tx dataservices.DataStoreTx) error {
+		role := portainer.StandardUserRole
+		if result.Admin {
+			role = portainer.AdministratorRole
+		}
+
+		if result.RoleManaged && user.Role != role {
+			user.Role = role
+
+			if err := tx.User().Update(user.ID, user); err != nil {
+				return err
+			}
+		}
+
+		memberships, err := tx.TeamMembership().TeamMembershipsByUserID(user.ID)
+		if err != nil {
+			return err
+		}
+
+		for _, teamID := range result.TeamIDs {
+			if teamMembershipExists(teamID, memberships) {
+				continue
+			}
+
+			if _, err := tx.Team().Read(teamID); err != nil {
+				if tx.IsErrObjectNotFound(err) {
+					log.Warn().Int("team_id", int(teamID)).Msg("the team of an OAuth claim rule does not exist")
+
+					continue
+				}
+
+				return err
+			}
+
+			membership := &portainer.TeamMembership{
+				UserID: user.ID,
+				TeamID: teamID,
+				Role:   portainer.TeamMember,
+			}
+
+			if err := tx.TeamMembership().Create(membership); err != nil {
+				return err
+			}
+		}
+
+		for _, endpointGroupID := range result.EndpointGroupIDs {
+			endpointGroup, err := tx.EndpointGroup().Read(endpointGroupID)
+			if err != nil {
+				if tx.IsErrObjectNotFound(err) {
+					log.Warn().Int("endpoint_group_id", int(endpointGroupID)).Msg("the environment group of an OAuth claim rule does not exist")
+
+					continue
+				}
+
+				return err
+			}
+
+			if _, ok := endpointGroup.UserAccessPolicies[user.ID]; ok {
+				continue
+			}
+
+			if endpointGroup.UserAccessPolicies == nil {
+				endpointGroup.UserAccessPolicies = portainer.UserAccessPolicies{}
+			}
+
+			endpointGroup.UserAccessPolicies[user.ID] = portainer.AccessPolicy{}
+
+			if err := tx.EndpointGroup().Update(endpointGroup.ID, endpointGroup); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
 }
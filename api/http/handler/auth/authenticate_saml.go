@@ -0,0 +1,385 @@
+package auth
+
+import (
+	"errors"
+	"net/http"
+	"slices"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+	httperrors "github.com/portainer/portainer/api/http/errors"
+	"github.com/portainer/portainer/api/http/security"
+	"github.com/portainer/portainer/api/internal/authorization"
+	"github.com/portainer/portainer/api/logoutcontext"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+
+	"github.com/rs/zerolog/log"
+)
+
+// maxSAMLFormSize is the maximum size of the form posted by the identity provider
+const maxSAMLFormSize = 2 << 20
+
+var errSAMLNotEnabled = errors.New("SAML authentication is not enabled")
+
+type samlLogoutResponse struct {
+	// URL of the identity provider the user must be redirected to, empty when single logout is not supported
+	LogoutURL string `json:"logoutURL" example:"https://idp.mydomain.tld/slo?SAMLRequest=..."`
+}
+
+func (handler *Handler) samlSettings() (*portainer.Settings, *httperror.HandlerError) {
+	settings, err := handler.DataStore.Settings().Settings()
+	if err != nil {
+		return nil, httperror.InternalServerError("Unable to retrieve settings from the database", err)
+	}
+
+	if !settings.IsAuthenticationMethodEnabled(portainer.AuthenticationSAML) {
+		return nil, httperror.Forbidden("SAML authentication is not enabled", errSAMLNotEnabled)
+	}
+
+	return settings, nil
+}
+
+// @id SAMLMetadata
+// @summary Retrieve the SAML service provider metadata
+// @description **Access policy**: public
+// @tags auth
+// @produce xml
+// @success 200 "Success"
+// @failure 403 "SAML authentication is not enabled"
+// @failure 500 "Server error"
+// @router /auth/saml/metadata [get]
+func (handler *Handler) samlMetadata(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	settings, httpErr := handler.samlSettings()
+	if httpErr != nil {
+		return httpErr
+	}
+
+	metadata, err := handler.SAMLService.Metadata(&settings.SAMLSettings)
+	if err != nil {
+		return httperror.InternalServerError("Unable to generate the SAML metadata", err)
+	}
+
+	w.Header().Set("Content-Type", "application/samlmetadata+xml")
+	_, _ = w.Write(metadata)
+
+	return nil
+}
+
+// @id SAMLLogin
+// @summary Start a SAML authentication
+// @description Redirects the user to the SAML identity provider.
+// @description **Access policy**: public
+// @tags auth
+// @success 302 "Redirect to the identity provider"
+// @failure 403 "SAML authentication is not enabled"
+// @failure 500 "Server error"
+// @router /auth/saml/login [get]
+func (handler *Handler) samlLogin(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	settings, httpErr := handler.samlSettings()
+	if httpErr != nil {
+		return httpErr
+	}
+
+	loginURL, err := handler.SAMLService.LoginURL(&settings.SAMLSettings)
+	if err != nil {
+		return httperror.InternalServerError("Unable to create the SAML authentication request", err)
+	}
+
+	http.Redirect(w, r, loginURL, http.StatusFound)
+
+	return nil
+}
+
+// @id SAMLAssertionConsumer
+// @summary Authenticate with SAML
+// @description Assertion consumer service receiving the responses of the SAML identity provider through the HTTP-POST binding.
+// @description On success, the session cookie is set and the user is redirected to Portainer.
+// @description **Access policy**: public
+// @tags auth
+// @accept x-www-form-urlencoded
+// @param SAMLResponse formData string true "Base64 encoded SAML response"
+// @success 302 "Redirect to Portainer"
+// @failure 400 "Invalid request"
+// @failure 403 "Forbidden"
+// @failure 500 "Server error"
+// @router /auth/saml/acs [post]
+func (handler *Handler) samlAssertionConsumer(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	settings, httpErr := handler.samlSettings()
+	if httpErr != nil {
+		return httpErr
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxSAMLFormSize)
+
+	encodedResponse := r.PostFormValue("SAMLResponse")
+	if encodedResponse == "" {
+		return httperror.BadRequest("Invalid request payload", errors.New("missing SAMLResponse"))
+	}
+
+	assertion, err := handler.SAMLService.ValidateResponse(encodedResponse, &settings.SAMLSettings)
+	if err != nil {
+		log.Debug().Err(err).Msg("SAML authentication error")
+
+		return httperror.Forbidden("Unable to authenticate through SAML", httperrors.ErrUnauthorized)
+	}
+
+	username := samlUsername(assertion, &settings.SAMLSettings)
+	if username == "" {
+		return httperror.Forbidden("Unable to authenticate through SAML", errors.New("the SAML assertion has no user identifier"))
+	}
+
+	var user *portainer.User
+	err = handler.DataStore.UpdateTx(func(tx dataservices.DataStoreTx) error {
+		user, err = handler.samlUser(tx, username, assertion, settings)
+
+		return err
+	})
+	if err != nil {
+		var httpErr *httperror.HandlerError
+		if errors.As(err, &httpErr) {
+			return httpErr
+		}
+
+		return httperror.InternalServerError("Unable to persist the user inside the database", err)
+	}
+
+	token, expirationTime, err := handler.generateSessionToken(r, composeTokenData(user, false))
+	if err != nil {
+		return httperror.InternalServerError("Unable to generate JWT token", err)
+	}
+
+	security.AddAuthCookie(w, token, expirationTime)
+
+	http.Redirect(w, r, "/", http.StatusFound)
+
+	return nil
+}
+
+// samlUser retrieves or provisions the user of an assertion, then synchronizes its teams and SAML session
+func (handler *Handler) samlUser(tx dataservices.DataStoreTx, username string, assertion *portainer.SAMLAssertion, appSettings *portainer.Settings) (*portainer.User, error) {
+	settings := &appSettings.SAMLSettings
+
+	user, err := tx.User().UserByUsername(username)
+	if err != nil && !tx.IsErrObjectNotFound(err) {
+		return nil, httperror.InternalServerError("Unable to retrieve a user with the specified username from the database", err)
+	}
+
+	if httpErr := rejectServiceAccount(user); httpErr != nil {
+		return nil, httpErr
+	}
+
+	if user != nil && appSettings.UserAuthenticationMethod(user) != portainer.AuthenticationSAML {
+		return nil, httperror.Forbidden("The account is bound to another authentication method", httperrors.ErrUnauthorized)
+	}
+
+	if user == nil {
+		if !settings.AutoCreateUsers {
+			return nil, httperror.Forbidden("Account not created beforehand in Portainer and automatic user provisioning not enabled", httperrors.ErrUnauthorized)
+		}
+
+		user = &portainer.User{
+			Username:                username,
+			Role:                    portainer.StandardUserRole,
+			PortainerAuthorizations: authorization.DefaultPortainerAuthorizations(),
+		}
+
+		if err := tx.User().Create(user); err != nil {
+			return nil, err
+		}
+
+		if settings.DefaultTeamID != 0 {
+			if err := tx.TeamMembership().Create(&portainer.TeamMembership{
+				UserID: user.ID,
+				TeamID: settings.DefaultTeamID,
+				Role:   portainer.TeamMember,
+			}); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if settings.GroupsAttribute != "" {
+		if err := syncUserTeamsWithSAMLGroups(tx, user, assertion.Attributes[settings.GroupsAttribute], settings.TeamMappings); err != nil {
+			return nil, err
+		}
+	}
+
+	user.AuthenticationMethod = portainer.AuthenticationSAML
+	user.SAMLNameID = assertion.NameID
+	user.SAMLSessionIndex = assertion.SessionIndex
+
+	return user, tx.User().Update(user.ID, user)
+}
+
+func samlUsername(assertion *portainer.SAMLAssertion, settings *portainer.SAMLSettings) string {
+	if settings.UserIdentifierAttribute == "" {
+		return assertion.NameID
+	}
+
+	values := assertion.Attributes[settings.UserIdentifierAttribute]
+	if len(values) == 0 {
+		return ""
+	}
+
+	return values[0]
+}
+
+// syncUserTeamsWithSAMLGroups adds the user to the mapped teams of its groups and removes it from the mapped
+// teams of the groups it no longer belongs to. The memberships of unmapped teams are left untouched
+func syncUserTeamsWithSAMLGroups(tx dataservices.DataStoreTx, user *portainer.User, groups []string, mappings []portainer.SAMLTeamMapping) error {
+	memberships, err := tx.TeamMembership().TeamMembershipsByUserID(user.ID)
+	if err != nil {
+		return err
+	}
+
+	expected := map[portainer.TeamID]bool{}
+	for _, mapping := range mappings {
+		expected[mapping.TeamID] = expected[mapping.TeamID] || slices.Contains(groups, mapping.Group)
+	}
+
+	for teamID, member := range expected {
+		exists := teamMembershipExists(teamID, memberships)
+
+		switch {
+		case member && !exists:
+			if err := tx.TeamMembership().Create(&portainer.TeamMembership{
+				UserID: user.ID,
+				TeamID: teamID,
+				Role:   portainer.TeamMember,
+			}); err != nil {
+				return err
+			}
+		case !member && exists:
+			if err := tx.TeamMembership().DeleteTeamMembershipByTeamIDAndUserID(teamID, user.ID); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// @id SAMLLogout
+// @summary Logout from Portainer and the SAML identity provider
+// @description Ends the Portainer session and returns the URL of the identity provider to end the SAML session.
+// @description **Access policy**: public
+// @tags auth
+// @security ApiKeyAuth
+// @security jwt
+// @produce json
+// @success 200 {object} samlLogoutResponse "Success"
+// @failure 500 "Server error"
+// @router /auth/saml/logout [post]
+func (handler *Handler) samlLogout(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	var resp samlLogoutResponse
+
+	tokenData, _ := handler.bouncer.CookieAuthLookup(r)
+	if tokenData != nil {
+		handler.KubernetesTokenCacheManager.RemoveUserFromCache(tokenData.ID)
+		logoutcontext.Cancel(tokenData.Token)
+		if err := handler.JWTService.RevokeToken(tokenData.Token); err != nil {
+			log.Warn().Err(err).Msg("unable to revoke the session")
+		}
+
+		handler.bouncer.RevokeJWT(tokenData.Token)
+
+		settings, err := handler.DataStore.Settings().Settings()
+		if err != nil {
+			return httperror.InternalServerError("Unable to retrieve settings from the database", err)
+		}
+
+		user, err := handler.DataStore.User().Read(tokenData.ID)
+		if err == nil && settings.IsAuthenticationMethodEnabled(portainer.AuthenticationSAML) {
+			if resp.LogoutURL, err = handler.SAMLService.LogoutURL(&settings.SAMLSettings, user.SAMLNameID, user.SAMLSessionIndex); err != nil {
+				log.Warn().Err(err).Msg("unable to create the SAML logout request")
+			}
+		}
+	}
+
+	security.RemoveAuthCookie(w)
+
+	return response.JSON(w, resp)
+}
+
+// @id SAMLSingleLogout
+// @summary SAML single logout service
+// @description Receives the logout messages of the SAML identity provider through the HTTP-Redirect binding.
+// @description A signed logout request invalidates every session of the user and is acknowledged to the identity provider.
+// @description **Access policy**: public
+// @tags auth
+// @param SAMLRequest query string false "Logout request of the identity provider"
+// @param SAMLResponse query string false "Answer of the identity provider to a logout request"
+// @success 302 "Redirect"
+// @failure 400 "Invalid request"
+// @failure 403 "Forbidden"
+// @failure 500 "Server error"
+// @router /auth/saml/slo [get]
+func (handler *Handler) samlSingleLogout(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	settings, httpErr := handler.samlSettings()
+	if httpErr != nil {
+		return httpErr
+	}
+
+	query := r.URL.Query()
+
+	// the identity provider acknowledges a logout started from Portainer, the local session is already closed
+	if query.Get("SAMLRequest") == "" {
+		http.Redirect(w, r, "/", http.StatusFound)
+
+		return nil
+	}
+
+	logoutRequest, err := handler.SAMLService.ValidateLogoutRequest(r.URL.RawQuery, &settings.SAMLSettings)
+	if err != nil {
+		log.Debug().Err(err).Msg("SAML logout error")
+
+		return httperror.Forbidden("Invalid SAML logout request", httperrors.ErrUnauthorized)
+	}
+
+	var loggedOut []portainer.UserID
+	if err := handler.DataStore.UpdateTx(func(tx dataservices.DataStoreTx) error {
+		users, err := tx.User().ReadAll()
+		if err != nil {
+			return err
+		}
+
+		now := time.Now().Unix()
+
+		for i := range users {
+			user := &users[i]
+			if user.SAMLNameID != logoutRequest.NameID {
+				continue
+			}
+
+			// invalidates every token issued to the user
+			user.TokenIssueAt = now
+			user.SAMLNameID = ""
+			user.SAMLSessionIndex = ""
+
+			if err := tx.User().Update(user.ID, user); err != nil {
+				return err
+			}
+
+			loggedOut = append(loggedOut, user.ID)
+		}
+
+		return nil
+	}); err != nil {
+		return httperror.InternalServerError("Unable to close the sessions of the user", err)
+	}
+
+	for _, userID := range loggedOut {
+		handler.KubernetesTokenCacheManager.RemoveUserFromCache(userID)
+	}
+
+	responseURL, err := handler.SAMLService.LogoutResponseURL(&settings.SAMLSettings, logoutRequest)
+	if err != nil {
+		return httperror.InternalServerError("Unable to create the SAML logout response", err)
+	}
+
+	http.Redirect(w, r, responseURL, http.StatusFound)
+
+	return nil
+}
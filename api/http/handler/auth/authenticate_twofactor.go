@@ -0,0 +1,284 @@
+package auth
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+	httperrors "github.com/portainer/portainer/api/http/errors"
+	"github.com/portainer/portainer/api/http/security"
+	"github.com/portainer/portainer/api/totp"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/request"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// maxTwoFactorFailures is the number of invalid codes after which a two-factor token is invalidated, the user
+	// must then log in with its password again
+	maxTwoFactorFailures = 3
+	// twoFactorFailuresRetention is the time the failures of a two-factor token are kept, past its expiry
+	twoFactorFailuresRetention = 10 * time.Minute
+)
+
+var errTwoFactorTokenInvalidated = errors.New("too many invalid two-factor authentication codes")
+
+type twoFactorEnrollPayload struct {
+	// Token returned by /auth when the enrollment is required
+	TwoFactorToken string `validate:"required"`
+}
+
+type twoFactorVerifyPayload struct {
+	// Token returned by /auth when the second factor is required
+	TwoFactorToken string `validate:"required"`
+	// Code displayed by the authenticator application
+	Code string `example:"123456"`
+	// Single-use recovery code, used instead of the code when the authenticator application is lost
+	RecoveryCode string `example:"abcd-efgh"`
+}
+
+type twoFactorVerifyResponse struct {
+	// JWT token used to authenticate against the API
+	JWT string `json:"jwt"`
+	// Recovery codes generated when the enrollment is confirmed, they are only displayed once
+	RecoveryCodes []string `json:"recoveryCodes,omitempty"`
+}
+
+func (payload *twoFactorEnrollPayload) Validate(r *http.Request) error {
+	if payload.TwoFactorToken == "" {
+		return errors.New("Invalid two-factor token")
+	}
+
+	return nil
+}
+
+func (payload *twoFactorVerifyPayload) Validate(r *http.Request) error {
+	if payload.TwoFactorToken == "" {
+		return errors.New("Invalid two-factor token")
+	}
+
+	if strings.TrimSpace(payload.Code) == "" && strings.TrimSpace(payload.RecoveryCode) == "" {
+		return errors.New("Invalid code, a code or a recovery code is required")
+	}
+
+	return nil
+}
+
+// @id AuthenticateTwoFactorEnroll
+// @summary Enroll a second factor during the login
+// @description Generates the TOTP secret of a user who must enroll a second factor before logging in.
+// @description The enrollment is confirmed by verifying a code with /auth/2fa/verify.
+// @description **Access policy**: public
+// @tags auth
+// @accept json
+// @produce json
+// @param body body twoFactorEnrollPayload true "Two-factor token"
+// @success 200 {object} totp.Enrollment "Success"
+// @failure 400 "Invalid request"
+// @failure 401 "Invalid or expired two-factor token"
+// @failure 409 "Two-factor authentication already enabled"
+// @failure 429 "Too many failed logins, the username or the IP address is locked out"
+// @failure 500 "Server error"
+// @router /auth/2fa/enroll [post]
+func (handler *Handler) twoFactorEnroll(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	var payload twoFactorEnrollPayload
+	if err := request.DecodeAndValidateJSONPayload(r, &payload); err != nil {
+		return httperror.BadRequest("Invalid request payload", err)
+	}
+
+	user, _, httpErr := handler.twoFactorUser(w, r, payload.TwoFactorToken)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	// a user who registered a security key must use it, the password alone cannot add another second factor
+	credentials, err := handler.DataStore.WebAuthnCredential().CredentialsByUserID(user.ID)
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve the WebAuthn credentials of the user from the database", err)
+	}
+
+	if len(credentials) > 0 {
+		return httperror.NewError(http.StatusConflict, "A second factor is already enabled", errors.New("the user registered a security key"))
+	}
+
+	var enrollment *totp.Enrollment
+	err = handler.DataStore.UpdateTx(func(tx dataservices.DataStoreTx) error {
+		var err error
+		enrollment, err = totp.Enroll(tx, user)
+
+		return err
+	})
+	if errors.Is(err, totp.ErrAlreadyEnabled) {
+		return httperror.NewError(http.StatusConflict, "Two-factor authentication is already enabled", err)
+	} else if err != nil {
+		return httperror.InternalServerError("Unable to enroll the second factor", err)
+	}
+
+	return response.JSON(w, enrollment)
+}
+
+// @id AuthenticateTwoFactorVerify
+// @summary Verify the second factor during the login
+// @description Completes the login of a user whose password was verified by /auth. A pending enrollment is confirmed
+// @description and the recovery codes are returned along with the JWT. The invalid codes count as failed logins, and the
+// @description two-factor token is invalidated after a few of them.
+// @description **Access policy**: public
+// @tags auth
+// @accept json
+// @produce json
+// @param body body twoFactorVerifyPayload true "Two-factor token and code"
+// @success 200 {object} twoFactorVerifyResponse "Success"
+// @failure 400 "Invalid request"
+// @failure 401 "Invalid or expired two-factor token"
+// @failure 422 "Invalid code"
+// @failure 429 "Too many failed logins, the username or the IP address is locked out"
+// @failure 500 "Server error"
+// @router /auth/2fa/verify [post]
+func (handler *Handler) twoFactorVerify(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	var payload twoFactorVerifyPayload
+	if err := request.DecodeAndValidateJSONPayload(r, &payload); err != nil {
+		return httperror.BadRequest("Invalid request payload", err)
+	}
+
+	user, tokenData, httpErr := handler.twoFactorUser(w, r, payload.TwoFactorToken)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	var recoveryCodes []string
+	err := handler.DataStore.UpdateTx(func(tx dataservices.DataStoreTx) error {
+		enabled, err := totp.IsEnabled(tx, user.ID)
+		if err != nil {
+			return err
+		}
+
+		if enabled {
+			return totp.Verify(tx, user.ID, payload.Code, payload.RecoveryCode, time.Now())
+		}
+
+		recoveryCodes, err = totp.Confirm(tx, user.ID, payload.Code, time.Now())
+
+		return err
+	})
+	if errors.Is(err, totp.ErrInvalidCode) {
+		handler.twoFactorFailures.record(payload.TwoFactorToken, time.Now())
+
+		if err := handler.LockoutService.RecordFailure(user.Username, security.StripAddrPort(r.RemoteAddr)); err != nil {
+			log.Warn().Err(err).Msg("unable to record the login attempt")
+		}
+
+		return httperror.NewError(http.StatusUnprocessableEntity, "Invalid two-factor authentication code", httperrors.ErrUnauthorized)
+	} else if errors.Is(err, totp.ErrNotEnrolled) {
+		return httperror.BadRequest("Two-factor authentication is not enrolled, enroll it with /auth/2fa/enroll first", err)
+	} else if err != nil {
+		return httperror.InternalServerError("Unable to verify the second factor", err)
+	}
+
+	handler.twoFactorFailures.forget(payload.TwoFactorToken)
+
+	if err := handler.LockoutService.RecordSuccess(user.Username); err != nil {
+		log.Warn().Err(err).Msg("unable to record the login attempt")
+	}
+
+	if payload.RecoveryCode != "" {
+		log.Info().Str("username", user.Username).Msg("two-factor authentication completed with a recovery code")
+	}
+
+	token, expirationTime, err := handler.generateSessionToken(r, composeTokenData(user, tokenData.ForceChangePassword))
+	if err != nil {
+		return httperror.InternalServerError("Unable to generate JWT token", err)
+	}
+
+	security.AddAuthCookie(w, token, expirationTime)
+
+	return response.JSON(w, &twoFactorVerifyResponse{JWT: token, RecoveryCodes: recoveryCodes})
+}
+
+// twoFactorUser returns the user of a pending two-factor authentication, unless its token was invalidated after too
+// many invalid codes or the user is locked out
+func (handler *Handler) twoFactorUser(w http.ResponseWriter, r *http.Request, twoFactorToken string) (*portainer.User, *portainer.TokenData, *httperror.HandlerError) {
+	tokenData, err := handler.JWTService.ParseAndVerifyTwoFactorToken(twoFactorToken)
+	if err != nil {
+		return nil, nil, httperror.NewError(http.StatusUnauthorized, "Invalid or expired two-factor token", err)
+	}
+
+	if handler.twoFactorFailures.invalidated(twoFactorToken, time.Now()) {
+		return nil, nil, httperror.NewError(http.StatusUnauthorized, "Invalid or expired two-factor token", errTwoFactorTokenInvalidated)
+	}
+
+	remaining, err := handler.LockoutService.Check(tokenData.Username, security.StripAddrPort(r.RemoteAddr))
+	if err != nil {
+		return nil, nil, httperror.InternalServerError("Unable to retrieve the failed logins from the database", err)
+	} else if remaining > 0 {
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(remaining.Seconds()))))
+
+		return nil, nil, httperror.NewError(http.StatusTooManyRequests, "Too many failed logins, try again later", errLockedOut)
+	}
+
+	user, err := handler.DataStore.User().Read(tokenData.ID)
+	if handler.DataStore.IsErrObjectNotFound(err) {
+		return nil, nil, httperror.NewError(http.StatusUnauthorized, "Invalid or expired two-factor token", err)
+	} else if err != nil {
+		return nil, nil, httperror.InternalServerError("Unable to retrieve a user with the specified identifier inside the database", err)
+	}
+
+	return user, tokenData, nil
+}
+
+// twoFactorFailures counts the invalid codes provided with every two-factor token
+type twoFactorFailures struct {
+	mu       sync.Mutex
+	failures map[string]twoFactorFailure
+}
+
+type twoFactorFailure struct {
+	count     int
+	expiresAt time.Time
+}
+
+func newTwoFactorFailures() *twoFactorFailures {
+	return &twoFactorFailures{failures: make(map[string]twoFactorFailure)}
+}
+
+// record counts an invalid code provided with a two-factor token
+func (f *twoFactorFailures) record(twoFactorToken string, now time.Time) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for token, failure := range f.failures {
+		if now.After(failure.expiresAt) {
+			delete(f.failures, token)
+		}
+	}
+
+	failure := f.failures[twoFactorToken]
+	failure.count++
+	failure.expiresAt = now.Add(twoFactorFailuresRetention)
+	f.failures[twoFactorToken] = failure
+}
+
+// forget removes the failures of a two-factor token once the user logged in
+func (f *twoFactorFailures) forget(twoFactorToken string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	delete(f.failures, twoFactorToken)
+}
+
+// invalidated returns true when too many invalid codes were provided with a two-factor token
+func (f *twoFactorFailures) invalidated(twoFactorToken string, now time.Time) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	failure, ok := f.failures[twoFactorToken]
+
+	return ok && now.Before(failure.expiresAt) && failure.count >= maxTwoFactorFailures
+}
@@ -0,0 +1,78 @@
+package auth
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+	"github.com/portainer/portainer/api/lockout"
+	"github.com/portainer/portainer/api/totp"
+
+	"github.com/segmentio/encoding/json"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTwoFactorVerify_Failures(t *testing.T) {
+	h, services := setupHandler(t)
+
+	user := &portainer.User{ID: 2, Username: "standard", Role: portainer.StandardUserRole}
+	require.NoError(t, services.store.User().Create(user))
+
+	require.NoError(t, services.store.UpdateTx(func(tx dataservices.DataStoreTx) error {
+		_, err := totp.Enroll(tx, user)
+
+		return err
+	}))
+
+	post := func(path string, payload any) int {
+		body, err := json.Marshal(payload)
+		require.NoError(t, err)
+
+		req := httptest.NewRequest(http.MethodPost, path, bytes.NewReader(body))
+
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+
+		return rr.Code
+	}
+
+	twoFactorToken := func() string {
+		token, _, err := services.jwtService.GenerateTwoFactorToken(&portainer.TokenData{ID: user.ID, Username: user.Username, Role: user.Role})
+		require.NoError(t, err)
+
+		return token
+	}
+
+	failures := func() int {
+		attempt, err := services.store.LoginAttempt().LoginAttemptBySubject(user.Username, false)
+		require.NoError(t, err)
+
+		return attempt.Failures
+	}
+
+	token := twoFactorToken()
+	for range maxTwoFactorFailures {
+		require.Equal(t, http.StatusUnprocessableEntity, post("/auth/2fa/verify", twoFactorVerifyPayload{TwoFactorToken: token, Code: "000000"}))
+	}
+
+	require.Equal(t, maxTwoFactorFailures, failures(), "the invalid codes count as failed logins")
+
+	// the token is invalidated, the password must be verified again
+	require.Equal(t, http.StatusUnauthorized, post("/auth/2fa/verify", twoFactorVerifyPayload{TwoFactorToken: token, Code: "000000"}))
+	require.Equal(t, http.StatusUnauthorized, post("/auth/2fa/enroll", twoFactorEnrollPayload{TwoFactorToken: token}))
+
+	t.Run("the user is locked out", func(t *testing.T) {
+		token := twoFactorToken()
+		for range lockout.UsernameThreshold - maxTwoFactorFailures {
+			require.Equal(t, http.StatusUnprocessableEntity, post("/auth/2fa/verify", twoFactorVerifyPayload{TwoFactorToken: token, Code: "000000"}))
+		}
+
+		require.Equal(t, lockout.UsernameThreshold, failures())
+
+		require.Equal(t, http.StatusTooManyRequests, post("/auth/2fa/verify", twoFactorVerifyPayload{TwoFactorToken: token, Code: "000000"}))
+		require.Equal(t, http.StatusTooManyRequests, post("/auth/2fa/enroll", twoFactorEnrollPayload{TwoFactorToken: token}))
+	})
+}
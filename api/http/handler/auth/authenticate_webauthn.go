@@ -0,0 +1,206 @@
+package auth
+
+import (
+	"errors"
+	"net/http"
+
+	portainer "github.com/portainer/portainer/api"
+	httperrors "github.com/portainer/portainer/api/http/errors"
+	"github.com/portainer/portainer/api/webauthn"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/request"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+
+	"github.com/rs/zerolog/log"
+)
+
+type webAuthnLoginBeginPayload struct {
+	// Token returned by /auth when the second factor is required, the security key is then used as a second factor.
+	// When omitted, the login is passwordless
+	TwoFactorToken string
+	// Username of a passwordless login, the user picks a discoverable credential on its authenticator when omitted
+	Username string `example:"admin"`
+}
+
+type webAuthnLoginBeginResponse struct {
+	// Identifier of the ceremony, to send back with the assertion
+	SessionID string `json:"sessionId"`
+	// Options of navigator.credentials.get, the binary values are base64url encoded
+	PublicKey *webauthn.RequestOptions `json:"publicKey"`
+}
+
+type webAuthnLoginFinishPayload struct {
+	// Identifier of the ceremony returned by /auth/webauthn/login/begin
+	SessionID string `validate:"required"`
+	// Assertion returned by the authenticator
+	Credential webauthn.CredentialResponse
+	// Token returned by /auth, required when the security key is used as a second factor
+	TwoFactorToken string
+}
+
+func (payload *webAuthnLoginBeginPayload) Validate(r *http.Request) error {
+	return nil
+}
+
+func (payload *webAuthnLoginFinishPayload) Validate(r *http.Request) error {
+	if payload.SessionID == "" {
+		return errors.New("Invalid session identifier")
+	}
+
+	if payload.Credential.RawID == "" {
+		return errors.New("Invalid credential")
+	}
+
+	return nil
+}
+
+// @id AuthenticateWebAuthnBegin
+// @summary Start a login with a security key
+// @description Returns the options of the assertion requested to the authenticator. With a two-factor token, the security key
+// @description is used as the second factor of the user, otherwise the login is passwordless and only allowed for the internal users.
+// @description **Access policy**: public
+// @tags auth
+// @accept json
+// @produce json
+// @param body body webAuthnLoginBeginPayload true "Login"
+// @success 200 {object} webAuthnLoginBeginResponse "Success"
+// @failure 400 "Invalid request"
+// @failure 401 "Invalid or expired two-factor token"
+// @failure 403 "WebAuthn login disabled"
+// @failure 500 "Server error"
+// @router /auth/webauthn/login/begin [post]
+func (handler *Handler) webAuthnLoginBegin(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	var payload webAuthnLoginBeginPayload
+	if err := request.DecodeAndValidateJSONPayload(r, &payload); err != nil {
+		return httperror.BadRequest("Invalid request payload", err)
+	}
+
+	settings, err := handler.DataStore.Settings().Settings()
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve settings from the database", err)
+	}
+
+	if !settings.WebAuthnSettings.Enabled || (payload.TwoFactorToken == "" && !settings.WebAuthnSettings.Passwordless) {
+		return httperror.Forbidden("The login with a security key is disabled", httperrors.ErrUnauthorized)
+	}
+
+	var userID portainer.UserID
+	var credentials []portainer.WebAuthnCredential
+
+	switch {
+	case payload.TwoFactorToken != "":
+		user, _, httpErr := handler.twoFactorUser(w, r, payload.TwoFactorToken)
+		if httpErr != nil {
+			return httpErr
+		}
+
+		if credentials, err = handler.DataStore.WebAuthnCredential().CredentialsByUserID(user.ID); err != nil {
+			return httperror.InternalServerError("Unable to retrieve the WebAuthn credentials of the user from the database", err)
+		}
+
+		if len(credentials) == 0 {
+			return httperror.BadRequest("No security key registered for the user", errors.New("no WebAuthn credential"))
+		}
+
+		userID = user.ID
+	case payload.Username != "":
+		// the credentials of an unknown user are not reported, the options of a discoverable login are returned instead
+		user, err := handler.DataStore.User().UserByUsername(payload.Username)
+		if err != nil && !handler.DataStore.IsErrObjectNotFound(err) {
+			return httperror.InternalServerError("Unable to retrieve a user with the specified username from the database", err)
+		}
+
+		if err == nil && settings.UserAuthenticationMethod(user) == portainer.AuthenticationInternal {
+			if credentials, err = handler.DataStore.WebAuthnCredential().CredentialsByUserID(user.ID); err != nil {
+				return httperror.InternalServerError("Unable to retrieve the WebAuthn credentials of the user from the database", err)
+			}
+		}
+	}
+
+	rp := webauthn.RelyingPartyFromRequest(&settings.WebAuthnSettings, r)
+
+	sessionID, options, err := handler.WebAuthnService.BeginLogin(rp, &settings.WebAuthnSettings, userID, credentials)
+	if errors.Is(err, webauthn.ErrTooManySessions) {
+		return httperror.NewError(http.StatusTooManyRequests, "Too many pending logins", err)
+	} else if err != nil {
+		return httperror.InternalServerError("Unable to start the WebAuthn login", err)
+	}
+
+	return response.JSON(w, &webAuthnLoginBeginResponse{SessionID: sessionID, PublicKey: options})
+}
+
+// @id AuthenticateWebAuthnFinish
+// @summary Complete a login with a security key
+// @description Verifies the assertion of the authenticator and returns the JWT of the user.
+// @description **Access policy**: public
+// @tags auth
+// @accept json
+// @produce json
+// @param body body webAuthnLoginFinishPayload true "Assertion"
+// @success 200 {object} authenticateResponse "Success"
+// @failure 400 "Invalid request"
+// @failure 403 "WebAuthn login disabled"
+// @failure 422 "Invalid assertion"
+// @failure 500 "Server error"
+// @router /auth/webauthn/login/finish [post]
+func (handler *Handler) webAuthnLoginFinish(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	var payload webAuthnLoginFinishPayload
+	if err := request.DecodeAndValidateJSONPayload(r, &payload); err != nil {
+		return httperror.BadRequest("Invalid request payload", err)
+	}
+
+	settings, err := handler.DataStore.Settings().Settings()
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve settings from the database", err)
+	}
+
+	if !settings.WebAuthnSettings.Enabled {
+		return httperror.Forbidden("The login with a security key is disabled", httperrors.ErrUnauthorized)
+	}
+
+	credential, sessionUserID, err := handler.WebAuthnService.FinishLogin(payload.SessionID, &payload.Credential, func(credentialID []byte) (*portainer.WebAuthnCredential, error) {
+		credential, err := handler.DataStore.WebAuthnCredential().CredentialByCredentialID(credentialID)
+		if handler.DataStore.IsErrObjectNotFound(err) {
+			return nil, webauthn.ErrUnknownCredential
+		}
+
+		return credential, err
+	})
+	if errors.Is(err, webauthn.ErrInvalidSession) || errors.Is(err, webauthn.ErrUnknownCredential) || errors.Is(err, webauthn.ErrVerification) {
+		log.Debug().Err(err).Msg("WebAuthn login rejected")
+
+		return httperror.NewError(http.StatusUnprocessableEntity, "Invalid security key assertion", httperrors.ErrUnauthorized)
+	} else if err != nil {
+		return httperror.InternalServerError("Unable to verify the WebAuthn assertion", err)
+	}
+
+	user, err := handler.DataStore.User().Read(credential.UserID)
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve a user with the specified identifier inside the database", err)
+	}
+
+	forceChangePassword := false
+
+	if sessionUserID != 0 {
+		// the second factor completes the login started with the password, which must still be pending
+		_, tokenData, httpErr := handler.twoFactorUser(w, r, payload.TwoFactorToken)
+		if httpErr != nil {
+			return httpErr
+		}
+
+		if tokenData.ID != sessionUserID {
+			return httperror.NewError(http.StatusUnauthorized, "Invalid or expired two-factor token", httperrors.ErrUnauthorized)
+		}
+
+		forceChangePassword = tokenData.ForceChangePassword
+	} else if !settings.WebAuthnSettings.Passwordless || settings.UserAuthenticationMethod(user) != portainer.AuthenticationInternal {
+		// a passwordless login replaces the password, it is limited to the users of the internal authentication
+		return httperror.NewError(http.StatusUnprocessableEntity, "Passwordless login is not allowed for this user", httperrors.ErrUnauthorized)
+	}
+
+	if err := handler.DataStore.WebAuthnCredential().Update(credential.ID, credential); err != nil {
+		return httperror.InternalServerError("Unable to persist the WebAuthn credential changes inside the database", err)
+	}
+
+	return handler.writeToken(w, r, user, forceChangePassword)
+}
@@ -5,9 +5,12 @@ import (
 
 	portainer "github.com/portainer/portainer/api"
 	"github.com/portainer/portainer/api/dataservices"
+	"github.com/portainer/portainer/api/devicecode"
 	"github.com/portainer/portainer/api/http/proxy"
 	"github.com/portainer/portainer/api/http/proxy/factory/kubernetes"
 	"github.com/portainer/portainer/api/http/security"
+	"github.com/portainer/portainer/api/lockout"
+	"github.com/portainer/portainer/api/webauthn"
 	httperror "github.com/portainer/portainer/pkg/libhttp/error"
 
 	"github.com/gorilla/mux"
@@ -21,26 +24,70 @@ type Handler struct {
 	JWTService                  portainer.JWTService
 	LDAPService                 portainer.LDAPService
 	OAuthService                portainer.OAuthService
+	SAMLService                 portainer.SAMLService
+	DeviceCodeService           *devicecode.Service
+	WebAuthnService             *webauthn.Service
+	LockoutService              *lockout.Service
 	ProxyManager                *proxy.Manager
 	KubernetesTokenCacheManager *kubernetes.TokenCacheManager
 	passwordStrengthChecker     security.PasswordStrengthChecker
 	bouncer                     security.BouncerService
+	twoFactorFailures           *twoFactorFailures
 }
 
 // NewHandler creates a handler to manage authentication operations.
 func NewHandler(bouncer security.BouncerService, rateLimiter *security.RateLimiter, passwordStrengthChecker security.PasswordStrengthChecker) *Handler {
 	h := &Handler{
 		Router:                  mux.NewRouter(),
+		DeviceCodeService:       devicecode.NewService(),
+		WebAuthnService:         webauthn.NewService(),
 		passwordStrengthChecker: passwordStrengthChecker,
 		bouncer:                 bouncer,
+		twoFactorFailures:       newTwoFactorFailures(),
 	}
 
 	h.Handle("/auth/oauth/validate",
 		rateLimiter.LimitAccess(bouncer.PublicAccess(httperror.LoggerHandler(h.validateOAuth)))).Methods(http.MethodPost)
+	h.Handle("/auth/saml/metadata",
+		bouncer.PublicAccess(httperror.LoggerHandler(h.samlMetadata))).Methods(http.MethodGet)
+	h.Handle("/auth/saml/login",
+		rateLimiter.LimitAccess(bouncer.PublicAccess(httperror.LoggerHandler(h.samlLogin)))).Methods(http.MethodGet)
+	h.Handle("/auth/saml/acs",
+		rateLimiter.LimitAccess(bouncer.PublicAccess(httperror.LoggerHandler(h.samlAssertionConsumer)))).Methods(http.MethodPost)
+	h.Handle("/auth/saml/logout",
+		bouncer.PublicAccess(httperror.LoggerHandler(h.samlLogout))).Methods(http.MethodPost)
+	h.Handle("/auth/saml/slo",
+		bouncer.PublicAccess(httperror.LoggerHandler(h.samlSingleLogout))).Methods(http.MethodGet)
+	h.Handle("/auth/device/authorize",
+		rateLimiter.LimitAccess(bouncer.PublicAccess(httperror.LoggerHandler(h.deviceAuthorize)))).Methods(http.MethodPost)
+	h.Handle("/auth/device/verify",
+		rateLimiter.LimitAccess(bouncer.AuthenticatedAccess(httperror.LoggerHandler(h.deviceVerify)))).Methods(http.MethodPost)
+	h.Handle("/auth/device/token",
+		rateLimiter.LimitAccess(bouncer.PublicAccess(httperror.LoggerHandler(h.deviceToken)))).Methods(http.MethodPost)
+	h.Handle("/auth/providers",
+		bouncer.PublicAccess(httperror.LoggerHandler(h.authProviderList))).Methods(http.MethodGet)
+	h.Handle("/auth/providers/{method}/test",
+		bouncer.AdminAccess(httperror.LoggerHandler(h.authProviderTest))).Methods(http.MethodPost)
 	h.Handle("/auth",
 		rateLimiter.LimitAccess(bouncer.PublicAccess(httperror.LoggerHandler(h.authenticate)))).Methods(http.MethodPost)
+	h.Handle("/auth/lockouts",
+		bouncer.AdminAccess(httperror.LoggerHandler(h.lockoutList))).Methods(http.MethodGet)
+	h.Handle("/auth/lockouts/{id}",
+		bouncer.AdminAccess(httperror.LoggerHandler(h.lockoutDelete))).Methods(http.MethodDelete)
+	h.Handle("/auth/2fa/enroll",
+		rateLimiter.LimitAccess(bouncer.PublicAccess(httperror.LoggerHandler(h.twoFactorEnroll)))).Methods(http.MethodPost)
+	h.Handle("/auth/2fa/verify",
+		rateLimiter.LimitAccess(bouncer.PublicAccess(httperror.LoggerHandler(h.twoFactorVerify)))).Methods(http.MethodPost)
+	h.Handle("/auth/webauthn/login/begin",
+		rateLimiter.LimitAccess(bouncer.PublicAccess(httperror.LoggerHandler(h.webAuthnLoginBegin)))).Methods(http.MethodPost)
+	h.Handle("/auth/webauthn/login/finish",
+		rateLimiter.LimitAccess(bouncer.PublicAccess(httperror.LoggerHandler(h.webAuthnLoginFinish)))).Methods(http.MethodPost)
+	h.Handle("/auth/refresh",
+		rateLimiter.LimitAccess(bouncer.AuthenticatedAccess(httperror.LoggerHandler(h.refresh)))).Methods(http.MethodPost)
 	h.Handle("/auth/logout",
 		bouncer.PublicAccess(httperror.LoggerHandler(h.logout))).Methods(http.MethodPost)
+	h.Handle("/.well-known/jwks.json",
+		bouncer.PublicAccess(httperror.LoggerHandler(h.jwks))).Methods(http.MethodGet)
 
 	return h
 }
@@ -0,0 +1,29 @@
+package auth
+
+import (
+	"net/http"
+
+	portainer "github.com/portainer/portainer/api"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+)
+
+type jwksResponse struct {
+	// Public keys verifying the JWTs of the user sessions, the key signing the new tokens first
+	Keys []portainer.JSONWebKey `json:"keys"`
+}
+
+// @id AuthJWKS
+// @summary Retrieve the keys verifying the JWTs
+// @description Returns the JSON Web Key Set of the public keys verifying the JWTs issued by Portainer, so that
+// @description other services can validate them. The keys retired by a rotation are listed until the tokens they signed expire.
+// @description **Access policy**: public
+// @tags auth
+// @produce json
+// @success 200 {object} jwksResponse "Success"
+// @router /.well-known/jwks.json [get]
+func (handler *Handler) jwks(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	w.Header().Set("Cache-Control", "public, max-age=300")
+
+	return response.JSON(w, &jwksResponse{Keys: handler.JWTService.JSONWebKeys()})
+}
@@ -0,0 +1,65 @@
+package auth
+
+import (
+	"net/http"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/http/security"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/request"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+)
+
+// @id AuthLockoutList
+// @summary List the tracked failed logins
+// @description List the usernames and IP addresses with failed logins, LockedUntil is set while they are locked out.
+// @description **Access policy**: administrator
+// @tags auth
+// @security ApiKeyAuth
+// @security jwt
+// @produce json
+// @success 200 {array} portainer.LoginAttempt "Success"
+// @failure 500 "Server error"
+// @router /auth/lockouts [get]
+func (handler *Handler) lockoutList(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	attempts, err := handler.DataStore.LoginAttempt().ReadAll()
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve the failed logins from the database", err)
+	}
+
+	return response.JSON(w, attempts)
+}
+
+// @id AuthLockoutDelete
+// @summary Unlock a username or an IP address
+// @description Lift the lockout of a username or an IP address and forget its failed logins.
+// @description **Access policy**: administrator
+// @tags auth
+// @security ApiKeyAuth
+// @security jwt
+// @param id path int true "Login attempt identifier"
+// @success 204 "Success"
+// @failure 400 "Invalid request"
+// @failure 404 "Login attempt not found"
+// @failure 500 "Server error"
+// @router /auth/lockouts/{id} [delete]
+func (handler *Handler) lockoutDelete(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	id, err := request.RetrieveNumericRouteVariableValue(r, "id")
+	if err != nil {
+		return httperror.BadRequest("Invalid login attempt identifier route variable", err)
+	}
+
+	tokenData, err := security.RetrieveTokenData(r)
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve user authentication token", err)
+	}
+
+	err = handler.LockoutService.Unlock(portainer.LoginAttemptID(id), tokenData.ID)
+	if handler.DataStore.IsErrObjectNotFound(err) {
+		return httperror.NotFound("Unable to find a login attempt with the specified identifier inside the database", err)
+	} else if err != nil {
+		return httperror.InternalServerError("Unable to unlock the login attempt", err)
+	}
+
+	return response.Empty(w)
+}
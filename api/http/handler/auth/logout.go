@@ -3,32 +3,115 @@ package auth
 import (
 	"net/http"
 
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
 	"github.com/portainer/portainer/api/http/security"
 	"github.com/portainer/portainer/api/logoutcontext"
+	"github.com/portainer/portainer/api/oauth"
 	httperror "github.com/portainer/portainer/pkg/libhttp/error"
 	"github.com/portainer/portainer/pkg/libhttp/response"
+
+	"github.com/rs/zerolog/log"
 )
 
+type logoutResponse struct {
+	// URL of the OpenID Connect provider the user must be redirected to, to end the SSO session
+	LogoutURL string `json:"logoutURL" example:"https://accounts.example.com/logout?id_token_hint=..."`
+}
+
 // @id Logout
 // @summary Logout
+// @description Ends the Portainer session. When RP-initiated logout is enabled for OAuth,
+// @description the URL of the end session endpoint of the provider is returned.
 // @description **Access policy**: public
 // @security ApiKeyAuth
 // @security jwt
 // @tags auth
+// @produce json
+// @success 200 {object} logoutResponse "Success, the user must be redirected to the provider"
 // @success 204 "Success"
 // @failure 500 "Server error"
 // @router /auth/logout [post]
 func (handler *Handler) logout(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	var resp logoutResponse
+
 	tokenData, _ := handler.bouncer.CookieAuthLookup(r)
 
 	if tokenData != nil {
 		handler.KubernetesTokenCacheManager.RemoveUserFromCache(tokenData.ID)
 		logoutcontext.Cancel(tokenData.Token)
+
+		if user, err := handler.DataStore.User().Read(tokenData.ID); err == nil {
+			resp.LogoutURL = handler.oauthLogoutURL(user)
+
+			if err := handler.persistRefreshToken(user, ""); err != nil {
+				log.Warn().Err(err).Msg("unable to remove the OAuth refresh token")
+			}
+
+			if err := handler.persistIDToken(user, ""); err != nil {
+				log.Warn().Err(err).Msg("unable to remove the OAuth id_token")
+			}
+		}
+
+		if err := handler.JWTService.RevokeToken(tokenData.Token); err != nil {
+			log.Warn().Err(err).Msg("unable to revoke the session")
+		}
+
+		handler.bouncer.RevokeJWT(tokenData.Token)
 	}
 
 	security.RemoveAuthCookie(w)
 
-	handler.bouncer.RevokeJWT(tokenData.Token)
+	if resp.LogoutURL == "" {
+		return response.Empty(w)
+	}
+
+	return response.JSON(w, resp)
+}
+
+// oauthLogoutURL returns the URL ending the session of an OAuth user on the provider,
+// it is empty when the user did not log in through OAuth or RP-initiated logout is disabled.
+// The user is only sent back to Portainer when the instance URL is configured
+func (handler *Handler) oauthLogoutURL(user *portainer.User) string {
+	settings, err := handler.DataStore.Settings().Settings()
+	if err != nil {
+		log.Warn().Err(err).Msg("unable to retrieve the settings from the database")
+
+		return ""
+	}
+
+	if !settings.IsAuthenticationMethodEnabled(portainer.AuthenticationOAuth) || settings.UserAuthenticationMethod(user) != portainer.AuthenticationOAuth {
+		return ""
+	}
+
+	postLogoutRedirectURI := ""
+	if settings.InstanceURL != "" {
+		postLogoutRedirectURI = instanceURL(settings) + "/"
+	}
+
+	logoutURL, err := oauth.LogoutURL(&settings.OAuthSettings, user.OAuthIDToken, postLogoutRedirectURI)
+	if err != nil {
+		log.Warn().Err(err).Msg("unable to create the OAuth logout URL")
+	}
+
+	return logoutURL
+}
+
+// persistIDToken saves the id_token of the last OAuth login of the user, an empty token removes it
+func (handler *Handler) persistIDToken(user *portainer.User, idToken string) error {
+	if user.OAuthIDToken == idToken {
+		return nil
+	}
+
+	return handler.DataStore.UpdateTx(func(tx dataservices.DataStoreTx) error {
+		u, err := tx.User().Read(user.ID)
+		if err != nil {
+			return err
+		}
+
+		u.OAuthIDToken = idToken
+		user.OAuthIDToken = idToken
 
-	return response.Empty(w)
+		return tx.User().Update(u.ID, u)
+	})
 }
@@ -0,0 +1,86 @@
+package auth
+
+import (
+	"errors"
+	"net/http"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/authprovider"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/request"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+)
+
+type authProviderResponse struct {
+	authprovider.Info
+	// Whether users can authenticate with the provider
+	Enabled bool `json:"Enabled" example:"true"`
+	// Whether the provider is the default authentication method
+	Default bool `json:"Default" example:"false"`
+}
+
+// @id AuthProviderList
+// @summary List the authentication providers
+// @description List the registered authentication providers along with their capabilities.
+// @description **Access policy**: public
+// @tags auth
+// @produce json
+// @success 200 {array} authProviderResponse "Success"
+// @failure 500 "Server error"
+// @router /auth/providers [get]
+func (handler *Handler) authProviderList(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	settings, err := handler.DataStore.Settings().Settings()
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve settings from the database", err)
+	}
+
+	providers := authprovider.Providers()
+
+	resp := make([]authProviderResponse, 0, len(providers))
+	for _, provider := range providers {
+		info := provider.Info()
+
+		resp = append(resp, authProviderResponse{
+			Info:    info,
+			Enabled: settings.IsAuthenticationMethodEnabled(info.Method),
+			Default: settings.AuthenticationMethod == info.Method,
+		})
+	}
+
+	return response.JSON(w, resp)
+}
+
+// @id AuthProviderTest
+// @summary Test the connectivity of an authentication provider
+// @description Checks that the provider can reach its backend, using the saved settings.
+// @description **Access policy**: administrator
+// @tags auth
+// @security ApiKeyAuth
+// @security jwt
+// @param method path int true "Authentication method of the provider"
+// @success 204 "Success"
+// @failure 400 "The provider does not support connectivity tests"
+// @failure 404 "Provider not found"
+// @failure 500 "Connectivity test failed"
+// @router /auth/providers/{method}/test [post]
+func (handler *Handler) authProviderTest(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	method, err := request.RetrieveNumericRouteVariableValue(r, "method")
+	if err != nil {
+		return httperror.BadRequest("Invalid authentication method route variable", err)
+	}
+
+	provider, ok := authprovider.Lookup(portainer.AuthenticationMethod(method))
+	if !ok {
+		return httperror.NotFound("Unable to find an authentication provider for the specified method", errors.New("unknown authentication method"))
+	}
+
+	if err := provider.TestConnectivity(); err != nil {
+		if errors.Is(err, authprovider.ErrNotSupported) {
+			return httperror.BadRequest("The authentication provider does not support connectivity tests", err)
+		}
+
+		return httperror.InternalServerError("Unable to connect to the authentication provider", err)
+	}
+
+	return response.Empty(w)
+}
@@ -0,0 +1,156 @@
+package auth
+
+import (
+	"errors"
+	"net/http"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/apikey"
+	"github.com/portainer/portainer/api/dataservices"
+	httperrors "github.com/portainer/portainer/api/http/errors"
+	"github.com/portainer/portainer/api/http/security"
+	"github.com/portainer/portainer/api/oauth"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+
+	"github.com/rs/zerolog/log"
+)
+
+const refreshTokenKeyLen = 32
+
+// @id AuthRefresh
+// @summary Renew the OAuth session of the current user
+// @description Issues a new JWT for the current user while the session of the user is still valid on the OAuth
+// @description provider. Only the sessions of the users linked to an OAuth provider can be renewed, the other sessions
+// @description expire with the user session timeout.
+// @description **Access policy**: authenticated
+// @tags auth
+// @security ApiKeyAuth
+// @security jwt
+// @produce json
+// @success 200 {object} authenticateResponse "Success"
+// @failure 401 "Unauthorized"
+// @failure 403 "Session cannot be renewed"
+// @failure 500 "Server error"
+// @router /auth/refresh [post]
+func (handler *Handler) refresh(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	tokenData, err := security.RetrieveTokenData(r)
+	if err != nil {
+		return httperror.Unauthorized("Unable to retrieve user details from authentication token", err)
+	}
+
+	if tokenData.ImpersonatorID != 0 {
+		return httperror.Forbidden(errImpersonationNotAllowed.Error(), errImpersonationNotAllowed)
+	}
+
+	// the callers authenticated by an API key have no session to renew
+	if tokenData.Token == "" {
+		return httperror.Forbidden("Only the sessions can be renewed", httperrors.ErrUnauthorized)
+	}
+
+	user, err := handler.DataStore.User().Read(tokenData.ID)
+	if err != nil {
+		if handler.DataStore.IsErrObjectNotFound(err) {
+			return httperror.Unauthorized("Unable to find the user", err)
+		}
+
+		return httperror.InternalServerError("Unable to retrieve the user from the database", err)
+	}
+
+	settings, err := handler.DataStore.Settings().Settings()
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve settings from the database", err)
+	}
+
+	if settings.UserAuthenticationMethod(user) != portainer.AuthenticationOAuth {
+		return httperror.Forbidden("Only the sessions of the OAuth users can be renewed", httperrors.ErrUnauthorized)
+	}
+
+	if !settings.IsAuthenticationMethodEnabled(portainer.AuthenticationOAuth) {
+		return httperror.Forbidden("OAuth authentication is not enabled", httperrors.ErrUnauthorized)
+	}
+
+	if len(user.OAuthRefreshToken) == 0 {
+		return httperror.Forbidden("No OAuth refresh token available for this user", httperrors.ErrUnauthorized)
+	}
+
+	refreshToken, err := oauth.DecryptRefreshToken(user.OAuthRefreshToken, settings.OAuthSettings.RefreshTokenKey)
+	if err != nil {
+		return httperror.Forbidden("Unable to renew the session", err)
+	}
+
+	username, newRefreshToken, err := handler.OAuthService.Refresh(refreshToken, &settings.OAuthSettings)
+	if err != nil {
+		log.Debug().Err(err).Msg("OAuth refresh error")
+
+		if err := handler.persistRefreshToken(user, ""); err != nil {
+			log.Warn().Err(err).Msg("unable to remove the OAuth refresh token")
+		}
+
+		return httperror.Forbidden("Unable to renew the session through OAuth", httperrors.ErrUnauthorized)
+	}
+
+	if username != user.Username {
+		return httperror.Forbidden("OAuth identity does not match the current user", httperrors.ErrUnauthorized)
+	}
+
+	if err := handler.persistRefreshToken(user, newRefreshToken); err != nil {
+		return httperror.InternalServerError("Unable to persist the OAuth refresh token", err)
+	}
+
+	return handler.writeToken(w, r, user, false)
+}
+
+// persistRefreshToken encrypts and stores the OAuth refresh token of a user, an empty token removes it
+func (handler *Handler) persistRefreshToken(user *portainer.User, refreshToken string) error {
+	if refreshToken == "" && len(user.OAuthRefreshToken) == 0 {
+		return nil
+	}
+
+	return handler.DataStore.UpdateTx(func(tx dataservices.DataStoreTx) error {
+		u, err := tx.User().Read(user.ID)
+		if err != nil {
+			return err
+		}
+
+		u.OAuthRefreshToken = nil
+
+		if refreshToken != "" {
+			key, err := getOrCreateRefreshTokenKey(tx)
+			if err != nil {
+				return err
+			}
+
+			if u.OAuthRefreshToken, err = oauth.EncryptRefreshToken(refreshToken, key); err != nil {
+				return err
+			}
+		}
+
+		user.OAuthRefreshToken = u.OAuthRefreshToken
+
+		return tx.User().Update(u.ID, u)
+	})
+}
+
+func getOrCreateRefreshTokenKey(tx dataservices.DataStoreTx) ([]byte, error) {
+	settings, err := tx.Settings().Settings()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(settings.OAuthSettings.RefreshTokenKey) > 0 {
+		return settings.OAuthSettings.RefreshTokenKey, nil
+	}
+
+	key := apikey.GenerateRandomKey(refreshTokenKeyLen)
+	if key == nil {
+		return nil, errors.New("unable to generate the refresh token encryption key")
+	}
+
+	settings.OAuthSettings.RefreshTokenKey = key
+
+	if err := tx.Settings().UpdateSettings(settings); err != nil {
+		return nil, err
+	}
+
+	return key, nil
+}
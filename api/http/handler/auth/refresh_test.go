@@ -0,0 +1,81 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/apikey"
+	"github.com/portainer/portainer/api/dataservices"
+	"github.com/portainer/portainer/api/datastore"
+	"github.com/portainer/portainer/api/http/security"
+	"github.com/portainer/portainer/api/internal/testhelpers"
+	"github.com/portainer/portainer/api/jwt"
+	"github.com/portainer/portainer/api/lockout"
+
+	"github.com/stretchr/testify/require"
+)
+
+type testServices struct {
+	store         dataservices.DataStore
+	jwtService    portainer.JWTService
+	apiKeyService apikey.APIKeyService
+}
+
+func setupHandler(t *testing.T) (*Handler, testServices) {
+	_, store := datastore.MustNewTestStore(t, true, true)
+
+	jwtService, err := jwt.NewService("1h", store)
+	require.NoError(t, err)
+
+	apiKeyService := apikey.NewAPIKeyService(store.APIKeyRepository(), store.User())
+	requestBouncer := security.NewRequestBouncer(store, jwtService, apiKeyService)
+	rateLimiter := security.NewRateLimiter(100, time.Second, time.Hour)
+
+	h := NewHandler(requestBouncer, rateLimiter, security.NewPasswordStrengthChecker(store.SettingsService))
+	h.DataStore = store
+	h.JWTService = jwtService
+	h.LockoutService = lockout.NewService(store)
+
+	return h, testServices{store: store, jwtService: jwtService, apiKeyService: apiKeyService}
+}
+
+func TestRefresh(t *testing.T) {
+	h, services := setupHandler(t)
+
+	user := &portainer.User{ID: 2, Username: "standard", Role: portainer.StandardUserRole}
+	require.NoError(t, services.store.User().Create(user))
+
+	refresh := func(authenticate func(r *http.Request)) int {
+		req := httptest.NewRequest(http.MethodPost, "/auth/refresh", nil)
+		authenticate(req)
+
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+
+		return rr.Code
+	}
+
+	t.Run("impersonation session", func(t *testing.T) {
+		token, _, err := services.jwtService.GenerateImpersonationToken(&portainer.TokenData{ID: user.ID, Username: user.Username, Role: user.Role, ImpersonatorID: 1}, 15*time.Minute, "", "")
+		require.NoError(t, err)
+
+		require.Equal(t, http.StatusForbidden, refresh(func(r *http.Request) { testhelpers.AddTestSecurityCookie(r, token) }))
+	})
+
+	t.Run("session of a user not linked to an OAuth provider", func(t *testing.T) {
+		token, _, err := services.jwtService.GenerateToken(&portainer.TokenData{ID: user.ID, Username: user.Username, Role: user.Role})
+		require.NoError(t, err)
+
+		require.Equal(t, http.StatusForbidden, refresh(func(r *http.Request) { testhelpers.AddTestSecurityCookie(r, token) }))
+	})
+
+	t.Run("API key", func(t *testing.T) {
+		rawAPIKey, _, err := services.apiKeyService.GenerateApiKey(*user, "test")
+		require.NoError(t, err)
+
+		require.Equal(t, http.StatusForbidden, refresh(func(r *http.Request) { r.Header.Set("X-API-KEY", rawAPIKey) }))
+	})
+}
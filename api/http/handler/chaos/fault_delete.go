@@ -0,0 +1,34 @@
+package chaos
+
+import (
+	"net/http"
+
+	portainer "github.com/portainer/portainer/api"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/request"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+)
+
+// @id ChaosFaultDelete
+// @summary Stop simulating the failure of an environment
+// @description Remove the simulated failure of an environment, its requests are proxied normally again.
+// @description Only available when Portainer is started with --feat chaos.
+// @description **Access policy**: administrator
+// @tags chaos
+// @security ApiKeyAuth
+// @security jwt
+// @param endpointId path int true "Environment identifier"
+// @success 204 "Success"
+// @failure 400 "Invalid request"
+// @failure 403 "The chaos feature is not enabled"
+// @router /chaos/faults/{endpointId} [delete]
+func (handler *Handler) faultDelete(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	endpointID, err := request.RetrieveNumericRouteVariableValue(r, "endpointId")
+	if err != nil {
+		return httperror.BadRequest("Invalid environment identifier route variable", err)
+	}
+
+	handler.Registry.Delete(portainer.EndpointID(endpointID))
+
+	return response.Empty(w)
+}
@@ -0,0 +1,24 @@
+package chaos
+
+import (
+	"net/http"
+
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+)
+
+// @id ChaosFaultList
+// @summary List the simulated failures
+// @description List the failures simulated on the environments, the expired ones excluded.
+// @description Only available when Portainer is started with --feat chaos.
+// @description **Access policy**: administrator
+// @tags chaos
+// @security ApiKeyAuth
+// @security jwt
+// @produce json
+// @success 200 {array} chaos.Fault "Success"
+// @failure 403 "The chaos feature is not enabled"
+// @router /chaos/faults [get]
+func (handler *Handler) faultList(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	return response.JSON(w, handler.Registry.List())
+}
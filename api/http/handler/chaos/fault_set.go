@@ -0,0 +1,105 @@
+package chaos
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/chaos"
+	"github.com/portainer/portainer/api/http/security"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/request"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+
+	"github.com/rs/zerolog/log"
+)
+
+type faultSetPayload struct {
+	// Type of the fault: latency, timeout or disconnect
+	Type string `validate:"required" example:"latency" enums:"latency,timeout,disconnect"`
+	// Latency added to the requests in milliseconds, for the latency faults
+	LatencyMs int `example:"2000"`
+	// Time the requests hang for before they time out in milliseconds, for the timeout faults. 30s by default
+	TimeoutMs int `example:"30000"`
+	// Fraction of the requests affected, between 0 and 1. All the requests by default
+	Probability float64 `example:"0.5"`
+	// Duration of the fault in seconds, 24 hours at most
+	DurationSeconds int `validate:"required" example:"3600"`
+}
+
+func (payload *faultSetPayload) Validate(r *http.Request) error {
+	if payload.Type == "" {
+		return errors.New("Invalid fault type")
+	}
+
+	if payload.DurationSeconds <= 0 {
+		return errors.New("Invalid fault duration")
+	}
+
+	return nil
+}
+
+// @id ChaosFaultSet
+// @summary Simulate a failure of an environment
+// @description Inject a simulated failure into the requests proxied to an environment, replacing its previous one:
+// @description added latency, requests timing out with a 504, or an agent disconnect failing the requests with a 502
+// @description and the snapshots of the environment. The fault expires after its duration and is not persisted.
+// @description Only available when Portainer is started with --feat chaos.
+// @description **Access policy**: administrator
+// @tags chaos
+// @security ApiKeyAuth
+// @security jwt
+// @accept json
+// @produce json
+// @param endpointId path int true "Environment identifier"
+// @param body body faultSetPayload true "Fault to simulate"
+// @success 200 {object} chaos.Fault "Success"
+// @failure 400 "Invalid request"
+// @failure 403 "The chaos feature is not enabled"
+// @failure 404 "Environment not found"
+// @failure 500 "Server error"
+// @router /chaos/faults/{endpointId} [put]
+func (handler *Handler) faultSet(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	endpointID, err := request.RetrieveNumericRouteVariableValue(r, "endpointId")
+	if err != nil {
+		return httperror.BadRequest("Invalid environment identifier route variable", err)
+	}
+
+	var payload faultSetPayload
+	if err := request.DecodeAndValidateJSONPayload(r, &payload); err != nil {
+		return httperror.BadRequest("Invalid request payload", err)
+	}
+
+	endpoint, err := handler.DataStore.Endpoint().Endpoint(portainer.EndpointID(endpointID))
+	if handler.DataStore.IsErrObjectNotFound(err) {
+		return httperror.NotFound("Unable to find an environment with the specified identifier inside the database", err)
+	} else if err != nil {
+		return httperror.InternalServerError("Unable to find an environment with the specified identifier inside the database", err)
+	}
+
+	tokenData, err := security.RetrieveTokenData(r)
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve user details from authentication token", err)
+	}
+
+	fault, err := handler.Registry.Set(chaos.Fault{
+		EndpointID:  endpoint.ID,
+		Type:        payload.Type,
+		Latency:     time.Duration(payload.LatencyMs) * time.Millisecond,
+		Timeout:     time.Duration(payload.TimeoutMs) * time.Millisecond,
+		Probability: payload.Probability,
+		CreatedBy:   tokenData.Username,
+	}, time.Duration(payload.DurationSeconds)*time.Second)
+	if err != nil {
+		return httperror.BadRequest("Invalid fault", err)
+	}
+
+	log.Warn().
+		Int("endpoint_id", int(endpoint.ID)).
+		Str("type", fault.Type).
+		Str("created_by", fault.CreatedBy).
+		Msg("simulating a failure of the environment")
+
+	return response.JSON(w, fault)
+}
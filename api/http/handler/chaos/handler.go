@@ -0,0 +1,37 @@
+package chaos
+
+import (
+	"net/http"
+
+	"github.com/portainer/portainer/api/chaos"
+	"github.com/portainer/portainer/api/dataservices"
+	"github.com/portainer/portainer/api/http/middlewares"
+	"github.com/portainer/portainer/api/http/security"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+
+	"github.com/gorilla/mux"
+)
+
+// Handler is the HTTP handler used to inject simulated failures into the environments(endpoints).
+type Handler struct {
+	*mux.Router
+	DataStore dataservices.DataStore
+	Registry  *chaos.Registry
+}
+
+// NewHandler creates a handler to manage the simulated failures, only served when the chaos feature flag is enabled.
+func NewHandler(bouncer security.BouncerService, dataStore dataservices.DataStore) *Handler {
+	h := &Handler{
+		Router:    mux.NewRouter(),
+		DataStore: dataStore,
+		Registry:  chaos.DefaultRegistry,
+	}
+
+	h.Use(middlewares.FeatureFlag(dataStore.Settings(), chaos.Feature), bouncer.AdminAccess)
+
+	h.Handle("/chaos/faults", httperror.LoggerHandler(h.faultList)).Methods(http.MethodGet)
+	h.Handle("/chaos/faults/{endpointId}", httperror.LoggerHandler(h.faultSet)).Methods(http.MethodPut)
+	h.Handle("/chaos/faults/{endpointId}", httperror.LoggerHandler(h.faultDelete)).Methods(http.MethodDelete)
+
+	return h
+}
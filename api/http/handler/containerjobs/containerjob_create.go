@@ -0,0 +1,80 @@
+package containerjobs
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+	"github.com/portainer/portainer/api/http/security"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/request"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+)
+
+type containerJobCreatePayload struct {
+	// Name of the job
+	Name string `validate:"required" example:"nightly-backup"`
+	containerJobUpdatePayload
+}
+
+func (payload *containerJobCreatePayload) Validate(r *http.Request) error {
+	if payload.Name == "" {
+		return errors.New("Invalid container job name")
+	}
+
+	return payload.containerJobUpdatePayload.Validate(r)
+}
+
+// @id ContainerJobCreate
+// @summary Create a container job
+// @description Create a container job, which runs a container on a cron schedule on regular Docker environments, the
+// @description equivalent of the Edge jobs for the environments reachable by Portainer. The image is pulled before
+// @description each run, then the container is run until it exits or times out, and removed. The outcome and the end of
+// @description the output of each run are recorded.
+// @description **Access policy**: administrator
+// @tags container_jobs
+// @security ApiKeyAuth
+// @security jwt
+// @accept json
+// @produce json
+// @param body body containerJobCreatePayload true "Container job details"
+// @success 200 {object} containerJobResponse "Success"
+// @failure 400 "Invalid request"
+// @failure 500 "Server error"
+// @router /container_jobs [post]
+func (handler *Handler) containerJobCreate(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	var payload containerJobCreatePayload
+	if err := request.DecodeAndValidateJSONPayload(r, &payload); err != nil {
+		return httperror.BadRequest("Invalid request payload", err)
+	}
+
+	tokenData, err := security.RetrieveTokenData(r)
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve user details from authentication token", err)
+	}
+
+	job := &portainer.ContainerJob{
+		Name:         payload.Name,
+		CreationDate: time.Now().Unix(),
+		CreatedBy:    tokenData.Username,
+	}
+	payload.apply(job)
+
+	if err := handler.DataStore.UpdateTx(func(tx dataservices.DataStoreTx) error {
+		if err := checkEndpoints(tx, payload.Endpoints); err != nil {
+			return err
+		}
+
+		return tx.ContainerJob().Create(job)
+	}); err != nil {
+		return containerJobPersistError(err)
+	}
+
+	if err := handler.ContainerJobService.Schedule(job); err != nil {
+		return httperror.InternalServerError("Unable to schedule the container job", err)
+	}
+
+	return response.JSON(w, newContainerJobResponse(job))
+}
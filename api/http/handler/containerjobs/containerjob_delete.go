@@ -0,0 +1,43 @@
+package containerjobs
+
+import (
+	"net/http"
+
+	"github.com/portainer/portainer/api/dataservices"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+)
+
+// @id ContainerJobDelete
+// @summary Remove a container job
+// @description Remove a container job and the history of its runs. The runs in progress are not stopped.
+// @description **Access policy**: administrator
+// @tags container_jobs
+// @security ApiKeyAuth
+// @security jwt
+// @param id path int true "Container job identifier"
+// @success 204 "Success"
+// @failure 400 "Invalid request"
+// @failure 404 "Container job not found"
+// @failure 500 "Server error"
+// @router /container_jobs/{id} [delete]
+func (handler *Handler) containerJobDelete(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	job, httpErr := handler.readContainerJob(r)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	handler.ContainerJobService.Unschedule(job.ID)
+
+	if err := handler.DataStore.UpdateTx(func(tx dataservices.DataStoreTx) error {
+		if err := tx.ContainerJobRun().DeleteByJobID(job.ID); err != nil {
+			return err
+		}
+
+		return tx.ContainerJob().Delete(job.ID)
+	}); err != nil {
+		return httperror.InternalServerError("Unable to remove the container job from the database", err)
+	}
+
+	return response.Empty(w)
+}
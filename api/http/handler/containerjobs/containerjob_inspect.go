@@ -0,0 +1,30 @@
+package containerjobs
+
+import (
+	"net/http"
+
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+)
+
+// @id ContainerJobInspect
+// @summary Inspect a container job
+// @description **Access policy**: administrator
+// @tags container_jobs
+// @security ApiKeyAuth
+// @security jwt
+// @produce json
+// @param id path int true "Container job identifier"
+// @success 200 {object} containerJobResponse "Success"
+// @failure 400 "Invalid request"
+// @failure 404 "Container job not found"
+// @failure 500 "Server error"
+// @router /container_jobs/{id} [get]
+func (handler *Handler) containerJobInspect(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	job, httpErr := handler.readContainerJob(r)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	return response.JSON(w, newContainerJobResponse(job))
+}
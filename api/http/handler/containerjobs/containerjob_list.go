@@ -0,0 +1,32 @@
+package containerjobs
+
+import (
+	"net/http"
+
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+)
+
+// @id ContainerJobList
+// @summary List the container jobs
+// @description **Access policy**: administrator
+// @tags container_jobs
+// @security ApiKeyAuth
+// @security jwt
+// @produce json
+// @success 200 {array} containerJobResponse "Success"
+// @failure 500 "Server error"
+// @router /container_jobs [get]
+func (handler *Handler) containerJobList(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	jobs, err := handler.DataStore.ContainerJob().ReadAll()
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve the container jobs from the database", err)
+	}
+
+	responses := make([]containerJobResponse, 0, len(jobs))
+	for i := range jobs {
+		responses = append(responses, newContainerJobResponse(&jobs[i]))
+	}
+
+	return response.JSON(w, responses)
+}
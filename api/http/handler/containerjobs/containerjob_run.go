@@ -0,0 +1,37 @@
+package containerjobs
+
+import (
+	"net/http"
+
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+)
+
+// @id ContainerJobRun
+// @summary Run a container job now
+// @description Start a run of a container job on each of its environments, including a paused job. The runs complete
+// @description in the background, an environment still running the previous run of the job is skipped.
+// @description **Access policy**: administrator
+// @tags container_jobs
+// @security ApiKeyAuth
+// @security jwt
+// @produce json
+// @param id path int true "Container job identifier"
+// @success 200 {array} portainer.ContainerJobRun "Success"
+// @failure 400 "Invalid request"
+// @failure 404 "Container job not found"
+// @failure 500 "Server error"
+// @router /container_jobs/{id}/run [post]
+func (handler *Handler) containerJobRun(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	job, httpErr := handler.readContainerJob(r)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	runs, err := handler.ContainerJobService.Run(job.ID, true)
+	if err != nil {
+		return httperror.InternalServerError("Unable to run the container job", err)
+	}
+
+	return response.JSON(w, runs)
+}
@@ -0,0 +1,98 @@
+package containerjobs
+
+import (
+	"errors"
+	"net/http"
+	"slices"
+
+	portainer "github.com/portainer/portainer/api"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/request"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+)
+
+// @id ContainerJobRunList
+// @summary List the runs of a container job
+// @description List the runs of a container job, the most recent first, without their output.
+// @description **Access policy**: administrator
+// @tags container_jobs
+// @security ApiKeyAuth
+// @security jwt
+// @produce json
+// @param id path int true "Container job identifier"
+// @param endpointId query int false "Only list the runs on this environment"
+// @param status query string false "Only list the runs with this status" Enums(running, succeeded, failed)
+// @success 200 {array} portainer.ContainerJobRun "Success"
+// @failure 400 "Invalid request"
+// @failure 404 "Container job not found"
+// @failure 500 "Server error"
+// @router /container_jobs/{id}/runs [get]
+func (handler *Handler) containerJobRunList(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	job, httpErr := handler.readContainerJob(r)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	endpointID, _ := request.RetrieveNumericQueryParameter(r, "endpointId", true)
+	status, _ := request.RetrieveQueryParameter(r, "status", true)
+
+	runs, err := handler.DataStore.ContainerJobRun().RunsByJobID(job.ID)
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve the runs of the container job from the database", err)
+	}
+
+	runs = slices.DeleteFunc(runs, func(run portainer.ContainerJobRun) bool {
+		return (endpointID != 0 && run.EndpointID != portainer.EndpointID(endpointID)) ||
+			(status != "" && run.Status != portainer.ContainerJobRunStatus(status))
+	})
+
+	slices.SortFunc(runs, func(a, b portainer.ContainerJobRun) int {
+		return int(b.ID - a.ID)
+	})
+
+	for i := range runs {
+		runs[i].Logs = ""
+	}
+
+	return response.JSON(w, runs)
+}
+
+// @id ContainerJobRunInspect
+// @summary Inspect a run of a container job
+// @description Retrieve a run of a container job with the end of the output of its container.
+// @description **Access policy**: administrator
+// @tags container_jobs
+// @security ApiKeyAuth
+// @security jwt
+// @produce json
+// @param id path int true "Container job identifier"
+// @param runId path int true "Run identifier"
+// @success 200 {object} portainer.ContainerJobRun "Success"
+// @failure 400 "Invalid request"
+// @failure 404 "Container job or run not found"
+// @failure 500 "Server error"
+// @router /container_jobs/{id}/runs/{runId} [get]
+func (handler *Handler) containerJobRunInspect(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	job, httpErr := handler.readContainerJob(r)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	runID, err := request.RetrieveNumericRouteVariableValue(r, "runId")
+	if err != nil {
+		return httperror.BadRequest("Invalid run identifier route variable", err)
+	}
+
+	run, err := handler.DataStore.ContainerJobRun().Read(portainer.ContainerJobRunID(runID))
+	if handler.DataStore.IsErrObjectNotFound(err) {
+		return httperror.NotFound("Unable to find a run with the specified identifier inside the database", err)
+	} else if err != nil {
+		return httperror.InternalServerError("Unable to find a run with the specified identifier inside the database", err)
+	}
+
+	if run.JobID != job.ID {
+		return httperror.NotFound("Unable to find a run with the specified identifier inside the database", errors.New("the run belongs to another job"))
+	}
+
+	return response.JSON(w, run)
+}
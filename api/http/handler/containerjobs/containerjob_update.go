@@ -0,0 +1,157 @@
+package containerjobs
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+	"github.com/portainer/portainer/api/docker/containerjobs"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/request"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+)
+
+type containerJobUpdatePayload struct {
+	// Image of the container, pulled before each run
+	Image string `validate:"required" example:"alpine:3.20"`
+	// Command of the container, the command of the image when empty
+	Command []string `example:"sh,-c,echo hello"`
+	// Environment variables of the container
+	Env []portainer.Pair
+	// Standard cron expression of the schedule, with five fields
+	CronExpression string `validate:"required" example:"0 2 * * *"`
+	// Regular Docker environments the container is run on
+	Endpoints []portainer.EndpointID `validate:"required" example:"1"`
+	// Pause the scheduled runs
+	Paused bool `example:"false"`
+	// Duration in seconds after which a run is stopped, 3600 by default
+	Timeout int `example:"3600"`
+}
+
+func (payload *containerJobUpdatePayload) Validate(r *http.Request) error {
+	if payload.Image == "" {
+		return errors.New("Invalid image")
+	}
+
+	if _, err := containerjobs.ParseSchedule(payload.CronExpression); err != nil {
+		return fmt.Errorf("Invalid cron expression: %w", err)
+	}
+
+	if len(payload.Endpoints) == 0 {
+		return errors.New("At least one environment is required")
+	}
+
+	if payload.Timeout < 0 {
+		return errors.New("Invalid timeout")
+	}
+
+	for _, pair := range payload.Env {
+		if pair.Name == "" {
+			return errors.New("Invalid environment variable name")
+		}
+	}
+
+	return nil
+}
+
+// apply sets the container, the schedule and the environments of the job
+func (payload *containerJobUpdatePayload) apply(job *portainer.ContainerJob) {
+	job.Image = payload.Image
+	job.Command = payload.Command
+	job.Env = payload.Env
+	job.CronExpression = payload.CronExpression
+	job.Endpoints = slices.Compact(slices.Sorted(slices.Values(payload.Endpoints)))
+	job.Paused = payload.Paused
+	job.Timeout = payload.Timeout
+
+	if job.Timeout == 0 {
+		job.Timeout = containerjobs.DefaultTimeout
+	}
+}
+
+// @id ContainerJobUpdate
+// @summary Update a container job
+// @description Update the container, the schedule and the environments of a container job. The runs in progress
+// @description are not stopped.
+// @description **Access policy**: administrator
+// @tags container_jobs
+// @security ApiKeyAuth
+// @security jwt
+// @accept json
+// @produce json
+// @param id path int true "Container job identifier"
+// @param body body containerJobUpdatePayload true "Container job details"
+// @success 200 {object} containerJobResponse "Success"
+// @failure 400 "Invalid request"
+// @failure 404 "Container job not found"
+// @failure 500 "Server error"
+// @router /container_jobs/{id} [put]
+func (handler *Handler) containerJobUpdate(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	var payload containerJobUpdatePayload
+	if err := request.DecodeAndValidateJSONPayload(r, &payload); err != nil {
+		return httperror.BadRequest("Invalid request payload", err)
+	}
+
+	job, httpErr := handler.readContainerJob(r)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	if err := handler.DataStore.UpdateTx(func(tx dataservices.DataStoreTx) error {
+		if err := checkEndpoints(tx, payload.Endpoints); err != nil {
+			return err
+		}
+
+		payload.apply(job)
+
+		return tx.ContainerJob().Update(job.ID, job)
+	}); err != nil {
+		return containerJobPersistError(err)
+	}
+
+	if err := handler.ContainerJobService.Schedule(job); err != nil {
+		return httperror.InternalServerError("Unable to schedule the container job", err)
+	}
+
+	return response.JSON(w, newContainerJobResponse(job))
+}
+
+// endpointError is returned when a job targets an environment it cannot run on
+type endpointError struct {
+	endpointID portainer.EndpointID
+	err        error
+}
+
+func (e *endpointError) Error() string {
+	return fmt.Sprintf("environment %d: %s", e.endpointID, e.err)
+}
+
+// checkEndpoints checks that the environments exist and that the jobs can run on them
+func checkEndpoints(tx dataservices.DataStoreTx, endpointIDs []portainer.EndpointID) error {
+	for _, endpointID := range endpointIDs {
+		endpoint, err := tx.Endpoint().Endpoint(endpointID)
+		if tx.IsErrObjectNotFound(err) {
+			return &endpointError{endpointID: endpointID, err: errors.New("the environment does not exist")}
+		} else if err != nil {
+			return err
+		}
+
+		if err := containerjobs.CheckEndpoint(endpoint); err != nil {
+			return &endpointError{endpointID: endpointID, err: err}
+		}
+	}
+
+	return nil
+}
+
+func containerJobPersistError(err error) *httperror.HandlerError {
+	var endpointErr *endpointError
+	if errors.As(err, &endpointErr) {
+		return httperror.BadRequest("Invalid environment", err)
+	}
+
+	return httperror.InternalServerError("Unable to persist the container job inside the database", err)
+}
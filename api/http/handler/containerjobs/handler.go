@@ -0,0 +1,83 @@
+package containerjobs
+
+import (
+	"net/http"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+	"github.com/portainer/portainer/api/docker/containerjobs"
+	"github.com/portainer/portainer/api/http/security"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/request"
+
+	"github.com/gorilla/mux"
+)
+
+// Handler is the HTTP handler used to manage the containers run on a schedule on the regular Docker environments.
+type Handler struct {
+	*mux.Router
+	DataStore           dataservices.DataStore
+	ContainerJobService *containerjobs.Service
+}
+
+// NewHandler creates a handler to manage the container jobs.
+func NewHandler(bouncer security.BouncerService, dataStore dataservices.DataStore, containerJobService *containerjobs.Service) *Handler {
+	h := &Handler{
+		Router:              mux.NewRouter(),
+		DataStore:           dataStore,
+		ContainerJobService: containerJobService,
+	}
+
+	h.Handle("/container_jobs",
+		bouncer.AdminAccess(httperror.LoggerHandler(h.containerJobCreate))).Methods(http.MethodPost)
+	h.Handle("/container_jobs",
+		bouncer.AdminAccess(httperror.LoggerHandler(h.containerJobList))).Methods(http.MethodGet)
+	h.Handle("/container_jobs/{id}",
+		bouncer.AdminAccess(httperror.LoggerHandler(h.containerJobInspect))).Methods(http.MethodGet)
+	h.Handle("/container_jobs/{id}",
+		bouncer.AdminAccess(httperror.LoggerHandler(h.containerJobUpdate))).Methods(http.MethodPut)
+	h.Handle("/container_jobs/{id}",
+		bouncer.AdminAccess(httperror.LoggerHandler(h.containerJobDelete))).Methods(http.MethodDelete)
+	h.Handle("/container_jobs/{id}/run",
+		bouncer.AdminAccess(httperror.LoggerHandler(h.containerJobRun))).Methods(http.MethodPost)
+	h.Handle("/container_jobs/{id}/runs",
+		bouncer.AdminAccess(httperror.LoggerHandler(h.containerJobRunList))).Methods(http.MethodGet)
+	h.Handle("/container_jobs/{id}/runs/{runId}",
+		bouncer.AdminAccess(httperror.LoggerHandler(h.containerJobRunInspect))).Methods(http.MethodGet)
+
+	return h
+}
+
+// containerJobResponse is a container job with the time of its next scheduled run
+type containerJobResponse struct {
+	*portainer.ContainerJob
+	// Unix timestamp of the next scheduled run, 0 when the job is paused
+	NextRunAt int64 `json:"NextRunAt" example:"1587399600"`
+}
+
+func newContainerJobResponse(job *portainer.ContainerJob) containerJobResponse {
+	resp := containerJobResponse{ContainerJob: job}
+
+	if schedule, err := containerjobs.ParseSchedule(job.CronExpression); err == nil && !job.Paused {
+		resp.NextRunAt = schedule.Next(time.Now()).Unix()
+	}
+
+	return resp
+}
+
+func (handler *Handler) readContainerJob(r *http.Request) (*portainer.ContainerJob, *httperror.HandlerError) {
+	id, err := request.RetrieveNumericRouteVariableValue(r, "id")
+	if err != nil {
+		return nil, httperror.BadRequest("Invalid container job identifier route variable", err)
+	}
+
+	job, err := handler.DataStore.ContainerJob().Read(portainer.ContainerJobID(id))
+	if handler.DataStore.IsErrObjectNotFound(err) {
+		return nil, httperror.NotFound("Unable to find a container job with the specified identifier inside the database", err)
+	} else if err != nil {
+		return nil, httperror.InternalServerError("Unable to find a container job with the specified identifier inside the database", err)
+	}
+
+	return job, nil
+}
@@ -40,6 +40,8 @@ func NewHandler(bouncer security.BouncerService, dataStore dataservices.DataStor
 		bouncer.AuthenticatedAccess(httperror.LoggerHandler(h.customTemplateInspect))).Methods(http.MethodGet)
 	h.Handle("/custom_templates/{id}/file",
 		bouncer.AuthenticatedAccess(httperror.LoggerHandler(h.customTemplateFile))).Methods(http.MethodGet)
+	h.Handle("/custom_templates/{id}/schema",
+		bouncer.AuthenticatedAccess(httperror.LoggerHandler(h.customTemplateSchema))).Methods(http.MethodGet)
 	h.Handle("/custom_templates/{id}",
 		bouncer.AuthenticatedAccess(httperror.LoggerHandler(h.customTemplateUpdate))).Methods(http.MethodPut)
 	h.Handle("/custom_templates/{id}",
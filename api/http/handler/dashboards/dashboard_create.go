@@ -0,0 +1,70 @@
+package dashboards
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dashboard"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/request"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+)
+
+type dashboardPayload struct {
+	// Name of the dashboard
+	Name string `validate:"required" example:"operations"`
+	// Teams the dashboard is shown to, empty to show it to every user
+	TeamIDs []portainer.TeamID `example:"1"`
+	// Widgets of the dashboard, in their display order
+	Widgets []portainer.DashboardWidget
+}
+
+func (payload *dashboardPayload) Validate(r *http.Request) error {
+	if payload.Name == "" {
+		return errors.New("Invalid dashboard name")
+	}
+
+	for i, widget := range payload.Widgets {
+		if err := dashboard.ValidateWidget(widget); err != nil {
+			return fmt.Errorf("Invalid widget %d: %w", i, err)
+		}
+	}
+
+	return nil
+}
+
+// @id DashboardCreate
+// @summary Create a home dashboard
+// @description Create a home dashboard composed of widgets: the status of the environments, the recent deployments,
+// @description the alerts and the top resource consumers.
+// @description **Access policy**: administrator
+// @tags dashboards
+// @security ApiKeyAuth
+// @security jwt
+// @accept json
+// @produce json
+// @param body body dashboardPayload true "Dashboard details"
+// @success 200 {object} portainer.Dashboard "Success"
+// @failure 400 "Invalid request"
+// @failure 500 "Server error"
+// @router /dashboards [post]
+func (handler *Handler) dashboardCreate(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	var payload dashboardPayload
+	if err := request.DecodeAndValidateJSONPayload(r, &payload); err != nil {
+		return httperror.BadRequest("Invalid request payload", err)
+	}
+
+	dashboard := &portainer.Dashboard{
+		Name:    payload.Name,
+		TeamIDs: payload.TeamIDs,
+		Widgets: payload.Widgets,
+	}
+
+	if err := handler.DataStore.Dashboard().Create(dashboard); err != nil {
+		return httperror.InternalServerError("Unable to persist the dashboard inside the database", err)
+	}
+
+	return response.JSON(w, dashboard)
+}
@@ -0,0 +1,168 @@
+package dashboards
+
+import (
+	"cmp"
+	"errors"
+	"net/http"
+	"slices"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dashboard"
+	"github.com/portainer/portainer/api/dataservices"
+	"github.com/portainer/portainer/api/http/security"
+	"github.com/portainer/portainer/api/internal/authorization"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+)
+
+type dashboardDataResponse struct {
+	Dashboard portainer.Dashboard `json:"Dashboard"`
+	// Widgets of the dashboard with their data
+	Widgets []dashboard.WidgetData `json:"Widgets"`
+}
+
+// @id DashboardData
+// @summary Fetch the widgets of a home dashboard
+// @description Compute the data of every widget of a home dashboard, from the environments and the stacks the user
+// @description can access.
+// @description **Access policy**: authenticated
+// @tags dashboards
+// @security ApiKeyAuth
+// @security jwt
+// @produce json
+// @param id path int true "Dashboard identifier"
+// @success 200 {object} dashboardDataResponse "Success"
+// @failure 400 "Invalid request"
+// @failure 403 "Permission denied"
+// @failure 404 "Dashboard not found"
+// @failure 500 "Server error"
+// @router /dashboards/{id}/data [get]
+func (handler *Handler) dashboardData(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	dashboard, httpErr := handler.retrieveDashboard(r)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	return handler.writeDashboardData(w, r, dashboard)
+}
+
+// @id DashboardHome
+// @summary Fetch the home dashboard of the user
+// @description Compute the data of every widget of the home dashboard of the user: the first dashboard of their
+// @description teams, otherwise the first dashboard without teams.
+// @description **Access policy**: authenticated
+// @tags dashboards
+// @security ApiKeyAuth
+// @security jwt
+// @produce json
+// @success 200 {object} dashboardDataResponse "Success"
+// @failure 404 "No dashboard is shown to the user"
+// @failure 500 "Server error"
+// @router /dashboards/home [get]
+func (handler *Handler) dashboardHome(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	securityContext, err := security.RetrieveRestrictedRequestContext(r)
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve info from request context", err)
+	}
+
+	dashboards, err := handler.DataStore.Dashboard().ReadAll()
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve the dashboards from the database", err)
+	}
+
+	slices.SortFunc(dashboards, func(a, b portainer.Dashboard) int { return cmp.Compare(a.ID, b.ID) })
+
+	i := slices.IndexFunc(dashboards, func(dashboard portainer.Dashboard) bool {
+		return isTeamDashboard(&dashboard, securityContext)
+	})
+	if i == -1 {
+		i = slices.IndexFunc(dashboards, func(dashboard portainer.Dashboard) bool { return len(dashboard.TeamIDs) == 0 })
+	}
+
+	if i == -1 {
+		return httperror.NotFound("No dashboard is shown to the user", errors.New("no dashboard of the teams of the user nor without teams"))
+	}
+
+	return handler.writeDashboardData(w, r, &dashboards[i])
+}
+
+func (handler *Handler) writeDashboardData(w http.ResponseWriter, r *http.Request, d *portainer.Dashboard) *httperror.HandlerError {
+	securityContext, err := security.RetrieveRestrictedRequestContext(r)
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve info from request context", err)
+	}
+
+	var widgets []dashboard.WidgetData
+
+	err = handler.DataStore.ViewTx(func(tx dataservices.DataStoreTx) error {
+		scope, err := userScope(tx, securityContext)
+		if err != nil {
+			return err
+		}
+
+		widgets, err = dashboard.Compute(tx, d, scope)
+
+		return err
+	})
+	if err != nil {
+		return httperror.InternalServerError("Unable to compute the dashboard widgets", err)
+	}
+
+	return response.JSON(w, dashboardDataResponse{Dashboard: *d, Widgets: widgets})
+}
+
+// userScope returns the environments and the stacks the user can access, and the edge stacks for the administrators
+func userScope(tx dataservices.DataStoreTx, securityContext *security.RestrictedRequestContext) (dashboard.Scope, error) {
+	var scope dashboard.Scope
+
+	endpoints, err := tx.Endpoint().Endpoints()
+	if err != nil {
+		return scope, err
+	}
+
+	groups, err := tx.EndpointGroup().ReadAll()
+	if err != nil {
+		return scope, err
+	}
+
+	stacks, err := tx.Stack().ReadAll()
+	if err != nil {
+		return scope, err
+	}
+
+	scope.Endpoints = security.FilterEndpoints(endpoints, groups, securityContext)
+
+	if securityContext.IsAdmin {
+		scope.Stacks = stacks
+
+		scope.EdgeStacks, err = tx.EdgeStack().EdgeStacks()
+
+		return scope, err
+	}
+
+	resourceControls, err := tx.ResourceControl().ReadAll()
+	if err != nil {
+		return scope, err
+	}
+
+	user, err := tx.User().Read(securityContext.UserID)
+	if err != nil {
+		return scope, err
+	}
+
+	userTeamIDs := make([]portainer.TeamID, 0, len(securityContext.UserMemberships))
+	for _, membership := range securityContext.UserMemberships {
+		userTeamIDs = append(userTeamIDs, membership.TeamID)
+	}
+
+	stacks = authorization.FilterAuthorizedStacks(authorization.DecorateStacks(stacks, resourceControls), user, userTeamIDs)
+
+	endpointIDs := make(map[portainer.EndpointID]bool, len(scope.Endpoints))
+	for _, endpoint := range scope.Endpoints {
+		endpointIDs[endpoint.ID] = true
+	}
+
+	scope.Stacks = slices.DeleteFunc(stacks, func(stack portainer.Stack) bool { return !endpointIDs[stack.EndpointID] })
+
+	return scope, nil
+}
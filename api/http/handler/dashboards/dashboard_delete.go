@@ -0,0 +1,42 @@
+package dashboards
+
+import (
+	"net/http"
+
+	portainer "github.com/portainer/portainer/api"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/request"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+)
+
+// @id DashboardDelete
+// @summary Remove a home dashboard
+// @description Remove a home dashboard.
+// @description **Access policy**: administrator
+// @tags dashboards
+// @security ApiKeyAuth
+// @security jwt
+// @param id path int true "Dashboard identifier"
+// @success 204 "Success"
+// @failure 400 "Invalid request"
+// @failure 404 "Dashboard not found"
+// @failure 500 "Server error"
+// @router /dashboards/{id} [delete]
+func (handler *Handler) dashboardDelete(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	id, err := request.RetrieveNumericRouteVariableValue(r, "id")
+	if err != nil {
+		return httperror.BadRequest("Invalid dashboard identifier route variable", err)
+	}
+
+	if _, err := handler.DataStore.Dashboard().Read(portainer.DashboardID(id)); handler.DataStore.IsErrObjectNotFound(err) {
+		return httperror.NotFound("Unable to find a dashboard with the specified identifier inside the database", err)
+	} else if err != nil {
+		return httperror.InternalServerError("Unable to find a dashboard with the specified identifier inside the database", err)
+	}
+
+	if err := handler.DataStore.Dashboard().Delete(portainer.DashboardID(id)); err != nil {
+		return httperror.InternalServerError("Unable to remove the dashboard from the database", err)
+	}
+
+	return response.Empty(w)
+}
@@ -0,0 +1,62 @@
+package dashboards
+
+import (
+	"errors"
+	"net/http"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/http/security"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/request"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+)
+
+// @id DashboardInspect
+// @summary Inspect a home dashboard
+// @description Retrieve the definition of a home dashboard shown to the user.
+// @description **Access policy**: authenticated
+// @tags dashboards
+// @security ApiKeyAuth
+// @security jwt
+// @produce json
+// @param id path int true "Dashboard identifier"
+// @success 200 {object} portainer.Dashboard "Success"
+// @failure 400 "Invalid request"
+// @failure 403 "Permission denied"
+// @failure 404 "Dashboard not found"
+// @failure 500 "Server error"
+// @router /dashboards/{id} [get]
+func (handler *Handler) dashboardInspect(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	dashboard, httpErr := handler.retrieveDashboard(r)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	return response.JSON(w, dashboard)
+}
+
+// retrieveDashboard reads the dashboard of the id route variable, ensuring it is shown to the user
+func (handler *Handler) retrieveDashboard(r *http.Request) (*portainer.Dashboard, *httperror.HandlerError) {
+	id, err := request.RetrieveNumericRouteVariableValue(r, "id")
+	if err != nil {
+		return nil, httperror.BadRequest("Invalid dashboard identifier route variable", err)
+	}
+
+	securityContext, err := security.RetrieveRestrictedRequestContext(r)
+	if err != nil {
+		return nil, httperror.InternalServerError("Unable to retrieve info from request context", err)
+	}
+
+	dashboard, err := handler.DataStore.Dashboard().Read(portainer.DashboardID(id))
+	if handler.DataStore.IsErrObjectNotFound(err) {
+		return nil, httperror.NotFound("Unable to find a dashboard with the specified identifier inside the database", err)
+	} else if err != nil {
+		return nil, httperror.InternalServerError("Unable to find a dashboard with the specified identifier inside the database", err)
+	}
+
+	if !canView(dashboard, securityContext) {
+		return nil, httperror.Forbidden("Access denied to the dashboard", errors.New("the dashboard is not shown to the teams of the user"))
+	}
+
+	return dashboard, nil
+}
@@ -0,0 +1,53 @@
+package dashboards
+
+import (
+	"net/http"
+	"slices"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/http/security"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+)
+
+// @id DashboardList
+// @summary List the home dashboards
+// @description List the home dashboards shown to the user: the ones of their teams and the ones without teams.
+// @description The administrators see all the dashboards.
+// @description **Access policy**: authenticated
+// @tags dashboards
+// @security ApiKeyAuth
+// @security jwt
+// @produce json
+// @success 200 {array} portainer.Dashboard "Success"
+// @failure 500 "Server error"
+// @router /dashboards [get]
+func (handler *Handler) dashboardList(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	securityContext, err := security.RetrieveRestrictedRequestContext(r)
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve info from request context", err)
+	}
+
+	dashboards, err := handler.DataStore.Dashboard().ReadAll()
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve the dashboards from the database", err)
+	}
+
+	dashboards = slices.DeleteFunc(dashboards, func(dashboard portainer.Dashboard) bool {
+		return !canView(&dashboard, securityContext)
+	})
+
+	return response.JSON(w, dashboards)
+}
+
+// canView returns whether a dashboard is shown to the user: the administrators see all of them, the other users see
+// the ones of their teams and the ones without teams
+func canView(dashboard *portainer.Dashboard, securityContext *security.RestrictedRequestContext) bool {
+	return securityContext.IsAdmin || len(dashboard.TeamIDs) == 0 || isTeamDashboard(dashboard, securityContext)
+}
+
+func isTeamDashboard(dashboard *portainer.Dashboard, securityContext *security.RestrictedRequestContext) bool {
+	return slices.ContainsFunc(securityContext.UserMemberships, func(membership portainer.TeamMembership) bool {
+		return slices.Contains(dashboard.TeamIDs, membership.TeamID)
+	})
+}
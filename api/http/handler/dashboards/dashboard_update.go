@@ -0,0 +1,55 @@
+package dashboards
+
+import (
+	"net/http"
+
+	portainer "github.com/portainer/portainer/api"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/request"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+)
+
+// @id DashboardUpdate
+// @summary Update a home dashboard
+// @description Replace the name, the teams and the widgets of a home dashboard.
+// @description **Access policy**: administrator
+// @tags dashboards
+// @security ApiKeyAuth
+// @security jwt
+// @accept json
+// @produce json
+// @param id path int true "Dashboard identifier"
+// @param body body dashboardPayload true "Dashboard details"
+// @success 200 {object} portainer.Dashboard "Success"
+// @failure 400 "Invalid request"
+// @failure 404 "Dashboard not found"
+// @failure 500 "Server error"
+// @router /dashboards/{id} [put]
+func (handler *Handler) dashboardUpdate(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	id, err := request.RetrieveNumericRouteVariableValue(r, "id")
+	if err != nil {
+		return httperror.BadRequest("Invalid dashboard identifier route variable", err)
+	}
+
+	var payload dashboardPayload
+	if err := request.DecodeAndValidateJSONPayload(r, &payload); err != nil {
+		return httperror.BadRequest("Invalid request payload", err)
+	}
+
+	dashboard, err := handler.DataStore.Dashboard().Read(portainer.DashboardID(id))
+	if handler.DataStore.IsErrObjectNotFound(err) {
+		return httperror.NotFound("Unable to find a dashboard with the specified identifier inside the database", err)
+	} else if err != nil {
+		return httperror.InternalServerError("Unable to find a dashboard with the specified identifier inside the database", err)
+	}
+
+	dashboard.Name = payload.Name
+	dashboard.TeamIDs = payload.TeamIDs
+	dashboard.Widgets = payload.Widgets
+
+	if err := handler.DataStore.Dashboard().Update(dashboard.ID, dashboard); err != nil {
+		return httperror.InternalServerError("Unable to persist the dashboard changes inside the database", err)
+	}
+
+	return response.JSON(w, dashboard)
+}
@@ -0,0 +1,42 @@
+package dashboards
+
+import (
+	"net/http"
+
+	"github.com/portainer/portainer/api/dataservices"
+	"github.com/portainer/portainer/api/http/security"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+
+	"github.com/gorilla/mux"
+)
+
+// Handler is the HTTP handler used to manage the home dashboards and fetch their widgets.
+type Handler struct {
+	*mux.Router
+	DataStore dataservices.DataStore
+}
+
+// NewHandler creates a handler to manage the home dashboards.
+func NewHandler(bouncer security.BouncerService, dataStore dataservices.DataStore) *Handler {
+	h := &Handler{
+		Router:    mux.NewRouter(),
+		DataStore: dataStore,
+	}
+
+	h.Handle("/dashboards",
+		bouncer.AdminAccess(httperror.LoggerHandler(h.dashboardCreate))).Methods(http.MethodPost)
+	h.Handle("/dashboards",
+		bouncer.AuthenticatedAccess(httperror.LoggerHandler(h.dashboardList))).Methods(http.MethodGet)
+	h.Handle("/dashboards/home",
+		bouncer.AuthenticatedAccess(httperror.LoggerHandler(h.dashboardHome))).Methods(http.MethodGet)
+	h.Handle("/dashboards/{id}",
+		bouncer.AuthenticatedAccess(httperror.LoggerHandler(h.dashboardInspect))).Methods(http.MethodGet)
+	h.Handle("/dashboards/{id}",
+		bouncer.AdminAccess(httperror.LoggerHandler(h.dashboardUpdate))).Methods(http.MethodPut)
+	h.Handle("/dashboards/{id}",
+		bouncer.AdminAccess(httperror.LoggerHandler(h.dashboardDelete))).Methods(http.MethodDelete)
+	h.Handle("/dashboards/{id}/data",
+		bouncer.AuthenticatedAccess(httperror.LoggerHandler(h.dashboardData))).Methods(http.MethodGet)
+
+	return h
+}
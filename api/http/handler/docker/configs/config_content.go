@@ -0,0 +1,112 @@
+package configs
+
+import (
+	"net/http"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+	httperrors "github.com/portainer/portainer/api/http/errors"
+	"github.com/portainer/portainer/api/http/handler/docker/utils"
+	"github.com/portainer/portainer/api/http/middlewares"
+	"github.com/portainer/portainer/api/http/security"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/request"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+
+	"github.com/docker/docker/api/types/swarm"
+	"github.com/rs/zerolog/log"
+)
+
+type configContentResponse struct {
+	// Config identifier
+	ID string `json:"Id" example:"ktnbskdj3tkmtv74xazngaqoh"`
+	// Config name
+	Name string `json:"Name" example:"nginx.conf"`
+	// Decoded content of the config
+	Content string `json:"Content"`
+}
+
+// @id dockerConfigContent
+// @summary Fetch the content of a Swarm config
+// @description Fetch the decoded content of a Swarm config. The content of the Swarm secrets is never returned by Docker and cannot be previewed.
+// @description **Access policy**: authenticated, with access to the config
+// @tags docker
+// @security ApiKeyAuth
+// @security jwt
+// @produce json
+// @param environmentId path int true "Environment identifier"
+// @param configId path string true "Config identifier"
+// @success 200 {object} configContentResponse "Success"
+// @failure 400 "Bad request"
+// @failure 403 "Permission denied to access the config"
+// @failure 404 "Environment or config not found"
+// @failure 500 "Internal server error"
+// @router /docker/{environmentId}/configs/{configId}/content [get]
+func (handler *Handler) configContent(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	configID, err := request.RetrieveRouteVariableValue(r, "configId")
+	if err != nil {
+		return httperror.BadRequest("Invalid config identifier route variable", err)
+	}
+
+	config, httpErr := handler.inspectConfig(r, configID)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	return response.JSON(w, configContentResponse{
+		ID:      config.ID,
+		Name:    config.Spec.Name,
+		Content: string(config.Spec.Data),
+	})
+}
+
+// inspectConfig retrieves a config after verifying that the user can access it, and logs the access to its content
+func (handler *Handler) inspectConfig(r *http.Request, configID string) (*swarm.Config, *httperror.HandlerError) {
+	endpoint, err := middlewares.FetchEndpoint(r)
+	if err != nil {
+		return nil, httperror.NotFound("Unable to find an environment on request context", err)
+	}
+
+	securityContext, err := security.RetrieveRestrictedRequestContext(r)
+	if err != nil {
+		return nil, httperror.InternalServerError("Unable to retrieve user details from request context", err)
+	}
+
+	cli, httpErr := utils.GetClient(r, handler.dockerClientFactory)
+	if httpErr != nil {
+		return nil, httpErr
+	}
+
+	config, _, err := cli.ConfigInspectWithRaw(r.Context(), configID)
+	if err != nil {
+		return nil, httperror.NotFound("Unable to find the config", err)
+	}
+
+	var canAccess bool
+	if err := handler.dataStore.ViewTx(func(tx dataservices.DataStoreTx) error {
+		canAccess, err = userCanAccessConfig(tx, securityContext, endpoint.ID, &config)
+		return err
+	}); err != nil {
+		return nil, httperror.InternalServerError("Unable to retrieve the resource controls", err)
+	}
+
+	if !canAccess {
+		return nil, httperror.Forbidden("Permission denied to access the config", httperrors.ErrResourceAccessDenied)
+	}
+
+	log.Info().
+		Str("event", "config_content_view").
+		Str("config_id", config.ID).
+		Str("config_name", config.Spec.Name).
+		Int("endpoint_id", int(endpoint.ID)).
+		Int("user_id", int(securityContext.UserID)).
+		Msg("config content accessed")
+
+	return &config, nil
+}
+
+// userCanAccessConfig returns whether the user can access a config through its own resource control, or the one of
+// the stack it belongs to. The configs without any resource control are restricted to the administrators
+func userCanAccessConfig(tx dataservices.DataStoreTx, securityContext *security.RestrictedRequestContext, endpointID portainer.EndpointID, config *swarm.Config) (bool, error) {
+	return utils.UserCanAccessSwarmResource(tx, securityContext, endpointID, portainer.ConfigResourceControl, config.ID, config.Spec.Labels)
+}
@@ -0,0 +1,76 @@
+package configs
+
+import (
+	"testing"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+	"github.com/portainer/portainer/api/datastore"
+	"github.com/portainer/portainer/api/docker/consts"
+	"github.com/portainer/portainer/api/http/security"
+	"github.com/portainer/portainer/api/internal/authorization"
+	"github.com/portainer/portainer/api/stacks/stackutils"
+
+	"github.com/docker/docker/api/types/swarm"
+	"github.com/stretchr/testify/require"
+)
+
+func newConfig(id, stackName string) *swarm.Config {
+	config := &swarm.Config{ID: id}
+	if stackName != "" {
+		config.Spec.Labels = map[string]string{consts.SwarmStackNameLabel: stackName}
+	}
+
+	return config
+}
+
+func TestUserCanAccessConfig(t *testing.T) {
+	is := require.New(t)
+
+	_, store := datastore.MustNewTestStore(t, true, false)
+
+	is.NoError(store.ResourceControl().Create(authorization.NewPrivateResourceControl("owned", portainer.ConfigResourceControl, 2)))
+	is.NoError(store.ResourceControl().Create(authorization.NewPrivateResourceControl(stackutils.ResourceControlID(1, "stack"), portainer.StackResourceControl, 2)))
+
+	tests := []struct {
+		name     string
+		context  *security.RestrictedRequestContext
+		config   *swarm.Config
+		expected bool
+	}{
+		{"admin without resource control", &security.RestrictedRequestContext{UserID: 1, IsAdmin: true}, newConfig("orphan", ""), true},
+		{"user without resource control", &security.RestrictedRequestContext{UserID: 2}, newConfig("orphan", ""), false},
+		{"owner of the config", &security.RestrictedRequestContext{UserID: 2}, newConfig("owned", ""), true},
+		{"other user of the config", &security.RestrictedRequestContext{UserID: 3}, newConfig("owned", ""), false},
+		{"owner of the stack", &security.RestrictedRequestContext{UserID: 2}, newConfig("inherited", "stack"), true},
+		{"other user of the stack", &security.RestrictedRequestContext{UserID: 3}, newConfig("inherited", "stack"), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := store.ViewTx(func(tx dataservices.DataStoreTx) error {
+				canAccess, err := userCanAccessConfig(tx, tt.context, 1, tt.config)
+				is.Equal(tt.expected, canAccess)
+
+				return err
+			})
+			is.NoError(err)
+		})
+	}
+}
+
+func TestDiffContent(t *testing.T) {
+	is := require.New(t)
+
+	diff, err := diffContent("a\nb\n", "a\nb\n", "from", "to")
+	is.NoError(err)
+	is.Empty(diff)
+
+	diff, err = diffContent("a\nb\n", "a\nc\n", "from", "to")
+	is.NoError(err)
+	is.Equal("--- from\n+++ to\n@@ -1,2 +1,2 @@\n a\n-b\n+c\n", diff)
+
+	diff, err = diffContent("a\nb", "a\nc", "from", "to")
+	is.NoError(err)
+	is.Equal("--- from\n+++ to\n@@ -1,2 +1,2 @@\n a\n-b\n+c\n", diff)
+}
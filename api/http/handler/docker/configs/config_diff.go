@@ -0,0 +1,112 @@
+package configs
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/request"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+
+	"github.com/pmezard/go-difflib/difflib"
+)
+
+type configDiffPayload struct {
+	// Identifier of the config to compare with, the Content is used when empty
+	CompareTo string `example:"ktnbskdj3tkmtv74xazngaqoh"`
+	// Content to compare with, such as the new content of the config before replacing it
+	Content string
+}
+
+func (payload *configDiffPayload) Validate(r *http.Request) error {
+	if payload.CompareTo != "" && payload.Content != "" {
+		return errors.New("CompareTo and Content cannot be used together")
+	}
+
+	return nil
+}
+
+type configDiffResponse struct {
+	// Unified diff between the content of the config and the compared content, empty when they are identical
+	Diff string `json:"Diff"`
+}
+
+// @id dockerConfigDiff
+// @summary Compare the content of a Swarm config
+// @description Compare the content of a Swarm config with the content of another config, or with the given content.
+// @description **Access policy**: authenticated, with access to the compared configs
+// @tags docker
+// @security ApiKeyAuth
+// @security jwt
+// @accept json
+// @produce json
+// @param environmentId path int true "Environment identifier"
+// @param configId path string true "Config identifier"
+// @param body body configDiffPayload true "Compared content"
+// @success 200 {object} configDiffResponse "Success"
+// @failure 400 "Bad request"
+// @failure 403 "Permission denied to access the config"
+// @failure 404 "Environment or config not found"
+// @failure 500 "Internal server error"
+// @router /docker/{environmentId}/configs/{configId}/diff [post]
+func (handler *Handler) configDiff(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	configID, err := request.RetrieveRouteVariableValue(r, "configId")
+	if err != nil {
+		return httperror.BadRequest("Invalid config identifier route variable", err)
+	}
+
+	var payload configDiffPayload
+	if err := request.DecodeAndValidateJSONPayload(r, &payload); err != nil {
+		return httperror.BadRequest("Invalid request payload", err)
+	}
+
+	config, httpErr := handler.inspectConfig(r, configID)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	fromFile, toFile, toContent := config.Spec.Name, "content", payload.Content
+	if payload.CompareTo != "" {
+		compared, httpErr := handler.inspectConfig(r, payload.CompareTo)
+		if httpErr != nil {
+			return httpErr
+		}
+
+		toFile, toContent = compared.Spec.Name, string(compared.Spec.Data)
+	}
+
+	diff, err := diffContent(string(config.Spec.Data), toContent, fromFile, toFile)
+	if err != nil {
+		return httperror.InternalServerError("Unable to compare the config content", err)
+	}
+
+	return response.JSON(w, configDiffResponse{Diff: diff})
+}
+
+func diffContent(from, to, fromFile, toFile string) (string, error) {
+	return difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        splitLines(from),
+		B:        splitLines(to),
+		FromFile: fromFile,
+		ToFile:   toFile,
+		Context:  3,
+	})
+}
+
+// splitLines splits a content into lines ending with a line break. Unlike difflib.SplitLines, it does not add an
+// empty line after a trailing line break, and it terminates the last line so that it stays on its own in the diff
+func splitLines(content string) []string {
+	if content == "" {
+		return nil
+	}
+
+	lines := strings.SplitAfter(content, "\n")
+	if last := lines[len(lines)-1]; last == "" {
+		lines = lines[:len(lines)-1]
+	} else {
+		lines[len(lines)-1] = last + "\n"
+	}
+
+	return lines
+}
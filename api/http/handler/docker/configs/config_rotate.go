@@ -0,0 +1,46 @@
+package configs
+
+import (
+	"net/http"
+
+	"github.com/portainer/portainer/api/docker/rotation"
+	"github.com/portainer/portainer/api/http/handler/docker/utils"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/request"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+)
+
+// @id dockerConfigRotate
+// @summary Rotate a Swarm config
+// @description Create a new version of a Swarm config, move the services using the config to the new version at the
+// @description same path, and remove the previous version. When a service cannot be updated, the services already
+// @description updated are moved back and the new version is removed. The new version is named after the config
+// @description followed by its version number unless a name is given.
+// @description **Access policy**: authenticated, with access to the config and to the services using it
+// @tags docker
+// @security ApiKeyAuth
+// @security jwt
+// @accept json
+// @produce json
+// @param environmentId path int true "Environment identifier"
+// @param configId path string true "Config identifier"
+// @param body body utils.RotationPayload true "Content of the new version"
+// @success 200 {object} rotation.Result "Success"
+// @failure 400 "Bad request"
+// @failure 403 "Permission denied to access the config or the services using it"
+// @failure 404 "Environment or config not found"
+// @failure 500 "Internal server error"
+// @router /docker/{environmentId}/configs/{configId}/rotate [post]
+func (handler *Handler) configRotate(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	configID, err := request.RetrieveRouteVariableValue(r, "configId")
+	if err != nil {
+		return httperror.BadRequest("Invalid config identifier route variable", err)
+	}
+
+	result, httpErr := utils.RotateSwarmObject(r, handler.dataStore, handler.bouncer, handler.dockerClientFactory, rotation.ConfigKind, configID)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	return response.JSON(w, result)
+}
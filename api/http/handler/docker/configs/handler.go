@@ -0,0 +1,39 @@
+package configs
+
+import (
+	"net/http"
+
+	"github.com/portainer/portainer/api/dataservices"
+	dockerclient "github.com/portainer/portainer/api/docker/client"
+	"github.com/portainer/portainer/api/http/middlewares"
+	"github.com/portainer/portainer/api/http/security"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+
+	"github.com/gorilla/mux"
+)
+
+type Handler struct {
+	*mux.Router
+	dockerClientFactory *dockerclient.ClientFactory
+	dataStore           dataservices.DataStore
+	bouncer             security.BouncerService
+}
+
+// NewHandler creates a handler to process non-proxied requests to docker APIs directly.
+func NewHandler(routePrefix string, bouncer security.BouncerService, dataStore dataservices.DataStore, dockerClientFactory *dockerclient.ClientFactory) *Handler {
+	h := &Handler{
+		Router:              mux.NewRouter(),
+		dataStore:           dataStore,
+		dockerClientFactory: dockerClientFactory,
+		bouncer:             bouncer,
+	}
+
+	router := h.PathPrefix(routePrefix).Subrouter()
+	router.Use(bouncer.AuthenticatedAccess, middlewares.CheckEndpointAuthorization(bouncer))
+
+	router.Handle("/{configId}/content", httperror.LoggerHandler(h.configContent)).Methods(http.MethodGet)
+	router.Handle("/{configId}/diff", httperror.LoggerHandler(h.configDiff)).Methods(http.MethodPost)
+	router.Handle("/{configId}/rotate", httperror.LoggerHandler(h.configRotate)).Methods(http.MethodPost)
+
+	return h
+}
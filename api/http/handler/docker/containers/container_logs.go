@@ -0,0 +1,212 @@
+package containers
+
+import (
+	"compress/gzip"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+	"github.com/portainer/portainer/api/docker/logs"
+	httperrors "github.com/portainer/portainer/api/http/errors"
+	"github.com/portainer/portainer/api/http/handler/docker/utils"
+	"github.com/portainer/portainer/api/http/middlewares"
+	"github.com/portainer/portainer/api/http/security"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/request"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/client"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	defaultLogsSearchLimit = 1000
+	maxLogsSearchLimit     = 10000
+)
+
+// @id dockerContainerLogsSearch
+// @summary Search the logs of a container
+// @description Read the logs of a container within a time range and return the last lines containing the search, or
+// @description matching it when it is a regular expression. Every line is returned when there is no search.
+// @description **Access policy**: authenticated, with access to the container
+// @tags docker
+// @security ApiKeyAuth
+// @security jwt
+// @produce json
+// @param environmentId path int true "Environment identifier"
+// @param containerId path string true "Container identifier"
+// @param search query string false "Substring searched in the lines, ignoring the case, or regular expression when regex is set"
+// @param regex query boolean false "Whether the search is a regular expression"
+// @param since query int false "Only read the lines written after this Unix timestamp"
+// @param until query int false "Only read the lines written before this Unix timestamp"
+// @param stdout query boolean false "Read the standard output, true by default"
+// @param stderr query boolean false "Read the standard error, true by default"
+// @param limit query int false "Maximum number of lines returned, 1000 by default and 10000 at most"
+// @success 200 {object} logs.SearchResult "Success"
+// @failure 400 "Bad request"
+// @failure 403 "Permission denied to access the container"
+// @failure 404 "Environment or container not found"
+// @failure 500 "Internal server error"
+// @router /docker/{environmentId}/containers/{containerId}/logs [get]
+func (handler *Handler) containerLogsSearch(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	options, filter, httpErr := utils.RetrieveLogsQueryParameters(r)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	limit, err := request.RetrieveNumericQueryParameter(r, "limit", true)
+	if err != nil {
+		return httperror.BadRequest("Invalid query parameter: limit", err)
+	} else if limit == 0 {
+		limit = defaultLogsSearchLimit
+	} else if limit < 0 || limit > maxLogsSearchLimit {
+		return httperror.BadRequest("Invalid query parameter: limit", fmt.Errorf("the limit must be between 1 and %d", maxLogsSearchLimit))
+	}
+
+	cli, container, httpErr := handler.inspectLogsContainer(r)
+	if httpErr != nil {
+		return httpErr
+	}
+	defer cli.Close()
+
+	out, err := logs.Open(r.Context(), cli, container.ID, options)
+	if err != nil {
+		return httperror.InternalServerError("Unable to read the logs of the container", err)
+	}
+	defer out.Close()
+
+	result, err := logs.Search(out, container.Config.Tty, filter, limit)
+	if err != nil {
+		return httperror.InternalServerError("Unable to read the logs of the container", err)
+	}
+
+	return response.JSON(w, result)
+}
+
+// @id dockerContainerLogsDownload
+// @summary Download the logs of a container
+// @description Download the logs of a container as a gzip compressed text file, optionally restricted to a time range
+// @description and to the lines selected by a search.
+// @description **Access policy**: authenticated, with access to the container
+// @tags docker
+// @security ApiKeyAuth
+// @security jwt
+// @produce application/gzip
+// @param environmentId path int true "Environment identifier"
+// @param containerId path string true "Container identifier"
+// @param search query string false "Substring searched in the lines, ignoring the case, or regular expression when regex is set"
+// @param regex query boolean false "Whether the search is a regular expression"
+// @param since query int false "Only read the lines written after this Unix timestamp"
+// @param until query int false "Only read the lines written before this Unix timestamp"
+// @param stdout query boolean false "Read the standard output, true by default"
+// @param stderr query boolean false "Read the standard error, true by default"
+// @param timestamps query boolean false "Prefix each line with the time at which it was written"
+// @success 200 {file} file "Success"
+// @failure 400 "Bad request"
+// @failure 403 "Permission denied to access the container"
+// @failure 404 "Environment or container not found"
+// @failure 500 "Internal server error"
+// @router /docker/{environmentId}/containers/{containerId}/logs/download [get]
+func (handler *Handler) containerLogsDownload(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	options, filter, httpErr := utils.RetrieveLogsQueryParameters(r)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	timestamps, _ := request.RetrieveBooleanQueryParameter(r, "timestamps", true)
+
+	cli, container, httpErr := handler.inspectLogsContainer(r)
+	if httpErr != nil {
+		return httpErr
+	}
+	defer cli.Close()
+
+	out, err := logs.Open(r.Context(), cli, container.ID, options)
+	if err != nil {
+		return httperror.InternalServerError("Unable to read the logs of the container", err)
+	}
+	defer out.Close()
+
+	w.Header().Set("Content-Type", "application/gzip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%s-logs.txt.gz", strings.TrimPrefix(container.Name, "/")))
+
+	gzipWriter := gzip.NewWriter(w)
+
+	err = logs.Read(out, container.Config.Tty, filter, func(line logs.Line) error {
+		if timestamps && !line.Timestamp.IsZero() {
+			if _, err := gzipWriter.Write([]byte(line.Timestamp.Format(time.RFC3339Nano) + " ")); err != nil {
+				return err
+			}
+		}
+
+		_, err := gzipWriter.Write([]byte(line.Text + "\n"))
+
+		return err
+	})
+	if err == nil {
+		err = gzipWriter.Close()
+	}
+
+	// the response has already started, the download is left incomplete
+	if err != nil {
+		log.Warn().Err(err).Str("container_id", container.ID).Msg("unable to download the logs of the container")
+	}
+
+	return nil
+}
+
+// inspectLogsContainer retrieves the container of a request on its logs after verifying that the user can read them
+func (handler *Handler) inspectLogsContainer(r *http.Request) (*client.Client, *types.ContainerJSON, *httperror.HandlerError) {
+	containerID, err := request.RetrieveRouteVariableValue(r, "containerId")
+	if err != nil {
+		return nil, nil, httperror.BadRequest("Invalid container identifier route variable", err)
+	}
+
+	endpoint, err := middlewares.FetchEndpoint(r)
+	if err != nil {
+		return nil, nil, httperror.NotFound("Unable to find an environment on request context", err)
+	}
+
+	if err := handler.bouncer.AuthorizedEndpointRoleOperation(r, endpoint, portainer.OperationDockerContainerLogs); err != nil {
+		return nil, nil, httperror.Forbidden("Permission denied by your role on the environment", err)
+	}
+
+	securityContext, err := security.RetrieveRestrictedRequestContext(r)
+	if err != nil {
+		return nil, nil, httperror.InternalServerError("Unable to retrieve user details from request context", err)
+	}
+
+	cli, httpErr := utils.GetClient(r, handler.dockerClientFactory)
+	if httpErr != nil {
+		return nil, nil, httpErr
+	}
+
+	container, err := cli.ContainerInspect(r.Context(), containerID)
+	if err != nil {
+		cli.Close()
+
+		return nil, nil, httperror.NotFound("Unable to find the container", err)
+	}
+
+	var canAccess bool
+	if err := handler.dataStore.ViewTx(func(tx dataservices.DataStoreTx) error {
+		canAccess, err = utils.UserCanAccessContainer(tx, securityContext, endpoint.ID, &container)
+		return err
+	}); err != nil {
+		cli.Close()
+
+		return nil, nil, httperror.InternalServerError("Unable to retrieve the resource controls", err)
+	}
+
+	if !canAccess {
+		cli.Close()
+
+		return nil, nil, httperror.Forbidden("Permission denied to access the container", httperrors.ErrResourceAccessDenied)
+	}
+
+	return cli, &container, nil
+}
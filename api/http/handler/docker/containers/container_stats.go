@@ -0,0 +1,108 @@
+package containers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+	"github.com/portainer/portainer/api/docker/containerstats"
+	"github.com/portainer/portainer/api/http/handler/docker/utils"
+	"github.com/portainer/portainer/api/http/middlewares"
+	"github.com/portainer/portainer/api/http/security"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/request"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+
+	"github.com/docker/docker/api/types/filters"
+)
+
+// @id dockerContainersStats
+// @summary Sample the resource usage of the containers
+// @description Return the CPU, memory, network and block I/O usage of the running containers of an environment, sampled
+// @description by the server at a regular interval. The sampling of the containers matching the filters starts with the
+// @description first request and stops when no request is made for two minutes, the latest samples being kept in memory
+// @description so that a dashboard polls a single endpoint instead of streaming the stats of every container.
+// @description The first request waits for the first sample.
+// @description **Access policy**: authenticated, only the containers the user can access are returned
+// @tags docker
+// @security ApiKeyAuth
+// @security jwt
+// @produce json
+// @param environmentId path int true "Environment identifier"
+// @param filters query string false "Filters of the sampled containers, in the JSON format of the Docker container list, e.g. {\"label\":[\"com.docker.compose.project=web\"]}"
+// @param interval query int false "Sampling interval in seconds, from 2 to 60, 5 by default"
+// @param history query int false "Number of the latest samples returned, up to 60, all the samples kept by default"
+// @success 200 {object} containerstats.Report "Success"
+// @failure 400 "Bad request"
+// @failure 403 "Permission denied"
+// @failure 404 "Environment not found"
+// @failure 429 "Too many samplers running"
+// @failure 500 "Internal server error"
+// @router /docker/{environmentId}/containers/stats [get]
+func (handler *Handler) containersStats(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	rawFilters, _ := request.RetrieveQueryParameter(r, "filters", true)
+
+	args, err := filters.FromJSON(rawFilters)
+	if err != nil {
+		return httperror.BadRequest("Invalid query parameter: filters", err)
+	}
+
+	interval, err := request.RetrieveNumericQueryParameter(r, "interval", true)
+	if err != nil {
+		return httperror.BadRequest("Invalid query parameter: interval", err)
+	}
+
+	history, err := request.RetrieveNumericQueryParameter(r, "history", true)
+	if err != nil {
+		return httperror.BadRequest("Invalid query parameter: history", err)
+	} else if history < 0 || history > containerstats.MaxHistory {
+		return httperror.BadRequest("Invalid query parameter: history", fmt.Errorf("the history cannot exceed %d samples", containerstats.MaxHistory))
+	}
+
+	endpoint, err := middlewares.FetchEndpoint(r)
+	if err != nil {
+		return httperror.NotFound("Unable to find an environment on request context", err)
+	}
+
+	if err := handler.bouncer.AuthorizedEndpointRoleOperation(r, endpoint, portainer.OperationDockerContainerStats); err != nil {
+		return httperror.Forbidden("Permission denied by your role on the environment", err)
+	}
+
+	securityContext, err := security.RetrieveRestrictedRequestContext(r)
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve user details from request context", err)
+	}
+
+	report, err := handler.statsService.Report(r.Context(), endpoint, containerstats.Query{
+		Filters:  args,
+		Interval: time.Duration(interval) * time.Second,
+		History:  history,
+	})
+	if errors.Is(err, containerstats.ErrInvalidInterval) {
+		return httperror.BadRequest("Invalid query parameter: interval", err)
+	} else if errors.Is(err, containerstats.ErrTooManySamplers) {
+		return httperror.NewError(http.StatusTooManyRequests, "Unable to sample the containers", err)
+	} else if err != nil {
+		return httperror.InternalServerError("Unable to sample the containers", err)
+	}
+
+	if err := handler.dataStore.ViewTx(func(tx dataservices.DataStoreTx) error {
+		canAccess, err := utils.ContainerAccessFilter(tx, securityContext, endpoint.ID)
+		if err != nil {
+			return err
+		}
+
+		report = report.Filter(func(c containerstats.Container) bool {
+			return canAccess(c.ID, c.Labels)
+		})
+
+		return nil
+	}); err != nil {
+		return httperror.InternalServerError("Unable to retrieve the resource controls", err)
+	}
+
+	return response.JSON(w, report)
+}
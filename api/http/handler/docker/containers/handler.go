@@ -7,6 +7,7 @@ import (
 	"github.com/portainer/portainer/api/dataservices"
 	"github.com/portainer/portainer/api/docker"
 	dockerclient "github.com/portainer/portainer/api/docker/client"
+	"github.com/portainer/portainer/api/docker/containerstats"
 	"github.com/portainer/portainer/api/http/middlewares"
 	"github.com/portainer/portainer/api/http/security"
 	httperror "github.com/portainer/portainer/pkg/libhttp/error"
@@ -17,24 +18,29 @@ type Handler struct {
 	dockerClientFactory *dockerclient.ClientFactory
 	dataStore           dataservices.DataStore
 	containerService    *docker.ContainerService
+	statsService        *containerstats.Service
 	bouncer             security.BouncerService
 }
 
 // NewHandler creates a handler to process non-proxied requests to docker APIs directly.
-func NewHandler(routePrefix string, bouncer security.BouncerService, dataStore dataservices.DataStore, dockerClientFactory *dockerclient.ClientFactory, containerService *docker.ContainerService) *Handler {
+func NewHandler(routePrefix string, bouncer security.BouncerService, dataStore dataservices.DataStore, dockerClientFactory *dockerclient.ClientFactory, containerService *docker.ContainerService, statsService *containerstats.Service) *Handler {
 	h := &Handler{
 		Router:              mux.NewRouter(),
 		dataStore:           dataStore,
 		dockerClientFactory: dockerClientFactory,
 		containerService:    containerService,
+		statsService:        statsService,
 		bouncer:             bouncer,
 	}
 
 	router := h.PathPrefix(routePrefix).Subrouter()
 	router.Use(bouncer.AuthenticatedAccess, middlewares.CheckEndpointAuthorization(bouncer))
 
+	router.Handle("/stats", httperror.LoggerHandler(h.containersStats)).Methods(http.MethodGet)
 	router.Handle("/{containerId}/gpus", httperror.LoggerHandler(h.containerGpusInspect)).Methods(http.MethodGet)
 	router.Handle("/{containerId}/recreate", httperror.LoggerHandler(h.recreate)).Methods(http.MethodPost)
+	router.Handle("/{containerId}/logs", httperror.LoggerHandler(h.containerLogsSearch)).Methods(http.MethodGet)
+	router.Handle("/{containerId}/logs/download", httperror.LoggerHandler(h.containerLogsDownload)).Methods(http.MethodGet)
 
 	return h
 }
@@ -7,8 +7,12 @@ import (
 	"github.com/portainer/portainer/api/dataservices"
 	"github.com/portainer/portainer/api/docker"
 	dockerclient "github.com/portainer/portainer/api/docker/client"
+	"github.com/portainer/portainer/api/docker/containerstats"
+	"github.com/portainer/portainer/api/http/handler/docker/configs"
 	"github.com/portainer/portainer/api/http/handler/docker/containers"
 	"github.com/portainer/portainer/api/http/handler/docker/images"
+	"github.com/portainer/portainer/api/http/handler/docker/secrets"
+	"github.com/portainer/portainer/api/http/handler/docker/volumes"
 	"github.com/portainer/portainer/api/http/middlewares"
 	"github.com/portainer/portainer/api/http/security"
 	"github.com/portainer/portainer/api/internal/authorization"
@@ -29,7 +33,7 @@ type Handler struct {
 }
 
 // NewHandler creates a handler to process non-proxied requests to docker APIs directly.
-func NewHandler(bouncer security.BouncerService, authorizationService *authorization.Service, dataStore dataservices.DataStore, dockerClientFactory *dockerclient.ClientFactory, containerService *docker.ContainerService) *Handler {
+func NewHandler(bouncer security.BouncerService, authorizationService *authorization.Service, dataStore dataservices.DataStore, dockerClientFactory *dockerclient.ClientFactory, containerService *docker.ContainerService, statsService *containerstats.Service) *Handler {
 	h := &Handler{
 		Router:               mux.NewRouter(),
 		requestBouncer:       bouncer,
@@ -46,11 +50,20 @@ func NewHandler(bouncer security.BouncerService, authorizationService *authoriza
 
 	endpointRouter.Handle("/dashboard", httperror.LoggerHandler(h.dashboard)).Methods(http.MethodGet)
 
-	containersHandler := containers.NewHandler("/docker/{id}/containers", bouncer, dataStore, dockerClientFactory, containerService)
+	containersHandler := containers.NewHandler("/docker/{id}/containers", bouncer, dataStore, dockerClientFactory, containerService, statsService)
 	endpointRouter.PathPrefix("/containers").Handler(containersHandler)
 
-	imagesHandler := images.NewHandler("/docker/{id}/images", bouncer, dockerClientFactory)
+	configsHandler := configs.NewHandler("/docker/{id}/configs", bouncer, dataStore, dockerClientFactory)
+	endpointRouter.PathPrefix("/configs").Handler(configsHandler)
+
+	imagesHandler := images.NewHandler("/docker/{id}/images", bouncer, dataStore, dockerClientFactory)
 	endpointRouter.PathPrefix("/images").Handler(imagesHandler)
+
+	secretsHandler := secrets.NewHandler("/docker/{id}/secrets", bouncer, dataStore, dockerClientFactory)
+	endpointRouter.PathPrefix("/secrets").Handler(secretsHandler)
+
+	volumesHandler := volumes.NewHandler("/docker/{id}/volumes", bouncer, dataStore, dockerClientFactory)
+	endpointRouter.PathPrefix("/volumes").Handler(volumesHandler)
 	return h
 }
 
@@ -3,7 +3,9 @@ package images
 import (
 	"net/http"
 
+	"github.com/portainer/portainer/api/dataservices"
 	"github.com/portainer/portainer/api/docker/client"
+	dockerimages "github.com/portainer/portainer/api/docker/images"
 	"github.com/portainer/portainer/api/http/middlewares"
 	"github.com/portainer/portainer/api/http/security"
 	httperror "github.com/portainer/portainer/pkg/libhttp/error"
@@ -15,19 +17,22 @@ type Handler struct {
 	*mux.Router
 	dockerClientFactory *client.ClientFactory
 	bouncer             security.BouncerService
+	digestClient        *dockerimages.DigestClient
 }
 
 // NewHandler creates a handler to process non-proxied requests to docker APIs directly.
-func NewHandler(routePrefix string, bouncer security.BouncerService, dockerClientFactory *client.ClientFactory) *Handler {
+func NewHandler(routePrefix string, bouncer security.BouncerService, dataStore dataservices.DataStore, dockerClientFactory *client.ClientFactory) *Handler {
 	h := &Handler{
 		Router:              mux.NewRouter(),
 		dockerClientFactory: dockerClientFactory,
 		bouncer:             bouncer,
+		digestClient:        dockerimages.NewClientWithRegistry(dockerimages.NewRegistryClient(dataStore), dockerClientFactory),
 	}
 
 	router := h.PathPrefix(routePrefix).Subrouter()
 	router.Use(bouncer.AuthenticatedAccess, middlewares.CheckEndpointAuthorization(bouncer))
 
 	router.Handle("", httperror.LoggerHandler(h.imagesList)).Methods(http.MethodGet)
+	router.Handle("/manifest", httperror.LoggerHandler(h.imageManifest)).Methods(http.MethodGet)
 	return h
 }
@@ -0,0 +1,107 @@
+package images
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+	dockerimages "github.com/portainer/portainer/api/docker/images"
+	"github.com/portainer/portainer/api/http/handler/docker/utils"
+	"github.com/portainer/portainer/api/http/middlewares"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/request"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+
+	"github.com/docker/docker/api/types"
+)
+
+// manifestTimeout bounds the resolution of the manifests of an image in its registry
+const manifestTimeout = 30 * time.Second
+
+type ImageManifestResponse struct {
+	dockerimages.Manifest
+	// Platform of the Docker engine of the environment, or of the node targeted by the X-PortainerAgent-Target header
+	Target dockerimages.Platform `json:"Target"`
+	// Whether the image has a variant for the target platform
+	Compatible bool `json:"Compatible" example:"true"`
+	// Warnings about the nodes of a Swarm cluster which cannot run the image
+	Warnings []string `json:"Warnings"`
+}
+
+// @id dockerImageManifest
+// @summary Inspect the variants of an image
+// @description Resolve the manifest list of an image in its registry and return the digest and the size of its
+// @description variant for each platform, with whether one matches the platform of the Docker engine. On a Swarm
+// @description manager, a warning is returned for the nodes whose platform has no variant of the image.
+// @description **Access policy**: authenticated
+// @tags docker
+// @security ApiKeyAuth
+// @security jwt
+// @produce json
+// @param environmentId path int true "Environment identifier"
+// @param image query string true "Image reference, e.g. nginx:latest"
+// @success 200 {object} ImageManifestResponse "Success"
+// @failure 400 "Bad request"
+// @failure 403 "Permission denied"
+// @failure 404 "Environment not found"
+// @failure 500 "Internal server error"
+// @failure 502 "Unable to retrieve the image manifest from its registry"
+// @router /docker/{environmentId}/images/manifest [get]
+func (handler *Handler) imageManifest(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	imageName, err := request.RetrieveQueryParameter(r, "image", false)
+	if err != nil {
+		return httperror.BadRequest("Invalid query parameter: image", err)
+	}
+
+	image, err := dockerimages.ParseImage(dockerimages.ParseImageOptions{Name: imageName})
+	if err != nil {
+		return httperror.BadRequest("Invalid query parameter: image", err)
+	}
+
+	endpoint, err := middlewares.FetchEndpoint(r)
+	if err != nil {
+		return httperror.NotFound("Unable to find an environment on request context", err)
+	}
+
+	if err := handler.bouncer.AuthorizedEndpointRoleOperation(r, endpoint, portainer.OperationDockerImageInspect); err != nil {
+		return httperror.Forbidden("Permission denied by your role on the environment", err)
+	}
+
+	cli, httpErr := utils.GetClient(r, handler.dockerClientFactory)
+	if httpErr != nil {
+		return httpErr
+	}
+	defer cli.Close()
+
+	info, err := cli.Info(r.Context())
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve the Docker engine information", err)
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), manifestTimeout)
+	defer cancel()
+
+	manifest, err := handler.digestClient.RemoteManifest(ctx, image)
+	if err != nil {
+		return httperror.NewError(http.StatusBadGateway, "Unable to retrieve the image manifest from its registry", err)
+	}
+
+	resp := ImageManifestResponse{
+		Manifest:   *manifest,
+		Target:     dockerimages.Platform{OS: info.OSType, Architecture: info.Architecture},
+		Compatible: manifest.PlatformFor(info.OSType, info.Architecture, "") != nil,
+		Warnings:   []string{},
+	}
+
+	if info.Swarm.ControlAvailable {
+		nodes, err := cli.NodeList(r.Context(), types.NodeListOptions{})
+		if err != nil {
+			return httperror.InternalServerError("Unable to retrieve the Swarm nodes", err)
+		}
+
+		resp.Warnings = dockerimages.PlatformWarnings(manifest, nodes)
+	}
+
+	return response.JSON(w, resp)
+}
@@ -0,0 +1,47 @@
+package secrets
+
+import (
+	"net/http"
+
+	"github.com/portainer/portainer/api/docker/rotation"
+	"github.com/portainer/portainer/api/http/handler/docker/utils"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/request"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+)
+
+// @id dockerSecretRotate
+// @summary Rotate a Swarm secret
+// @description Create a new version of a Swarm secret, move the services using the secret to the new version at the
+// @description same path, and remove the previous version. When a service cannot be updated, the services already
+// @description updated are moved back and the new version is removed. The new version is named after the secret
+// @description followed by its version number unless a name is given. The secrets provided by a secret driver cannot
+// @description be rotated.
+// @description **Access policy**: authenticated, with access to the secret and to the services using it
+// @tags docker
+// @security ApiKeyAuth
+// @security jwt
+// @accept json
+// @produce json
+// @param environmentId path int true "Environment identifier"
+// @param secretId path string true "Secret identifier"
+// @param body body utils.RotationPayload true "Content of the new version"
+// @success 200 {object} rotation.Result "Success"
+// @failure 400 "Bad request"
+// @failure 403 "Permission denied to access the secret or the services using it"
+// @failure 404 "Environment or secret not found"
+// @failure 500 "Internal server error"
+// @router /docker/{environmentId}/secrets/{secretId}/rotate [post]
+func (handler *Handler) secretRotate(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	secretID, err := request.RetrieveRouteVariableValue(r, "secretId")
+	if err != nil {
+		return httperror.BadRequest("Invalid secret identifier route variable", err)
+	}
+
+	result, httpErr := utils.RotateSwarmObject(r, handler.dataStore, handler.bouncer, handler.dockerClientFactory, rotation.SecretKind, secretID)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	return response.JSON(w, result)
+}
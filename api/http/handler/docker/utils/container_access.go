@@ -0,0 +1,73 @@
+package utils
+
+import (
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+	"github.com/portainer/portainer/api/docker/consts"
+	"github.com/portainer/portainer/api/http/security"
+	"github.com/portainer/portainer/api/internal/authorization"
+	"github.com/portainer/portainer/api/stacks/stackutils"
+
+	"github.com/docker/docker/api/types"
+)
+
+// UserCanAccessContainer returns whether the user can access a container through its own resource control, or the one
+// of the service or the stack it belongs to. The containers without any resource control are restricted to the
+// administrators
+func UserCanAccessContainer(tx dataservices.DataStoreTx, securityContext *security.RestrictedRequestContext, endpointID portainer.EndpointID, container *types.ContainerJSON) (bool, error) {
+	canAccess, err := ContainerAccessFilter(tx, securityContext, endpointID)
+	if err != nil {
+		return false, err
+	}
+
+	var labels map[string]string
+	if container.Config != nil {
+		labels = container.Config.Labels
+	}
+
+	return canAccess(container.ID, labels), nil
+}
+
+// ContainerAccessFilter returns a function telling whether the user can access a container of an environment, from its
+// identifier and its labels, following the rules of UserCanAccessContainer
+func ContainerAccessFilter(tx dataservices.DataStoreTx, securityContext *security.RestrictedRequestContext, endpointID portainer.EndpointID) (func(containerID string, labels map[string]string) bool, error) {
+	if securityContext.IsAdmin {
+		return func(string, map[string]string) bool { return true }, nil
+	}
+
+	resourceControls, err := tx.ResourceControl().ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	userTeamIDs := make([]portainer.TeamID, 0, len(securityContext.UserMemberships))
+	for _, membership := range securityContext.UserMemberships {
+		userTeamIDs = append(userTeamIDs, membership.TeamID)
+	}
+
+	return func(containerID string, labels map[string]string) bool {
+		resourceControl := authorization.GetResourceControlByResourceIDAndType(containerID, portainer.ContainerResourceControl, resourceControls)
+
+		if resourceControl == nil && labels[consts.SwarmServiceIDLabel] != "" {
+			resourceControl = authorization.GetResourceControlByResourceIDAndType(labels[consts.SwarmServiceIDLabel], portainer.ServiceResourceControl, resourceControls)
+		}
+
+		if resourceControl == nil {
+			stackName := labels[consts.SwarmStackNameLabel]
+			if stackName == "" {
+				stackName = labels[consts.ComposeStackNameLabel]
+			}
+
+			if stackName == "" {
+				return false
+			}
+
+			resourceControl = authorization.GetResourceControlByResourceIDAndType(stackutils.ResourceControlID(endpointID, stackName), portainer.StackResourceControl, resourceControls)
+			if resourceControl == nil {
+				return false
+			}
+		}
+
+		return authorization.UserCanAccessResource(securityContext.UserID, userTeamIDs, resourceControl)
+	}, nil
+}
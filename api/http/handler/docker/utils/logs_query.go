@@ -0,0 +1,55 @@
+package utils
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/portainer/portainer/api/docker/logs"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/request"
+)
+
+// RetrieveLogsQueryParameters reads the search, regex, since, until, stdout and stderr query parameters of a request on
+// the output of a container. Both streams are read unless one of them is set to false
+func RetrieveLogsQueryParameters(r *http.Request) (logs.Options, *logs.Filter, *httperror.HandlerError) {
+	options := logs.Options{
+		Tail:   logs.TailAll,
+		Stdout: r.FormValue("stdout") != "false",
+		Stderr: r.FormValue("stderr") != "false",
+	}
+
+	if !options.Stdout && !options.Stderr {
+		return options, nil, httperror.BadRequest("Invalid query parameters: stdout and stderr", errors.New("at least one of the streams must be read"))
+	}
+
+	since, err := request.RetrieveNumericQueryParameter(r, "since", true)
+	if err != nil {
+		return options, nil, httperror.BadRequest("Invalid query parameter: since", err)
+	} else if since < 0 {
+		return options, nil, httperror.BadRequest("Invalid query parameter: since", errors.New("negative timestamp"))
+	}
+
+	until, err := request.RetrieveNumericQueryParameter(r, "until", true)
+	if err != nil {
+		return options, nil, httperror.BadRequest("Invalid query parameter: until", err)
+	} else if until < 0 {
+		return options, nil, httperror.BadRequest("Invalid query parameter: until", errors.New("negative timestamp"))
+	}
+
+	if until > 0 && until < since {
+		return options, nil, httperror.BadRequest("Invalid query parameter: until", errors.New("until is before since"))
+	}
+
+	options.Since = int64(since)
+	options.Until = int64(until)
+
+	search, _ := request.RetrieveQueryParameter(r, "search", true)
+	regex, _ := request.RetrieveBooleanQueryParameter(r, "regex", true)
+
+	filter, err := logs.NewFilter(search, regex)
+	if err != nil {
+		return options, nil, httperror.BadRequest("Invalid query parameter: search", err)
+	}
+
+	return options, filter, nil
+}
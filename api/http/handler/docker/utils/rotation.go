@@ -0,0 +1,174 @@
+package utils
+
+import (
+	"errors"
+	"net/http"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+	prclient "github.com/portainer/portainer/api/docker/client"
+	"github.com/portainer/portainer/api/docker/rotation"
+	httperrors "github.com/portainer/portainer/api/http/errors"
+	"github.com/portainer/portainer/api/http/middlewares"
+	"github.com/portainer/portainer/api/http/security"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/request"
+
+	"github.com/rs/zerolog/log"
+)
+
+// RotationPayload is the payload of the rotation of a Swarm config or secret
+type RotationPayload struct {
+	// Base64 encoded content of the new version
+	Data []byte `example:"bmV3IGNvbnRlbnQ=" validate:"required"`
+	// Name of the new version, the name of the rotated object followed by the number of the new version by default
+	Name string `example:"nginx.conf_v2"`
+	// Keep the previous version instead of removing it once the services use the new one
+	KeepPrevious bool `example:"false"`
+}
+
+func (payload *RotationPayload) Validate(r *http.Request) error {
+	if len(payload.Data) == 0 {
+		return errors.New("invalid Data: the new version must have a content")
+	}
+
+	return nil
+}
+
+// rotationOperations are the operations run by the rotations of the configs and the secrets
+var rotationOperations = map[rotation.Kind]struct {
+	create, delete portainer.Authorization
+	resourceType   portainer.ResourceControlType
+}{
+	rotation.ConfigKind: {portainer.OperationDockerConfigCreate, portainer.OperationDockerConfigDelete, portainer.ConfigResourceControl},
+	rotation.SecretKind: {portainer.OperationDockerSecretCreate, portainer.OperationDockerSecretDelete, portainer.SecretResourceControl},
+}
+
+// RotateSwarmObject rotates the config or the secret of a request, after verifying that the user can replace it and
+// update the services using it. The resource control of the previous version is moved to the new one
+func RotateSwarmObject(r *http.Request, dataStore dataservices.DataStore, bouncer security.BouncerService, dockerClientFactory *prclient.ClientFactory, kind rotation.Kind, id string) (*rotation.Result, *httperror.HandlerError) {
+	var payload RotationPayload
+	if err := request.DecodeAndValidateJSONPayload(r, &payload); err != nil {
+		return nil, httperror.BadRequest("Invalid request payload", err)
+	}
+
+	endpoint, err := middlewares.FetchEndpoint(r)
+	if err != nil {
+		return nil, httperror.NotFound("Unable to find an environment on request context", err)
+	}
+
+	operations := rotationOperations[kind]
+	required := []portainer.Authorization{operations.create, portainer.OperationDockerServiceUpdate}
+	if !payload.KeepPrevious {
+		required = append(required, operations.delete)
+	}
+
+	for _, operation := range required {
+		if err := bouncer.AuthorizedEndpointRoleOperation(r, endpoint, operation); err != nil {
+			return nil, httperror.Forbidden("Permission denied by your role on the environment", err)
+		}
+	}
+
+	securityContext, err := security.RetrieveRestrictedRequestContext(r)
+	if err != nil {
+		return nil, httperror.InternalServerError("Unable to retrieve user details from request context", err)
+	}
+
+	cli, httpErr := GetClient(r, dockerClientFactory)
+	if httpErr != nil {
+		return nil, httpErr
+	}
+	defer cli.Close()
+
+	var objectID string
+	var labels map[string]string
+
+	switch kind {
+	case rotation.ConfigKind:
+		config, _, err := cli.ConfigInspectWithRaw(r.Context(), id)
+		if err != nil {
+			return nil, httperror.NotFound("Unable to find the config", err)
+		}
+
+		objectID, labels = config.ID, config.Spec.Labels
+	case rotation.SecretKind:
+		secret, _, err := cli.SecretInspectWithRaw(r.Context(), id)
+		if err != nil {
+			return nil, httperror.NotFound("Unable to find the secret", err)
+		}
+
+		objectID, labels = secret.ID, secret.Spec.Labels
+	}
+
+	services, err := rotation.DependentServices(r.Context(), cli, kind, objectID)
+	if err != nil {
+		return nil, httperror.InternalServerError("Unable to list the services", err)
+	}
+
+	var canAccess bool
+	if err := dataStore.ViewTx(func(tx dataservices.DataStoreTx) error {
+		if canAccess, err = UserCanAccessSwarmResource(tx, securityContext, endpoint.ID, operations.resourceType, objectID, labels); err != nil || !canAccess {
+			return err
+		}
+
+		for _, service := range services {
+			if canAccess, err = UserCanAccessSwarmResource(tx, securityContext, endpoint.ID, portainer.ServiceResourceControl, service.ID, service.Spec.Labels); err != nil || !canAccess {
+				return err
+			}
+		}
+
+		return nil
+	}); err != nil {
+		return nil, httperror.InternalServerError("Unable to retrieve the resource controls", err)
+	}
+
+	if !canAccess {
+		return nil, httperror.Forbidden("Permission denied to access the "+string(kind)+" or the services using it", httperrors.ErrResourceAccessDenied)
+	}
+
+	result, err := rotation.Rotate(r.Context(), cli, kind, objectID, rotation.Options{
+		Data:         payload.Data,
+		Name:         payload.Name,
+		KeepPrevious: payload.KeepPrevious,
+	})
+	if errors.Is(err, rotation.ErrExternalSecret) {
+		return nil, httperror.BadRequest("Unable to rotate the secret", err)
+	} else if err != nil {
+		return nil, httperror.InternalServerError("Unable to rotate the "+string(kind), err)
+	}
+
+	if err := dataStore.UpdateTx(func(tx dataservices.DataStoreTx) error {
+		resourceControl, err := tx.ResourceControl().ResourceControlByResourceIDAndType(objectID, operations.resourceType)
+		if err != nil || resourceControl == nil {
+			return err
+		}
+
+		newResourceControl := *resourceControl
+		newResourceControl.ID = 0
+		newResourceControl.ResourceID = result.ID
+
+		if err := tx.ResourceControl().Create(&newResourceControl); err != nil {
+			return err
+		}
+
+		if !result.PreviousRemoved {
+			return nil
+		}
+
+		return tx.ResourceControl().Delete(resourceControl.ID)
+	}); err != nil {
+		// the rotation is done, only the access to the new version is restricted to the administrators
+		log.Error().Err(err).Str("kind", string(kind)).Str("id", result.ID).Msg("unable to move the resource control to the new version")
+	}
+
+	log.Info().
+		Str("event", string(kind)+"_rotation").
+		Str("previous_id", objectID).
+		Str("id", result.ID).
+		Int("services", len(result.Services)).
+		Int("endpoint_id", int(endpoint.ID)).
+		Int("user_id", int(securityContext.UserID)).
+		Msg("Swarm object rotated")
+
+	return result, nil
+}
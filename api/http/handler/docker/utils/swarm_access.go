@@ -0,0 +1,43 @@
+package utils
+
+import (
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+	"github.com/portainer/portainer/api/docker/consts"
+	"github.com/portainer/portainer/api/http/security"
+	"github.com/portainer/portainer/api/internal/authorization"
+	"github.com/portainer/portainer/api/stacks/stackutils"
+)
+
+// UserCanAccessSwarmResource returns whether the user can access a Swarm service, config or secret through its own
+// resource control, or the one of the stack it belongs to. The resources without any resource control are restricted
+// to the administrators
+func UserCanAccessSwarmResource(tx dataservices.DataStoreTx, securityContext *security.RestrictedRequestContext, endpointID portainer.EndpointID, resourceType portainer.ResourceControlType, resourceID string, labels map[string]string) (bool, error) {
+	if securityContext.IsAdmin {
+		return true, nil
+	}
+
+	resourceControl, err := tx.ResourceControl().ResourceControlByResourceIDAndType(resourceID, resourceType)
+	if err != nil {
+		return false, err
+	}
+
+	if resourceControl == nil {
+		stackName := labels[consts.SwarmStackNameLabel]
+		if stackName == "" {
+			return false, nil
+		}
+
+		resourceControl, err = tx.ResourceControl().ResourceControlByResourceIDAndType(stackutils.ResourceControlID(endpointID, stackName), portainer.StackResourceControl)
+		if err != nil || resourceControl == nil {
+			return false, err
+		}
+	}
+
+	userTeamIDs := make([]portainer.TeamID, 0, len(securityContext.UserMemberships))
+	for _, membership := range securityContext.UserMemberships {
+		userTeamIDs = append(userTeamIDs, membership.TeamID)
+	}
+
+	return authorization.UserCanAccessResource(securityContext.UserID, userTeamIDs, resourceControl), nil
+}
@@ -0,0 +1,61 @@
+package utils
+
+import (
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+	"github.com/portainer/portainer/api/docker/consts"
+	"github.com/portainer/portainer/api/http/security"
+	"github.com/portainer/portainer/api/internal/authorization"
+	"github.com/portainer/portainer/api/stacks/stackutils"
+
+	"github.com/docker/docker/api/types/volume"
+)
+
+// VolumeResourceID returns the identifier of the resource control of a volume, its name being only unique within a
+// Docker host or a Swarm cluster
+func VolumeResourceID(dockerID, volumeName string) string {
+	return volumeName + "_" + dockerID
+}
+
+// UserCanBrowseVolume returns whether the user can browse the files of a volume. The regular users need the volume
+// browser to be allowed on the environment(endpoint) and access to the volume through its own resource control, or
+// the one of the stack it belongs to. The volumes without any resource control are restricted to the administrators
+func UserCanBrowseVolume(tx dataservices.DataStoreTx, securityContext *security.RestrictedRequestContext, endpoint *portainer.Endpoint, dockerID string, volume *volume.Volume) (bool, error) {
+	if securityContext.IsAdmin {
+		return true, nil
+	}
+
+	if !endpoint.SecuritySettings.AllowVolumeBrowserForRegularUsers {
+		return false, nil
+	}
+
+	resourceControls, err := tx.ResourceControl().ReadAll()
+	if err != nil {
+		return false, err
+	}
+
+	resourceControl := authorization.GetResourceControlByResourceIDAndType(VolumeResourceID(dockerID, volume.Name), portainer.VolumeResourceControl, resourceControls)
+
+	if resourceControl == nil {
+		stackName := volume.Labels[consts.SwarmStackNameLabel]
+		if stackName == "" {
+			stackName = volume.Labels[consts.ComposeStackNameLabel]
+		}
+
+		if stackName == "" {
+			return false, nil
+		}
+
+		resourceControl = authorization.GetResourceControlByResourceIDAndType(stackutils.ResourceControlID(endpoint.ID, stackName), portainer.StackResourceControl, resourceControls)
+		if resourceControl == nil {
+			return false, nil
+		}
+	}
+
+	userTeamIDs := make([]portainer.TeamID, 0, len(securityContext.UserMemberships))
+	for _, membership := range securityContext.UserMemberships {
+		userTeamIDs = append(userTeamIDs, membership.TeamID)
+	}
+
+	return authorization.UserCanAccessResource(securityContext.UserID, userTeamIDs, resourceControl), nil
+}
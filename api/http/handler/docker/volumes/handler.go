@@ -0,0 +1,39 @@
+package volumes
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/portainer/portainer/api/dataservices"
+	dockerclient "github.com/portainer/portainer/api/docker/client"
+	"github.com/portainer/portainer/api/http/middlewares"
+	"github.com/portainer/portainer/api/http/security"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+)
+
+type Handler struct {
+	*mux.Router
+	dockerClientFactory *dockerclient.ClientFactory
+	dataStore           dataservices.DataStore
+	bouncer             security.BouncerService
+}
+
+// NewHandler creates a handler to browse and transfer the files of the Docker volumes through the agents.
+func NewHandler(routePrefix string, bouncer security.BouncerService, dataStore dataservices.DataStore, dockerClientFactory *dockerclient.ClientFactory) *Handler {
+	h := &Handler{
+		Router:              mux.NewRouter(),
+		dataStore:           dataStore,
+		dockerClientFactory: dockerClientFactory,
+		bouncer:             bouncer,
+	}
+
+	router := h.PathPrefix(routePrefix).Subrouter()
+	router.Use(bouncer.AuthenticatedAccess, middlewares.CheckEndpointAuthorization(bouncer))
+
+	router.Handle("/{volumeName}/files", httperror.LoggerHandler(h.volumeFilesList)).Methods(http.MethodGet)
+	router.Handle("/{volumeName}/files", httperror.LoggerHandler(h.volumeFilesDelete)).Methods(http.MethodDelete)
+	router.Handle("/{volumeName}/files/download", httperror.LoggerHandler(h.volumeFilesDownload)).Methods(http.MethodGet)
+	router.Handle("/{volumeName}/files/upload", httperror.LoggerHandler(h.volumeFilesUpload)).Methods(http.MethodPost)
+
+	return h
+}
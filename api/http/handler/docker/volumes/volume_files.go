@@ -0,0 +1,340 @@
+package volumes
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"strconv"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+	"github.com/portainer/portainer/api/docker/volumefiles"
+	httperrors "github.com/portainer/portainer/api/http/errors"
+	"github.com/portainer/portainer/api/http/handler/docker/utils"
+	"github.com/portainer/portainer/api/http/middlewares"
+	"github.com/portainer/portainer/api/http/security"
+	endpointsutils "github.com/portainer/portainer/pkg/endpoints"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/request"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+
+	"github.com/rs/zerolog/log"
+)
+
+// transferTimeout bounds the requests sent to the agent, the transfers of large files outlast the default timeout
+// of the Docker clients
+const transferTimeout = time.Hour
+
+var errAgentRequired = errors.New("the files of the volumes can only be browsed on the agent environments reachable by Portainer")
+
+type volumeFilesUploadResponse struct {
+	// Number of files written in the volume
+	Files int `json:"Files" example:"1"`
+}
+
+// @id dockerVolumeFilesList
+// @summary List the files of a volume
+// @description List the files and the directories of a directory of a volume, through the agent of the environment.
+// @description **Access policy**: authenticated, with access to the volume. The regular users also need the volume
+// @description browser to be allowed on the environment
+// @tags docker
+// @security ApiKeyAuth
+// @security jwt
+// @produce json
+// @param environmentId path int true "Environment identifier"
+// @param volumeName path string true "Volume name"
+// @param path query string false "Path of the directory in the volume, the root of the volume by default"
+// @success 200 {array} volumefiles.File "Success"
+// @failure 400 "Bad request"
+// @failure 403 "Permission denied to access the volume"
+// @failure 404 "Environment, volume or directory not found"
+// @failure 500 "Internal server error"
+// @router /docker/{environmentId}/volumes/{volumeName}/files [get]
+func (handler *Handler) volumeFilesList(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	dir, _ := request.RetrieveQueryParameter(r, "path", true)
+
+	client, volumeName, httpErr := handler.volumeFilesClient(r, portainer.OperationDockerAgentBrowseList)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	files, err := client.List(r.Context(), volumeName, dir)
+	if err != nil {
+		return fileError("Unable to list the files of the volume", err)
+	}
+
+	return response.JSON(w, files)
+}
+
+// @id dockerVolumeFilesDownload
+// @summary Download a file of a volume
+// @description Download a file of a volume, or the content of a directory as a tar archive whose paths are relative
+// @description to the directory.
+// @description **Access policy**: authenticated, with access to the volume. The regular users also need the volume
+// @description browser to be allowed on the environment
+// @tags docker
+// @security ApiKeyAuth
+// @security jwt
+// @produce application/octet-stream,application/x-tar
+// @param environmentId path int true "Environment identifier"
+// @param volumeName path string true "Volume name"
+// @param path query string false "Path of the file or the directory in the volume, the root of the volume by default"
+// @success 200 {file} file "Success"
+// @failure 400 "Bad request"
+// @failure 403 "Permission denied to access the volume"
+// @failure 404 "Environment, volume or file not found"
+// @failure 500 "Internal server error"
+// @router /docker/{environmentId}/volumes/{volumeName}/files/download [get]
+func (handler *Handler) volumeFilesDownload(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	p, _ := request.RetrieveQueryParameter(r, "path", true)
+	p = volumefiles.CleanPath(p)
+
+	client, volumeName, httpErr := handler.volumeFilesClient(r, portainer.OperationDockerAgentBrowseGet)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	file, err := client.Stat(r.Context(), volumeName, p)
+	if err != nil {
+		return fileError("Unable to find the file in the volume", err)
+	}
+
+	if file.Dir {
+		name := path.Base(p)
+		if p == "/" {
+			name = volumeName
+		}
+
+		w.Header().Set("Content-Type", "application/x-tar")
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", name+".tar"))
+
+		// the response has already started, the download is left incomplete
+		if err := volumefiles.WriteTar(r.Context(), client, volumeName, p, w); err != nil {
+			log.Warn().Err(err).Str("volume", volumeName).Str("path", p).Msg("unable to download the directory of the volume")
+		}
+
+		return nil
+	}
+
+	content, err := client.Open(r.Context(), volumeName, p)
+	if err != nil {
+		return fileError("Unable to read the file of the volume", err)
+	}
+	defer content.Close()
+
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", path.Base(p)))
+	w.Header().Set("Content-Length", strconv.FormatInt(file.Size, 10))
+
+	if _, err := io.CopyN(w, content, file.Size); err != nil {
+		log.Warn().Err(err).Str("volume", volumeName).Str("path", p).Msg("unable to download the file of the volume")
+	}
+
+	return nil
+}
+
+// @id dockerVolumeFilesUpload
+// @summary Upload files in a volume
+// @description Write a file in a directory of a volume, replacing the existing one, or the regular files of a tar
+// @description archive when extract is set. The missing directories are created.
+// @description **Access policy**: authenticated, with access to the volume. The regular users also need the volume
+// @description browser to be allowed on the environment
+// @tags docker
+// @security ApiKeyAuth
+// @security jwt
+// @accept multipart/form-data
+// @produce json
+// @param environmentId path int true "Environment identifier"
+// @param volumeName path string true "Volume name"
+// @param path query string false "Path of the directory in the volume, the root of the volume by default"
+// @param extract query boolean false "Whether the file is a tar archive whose files are written in the directory"
+// @param file formData file true "File"
+// @success 200 {object} volumeFilesUploadResponse "Success"
+// @failure 400 "Bad request"
+// @failure 403 "Permission denied to access the volume"
+// @failure 404 "Environment or volume not found"
+// @failure 500 "Internal server error"
+// @router /docker/{environmentId}/volumes/{volumeName}/files/upload [post]
+func (handler *Handler) volumeFilesUpload(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	dir, _ := request.RetrieveQueryParameter(r, "path", true)
+	extract, _ := request.RetrieveBooleanQueryParameter(r, "extract", true)
+
+	client, volumeName, httpErr := handler.volumeFilesClient(r, portainer.OperationDockerAgentBrowsePut)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	// the file is streamed to the agent instead of being buffered by Portainer
+	reader, err := r.MultipartReader()
+	if err != nil {
+		return httperror.BadRequest("Invalid multipart form", err)
+	}
+
+	for {
+		part, err := reader.NextPart()
+		if errors.Is(err, io.EOF) {
+			return httperror.BadRequest("Invalid multipart form", errors.New("the form has no file"))
+		} else if err != nil {
+			return httperror.BadRequest("Invalid multipart form", err)
+		}
+
+		if part.FormName() != "file" {
+			continue
+		}
+
+		if extract {
+			count, err := volumefiles.ExtractTar(r.Context(), client, volumeName, dir, part)
+			if err != nil {
+				return fileError("Unable to extract the archive in the volume", err)
+			}
+
+			return response.JSON(w, volumeFilesUploadResponse{Files: count})
+		}
+
+		name := path.Base(volumefiles.CleanPath(part.FileName()))
+		if name == "/" {
+			return httperror.BadRequest("Invalid multipart form", errors.New("the file has no name"))
+		}
+
+		if err := client.Upload(r.Context(), volumeName, dir, name, part); err != nil {
+			return fileError("Unable to write the file in the volume", err)
+		}
+
+		return response.JSON(w, volumeFilesUploadResponse{Files: 1})
+	}
+}
+
+// @id dockerVolumeFilesDelete
+// @summary Delete a file of a volume
+// @description Remove a file of a volume, or a directory with its content.
+// @description **Access policy**: authenticated, with access to the volume. The regular users also need the volume
+// @description browser to be allowed on the environment
+// @tags docker
+// @security ApiKeyAuth
+// @security jwt
+// @param environmentId path int true "Environment identifier"
+// @param volumeName path string true "Volume name"
+// @param path query string true "Path of the file or the directory in the volume"
+// @success 204 "Success"
+// @failure 400 "Bad request"
+// @failure 403 "Permission denied to access the volume"
+// @failure 404 "Environment, volume or file not found"
+// @failure 500 "Internal server error"
+// @router /docker/{environmentId}/volumes/{volumeName}/files [delete]
+func (handler *Handler) volumeFilesDelete(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	p, err := request.RetrieveQueryParameter(r, "path", false)
+	if err != nil {
+		return httperror.BadRequest("Invalid query parameter: path", err)
+	}
+
+	if volumefiles.CleanPath(p) == "/" {
+		return httperror.BadRequest("Invalid query parameter: path", errors.New("the root of the volume cannot be removed"))
+	}
+
+	client, volumeName, httpErr := handler.volumeFilesClient(r, portainer.OperationDockerAgentBrowseDelete)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	if _, err := client.Stat(r.Context(), volumeName, p); err != nil {
+		return fileError("Unable to find the file in the volume", err)
+	}
+
+	if err := client.Delete(r.Context(), volumeName, p); err != nil {
+		return fileError("Unable to remove the file of the volume", err)
+	}
+
+	return response.Empty(w)
+}
+
+// volumeFilesClient returns a client of the file browser of the agent and the volume of a request, after verifying
+// that the user can run the operation on the volume
+func (handler *Handler) volumeFilesClient(r *http.Request, operation portainer.Authorization) (*volumefiles.Client, string, *httperror.HandlerError) {
+	volumeName, err := request.RetrieveRouteVariableValue(r, "volumeName")
+	if err != nil {
+		return nil, "", httperror.BadRequest("Invalid volume name route variable", err)
+	}
+
+	endpoint, err := middlewares.FetchEndpoint(r)
+	if err != nil {
+		return nil, "", httperror.NotFound("Unable to find an environment on request context", err)
+	}
+
+	if (endpoint.Type != portainer.AgentOnDockerEnvironment && endpoint.Type != portainer.EdgeAgentOnDockerEnvironment) ||
+		!endpointsutils.HasDirectConnectivity(endpoint) {
+		return nil, "", httperror.BadRequest("Unsupported environment", errAgentRequired)
+	}
+
+	if err := handler.bouncer.AuthorizedEndpointRoleOperation(r, endpoint, operation); err != nil {
+		return nil, "", httperror.Forbidden("Permission denied by your role on the environment", err)
+	}
+
+	securityContext, err := security.RetrieveRestrictedRequestContext(r)
+	if err != nil {
+		return nil, "", httperror.InternalServerError("Unable to retrieve user details from request context", err)
+	}
+
+	agentTargetHeader := r.Header.Get(portainer.PortainerAgentTargetHeader)
+
+	timeout := transferTimeout
+
+	cli, err := handler.dockerClientFactory.CreateClient(endpoint, agentTargetHeader, &timeout)
+	if err != nil {
+		return nil, "", httperror.InternalServerError("Unable to connect to the Docker daemon", err)
+	}
+	// closing the Docker client leaves its HTTP client usable by the client of the file browser
+	defer cli.Close()
+
+	volume, err := cli.VolumeInspect(r.Context(), volumeName)
+	if err != nil {
+		return nil, "", httperror.NotFound("Unable to find the volume", err)
+	}
+
+	info, err := cli.Info(r.Context())
+	if err != nil {
+		return nil, "", httperror.InternalServerError("Unable to retrieve the information of the Docker environment", err)
+	}
+
+	dockerID := info.ID
+	if info.Swarm.Cluster != nil {
+		dockerID = info.Swarm.Cluster.ID
+	}
+
+	var canBrowse bool
+	if err := handler.dataStore.ViewTx(func(tx dataservices.DataStoreTx) error {
+		canBrowse, err = utils.UserCanBrowseVolume(tx, securityContext, endpoint, dockerID, &volume)
+		return err
+	}); err != nil {
+		return nil, "", httperror.InternalServerError("Unable to verify the access to the volume", err)
+	}
+
+	if !canBrowse {
+		return nil, "", httperror.Forbidden("Permission denied to access the volume", httperrors.ErrResourceAccessDenied)
+	}
+
+	client, err := handler.dockerClientFactory.CreateVolumeFilesClient(cli, endpoint, agentTargetHeader)
+	if err != nil {
+		return nil, "", httperror.InternalServerError("Unable to connect to the agent", err)
+	}
+
+	return client, volume.Name, nil
+}
+
+// fileError maps the errors of the agent to the ones of the API
+func fileError(message string, err error) *httperror.HandlerError {
+	var statusErr *volumefiles.StatusError
+
+	switch {
+	case errors.Is(err, volumefiles.ErrNotFound):
+		return httperror.NotFound(message, err)
+	case errors.As(err, &statusErr) && statusErr.StatusCode == http.StatusForbidden:
+		return httperror.Forbidden(message, err)
+	case errors.As(err, &statusErr) && statusErr.StatusCode == http.StatusBadRequest:
+		return httperror.BadRequest(message, err)
+	}
+
+	return httperror.InternalServerError(message, err)
+}
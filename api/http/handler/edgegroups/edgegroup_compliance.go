@@ -0,0 +1,113 @@
+package edgegroups
+
+import (
+	"errors"
+	"net/http"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+	"github.com/portainer/portainer/api/internal/edge/compliance"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/request"
+)
+
+type edgeGroupComplianceSummary struct {
+	EdgeGroupID portainer.EdgeGroupID `json:"EdgeGroupId" example:"1"`
+	Name        string                `json:"Name"`
+	Summary     compliance.Summary    `json:"Summary"`
+}
+
+// @id EdgeGroupComplianceList
+// @summary List the compliance of every EdgeGroup
+// @description Counts, for every Edge group, the environments running the current version of their Edge stacks
+// @description against the drifted, offline and failed ones.
+// @description **Access policy**: administrator
+// @tags edge_groups
+// @security ApiKeyAuth
+// @security jwt
+// @produce json
+// @success 200 {array} edgeGroupComplianceSummary
+// @failure 500
+// @failure 503 "Edge compute features are disabled"
+// @router /edge_groups/compliance [get]
+func (handler *Handler) edgeGroupComplianceList(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	var summaries []edgeGroupComplianceSummary
+
+	err := handler.DataStore.ViewTx(func(tx dataservices.DataStoreTx) error {
+		evaluator, err := compliance.NewEvaluator(tx)
+		if err != nil {
+			return httperror.InternalServerError("Unable to compute the compliance of the Edge groups", err)
+		}
+
+		edgeGroups, err := tx.EdgeGroup().ReadAll()
+		if err != nil {
+			return httperror.InternalServerError("Unable to retrieve Edge groups from the database", err)
+		}
+
+		summaries = make([]edgeGroupComplianceSummary, 0, len(edgeGroups))
+		for i := range edgeGroups {
+			summaries = append(summaries, edgeGroupComplianceSummary{
+				EdgeGroupID: edgeGroups[i].ID,
+				Name:        edgeGroups[i].Name,
+				Summary:     evaluator.EdgeGroupReport(&edgeGroups[i]).Summary,
+			})
+		}
+
+		return nil
+	})
+
+	return txResponse(w, summaries, err)
+}
+
+// @id EdgeGroupCompliance
+// @summary Inspect the compliance of an EdgeGroup
+// @description Lists the environments of the Edge group along with the state of each of their Edge stacks.
+// @description **Access policy**: administrator
+// @tags edge_groups
+// @security ApiKeyAuth
+// @security jwt
+// @produce json
+// @param id path int true "EdgeGroup Id"
+// @param state query string false "Only list the environments in this state" Enums(InSync, Drifted, Offline, Error)
+// @success 200 {object} compliance.Report
+// @failure 400
+// @failure 404
+// @failure 500
+// @failure 503 "Edge compute features are disabled"
+// @router /edge_groups/{id}/compliance [get]
+func (handler *Handler) edgeGroupCompliance(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	edgeGroupID, err := request.RetrieveNumericRouteVariableValue(r, "id")
+	if err != nil {
+		return httperror.BadRequest("Invalid Edge group identifier route variable", err)
+	}
+
+	state, _ := request.RetrieveQueryParameter(r, "state", true)
+	if state != "" && !compliance.State(state).IsValid() {
+		return httperror.BadRequest("Invalid state query parameter", errors.New("state must be one of: InSync, Drifted, Offline or Error"))
+	}
+
+	var report compliance.Report
+	err = handler.DataStore.ViewTx(func(tx dataservices.DataStoreTx) error {
+		edgeGroup, err := tx.EdgeGroup().Read(portainer.EdgeGroupID(edgeGroupID))
+		if tx.IsErrObjectNotFound(err) {
+			return httperror.NotFound("Unable to find an Edge group with the specified identifier inside the database", err)
+		} else if err != nil {
+			return httperror.InternalServerError("Unable to find an Edge group with the specified identifier inside the database", err)
+		}
+
+		evaluator, err := compliance.NewEvaluator(tx)
+		if err != nil {
+			return httperror.InternalServerError("Unable to compute the compliance of the Edge group", err)
+		}
+
+		report = evaluator.EdgeGroupReport(edgeGroup)
+
+		return nil
+	})
+
+	if state != "" {
+		report = report.Filter(compliance.State(state))
+	}
+
+	return txResponse(w, report, err)
+}
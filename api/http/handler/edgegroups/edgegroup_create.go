@@ -17,6 +17,8 @@ type edgeGroupCreatePayload struct {
 	TagIDs       []portainer.TagID
 	Endpoints    []portainer.EndpointID
 	PartialMatch bool
+	// Maximum rate, in bytes per second, of the data transfers initiated by Portainer. 0 means unlimited
+	BandwidthLimit int64 `example:"1048576"`
 }
 
 func (payload *edgeGroupCreatePayload) Validate(r *http.Request) error {
@@ -28,6 +30,10 @@ func (payload *edgeGroupCreatePayload) Validate(r *http.Request) error {
 		return errors.New("tagIDs is mandatory for a dynamic Edge group")
 	}
 
+	if payload.BandwidthLimit < 0 {
+		return errors.New("invalid bandwidth limit")
+	}
+
 	return nil
 }
 
@@ -90,11 +96,12 @@ func (handler *Handler) edgeGroupCreate(w http.ResponseWriter, r *http.Request)
 		}
 
 		edgeGroup = &portainer.EdgeGroup{
-			Name:         payload.Name,
-			Dynamic:      payload.Dynamic,
-			TagIDs:       []portainer.TagID{},
-			Endpoints:    []portainer.EndpointID{},
-			PartialMatch: payload.PartialMatch,
+			Name:           payload.Name,
+			Dynamic:        payload.Dynamic,
+			TagIDs:         []portainer.TagID{},
+			Endpoints:      []portainer.EndpointID{},
+			PartialMatch:   payload.PartialMatch,
+			BandwidthLimit: payload.BandwidthLimit,
 		}
 
 		if err := calculateEndpointsOrTags(tx, edgeGroup, payload.Endpoints, payload.TagIDs); err != nil {
@@ -21,6 +21,8 @@ type edgeGroupUpdatePayload struct {
 	TagIDs       []portainer.TagID
 	Endpoints    []portainer.EndpointID
 	PartialMatch *bool
+	// Maximum rate, in bytes per second, of the data transfers initiated by Portainer. 0 means unlimited
+	BandwidthLimit *int64 `example:"1048576"`
 }
 
 func (payload *edgeGroupUpdatePayload) Validate(r *http.Request) error {
@@ -32,6 +34,10 @@ func (payload *edgeGroupUpdatePayload) Validate(r *http.Request) error {
 		return errors.New("tagIDs is mandatory for a dynamic Edge group")
 	}
 
+	if payload.BandwidthLimit != nil && *payload.BandwidthLimit < 0 {
+		return errors.New("invalid bandwidth limit")
+	}
+
 	return nil
 }
 
@@ -105,6 +111,12 @@ func (handler *Handler) edgeGroupUpdate(w http.ResponseWriter, r *http.Request)
 			edgeGroup.PartialMatch = *payload.PartialMatch
 		}
 
+		bandwidthLimitChanged := false
+		if payload.BandwidthLimit != nil && *payload.BandwidthLimit != edgeGroup.BandwidthLimit {
+			edgeGroup.BandwidthLimit = *payload.BandwidthLimit
+			bandwidthLimitChanged = true
+		}
+
 		if err := tx.EdgeGroup().Update(edgeGroup.ID, edgeGroup); err != nil {
 			return httperror.InternalServerError("Unable to persist Edge group changes inside the database", err)
 		}
@@ -143,6 +155,11 @@ func (handler *Handler) edgeGroupUpdate(w http.ResponseWriter, r *http.Request)
 				continue
 			}
 
+			if bandwidthLimitChanged {
+				// the bandwidth limit is part of the cached edge status response
+				cache.Del(endpoint.ID)
+			}
+
 			var operation string
 			if slices.Contains(newRelatedEndpoints, endpointID) && slices.Contains(oldRelatedEndpoints, endpointID) {
 				continue
@@ -25,6 +25,10 @@ type edgeJobBasePayload struct {
 	Recurring      bool
 	Endpoints      []portainer.EndpointID
 	EdgeGroups     []portainer.EdgeGroupID
+	// Whether the logs of every execution are collected
+	AutoCollectLogs bool `example:"false"`
+	// Maximum number of executions kept for each environment, 0 only applies the retention policy of the executions
+	MaxExecutions int `example:"20"`
 }
 
 func (handler *Handler) edgeJobCreate(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
@@ -69,6 +73,10 @@ func (payload *edgeJobCreateFromFileContentPayload) Validate(r *http.Request) er
 		return errors.New("invalid script file content")
 	}
 
+	if payload.MaxExecutions < 0 {
+		return errors.New("invalid maximum number of executions")
+	}
+
 	return nil
 }
 
@@ -163,6 +171,15 @@ func (payload *edgeJobCreateFromFilePayload) Validate(r *http.Request) error {
 		return errors.New("no environments or groups have been provided")
 	}
 
+	autoCollectLogs, _ := request.RetrieveBooleanMultiPartFormValue(r, "AutoCollectLogs", true)
+	payload.AutoCollectLogs = autoCollectLogs
+
+	maxExecutions, _ := request.RetrieveNumericMultiPartFormValue(r, "MaxExecutions", true)
+	if maxExecutions < 0 {
+		return errors.New("invalid maximum number of executions")
+	}
+	payload.MaxExecutions = maxExecutions
+
 	file, _, err := request.RetrieveMultiPartFormFile(r, "file")
 	if err != nil {
 		return errors.New("invalid script file. Ensure that the file is uploaded correctly")
@@ -186,6 +203,8 @@ func (payload *edgeJobCreateFromFilePayload) Validate(r *http.Request) error {
 // @param EdgeGroups formData string true "JSON stringified array of Edge Groups ids"
 // @param Endpoints formData string true "JSON stringified array of Environment ids"
 // @param Recurring formData bool false "If recurring"
+// @param AutoCollectLogs formData bool false "Whether the logs of every execution are collected"
+// @param MaxExecutions formData int false "Maximum number of executions kept for each environment"
 // @success 200 {object} portainer.EdgeGroup
 // @failure 503 "Edge compute features are disabled"
 // @failure 500
@@ -218,6 +237,8 @@ func (handler *Handler) createEdgeJobObjectFromPayload(tx dataservices.DataStore
 		EdgeGroups:          payload.EdgeGroups,
 		Version:             1,
 		GroupLogsCollection: map[portainer.EndpointID]portainer.EdgeJobEndpointMeta{},
+		AutoCollectLogs:     payload.AutoCollectLogs,
+		MaxExecutions:       payload.MaxExecutions,
 	}
 }
 
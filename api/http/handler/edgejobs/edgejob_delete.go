@@ -79,6 +79,10 @@ func (handler *Handler) deleteEdgeJob(tx dataservices.DataStoreTx, edgeJobID por
 		cache.Del(endpointID)
 	}
 
+	if err := tx.EdgeJobExecution().DeleteByJobID(edgeJob.ID); err != nil {
+		return httperror.InternalServerError("Unable to remove the executions of the Edge job from the database", err)
+	}
+
 	if err := tx.EdgeJob().Delete(edgeJob.ID); err != nil {
 		return httperror.InternalServerError("Unable to remove the Edge job from the database", err)
 	}
@@ -0,0 +1,146 @@
+package edgejobs
+
+import (
+	"errors"
+	"net/http"
+	"slices"
+	"strconv"
+
+	portainer "github.com/portainer/portainer/api"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/request"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+)
+
+// @id EdgeJobExecutionList
+// @summary List the executions of an EdgeJob
+// @description List the executions of an Edge job reported by the agents, the most recent first, without their logs.
+// @description The logs of the last execution of an environment can be collected on demand with the
+// @description /edge_jobs/{id}/tasks/{taskID}/logs endpoint, or with every execution when the job collects them
+// @description automatically.
+// @description **Access policy**: administrator
+// @tags edge_jobs
+// @security ApiKeyAuth
+// @security jwt
+// @produce json
+// @param id path int true "EdgeJob Id"
+// @param endpointId query int false "Only list the executions on this environment"
+// @param start query int false "Start searching from"
+// @param limit query int false "Limit results to this value"
+// @success 200 {array} portainer.EdgeJobExecution "Success"
+// @failure 400 "Invalid request"
+// @failure 404 "Edge job not found"
+// @failure 500 "Server error"
+// @failure 503 "Edge compute features are disabled"
+// @router /edge_jobs/{id}/executions [get]
+func (handler *Handler) edgeJobExecutionList(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	edgeJob, httpErr := handler.readEdgeJob(r)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	endpointID, _ := request.RetrieveNumericQueryParameter(r, "endpointId", true)
+
+	start, _ := request.RetrieveNumericQueryParameter(r, "start", true)
+	if start != 0 {
+		start--
+	}
+
+	limit, _ := request.RetrieveNumericQueryParameter(r, "limit", true)
+
+	executions, err := handler.DataStore.EdgeJobExecution().ExecutionsByJobID(edgeJob.ID)
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve the executions of the Edge job from the database", err)
+	}
+
+	executions = slices.DeleteFunc(executions, func(execution portainer.EdgeJobExecution) bool {
+		return endpointID != 0 && execution.EndpointID != portainer.EndpointID(endpointID)
+	})
+
+	slices.SortFunc(executions, func(a, b portainer.EdgeJobExecution) int {
+		if a.StartedAt != b.StartedAt {
+			return int(b.StartedAt - a.StartedAt)
+		}
+
+		return int(b.ID - a.ID)
+	})
+
+	w.Header().Set("X-Total-Count", strconv.Itoa(len(executions)))
+
+	executions = paginate(executions, start, limit)
+	for i := range executions {
+		executions[i].Logs = ""
+	}
+
+	return response.JSON(w, executions)
+}
+
+// @id EdgeJobExecutionInspect
+// @summary Inspect an execution of an EdgeJob
+// @description Retrieve an execution of an Edge job with the end of the output of its script, when collected.
+// @description **Access policy**: administrator
+// @tags edge_jobs
+// @security ApiKeyAuth
+// @security jwt
+// @produce json
+// @param id path int true "EdgeJob Id"
+// @param executionId path int true "Execution identifier"
+// @success 200 {object} portainer.EdgeJobExecution "Success"
+// @failure 400 "Invalid request"
+// @failure 404 "Edge job or execution not found"
+// @failure 500 "Server error"
+// @failure 503 "Edge compute features are disabled"
+// @router /edge_jobs/{id}/executions/{executionId} [get]
+func (handler *Handler) edgeJobExecutionInspect(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	edgeJob, httpErr := handler.readEdgeJob(r)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	executionID, err := request.RetrieveNumericRouteVariableValue(r, "executionId")
+	if err != nil {
+		return httperror.BadRequest("Invalid execution identifier route variable", err)
+	}
+
+	execution, err := handler.DataStore.EdgeJobExecution().Read(portainer.EdgeJobExecutionID(executionID))
+	if handler.DataStore.IsErrObjectNotFound(err) {
+		return httperror.NotFound("Unable to find an execution with the specified identifier inside the database", err)
+	} else if err != nil {
+		return httperror.InternalServerError("Unable to find an execution with the specified identifier inside the database", err)
+	}
+
+	if execution.JobID != edgeJob.ID {
+		return httperror.NotFound("Unable to find an execution with the specified identifier inside the database", errors.New("the execution belongs to another job"))
+	}
+
+	return response.JSON(w, execution)
+}
+
+func (handler *Handler) readEdgeJob(r *http.Request) (*portainer.EdgeJob, *httperror.HandlerError) {
+	edgeJobID, err := request.RetrieveNumericRouteVariableValue(r, "id")
+	if err != nil {
+		return nil, httperror.BadRequest("Invalid Edge job identifier route variable", err)
+	}
+
+	edgeJob, err := handler.DataStore.EdgeJob().Read(portainer.EdgeJobID(edgeJobID))
+	if handler.DataStore.IsErrObjectNotFound(err) {
+		return nil, httperror.NotFound("Unable to find an Edge job with the specified identifier inside the database", err)
+	} else if err != nil {
+		return nil, httperror.InternalServerError("Unable to find an Edge job with the specified identifier inside the database", err)
+	}
+
+	return edgeJob, nil
+}
+
+func paginate(executions []portainer.EdgeJobExecution, start, limit int) []portainer.EdgeJobExecution {
+	if start >= len(executions) {
+		return []portainer.EdgeJobExecution{}
+	}
+
+	end := len(executions)
+	if limit > 0 {
+		end = min(start+limit, end)
+	}
+
+	return executions[start:end]
+}
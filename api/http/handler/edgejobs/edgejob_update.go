@@ -25,6 +25,10 @@ type edgeJobUpdatePayload struct {
 	Endpoints      []portainer.EndpointID
 	EdgeGroups     []portainer.EdgeGroupID
 	FileContent    *string
+	// Whether the logs of every execution are collected
+	AutoCollectLogs *bool `example:"false"`
+	// Maximum number of executions kept for each environment, 0 only applies the retention policy of the executions
+	MaxExecutions *int `example:"20"`
 }
 
 func (payload *edgeJobUpdatePayload) Validate(r *http.Request) error {
@@ -32,6 +36,10 @@ func (payload *edgeJobUpdatePayload) Validate(r *http.Request) error {
 		return errors.New("invalid Edge job name format. Allowed characters are: [a-zA-Z0-9_.-]")
 	}
 
+	if payload.MaxExecutions != nil && *payload.MaxExecutions < 0 {
+		return errors.New("invalid maximum number of executions")
+	}
+
 	return nil
 }
 
@@ -212,6 +220,16 @@ func (handler *Handler) updateEdgeSchedule(tx dataservices.DataStoreTx, edgeJob
 		updateVersion = true
 	}
 
+	// the agents learn whether to collect the logs with the next version of the job
+	if payload.AutoCollectLogs != nil && *payload.AutoCollectLogs != edgeJob.AutoCollectLogs {
+		edgeJob.AutoCollectLogs = *payload.AutoCollectLogs
+		updateVersion = true
+	}
+
+	if payload.MaxExecutions != nil {
+		edgeJob.MaxExecutions = *payload.MaxExecutions
+	}
+
 	if updateVersion {
 		edgeJob.Version++
 	}
@@ -40,6 +40,10 @@ func NewHandler(bouncer security.BouncerService) *Handler {
 		bouncer.AdminAccess(bouncer.EdgeComputeOperation(httperror.LoggerHandler(h.edgeJobUpdate)))).Methods(http.MethodPut)
 	h.Handle("/edge_jobs/{id}",
 		bouncer.AdminAccess(bouncer.EdgeComputeOperation(httperror.LoggerHandler(h.edgeJobDelete)))).Methods(http.MethodDelete)
+	h.Handle("/edge_jobs/{id}/executions",
+		bouncer.AdminAccess(bouncer.EdgeComputeOperation(httperror.LoggerHandler(h.edgeJobExecutionList)))).Methods(http.MethodGet)
+	h.Handle("/edge_jobs/{id}/executions/{executionId}",
+		bouncer.AdminAccess(bouncer.EdgeComputeOperation(httperror.LoggerHandler(h.edgeJobExecutionInspect)))).Methods(http.MethodGet)
 	h.Handle("/edge_jobs/{id}/file",
 		bouncer.AdminAccess(bouncer.EdgeComputeOperation(httperror.LoggerHandler(h.edgeJobFile)))).Methods(http.MethodGet)
 	h.Handle("/edge_jobs/{id}/tasks",
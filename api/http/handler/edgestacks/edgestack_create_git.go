@@ -48,6 +48,8 @@ type edgeStackFromGitRepositoryPayload struct {
 	UseManifestNamespaces bool
 	// TLSSkipVerify skips SSL verification when cloning the Git repository
 	TLSSkipVerify bool `example:"false"`
+	// Staggered rollout policy of the stack
+	Rollout portainer.EdgeStackRolloutPolicy
 }
 
 func (payload *edgeStackFromGitRepositoryPayload) Validate(r *http.Request) error {
@@ -84,7 +86,7 @@ func (payload *edgeStackFromGitRepositoryPayload) Validate(r *http.Request) erro
 		return httperrors.NewInvalidPayloadError("Invalid edge groups. At least one edge group must be specified")
 	}
 
-	return nil
+	return validateRolloutPolicy(payload.Rollout, portainer.EdgeStackPrePullConfig{})
 }
 
 // @id EdgeStackCreateRepository
@@ -113,6 +115,8 @@ func (handler *Handler) createEdgeStackFromGitRepository(r *http.Request, tx dat
 		return nil, errors.Wrap(err, "failed to create edge stack object")
 	}
 
+	stack.Rollout = payload.Rollout
+
 	if dryrun {
 		return stack, nil
 	}
@@ -34,6 +34,10 @@ type edgeStackFromStringPayload struct {
 	Registries []portainer.RegistryID
 	// Uses the manifest's namespaces instead of the default one
 	UseManifestNamespaces bool
+	// Image pre-pull configuration of the stack
+	PrePull portainer.EdgeStackPrePullConfig
+	// Staggered rollout policy of the stack
+	Rollout portainer.EdgeStackRolloutPolicy
 }
 
 func (payload *edgeStackFromStringPayload) Validate(r *http.Request) error {
@@ -57,6 +61,14 @@ func (payload *edgeStackFromStringPayload) Validate(r *http.Request) error {
 		return httperrors.NewInvalidPayloadError("Invalid deployment type")
 	}
 
+	if err := validatePrePullConfig(payload.PrePull); err != nil {
+		return err
+	}
+
+	if err := validateRolloutPolicy(payload.Rollout, payload.PrePull); err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -86,6 +98,10 @@ func (handler *Handler) createEdgeStackFromFileContent(r *http.Request, tx datas
 		return nil, errors.Wrap(err, "failed to create Edge stack object")
 	}
 
+	stack.PrePull = payload.PrePull
+	stack.PrePull.Activated = false
+	stack.Rollout = payload.Rollout
+
 	if dryrun {
 		return stack, nil
 	}
@@ -0,0 +1,106 @@
+package edgestacks
+
+import (
+	"net/http"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+	httperrors "github.com/portainer/portainer/api/http/errors"
+	edgestackutils "github.com/portainer/portainer/api/internal/edge/edgestacks"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/request"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+
+	"github.com/pkg/errors"
+)
+
+// @id EdgeStackPrePullInspect
+// @summary Inspect the image pre-pull readiness of an EdgeStack
+// @description **Access policy**: administrator
+// @tags edge_stacks
+// @security ApiKeyAuth
+// @security jwt
+// @produce json
+// @param id path int true "EdgeStack Id"
+// @success 200 {object} edgestackutils.PrePullReadiness
+// @failure 400
+// @failure 404
+// @failure 500
+// @failure 503 "Edge compute features are disabled"
+// @router /edge_stacks/{id}/prepull [get]
+func (handler *Handler) edgeStackPrePullInspect(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	edgeStackID, err := request.RetrieveNumericRouteVariableValue(r, "id")
+	if err != nil {
+		return httperror.BadRequest("Invalid edge stack identifier route variable", err)
+	}
+
+	edgeStack, err := handler.DataStore.EdgeStack().EdgeStack(portainer.EdgeStackID(edgeStackID))
+	if err != nil {
+		return handler.handlerDBErr(err, "Unable to find an edge stack with the specified identifier inside the database")
+	}
+
+	if !edgeStack.PrePull.Enabled {
+		return httperror.BadRequest("Image pre-pull is not enabled for this edge stack", errors.New("pre-pull disabled"))
+	}
+
+	return response.JSON(w, edgestackutils.GetPrePullReadiness(edgeStack))
+}
+
+// @id EdgeStackPrePullActivate
+// @summary Activate an EdgeStack that is in the image pre-pull phase
+// @description Switches the stack over regardless of the readiness threshold and the activation date.
+// @description **Access policy**: administrator
+// @tags edge_stacks
+// @security ApiKeyAuth
+// @security jwt
+// @produce json
+// @param id path int true "EdgeStack Id"
+// @success 200 {object} portainer.EdgeStack
+// @failure 400
+// @failure 404
+// @failure 500
+// @failure 503 "Edge compute features are disabled"
+// @router /edge_stacks/{id}/prepull/activate [post]
+func (handler *Handler) edgeStackPrePullActivate(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	edgeStackID, err := request.RetrieveNumericRouteVariableValue(r, "id")
+	if err != nil {
+		return httperror.BadRequest("Invalid edge stack identifier route variable", err)
+	}
+
+	var stack *portainer.EdgeStack
+	if err := handler.DataStore.UpdateTx(func(tx dataservices.DataStoreTx) error {
+		stack, err = tx.EdgeStack().EdgeStack(portainer.EdgeStackID(edgeStackID))
+		if err != nil {
+			return handler.handlerDBErr(err, "Unable to find an edge stack with the specified identifier inside the database")
+		}
+
+		if !edgestackutils.IsPrePullPending(stack) {
+			return httperror.BadRequest("Edge stack is not in the image pre-pull phase", errors.New("pre-pull not pending"))
+		}
+
+		edgestackutils.ActivatePrePull(stack)
+
+		return tx.EdgeStack().UpdateEdgeStack(stack.ID, stack)
+	}); err != nil {
+		var httpErr *httperror.HandlerError
+		if errors.As(err, &httpErr) {
+			return httpErr
+		}
+
+		return httperror.InternalServerError("Unable to activate the edge stack", err)
+	}
+
+	return response.JSON(w, stack)
+}
+
+func validatePrePullConfig(config portainer.EdgeStackPrePullConfig) error {
+	if config.ReadinessThreshold < 0 || config.ReadinessThreshold > 100 {
+		return httperrors.NewInvalidPayloadError("Invalid pre-pull readiness threshold, must be between 0 and 100")
+	}
+
+	if config.ActivationDate < 0 {
+		return httperrors.NewInvalidPayloadError("Invalid pre-pull activation date")
+	}
+
+	return nil
+}
@@ -0,0 +1,64 @@
+package edgestacks
+
+import (
+	"net/http"
+
+	portainer "github.com/portainer/portainer/api"
+	httperrors "github.com/portainer/portainer/api/http/errors"
+	edgestackutils "github.com/portainer/portainer/api/internal/edge/edgestacks"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/request"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+
+	"github.com/pkg/errors"
+)
+
+// @id EdgeStackRolloutInspect
+// @summary Inspect the staggered rollout of an EdgeStack
+// @description Returns the progress of the rollout of the current version of the stack and the rollout state of each
+// @description of its environments.
+// @description **Access policy**: administrator
+// @tags edge_stacks
+// @security ApiKeyAuth
+// @security jwt
+// @produce json
+// @param id path int true "EdgeStack Id"
+// @success 200 {object} edgestackutils.RolloutProgress
+// @failure 400
+// @failure 404
+// @failure 500
+// @failure 503 "Edge compute features are disabled"
+// @router /edge_stacks/{id}/rollout [get]
+func (handler *Handler) edgeStackRolloutInspect(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	edgeStackID, err := request.RetrieveNumericRouteVariableValue(r, "id")
+	if err != nil {
+		return httperror.BadRequest("Invalid edge stack identifier route variable", err)
+	}
+
+	edgeStack, err := handler.DataStore.EdgeStack().EdgeStack(portainer.EdgeStackID(edgeStackID))
+	if err != nil {
+		return handler.handlerDBErr(err, "Unable to find an edge stack with the specified identifier inside the database")
+	}
+
+	if edgeStack.RolloutState == nil {
+		return httperror.BadRequest("The current version of this edge stack is not deployed by a staggered rollout", errors.New("no staggered rollout"))
+	}
+
+	return response.JSON(w, edgestackutils.GetRolloutProgress(edgeStack.RolloutState))
+}
+
+func validateRolloutPolicy(policy portainer.EdgeStackRolloutPolicy, prePull portainer.EdgeStackPrePullConfig) error {
+	if policy.BatchPercentage < 0 || policy.BatchPercentage > 100 {
+		return httperrors.NewInvalidPayloadError("Invalid rollout batch percentage, must be between 0 and 100")
+	}
+
+	if policy.FailureThreshold < 0 || policy.FailureThreshold > 100 {
+		return httperrors.NewInvalidPayloadError("Invalid rollout failure threshold, must be between 0 and 100")
+	}
+
+	if prePull.Enabled && edgestackutils.IsStaggeredRollout(policy) {
+		return httperrors.NewInvalidPayloadError("A staggered rollout cannot be combined with the image pre-pull")
+	}
+
+	return nil
+}
@@ -8,6 +8,7 @@ import (
 
 	portainer "github.com/portainer/portainer/api"
 	"github.com/portainer/portainer/api/dataservices"
+	edgestackutils "github.com/portainer/portainer/api/internal/edge/edgestacks"
 	httperror "github.com/portainer/portainer/pkg/libhttp/error"
 	"github.com/portainer/portainer/pkg/libhttp/request"
 	"github.com/portainer/portainer/pkg/libhttp/response"
@@ -123,6 +124,22 @@ func (handler *Handler) updateEdgeStackStatus(tx dataservices.DataStoreTx, r *ht
 
 	updateEnvStatus(payload.EndpointID, stack, deploymentStatus)
 
+	if status == portainer.EdgeStackStatusImagesPulled && edgestackutils.ActivatePrePullIfReady(stack, time.Now().Unix()) {
+		log.Info().
+			Int("stackID", int(stackID)).
+			Msg("images pre-pulled on enough environments, activating the edge stack")
+	}
+
+	if stack.RolloutState != nil {
+		rolloutStatus := stack.RolloutState.Status
+		if edgestackutils.RecordRolloutStatus(stack, payload.EndpointID, status, time.Now().Unix()) && stack.RolloutState.Status != rolloutStatus {
+			log.Info().
+				Int("stackID", int(stackID)).
+				Str("rolloutStatus", string(stack.RolloutState.Status)).
+				Msg("rollout of the edge stack finished")
+		}
+	}
+
 	if err := tx.EdgeStack().UpdateEdgeStack(stackID, stack); err != nil {
 		return nil, handler.handlerDBErr(fmt.Errorf("unable to update Edge stack to the database: %w. Environment name: %s", err, endpoint.Name), "unable to update Edge stack")
 	}
@@ -2,10 +2,12 @@ package edgestacks
 
 import (
 	"net/http"
+	"time"
 
 	portainer "github.com/portainer/portainer/api"
 	"github.com/portainer/portainer/api/dataservices"
 	"github.com/portainer/portainer/api/internal/edge"
+	edgestackutils "github.com/portainer/portainer/api/internal/edge/edgestacks"
 	"github.com/portainer/portainer/api/set"
 	httperror "github.com/portainer/portainer/pkg/libhttp/error"
 	"github.com/portainer/portainer/pkg/libhttp/request"
@@ -21,6 +23,10 @@ type updateEdgeStackPayload struct {
 	DeploymentType   portainer.EdgeStackDeploymentType
 	// Uses the manifest's namespaces instead of the default one
 	UseManifestNamespaces bool
+	// Image pre-pull configuration of the stack, applied with the next version of the stack
+	PrePull *portainer.EdgeStackPrePullConfig
+	// Staggered rollout policy of the stack, applied with the next version of the stack
+	Rollout *portainer.EdgeStackRolloutPolicy
 }
 
 func (payload *updateEdgeStackPayload) Validate(r *http.Request) error {
@@ -32,6 +38,21 @@ func (payload *updateEdgeStackPayload) Validate(r *http.Request) error {
 		return errors.New("edge Groups are mandatory for an Edge stack")
 	}
 
+	if payload.PrePull != nil {
+		if err := validatePrePullConfig(*payload.PrePull); err != nil {
+			return err
+		}
+	}
+
+	if payload.Rollout != nil {
+		prePull := portainer.EdgeStackPrePullConfig{}
+		if payload.PrePull != nil {
+			prePull = *payload.PrePull
+		}
+
+		return validateRolloutPolicy(*payload.Rollout, prePull)
+	}
+
 	return nil
 }
 
@@ -120,9 +141,27 @@ func (handler *Handler) updateEdgeStack(tx dataservices.DataStoreTx, stackID por
 	stack.EdgeGroups = groupsIds
 
 	if payload.UpdateVersion {
+		if payload.PrePull != nil {
+			stack.PrePull = *payload.PrePull
+			stack.PrePull.Activated = false
+		} else if stack.PrePull.Enabled {
+			// every new version goes through the pre-pull phase again
+			stack.PrePull.Activated = false
+		}
+
+		if payload.Rollout != nil {
+			stack.Rollout = *payload.Rollout
+		}
+
+		if stack.PrePull.Enabled && edgestackutils.IsStaggeredRollout(stack.Rollout) {
+			return nil, httperror.BadRequest("A staggered rollout cannot be combined with the image pre-pull", errors.New("staggered rollout with pre-pull"))
+		}
+
 		if err := handler.updateStackVersion(stack, payload.DeploymentType, []byte(payload.StackFileContent), "", relatedEndpointIds); err != nil {
 			return nil, httperror.InternalServerError("Unable to update stack version", err)
 		}
+	} else {
+		edgestackutils.SyncRolloutEndpoints(stack, relatedEndpointIds, time.Now().Unix())
 	}
 
 	if err := tx.EdgeStack().UpdateEdgeStack(stack.ID, stack); err != nil {
@@ -3,6 +3,7 @@ package edgestacks
 import (
 	"fmt"
 	"strconv"
+	"time"
 
 	portainer "github.com/portainer/portainer/api"
 	"github.com/portainer/portainer/api/filesystem"
@@ -14,6 +15,7 @@ import (
 func (handler *Handler) updateStackVersion(stack *portainer.EdgeStack, deploymentType portainer.EdgeStackDeploymentType, config []byte, oldGitHash string, relatedEnvironmentsIDs []portainer.EndpointID) error {
 	stack.Version = stack.Version + 1
 	stack.Status = edgestackutils.NewStatus(stack.Status, relatedEnvironmentsIDs)
+	edgestackutils.StartRollout(stack, relatedEnvironmentsIDs, time.Now().Unix())
 
 	return handler.storeStackFile(stack, deploymentType, config)
 }
@@ -0,0 +1,248 @@
+package endpointedge
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+	"github.com/portainer/portainer/api/http/middlewares"
+	"github.com/portainer/portainer/api/internal/edge"
+	"github.com/portainer/portainer/api/internal/edge/cache"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/request"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+)
+
+// maxExecutionLogsSize is the size of the end of the output of a script kept with its execution
+const maxExecutionLogsSize = 64 * 1024
+
+type edgeJobExecutionPayload struct {
+	// Unix timestamps of the start and of the end of the execution
+	StartedAt int64 `example:"1587399600"`
+	EndedAt   int64 `example:"1587399660"`
+	// Exit code of the script
+	ExitCode int `example:"0"`
+	// Output of the script, only sent when the logs of the execution are collected
+	Logs *string
+}
+
+func (payload *edgeJobExecutionPayload) Validate(r *http.Request) error {
+	if payload.StartedAt <= 0 {
+		return errors.New("invalid start of the execution")
+	}
+
+	if payload.EndedAt < payload.StartedAt {
+		return errors.New("invalid end of the execution, before its start")
+	}
+
+	return nil
+}
+
+// endpointEdgeJobExecutionCreate
+// @summary Record an execution of an EdgeJob
+// @description Record an execution of an Edge job on the environment with its exit code and, when its logs are
+// @description collected, the output of the script. Only the end of the output is kept, and the oldest executions of
+// @description the environment past the maximum number of executions of the job are removed.
+// @description **Access policy**: public
+// @tags edge, endpoints
+// @accept json
+// @produce json
+// @param id path int true "environment(endpoint) Id"
+// @param jobID path int true "Job Id"
+// @param body body edgeJobExecutionPayload true "Execution details"
+// @success 200 {object} portainer.EdgeJobExecution
+// @failure 400
+// @failure 403
+// @failure 404
+// @failure 500
+// @router /endpoints/{id}/edge/jobs/{jobID}/executions [post]
+func (handler *Handler) endpointEdgeJobExecutionCreate(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	endpoint, err := middlewares.FetchEndpoint(r)
+	if err != nil {
+		return httperror.BadRequest("Unable to find an environment on request context", err)
+	}
+
+	if err := handler.requestBouncer.AuthorizedEdgeEndpointOperation(r, endpoint); err != nil {
+		return httperror.Forbidden("Permission denied to access environment", fmt.Errorf("unauthorized edge endpoint operation: %w. Environment name: %s", err, endpoint.Name))
+	}
+
+	edgeJobID, err := request.RetrieveNumericRouteVariableValue(r, "jobID")
+	if err != nil {
+		return httperror.BadRequest("Invalid edge job identifier route variable", fmt.Errorf("invalid Edge job route variable: %w. Environment name: %s", err, endpoint.Name))
+	}
+
+	var payload edgeJobExecutionPayload
+	if err := request.DecodeAndValidateJSONPayload(r, &payload); err != nil {
+		return httperror.BadRequest("Invalid request payload", fmt.Errorf("invalid Edge job execution payload: %w. Environment name: %s", err, endpoint.Name))
+	}
+
+	var execution *portainer.EdgeJobExecution
+	if err := handler.DataStore.UpdateTx(func(tx dataservices.DataStoreTx) error {
+		execution, err = handler.recordEdgeJobExecution(tx, endpoint.ID, portainer.EdgeJobID(edgeJobID), payload)
+
+		return err
+	}); err != nil {
+		var httpErr *httperror.HandlerError
+		if errors.As(err, &httpErr) {
+			httpErr.Err = fmt.Errorf("edge polling error: %w. Environment name: %s", httpErr.Err, endpoint.Name)
+			return httpErr
+		}
+
+		return httperror.InternalServerError("Unexpected error", fmt.Errorf("edge polling error: %w. Environment name: %s", err, endpoint.Name))
+	}
+
+	return response.JSON(w, execution)
+}
+
+func (handler *Handler) recordEdgeJobExecution(tx dataservices.DataStoreTx, endpointID portainer.EndpointID, edgeJobID portainer.EdgeJobID, payload edgeJobExecutionPayload) (*portainer.EdgeJobExecution, error) {
+	edgeJob, err := tx.EdgeJob().Read(edgeJobID)
+	if tx.IsErrObjectNotFound(err) {
+		return nil, httperror.NotFound("Unable to find an edge job with the specified identifier inside the database", err)
+	} else if err != nil {
+		return nil, httperror.InternalServerError("Unable to find an edge job with the specified identifier inside the database", err)
+	}
+
+	targeted, err := edgeJobTargetsEndpoint(tx, edgeJob, endpointID)
+	if err != nil {
+		return nil, httperror.InternalServerError("Unable to retrieve relations", err)
+	} else if !targeted {
+		return nil, httperror.Forbidden("The edge job does not run on the environment", errors.New("the edge job does not target the environment"))
+	}
+
+	execution := &portainer.EdgeJobExecution{
+		JobID:      edgeJob.ID,
+		EndpointID: endpointID,
+		StartedAt:  payload.StartedAt,
+		EndedAt:    payload.EndedAt,
+		ExitCode:   payload.ExitCode,
+	}
+
+	if payload.Logs != nil {
+		execution.LogsCollected = true
+		execution.Logs = tailLogs(*payload.Logs)
+
+		// the logs of the last execution are also the ones of the task of the environment
+		if err := handler.storeEdgeJobTaskLogs(tx, edgeJob, endpointID, execution.Logs); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.EdgeJobExecution().Create(execution); err != nil {
+		return nil, httperror.InternalServerError("Unable to persist the edge job execution to the database", err)
+	}
+
+	if err := pruneEdgeJobExecutions(tx, edgeJob, endpointID); err != nil {
+		return nil, httperror.InternalServerError("Unable to remove the oldest executions of the edge job from the database", err)
+	}
+
+	return execution, nil
+}
+
+// storeEdgeJobTaskLogs stores the collected logs of the task of an environment and marks their collection as done
+func (handler *Handler) storeEdgeJobTaskLogs(tx dataservices.DataStoreTx, edgeJob *portainer.EdgeJob, endpointID portainer.EndpointID, logs string) error {
+	if err := handler.FileService.StoreEdgeJobTaskLogFileFromBytes(strconv.Itoa(int(edgeJob.ID)), strconv.Itoa(int(endpointID)), []byte(logs)); err != nil {
+		return httperror.InternalServerError("Unable to save task log to the filesystem", err)
+	}
+
+	meta := portainer.EdgeJobEndpointMeta{CollectLogs: false, LogsStatus: portainer.EdgeJobLogsStatusCollected}
+	if _, ok := edgeJob.GroupLogsCollection[endpointID]; ok {
+		edgeJob.GroupLogsCollection[endpointID] = meta
+	} else {
+		edgeJob.Endpoints[endpointID] = meta
+	}
+
+	if err := tx.EdgeJob().Update(edgeJob.ID, edgeJob); err != nil {
+		return httperror.InternalServerError("Unable to persist edge job changes to the database", err)
+	}
+
+	cache.Del(endpointID)
+
+	return nil
+}
+
+// attachLogsToLastExecution attaches the logs collected on demand to the last execution of an environment, unless its
+// logs were already collected
+func attachLogsToLastExecution(tx dataservices.DataStoreTx, edgeJobID portainer.EdgeJobID, endpointID portainer.EndpointID, logs string) error {
+	executions, err := endpointExecutions(tx, edgeJobID, endpointID)
+	if err != nil || len(executions) == 0 || executions[0].LogsCollected {
+		return err
+	}
+
+	execution := executions[0]
+	execution.LogsCollected = true
+	execution.Logs = tailLogs(logs)
+
+	return tx.EdgeJobExecution().Update(execution.ID, &execution)
+}
+
+// pruneEdgeJobExecutions removes the oldest executions of an environment past the maximum number of executions of
+// the job
+func pruneEdgeJobExecutions(tx dataservices.DataStoreTx, edgeJob *portainer.EdgeJob, endpointID portainer.EndpointID) error {
+	if edgeJob.MaxExecutions <= 0 {
+		return nil
+	}
+
+	executions, err := endpointExecutions(tx, edgeJob.ID, endpointID)
+	if err != nil || len(executions) <= edgeJob.MaxExecutions {
+		return err
+	}
+
+	for _, execution := range executions[edgeJob.MaxExecutions:] {
+		if err := tx.EdgeJobExecution().Delete(execution.ID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// endpointExecutions returns the executions of an Edge job on an environment, the most recent first
+func endpointExecutions(tx dataservices.DataStoreTx, edgeJobID portainer.EdgeJobID, endpointID portainer.EndpointID) ([]portainer.EdgeJobExecution, error) {
+	executions, err := tx.EdgeJobExecution().ExecutionsByJobID(edgeJobID)
+	if err != nil {
+		return nil, err
+	}
+
+	executions = slices.DeleteFunc(executions, func(execution portainer.EdgeJobExecution) bool {
+		return execution.EndpointID != endpointID
+	})
+
+	slices.SortFunc(executions, func(a, b portainer.EdgeJobExecution) int {
+		if a.StartedAt != b.StartedAt {
+			return int(b.StartedAt - a.StartedAt)
+		}
+
+		return int(b.ID - a.ID)
+	})
+
+	return executions, nil
+}
+
+// edgeJobTargetsEndpoint returns true when an Edge job runs on an environment, directly or through its edge groups
+func edgeJobTargetsEndpoint(tx dataservices.DataStoreTx, edgeJob *portainer.EdgeJob, endpointID portainer.EndpointID) (bool, error) {
+	if _, ok := edgeJob.Endpoints[endpointID]; ok {
+		return true, nil
+	}
+
+	for _, edgeGroupID := range edgeJob.EdgeGroups {
+		member, _, err := edge.EndpointInEdgeGroup(tx, endpointID, edgeGroupID)
+		if err != nil || member {
+			return member, err
+		}
+	}
+
+	return false, nil
+}
+
+// tailLogs returns the end of the output of a script kept with its execution
+func tailLogs(logs string) string {
+	if len(logs) > maxExecutionLogsSize {
+		return logs[len(logs)-maxExecutionLogsSize:]
+	}
+
+	return logs
+}
@@ -0,0 +1,108 @@
+package endpointedge
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+
+	"github.com/segmentio/encoding/json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEdgeJobExecutions(t *testing.T) {
+	handler := mustSetupHandler(t)
+
+	endpoint := portainer.Endpoint{
+		ID:              91,
+		Name:            "test-endpoint-91",
+		Type:            portainer.EdgeAgentOnDockerEnvironment,
+		URL:             "https://portainer.io:9443",
+		EdgeID:          "edge-id-91",
+		LastCheckInDate: time.Now().Unix(),
+		UserTrusted:     true,
+	}
+	require.NoError(t, createEndpoint(handler, endpoint, portainer.EndpointRelation{EndpointID: endpoint.ID}))
+
+	edgeJob := portainer.EdgeJob{
+		ID:             36,
+		CronExpression: "* * * * *",
+		Name:           "test-edge-job",
+		Endpoints: map[portainer.EndpointID]portainer.EdgeJobEndpointMeta{
+			endpoint.ID: {},
+		},
+		GroupLogsCollection: map[portainer.EndpointID]portainer.EdgeJobEndpointMeta{},
+		MaxExecutions:       2,
+	}
+	require.NoError(t, handler.DataStore.EdgeJob().CreateWithID(edgeJob.ID, &edgeJob))
+
+	post := func(path string, payload any) *httptest.ResponseRecorder {
+		body, err := json.Marshal(payload)
+		require.NoError(t, err)
+
+		req, err := http.NewRequest(http.MethodPost, fmt.Sprintf("/api/endpoints/%d/edge/jobs/%d/%s", endpoint.ID, edgeJob.ID, path), bytes.NewReader(body))
+		require.NoError(t, err)
+		req.Header.Set(portainer.PortainerAgentEdgeIDHeader, endpoint.EdgeID)
+
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		return rec
+	}
+
+	logs := "hello\n"
+	for i, payload := range []edgeJobExecutionPayload{
+		{StartedAt: 100, EndedAt: 110, ExitCode: 1},
+		{StartedAt: 200, EndedAt: 210},
+		{StartedAt: 300, EndedAt: 310, Logs: &logs},
+	} {
+		rec := post("executions", payload)
+		require.Equal(t, http.StatusOK, rec.Code, "execution %d", i)
+	}
+
+	executions, err := endpointExecutions(handler.DataStore, edgeJob.ID, endpoint.ID)
+	require.NoError(t, err)
+	require.Len(t, executions, 2, "the oldest execution is removed")
+
+	assert.Equal(t, int64(300), executions[0].StartedAt)
+	assert.True(t, executions[0].LogsCollected)
+	assert.Equal(t, logs, executions[0].Logs)
+	assert.False(t, executions[1].LogsCollected)
+
+	updatedJob, err := handler.DataStore.EdgeJob().Read(edgeJob.ID)
+	require.NoError(t, err)
+	assert.Equal(t, portainer.EdgeJobLogsStatusCollected, updatedJob.Endpoints[endpoint.ID].LogsStatus)
+
+	t.Run("logs collected on demand", func(t *testing.T) {
+		require.Equal(t, http.StatusOK, post("executions", edgeJobExecutionPayload{StartedAt: 400, EndedAt: 410}).Code)
+		require.Equal(t, http.StatusOK, post("logs", logsPayload{FileContent: "on demand\n"}).Code)
+
+		executions, err := endpointExecutions(handler.DataStore, edgeJob.ID, endpoint.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "on demand\n", executions[0].Logs)
+		assert.Equal(t, logs, executions[1].Logs, "the logs of the older executions are kept")
+	})
+
+	t.Run("invalid executions", func(t *testing.T) {
+		assert.Equal(t, http.StatusBadRequest, post("executions", edgeJobExecutionPayload{StartedAt: 500, EndedAt: 490}).Code)
+
+		otherJob := portainer.EdgeJob{ID: 37, Endpoints: map[portainer.EndpointID]portainer.EdgeJobEndpointMeta{}}
+		require.NoError(t, handler.DataStore.EdgeJob().CreateWithID(otherJob.ID, &otherJob))
+
+		edgeJob.ID = otherJob.ID
+		assert.Equal(t, http.StatusForbidden, post("executions", edgeJobExecutionPayload{StartedAt: 500, EndedAt: 510}).Code)
+	})
+}
+
+func TestTailLogs(t *testing.T) {
+	logs := string(bytes.Repeat([]byte("a"), maxExecutionLogsSize)) + "end"
+
+	assert.Len(t, tailLogs(logs), maxExecutionLogsSize)
+	assert.Equal(t, "end", tailLogs(logs)[maxExecutionLogsSize-3:])
+	assert.Equal(t, "short", tailLogs("short"))
+}
@@ -4,12 +4,10 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
-	"strconv"
 
 	portainer "github.com/portainer/portainer/api"
 	"github.com/portainer/portainer/api/dataservices"
 	"github.com/portainer/portainer/api/http/middlewares"
-	"github.com/portainer/portainer/api/internal/edge/cache"
 	httperror "github.com/portainer/portainer/pkg/libhttp/error"
 	"github.com/portainer/portainer/pkg/libhttp/request"
 	"github.com/portainer/portainer/pkg/libhttp/response"
@@ -85,22 +83,13 @@ func (handler *Handler) getEdgeJobLobs(tx dataservices.DataStoreTx, endpointID p
 		return httperror.InternalServerError("Unable to find an edge job with the specified identifier inside the database", err)
 	}
 
-	if err := handler.FileService.StoreEdgeJobTaskLogFileFromBytes(strconv.Itoa(int(edgeJobID)), strconv.Itoa(int(endpoint.ID)), []byte(payload.FileContent)); err != nil {
-		return httperror.InternalServerError("Unable to save task log to the filesystem", err)
+	if err := handler.storeEdgeJobTaskLogs(tx, edgeJob, endpoint.ID, payload.FileContent); err != nil {
+		return err
 	}
 
-	meta := portainer.EdgeJobEndpointMeta{CollectLogs: false, LogsStatus: portainer.EdgeJobLogsStatusCollected}
-	if _, ok := edgeJob.GroupLogsCollection[endpoint.ID]; ok {
-		edgeJob.GroupLogsCollection[endpoint.ID] = meta
-	} else {
-		edgeJob.Endpoints[endpoint.ID] = meta
+	if err := attachLogsToLastExecution(tx, edgeJob.ID, endpoint.ID, payload.FileContent); err != nil {
+		return httperror.InternalServerError("Unable to persist the logs of the edge job execution to the database", err)
 	}
 
-	if err := tx.EdgeJob().Update(edgeJob.ID, edgeJob); err != nil {
-		return httperror.InternalServerError("Unable to persist edge job changes to the database", err)
-	}
-
-	cache.Del(endpointID)
-
 	return nil
 }
@@ -0,0 +1,88 @@
+package endpointedge
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/portainer/portainer/api/edge/delta"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/request"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+)
+
+// maxChunksPerRequest bounds the size of a chunks response
+const maxChunksPerRequest = 256
+
+type stackChunksPayload struct {
+	// Hashes of the requested chunks
+	Hashes []string
+}
+
+type stackChunksResponse struct {
+	// Digest of the manifest the chunks belong to
+	ManifestDigest string
+	// Base64 encoded content of the chunks, indexed by their hash
+	Chunks map[string]string
+}
+
+func (payload *stackChunksPayload) Validate(r *http.Request) error {
+	if len(payload.Hashes) == 0 {
+		return errors.New("no chunk requested")
+	}
+
+	if len(payload.Hashes) > maxChunksPerRequest {
+		return fmt.Errorf("too many chunks requested, the maximum is %d", maxChunksPerRequest)
+	}
+
+	return nil
+}
+
+// @summary Retrieve chunks of the files of an Edge Stack for an Environment(Endpoint)
+// @description Used by the agents to download the chunks listed in the manifest of a delta update that they don't have yet.
+// @description **Access policy**: public
+// @tags edge, endpoints, edge_stacks
+// @accept json
+// @produce json
+// @param id path int true "environment(endpoint) Id"
+// @param stackId path int true "EdgeStack Id"
+// @param body body stackChunksPayload true "Requested chunks"
+// @success 200 {object} stackChunksResponse
+// @failure 400
+// @failure 404 "Chunk not found in the current version of the stack"
+// @failure 500
+// @router /endpoints/{id}/edge/stacks/{stackId}/chunks [post]
+func (handler *Handler) endpointEdgeStackChunks(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	files, httpErr := handler.loadEdgeStackFiles(r)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	var payload stackChunksPayload
+	if err := request.DecodeAndValidateJSONPayload(r, &payload); err != nil {
+		return httperror.BadRequest("Invalid request payload", err)
+	}
+
+	manifest, chunks, err := delta.BuildManifest(files.dirEntries)
+	if err != nil {
+		return httperror.InternalServerError("Unable to build the manifest of the stack files", fmt.Errorf("failed to build the manifest: %w. Environment name: %s", err, files.endpoint.Name))
+	}
+
+	resp := stackChunksResponse{
+		ManifestDigest: manifest.Digest,
+		Chunks:         make(map[string]string, len(payload.Hashes)),
+	}
+
+	for _, hash := range payload.Hashes {
+		chunk, ok := chunks[hash]
+		if !ok {
+			// the stack was updated since the agent fetched the manifest
+			return httperror.NotFound("Chunk not found in the current version of the stack", fmt.Errorf("unknown chunk %s. Environment name: %s", hash, files.endpoint.Name))
+		}
+
+		resp.Chunks[hash] = base64.StdEncoding.EncodeToString(chunk)
+	}
+
+	return response.JSON(w, resp)
+}
@@ -6,8 +6,10 @@ import (
 
 	portainer "github.com/portainer/portainer/api"
 	"github.com/portainer/portainer/api/edge"
+	"github.com/portainer/portainer/api/edge/delta"
 	"github.com/portainer/portainer/api/filesystem"
 	"github.com/portainer/portainer/api/http/middlewares"
+	edgestackutils "github.com/portainer/portainer/api/internal/edge/edgestacks"
 	"github.com/portainer/portainer/api/internal/endpointutils"
 	"github.com/portainer/portainer/api/kubernetes"
 	httperror "github.com/portainer/portainer/pkg/libhttp/error"
@@ -15,44 +17,93 @@ import (
 	"github.com/portainer/portainer/pkg/libhttp/response"
 )
 
+// edgeStackFiles holds the files of an edge stack sent to an environment
+type edgeStackFiles struct {
+	endpoint    *portainer.Endpoint
+	edgeStack   *portainer.EdgeStack
+	fileName    string
+	namespace   string
+	dirEntries  []filesystem.DirEntry
+	fileContent string
+}
+
 // @summary Inspect an Edge Stack for an Environment(Endpoint)
+// @description When delta is set, the content of the files is replaced by a manifest listing their chunks,
+// @description the agent then downloads the chunks it doesn't have yet.
 // @description **Access policy**: public
 // @tags edge, endpoints, edge_stacks
 // @accept json
 // @produce json
 // @param id path int true "environment(endpoint) Id"
 // @param stackId path int true "EdgeStack Id"
+// @param delta query bool false "Return a manifest of the files chunks instead of their content"
 // @success 200 {object} edge.StackPayload
 // @failure 500
 // @failure 400
 // @failure 404
 // @router /endpoints/{id}/edge/stacks/{stackId} [get]
 func (handler *Handler) endpointEdgeStackInspect(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	useDelta, _ := request.RetrieveBooleanQueryParameter(r, "delta", true)
+
+	files, httpErr := handler.loadEdgeStackFiles(r)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	payload := edge.StackPayload{
+		DirEntries:       files.dirEntries,
+		EntryFileName:    files.fileName,
+		StackFileContent: files.fileContent,
+		Name:             files.edgeStack.Name,
+		Namespace:        files.namespace,
+		PrePullImage:     files.edgeStack.PrePull.Enabled,
+		PrePullOnly:      edgestackutils.IsPrePullPending(files.edgeStack),
+	}
+
+	if useDelta {
+		manifest, _, err := delta.BuildManifest(files.dirEntries)
+		if err != nil {
+			return httperror.InternalServerError("Unable to build the manifest of the stack files", fmt.Errorf("failed to build the manifest: %w. Environment name: %s", err, files.endpoint.Name))
+		}
+
+		payload.DirEntries = nil
+		payload.StackFileContent = ""
+		payload.Manifest = manifest
+	}
+
+	return response.JSON(w, payload)
+}
+
+func (handler *Handler) loadEdgeStackFiles(r *http.Request) (*edgeStackFiles, *httperror.HandlerError) {
 	endpoint, err := middlewares.FetchEndpoint(r)
 	if err != nil {
-		return httperror.BadRequest("Unable to find an environment on request context", err)
+		return nil, httperror.BadRequest("Unable to find an environment on request context", err)
 	}
 
 	if err := handler.requestBouncer.AuthorizedEdgeEndpointOperation(r, endpoint); err != nil {
-		return httperror.Forbidden("Permission denied to access environment", fmt.Errorf("unauthorized edge endpoint operation: %w. Environment name: %s", err, endpoint.Name))
+		return nil, httperror.Forbidden("Permission denied to access environment", fmt.Errorf("unauthorized edge endpoint operation: %w. Environment name: %s", err, endpoint.Name))
 	}
 
 	edgeStackID, err := request.RetrieveNumericRouteVariableValue(r, "stackId")
 	if err != nil {
-		return httperror.BadRequest("Invalid edge stack identifier route variable", fmt.Errorf("invalid Edge stack route variable: %w. Environment name: %s", err, endpoint.Name))
+		return nil, httperror.BadRequest("Invalid edge stack identifier route variable", fmt.Errorf("invalid Edge stack route variable: %w. Environment name: %s", err, endpoint.Name))
 	}
 
 	edgeStack, err := handler.DataStore.EdgeStack().EdgeStack(portainer.EdgeStackID(edgeStackID))
 	if handler.DataStore.IsErrObjectNotFound(err) {
-		return httperror.NotFound("Unable to find an edge stack with the specified identifier inside the database", fmt.Errorf("unable to find the Edge stack from database: %w. Environment name: %s", err, endpoint.Name))
+		return nil, httperror.NotFound("Unable to find an edge stack with the specified identifier inside the database", fmt.Errorf("unable to find the Edge stack from database: %w. Environment name: %s", err, endpoint.Name))
 	} else if err != nil {
-		return httperror.InternalServerError("Unable to find an edge stack with the specified identifier inside the database", fmt.Errorf("failed to find the Edge stack from database: %w. Environment name: %s", err, endpoint.Name))
+		return nil, httperror.InternalServerError("Unable to find an edge stack with the specified identifier inside the database", fmt.Errorf("failed to find the Edge stack from database: %w. Environment name: %s", err, endpoint.Name))
+	}
+
+	if edgestackutils.EndpointVersion(edgeStack.RolloutState, edgeStack.Version, endpoint.ID) == 0 {
+		return nil, httperror.NotFound("The edge stack was not released to the environment yet", fmt.Errorf("the rollout of the Edge stack did not reach the environment. Environment name: %s", endpoint.Name))
 	}
 
 	fileName := edgeStack.EntryPoint
 	if endpointutils.IsDockerEndpoint(endpoint) {
 		if fileName == "" {
-			return httperror.BadRequest("Docker is not supported by this stack", fmt.Errorf("no filename is provided for the Docker endpoint. Environment name: %s", endpoint.Name))
+			return nil, httperror.BadRequest("Docker is not supported by this stack", fmt.Errorf("no filename is provided for the Docker endpoint. Environment name: %s", endpoint.Name))
 		}
 	}
 
@@ -65,27 +116,26 @@ func (handler *Handler) endpointEdgeStackInspect(w http.ResponseWriter, r *http.
 		fileName = edgeStack.ManifestPath
 
 		if fileName == "" {
-			return httperror.BadRequest("Kubernetes is not supported by this stack", fmt.Errorf("no filename is provided for the Kubernetes endpoint. Environment name: %s", endpoint.Name))
+			return nil, httperror.BadRequest("Kubernetes is not supported by this stack", fmt.Errorf("no filename is provided for the Kubernetes endpoint. Environment name: %s", endpoint.Name))
 		}
 	}
 
 	dirEntries, err := filesystem.LoadDir(edgeStack.ProjectPath)
 	if err != nil {
-		return httperror.InternalServerError("Unable to load repository", fmt.Errorf("failed to load project directory: %w. Environment name: %s", err, endpoint.Name))
+		return nil, httperror.InternalServerError("Unable to load repository", fmt.Errorf("failed to load project directory: %w. Environment name: %s", err, endpoint.Name))
 	}
 
 	fileContent, err := filesystem.FilterDirForCompatibility(dirEntries, fileName, endpoint.Agent.Version)
 	if err != nil {
-		return httperror.InternalServerError("File not found", fmt.Errorf("unable to find file: %w. Environment name: %s", err, endpoint.Name))
+		return nil, httperror.InternalServerError("File not found", fmt.Errorf("unable to find file: %w. Environment name: %s", err, endpoint.Name))
 	}
 
-	dirEntries = filesystem.FilterDirForEntryFile(dirEntries, fileName)
-
-	return response.JSON(w, edge.StackPayload{
-		DirEntries:       dirEntries,
-		EntryFileName:    fileName,
-		StackFileContent: fileContent,
-		Name:             edgeStack.Name,
-		Namespace:        namespace,
-	})
+	return &edgeStackFiles{
+		endpoint:    endpoint,
+		edgeStack:   edgeStack,
+		fileName:    fileName,
+		namespace:   namespace,
+		dirEntries:  filesystem.FilterDirForEntryFile(dirEntries, fileName),
+		fileContent: fileContent,
+	}, nil
 }
@@ -0,0 +1,71 @@
+package endpointedge
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/edge"
+
+	"github.com/segmentio/encoding/json"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEdgeStackInspectDelta(t *testing.T) {
+	is := require.New(t)
+
+	handler := mustSetupHandler(t)
+
+	endpoint := portainer.Endpoint{
+		ID:              92,
+		Name:            "test-endpoint-92",
+		Type:            portainer.EdgeAgentOnDockerEnvironment,
+		URL:             "https://portainer.io:9443",
+		EdgeID:          "edge-id-92",
+		LastCheckInDate: time.Now().Unix(),
+		UserTrusted:     true,
+	}
+	is.NoError(createEndpoint(handler, endpoint, portainer.EndpointRelation{EndpointID: endpoint.ID}))
+
+	projectPath, err := handler.FileService.StoreEdgeStackFileFromBytes("7", "docker-compose.yml", []byte("services:\n  web:\n    image: nginx\n"))
+	is.NoError(err)
+
+	edgeStack := portainer.EdgeStack{
+		ID:          7,
+		Name:        "web",
+		ProjectPath: projectPath,
+		EntryPoint:  "docker-compose.yml",
+		Version:     1,
+	}
+	is.NoError(handler.DataStore.EdgeStack().Create(edgeStack.ID, &edgeStack))
+
+	inspect := func(query string) edge.StackPayload {
+		req, err := http.NewRequest(http.MethodGet, fmt.Sprintf("/api/endpoints/%d/edge/stacks/%d%s", endpoint.ID, edgeStack.ID, query), nil)
+		is.NoError(err)
+		req.Header.Set(portainer.PortainerAgentEdgeIDHeader, endpoint.EdgeID)
+
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		is.Equal(http.StatusOK, rec.Code)
+
+		var payload edge.StackPayload
+		is.NoError(json.NewDecoder(rec.Body).Decode(&payload))
+
+		return payload
+	}
+
+	payload := inspect("")
+	is.Contains(payload.StackFileContent, "image: nginx")
+	is.NotEmpty(payload.DirEntries)
+	is.Nil(payload.Manifest)
+
+	// the agent downloads the chunks listed by the manifest, the files are not sent
+	payload = inspect("?delta=true")
+	is.Empty(payload.StackFileContent)
+	is.Empty(payload.DirEntries)
+	is.NotNil(payload.Manifest)
+	is.NotEmpty(payload.Manifest.Entries)
+}
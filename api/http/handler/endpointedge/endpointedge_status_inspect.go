@@ -57,6 +57,8 @@ type endpointEdgeStatusInspectResponse struct {
 	Credentials string `json:"credentials"`
 	// List of stacks to be deployed on the environments(endpoints)
 	Stacks []stackStatusResponse `json:"stacks"`
+	// Maximum rate, in bytes per second, of the data transfers initiated by Portainer. 0 means unlimited
+	BandwidthLimit int64 `json:"bandwidthLimit" example:"1048576"`
 }
 
 // @id EndpointEdgeStatusInspect
@@ -170,6 +172,12 @@ func (handler *Handler) inspectStatus(tx dataservices.DataStoreTx, r *http.Reque
 		Credentials:     tunnel.Credentials,
 	}
 
+	bandwidthLimit, err := edge.EffectiveBandwidthLimit(tx, endpoint)
+	if err != nil {
+		return nil, httperror.InternalServerError("Unable to compute the bandwidth limit of the environment", err)
+	}
+	statusResponse.BandwidthLimit = bandwidthLimit
+
 	schedules, handlerErr := handler.buildSchedules(tx, endpoint.ID)
 	if handlerErr != nil {
 		return nil, handlerErr
@@ -217,18 +225,9 @@ func (handler *Handler) buildSchedules(tx dataservices.DataStoreTx, endpointID p
 	}
 
 	for _, job := range edgeJobs {
-		_, endpointHasJob := job.Endpoints[endpointID]
-		if !endpointHasJob {
-			for _, edgeGroupID := range job.EdgeGroups {
-				member, _, err := edge.EndpointInEdgeGroup(tx, endpointID, edgeGroupID)
-				if err != nil {
-					return nil, httperror.InternalServerError("Unable to retrieve relations", err)
-				} else if member {
-					endpointHasJob = true
-
-					break
-				}
-			}
+		endpointHasJob, err := edgeJobTargetsEndpoint(tx, &job, endpointID)
+		if err != nil {
+			return nil, httperror.InternalServerError("Unable to retrieve relations", err)
 		}
 
 		if !endpointHasJob {
@@ -242,6 +241,8 @@ func (handler *Handler) buildSchedules(tx dataservices.DataStoreTx, endpointID p
 			collectLogs = job.Endpoints[endpointID].CollectLogs
 		}
 
+		collectLogs = collectLogs || job.AutoCollectLogs
+
 		schedule := edgeJobResponse{
 			ID:             job.ID,
 			CronExpression: job.CronExpression,
@@ -269,11 +270,16 @@ func (handler *Handler) buildEdgeStacks(tx dataservices.DataStoreTx, endpointID
 
 	edgeStacksStatus := []stackStatusResponse{}
 	for stackID := range relation.EdgeStacks {
-		version, ok := tx.EdgeStack().EdgeStackVersion(stackID)
+		version, ok := tx.EdgeStack().EdgeStackEndpointVersion(stackID, endpointID)
 		if !ok {
 			return nil, httperror.InternalServerError("Unable to retrieve edge stack from the database", err)
 		}
 
+		// the staggered rollout of the stack did not reach the environment yet
+		if version == 0 {
+			continue
+		}
+
 		stackStatus := stackStatusResponse{
 			ID:      stackID,
 			Version: version,
@@ -0,0 +1,131 @@
+package endpointedge
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+	"github.com/portainer/portainer/api/http/middlewares"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/request"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+)
+
+type transferStatusPayload struct {
+	// Type of the transfer
+	Type portainer.EdgeTransferType `example:"image_pull" enums:"image_pull,logs_upload,diagnostics_upload"`
+	// Reference identifies the transferred data
+	Reference string `example:"nginx:latest"`
+	// Amount of data transferred so far
+	BytesTransferred int64
+	// Total amount of data to transfer, 0 when unknown
+	TotalBytes int64
+	// Limit applied by the agent to the transfer, in bytes per second
+	BandwidthLimit int64
+	// Average transfer rate, in bytes per second
+	AverageRate int64
+	// Whether the transfer was slowed down by the bandwidth limit
+	Throttled bool
+	Completed bool
+	Error     string
+	// Unix timestamp of the beginning of the transfer
+	StartedAt int64
+}
+
+func (payload *transferStatusPayload) Validate(r *http.Request) error {
+	switch payload.Type {
+	case portainer.EdgeTransferImagePull, portainer.EdgeTransferLogsUpload, portainer.EdgeTransferDiagnosticsUpload:
+	default:
+		return errors.New("invalid transfer type")
+	}
+
+	if payload.Reference == "" {
+		return errors.New("invalid transfer reference")
+	}
+
+	if payload.BytesTransferred < 0 || payload.TotalBytes < 0 || payload.BandwidthLimit < 0 || payload.AverageRate < 0 {
+		return errors.New("invalid transfer size")
+	}
+
+	return nil
+}
+
+// @summary Report the status of a data transfer of an Edge environment(endpoint)
+// @description **Access policy**: public
+// @tags edge, endpoints
+// @accept json
+// @param id path int true "environment(endpoint) Id"
+// @param body body transferStatusPayload true "Transfer status"
+// @success 204
+// @failure 400
+// @failure 403
+// @failure 500
+// @router /endpoints/{id}/edge/transfers [post]
+func (handler *Handler) endpointEdgeTransferUpdate(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	endpoint, err := middlewares.FetchEndpoint(r)
+	if err != nil {
+		return httperror.BadRequest("Unable to find an environment on request context", err)
+	}
+
+	if err := handler.requestBouncer.AuthorizedEdgeEndpointOperation(r, endpoint); err != nil {
+		return httperror.Forbidden("Permission denied to access environment", fmt.Errorf("unauthorized edge endpoint operation: %w. Environment name: %s", err, endpoint.Name))
+	}
+
+	var payload transferStatusPayload
+	if err := request.DecodeAndValidateJSONPayload(r, &payload); err != nil {
+		return httperror.BadRequest("Invalid request payload", fmt.Errorf("invalid transfer status payload: %w. Environment name: %s", err, endpoint.Name))
+	}
+
+	status := portainer.EdgeTransferStatus{
+		Type:             payload.Type,
+		Reference:        payload.Reference,
+		BytesTransferred: payload.BytesTransferred,
+		TotalBytes:       payload.TotalBytes,
+		BandwidthLimit:   payload.BandwidthLimit,
+		AverageRate:      payload.AverageRate,
+		Throttled:        payload.Throttled,
+		Completed:        payload.Completed,
+		Error:            payload.Error,
+		StartedAt:        payload.StartedAt,
+		UpdatedAt:        time.Now().Unix(),
+	}
+
+	if err := handler.DataStore.UpdateTx(func(tx dataservices.DataStoreTx) error {
+		environment, err := tx.Endpoint().Endpoint(endpoint.ID)
+		if err != nil {
+			return err
+		}
+
+		environment.EdgeTransfers = upsertTransferStatus(environment.EdgeTransfers, status)
+
+		return tx.Endpoint().UpdateEndpoint(environment.ID, environment)
+	}); err != nil {
+		return httperror.InternalServerError("Unable to persist the transfer status", fmt.Errorf("unable to update the environment: %w. Environment name: %s", err, endpoint.Name))
+	}
+
+	return response.Empty(w)
+}
+
+// upsertTransferStatus replaces the status of an ongoing transfer or appends it, keeping only the latest transfers
+func upsertTransferStatus(transfers []portainer.EdgeTransferStatus, status portainer.EdgeTransferStatus) []portainer.EdgeTransferStatus {
+	for i := range transfers {
+		if transfers[i].Type == status.Type && transfers[i].Reference == status.Reference && !transfers[i].Completed {
+			status.StartedAt = cmp.Or(status.StartedAt, transfers[i].StartedAt)
+			transfers = append(transfers[:i], transfers[i+1:]...)
+
+			break
+		}
+	}
+
+	transfers = append(transfers, status)
+
+	if len(transfers) > portainer.MaxEdgeTransfersPerEndpoint {
+		transfers = transfers[len(transfers)-portainer.MaxEdgeTransfersPerEndpoint:]
+	}
+
+	return transfers
+}
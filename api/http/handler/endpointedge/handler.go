@@ -36,11 +36,20 @@ func NewHandler(bouncer security.BouncerService, dataStore dataservices.DataStor
 	endpointRouter := h.PathPrefix("/api/endpoints/{id}").Subrouter()
 	endpointRouter.Use(middlewares.WithEndpoint(dataStore.Endpoint(), "id"))
 
+	endpointRouter.Handle("/edge/stacks/{stackId}/chunks",
+		bouncer.PublicAccess(httperror.LoggerHandler(h.endpointEdgeStackChunks))).Methods(http.MethodPost)
+
 	endpointRouter.PathPrefix("/edge/stacks/{stackId}").Handler(
 		bouncer.PublicAccess(httperror.LoggerHandler(h.endpointEdgeStackInspect))).Methods(http.MethodGet)
 
+	endpointRouter.PathPrefix("/edge/transfers").Handler(
+		bouncer.PublicAccess(httperror.LoggerHandler(h.endpointEdgeTransferUpdate))).Methods(http.MethodPost)
+
 	endpointRouter.PathPrefix("/edge/jobs/{jobID}/logs").Handler(
 		bouncer.PublicAccess(httperror.LoggerHandler(h.endpointEdgeJobsLogs))).Methods(http.MethodPost)
 
+	endpointRouter.PathPrefix("/edge/jobs/{jobID}/executions").Handler(
+		bouncer.PublicAccess(httperror.LoggerHandler(h.endpointEdgeJobExecutionCreate))).Methods(http.MethodPost)
+
 	return h
 }
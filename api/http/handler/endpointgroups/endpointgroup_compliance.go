@@ -0,0 +1,124 @@
+package endpointgroups
+
+import (
+	"errors"
+	"net/http"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+	"github.com/portainer/portainer/api/internal/edge/compliance"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/request"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+)
+
+type endpointGroupComplianceSummary struct {
+	EndpointGroupID portainer.EndpointGroupID `json:"EndpointGroupId" example:"1"`
+	Name            string                    `json:"Name"`
+	Summary         compliance.Summary        `json:"Summary"`
+}
+
+// @summary List the compliance of every Environment(Endpoint) group
+// @description Counts, for every environment(endpoint) group, the Edge environments running the current version of
+// @description their Edge stacks against the drifted, offline and failed ones.
+// @description **Access policy**: administrator
+// @tags endpoint_groups
+// @security ApiKeyAuth
+// @security jwt
+// @produce json
+// @success 200 {array} endpointGroupComplianceSummary "Success"
+// @failure 500 "Server error"
+// @router /endpoint_groups/compliance [get]
+func (handler *Handler) endpointGroupComplianceList(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	var summaries []endpointGroupComplianceSummary
+
+	if err := handler.DataStore.ViewTx(func(tx dataservices.DataStoreTx) error {
+		evaluator, err := compliance.NewEvaluator(tx)
+		if err != nil {
+			return httperror.InternalServerError("Unable to compute the compliance of the environment groups", err)
+		}
+
+		endpointGroups, err := tx.EndpointGroup().ReadAll()
+		if err != nil {
+			return httperror.InternalServerError("Unable to retrieve environment groups from the database", err)
+		}
+
+		summaries = make([]endpointGroupComplianceSummary, 0, len(endpointGroups))
+		for _, endpointGroup := range endpointGroups {
+			summaries = append(summaries, endpointGroupComplianceSummary{
+				EndpointGroupID: endpointGroup.ID,
+				Name:            endpointGroup.Name,
+				Summary:         evaluator.EndpointGroupReport(endpointGroup.ID).Summary,
+			})
+		}
+
+		return nil
+	}); err != nil {
+		var httpErr *httperror.HandlerError
+		if errors.As(err, &httpErr) {
+			return httpErr
+		}
+
+		return httperror.InternalServerError("Unexpected error", err)
+	}
+
+	return response.JSON(w, summaries)
+}
+
+// @summary Inspect the compliance of an Environment(Endpoint) group
+// @description Lists the Edge environments of the group along with the state of each of their Edge stacks.
+// @description **Access policy**: administrator
+// @tags endpoint_groups
+// @security ApiKeyAuth
+// @security jwt
+// @produce json
+// @param id path int true "Environment(Endpoint) group identifier"
+// @param state query string false "Only list the environments in this state" Enums(InSync, Drifted, Offline, Error)
+// @success 200 {object} compliance.Report "Success"
+// @failure 400 "Invalid request"
+// @failure 404 "EndpointGroup not found"
+// @failure 500 "Server error"
+// @router /endpoint_groups/{id}/compliance [get]
+func (handler *Handler) endpointGroupCompliance(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	endpointGroupID, err := request.RetrieveNumericRouteVariableValue(r, "id")
+	if err != nil {
+		return httperror.BadRequest("Invalid environment group identifier route variable", err)
+	}
+
+	state, _ := request.RetrieveQueryParameter(r, "state", true)
+	if state != "" && !compliance.State(state).IsValid() {
+		return httperror.BadRequest("Invalid state query parameter", errors.New("state must be one of: InSync, Drifted, Offline or Error"))
+	}
+
+	var report compliance.Report
+	if err := handler.DataStore.ViewTx(func(tx dataservices.DataStoreTx) error {
+		_, err := tx.EndpointGroup().Read(portainer.EndpointGroupID(endpointGroupID))
+		if tx.IsErrObjectNotFound(err) {
+			return httperror.NotFound("Unable to find an environment group with the specified identifier inside the database", err)
+		} else if err != nil {
+			return httperror.InternalServerError("Unable to find an environment group with the specified identifier inside the database", err)
+		}
+
+		evaluator, err := compliance.NewEvaluator(tx)
+		if err != nil {
+			return httperror.InternalServerError("Unable to compute the compliance of the environment group", err)
+		}
+
+		report = evaluator.EndpointGroupReport(portainer.EndpointGroupID(endpointGroupID))
+
+		return nil
+	}); err != nil {
+		var httpErr *httperror.HandlerError
+		if errors.As(err, &httpErr) {
+			return httpErr
+		}
+
+		return httperror.InternalServerError("Unexpected error", err)
+	}
+
+	if state != "" {
+		report = report.Filter(compliance.State(state))
+	}
+
+	return response.JSON(w, report)
+}
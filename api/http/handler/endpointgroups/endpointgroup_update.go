@@ -9,6 +9,7 @@ import (
 	"github.com/portainer/portainer/api/dataservices"
 	"github.com/portainer/portainer/api/internal/endpointutils"
 	"github.com/portainer/portainer/api/pendingactions/handlers"
+	"github.com/portainer/portainer/api/stacks/stackutils"
 	"github.com/portainer/portainer/api/tag"
 	httperror "github.com/portainer/portainer/pkg/libhttp/error"
 	"github.com/portainer/portainer/pkg/libhttp/request"
@@ -26,10 +27,13 @@ type endpointGroupUpdatePayload struct {
 	TagIDs             []portainer.TagID `example:"3,4"`
 	UserAccessPolicies portainer.UserAccessPolicies
 	TeamAccessPolicies portainer.TeamAccessPolicies
+	// Variables merged into the env of the stacks deployed to the environments of the group. Replaces all the
+	// variables, unchanged when omitted
+	Variables []portainer.Pair
 }
 
 func (payload *endpointGroupUpdatePayload) Validate(r *http.Request) error {
-	return nil
+	return stackutils.ValidateVariables(payload.Variables)
 }
 
 // @id EndpointGroupUpdate
@@ -93,6 +97,10 @@ func (handler *Handler) updateEndpointGroup(tx dataservices.DataStoreTx, endpoin
 		endpointGroup.Description = payload.Description
 	}
 
+	if payload.Variables != nil {
+		endpointGroup.Variables = payload.Variables
+	}
+
 	tagsChanged := false
 	if payload.TagIDs != nil {
 		payloadTagSet := tag.Set(payload.TagIDs)
@@ -29,8 +29,12 @@ func NewHandler(bouncer security.BouncerService) *Handler {
 		bouncer.AdminAccess(httperror.LoggerHandler(h.endpointGroupCreate))).Methods(http.MethodPost)
 	h.Handle("/endpoint_groups",
 		bouncer.RestrictedAccess(httperror.LoggerHandler(h.endpointGroupList))).Methods(http.MethodGet)
+	h.Handle("/endpoint_groups/compliance",
+		bouncer.AdminAccess(httperror.LoggerHandler(h.endpointGroupComplianceList))).Methods(http.MethodGet)
 	h.Handle("/endpoint_groups/{id}",
 		bouncer.AdminAccess(httperror.LoggerHandler(h.endpointGroupInspect))).Methods(http.MethodGet)
+	h.Handle("/endpoint_groups/{id}/compliance",
+		bouncer.AdminAccess(httperror.LoggerHandler(h.endpointGroupCompliance))).Methods(http.MethodGet)
 	h.Handle("/endpoint_groups/{id}",
 		bouncer.AdminAccess(httperror.LoggerHandler(h.endpointGroupUpdate))).Methods(http.MethodPut)
 	h.Handle("/endpoint_groups/{id}",
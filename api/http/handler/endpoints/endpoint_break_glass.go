@@ -0,0 +1,141 @@
+package endpoints
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/http/security"
+	"github.com/portainer/portainer/api/internal/immutable"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/request"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+
+	"github.com/rs/zerolog/log"
+)
+
+type endpointBreakGlassPayload struct {
+	// Reason of the lift of the immutable mode, for the change management
+	Reason string `validate:"required" example:"Hotfix of INC-1234"`
+	// Time the immutable mode is lifted for, 1h when empty, 24h at most
+	Duration string `example:"1h"`
+}
+
+func (payload *endpointBreakGlassPayload) Validate(r *http.Request) error {
+	if payload.Reason == "" {
+		return errors.New("A reason is required to activate break-glass access")
+	}
+
+	if payload.Duration == "" {
+		return nil
+	}
+
+	duration, err := time.ParseDuration(payload.Duration)
+	if err != nil || duration <= 0 || duration > immutable.MaxBreakGlassDuration {
+		return errors.New("Invalid duration. Value must be a positive duration of 24h at most, e.g. 30m or 2h")
+	}
+
+	return nil
+}
+
+// @id EndpointBreakGlass
+// @summary Activate break-glass access to an immutable environment
+// @description Temporarily lift the immutable mode of an environment, the changes outside of the stacks are allowed
+// @description until the access expires or is revoked. The activation and the calls made meanwhile are recorded in
+// @description the audit logs.
+// @description **Access policy**: administrator
+// @tags endpoints
+// @security ApiKeyAuth
+// @security jwt
+// @accept json
+// @produce json
+// @param id path int true "Environment(Endpoint) identifier"
+// @param body body endpointBreakGlassPayload true "Break-glass details"
+// @success 200 {object} portainer.EndpointBreakGlass "Success"
+// @failure 400 "Invalid request or the environment is not immutable"
+// @failure 404 "Environment(Endpoint) not found"
+// @failure 500 "Server error"
+// @router /endpoints/{id}/breakglass [post]
+func (handler *Handler) endpointBreakGlass(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	endpoint, httpErr := handler.routeEndpoint(r)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	var payload endpointBreakGlassPayload
+	if err := request.DecodeAndValidateJSONPayload(r, &payload); err != nil {
+		return httperror.BadRequest("Invalid request payload", err)
+	}
+
+	if !endpoint.Immutable {
+		return httperror.BadRequest("The environment is not in immutable mode", errors.New("break-glass access only applies to the immutable environments"))
+	}
+
+	tokenData, err := security.RetrieveTokenData(r)
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve user authentication token", err)
+	}
+
+	duration := time.Hour
+	if payload.Duration != "" {
+		duration, _ = time.ParseDuration(payload.Duration)
+	}
+
+	now := time.Now()
+	endpoint.BreakGlass = &portainer.EndpointBreakGlass{
+		Reason:      payload.Reason,
+		ActivatedBy: tokenData.Username,
+		ActivatedAt: now.Unix(),
+		ExpiresAt:   now.Add(duration).Unix(),
+	}
+
+	if err := handler.DataStore.Endpoint().UpdateEndpoint(endpoint.ID, endpoint); err != nil {
+		return httperror.InternalServerError("Unable to persist environment changes inside the database", err)
+	}
+
+	handler.ProxyManager.DeleteEndpointProxy(endpoint.ID)
+
+	log.Warn().
+		Int("endpoint_id", int(endpoint.ID)).
+		Str("username", tokenData.Username).
+		Str("reason", payload.Reason).
+		Time("expires_at", now.Add(duration)).
+		Msg("break-glass access activated on an immutable environment")
+
+	return response.JSON(w, endpoint.BreakGlass)
+}
+
+// @id EndpointBreakGlassRevoke
+// @summary Revoke the break-glass access to an immutable environment
+// @description Restore the immutable mode of an environment before its break-glass access expires.
+// @description **Access policy**: administrator
+// @tags endpoints
+// @security ApiKeyAuth
+// @security jwt
+// @param id path int true "Environment(Endpoint) identifier"
+// @success 204 "Success"
+// @failure 400 "Invalid request"
+// @failure 404 "Environment(Endpoint) not found"
+// @failure 500 "Server error"
+// @router /endpoints/{id}/breakglass [delete]
+func (handler *Handler) endpointBreakGlassRevoke(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	endpoint, httpErr := handler.routeEndpoint(r)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	if endpoint.BreakGlass == nil {
+		return response.Empty(w)
+	}
+
+	endpoint.BreakGlass = nil
+
+	if err := handler.DataStore.Endpoint().UpdateEndpoint(endpoint.ID, endpoint); err != nil {
+		return httperror.InternalServerError("Unable to persist environment changes inside the database", err)
+	}
+
+	handler.ProxyManager.DeleteEndpointProxy(endpoint.ID)
+
+	return response.Empty(w)
+}
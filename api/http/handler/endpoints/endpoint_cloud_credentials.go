@@ -0,0 +1,159 @@
+package endpoints
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/cloud"
+	"github.com/portainer/portainer/api/dataservices"
+	"github.com/portainer/portainer/api/http/security"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/request"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+)
+
+type cloudCredentialCreatePayload struct {
+	// Name of the credential
+	Name string `validate:"required" example:"production"`
+	// Provider of the account, civo, linode or digitalocean
+	Provider string `validate:"required" example:"civo"`
+	// API key of the account, it is never returned
+	APIKey string `validate:"required" example:"k3y"`
+}
+
+func (payload *cloudCredentialCreatePayload) Validate(r *http.Request) error {
+	if payload.Name == "" {
+		return errors.New("Invalid credential name")
+	}
+
+	if !cloud.IsProvider(payload.Provider) {
+		return errors.New("Invalid provider. Value must be one of: civo, linode or digitalocean")
+	}
+
+	if payload.APIKey == "" {
+		return errors.New("Invalid API key")
+	}
+
+	return nil
+}
+
+// @id EndpointCloudCredentialCreate
+// @summary Create a cloud provider credential
+// @description Store the API key of an account of a Kubernetes-as-a-Service provider, the clusters are provisioned with.
+// @description The API key is encrypted in the database and never returned.
+// @description **Access policy**: administrator
+// @tags endpoints
+// @security ApiKeyAuth
+// @security jwt
+// @accept json
+// @produce json
+// @param body body cloudCredentialCreatePayload true "Credential details"
+// @success 200 {object} portainer.CloudCredential "Success"
+// @failure 400 "Invalid request"
+// @failure 500 "Server error"
+// @router /endpoints/cloud_credentials [post]
+func (handler *Handler) cloudCredentialCreate(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	var payload cloudCredentialCreatePayload
+	if err := request.DecodeAndValidateJSONPayload(r, &payload); err != nil {
+		return httperror.BadRequest("Invalid request payload", err)
+	}
+
+	tokenData, err := security.RetrieveTokenData(r)
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve user details from authentication token", err)
+	}
+
+	credential := &portainer.CloudCredential{
+		Name:      payload.Name,
+		Provider:  payload.Provider,
+		CreatedAt: time.Now().Unix(),
+		CreatedBy: tokenData.ID,
+	}
+
+	if err := handler.DataStore.UpdateTx(func(tx dataservices.DataStoreTx) error {
+		if credential.EncryptedAPIKey, err = cloud.EncryptAPIKey(tx, payload.APIKey); err != nil {
+			return err
+		}
+
+		return tx.CloudCredential().Create(credential)
+	}); err != nil {
+		return httperror.InternalServerError("Unable to persist the cloud credential inside the database", err)
+	}
+
+	credential.EncryptedAPIKey = nil
+
+	return response.JSON(w, credential)
+}
+
+// @id EndpointCloudCredentialList
+// @summary List the cloud provider credentials
+// @description List the credentials of the Kubernetes-as-a-Service providers, without their API keys.
+// @description **Access policy**: administrator
+// @tags endpoints
+// @security ApiKeyAuth
+// @security jwt
+// @produce json
+// @success 200 {array} portainer.CloudCredential "Success"
+// @failure 500 "Server error"
+// @router /endpoints/cloud_credentials [get]
+func (handler *Handler) cloudCredentialList(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	credentials, err := handler.DataStore.CloudCredential().ReadAll()
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve the cloud credentials from the database", err)
+	}
+
+	for i := range credentials {
+		credentials[i].EncryptedAPIKey = nil
+	}
+
+	return response.JSON(w, credentials)
+}
+
+// @id EndpointCloudCredentialDelete
+// @summary Remove a cloud provider credential
+// @description Remove a credential of a Kubernetes-as-a-Service provider. The clusters already provisioned with it are
+// @description kept, the credential cannot be removed while a cluster is being provisioned with it.
+// @description **Access policy**: administrator
+// @tags endpoints
+// @security ApiKeyAuth
+// @security jwt
+// @param id path int true "Cloud credential identifier"
+// @success 204 "Success"
+// @failure 400 "Invalid request"
+// @failure 404 "Cloud credential not found"
+// @failure 409 "A cluster is being provisioned with the credential"
+// @failure 500 "Server error"
+// @router /endpoints/cloud_credentials/{id} [delete]
+func (handler *Handler) cloudCredentialDelete(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	credentialID, err := request.RetrieveNumericRouteVariableValue(r, "id")
+	if err != nil {
+		return httperror.BadRequest("Invalid cloud credential identifier route variable", err)
+	}
+
+	if _, err := handler.DataStore.CloudCredential().Read(portainer.CloudCredentialID(credentialID)); handler.DataStore.IsErrObjectNotFound(err) {
+		return httperror.NotFound("Unable to find a cloud credential with the specified identifier inside the database", err)
+	} else if err != nil {
+		return httperror.InternalServerError("Unable to find a cloud credential with the specified identifier inside the database", err)
+	}
+
+	endpoints, err := handler.DataStore.Endpoint().Endpoints()
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve the environments from the database", err)
+	}
+
+	for _, endpoint := range endpoints {
+		provisioning := endpoint.CloudProvisioning
+		if provisioning != nil && provisioning.CredentialID == portainer.CloudCredentialID(credentialID) &&
+			(provisioning.State == cloud.StateProvisioning || provisioning.State == cloud.StateDeploying) {
+			return httperror.Conflict("A cluster is being provisioned with the cloud credential", errors.New("credential in use"))
+		}
+	}
+
+	if err := handler.DataStore.CloudCredential().Delete(portainer.CloudCredentialID(credentialID)); err != nil {
+		return httperror.InternalServerError("Unable to remove the cloud credential from the database", err)
+	}
+
+	return response.Empty(w)
+}
@@ -41,6 +41,9 @@ type endpointCreatePayload struct {
 	TagIDs                 []portainer.TagID
 	EdgeCheckinInterval    int
 	ContainerEngine        string
+	NomadToken             string
+	NomadRegion            string
+	NomadNamespace         string
 }
 
 type endpointCreationEnum int
@@ -52,6 +55,7 @@ const (
 	azureEnvironment
 	edgeAgentEnvironment
 	localKubernetesEnvironment
+	nomadEnvironment
 )
 
 func (payload *endpointCreatePayload) Validate(r *http.Request) error {
@@ -63,7 +67,7 @@ func (payload *endpointCreatePayload) Validate(r *http.Request) error {
 
 	endpointCreationType, err := request.RetrieveNumericMultiPartFormValue(r, "EndpointCreationType", false)
 	if err != nil || endpointCreationType == 0 {
-		return errors.New("invalid environment type value. Value must be one of: 1 (Docker environment), 2 (Agent environment), 3 (Azure environment), 4 (Edge Agent environment) or 5 (Local Kubernetes environment)")
+		return errors.New("invalid environment type value. Value must be one of: 1 (Docker environment), 2 (Agent environment), 3 (Azure environment), 4 (Edge Agent environment), 5 (Local Kubernetes environment) or 6 (Nomad environment)")
 	}
 	payload.EndpointCreationType = endpointCreationEnum(endpointCreationType)
 
@@ -142,6 +146,21 @@ func (payload *endpointCreatePayload) Validate(r *http.Request) error {
 		}
 		payload.AzureAuthenticationKey = azureAuthenticationKey
 
+	case nomadEnvironment:
+		endpointURL, err := request.RetrieveMultiPartFormValue(r, "URL", false)
+		if err != nil || strings.TrimSpace(endpointURL) == "" {
+			return errors.New("URL cannot be empty")
+		}
+
+		payload.URL = endpointURL
+
+		publicURL, _ := request.RetrieveMultiPartFormValue(r, "PublicURL", true)
+		payload.PublicURL = publicURL
+
+		payload.NomadToken, _ = request.RetrieveMultiPartFormValue(r, "NomadToken", true)
+		payload.NomadRegion, _ = request.RetrieveMultiPartFormValue(r, "NomadRegion", true)
+		payload.NomadNamespace, _ = request.RetrieveMultiPartFormValue(r, "NomadNamespace", true)
+
 	case edgeAgentEnvironment:
 		endpointURL, err := request.RetrieveMultiPartFormValue(r, "URL", false)
 		if err != nil || strings.EqualFold("", strings.Trim(endpointURL, " ")) {
@@ -191,7 +210,7 @@ func (payload *endpointCreatePayload) Validate(r *http.Request) error {
 // @accept multipart/form-data
 // @produce json
 // @param Name formData string true "Name that will be used to identify this environment(endpoint) (example: my-environment)"
-// @param EndpointCreationType formData integer true "Environment(Endpoint) type. Value must be one of: 1 (Local Docker environment), 2 (Agent environment), 3 (Azure environment), 4 (Edge agent environment) or 5 (Local Kubernetes Environment)" Enum(1,2,3,4,5)
+// @param EndpointCreationType formData integer true "Environment(Endpoint) type. Value must be one of: 1 (Local Docker environment), 2 (Agent environment), 3 (Azure environment), 4 (Edge agent environment), 5 (Local Kubernetes Environment) or 6 (Nomad environment)" Enum(1,2,3,4,5,6)
 // @param ContainerEngine formData string false "Container engine used by the environment(endpoint). Value must be one of: 'docker' or 'podman'"
 // @param URL formData string false "URL or IP address of a Docker host (example: docker.mydomain.tld:2375). Defaults to local if not specified (Linux: /var/run/docker.sock, Windows: //./pipe/docker_engine). Cannot be empty if EndpointCreationType is set to 4 (Edge agent environment)"
 // @param PublicURL formData string false "URL or IP address where exposed containers will be reachable. Defaults to URL if not specified (example: docker.mydomain.tld:2375)"
@@ -208,6 +227,9 @@ func (payload *endpointCreatePayload) Validate(r *http.Request) error {
 // @param TagIds formData []int false "List of tag identifiers to which this environment(endpoint) is associated"
 // @param EdgeCheckinInterval formData int false "The check in interval for edge agent (in seconds)"
 // @param EdgeTunnelServerAddress formData string true "URL or IP address that will be used to establish a reverse tunnel"
+// @param NomadToken formData string false "ACL token of the Nomad cluster. Only used if environment(endpoint) type is set to 6"
+// @param NomadRegion formData string false "Region of the Nomad cluster, the region of the agent is used when empty. Only used if environment(endpoint) type is set to 6"
+// @param NomadNamespace formData string false "Namespace of the Nomad jobs, the default namespace is used when empty. Only used if environment(endpoint) type is set to 6"
 // @param Gpus formData string false "List of GPUs - json stringified array of {name, value} structs"
 // @success 200 {object} portainer.Endpoint "Success"
 // @failure 400 "Invalid request"
@@ -234,6 +256,16 @@ func (handler *Handler) endpointCreate(w http.ResponseWriter, r *http.Request) *
 		return endpointCreationError
 	}
 
+	if err := handler.createEndpointRelation(endpoint); err != nil {
+		return err
+	}
+
+	return response.JSON(w, endpoint)
+}
+
+// createEndpointRelation creates the relation of a new environment(endpoint) to the edge stacks, and detects the
+// features of the Kubernetes environments
+func (handler *Handler) createEndpointRelation(endpoint *portainer.Endpoint) *httperror.HandlerError {
 	endpointGroup, err := handler.DataStore.EndpointGroup().Read(endpoint.GroupID)
 	if err != nil {
 		return httperror.InternalServerError("Unable to find an environment group inside the database", err)
@@ -281,7 +313,7 @@ func (handler *Handler) endpointCreate(w http.ResponseWriter, r *http.Request) *
 		return httperror.InternalServerError("Unable to persist the relation object inside the database", err)
 	}
 
-	return response.JSON(w, endpoint)
+	return nil
 }
 
 func (handler *Handler) createEndpoint(tx dataservices.DataStoreTx, payload *endpointCreatePayload) (*portainer.Endpoint, *httperror.HandlerError) {
@@ -296,6 +328,9 @@ func (handler *Handler) createEndpoint(tx dataservices.DataStoreTx, payload *end
 
 	case localKubernetesEnvironment:
 		return handler.createKubernetesEndpoint(tx, payload)
+
+	case nomadEnvironment:
+		return handler.createNomadEndpoint(tx, payload)
 	}
 
 	endpointType := portainer.DockerEnvironment
@@ -492,6 +527,48 @@ func (handler *Handler) createKubernetesEndpoint(tx dataservices.DataStoreTx, pa
 	return endpoint, nil
 }
 
+func (handler *Handler) createNomadEndpoint(tx dataservices.DataStoreTx, payload *endpointCreatePayload) (*portainer.Endpoint, *httperror.HandlerError) {
+	endpointID := tx.Endpoint().GetNextIdentifier()
+
+	endpoint := &portainer.Endpoint{
+		ID:        portainer.EndpointID(endpointID),
+		Name:      payload.Name,
+		URL:       payload.URL,
+		Type:      portainer.NomadEnvironment,
+		GroupID:   portainer.EndpointGroupID(payload.GroupID),
+		PublicURL: payload.PublicURL,
+		Gpus:      payload.Gpus,
+		TLSConfig: portainer.TLSConfiguration{
+			TLS:           payload.TLS,
+			TLSSkipVerify: payload.TLSSkipVerify,
+		},
+		UserAccessPolicies: portainer.UserAccessPolicies{},
+		TeamAccessPolicies: portainer.TeamAccessPolicies{},
+		TagIDs:             payload.TagIDs,
+		Status:             portainer.EndpointStatusUp,
+		Snapshots:          []portainer.DockerSnapshot{},
+		Kubernetes:         portainer.KubernetesDefault(),
+		Nomad: &portainer.NomadData{
+			Token:     payload.NomadToken,
+			Region:    payload.NomadRegion,
+			Namespace: payload.NomadNamespace,
+			Snapshots: []portainer.NomadSnapshot{},
+		},
+	}
+
+	if payload.TLS {
+		if err := handler.storeTLSFiles(endpoint, payload); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := handler.snapshotAndPersistEndpoint(tx, endpoint); err != nil {
+		return nil, err
+	}
+
+	return endpoint, nil
+}
+
 func (handler *Handler) createTLSSecuredEndpoint(tx dataservices.DataStoreTx, payload *endpointCreatePayload, endpointType portainer.EndpointType, agentVersion string) (*portainer.Endpoint, *httperror.HandlerError) {
 	endpointID := tx.Endpoint().GetNextIdentifier()
 	endpoint := &portainer.Endpoint{
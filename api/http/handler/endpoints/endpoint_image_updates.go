@@ -0,0 +1,133 @@
+package endpoints
+
+import (
+	"errors"
+	"net/http"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/docker/imageupdates"
+	"github.com/portainer/portainer/api/internal/endpointutils"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/request"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+)
+
+// @id EndpointImageUpdatesList
+// @summary List the image updates of the environments
+// @description List the running containers, Swarm services and stacks of every Docker environment with the status of
+// @description their images at the time of the last check, which runs every hour.
+// @description **Access policy**: administrator
+// @tags endpoints
+// @security ApiKeyAuth
+// @security jwt
+// @produce json
+// @param outdated query boolean false "Only list the containers, services and stacks having an update available"
+// @success 200 {array} imageupdates.Report "Success"
+// @failure 500 "Server error"
+// @router /endpoints/image_updates [get]
+func (handler *Handler) endpointImageUpdatesList(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	outdated, _ := request.RetrieveBooleanQueryParameter(r, "outdated", true)
+
+	reports, err := handler.ImageUpdateService.Reports()
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve the image updates of the environments", err)
+	}
+
+	if outdated {
+		for i := range reports {
+			reports[i] = *reports[i].Outdated()
+		}
+	}
+
+	return response.JSON(w, reports)
+}
+
+// @id EndpointImageUpdatesInspect
+// @summary Inspect the image updates of an environment
+// @description List the running containers, Swarm services and stacks of a Docker environment with the status of their
+// @description images at the time of the last check. A workload has an update available when the registry has a newer
+// @description image for the tag of one of its containers.
+// @description **Access policy**: administrator
+// @tags endpoints
+// @security ApiKeyAuth
+// @security jwt
+// @produce json
+// @param id path int true "Environment(Endpoint) identifier"
+// @param outdated query boolean false "Only list the containers, services and stacks having an update available"
+// @success 200 {object} imageupdates.Report "Success"
+// @failure 400 "Invalid request"
+// @failure 404 "Environment(Endpoint) not found or not checked yet"
+// @failure 500 "Server error"
+// @router /endpoints/{id}/image_updates [get]
+func (handler *Handler) endpointImageUpdatesInspect(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	endpoint, httpErr := handler.imageUpdatesEndpoint(r)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	outdated, _ := request.RetrieveBooleanQueryParameter(r, "outdated", true)
+
+	report, err := handler.ImageUpdateService.Report(endpoint.ID)
+	if errors.Is(err, imageupdates.ErrNotChecked) {
+		return httperror.NotFound("The images of the environment have not been checked yet", err)
+	} else if err != nil {
+		return httperror.InternalServerError("Unable to retrieve the image updates of the environment", err)
+	}
+
+	if outdated {
+		report = report.Outdated()
+	}
+
+	return response.JSON(w, report)
+}
+
+// @id EndpointImageUpdatesCheck
+// @summary Check the image updates of an environment
+// @description Compare the images of the running containers of a Docker environment with the current digests of their
+// @description tags in the registries, and return the report of the check.
+// @description **Access policy**: administrator
+// @tags endpoints
+// @security ApiKeyAuth
+// @security jwt
+// @produce json
+// @param id path int true "Environment(Endpoint) identifier"
+// @success 200 {object} imageupdates.Report "Success"
+// @failure 400 "Invalid request"
+// @failure 404 "Environment(Endpoint) not found"
+// @failure 500 "Server error"
+// @router /endpoints/{id}/image_updates/check [post]
+func (handler *Handler) endpointImageUpdatesCheck(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	endpoint, httpErr := handler.imageUpdatesEndpoint(r)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	report, err := handler.ImageUpdateService.Check(endpoint)
+	if errors.Is(err, imageupdates.ErrNoConnectivity) {
+		return httperror.BadRequest("The images of an asynchronous Edge environment cannot be checked", err)
+	} else if err != nil {
+		return httperror.InternalServerError("Unable to check the image updates of the environment", err)
+	}
+
+	return response.JSON(w, report)
+}
+
+func (handler *Handler) imageUpdatesEndpoint(r *http.Request) (*portainer.Endpoint, *httperror.HandlerError) {
+	endpointID, err := request.RetrieveNumericRouteVariableValue(r, "id")
+	if err != nil {
+		return nil, httperror.BadRequest("Invalid environment identifier route variable", err)
+	}
+
+	endpoint, err := handler.DataStore.Endpoint().Endpoint(portainer.EndpointID(endpointID))
+	if handler.DataStore.IsErrObjectNotFound(err) {
+		return nil, httperror.NotFound("Unable to find an environment with the specified identifier inside the database", err)
+	} else if err != nil {
+		return nil, httperror.InternalServerError("Unable to find an environment with the specified identifier inside the database", err)
+	}
+
+	if !endpointutils.IsDockerEndpoint(endpoint) {
+		return nil, httperror.BadRequest("The image updates are only checked for the Docker environments", errors.New("not a Docker environment"))
+	}
+
+	return endpoint, nil
+}
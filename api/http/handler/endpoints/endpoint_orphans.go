@@ -0,0 +1,159 @@
+package endpoints
+
+import (
+	"errors"
+	"net/http"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/docker/orphans"
+	"github.com/portainer/portainer/api/internal/endpointutils"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/request"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+)
+
+type endpointOrphansCleanupPayload struct {
+	orphans.Selection
+	// Remove all the orphaned volumes of the report, the data of the volumes is lost
+	AllVolumes bool `example:"false"`
+	// Remove all the orphaned images of the report
+	AllImages bool `example:"true"`
+	// Remove all the orphaned networks of the report
+	AllNetworks bool `example:"true"`
+}
+
+func (payload *endpointOrphansCleanupPayload) Validate(r *http.Request) error {
+	if len(payload.Volumes) == 0 && len(payload.Images) == 0 && len(payload.Networks) == 0 &&
+		!payload.AllVolumes && !payload.AllImages && !payload.AllNetworks {
+		return errors.New("No orphaned resource selected")
+	}
+
+	return nil
+}
+
+// @id EndpointOrphansList
+// @summary List the orphaned resources of the environments
+// @description List the volumes not mounted by any container, the images not used by any container and the networks
+// @description without any attachment of every Docker environment, from their last snapshot.
+// @description **Access policy**: administrator
+// @tags endpoints
+// @security ApiKeyAuth
+// @security jwt
+// @produce json
+// @success 200 {array} orphans.Report "Success"
+// @failure 500 "Server error"
+// @router /endpoints/orphans [get]
+func (handler *Handler) endpointOrphansList(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	reports, err := handler.OrphanService.Reports()
+	if err != nil {
+		return httperror.InternalServerError("Unable to compute the orphaned resources of the environments", err)
+	}
+
+	return response.JSON(w, reports)
+}
+
+// @id EndpointOrphansInspect
+// @summary Inspect the orphaned resources of an environment
+// @description List the volumes not mounted by any container, the images not used by any container and the networks
+// @description without any attachment of a Docker environment, from its last snapshot.
+// @description **Access policy**: administrator
+// @tags endpoints
+// @security ApiKeyAuth
+// @security jwt
+// @produce json
+// @param id path int true "Environment(Endpoint) identifier"
+// @success 200 {object} orphans.Report "Success"
+// @failure 400 "Invalid request"
+// @failure 404 "Environment(Endpoint) not found or without snapshot"
+// @failure 500 "Server error"
+// @router /endpoints/{id}/orphans [get]
+func (handler *Handler) endpointOrphansInspect(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	endpoint, httpErr := handler.orphansEndpoint(r)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	report, err := handler.OrphanService.Report(endpoint)
+	if errors.Is(err, orphans.ErrNoSnapshot) {
+		return httperror.NotFound("The environment has not been snapshotted yet", err)
+	} else if err != nil {
+		return httperror.InternalServerError("Unable to compute the orphaned resources of the environment", err)
+	}
+
+	return response.JSON(w, report)
+}
+
+// @id EndpointOrphansCleanup
+// @summary Remove the orphaned resources of an environment
+// @description Remove the selected orphaned resources of a Docker environment. Only the resources reported as orphaned
+// @description by its last snapshot are removed, the ones used since then by a container or a Swarm service are kept.
+// @description **Access policy**: administrator
+// @tags endpoints
+// @security ApiKeyAuth
+// @security jwt
+// @accept json
+// @produce json
+// @param id path int true "Environment(Endpoint) identifier"
+// @param body body endpointOrphansCleanupPayload true "Resources to remove"
+// @success 200 {object} orphans.CleanupResult "Success"
+// @failure 400 "Invalid request"
+// @failure 404 "Environment(Endpoint) not found or without snapshot"
+// @failure 500 "Server error"
+// @router /endpoints/{id}/orphans/cleanup [post]
+func (handler *Handler) endpointOrphansCleanup(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	endpoint, httpErr := handler.orphansEndpoint(r)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	var payload endpointOrphansCleanupPayload
+	if err := request.DecodeAndValidateJSONPayload(r, &payload); err != nil {
+		return httperror.BadRequest("Invalid request payload", err)
+	}
+
+	selection := payload.Selection
+	if payload.AllVolumes || payload.AllImages || payload.AllNetworks {
+		report, err := handler.OrphanService.Report(endpoint)
+		if errors.Is(err, orphans.ErrNoSnapshot) {
+			return httperror.NotFound("The environment has not been snapshotted yet", err)
+		} else if err != nil {
+			return httperror.InternalServerError("Unable to compute the orphaned resources of the environment", err)
+		}
+
+		all := orphans.SelectAll(report, payload.AllVolumes, payload.AllImages, payload.AllNetworks)
+		selection.Volumes = append(selection.Volumes, all.Volumes...)
+		selection.Images = append(selection.Images, all.Images...)
+		selection.Networks = append(selection.Networks, all.Networks...)
+	}
+
+	result, err := handler.OrphanService.Cleanup(endpoint, selection)
+	if errors.Is(err, orphans.ErrNoSnapshot) {
+		return httperror.NotFound("The environment has not been snapshotted yet", err)
+	} else if errors.Is(err, orphans.ErrNoConnectivity) {
+		return httperror.BadRequest("The orphaned resources of an asynchronous Edge environment cannot be removed", err)
+	} else if err != nil {
+		return httperror.InternalServerError("Unable to remove the orphaned resources of the environment", err)
+	}
+
+	return response.JSON(w, result)
+}
+
+func (handler *Handler) orphansEndpoint(r *http.Request) (*portainer.Endpoint, *httperror.HandlerError) {
+	endpointID, err := request.RetrieveNumericRouteVariableValue(r, "id")
+	if err != nil {
+		return nil, httperror.BadRequest("Invalid environment identifier route variable", err)
+	}
+
+	endpoint, err := handler.DataStore.Endpoint().Endpoint(portainer.EndpointID(endpointID))
+	if handler.DataStore.IsErrObjectNotFound(err) {
+		return nil, httperror.NotFound("Unable to find an environment with the specified identifier inside the database", err)
+	} else if err != nil {
+		return nil, httperror.InternalServerError("Unable to find an environment with the specified identifier inside the database", err)
+	}
+
+	if !endpointutils.IsDockerEndpoint(endpoint) {
+		return nil, httperror.BadRequest("The orphaned resources are only reported for the Docker environments", errors.New("not a Docker environment"))
+	}
+
+	return endpoint, nil
+}
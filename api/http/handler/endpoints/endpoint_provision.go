@@ -0,0 +1,165 @@
+package endpoints
+
+import (
+	"errors"
+	"net/http"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/cloud"
+	"github.com/portainer/portainer/api/dataservices"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/request"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+
+	"github.com/rs/zerolog/log"
+)
+
+type endpointProvisionPayload struct {
+	// Name of the environment and of the cluster
+	Name string `validate:"required" example:"production"`
+	// Credential of the account of the provider the cluster is created with
+	CredentialID portainer.CloudCredentialID `validate:"required" example:"1"`
+	// Region of the cluster, as named by the provider
+	Region string `validate:"required" example:"LON1"`
+	// Size of the nodes, as named by the provider
+	NodeSize string `validate:"required" example:"g4s.kube.medium"`
+	// Number of nodes
+	NodeCount int `validate:"required" example:"3"`
+	// Version of Kubernetes, the default version of the provider when empty
+	KubernetesVersion string `example:"1.30.0"`
+	// Group of the environment, the unassigned group by default
+	GroupID portainer.EndpointGroupID `example:"1"`
+	// Tags of the environment
+	TagIDs []portainer.TagID `example:"1,2"`
+}
+
+func (payload *endpointProvisionPayload) Validate(r *http.Request) error {
+	if payload.CredentialID == 0 {
+		return errors.New("Invalid cloud credential identifier")
+	}
+
+	return cloud.ValidateClusterRequest(payload.clusterRequest())
+}
+
+func (payload *endpointProvisionPayload) clusterRequest() cloud.ClusterRequest {
+	return cloud.ClusterRequest{
+		Name:              payload.Name,
+		Region:            payload.Region,
+		NodeSize:          payload.NodeSize,
+		NodeCount:         payload.NodeCount,
+		KubernetesVersion: payload.KubernetesVersion,
+	}
+}
+
+// @id EndpointProvision
+// @summary Provision a managed Kubernetes cluster as a new environment(endpoint)
+// @description Create a cluster with a Kubernetes-as-a-Service provider and register it as a new environment.
+// @description The environment is returned as soon as the provider accepts the creation of the cluster. Once the cluster
+// @description is ready, the Portainer agent is deployed in it and exposed through a load balancer the environment
+// @description is connected to. The progress is reported in the CloudProvisioning field of the environment.
+// @description **Access policy**: administrator
+// @tags endpoints
+// @security ApiKeyAuth
+// @security jwt
+// @accept json
+// @produce json
+// @param body body endpointProvisionPayload true "Cluster details"
+// @success 202 {object} portainer.Endpoint "Provisioning started"
+// @failure 400 "Invalid request"
+// @failure 409 "Name is not unique"
+// @failure 502 "The provider refused to create the cluster"
+// @failure 500 "Server error"
+// @router /endpoints/provision [post]
+func (handler *Handler) endpointProvision(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	var payload endpointProvisionPayload
+	if err := request.DecodeAndValidateJSONPayload(r, &payload); err != nil {
+		return httperror.BadRequest("Invalid request payload", err)
+	}
+
+	if payload.GroupID == 0 {
+		payload.GroupID = 1
+	}
+
+	if payload.TagIDs == nil {
+		payload.TagIDs = make([]portainer.TagID, 0)
+	}
+
+	if _, err := handler.DataStore.CloudCredential().Read(payload.CredentialID); handler.DataStore.IsErrObjectNotFound(err) {
+		return httperror.BadRequest("Unable to find a cloud credential with the specified identifier inside the database", err)
+	} else if err != nil {
+		return httperror.InternalServerError("Unable to find a cloud credential with the specified identifier inside the database", err)
+	}
+
+	if _, err := handler.DataStore.EndpointGroup().Read(payload.GroupID); handler.DataStore.IsErrObjectNotFound(err) {
+		return httperror.BadRequest("Unable to find an environment group with the specified identifier inside the database", err)
+	} else if err != nil {
+		return httperror.InternalServerError("Unable to find an environment group with the specified identifier inside the database", err)
+	}
+
+	for _, tagID := range payload.TagIDs {
+		if _, err := handler.DataStore.Tag().Read(tagID); handler.DataStore.IsErrObjectNotFound(err) {
+			return httperror.BadRequest("Unable to find a tag with the specified identifier inside the database", err)
+		} else if err != nil {
+			return httperror.InternalServerError("Unable to find a tag with the specified identifier inside the database", err)
+		}
+	}
+
+	isUnique, err := handler.isNameUnique(payload.Name, 0)
+	if err != nil {
+		return httperror.InternalServerError("Unable to check if name is unique", err)
+	}
+
+	if !isUnique {
+		return httperror.Conflict("Name is not unique", nil)
+	}
+
+	provisioning, err := handler.CloudProvisioner.CreateCluster(payload.CredentialID, payload.clusterRequest())
+	if err != nil {
+		return httperror.NewError(http.StatusBadGateway, "Unable to create the cluster", err)
+	}
+
+	// the agents serve a self-signed certificate and authenticate Portainer by its signature
+	endpoint := &portainer.Endpoint{
+		Name:    payload.Name,
+		Type:    portainer.AgentOnKubernetesEnvironment,
+		GroupID: payload.GroupID,
+		TLSConfig: portainer.TLSConfiguration{
+			TLS:           true,
+			TLSSkipVerify: true,
+		},
+		UserAccessPolicies: portainer.UserAccessPolicies{},
+		TeamAccessPolicies: portainer.TeamAccessPolicies{},
+		TagIDs:             payload.TagIDs,
+		Status:             portainer.EndpointStatusDown,
+		Snapshots:          []portainer.DockerSnapshot{},
+		Kubernetes:         portainer.KubernetesDefault(),
+		CloudProvisioning:  provisioning,
+	}
+
+	if err := handler.DataStore.UpdateTx(func(tx dataservices.DataStoreTx) error {
+		endpoint.ID = portainer.EndpointID(tx.Endpoint().GetNextIdentifier())
+
+		if err := handler.saveEndpointAndUpdateAuthorizations(tx, endpoint); err != nil {
+			return err
+		}
+
+		return tx.EndpointRelation().Create(&portainer.EndpointRelation{
+			EndpointID: endpoint.ID,
+			EdgeStacks: map[portainer.EdgeStackID]bool{},
+		})
+	}); err != nil {
+		return httperror.InternalServerError("Unable to persist the environment inside the database", err)
+	}
+
+	handler.CloudProvisioner.Watch(endpoint.ID)
+
+	log.Info().
+		Int("endpoint_id", int(endpoint.ID)).
+		Str("provider", provisioning.Provider).
+		Str("cluster_id", provisioning.ClusterID).
+		Msg("started the provisioning of a cluster")
+
+	hideFields(endpoint)
+
+	return response.JSONWithStatus(w, endpoint, http.StatusAccepted)
+}
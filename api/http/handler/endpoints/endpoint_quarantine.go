@@ -0,0 +1,123 @@
+package endpoints
+
+import (
+	"errors"
+	"net/http"
+	"slices"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/docker/quarantine"
+	"github.com/portainer/portainer/api/http/security"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/request"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+)
+
+type endpointQuarantinePayload struct {
+	// Action applied to the workloads: stop stops every non-system container and scales down the replicated Swarm
+	// services, isolate disconnects every non-system container from the networks which are not internal
+	Action string `validate:"required" enums:"stop,isolate" example:"stop"`
+	// Reason of the quarantine, for the incident response
+	Reason string `example:"Suspicious outbound traffic"`
+	// Networks the containers stay connected to when they are isolated, in addition to the internal ones
+	KeepNetworks []string `example:"monitoring"`
+}
+
+func (payload *endpointQuarantinePayload) Validate(r *http.Request) error {
+	if !slices.Contains([]string{quarantine.ActionStop, quarantine.ActionIsolate}, payload.Action) {
+		return errors.New("Invalid action. Value must be one of: stop or isolate")
+	}
+
+	return nil
+}
+
+type endpointQuarantineResponse struct {
+	Quarantine *portainer.EndpointQuarantine `json:"Quarantine"`
+	Result     *quarantine.Result            `json:"Result"`
+}
+
+// @id EndpointQuarantine
+// @summary Quarantine an environment
+// @description Emergency stop of a compromised Docker environment. The environment is marked as quarantined, which
+// @description blocks its deployments until it is released, then its workloads are stopped or disconnected from the
+// @description external networks. The Portainer server and agent containers, and the containers labeled with
+// @description io.portainer.system=true, are left untouched. Quarantining an environment again applies the action again.
+// @description **Access policy**: administrator
+// @tags endpoints
+// @security ApiKeyAuth
+// @security jwt
+// @accept json
+// @produce json
+// @param id path int true "Environment(Endpoint) identifier"
+// @param body body endpointQuarantinePayload true "Quarantine details"
+// @success 200 {object} endpointQuarantineResponse "Success"
+// @failure 400 "Invalid request or not a reachable Docker environment"
+// @failure 404 "Environment(Endpoint) not found"
+// @failure 500 "Server error"
+// @router /endpoints/{id}/quarantine [post]
+func (handler *Handler) endpointQuarantine(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	endpoint, httpErr := handler.routeEndpoint(r)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	var payload endpointQuarantinePayload
+	if err := request.DecodeAndValidateJSONPayload(r, &payload); err != nil {
+		return httperror.BadRequest("Invalid request payload", err)
+	}
+
+	tokenData, err := security.RetrieveTokenData(r)
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve user authentication token", err)
+	}
+
+	result, err := handler.QuarantineService.Quarantine(endpoint, quarantine.Options{
+		Action:       payload.Action,
+		KeepNetworks: payload.KeepNetworks,
+	}, payload.Reason, tokenData.Username)
+
+	// the proxy of the environment holds its previous state
+	handler.ProxyManager.DeleteEndpointProxy(endpoint.ID)
+
+	if errors.Is(err, quarantine.ErrNotDocker) || errors.Is(err, quarantine.ErrNoConnectivity) {
+		return httperror.BadRequest("Unable to quarantine the environment", err)
+	} else if err != nil {
+		return httperror.InternalServerError("Unable to quarantine the workloads of the environment", err)
+	}
+
+	return response.JSON(w, endpointQuarantineResponse{Quarantine: endpoint.Quarantine, Result: result})
+}
+
+// @id EndpointQuarantineRelease
+// @summary Release an environment from quarantine
+// @description Lift the quarantine of an environment, which allows its deployments again. The stopped workloads
+// @description are not restarted and the isolated containers are not reconnected.
+// @description **Access policy**: administrator
+// @tags endpoints
+// @security ApiKeyAuth
+// @security jwt
+// @param id path int true "Environment(Endpoint) identifier"
+// @success 204 "Success"
+// @failure 400 "Invalid request"
+// @failure 404 "Environment(Endpoint) not found"
+// @failure 500 "Server error"
+// @router /endpoints/{id}/quarantine [delete]
+func (handler *Handler) endpointQuarantineRelease(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	endpoint, httpErr := handler.routeEndpoint(r)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	tokenData, err := security.RetrieveTokenData(r)
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve user authentication token", err)
+	}
+
+	if err := handler.QuarantineService.Release(endpoint, tokenData.Username); err != nil {
+		return httperror.InternalServerError("Unable to release the environment from quarantine", err)
+	}
+
+	handler.ProxyManager.DeleteEndpointProxy(endpoint.ID)
+
+	return response.Empty(w)
+}
@@ -0,0 +1,165 @@
+package endpoints
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+	"github.com/portainer/portainer/api/registration"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/request"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+
+	"github.com/rs/zerolog/log"
+)
+
+type endpointRegisterPayload struct {
+	// Name of the environment
+	Name string `validate:"required" example:"node-1"`
+	// URL of the agent, as reachable from Portainer
+	URL string `validate:"required" example:"tcp://10.0.0.5:9001"`
+}
+
+func (payload *endpointRegisterPayload) Validate(r *http.Request) error {
+	if payload.Name == "" {
+		return errors.New("Invalid environment name")
+	}
+
+	if payload.URL == "" {
+		return errors.New("Invalid agent URL")
+	}
+
+	return nil
+}
+
+// @id EndpointRegister
+// @summary Register an agent as a new environment
+// @description Used by the agents to register themselves as new environments on their first contact, with a
+// @description registration token passed in the X-PortainerAgent-RegistrationToken header. The environment is
+// @description created in the group and with the tags of the token. An agent registering again with the same URL
+// @description and token gets its existing environment, the URL of an environment registered otherwise is a conflict.
+// @description **Access policy**: public, with a valid registration token
+// @tags endpoints
+// @accept json
+// @produce json
+// @param X-PortainerAgent-RegistrationToken header string true "Registration token"
+// @param body body endpointRegisterPayload true "Agent details"
+// @success 200 {object} portainer.Endpoint "Success"
+// @failure 400 "Invalid request"
+// @failure 401 "Invalid registration token"
+// @failure 409 "Name or URL is not unique"
+// @failure 500 "Server error"
+// @router /endpoints/register [post]
+func (handler *Handler) endpointRegister(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	raw := r.Header.Get(portainer.PortainerAgentRegistrationTokenHeader)
+	if raw == "" {
+		return httperror.Unauthorized("Invalid registration token", registration.ErrInvalidToken)
+	}
+
+	var payload endpointRegisterPayload
+	if err := request.DecodeAndValidateJSONPayload(r, &payload); err != nil {
+		return httperror.BadRequest("Invalid request payload", err)
+	}
+
+	// the registrations are serialized so that a token is never used past its maximum number of registrations
+	handler.registrationMu.Lock()
+	defer handler.registrationMu.Unlock()
+
+	token, err := handler.DataStore.RegistrationToken().RegistrationTokenByDigest(registration.Digest(raw))
+	if handler.DataStore.IsErrObjectNotFound(err) {
+		return httperror.Unauthorized("Invalid registration token", registration.ErrInvalidToken)
+	} else if err != nil {
+		return httperror.InternalServerError("Unable to retrieve the registration token from the database", err)
+	}
+
+	if err := registration.Check(token, time.Now()); err != nil {
+		log.Warn().Err(err).Int("token_id", int(token.ID)).Str("url", payload.URL).Msg("rejected an agent registration")
+
+		return httperror.Unauthorized("Invalid registration token", registration.ErrInvalidToken)
+	}
+
+	endpoints, err := handler.DataStore.Endpoint().Endpoints()
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve the environments from the database", err)
+	}
+
+	for i := range endpoints {
+		if endpoints[i].URL != payload.URL && endpoints[i].URL != strings.TrimPrefix(payload.URL, "tcp://") {
+			continue
+		}
+
+		// only the environments registered with the token are returned, the token does not grant the others
+		if endpoints[i].RegistrationTokenID != token.ID {
+			return httperror.Conflict("URL is not unique", errors.New("an environment with the same URL was not registered with this token"))
+		}
+
+		hideFields(&endpoints[i])
+
+		return response.JSON(w, endpoints[i])
+	}
+
+	isUnique, err := handler.isNameUnique(payload.Name, 0)
+	if err != nil {
+		return httperror.InternalServerError("Unable to check if name is unique", err)
+	}
+
+	if !isUnique {
+		return httperror.Conflict("Name is not unique", nil)
+	}
+
+	tagIDs := token.TagIDs
+	if tagIDs == nil {
+		tagIDs = make([]portainer.TagID, 0)
+	}
+
+	// the agents serve a self-signed certificate and authenticate Portainer by its signature
+	endpoint, endpointCreationError := handler.createEndpoint(handler.DataStore, &endpointCreatePayload{
+		Name:                 payload.Name,
+		URL:                  payload.URL,
+		EndpointCreationType: agentEnvironment,
+		GroupID:              int(token.GroupID),
+		TagIDs:               tagIDs,
+		TLS:                  true,
+		TLSSkipVerify:        true,
+		TLSSkipClientVerify:  true,
+	})
+	if endpointCreationError != nil {
+		return endpointCreationError
+	}
+
+	if err := handler.createEndpointRelation(endpoint); err != nil {
+		return err
+	}
+
+	endpoint.RegistrationTokenID = token.ID
+
+	if err := handler.DataStore.UpdateTx(func(tx dataservices.DataStoreTx) error {
+		if err := tx.Endpoint().UpdateEndpoint(endpoint.ID, endpoint); err != nil {
+			return err
+		}
+
+		token, err := tx.RegistrationToken().Read(token.ID)
+		if err != nil {
+			return err
+		}
+
+		token.Uses++
+
+		return tx.RegistrationToken().Update(token.ID, token)
+	}); err != nil {
+		return httperror.InternalServerError("Unable to update the registration token inside the database", err)
+	}
+
+	log.Info().
+		Int("endpoint_id", int(endpoint.ID)).
+		Int("token_id", int(token.ID)).
+		Str("url", endpoint.URL).
+		Msg("registered an agent as a new environment")
+
+	hideFields(endpoint)
+
+	return response.JSON(w, endpoint)
+}
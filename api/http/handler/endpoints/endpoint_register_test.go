@@ -0,0 +1,105 @@
+package endpoints
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/datastore"
+	"github.com/portainer/portainer/api/internal/testhelpers"
+	"github.com/portainer/portainer/api/registration"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEndpointRegister(t *testing.T) {
+	is := require.New(t)
+
+	_, store := datastore.MustNewTestStore(t, true, false)
+
+	handler := NewHandler(testhelpers.NewTestRequestBouncer())
+	handler.DataStore = store
+
+	is.NoError(store.Endpoint().Create(&portainer.Endpoint{ID: 1, Name: "node-1", URL: "tcp://10.0.0.5:9001"}))
+
+	now := time.Now()
+	raw, token, err := registration.NewToken("datacenter-1", 1, nil, 1, time.Hour, 1, now)
+	is.NoError(err)
+	is.NoError(store.RegistrationToken().Create(token))
+
+	expiredRaw, expired, err := registration.NewToken("expired", 1, nil, 0, time.Hour, 1, now.Add(-2*time.Hour))
+	is.NoError(err)
+	is.NoError(store.RegistrationToken().Create(expired))
+
+	register := func(token, body string) int {
+		req := httptest.NewRequest(http.MethodPost, "/endpoints/register", strings.NewReader(body))
+		if token != "" {
+			req.Header.Set(portainer.PortainerAgentRegistrationTokenHeader, token)
+		}
+
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+
+		return rr.Code
+	}
+
+	is.Equal(http.StatusUnauthorized, register("", `{"Name":"node-2","URL":"tcp://10.0.0.6:9001"}`))
+	is.Equal(http.StatusUnauthorized, register("prt_unknown", `{"Name":"node-2","URL":"tcp://10.0.0.6:9001"}`))
+	is.Equal(http.StatusUnauthorized, register(expiredRaw, `{"Name":"node-2","URL":"tcp://10.0.0.6:9001"}`))
+	is.Equal(http.StatusBadRequest, register(raw, `{"Name":"node-2"}`))
+
+	// the token does not grant the environments it did not register
+	is.Equal(http.StatusConflict, register(raw, `{"Name":"node-1","URL":"tcp://10.0.0.5:9001"}`))
+	is.Equal(http.StatusConflict, register(raw, `{"Name":"node-1","URL":"tcp://10.0.0.6:9001"}`))
+
+	token, err = store.RegistrationToken().Read(token.ID)
+	is.NoError(err)
+	is.Zero(token.Uses)
+
+	// a used up one-time token is rejected
+	token.Uses = 1
+	is.NoError(store.RegistrationToken().Update(token.ID, token))
+	is.Equal(http.StatusUnauthorized, register(raw, `{"Name":"node-2","URL":"tcp://10.0.0.6:9001"}`))
+}
+
+func TestEndpointRegister_Again(t *testing.T) {
+	is := require.New(t)
+
+	_, store := datastore.MustNewTestStore(t, true, false)
+
+	handler := NewHandler(testhelpers.NewTestRequestBouncer())
+	handler.DataStore = store
+
+	now := time.Now()
+	raw, token, err := registration.NewToken("datacenter-1", 1, nil, 0, time.Hour, 1, now)
+	is.NoError(err)
+	is.NoError(store.RegistrationToken().Create(token))
+
+	otherRaw, other, err := registration.NewToken("datacenter-2", 1, nil, 0, time.Hour, 1, now)
+	is.NoError(err)
+	is.NoError(store.RegistrationToken().Create(other))
+
+	is.NoError(store.Endpoint().Create(&portainer.Endpoint{ID: 1, Name: "node-1", URL: "tcp://10.0.0.5:9001", RegistrationTokenID: token.ID}))
+
+	register := func(token string) int {
+		req := httptest.NewRequest(http.MethodPost, "/endpoints/register", strings.NewReader(`{"Name":"node-1","URL":"tcp://10.0.0.5:9001"}`))
+		req.Header.Set(portainer.PortainerAgentRegistrationTokenHeader, token)
+
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+
+		return rr.Code
+	}
+
+	// an agent registering again gets its environment, without using the token
+	is.Equal(http.StatusOK, register(raw))
+
+	token, err = store.RegistrationToken().Read(token.ID)
+	is.NoError(err)
+	is.Zero(token.Uses)
+
+	is.Equal(http.StatusConflict, register(otherRaw))
+}
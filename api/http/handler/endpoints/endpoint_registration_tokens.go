@@ -0,0 +1,171 @@
+package endpoints
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/http/security"
+	"github.com/portainer/portainer/api/registration"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/request"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+)
+
+type registrationTokenCreatePayload struct {
+	// Name of the token
+	Name string `validate:"required" example:"datacenter-1"`
+	// Group of the registered environments, the unassigned group by default
+	GroupID portainer.EndpointGroupID `example:"1"`
+	// Tags of the registered environments
+	TagIDs []portainer.TagID `example:"1,2"`
+	// Maximum number of registrations, 1 for a one-time token, 0 for an unlimited number
+	MaxUses int `example:"1"`
+	// Validity of the token as a duration, e.g. 72h. The token never expires when empty
+	ExpiresIn string `example:"72h"`
+}
+
+func (payload *registrationTokenCreatePayload) Validate(r *http.Request) error {
+	if payload.Name == "" {
+		return errors.New("Invalid token name")
+	}
+
+	if payload.MaxUses < 0 {
+		return errors.New("Invalid maximum number of registrations")
+	}
+
+	if payload.ExpiresIn != "" {
+		if validity, err := time.ParseDuration(payload.ExpiresIn); err != nil || validity <= 0 {
+			return errors.New("Invalid token validity, it must be a positive duration, e.g. 72h")
+		}
+	}
+
+	return nil
+}
+
+type registrationTokenCreateResponse struct {
+	// The token to pass to the agents, it is only returned once
+	Token             string                       `json:"Token" example:"prt_5TtGx1Ia5AHmZpIHmIxu3ej8p0t9rO5y2JbVYbgiKoU"`
+	RegistrationToken *portainer.RegistrationToken `json:"RegistrationToken"`
+}
+
+// @id EndpointRegistrationTokenCreate
+// @summary Create a registration token
+// @description Create a token the agents present to register themselves as new environments on their first contact,
+// @description in the group and with the tags of the token. The token is only returned in this response.
+// @description **Access policy**: administrator
+// @tags endpoints
+// @security ApiKeyAuth
+// @security jwt
+// @accept json
+// @produce json
+// @param body body registrationTokenCreatePayload true "Registration token details"
+// @success 200 {object} registrationTokenCreateResponse "Success"
+// @failure 400 "Invalid request"
+// @failure 500 "Server error"
+// @router /endpoints/registration_tokens [post]
+func (handler *Handler) registrationTokenCreate(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	var payload registrationTokenCreatePayload
+	if err := request.DecodeAndValidateJSONPayload(r, &payload); err != nil {
+		return httperror.BadRequest("Invalid request payload", err)
+	}
+
+	tokenData, err := security.RetrieveTokenData(r)
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve user details from authentication token", err)
+	}
+
+	if payload.GroupID == 0 {
+		payload.GroupID = 1
+	}
+
+	if _, err := handler.DataStore.EndpointGroup().Read(payload.GroupID); handler.DataStore.IsErrObjectNotFound(err) {
+		return httperror.BadRequest("Unable to find an environment group with the specified identifier inside the database", err)
+	} else if err != nil {
+		return httperror.InternalServerError("Unable to find an environment group with the specified identifier inside the database", err)
+	}
+
+	for _, tagID := range payload.TagIDs {
+		if _, err := handler.DataStore.Tag().Read(tagID); handler.DataStore.IsErrObjectNotFound(err) {
+			return httperror.BadRequest("Unable to find a tag with the specified identifier inside the database", err)
+		} else if err != nil {
+			return httperror.InternalServerError("Unable to find a tag with the specified identifier inside the database", err)
+		}
+	}
+
+	var validity time.Duration
+	if payload.ExpiresIn != "" {
+		validity, _ = time.ParseDuration(payload.ExpiresIn)
+	}
+
+	raw, token, err := registration.NewToken(payload.Name, payload.GroupID, payload.TagIDs, payload.MaxUses, validity, tokenData.ID, time.Now())
+	if err != nil {
+		return httperror.InternalServerError("Unable to generate the registration token", err)
+	}
+
+	if err := handler.DataStore.RegistrationToken().Create(token); err != nil {
+		return httperror.InternalServerError("Unable to persist the registration token inside the database", err)
+	}
+
+	token.Digest = ""
+
+	return response.JSON(w, registrationTokenCreateResponse{Token: raw, RegistrationToken: token})
+}
+
+// @id EndpointRegistrationTokenList
+// @summary List the registration tokens
+// @description List the registration tokens of the agents, without the tokens themselves.
+// @description **Access policy**: administrator
+// @tags endpoints
+// @security ApiKeyAuth
+// @security jwt
+// @produce json
+// @success 200 {array} portainer.RegistrationToken "Success"
+// @failure 500 "Server error"
+// @router /endpoints/registration_tokens [get]
+func (handler *Handler) registrationTokenList(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	tokens, err := handler.DataStore.RegistrationToken().ReadAll()
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve the registration tokens from the database", err)
+	}
+
+	for i := range tokens {
+		tokens[i].Digest = ""
+	}
+
+	return response.JSON(w, tokens)
+}
+
+// @id EndpointRegistrationTokenDelete
+// @summary Revoke a registration token
+// @description Remove a registration token, the agents can no longer register with it. The environments registered
+// @description with the token are kept.
+// @description **Access policy**: administrator
+// @tags endpoints
+// @security ApiKeyAuth
+// @security jwt
+// @param id path int true "Registration token identifier"
+// @success 204 "Success"
+// @failure 400 "Invalid request"
+// @failure 404 "Registration token not found"
+// @failure 500 "Server error"
+// @router /endpoints/registration_tokens/{id} [delete]
+func (handler *Handler) registrationTokenDelete(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	tokenID, err := request.RetrieveNumericRouteVariableValue(r, "id")
+	if err != nil {
+		return httperror.BadRequest("Invalid registration token identifier route variable", err)
+	}
+
+	if _, err := handler.DataStore.RegistrationToken().Read(portainer.RegistrationTokenID(tokenID)); handler.DataStore.IsErrObjectNotFound(err) {
+		return httperror.NotFound("Unable to find a registration token with the specified identifier inside the database", err)
+	} else if err != nil {
+		return httperror.InternalServerError("Unable to find a registration token with the specified identifier inside the database", err)
+	}
+
+	if err := handler.DataStore.RegistrationToken().Delete(portainer.RegistrationTokenID(tokenID)); err != nil {
+		return httperror.InternalServerError("Unable to remove the registration token from the database", err)
+	}
+
+	return response.Empty(w)
+}
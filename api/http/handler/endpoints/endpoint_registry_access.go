@@ -2,26 +2,101 @@ package endpoints
 
 import (
 	"errors"
+	"fmt"
 	"net/http"
+	"strings"
 
 	portainer "github.com/portainer/portainer/api"
 	"github.com/portainer/portainer/api/dataservices"
 	"github.com/portainer/portainer/api/http/security"
+	"github.com/portainer/portainer/api/internal/endpointutils"
+	"github.com/portainer/portainer/api/internal/registryutils"
 	httperror "github.com/portainer/portainer/pkg/libhttp/error"
 	"github.com/portainer/portainer/pkg/libhttp/request"
 	"github.com/portainer/portainer/pkg/libhttp/response"
+
+	"k8s.io/apimachinery/pkg/util/validation"
 )
 
 type registryAccessPayload struct {
 	UserAccessPolicies portainer.UserAccessPolicies
 	TeamAccessPolicies portainer.TeamAccessPolicies
-	Namespaces         []string
+	// Namespaces of a Kubernetes environment the registry is bound to. The image pull secret of the registry is only
+	// created in these namespaces, and the stacks deployed to them reference it
+	Namespaces []string
 }
 
 func (payload *registryAccessPayload) Validate(r *http.Request) error {
+	for _, namespace := range payload.Namespaces {
+		if errs := validation.IsDNS1123Label(namespace); len(errs) > 0 {
+			return fmt.Errorf("invalid namespace %q: %s", namespace, strings.Join(errs, ", "))
+		}
+	}
+
 	return nil
 }
 
+// @id endpointRegistriesSync
+// @summary Synchronize the registry secrets of a Kubernetes environment
+// @description Recreate the image pull secrets of the registries in the namespaces they are bound to on a Kubernetes
+// @description environment, and remove the registry secrets of Portainer from the namespaces they are not bound to
+// @description anymore.
+// @description **Access policy**: administrator
+// @tags endpoints
+// @security ApiKeyAuth
+// @security jwt
+// @produce json
+// @param id path int true "Environment(Endpoint) identifier"
+// @success 200 {object} cli.RegistrySecretsSync "Success"
+// @failure 400 "Invalid request"
+// @failure 404 "Endpoint not found"
+// @failure 500 "Server error"
+// @router /endpoints/{id}/registries/sync [post]
+func (handler *Handler) endpointRegistriesSync(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	endpointID, err := request.RetrieveNumericRouteVariableValue(r, "id")
+	if err != nil {
+		return httperror.BadRequest("Invalid environment identifier route variable", err)
+	}
+
+	endpoint, err := handler.DataStore.Endpoint().Endpoint(portainer.EndpointID(endpointID))
+	if handler.DataStore.IsErrObjectNotFound(err) {
+		return httperror.NotFound("Unable to find an environment with the specified identifier inside the database", err)
+	} else if err != nil {
+		return httperror.InternalServerError("Unable to find an environment with the specified identifier inside the database", err)
+	}
+
+	if !endpointutils.IsKubernetesEndpoint(endpoint) {
+		return httperror.BadRequest("The registry secrets can only be synchronized on a Kubernetes environment", errors.New("unsupported environment type"))
+	}
+
+	registries, err := handler.DataStore.Registry().ReadAll()
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve registries from the database", err)
+	}
+
+	for i := range registries {
+		if len(registries[i].RegistryAccesses[endpoint.ID].Namespaces) == 0 {
+			continue
+		}
+
+		if err := registryutils.EnsureRegTokenValid(handler.DataStore, &registries[i]); err != nil {
+			return httperror.InternalServerError("Unable to retrieve the token of an ECR registry", err)
+		}
+	}
+
+	cli, err := handler.K8sClientFactory.GetPrivilegedKubeClient(endpoint)
+	if err != nil {
+		return httperror.InternalServerError("Unable to create Kubernetes client", err)
+	}
+
+	report, err := cli.SyncRegistrySecrets(endpoint.ID, registries)
+	if err != nil {
+		return httperror.InternalServerError("Unable to synchronize the registry secrets", err)
+	}
+
+	return response.JSON(w, report)
+}
+
 // @id endpointRegistryAccess
 // @summary update registry access for environment
 // @description **Access policy**: authenticated
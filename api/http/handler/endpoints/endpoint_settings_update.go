@@ -31,6 +31,10 @@ type endpointSettingsUpdatePayload struct {
 
 	EnableGPUManagement *bool `json:"enableGPUManagement" example:"false"`
 
+	// Whether the workloads only change through stacks, the proxied Docker and Kubernetes API calls changing them
+	// being refused for every user unless break-glass access is active
+	Immutable *bool `json:"immutable" example:"false"`
+
 	Gpus []portainer.Pair `json:"gpus"`
 }
 
@@ -119,6 +123,12 @@ func (handler *Handler) endpointSettingsUpdate(w http.ResponseWriter, r *http.Re
 		endpoint.Gpus = payload.Gpus
 	}
 
+	immutableChanged := payload.Immutable != nil && *payload.Immutable != endpoint.Immutable
+	if immutableChanged {
+		endpoint.Immutable = *payload.Immutable
+		endpoint.BreakGlass = nil
+	}
+
 	endpoint.SecuritySettings = securitySettings
 
 	err = handler.DataStore.Endpoint().UpdateEndpoint(portainer.EndpointID(endpointID), endpoint)
@@ -126,5 +136,10 @@ func (handler *Handler) endpointSettingsUpdate(w http.ResponseWriter, r *http.Re
 		return httperror.InternalServerError("Failed persisting environment in database", err)
 	}
 
+	if immutableChanged {
+		// the proxy of the environment holds its previous mode
+		handler.ProxyManager.DeleteEndpointProxy(endpoint.ID)
+	}
+
 	return response.JSON(w, endpoint)
 }
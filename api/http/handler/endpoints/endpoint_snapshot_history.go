@@ -0,0 +1,152 @@
+package endpoints
+
+import (
+	"errors"
+	"net/http"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/internal/snapshot"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/request"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+)
+
+type snapshotHistoryItem struct {
+	ID   portainer.SnapshotHistoryID `json:"Id" example:"1"`
+	Time int64                       `json:"Time" example:"1587399600"`
+	// Counts of the resources of the environment at the time of the snapshot
+	ContainerCount int `json:"ContainerCount" example:"12"`
+	ImageCount     int `json:"ImageCount" example:"30"`
+	VolumeCount    int `json:"VolumeCount" example:"8"`
+}
+
+// @id EndpointSnapshotHistory
+// @summary List the most recent snapshots of an environment(endpoint)
+// @description List the most recent Docker snapshots of an environment(endpoint), the most recent first. They can be compared with the snapshot diff API.
+// @description **Access policy**: restricted
+// @tags endpoints
+// @security ApiKeyAuth
+// @security jwt
+// @produce json
+// @param id path int true "Environment(Endpoint) identifier"
+// @success 200 {array} snapshotHistoryItem "Success"
+// @failure 400 "Invalid request"
+// @failure 403 "Permission denied"
+// @failure 404 "Environment(Endpoint) not found"
+// @failure 500 "Server error"
+// @router /endpoints/{id}/snapshots [get]
+func (handler *Handler) endpointSnapshotHistory(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	_, entries, httpErr := handler.snapshotHistory(r)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	items := make([]snapshotHistoryItem, 0, len(entries))
+	for _, entry := range entries {
+		items = append(items, snapshotHistoryItem{
+			ID:             entry.ID,
+			Time:           entry.Time,
+			ContainerCount: len(entry.Docker.SnapshotRaw.Containers),
+			ImageCount:     len(entry.Docker.SnapshotRaw.Images),
+			VolumeCount:    len(entry.Docker.SnapshotRaw.Volumes.Volumes),
+		})
+	}
+
+	return response.JSON(w, items)
+}
+
+// @id EndpointSnapshotDiff
+// @summary Compare two snapshots of an environment(endpoint)
+// @description Compare two of the most recent Docker snapshots of an environment(endpoint): the containers added and removed,
+// @description the containers recreated from another image, and the volumes and images added and removed.
+// @description The snapshots are chosen by identifier, or the first one as the snapshot taken at or before a Unix timestamp.
+// @description By default, the latest snapshot is compared to the previous one.
+// @description **Access policy**: restricted
+// @tags endpoints
+// @security ApiKeyAuth
+// @security jwt
+// @produce json
+// @param id path int true "Environment(Endpoint) identifier"
+// @param from query int false "Identifier of the snapshot the changes are computed from"
+// @param since query int false "Unix timestamp, the changes are computed from the snapshot taken at or before it. Ignored when from is set"
+// @param to query int false "Identifier of the snapshot the changes are computed to, the latest snapshot by default"
+// @success 200 {object} snapshot.SnapshotDiff "Success"
+// @failure 400 "Invalid request"
+// @failure 403 "Permission denied"
+// @failure 404 "Environment(Endpoint) or snapshot not found"
+// @failure 500 "Server error"
+// @router /endpoints/{id}/snapshots/diff [get]
+func (handler *Handler) endpointSnapshotDiff(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	fromID, err := request.RetrieveNumericQueryParameter(r, "from", true)
+	if err != nil {
+		return httperror.BadRequest("Invalid query parameter: from", err)
+	}
+
+	toID, err := request.RetrieveNumericQueryParameter(r, "to", true)
+	if err != nil {
+		return httperror.BadRequest("Invalid query parameter: to", err)
+	}
+
+	since, err := request.RetrieveNumericQueryParameter(r, "since", true)
+	if err != nil {
+		return httperror.BadRequest("Invalid query parameter: since", err)
+	}
+
+	endpoint, entries, httpErr := handler.snapshotHistory(r)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	if len(entries) == 0 {
+		return httperror.NotFound("The environment has no snapshot history", errors.New("no snapshot history"))
+	}
+
+	to := &entries[0]
+	if toID != 0 {
+		if to = snapshot.FindHistoryEntry(entries, portainer.SnapshotHistoryID(toID)); to == nil {
+			return httperror.NotFound("Unable to find the snapshot to compare to", errors.New("snapshot not found"))
+		}
+	}
+
+	var from *portainer.SnapshotHistoryEntry
+	switch {
+	case fromID != 0:
+		from = snapshot.FindHistoryEntry(entries, portainer.SnapshotHistoryID(fromID))
+	case since != 0:
+		from = snapshot.HistoryEntryAt(entries, int64(since))
+	case len(entries) > 1:
+		from = &entries[1]
+	}
+
+	if from == nil {
+		return httperror.NotFound("Unable to find the snapshot to compare from", errors.New("snapshot not found"))
+	}
+
+	return response.JSON(w, snapshot.NewSnapshotDiff(endpoint, from, to))
+}
+
+// snapshotHistory returns the environment(endpoint) of the request and its snapshot history, the most recent first
+func (handler *Handler) snapshotHistory(r *http.Request) (*portainer.Endpoint, []portainer.SnapshotHistoryEntry, *httperror.HandlerError) {
+	endpointID, err := request.RetrieveNumericRouteVariableValue(r, "id")
+	if err != nil {
+		return nil, nil, httperror.BadRequest("Invalid environment identifier route variable", err)
+	}
+
+	endpoint, err := handler.DataStore.Endpoint().Endpoint(portainer.EndpointID(endpointID))
+	if handler.DataStore.IsErrObjectNotFound(err) {
+		return nil, nil, httperror.NotFound("Unable to find an environment with the specified identifier inside the database", err)
+	} else if err != nil {
+		return nil, nil, httperror.InternalServerError("Unable to find an environment with the specified identifier inside the database", err)
+	}
+
+	if err := handler.requestBouncer.AuthorizedEndpointOperation(r, endpoint); err != nil {
+		return nil, nil, httperror.Forbidden("Permission denied to access environment", err)
+	}
+
+	entries, err := handler.DataStore.SnapshotHistory().EntriesByEndpointID(endpoint.ID)
+	if err != nil {
+		return nil, nil, httperror.InternalServerError("Unable to retrieve the snapshot history from the database", err)
+	}
+
+	return endpoint, entries, nil
+}
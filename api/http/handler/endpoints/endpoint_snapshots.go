@@ -62,6 +62,10 @@ func (handler *Handler) endpointSnapshots(w http.ResponseWriter, r *http.Request
 
 		latestEndpointReference.Agent.Version = endpoint.Agent.Version
 
+		if snapshot.IsAgentFailover(latestEndpointReference, &endpoint) {
+			latestEndpointReference.URL = endpoint.URL
+		}
+
 		err = handler.DataStore.Endpoint().UpdateEndpoint(latestEndpointReference.ID, latestEndpointReference)
 		if err != nil {
 			return httperror.InternalServerError("Unable to persist environment changes inside the database", err)
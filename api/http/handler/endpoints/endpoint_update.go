@@ -2,6 +2,9 @@ package endpoints
 
 import (
 	"cmp"
+	"errors"
+	"fmt"
+	"net"
 	"net/http"
 	"reflect"
 	"strconv"
@@ -12,6 +15,7 @@ import (
 	"github.com/portainer/portainer/api/http/client"
 	"github.com/portainer/portainer/api/internal/endpointutils"
 	"github.com/portainer/portainer/api/pendingactions/handlers"
+	"github.com/portainer/portainer/api/stacks/stackutils"
 	httperror "github.com/portainer/portainer/pkg/libhttp/error"
 	"github.com/portainer/portainer/pkg/libhttp/request"
 	"github.com/portainer/portainer/pkg/libhttp/response"
@@ -24,6 +28,8 @@ type endpointUpdatePayload struct {
 	Name *string `example:"my-environment"`
 	// URL or IP address of a Docker host
 	URL *string `example:"docker.mydomain.tld:2375"`
+	// Addresses of the replicas of a Docker agent, the environment fails over to them when its URL is unreachable
+	AgentURLs []string `example:"10.0.0.1:9001,10.0.0.2:9001"`
 	// URL or IP address where exposed containers will be reachable.\
 	// Defaults to URL if not specified
 	PublicURL *string `example:"docker.mydomain.tld:2375"`
@@ -45,6 +51,12 @@ type endpointUpdatePayload struct {
 	AzureTenantID *string `example:"34ddc78d-4fel-2358-8cc1-df84c8o839f5"`
 	// Azure authentication key
 	AzureAuthenticationKey *string `example:"cOrXoK/1D35w8YQ8nH1/8ZGwzz45JIYD5jxHKXEQknk="`
+	// ACL token of the Nomad cluster
+	NomadToken *string `example:"d8ee2a7c-1a5b-4b0e-a1f8-5d1e2c2f6f1b"`
+	// Region of the Nomad cluster
+	NomadRegion *string `example:"global"`
+	// Namespace of the Nomad jobs
+	NomadNamespace *string `example:"default"`
 	// List of tag identifiers to which this environment(endpoint) is associated
 	TagIDs             []portainer.TagID `example:"1,2"`
 	UserAccessPolicies portainer.UserAccessPolicies
@@ -53,10 +65,19 @@ type endpointUpdatePayload struct {
 	EdgeCheckinInterval *int `example:"5"`
 	// Associated Kubernetes data
 	Kubernetes *portainer.KubernetesData
+	// Variables merged into the env of the stacks deployed to the environment(endpoint). Replaces all the variables,
+	// unchanged when omitted
+	Variables []portainer.Pair
 }
 
 func (payload *endpointUpdatePayload) Validate(r *http.Request) error {
-	return nil
+	for _, agentURL := range payload.AgentURLs {
+		if _, _, err := net.SplitHostPort(strings.TrimPrefix(agentURL, "tcp://")); err != nil {
+			return fmt.Errorf("invalid agent URL %q: %w", agentURL, err)
+		}
+	}
+
+	return stackutils.ValidateVariables(payload.Variables)
 }
 
 // @id EndpointUpdate
@@ -112,10 +133,25 @@ func (handler *Handler) endpointUpdate(w http.ResponseWriter, r *http.Request) *
 		updateEndpointProxy = true
 	}
 
+	if payload.AgentURLs != nil {
+		if endpoint.Type != portainer.AgentOnDockerEnvironment && len(payload.AgentURLs) > 0 {
+			return httperror.BadRequest("Invalid request payload", errors.New("the agent URLs are only supported by the Docker agent environments"))
+		}
+
+		endpoint.AgentURLs = make([]string, 0, len(payload.AgentURLs))
+		for _, agentURL := range payload.AgentURLs {
+			endpoint.AgentURLs = append(endpoint.AgentURLs, strings.TrimPrefix(agentURL, "tcp://"))
+		}
+	}
+
 	if payload.Gpus != nil {
 		endpoint.Gpus = payload.Gpus
 	}
 
+	if payload.Variables != nil {
+		endpoint.Variables = payload.Variables
+	}
+
 	endpoint.PublicURL = *cmp.Or(payload.PublicURL, &endpoint.PublicURL)
 	endpoint.EdgeCheckinInterval = *cmp.Or(payload.EdgeCheckinInterval, &endpoint.EdgeCheckinInterval)
 
@@ -197,6 +233,26 @@ func (handler *Handler) endpointUpdate(w http.ResponseWriter, r *http.Request) *
 		endpoint.AzureCredentials = credentials
 	}
 
+	if endpoint.Type == portainer.NomadEnvironment {
+		updateEndpointProxy = true
+
+		if endpoint.Nomad == nil {
+			endpoint.Nomad = &portainer.NomadData{}
+		}
+
+		if payload.NomadToken != nil {
+			endpoint.Nomad.Token = *payload.NomadToken
+		}
+
+		if payload.NomadRegion != nil {
+			endpoint.Nomad.Region = *payload.NomadRegion
+		}
+
+		if payload.NomadNamespace != nil {
+			endpoint.Nomad.Namespace = *payload.NomadNamespace
+		}
+	}
+
 	if payload.TLS != nil {
 		folder := strconv.Itoa(endpointID)
 
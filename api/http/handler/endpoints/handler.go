@@ -2,10 +2,15 @@ package endpoints
 
 import (
 	"net/http"
+	"sync"
 
 	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/cloud"
 	"github.com/portainer/portainer/api/dataservices"
 	dockerclient "github.com/portainer/portainer/api/docker/client"
+	"github.com/portainer/portainer/api/docker/imageupdates"
+	"github.com/portainer/portainer/api/docker/orphans"
+	"github.com/portainer/portainer/api/docker/quarantine"
 	"github.com/portainer/portainer/api/http/proxy"
 	"github.com/portainer/portainer/api/http/security"
 	"github.com/portainer/portainer/api/internal/authorization"
@@ -18,6 +23,12 @@ import (
 
 func hideFields(endpoint *portainer.Endpoint) {
 	endpoint.AzureCredentials = portainer.AzureCredentials{}
+	endpoint.AgentSecret = ""
+	if endpoint.Nomad != nil {
+		nomad := *endpoint.Nomad
+		nomad.Token = ""
+		endpoint.Nomad = &nomad
+	}
 	if len(endpoint.Snapshots) > 0 {
 		endpoint.Snapshots[0].SnapshotRaw = portainer.DockerSnapshotRaw{}
 	}
@@ -39,6 +50,11 @@ type Handler struct {
 	BindAddress           string
 	BindAddressHTTPS      string
 	PendingActionsService *pendingactions.PendingActionsService
+	OrphanService         *orphans.Service
+	ImageUpdateService    *imageupdates.Service
+	QuarantineService     *quarantine.Service
+	CloudProvisioner      *cloud.Provisioner
+	registrationMu        sync.Mutex
 }
 
 // NewHandler creates a handler to manage environment(endpoint) operations.
@@ -61,6 +77,25 @@ func NewHandler(bouncer security.BouncerService) *Handler {
 	h.Handle("/endpoints/agent_versions",
 		bouncer.RestrictedAccess(httperror.LoggerHandler(h.agentVersions))).Methods(http.MethodGet)
 	h.Handle("/endpoints/relations", bouncer.RestrictedAccess(httperror.LoggerHandler(h.updateRelations))).Methods(http.MethodPut)
+	h.Handle("/endpoints/orphans",
+		bouncer.AdminAccess(httperror.LoggerHandler(h.endpointOrphansList))).Methods(http.MethodGet)
+	h.Handle("/endpoints/image_updates",
+		bouncer.AdminAccess(httperror.LoggerHandler(h.endpointImageUpdatesList))).Methods(http.MethodGet)
+	h.Handle("/endpoints/registration_tokens",
+		bouncer.AdminAccess(httperror.LoggerHandler(h.registrationTokenCreate))).Methods(http.MethodPost)
+	h.Handle("/endpoints/registration_tokens",
+		bouncer.AdminAccess(httperror.LoggerHandler(h.registrationTokenList))).Methods(http.MethodGet)
+	h.Handle("/endpoints/registration_tokens/{id}",
+		bouncer.AdminAccess(httperror.LoggerHandler(h.registrationTokenDelete))).Methods(http.MethodDelete)
+	h.Handle("/endpoints/cloud_credentials",
+		bouncer.AdminAccess(httperror.LoggerHandler(h.cloudCredentialCreate))).Methods(http.MethodPost)
+	h.Handle("/endpoints/cloud_credentials",
+		bouncer.AdminAccess(httperror.LoggerHandler(h.cloudCredentialList))).Methods(http.MethodGet)
+	h.Handle("/endpoints/cloud_credentials/{id}",
+		bouncer.AdminAccess(httperror.LoggerHandler(h.cloudCredentialDelete))).Methods(http.MethodDelete)
+	h.Handle("/endpoints/provision",
+		bouncer.AdminAccess(httperror.LoggerHandler(h.endpointProvision))).Methods(http.MethodPost)
+	h.Handle("/endpoints/register", bouncer.PublicAccess(httperror.LoggerHandler(h.endpointRegister))).Methods(http.MethodPost)
 
 	h.Handle("/endpoints/{id}",
 		bouncer.RestrictedAccess(httperror.LoggerHandler(h.endpointInspect))).Methods(http.MethodGet)
@@ -74,8 +109,30 @@ func NewHandler(bouncer security.BouncerService) *Handler {
 		bouncer.AuthenticatedAccess(httperror.LoggerHandler(h.endpointDockerhubStatus))).Methods(http.MethodGet)
 	h.Handle("/endpoints/{id}/snapshot",
 		bouncer.AdminAccess(httperror.LoggerHandler(h.endpointSnapshot))).Methods(http.MethodPost)
+	h.Handle("/endpoints/{id}/snapshots",
+		bouncer.RestrictedAccess(httperror.LoggerHandler(h.endpointSnapshotHistory))).Methods(http.MethodGet)
+	h.Handle("/endpoints/{id}/snapshots/diff",
+		bouncer.RestrictedAccess(httperror.LoggerHandler(h.endpointSnapshotDiff))).Methods(http.MethodGet)
+	h.Handle("/endpoints/{id}/orphans",
+		bouncer.AdminAccess(httperror.LoggerHandler(h.endpointOrphansInspect))).Methods(http.MethodGet)
+	h.Handle("/endpoints/{id}/orphans/cleanup",
+		bouncer.AdminAccess(httperror.LoggerHandler(h.endpointOrphansCleanup))).Methods(http.MethodPost)
+	h.Handle("/endpoints/{id}/image_updates",
+		bouncer.AdminAccess(httperror.LoggerHandler(h.endpointImageUpdatesInspect))).Methods(http.MethodGet)
+	h.Handle("/endpoints/{id}/image_updates/check",
+		bouncer.AdminAccess(httperror.LoggerHandler(h.endpointImageUpdatesCheck))).Methods(http.MethodPost)
+	h.Handle("/endpoints/{id}/quarantine",
+		bouncer.AdminAccess(httperror.LoggerHandler(h.endpointQuarantine))).Methods(http.MethodPost)
+	h.Handle("/endpoints/{id}/quarantine",
+		bouncer.AdminAccess(httperror.LoggerHandler(h.endpointQuarantineRelease))).Methods(http.MethodDelete)
+	h.Handle("/endpoints/{id}/breakglass",
+		bouncer.AdminAccess(httperror.LoggerHandler(h.endpointBreakGlass))).Methods(http.MethodPost)
+	h.Handle("/endpoints/{id}/breakglass",
+		bouncer.AdminAccess(httperror.LoggerHandler(h.endpointBreakGlassRevoke))).Methods(http.MethodDelete)
 	h.Handle("/endpoints/{id}/registries",
 		bouncer.AuthenticatedAccess(httperror.LoggerHandler(h.endpointRegistriesList))).Methods(http.MethodGet)
+	h.Handle("/endpoints/{id}/registries/sync",
+		bouncer.AdminAccess(httperror.LoggerHandler(h.endpointRegistriesSync))).Methods(http.MethodPost)
 	h.Handle("/endpoints/{id}/registries/{registryId}",
 		bouncer.AuthenticatedAccess(httperror.LoggerHandler(h.endpointRegistryAccess))).Methods(http.MethodPut)
 
@@ -0,0 +1,37 @@
+package fixtures
+
+import (
+	"net/http"
+
+	"github.com/portainer/portainer/api/dataservices"
+	"github.com/portainer/portainer/api/fixtures"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+)
+
+// @id FixturesClear
+// @summary Remove the synthetic fleet
+// @description Remove the generated environments, stacks, users and teams, whose names start with fixture-.
+// @description Only available when Portainer is started with --feat fixtures.
+// @description **Access policy**: administrator
+// @tags fixtures
+// @security ApiKeyAuth
+// @security jwt
+// @produce json
+// @success 200 {object} fixtures.Result "Success"
+// @failure 403 "The fixtures feature is not enabled"
+// @failure 500 "Server error"
+// @router /fixtures [delete]
+func (handler *Handler) fixturesClear(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	var result *fixtures.Result
+	if err := handler.DataStore.UpdateTx(func(tx dataservices.DataStoreTx) error {
+		var err error
+		result, err = fixtures.Clear(tx)
+
+		return err
+	}); err != nil {
+		return httperror.InternalServerError("Unable to remove the synthetic fleet", err)
+	}
+
+	return response.JSON(w, result)
+}
@@ -0,0 +1,89 @@
+package fixtures
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/portainer/portainer/api/dataservices"
+	"github.com/portainer/portainer/api/fixtures"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/request"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+)
+
+type fixturesSeedPayload struct {
+	// Number of Docker environments to generate
+	Endpoints int `example:"500"`
+	// Number of containers of the snapshot of each environment
+	ContainersPerEndpoint int `example:"40"`
+	// Number of compose stacks of each environment, their containers are part of the snapshot
+	StacksPerEndpoint int `example:"5"`
+	// Number of standard users to generate, each one is a member of one of the generated teams
+	Users int `example:"200"`
+	// Number of teams to generate
+	Teams int `example:"20"`
+	// Password of the generated users
+	Password string `validate:"required" example:"fixtures"`
+	// Seed of the random generation, the same seed generates the same fleet
+	Seed int64 `example:"42"`
+}
+
+func (payload *fixturesSeedPayload) Validate(r *http.Request) error {
+	if payload.Password == "" {
+		return errors.New("Invalid password")
+	}
+
+	return payload.options().Validate()
+}
+
+func (payload *fixturesSeedPayload) options() fixtures.Options {
+	return fixtures.Options{
+		Endpoints:             payload.Endpoints,
+		ContainersPerEndpoint: payload.ContainersPerEndpoint,
+		StacksPerEndpoint:     payload.StacksPerEndpoint,
+		Users:                 payload.Users,
+		Teams:                 payload.Teams,
+		Seed:                  payload.Seed,
+	}
+}
+
+// @id FixturesSeed
+// @summary Generate a synthetic fleet
+// @description Generate Docker environments with realistic snapshots, compose stacks, users and teams, for the
+// @description performance work and the development of the UI against large fleets. The environments have no URL
+// @description and are never snapshotted. The names of the generated objects start with fixture-.
+// @description Only available when Portainer is started with --feat fixtures.
+// @description **Access policy**: administrator
+// @tags fixtures
+// @security ApiKeyAuth
+// @security jwt
+// @accept json
+// @produce json
+// @param body body fixturesSeedPayload true "Number of objects to generate"
+// @success 200 {object} fixtures.Result "Success"
+// @failure 400 "Invalid request"
+// @failure 403 "The fixtures feature is not enabled"
+// @failure 500 "Server error"
+// @router /fixtures [post]
+func (handler *Handler) fixturesSeed(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	var payload fixturesSeedPayload
+	if err := request.DecodeAndValidateJSONPayload(r, &payload); err != nil {
+		return httperror.BadRequest("Invalid request payload", err)
+	}
+
+	passwordHash, err := handler.CryptoService.Hash(payload.Password)
+	if err != nil {
+		return httperror.InternalServerError("Unable to hash the password of the users", err)
+	}
+
+	var result *fixtures.Result
+	if err := handler.DataStore.UpdateTx(func(tx dataservices.DataStoreTx) error {
+		result, err = fixtures.Seed(tx, payload.options(), passwordHash)
+
+		return err
+	}); err != nil {
+		return httperror.InternalServerError("Unable to generate the synthetic fleet", err)
+	}
+
+	return response.JSON(w, result)
+}
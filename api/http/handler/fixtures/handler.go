@@ -0,0 +1,37 @@
+package fixtures
+
+import (
+	"net/http"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+	"github.com/portainer/portainer/api/fixtures"
+	"github.com/portainer/portainer/api/http/middlewares"
+	"github.com/portainer/portainer/api/http/security"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+
+	"github.com/gorilla/mux"
+)
+
+// Handler is the HTTP handler used to generate and remove the synthetic fleets of the development and load tests.
+type Handler struct {
+	*mux.Router
+	DataStore     dataservices.DataStore
+	CryptoService portainer.CryptoService
+}
+
+// NewHandler creates a handler to generate synthetic fleets, only served when the fixtures feature flag is enabled.
+func NewHandler(bouncer security.BouncerService, dataStore dataservices.DataStore, cryptoService portainer.CryptoService) *Handler {
+	h := &Handler{
+		Router:        mux.NewRouter(),
+		DataStore:     dataStore,
+		CryptoService: cryptoService,
+	}
+
+	h.Use(middlewares.FeatureFlag(dataStore.Settings(), fixtures.Feature), bouncer.AdminAccess)
+
+	h.Handle("/fixtures", httperror.LoggerHandler(h.fixturesSeed)).Methods(http.MethodPost)
+	h.Handle("/fixtures", httperror.LoggerHandler(h.fixturesClear)).Methods(http.MethodDelete)
+
+	return h
+}
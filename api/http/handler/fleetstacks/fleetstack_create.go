@@ -0,0 +1,142 @@
+package fleetstacks
+
+import (
+	"errors"
+	"net/http"
+	"slices"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+	"github.com/portainer/portainer/api/http/security"
+	"github.com/portainer/portainer/api/stacks/fleetstacks"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/request"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+)
+
+type fleetStackCreatePayload struct {
+	// Name of the stack created on each environment
+	Name string `validate:"required" example:"monitoring"`
+	fleetStackUpdatePayload
+}
+
+func (payload *fleetStackCreatePayload) Validate(r *http.Request) error {
+	if payload.Name == "" {
+		return errors.New("Invalid fleet stack name")
+	}
+
+	return payload.fleetStackUpdatePayload.Validate(r)
+}
+
+// @id FleetStackCreate
+// @summary Deploy a compose stack to several environments
+// @description Create a fleet stack, a compose stack deployed to the regular Docker environments having one of the
+// @description tags, directly or through their group, or belonging to one of the groups. A stack is created on each of
+// @description the environments, which are deployed by batches. The rollout is halted when the number of failed
+// @description deployments exceeds the failure threshold.
+// @description **Access policy**: administrator
+// @tags fleet_stacks
+// @security ApiKeyAuth
+// @security jwt
+// @accept json
+// @produce json
+// @param body body fleetStackCreatePayload true "Fleet stack details"
+// @success 200 {object} fleetStackResponse "Success"
+// @failure 400 "Invalid request"
+// @failure 409 "A stack with the same name exists on one of the environments"
+// @failure 500 "Server error"
+// @router /fleet_stacks [post]
+func (handler *Handler) fleetStackCreate(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	var payload fleetStackCreatePayload
+	if err := request.DecodeAndValidateJSONPayload(r, &payload); err != nil {
+		return httperror.BadRequest("Invalid request payload", err)
+	}
+
+	tokenData, err := security.RetrieveTokenData(r)
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve user details from authentication token", err)
+	}
+
+	fleetStack := &portainer.FleetStack{
+		Name:         handler.ComposeStackManager.NormalizeStackName(payload.Name),
+		Version:      1,
+		Environments: make(map[portainer.EndpointID]portainer.FleetStackEnvironment),
+		CreationDate: time.Now().Unix(),
+		CreatedBy:    tokenData.Username,
+	}
+	payload.apply(fleetStack)
+
+	if fleetStack.Name == "" {
+		return httperror.BadRequest("Invalid request payload", errors.New("Invalid fleet stack name"))
+	}
+
+	if err := handler.DataStore.UpdateTx(func(tx dataservices.DataStoreTx) error {
+		if err := checkUniqueName(tx, fleetStack); err != nil {
+			return err
+		}
+
+		return tx.FleetStack().Create(fleetStack)
+	}); err != nil {
+		var conflictErr *nameConflictError
+		if errors.As(err, &conflictErr) {
+			return httperror.Conflict("Unable to create the fleet stack", err)
+		}
+
+		return httperror.InternalServerError("Unable to persist the fleet stack inside the database", err)
+	}
+
+	handler.FleetStacksService.Rollout(fleetStack.ID)
+
+	return response.JSON(w, newFleetStackResponse(fleetStack))
+}
+
+type nameConflictError struct {
+	name       string
+	endpointID portainer.EndpointID
+}
+
+func (e *nameConflictError) Error() string {
+	if e.endpointID == 0 {
+		return "a fleet stack named " + e.name + " already exists"
+	}
+
+	return "a stack named " + e.name + " already exists on one of the environments of the fleet stack"
+}
+
+// checkUniqueName checks that no other fleet stack has the name of the fleet stack, and that no stack deployed on
+// its own has this name on the environments of the fleet stack
+func checkUniqueName(tx dataservices.DataStoreTx, fleetStack *portainer.FleetStack) error {
+	fleetStacks, err := tx.FleetStack().ReadAll()
+	if err != nil {
+		return err
+	}
+
+	if slices.ContainsFunc(fleetStacks, func(other portainer.FleetStack) bool {
+		return other.ID != fleetStack.ID && other.Name == fleetStack.Name
+	}) {
+		return &nameConflictError{name: fleetStack.Name}
+	}
+
+	targets, err := fleetstacks.TargetEndpoints(tx, fleetStack)
+	if err != nil {
+		return err
+	}
+
+	stacks, err := tx.Stack().StacksByName(fleetStack.Name)
+	if err != nil && !tx.IsErrObjectNotFound(err) {
+		return err
+	}
+
+	for _, stack := range stacks {
+		if stack.FleetStackID == fleetStack.ID && fleetStack.ID != 0 {
+			continue
+		}
+
+		if slices.ContainsFunc(targets, func(endpoint portainer.Endpoint) bool { return endpoint.ID == stack.EndpointID }) {
+			return &nameConflictError{name: fleetStack.Name, endpointID: stack.EndpointID}
+		}
+	}
+
+	return nil
+}
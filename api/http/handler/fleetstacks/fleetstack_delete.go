@@ -0,0 +1,50 @@
+package fleetstacks
+
+import (
+	"net/http"
+
+	"github.com/portainer/portainer/api/dataservices"
+	"github.com/portainer/portainer/api/stacks/fleetstacks"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+)
+
+// @id FleetStackDelete
+// @summary Remove a fleet stack
+// @description Stop the rollout of a fleet stack and remove it. The stacks deployed on its environments are detached
+// @description and keep running, they can be removed as any other stack.
+// @description **Access policy**: administrator
+// @tags fleet_stacks
+// @security ApiKeyAuth
+// @security jwt
+// @param id path int true "Fleet stack identifier"
+// @success 204 "Success"
+// @failure 400 "Invalid request"
+// @failure 404 "Fleet stack not found"
+// @failure 500 "Server error"
+// @router /fleet_stacks/{id} [delete]
+func (handler *Handler) fleetStackDelete(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	fleetStack, httpErr := handler.readFleetStack(r)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	handler.FleetStacksService.Stop(fleetStack.ID)
+
+	if err := handler.DataStore.UpdateTx(func(tx dataservices.DataStoreTx) error {
+		fleetStack, err := tx.FleetStack().Read(fleetStack.ID)
+		if err != nil {
+			return err
+		}
+
+		if err := fleetstacks.Detach(tx, fleetStack); err != nil {
+			return err
+		}
+
+		return tx.FleetStack().Delete(fleetStack.ID)
+	}); err != nil {
+		return httperror.InternalServerError("Unable to remove the fleet stack from the database", err)
+	}
+
+	return response.Empty(w)
+}
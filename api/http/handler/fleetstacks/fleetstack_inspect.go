@@ -0,0 +1,31 @@
+package fleetstacks
+
+import (
+	"net/http"
+
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+)
+
+// @id FleetStackInspect
+// @summary Inspect a fleet stack
+// @description Retrieve a fleet stack with the state of its deployments to each of its environments.
+// @description **Access policy**: administrator
+// @tags fleet_stacks
+// @security ApiKeyAuth
+// @security jwt
+// @produce json
+// @param id path int true "Fleet stack identifier"
+// @success 200 {object} fleetStackResponse "Success"
+// @failure 400 "Invalid request"
+// @failure 404 "Fleet stack not found"
+// @failure 500 "Server error"
+// @router /fleet_stacks/{id} [get]
+func (handler *Handler) fleetStackInspect(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	fleetStack, httpErr := handler.readFleetStack(r)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	return response.JSON(w, newFleetStackResponse(fleetStack))
+}
@@ -0,0 +1,33 @@
+package fleetstacks
+
+import (
+	"net/http"
+
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+)
+
+// @id FleetStackList
+// @summary List the fleet stacks
+// @description List the fleet stacks with the state of their deployments to each of their environments.
+// @description **Access policy**: administrator
+// @tags fleet_stacks
+// @security ApiKeyAuth
+// @security jwt
+// @produce json
+// @success 200 {array} fleetStackResponse "Success"
+// @failure 500 "Server error"
+// @router /fleet_stacks [get]
+func (handler *Handler) fleetStackList(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	fleetStacks, err := handler.DataStore.FleetStack().ReadAll()
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve the fleet stacks from the database", err)
+	}
+
+	responses := make([]fleetStackResponse, 0, len(fleetStacks))
+	for i := range fleetStacks {
+		responses = append(responses, newFleetStackResponse(&fleetStacks[i]))
+	}
+
+	return response.JSON(w, responses)
+}
@@ -0,0 +1,33 @@
+package fleetstacks
+
+import (
+	"net/http"
+
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+)
+
+// @id FleetStackRollout
+// @summary Roll a fleet stack out again
+// @description Restart the rollout of the current version of a fleet stack, to retry the failed deployments of a
+// @description halted rollout or to deploy the environments selected since the last rollout.
+// @description **Access policy**: administrator
+// @tags fleet_stacks
+// @security ApiKeyAuth
+// @security jwt
+// @param id path int true "Fleet stack identifier"
+// @success 204 "Success"
+// @failure 400 "Invalid request"
+// @failure 404 "Fleet stack not found"
+// @failure 500 "Server error"
+// @router /fleet_stacks/{id}/rollout [post]
+func (handler *Handler) fleetStackRollout(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	fleetStack, httpErr := handler.readFleetStack(r)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	handler.FleetStacksService.Rollout(fleetStack.ID)
+
+	return response.Empty(w)
+}
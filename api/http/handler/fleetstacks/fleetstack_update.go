@@ -0,0 +1,123 @@
+package fleetstacks
+
+import (
+	"errors"
+	"net/http"
+	"slices"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+	"github.com/portainer/portainer/api/http/security"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/request"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+)
+
+type fleetStackUpdatePayload struct {
+	// Content of the compose file
+	StackFileContent string `validate:"required" example:"services:\n  web:\n    image: nginx"`
+	// Environment variables used during the deployments
+	Env []portainer.Pair
+	// The environments having one of these tags, directly or through their group, are selected
+	TagIDs []portainer.TagID `example:"1"`
+	// The environments of these groups are selected
+	EndpointGroupIDs []portainer.EndpointGroupID `example:"2"`
+	// Settings of the rollout to the environments
+	Rollout portainer.FleetStackRolloutSettings
+}
+
+func (payload *fleetStackUpdatePayload) Validate(r *http.Request) error {
+	if payload.StackFileContent == "" {
+		return errors.New("Invalid stack file content")
+	}
+
+	if len(payload.TagIDs) == 0 && len(payload.EndpointGroupIDs) == 0 {
+		return errors.New("The environments must be selected by at least one tag or group")
+	}
+
+	if payload.Rollout.BatchSize < 0 || payload.Rollout.BatchInterval < 0 || payload.Rollout.FailureThreshold < 0 {
+		return errors.New("Invalid rollout settings")
+	}
+
+	return nil
+}
+
+// apply sets the content, the selection of the environments and the rollout settings of the fleet stack
+func (payload *fleetStackUpdatePayload) apply(fleetStack *portainer.FleetStack) {
+	fleetStack.StackFileContent = payload.StackFileContent
+	fleetStack.Env = payload.Env
+	fleetStack.TagIDs = payload.TagIDs
+	fleetStack.EndpointGroupIDs = payload.EndpointGroupIDs
+	fleetStack.Rollout = payload.Rollout
+}
+
+// @id FleetStackUpdate
+// @summary Update a fleet stack
+// @description Update the content, the selection of the environments and the rollout settings of a fleet stack, then
+// @description roll it out. A change of the content or of the environment variables is a new version, deployed to
+// @description all the environments, the other environments are only deployed when they do not run the current version.
+// @description The stacks of the environments that are no longer selected are detached from the fleet stack and keep running.
+// @description **Access policy**: administrator
+// @tags fleet_stacks
+// @security ApiKeyAuth
+// @security jwt
+// @accept json
+// @produce json
+// @param id path int true "Fleet stack identifier"
+// @param body body fleetStackUpdatePayload true "Fleet stack details"
+// @success 200 {object} fleetStackResponse "Success"
+// @failure 400 "Invalid request"
+// @failure 404 "Fleet stack not found"
+// @failure 409 "A stack with the same name exists on one of the environments"
+// @failure 500 "Server error"
+// @router /fleet_stacks/{id} [put]
+func (handler *Handler) fleetStackUpdate(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	var payload fleetStackUpdatePayload
+	if err := request.DecodeAndValidateJSONPayload(r, &payload); err != nil {
+		return httperror.BadRequest("Invalid request payload", err)
+	}
+
+	fleetStack, httpErr := handler.readFleetStack(r)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	tokenData, err := security.RetrieveTokenData(r)
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve user details from authentication token", err)
+	}
+
+	if err := handler.DataStore.UpdateTx(func(tx dataservices.DataStoreTx) error {
+		// the environments may have been updated by the rollout in progress
+		fleetStack, err = tx.FleetStack().Read(fleetStack.ID)
+		if err != nil {
+			return err
+		}
+
+		if fleetStack.StackFileContent != payload.StackFileContent || !slices.Equal(fleetStack.Env, payload.Env) {
+			fleetStack.Version++
+		}
+
+		payload.apply(fleetStack)
+		fleetStack.UpdateDate = time.Now().Unix()
+		fleetStack.UpdatedBy = tokenData.Username
+
+		if err := checkUniqueName(tx, fleetStack); err != nil {
+			return err
+		}
+
+		return tx.FleetStack().Update(fleetStack.ID, fleetStack)
+	}); err != nil {
+		var conflictErr *nameConflictError
+		if errors.As(err, &conflictErr) {
+			return httperror.Conflict("Unable to update the fleet stack", err)
+		}
+
+		return httperror.InternalServerError("Unable to persist the fleet stack changes inside the database", err)
+	}
+
+	handler.FleetStacksService.Rollout(fleetStack.ID)
+
+	return response.JSON(w, newFleetStackResponse(fleetStack))
+}
@@ -0,0 +1,72 @@
+package fleetstacks
+
+import (
+	"net/http"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+	"github.com/portainer/portainer/api/http/security"
+	"github.com/portainer/portainer/api/stacks/fleetstacks"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/request"
+
+	"github.com/gorilla/mux"
+)
+
+// Handler is the HTTP handler used to manage the stacks deployed to several environments.
+type Handler struct {
+	*mux.Router
+	DataStore           dataservices.DataStore
+	FleetStacksService  *fleetstacks.Service
+	ComposeStackManager portainer.ComposeStackManager
+}
+
+// NewHandler creates a handler to manage the fleet stacks.
+func NewHandler(bouncer security.BouncerService, dataStore dataservices.DataStore, fleetStacksService *fleetstacks.Service) *Handler {
+	h := &Handler{
+		Router:             mux.NewRouter(),
+		DataStore:          dataStore,
+		FleetStacksService: fleetStacksService,
+	}
+
+	h.Handle("/fleet_stacks",
+		bouncer.AdminAccess(httperror.LoggerHandler(h.fleetStackCreate))).Methods(http.MethodPost)
+	h.Handle("/fleet_stacks",
+		bouncer.AdminAccess(httperror.LoggerHandler(h.fleetStackList))).Methods(http.MethodGet)
+	h.Handle("/fleet_stacks/{id}",
+		bouncer.AdminAccess(httperror.LoggerHandler(h.fleetStackInspect))).Methods(http.MethodGet)
+	h.Handle("/fleet_stacks/{id}",
+		bouncer.AdminAccess(httperror.LoggerHandler(h.fleetStackUpdate))).Methods(http.MethodPut)
+	h.Handle("/fleet_stacks/{id}",
+		bouncer.AdminAccess(httperror.LoggerHandler(h.fleetStackDelete))).Methods(http.MethodDelete)
+	h.Handle("/fleet_stacks/{id}/rollout",
+		bouncer.AdminAccess(httperror.LoggerHandler(h.fleetStackRollout))).Methods(http.MethodPost)
+
+	return h
+}
+
+// fleetStackResponse is a fleet stack with the aggregated state of its deployments
+type fleetStackResponse struct {
+	*portainer.FleetStack
+	Health fleetstacks.Health `json:"Health"`
+}
+
+func newFleetStackResponse(fleetStack *portainer.FleetStack) fleetStackResponse {
+	return fleetStackResponse{FleetStack: fleetStack, Health: fleetstacks.NewHealth(fleetStack)}
+}
+
+func (handler *Handler) readFleetStack(r *http.Request) (*portainer.FleetStack, *httperror.HandlerError) {
+	id, err := request.RetrieveNumericRouteVariableValue(r, "id")
+	if err != nil {
+		return nil, httperror.BadRequest("Invalid fleet stack identifier route variable", err)
+	}
+
+	fleetStack, err := handler.DataStore.FleetStack().Read(portainer.FleetStackID(id))
+	if handler.DataStore.IsErrObjectNotFound(err) {
+		return nil, httperror.NotFound("Unable to find a fleet stack with the specified identifier inside the database", err)
+	} else if err != nil {
+		return nil, httperror.InternalServerError("Unable to find a fleet stack with the specified identifier inside the database", err)
+	}
+
+	return fleetStack, nil
+}
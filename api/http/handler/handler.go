@@ -4,9 +4,13 @@ import (
 	"net/http"
 	"strings"
 
+	"github.com/portainer/portainer/api/http/handler/auditlogs"
 	"github.com/portainer/portainer/api/http/handler/auth"
 	"github.com/portainer/portainer/api/http/handler/backup"
+	"github.com/portainer/portainer/api/http/handler/chaos"
+	"github.com/portainer/portainer/api/http/handler/containerjobs"
 	"github.com/portainer/portainer/api/http/handler/customtemplates"
+	"github.com/portainer/portainer/api/http/handler/dashboards"
 	"github.com/portainer/portainer/api/http/handler/docker"
 	"github.com/portainer/portainer/api/http/handler/edgegroups"
 	"github.com/portainer/portainer/api/http/handler/edgejobs"
@@ -17,15 +21,21 @@ import (
 	"github.com/portainer/portainer/api/http/handler/endpointproxy"
 	"github.com/portainer/portainer/api/http/handler/endpoints"
 	"github.com/portainer/portainer/api/http/handler/file"
+	"github.com/portainer/portainer/api/http/handler/fixtures"
+	"github.com/portainer/portainer/api/http/handler/fleetstacks"
 	"github.com/portainer/portainer/api/http/handler/gitops"
 	"github.com/portainer/portainer/api/http/handler/helm"
 	"github.com/portainer/portainer/api/http/handler/hostmanagement/openamt"
 	"github.com/portainer/portainer/api/http/handler/kubernetes"
 	"github.com/portainer/portainer/api/http/handler/ldap"
 	"github.com/portainer/portainer/api/http/handler/motd"
+	"github.com/portainer/portainer/api/http/handler/plugins"
+	"github.com/portainer/portainer/api/http/handler/prunepolicies"
 	"github.com/portainer/portainer/api/http/handler/registries"
+	"github.com/portainer/portainer/api/http/handler/reports"
 	"github.com/portainer/portainer/api/http/handler/resourcecontrols"
 	"github.com/portainer/portainer/api/http/handler/roles"
+	"github.com/portainer/portainer/api/http/handler/sessionrecordings"
 	"github.com/portainer/portainer/api/http/handler/settings"
 	"github.com/portainer/portainer/api/http/handler/ssl"
 	"github.com/portainer/portainer/api/http/handler/stacks"
@@ -43,43 +53,53 @@ import (
 
 // Handler is a collection of all the service handlers.
 type Handler struct {
-	AuthHandler            *auth.Handler
-	BackupHandler          *backup.Handler
-	CustomTemplatesHandler *customtemplates.Handler
-	DockerHandler          *docker.Handler
-	EdgeGroupsHandler      *edgegroups.Handler
-	EdgeJobsHandler        *edgejobs.Handler
-	EdgeStacksHandler      *edgestacks.Handler
-	EdgeTemplatesHandler   *edgetemplates.Handler
-	EndpointEdgeHandler    *endpointedge.Handler
-	EndpointGroupHandler   *endpointgroups.Handler
-	EndpointHandler        *endpoints.Handler
-	EndpointHelmHandler    *helm.Handler
-	EndpointProxyHandler   *endpointproxy.Handler
-	GitOperationHandler    *gitops.Handler
-	HelmTemplatesHandler   *helm.Handler
-	KubernetesHandler      *kubernetes.Handler
-	FileHandler            *file.Handler
-	LDAPHandler            *ldap.Handler
-	MOTDHandler            *motd.Handler
-	RegistryHandler        *registries.Handler
-	ResourceControlHandler *resourcecontrols.Handler
-	RoleHandler            *roles.Handler
-	SettingsHandler        *settings.Handler
-	SSLHandler             *ssl.Handler
-	OpenAMTHandler         *openamt.Handler
-	StackHandler           *stacks.Handler
-	StorybookHandler       *storybook.Handler
-	SystemHandler          *system.Handler
-	TagHandler             *tags.Handler
-	TeamMembershipHandler  *teammemberships.Handler
-	TeamHandler            *teams.Handler
-	TemplatesHandler       *templates.Handler
-	UploadHandler          *upload.Handler
-	UserHandler            *users.Handler
-	WebSocketHandler       *websocket.Handler
-	WebhookHandler         *webhooks.Handler
-	UserHelmHandler        *helm.Handler
+	AuditLogHandler          *auditlogs.Handler
+	AuthHandler              *auth.Handler
+	BackupHandler            *backup.Handler
+	ChaosHandler             *chaos.Handler
+	ContainerJobsHandler     *containerjobs.Handler
+	CustomTemplatesHandler   *customtemplates.Handler
+	DashboardHandler         *dashboards.Handler
+	DockerHandler            *docker.Handler
+	EdgeGroupsHandler        *edgegroups.Handler
+	EdgeJobsHandler          *edgejobs.Handler
+	EdgeStacksHandler        *edgestacks.Handler
+	EdgeTemplatesHandler     *edgetemplates.Handler
+	EndpointEdgeHandler      *endpointedge.Handler
+	EndpointGroupHandler     *endpointgroups.Handler
+	EndpointHandler          *endpoints.Handler
+	EndpointHelmHandler      *helm.Handler
+	EndpointProxyHandler     *endpointproxy.Handler
+	GitOperationHandler      *gitops.Handler
+	HelmTemplatesHandler     *helm.Handler
+	KubernetesHandler        *kubernetes.Handler
+	FileHandler              *file.Handler
+	FixturesHandler          *fixtures.Handler
+	FleetStacksHandler       *fleetstacks.Handler
+	LDAPHandler              *ldap.Handler
+	MOTDHandler              *motd.Handler
+	PluginHandler            *plugins.Handler
+	PrunePoliciesHandler     *prunepolicies.Handler
+	RegistryHandler          *registries.Handler
+	ReportHandler            *reports.Handler
+	ResourceControlHandler   *resourcecontrols.Handler
+	RoleHandler              *roles.Handler
+	SessionRecordingsHandler *sessionrecordings.Handler
+	SettingsHandler          *settings.Handler
+	SSLHandler               *ssl.Handler
+	OpenAMTHandler           *openamt.Handler
+	StackHandler             *stacks.Handler
+	StorybookHandler         *storybook.Handler
+	SystemHandler            *system.Handler
+	TagHandler               *tags.Handler
+	TeamMembershipHandler    *teammemberships.Handler
+	TeamHandler              *teams.Handler
+	TemplatesHandler         *templates.Handler
+	UploadHandler            *upload.Handler
+	UserHandler              *users.Handler
+	WebSocketHandler         *websocket.Handler
+	WebhookHandler           *webhooks.Handler
+	UserHelmHandler          *helm.Handler
 }
 
 // @title PortainerCE API
@@ -108,6 +128,8 @@ type Handler struct {
 // @tag.description Authenticate against Portainer HTTP API
 // @tag.name backup
 // @tag.description Manage backups
+// @tag.name container_jobs
+// @tag.description Manage the containers run on a schedule on the Docker environments(endpoints)
 // @tag.name custom_templates
 // @tag.description Manage Custom Templates
 // @tag.name docker
@@ -138,12 +160,16 @@ type Handler struct {
 // @tag.description Manage LDAP settings
 // @tag.name motd
 // @tag.description Fetch the message of the day
+// @tag.name prune_policies
+// @tag.description Manage the scheduled removal of the unused resources of the Docker environments(endpoints)
 // @tag.name registries
 // @tag.description Manage Docker registries
 // @tag.name resource_controls
 // @tag.description Manage access control on Docker resources
 // @tag.name roles
 // @tag.description Manage roles
+// @tag.name session_recordings
+// @tag.description Audit and replay the recordings of the console sessions
 // @tag.name settings
 // @tag.description Manage Portainer settings
 // @tag.name ssl
@@ -176,14 +202,22 @@ func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	switch {
 	case strings.HasPrefix(r.URL.Path, "/api/endpoints") && strings.Contains(r.URL.Path, "/edge/"):
 		h.EndpointEdgeHandler.ServeHTTP(w, r)
+	case strings.HasPrefix(r.URL.Path, "/api/audit_logs"):
+		http.StripPrefix("/api", h.AuditLogHandler).ServeHTTP(w, r)
 	case strings.HasPrefix(r.URL.Path, "/api/auth"):
 		http.StripPrefix("/api", h.AuthHandler).ServeHTTP(w, r)
+	case r.URL.Path == "/.well-known/jwks.json":
+		h.AuthHandler.ServeHTTP(w, r)
 	case strings.HasPrefix(r.URL.Path, "/api/backup"):
 		http.StripPrefix("/api", h.BackupHandler).ServeHTTP(w, r)
 	case strings.HasPrefix(r.URL.Path, "/api/restore"):
 		http.StripPrefix("/api", h.BackupHandler).ServeHTTP(w, r)
+	case strings.HasPrefix(r.URL.Path, "/api/container_jobs"):
+		http.StripPrefix("/api", h.ContainerJobsHandler).ServeHTTP(w, r)
 	case strings.HasPrefix(r.URL.Path, "/api/custom_templates"):
 		http.StripPrefix("/api", h.CustomTemplatesHandler).ServeHTTP(w, r)
+	case strings.HasPrefix(r.URL.Path, "/api/dashboards"):
+		http.StripPrefix("/api", h.DashboardHandler).ServeHTTP(w, r)
 	case strings.HasPrefix(r.URL.Path, "/api/edge_stacks"):
 		http.StripPrefix("/api", h.EdgeStacksHandler).ServeHTTP(w, r)
 	case strings.HasPrefix(r.URL.Path, "/api/edge_groups"):
@@ -211,23 +245,39 @@ func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 			http.StripPrefix("/api/endpoints", h.EndpointProxyHandler).ServeHTTP(w, r)
 		case strings.Contains(r.URL.Path, "/azure/"):
 			http.StripPrefix("/api/endpoints", h.EndpointProxyHandler).ServeHTTP(w, r)
+		case strings.Contains(r.URL.Path, "/nomad/"):
+			http.StripPrefix("/api/endpoints", h.EndpointProxyHandler).ServeHTTP(w, r)
 		case strings.Contains(r.URL.Path, "/agent/"):
 			http.StripPrefix("/api/endpoints", h.EndpointProxyHandler).ServeHTTP(w, r)
 		default:
 			http.StripPrefix("/api", h.EndpointHandler).ServeHTTP(w, r)
 		}
+	case strings.HasPrefix(r.URL.Path, "/api/chaos"):
+		http.StripPrefix("/api", h.ChaosHandler).ServeHTTP(w, r)
+	case strings.HasPrefix(r.URL.Path, "/api/fixtures"):
+		http.StripPrefix("/api", h.FixturesHandler).ServeHTTP(w, r)
+	case strings.HasPrefix(r.URL.Path, "/api/fleet_stacks"):
+		http.StripPrefix("/api", h.FleetStacksHandler).ServeHTTP(w, r)
 	case strings.HasPrefix(r.URL.Path, "/api/gitops"):
 		http.StripPrefix("/api", h.GitOperationHandler).ServeHTTP(w, r)
 	case strings.HasPrefix(r.URL.Path, "/api/ldap"):
 		http.StripPrefix("/api", h.LDAPHandler).ServeHTTP(w, r)
 	case strings.HasPrefix(r.URL.Path, "/api/motd"):
 		http.StripPrefix("/api", h.MOTDHandler).ServeHTTP(w, r)
+	case strings.HasPrefix(r.URL.Path, "/api/plugins"):
+		http.StripPrefix("/api", h.PluginHandler).ServeHTTP(w, r)
+	case strings.HasPrefix(r.URL.Path, "/api/prune_policies"):
+		http.StripPrefix("/api", h.PrunePoliciesHandler).ServeHTTP(w, r)
 	case strings.HasPrefix(r.URL.Path, "/api/registries"):
 		http.StripPrefix("/api", h.RegistryHandler).ServeHTTP(w, r)
+	case strings.HasPrefix(r.URL.Path, "/api/reports"):
+		http.StripPrefix("/api", h.ReportHandler).ServeHTTP(w, r)
 	case strings.HasPrefix(r.URL.Path, "/api/resource_controls"):
 		http.StripPrefix("/api", h.ResourceControlHandler).ServeHTTP(w, r)
 	case strings.HasPrefix(r.URL.Path, "/api/roles"):
 		http.StripPrefix("/api", h.RoleHandler).ServeHTTP(w, r)
+	case strings.HasPrefix(r.URL.Path, "/api/session_recordings"):
+		http.StripPrefix("/api", h.SessionRecordingsHandler).ServeHTTP(w, r)
 	case strings.HasPrefix(r.URL.Path, "/api/settings"):
 		http.StripPrefix("/api", h.SettingsHandler).ServeHTTP(w, r)
 	case strings.HasPrefix(r.URL.Path, "/api/stacks"):
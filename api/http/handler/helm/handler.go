@@ -5,6 +5,7 @@ import (
 
 	portainer "github.com/portainer/portainer/api"
 	"github.com/portainer/portainer/api/dataservices"
+	"github.com/portainer/portainer/api/helmrepositories"
 	"github.com/portainer/portainer/api/http/middlewares"
 	"github.com/portainer/portainer/api/http/security"
 	"github.com/portainer/portainer/api/kubernetes"
@@ -24,10 +25,11 @@ type Handler struct {
 	kubeClusterAccessService kubernetes.KubeClusterAccessService
 	kubernetesDeployer       portainer.KubernetesDeployer
 	helmPackageManager       libhelm.HelmPackageManager
+	repositoryService        *helmrepositories.Service
 }
 
 // NewHandler creates a handler to manage endpoint group operations.
-func NewHandler(bouncer security.BouncerService, dataStore dataservices.DataStore, jwtService portainer.JWTService, kubernetesDeployer portainer.KubernetesDeployer, helmPackageManager libhelm.HelmPackageManager, kubeClusterAccessService kubernetes.KubeClusterAccessService) *Handler {
+func NewHandler(bouncer security.BouncerService, dataStore dataservices.DataStore, jwtService portainer.JWTService, kubernetesDeployer portainer.KubernetesDeployer, helmPackageManager libhelm.HelmPackageManager, kubeClusterAccessService kubernetes.KubeClusterAccessService, repositoryService *helmrepositories.Service) *Handler {
 	h := &Handler{
 		Router:                   mux.NewRouter(),
 		requestBouncer:           bouncer,
@@ -36,6 +38,7 @@ func NewHandler(bouncer security.BouncerService, dataStore dataservices.DataStor
 		kubernetesDeployer:       kubernetesDeployer,
 		helmPackageManager:       helmPackageManager,
 		kubeClusterAccessService: kubeClusterAccessService,
+		repositoryService:        repositoryService,
 	}
 
 	h.Use(middlewares.WithEndpoint(dataStore.Endpoint(), "id"),
@@ -53,6 +56,22 @@ func NewHandler(bouncer security.BouncerService, dataStore dataservices.DataStor
 	h.Handle("/{id}/kubernetes/helm",
 		httperror.LoggerHandler(h.helmInstall)).Methods(http.MethodPost)
 
+	// `helm upgrade RELEASE_NAME [CHART] flags`
+	h.Handle("/{id}/kubernetes/helm/{release}",
+		httperror.LoggerHandler(h.helmUpgrade)).Methods(http.MethodPut)
+
+	// `helm history RELEASE_NAME -o json`
+	h.Handle("/{id}/kubernetes/helm/{release}/history",
+		httperror.LoggerHandler(h.helmHistory)).Methods(http.MethodGet)
+
+	// `helm get values RELEASE_NAME -o yaml`
+	h.Handle("/{id}/kubernetes/helm/{release}/values",
+		httperror.LoggerHandler(h.helmValues)).Methods(http.MethodGet)
+
+	// `helm rollback RELEASE_NAME [REVISION]`
+	h.Handle("/{id}/kubernetes/helm/{release}/rollback",
+		httperror.LoggerHandler(h.helmRollback)).Methods(http.MethodPost)
+
 	// Deprecated
 	h.Handle("/{id}/kubernetes/helm/repositories",
 		httperror.LoggerHandler(h.userGetHelmRepos)).Methods(http.MethodGet)
@@ -63,11 +82,12 @@ func NewHandler(bouncer security.BouncerService, dataStore dataservices.DataStor
 }
 
 // NewTemplateHandler creates a template handler to manage environment(endpoint) group operations.
-func NewTemplateHandler(bouncer security.BouncerService, helmPackageManager libhelm.HelmPackageManager) *Handler {
+func NewTemplateHandler(bouncer security.BouncerService, helmPackageManager libhelm.HelmPackageManager, repositoryService *helmrepositories.Service) *Handler {
 	h := &Handler{
 		Router:             mux.NewRouter(),
 		helmPackageManager: helmPackageManager,
 		requestBouncer:     bouncer,
+		repositoryService:  repositoryService,
 	}
 
 	h.Use(bouncer.AuthenticatedAccess)
@@ -118,3 +138,19 @@ func (handler *Handler) getHelmClusterAccess(r *http.Request) (*options.Kubernet
 		AuthToken:                bearerToken,
 	}, nil
 }
+
+// repositoryAuth returns the authentication of the helm commands to a repository of the user or of its teams, the
+// returned function removing the files of its certificates
+func (handler *Handler) repositoryAuth(r *http.Request, repoURL string) (*options.RepositoryAuthentication, func(), error) {
+	tokenData, err := security.RetrieveTokenData(r)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	repository, err := handler.repositoryService.Repository(tokenData.ID, repoURL)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return helmrepositories.AuthOptions(&repository)
+}
@@ -0,0 +1,60 @@
+package helm
+
+import (
+	"net/http"
+
+	"github.com/portainer/portainer/pkg/libhelm/options"
+	_ "github.com/portainer/portainer/pkg/libhelm/release"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/request"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+)
+
+// @id HelmHistory
+// @summary List the revisions of a Helm Release
+// @description
+// @description **Access policy**: authenticated
+// @tags helm
+// @security ApiKeyAuth
+// @security jwt
+// @produce json
+// @param id path int true "Environment(Endpoint) identifier"
+// @param release path string true "The name of the release/application"
+// @param namespace query string false "An optional namespace"
+// @param max query int false "Maximum number of revisions returned, the latest ones"
+// @success 200 {array} release.ReleaseHistoryElement "Success"
+// @failure 400 "Invalid environment(endpoint) id or bad request"
+// @failure 401 "Unauthorized"
+// @failure 404 "Environment(Endpoint) or ServiceAccount not found"
+// @failure 500 "Server error or helm error"
+// @router /endpoints/{id}/kubernetes/helm/{release}/history [get]
+func (handler *Handler) helmHistory(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	release, err := request.RetrieveRouteVariableValue(r, "release")
+	if err != nil {
+		return httperror.BadRequest("No release specified", err)
+	}
+
+	max, err := request.RetrieveNumericQueryParameter(r, "max", true)
+	if err != nil || max < 0 {
+		return httperror.BadRequest("Invalid query parameter: max", err)
+	}
+
+	clusterAccess, httperr := handler.getHelmClusterAccess(r)
+	if httperr != nil {
+		return httperr
+	}
+
+	namespace, _ := request.RetrieveQueryParameter(r, "namespace", true)
+
+	history, err := handler.helmPackageManager.History(options.HistoryOptions{
+		Name:                    release,
+		Namespace:               namespace,
+		Max:                     max,
+		KubernetesClusterAccess: clusterAccess,
+	})
+	if err != nil {
+		return httperror.InternalServerError("Helm returned an error", err)
+	}
+
+	return response.JSON(w, history)
+}
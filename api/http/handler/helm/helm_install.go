@@ -110,23 +110,22 @@ func (handler *Handler) installChart(r *http.Request, p installChartPayload) (*r
 		},
 	}
 
-	if p.Values != "" {
-		file, err := os.CreateTemp("", "helm-values")
-		if err != nil {
-			return nil, err
-		}
-		defer os.Remove(file.Name())
+	repoAuth, cleanup, err := handler.repositoryAuth(r, p.Repo)
+	if err != nil {
+		return nil, err
+	}
+	defer cleanup()
 
-		if _, err := file.WriteString(p.Values); err != nil {
-			file.Close()
-			return nil, err
-		}
+	installOpts.RepoAuth = repoAuth
 
-		if err := file.Close(); err != nil {
+	if p.Values != "" {
+		valuesFile, err := createValuesFile(p.Values)
+		if err != nil {
 			return nil, err
 		}
+		defer os.Remove(valuesFile)
 
-		installOpts.ValuesFile = file.Name()
+		installOpts.ValuesFile = valuesFile
 	}
 
 	release, err := handler.helmPackageManager.Install(installOpts)
@@ -134,22 +133,49 @@ func (handler *Handler) installChart(r *http.Request, p installChartPayload) (*r
 		return nil, err
 	}
 
-	manifest, err := handler.applyPortainerLabelsToHelmAppManifest(r, installOpts, release.Manifest)
-	if err != nil {
+	if err := handler.labelHelmAppManifest(r, installOpts.Name, installOpts.Namespace, release.Manifest); err != nil {
 		return nil, err
 	}
 
-	if err := handler.updateHelmAppManifest(r, manifest, installOpts.Namespace); err != nil {
-		return nil, err
+	return release, nil
+}
+
+// createValuesFile writes the values of a release to a temporary file passed to the helm binary
+func createValuesFile(values string) (string, error) {
+	file, err := os.CreateTemp("", "helm-values")
+	if err != nil {
+		return "", err
 	}
 
-	return release, nil
+	if _, err := file.WriteString(values); err != nil {
+		file.Close()
+		os.Remove(file.Name())
+		return "", err
+	}
+
+	if err := file.Close(); err != nil {
+		os.Remove(file.Name())
+		return "", err
+	}
+
+	return file.Name(), nil
+}
+
+// labelHelmAppManifest applies the portainer labels to the resources of the manifest of a release, every install,
+// upgrade or rollback of the release deploying its resources without them
+func (handler *Handler) labelHelmAppManifest(r *http.Request, releaseName, namespace, manifest string) error {
+	labeledManifest, err := handler.applyPortainerLabelsToHelmAppManifest(r, releaseName, manifest)
+	if err != nil {
+		return err
+	}
+
+	return handler.updateHelmAppManifest(r, labeledManifest, namespace)
 }
 
 // applyPortainerLabelsToHelmAppManifest will patch all the resources deployed in the helm release manifest
 // with portainer specific labels. This is to mark the resources as managed by portainer - hence the helm apps
 // wont appear external in the portainer UI.
-func (handler *Handler) applyPortainerLabelsToHelmAppManifest(r *http.Request, installOpts options.InstallOptions, manifest string) ([]byte, error) {
+func (handler *Handler) applyPortainerLabelsToHelmAppManifest(r *http.Request, releaseName string, manifest string) ([]byte, error) {
 	// Patch helm release by adding with portainer labels to all deployed resources
 	tokenData, err := security.RetrieveTokenData(r)
 	if err != nil {
@@ -161,7 +187,7 @@ func (handler *Handler) applyPortainerLabelsToHelmAppManifest(r *http.Request, i
 		return nil, errors.Wrap(err, "unable to load user information from the database")
 	}
 
-	appLabels := kubernetes.GetHelmAppLabels(installOpts.Name, user.Username)
+	appLabels := kubernetes.GetHelmAppLabels(releaseName, user.Username)
 
 	labeledManifest, err := kubernetes.AddAppLabels([]byte(manifest), appLabels)
 	if err != nil {
@@ -174,7 +200,6 @@ func (handler *Handler) applyPortainerLabelsToHelmAppManifest(r *http.Request, i
 // updateHelmAppManifest will update the resources of helm release manifest with portainer labels using kubectl.
 // The resources of the manifest will be updated in parallel and individuallly since resources of a chart
 // can be deployed to different namespaces.
-// NOTE: These updates will need to be re-applied when upgrading the helm release
 func (handler *Handler) updateHelmAppManifest(r *http.Request, manifest []byte, namespace string) error {
 	endpoint, err := middlewares.FetchEndpoint(r)
 	if err != nil {
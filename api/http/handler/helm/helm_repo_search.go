@@ -5,18 +5,24 @@ import (
 	"net/http"
 	"net/url"
 
-	"github.com/portainer/portainer/pkg/libhelm/options"
+	"github.com/portainer/portainer/api/http/security"
+	"github.com/portainer/portainer/pkg/libhelm/binary"
 	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/request"
 
 	"github.com/pkg/errors"
+	"github.com/segmentio/encoding/json"
 )
 
 // @id HelmRepoSearch
 // @summary Search Helm Charts
-// @description
+// @description List the charts of a Helm repository, or the merged charts of the global repository and of the
+// @description repositories of the user and of its teams when no repository is specified, each chart version having the
+// @description URL of its repository. The indexes of the repositories are cached and refreshed every hour.
 // @description **Access policy**: authenticated
 // @tags helm
-// @param repo query string true "Helm repository URL"
+// @param repo query string false "Helm repository URL"
+// @param refresh query boolean false "Download the indexes of the repositories instead of using the cached ones"
 // @security ApiKeyAuth
 // @security jwt
 // @produce json
@@ -27,21 +33,41 @@ import (
 // @failure 500 "Server error"
 // @router /templates/helm [get]
 func (handler *Handler) helmRepoSearch(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
-	repo := r.URL.Query().Get("repo")
-	if repo == "" {
-		return httperror.BadRequest("Bad request", errors.New("missing `repo` query parameter"))
+	refresh, err := request.RetrieveBooleanQueryParameter(r, "refresh", true)
+	if err != nil {
+		return httperror.BadRequest("Invalid query parameter: refresh", err)
 	}
 
-	_, err := url.ParseRequestURI(repo)
+	tokenData, err := security.RetrieveTokenData(r)
 	if err != nil {
-		return httperror.BadRequest("Bad request", errors.Wrap(err, fmt.Sprintf("provided URL %q is not valid", repo)))
+		return httperror.InternalServerError("Unable to retrieve user authentication token", err)
 	}
 
-	searchOpts := options.SearchRepoOptions{
-		Repo: repo,
+	var index *binary.File
+
+	repo := r.URL.Query().Get("repo")
+	if repo == "" {
+		index, err = handler.repositoryService.Charts(tokenData.ID, refresh)
+		if err != nil {
+			return httperror.InternalServerError("Unable to retrieve the Helm repositories", err)
+		}
+	} else {
+		if _, err := url.ParseRequestURI(repo); err != nil {
+			return httperror.BadRequest("Bad request", errors.Wrap(err, fmt.Sprintf("provided URL %q is not valid", repo)))
+		}
+
+		repository, err := handler.repositoryService.Repository(tokenData.ID, repo)
+		if err != nil {
+			return httperror.InternalServerError("Unable to retrieve the Helm repositories", err)
+		}
+
+		index, err = handler.repositoryService.Index(repository, refresh)
+		if err != nil {
+			return httperror.InternalServerError("Search failed", err)
+		}
 	}
 
-	result, err := handler.helmPackageManager.SearchRepo(searchOpts)
+	result, err := json.Marshal(index)
 	if err != nil {
 		return httperror.InternalServerError("Search failed", err)
 	}
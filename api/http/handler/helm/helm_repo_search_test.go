@@ -7,28 +7,61 @@ import (
 	"net/url"
 	"testing"
 
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/datastore"
+	"github.com/portainer/portainer/api/helmrepositories"
+	"github.com/portainer/portainer/api/http/security"
 	helper "github.com/portainer/portainer/api/internal/testhelpers"
+	"github.com/portainer/portainer/pkg/libhelm/binary"
 	"github.com/portainer/portainer/pkg/libhelm/binary/test"
+
+	"github.com/segmentio/encoding/json"
 	"github.com/stretchr/testify/assert"
 )
 
 func Test_helmRepoSearch(t *testing.T) {
 	is := assert.New(t)
 
+	_, store := datastore.MustNewTestStore(t, true, true)
+
+	err := store.User().Create(&portainer.User{Username: "standard", Role: portainer.StandardUserRole})
+	is.NoError(err, "error creating a user")
+
+	err = store.Team().Create(&portainer.Team{ID: 1, Name: "team"})
+	is.NoError(err, "error creating a team")
+
+	err = store.TeamMembership().Create(&portainer.TeamMembership{UserID: 1, TeamID: 1, Role: portainer.TeamMember})
+	is.NoError(err, "error creating a team membership")
+
+	err = store.HelmUserRepository().Create(&portainer.HelmUserRepository{UserID: 1, URL: "https://user.example.com/charts"})
+	is.NoError(err, "error creating a user repository")
+
+	err = store.HelmUserRepository().Create(&portainer.HelmUserRepository{TeamID: 1, URL: "https://team.example.com/charts"})
+	is.NoError(err, "error creating a team repository")
+
+	err = store.HelmUserRepository().Create(&portainer.HelmUserRepository{TeamID: 2, URL: "https://other.example.com/charts"})
+	is.NoError(err, "error creating a team repository")
+
 	helmPackageManager := test.NewMockHelmBinaryPackageManager("")
-	h := NewTemplateHandler(helper.NewTestRequestBouncer(), helmPackageManager)
+	h := NewTemplateHandler(helper.NewTestRequestBouncer(), helmPackageManager, helmrepositories.NewService(store, helmPackageManager))
 
 	assert.NotNil(t, h, "Handler should not fail")
 
+	search := func(query string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/templates/helm"+query, nil)
+		req = req.WithContext(security.StoreTokenData(req, &portainer.TokenData{ID: 1, Username: "standard", Role: portainer.StandardUserRole}))
+
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+
+		return rr
+	}
+
 	repos := []string{"https://charts.bitnami.com/bitnami", "https://portainer.github.io/k8s"}
 
 	for _, repo := range repos {
 		t.Run(repo, func(t *testing.T) {
-			repoUrlEncoded := url.QueryEscape(repo)
-			req := httptest.NewRequest(http.MethodGet, "/templates/helm?repo="+repoUrlEncoded, nil)
-			rr := httptest.NewRecorder()
-			h.ServeHTTP(rr, req)
-
+			rr := search("?repo=" + url.QueryEscape(repo))
 			is.Equal(http.StatusOK, rr.Code, "Status should be 200 OK")
 
 			body, err := io.ReadAll(rr.Body)
@@ -37,13 +70,27 @@ func Test_helmRepoSearch(t *testing.T) {
 		})
 	}
 
-	t.Run("fails on invalid URL", func(t *testing.T) {
-		repo := "abc.com"
-		repoUrlEncoded := url.QueryEscape(repo)
-		req := httptest.NewRequest(http.MethodGet, "/templates/helm?repo="+repoUrlEncoded, nil)
-		rr := httptest.NewRecorder()
-		h.ServeHTTP(rr, req)
+	t.Run("merges the charts of the repositories of the user", func(t *testing.T) {
+		settings, err := store.Settings().Settings()
+		is.NoError(err)
 
+		rr := search("?refresh=true")
+		is.Equal(http.StatusOK, rr.Code, "Status should be 200 OK")
+
+		var index binary.File
+		err = json.NewDecoder(rr.Body).Decode(&index)
+		is.NoError(err, "response should be json")
+
+		repositories := []string{}
+		for _, entry := range index.Entries["portainer"] {
+			repositories = append(repositories, entry.Repo)
+		}
+
+		is.Equal([]string{settings.HelmRepositoryURL, "https://user.example.com/charts", "https://team.example.com/charts"}, repositories)
+	})
+
+	t.Run("fails on invalid URL", func(t *testing.T) {
+		rr := search("?repo=" + url.QueryEscape("abc.com"))
 		is.Equal(http.StatusBadRequest, rr.Code, "Status should be 400 Bad request")
 	})
 }
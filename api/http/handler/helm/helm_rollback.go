@@ -0,0 +1,87 @@
+package helm
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/portainer/portainer/pkg/libhelm/options"
+	_ "github.com/portainer/portainer/pkg/libhelm/release"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/request"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+)
+
+// @id HelmRollback
+// @summary Rollback Helm Release
+// @description Roll a release back to a previous revision, creating a new revision with its chart and values.
+// @description **Access policy**: authenticated
+// @tags helm
+// @security ApiKeyAuth
+// @security jwt
+// @produce json
+// @param id path int true "Environment(Endpoint) identifier"
+// @param release path string true "The name of the release/application to roll back"
+// @param namespace query string false "An optional namespace"
+// @param revision query int false "Revision to roll back to, the previous one by default"
+// @success 200 {object} release.ReleaseHistoryElement "The revision created by the rollback"
+// @failure 400 "Invalid environment(endpoint) id or bad request"
+// @failure 401 "Unauthorized"
+// @failure 404 "Environment(Endpoint) or ServiceAccount not found"
+// @failure 500 "Server error or helm error"
+// @router /endpoints/{id}/kubernetes/helm/{release}/rollback [post]
+func (handler *Handler) helmRollback(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	releaseName, err := request.RetrieveRouteVariableValue(r, "release")
+	if err != nil {
+		return httperror.BadRequest("No release specified", err)
+	}
+
+	revision, err := request.RetrieveNumericQueryParameter(r, "revision", true)
+	if err != nil || revision < 0 {
+		return httperror.BadRequest("Invalid query parameter: revision", err)
+	}
+
+	clusterAccess, httperr := handler.getHelmClusterAccess(r)
+	if httperr != nil {
+		return httperr
+	}
+
+	namespace, _ := request.RetrieveQueryParameter(r, "namespace", true)
+
+	if err := handler.helmPackageManager.Rollback(options.RollbackOptions{
+		Name:                    releaseName,
+		Namespace:               namespace,
+		Revision:                revision,
+		KubernetesClusterAccess: clusterAccess,
+	}); err != nil {
+		return httperror.InternalServerError("Helm returned an error", err)
+	}
+
+	// the resources of the revision rolled back to are deployed again without the portainer labels
+	manifest, err := handler.helmPackageManager.Get(options.GetOptions{
+		Name:                    releaseName,
+		Namespace:               namespace,
+		ReleaseResource:         options.GetManifest,
+		KubernetesClusterAccess: clusterAccess,
+	})
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve the manifest of the release", err)
+	}
+
+	if err := handler.labelHelmAppManifest(r, releaseName, namespace, string(manifest)); err != nil {
+		return httperror.InternalServerError("Unable to label the resources of the release", err)
+	}
+
+	history, err := handler.helmPackageManager.History(options.HistoryOptions{
+		Name:                    releaseName,
+		Namespace:               namespace,
+		Max:                     1,
+		KubernetesClusterAccess: clusterAccess,
+	})
+	if err != nil {
+		return httperror.InternalServerError("Helm returned an error", err)
+	} else if len(history) == 0 {
+		return httperror.InternalServerError("Helm returned an error", errors.New("the release has no revision"))
+	}
+
+	return response.JSON(w, history[0])
+}
@@ -0,0 +1,109 @@
+package helm
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/datastore"
+	"github.com/portainer/portainer/api/exec/exectest"
+	"github.com/portainer/portainer/api/helmrepositories"
+	"github.com/portainer/portainer/api/http/security"
+	"github.com/portainer/portainer/api/internal/testhelpers"
+	helper "github.com/portainer/portainer/api/internal/testhelpers"
+	"github.com/portainer/portainer/api/jwt"
+	"github.com/portainer/portainer/api/kubernetes"
+	"github.com/portainer/portainer/pkg/libhelm/binary/test"
+	"github.com/portainer/portainer/pkg/libhelm/options"
+	"github.com/portainer/portainer/pkg/libhelm/release"
+
+	"github.com/segmentio/encoding/json"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_helmRollback(t *testing.T) {
+	is := assert.New(t)
+
+	_, store := datastore.MustNewTestStore(t, true, true)
+
+	err := store.Endpoint().Create(&portainer.Endpoint{ID: 1})
+	is.NoError(err, "error creating environment")
+
+	err = store.User().Create(&portainer.User{Username: "admin", Role: portainer.AdministratorRole})
+	is.NoError(err, "error creating a user")
+
+	jwtService, err := jwt.NewService("1h", store)
+	is.NoError(err, "Error initiating jwt service")
+
+	kubernetesDeployer := exectest.NewKubernetesDeployer()
+	helmPackageManager := test.NewMockHelmBinaryPackageManager("")
+	kubeClusterAccessService := kubernetes.NewKubeClusterAccessService("", "", "")
+	h := NewHandler(helper.NewTestRequestBouncer(), store, jwtService, kubernetesDeployer, helmPackageManager, kubeClusterAccessService, helmrepositories.NewService(store, helmPackageManager))
+
+	is.NotNil(h, "Handler should not fail")
+
+	// Install and upgrade a single chart directly, to be rolled back by the handler
+	_, err = h.helmPackageManager.Install(options.InstallOptions{Name: "nginx-rollback", Chart: "nginx", Namespace: "default"})
+	is.NoError(err)
+	_, err = h.helmPackageManager.Upgrade(options.UpgradeOptions{Name: "nginx-rollback", Chart: "nginx", Namespace: "default"})
+	is.NoError(err)
+
+	serve := func(method, url string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, url, nil)
+		ctx := security.StoreTokenData(req, &portainer.TokenData{ID: 1, Username: "admin", Role: 1})
+		req = req.WithContext(ctx)
+		testhelpers.AddTestSecurityCookie(req, "Bearer dummytoken")
+
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+
+		return rr
+	}
+
+	t.Run("helmRollback creates a new revision", func(t *testing.T) {
+		rr := serve(http.MethodPost, "/1/kubernetes/helm/nginx-rollback/rollback?namespace=default&revision=1")
+		is.Equal(http.StatusOK, rr.Code, "Status should be 200")
+
+		body, err := io.ReadAll(rr.Body)
+		is.NoError(err, "ReadAll should not return error")
+
+		resp := release.ReleaseHistoryElement{}
+		err = json.Unmarshal(body, &resp)
+		is.NoError(err, "response should be json")
+		is.Equal(3, resp.Revision)
+		is.Equal("deployed", resp.Status)
+	})
+
+	t.Run("helmRollback fails with an invalid revision", func(t *testing.T) {
+		rr := serve(http.MethodPost, "/1/kubernetes/helm/nginx-rollback/rollback?namespace=default&revision=-1")
+		is.Equal(http.StatusBadRequest, rr.Code, "Status should be 400")
+
+		rr = serve(http.MethodPost, "/1/kubernetes/helm/nginx-rollback/rollback?namespace=default&revision=3")
+		is.Equal(http.StatusInternalServerError, rr.Code, "Status should be 500")
+	})
+
+	t.Run("helmHistory lists the revisions", func(t *testing.T) {
+		rr := serve(http.MethodGet, "/1/kubernetes/helm/nginx-rollback/history?namespace=default&max=2")
+		is.Equal(http.StatusOK, rr.Code, "Status should be 200")
+
+		resp := []release.ReleaseHistoryElement{}
+		err := json.NewDecoder(rr.Body).Decode(&resp)
+		is.NoError(err, "response should be json")
+		is.Len(resp, 2)
+		is.Equal(2, resp[0].Revision)
+		is.Equal("superseded", resp[0].Status)
+		is.Equal(3, resp[1].Revision)
+	})
+
+	t.Run("helmValues returns the values of the release", func(t *testing.T) {
+		rr := serve(http.MethodGet, "/1/kubernetes/helm/nginx-rollback/values?namespace=default&revision=1")
+		is.Equal(http.StatusOK, rr.Code, "Status should be 200")
+
+		resp := releaseValuesResponse{}
+		err := json.NewDecoder(rr.Body).Decode(&resp)
+		is.NoError(err, "response should be json")
+		is.Equal(test.MockReleaseValues, resp.Values)
+	})
+}
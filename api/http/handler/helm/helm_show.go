@@ -51,10 +51,17 @@ func (handler *Handler) helmShow(w http.ResponseWriter, r *http.Request) *httper
 		log.Debug().Str("default_command", cmd).Msg("command not provided, using default")
 	}
 
+	repoAuth, cleanup, err := handler.repositoryAuth(r, repo)
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve the Helm repository", err)
+	}
+	defer cleanup()
+
 	showOptions := options.ShowOptions{
 		OutputFormat: options.ShowOutputFormat(cmd),
 		Chart:        chart,
 		Repo:         repo,
+		RepoAuth:     repoAuth,
 	}
 	result, err := handler.helmPackageManager.Show(showOptions)
 	if err != nil {
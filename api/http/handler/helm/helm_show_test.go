@@ -8,6 +8,10 @@ import (
 	"net/url"
 	"testing"
 
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/datastore"
+	"github.com/portainer/portainer/api/helmrepositories"
+	"github.com/portainer/portainer/api/http/security"
 	helper "github.com/portainer/portainer/api/internal/testhelpers"
 	"github.com/portainer/portainer/pkg/libhelm/binary/test"
 	"github.com/stretchr/testify/assert"
@@ -16,8 +20,10 @@ import (
 func Test_helmShow(t *testing.T) {
 	is := assert.New(t)
 
+	_, store := datastore.MustNewTestStore(t, true, true)
+
 	helmPackageManager := test.NewMockHelmBinaryPackageManager("")
-	h := NewTemplateHandler(helper.NewTestRequestBouncer(), helmPackageManager)
+	h := NewTemplateHandler(helper.NewTestRequestBouncer(), helmPackageManager, helmrepositories.NewService(store, helmPackageManager))
 
 	is.NotNil(h, "Handler should not fail")
 
@@ -34,6 +40,7 @@ func Test_helmShow(t *testing.T) {
 			repoUrlEncoded := url.QueryEscape("https://charts.bitnami.com/bitnami")
 			chart := "nginx"
 			req := httptest.NewRequest("GET", fmt.Sprintf("/templates/helm/%s?repo=%s&chart=%s", cmd, repoUrlEncoded, chart), nil)
+			req = req.WithContext(security.StoreTokenData(req, &portainer.TokenData{ID: 1, Username: "admin", Role: 1}))
 			rr := httptest.NewRecorder()
 			h.ServeHTTP(rr, req)
 
@@ -0,0 +1,129 @@
+package helm
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/portainer/portainer/pkg/libhelm/options"
+	"github.com/portainer/portainer/pkg/libhelm/release"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/request"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+)
+
+type upgradeChartPayload struct {
+	Namespace string `json:"namespace"`
+	Chart     string `json:"chart"`
+	Repo      string `json:"repo"`
+	// Version of the chart, the latest one when empty
+	Version string `json:"version"`
+	Values  string `json:"values"`
+	// ReuseValues merges the values with the ones of the current revision instead of the defaults of the chart
+	ReuseValues bool `json:"reuseValues"`
+}
+
+// @id HelmUpgrade
+// @summary Upgrade Helm Release
+// @description Upgrade a release to a version of its chart or to new values, creating a new revision.
+// @description **Access policy**: authenticated
+// @tags helm
+// @security ApiKeyAuth
+// @security jwt
+// @accept json
+// @produce json
+// @param id path int true "Environment(Endpoint) identifier"
+// @param release path string true "The name of the release/application to upgrade"
+// @param payload body upgradeChartPayload true "Chart details"
+// @success 200 {object} release.Release "Success"
+// @failure 400 "Invalid environment(endpoint) id or bad request"
+// @failure 401 "Unauthorized"
+// @failure 404 "Environment(Endpoint) or ServiceAccount not found"
+// @failure 500 "Server error or helm error"
+// @router /endpoints/{id}/kubernetes/helm/{release} [put]
+func (handler *Handler) helmUpgrade(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	releaseName, err := request.RetrieveRouteVariableValue(r, "release")
+	if err != nil {
+		return httperror.BadRequest("No release specified", err)
+	}
+
+	var payload upgradeChartPayload
+	if err := request.DecodeAndValidateJSONPayload(r, &payload); err != nil {
+		return httperror.BadRequest("Invalid Helm upgrade payload", err)
+	}
+
+	release, err := handler.upgradeChart(r, releaseName, payload)
+	if err != nil {
+		return httperror.InternalServerError("Unable to upgrade the release", err)
+	}
+
+	return response.JSON(w, release)
+}
+
+func (p *upgradeChartPayload) Validate(_ *http.Request) error {
+	var required []string
+	if p.Repo == "" {
+		required = append(required, "repo")
+	}
+
+	if p.Namespace == "" {
+		required = append(required, "namespace")
+	}
+
+	if p.Chart == "" {
+		required = append(required, "chart")
+	}
+
+	if len(required) > 0 {
+		return fmt.Errorf("required field(s) missing: %s", strings.Join(required, ", "))
+	}
+
+	return nil
+}
+
+func (handler *Handler) upgradeChart(r *http.Request, releaseName string, p upgradeChartPayload) (*release.Release, error) {
+	clusterAccess, httperr := handler.getHelmClusterAccess(r)
+	if httperr != nil {
+		return nil, httperr.Err
+	}
+
+	upgradeOpts := options.UpgradeOptions{
+		Name:                    releaseName,
+		Chart:                   p.Chart,
+		Namespace:               p.Namespace,
+		Repo:                    p.Repo,
+		Version:                 p.Version,
+		ReuseValues:             p.ReuseValues,
+		KubernetesClusterAccess: clusterAccess,
+	}
+
+	repoAuth, cleanup, err := handler.repositoryAuth(r, p.Repo)
+	if err != nil {
+		return nil, err
+	}
+	defer cleanup()
+
+	upgradeOpts.RepoAuth = repoAuth
+
+	if p.Values != "" {
+		valuesFile, err := createValuesFile(p.Values)
+		if err != nil {
+			return nil, err
+		}
+		defer os.Remove(valuesFile)
+
+		upgradeOpts.ValuesFile = valuesFile
+	}
+
+	release, err := handler.helmPackageManager.Upgrade(upgradeOpts)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := handler.labelHelmAppManifest(r, upgradeOpts.Name, upgradeOpts.Namespace, release.Manifest); err != nil {
+		return nil, err
+	}
+
+	return release, nil
+}
@@ -0,0 +1,90 @@
+package helm
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/datastore"
+	"github.com/portainer/portainer/api/exec/exectest"
+	"github.com/portainer/portainer/api/helmrepositories"
+	"github.com/portainer/portainer/api/http/security"
+	"github.com/portainer/portainer/api/internal/testhelpers"
+	helper "github.com/portainer/portainer/api/internal/testhelpers"
+	"github.com/portainer/portainer/api/jwt"
+	"github.com/portainer/portainer/api/kubernetes"
+	"github.com/portainer/portainer/pkg/libhelm/binary/test"
+	"github.com/portainer/portainer/pkg/libhelm/options"
+	"github.com/portainer/portainer/pkg/libhelm/release"
+
+	"github.com/segmentio/encoding/json"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_helmUpgrade(t *testing.T) {
+	is := assert.New(t)
+
+	_, store := datastore.MustNewTestStore(t, true, true)
+
+	err := store.Endpoint().Create(&portainer.Endpoint{ID: 1})
+	is.NoError(err, "error creating environment")
+
+	err = store.User().Create(&portainer.User{Username: "admin", Role: portainer.AdministratorRole})
+	is.NoError(err, "error creating a user")
+
+	jwtService, err := jwt.NewService("1h", store)
+	is.NoError(err, "Error initiating jwt service")
+
+	kubernetesDeployer := exectest.NewKubernetesDeployer()
+	helmPackageManager := test.NewMockHelmBinaryPackageManager("")
+	kubeClusterAccessService := kubernetes.NewKubeClusterAccessService("", "", "")
+	h := NewHandler(helper.NewTestRequestBouncer(), store, jwtService, kubernetesDeployer, helmPackageManager, kubeClusterAccessService, helmrepositories.NewService(store, helmPackageManager))
+
+	is.NotNil(h, "Handler should not fail")
+
+	// Install a single chart directly, to be upgraded by the handler
+	_, err = h.helmPackageManager.Install(options.InstallOptions{Name: "nginx-upgrade", Chart: "nginx", Namespace: "default"})
+	is.NoError(err)
+
+	upgrade := func(payload upgradeChartPayload, releaseName string) *httptest.ResponseRecorder {
+		data, err := json.Marshal(payload)
+		is.NoError(err)
+
+		req := httptest.NewRequest(http.MethodPut, "/1/kubernetes/helm/"+releaseName, bytes.NewBuffer(data))
+		ctx := security.StoreTokenData(req, &portainer.TokenData{ID: 1, Username: "admin", Role: 1})
+		req = req.WithContext(ctx)
+		testhelpers.AddTestSecurityCookie(req, "Bearer dummytoken")
+
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+
+		return rr
+	}
+
+	t.Run("helmUpgrade creates a new revision", func(t *testing.T) {
+		rr := upgrade(upgradeChartPayload{Namespace: "default", Chart: "nginx", Repo: "https://charts.bitnami.com/bitnami", Values: "replicaCount: 2"}, "nginx-upgrade")
+		is.Equal(http.StatusOK, rr.Code, "Status should be 200")
+
+		body, err := io.ReadAll(rr.Body)
+		is.NoError(err, "ReadAll should not return error")
+
+		resp := release.Release{}
+		err = json.Unmarshal(body, &resp)
+		is.NoError(err, "response should be json")
+		is.Equal("nginx-upgrade", resp.Name)
+		is.Equal(2, resp.Version)
+	})
+
+	t.Run("helmUpgrade fails without a chart", func(t *testing.T) {
+		rr := upgrade(upgradeChartPayload{Namespace: "default", Repo: "https://charts.bitnami.com/bitnami"}, "nginx-upgrade")
+		is.Equal(http.StatusBadRequest, rr.Code, "Status should be 400")
+	})
+
+	t.Run("helmUpgrade fails for an unknown release", func(t *testing.T) {
+		rr := upgrade(upgradeChartPayload{Namespace: "default", Chart: "nginx", Repo: "https://charts.bitnami.com/bitnami"}, "unknown")
+		is.Equal(http.StatusInternalServerError, rr.Code, "Status should be 500")
+	})
+}
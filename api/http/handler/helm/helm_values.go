@@ -0,0 +1,73 @@
+package helm
+
+import (
+	"net/http"
+
+	"github.com/portainer/portainer/pkg/libhelm/options"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/request"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+)
+
+type releaseValuesResponse struct {
+	// Values of the release, in YAML
+	Values string `json:"values" example:"replicaCount: 2"`
+}
+
+// @id HelmValues
+// @summary Get the values of a Helm Release
+// @description Return the values supplied when installing or upgrading a release, or all the computed values including
+// @description the defaults of its chart.
+// @description **Access policy**: authenticated
+// @tags helm
+// @security ApiKeyAuth
+// @security jwt
+// @produce json
+// @param id path int true "Environment(Endpoint) identifier"
+// @param release path string true "The name of the release/application"
+// @param namespace query string false "An optional namespace"
+// @param revision query int false "Revision of the release, the latest one by default"
+// @param all query boolean false "Return all the computed values instead of the supplied ones"
+// @success 200 {object} releaseValuesResponse "Success"
+// @failure 400 "Invalid environment(endpoint) id or bad request"
+// @failure 401 "Unauthorized"
+// @failure 404 "Environment(Endpoint) or ServiceAccount not found"
+// @failure 500 "Server error or helm error"
+// @router /endpoints/{id}/kubernetes/helm/{release}/values [get]
+func (handler *Handler) helmValues(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	release, err := request.RetrieveRouteVariableValue(r, "release")
+	if err != nil {
+		return httperror.BadRequest("No release specified", err)
+	}
+
+	revision, err := request.RetrieveNumericQueryParameter(r, "revision", true)
+	if err != nil || revision < 0 {
+		return httperror.BadRequest("Invalid query parameter: revision", err)
+	}
+
+	all, err := request.RetrieveBooleanQueryParameter(r, "all", true)
+	if err != nil {
+		return httperror.BadRequest("Invalid query parameter: all", err)
+	}
+
+	clusterAccess, httperr := handler.getHelmClusterAccess(r)
+	if httperr != nil {
+		return httperr
+	}
+
+	namespace, _ := request.RetrieveQueryParameter(r, "namespace", true)
+
+	values, err := handler.helmPackageManager.Get(options.GetOptions{
+		Name:                    release,
+		Namespace:               namespace,
+		ReleaseResource:         options.GetValues,
+		Revision:                revision,
+		AllValues:               all,
+		KubernetesClusterAccess: clusterAccess,
+	})
+	if err != nil {
+		return httperror.InternalServerError("Helm returned an error", err)
+	}
+
+	return response.JSON(w, releaseValuesResponse{Values: string(values)})
+}
@@ -5,6 +5,7 @@ import (
 	"strings"
 
 	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/helmrepositories"
 	"github.com/portainer/portainer/api/http/security"
 	"github.com/portainer/portainer/pkg/libhelm"
 	httperror "github.com/portainer/portainer/pkg/libhttp/error"
@@ -118,6 +119,10 @@ func (handler *Handler) userGetHelmRepos(w http.ResponseWriter, r *http.Request)
 		return httperror.InternalServerError("Unable to get user Helm repositories", err)
 	}
 
+	for i := range userRepos {
+		helmrepositories.HideSecrets(&userRepos[i])
+	}
+
 	resp := helmUserRepositoryResponse{
 		GlobalRepository: settings.HelmRepositoryURL,
 		UserRepositories: userRepos,
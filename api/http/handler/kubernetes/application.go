@@ -70,6 +70,7 @@ func (handler *Handler) getApplicationsResources(w http.ResponseWriter, r *http.
 // @param namespace query string true "Namespace name"
 // @param nodeName query string true "Node name"
 // @param withDependencies query boolean false "Include dependencies in the response"
+// @param withWarningEvents query boolean false "Include the warning events of the last hour about the applications and their pods in the response"
 // @success 200 {array} models.K8sApplication "Success"
 // @failure 400 "Invalid request payload, such as missing required fields or fields not meeting validation criteria."
 // @failure 401 "Unauthorized access - the user is not authenticated or does not have the necessary permissions. Ensure that you have provided a valid API key or JWT token, and that you have the required permissions."
@@ -129,6 +130,12 @@ func (handler *Handler) getAllKubernetesApplications(r *http.Request) ([]models.
 		return nil, httperror.BadRequest("Unable to parse the nodeName query parameter", err)
 	}
 
+	withWarningEvents, err := request.RetrieveBooleanQueryParameter(r, "withWarningEvents", true)
+	if err != nil {
+		log.Error().Err(err).Str("context", "getAllKubernetesApplications").Msg("Unable to parse the withWarningEvents query parameter")
+		return nil, httperror.BadRequest("Unable to parse the withWarningEvents query parameter", err)
+	}
+
 	cli, httpErr := handler.prepareKubeClient(r)
 	if httpErr != nil {
 		log.Error().Err(httpErr).Str("context", "getAllKubernetesApplications").Str("namespace", namespace).Str("nodeName", nodeName).Msg("Unable to get a Kubernetes client for the user")
@@ -146,5 +153,12 @@ func (handler *Handler) getAllKubernetesApplications(r *http.Request) ([]models.
 		return nil, httperror.InternalServerError("Unable to get the list of applications", err)
 	}
 
+	if withWarningEvents {
+		if err := cli.AddApplicationWarningEvents(applications); err != nil {
+			log.Error().Err(err).Str("context", "getAllKubernetesApplications").Str("namespace", namespace).Msg("Unable to get the warning events of the applications")
+			return nil, httperror.InternalServerError("Unable to get the warning events of the applications", err)
+		}
+	}
+
 	return applications, nil
 }
@@ -0,0 +1,223 @@
+package kubernetes
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	models "github.com/portainer/portainer/api/http/models/kubernetes"
+	"github.com/portainer/portainer/api/kubernetes/cli"
+	"github.com/portainer/portainer/api/ws"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/request"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+
+	"github.com/gorilla/websocket"
+	"github.com/rs/zerolog/log"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+const (
+	defaultApplicationLogsTail = 100
+	// maxApplicationLogsBatchSize is the maximum number of lines sent in a single websocket message
+	maxApplicationLogsBatchSize = 200
+)
+
+var logsUpgrader = websocket.Upgrader{}
+
+// @id GetKubernetesApplicationLogs
+// @summary Get the logs of the pods of an application
+// @description Get the logs of all the pods of a Deployment, a StatefulSet, a DaemonSet or of a single pod, interleaved by
+// @description timestamp and labelled with the pod each line comes from.
+// @description When the request is a websocket upgrade, the logs are followed: the last lines are sent, followed by the
+// @description new ones until the pods stop or the connection is closed. Each message is a JSON array of lines.
+// @description The logs of the previous instance of the containers are never followed.
+// @description **Access policy**: Authenticated user, with access to the namespace.
+// @tags kubernetes
+// @security ApiKeyAuth || jwt
+// @produce json
+// @param id path int true "Environment identifier"
+// @param namespace path string true "The namespace name of the application"
+// @param kind path string true "The kind of the application" Enums(Deployment, StatefulSet, DaemonSet, Pod)
+// @param name path string true "The name of the application"
+// @param container query string false "Container of the pods, their first container by default"
+// @param previous query boolean false "Read the logs of the previous instance of the containers, the pods without one being skipped"
+// @param tail query int false "Number of lines read from the end of the logs of every pod, 100 by default and -1 for all of them"
+// @param since query int false "Only read the lines written after this Unix timestamp"
+// @success 200 {array} models.K8sLogLine "Success"
+// @failure 400 "Invalid request, such as an unsupported kind of application."
+// @failure 401 "Unauthorized access - the user is not authenticated or does not have the necessary permissions. Ensure that you have provided a valid API key or JWT token, and that you have the required permissions."
+// @failure 403 "Permission denied - the user is authenticated but does not have the necessary permissions to access the requested resource or perform the specified operation. Check your user roles and permissions."
+// @failure 404 "Unable to find an environment with the specified identifier or the application."
+// @failure 500 "Server error occurred while attempting to retrieve the logs."
+// @router /kubernetes/{id}/namespaces/{namespace}/applications/{kind}/{name}/logs [get]
+func (handler *Handler) getKubernetesApplicationLogs(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	namespace, err := request.RetrieveRouteVariableValue(r, "namespace")
+	if err != nil {
+		return httperror.BadRequest("Invalid namespace identifier route variable", err)
+	}
+
+	kind, err := request.RetrieveRouteVariableValue(r, "kind")
+	if err != nil {
+		return httperror.BadRequest("Invalid kind route variable", err)
+	}
+
+	name, err := request.RetrieveRouteVariableValue(r, "name")
+	if err != nil {
+		return httperror.BadRequest("Invalid name route variable", err)
+	}
+
+	options, err := parseApplicationLogsOptions(r)
+	if err != nil {
+		return httperror.BadRequest("Invalid query parameter", err)
+	}
+
+	cli, httpErr := handler.prepareKubeClient(r)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	// the pods are looked for before upgrading the connection so that the errors are returned as HTTP errors
+	if _, err := cli.GetWorkloadPods(r.Context(), namespace, kind, name); err != nil {
+		return applicationLogsError(err)
+	}
+
+	if websocket.IsWebSocketUpgrade(r) {
+		return handler.followKubernetesApplicationLogs(w, r, cli, namespace, kind, name, options)
+	}
+
+	lines, err := cli.GetWorkloadLogs(r.Context(), namespace, kind, name, options)
+	if err != nil {
+		return applicationLogsError(err)
+	}
+
+	return response.JSON(w, lines)
+}
+
+func (handler *Handler) followKubernetesApplicationLogs(w http.ResponseWriter, r *http.Request, kcl *cli.KubeClient, namespace, kind, name string, options cli.WorkloadLogsOptions) *httperror.HandlerError {
+	r.Header.Del("Origin")
+
+	websocketConn, err := logsUpgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return httperror.InternalServerError("An error occurred during websocket upgrade", err)
+	}
+	defer websocketConn.Close()
+
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+
+	// the messages of the client are only read to notice when the connection is closed
+	go func() {
+		for {
+			if _, _, err := websocketConn.ReadMessage(); err != nil {
+				cancel()
+
+				return
+			}
+		}
+	}()
+
+	batches := make(chan []models.K8sLogLine)
+	followErr := make(chan error, 1)
+
+	go func() {
+		defer close(batches)
+
+		followErr <- kcl.FollowWorkloadLogs(ctx, namespace, kind, name, options, func(lines []models.K8sLogLine) error {
+			for len(lines) > 0 {
+				size := min(len(lines), maxApplicationLogsBatchSize)
+
+				select {
+				case batches <- lines[:size]:
+					lines = lines[size:]
+				case <-ctx.Done():
+					return ctx.Err()
+				}
+			}
+
+			return nil
+		})
+	}()
+
+	pingTicker := time.NewTicker(ws.PingPeriod)
+	defer pingTicker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+
+		case batch, ok := <-batches:
+			if !ok {
+				if err := <-followErr; err != nil && ctx.Err() == nil {
+					log.Debug().Err(err).Str("context", "followKubernetesApplicationLogs").Msg("Unable to follow the logs of the application")
+					closeLogsWebsocket(websocketConn, websocket.CloseInternalServerErr, "unable to read the logs of the application")
+
+					return nil
+				}
+
+				closeLogsWebsocket(websocketConn, websocket.CloseNormalClosure, "the pods stopped")
+
+				return nil
+			}
+
+			websocketConn.SetWriteDeadline(time.Now().Add(ws.WriteWait))
+			if err := websocketConn.WriteJSON(batch); err != nil {
+				return nil
+			}
+
+		case <-pingTicker.C:
+			if err := websocketConn.WriteControl(websocket.PingMessage, nil, time.Now().Add(ws.WriteWait)); err != nil {
+				return nil
+			}
+		}
+	}
+}
+
+func parseApplicationLogsOptions(r *http.Request) (cli.WorkloadLogsOptions, error) {
+	options := cli.WorkloadLogsOptions{TailLines: defaultApplicationLogsTail}
+	options.Container, _ = request.RetrieveQueryParameter(r, "container", true)
+	options.Previous, _ = request.RetrieveBooleanQueryParameter(r, "previous", true)
+
+	if r.FormValue("tail") != "" {
+		tail, err := request.RetrieveNumericQueryParameter(r, "tail", true)
+		if err != nil {
+			return options, err
+		} else if tail < -1 {
+			return options, errors.New("the number of lines must be -1 or positive")
+		}
+
+		options.TailLines = int64(tail)
+	}
+
+	since, err := request.RetrieveNumericQueryParameter(r, "since", true)
+	if err != nil {
+		return options, err
+	}
+
+	if since != 0 {
+		options.SinceTime = time.Unix(int64(since), 0)
+	}
+
+	return options, nil
+}
+
+func applicationLogsError(err error) *httperror.HandlerError {
+	switch {
+	case errors.Is(err, cli.ErrUnsupportedWorkloadKind):
+		return httperror.BadRequest("Unsupported kind of application", err)
+	case k8serrors.IsNotFound(err):
+		return httperror.NotFound("Unable to find the application", err)
+	case k8serrors.IsUnauthorized(err) || k8serrors.IsForbidden(err):
+		log.Error().Err(err).Str("context", "getKubernetesApplicationLogs").Msg("Unauthorized access to the Kubernetes API")
+		return httperror.Forbidden("Unauthorized access to the Kubernetes API", err)
+	}
+
+	log.Error().Err(err).Str("context", "getKubernetesApplicationLogs").Msg("Unable to retrieve the logs of the application")
+	return httperror.InternalServerError("Unable to retrieve the logs of the application", err)
+}
+
+func closeLogsWebsocket(websocketConn *websocket.Conn, code int, reason string) {
+	websocketConn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(code, reason), time.Now().Add(ws.WriteWait))
+}
@@ -1,9 +1,12 @@
 package kubernetes
 
 import (
+	"cmp"
 	"errors"
 	"fmt"
 	"net/http"
+	"net/url"
+	"time"
 
 	portainer "github.com/portainer/portainer/api"
 	"github.com/portainer/portainer/api/http/security"
@@ -14,18 +17,44 @@ import (
 	"github.com/portainer/portainer/pkg/libhttp/response"
 	"github.com/rs/zerolog/log"
 
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clientauthenticationv1 "k8s.io/client-go/pkg/apis/clientauthentication/v1"
 	clientV1 "k8s.io/client-go/tools/clientcmd/api/v1"
 )
 
+const (
+	kubeconfigModeToken = "token"
+	kubeconfigModeExec  = "exec"
+	// defaultExecCredentialTTL is the lifetime of the tokens issued to the exec credential plugin, kubectl requesting
+	// a new one once it expired
+	defaultExecCredentialTTL = time.Hour
+	minKubeconfigTTL         = 5 * time.Minute
+	maxKubeconfigTTL         = 30 * 24 * time.Hour
+	execCredentialAPIVersion = "client.authentication.k8s.io/v1"
+	// apiKeyEnvironmentVariable is the variable the exec credential plugin reads the API key of the user from, so
+	// that the kubeconfig file holds no secret
+	apiKeyEnvironmentVariable = "PORTAINER_API_KEY"
+)
+
+// errImpersonationNotAllowed prevents the impersonation tokens from being turned into kubeconfig tokens that outlive
+// the impersonation and are not recorded as sessions
+var errImpersonationNotAllowed = errors.New("This operation is not allowed while impersonating a user")
+
 // @id GetKubernetesConfig
 // @summary Generate a kubeconfig file
-// @description Generate a kubeconfig file that allows a client to communicate with the Kubernetes API server
+// @description Generate a kubeconfig file that allows a client to communicate with the Kubernetes API server.
+// @description In the token mode, the kubeconfig holds a token expiring after the ttl, or after the kubeconfig expiry
+// @description of the settings when no ttl is given. In the exec mode, the kubeconfig holds no token: kubectl requests
+// @description a short-lived one from the Portainer API with the API key set in the PORTAINER_API_KEY environment
+// @description variable, and renews it when it expires.
 // @description **Access policy**: Authenticated user.
 // @tags kubernetes
 // @security ApiKeyAuth || jwt
 // @produce application/json, application/yaml
 // @param ids query []int false "will include only these environments(endpoints)"
 // @param excludeIds query []int false "will exclude these environments(endpoints)"
+// @param mode query string false "How kubectl authenticates, token by default" Enums(token, exec)
+// @param ttl query string false "Lifetime of the tokens, such as 1h, between 5m and 720h. 1h by default in the exec mode"
 // @success 200 {object} interface{} "Success"
 // @failure 400 "Invalid request payload, such as missing required fields or fields not meeting validation criteria."
 // @failure 401 "Unauthorized access - the user is not authenticated or does not have the necessary permissions. Ensure that you have provided a valid API key or JWT token, and that you have the required permissions."
@@ -40,10 +69,34 @@ func (handler *Handler) getKubernetesConfig(w http.ResponseWriter, r *http.Reque
 		return httperror.Forbidden("Permission denied to access environment", err)
 	}
 
-	bearerToken, err := handler.JwtService.GenerateTokenForKubeconfig(tokenData)
+	if tokenData.ImpersonatorID != 0 {
+		return httperror.Forbidden(errImpersonationNotAllowed.Error(), errImpersonationNotAllowed)
+	}
+
+	mode, _ := request.RetrieveQueryParameter(r, "mode", true)
+	if mode == "" {
+		mode = kubeconfigModeToken
+	} else if mode != kubeconfigModeToken && mode != kubeconfigModeExec {
+		return httperror.BadRequest("Invalid query parameter: mode", errors.New("the mode must be token or exec"))
+	}
+
+	ttl, err := parseKubeconfigTTL(r)
 	if err != nil {
-		log.Error().Err(err).Str("context", "getKubernetesConfig").Msg("Unable to generate JWT token")
-		return httperror.InternalServerError("Unable to generate JWT token", err)
+		return httperror.BadRequest("Invalid query parameter: ttl", err)
+	}
+
+	bearerToken := ""
+	if mode == kubeconfigModeToken {
+		if ttl > 0 {
+			bearerToken, _, err = handler.JwtService.GenerateTokenForKubeconfigWithTTL(tokenData, ttl)
+		} else {
+			bearerToken, err = handler.JwtService.GenerateTokenForKubeconfig(tokenData)
+		}
+
+		if err != nil {
+			log.Error().Err(err).Str("context", "getKubernetesConfig").Msg("Unable to generate JWT token")
+			return httperror.InternalServerError("Unable to generate JWT token", err)
+		}
 	}
 
 	endpoints, handlerErr := handler.filterUserKubeEndpoints(r)
@@ -59,9 +112,104 @@ func (handler *Handler) getKubernetesConfig(w http.ResponseWriter, r *http.Reque
 
 	config := handler.buildConfig(r, tokenData, bearerToken, endpoints, false)
 
+	if mode == kubeconfigModeExec {
+		authInfo := buildExecAuthInfo(handler.kubeClusterAccessService.GetCredentialURL(r.Host), cmp.Or(ttl, defaultExecCredentialTTL))
+		for i := range config.AuthInfos {
+			config.AuthInfos[i].AuthInfo = authInfo
+		}
+	}
+
 	return writeFileContent(w, r, endpoints, tokenData, config)
 }
 
+// @id GetKubernetesCredential
+// @summary Issue a short-lived Kubernetes credential
+// @description Issue a token for the Kubernetes environments, in the format of the exec credential plugins of kubectl.
+// @description It is requested by the kubeconfig files generated in the exec mode, kubectl requesting a new one when it
+// @description expires. The credential can only be requested with an API key, so that the tokens of the kubeconfig
+// @description files cannot renew themselves.
+// @description **Access policy**: Authenticated user.
+// @tags kubernetes
+// @security ApiKeyAuth
+// @produce json
+// @param ttl query string false "Lifetime of the token, such as 1h, between 5m and 720h, 1h by default"
+// @success 200 {object} clientauthenticationv1.ExecCredential "Success"
+// @failure 400 "Invalid request, such as a lifetime out of bounds."
+// @failure 401 "Unauthorized access - the user is not authenticated or does not have the necessary permissions. Ensure that you have provided a valid API key or JWT token, and that you have the required permissions."
+// @failure 403 "The credential was not requested with an API key."
+// @failure 500 "Server error occurred while attempting to issue the credential."
+// @router /kubernetes/credential [get]
+func (handler *Handler) getKubernetesCredential(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	tokenData, err := security.RetrieveTokenData(r)
+	if err != nil {
+		return httperror.Forbidden("Permission denied to access environment", err)
+	}
+
+	// the users authenticated by an API key have no token
+	if tokenData.Token != "" {
+		return httperror.Forbidden("An API key is required to request a Kubernetes credential", errors.New("not authenticated by an API key"))
+	}
+
+	ttl, err := parseKubeconfigTTL(r)
+	if err != nil {
+		return httperror.BadRequest("Invalid query parameter: ttl", err)
+	}
+
+	token, expiresAt, err := handler.JwtService.GenerateTokenForKubeconfigWithTTL(tokenData, cmp.Or(ttl, defaultExecCredentialTTL))
+	if err != nil {
+		log.Error().Err(err).Str("context", "getKubernetesCredential").Msg("Unable to generate JWT token")
+		return httperror.InternalServerError("Unable to generate JWT token", err)
+	}
+
+	expirationTimestamp := metav1.NewTime(expiresAt)
+
+	return response.JSON(w, clientauthenticationv1.ExecCredential{
+		TypeMeta: metav1.TypeMeta{APIVersion: execCredentialAPIVersion, Kind: "ExecCredential"},
+		Status: &clientauthenticationv1.ExecCredentialStatus{
+			ExpirationTimestamp: &expirationTimestamp,
+			Token:               token,
+		},
+	})
+}
+
+// parseKubeconfigTTL returns the lifetime of the kubeconfig tokens, 0 when it is not set
+func parseKubeconfigTTL(r *http.Request) (time.Duration, error) {
+	value, _ := request.RetrieveQueryParameter(r, "ttl", true)
+	if value == "" {
+		return 0, nil
+	}
+
+	ttl, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, err
+	}
+
+	if ttl < minKubeconfigTTL || ttl > maxKubeconfigTTL {
+		return 0, fmt.Errorf("the lifetime of the tokens must be between %s and %s", minKubeconfigTTL, maxKubeconfigTTL)
+	}
+
+	return ttl, nil
+}
+
+// buildExecAuthInfo builds the credentials of a kubeconfig requesting the tokens from the Portainer API, with the API
+// key of the user read from the environment
+func buildExecAuthInfo(credentialURL string, ttl time.Duration) clientV1.AuthInfo {
+	credentialURL += "?" + url.Values{"ttl": {ttl.String()}}.Encode()
+
+	// the certificate of Portainer is not verified, like in the cluster configurations
+	command := fmt.Sprintf(`curl --silent --show-error --fail --insecure --header "X-API-Key: $%s" "%s"`, apiKeyEnvironmentVariable, credentialURL)
+
+	return clientV1.AuthInfo{
+		Exec: &clientV1.ExecConfig{
+			APIVersion:      execCredentialAPIVersion,
+			Command:         "sh",
+			Args:            []string{"-c", command},
+			InstallHint:     fmt.Sprintf("curl is required, and an access token of your Portainer account must be set in the %s environment variable", apiKeyEnvironmentVariable),
+			InteractiveMode: clientV1.NeverExecInteractiveMode,
+		},
+	}
+}
+
 func (handler *Handler) filterUserKubeEndpoints(r *http.Request) ([]portainer.Endpoint, *httperror.HandlerError) {
 	var endpointIDs []portainer.EndpointID
 	_ = request.RetrieveJSONQueryParameter(r, "ids", &endpointIDs, true)
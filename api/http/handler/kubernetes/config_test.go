@@ -0,0 +1,24 @@
+package kubernetes
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/http/security"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetKubernetesConfig_Impersonation(t *testing.T) {
+	handler := &Handler{}
+
+	req := httptest.NewRequest(http.MethodGet, "/kubernetes/config?ttl=720h", nil)
+	req = req.WithContext(security.StoreTokenData(req, &portainer.TokenData{ID: 2, Username: "standard", Role: portainer.StandardUserRole, ImpersonatorID: 1}))
+
+	handlerErr := handler.getKubernetesConfig(httptest.NewRecorder(), req)
+	require.NotNil(t, handlerErr)
+	require.Equal(t, http.StatusForbidden, handlerErr.StatusCode)
+	require.ErrorIs(t, handlerErr.Err, errImpersonationNotAllowed)
+}
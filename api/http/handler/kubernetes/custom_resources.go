@@ -0,0 +1,225 @@
+package kubernetes
+
+import (
+	"errors"
+	"net/http"
+
+	models "github.com/portainer/portainer/api/http/models/kubernetes"
+	"github.com/portainer/portainer/api/kubernetes/cli"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/request"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+
+	"github.com/rs/zerolog/log"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/yaml"
+)
+
+// @id GetKubernetesCustomResourceDefinitions
+// @summary Get the custom resource definitions of the given Portainer environment
+// @description Get the custom resource definitions installed in the cluster, sorted by name. The non-administrator
+// @description users only get the definitions whose resources their RBAC rules allow them to list in one of their
+// @description namespaces.
+// @description **Access policy**: Authenticated user.
+// @tags kubernetes
+// @security ApiKeyAuth || jwt
+// @produce json
+// @param id path int true "Environment identifier"
+// @success 200 {array} kubernetes.K8sCustomResourceDefinition "Success"
+// @failure 400 "Invalid request"
+// @failure 403 "Permission denied"
+// @failure 404 "Unable to find an environment with the specified identifier."
+// @failure 500 "Server error"
+// @router /kubernetes/{id}/crds [get]
+func (handler *Handler) getKubernetesCustomResourceDefinitions(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	privilegedClient, httpErr := handler.prepareKubeClient(r)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	userClient, httpErr := handler.getProxyKubeClient(r)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	crds, err := privilegedClient.GetCustomResourceDefinitions(r.Context())
+	if err != nil {
+		return customResourceError(err, "getKubernetesCustomResourceDefinitions", "Unable to retrieve the custom resource definitions")
+	}
+
+	crds, err = userClient.FilterAccessibleCustomResourceDefinitions(r.Context(), crds)
+	if err != nil {
+		return customResourceError(err, "getKubernetesCustomResourceDefinitions", "Unable to retrieve the permissions of the user")
+	}
+
+	return response.JSON(w, crds)
+}
+
+// @id GetKubernetesCustomResources
+// @summary Get the resources of a custom resource definition
+// @description Get the resources of a custom resource definition in a namespace, or in all the namespaces the user has
+// @description access to, with the status of their Ready condition. The resources are listed with the permissions of
+// @description the user.
+// @description **Access policy**: Authenticated user.
+// @tags kubernetes
+// @security ApiKeyAuth || jwt
+// @produce json
+// @param id path int true "Environment identifier"
+// @param crd path string true "Custom resource definition name" example(certificates.cert-manager.io)
+// @param namespace query string false "Namespace of the resources, all the namespaces when empty. Ignored for the cluster resources"
+// @success 200 {array} kubernetes.K8sCustomResource "Success"
+// @failure 400 "Invalid request"
+// @failure 403 "Permission denied"
+// @failure 404 "Unable to find an environment with the specified identifier or unable to find the custom resource definition."
+// @failure 500 "Server error"
+// @router /kubernetes/{id}/crds/{crd}/resources [get]
+func (handler *Handler) getKubernetesCustomResources(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	namespace, err := request.RetrieveQueryParameter(r, "namespace", true)
+	if err != nil {
+		return httperror.BadRequest("Unable to parse the namespace query parameter", err)
+	}
+
+	crd, userClient, httpErr := handler.customResourceRequest(r)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	resources, err := userClient.GetCustomResources(r.Context(), crd, namespace)
+	if err != nil {
+		return customResourceError(err, "getKubernetesCustomResources", "Unable to retrieve the custom resources")
+	}
+
+	return response.JSON(w, resources)
+}
+
+// @id GetKubernetesCustomResource
+// @summary Get the manifest of a custom resource
+// @description Get the YAML manifest of a resource of a custom resource definition, without its managed fields. The
+// @description resource is read with the permissions of the user.
+// @description **Access policy**: Authenticated user.
+// @tags kubernetes
+// @security ApiKeyAuth || jwt
+// @produce text/yaml
+// @param id path int true "Environment identifier"
+// @param crd path string true "Custom resource definition name" example(certificates.cert-manager.io)
+// @param name path string true "Resource name"
+// @param namespace query string false "Namespace of the resource, ignored for the cluster resources"
+// @success 200 {string} string "Success"
+// @failure 400 "Invalid request"
+// @failure 403 "Permission denied"
+// @failure 404 "Unable to find an environment with the specified identifier, the custom resource definition or the resource."
+// @failure 500 "Server error"
+// @router /kubernetes/{id}/crds/{crd}/resources/{name} [get]
+func (handler *Handler) getKubernetesCustomResource(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	name, err := request.RetrieveRouteVariableValue(r, "name")
+	if err != nil {
+		return httperror.BadRequest("Invalid resource name route variable", err)
+	}
+
+	namespace, err := request.RetrieveQueryParameter(r, "namespace", true)
+	if err != nil {
+		return httperror.BadRequest("Unable to parse the namespace query parameter", err)
+	}
+
+	crd, userClient, httpErr := handler.customResourceRequest(r)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	resource, err := userClient.GetCustomResource(r.Context(), crd, namespace, name)
+	if err != nil {
+		return customResourceError(err, "getKubernetesCustomResource", "Unable to retrieve the custom resource")
+	}
+
+	return customResourceYAML(w, resource)
+}
+
+// @id ApplyKubernetesCustomResource
+// @summary Apply the manifest of a custom resource
+// @description Create or update a resource of a custom resource definition from its YAML or JSON manifest. The
+// @description manifest is applied server-side with the permissions of the user, and validated against the schema of
+// @description the definition, the unknown fields being refused. A dry run only validates the manifest. The applied
+// @description resource is returned as YAML.
+// @description **Access policy**: Authenticated user.
+// @tags kubernetes
+// @security ApiKeyAuth || jwt
+// @accept json
+// @produce text/yaml
+// @param id path int true "Environment identifier"
+// @param crd path string true "Custom resource definition name" example(certificates.cert-manager.io)
+// @param body body kubernetes.K8sCustomResourceApplyPayload true "Manifest of the resource"
+// @success 200 {string} string "Success"
+// @failure 400 "Invalid manifest, such as a resource of another definition or a resource refused by the schema of the definition."
+// @failure 403 "Permission denied"
+// @failure 404 "Unable to find an environment with the specified identifier or unable to find the custom resource definition."
+// @failure 500 "Server error"
+// @router /kubernetes/{id}/crds/{crd}/resources [put]
+func (handler *Handler) applyKubernetesCustomResource(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	var payload models.K8sCustomResourceApplyPayload
+	if err := request.DecodeAndValidateJSONPayload(r, &payload); err != nil {
+		return httperror.BadRequest("Invalid request payload", err)
+	}
+
+	crd, userClient, httpErr := handler.customResourceRequest(r)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	resource, err := userClient.ApplyCustomResource(r.Context(), crd, []byte(payload.Manifest), payload.DryRun)
+	if err != nil {
+		return customResourceError(err, "applyKubernetesCustomResource", "Unable to apply the custom resource")
+	}
+
+	return customResourceYAML(w, resource)
+}
+
+// customResourceRequest returns the custom resource definition of the request, which is read with the privileged
+// client as the users are not allowed to read the definitions, and the client of the user
+func (handler *Handler) customResourceRequest(r *http.Request) (models.K8sCustomResourceDefinition, *cli.KubeClient, *httperror.HandlerError) {
+	crdName, err := request.RetrieveRouteVariableValue(r, "crd")
+	if err != nil {
+		return models.K8sCustomResourceDefinition{}, nil, httperror.BadRequest("Invalid custom resource definition route variable", err)
+	}
+
+	privilegedClient, httpErr := handler.prepareKubeClient(r)
+	if httpErr != nil {
+		return models.K8sCustomResourceDefinition{}, nil, httpErr
+	}
+
+	userClient, httpErr := handler.getProxyKubeClient(r)
+	if httpErr != nil {
+		return models.K8sCustomResourceDefinition{}, nil, httpErr
+	}
+
+	crd, err := privilegedClient.GetCustomResourceDefinition(r.Context(), crdName)
+	if err != nil {
+		return models.K8sCustomResourceDefinition{}, nil, customResourceError(err, "customResourceRequest", "Unable to retrieve the custom resource definition")
+	}
+
+	return crd, userClient, nil
+}
+
+func customResourceYAML(w http.ResponseWriter, resource *unstructured.Unstructured) *httperror.HandlerError {
+	manifest, err := yaml.Marshal(resource.Object)
+	if err != nil {
+		return httperror.InternalServerError("Unable to encode the custom resource", err)
+	}
+
+	return response.YAML(w, string(manifest))
+}
+
+func customResourceError(err error, logContext, message string) *httperror.HandlerError {
+	switch {
+	case k8serrors.IsUnauthorized(err), k8serrors.IsForbidden(err):
+		return httperror.Forbidden("Unauthorized access to the custom resources", err)
+	case k8serrors.IsNotFound(err):
+		return httperror.NotFound(message, err)
+	case errors.Is(err, cli.ErrInvalidCustomResource), k8serrors.IsInvalid(err), k8serrors.IsBadRequest(err):
+		return httperror.BadRequest(err.Error(), err)
+	}
+
+	log.Error().Err(err).Str("context", logContext).Msg(message)
+
+	return httperror.InternalServerError(message, err)
+}
@@ -3,13 +3,18 @@ package kubernetes
 import (
 	"net/http"
 
+	"github.com/portainer/portainer/api/http/middlewares"
+	models "github.com/portainer/portainer/api/http/models/kubernetes"
 	httperror "github.com/portainer/portainer/pkg/libhttp/error"
 	"github.com/portainer/portainer/pkg/libhttp/response"
+
+	"github.com/rs/zerolog/log"
 )
 
 // @id GetKubernetesDashboard
 // @summary Get the dashboard summary data
 // @description Get the dashboard summary data which is simply a count of a range of different commonly used kubernetes resources.
+// @description The CPU and memory utilization of the nodes is included when the metrics API is available.
 // @description **Access policy**: Authenticated user.
 // @tags kubernetes
 // @security ApiKeyAuth || jwt
@@ -32,5 +37,36 @@ func (handler *Handler) getKubernetesDashboard(w http.ResponseWriter, r *http.Re
 		return httperror.InternalServerError("Unable to retrieve dashboard data", err)
 	}
 
+	dashboard.Utilization = handler.clusterUtilization(r)
+
 	return response.JSON(w, dashboard)
 }
+
+// clusterUtilization returns the utilization of the nodes, or nil when the metrics API is not available so that the
+// dashboard can still be shown
+func (handler *Handler) clusterUtilization(r *http.Request) *models.K8sUtilization {
+	endpoint, err := middlewares.FetchEndpoint(r)
+	if err != nil {
+		return nil
+	}
+
+	cli, err := handler.KubernetesClientFactory.GetPrivilegedKubeClient(endpoint)
+	if err != nil {
+		log.Debug().Err(err).Str("context", "clusterUtilization").Msg("Unable to get a privileged Kubernetes client")
+		return nil
+	}
+
+	metricsClient, err := handler.KubernetesClientFactory.CreateRemoteMetricsClient(endpoint)
+	if err != nil {
+		log.Debug().Err(err).Str("context", "clusterUtilization").Msg("Unable to create the Kubernetes metrics client")
+		return nil
+	}
+
+	utilization, err := cli.GetClusterUtilization(r.Context(), metricsClient)
+	if err != nil {
+		log.Debug().Err(err).Str("context", "clusterUtilization").Msg("Unable to retrieve the utilization of the nodes")
+		return nil
+	}
+
+	return utilization
+}
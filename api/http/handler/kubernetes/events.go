@@ -0,0 +1,124 @@
+package kubernetes
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	models "github.com/portainer/portainer/api/http/models/kubernetes"
+	"github.com/portainer/portainer/api/kubernetes/cli"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/request"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+
+	"github.com/rs/zerolog/log"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+// @id GetKubernetesEvents
+// @summary Get a list of Kubernetes events
+// @description Get the events of the namespaces the user has access to, the most recent first, filtered by namespace,
+// @description by the object they are about, by type and by the time they last occurred. The total number of matching
+// @description events is returned in the X-Total-Count header.
+// @description **Access policy**: Authenticated user.
+// @tags kubernetes
+// @security ApiKeyAuth || jwt
+// @produce json
+// @param id path int true "Environment identifier"
+// @param namespace query string false "List the events of this namespace"
+// @param kind query string false "List the events about objects of this kind, such as Pod or Deployment"
+// @param name query string false "List the events about objects with this name"
+// @param uid query string false "List the events about the object with this UID"
+// @param type query string false "List the events of this type" Enums(Normal, Warning)
+// @param since query int false "List the events which last occurred after this Unix timestamp"
+// @param until query int false "List the events which last occurred before this Unix timestamp"
+// @param start query int false "Start searching from"
+// @param limit query int false "Limit results to this value"
+// @success 200 {array} models.K8sEvent "Success"
+// @failure 400 "Invalid request, such as an unknown event type."
+// @failure 401 "Unauthorized access - the user is not authenticated or does not have the necessary permissions. Ensure that you have provided a valid API key or JWT token, and that you have the required permissions."
+// @failure 403 "Permission denied - the user is authenticated but does not have the necessary permissions to access the requested resource or perform the specified operation. Check your user roles and permissions."
+// @failure 404 "Unable to find an environment with the specified identifier."
+// @failure 500 "Server error occurred while attempting to retrieve the events."
+// @router /kubernetes/{id}/events [get]
+func (handler *Handler) getKubernetesEvents(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	filters, err := parseEventFilters(r)
+	if err != nil {
+		return httperror.BadRequest("Invalid query parameter", err)
+	}
+
+	start, _ := request.RetrieveNumericQueryParameter(r, "start", true)
+	if start != 0 {
+		start--
+	}
+
+	limit, _ := request.RetrieveNumericQueryParameter(r, "limit", true)
+
+	cli, httpErr := handler.prepareKubeClient(r)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	events, err := cli.GetEvents(filters)
+	if err != nil {
+		if k8serrors.IsUnauthorized(err) || k8serrors.IsForbidden(err) {
+			log.Error().Err(err).Str("context", "getKubernetesEvents").Msg("Unauthorized access to the Kubernetes API")
+			return httperror.Forbidden("Unauthorized access to the Kubernetes API", err)
+		}
+
+		log.Error().Err(err).Str("context", "getKubernetesEvents").Msg("Unable to retrieve events from the Kubernetes")
+		return httperror.InternalServerError("Unable to retrieve events from the Kubernetes", err)
+	}
+
+	w.Header().Set("X-Total-Count", strconv.Itoa(len(events)))
+
+	return response.JSON(w, paginateEvents(events, start, limit))
+}
+
+func parseEventFilters(r *http.Request) (cli.EventFilters, error) {
+	filters := cli.EventFilters{}
+	filters.Namespace, _ = request.RetrieveQueryParameter(r, "namespace", true)
+	filters.Kind, _ = request.RetrieveQueryParameter(r, "kind", true)
+	filters.Name, _ = request.RetrieveQueryParameter(r, "name", true)
+	filters.UID, _ = request.RetrieveQueryParameter(r, "uid", true)
+	filters.Type, _ = request.RetrieveQueryParameter(r, "type", true)
+
+	if filters.Type != "" && filters.Type != corev1.EventTypeNormal && filters.Type != corev1.EventTypeWarning {
+		return filters, errors.New("the type must be Normal or Warning")
+	}
+
+	since, err := request.RetrieveNumericQueryParameter(r, "since", true)
+	if err != nil {
+		return filters, err
+	}
+
+	until, err := request.RetrieveNumericQueryParameter(r, "until", true)
+	if err != nil {
+		return filters, err
+	}
+
+	if since != 0 {
+		filters.Since = time.Unix(int64(since), 0)
+	}
+
+	if until != 0 {
+		filters.Until = time.Unix(int64(until), 0)
+	}
+
+	return filters, nil
+}
+
+func paginateEvents(events []models.K8sEvent, start, limit int) []models.K8sEvent {
+	if start >= len(events) {
+		return []models.K8sEvent{}
+	}
+
+	end := len(events)
+	if limit > 0 {
+		end = min(start+limit, end)
+	}
+
+	return events[start:end]
+}
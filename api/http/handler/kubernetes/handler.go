@@ -13,6 +13,7 @@ import (
 	"github.com/portainer/portainer/api/internal/authorization"
 	"github.com/portainer/portainer/api/kubernetes"
 	"github.com/portainer/portainer/api/kubernetes/cli"
+	"github.com/portainer/portainer/api/kubernetes/teamaccess"
 	httperror "github.com/portainer/portainer/pkg/libhttp/error"
 	"github.com/portainer/portainer/pkg/libhttp/request"
 	"github.com/rs/zerolog/log"
@@ -28,6 +29,8 @@ type Handler struct {
 	KubernetesClientFactory  *cli.ClientFactory
 	JwtService               portainer.JWTService
 	kubeClusterAccessService kubernetes.KubeClusterAccessService
+	TeamAccessService        *teamaccess.Service
+	nodeDrains               *nodeDrains
 }
 
 // NewHandler creates a handler to process pre-proxied requests to external APIs.
@@ -39,12 +42,14 @@ func NewHandler(bouncer security.BouncerService, authorizationService *authoriza
 		JwtService:               jwtService,
 		kubeClusterAccessService: kubeClusterAccessService,
 		KubernetesClientFactory:  kubernetesClientFactory,
+		nodeDrains:               newNodeDrains(),
 	}
 
 	kubeRouter := h.PathPrefix("/kubernetes").Subrouter()
 	kubeRouter.Use(bouncer.AuthenticatedAccess)
 	kubeRouter.PathPrefix("/config").Handler(
 		bouncer.AuthenticatedAccess(httperror.LoggerHandler(h.getKubernetesConfig))).Methods(http.MethodGet)
+	kubeRouter.Handle("/credential", httperror.LoggerHandler(h.getKubernetesCredential)).Methods(http.MethodGet)
 
 	// endpoints
 	endpointRouter := kubeRouter.PathPrefix("/{id}").Subrouter()
@@ -61,7 +66,17 @@ func NewHandler(bouncer security.BouncerService, authorizationService *authoriza
 	endpointRouter.Handle("/cluster_role_bindings/delete", httperror.LoggerHandler(h.deleteClusterRoleBindings)).Methods(http.MethodPost)
 	endpointRouter.Handle("/configmaps", httperror.LoggerHandler(h.GetAllKubernetesConfigMaps)).Methods(http.MethodGet)
 	endpointRouter.Handle("/configmaps/count", httperror.LoggerHandler(h.getAllKubernetesConfigMapsCount)).Methods(http.MethodGet)
+	endpointRouter.Handle("/crds", httperror.LoggerHandler(h.getKubernetesCustomResourceDefinitions)).Methods(http.MethodGet)
+	endpointRouter.Handle("/crds/{crd}/resources", httperror.LoggerHandler(h.getKubernetesCustomResources)).Methods(http.MethodGet)
+	endpointRouter.Handle("/crds/{crd}/resources", httperror.LoggerHandler(h.applyKubernetesCustomResource)).Methods(http.MethodPut)
+	endpointRouter.Handle("/crds/{crd}/resources/{name}", httperror.LoggerHandler(h.getKubernetesCustomResource)).Methods(http.MethodGet)
 	endpointRouter.Handle("/dashboard", httperror.LoggerHandler(h.getKubernetesDashboard)).Methods(http.MethodGet)
+	endpointRouter.Handle("/events", httperror.LoggerHandler(h.getKubernetesEvents)).Methods(http.MethodGet)
+	endpointRouter.Handle("/nodes/{name}", bouncer.AdminAccess(httperror.LoggerHandler(h.updateKubernetesNode))).Methods(http.MethodPut)
+	endpointRouter.Handle("/nodes/{name}/cordon", bouncer.AdminAccess(httperror.LoggerHandler(h.cordonKubernetesNode))).Methods(http.MethodPost)
+	endpointRouter.Handle("/nodes/{name}/uncordon", bouncer.AdminAccess(httperror.LoggerHandler(h.uncordonKubernetesNode))).Methods(http.MethodPost)
+	endpointRouter.Handle("/nodes/{name}/drain", bouncer.AdminAccess(httperror.LoggerHandler(h.drainKubernetesNode))).Methods(http.MethodPost)
+	endpointRouter.Handle("/nodes/{name}/drain", bouncer.AdminAccess(httperror.LoggerHandler(h.getKubernetesNodeDrainStatus))).Methods(http.MethodGet)
 	endpointRouter.Handle("/nodes_limits", httperror.LoggerHandler(h.getKubernetesNodesLimits)).Methods(http.MethodGet)
 	endpointRouter.Handle("/max_resource_limits", httperror.LoggerHandler(h.getKubernetesMaxResourceLimits)).Methods(http.MethodGet)
 	endpointRouter.Handle("/metrics/applications_resources", httperror.LoggerHandler(h.getApplicationsResources)).Methods(http.MethodGet)
@@ -69,8 +84,12 @@ func NewHandler(bouncer security.BouncerService, authorizationService *authoriza
 	endpointRouter.Handle("/metrics/nodes/{name}", httperror.LoggerHandler(h.getKubernetesMetricsForNode)).Methods(http.MethodGet)
 	endpointRouter.Handle("/metrics/pods/namespace/{namespace}", httperror.LoggerHandler(h.getKubernetesMetricsForAllPods)).Methods(http.MethodGet)
 	endpointRouter.Handle("/metrics/pods/namespace/{namespace}/{name}", httperror.LoggerHandler(h.getKubernetesMetricsForPod)).Methods(http.MethodGet)
+	endpointRouter.Handle("/metrics/nodes_usage", httperror.LoggerHandler(h.getKubernetesNodesUsage)).Methods(http.MethodGet)
+	endpointRouter.Handle("/metrics/pods_usage", httperror.LoggerHandler(h.getKubernetesPodsUsage)).Methods(http.MethodGet)
 	endpointRouter.Handle("/ingresscontrollers", httperror.LoggerHandler(h.getAllKubernetesIngressControllers)).Methods(http.MethodGet)
 	endpointRouter.Handle("/ingresscontrollers", httperror.LoggerHandler(h.updateKubernetesIngressControllers)).Methods(http.MethodPut)
+	endpointRouter.Handle("/ingresscontrollers/detected", bouncer.AdminAccess(httperror.LoggerHandler(h.getKubernetesDetectedIngressControllers))).Methods(http.MethodGet)
+	endpointRouter.Handle("/ingressclasses", httperror.LoggerHandler(h.getKubernetesIngressClasses)).Methods(http.MethodGet)
 	endpointRouter.Handle("/ingresses/delete", httperror.LoggerHandler(h.deleteKubernetesIngresses)).Methods(http.MethodPost)
 	endpointRouter.Handle("/ingresses", httperror.LoggerHandler(h.GetAllKubernetesClusterIngresses)).Methods(http.MethodGet)
 	endpointRouter.Handle("/ingresses/count", httperror.LoggerHandler(h.getAllKubernetesClusterIngressesCount)).Methods(http.MethodGet)
@@ -88,6 +107,9 @@ func NewHandler(bouncer security.BouncerService, authorizationService *authoriza
 	endpointRouter.Handle("/namespaces/{namespace}", httperror.LoggerHandler(h.updateKubernetesNamespace)).Methods(http.MethodPut)
 	endpointRouter.Handle("/volumes", httperror.LoggerHandler(h.GetAllKubernetesVolumes)).Methods(http.MethodGet)
 	endpointRouter.Handle("/volumes/count", httperror.LoggerHandler(h.getAllKubernetesVolumesCount)).Methods(http.MethodGet)
+	endpointRouter.Handle("/volumes/orphaned", bouncer.AdminAccess(httperror.LoggerHandler(h.getKubernetesOrphanedVolumes))).Methods(http.MethodGet)
+	endpointRouter.Handle("/persistent_volumes", bouncer.AdminAccess(httperror.LoggerHandler(h.getKubernetesPersistentVolumes))).Methods(http.MethodGet)
+	endpointRouter.Handle("/storage_classes", httperror.LoggerHandler(h.getKubernetesStorageClasses)).Methods(http.MethodGet)
 	endpointRouter.Handle("/service_accounts", httperror.LoggerHandler(h.getAllKubernetesServiceAccounts)).Methods(http.MethodGet)
 	endpointRouter.Handle("/service_accounts/delete", httperror.LoggerHandler(h.deleteKubernetesServiceAccounts)).Methods(http.MethodPost)
 	endpointRouter.Handle("/roles", httperror.LoggerHandler(h.getAllKubernetesRoles)).Methods(http.MethodGet)
@@ -103,8 +125,13 @@ func NewHandler(bouncer security.BouncerService, authorizationService *authoriza
 	// in the future this piece of code might be in another package (or a few different packages - namespaces/namespace?)
 	// to keep it simple, we've decided to leave it like this.
 	namespaceRouter := endpointRouter.PathPrefix("/namespaces/{namespace}").Subrouter()
+	namespaceRouter.Handle("/applications/{kind}/{name}/logs", httperror.LoggerHandler(h.getKubernetesApplicationLogs)).Methods(http.MethodGet)
 	namespaceRouter.Handle("/configmaps/{configmap}", httperror.LoggerHandler(h.getKubernetesConfigMap)).Methods(http.MethodGet)
 	namespaceRouter.Handle("/system", bouncer.RestrictedAccess(httperror.LoggerHandler(h.namespacesToggleSystem))).Methods(http.MethodPut)
+	namespaceRouter.Handle("/teams", bouncer.AdminAccess(httperror.LoggerHandler(h.getKubernetesNamespaceTeams))).Methods(http.MethodGet)
+	namespaceRouter.Handle("/teams", bouncer.AdminAccess(httperror.LoggerHandler(h.updateKubernetesNamespaceTeams))).Methods(http.MethodPut)
+	namespaceRouter.Handle("/quotas", bouncer.AdminAccess(httperror.LoggerHandler(h.getKubernetesNamespaceQuotas))).Methods(http.MethodGet)
+	namespaceRouter.Handle("/quotas", bouncer.AdminAccess(httperror.LoggerHandler(h.updateKubernetesNamespaceQuotas))).Methods(http.MethodPut)
 	namespaceRouter.Handle("/ingresscontrollers", httperror.LoggerHandler(h.getKubernetesIngressControllersByNamespace)).Methods(http.MethodGet)
 	namespaceRouter.Handle("/ingresscontrollers", httperror.LoggerHandler(h.updateKubernetesIngressControllersByNamespace)).Methods(http.MethodPut)
 	namespaceRouter.Handle("/ingresses/{ingress}", httperror.LoggerHandler(h.getKubernetesIngress)).Methods(http.MethodGet)
@@ -117,6 +144,7 @@ func NewHandler(bouncer security.BouncerService, authorizationService *authoriza
 	namespaceRouter.Handle("/services", httperror.LoggerHandler(h.getKubernetesServicesByNamespace)).Methods(http.MethodGet)
 	namespaceRouter.Handle("/volumes", httperror.LoggerHandler(h.GetKubernetesVolumesInNamespace)).Methods(http.MethodGet)
 	namespaceRouter.Handle("/volumes/{volume}", httperror.LoggerHandler(h.getKubernetesVolume)).Methods(http.MethodGet)
+	namespaceRouter.Handle("/volumes/{volume}/expand", httperror.LoggerHandler(h.expandKubernetesVolume)).Methods(http.MethodPost)
 
 	// Deprecated
 	endpointRouter.Handle("/namespaces", middlewares.Deprecated(endpointRouter, deprecatedNamespaceParser)).Methods(http.MethodPut)
@@ -210,7 +238,7 @@ func (handler *Handler) kubeClientMiddleware(next http.Handler) http.Handler {
 				return
 			}
 
-			teamMemberships, err := handler.DataStore.TeamMembership().TeamMembershipsByUserID(user.ID)
+			teamMemberships, err := authorization.EffectiveTeamMemberships(handler.DataStore, user.ID)
 			if err != nil {
 				httperror.WriteError(w, http.StatusInternalServerError, "an error occurred during the KubeClientMiddleware operation, unable to get team memberships for user: ", err)
 				return
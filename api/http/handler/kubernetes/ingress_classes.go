@@ -0,0 +1,95 @@
+package kubernetes
+
+import (
+	"net/http"
+
+	"github.com/portainer/portainer/api/http/middlewares"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+
+	"github.com/rs/zerolog/log"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+// @id GetKubernetesIngressClasses
+// @summary Get the IngressClasses of a cluster
+// @description Get the IngressClasses of the given environment, whether they are the default class of the cluster and
+// @description whether an ingress uses them.
+// @description **Access policy**: Authenticated user.
+// @tags kubernetes
+// @security ApiKeyAuth || jwt
+// @produce json
+// @param id path int true "Environment identifier"
+// @success 200 {array} models.K8sIngressClass "Success"
+// @failure 401 "Unauthorized access - the user is not authenticated or does not have the necessary permissions. Ensure that you have provided a valid API key or JWT token, and that you have the required permissions."
+// @failure 403 "Permission denied - the user is authenticated but does not have the necessary permissions to access the requested resource or perform the specified operation. Check your user roles and permissions."
+// @failure 404 "Unable to find an environment with the specified identifier."
+// @failure 500 "Server error occurred while attempting to retrieve the ingress classes."
+// @router /kubernetes/{id}/ingressclasses [get]
+func (handler *Handler) getKubernetesIngressClasses(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	endpoint, err := middlewares.FetchEndpoint(r)
+	if err != nil {
+		return httperror.NotFound("Unable to find an environment on request context", err)
+	}
+
+	cli, err := handler.KubernetesClientFactory.GetPrivilegedKubeClient(endpoint)
+	if err != nil {
+		log.Error().Err(err).Str("context", "getKubernetesIngressClasses").Msg("Unable to get privileged kube client")
+		return httperror.InternalServerError("Unable to get privileged kube client", err)
+	}
+
+	classes, err := cli.GetIngressClasses()
+	if err != nil {
+		if k8serrors.IsUnauthorized(err) || k8serrors.IsForbidden(err) {
+			log.Error().Err(err).Str("context", "getKubernetesIngressClasses").Msg("Unauthorized access to the Kubernetes API")
+			return httperror.Forbidden("Unauthorized access to the Kubernetes API", err)
+		}
+
+		log.Error().Err(err).Str("context", "getKubernetesIngressClasses").Msg("Unable to retrieve ingress classes from the Kubernetes")
+		return httperror.InternalServerError("Unable to retrieve ingress classes from the Kubernetes", err)
+	}
+
+	return response.JSON(w, classes)
+}
+
+// @id GetKubernetesDetectedIngressControllers
+// @summary Detect the ingress controllers installed in a cluster
+// @description Detect the ingress controllers running in the given environment, such as ingress-nginx, NGINX Ingress,
+// @description Traefik, HAProxy, Contour, Kong or the AWS Load Balancer Controller, from the images of its Deployments and
+// @description DaemonSets, with their version, their readiness and the IngressClasses they implement.
+// @description **Access policy**: Administrator
+// @tags kubernetes
+// @security ApiKeyAuth || jwt
+// @produce json
+// @param id path int true "Environment identifier"
+// @success 200 {array} models.K8sDetectedIngressController "Success"
+// @failure 401 "Unauthorized access - the user is not authenticated or does not have the necessary permissions. Ensure that you have provided a valid API key or JWT token, and that you have the required permissions."
+// @failure 403 "Permission denied - the user is authenticated but does not have the necessary permissions to access the requested resource or perform the specified operation. Check your user roles and permissions."
+// @failure 404 "Unable to find an environment with the specified identifier."
+// @failure 500 "Server error occurred while attempting to detect the ingress controllers."
+// @router /kubernetes/{id}/ingresscontrollers/detected [get]
+func (handler *Handler) getKubernetesDetectedIngressControllers(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	endpoint, err := middlewares.FetchEndpoint(r)
+	if err != nil {
+		return httperror.NotFound("Unable to find an environment on request context", err)
+	}
+
+	cli, err := handler.KubernetesClientFactory.GetPrivilegedKubeClient(endpoint)
+	if err != nil {
+		log.Error().Err(err).Str("context", "getKubernetesDetectedIngressControllers").Msg("Unable to get privileged kube client")
+		return httperror.InternalServerError("Unable to get privileged kube client", err)
+	}
+
+	controllers, err := cli.DetectIngressControllers()
+	if err != nil {
+		if k8serrors.IsUnauthorized(err) || k8serrors.IsForbidden(err) {
+			log.Error().Err(err).Str("context", "getKubernetesDetectedIngressControllers").Msg("Unauthorized access to the Kubernetes API")
+			return httperror.Forbidden("Unauthorized access to the Kubernetes API", err)
+		}
+
+		log.Error().Err(err).Str("context", "getKubernetesDetectedIngressControllers").Msg("Unable to detect the ingress controllers")
+		return httperror.InternalServerError("Unable to detect the ingress controllers", err)
+	}
+
+	return response.JSON(w, controllers)
+}
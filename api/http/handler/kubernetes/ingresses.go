@@ -1,12 +1,14 @@
 package kubernetes
 
 import (
+	"errors"
 	"net/http"
 
 	portainer "github.com/portainer/portainer/api"
 	"github.com/portainer/portainer/api/http/middlewares"
 	models "github.com/portainer/portainer/api/http/models/kubernetes"
 	"github.com/portainer/portainer/api/http/security"
+	"github.com/portainer/portainer/api/kubernetes/cli"
 	httperror "github.com/portainer/portainer/pkg/libhttp/error"
 	"github.com/portainer/portainer/pkg/libhttp/request"
 	"github.com/portainer/portainer/pkg/libhttp/response"
@@ -678,7 +680,7 @@ func (handler *Handler) getKubernetesIngress(w http.ResponseWriter, r *http.Requ
 // @param namespace path string true "Namespace name"
 // @param body body models.K8sIngressInfo true "Ingress details"
 // @success 204 "Success"
-// @failure 400 "Invalid request payload, such as missing required fields or fields not meeting validation criteria."
+// @failure 400 "Invalid request payload, such as an unknown ingress class, a secret which is not a TLS secret or a path already served by another ingress."
 // @failure 401 "Unauthorized access - the user is not authenticated or does not have the necessary permissions. Ensure that you have provided a valid API key or JWT token, and that you have the required permissions."
 // @failure 403 "Permission denied - the user is authenticated but does not have the necessary permissions to access the requested resource or perform the specified operation. Check your user roles and permissions."
 // @failure 404 "Unable to find an environment with the specified identifier."
@@ -699,6 +701,15 @@ func (handler *Handler) createKubernetesIngress(w http.ResponseWriter, r *http.R
 		return httperror.BadRequest("Unable to decode and validate the request payload", err)
 	}
 
+	if payload.Namespace != namespace {
+		log.Error().Str("context", "createKubernetesIngress").Str("namespace", namespace).Msg("The namespace of the ingress does not match the namespace of the request")
+		return httperror.BadRequest("The namespace of the ingress does not match the namespace of the request", errors.New("namespace mismatch"))
+	}
+
+	if handlerErr := handler.validateIngress(r, namespace, payload); handlerErr != nil {
+		return handlerErr
+	}
+
 	owner := "admin"
 	tokenData, err := security.RetrieveTokenData(r)
 	if err == nil && tokenData != nil {
@@ -790,7 +801,7 @@ func (handler *Handler) deleteKubernetesIngresses(w http.ResponseWriter, r *http
 // @param namespace path string true "Namespace name"
 // @param body body models.K8sIngressInfo true "Ingress details"
 // @success 204 "Success"
-// @failure 400 "Invalid request payload, such as missing required fields or fields not meeting validation criteria."
+// @failure 400 "Invalid request payload, such as an unknown ingress class, a secret which is not a TLS secret or a path already served by another ingress."
 // @failure 401 "Unauthorized access - the user is not authenticated or does not have the necessary permissions. Ensure that you have provided a valid API key or JWT token, and that you have the required permissions."
 // @failure 403 "Permission denied - the user is authenticated but does not have the necessary permissions to access the requested resource or perform the specified operation. Check your user roles and permissions."
 // @failure 404 "Unable to find an environment with the specified identifier or unable to find the specified ingress."
@@ -810,6 +821,15 @@ func (handler *Handler) updateKubernetesIngress(w http.ResponseWriter, r *http.R
 		return httperror.BadRequest("Unable to decode and validate the request payload", err)
 	}
 
+	if payload.Namespace != namespace {
+		log.Error().Str("context", "updateKubernetesIngress").Str("namespace", namespace).Msg("The namespace of the ingress does not match the namespace of the request")
+		return httperror.BadRequest("The namespace of the ingress does not match the namespace of the request", errors.New("namespace mismatch"))
+	}
+
+	if handlerErr := handler.validateIngress(r, namespace, payload); handlerErr != nil {
+		return handlerErr
+	}
+
 	cli, handlerErr := handler.getProxyKubeClient(r)
 	if handlerErr != nil {
 		return handlerErr
@@ -833,3 +853,24 @@ func (handler *Handler) updateKubernetesIngress(w http.ResponseWriter, r *http.R
 
 	return response.Empty(w)
 }
+
+// validateIngress checks an ingress against the whole cluster, which the user might not be allowed to read
+func (handler *Handler) validateIngress(r *http.Request, namespace string, payload models.K8sIngressInfo) *httperror.HandlerError {
+	endpoint, err := middlewares.FetchEndpoint(r)
+	if err != nil {
+		return httperror.NotFound("Unable to find an environment on request context", err)
+	}
+
+	kubeClient, err := handler.KubernetesClientFactory.GetPrivilegedKubeClient(endpoint)
+	if err != nil {
+		return httperror.InternalServerError("Unable to get privileged kube client", err)
+	}
+
+	if err := kubeClient.ValidateIngress(namespace, payload); errors.Is(err, cli.ErrInvalidIngress) {
+		return httperror.BadRequest("Invalid ingress", err)
+	} else if err != nil {
+		return httperror.InternalServerError("Unable to validate the ingress", err)
+	}
+
+	return nil
+}
@@ -1,15 +1,19 @@
 package kubernetes
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/portainer/portainer/api/http/middlewares"
+	"github.com/portainer/portainer/api/kubernetes/cli"
 	httperror "github.com/portainer/portainer/pkg/libhttp/error"
 	"github.com/portainer/portainer/pkg/libhttp/request"
 	"github.com/portainer/portainer/pkg/libhttp/response"
 	"github.com/rs/zerolog/log"
 
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	metricsv "k8s.io/metrics/pkg/client/clientset/versioned"
 )
 
 // @id GetKubernetesMetricsForAllNodes
@@ -181,3 +185,101 @@ func (handler *Handler) getKubernetesMetricsForPod(w http.ResponseWriter, r *htt
 
 	return response.JSON(w, metrics)
 }
+
+// @id GetKubernetesNodesUsage
+// @summary Get the CPU and memory utilization of the nodes
+// @description Get the CPU and memory used on each node out of its allocatable resources, as reported by the metrics API.
+// @description **Access policy**: Authenticated user.
+// @tags kubernetes
+// @security ApiKeyAuth || jwt
+// @produce json
+// @param id path int true "Environment identifier"
+// @success 200 {array} models.K8sNodeUsage "Success"
+// @failure 401 "Unauthorized access - the user is not authenticated or does not have the necessary permissions. Ensure that you have provided a valid API key or JWT token, and that you have the required permissions."
+// @failure 403 "Permission denied - the user is authenticated but does not have the necessary permissions to access the requested resource or perform the specified operation. Check your user roles and permissions."
+// @failure 500 "Server error occurred while attempting to retrieve the utilization of the nodes."
+// @failure 503 "The metrics API is not available, the metrics server is not installed in the cluster."
+// @router /kubernetes/{id}/metrics/nodes_usage [get]
+func (handler *Handler) getKubernetesNodesUsage(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	cli, httpErr := handler.prepareKubeClient(r)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	metricsClient, httpErr := handler.createMetricsClient(r)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	nodesUsage, err := cli.GetNodesUsage(r.Context(), metricsClient)
+	if err != nil {
+		return metricsUsageError("getKubernetesNodesUsage", err)
+	}
+
+	return response.JSON(w, nodesUsage)
+}
+
+// @id GetKubernetesPodsUsage
+// @summary Get the CPU and memory usage of the pods
+// @description Get the CPU and memory used by the pods of a namespace, or of all the namespaces the user has access to,
+// @description as reported by the metrics API. The pods which are not running have no usage.
+// @description **Access policy**: Authenticated user.
+// @tags kubernetes
+// @security ApiKeyAuth || jwt
+// @produce json
+// @param id path int true "Environment identifier"
+// @param namespace query string false "Get the usage of the pods of this namespace"
+// @success 200 {array} models.K8sPodUsage "Success"
+// @failure 401 "Unauthorized access - the user is not authenticated or does not have the necessary permissions. Ensure that you have provided a valid API key or JWT token, and that you have the required permissions."
+// @failure 403 "Permission denied - the user is authenticated but does not have the necessary permissions to access the requested resource or perform the specified operation. Check your user roles and permissions."
+// @failure 500 "Server error occurred while attempting to retrieve the usage of the pods."
+// @failure 503 "The metrics API is not available, the metrics server is not installed in the cluster."
+// @router /kubernetes/{id}/metrics/pods_usage [get]
+func (handler *Handler) getKubernetesPodsUsage(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	namespace, _ := request.RetrieveQueryParameter(r, "namespace", true)
+
+	cli, httpErr := handler.prepareKubeClient(r)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	metricsClient, httpErr := handler.createMetricsClient(r)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	podsUsage, err := cli.GetPodsUsage(r.Context(), metricsClient, namespace)
+	if err != nil {
+		return metricsUsageError("getKubernetesPodsUsage", err)
+	}
+
+	return response.JSON(w, podsUsage)
+}
+
+func (handler *Handler) createMetricsClient(r *http.Request) (*metricsv.Clientset, *httperror.HandlerError) {
+	endpoint, err := middlewares.FetchEndpoint(r)
+	if err != nil {
+		return nil, httperror.NotFound("Unable to find the Kubernetes endpoint associated to the request.", err)
+	}
+
+	metricsClient, err := handler.KubernetesClientFactory.CreateRemoteMetricsClient(endpoint)
+	if err != nil {
+		log.Error().Err(err).Str("context", "createMetricsClient").Msg("Failed to create metrics KubeClient")
+		return nil, httperror.InternalServerError("failed to create metrics KubeClient", err)
+	}
+
+	return metricsClient, nil
+}
+
+func metricsUsageError(logContext string, err error) *httperror.HandlerError {
+	switch {
+	case errors.Is(err, cli.ErrMetricsUnavailable):
+		return httperror.NewError(http.StatusServiceUnavailable, "The metrics API is not available", err)
+	case k8serrors.IsUnauthorized(err) || k8serrors.IsForbidden(err):
+		log.Error().Err(err).Str("context", logContext).Msg("Unauthorized access to the Kubernetes API")
+		return httperror.Forbidden("Unauthorized access to the Kubernetes API", err)
+	}
+
+	log.Error().Err(err).Str("context", logContext).Msg("Failed to fetch metrics")
+	return httperror.InternalServerError("Failed to fetch metrics", err)
+}
@@ -0,0 +1,131 @@
+package kubernetes
+
+import (
+	"net/http"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/http/middlewares"
+	models "github.com/portainer/portainer/api/http/models/kubernetes"
+	"github.com/portainer/portainer/api/kubernetes/cli"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/request"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+
+	"github.com/rs/zerolog/log"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+// @id KubernetesNamespaceQuotasInspect
+// @summary Get the quotas of a namespace
+// @description Get the ResourceQuota, with the usage of the namespace, and the LimitRange managed by Portainer in a
+// @description namespace, with warnings when the CPU or the memory quotas of the namespaces exceed the capacity of the
+// @description cluster in the latest snapshot of the environment.
+// @description **Access policy**: Administrator
+// @security ApiKeyAuth || jwt
+// @tags kubernetes
+// @produce json
+// @param id path int true "Environment identifier"
+// @param namespace path string true "Namespace name"
+// @success 200 {object} models.K8sNamespaceQuotas "Success"
+// @failure 400 "Invalid request"
+// @failure 403 "Permission denied"
+// @failure 404 "Unable to find an environment with the specified identifier or unable to find the namespace."
+// @failure 500 "Server error"
+// @router /kubernetes/{id}/namespaces/{namespace}/quotas [get]
+func (handler *Handler) getKubernetesNamespaceQuotas(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	endpoint, namespaceName, kubeClient, httpErr := handler.namespaceQuotasRequest(r)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	quotas, err := kubeClient.GetNamespaceQuotas(namespaceName)
+	if k8serrors.IsNotFound(err) {
+		return httperror.NotFound("Unable to find the namespace", err)
+	} else if err != nil {
+		return httperror.InternalServerError("Unable to retrieve the quotas of the namespace", err)
+	}
+
+	if quotas.ResourceQuota != nil {
+		quotas.Warnings = namespaceQuotaWarnings(kubeClient, namespaceName, quotas.ResourceQuota.Hard, endpoint)
+	}
+
+	return response.JSON(w, quotas)
+}
+
+// @id KubernetesNamespaceQuotasUpdate
+// @summary Replace the quotas of a namespace
+// @description Replace the ResourceQuota and the LimitRange managed by Portainer in a namespace, a null ResourceQuota
+// @description or LimitRange removing it. The quotas are saved even when they over-commit the cluster, in which case
+// @description warnings are returned.
+// @description **Access policy**: Administrator
+// @security ApiKeyAuth || jwt
+// @tags kubernetes
+// @accept json
+// @produce json
+// @param id path int true "Environment identifier"
+// @param namespace path string true "Namespace name"
+// @param body body models.K8sNamespaceQuotas true "Quotas of the namespace"
+// @success 200 {object} models.K8sNamespaceQuotas "Success"
+// @failure 400 "Invalid request payload, such as an invalid quantity or a minimum greater than its maximum."
+// @failure 403 "Permission denied"
+// @failure 404 "Unable to find an environment with the specified identifier or unable to find the namespace."
+// @failure 500 "Server error"
+// @router /kubernetes/{id}/namespaces/{namespace}/quotas [put]
+func (handler *Handler) updateKubernetesNamespaceQuotas(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	var payload models.K8sNamespaceQuotas
+	if err := request.DecodeAndValidateJSONPayload(r, &payload); err != nil {
+		return httperror.BadRequest("Invalid request payload", err)
+	}
+
+	endpoint, namespaceName, kubeClient, httpErr := handler.namespaceQuotasRequest(r)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	err := kubeClient.UpdateNamespaceQuotas(namespaceName, payload)
+	if k8serrors.IsNotFound(err) {
+		return httperror.NotFound("Unable to find the namespace", err)
+	} else if err != nil {
+		return httperror.InternalServerError("Unable to update the quotas of the namespace", err)
+	}
+
+	quotas, err := kubeClient.GetNamespaceQuotas(namespaceName)
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve the quotas of the namespace", err)
+	}
+
+	if quotas.ResourceQuota != nil {
+		quotas.Warnings = namespaceQuotaWarnings(kubeClient, namespaceName, quotas.ResourceQuota.Hard, endpoint)
+	}
+
+	return response.JSON(w, quotas)
+}
+
+func (handler *Handler) namespaceQuotasRequest(r *http.Request) (*portainer.Endpoint, string, *cli.KubeClient, *httperror.HandlerError) {
+	endpoint, err := middlewares.FetchEndpoint(r)
+	if err != nil {
+		return nil, "", nil, httperror.NotFound("Unable to find an environment on request context", err)
+	}
+
+	namespaceName, err := request.RetrieveRouteVariableValue(r, "namespace")
+	if err != nil {
+		return nil, "", nil, httperror.BadRequest("Invalid namespace identifier route variable", err)
+	}
+
+	kubeClient, err := handler.KubernetesClientFactory.GetPrivilegedKubeClient(endpoint)
+	if err != nil {
+		return nil, "", nil, httperror.InternalServerError("Unable to create kubernetes client", err)
+	}
+
+	return endpoint, namespaceName, kubeClient, nil
+}
+
+// namespaceQuotaWarnings returns the over-commit warnings of a quota, which are informative and left out on error
+func namespaceQuotaWarnings(kubeClient *cli.KubeClient, namespace string, hard map[string]string, endpoint *portainer.Endpoint) []string {
+	warnings, err := kubeClient.GetNamespaceQuotaWarnings(namespace, hard, endpoint)
+	if err != nil {
+		log.Warn().Err(err).Str("namespace", namespace).Msg("unable to compute the over-commit warnings of the namespace quota")
+	}
+
+	return warnings
+}
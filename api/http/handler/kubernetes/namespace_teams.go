@@ -0,0 +1,146 @@
+package kubernetes
+
+import (
+	"net/http"
+	"slices"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/http/middlewares"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/request"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+
+	"github.com/pkg/errors"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+type namespaceTeamsPayload struct {
+	// Identifiers of the teams having access to the namespace, the members of the teams below them having access too
+	TeamIDs []portainer.TeamID `example:"1,2"`
+}
+
+func (payload *namespaceTeamsPayload) Validate(r *http.Request) error {
+	if payload.TeamIDs == nil {
+		return errors.New("missing mandatory field: TeamIDs")
+	}
+
+	return nil
+}
+
+// @id KubernetesNamespaceTeamsList
+// @summary Get the teams having access to a namespace
+// @description Get the identifiers of the teams having access to a namespace.
+// @description **Access policy**: Administrator
+// @security ApiKeyAuth || jwt
+// @tags kubernetes
+// @produce json
+// @param id path int true "Environment identifier"
+// @param namespace path string true "Namespace name"
+// @success 200 {array} int "Success"
+// @failure 400 "Invalid request"
+// @failure 403 "Permission denied"
+// @failure 404 "Unable to find an environment with the specified identifier or unable to find the namespace."
+// @failure 500 "Server error"
+// @router /kubernetes/{id}/namespaces/{namespace}/teams [get]
+func (handler *Handler) getKubernetesNamespaceTeams(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	endpoint, err := middlewares.FetchEndpoint(r)
+	if err != nil {
+		return httperror.NotFound("Unable to find an environment on request context", err)
+	}
+
+	namespaceName, err := request.RetrieveRouteVariableValue(r, "namespace")
+	if err != nil {
+		return httperror.BadRequest("Invalid namespace identifier route variable", err)
+	}
+
+	kubeClient, err := handler.KubernetesClientFactory.GetPrivilegedKubeClient(endpoint)
+	if err != nil {
+		return httperror.InternalServerError("Unable to create kubernetes client", err)
+	}
+
+	teamIDs, err := kubeClient.GetNamespaceTeamAccess(namespaceName)
+	if k8serrors.IsNotFound(err) {
+		return httperror.NotFound("Unable to find the namespace", err)
+	} else if err != nil {
+		return httperror.InternalServerError("Unable to retrieve the teams having access to the namespace", err)
+	}
+
+	return response.JSON(w, teamIDs)
+}
+
+// @id KubernetesNamespaceTeamsUpdate
+// @summary Replace the teams having access to a namespace
+// @description Replace the teams having access to a namespace. The members of the teams, and of the teams below
+// @description them, are bound to the edit cluster role in the namespace with a RoleBinding per team, which is kept in
+// @description sync when the memberships of the teams change, so that the access is enforced by the cluster itself.
+// @description **Access policy**: Administrator
+// @security ApiKeyAuth || jwt
+// @tags kubernetes
+// @accept json
+// @produce json
+// @param id path int true "Environment identifier"
+// @param namespace path string true "Namespace name"
+// @param body body namespaceTeamsPayload true "Teams having access to the namespace"
+// @success 200 {array} int "Success"
+// @failure 400 "Invalid request payload, such as an unknown team."
+// @failure 403 "Permission denied"
+// @failure 404 "Unable to find an environment with the specified identifier or unable to find the namespace."
+// @failure 500 "Server error"
+// @router /kubernetes/{id}/namespaces/{namespace}/teams [put]
+func (handler *Handler) updateKubernetesNamespaceTeams(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	endpoint, err := middlewares.FetchEndpoint(r)
+	if err != nil {
+		return httperror.NotFound("Unable to find an environment on request context", err)
+	}
+
+	namespaceName, err := request.RetrieveRouteVariableValue(r, "namespace")
+	if err != nil {
+		return httperror.BadRequest("Invalid namespace identifier route variable", err)
+	}
+
+	var payload namespaceTeamsPayload
+	if err := request.DecodeAndValidateJSONPayload(r, &payload); err != nil {
+		return httperror.BadRequest("Invalid request payload", err)
+	}
+
+	teamIDs := make([]int, 0, len(payload.TeamIDs))
+	for _, teamID := range payload.TeamIDs {
+		if _, err := handler.DataStore.Team().Read(teamID); handler.DataStore.IsErrObjectNotFound(err) {
+			return httperror.BadRequest("Unable to find a team with the specified identifier inside the database", err)
+		} else if err != nil {
+			return httperror.InternalServerError("Unable to find a team with the specified identifier inside the database", err)
+		}
+
+		teamIDs = append(teamIDs, int(teamID))
+	}
+
+	kubeClient, err := handler.KubernetesClientFactory.GetPrivilegedKubeClient(endpoint)
+	if err != nil {
+		return httperror.InternalServerError("Unable to create kubernetes client", err)
+	}
+
+	previousTeamIDs, err := kubeClient.UpdateNamespaceTeamAccess(namespaceName, teamIDs)
+	if k8serrors.IsNotFound(err) {
+		return httperror.NotFound("Unable to find the namespace", err)
+	} else if err != nil {
+		return httperror.InternalServerError("Unable to update the teams having access to the namespace", err)
+	}
+
+	slices.Sort(teamIDs)
+	teamIDs = slices.Compact(teamIDs)
+
+	// the teams which lost their access are synchronized too so that their RoleBinding is removed
+
+	changedTeamIDs := []portainer.TeamID{}
+	for _, teamID := range append(previousTeamIDs, teamIDs...) {
+		if !slices.Contains(changedTeamIDs, portainer.TeamID(teamID)) {
+			changedTeamIDs = append(changedTeamIDs, portainer.TeamID(teamID))
+		}
+	}
+
+	if err := handler.TeamAccessService.SyncTeamsInEnvironment(kubeClient, changedTeamIDs...); err != nil {
+		return httperror.InternalServerError("Unable to synchronize the RoleBindings of the teams", err)
+	}
+
+	return response.JSON(w, teamIDs)
+}
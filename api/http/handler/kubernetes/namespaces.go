@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"net/http"
 
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/http/middlewares"
 	models "github.com/portainer/portainer/api/http/models/kubernetes"
 	httperror "github.com/portainer/portainer/pkg/libhttp/error"
 	"github.com/portainer/portainer/pkg/libhttp/request"
@@ -22,6 +24,7 @@ import (
 // @produce json
 // @param id path int true "Environment identifier"
 // @param withResourceQuota query boolean true "When set to true, include the resource quota information as part of the Namespace information. Default is false"
+// @param withUsage query boolean false "When set to true, include the CPU and memory used by the pods of the namespace when the metrics API is available. Default is false"
 // @success 200 {array} portainer.K8sNamespaceInfo "Success"
 // @failure 400 "Invalid request payload, such as missing required fields or fields not meeting validation criteria."
 // @failure 401 "Unauthorized access - the user is not authenticated or does not have the necessary permissions. Ensure that you have provided a valid API key or JWT token, and that you have the required permissions."
@@ -36,6 +39,12 @@ func (handler *Handler) getKubernetesNamespaces(w http.ResponseWriter, r *http.R
 		return httperror.BadRequest("an error occurred during the GetKubernetesNamespaces operation, invalid query parameter withResourceQuota. Error: ", err)
 	}
 
+	withUsage, err := request.RetrieveBooleanQueryParameter(r, "withUsage", true)
+	if err != nil {
+		log.Error().Err(err).Str("context", "GetKubernetesNamespaces").Msg("Invalid query parameter withUsage")
+		return httperror.BadRequest("an error occurred during the GetKubernetesNamespaces operation, invalid query parameter withUsage. Error: ", err)
+	}
+
 	cli, httpErr := handler.prepareKubeClient(r)
 	if httpErr != nil {
 		log.Error().Err(httpErr).Str("context", "GetKubernetesNamespaces").Msg("Unable to get a Kubernetes client for the user")
@@ -48,6 +57,10 @@ func (handler *Handler) getKubernetesNamespaces(w http.ResponseWriter, r *http.R
 		return httperror.InternalServerError("an error occurred during the GetKubernetesNamespaces operation, unable to retrieve namespaces from the Kubernetes cluster. Error: ", err)
 	}
 
+	if withUsage {
+		handler.addNamespacesUsage(r, namespaces)
+	}
+
 	if withResourceQuota {
 		return cli.CombineNamespacesWithResourceQuotas(namespaces, w)
 	}
@@ -96,6 +109,7 @@ func (handler *Handler) getKubernetesNamespacesCount(w http.ResponseWriter, r *h
 // @param id path int true "Environment identifier"
 // @param namespace path string true "The namespace name to get details for"
 // @param withResourceQuota query boolean true "When set to true, include the resource quota information as part of the Namespace information. Default is false"
+// @param withUsage query boolean false "When set to true, include the CPU and memory used by the pods of the namespace when the metrics API is available. Default is false"
 // @success 200 {object} portainer.K8sNamespaceInfo "Success"
 // @failure 400 "Invalid request payload, such as missing required fields or fields not meeting validation criteria."
 // @failure 401 "Unauthorized access - the user is not authenticated or does not have the necessary permissions. Ensure that you have provided a valid API key or JWT token, and that you have the required permissions."
@@ -116,6 +130,12 @@ func (handler *Handler) getKubernetesNamespace(w http.ResponseWriter, r *http.Re
 		return httperror.BadRequest("an error occurred during the GetKubernetesNamespace operation for the namespace %s, invalid query parameter withResourceQuota. Error: ", err)
 	}
 
+	withUsage, err := request.RetrieveBooleanQueryParameter(r, "withUsage", true)
+	if err != nil {
+		log.Error().Err(err).Str("context", "GetKubernetesNamespace").Msg("Invalid query parameter withUsage")
+		return httperror.BadRequest("an error occurred during the GetKubernetesNamespace operation, invalid query parameter withUsage. Error: ", err)
+	}
+
 	cli, httpErr := handler.getProxyKubeClient(r)
 	if httpErr != nil {
 		log.Error().Err(httpErr).Str("context", "GetKubernetesNamespace").Msg("Unable to get a Kubernetes client for the user")
@@ -138,6 +158,12 @@ func (handler *Handler) getKubernetesNamespace(w http.ResponseWriter, r *http.Re
 		return httperror.InternalServerError(fmt.Sprintf("an error occurred during the GetKubernetesNamespace operation, unable to get the namespace: %s. Error: ", namespaceName), err)
 	}
 
+	if withUsage {
+		namespaces := map[string]portainer.K8sNamespaceInfo{namespaceInfo.Name: namespaceInfo}
+		handler.addNamespacesUsage(r, namespaces)
+		namespaceInfo = namespaces[namespaceInfo.Name]
+	}
+
 	if withResourceQuota {
 		return cli.CombineNamespaceWithResourceQuota(namespaceInfo, w)
 	}
@@ -278,3 +304,41 @@ func (handler *Handler) updateKubernetesNamespace(w http.ResponseWriter, r *http
 
 	return response.JSON(w, namespace)
 }
+
+// addNamespacesUsage sets the CPU and memory used by the pods of the namespaces, the namespaces being left without usage
+// when the metrics API is not available
+func (handler *Handler) addNamespacesUsage(r *http.Request, namespaces map[string]portainer.K8sNamespaceInfo) {
+	endpoint, err := middlewares.FetchEndpoint(r)
+	if err != nil {
+		return
+	}
+
+	cli, err := handler.KubernetesClientFactory.GetPrivilegedKubeClient(endpoint)
+	if err != nil {
+		log.Debug().Err(err).Str("context", "addNamespacesUsage").Msg("Unable to get a privileged Kubernetes client")
+		return
+	}
+
+	metricsClient, err := handler.KubernetesClientFactory.CreateRemoteMetricsClient(endpoint)
+	if err != nil {
+		log.Debug().Err(err).Str("context", "addNamespacesUsage").Msg("Unable to create the Kubernetes metrics client")
+		return
+	}
+
+	names := make([]string, 0, len(namespaces))
+	for name := range namespaces {
+		names = append(names, name)
+	}
+
+	usages, err := cli.GetNamespacesUsage(r.Context(), metricsClient, names...)
+	if err != nil {
+		log.Debug().Err(err).Str("context", "addNamespacesUsage").Msg("Unable to retrieve the usage of the namespaces")
+		return
+	}
+
+	for name, namespace := range namespaces {
+		usage := usages[name]
+		namespace.Usage = &usage
+		namespaces[name] = namespace
+	}
+}
@@ -0,0 +1,302 @@
+package kubernetes
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/http/middlewares"
+	models "github.com/portainer/portainer/api/http/models/kubernetes"
+	"github.com/portainer/portainer/api/kubernetes/cli"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/request"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+
+	"github.com/rs/zerolog/log"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+// nodeDrains keeps the progress of the drains of the nodes, by environment and node name
+type nodeDrains struct {
+	mu       sync.Mutex
+	statuses map[string]models.K8sNodeDrainStatus
+}
+
+func newNodeDrains() *nodeDrains {
+	return &nodeDrains{statuses: map[string]models.K8sNodeDrainStatus{}}
+}
+
+func nodeDrainKey(endpointID portainer.EndpointID, name string) string {
+	return fmt.Sprintf("%d/%s", endpointID, name)
+}
+
+// start records the beginning of the drain of a node, it returns false when the node is already being drained
+func (d *nodeDrains) start(key string, status models.K8sNodeDrainStatus) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if current, ok := d.statuses[key]; ok && current.Status == models.K8sNodeDrainStatusDraining {
+		return false
+	}
+
+	d.statuses[key] = status
+
+	return true
+}
+
+func (d *nodeDrains) update(key string, fn func(status *models.K8sNodeDrainStatus)) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	status := d.statuses[key]
+	fn(&status)
+	d.statuses[key] = status
+}
+
+func (d *nodeDrains) get(key string) (models.K8sNodeDrainStatus, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	status, ok := d.statuses[key]
+
+	return status, ok
+}
+
+// @id KubernetesNodeCordon
+// @summary Cordon a node
+// @description Mark a node as unschedulable, the pods running on it are left running.
+// @description **Access policy**: Administrator
+// @security ApiKeyAuth || jwt
+// @tags kubernetes
+// @param id path int true "Environment identifier"
+// @param name path string true "Node name"
+// @success 204 "Success"
+// @failure 400 "Invalid request"
+// @failure 403 "Permission denied"
+// @failure 404 "Unable to find an environment with the specified identifier or unable to find the node."
+// @failure 500 "Server error"
+// @router /kubernetes/{id}/nodes/{name}/cordon [post]
+func (handler *Handler) cordonKubernetesNode(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	return handler.setKubernetesNodeCordon(w, r, true)
+}
+
+// @id KubernetesNodeUncordon
+// @summary Uncordon a node
+// @description Mark a node as schedulable again.
+// @description **Access policy**: Administrator
+// @security ApiKeyAuth || jwt
+// @tags kubernetes
+// @param id path int true "Environment identifier"
+// @param name path string true "Node name"
+// @success 204 "Success"
+// @failure 400 "Invalid request"
+// @failure 403 "Permission denied"
+// @failure 404 "Unable to find an environment with the specified identifier or unable to find the node."
+// @failure 500 "Server error"
+// @router /kubernetes/{id}/nodes/{name}/uncordon [post]
+func (handler *Handler) uncordonKubernetesNode(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	return handler.setKubernetesNodeCordon(w, r, false)
+}
+
+func (handler *Handler) setKubernetesNodeCordon(w http.ResponseWriter, r *http.Request, cordon bool) *httperror.HandlerError {
+	_, nodeName, kubeClient, httpErr := handler.nodeRequest(r)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	err := kubeClient.CordonNode(nodeName, cordon)
+	if k8serrors.IsNotFound(err) {
+		return httperror.NotFound("Unable to find the node", err)
+	} else if err != nil {
+		log.Error().Err(err).Str("context", "setKubernetesNodeCordon").Str("node", nodeName).Msg("Unable to update the node")
+		return httperror.InternalServerError("Unable to update the node", err)
+	}
+
+	return response.Empty(w)
+}
+
+// @id KubernetesNodeUpdate
+// @summary Update the labels and the taints of a node
+// @description Replace the labels and the taints of a node. The labels and the taints set by Kubernetes, the kubelet
+// @description or the cloud provider, such as kubernetes.io/hostname or node.kubernetes.io/unreachable, are kept and
+// @description cannot be set. A null field leaves it unchanged.
+// @description **Access policy**: Administrator
+// @security ApiKeyAuth || jwt
+// @tags kubernetes
+// @accept json
+// @param id path int true "Environment identifier"
+// @param name path string true "Node name"
+// @param body body models.K8sNodeUpdatePayload true "Labels and taints of the node"
+// @success 204 "Success"
+// @failure 400 "Invalid request payload, such as an invalid key or a label managed by Kubernetes."
+// @failure 403 "Permission denied"
+// @failure 404 "Unable to find an environment with the specified identifier or unable to find the node."
+// @failure 500 "Server error"
+// @router /kubernetes/{id}/nodes/{name} [put]
+func (handler *Handler) updateKubernetesNode(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	var payload models.K8sNodeUpdatePayload
+	if err := request.DecodeAndValidateJSONPayload(r, &payload); err != nil {
+		return httperror.BadRequest("Invalid request payload", err)
+	}
+
+	_, nodeName, kubeClient, httpErr := handler.nodeRequest(r)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	err := kubeClient.UpdateNode(nodeName, payload)
+	if k8serrors.IsNotFound(err) {
+		return httperror.NotFound("Unable to find the node", err)
+	} else if k8serrors.IsInvalid(err) {
+		return httperror.BadRequest("Invalid labels or taints", err)
+	} else if err != nil {
+		log.Error().Err(err).Str("context", "updateKubernetesNode").Str("node", nodeName).Msg("Unable to update the node")
+		return httperror.InternalServerError("Unable to update the node", err)
+	}
+
+	return response.Empty(w)
+}
+
+// @id KubernetesNodeDrain
+// @summary Drain a node
+// @description Cordon a node and evict its pods, honoring their PodDisruptionBudgets unless the eviction is disabled.
+// @description The drain is refused when the node runs pods which cannot be evicted with the given options, such as
+// @description the pods of the DaemonSets or the pods which are not managed by a controller. The drain runs in the
+// @description background, its progress is returned by the drain status.
+// @description **Access policy**: Administrator
+// @security ApiKeyAuth || jwt
+// @tags kubernetes
+// @accept json
+// @produce json
+// @param id path int true "Environment identifier"
+// @param name path string true "Node name"
+// @param body body models.K8sNodeDrainPayload true "Options of the drain"
+// @success 202 {object} models.K8sNodeDrainStatus "The drain has started"
+// @failure 400 "Invalid request payload, or the node runs pods which cannot be evicted with these options."
+// @failure 403 "Permission denied"
+// @failure 404 "Unable to find an environment with the specified identifier or unable to find the node."
+// @failure 409 "The node is already being drained"
+// @failure 500 "Server error"
+// @router /kubernetes/{id}/nodes/{name}/drain [post]
+func (handler *Handler) drainKubernetesNode(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	var payload models.K8sNodeDrainPayload
+	if err := request.DecodeAndValidateJSONPayload(r, &payload); err != nil {
+		return httperror.BadRequest("Invalid request payload", err)
+	}
+
+	endpoint, nodeName, kubeClient, httpErr := handler.nodeRequest(r)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	// the pods are checked before starting so that a drain which cannot succeed is refused rather than failing later
+	pods, err := kubeClient.GetNodePodsToDrain(r.Context(), nodeName, payload)
+	if k8serrors.IsNotFound(err) {
+		return httperror.NotFound("Unable to find the node", err)
+	} else if errors.Is(err, cli.ErrNodeDrainBlocked) {
+		return httperror.BadRequest(err.Error(), err)
+	} else if err != nil {
+		log.Error().Err(err).Str("context", "drainKubernetesNode").Str("node", nodeName).Msg("Unable to retrieve the pods of the node")
+		return httperror.InternalServerError("Unable to retrieve the pods of the node", err)
+	}
+
+	key := nodeDrainKey(endpoint.ID, nodeName)
+	status := models.K8sNodeDrainStatus{
+		Node:        nodeName,
+		Status:      models.K8sNodeDrainStatusDraining,
+		StartedAt:   time.Now(),
+		PodsTotal:   len(pods),
+		PendingPods: []string{},
+	}
+
+	for _, pod := range pods {
+		status.PendingPods = append(status.PendingPods, pod.Namespace+"/"+pod.Name)
+	}
+
+	if !handler.nodeDrains.start(key, status) {
+		return httperror.Conflict("The node is already being drained", errors.New("node drain in progress"))
+	}
+
+	// the drain outlives the request
+	go func() {
+		err := kubeClient.DrainNode(context.Background(), nodeName, payload, func(progress models.K8sNodeDrainStatus) {
+			handler.nodeDrains.update(key, func(status *models.K8sNodeDrainStatus) {
+				status.PodsTotal = progress.PodsTotal
+				status.PodsEvicted = progress.PodsEvicted
+				status.PendingPods = progress.PendingPods
+			})
+		})
+
+		handler.nodeDrains.update(key, func(status *models.K8sNodeDrainStatus) {
+			status.FinishedAt = time.Now()
+			status.Status = models.K8sNodeDrainStatusDrained
+
+			if err != nil {
+				status.Status = models.K8sNodeDrainStatusFailed
+				status.Error = err.Error()
+			}
+		})
+
+		if err != nil {
+			log.Warn().Err(err).Str("context", "drainKubernetesNode").Str("node", nodeName).Msg("Unable to drain the node")
+		}
+	}()
+
+	return response.JSONWithStatus(w, status, http.StatusAccepted)
+}
+
+// @id KubernetesNodeDrainStatus
+// @summary Get the progress of the drain of a node
+// @description Get the progress of the latest drain of a node started since Portainer is running.
+// @description **Access policy**: Administrator
+// @security ApiKeyAuth || jwt
+// @tags kubernetes
+// @produce json
+// @param id path int true "Environment identifier"
+// @param name path string true "Node name"
+// @success 200 {object} models.K8sNodeDrainStatus "Success"
+// @failure 400 "Invalid request"
+// @failure 403 "Permission denied"
+// @failure 404 "Unable to find an environment with the specified identifier or no drain of the node was started."
+// @router /kubernetes/{id}/nodes/{name}/drain [get]
+func (handler *Handler) getKubernetesNodeDrainStatus(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	endpoint, err := middlewares.FetchEndpoint(r)
+	if err != nil {
+		return httperror.NotFound("Unable to find an environment on request context", err)
+	}
+
+	nodeName, err := request.RetrieveRouteVariableValue(r, "name")
+	if err != nil {
+		return httperror.BadRequest("Invalid node name route variable", err)
+	}
+
+	status, ok := handler.nodeDrains.get(nodeDrainKey(endpoint.ID, nodeName))
+	if !ok {
+		return httperror.NotFound("No drain of the node was started", errors.New("node drain not found"))
+	}
+
+	return response.JSON(w, status)
+}
+
+func (handler *Handler) nodeRequest(r *http.Request) (*portainer.Endpoint, string, *cli.KubeClient, *httperror.HandlerError) {
+	endpoint, err := middlewares.FetchEndpoint(r)
+	if err != nil {
+		return nil, "", nil, httperror.NotFound("Unable to find an environment on request context", err)
+	}
+
+	nodeName, err := request.RetrieveRouteVariableValue(r, "name")
+	if err != nil {
+		return nil, "", nil, httperror.BadRequest("Invalid node name route variable", err)
+	}
+
+	kubeClient, err := handler.KubernetesClientFactory.GetPrivilegedKubeClient(endpoint)
+	if err != nil {
+		return nil, "", nil, httperror.InternalServerError("Unable to create kubernetes client", err)
+	}
+
+	return endpoint, nodeName, kubeClient, nil
+}
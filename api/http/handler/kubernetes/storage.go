@@ -0,0 +1,164 @@
+package kubernetes
+
+import (
+	"errors"
+	"net/http"
+
+	models "github.com/portainer/portainer/api/http/models/kubernetes"
+	"github.com/portainer/portainer/api/kubernetes/cli"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/request"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+
+	"github.com/rs/zerolog/log"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// @id GetKubernetesStorageClasses
+// @summary Get the storage classes of the given Portainer environment
+// @description Get the storage classes of the cluster with their provisioner, their parameters, whether they are the
+// @description default storage class and whether they allow volume expansion, sorted by name.
+// @description **Access policy**: Authenticated user.
+// @tags kubernetes
+// @security ApiKeyAuth || jwt
+// @produce json
+// @param id path int true "Environment identifier"
+// @success 200 {array} kubernetes.K8sStorageClassInfo "Success"
+// @failure 400 "Invalid request"
+// @failure 403 "Permission denied"
+// @failure 404 "Unable to find an environment with the specified identifier."
+// @failure 500 "Server error"
+// @router /kubernetes/{id}/storage_classes [get]
+func (handler *Handler) getKubernetesStorageClasses(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	cli, httpErr := handler.prepareKubeClient(r)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	storageClasses, err := cli.GetStorageClasses()
+	if err != nil {
+		log.Error().Err(err).Str("context", "getKubernetesStorageClasses").Msg("Unable to retrieve the storage classes")
+		return httperror.InternalServerError("Unable to retrieve the storage classes", err)
+	}
+
+	return response.JSON(w, storageClasses)
+}
+
+// @id GetKubernetesPersistentVolumes
+// @summary Get the PersistentVolumes of the given Portainer environment
+// @description Get the PersistentVolumes of the cluster with their capacity, their binding status and the claim they
+// @description are bound to, sorted by name.
+// @description **Access policy**: Administrator
+// @tags kubernetes
+// @security ApiKeyAuth || jwt
+// @produce json
+// @param id path int true "Environment identifier"
+// @success 200 {array} kubernetes.K8sPersistentVolumeInfo "Success"
+// @failure 400 "Invalid request"
+// @failure 403 "Permission denied"
+// @failure 404 "Unable to find an environment with the specified identifier."
+// @failure 500 "Server error"
+// @router /kubernetes/{id}/persistent_volumes [get]
+func (handler *Handler) getKubernetesPersistentVolumes(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	cli, httpErr := handler.prepareKubeClient(r)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	persistentVolumes, err := cli.GetPersistentVolumes()
+	if err != nil {
+		log.Error().Err(err).Str("context", "getKubernetesPersistentVolumes").Msg("Unable to retrieve the persistent volumes")
+		return httperror.InternalServerError("Unable to retrieve the persistent volumes", err)
+	}
+
+	return response.JSON(w, persistentVolumes)
+}
+
+// @id GetKubernetesOrphanedVolumes
+// @summary Get the orphaned volumes of the given Portainer environment
+// @description Get the PersistentVolumes which were released by their claim or which are not claimed, and the
+// @description PersistentVolumeClaims which lost their volume or which are not mounted by any pod. They hold storage
+// @description that is likely not needed anymore.
+// @description **Access policy**: Administrator
+// @tags kubernetes
+// @security ApiKeyAuth || jwt
+// @produce json
+// @param id path int true "Environment identifier"
+// @success 200 {array} kubernetes.K8sOrphanedVolume "Success"
+// @failure 400 "Invalid request"
+// @failure 403 "Permission denied"
+// @failure 404 "Unable to find an environment with the specified identifier."
+// @failure 500 "Server error"
+// @router /kubernetes/{id}/volumes/orphaned [get]
+func (handler *Handler) getKubernetesOrphanedVolumes(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	cli, httpErr := handler.prepareKubeClient(r)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	orphans, err := cli.GetOrphanedVolumes()
+	if err != nil {
+		log.Error().Err(err).Str("context", "getKubernetesOrphanedVolumes").Msg("Unable to retrieve the orphaned volumes")
+		return httperror.InternalServerError("Unable to retrieve the orphaned volumes", err)
+	}
+
+	return response.JSON(w, orphans)
+}
+
+// @id KubernetesVolumeExpand
+// @summary Expand a volume
+// @description Increase the storage requested by a PersistentVolumeClaim. The storage class of the claim must allow
+// @description volume expansion, and the volume is resized by its provisioner, the capacity of the claim being
+// @description updated once it is done. Some provisioners require the pods using the volume to be restarted.
+// @description **Access policy**: Authenticated user.
+// @tags kubernetes
+// @security ApiKeyAuth || jwt
+// @accept json
+// @param id path int true "Environment identifier"
+// @param namespace path string true "Namespace name"
+// @param volume path string true "Volume name"
+// @param body body kubernetes.K8sVolumeExpandPayload true "New size of the volume"
+// @success 204 "Success"
+// @failure 400 "Invalid request payload, the storage class does not allow volume expansion or the size is not increased."
+// @failure 403 "Permission denied"
+// @failure 404 "Unable to find an environment with the specified identifier or unable to find the volume."
+// @failure 500 "Server error"
+// @router /kubernetes/{id}/namespaces/{namespace}/volumes/{volume}/expand [post]
+func (handler *Handler) expandKubernetesVolume(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	namespace, err := request.RetrieveRouteVariableValue(r, "namespace")
+	if err != nil {
+		return httperror.BadRequest("Invalid namespace identifier", err)
+	}
+
+	volumeName, err := request.RetrieveRouteVariableValue(r, "volume")
+	if err != nil {
+		return httperror.BadRequest("Invalid volume name", err)
+	}
+
+	var payload models.K8sVolumeExpandPayload
+	if err := request.DecodeAndValidateJSONPayload(r, &payload); err != nil {
+		return httperror.BadRequest("Invalid request payload", err)
+	}
+
+	kubeClient, httpErr := handler.prepareKubeClient(r)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	err = kubeClient.ExpandVolume(namespace, volumeName, resource.MustParse(payload.Size))
+	switch {
+	case err == nil:
+		return response.Empty(w)
+	case k8serrors.IsUnauthorized(err), k8serrors.IsForbidden(err):
+		return httperror.Forbidden("Unauthorized access to the volume", err)
+	case k8serrors.IsNotFound(err):
+		return httperror.NotFound("Volume not found", err)
+	case errors.Is(err, cli.ErrVolumeExpansionNotAllowed), errors.Is(err, cli.ErrVolumeSizeNotIncreased), k8serrors.IsInvalid(err):
+		return httperror.BadRequest(err.Error(), err)
+	}
+
+	log.Error().Err(err).Str("context", "expandKubernetesVolume").Str("namespace", namespace).Str("volume", volumeName).Msg("Unable to expand the volume")
+
+	return httperror.InternalServerError("Unable to expand the volume", err)
+}
@@ -0,0 +1,69 @@
+package plugins
+
+import (
+	"net/http"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+	"github.com/portainer/portainer/api/http/security"
+	"github.com/portainer/portainer/api/plugins"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+
+	"github.com/gorilla/mux"
+)
+
+// Handler is the HTTP handler used to serve the routes of the plugins.
+type Handler struct {
+	*mux.Router
+	dataStore dataservices.DataStore
+	names     []string
+}
+
+// NewHandler returns a new Handler serving the plugins of the default registry
+func NewHandler(bouncer security.BouncerService, dataStore dataservices.DataStore) *Handler {
+	h := &Handler{
+		Router:    mux.NewRouter(),
+		dataStore: dataStore,
+		names:     []string{},
+	}
+
+	h.Handle("/plugins",
+		bouncer.AuthenticatedAccess(httperror.LoggerHandler(h.pluginList))).Methods(http.MethodGet)
+
+	for _, plugin := range plugins.Plugins() {
+		name := plugin.Name()
+		prefix := "/plugins/" + name
+
+		h.PathPrefix(prefix + "/").Handler(
+			bouncer.AuthenticatedAccess(http.StripPrefix(prefix, plugin.Handler(h))))
+
+		h.names = append(h.names, name)
+	}
+
+	return h
+}
+
+// DataStore implements the plugins.Host interface
+func (handler *Handler) DataStore() dataservices.DataStore {
+	return handler.dataStore
+}
+
+// TokenData implements the plugins.Host interface
+func (handler *Handler) TokenData(r *http.Request) (*portainer.TokenData, error) {
+	return security.RetrieveTokenData(r)
+}
+
+// @id PluginList
+// @summary List the plugins
+// @description List the names of the plugins, their routes are served under /api/plugins/{name}.
+// @description **Access policy**: authenticated
+// @tags plugins
+// @security ApiKeyAuth
+// @security jwt
+// @produce json
+// @success 200 {array} string "Success"
+// @router /plugins [get]
+func (handler *Handler) pluginList(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	return response.JSON(w, handler.names)
+}
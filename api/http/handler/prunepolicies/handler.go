@@ -0,0 +1,83 @@
+package prunepolicies
+
+import (
+	"net/http"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+	"github.com/portainer/portainer/api/docker/prunepolicies"
+	"github.com/portainer/portainer/api/http/security"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/request"
+
+	"github.com/gorilla/mux"
+)
+
+// Handler is the HTTP handler used to manage the scheduled removal of the unused resources of the Docker environments.
+type Handler struct {
+	*mux.Router
+	DataStore          dataservices.DataStore
+	PrunePolicyService *prunepolicies.Service
+}
+
+// NewHandler creates a handler to manage the prune policies.
+func NewHandler(bouncer security.BouncerService, dataStore dataservices.DataStore, prunePolicyService *prunepolicies.Service) *Handler {
+	h := &Handler{
+		Router:             mux.NewRouter(),
+		DataStore:          dataStore,
+		PrunePolicyService: prunePolicyService,
+	}
+
+	h.Handle("/prune_policies",
+		bouncer.AdminAccess(httperror.LoggerHandler(h.prunePolicyCreate))).Methods(http.MethodPost)
+	h.Handle("/prune_policies",
+		bouncer.AdminAccess(httperror.LoggerHandler(h.prunePolicyList))).Methods(http.MethodGet)
+	h.Handle("/prune_policies/{id}",
+		bouncer.AdminAccess(httperror.LoggerHandler(h.prunePolicyInspect))).Methods(http.MethodGet)
+	h.Handle("/prune_policies/{id}",
+		bouncer.AdminAccess(httperror.LoggerHandler(h.prunePolicyUpdate))).Methods(http.MethodPut)
+	h.Handle("/prune_policies/{id}",
+		bouncer.AdminAccess(httperror.LoggerHandler(h.prunePolicyDelete))).Methods(http.MethodDelete)
+	h.Handle("/prune_policies/{id}/run",
+		bouncer.AdminAccess(httperror.LoggerHandler(h.prunePolicyRun))).Methods(http.MethodPost)
+	h.Handle("/prune_policies/{id}/reports",
+		bouncer.AdminAccess(httperror.LoggerHandler(h.prunePolicyReportList))).Methods(http.MethodGet)
+	h.Handle("/prune_policies/{id}/reports/{reportId}",
+		bouncer.AdminAccess(httperror.LoggerHandler(h.prunePolicyReportInspect))).Methods(http.MethodGet)
+
+	return h
+}
+
+// prunePolicyResponse is a prune policy with the time of its next scheduled run
+type prunePolicyResponse struct {
+	*portainer.PrunePolicy
+	// Unix timestamp of the next scheduled run, 0 when the policy is paused
+	NextRunAt int64 `json:"NextRunAt" example:"1587399600"`
+}
+
+func newPrunePolicyResponse(policy *portainer.PrunePolicy) prunePolicyResponse {
+	resp := prunePolicyResponse{PrunePolicy: policy}
+
+	if schedule, err := prunepolicies.ParseSchedule(policy.CronExpression); err == nil && !policy.Paused {
+		resp.NextRunAt = schedule.Next(time.Now()).Unix()
+	}
+
+	return resp
+}
+
+func (handler *Handler) readPrunePolicy(r *http.Request) (*portainer.PrunePolicy, *httperror.HandlerError) {
+	id, err := request.RetrieveNumericRouteVariableValue(r, "id")
+	if err != nil {
+		return nil, httperror.BadRequest("Invalid prune policy identifier route variable", err)
+	}
+
+	policy, err := handler.DataStore.PrunePolicy().Read(portainer.PrunePolicyID(id))
+	if handler.DataStore.IsErrObjectNotFound(err) {
+		return nil, httperror.NotFound("Unable to find a prune policy with the specified identifier inside the database", err)
+	} else if err != nil {
+		return nil, httperror.InternalServerError("Unable to find a prune policy with the specified identifier inside the database", err)
+	}
+
+	return policy, nil
+}
@@ -0,0 +1,80 @@
+package prunepolicies
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+	"github.com/portainer/portainer/api/http/security"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/request"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+)
+
+type prunePolicyCreatePayload struct {
+	// Name of the policy
+	Name string `validate:"required" example:"nightly-images"`
+	prunePolicyUpdatePayload
+}
+
+func (payload *prunePolicyCreatePayload) Validate(r *http.Request) error {
+	if payload.Name == "" {
+		return errors.New("Invalid prune policy name")
+	}
+
+	return payload.prunePolicyUpdatePayload.Validate(r)
+}
+
+// @id PrunePolicyCreate
+// @summary Create a prune policy
+// @description Create a prune policy, which removes the unused images, volumes and networks of Docker environments
+// @description on a cron schedule. The resources are listed from the environments at each run, the ones used by a
+// @description container, stopped or not, or by a Swarm service are never removed. A dry run only reports what would
+// @description be removed. Each run records a report of the removed resources and of the reclaimed disk space.
+// @description **Access policy**: administrator
+// @tags prune_policies
+// @security ApiKeyAuth
+// @security jwt
+// @accept json
+// @produce json
+// @param body body prunePolicyCreatePayload true "Prune policy details"
+// @success 200 {object} prunePolicyResponse "Success"
+// @failure 400 "Invalid request"
+// @failure 500 "Server error"
+// @router /prune_policies [post]
+func (handler *Handler) prunePolicyCreate(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	var payload prunePolicyCreatePayload
+	if err := request.DecodeAndValidateJSONPayload(r, &payload); err != nil {
+		return httperror.BadRequest("Invalid request payload", err)
+	}
+
+	tokenData, err := security.RetrieveTokenData(r)
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve user details from authentication token", err)
+	}
+
+	policy := &portainer.PrunePolicy{
+		Name:         payload.Name,
+		CreationDate: time.Now().Unix(),
+		CreatedBy:    tokenData.Username,
+	}
+	payload.apply(policy)
+
+	if err := handler.DataStore.UpdateTx(func(tx dataservices.DataStoreTx) error {
+		if err := checkTargets(tx, payload.Endpoints, payload.EndpointGroups); err != nil {
+			return err
+		}
+
+		return tx.PrunePolicy().Create(policy)
+	}); err != nil {
+		return prunePolicyPersistError(err)
+	}
+
+	if err := handler.PrunePolicyService.Schedule(policy); err != nil {
+		return httperror.InternalServerError("Unable to schedule the prune policy", err)
+	}
+
+	return response.JSON(w, newPrunePolicyResponse(policy))
+}
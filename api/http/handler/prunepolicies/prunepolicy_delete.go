@@ -0,0 +1,43 @@
+package prunepolicies
+
+import (
+	"net/http"
+
+	"github.com/portainer/portainer/api/dataservices"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+)
+
+// @id PrunePolicyDelete
+// @summary Remove a prune policy
+// @description Remove a prune policy and its reports. The runs in progress are not stopped.
+// @description **Access policy**: administrator
+// @tags prune_policies
+// @security ApiKeyAuth
+// @security jwt
+// @param id path int true "Prune policy identifier"
+// @success 204 "Success"
+// @failure 400 "Invalid request"
+// @failure 404 "Prune policy not found"
+// @failure 500 "Server error"
+// @router /prune_policies/{id} [delete]
+func (handler *Handler) prunePolicyDelete(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	policy, httpErr := handler.readPrunePolicy(r)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	handler.PrunePolicyService.Unschedule(policy.ID)
+
+	if err := handler.DataStore.UpdateTx(func(tx dataservices.DataStoreTx) error {
+		if err := tx.PruneReport().DeleteByPolicyID(policy.ID); err != nil {
+			return err
+		}
+
+		return tx.PrunePolicy().Delete(policy.ID)
+	}); err != nil {
+		return httperror.InternalServerError("Unable to remove the prune policy from the database", err)
+	}
+
+	return response.Empty(w)
+}
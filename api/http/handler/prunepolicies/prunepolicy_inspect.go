@@ -0,0 +1,30 @@
+package prunepolicies
+
+import (
+	"net/http"
+
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+)
+
+// @id PrunePolicyInspect
+// @summary Inspect a prune policy
+// @description **Access policy**: administrator
+// @tags prune_policies
+// @security ApiKeyAuth
+// @security jwt
+// @produce json
+// @param id path int true "Prune policy identifier"
+// @success 200 {object} prunePolicyResponse "Success"
+// @failure 400 "Invalid request"
+// @failure 404 "Prune policy not found"
+// @failure 500 "Server error"
+// @router /prune_policies/{id} [get]
+func (handler *Handler) prunePolicyInspect(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	policy, httpErr := handler.readPrunePolicy(r)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	return response.JSON(w, newPrunePolicyResponse(policy))
+}
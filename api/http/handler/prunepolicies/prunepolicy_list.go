@@ -0,0 +1,32 @@
+package prunepolicies
+
+import (
+	"net/http"
+
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+)
+
+// @id PrunePolicyList
+// @summary List the prune policies
+// @description **Access policy**: administrator
+// @tags prune_policies
+// @security ApiKeyAuth
+// @security jwt
+// @produce json
+// @success 200 {array} prunePolicyResponse "Success"
+// @failure 500 "Server error"
+// @router /prune_policies [get]
+func (handler *Handler) prunePolicyList(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	policies, err := handler.DataStore.PrunePolicy().ReadAll()
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve the prune policies from the database", err)
+	}
+
+	responses := make([]prunePolicyResponse, 0, len(policies))
+	for i := range policies {
+		responses = append(responses, newPrunePolicyResponse(&policies[i]))
+	}
+
+	return response.JSON(w, responses)
+}
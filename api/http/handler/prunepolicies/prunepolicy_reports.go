@@ -0,0 +1,101 @@
+package prunepolicies
+
+import (
+	"errors"
+	"net/http"
+	"slices"
+
+	portainer "github.com/portainer/portainer/api"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/request"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+)
+
+// @id PrunePolicyReportList
+// @summary List the reports of a prune policy
+// @description List the reports of the runs of a prune policy, the most recent first.
+// @description **Access policy**: administrator
+// @tags prune_policies
+// @security ApiKeyAuth
+// @security jwt
+// @produce json
+// @param id path int true "Prune policy identifier"
+// @param endpointId query int false "Only list the reports of this environment"
+// @param status query string false "Only list the reports with this status" Enums(running, succeeded, failed)
+// @param dryRun query bool false "Only list the reports of the dry runs, or of the other runs"
+// @success 200 {array} portainer.PruneReport "Success"
+// @failure 400 "Invalid request"
+// @failure 404 "Prune policy not found"
+// @failure 500 "Server error"
+// @router /prune_policies/{id}/reports [get]
+func (handler *Handler) prunePolicyReportList(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	policy, httpErr := handler.readPrunePolicy(r)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	endpointID, _ := request.RetrieveNumericQueryParameter(r, "endpointId", true)
+	status, _ := request.RetrieveQueryParameter(r, "status", true)
+
+	filterDryRun := r.URL.Query().Has("dryRun")
+	dryRun, err := request.RetrieveBooleanQueryParameter(r, "dryRun", true)
+	if err != nil {
+		return httperror.BadRequest("Invalid query parameter: dryRun", err)
+	}
+
+	reports, err := handler.DataStore.PruneReport().ReportsByPolicyID(policy.ID)
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve the reports of the prune policy from the database", err)
+	}
+
+	reports = slices.DeleteFunc(reports, func(report portainer.PruneReport) bool {
+		return (endpointID != 0 && report.EndpointID != portainer.EndpointID(endpointID)) ||
+			(status != "" && report.Status != portainer.PruneReportStatus(status)) ||
+			(filterDryRun && report.DryRun != dryRun)
+	})
+
+	slices.SortFunc(reports, func(a, b portainer.PruneReport) int {
+		return int(b.ID - a.ID)
+	})
+
+	return response.JSON(w, reports)
+}
+
+// @id PrunePolicyReportInspect
+// @summary Inspect a report of a prune policy
+// @description **Access policy**: administrator
+// @tags prune_policies
+// @security ApiKeyAuth
+// @security jwt
+// @produce json
+// @param id path int true "Prune policy identifier"
+// @param reportId path int true "Report identifier"
+// @success 200 {object} portainer.PruneReport "Success"
+// @failure 400 "Invalid request"
+// @failure 404 "Prune policy or report not found"
+// @failure 500 "Server error"
+// @router /prune_policies/{id}/reports/{reportId} [get]
+func (handler *Handler) prunePolicyReportInspect(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	policy, httpErr := handler.readPrunePolicy(r)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	reportID, err := request.RetrieveNumericRouteVariableValue(r, "reportId")
+	if err != nil {
+		return httperror.BadRequest("Invalid report identifier route variable", err)
+	}
+
+	report, err := handler.DataStore.PruneReport().Read(portainer.PruneReportID(reportID))
+	if handler.DataStore.IsErrObjectNotFound(err) {
+		return httperror.NotFound("Unable to find a report with the specified identifier inside the database", err)
+	} else if err != nil {
+		return httperror.InternalServerError("Unable to find a report with the specified identifier inside the database", err)
+	}
+
+	if report.PolicyID != policy.ID {
+		return httperror.NotFound("Unable to find a report with the specified identifier inside the database", errors.New("the report belongs to another policy"))
+	}
+
+	return response.JSON(w, report)
+}
@@ -0,0 +1,51 @@
+package prunepolicies
+
+import (
+	"net/http"
+
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/request"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+)
+
+// @id PrunePolicyRun
+// @summary Run a prune policy now
+// @description Start a run of a prune policy on each of its environments, including a paused policy. The runs
+// @description complete in the background and their reports are updated with the removed resources, an environment
+// @description still running the previous run of the policy is skipped. Use dryRun=true to preview what the policy
+// @description would remove.
+// @description **Access policy**: administrator
+// @tags prune_policies
+// @security ApiKeyAuth
+// @security jwt
+// @produce json
+// @param id path int true "Prune policy identifier"
+// @param dryRun query bool false "Only report the resources which would be removed, the dry run mode of the policy by default"
+// @success 200 {array} portainer.PruneReport "Success"
+// @failure 400 "Invalid request"
+// @failure 404 "Prune policy not found"
+// @failure 500 "Server error"
+// @router /prune_policies/{id}/run [post]
+func (handler *Handler) prunePolicyRun(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	policy, httpErr := handler.readPrunePolicy(r)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	var dryRun *bool
+	if r.URL.Query().Has("dryRun") {
+		value, err := request.RetrieveBooleanQueryParameter(r, "dryRun", false)
+		if err != nil {
+			return httperror.BadRequest("Invalid query parameter: dryRun", err)
+		}
+
+		dryRun = &value
+	}
+
+	reports, err := handler.PrunePolicyService.Run(policy.ID, true, dryRun)
+	if err != nil {
+		return httperror.InternalServerError("Unable to run the prune policy", err)
+	}
+
+	return response.JSON(w, reports)
+}
@@ -0,0 +1,172 @@
+package prunepolicies
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+	"github.com/portainer/portainer/api/docker/prunepolicies"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/request"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+)
+
+type prunePolicyUpdatePayload struct {
+	// Docker environments pruned by the policy
+	Endpoints []portainer.EndpointID `example:"1"`
+	// Environment groups whose Docker environments are pruned by the policy, including the ones added later
+	EndpointGroups []portainer.EndpointGroupID `example:"1"`
+	// Standard cron expression of the schedule, with five fields
+	CronExpression string `validate:"required" example:"0 3 * * *"`
+	// Pause the scheduled runs
+	Paused bool `example:"false"`
+	// Only report the resources the scheduled runs would remove
+	DryRun bool `example:"false"`
+	// Remove the dangling images, the untagged ones not used by any container
+	Images bool `example:"true"`
+	// Remove all the images not used by any container rather than only the dangling ones, requires Images
+	AllImages bool `example:"false"`
+	// Remove the volumes not mounted by any container. The data of the volumes is lost
+	Volumes bool `example:"false"`
+	// Remove the networks without any container attached
+	Networks bool `example:"true"`
+	// Only remove the resources created longer ago than this duration, e.g. 168h. Empty to remove them regardless of
+	// their age
+	OlderThan string `example:"168h"`
+}
+
+func (payload *prunePolicyUpdatePayload) Validate(r *http.Request) error {
+	if _, err := prunepolicies.ParseSchedule(payload.CronExpression); err != nil {
+		return fmt.Errorf("Invalid cron expression: %w", err)
+	}
+
+	if len(payload.Endpoints) == 0 && len(payload.EndpointGroups) == 0 {
+		return errors.New("At least one environment or environment group is required")
+	}
+
+	if !payload.Images && !payload.Volumes && !payload.Networks {
+		return errors.New("At least one of images, volumes or networks must be pruned")
+	}
+
+	if payload.AllImages && !payload.Images {
+		return errors.New("All the images can only be pruned when the images are pruned")
+	}
+
+	if payload.OlderThan != "" {
+		if olderThan, err := time.ParseDuration(payload.OlderThan); err != nil || olderThan <= 0 {
+			return errors.New("Invalid age. Must be a positive duration, e.g. 168h")
+		}
+	}
+
+	return nil
+}
+
+// apply sets the targets, the schedule and the resources of the policy
+func (payload *prunePolicyUpdatePayload) apply(policy *portainer.PrunePolicy) {
+	policy.Endpoints = slices.Compact(slices.Sorted(slices.Values(payload.Endpoints)))
+	policy.EndpointGroups = slices.Compact(slices.Sorted(slices.Values(payload.EndpointGroups)))
+	policy.CronExpression = payload.CronExpression
+	policy.Paused = payload.Paused
+	policy.DryRun = payload.DryRun
+	policy.Images = payload.Images
+	policy.AllImages = payload.AllImages
+	policy.Volumes = payload.Volumes
+	policy.Networks = payload.Networks
+	policy.OlderThan = payload.OlderThan
+}
+
+// @id PrunePolicyUpdate
+// @summary Update a prune policy
+// @description Update the targets, the schedule and the resources of a prune policy. The runs in progress are not
+// @description stopped.
+// @description **Access policy**: administrator
+// @tags prune_policies
+// @security ApiKeyAuth
+// @security jwt
+// @accept json
+// @produce json
+// @param id path int true "Prune policy identifier"
+// @param body body prunePolicyUpdatePayload true "Prune policy details"
+// @success 200 {object} prunePolicyResponse "Success"
+// @failure 400 "Invalid request"
+// @failure 404 "Prune policy not found"
+// @failure 500 "Server error"
+// @router /prune_policies/{id} [put]
+func (handler *Handler) prunePolicyUpdate(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	var payload prunePolicyUpdatePayload
+	if err := request.DecodeAndValidateJSONPayload(r, &payload); err != nil {
+		return httperror.BadRequest("Invalid request payload", err)
+	}
+
+	policy, httpErr := handler.readPrunePolicy(r)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	if err := handler.DataStore.UpdateTx(func(tx dataservices.DataStoreTx) error {
+		if err := checkTargets(tx, payload.Endpoints, payload.EndpointGroups); err != nil {
+			return err
+		}
+
+		payload.apply(policy)
+
+		return tx.PrunePolicy().Update(policy.ID, policy)
+	}); err != nil {
+		return prunePolicyPersistError(err)
+	}
+
+	if err := handler.PrunePolicyService.Schedule(policy); err != nil {
+		return httperror.InternalServerError("Unable to schedule the prune policy", err)
+	}
+
+	return response.JSON(w, newPrunePolicyResponse(policy))
+}
+
+// targetError is returned when a policy targets an environment or a group it cannot run on
+type targetError struct {
+	err error
+}
+
+func (e *targetError) Error() string {
+	return e.err.Error()
+}
+
+// checkTargets checks that the environments and the environment groups exist and that the policies can run on the
+// environments
+func checkTargets(tx dataservices.DataStoreTx, endpointIDs []portainer.EndpointID, groupIDs []portainer.EndpointGroupID) error {
+	for _, endpointID := range endpointIDs {
+		endpoint, err := tx.Endpoint().Endpoint(endpointID)
+		if tx.IsErrObjectNotFound(err) {
+			return &targetError{err: fmt.Errorf("environment %d: the environment does not exist", endpointID)}
+		} else if err != nil {
+			return err
+		}
+
+		if err := prunepolicies.CheckEndpoint(endpoint); err != nil {
+			return &targetError{err: fmt.Errorf("environment %d: %w", endpointID, err)}
+		}
+	}
+
+	for _, groupID := range groupIDs {
+		if _, err := tx.EndpointGroup().Read(groupID); tx.IsErrObjectNotFound(err) {
+			return &targetError{err: fmt.Errorf("environment group %d: the group does not exist", groupID)}
+		} else if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func prunePolicyPersistError(err error) *httperror.HandlerError {
+	var targetErr *targetError
+	if errors.As(err, &targetErr) {
+		return httperror.BadRequest("Invalid environment", err)
+	}
+
+	return httperror.InternalServerError("Unable to persist the prune policy inside the database", err)
+}
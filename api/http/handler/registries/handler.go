@@ -2,15 +2,21 @@ package registries
 
 import (
 	"net/http"
+	"regexp"
+	"slices"
+	"strings"
 
 	portainer "github.com/portainer/portainer/api"
 	"github.com/portainer/portainer/api/dataservices"
+	dockerclient "github.com/portainer/portainer/api/docker/client"
 	"github.com/portainer/portainer/api/http/proxy"
 	"github.com/portainer/portainer/api/http/security"
+	"github.com/portainer/portainer/api/internal/authorization"
 	"github.com/portainer/portainer/api/internal/endpointutils"
 	"github.com/portainer/portainer/api/kubernetes"
 	"github.com/portainer/portainer/api/kubernetes/cli"
 	"github.com/portainer/portainer/api/pendingactions"
+	"github.com/portainer/portainer/api/registrycredentials"
 	httperror "github.com/portainer/portainer/pkg/libhttp/error"
 	"github.com/portainer/portainer/pkg/libhttp/request"
 
@@ -21,7 +27,13 @@ import (
 
 func hideFields(registry *portainer.Registry, hideAccesses bool) {
 	registry.Password = ""
+	registry.AccessToken = ""
 	registry.ManagementConfiguration = nil
+	for teamID, robot := range registry.Harbor.TeamRobots {
+		robot.Secret = ""
+		registry.Harbor.TeamRobots[teamID] = robot
+	}
+	registrycredentials.HideSecrets(registry.CredentialProvider)
 	if hideAccesses {
 		registry.RegistryAccesses = nil
 	}
@@ -34,8 +46,10 @@ type Handler struct {
 	DataStore             dataservices.DataStore
 	FileService           portainer.FileService
 	ProxyManager          *proxy.Manager
+	DockerClientFactory   *dockerclient.ClientFactory
 	K8sClientFactory      *cli.ClientFactory
 	PendingActionsService *pendingactions.PendingActionsService
+	CredentialService     *registrycredentials.Service
 }
 
 // NewHandler creates a handler to manage registry operations.
@@ -65,6 +79,19 @@ func (handler *Handler) initRouter(bouncer accessGuard) {
 	adminRouter.Handle("/registries/{id}", httperror.LoggerHandler(handler.registryUpdate)).Methods(http.MethodPut)
 	adminRouter.Handle("/registries/{id}/configure", httperror.LoggerHandler(handler.registryConfigure)).Methods(http.MethodPost)
 	adminRouter.Handle("/registries/{id}", httperror.LoggerHandler(handler.registryDelete)).Methods(http.MethodDelete)
+	adminRouter.Handle("/registries/{id}/catalog", httperror.LoggerHandler(handler.registryCatalog)).Methods(http.MethodGet)
+	adminRouter.Handle("/registries/{id}/tags", httperror.LoggerHandler(handler.registryTags)).Methods(http.MethodGet)
+	adminRouter.Handle("/registries/{id}/tags", httperror.LoggerHandler(handler.registryTagDelete)).Methods(http.MethodDelete)
+	adminRouter.Handle("/registries/{id}/manifests", httperror.LoggerHandler(handler.registryManifestDelete)).Methods(http.MethodDelete)
+	adminRouter.Handle("/registries/{id}/gc", httperror.LoggerHandler(handler.registryGarbageCollect)).Methods(http.MethodPost)
+	adminRouter.Handle("/registries/{id}/gc/schedule", httperror.LoggerHandler(handler.registryGCScheduleInspect)).Methods(http.MethodGet)
+	adminRouter.Handle("/registries/{id}/gc/schedule", httperror.LoggerHandler(handler.registryGCScheduleUpdate)).Methods(http.MethodPut)
+	adminRouter.Handle("/registries/{id}/harbor/projects", httperror.LoggerHandler(handler.registryHarborProjects)).Methods(http.MethodGet)
+	adminRouter.Handle("/registries/{id}/harbor/teams", httperror.LoggerHandler(handler.registryHarborTeamsUpdate)).Methods(http.MethodPut)
+	adminRouter.Handle("/registries/{id}/harbor/sync", httperror.LoggerHandler(handler.registryHarborSync)).Methods(http.MethodPost)
+	adminRouter.Handle("/registries/{id}/credential-provider", httperror.LoggerHandler(handler.registryCredentialProviderUpdate)).Methods(http.MethodPut)
+	adminRouter.Handle("/registries/{id}/credential-provider", httperror.LoggerHandler(handler.registryCredentialProviderDelete)).Methods(http.MethodDelete)
+	adminRouter.Handle("/registries/{id}/credential-provider/refresh", httperror.LoggerHandler(handler.registryCredentialsRefresh)).Methods(http.MethodPost)
 
 	authenticatedRouter.Handle("/registries/{id}", httperror.LoggerHandler(handler.registryInspect)).Methods(http.MethodGet)
 	authenticatedRouter.PathPrefix("/registries/proxies/gitlab").Handler(httperror.LoggerHandler(handler.proxyRequestsToGitlabAPIWithoutRegistry))
@@ -76,6 +103,76 @@ type accessGuard interface {
 	AuthorizedEndpointOperation(r *http.Request, endpoint *portainer.Endpoint) error
 }
 
+// validateEcrData checks the role assumed by an ECR registry
+func validateEcrData(ecr portainer.EcrData) error {
+	if ecr.RoleARN != "" && !strings.HasPrefix(ecr.RoleARN, "arn:") {
+		return errors.New("invalid role ARN, it must start with arn:")
+	}
+
+	if ecr.ExternalID != "" && ecr.RoleARN == "" {
+		return errors.New("an external ID requires a role ARN")
+	}
+
+	return nil
+}
+
+var (
+	// defaultRegistryURLs are the URLs of the registries of the registry types of a single service
+	defaultRegistryURLs = map[portainer.RegistryType]string{
+		portainer.QuayRegistry:   "quay.io",
+		portainer.GitHubRegistry: "ghcr.io",
+	}
+
+	// githubTokenPrefixes are the prefixes of the tokens of GitHub accepted by the GitHub Container Registry: personal
+	// access tokens, fine-grained personal access tokens, OAuth, user-to-server and GitHub Actions tokens
+	githubTokenPrefixes = []string{"ghp_", "github_pat_", "gho_", "ghu_", "ghs_"}
+
+	githubOwnerPattern = regexp.MustCompile(`^[A-Za-z0-9](?:[A-Za-z0-9-]{0,38})$`)
+	quayRobotPattern   = regexp.MustCompile(`^[a-z0-9_]+\+[a-z0-9_]+$`)
+)
+
+// validateGitHubData checks the owner and the credentials of a GitHub Container Registry, which only accepts GitHub
+// tokens as passwords
+func validateGitHubData(github portainer.GitHubRegistryData, password string) error {
+	if github.Owner != "" && !githubOwnerPattern.MatchString(github.Owner) {
+		return errors.New("invalid GitHub owner, it must be the login of an organization or a user")
+	}
+
+	if password != "" && !slices.ContainsFunc(githubTokenPrefixes, func(prefix string) bool { return strings.HasPrefix(password, prefix) }) {
+		return errors.New("invalid credentials. The password of a GitHub Container Registry must be a personal access token with the read:packages scope")
+	}
+
+	return nil
+}
+
+// validateQuayData checks the organization and the robot account of a Quay registry, whose username is
+// namespace+name
+func validateQuayData(quay portainer.QuayRegistryData, username string) error {
+	if quay.UseOrganisation && quay.OrganisationName == "" {
+		return errors.New("invalid Quay organisation name, it must be specified when an organisation is used")
+	}
+
+	if strings.Contains(username, "+") && !quayRobotPattern.MatchString(username) {
+		return errors.New("invalid Quay robot account. Its username must be namespace+name")
+	}
+
+	return nil
+}
+
+// quayNamespace returns the namespace of the repositories of a Quay registry: its organization, the namespace of its
+// robot account or its user
+func quayNamespace(reg *portainer.Registry) string {
+	if reg.Quay.UseOrganisation && reg.Quay.OrganisationName != "" {
+		return reg.Quay.OrganisationName
+	}
+
+	if namespace, _, ok := strings.Cut(reg.Username, "+"); ok {
+		return namespace
+	}
+
+	return reg.Username
+}
+
 func (handler *Handler) registriesHaveSameURLAndCredentials(r1, r2 *portainer.Registry) bool {
 	hasSameUrl := r1.URL == r2.URL
 	hasSameCredentials := r1.Authentication == r2.Authentication && (!r1.Authentication || (r1.Authentication && r1.Username == r2.Username))
@@ -128,7 +225,7 @@ func (handler *Handler) userHasRegistryAccess(r *http.Request, registry *portain
 		return false, false, err
 	}
 
-	memberships, err := handler.DataStore.TeamMembership().TeamMembershipsByUserID(user.ID)
+	memberships, err := authorization.EffectiveTeamMemberships(handler.DataStore, user.ID)
 	if err != nil {
 		return false, false, nil
 	}
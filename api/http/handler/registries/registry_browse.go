@@ -0,0 +1,249 @@
+package registries
+
+import (
+	"context"
+	"crypto/tls"
+	"net/http"
+	"strings"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/crypto"
+	"github.com/portainer/portainer/api/docker/registry"
+	"github.com/portainer/portainer/api/internal/registryutils"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/request"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+)
+
+// githubAPIURL is the URL of the GitHub API listing the repositories of the GitHub Container Registries
+var githubAPIURL = registry.GitHubAPIURL
+
+const (
+	defaultCatalogPageSize = 100
+	maxCatalogPageSize     = 1000
+	defaultTagsPageSize    = 20
+	maxTagsPageSize        = 100
+)
+
+// @id RegistryCatalog
+// @summary List the repositories of a registry
+// @description List a page of the repositories of a registry, in lexical order, through the catalog API of the registry.
+// @description The next page is retrieved by passing the Next value of a page as last, Next being empty on the last page.
+// @description The registries that do not implement the catalog API, like Docker Hub or ECR, cannot be browsed.
+// @description The repositories of a GitHub Container Registry are the container packages of its owner, listed through the
+// @description GitHub API. The repositories of a Quay registry are the ones of its organization, of the namespace of its
+// @description robot account or of its user.
+// @description **Access policy**: administrator
+// @tags registries
+// @security ApiKeyAuth
+// @security jwt
+// @produce json
+// @param id path int true "Registry identifier"
+// @param n query int false "Number of repositories of the page, 100 by default and 1000 at most"
+// @param last query string false "Last repository of the previous page"
+// @success 200 {object} registry.CatalogPage "Success"
+// @failure 400 "Invalid request"
+// @failure 404 "Registry not found"
+// @failure 502 "The registry could not be browsed"
+// @failure 500 "Server error"
+// @router /registries/{id}/catalog [get]
+func (handler *Handler) registryCatalog(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	n, err := pageSize(r, defaultCatalogPageSize, maxCatalogPageSize)
+	if err != nil {
+		return httperror.BadRequest("Invalid query parameter: n", err)
+	}
+
+	last, _ := request.RetrieveQueryParameter(r, "last", true)
+
+	reg, client, httpErr := handler.registryBrowserClient(r)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	var page *registry.CatalogPage
+
+	switch reg.Type {
+	case portainer.GitHubRegistry:
+		var repositories []string
+		if repositories, err = client.GitHubRepositories(r.Context(), githubAPIURL, reg.GitHub.Owner); err == nil {
+			page = registry.PageRepositories(repositories, n, last)
+		}
+	case portainer.QuayRegistry:
+		var repositories []string
+		if repositories, err = client.Repositories(r.Context()); err == nil {
+			page = registry.PageRepositories(filterNamespace(repositories, quayNamespace(reg)), n, last)
+		}
+	default:
+		page, err = client.Catalog(r.Context(), n, last)
+	}
+
+	if err != nil {
+		return registryBrowseError("Unable to list the repositories of the registry", err)
+	}
+
+	return response.JSON(w, page)
+}
+
+// @id RegistryTags
+// @summary List the tags of a repository
+// @description List a page of the tags of a repository of a registry with the metadata of their manifest: digest,
+// @description compressed size, creation date and architectures. The size and the creation date of a multi-architecture
+// @description image are the ones of its first architecture. The tags whose metadata cannot be retrieved are listed with
+// @description an Error. The next page is retrieved by passing the Next value of a page as last.
+// @description **Access policy**: administrator
+// @tags registries
+// @security ApiKeyAuth
+// @security jwt
+// @produce json
+// @param id path int true "Registry identifier"
+// @param repository query string true "Name of the repository, e.g. portainer/agent"
+// @param n query int false "Number of tags of the page, 20 by default and 100 at most"
+// @param last query string false "Last tag of the previous page"
+// @success 200 {object} registry.TagsPage "Success"
+// @failure 400 "Invalid request"
+// @failure 404 "Registry not found"
+// @failure 502 "The registry could not be browsed"
+// @failure 500 "Server error"
+// @router /registries/{id}/tags [get]
+func (handler *Handler) registryTags(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	repository, httpErr := repositoryQueryParameter(r)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	n, err := pageSize(r, defaultTagsPageSize, maxTagsPageSize)
+	if err != nil {
+		return httperror.BadRequest("Invalid query parameter: n", err)
+	}
+
+	last, _ := request.RetrieveQueryParameter(r, "last", true)
+
+	_, client, httpErr := handler.registryBrowserClient(r)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	page, err := client.Tags(r.Context(), repository, n, last)
+	if err != nil {
+		return registryBrowseError("Unable to list the tags of the repository", err)
+	}
+
+	return response.JSON(w, page)
+}
+
+// registryBrowserClient returns the registry of the request and a client of its API, authenticated with the
+// credentials of the registry and using the TLS configuration of its management
+func (handler *Handler) registryBrowserClient(r *http.Request) (*portainer.Registry, *registry.Client, *httperror.HandlerError) {
+	reg, httpErr := handler.requestRegistry(r)
+	if httpErr != nil {
+		return nil, nil, httpErr
+	}
+
+	client, httpErr := handler.registryClient(reg)
+	if httpErr != nil {
+		return nil, nil, httpErr
+	}
+
+	return reg, client, nil
+}
+
+// isHarbor reports whether a registry is a Harbor registry, the custom registries being probed through the Harbor API
+func isHarbor(ctx context.Context, reg *portainer.Registry, client *registry.Client) bool {
+	return reg.Type == portainer.HarborRegistry || (reg.Type == portainer.CustomRegistry && client.IsHarbor(ctx))
+}
+
+// requestRegistry returns the registry of the id route variable of the request
+func (handler *Handler) requestRegistry(r *http.Request) (*portainer.Registry, *httperror.HandlerError) {
+	registryID, err := request.RetrieveNumericRouteVariableValue(r, "id")
+	if err != nil {
+		return nil, httperror.BadRequest("Invalid registry identifier route variable", err)
+	}
+
+	reg, err := handler.DataStore.Registry().Read(portainer.RegistryID(registryID))
+	if handler.DataStore.IsErrObjectNotFound(err) {
+		return nil, httperror.NotFound("Unable to find a registry with the specified identifier inside the database", err)
+	} else if err != nil {
+		return nil, httperror.InternalServerError("Unable to find a registry with the specified identifier inside the database", err)
+	}
+
+	return reg, nil
+}
+
+// registryClient returns a client of the API of a registry, authenticated with its credentials
+func (handler *Handler) registryClient(reg *portainer.Registry) (*registry.Client, *httperror.HandlerError) {
+	var err error
+	var username, password string
+	if reg.Authentication {
+		if err := registryutils.EnsureRegTokenValid(handler.DataStore, reg); err != nil {
+			return nil, httperror.InternalServerError("Unable to retrieve the token of the registry", err)
+		}
+
+		if username, password, err = registryutils.GetRegEffectiveCredential(reg); err != nil {
+			return nil, httperror.InternalServerError("Unable to retrieve the credentials of the registry", err)
+		}
+	}
+
+	var tlsConfig *tls.Config
+	if reg.ManagementConfiguration != nil && reg.ManagementConfiguration.TLSConfig.TLS {
+		config := reg.ManagementConfiguration.TLSConfig
+
+		if tlsConfig, err = crypto.CreateTLSConfigurationFromDisk(config.TLSCACertPath, config.TLSCertPath, config.TLSKeyPath, config.TLSSkipVerify); err != nil {
+			return nil, httperror.InternalServerError("Unable to load the TLS configuration of the registry", err)
+		}
+	}
+
+	return registry.NewClient(registryAPIURL(reg), username, password, tlsConfig), nil
+}
+
+// registryAPIURL returns the base URL of the API of a registry, its URL possibly including a namespace
+func registryAPIURL(reg *portainer.Registry) string {
+	registryURL := reg.URL
+	if reg.Type == portainer.ProGetRegistry && reg.BaseURL != "" {
+		registryURL = reg.BaseURL
+	}
+
+	scheme := "https://"
+	if strings.HasPrefix(registryURL, "http://") {
+		scheme = "http://"
+	}
+
+	host, _, _ := strings.Cut(strings.TrimPrefix(strings.TrimPrefix(registryURL, "https://"), "http://"), "/")
+	if reg.Type == portainer.DockerHubRegistry || host == "docker.io" {
+		host = "registry-1.docker.io"
+	}
+
+	return scheme + host
+}
+
+// filterNamespace returns the repositories of a namespace, all of them when the namespace is empty
+func filterNamespace(repositories []string, namespace string) []string {
+	if namespace == "" {
+		return repositories
+	}
+
+	filtered := make([]string, 0, len(repositories))
+	for _, repository := range repositories {
+		if strings.HasPrefix(repository, namespace+"/") {
+			filtered = append(filtered, repository)
+		}
+	}
+
+	return filtered
+}
+
+func pageSize(r *http.Request, defaultSize, maxSize int) (int, error) {
+	n, err := request.RetrieveNumericQueryParameter(r, "n", true)
+	if err != nil {
+		return 0, err
+	}
+
+	if n <= 0 {
+		return defaultSize, nil
+	}
+
+	return min(n, maxSize), nil
+}
+
+func registryBrowseError(message string, err error) *httperror.HandlerError {
+	return httperror.NewError(http.StatusBadGateway, message, err)
+}
@@ -0,0 +1,127 @@
+package registries
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/request"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+
+	"github.com/opencontainers/go-digest"
+)
+
+// @id RegistryTagDelete
+// @summary Delete a tag of a repository
+// @description Delete a tag of a repository of a registry.
+// @description On a Harbor registry, only the tag is deleted through the Harbor API. On the other registries, the Docker
+// @description Registry HTTP API V2 only deletes manifests: the manifest of the tag is deleted, along with every other
+// @description tag referencing it, and the registry must allow the deletions.
+// @description The storage is only reclaimed by the garbage collection of the registry.
+// @description **Access policy**: administrator
+// @tags registries
+// @security ApiKeyAuth
+// @security jwt
+// @param id path int true "Registry identifier"
+// @param repository query string true "Name of the repository, e.g. portainer/agent"
+// @param tag query string true "Tag to delete"
+// @success 204 "Success"
+// @failure 400 "Invalid request"
+// @failure 404 "Registry not found"
+// @failure 502 "The tag could not be deleted by the registry"
+// @failure 500 "Server error"
+// @router /registries/{id}/tags [delete]
+func (handler *Handler) registryTagDelete(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	repository, httpErr := repositoryQueryParameter(r)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	tag, err := request.RetrieveQueryParameter(r, "tag", false)
+	if err != nil {
+		return httperror.BadRequest("Invalid query parameter: tag", err)
+	}
+
+	reg, client, httpErr := handler.registryBrowserClient(r)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	if isHarbor(r.Context(), reg, client) {
+		err = client.HarborDeleteTag(r.Context(), repository, tag)
+	} else {
+		_, err = client.DeleteTag(r.Context(), repository, tag)
+	}
+
+	if err != nil {
+		return registryBrowseError("Unable to delete the tag", err)
+	}
+
+	return response.Empty(w)
+}
+
+// @id RegistryManifestDelete
+// @summary Delete a manifest of a repository
+// @description Delete a manifest of a repository of a registry by digest, along with every tag referencing it. On a
+// @description Harbor registry, the artifact of the digest is deleted through the Harbor API.
+// @description The storage is only reclaimed by the garbage collection of the registry.
+// @description **Access policy**: administrator
+// @tags registries
+// @security ApiKeyAuth
+// @security jwt
+// @param id path int true "Registry identifier"
+// @param repository query string true "Name of the repository, e.g. portainer/agent"
+// @param digest query string true "Digest of the manifest"
+// @success 204 "Success"
+// @failure 400 "Invalid request"
+// @failure 404 "Registry not found"
+// @failure 502 "The manifest could not be deleted by the registry"
+// @failure 500 "Server error"
+// @router /registries/{id}/manifests [delete]
+func (handler *Handler) registryManifestDelete(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	repository, httpErr := repositoryQueryParameter(r)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	value, err := request.RetrieveQueryParameter(r, "digest", false)
+	if err != nil {
+		return httperror.BadRequest("Invalid query parameter: digest", err)
+	}
+
+	manifestDigest, err := digest.Parse(value)
+	if err != nil {
+		return httperror.BadRequest("Invalid query parameter: digest", err)
+	}
+
+	reg, client, httpErr := handler.registryBrowserClient(r)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	if isHarbor(r.Context(), reg, client) {
+		err = client.HarborDeleteArtifact(r.Context(), repository, manifestDigest)
+	} else {
+		err = client.DeleteManifest(r.Context(), repository, manifestDigest)
+	}
+
+	if err != nil {
+		return registryBrowseError("Unable to delete the manifest", err)
+	}
+
+	return response.Empty(w)
+}
+
+func repositoryQueryParameter(r *http.Request) (string, *httperror.HandlerError) {
+	repository, err := request.RetrieveQueryParameter(r, "repository", false)
+	if err != nil {
+		return "", httperror.BadRequest("Invalid query parameter: repository", err)
+	}
+
+	if strings.Contains(repository, "..") || strings.HasPrefix(repository, "/") {
+		return "", httperror.BadRequest("Invalid query parameter: repository", errors.New("invalid repository name"))
+	}
+
+	return repository, nil
+}
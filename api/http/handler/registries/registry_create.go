@@ -4,10 +4,12 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"time"
 
 	portainer "github.com/portainer/portainer/api"
 	httperrors "github.com/portainer/portainer/api/http/errors"
 	"github.com/portainer/portainer/api/http/security"
+	"github.com/portainer/portainer/api/registrycredentials"
 	httperror "github.com/portainer/portainer/pkg/libhttp/error"
 	"github.com/portainer/portainer/pkg/libhttp/request"
 	"github.com/portainer/portainer/pkg/libhttp/response"
@@ -24,48 +26,85 @@ type registryCreatePayload struct {
 	//	5 (ProGet registry),
 	//	6 (DockerHub)
 	//	7 (ECR)
-	Type portainer.RegistryType `example:"1" validate:"required" enums:"1,2,3,4,5,6,7"`
-	// URL or IP address of the Docker registry
+	//	8 (Harbor)
+	//	9 (GitHub Container Registry)
+	Type portainer.RegistryType `example:"1" validate:"required" enums:"1,2,3,4,5,6,7,8,9"`
+	// URL or IP address of the Docker registry. Defaults to quay.io for Quay and to ghcr.io for the GitHub Container
+	// Registry
 	URL string `example:"registry.mydomain.tld:2375/feed" validate:"required"`
 	// BaseURL required for ProGet registry
 	BaseURL string `example:"registry.mydomain.tld:2375"`
 	// Is authentication against this registry enabled
 	Authentication bool `example:"false" validate:"required"`
-	// Username used to authenticate against this registry. Required when Authentication is true
+	// Username used to authenticate against this registry, the access key ID for ECR. Required when Authentication is true,
+	// unless the ECR registry uses the default AWS credentials
 	Username string `example:"registry_user"`
-	// Password used to authenticate against this registry. required when Authentication is true
+	// Password used to authenticate against this registry, the secret access key for ECR. Required when Authentication is
+	// true, unless the ECR registry uses the default AWS credentials
 	Password string `example:"registry_password"`
 	// Gitlab specific details, required when type = 4
 	Gitlab portainer.GitlabRegistryData
-	// Quay specific details, required when type = 1
+	// Quay specific details, required when type = 1. The username of a robot account is namespace+name
 	Quay portainer.QuayRegistryData
+	// GitHub Container Registry specific details, used when type = 9. The password must be a personal access token
+	GitHub portainer.GitHubRegistryData
 	// ECR specific details, required when type = 7
 	Ecr portainer.EcrData
+	// Secret manager the credentials are retrieved from instead of Username and Password, which then do not need to be
+	// specified
+	CredentialProvider *portainer.RegistryCredentialProvider
 }
 
 func (payload *registryCreatePayload) Validate(_ *http.Request) error {
 	if len(payload.Name) == 0 {
 		return errors.New("invalid registry name")
 	}
+	if len(payload.URL) == 0 {
+		payload.URL = defaultRegistryURLs[payload.Type]
+	}
 	if len(payload.URL) == 0 {
 		return errors.New("invalid registry URL")
 	}
 
 	if payload.Authentication {
-		if len(payload.Username) == 0 || len(payload.Password) == 0 {
+		useDefaultCredentials := payload.Type == portainer.EcrRegistry && payload.Ecr.UseDefaultCredentials
+		useProvider := payload.CredentialProvider != nil
+		if !useDefaultCredentials && !useProvider && (len(payload.Username) == 0 || len(payload.Password) == 0) {
 			return errors.New("invalid credentials. Username and password must be specified when authentication is enabled")
 		}
 		if payload.Type == portainer.EcrRegistry {
 			if len(payload.Ecr.Region) == 0 {
 				return errors.New("invalid credentials: access key ID, secret access key and region must be specified when authentication is enabled")
 			}
+
+			if err := validateEcrData(payload.Ecr); err != nil {
+				return err
+			}
+		}
+	}
+
+	if payload.CredentialProvider != nil {
+		if !payload.Authentication {
+			return errors.New("a credential provider requires authentication to be enabled")
+		}
+
+		if err := registrycredentials.Validate(payload.CredentialProvider); err != nil {
+			return err
 		}
 	}
 
 	switch payload.Type {
-	case portainer.QuayRegistry, portainer.AzureRegistry, portainer.CustomRegistry, portainer.GitlabRegistry, portainer.ProGetRegistry, portainer.DockerHubRegistry, portainer.EcrRegistry:
+	case portainer.QuayRegistry:
+		if err := validateQuayData(payload.Quay, payload.Username); err != nil {
+			return err
+		}
+	case portainer.GitHubRegistry:
+		if err := validateGitHubData(payload.GitHub, payload.Password); err != nil {
+			return err
+		}
+	case portainer.AzureRegistry, portainer.CustomRegistry, portainer.GitlabRegistry, portainer.ProGetRegistry, portainer.DockerHubRegistry, portainer.EcrRegistry, portainer.HarborRegistry:
 	default:
-		return errors.New("invalid registry type. Valid values are: 1 (Quay.io), 2 (Azure container registry), 3 (custom registry), 4 (Gitlab registry), 5 (ProGet registry), 6 (DockerHub), 7 (ECR)")
+		return errors.New("invalid registry type. Valid values are: 1 (Quay.io), 2 (Azure container registry), 3 (custom registry), 4 (Gitlab registry), 5 (ProGet registry), 6 (DockerHub), 7 (ECR), 8 (Harbor), 9 (GitHub Container Registry)")
 	}
 
 	if payload.Type == portainer.ProGetRegistry && payload.BaseURL == "" {
@@ -115,10 +154,24 @@ func (handler *Handler) registryCreate(w http.ResponseWriter, r *http.Request) *
 		Password:         payload.Password,
 		Gitlab:           payload.Gitlab,
 		Quay:             payload.Quay,
+		GitHub:           payload.GitHub,
 		RegistryAccesses: portainer.RegistryAccesses{},
 		Ecr:              payload.Ecr,
 	}
 
+	if provider := payload.CredentialProvider; provider != nil {
+		provider.LastRefresh, provider.LastError, provider.Events = 0, "", nil
+
+		creds, err := registrycredentials.Retrieve(r.Context(), provider)
+		if err != nil {
+			return httperror.BadRequest("Unable to retrieve the credentials from the credential provider", err)
+		}
+
+		registrycredentials.Apply(registry, creds)
+		provider.LastRefresh = time.Now().Unix()
+		registry.CredentialProvider = provider
+	}
+
 	registry.ManagementConfiguration = syncConfig(registry)
 
 	registries, err := handler.DataStore.Registry().ReadAll()
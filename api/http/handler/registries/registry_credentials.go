@@ -0,0 +1,159 @@
+package registries
+
+import (
+	"errors"
+	"net/http"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/registrycredentials"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/request"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+)
+
+type registryCredentialProviderPayload struct {
+	// Secret manager of the credentials. Valid values are: vault, awsSecretsManager
+	Type portainer.RegistryCredentialProviderType `example:"vault" validate:"required" enums:"vault,awsSecretsManager"`
+	// Secret of the KV secrets engine of HashiCorp Vault, required when the type is vault. The token and the AppRole
+	// secret ID are kept when they are empty
+	Vault *portainer.VaultSecretSource
+	// Secret of AWS Secrets Manager, required when the type is awsSecretsManager. The secret access key is kept when it
+	// is empty
+	AWSSecretsManager *portainer.AWSSecretsManagerSecretSource
+	// Key of the username in the secret, username by default
+	UsernameKey string `example:"username"`
+	// Key of the password in the secret, password by default
+	PasswordKey string `example:"password"`
+	// Interval between the refreshes of the credentials, 1h by default and at least 5m
+	RefreshInterval string `example:"1h"`
+}
+
+func (payload *registryCredentialProviderPayload) Validate(r *http.Request) error {
+	switch payload.Type {
+	case portainer.VaultCredentialProvider, portainer.AWSSecretsManagerCredentialProvider:
+		return nil
+	}
+
+	return errors.New("invalid credential provider type. Valid values are: vault, awsSecretsManager")
+}
+
+// @id RegistryCredentialProviderUpdate
+// @summary Source the credentials of a registry from a secret manager
+// @description Source the credentials of a registry from HashiCorp Vault or AWS Secrets Manager. The credentials are
+// @description retrieved right away and then refreshed on the interval of the provider, the registry secrets of the
+// @description Kubernetes namespaces using the registry being updated when they are rotated.
+// @description **Access policy**: administrator
+// @tags registries
+// @security ApiKeyAuth
+// @security jwt
+// @accept json
+// @produce json
+// @param id path int true "Registry identifier"
+// @param body body registryCredentialProviderPayload true "Credential provider"
+// @success 200 {object} portainer.Registry "Success"
+// @failure 400 "Invalid request or the credentials could not be retrieved"
+// @failure 404 "Registry not found"
+// @failure 500 "Server error"
+// @router /registries/{id}/credential-provider [put]
+func (handler *Handler) registryCredentialProviderUpdate(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	var payload registryCredentialProviderPayload
+	if err := request.DecodeAndValidateJSONPayload(r, &payload); err != nil {
+		return httperror.BadRequest("Invalid request payload", err)
+	}
+
+	registry, httpErr := handler.requestRegistry(r)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	provider := &portainer.RegistryCredentialProvider{
+		Type:              payload.Type,
+		Vault:             payload.Vault,
+		AWSSecretsManager: payload.AWSSecretsManager,
+		UsernameKey:       payload.UsernameKey,
+		PasswordKey:       payload.PasswordKey,
+		RefreshInterval:   payload.RefreshInterval,
+	}
+
+	registrycredentials.MergeSecrets(provider, registry.CredentialProvider)
+
+	if err := registrycredentials.Validate(provider); err != nil {
+		return httperror.BadRequest("Invalid request payload", err)
+	}
+
+	if err := handler.CredentialService.Configure(r.Context(), registry, provider); err != nil {
+		if errors.Is(err, registrycredentials.ErrRetrieve) {
+			return httperror.BadRequest("Unable to retrieve the credentials from the credential provider", err)
+		}
+
+		return httperror.InternalServerError("Unable to update the credentials of the registry", err)
+	}
+
+	hideFields(registry, false)
+
+	return response.JSON(w, registry)
+}
+
+// @id RegistryCredentialProviderDelete
+// @summary Stop sourcing the credentials of a registry from a secret manager
+// @description Stop sourcing the credentials of a registry from a secret manager, the last credentials retrieved being
+// @description kept as the static credentials of the registry.
+// @description **Access policy**: administrator
+// @tags registries
+// @security ApiKeyAuth
+// @security jwt
+// @param id path int true "Registry identifier"
+// @success 204 "Success"
+// @failure 400 "Invalid request"
+// @failure 404 "Registry not found"
+// @failure 500 "Server error"
+// @router /registries/{id}/credential-provider [delete]
+func (handler *Handler) registryCredentialProviderDelete(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	registry, httpErr := handler.requestRegistry(r)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	registry.CredentialProvider = nil
+
+	if err := handler.DataStore.Registry().Update(registry.ID, registry); err != nil {
+		return httperror.InternalServerError("Unable to persist registry changes inside the database", err)
+	}
+
+	return response.Empty(w)
+}
+
+// @id RegistryCredentialsRefresh
+// @summary Refresh the credentials of a registry from its secret manager
+// @description Retrieve the credentials of a registry from its secret manager without waiting for the refresh
+// @description interval. A failure is recorded as an event of the credential provider of the registry.
+// @description **Access policy**: administrator
+// @tags registries
+// @security ApiKeyAuth
+// @security jwt
+// @produce json
+// @param id path int true "Registry identifier"
+// @success 200 {object} portainer.RegistryCredentialProvider "Success"
+// @failure 400 "The registry has no credential provider"
+// @failure 404 "Registry not found"
+// @failure 502 "The credentials could not be refreshed"
+// @failure 500 "Server error"
+// @router /registries/{id}/credential-provider/refresh [post]
+func (handler *Handler) registryCredentialsRefresh(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	registry, httpErr := handler.requestRegistry(r)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	if registry.CredentialProvider == nil {
+		return httperror.BadRequest("The registry has no credential provider", errors.New("no credential provider"))
+	}
+
+	if err := handler.CredentialService.Refresh(r.Context(), registry); err != nil {
+		return httperror.NewError(http.StatusBadGateway, "Unable to refresh the credentials of the registry", err)
+	}
+
+	registrycredentials.HideSecrets(registry.CredentialProvider)
+
+	return response.JSON(w, registry.CredentialProvider)
+}
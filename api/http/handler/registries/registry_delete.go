@@ -1,6 +1,7 @@
 package registries
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 
@@ -51,10 +52,31 @@ func (handler *Handler) registryDelete(w http.ResponseWriter, r *http.Request) *
 	}
 
 	handler.deleteKubernetesSecrets(registry)
+	handler.deleteHarborRobots(r.Context(), registry)
 
 	return response.Empty(w)
 }
 
+// deleteHarborRobots deletes the robot accounts created for the teams of a Harbor registry
+func (handler *Handler) deleteHarborRobots(ctx context.Context, registry *portainer.Registry) {
+	if registry.Type != portainer.HarborRegistry || len(registry.Harbor.TeamRobots) == 0 {
+		return
+	}
+
+	client, httpErr := handler.registryClient(registry)
+	if httpErr != nil {
+		log.Warn().Err(httpErr.Err).Msg("unable to create a client of the Harbor registry to delete its robot accounts")
+
+		return
+	}
+
+	for _, robot := range registry.Harbor.TeamRobots {
+		if err := client.HarborDeleteRobot(ctx, robot.ID); err != nil {
+			log.Warn().Err(err).Str("robot", robot.Name).Msg("unable to delete the robot account of the Harbor registry")
+		}
+	}
+}
+
 func (handler *Handler) deleteKubernetesSecrets(registry *portainer.Registry) {
 	for endpointId, access := range registry.RegistryAccesses {
 		if access.Namespaces != nil {
@@ -0,0 +1,300 @@
+package registries
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"path"
+	"sync"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/internal/endpointutils"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/request"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/client"
+	"github.com/docker/docker/pkg/stdcopy"
+	"github.com/robfig/cron/v3"
+)
+
+const (
+	defaultRegistryConfigPath = "/etc/docker/registry/config.yml"
+	// maxGCOutputSize is the size of the end of the output of the garbage collection returned to the user
+	maxGCOutputSize = 64 * 1024
+)
+
+// harborCronParser parses the cron expressions of Harbor, which have a seconds field
+var harborCronParser = cron.NewParser(cron.Second | cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow)
+
+type registryGCPayload struct {
+	// Environment running the container of a self-hosted distribution registry. Ignored for a Harbor registry
+	EndpointID portainer.EndpointID `example:"1"`
+	// Identifier or name of the container of the registry on the environment. Ignored for a Harbor registry
+	ContainerID string `example:"registry"`
+	// Path of the configuration file of the registry in the container, /etc/docker/registry/config.yml by default
+	ConfigPath string `example:"/etc/docker/registry/config.yml"`
+	// Delete the manifests that are not tagged anymore
+	DeleteUntagged bool `example:"true"`
+	// Only report what would be deleted. Ignored for a Harbor registry
+	DryRun bool `example:"false"`
+}
+
+func (payload *registryGCPayload) Validate(r *http.Request) error {
+	if payload.ConfigPath != "" && (!path.IsAbs(payload.ConfigPath) || path.Clean(payload.ConfigPath) != payload.ConfigPath) {
+		return errors.New("invalid configuration path, it must be an absolute path")
+	}
+
+	return nil
+}
+
+type registryGCResponse struct {
+	// End of the output of the garbage collection of a self-hosted registry. Empty for a Harbor registry, which runs
+	// the garbage collection in the background
+	Output string `example:"12 blobs eligible for deletion"`
+}
+
+type registryGCSchedulePayload struct {
+	// Cron expression of the schedule with a seconds field, e.g. 0 0 2 * * * for every day at 2am. An empty expression
+	// removes the schedule
+	Cron string `example:"0 0 2 * * *"`
+	// Delete the manifests that are not tagged anymore
+	DeleteUntagged bool `example:"true"`
+}
+
+func (payload *registryGCSchedulePayload) Validate(r *http.Request) error {
+	if payload.Cron == "" {
+		return nil
+	}
+
+	if _, err := harborCronParser.Parse(payload.Cron); err != nil {
+		return fmt.Errorf("invalid cron expression: %w", err)
+	}
+
+	return nil
+}
+
+// @id RegistryGarbageCollect
+// @summary Run the garbage collection of a registry
+// @description Run the garbage collection of a registry, which reclaims the storage of the deleted manifests.
+// @description A Harbor registry runs the garbage collection in the background through the Harbor API.
+// @description A self-hosted distribution registry has no API for it: the garbage collection is run in the container of
+// @description the registry on a Docker environment, and the output is returned when it completes. The registry should
+// @description be in read-only mode while it runs, as the blobs uploaded meanwhile can be deleted.
+// @description **Access policy**: administrator
+// @tags registries
+// @security ApiKeyAuth
+// @security jwt
+// @accept json
+// @produce json
+// @param id path int true "Registry identifier"
+// @param body body registryGCPayload true "Garbage collection options"
+// @success 200 {object} registryGCResponse "Success"
+// @failure 400 "Invalid request"
+// @failure 404 "Registry or environment not found"
+// @failure 502 "The garbage collection could not be run"
+// @failure 500 "Server error"
+// @router /registries/{id}/gc [post]
+func (handler *Handler) registryGarbageCollect(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	var payload registryGCPayload
+	if err := request.DecodeAndValidateJSONPayload(r, &payload); err != nil {
+		return httperror.BadRequest("Invalid request payload", err)
+	}
+
+	reg, registryClient, httpErr := handler.registryBrowserClient(r)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	if isHarbor(r.Context(), reg, registryClient) {
+		if err := registryClient.HarborRunGC(r.Context(), payload.DeleteUntagged); err != nil {
+			return registryBrowseError("Unable to start the garbage collection of the registry", err)
+		}
+
+		return response.JSON(w, registryGCResponse{})
+	}
+
+	if reg.Type != portainer.CustomRegistry {
+		return httperror.BadRequest("The garbage collection can only be run on Harbor and self-hosted registries", errors.New("unsupported registry type"))
+	}
+
+	if payload.EndpointID == 0 || payload.ContainerID == "" {
+		return httperror.BadRequest("Invalid request payload", errors.New("the environment and the container of a self-hosted registry are required"))
+	}
+
+	endpoint, err := handler.DataStore.Endpoint().Endpoint(payload.EndpointID)
+	if handler.DataStore.IsErrObjectNotFound(err) {
+		return httperror.NotFound("Unable to find an environment with the specified identifier inside the database", err)
+	} else if err != nil {
+		return httperror.InternalServerError("Unable to find an environment with the specified identifier inside the database", err)
+	}
+
+	if !endpointutils.IsDockerEndpoint(endpoint) {
+		return httperror.BadRequest("The garbage collection of a self-hosted registry requires a Docker environment", errors.New("unsupported environment type"))
+	}
+
+	cli, err := handler.DockerClientFactory.CreateClient(endpoint, "", nil)
+	if err != nil {
+		return httperror.InternalServerError("Unable to create Docker client", err)
+	}
+	defer cli.Close()
+
+	output, err := garbageCollect(r.Context(), cli, payload)
+	if err != nil {
+		return registryBrowseError("Unable to run the garbage collection of the registry", err)
+	}
+
+	return response.JSON(w, registryGCResponse{Output: output})
+}
+
+// @id RegistryGarbageCollectionSchedule
+// @summary Retrieve the garbage collection schedule of a Harbor registry
+// @description **Access policy**: administrator
+// @tags registries
+// @security ApiKeyAuth
+// @security jwt
+// @produce json
+// @param id path int true "Registry identifier"
+// @success 200 {object} registry.GCSchedule "Success"
+// @failure 400 "The registry is not a Harbor registry"
+// @failure 404 "Registry not found"
+// @failure 502 "The schedule could not be retrieved"
+// @failure 500 "Server error"
+// @router /registries/{id}/gc/schedule [get]
+func (handler *Handler) registryGCScheduleInspect(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	reg, registryClient, httpErr := handler.registryBrowserClient(r)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	if !isHarbor(r.Context(), reg, registryClient) {
+		return errGCScheduleUnsupported()
+	}
+
+	schedule, err := registryClient.HarborGCSchedule(r.Context())
+	if err != nil {
+		return registryBrowseError("Unable to retrieve the garbage collection schedule of the registry", err)
+	}
+
+	return response.JSON(w, schedule)
+}
+
+// @id RegistryGarbageCollectionScheduleUpdate
+// @summary Schedule the garbage collection of a Harbor registry
+// @description Schedule the garbage collection of a Harbor registry, which runs it in the background.
+// @description The self-hosted distribution registries cannot be scheduled, their garbage collection requiring the
+// @description registry to be in read-only mode.
+// @description **Access policy**: administrator
+// @tags registries
+// @security ApiKeyAuth
+// @security jwt
+// @accept json
+// @param id path int true "Registry identifier"
+// @param body body registryGCSchedulePayload true "Garbage collection schedule"
+// @success 204 "Success"
+// @failure 400 "Invalid request"
+// @failure 404 "Registry not found"
+// @failure 502 "The schedule could not be updated"
+// @failure 500 "Server error"
+// @router /registries/{id}/gc/schedule [put]
+func (handler *Handler) registryGCScheduleUpdate(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	var payload registryGCSchedulePayload
+	if err := request.DecodeAndValidateJSONPayload(r, &payload); err != nil {
+		return httperror.BadRequest("Invalid request payload", err)
+	}
+
+	reg, registryClient, httpErr := handler.registryBrowserClient(r)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	if !isHarbor(r.Context(), reg, registryClient) {
+		return errGCScheduleUnsupported()
+	}
+
+	if err := registryClient.HarborSetGCSchedule(r.Context(), payload.Cron, payload.DeleteUntagged); err != nil {
+		return registryBrowseError("Unable to update the garbage collection schedule of the registry", err)
+	}
+
+	return response.Empty(w)
+}
+
+func errGCScheduleUnsupported() *httperror.HandlerError {
+	return httperror.BadRequest("Only the garbage collection of the Harbor registries can be scheduled", errors.New("unsupported registry"))
+}
+
+// garbageCollect runs the garbage collection of the distribution registry running in a container and returns the
+// end of its output
+func garbageCollect(ctx context.Context, cli *client.Client, payload registryGCPayload) (string, error) {
+	configPath := payload.ConfigPath
+	if configPath == "" {
+		configPath = defaultRegistryConfigPath
+	}
+
+	cmd := []string{"registry", "garbage-collect"}
+	if payload.DeleteUntagged {
+		cmd = append(cmd, "--delete-untagged")
+	}
+	if payload.DryRun {
+		cmd = append(cmd, "--dry-run")
+	}
+	cmd = append(cmd, configPath)
+
+	exec, err := cli.ContainerExecCreate(ctx, payload.ContainerID, container.ExecOptions{
+		Cmd:          cmd,
+		AttachStdout: true,
+		AttachStderr: true,
+	})
+	if err != nil {
+		return "", fmt.Errorf("unable to run the garbage collection in the container: %w", err)
+	}
+
+	attached, err := cli.ContainerExecAttach(ctx, exec.ID, container.ExecAttachOptions{})
+	if err != nil {
+		return "", fmt.Errorf("unable to start the garbage collection: %w", err)
+	}
+	defer attached.Close()
+
+	output := &tailBuffer{}
+	if _, err := stdcopy.StdCopy(output, output, attached.Reader); err != nil {
+		return output.String(), fmt.Errorf("unable to read the output of the garbage collection: %w", err)
+	}
+
+	inspect, err := cli.ContainerExecInspect(ctx, exec.ID)
+	if err != nil {
+		return output.String(), fmt.Errorf("unable to inspect the garbage collection: %w", err)
+	}
+
+	if inspect.ExitCode != 0 {
+		return output.String(), fmt.Errorf("the garbage collection exited with code %d: %s", inspect.ExitCode, output.String())
+	}
+
+	return output.String(), nil
+}
+
+// tailBuffer keeps the end of what is written to it
+type tailBuffer struct {
+	mu  sync.Mutex
+	buf []byte
+}
+
+func (buffer *tailBuffer) Write(p []byte) (int, error) {
+	buffer.mu.Lock()
+	defer buffer.mu.Unlock()
+
+	buffer.buf = append(buffer.buf, p...)
+	if len(buffer.buf) > maxGCOutputSize {
+		buffer.buf = buffer.buf[len(buffer.buf)-maxGCOutputSize:]
+	}
+
+	return len(p), nil
+}
+
+func (buffer *tailBuffer) String() string {
+	buffer.mu.Lock()
+	defer buffer.mu.Unlock()
+
+	return string(buffer.buf)
+}
@@ -0,0 +1,475 @@
+package registries
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/docker/registry"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/request"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+)
+
+type harborTeamMappingPayload struct {
+	TeamID portainer.TeamID `example:"1" validate:"required"`
+	// Name of the Harbor project
+	Project string `example:"library" validate:"required"`
+	// Role of the members of the team in the project: 1 (project admin), 2 (developer), 3 (guest), 4 (maintainer),
+	// 5 (limited guest)
+	Role portainer.HarborProjectRole `example:"2" validate:"required" enums:"1,2,3,4,5"`
+}
+
+type registryHarborTeamsPayload struct {
+	// Mappings of the Portainer teams to the Harbor projects, replacing the current ones
+	Mappings []harborTeamMappingPayload
+}
+
+func (payload *registryHarborTeamsPayload) Validate(r *http.Request) error {
+	type key struct {
+		teamID  portainer.TeamID
+		project string
+	}
+
+	mapped := make(map[key]bool, len(payload.Mappings))
+	for _, mapping := range payload.Mappings {
+		if mapping.TeamID == 0 {
+			return errors.New("invalid team identifier")
+		}
+
+		if mapping.Project == "" {
+			return errors.New("invalid project name")
+		}
+
+		if mapping.Role < portainer.HarborProjectAdminRole || mapping.Role > portainer.HarborLimitedGuestRole {
+			return errors.New("invalid project role. Valid values are: 1 (project admin), 2 (developer), 3 (guest), 4 (maintainer), 5 (limited guest)")
+		}
+
+		k := key{teamID: mapping.TeamID, project: mapping.Project}
+		if mapped[k] {
+			return fmt.Errorf("the team %d is mapped more than once to the project %s", mapping.TeamID, mapping.Project)
+		}
+		mapped[k] = true
+	}
+
+	return nil
+}
+
+type harborSyncReport struct {
+	// Team mappings after the synchronization
+	Mappings []portainer.HarborTeamMapping
+	// Errors of the synchronization, which goes on when a member, a project or a robot account cannot be synchronized
+	Errors []string `example:"unable to add the user alice to the project library: registry error 404"`
+}
+
+func (report *harborSyncReport) addError(format string, args ...any) {
+	report.Errors = append(report.Errors, fmt.Sprintf(format, args...))
+}
+
+// @id RegistryHarborProjects
+// @summary List the projects of a Harbor registry
+// @description List the projects of a Harbor registry visible to the user of the registry, with their storage quota and
+// @description usage. The quotas are only returned when the user of the registry is an administrator of Harbor.
+// @description **Access policy**: administrator
+// @tags registries
+// @security ApiKeyAuth
+// @security jwt
+// @produce json
+// @param id path int true "Registry identifier"
+// @success 200 {array} registry.HarborProject "Success"
+// @failure 400 "The registry is not a Harbor registry"
+// @failure 404 "Registry not found"
+// @failure 502 "The projects could not be listed"
+// @failure 500 "Server error"
+// @router /registries/{id}/harbor/projects [get]
+func (handler *Handler) registryHarborProjects(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	_, client, httpErr := handler.harborRegistryClient(r)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	projects, err := client.HarborProjects(r.Context())
+	if err != nil {
+		return registryBrowseError("Unable to list the projects of the registry", err)
+	}
+
+	return response.JSON(w, projects)
+}
+
+// @id RegistryHarborTeamsUpdate
+// @summary Map the Portainer teams to the Harbor projects
+// @description Replace the mappings of the Portainer teams to the projects of a Harbor registry, and synchronize them.
+// @description The members of a team are added to its projects with its role, the users being matched by username, and
+// @description a robot account is created for every team to pull the images of its projects, and to push them unless
+// @description the role of the team is guest. The users that are not administrators use the robot account of their team
+// @description to deploy the images of the registry.
+// @description The user of the registry must be an administrator of Harbor.
+// @description **Access policy**: administrator
+// @tags registries
+// @security ApiKeyAuth
+// @security jwt
+// @accept json
+// @produce json
+// @param id path int true "Registry identifier"
+// @param body body registryHarborTeamsPayload true "Team mappings"
+// @success 200 {object} harborSyncReport "Success"
+// @failure 400 "Invalid request"
+// @failure 404 "Registry not found"
+// @failure 500 "Server error"
+// @router /registries/{id}/harbor/teams [put]
+func (handler *Handler) registryHarborTeamsUpdate(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	var payload registryHarborTeamsPayload
+	if err := request.DecodeAndValidateJSONPayload(r, &payload); err != nil {
+		return httperror.BadRequest("Invalid request payload", err)
+	}
+
+	reg, client, httpErr := handler.harborRegistryClient(r)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	for _, mapping := range payload.Mappings {
+		if _, err := handler.DataStore.Team().Read(mapping.TeamID); handler.DataStore.IsErrObjectNotFound(err) {
+			return httperror.BadRequest("Unable to find a team with the specified identifier inside the database", err)
+		} else if err != nil {
+			return httperror.InternalServerError("Unable to find a team with the specified identifier inside the database", err)
+		}
+	}
+
+	previous := reg.Harbor.TeamMappings
+
+	mappings := make([]portainer.HarborTeamMapping, 0, len(payload.Mappings))
+	for _, mapping := range payload.Mappings {
+		var members []string
+		for _, current := range previous {
+			if current.TeamID == mapping.TeamID && current.Project == mapping.Project {
+				members = current.Members
+			}
+		}
+
+		mappings = append(mappings, portainer.HarborTeamMapping{TeamID: mapping.TeamID, Project: mapping.Project, Role: mapping.Role, Members: members})
+	}
+
+	reg.Harbor.TeamMappings = mappings
+
+	return handler.syncHarborRegistry(w, r.Context(), reg, client, previous)
+}
+
+// @id RegistryHarborSync
+// @summary Synchronize the Portainer teams with the Harbor projects
+// @description Synchronize the members and the robot accounts of the teams mapped to the projects of a Harbor registry,
+// @description e.g. after the membership of the teams changed.
+// @description **Access policy**: administrator
+// @tags registries
+// @security ApiKeyAuth
+// @security jwt
+// @produce json
+// @param id path int true "Registry identifier"
+// @success 200 {object} harborSyncReport "Success"
+// @failure 400 "The registry is not a Harbor registry"
+// @failure 404 "Registry not found"
+// @failure 500 "Server error"
+// @router /registries/{id}/harbor/sync [post]
+func (handler *Handler) registryHarborSync(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	reg, client, httpErr := handler.harborRegistryClient(r)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	return handler.syncHarborRegistry(w, r.Context(), reg, client, reg.Harbor.TeamMappings)
+}
+
+func (handler *Handler) syncHarborRegistry(w http.ResponseWriter, ctx context.Context, reg *portainer.Registry, client *registry.Client, previous []portainer.HarborTeamMapping) *httperror.HandlerError {
+	report, err := handler.syncHarborTeams(ctx, client, reg, previous)
+	if err != nil {
+		return httperror.InternalServerError("Unable to synchronize the teams with the registry", err)
+	}
+
+	if err := handler.DataStore.Registry().Update(reg.ID, reg); err != nil {
+		return httperror.InternalServerError("Unable to persist registry changes inside the database", err)
+	}
+
+	return response.JSON(w, report)
+}
+
+// harborRegistryClient returns the Harbor registry of the request and a client of its API
+func (handler *Handler) harborRegistryClient(r *http.Request) (*portainer.Registry, *registry.Client, *httperror.HandlerError) {
+	reg, httpErr := handler.requestRegistry(r)
+	if httpErr != nil {
+		return nil, nil, httpErr
+	}
+
+	if reg.Type != portainer.HarborRegistry {
+		return nil, nil, httperror.BadRequest("The registry is not a Harbor registry", errors.New("unsupported registry type"))
+	}
+
+	client, httpErr := handler.registryClient(reg)
+	if httpErr != nil {
+		return nil, nil, httpErr
+	}
+
+	return reg, client, nil
+}
+
+// syncHarborTeams synchronizes the members of the projects and the robot accounts of the teams mapped to the projects
+// of a Harbor registry. Only the members added by the previous synchronizations are updated or removed, the other
+// members of the projects being left untouched. The mappings of the deleted teams are removed
+func (handler *Handler) syncHarborTeams(ctx context.Context, client *registry.Client, reg *portainer.Registry, previous []portainer.HarborTeamMapping) (*harborSyncReport, error) {
+	report := &harborSyncReport{Errors: []string{}}
+
+	// the usernames added to the projects by the previous synchronizations
+	synced := make(map[string]map[string]bool)
+	for _, mapping := range previous {
+		for _, username := range mapping.Members {
+			setAdd(synced, mapping.Project, username)
+		}
+	}
+
+	teamNames := make(map[portainer.TeamID]string)
+	teamMembers := make(map[portainer.TeamID][]string)
+	desired := make(map[string]map[string]portainer.HarborProjectRole)
+
+	mappings := make([]portainer.HarborTeamMapping, 0, len(reg.Harbor.TeamMappings))
+	for _, mapping := range reg.Harbor.TeamMappings {
+		usernames, ok := teamMembers[mapping.TeamID]
+		if !ok {
+			team, err := handler.DataStore.Team().Read(mapping.TeamID)
+			if handler.DataStore.IsErrObjectNotFound(err) {
+				report.addError("the team %d does not exist anymore, its mapping to the project %s is removed", mapping.TeamID, mapping.Project)
+				continue
+			} else if err != nil {
+				return nil, err
+			}
+
+			if usernames, err = handler.teamUsernames(team.ID); err != nil {
+				return nil, err
+			}
+
+			teamNames[team.ID] = team.Name
+			teamMembers[team.ID] = usernames
+		}
+
+		if desired[mapping.Project] == nil {
+			desired[mapping.Project] = make(map[string]portainer.HarborProjectRole)
+		}
+
+		for _, username := range usernames {
+			if role, ok := desired[mapping.Project][username]; !ok || harborRoleRank(mapping.Role) > harborRoleRank(role) {
+				desired[mapping.Project][username] = mapping.Role
+			}
+		}
+
+		mappings = append(mappings, mapping)
+	}
+
+	projects := append(sortedKeys(desired), sortedKeys(synced)...)
+	slices.Sort(projects)
+
+	added := make(map[string]map[string]bool)
+	for _, project := range slices.Compact(projects) {
+		handler.syncHarborProjectMembers(ctx, client, project, desired[project], synced[project], added, report)
+	}
+
+	for i, mapping := range mappings {
+		mappings[i].Members = slices.DeleteFunc(slices.Clone(teamMembers[mapping.TeamID]), func(username string) bool {
+			return !added[mapping.Project][username]
+		})
+	}
+
+	reg.Harbor.TeamMappings = mappings
+	syncHarborRobots(ctx, client, reg, teamNames, report)
+
+	report.Mappings = reg.Harbor.TeamMappings
+
+	return report, nil
+}
+
+// syncHarborProjectMembers adds the desired members to a project with their role and removes the members previously
+// synchronized that are not desired anymore, recording the members added by Portainer in added
+func (handler *Handler) syncHarborProjectMembers(ctx context.Context, client *registry.Client, project string, desired map[string]portainer.HarborProjectRole, synced map[string]bool, added map[string]map[string]bool, report *harborSyncReport) {
+	members, err := client.HarborProjectMembers(ctx, project)
+	if err != nil {
+		report.addError("unable to list the members of the project %s: %s", project, err)
+
+		// keep track of the members previously added so that the next synchronization removes them
+		for username := range synced {
+			setAdd(added, project, username)
+		}
+
+		return
+	}
+
+	byName := make(map[string]registry.HarborMember, len(members))
+	for _, member := range members {
+		if member.EntityType == "u" {
+			byName[strings.ToLower(member.EntityName)] = member
+		}
+	}
+
+	for _, username := range sortedKeys(desired) {
+		role := desired[username]
+
+		member, ok := byName[strings.ToLower(username)]
+		if !ok {
+			if err := client.HarborAddProjectMember(ctx, project, username, int(role)); err != nil {
+				report.addError("unable to add the user %s to the project %s: %s", username, project, err)
+				continue
+			}
+
+			setAdd(added, project, username)
+
+			continue
+		}
+
+		// the members that were not added by Portainer keep their role
+		if !synced[username] {
+			continue
+		}
+
+		setAdd(added, project, username)
+
+		if member.RoleID != int(role) {
+			if err := client.HarborUpdateProjectMember(ctx, project, member.ID, int(role)); err != nil {
+				report.addError("unable to update the role of the user %s in the project %s: %s", username, project, err)
+			}
+		}
+	}
+
+	for _, username := range sortedKeys(synced) {
+		if _, ok := desired[username]; ok {
+			continue
+		}
+
+		member, ok := byName[strings.ToLower(username)]
+		if !ok {
+			continue
+		}
+
+		if err := client.HarborDeleteProjectMember(ctx, project, member.ID); err != nil && !registry.IsNotFound(err) {
+			report.addError("unable to remove the user %s from the project %s: %s", username, project, err)
+			setAdd(added, project, username)
+		}
+	}
+}
+
+// syncHarborRobots creates a robot account for every mapped team, allowed to access the projects of the team, and
+// deletes the robot accounts of the teams that are not mapped anymore. A robot account whose permissions changed is
+// replaced, Harbor only returning the secret of a robot account when it is created
+func syncHarborRobots(ctx context.Context, client *registry.Client, reg *portainer.Registry, teamNames map[portainer.TeamID]string, report *harborSyncReport) {
+	desired := make(map[portainer.TeamID][]portainer.HarborRobotPermission)
+	for _, mapping := range reg.Harbor.TeamMappings {
+		push := mapping.Role != portainer.HarborGuestRole && mapping.Role != portainer.HarborLimitedGuestRole
+		desired[mapping.TeamID] = append(desired[mapping.TeamID], portainer.HarborRobotPermission{Project: mapping.Project, Push: push})
+	}
+
+	for _, permissions := range desired {
+		slices.SortFunc(permissions, func(a, b portainer.HarborRobotPermission) int {
+			return cmp.Compare(a.Project, b.Project)
+		})
+	}
+
+	if reg.Harbor.TeamRobots == nil {
+		reg.Harbor.TeamRobots = make(map[portainer.TeamID]portainer.HarborRobotAccount)
+	}
+
+	for teamID, robot := range reg.Harbor.TeamRobots {
+		if permissions, ok := desired[teamID]; ok && slices.Equal(permissions, robot.Permissions) {
+			continue
+		}
+
+		if err := client.HarborDeleteRobot(ctx, robot.ID); err != nil && !registry.IsNotFound(err) {
+			report.addError("unable to delete the robot account %s: %s", robot.Name, err)
+			continue
+		}
+
+		delete(reg.Harbor.TeamRobots, teamID)
+	}
+
+	for _, teamID := range sortedKeys(desired) {
+		if _, ok := reg.Harbor.TeamRobots[teamID]; ok {
+			continue
+		}
+
+		accesses := make([]registry.HarborRobotAccess, 0, len(desired[teamID]))
+		for _, permission := range desired[teamID] {
+			accesses = append(accesses, registry.HarborRobotAccess{Project: permission.Project, Push: permission.Push})
+		}
+
+		// the name is unique so that a robot account left behind by a failed deletion does not prevent the creation
+		name := fmt.Sprintf("portainer-team-%d-%d", teamID, time.Now().Unix())
+
+		robot, err := client.HarborCreateRobot(ctx, name, "Robot account of the Portainer team "+teamNames[teamID], accesses)
+		if err != nil {
+			report.addError("unable to create the robot account of the team %s: %s", teamNames[teamID], err)
+			continue
+		}
+
+		reg.Harbor.TeamRobots[teamID] = portainer.HarborRobotAccount{
+			ID:          robot.ID,
+			Name:        robot.Name,
+			Secret:      robot.Secret,
+			Permissions: desired[teamID],
+		}
+	}
+}
+
+// teamUsernames returns the usernames of the members of a team
+func (handler *Handler) teamUsernames(teamID portainer.TeamID) ([]string, error) {
+	memberships, err := handler.DataStore.TeamMembership().TeamMembershipsByTeamID(teamID)
+	if err != nil {
+		return nil, err
+	}
+
+	usernames := make([]string, 0, len(memberships))
+	for _, membership := range memberships {
+		user, err := handler.DataStore.User().Read(membership.UserID)
+		if handler.DataStore.IsErrObjectNotFound(err) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+
+		usernames = append(usernames, user.Username)
+	}
+
+	slices.Sort(usernames)
+
+	return usernames, nil
+}
+
+// harborRoleRank orders the roles of the Harbor projects by privilege, the highest role of a user being kept when
+// several of their teams are mapped to the same project
+func harborRoleRank(role portainer.HarborProjectRole) int {
+	return slices.Index([]portainer.HarborProjectRole{
+		portainer.HarborLimitedGuestRole,
+		portainer.HarborGuestRole,
+		portainer.HarborDeveloperRole,
+		portainer.HarborMaintainerRole,
+		portainer.HarborProjectAdminRole,
+	}, role)
+}
+
+func setAdd(sets map[string]map[string]bool, key, value string) {
+	if sets[key] == nil {
+		sets[key] = make(map[string]bool)
+	}
+
+	sets[key][value] = true
+}
+
+func sortedKeys[K cmp.Ordered, V any](m map[K]V) []K {
+	keys := make([]K, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+
+	slices.Sort(keys)
+
+	return keys
+}
@@ -0,0 +1,187 @@
+package registries
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/datastore"
+	"github.com/portainer/portainer/api/docker/registry"
+
+	"github.com/stretchr/testify/require"
+)
+
+// fakeHarbor serves the members of the library project and the robot accounts of a Harbor registry
+type fakeHarbor struct {
+	mu      sync.Mutex
+	members map[string]registry.HarborMember
+	robots  map[int64]map[string]any
+	nextID  int64
+}
+
+func (harbor *fakeHarbor) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	harbor.mu.Lock()
+	defer harbor.mu.Unlock()
+
+	path := strings.TrimPrefix(r.URL.Path, "/api/v2.0")
+
+	switch {
+	case path == "/projects/library/members" && r.Method == http.MethodGet:
+		members := []registry.HarborMember{}
+		for _, member := range harbor.members {
+			members = append(members, member)
+		}
+
+		json.NewEncoder(w).Encode(members)
+	case path == "/projects/library/members" && r.Method == http.MethodPost:
+		var body struct {
+			RoleID     int `json:"role_id"`
+			MemberUser struct {
+				Username string `json:"username"`
+			} `json:"member_user"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+
+		if body.MemberUser.Username == "ghost" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		harbor.nextID++
+		harbor.members[body.MemberUser.Username] = registry.HarborMember{ID: harbor.nextID, EntityName: body.MemberUser.Username, EntityType: "u", RoleID: body.RoleID}
+		w.WriteHeader(http.StatusCreated)
+	case strings.HasPrefix(path, "/projects/library/members/"):
+		id, _ := strconv.ParseInt(strings.TrimPrefix(path, "/projects/library/members/"), 10, 64)
+
+		for name, member := range harbor.members {
+			if member.ID != id {
+				continue
+			}
+
+			if r.Method == http.MethodDelete {
+				delete(harbor.members, name)
+			} else {
+				var body struct {
+					RoleID int `json:"role_id"`
+				}
+				json.NewDecoder(r.Body).Decode(&body)
+
+				member.RoleID = body.RoleID
+				harbor.members[name] = member
+			}
+		}
+	case path == "/robots" && r.Method == http.MethodPost:
+		var body map[string]any
+		json.NewDecoder(r.Body).Decode(&body)
+
+		harbor.nextID++
+		harbor.robots[harbor.nextID] = body
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(registry.HarborRobot{ID: harbor.nextID, Name: "robot$" + body["name"].(string), Secret: "secret"})
+	case strings.HasPrefix(path, "/robots/") && r.Method == http.MethodDelete:
+		id, _ := strconv.ParseInt(strings.TrimPrefix(path, "/robots/"), 10, 64)
+		delete(harbor.robots, id)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func TestSyncHarborTeams(t *testing.T) {
+	is := require.New(t)
+
+	_, store := datastore.MustNewTestStore(t, true, false)
+
+	createUser := func(username string) portainer.UserID {
+		user := &portainer.User{Username: username, Role: portainer.StandardUserRole}
+		is.NoError(store.User().Create(user))
+
+		return user.ID
+	}
+
+	alice, bob, carol := createUser("alice"), createUser("bob"), createUser("carol")
+	ghost := createUser("ghost")
+
+	devs := &portainer.Team{Name: "devs"}
+	is.NoError(store.Team().Create(devs))
+	ops := &portainer.Team{Name: "ops"}
+	is.NoError(store.Team().Create(ops))
+
+	for _, membership := range []portainer.TeamMembership{
+		{UserID: alice, TeamID: devs.ID},
+		{UserID: bob, TeamID: devs.ID},
+		{UserID: bob, TeamID: ops.ID},
+		{UserID: carol, TeamID: ops.ID},
+	} {
+		is.NoError(store.TeamMembership().Create(&membership))
+	}
+
+	// carol is a member of the project that was not added by Portainer
+	harbor := &fakeHarbor{
+		members: map[string]registry.HarborMember{
+			"admin": {ID: 1, EntityName: "admin", EntityType: "u", RoleID: int(portainer.HarborProjectAdminRole)},
+			"carol": {ID: 2, EntityName: "carol", EntityType: "u", RoleID: int(portainer.HarborGuestRole)},
+		},
+		robots: map[int64]map[string]any{},
+		nextID: 2,
+	}
+	server := httptest.NewServer(harbor)
+	t.Cleanup(server.Close)
+
+	client := registry.NewClient(server.URL, "admin", "secret", nil)
+	handler := &Handler{DataStore: store}
+
+	reg := &portainer.Registry{Type: portainer.HarborRegistry, Harbor: portainer.HarborRegistryData{TeamMappings: []portainer.HarborTeamMapping{
+		{TeamID: devs.ID, Project: "library", Role: portainer.HarborDeveloperRole},
+		{TeamID: ops.ID, Project: "library", Role: portainer.HarborGuestRole},
+		{TeamID: 100, Project: "library", Role: portainer.HarborGuestRole},
+	}}}
+
+	report, err := handler.syncHarborTeams(context.Background(), client, reg, nil)
+	is.NoError(err)
+	is.Len(report.Errors, 1)
+	is.Contains(report.Errors[0], "the team 100 does not exist anymore")
+
+	// bob keeps the highest role of his teams and carol keeps her role
+	is.Equal(int(portainer.HarborDeveloperRole), harbor.members["alice"].RoleID)
+	is.Equal(int(portainer.HarborDeveloperRole), harbor.members["bob"].RoleID)
+	is.Equal(int(portainer.HarborGuestRole), harbor.members["carol"].RoleID)
+
+	is.Len(reg.Harbor.TeamMappings, 2)
+	is.Equal([]string{"alice", "bob"}, reg.Harbor.TeamMappings[0].Members)
+	is.Equal([]string{"bob"}, reg.Harbor.TeamMappings[1].Members)
+
+	is.Len(harbor.robots, 2)
+	is.Equal([]portainer.HarborRobotPermission{{Project: "library", Push: true}}, reg.Harbor.TeamRobots[devs.ID].Permissions)
+	is.Equal([]portainer.HarborRobotPermission{{Project: "library", Push: false}}, reg.Harbor.TeamRobots[ops.ID].Permissions)
+	is.Equal("secret", reg.Harbor.TeamRobots[devs.ID].Secret)
+
+	// the devs are not mapped anymore and the ops become maintainers: alice is removed, carol keeps her own membership
+	previous := reg.Harbor.TeamMappings
+	reg.Harbor.TeamMappings = []portainer.HarborTeamMapping{previous[1]}
+	reg.Harbor.TeamMappings[0].Role = portainer.HarborMaintainerRole
+	is.NoError(store.TeamMembership().Create(&portainer.TeamMembership{UserID: ghost, TeamID: ops.ID}))
+
+	opsRobot := reg.Harbor.TeamRobots[ops.ID]
+
+	report, err = handler.syncHarborTeams(context.Background(), client, reg, previous)
+	is.NoError(err)
+	is.Len(report.Errors, 1)
+	is.Contains(report.Errors[0], "unable to add the user ghost")
+
+	is.NotContains(harbor.members, "alice")
+	is.Equal(int(portainer.HarborMaintainerRole), harbor.members["bob"].RoleID)
+	is.Equal(int(portainer.HarborGuestRole), harbor.members["carol"].RoleID)
+	is.Equal([]string{"bob"}, reg.Harbor.TeamMappings[0].Members)
+
+	// the robot account of the devs is deleted and the one of the ops is replaced to push
+	is.Len(harbor.robots, 1)
+	is.NotContains(reg.Harbor.TeamRobots, devs.ID)
+	is.NotEqual(opsRobot.ID, reg.Harbor.TeamRobots[ops.ID].ID)
+	is.Equal([]portainer.HarborRobotPermission{{Project: "library", Push: true}}, reg.Harbor.TeamRobots[ops.ID].Permissions)
+}
@@ -29,9 +29,11 @@ type registryUpdatePayload struct {
 	Password *string `example:"registry_password"`
 	// Quay data
 	Quay *portainer.QuayRegistryData
+	// GitHub Container Registry data
+	GitHub *portainer.GitHubRegistryData `json:",omitempty"`
 	// Registry access control
 	RegistryAccesses *portainer.RegistryAccesses `json:",omitempty"`
-	// ECR data
+	// ECR data, the region is kept when it is empty
 	Ecr *portainer.EcrData `json:",omitempty"`
 }
 
@@ -122,22 +124,46 @@ func (handler *Handler) registryUpdate(w http.ResponseWriter, r *http.Request) *
 				registry.Password = *payload.Password
 			}
 
-			if registry.Type == portainer.EcrRegistry && payload.Ecr != nil && payload.Ecr.Region != "" {
-				shouldUpdateSecrets = shouldUpdateSecrets || (registry.Ecr.Region != payload.Ecr.Region)
-				registry.Ecr.Region = payload.Ecr.Region
+			if registry.Type == portainer.EcrRegistry && payload.Ecr != nil {
+				if err := validateEcrData(*payload.Ecr); err != nil {
+					return httperror.BadRequest("Invalid request payload", err)
+				}
+
+				ecr := registry.Ecr
+				ecr.Region = cmp.Or(payload.Ecr.Region, registry.Ecr.Region)
+				ecr.UseDefaultCredentials = payload.Ecr.UseDefaultCredentials
+				ecr.RoleARN = payload.Ecr.RoleARN
+				ecr.ExternalID = payload.Ecr.ExternalID
+
+				shouldUpdateSecrets = shouldUpdateSecrets || ecr != registry.Ecr
+				registry.Ecr = ecr
 			}
 		} else {
 			registry.Authentication = false
 			registry.Username = ""
 			registry.Password = ""
 
-			registry.Ecr.Region = ""
+			registry.Ecr = portainer.EcrData{}
+			registry.CredentialProvider = nil
 
 			registry.AccessToken = ""
 			registry.AccessTokenExpiry = 0
 		}
 	}
 
+	registry.Quay = *cmp.Or(payload.Quay, &registry.Quay)
+	registry.GitHub = *cmp.Or(payload.GitHub, &registry.GitHub)
+
+	switch registry.Type {
+	case portainer.QuayRegistry:
+		err = validateQuayData(registry.Quay, registry.Username)
+	case portainer.GitHubRegistry:
+		err = validateGitHubData(registry.GitHub, registry.Password)
+	}
+	if err != nil {
+		return httperror.BadRequest("Invalid request payload", err)
+	}
+
 	registry.ManagementConfiguration = syncConfig(registry)
 
 	if payload.URL != nil {
@@ -171,8 +197,6 @@ func (handler *Handler) registryUpdate(w http.ResponseWriter, r *http.Request) *
 		}
 	}
 
-	registry.Quay = *cmp.Or(payload.Quay, &registry.Quay)
-
 	if err := handler.DataStore.Registry().Update(registry.ID, registry); err != nil {
 		return httperror.InternalServerError("Unable to persist registry changes inside the database", err)
 	}
@@ -0,0 +1,78 @@
+package reports
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/portainer/portainer/api/fleetreport"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/request"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+)
+
+// @id FleetReportInspect
+// @summary Generate the fleet report
+// @description Generate the report of the last complete week or month: the health of the environments, their
+// @description outdated images, the failed deployments, the new environments and the activity of the users.
+// @description **Access policy**: administrator
+// @tags reports
+// @security ApiKeyAuth
+// @security jwt
+// @produce json,text/html,text/csv,application/pdf
+// @param frequency query string false "Period of the report" Enums(weekly, monthly) default(weekly)
+// @param format query string false "Format of the report" Enums(json, html, csv, pdf) default(json)
+// @success 200 {object} fleetreport.Report "Success"
+// @failure 400 "Invalid request"
+// @failure 500 "Server error"
+// @router /reports/fleet [get]
+func (handler *Handler) fleetReportInspect(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	frequency, _ := request.RetrieveQueryParameter(r, "frequency", true)
+	if frequency == "" {
+		frequency = fleetreport.FrequencyWeekly
+	}
+
+	format, _ := request.RetrieveQueryParameter(r, "format", true)
+
+	report, err := handler.FleetReportService.Generate(frequency, time.Now())
+	if errors.Is(err, fleetreport.ErrInvalidFrequency) {
+		return httperror.BadRequest("Invalid query parameter: frequency", err)
+	} else if err != nil {
+		return httperror.InternalServerError("Unable to generate the fleet report", err)
+	}
+
+	filename := "fleet-report-" + time.Unix(report.From, 0).UTC().Format(time.DateOnly)
+
+	var content []byte
+
+	switch format {
+	case "", "json":
+		return response.JSON(w, report)
+	case "html":
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+
+		content, err = report.HTML()
+	case "csv":
+		w.Header().Set("Content-Type", "text/csv")
+		w.Header().Set("Content-Disposition", "attachment; filename="+filename+".csv")
+
+		content, err = report.CSV()
+	case "pdf":
+		w.Header().Set("Content-Type", "application/pdf")
+		w.Header().Set("Content-Disposition", "attachment; filename="+filename+".pdf")
+
+		content = report.PDF()
+	default:
+		return httperror.BadRequest("Invalid query parameter: format", errors.New("the format must be json, html, csv or pdf"))
+	}
+
+	if err != nil {
+		return httperror.InternalServerError("Unable to render the fleet report", err)
+	}
+
+	if _, err := w.Write(content); err != nil {
+		return httperror.InternalServerError("Unable to write the fleet report", err)
+	}
+
+	return nil
+}
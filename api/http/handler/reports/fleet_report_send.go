@@ -0,0 +1,68 @@
+package reports
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/portainer/portainer/api/fleetreport"
+	"github.com/portainer/portainer/api/smtp"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/request"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+)
+
+type fleetReportSendPayload struct {
+	// Period of the report, weekly or monthly
+	Frequency string `example:"weekly" enums:"weekly,monthly"`
+	// Email addresses the report is sent to
+	Recipients []string `validate:"required" example:"ops@mydomain.tld"`
+}
+
+func (payload *fleetReportSendPayload) Validate(r *http.Request) error {
+	if len(payload.Recipients) == 0 {
+		return errors.New("Invalid recipients. At least one email address is required")
+	}
+
+	return nil
+}
+
+// @id FleetReportSend
+// @summary Send the fleet report
+// @description Email the report of the last complete week or month through the SMTP server of the settings, to
+// @description check the configuration of the scheduled reports.
+// @description **Access policy**: administrator
+// @tags reports
+// @security ApiKeyAuth
+// @security jwt
+// @accept json
+// @param body body fleetReportSendPayload true "Report details"
+// @success 204 "Success"
+// @failure 400 "Invalid request or no SMTP server configured"
+// @failure 500 "Server error"
+// @router /reports/fleet/send [post]
+func (handler *Handler) fleetReportSend(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	var payload fleetReportSendPayload
+	if err := request.DecodeAndValidateJSONPayload(r, &payload); err != nil {
+		return httperror.BadRequest("Invalid request payload", err)
+	}
+
+	if payload.Frequency == "" {
+		payload.Frequency = fleetreport.FrequencyWeekly
+	}
+
+	report, err := handler.FleetReportService.Generate(payload.Frequency, time.Now())
+	if errors.Is(err, fleetreport.ErrInvalidFrequency) {
+		return httperror.BadRequest("Invalid report frequency", err)
+	} else if err != nil {
+		return httperror.InternalServerError("Unable to generate the fleet report", err)
+	}
+
+	if err := handler.FleetReportService.Send(report, payload.Recipients); errors.Is(err, smtp.ErrNotConfigured) {
+		return httperror.BadRequest("No SMTP server is configured in the settings", err)
+	} else if err != nil {
+		return httperror.InternalServerError("Unable to send the fleet report", err)
+	}
+
+	return response.Empty(w)
+}
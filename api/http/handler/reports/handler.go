@@ -0,0 +1,32 @@
+package reports
+
+import (
+	"net/http"
+
+	"github.com/portainer/portainer/api/fleetreport"
+	"github.com/portainer/portainer/api/http/security"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+
+	"github.com/gorilla/mux"
+)
+
+// Handler is the HTTP handler used to generate and send the fleet reports.
+type Handler struct {
+	*mux.Router
+	FleetReportService *fleetreport.Service
+}
+
+// NewHandler creates a handler to generate and send the fleet reports.
+func NewHandler(bouncer security.BouncerService, fleetReportService *fleetreport.Service) *Handler {
+	h := &Handler{
+		Router:             mux.NewRouter(),
+		FleetReportService: fleetReportService,
+	}
+
+	h.Handle("/reports/fleet",
+		bouncer.AdminAccess(httperror.LoggerHandler(h.fleetReportInspect))).Methods(http.MethodGet)
+	h.Handle("/reports/fleet/send",
+		bouncer.AdminAccess(httperror.LoggerHandler(h.fleetReportSend))).Methods(http.MethodPost)
+
+	return h
+}
@@ -0,0 +1,57 @@
+package sessionrecordings
+
+import (
+	"net/http"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+	"github.com/portainer/portainer/api/http/security"
+	"github.com/portainer/portainer/api/sessionrecording"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/request"
+
+	"github.com/gorilla/mux"
+)
+
+// Handler is the HTTP handler used to audit and replay the recordings of the console sessions.
+type Handler struct {
+	*mux.Router
+	DataStore               dataservices.DataStore
+	SessionRecordingService *sessionrecording.Service
+}
+
+// NewHandler creates a handler to manage the session recordings.
+func NewHandler(bouncer security.BouncerService, dataStore dataservices.DataStore, sessionRecordingService *sessionrecording.Service) *Handler {
+	h := &Handler{
+		Router:                  mux.NewRouter(),
+		DataStore:               dataStore,
+		SessionRecordingService: sessionRecordingService,
+	}
+
+	h.Handle("/session_recordings",
+		bouncer.AdminAccess(httperror.LoggerHandler(h.sessionRecordingList))).Methods(http.MethodGet)
+	h.Handle("/session_recordings/{id}",
+		bouncer.AdminAccess(httperror.LoggerHandler(h.sessionRecordingInspect))).Methods(http.MethodGet)
+	h.Handle("/session_recordings/{id}",
+		bouncer.AdminAccess(httperror.LoggerHandler(h.sessionRecordingDelete))).Methods(http.MethodDelete)
+	h.Handle("/session_recordings/{id}/cast",
+		bouncer.AdminAccess(httperror.LoggerHandler(h.sessionRecordingCast))).Methods(http.MethodGet)
+
+	return h
+}
+
+func (handler *Handler) readSessionRecording(r *http.Request) (*portainer.SessionRecording, *httperror.HandlerError) {
+	id, err := request.RetrieveNumericRouteVariableValue(r, "id")
+	if err != nil {
+		return nil, httperror.BadRequest("Invalid session recording identifier route variable", err)
+	}
+
+	recording, err := handler.DataStore.SessionRecording().Read(portainer.SessionRecordingID(id))
+	if handler.DataStore.IsErrObjectNotFound(err) {
+		return nil, httperror.NotFound("Unable to find a session recording with the specified identifier inside the database", err)
+	} else if err != nil {
+		return nil, httperror.InternalServerError("Unable to find a session recording with the specified identifier inside the database", err)
+	}
+
+	return recording, nil
+}
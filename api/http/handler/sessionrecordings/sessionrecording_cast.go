@@ -0,0 +1,47 @@
+package sessionrecordings
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+)
+
+// @id SessionRecordingCast
+// @summary Download the output of a session recording
+// @description Download the recorded output of a console session in the asciicast v2 format, which can be replayed
+// @description with an asciinema player. The output of a session still open is recorded until now.
+// @description **Access policy**: administrator
+// @tags session_recordings
+// @security ApiKeyAuth
+// @security jwt
+// @produce application/x-asciicast
+// @param id path int true "Session recording identifier"
+// @success 200 {file} file "Success"
+// @failure 400 "Invalid request"
+// @failure 404 "Session recording not found"
+// @failure 500 "Server error"
+// @router /session_recordings/{id}/cast [get]
+func (handler *Handler) sessionRecordingCast(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	recording, httpErr := handler.readSessionRecording(r)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	file, err := handler.SessionRecordingService.Open(recording.ID)
+	if os.IsNotExist(err) {
+		return httperror.NotFound("Unable to find the output of the session recording", err)
+	} else if err != nil {
+		return httperror.InternalServerError("Unable to read the output of the session recording", err)
+	}
+	defer file.Close()
+
+	w.Header().Set("Content-Type", "application/x-asciicast")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=session-%d.cast", recording.ID))
+
+	http.ServeContent(w, r, "", time.Unix(recording.StartedAt, 0), file)
+
+	return nil
+}
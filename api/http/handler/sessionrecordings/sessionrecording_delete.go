@@ -0,0 +1,43 @@
+package sessionrecordings
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/portainer/portainer/api/sessionrecording"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+)
+
+// @id SessionRecordingDelete
+// @summary Remove a session recording
+// @description Remove the recording of a console session and its output. The recording of a session still open cannot
+// @description be removed.
+// @description **Access policy**: administrator
+// @tags session_recordings
+// @security ApiKeyAuth
+// @security jwt
+// @param id path int true "Session recording identifier"
+// @success 204 "Success"
+// @failure 400 "Invalid request"
+// @failure 404 "Session recording not found"
+// @failure 409 "The session is still open"
+// @failure 500 "Server error"
+// @router /session_recordings/{id} [delete]
+func (handler *Handler) sessionRecordingDelete(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	recording, httpErr := handler.readSessionRecording(r)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	err := handler.SessionRecordingService.Delete(recording.ID)
+	if errors.Is(err, sessionrecording.ErrRecordingInProgress) {
+		return httperror.Conflict("The session of the recording is still open", err)
+	} else if handler.DataStore.IsErrObjectNotFound(err) {
+		return httperror.NotFound("Unable to find a session recording with the specified identifier inside the database", err)
+	} else if err != nil {
+		return httperror.InternalServerError("Unable to remove the session recording", err)
+	}
+
+	return response.Empty(w)
+}
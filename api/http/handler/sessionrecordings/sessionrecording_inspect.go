@@ -0,0 +1,31 @@
+package sessionrecordings
+
+import (
+	"net/http"
+
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+)
+
+// @id SessionRecordingInspect
+// @summary Inspect a session recording
+// @description Retrieve the details of the recording of a console session.
+// @description **Access policy**: administrator
+// @tags session_recordings
+// @security ApiKeyAuth
+// @security jwt
+// @produce json
+// @param id path int true "Session recording identifier"
+// @success 200 {object} portainer.SessionRecording "Success"
+// @failure 400 "Invalid request"
+// @failure 404 "Session recording not found"
+// @failure 500 "Server error"
+// @router /session_recordings/{id} [get]
+func (handler *Handler) sessionRecordingInspect(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	recording, httpErr := handler.readSessionRecording(r)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	return response.JSON(w, recording)
+}
@@ -0,0 +1,49 @@
+package sessionrecordings
+
+import (
+	"net/http"
+	"slices"
+
+	portainer "github.com/portainer/portainer/api"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/request"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+)
+
+// @id SessionRecordingList
+// @summary List the session recordings
+// @description List the recordings of the exec and attach console sessions opened on the containers, and of the
+// @description kubectl shell sessions, the most recent first. The sessions are recorded when the recording is enabled in the settings.
+// @description **Access policy**: administrator
+// @tags session_recordings
+// @security ApiKeyAuth
+// @security jwt
+// @produce json
+// @param endpointId query int false "Only list the sessions opened on this environment"
+// @param userId query int false "Only list the sessions opened by this user"
+// @param containerId query string false "Only list the sessions opened on this container"
+// @success 200 {array} portainer.SessionRecording "Success"
+// @failure 500 "Server error"
+// @router /session_recordings [get]
+func (handler *Handler) sessionRecordingList(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	endpointID, _ := request.RetrieveNumericQueryParameter(r, "endpointId", true)
+	userID, _ := request.RetrieveNumericQueryParameter(r, "userId", true)
+	containerID, _ := request.RetrieveQueryParameter(r, "containerId", true)
+
+	recordings, err := handler.DataStore.SessionRecording().ReadAll()
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve the session recordings from the database", err)
+	}
+
+	recordings = slices.DeleteFunc(recordings, func(recording portainer.SessionRecording) bool {
+		return (endpointID != 0 && recording.EndpointID != portainer.EndpointID(endpointID)) ||
+			(userID != 0 && recording.UserID != portainer.UserID(userID)) ||
+			(containerID != "" && recording.ContainerID != containerID)
+	})
+
+	slices.SortFunc(recordings, func(a, b portainer.SessionRecording) int {
+		return int(b.ID - a.ID)
+	})
+
+	return response.JSON(w, recordings)
+}
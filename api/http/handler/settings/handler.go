@@ -5,7 +5,9 @@ import (
 
 	portainer "github.com/portainer/portainer/api"
 	"github.com/portainer/portainer/api/dataservices"
+	"github.com/portainer/portainer/api/docker/orphans"
 	"github.com/portainer/portainer/api/http/security"
+	"github.com/portainer/portainer/api/stacks/drift"
 	httperror "github.com/portainer/portainer/pkg/libhttp/error"
 
 	"github.com/gorilla/mux"
@@ -15,6 +17,12 @@ func hideFields(settings *portainer.Settings) {
 	settings.LDAPSettings.Password = ""
 	settings.OAuthSettings.ClientSecret = ""
 	settings.OAuthSettings.KubeSecretKey = nil
+	settings.OAuthSettings.RefreshTokenKey = nil
+	settings.TwoFactorSettings.SecretKey = nil
+	settings.SnapshotWebhookSettings.Secret = ""
+	settings.SMTPSettings.Password = ""
+	settings.AuditLogSettings.HTTPSinkSecret = ""
+	settings.CloudCredentialKey = nil
 }
 
 // Handler is the HTTP handler used to handle settings operations.
@@ -25,6 +33,8 @@ type Handler struct {
 	JWTService      portainer.JWTService
 	LDAPService     portainer.LDAPService
 	SnapshotService portainer.SnapshotService
+	OrphanService   *orphans.Service
+	DriftService    *drift.Service
 }
 
 // NewHandler creates a handler to manage settings operations.
@@ -13,10 +13,14 @@ import (
 type publicSettingsResponse struct {
 	// URL to a logo that will be displayed on the login page as well as on top of the sidebar. Will use default Portainer logo when value is empty string
 	LogoURL string `json:"LogoURL" example:"https://mycompany.mydomain.tld/logo.png"`
-	// Active authentication method for the Portainer instance. Valid values are: 1 for internal, 2 for LDAP, or 3 for oauth
+	// Active authentication method for the Portainer instance. Valid values are: 1 for internal, 2 for LDAP, 3 for oauth or 4 for SAML
 	AuthenticationMethod portainer.AuthenticationMethod `json:"AuthenticationMethod" example:"1"`
+	// Additional authentication methods users can authenticate with
+	EnabledAuthenticationMethods []portainer.AuthenticationMethod `json:"EnabledAuthenticationMethods"`
 	// The minimum required length for a password of any user when using internal auth mode
 	RequiredPasswordLength int `json:"RequiredPasswordLength" example:"1"`
+	// The requirements of the passwords of the users when using internal auth mode
+	PasswordPolicy portainer.PasswordPolicy `json:"PasswordPolicy"`
 	// Deployment options for encouraging deployment as code
 	GlobalDeploymentOptions portainer.GlobalDeploymentOptions `json:"GlobalDeploymentOptions"`
 	// Whether edge compute features are enabled
@@ -27,8 +31,12 @@ type publicSettingsResponse struct {
 	OAuthLoginURI string `json:"OAuthLoginURI" example:"https://gitlab.com/oauth"`
 	// The URL used for oauth logout
 	OAuthLogoutURI string `json:"OAuthLogoutURI" example:"https://gitlab.com/oauth/logout"`
+	// The URL used for SAML login
+	SAMLLoginURI string `json:"SAMLLoginURI" example:"/api/auth/saml/login"`
 	// Whether telemetry is enabled
 	EnableTelemetry bool `json:"EnableTelemetry" example:"true"`
+	// Whether the console sessions are recorded, so that the users can be notified
+	EnableSessionRecording bool `json:"EnableSessionRecording" example:"false"`
 	// The expiry of a Kubeconfig
 	KubeconfigExpiry string `example:"24h" default:"0"`
 	// Whether team sync is enabled
@@ -76,9 +84,11 @@ func generatePublicSettings(appSettings *portainer.Settings) *publicSettingsResp
 		LogoURL:                   appSettings.LogoURL,
 		AuthenticationMethod:      appSettings.AuthenticationMethod,
 		RequiredPasswordLength:    appSettings.InternalAuthSettings.RequiredPasswordLength,
+		PasswordPolicy:            appSettings.InternalAuthSettings.PasswordPolicy,
 		EnableEdgeComputeFeatures: appSettings.EnableEdgeComputeFeatures,
 		GlobalDeploymentOptions:   appSettings.GlobalDeploymentOptions,
 		EnableTelemetry:           appSettings.EnableTelemetry,
+		EnableSessionRecording:    appSettings.EnableSessionRecording,
 		KubeconfigExpiry:          appSettings.KubeconfigExpiry,
 		Features:                  featureflags.FeatureFlags(),
 		IsAMTEnabled:              appSettings.EnableEdgeComputeFeatures && appSettings.OpenAMTConfiguration.Enabled,
@@ -91,8 +101,10 @@ func generatePublicSettings(appSettings *portainer.Settings) *publicSettingsResp
 
 	publicSettings.IsDockerDesktopExtension = appSettings.IsDockerDesktopExtension
 
-	// If OAuth authentication is on, compose the related fields from application settings
-	if publicSettings.AuthenticationMethod == portainer.AuthenticationOAuth {
+	publicSettings.EnabledAuthenticationMethods = appSettings.EnabledAuthenticationMethods
+
+	// If OAuth authentication is enabled, compose the related fields from application settings
+	if appSettings.IsAuthenticationMethodEnabled(portainer.AuthenticationOAuth) {
 		publicSettings.OAuthLogoutURI = appSettings.OAuthSettings.LogoutURI
 		publicSettings.OAuthLoginURI = fmt.Sprintf("%s?response_type=code&client_id=%s&redirect_uri=%s&scope=%s",
 			appSettings.OAuthSettings.AuthorizationURI,
@@ -105,10 +117,15 @@ func generatePublicSettings(appSettings *portainer.Settings) *publicSettingsResp
 			publicSettings.OAuthLoginURI += "&prompt=login"
 		}
 	}
-	// If LDAP authentication is on, compose the related fields from application settings
-	if publicSettings.AuthenticationMethod == portainer.AuthenticationLDAP && appSettings.LDAPSettings.GroupSearchSettings != nil {
+	// If SAML authentication is enabled, compose the related fields from application settings
+	if appSettings.IsAuthenticationMethodEnabled(portainer.AuthenticationSAML) {
+		publicSettings.SAMLLoginURI = "/api/auth/saml/login"
+		publicSettings.TeamSync = appSettings.SAMLSettings.GroupsAttribute != "" && len(appSettings.SAMLSettings.TeamMappings) > 0
+	}
+	// If LDAP authentication is enabled, compose the related fields from application settings
+	if appSettings.IsAuthenticationMethodEnabled(portainer.AuthenticationLDAP) && appSettings.LDAPSettings.GroupSearchSettings != nil {
 		if len(appSettings.LDAPSettings.GroupSearchSettings) > 0 {
-			publicSettings.TeamSync = len(appSettings.LDAPSettings.GroupSearchSettings[0].GroupBaseDN) > 0
+			publicSettings.TeamSync = publicSettings.TeamSync || len(appSettings.LDAPSettings.GroupSearchSettings[0].GroupBaseDN) > 0
 		}
 	}
 
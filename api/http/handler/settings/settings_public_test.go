@@ -74,3 +74,22 @@ func TestGeneratePublicSettingsWithoutSSO(t *testing.T) {
 		t.Errorf("wrong OAuthLogoutURI, want: %s, got: %s", dummyOAuthLogoutURI, publicSettings.OAuthLogoutURI)
 	}
 }
+
+func TestGeneratePublicSettingsWithOAuthEnabledAlongsideInternal(t *testing.T) {
+	setup()
+
+	mockAppSettings.AuthenticationMethod = portainer.AuthenticationInternal
+	mockAppSettings.EnabledAuthenticationMethods = []portainer.AuthenticationMethod{portainer.AuthenticationOAuth}
+	publicSettings := generatePublicSettings(mockAppSettings)
+	if publicSettings.AuthenticationMethod != portainer.AuthenticationInternal {
+		t.Errorf("wrong AuthenticationMethod, want: %d, got: %d", portainer.AuthenticationInternal, publicSettings.AuthenticationMethod)
+	}
+
+	if publicSettings.OAuthLoginURI == "" {
+		t.Errorf("OAuthLoginURI should be set when OAuth is an enabled authentication method")
+	}
+
+	if len(publicSettings.EnabledAuthenticationMethods) != 1 || publicSettings.EnabledAuthenticationMethods[0] != portainer.AuthenticationOAuth {
+		t.Errorf("wrong EnabledAuthenticationMethods, got: %v", publicSettings.EnabledAuthenticationMethods)
+	}
+}
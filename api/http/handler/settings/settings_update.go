@@ -3,13 +3,26 @@ package settings
 import (
 	"cmp"
 	"net/http"
+	"net/mail"
+	"net/url"
+	"slices"
 	"strings"
 	"time"
 
 	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/auditlog"
+	"github.com/portainer/portainer/api/authprovider"
 	"github.com/portainer/portainer/api/dataservices"
 	"github.com/portainer/portainer/api/filesystem"
+	"github.com/portainer/portainer/api/fleetreport"
+	"github.com/portainer/portainer/api/internal/authorization"
 	"github.com/portainer/portainer/api/internal/edge"
+	"github.com/portainer/portainer/api/jwt"
+	"github.com/portainer/portainer/api/oauth"
+	"github.com/portainer/portainer/api/retention"
+	"github.com/portainer/portainer/api/saml"
+	"github.com/portainer/portainer/api/update"
+	"github.com/portainer/portainer/api/webauthn"
 	"github.com/portainer/portainer/pkg/libhelm"
 	httperror "github.com/portainer/portainer/pkg/libhttp/error"
 	"github.com/portainer/portainer/pkg/libhttp/request"
@@ -25,13 +38,44 @@ type settingsUpdatePayload struct {
 	LogoURL *string `example:"https://mycompany.mydomain.tld/logo.png"`
 	// A list of label name & value that will be used to hide containers when querying containers
 	BlackListedLabels []portainer.Pair
-	// Active authentication method for the Portainer instance. Valid values are: 1 for internal, 2 for LDAP, or 3 for oauth
+	// Rules assigning the ownership of the Docker resources deployed outside of Portainer from their labels
+	OwnershipRules []portainer.ResourceOwnershipRule
+	// Active authentication method for the Portainer instance. Valid values are: 1 for internal, 2 for LDAP, 3 for oauth or 4 for SAML
 	AuthenticationMethod *int `example:"1"`
-	InternalAuthSettings *portainer.InternalAuthSettings
-	LDAPSettings         *portainer.LDAPSettings
-	OAuthSettings        *portainer.OAuthSettings
+	// Additional authentication methods users can authenticate with. Valid values are: 1 for internal, 2 for LDAP, 3 for oauth or 4 for SAML
+	EnabledAuthenticationMethods *[]portainer.AuthenticationMethod `example:"1,2"`
+	InternalAuthSettings         *portainer.InternalAuthSettings
+	LDAPSettings                 *portainer.LDAPSettings
+	OAuthSettings                *portainer.OAuthSettings
+	SAMLSettings                 *portainer.SAMLSettings
+	// Whether the administrators must enroll and use a second factor to log in
+	EnforceTwoFactorForAdministrators *bool `example:"false"`
+	// Settings of the login with security keys and platform authenticators
+	WebAuthnSettings *portainer.WebAuthnSettings
+	// Settings of the keys signing the JWTs of the user sessions, the keys themselves cannot be updated
+	JWTSettings *portainer.JWTSettings
 	// The interval in which environment(endpoint) snapshots are created
 	SnapshotInterval *string `example:"5m"`
+	// Number of snapshots kept in the history of each environment(endpoint), 0 to use the default
+	SnapshotHistorySize *int `example:"24"`
+	// Webhook the inventory changes of the environments are sent to after their snapshots
+	SnapshotWebhookSettings *portainer.SnapshotWebhookSettings
+	// Retention and forwarding of the audit logs
+	AuditLogSettings *portainer.AuditLogSettings
+	// Scheduled removal of the orphaned volumes, images and networks
+	OrphanCleanupSettings *portainer.OrphanCleanupSettings
+	// Interval between the drift checks of the Docker stacks, e.g. 1h. Empty to disable the scheduled check
+	StackDriftCheckInterval *string `example:"1h"`
+	// Retention policies by category: auditLogs, sessions, loginAttempts, webhookLogs, containerJobRuns,
+	// edgeJobExecutions, sessionRecordings or pruneReports. Replaces all the policies, the categories without policy use
+	// their default one
+	RetentionPolicies map[string]portainer.RetentionPolicy
+	// SMTP server the emails are sent through. The password is kept when empty
+	SMTPSettings *portainer.SMTPSettings
+	// Scheduled emails of the fleet report
+	FleetReportSettings *portainer.FleetReportSettings
+	// Release channel checked for the updates of Portainer
+	UpdateSettings *portainer.UpdateSettings
 	// URL to the templates that will be displayed in the UI when navigating to App Templates
 	TemplatesURL *string `example:"https://raw.githubusercontent.com/portainer/templates/master/templates.json"`
 	// Deployment options for encouraging deployment as code
@@ -45,6 +89,9 @@ type settingsUpdatePayload struct {
 	KubeconfigExpiry *string `example:"24h" default:"0"`
 	// Whether telemetry is enabled
 	EnableTelemetry *bool `example:"false"`
+	// Whether the output of the exec and attach console sessions opened on the containers, and of the kubectl shell
+	// sessions, is recorded
+	EnableSessionRecording *bool `example:"false"`
 	// Helm repository URL
 	HelmRepositoryURL *string `example:"https://charts.bitnami.com/bitnami"`
 	// Kubectl Shell Image
@@ -55,11 +102,135 @@ type settingsUpdatePayload struct {
 	EnforceEdgeID *bool `example:"false"`
 	// EdgePortainerURL is the URL that is exposed to edge agents
 	EdgePortainerURL *string `json:"EdgePortainerURL"`
+	// URL the users reach Portainer at, used in the links given to them. Empty to disable these links
+	InstanceURL *string `example:"https://portainer.mydomain.tld"`
 }
 
 func (payload *settingsUpdatePayload) Validate(r *http.Request) error {
-	if payload.AuthenticationMethod != nil && *payload.AuthenticationMethod != 1 && *payload.AuthenticationMethod != 2 && *payload.AuthenticationMethod != 3 {
-		return errors.New("Invalid authentication method value. Value must be one of: 1 (internal), 2 (LDAP/AD) or 3 (OAuth)")
+	if payload.AuthenticationMethod != nil && !authprovider.IsValidMethod(portainer.AuthenticationMethod(*payload.AuthenticationMethod)) {
+		return errors.New("Invalid authentication method value. Value must be one of: 1 (internal), 2 (LDAP/AD), 3 (OAuth), 4 (SAML) or a registered provider")
+	}
+
+	if payload.EnabledAuthenticationMethods != nil {
+		for _, method := range *payload.EnabledAuthenticationMethods {
+			if !authprovider.IsValidMethod(method) {
+				return errors.New("Invalid enabled authentication method value. Values must be 1 (internal), 2 (LDAP/AD), 3 (OAuth), 4 (SAML) or a registered provider")
+			}
+		}
+	}
+
+	if payload.WebAuthnSettings != nil {
+		switch payload.WebAuthnSettings.Attestation {
+		case "", webauthn.AttestationNone, webauthn.AttestationIndirect, webauthn.AttestationDirect:
+		default:
+			return errors.New("Invalid WebAuthn attestation. Value must be none, indirect or direct")
+		}
+
+		switch payload.WebAuthnSettings.UserVerification {
+		case "", webauthn.UserVerificationRequired, webauthn.UserVerificationPreferred, webauthn.UserVerificationDiscouraged:
+		default:
+			return errors.New("Invalid WebAuthn user verification. Value must be required, preferred or discouraged")
+		}
+
+		for _, origin := range payload.WebAuthnSettings.Origins {
+			if u, err := url.Parse(origin); err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" || u.Path != "" {
+				return errors.New("Invalid WebAuthn origin. Origins must be like https://portainer.mydomain.tld")
+			}
+		}
+	}
+
+	if payload.InternalAuthSettings != nil && payload.InternalAuthSettings.PasswordPolicy.MaxAge != "" {
+		if maxAge, err := time.ParseDuration(payload.InternalAuthSettings.PasswordPolicy.MaxAge); err != nil || maxAge < 0 {
+			return errors.New("Invalid password maximum age. Must be a duration like 2160h")
+		}
+	}
+
+	if payload.JWTSettings != nil {
+		if payload.JWTSettings.Algorithm != "" && !jwt.IsValidAlgorithm(payload.JWTSettings.Algorithm) {
+			return errors.New("Invalid JWT signing algorithm. Value must be ES256 or RS256")
+		}
+
+		if interval, err := jwt.KeyRotationInterval(payload.JWTSettings); err != nil || interval < 0 {
+			return errors.New("Invalid JWT key rotation interval")
+		}
+	}
+
+	for i, rule := range payload.OwnershipRules {
+		if err := authorization.ValidateOwnershipRule(rule); err != nil {
+			return errors.Wrapf(err, "Invalid ownership rule %d", i)
+		}
+	}
+
+	if err := retention.ValidatePolicies(payload.RetentionPolicies); err != nil {
+		return errors.Wrap(err, "Invalid retention policies")
+	}
+
+	if payload.SnapshotHistorySize != nil && *payload.SnapshotHistorySize < 0 {
+		return errors.New("Invalid snapshot history size. Must be a positive number, or 0 to use the default")
+	}
+
+	if payload.SnapshotWebhookSettings != nil && payload.SnapshotWebhookSettings.URL != "" {
+		if u, err := url.Parse(payload.SnapshotWebhookSettings.URL); err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+			return errors.New("Invalid snapshot webhook URL. Must be an http or https URL")
+		}
+	}
+
+	if payload.AuditLogSettings != nil {
+		if payload.AuditLogSettings.RetentionDays < 0 {
+			return errors.New("Invalid audit log retention. Must be a positive number of days, or 0 to keep the audit logs forever")
+		}
+
+		if payload.AuditLogSettings.SyslogAddress != "" {
+			if _, _, err := auditlog.ParseSyslogAddress(payload.AuditLogSettings.SyslogAddress); err != nil {
+				return errors.New("Invalid syslog address. Must be like udp://host:514 or tcp://host:514")
+			}
+		}
+
+		if payload.AuditLogSettings.HTTPSinkURL != "" {
+			if u, err := url.Parse(payload.AuditLogSettings.HTTPSinkURL); err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+				return errors.New("Invalid audit log HTTP sink URL. Must be an http or https URL")
+			}
+		}
+	}
+
+	if payload.OrphanCleanupSettings != nil && payload.OrphanCleanupSettings.Interval != "" {
+		if interval, err := time.ParseDuration(payload.OrphanCleanupSettings.Interval); err != nil || interval < time.Hour {
+			return errors.New("Invalid orphaned resources cleanup interval. Must be a duration of at least 1h, e.g. 24h")
+		}
+	}
+
+	if payload.StackDriftCheckInterval != nil && *payload.StackDriftCheckInterval != "" {
+		if interval, err := time.ParseDuration(*payload.StackDriftCheckInterval); err != nil || interval < 5*time.Minute {
+			return errors.New("Invalid stack drift check interval. Must be a duration of at least 5m, e.g. 1h")
+		}
+	}
+
+	if payload.SMTPSettings != nil && payload.SMTPSettings.Host != "" {
+		if payload.SMTPSettings.Port <= 0 || payload.SMTPSettings.Port > 65535 {
+			return errors.New("Invalid SMTP port")
+		}
+
+		if _, err := mail.ParseAddress(payload.SMTPSettings.From); err != nil {
+			return errors.New("Invalid SMTP sender. Must be an email address")
+		}
+	}
+
+	if payload.FleetReportSettings != nil {
+		if err := fleetreport.ValidateSettings(*payload.FleetReportSettings); err != nil {
+			return errors.New("Invalid fleet report frequency. Must be weekly or monthly, or empty to disable the report")
+		}
+
+		for _, recipient := range payload.FleetReportSettings.Recipients {
+			if _, err := mail.ParseAddress(recipient); err != nil {
+				return errors.Errorf("Invalid fleet report recipient %q. Must be an email address", recipient)
+			}
+		}
+	}
+
+	if payload.UpdateSettings != nil {
+		if err := update.ValidateSettings(*payload.UpdateSettings); err != nil {
+			return errors.Wrap(err, "Invalid release channel settings")
+		}
 	}
 
 	if payload.LogoURL != nil && *payload.LogoURL != "" && !govalidator.IsURL(*payload.LogoURL) {
@@ -92,10 +263,42 @@ func (payload *settingsUpdatePayload) Validate(r *http.Request) error {
 		}
 	}
 
+	if payload.InstanceURL != nil && *payload.InstanceURL != "" {
+		if u, err := url.Parse(*payload.InstanceURL); err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+			return errors.New("Invalid instance URL. Must be an http or https URL")
+		}
+	}
+
 	if payload.OAuthSettings != nil {
 		if payload.OAuthSettings.AuthStyle < oauth2.AuthStyleAutoDetect || payload.OAuthSettings.AuthStyle > oauth2.AuthStyleInHeader {
 			return errors.New("Invalid OAuth AuthStyle")
 		}
+
+		if err := oauth.ValidateClaimRules(payload.OAuthSettings.ClaimRules); err != nil {
+			return errors.Wrap(err, "Invalid OAuth claim rules")
+		}
+
+		for _, u := range []string{payload.OAuthSettings.DiscoveryURI, payload.OAuthSettings.EndSessionURI} {
+			if u != "" && !govalidator.IsURL(u) {
+				return errors.New("Invalid OAuth URL. Must correspond to a valid URL format")
+			}
+		}
+
+		if payload.OAuthSettings.RPInitiatedLogout && payload.OAuthSettings.EndSessionURI == "" && payload.OAuthSettings.DiscoveryURI == "" {
+			return errors.New("An end session URI or a discovery URI is required to enable the RP-initiated logout")
+		}
+	}
+
+	if payload.SAMLSettings != nil {
+		for _, u := range []string{payload.SAMLSettings.ACSURL, payload.SAMLSettings.SLOURL, payload.SAMLSettings.IdPSSOURL, payload.SAMLSettings.IdPSLOURL} {
+			if u != "" && !govalidator.IsURL(u) {
+				return errors.New("Invalid SAML URL. Must correspond to a valid URL format")
+			}
+		}
+
+		if payload.SAMLSettings.AllowedClockSkew < 0 {
+			return errors.New("Invalid SAML allowed clock skew")
+		}
 	}
 
 	return nil
@@ -122,6 +325,20 @@ func (handler *Handler) settingsUpdate(w http.ResponseWriter, r *http.Request) *
 		return httperror.BadRequest("Invalid request payload", err)
 	}
 
+	// the endpoints are read from the discovery document before the update, outside of the transaction
+	if payload.OAuthSettings != nil && payload.OAuthSettings.DiscoveryURI != "" {
+		metadata, err := oauth.Discover(payload.OAuthSettings.DiscoveryURI)
+		if err != nil {
+			return httperror.BadRequest("Unable to retrieve the OpenID Connect discovery document", err)
+		}
+
+		metadata.Apply(payload.OAuthSettings)
+
+		if payload.OAuthSettings.RPInitiatedLogout && payload.OAuthSettings.EndSessionURI == "" {
+			return httperror.BadRequest("The OpenID Connect provider does not expose an end session endpoint", errors.New("missing end_session_endpoint"))
+		}
+	}
+
 	var settings *portainer.Settings
 	if err = handler.DataStore.UpdateTx(func(tx dataservices.DataStoreTx) error {
 		settings, err = handler.updateSettings(tx, payload)
@@ -150,7 +367,21 @@ func (handler *Handler) updateSettings(tx dataservices.DataStoreTx, payload sett
 		settings.AuthenticationMethod = portainer.AuthenticationMethod(*payload.AuthenticationMethod)
 	}
 
+	if payload.EnabledAuthenticationMethods != nil {
+		settings.EnabledAuthenticationMethods = []portainer.AuthenticationMethod{}
+		for _, method := range *payload.EnabledAuthenticationMethods {
+			if !slices.Contains(settings.EnabledAuthenticationMethods, method) {
+				settings.EnabledAuthenticationMethods = append(settings.EnabledAuthenticationMethods, method)
+			}
+		}
+	}
+
 	settings.LogoURL = *cmp.Or(payload.LogoURL, &settings.LogoURL)
+	settings.TwoFactorSettings.EnforceForAdministrators = *cmp.Or(payload.EnforceTwoFactorForAdministrators, &settings.TwoFactorSettings.EnforceForAdministrators)
+	settings.WebAuthnSettings = *cmp.Or(payload.WebAuthnSettings, &settings.WebAuthnSettings)
+
+	// the signing key is rotated on the next token when the algorithm changes
+	settings.JWTSettings = *cmp.Or(payload.JWTSettings, &settings.JWTSettings)
 	settings.TemplatesURL = *cmp.Or(payload.TemplatesURL, &settings.TemplatesURL)
 
 	// Update the global deployment options, and the environment deployment options if they have changed
@@ -175,8 +406,13 @@ func (handler *Handler) updateSettings(tx dataservices.DataStoreTx, payload sett
 		settings.BlackListedLabels = payload.BlackListedLabels
 	}
 
+	if payload.OwnershipRules != nil {
+		settings.OwnershipRules = payload.OwnershipRules
+	}
+
 	if payload.InternalAuthSettings != nil {
 		settings.InternalAuthSettings.RequiredPasswordLength = payload.InternalAuthSettings.RequiredPasswordLength
+		settings.InternalAuthSettings.PasswordPolicy = payload.InternalAuthSettings.PasswordPolicy
 	}
 
 	if payload.LDAPSettings != nil {
@@ -199,16 +435,60 @@ func (handler *Handler) updateSettings(tx dataservices.DataStoreTx, payload sett
 			kubeSecret = settings.OAuthSettings.KubeSecretKey
 		}
 
+		refreshTokenKey := settings.OAuthSettings.RefreshTokenKey
+
+		// the claim rules are kept when they are omitted, an empty list removes them
+		claimRules := payload.OAuthSettings.ClaimRules
+		if claimRules == nil {
+			claimRules = settings.OAuthSettings.ClaimRules
+		}
+
+		for _, rule := range claimRules {
+			if rule.TeamID != 0 {
+				if _, err := tx.Team().Read(rule.TeamID); err != nil {
+					return nil, httperror.BadRequest("Invalid OAuth claim rule, unable to find the team", err)
+				}
+			}
+
+			if rule.EndpointGroupID != 0 {
+				if _, err := tx.EndpointGroup().Read(rule.EndpointGroupID); err != nil {
+					return nil, httperror.BadRequest("Invalid OAuth claim rule, unable to find the environment group", err)
+				}
+			}
+		}
+
 		settings.OAuthSettings = *payload.OAuthSettings
 		settings.OAuthSettings.ClientSecret = clientSecret
 		settings.OAuthSettings.KubeSecretKey = kubeSecret
+		settings.OAuthSettings.RefreshTokenKey = refreshTokenKey
+		settings.OAuthSettings.ClaimRules = claimRules
 		settings.OAuthSettings.AuthStyle = payload.OAuthSettings.AuthStyle
 	}
 
+	if payload.SAMLSettings != nil {
+		samlSettings := *payload.SAMLSettings
+
+		// the identity provider fields are read from its metadata when it is provided
+		if samlSettings.IdPMetadata != "" {
+			metadata, err := saml.ParseIdPMetadata([]byte(samlSettings.IdPMetadata))
+			if err != nil {
+				return nil, httperror.BadRequest("Invalid SAML identity provider metadata", err)
+			}
+
+			samlSettings.IdPEntityID = metadata.EntityID
+			samlSettings.IdPSSOURL = metadata.SSOURL
+			samlSettings.IdPSLOURL = metadata.SLOURL
+			samlSettings.IdPCertificates = metadata.Certificates
+		}
+
+		settings.SAMLSettings = samlSettings
+	}
+
 	settings.EnableEdgeComputeFeatures = *cmp.Or(payload.EnableEdgeComputeFeatures, &settings.EnableEdgeComputeFeatures)
 	settings.TrustOnFirstConnect = *cmp.Or(payload.TrustOnFirstConnect, &settings.TrustOnFirstConnect)
 	settings.EnforceEdgeID = *cmp.Or(payload.EnforceEdgeID, &settings.EnforceEdgeID)
 	settings.EdgePortainerURL = *cmp.Or(payload.EdgePortainerURL, &settings.EdgePortainerURL)
+	settings.InstanceURL = strings.TrimSuffix(*cmp.Or(payload.InstanceURL, &settings.InstanceURL), "/")
 
 	if payload.SnapshotInterval != nil && *payload.SnapshotInterval != settings.SnapshotInterval {
 		if err := handler.updateSnapshotInterval(settings, *payload.SnapshotInterval); err != nil {
@@ -216,8 +496,61 @@ func (handler *Handler) updateSettings(tx dataservices.DataStoreTx, payload sett
 		}
 	}
 
+	if payload.SnapshotWebhookSettings != nil {
+		secret := cmp.Or(payload.SnapshotWebhookSettings.Secret, settings.SnapshotWebhookSettings.Secret)
+
+		settings.SnapshotWebhookSettings = *payload.SnapshotWebhookSettings
+		settings.SnapshotWebhookSettings.Secret = secret
+	}
+
+	if payload.AuditLogSettings != nil {
+		secret := cmp.Or(payload.AuditLogSettings.HTTPSinkSecret, settings.AuditLogSettings.HTTPSinkSecret)
+
+		settings.AuditLogSettings = *payload.AuditLogSettings
+		settings.AuditLogSettings.HTTPSinkSecret = secret
+	}
+
+	if payload.OrphanCleanupSettings != nil && *payload.OrphanCleanupSettings != settings.OrphanCleanupSettings {
+		settings.OrphanCleanupSettings = *payload.OrphanCleanupSettings
+
+		if err := handler.OrphanService.SetSchedule(settings.OrphanCleanupSettings); err != nil {
+			return nil, httperror.InternalServerError("Unable to schedule the cleanup of the orphaned resources", err)
+		}
+	}
+
+	if payload.StackDriftCheckInterval != nil && *payload.StackDriftCheckInterval != settings.StackDriftCheckInterval {
+		settings.StackDriftCheckInterval = *payload.StackDriftCheckInterval
+
+		if err := handler.DriftService.SetSchedule(settings.StackDriftCheckInterval); err != nil {
+			return nil, httperror.InternalServerError("Unable to schedule the drift check of the stacks", err)
+		}
+	}
+
+	if payload.RetentionPolicies != nil {
+		settings.RetentionPolicies = payload.RetentionPolicies
+	}
+
+	if payload.SMTPSettings != nil {
+		password := cmp.Or(payload.SMTPSettings.Password, settings.SMTPSettings.Password)
+
+		settings.SMTPSettings = *payload.SMTPSettings
+		settings.SMTPSettings.Password = password
+	}
+
+	if payload.FleetReportSettings != nil {
+		lastSentAt := settings.FleetReportSettings.LastSentAt
+
+		settings.FleetReportSettings = *payload.FleetReportSettings
+		settings.FleetReportSettings.LastSentAt = lastSentAt
+	}
+
+	if payload.UpdateSettings != nil {
+		settings.UpdateSettings = *payload.UpdateSettings
+	}
+
 	settings.EdgeAgentCheckinInterval = *cmp.Or(payload.EdgeAgentCheckinInterval, &settings.EdgeAgentCheckinInterval)
 	settings.KubeconfigExpiry = *cmp.Or(payload.KubeconfigExpiry, &settings.KubeconfigExpiry)
+	settings.SnapshotHistorySize = *cmp.Or(payload.SnapshotHistorySize, &settings.SnapshotHistorySize)
 
 	if payload.UserSessionTimeout != nil {
 		settings.UserSessionTimeout = *payload.UserSessionTimeout
@@ -228,6 +561,7 @@ func (handler *Handler) updateSettings(tx dataservices.DataStoreTx, payload sett
 	}
 
 	settings.EnableTelemetry = *cmp.Or(payload.EnableTelemetry, &settings.EnableTelemetry)
+	settings.EnableSessionRecording = *cmp.Or(payload.EnableSessionRecording, &settings.EnableSessionRecording)
 
 	if err := handler.updateTLS(settings); err != nil {
 		return nil, err
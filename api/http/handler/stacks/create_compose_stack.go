@@ -3,11 +3,13 @@ package stacks
 import (
 	"fmt"
 	"net/http"
+	"path"
 	"strings"
 
 	portainer "github.com/portainer/portainer/api"
 	"github.com/portainer/portainer/api/filesystem"
 	"github.com/portainer/portainer/api/git/update"
+	httperrors "github.com/portainer/portainer/api/http/errors"
 	"github.com/portainer/portainer/api/http/security"
 	"github.com/portainer/portainer/api/stacks/deployments"
 	"github.com/portainer/portainer/api/stacks/stackbuilders"
@@ -180,6 +182,11 @@ type composeStackFromGitRepositoryPayload struct {
 	FromAppTemplate bool `example:"false"`
 	// TLSSkipVerify skips SSL verification when cloning the Git repository
 	TLSSkipVerify bool `example:"false"`
+	// Keep a clone of the repository on the environment and deploy the stack from it, so that the relative paths
+	// of the bind mounts point inside the repository
+	SupportRelativePath bool `example:"false"`
+	// Absolute path on the environment the repository is cloned under. Required when SupportRelativePath is true
+	FilesystemPath string `example:"/opt/portainer"`
 }
 
 func createStackPayloadFromComposeGitPayload(name, repoUrl, repoReference, repoUsername, repoPassword string, repoAuthentication bool, composeFile string, additionalFiles []string, autoUpdate *portainer.AutoUpdateSettings, env []portainer.Pair, fromAppTemplate bool, repoSkipSSLVerify bool) stackbuilders.StackPayload {
@@ -214,12 +221,28 @@ func (payload *composeStackFromGitRepositoryPayload) Validate(r *http.Request) e
 	if err := update.ValidateAutoUpdateSettings(payload.AutoUpdate); err != nil {
 		return err
 	}
+	if payload.SupportRelativePath && !isAbsolutePath(payload.FilesystemPath) {
+		return errors.New("Invalid filesystem path. Must be an absolute path when the relative paths are supported")
+	}
 	return nil
 }
 
+// isAbsolutePath checks if a path of an environment is absolute, for both the Linux and the Windows environments
+func isAbsolutePath(p string) bool {
+	p = strings.ReplaceAll(p, `\`, "/")
+	if len(p) >= 3 && p[1] == ':' && p[2] == '/' {
+		return true
+	}
+
+	return path.IsAbs(p) && path.Clean(p) != "/"
+}
+
 // @id StackCreateDockerStandaloneRepository
 // @summary Deploy a new compose stack from repository
 // @description Deploy a new stack into a Docker environment specified via the environment identifier.
+// @description With SupportRelativePath, the repository is kept under FilesystemPath on the environment and the stack
+// @description is deployed from it, which requires the administrators of the environment unless the bind mounts are
+// @description allowed for the regular users.
 // @description **Access policy**: authenticated
 // @tags stacks
 // @security ApiKeyAuth
@@ -230,6 +253,7 @@ func (payload *composeStackFromGitRepositoryPayload) Validate(r *http.Request) e
 // @param body body composeStackFromGitRepositoryPayload true "stack config"
 // @success 200 {object} portainer.Stack
 // @failure 400 "Invalid request"
+// @failure 403 "Permission denied"
 // @failure 409 "Stack name or webhook ID already exists"
 // @failure 500 "Server error"
 // @router /stacks/create/standalone/repository [post]
@@ -283,6 +307,18 @@ func (handler *Handler) createComposeStackFromGitRepository(w http.ResponseWrite
 		return httperror.InternalServerError("Unable to retrieve info from request context", err)
 	}
 
+	if payload.SupportRelativePath && !endpoint.SecuritySettings.AllowBindMountsForRegularUsers {
+		// the repository is written to the filesystem of the host, like a bind mount
+		canBindMount, err := handler.userCanCreateStack(securityContext, endpoint.ID)
+		if err != nil {
+			return httperror.InternalServerError("Unable to verify user authorizations", err)
+		}
+
+		if !canBindMount {
+			return httperror.Forbidden("Only the administrators of the environment can deploy a stack with relative paths", httperrors.ErrResourceAccessDenied)
+		}
+	}
+
 	stackPayload := createStackPayloadFromComposeGitPayload(payload.Name,
 		strings.TrimSuffix(payload.RepositoryURL, "/"),
 		payload.RepositoryReferenceName,
@@ -296,6 +332,8 @@ func (handler *Handler) createComposeStackFromGitRepository(w http.ResponseWrite
 		payload.FromAppTemplate,
 		payload.TLSSkipVerify,
 	)
+	stackPayload.SupportRelativePath = payload.SupportRelativePath
+	stackPayload.FilesystemPath = payload.FilesystemPath
 
 	composeStackBuilder := stackbuilders.CreateComposeStackGitBuilder(securityContext,
 		handler.DataStore,
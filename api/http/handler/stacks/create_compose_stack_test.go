@@ -0,0 +1,28 @@
+package stacks
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestComposeStackFromGitRepositoryPayloadValidate(t *testing.T) {
+	is := require.New(t)
+
+	payload := composeStackFromGitRepositoryPayload{
+		Name:                "shop",
+		RepositoryURL:       "https://github.com/portainer/portainer-compose",
+		SupportRelativePath: true,
+	}
+	is.Error(payload.Validate(nil), "the filesystem path is required with the relative paths")
+
+	for _, p := range []string{"/", "opt/portainer", "../portainer"} {
+		payload.FilesystemPath = p
+		is.Error(payload.Validate(nil), p)
+	}
+
+	for _, p := range []string{"/opt/portainer", `C:\portainer`, "D:/portainer"} {
+		payload.FilesystemPath = p
+		is.NoError(payload.Validate(nil), p)
+	}
+}
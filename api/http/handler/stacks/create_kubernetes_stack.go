@@ -50,11 +50,14 @@ type kubernetesGitDeploymentPayload struct {
 	ManifestFile             string
 	AdditionalFiles          []string
 	AutoUpdate               *portainer.AutoUpdateSettings
+	// Overlay directory built when the manifest file is a kustomization, relative to the root of the repository.
+	// It can reference the variables of the environment, e.g. overlays/${ENVIRONMENT}
+	KustomizeOverlay string `example:"overlays/production"`
 	// TLSSkipVerify skips SSL verification when cloning the Git repository
 	TLSSkipVerify bool `example:"false"`
 }
 
-func createStackPayloadFromK8sGitPayload(name, repoUrl, repoReference, repoUsername, repoPassword string, repoAuthentication, composeFormat bool, namespace, manifest string, additionalFiles []string, autoUpdate *portainer.AutoUpdateSettings, repoSkipSSLVerify bool) stackbuilders.StackPayload {
+func createStackPayloadFromK8sGitPayload(name, repoUrl, repoReference, repoUsername, repoPassword string, repoAuthentication, composeFormat bool, namespace, manifest string, additionalFiles []string, autoUpdate *portainer.AutoUpdateSettings, repoSkipSSLVerify bool, kustomizeOverlay string) stackbuilders.StackPayload {
 	return stackbuilders.StackPayload{
 		StackName: name,
 		RepositoryConfigPayload: stackbuilders.RepositoryConfigPayload{
@@ -65,10 +68,11 @@ func createStackPayloadFromK8sGitPayload(name, repoUrl, repoReference, repoUsern
 			Password:       repoPassword,
 			TLSSkipVerify:  repoSkipSSLVerify,
 		},
-		Namespace:       namespace,
-		ManifestFile:    manifest,
-		AdditionalFiles: additionalFiles,
-		AutoUpdate:      autoUpdate,
+		Namespace:        namespace,
+		ManifestFile:     manifest,
+		AdditionalFiles:  additionalFiles,
+		AutoUpdate:       autoUpdate,
+		KustomizeOverlay: kustomizeOverlay,
 	}
 }
 
@@ -108,6 +112,10 @@ func (payload *kubernetesGitDeploymentPayload) Validate(r *http.Request) error {
 		return errors.New("Invalid manifest file in repository")
 	}
 
+	if err := stackutils.ValidateKustomizeOverlay(payload.KustomizeOverlay); err != nil {
+		return err
+	}
+
 	return update.ValidateAutoUpdateSettings(payload.AutoUpdate)
 }
 
@@ -231,6 +239,7 @@ func (handler *Handler) createKubernetesStackFromGitRepository(w http.ResponseWr
 		payload.AdditionalFiles,
 		payload.AutoUpdate,
 		payload.TLSSkipVerify,
+		payload.KustomizeOverlay,
 	)
 
 	k8sStackBuilder := stackbuilders.CreateKubernetesStackGitBuilder(handler.DataStore,
@@ -304,7 +313,7 @@ func (handler *Handler) deployKubernetesStack(userID portainer.UserID, endpoint
 	user := &portainer.User{
 		ID: userID,
 	}
-	k8sDeploymentConfig, err := deployments.CreateKubernetesStackDeploymentConfig(stack, handler.KubernetesDeployer, appLabels, user, endpoint)
+	k8sDeploymentConfig, err := deployments.CreateKubernetesStackDeploymentConfig(handler.DataStore, stack, handler.KubernetesDeployer, appLabels, user, endpoint)
 	if err != nil {
 		return "", errors.Wrap(err, "failed to create temp kub deployment files")
 	}
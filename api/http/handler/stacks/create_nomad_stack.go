@@ -0,0 +1,128 @@
+package stacks
+
+import (
+	"context"
+	"net/http"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/nomad"
+	"github.com/portainer/portainer/api/stacks/stackbuilders"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/request"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+
+	"github.com/pkg/errors"
+)
+
+type nomadStringDeploymentPayload struct {
+	// Name of the stack
+	StackName string `example:"myStack" validate:"required"`
+	// Content of the HCL Nomad jobspec
+	StackFileContent string `example:"job \"web\" { ... }" validate:"required"`
+}
+
+func (payload *nomadStringDeploymentPayload) Validate(r *http.Request) error {
+	if len(payload.StackName) == 0 {
+		return errors.New("Invalid stack name")
+	}
+
+	if len(payload.StackFileContent) == 0 {
+		return errors.New("Invalid stack file content")
+	}
+
+	return nil
+}
+
+type createNomadStackResponse struct {
+	// Identifier of the evaluation created by the job registration
+	EvalID string `json:"EvalID"`
+	Stack  *portainer.Stack
+}
+
+func (handler *Handler) createNomadStack(w http.ResponseWriter, r *http.Request, method string, endpoint *portainer.Endpoint, userID portainer.UserID) *httperror.HandlerError {
+	switch method {
+	case "string":
+		return handler.createNomadStackFromFileContent(w, r, endpoint, userID)
+	}
+
+	return httperror.BadRequest("Invalid value for query parameter: method. Value must be: string", errors.New(request.ErrInvalidQueryParameter))
+}
+
+// @id StackCreateNomadString
+// @summary Deploy a new Nomad stack from a jobspec
+// @description Register the job of a HCL jobspec into a Nomad environment specified via the environment identifier.
+// @description **Access policy**: authenticated
+// @tags stacks
+// @security ApiKeyAuth
+// @security jwt
+// @produce json
+// @param body body nomadStringDeploymentPayload true "stack config"
+// @param endpointId query int true "Identifier of the environment that will be used to deploy the stack"
+// @success 200 {object} createNomadStackResponse
+// @failure 400 "Invalid request"
+// @failure 409 "Stack name already exists"
+// @failure 500 "Server error"
+// @router /stacks/create/nomad/string [post]
+func (handler *Handler) createNomadStackFromFileContent(w http.ResponseWriter, r *http.Request, endpoint *portainer.Endpoint, userID portainer.UserID) *httperror.HandlerError {
+	if endpoint.Type != portainer.NomadEnvironment {
+		return httperror.BadRequest("Environment type does not match", errors.New("Environment type does not match"))
+	}
+
+	var payload nomadStringDeploymentPayload
+	if err := request.DecodeAndValidateJSONPayload(r, &payload); err != nil {
+		return httperror.BadRequest("Invalid request payload", err)
+	}
+
+	user, err := handler.DataStore.User().Read(userID)
+	if err != nil {
+		return httperror.InternalServerError("Unable to load user information from the database", err)
+	}
+
+	if isUnique, err := handler.checkUniqueStackName(endpoint, payload.StackName, 0); err != nil {
+		return httperror.InternalServerError("Unable to check for name collision", err)
+	} else if !isUnique {
+		return stackExistsError(payload.StackName)
+	}
+
+	stackPayload := stackbuilders.StackPayload{
+		StackName:        payload.StackName,
+		StackFileContent: payload.StackFileContent,
+	}
+
+	nomadStackBuilder := stackbuilders.CreateNomadStackFileContentBuilder(handler.DataStore, handler.FileService, user)
+
+	stackBuilderDirector := stackbuilders.NewStackBuilderDirector(nomadStackBuilder)
+	stack, httpErr := stackBuilderDirector.Build(&stackPayload, endpoint)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	return response.JSON(w, &createNomadStackResponse{EvalID: nomadStackBuilder.GetResponse(), Stack: stack})
+}
+
+// deleteNomadStack deregisters the job of the stored jobspec of the stack
+func deleteNomadStack(stack *portainer.Stack, endpoint *portainer.Endpoint, fileService portainer.FileService) error {
+	jobspec, err := fileService.GetFileContent(stack.ProjectPath, stack.EntryPoint)
+	if err != nil {
+		return errors.WithMessage(err, "failed to read the Nomad jobspec")
+	}
+
+	client, err := nomad.NewClient(endpoint)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.TODO()
+
+	job, err := client.ParseJob(ctx, string(jobspec))
+	if err != nil {
+		return errors.WithMessage(err, "failed to parse the Nomad jobspec")
+	}
+
+	jobID, _ := job["ID"].(string)
+	if jobID == "" {
+		return errors.New("the Nomad jobspec has no job identifier")
+	}
+
+	return client.DeregisterJob(ctx, jobID)
+}
@@ -0,0 +1,131 @@
+package stacks
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/docker/quarantine"
+	"github.com/portainer/portainer/api/http/security"
+	"github.com/portainer/portainer/api/internal/endpointutils"
+	k "github.com/portainer/portainer/api/kubernetes"
+	"github.com/portainer/portainer/api/stacks/deployments"
+	"github.com/portainer/portainer/api/stacks/stackutils"
+	"github.com/portainer/portainer/api/stacks/stackversions"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+
+	"github.com/rs/zerolog/log"
+)
+
+// authorizeStackCreation checks that the user of the request can create and deploy a stack on the environment
+func (handler *Handler) authorizeStackCreation(r *http.Request, securityContext *security.RestrictedRequestContext, endpoint *portainer.Endpoint) *httperror.HandlerError {
+	canManage, err := handler.userCanManageStacks(securityContext, endpoint)
+	if err != nil {
+		return httperror.InternalServerError("Unable to verify user authorizations to validate stack creation", err)
+	}
+	if !canManage {
+		errMsg := "Stack creation is disabled for non-admin users"
+		return httperror.Forbidden(errMsg, errors.New(errMsg))
+	}
+
+	if err := handler.requestBouncer.AuthorizedEndpointOperation(r, endpoint); err != nil {
+		return httperror.Forbidden("Permission denied to access environment", err)
+	}
+
+	if err := handler.requestBouncer.AuthorizedEndpointRoleOperation(r, endpoint, portainer.OperationPortainerStackCreate); err != nil {
+		return httperror.Forbidden("Permission denied by your role on the environment", err)
+	}
+
+	if err := quarantine.Check(endpoint); err != nil {
+		return httperror.Conflict("Unable to deploy the stack", err)
+	}
+
+	return nil
+}
+
+// checkStackEnvironment checks that a stack of the given type can be deployed on the environment
+func checkStackEnvironment(stackType portainer.StackType, endpoint *portainer.Endpoint, swarmID string) *httperror.HandlerError {
+	switch stackType {
+	case portainer.DockerComposeStack:
+		if !endpointutils.IsDockerEndpoint(endpoint) {
+			return httperror.BadRequest("Environment type does not match", errors.New("a Compose stack can only be deployed to a Docker environment"))
+		}
+	case portainer.DockerSwarmStack:
+		if !endpointutils.IsDockerEndpoint(endpoint) || endpoint.ContainerEngine == portainer.ContainerEnginePodman {
+			return httperror.BadRequest("Environment type does not match", errors.New("a Swarm stack can only be deployed to a Docker environment"))
+		}
+
+		if swarmID == "" {
+			return httperror.BadRequest("Invalid request payload", errors.New("the Swarm cluster identifier is required for a Swarm stack"))
+		}
+	case portainer.KubernetesStack:
+		if !endpointutils.IsKubernetesEndpoint(endpoint) {
+			return httperror.BadRequest("Environment type does not match", errors.New("a Kubernetes stack can only be deployed to a Kubernetes environment"))
+		}
+	default:
+		return httperror.BadRequest("Invalid stack type", errors.New("only the Compose, Swarm and Kubernetes stacks are supported"))
+	}
+
+	return nil
+}
+
+// createStackFromFiles stores the files of a new stack, by file name, deploys the stack and saves it. The files are
+// removed when the deployment fails
+func (handler *Handler) createStackFromFiles(securityContext *security.RestrictedRequestContext, stack *portainer.Stack, endpoint *portainer.Endpoint, user *portainer.User, files map[string][]byte) *httperror.HandlerError {
+	stackFolder := strconv.Itoa(int(stack.ID))
+	for _, file := range append([]string{stack.EntryPoint}, stack.AdditionalFiles...) {
+		projectPath, err := handler.FileService.StoreStackFileFromBytes(stackFolder, file, files[file])
+		if err != nil {
+			return httperror.InternalServerError("Unable to persist the stack files on disk", err)
+		}
+
+		stack.ProjectPath = projectPath
+	}
+
+	if httpErr := handler.deployNewStack(securityContext, stack, endpoint, user); httpErr != nil {
+		if err := handler.FileService.RemoveDirectory(stack.ProjectPath); err != nil {
+			log.Warn().Err(err).Msg("unable to remove the files of the stack")
+		}
+
+		return httpErr
+	}
+
+	if err := handler.DataStore.Stack().Create(stack); err != nil {
+		return httperror.InternalServerError("Unable to persist the stack inside the database", err)
+	}
+
+	stackversions.RecordStack(handler.DataStore, stack, stack.CreatedBy)
+
+	return nil
+}
+
+func (handler *Handler) deployNewStack(securityContext *security.RestrictedRequestContext, stack *portainer.Stack, endpoint *portainer.Endpoint, user *portainer.User) *httperror.HandlerError {
+	var deploymentConfiger deployments.StackDeploymentConfiger
+	var err error
+
+	switch stack.Type {
+	case portainer.DockerComposeStack:
+		deploymentConfiger, err = deployments.CreateComposeStackDeploymentConfig(securityContext, stack, endpoint, handler.DataStore, handler.FileService, handler.StackDeployer, true, false)
+	case portainer.DockerSwarmStack:
+		deploymentConfiger, err = deployments.CreateSwarmStackDeploymentConfig(securityContext, stack, endpoint, handler.DataStore, handler.FileService, handler.StackDeployer, stack.Option != nil && stack.Option.Prune, true)
+	case portainer.KubernetesStack:
+		appLabels := k.KubeAppLabels{
+			StackID:   int(stack.ID),
+			StackName: stack.Name,
+			Owner:     stackutils.SanitizeLabel(user.Username),
+			Kind:      "content",
+		}
+
+		deploymentConfiger, err = deployments.CreateKubernetesStackDeploymentConfig(handler.DataStore, stack, handler.KubernetesDeployer, appLabels, user, endpoint)
+	}
+	if err != nil {
+		return httperror.InternalServerError(err.Error(), err)
+	}
+
+	if err := deploymentConfiger.Deploy(); err != nil {
+		return httperror.InternalServerError(err.Error(), err)
+	}
+
+	return nil
+}
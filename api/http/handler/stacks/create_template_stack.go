@@ -0,0 +1,193 @@
+package stacks
+
+import (
+	"errors"
+	"net/http"
+	"path"
+	"strconv"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+	httperrors "github.com/portainer/portainer/api/http/errors"
+	"github.com/portainer/portainer/api/http/security"
+	"github.com/portainer/portainer/api/internal/authorization"
+	"github.com/portainer/portainer/api/stacks/templatevariables"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/request"
+)
+
+type stackCreateFromTemplatePayload struct {
+	// Identifier of the custom template to deploy
+	CustomTemplateID portainer.CustomTemplateID `example:"1" validate:"required"`
+	// Name of the stack
+	Name string `example:"myStack" validate:"required"`
+	// Values of the variables of the template, by name. The variables without value get their default value
+	Variables map[string]string
+	// Swarm cluster identifier, required for the Swarm templates
+	SwarmID string `example:"jpofkc0i9uo9wtx1zesuk649w"`
+	// Kubernetes namespace of the stack, default when empty
+	Namespace string `example:"default"`
+	// A list of environment variables used during stack deployment
+	Env []portainer.Pair
+}
+
+func (payload *stackCreateFromTemplatePayload) Validate(r *http.Request) error {
+	if payload.CustomTemplateID == 0 {
+		return errors.New("Invalid custom template identifier")
+	}
+
+	if payload.Name == "" {
+		return errors.New("Invalid stack name")
+	}
+
+	return nil
+}
+
+// @id StackCreateFromTemplate
+// @summary Deploy a custom template
+// @description Create and deploy a stack from a custom template. The {{ name }} references to the variables of the
+// @description template in its file are replaced by the given values, or by the default values of the variables.
+// @description The values are checked against the type and the validation regular expression of the variables, the
+// @description schema of the variables is returned by the custom template schema.
+// @description **Access policy**: authenticated
+// @tags stacks
+// @security ApiKeyAuth
+// @security jwt
+// @accept json
+// @produce json
+// @param endpointId query int true "Identifier of the environment the stack is deployed to"
+// @param body body stackCreateFromTemplatePayload true "Template deployment"
+// @success 200 {object} portainer.Stack "Success"
+// @failure 400 "Invalid request"
+// @failure 403 "Permission denied"
+// @failure 404 "Environment or custom template not found"
+// @failure 409 "A stack with the same name already exists or the environment is quarantined"
+// @failure 500 "Server error"
+// @router /stacks/create/template [post]
+func (handler *Handler) stackCreateFromTemplate(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	endpointID, err := request.RetrieveNumericQueryParameter(r, "endpointId", false)
+	if err != nil {
+		return httperror.BadRequest("Invalid query parameter: endpointId", err)
+	}
+
+	var payload stackCreateFromTemplatePayload
+	if err := request.DecodeAndValidateJSONPayload(r, &payload); err != nil {
+		return httperror.BadRequest("Invalid request payload", err)
+	}
+
+	endpoint, err := handler.DataStore.Endpoint().Endpoint(portainer.EndpointID(endpointID))
+	if handler.DataStore.IsErrObjectNotFound(err) {
+		return httperror.NotFound("Unable to find an environment with the specified identifier inside the database", err)
+	} else if err != nil {
+		return httperror.InternalServerError("Unable to find an environment with the specified identifier inside the database", err)
+	}
+
+	securityContext, err := security.RetrieveRestrictedRequestContext(r)
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve user info from request context", err)
+	}
+
+	if httpErr := handler.authorizeStackCreation(r, securityContext, endpoint); httpErr != nil {
+		return httpErr
+	}
+
+	customTemplate, err := handler.DataStore.CustomTemplate().Read(payload.CustomTemplateID)
+	if handler.DataStore.IsErrObjectNotFound(err) {
+		return httperror.NotFound("Unable to find a custom template with the specified identifier inside the database", err)
+	} else if err != nil {
+		return httperror.InternalServerError("Unable to find a custom template with the specified identifier inside the database", err)
+	}
+
+	if !securityContext.IsAdmin {
+		resourceControl, err := handler.DataStore.ResourceControl().ResourceControlByResourceIDAndType(strconv.Itoa(int(customTemplate.ID)), portainer.CustomTemplateResourceControl)
+		if err != nil {
+			return httperror.InternalServerError("Unable to retrieve a resource control associated to the custom template", err)
+		}
+
+		userTeamIDs := make([]portainer.TeamID, 0, len(securityContext.UserMemberships))
+		for _, membership := range securityContext.UserMemberships {
+			userTeamIDs = append(userTeamIDs, membership.TeamID)
+		}
+
+		if !authorization.UserCanAccessCustomTemplate(customTemplate, resourceControl, securityContext.UserID, userTeamIDs) {
+			return httperror.Forbidden("Access denied to the custom template", httperrors.ErrResourceAccessDenied)
+		}
+	}
+
+	if customTemplate.Type == portainer.KubernetesStack && customTemplate.IsComposeFormat {
+		return httperror.BadRequest("Unsupported custom template", errors.New("the Kubernetes templates in the Compose format cannot be deployed"))
+	}
+
+	if httpErr := checkStackEnvironment(customTemplate.Type, endpoint, payload.SwarmID); httpErr != nil {
+		return httpErr
+	}
+
+	values, err := templatevariables.Resolve(customTemplate.Variables, payload.Variables)
+	if err != nil {
+		return httperror.BadRequest("Invalid template variables", err)
+	}
+
+	entryPoint := customTemplate.EntryPoint
+	if customTemplate.GitConfig != nil {
+		entryPoint = customTemplate.GitConfig.ConfigFilePath
+	}
+
+	content, err := handler.FileService.GetFileContent(customTemplate.ProjectPath, entryPoint)
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve custom template file from disk", err)
+	}
+
+	name := payload.Name
+	switch customTemplate.Type {
+	case portainer.DockerComposeStack:
+		name = handler.ComposeStackManager.NormalizeStackName(name)
+	case portainer.DockerSwarmStack:
+		name = handler.SwarmStackManager.NormalizeStackName(name)
+	}
+
+	var isUnique bool
+	if customTemplate.Type == portainer.KubernetesStack {
+		isUnique, err = handler.checkUniqueStackName(endpoint, name, 0)
+	} else {
+		isUnique, err = handler.checkUniqueStackNameInDocker(endpoint, name, 0, customTemplate.Type == portainer.DockerSwarmStack)
+	}
+	if err != nil {
+		return httperror.InternalServerError("Unable to check for name collision", err)
+	} else if !isUnique {
+		return stackExistsError(name)
+	}
+
+	user, err := handler.DataStore.User().Read(securityContext.UserID)
+	if err != nil {
+		return httperror.InternalServerError("Unable to load user information from the database", err)
+	}
+
+	stack := &portainer.Stack{
+		ID:           portainer.StackID(handler.DataStore.Stack().GetNextIdentifier()),
+		Name:         name,
+		Type:         customTemplate.Type,
+		EndpointID:   endpoint.ID,
+		EntryPoint:   path.Base(entryPoint),
+		Env:          payload.Env,
+		Status:       portainer.StackStatusActive,
+		CreationDate: time.Now().Unix(),
+		CreatedBy:    user.Username,
+	}
+
+	switch stack.Type {
+	case portainer.DockerSwarmStack:
+		stack.SwarmID = payload.SwarmID
+	case portainer.KubernetesStack:
+		stack.Namespace = payload.Namespace
+		if stack.Namespace == "" {
+			stack.Namespace = "default"
+		}
+	}
+
+	files := map[string][]byte{stack.EntryPoint: templatevariables.Render(content, values)}
+	if httpErr := handler.createStackFromFiles(securityContext, stack, endpoint, user, files); httpErr != nil {
+		return httpErr
+	}
+
+	return handler.decorateStackResponse(w, stack, user.ID)
+}
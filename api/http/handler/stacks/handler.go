@@ -11,6 +11,7 @@ import (
 	"github.com/portainer/portainer/api/dataservices"
 	dockerclient "github.com/portainer/portainer/api/docker/client"
 	"github.com/portainer/portainer/api/docker/consts"
+	httperrors "github.com/portainer/portainer/api/http/errors"
 	"github.com/portainer/portainer/api/http/middlewares"
 	"github.com/portainer/portainer/api/http/security"
 	"github.com/portainer/portainer/api/internal/authorization"
@@ -18,8 +19,11 @@ import (
 	"github.com/portainer/portainer/api/kubernetes/cli"
 	"github.com/portainer/portainer/api/scheduler"
 	"github.com/portainer/portainer/api/stacks/deployments"
+	"github.com/portainer/portainer/api/stacks/drift"
+	"github.com/portainer/portainer/api/stacks/hooks"
 	"github.com/portainer/portainer/api/stacks/stackutils"
 	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/request"
 
 	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/api/types/container"
@@ -43,6 +47,17 @@ type Handler struct {
 	KubernetesClientFactory *cli.ClientFactory
 	Scheduler               *scheduler.Scheduler
 	StackDeployer           deployments.StackDeployer
+	DriftService            *drift.Service
+}
+
+// sanitizeStack removes the git password and the secrets of the hooks of a stack returned by the API, to minimise
+// possible security leaks
+func sanitizeStack(stack *portainer.Stack) {
+	if stack.GitConfig != nil && stack.GitConfig.Authentication != nil && stack.GitConfig.Authentication.Password != "" {
+		stack.GitConfig.Authentication.Password = ""
+	}
+
+	stack.Hooks = hooks.Redact(stack.Hooks)
 }
 
 func stackExistsError(name string) *httperror.HandlerError {
@@ -66,6 +81,14 @@ func NewHandler(bouncer security.BouncerService) *Handler {
 		bouncer.AuthenticatedAccess(middlewares.Deprecated(h, deprecatedStackCreateUrlParser))).Methods(http.MethodPost) // Deprecated
 	h.Handle("/stacks",
 		bouncer.AuthenticatedAccess(httperror.LoggerHandler(h.stackList))).Methods(http.MethodGet)
+	h.Handle("/stacks/preview/kubernetes/{method}",
+		bouncer.AuthenticatedAccess(httperror.LoggerHandler(h.stackPreviewKubernetes))).Methods(http.MethodPost)
+	h.Handle("/stacks/create/template",
+		bouncer.AuthenticatedAccess(httperror.LoggerHandler(h.stackCreateFromTemplate))).Methods(http.MethodPost)
+	h.Handle("/stacks/import",
+		bouncer.AuthenticatedAccess(httperror.LoggerHandler(h.stackImport))).Methods(http.MethodPost)
+	h.Handle("/stacks/convert",
+		bouncer.AuthenticatedAccess(httperror.LoggerHandler(h.stackConvert))).Methods(http.MethodPost)
 	h.Handle("/stacks/{id}",
 		bouncer.AuthenticatedAccess(httperror.LoggerHandler(h.stackInspect))).Methods(http.MethodGet)
 	h.Handle("/stacks/{id}",
@@ -80,14 +103,40 @@ func NewHandler(bouncer security.BouncerService) *Handler {
 		bouncer.AuthenticatedAccess(httperror.LoggerHandler(h.stackUpdateGit))).Methods(http.MethodPost)
 	h.Handle("/stacks/{id}/git/redeploy",
 		bouncer.AuthenticatedAccess(httperror.LoggerHandler(h.stackGitRedeploy))).Methods(http.MethodPut)
+	h.Handle("/stacks/{id}/autoupdate/pause",
+		bouncer.AuthenticatedAccess(httperror.LoggerHandler(h.stackAutoUpdatePause))).Methods(http.MethodPost)
+	h.Handle("/stacks/{id}/autoupdate/resume",
+		bouncer.AuthenticatedAccess(httperror.LoggerHandler(h.stackAutoUpdateResume))).Methods(http.MethodPost)
+	h.Handle("/stacks/{id}/autoupdate/redeploy",
+		bouncer.AuthenticatedAccess(httperror.LoggerHandler(h.stackAutoUpdateRedeploy))).Methods(http.MethodPost)
+	h.Handle("/stacks/{id}/versions",
+		bouncer.AuthenticatedAccess(httperror.LoggerHandler(h.stackVersionList))).Methods(http.MethodGet)
+	h.Handle("/stacks/{id}/versions/diff",
+		bouncer.AuthenticatedAccess(httperror.LoggerHandler(h.stackVersionDiff))).Methods(http.MethodGet)
+	h.Handle("/stacks/{id}/versions/{version}/rollback",
+		bouncer.AuthenticatedAccess(httperror.LoggerHandler(h.stackVersionRollback))).Methods(http.MethodPost)
+	h.Handle("/stacks/{id}/build",
+		bouncer.AuthenticatedAccess(httperror.LoggerHandler(h.stackBuild))).Methods(http.MethodPost)
+	h.Handle("/stacks/{id}/drift",
+		bouncer.AuthenticatedAccess(httperror.LoggerHandler(h.stackDrift))).Methods(http.MethodGet)
+	h.Handle("/stacks/{id}/export",
+		bouncer.AuthenticatedAccess(httperror.LoggerHandler(h.stackExport))).Methods(http.MethodGet)
 	h.Handle("/stacks/{id}/file",
 		bouncer.AuthenticatedAccess(httperror.LoggerHandler(h.stackFile))).Methods(http.MethodGet)
+	h.Handle("/stacks/{id}/hooks",
+		bouncer.AuthenticatedAccess(httperror.LoggerHandler(h.stackUpdateHooks))).Methods(http.MethodPut)
+	h.Handle("/stacks/{id}/hooks/test",
+		bouncer.AuthenticatedAccess(httperror.LoggerHandler(h.stackTestHook))).Methods(http.MethodPost)
 	h.Handle("/stacks/{id}/migrate",
 		bouncer.AuthenticatedAccess(httperror.LoggerHandler(h.stackMigrate))).Methods(http.MethodPost)
+	h.Handle("/stacks/{id}/services/{serviceName}/scale",
+		bouncer.AuthenticatedAccess(httperror.LoggerHandler(h.stackServiceScale))).Methods(http.MethodPost)
 	h.Handle("/stacks/{id}/start",
 		bouncer.AuthenticatedAccess(httperror.LoggerHandler(h.stackStart))).Methods(http.MethodPost)
 	h.Handle("/stacks/{id}/stop",
 		bouncer.AuthenticatedAccess(httperror.LoggerHandler(h.stackStop))).Methods(http.MethodPost)
+	h.Handle("/stacks/{id}/usage",
+		bouncer.AuthenticatedAccess(httperror.LoggerHandler(h.stackUsage))).Methods(http.MethodGet)
 	h.Handle("/stacks/webhooks/{webhookID}",
 		bouncer.PublicAccess(httperror.LoggerHandler(h.webhookInvoke))).Methods(http.MethodPost)
 
@@ -215,3 +264,60 @@ func (handler *Handler) checkUniqueWebhookID(webhookID string) (bool, error) {
 	}
 	return false, err
 }
+
+// manageableStack returns the stack of the id route variable, once it is checked that the user can update it
+func (handler *Handler) manageableStack(r *http.Request) (*portainer.Stack, *portainer.Endpoint, *httperror.HandlerError) {
+	stackID, err := request.RetrieveNumericRouteVariableValue(r, "id")
+	if err != nil {
+		return nil, nil, httperror.BadRequest("Invalid stack identifier route variable", err)
+	}
+
+	stack, err := handler.DataStore.Stack().Read(portainer.StackID(stackID))
+	if handler.DataStore.IsErrObjectNotFound(err) {
+		return nil, nil, httperror.NotFound("Unable to find a stack with the specified identifier inside the database", err)
+	} else if err != nil {
+		return nil, nil, httperror.InternalServerError("Unable to find a stack with the specified identifier inside the database", err)
+	}
+
+	endpoint, err := handler.DataStore.Endpoint().Endpoint(stack.EndpointID)
+	if handler.DataStore.IsErrObjectNotFound(err) {
+		return nil, nil, httperror.NotFound("Unable to find the environment associated to the stack inside the database", err)
+	} else if err != nil {
+		return nil, nil, httperror.InternalServerError("Unable to find the environment associated to the stack inside the database", err)
+	}
+
+	if err := handler.requestBouncer.AuthorizedEndpointOperation(r, endpoint); err != nil {
+		return nil, nil, httperror.Forbidden("Permission denied to access environment", err)
+	}
+
+	if err := handler.requestBouncer.AuthorizedEndpointRoleOperation(r, endpoint, portainer.OperationPortainerStackUpdate); err != nil {
+		return nil, nil, httperror.Forbidden("Permission denied by your role on the environment", err)
+	}
+
+	securityContext, err := security.RetrieveRestrictedRequestContext(r)
+	if err != nil {
+		return nil, nil, httperror.InternalServerError("Unable to retrieve info from request context", err)
+	}
+
+	if stack.Type == portainer.DockerSwarmStack || stack.Type == portainer.DockerComposeStack {
+		resourceControl, err := handler.DataStore.ResourceControl().ResourceControlByResourceIDAndType(stackutils.ResourceControlID(stack.EndpointID, stack.Name), portainer.StackResourceControl)
+		if err != nil {
+			return nil, nil, httperror.InternalServerError("Unable to retrieve a resource control associated to the stack", err)
+		}
+
+		if access, err := handler.userCanAccessStack(securityContext, endpoint.ID, resourceControl); err != nil {
+			return nil, nil, httperror.InternalServerError("Unable to verify user authorizations to validate stack access", err)
+		} else if !access {
+			return nil, nil, httperror.Forbidden("Access denied to resource", httperrors.ErrResourceAccessDenied)
+		}
+	}
+
+	if canManage, err := handler.userCanManageStacks(securityContext, endpoint); err != nil {
+		return nil, nil, httperror.InternalServerError("Unable to verify user authorizations to validate stack update", err)
+	} else if !canManage {
+		errMsg := "Stack editing is disabled for non-admin users"
+		return nil, nil, httperror.Forbidden(errMsg, errors.New(errMsg))
+	}
+
+	return stack, endpoint, nil
+}
@@ -0,0 +1,155 @@
+package stacks
+
+import (
+	"errors"
+	"net/http"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/stacks/deployments"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+)
+
+// @id StackAutoUpdatePause
+// @summary Pause the auto update of a git stack
+// @description Stop polling the git repository of the stack and ignore the invocations of its webhook until the auto update is resumed.
+// @description **Access policy**: authenticated
+// @tags stacks
+// @security ApiKeyAuth
+// @security jwt
+// @produce json
+// @param id path int true "Stack identifier"
+// @success 200 {object} portainer.Stack "Success"
+// @failure 400 "Invalid request"
+// @failure 403 "Permission denied"
+// @failure 404 "Not found"
+// @failure 409 "The auto update of the stack is not enabled"
+// @failure 500 "Server error"
+// @router /stacks/{id}/autoupdate/pause [post]
+func (handler *Handler) stackAutoUpdatePause(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	stack, httpErr := handler.autoUpdateStack(r)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	if stack.AutoUpdate == nil {
+		return httperror.Conflict("The auto update of the stack is not enabled", errors.New("no auto update settings"))
+	}
+
+	deployments.StopAutoupdate(stack.ID, stack.AutoUpdate.JobID, handler.Scheduler)
+
+	stack.AutoUpdate.Paused = true
+	stack.AutoUpdate.JobID = ""
+
+	return handler.saveAutoUpdateStack(w, stack)
+}
+
+// @id StackAutoUpdateResume
+// @summary Resume the auto update of a git stack
+// @description Resume polling the git repository of the stack and accept the invocations of its webhook again.
+// @description **Access policy**: authenticated
+// @tags stacks
+// @security ApiKeyAuth
+// @security jwt
+// @produce json
+// @param id path int true "Stack identifier"
+// @success 200 {object} portainer.Stack "Success"
+// @failure 400 "Invalid request"
+// @failure 403 "Permission denied"
+// @failure 404 "Not found"
+// @failure 409 "The auto update of the stack is not enabled"
+// @failure 500 "Server error"
+// @router /stacks/{id}/autoupdate/resume [post]
+func (handler *Handler) stackAutoUpdateResume(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	stack, httpErr := handler.autoUpdateStack(r)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	if stack.AutoUpdate == nil {
+		return httperror.Conflict("The auto update of the stack is not enabled", errors.New("no auto update settings"))
+	}
+
+	if !stack.AutoUpdate.Paused {
+		return handler.saveAutoUpdateStack(w, stack)
+	}
+
+	stack.AutoUpdate.Paused = false
+
+	if stack.AutoUpdate.Interval != "" {
+		jobID, err := deployments.StartAutoupdate(stack.ID, stack.AutoUpdate.Interval, handler.Scheduler, handler.StackDeployer, handler.DataStore, handler.GitService)
+		if err != nil {
+			return err
+		}
+
+		stack.AutoUpdate.JobID = jobID
+	}
+
+	return handler.saveAutoUpdateStack(w, stack)
+}
+
+// @id StackAutoUpdateRedeploy
+// @summary Force the redeployment of a git stack
+// @description Pull the tracked reference of the git repository and redeploy the stack, even when the deployed commit did not change.
+// @description The redeployment is recorded in the auto update status of the stack, it happens even when the auto update is paused.
+// @description **Access policy**: authenticated
+// @tags stacks
+// @security ApiKeyAuth
+// @security jwt
+// @produce json
+// @param id path int true "Stack identifier"
+// @success 200 {object} portainer.Stack "Success"
+// @failure 400 "Invalid request"
+// @failure 403 "Permission denied"
+// @failure 404 "Not found"
+// @failure 409 "The stack author is missing"
+// @failure 500 "Server error"
+// @router /stacks/{id}/autoupdate/redeploy [post]
+func (handler *Handler) stackAutoUpdateRedeploy(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	stack, httpErr := handler.autoUpdateStack(r)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	if err := deployments.ForceRedeploy(stack.ID, handler.StackDeployer, handler.DataStore, handler.GitService); err != nil {
+		var stackAuthorMissingErr *deployments.StackAuthorMissingErr
+		if errors.As(err, &stackAuthorMissingErr) {
+			return httperror.Conflict("Unable to redeploy the stack", err)
+		}
+
+		return httperror.InternalServerError("Unable to redeploy the stack", err)
+	}
+
+	stack, err := handler.DataStore.Stack().Read(stack.ID)
+	if err != nil {
+		return httperror.InternalServerError("Unable to find a stack with the specified identifier inside the database", err)
+	}
+
+	sanitizeStack(stack)
+
+	return response.JSON(w, stack)
+}
+
+func (handler *Handler) saveAutoUpdateStack(w http.ResponseWriter, stack *portainer.Stack) *httperror.HandlerError {
+	if err := handler.DataStore.Stack().Update(stack.ID, stack); err != nil {
+		return httperror.InternalServerError("Unable to persist the stack changes inside the database", err)
+	}
+
+	sanitizeStack(stack)
+
+	return response.JSON(w, stack)
+}
+
+// autoUpdateStack returns the git stack whose auto update is controlled
+func (handler *Handler) autoUpdateStack(r *http.Request) (*portainer.Stack, *httperror.HandlerError) {
+	stack, _, httpErr := handler.manageableStack(r)
+	if httpErr != nil {
+		return nil, httpErr
+	}
+
+	if stack.GitConfig == nil {
+		return nil, httperror.BadRequest("The stack is not deployed from a git repository", errors.New("no git config in the stack"))
+	}
+
+	return stack, nil
+}
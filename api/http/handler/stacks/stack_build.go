@@ -0,0 +1,185 @@
+package stacks
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sync"
+
+	portainer "github.com/portainer/portainer/api"
+	httperrors "github.com/portainer/portainer/api/http/errors"
+	"github.com/portainer/portainer/api/http/security"
+	"github.com/portainer/portainer/api/stacks/stackutils"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/request"
+
+	"github.com/rs/zerolog/log"
+)
+
+// buildMessage is a line of the build output stream, in the format of the Docker build API
+type buildMessage struct {
+	Stream string `json:"stream,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+// buildOutputWriter writes the build output to the response as JSON messages, flushing every message so that the
+// output is streamed while the images are built
+type buildOutputWriter struct {
+	mu      sync.Mutex
+	encoder *json.Encoder
+	flusher http.Flusher
+}
+
+func newBuildOutputWriter(w http.ResponseWriter) *buildOutputWriter {
+	flusher, _ := w.(http.Flusher)
+
+	return &buildOutputWriter{encoder: json.NewEncoder(w), flusher: flusher}
+}
+
+func (writer *buildOutputWriter) Write(p []byte) (int, error) {
+	if err := writer.write(buildMessage{Stream: string(p)}); err != nil {
+		return 0, err
+	}
+
+	return len(p), nil
+}
+
+func (writer *buildOutputWriter) write(message buildMessage) error {
+	writer.mu.Lock()
+	defer writer.mu.Unlock()
+
+	if err := writer.encoder.Encode(message); err != nil {
+		return err
+	}
+
+	if writer.flusher != nil {
+		writer.flusher.Flush()
+	}
+
+	return nil
+}
+
+// @id StackBuild
+// @summary Build the images of a stack
+// @description Build, on the environment of the stack, the images of the services of a Compose or Swarm git stack that
+// @description have a build section, the build contexts being resolved in the repository of the stack.
+// @description The build output is streamed as JSON messages, one per line, in the format of the Docker build API:
+// @description {"stream": "..."} for the output and {"error": "..."} when the build fails.
+// @description The Compose stacks build the missing images when they are deployed, the Swarm stacks deploy the images
+// @description built on the environment under the image name of their services.
+// @description **Access policy**: authenticated
+// @tags stacks
+// @security ApiKeyAuth
+// @security jwt
+// @produce json
+// @param id path int true "Stack identifier"
+// @param pull query bool false "Always attempt to pull a newer version of the base images"
+// @param noCache query bool false "Do not use the build cache"
+// @success 200 "Build output stream"
+// @failure 400 "Invalid request"
+// @failure 403 "Permission denied"
+// @failure 404 "Not found"
+// @failure 500 "Server error"
+// @router /stacks/{id}/build [post]
+func (handler *Handler) stackBuild(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	stackID, err := request.RetrieveNumericRouteVariableValue(r, "id")
+	if err != nil {
+		return httperror.BadRequest("Invalid stack identifier route variable", err)
+	}
+
+	pull, _ := request.RetrieveBooleanQueryParameter(r, "pull", true)
+	noCache, _ := request.RetrieveBooleanQueryParameter(r, "noCache", true)
+
+	securityContext, err := security.RetrieveRestrictedRequestContext(r)
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve info from request context", err)
+	}
+
+	stack, err := handler.DataStore.Stack().Read(portainer.StackID(stackID))
+	if handler.DataStore.IsErrObjectNotFound(err) {
+		return httperror.NotFound("Unable to find a stack with the specified identifier inside the database", err)
+	} else if err != nil {
+		return httperror.InternalServerError("Unable to find a stack with the specified identifier inside the database", err)
+	}
+
+	if stack.Type != portainer.DockerComposeStack && stack.Type != portainer.DockerSwarmStack {
+		return httperror.BadRequest("Only the images of the Compose and Swarm stacks can be built", errors.New("unsupported stack type"))
+	}
+
+	if !stackutils.IsGitStack(stack) {
+		return httperror.BadRequest("Only the images of the git stacks can be built", errors.New("the stack is not deployed from a git repository"))
+	}
+
+	if stackutils.IsRelativePathStack(stack) {
+		return httperror.BadRequest("The images of the stacks deployed with a relative path are built when they are deployed", errors.New("unsupported stack"))
+	}
+
+	endpoint, err := handler.DataStore.Endpoint().Endpoint(stack.EndpointID)
+	if handler.DataStore.IsErrObjectNotFound(err) {
+		return httperror.NotFound("Unable to find an environment with the specified identifier inside the database", err)
+	} else if err != nil {
+		return httperror.InternalServerError("Unable to find an environment with the specified identifier inside the database", err)
+	}
+
+	if err := handler.requestBouncer.AuthorizedEndpointOperation(r, endpoint); err != nil {
+		return httperror.Forbidden("Permission denied to access environment", err)
+	}
+
+	if err := handler.requestBouncer.AuthorizedEndpointRoleOperation(r, endpoint, portainer.OperationPortainerStackUpdate); err != nil {
+		return httperror.Forbidden("Permission denied by your role on the environment", err)
+	}
+
+	resourceControl, err := handler.DataStore.ResourceControl().ResourceControlByResourceIDAndType(stackutils.ResourceControlID(stack.EndpointID, stack.Name), portainer.StackResourceControl)
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve a resource control associated to the stack", err)
+	}
+
+	access, err := handler.userCanAccessStack(securityContext, endpoint.ID, resourceControl)
+	if err != nil {
+		return httperror.InternalServerError("Unable to verify user authorizations to validate stack access", err)
+	}
+	if !access {
+		return httperror.Forbidden("Access denied to resource", httperrors.ErrResourceAccessDenied)
+	}
+
+	canManage, err := handler.userCanManageStacks(securityContext, endpoint)
+	if err != nil {
+		return httperror.InternalServerError("Unable to verify user authorizations to validate stack access", err)
+	}
+	if !canManage {
+		errMsg := "Stack management is disabled for non-admin users"
+		return httperror.Forbidden(errMsg, errors.New(errMsg))
+	}
+
+	user, err := handler.DataStore.User().Read(securityContext.UserID)
+	if err != nil {
+		return httperror.InternalServerError("Unable to load user information from the database", err)
+	}
+
+	registries, err := handler.DataStore.Registry().ReadAll()
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve registries from the database", err)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(http.StatusOK)
+
+	output := newBuildOutputWriter(w)
+
+	// the status is already sent, the build failures are reported in the stream
+	if err := handler.ComposeStackManager.Build(r.Context(), stack, endpoint, portainer.ComposeBuildOptions{
+		ComposeOptions: portainer.ComposeOptions{Registries: security.FilterRegistries(registries, user, securityContext.UserMemberships, endpoint.ID)},
+		Output:         output,
+		Pull:           pull,
+		NoCache:        noCache,
+	}); err != nil {
+		log.Warn().Err(err).Int("stack_id", int(stack.ID)).Msg("unable to build the images of the stack")
+
+		if err := output.write(buildMessage{Error: err.Error()}); err != nil {
+			log.Debug().Err(err).Msg("unable to write the build error")
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,75 @@
+package stacks
+
+import (
+	"errors"
+	"net/http"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/stacks/stackutils"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/request"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+)
+
+type stackConvertPayload struct {
+	// Content of the stack file to convert
+	StackFileContent string `example:"version: 3\n services:\n web:\n image:nginx" validate:"required"`
+	// Type of the target stack. Valid values are: 1 (Swarm), 2 (Compose) or 3 (Kubernetes)
+	Type portainer.StackType `example:"1" enums:"1,2,3" validate:"required"`
+}
+
+func (payload *stackConvertPayload) Validate(r *http.Request) error {
+	if payload.StackFileContent == "" {
+		return errors.New("Invalid stack file content")
+	}
+
+	if payload.Type != portainer.DockerSwarmStack && payload.Type != portainer.DockerComposeStack && payload.Type != portainer.KubernetesStack {
+		return errors.New("Invalid stack type. Valid values are: 1 (Swarm), 2 (Compose) or 3 (Kubernetes)")
+	}
+
+	return nil
+}
+
+type stackConvertResponse struct {
+	// Content of the converted stack file
+	StackFileContent string `json:"StackFileContent"`
+	// Options which could not be converted, and were removed or replaced
+	Warnings []string `json:"Warnings"`
+}
+
+// @id StackConvert
+// @summary Convert a stack file between Compose, Swarm and Kubernetes
+// @description Convert the content of a Compose stack file into a Swarm stack file, or of a Swarm stack file into a Compose stack file.
+// @description Compose and Swarm stack files can also be converted into Kubernetes manifests, to be reviewed before deploying them as a Kubernetes stack.
+// @description The options that are not supported by the target orchestrator are removed and listed in the warnings.
+// @description **Access policy**: authenticated
+// @tags stacks
+// @security ApiKeyAuth
+// @security jwt
+// @accept json
+// @produce json
+// @param body body stackConvertPayload true "Stack file to convert"
+// @success 200 {object} stackConvertResponse "Success"
+// @failure 400 "Invalid request"
+// @failure 500 "Server error"
+// @router /stacks/convert [post]
+func (handler *Handler) stackConvert(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	var payload stackConvertPayload
+	if err := request.DecodeAndValidateJSONPayload(r, &payload); err != nil {
+		return httperror.BadRequest("Invalid request payload", err)
+	}
+
+	content, warnings, err := stackutils.ConvertStackFile([]byte(payload.StackFileContent), payload.Type)
+	if err != nil {
+		return httperror.BadRequest("Unable to convert the stack file", err)
+	}
+
+	if warnings == nil {
+		warnings = []string{}
+	}
+
+	return response.JSON(w, stackConvertResponse{
+		StackFileContent: string(content),
+		Warnings:         warnings,
+	})
+}
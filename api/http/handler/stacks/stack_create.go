@@ -5,6 +5,7 @@ import (
 	"net/http"
 
 	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/docker/quarantine"
 	"github.com/portainer/portainer/api/http/security"
 	"github.com/portainer/portainer/api/internal/authorization"
 	"github.com/portainer/portainer/api/stacks/stackutils"
@@ -57,6 +58,14 @@ func (handler *Handler) stackCreate(w http.ResponseWriter, r *http.Request) *htt
 		return httperror.Forbidden("Permission denied to access environment", err)
 	}
 
+	if err := handler.requestBouncer.AuthorizedEndpointRoleOperation(r, endpoint, portainer.OperationPortainerStackCreate); err != nil {
+		return httperror.Forbidden("Permission denied by your role on the environment", err)
+	}
+
+	if err := quarantine.Check(endpoint); err != nil {
+		return httperror.Conflict("Unable to deploy the stack", err)
+	}
+
 	tokenData, err := security.RetrieveTokenData(r)
 	if err != nil {
 		return httperror.InternalServerError("Unable to retrieve user details from authentication token", err)
@@ -69,6 +78,8 @@ func (handler *Handler) stackCreate(w http.ResponseWriter, r *http.Request) *htt
 		return handler.createComposeStack(w, r, method, endpoint, tokenData.ID)
 	case "kubernetes":
 		return handler.createKubernetesStack(w, r, method, endpoint, tokenData.ID)
+	case "nomad":
+		return handler.createNomadStack(w, r, method, endpoint, tokenData.ID)
 	}
 
 	return httperror.BadRequest("Invalid value for query parameter: type. Value must be one of: 1 (Swarm stack) or 2 (Compose stack)", errors.New(request.ErrInvalidQueryParameter))
@@ -88,6 +99,10 @@ func (handler *Handler) createComposeStack(w http.ResponseWriter, r *http.Reques
 }
 
 func (handler *Handler) createSwarmStack(w http.ResponseWriter, r *http.Request, method string, endpoint *portainer.Endpoint, userID portainer.UserID) *httperror.HandlerError {
+	if endpoint.ContainerEngine == portainer.ContainerEnginePodman {
+		return httperror.BadRequest("Swarm stacks cannot be deployed to Podman environments", errors.New("podman has no swarm mode"))
+	}
+
 	switch method {
 	case "string":
 		return handler.createSwarmStackFromFileContent(w, r, endpoint, userID)
@@ -134,10 +149,7 @@ func (handler *Handler) decorateStackResponse(w http.ResponseWriter, stack *port
 
 	stack.ResourceControl = resourceControl
 
-	if stack.GitConfig != nil && stack.GitConfig.Authentication != nil && stack.GitConfig.Authentication.Password != "" {
-		// sanitize password in the http response to minimise possible security leaks
-		stack.GitConfig.Authentication.Password = ""
-	}
+	sanitizeStack(stack)
 
 	return response.JSON(w, stack)
 }
@@ -155,6 +167,8 @@ func getStackTypeFromQueryParameter(r *http.Request) (string, error) {
 		return "standalone", nil
 	case 3:
 		return "kubernetes", nil
+	case 4:
+		return "nomad", nil
 	}
 
 	return "", errors.New(request.ErrInvalidQueryParameter)
@@ -169,7 +183,7 @@ func getStackTypeFromQueryParameter(r *http.Request) (string, error) {
 // @security jwt
 // @accept json,multipart/form-data
 // @produce json
-// @param type query int true "Stack deployment type. Possible values: 1 (Swarm stack), 2 (Compose stack) or 3 (Kubernetes stack)." Enums(1,2,3)
+// @param type query int true "Stack deployment type. Possible values: 1 (Swarm stack), 2 (Compose stack), 3 (Kubernetes stack) or 4 (Nomad stack)." Enums(1,2,3,4)
 // @param method query string true "Stack deployment method. Possible values: file, string, repository or url." Enums(string, file, repository, url)
 // @param endpointId query int true "Identifier of the environment(endpoint) that will be used to deploy the stack"
 // @param body body object true "for body documentation see the relevant /stacks/create/{type}/{method} endpoint"
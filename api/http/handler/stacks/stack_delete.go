@@ -4,14 +4,17 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"os"
 	"strconv"
 
 	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
 	"github.com/portainer/portainer/api/filesystem"
 	httperrors "github.com/portainer/portainer/api/http/errors"
 	"github.com/portainer/portainer/api/http/security"
 	"github.com/portainer/portainer/api/stacks/deployments"
 	"github.com/portainer/portainer/api/stacks/stackutils"
+	"github.com/portainer/portainer/api/stacks/stackversions"
 	httperror "github.com/portainer/portainer/pkg/libhttp/error"
 	"github.com/portainer/portainer/pkg/libhttp/request"
 	"github.com/portainer/portainer/pkg/libhttp/response"
@@ -92,6 +95,10 @@ func (handler *Handler) stackDelete(w http.ResponseWriter, r *http.Request) *htt
 			return httperror.Forbidden("Permission denied to access endpoint", err)
 		}
 
+		if err := handler.requestBouncer.AuthorizedEndpointRoleOperation(r, endpoint, portainer.OperationPortainerStackDelete); err != nil {
+			return httperror.Forbidden("Permission denied by your role on the environment", err)
+		}
+
 		if stack.Type == portainer.DockerSwarmStack || stack.Type == portainer.DockerComposeStack {
 			access, err := handler.userCanAccessStack(securityContext, endpoint.ID, resourceControl)
 			if err != nil {
@@ -125,6 +132,12 @@ func (handler *Handler) stackDelete(w http.ResponseWriter, r *http.Request) *htt
 		return httperror.InternalServerError("Unable to remove the stack from the database", err)
 	}
 
+	if err := handler.DataStore.UpdateTx(func(tx dataservices.DataStoreTx) error {
+		return stackversions.RemoveHistory(tx, stack.ID)
+	}); err != nil {
+		log.Warn().Err(err).Msg("Unable to remove the stack history from the database")
+	}
+
 	if resourceControl != nil {
 		if err := handler.DataStore.ResourceControl().Delete(resourceControl.ID); err != nil {
 			return httperror.InternalServerError("Unable to remove the associated resource control from the database", err)
@@ -167,6 +180,10 @@ func (handler *Handler) deleteExternalStack(r *http.Request, w http.ResponseWrit
 		return httperror.Forbidden("Permission denied to access endpoint", err)
 	}
 
+	if err := handler.requestBouncer.AuthorizedEndpointRoleOperation(r, endpoint, portainer.OperationPortainerStackDelete); err != nil {
+		return httperror.Forbidden("Permission denied by your role on the environment", err)
+	}
+
 	stack = &portainer.Stack{
 		Name: stackName,
 		Type: portainer.DockerSwarmStack,
@@ -203,6 +220,20 @@ func (handler *Handler) deleteStack(userID portainer.UserID, stack *portainer.St
 	if stack.Type == portainer.KubernetesStack {
 		manifestFiles := stackutils.GetStackFilePaths(stack, true)
 
+		// the resources of a kustomize stack are the ones of its built kustomization
+		if stackutils.IsKustomizeStack(stack) {
+			tmpDir, err := os.MkdirTemp("", "kub_removal")
+			if err != nil {
+				return errors.Wrap(err, "failed to create temp kub removal directory")
+			}
+			defer os.RemoveAll(tmpDir)
+
+			manifestFiles, err = deployments.WriteKubernetesManifests(handler.DataStore, handler.KubernetesDeployer, stack, endpoint, nil, tmpDir)
+			if err != nil {
+				return errors.WithMessage(err, "failed to build the kustomization of the stack")
+			}
+		}
+
 		out, err := handler.KubernetesDeployer.Remove(userID, endpoint, manifestFiles, stack.Namespace)
 		if err != nil {
 			for _, manifest := range manifestFiles {
@@ -218,6 +249,10 @@ func (handler *Handler) deleteStack(userID portainer.UserID, stack *portainer.St
 		return errors.WithMessagef(err, "failed to remove kubernetes resources: %q", out)
 	}
 
+	if stack.Type == portainer.NomadStack {
+		return deleteNomadStack(stack, endpoint, handler.FileService)
+	}
+
 	return fmt.Errorf("unsupported stack type: %v", stack.Type)
 }
 
@@ -299,6 +334,10 @@ func (handler *Handler) stackDeleteKubernetesByName(w http.ResponseWriter, r *ht
 			if err != nil {
 				return httperror.Forbidden("Permission denied to access endpoint", err)
 			}
+
+			if err := handler.requestBouncer.AuthorizedEndpointRoleOperation(r, endpoint, portainer.OperationPortainerStackDelete); err != nil {
+				return httperror.Forbidden("Permission denied by your role on the environment", err)
+			}
 		}
 
 		canManage, err := handler.userCanManageStacks(securityContext, endpoint)
@@ -0,0 +1,85 @@
+package stacks
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/http/security"
+	"github.com/portainer/portainer/api/stacks/hooks"
+	"github.com/portainer/portainer/api/stacks/stackarchive"
+	"github.com/portainer/portainer/api/stacks/stackutils"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+)
+
+// @id StackExport
+// @summary Export a stack
+// @description Export a Compose, Swarm or Kubernetes stack as a gzipped tar archive holding its stack files, its
+// @description environment variables, its options and hooks and whether it has a redeploy webhook.
+// @description The archive can be imported into another environment or Portainer instance with the stack import.
+// @description The environment variables are exported in clear text, the git credentials and the webhook tokens are not exported.
+// @description The secrets of the hooks are only exported for the administrators of the environment, who manage the hooks.
+// @description **Access policy**: authenticated
+// @tags stacks
+// @security ApiKeyAuth
+// @security jwt
+// @produce application/gzip
+// @param id path int true "Stack identifier"
+// @success 200 "Success"
+// @failure 400 "Invalid request"
+// @failure 403 "Permission denied"
+// @failure 404 "Stack not found"
+// @failure 500 "Server error"
+// @router /stacks/{id}/export [get]
+func (handler *Handler) stackExport(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	stack, endpoint, httpErr := handler.manageableStack(r)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	securityContext, err := security.RetrieveRestrictedRequestContext(r)
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve info from request context", err)
+	}
+
+	user, err := handler.DataStore.User().Read(securityContext.UserID)
+	if err != nil {
+		return httperror.InternalServerError("Unable to load user information from the database", err)
+	}
+
+	isAdminOrEndpointAdmin, err := stackutils.UserIsAdminOrEndpointAdmin(user, endpoint.ID)
+	if err != nil {
+		return httperror.InternalServerError("Unable to verify user authorizations", err)
+	}
+
+	if !isAdminOrEndpointAdmin {
+		stack.Hooks = hooks.Redact(stack.Hooks)
+	}
+
+	if stack.Type != portainer.DockerComposeStack && stack.Type != portainer.DockerSwarmStack && stack.Type != portainer.KubernetesStack {
+		return httperror.BadRequest("Unsupported stack", errors.New("only the Compose, Swarm and Kubernetes stacks can be exported"))
+	}
+
+	webhook, err := handler.DataStore.Webhook().WebhookByResourceID(strconv.Itoa(int(stack.ID)))
+	if err != nil && !handler.DataStore.IsErrObjectNotFound(err) {
+		return httperror.InternalServerError("Unable to retrieve the webhook of the stack from the database", err)
+	}
+
+	hasWebhook := webhook != nil && webhook.WebhookType == portainer.StackWebhook
+
+	content, err := stackarchive.Export(handler.FileService, stack, hasWebhook)
+	if err != nil {
+		return httperror.InternalServerError("Unable to export the stack", err)
+	}
+
+	w.Header().Set("Content-Type", "application/gzip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", stack.Name+".stack.tar.gz"))
+
+	if _, err := w.Write(content); err != nil {
+		return httperror.InternalServerError("Unable to write the archive of the stack", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,210 @@
+package stacks
+
+import (
+	"bytes"
+	"cmp"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+	httperrors "github.com/portainer/portainer/api/http/errors"
+	"github.com/portainer/portainer/api/http/security"
+	"github.com/portainer/portainer/api/stacks/hooks"
+	"github.com/portainer/portainer/api/stacks/stackarchive"
+	"github.com/portainer/portainer/api/stacks/stackutils"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/request"
+
+	"github.com/gofrs/uuid"
+)
+
+type stackImportPayload struct {
+	// Stack archive produced by the stack export
+	Archive []byte
+	// Name of the imported stack, the name of the exported stack by default
+	Name string
+	// Swarm cluster identifier, required for the Swarm stacks
+	SwarmID string
+	// Kubernetes namespace of the imported stack, the namespace of the exported stack by default
+	Namespace string
+	// What to do when the name is already used on the environment: fail (default) or rename
+	OnConflict stackarchive.ConflictPolicy
+}
+
+func (payload *stackImportPayload) Validate(r *http.Request) error {
+	archive, _, err := request.RetrieveMultiPartFormFile(r, "file")
+	if err != nil {
+		return errors.New("Invalid stack archive. Ensure that the archive is uploaded correctly")
+	}
+	payload.Archive = archive
+
+	payload.Name, _ = request.RetrieveMultiPartFormValue(r, "Name", true)
+	payload.SwarmID, _ = request.RetrieveMultiPartFormValue(r, "SwarmID", true)
+	payload.Namespace, _ = request.RetrieveMultiPartFormValue(r, "Namespace", true)
+
+	onConflict, _ := request.RetrieveMultiPartFormValue(r, "OnConflict", true)
+	payload.OnConflict = cmp.Or(stackarchive.ConflictPolicy(onConflict), stackarchive.ConflictFail)
+	if payload.OnConflict != stackarchive.ConflictFail && payload.OnConflict != stackarchive.ConflictRename {
+		return errors.New("Invalid conflict policy. Must be fail or rename")
+	}
+
+	return nil
+}
+
+// @id StackImport
+// @summary Import a stack
+// @description Create and deploy a stack from an archive produced by the stack export, on the same or another Portainer instance.
+// @description The stack is deployed from the files of the archive, the exported git stacks are not linked to their repository anymore.
+// @description When the name is already used on the environment, the import fails unless OnConflict is rename, the stack is then
+// @description imported under its name followed by the first free number, e.g. myStack-2.
+// @description The hooks of the stack require the administrators of the environment and its redeploy webhook is only recreated,
+// @description with a new token, for the administrators.
+// @description **Access policy**: authenticated
+// @tags stacks
+// @security ApiKeyAuth
+// @security jwt
+// @accept multipart/form-data
+// @produce json
+// @param endpointId query int true "Identifier of the environment the stack is imported into"
+// @param file formData file true "Stack archive"
+// @param Name formData string false "Name of the imported stack"
+// @param SwarmID formData string false "Swarm cluster identifier, required for the Swarm stacks"
+// @param Namespace formData string false "Kubernetes namespace of the imported stack"
+// @param OnConflict formData string false "What to do when the name is already used" Enums(fail, rename)
+// @success 200 {object} portainer.Stack "Success"
+// @failure 400 "Invalid request"
+// @failure 403 "Permission denied"
+// @failure 404 "Environment not found"
+// @failure 409 "A stack with the same name already exists or the environment is quarantined"
+// @failure 500 "Server error"
+// @router /stacks/import [post]
+func (handler *Handler) stackImport(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	endpointID, err := request.RetrieveNumericQueryParameter(r, "endpointId", false)
+	if err != nil {
+		return httperror.BadRequest("Invalid query parameter: endpointId", err)
+	}
+
+	var payload stackImportPayload
+	if err := payload.Validate(r); err != nil {
+		return httperror.BadRequest("Invalid request payload", err)
+	}
+
+	endpoint, err := handler.DataStore.Endpoint().Endpoint(portainer.EndpointID(endpointID))
+	if handler.DataStore.IsErrObjectNotFound(err) {
+		return httperror.NotFound("Unable to find an environment with the specified identifier inside the database", err)
+	} else if err != nil {
+		return httperror.InternalServerError("Unable to find an environment with the specified identifier inside the database", err)
+	}
+
+	securityContext, err := security.RetrieveRestrictedRequestContext(r)
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve user info from request context", err)
+	}
+
+	if httpErr := handler.authorizeStackCreation(r, securityContext, endpoint); httpErr != nil {
+		return httpErr
+	}
+
+	archive, err := stackarchive.Read(bytes.NewReader(payload.Archive))
+	if err != nil {
+		return httperror.BadRequest("Invalid stack archive", err)
+	}
+	manifest := &archive.Manifest
+
+	if httpErr := checkStackEnvironment(manifest.Type, endpoint, payload.SwarmID); httpErr != nil {
+		return httpErr
+	}
+
+	user, err := handler.DataStore.User().Read(securityContext.UserID)
+	if err != nil {
+		return httperror.InternalServerError("Unable to load user information from the database", err)
+	}
+
+	if manifest.Hooks != nil {
+		isAdminOrEndpointAdmin, err := stackutils.UserIsAdminOrEndpointAdmin(user, endpoint.ID)
+		if err != nil {
+			return httperror.InternalServerError("Unable to verify user authorizations", err)
+		}
+
+		if !isAdminOrEndpointAdmin {
+			return httperror.Forbidden("Only the administrators of the environment can import a stack with hooks", httperrors.ErrResourceAccessDenied)
+		}
+
+		// the hooks exported for the other users are redacted, their secrets have no stored value to be restored from
+		if err := hooks.Restore(manifest.Hooks, nil); err != nil {
+			return httperror.BadRequest("Invalid stack hooks", err)
+		}
+
+		if err := hooks.Validate(manifest.Hooks, manifest.Type); err != nil {
+			return httperror.BadRequest("Invalid stack hooks", err)
+		}
+	}
+
+	name := cmp.Or(payload.Name, manifest.Name)
+	switch manifest.Type {
+	case portainer.DockerComposeStack:
+		name = handler.ComposeStackManager.NormalizeStackName(name)
+	case portainer.DockerSwarmStack:
+		name = handler.SwarmStackManager.NormalizeStackName(name)
+	}
+
+	resolvedName, err := stackarchive.ResolveName(name, payload.OnConflict, func(name string) (bool, error) {
+		if manifest.Type == portainer.KubernetesStack {
+			return handler.checkUniqueStackName(endpoint, name, 0)
+		}
+
+		return handler.checkUniqueStackNameInDocker(endpoint, name, 0, manifest.Type == portainer.DockerSwarmStack)
+	})
+	if errors.Is(err, stackarchive.ErrNameConflict) {
+		return stackExistsError(name)
+	} else if err != nil {
+		return httperror.InternalServerError("Unable to check for name collision", err)
+	}
+
+	stack := &portainer.Stack{
+		ID:              portainer.StackID(handler.DataStore.Stack().GetNextIdentifier()),
+		Name:            resolvedName,
+		Type:            manifest.Type,
+		EndpointID:      endpoint.ID,
+		EntryPoint:      manifest.EntryPoint,
+		AdditionalFiles: manifest.AdditionalFiles,
+		Env:             manifest.Env,
+		Option:          manifest.Option,
+		Hooks:           manifest.Hooks,
+		Status:          portainer.StackStatusActive,
+		CreationDate:    time.Now().Unix(),
+		CreatedBy:       user.Username,
+	}
+
+	switch stack.Type {
+	case portainer.DockerSwarmStack:
+		stack.SwarmID = payload.SwarmID
+	case portainer.KubernetesStack:
+		stack.Namespace = cmp.Or(payload.Namespace, manifest.Namespace, "default")
+	}
+
+	if httpErr := handler.createStackFromFiles(securityContext, stack, endpoint, user, archive.Files); httpErr != nil {
+		return httpErr
+	}
+
+	if manifest.Webhook && securityContext.IsAdmin {
+		token, err := uuid.NewV4()
+		if err != nil {
+			return httperror.InternalServerError("Error creating unique token", err)
+		}
+
+		if err := handler.DataStore.Webhook().Create(&portainer.Webhook{
+			Token:          token.String(),
+			ResourceID:     strconv.Itoa(int(stack.ID)),
+			EndpointID:     endpoint.ID,
+			WebhookType:    portainer.StackWebhook,
+			TokenCreatedAt: time.Now().Unix(),
+		}); err != nil {
+			return httperror.InternalServerError("Unable to persist the webhook inside the database", err)
+		}
+	}
+
+	return handler.decorateStackResponse(w, stack, user.ID)
+}
@@ -0,0 +1,78 @@
+package stacks
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/datastore"
+	"github.com/portainer/portainer/api/filesystem"
+	"github.com/portainer/portainer/api/http/security"
+	"github.com/portainer/portainer/api/internal/testhelpers"
+	"github.com/portainer/portainer/api/stacks/hooks"
+	"github.com/portainer/portainer/api/stacks/stackarchive"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newImportRequest(t *testing.T, archive []byte) *http.Request {
+	var body bytes.Buffer
+
+	w := multipart.NewWriter(&body)
+	fw, err := w.CreateFormFile("file", "stack.tar.gz")
+	require.NoError(t, err)
+
+	_, err = fw.Write(archive)
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+
+	r := httptest.NewRequest(http.MethodPost, "/stacks/import?endpointId=1", &body)
+	r.Header.Set("Content-Type", w.FormDataContentType())
+
+	return r.WithContext(security.StoreRestrictedRequestContext(r, &security.RestrictedRequestContext{IsAdmin: true, UserID: 1}))
+}
+
+func TestStackImport_InvalidHooks(t *testing.T) {
+	is := require.New(t)
+
+	_, store := datastore.MustNewTestStore(t, true, false)
+	is.NoError(store.User().Create(&portainer.User{ID: 1, Username: "admin", Role: portainer.AdministratorRole}))
+	is.NoError(store.Endpoint().Create(&portainer.Endpoint{ID: 1, Type: portainer.DockerEnvironment}))
+
+	fileService, err := filesystem.NewService(t.TempDir(), "")
+	is.NoError(err)
+
+	projectPath, err := fileService.StoreStackFileFromBytes("1", "docker-compose.yml", []byte("services: {}"))
+	is.NoError(err)
+
+	h := NewHandler(testhelpers.NewTestRequestBouncer())
+	h.DataStore = store
+
+	for _, stackHooks := range []*portainer.StackHooks{
+		// invalid URL
+		{PreDeploy: []portainer.StackHook{{Name: "notify", Type: portainer.StackHookHTTP, URL: "ftp://hooks.example.com"}}},
+		// secret redacted by the export
+		{PostDeploy: []portainer.StackHook{{Name: "notify", Type: portainer.StackHookHTTP, URL: "https://hooks.example.com", Headers: []portainer.Pair{{Name: "Authorization", Value: hooks.RedactedValue}}}}},
+	} {
+		archive, err := stackarchive.Export(fileService, &portainer.Stack{
+			Name:        "shop",
+			Type:        portainer.DockerComposeStack,
+			ProjectPath: projectPath,
+			EntryPoint:  "docker-compose.yml",
+			Hooks:       stackHooks,
+		}, false)
+		is.NoError(err)
+
+		httpErr := h.stackImport(httptest.NewRecorder(), newImportRequest(t, archive))
+		is.NotNil(httpErr)
+		is.Equal(http.StatusBadRequest, httpErr.StatusCode)
+		is.Equal("Invalid stack hooks", httpErr.Message)
+	}
+
+	stacks, err := store.Stack().ReadAll()
+	is.NoError(err)
+	is.Empty(stacks)
+}
@@ -16,6 +16,7 @@ type stackListOperationFilters struct {
 	SwarmID               string `json:"SwarmID"`
 	EndpointID            int    `json:"EndpointID"`
 	IncludeOrphanedStacks bool   `json:"IncludeOrphanedStacks"`
+	Drifted               bool   `json:"Drifted"`
 }
 
 // @id StackList
@@ -28,7 +29,7 @@ type stackListOperationFilters struct {
 // @tags stacks
 // @security ApiKeyAuth
 // @security jwt
-// @param filters query string false "Filters to process on the stack list. Encoded as JSON (a map[string]string). For example, {'SwarmID': 'jpofkc0i9uo9wtx1zesuk649w'} will only return stacks that are part of the specified Swarm cluster. Available filters: EndpointID, SwarmID."
+// @param filters query string false "Filters to process on the stack list. Encoded as JSON (a map[string]string). For example, {'SwarmID': 'jpofkc0i9uo9wtx1zesuk649w'} will only return stacks that are part of the specified Swarm cluster. Available filters: EndpointID, SwarmID, Drifted. The Drifted filter only returns the stacks whose last drift check found differences."
 // @success 200 {array} portainer.Stack "Success"
 // @success 204 "Success"
 // @failure 400 "Invalid request"
@@ -52,6 +53,10 @@ func (handler *Handler) stackList(w http.ResponseWriter, r *http.Request) *httpe
 	}
 	stacks = filterStacks(stacks, &filters, endpoints)
 
+	if filters.Drifted {
+		stacks = filterDriftedStacks(stacks)
+	}
+
 	resourceControls, err := handler.DataStore.ResourceControl().ReadAll()
 	if err != nil {
 		return httperror.InternalServerError("Unable to retrieve resource controls from the database", err)
@@ -82,11 +87,8 @@ func (handler *Handler) stackList(w http.ResponseWriter, r *http.Request) *httpe
 		stacks = authorization.FilterAuthorizedStacks(stacks, user, userTeamIDs)
 	}
 
-	for _, stack := range stacks {
-		if stack.GitConfig != nil && stack.GitConfig.Authentication != nil && stack.GitConfig.Authentication.Password != "" {
-			// sanitize password in the http response to minimise possible security leaks
-			stack.GitConfig.Authentication.Password = ""
-		}
+	for i := range stacks {
+		sanitizeStack(&stacks[i])
 	}
 
 	return response.JSON(w, stacks)
@@ -147,6 +149,18 @@ func filterStacks(stacks []portainer.Stack, filters *stackListOperationFilters,
 	return filteredStacks
 }
 
+// filterDriftedStacks returns the stacks whose last drift check found differences with their stack file
+func filterDriftedStacks(stacks []portainer.Stack) []portainer.Stack {
+	drifted := make([]portainer.Stack, 0, len(stacks))
+	for _, stack := range stacks {
+		if stack.Drift != nil && stack.Drift.Drifted {
+			drifted = append(drifted, stack)
+		}
+	}
+
+	return drifted
+}
+
 func isOrphanedStack(stack portainer.Stack, endpoints []portainer.Endpoint) bool {
 	for _, endpoint := range endpoints {
 		if stack.EndpointID == endpoint.ID {
@@ -0,0 +1,192 @@
+package stacks
+
+import (
+	"net/http"
+	"os"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/docker/quarantine"
+	"github.com/portainer/portainer/api/filesystem"
+	gittypes "github.com/portainer/portainer/api/git/types"
+	"github.com/portainer/portainer/api/http/security"
+	"github.com/portainer/portainer/api/internal/endpointutils"
+	k "github.com/portainer/portainer/api/kubernetes"
+	"github.com/portainer/portainer/api/stacks/deployments"
+	"github.com/portainer/portainer/api/stacks/stackutils"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/request"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+
+	"github.com/pkg/errors"
+)
+
+// @id StackPreviewKubernetes
+// @summary Preview the deployment of a new kubernetes stack
+// @description Return the manifests a new kubernetes stack would be deployed with, once built, expanded and labeled,
+// @description and the diff between the live resources and the resources once applied, with the semantics of kubectl
+// @description diff. The resources are applied with a server-side dry run with the permissions of the user, nothing
+// @description is changed in the cluster and the stack is not created. The body is the one of the
+// @description /stacks/create/kubernetes/{method} endpoint.
+// @description **Access policy**: authenticated
+// @tags stacks
+// @security ApiKeyAuth
+// @security jwt
+// @accept json
+// @produce json
+// @param method path string true "Stack deployment method" Enums(string, repository)
+// @param endpointId query int true "Identifier of the environment that will be used to deploy the stack"
+// @param body body object true "for body documentation see the relevant /stacks/create/kubernetes/{method} endpoint"
+// @success 200 {object} deployments.KubernetesPreview
+// @failure 400 "Invalid request"
+// @failure 403 "Permission denied"
+// @failure 404 "Environment not found"
+// @failure 500 "Server error, such as manifests refused by the cluster"
+// @router /stacks/preview/kubernetes/{method} [post]
+func (handler *Handler) stackPreviewKubernetes(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	method, err := request.RetrieveRouteVariableValue(r, "method")
+	if err != nil {
+		return httperror.BadRequest("Invalid path parameter: method", err)
+	}
+
+	endpointID, err := request.RetrieveNumericQueryParameter(r, "endpointId", false)
+	if err != nil {
+		return httperror.BadRequest("Invalid query parameter: endpointId", err)
+	}
+
+	endpoint, err := handler.DataStore.Endpoint().Endpoint(portainer.EndpointID(endpointID))
+	if handler.DataStore.IsErrObjectNotFound(err) {
+		return httperror.NotFound("Unable to find an environment with the specified identifier inside the database", err)
+	} else if err != nil {
+		return httperror.InternalServerError("Unable to find an environment with the specified identifier inside the database", err)
+	}
+
+	if !endpointutils.IsKubernetesEndpoint(endpoint) {
+		return httperror.BadRequest("Environment type does not match", errors.New("Environment type does not match"))
+	}
+
+	securityContext, err := security.RetrieveRestrictedRequestContext(r)
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve user info from request context", err)
+	}
+
+	canManage, err := handler.userCanManageStacks(securityContext, endpoint)
+	if err != nil {
+		return httperror.InternalServerError("Unable to verify user authorizations to validate stack creation", err)
+	}
+	if !canManage {
+		errMsg := "Stack creation is disabled for non-admin users"
+		return httperror.Forbidden(errMsg, errors.New(errMsg))
+	}
+
+	if err := handler.requestBouncer.AuthorizedEndpointOperation(r, endpoint); err != nil {
+		return httperror.Forbidden("Permission denied to access environment", err)
+	}
+
+	if err := handler.requestBouncer.AuthorizedEndpointRoleOperation(r, endpoint, portainer.OperationPortainerStackCreate); err != nil {
+		return httperror.Forbidden("Permission denied by your role on the environment", err)
+	}
+
+	if err := quarantine.Check(endpoint); err != nil {
+		return httperror.Conflict("Unable to deploy the stack", err)
+	}
+
+	user, err := handler.DataStore.User().Read(securityContext.UserID)
+	if err != nil {
+		return httperror.InternalServerError("Unable to load user information from the database", err)
+	}
+
+	tmpDir, err := os.MkdirTemp("", "kub_preview_stack")
+	if err != nil {
+		return httperror.InternalServerError("Unable to create a temporary directory", err)
+	}
+
+	defer os.RemoveAll(tmpDir)
+
+	stack := &portainer.Stack{
+		// the identifier the stack would be created with, which is part of the labels of its resources
+		ID:          portainer.StackID(handler.DataStore.Stack().GetNextIdentifier()),
+		Type:        portainer.KubernetesStack,
+		EndpointID:  endpoint.ID,
+		ProjectPath: tmpDir,
+		CreatedBy:   user.Username,
+	}
+
+	var kind string
+	switch method {
+	case "string":
+		kind = "content"
+		if httpErr := previewKubernetesStackFromFileContent(r, stack); httpErr != nil {
+			return httpErr
+		}
+	case "repository":
+		kind = "git"
+		if httpErr := handler.previewKubernetesStackFromGitRepository(r, stack); httpErr != nil {
+			return httpErr
+		}
+	default:
+		return httperror.BadRequest("Invalid value for path parameter: method. Value must be one of: string or repository", errors.New(request.ErrInvalidQueryParameter))
+	}
+
+	appLabels := k.KubeAppLabels{
+		StackID:   int(stack.ID),
+		StackName: stack.Name,
+		Owner:     stackutils.SanitizeLabel(stack.CreatedBy),
+		Kind:      kind,
+	}
+
+	preview, err := deployments.PreviewKubernetesStack(handler.DataStore, handler.KubernetesDeployer, stack, appLabels, user, endpoint)
+	if err != nil {
+		return httperror.InternalServerError(err.Error(), err)
+	}
+
+	return response.JSON(w, preview)
+}
+
+func previewKubernetesStackFromFileContent(r *http.Request, stack *portainer.Stack) *httperror.HandlerError {
+	var payload kubernetesStringDeploymentPayload
+	if err := request.DecodeAndValidateJSONPayload(r, &payload); err != nil {
+		return httperror.BadRequest("Invalid request payload", err)
+	}
+
+	stack.Name = payload.StackName
+	stack.Namespace = payload.Namespace
+	stack.EntryPoint = filesystem.ManifestFileDefaultName
+
+	if err := filesystem.WriteToFile(filesystem.JoinPaths(stack.ProjectPath, stack.EntryPoint), []byte(payload.StackFileContent)); err != nil {
+		return httperror.InternalServerError("Unable to write the Kubernetes manifest file", err)
+	}
+
+	return nil
+}
+
+func (handler *Handler) previewKubernetesStackFromGitRepository(r *http.Request, stack *portainer.Stack) *httperror.HandlerError {
+	var payload kubernetesGitDeploymentPayload
+	if err := request.DecodeAndValidateJSONPayload(r, &payload); err != nil {
+		return httperror.BadRequest("Invalid request payload", err)
+	}
+
+	stack.Name = payload.StackName
+	stack.Namespace = payload.Namespace
+	stack.EntryPoint = payload.ManifestFile
+	stack.AdditionalFiles = payload.AdditionalFiles
+	stack.KustomizeOverlay = payload.KustomizeOverlay
+
+	repoConfig := gittypes.RepoConfig{
+		URL:           payload.RepositoryURL,
+		ReferenceName: payload.RepositoryReferenceName,
+		TLSSkipVerify: payload.TLSSkipVerify,
+	}
+
+	if payload.RepositoryAuthentication {
+		repoConfig.Authentication = &gittypes.GitAuthentication{
+			Username: payload.RepositoryUsername,
+			Password: payload.RepositoryPassword,
+		}
+	}
+
+	if _, err := stackutils.DownloadGitRepository(repoConfig, handler.GitService, func() string { return stack.ProjectPath }); err != nil {
+		return httperror.InternalServerError(err.Error(), err)
+	}
+
+	return nil
+}
@@ -0,0 +1,167 @@
+package stacks
+
+import (
+	"errors"
+	"maps"
+	"net/http"
+
+	portainer "github.com/portainer/portainer/api"
+	httperrors "github.com/portainer/portainer/api/http/errors"
+	"github.com/portainer/portainer/api/http/security"
+	"github.com/portainer/portainer/api/stacks/stackutils"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/request"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+	"github.com/portainer/portainer/pkg/libstack"
+)
+
+type stackScalePayload struct {
+	// Number of containers the service must run, 0 removes its containers
+	Replicas int `example:"3"`
+}
+
+func (payload *stackScalePayload) Validate(r *http.Request) error {
+	if payload.Replicas < 0 {
+		return errors.New("invalid Replicas: the number of containers cannot be negative")
+	}
+
+	return nil
+}
+
+// @id StackServiceScale
+// @summary Scale a service of a stack
+// @description Set the number of containers of a service of a Compose stack, like docker compose up --scale, without
+// @description redeploying the other services or editing the stack file.
+// @description The number of containers is kept in the ServiceScales of the stack: it overrides the stack file for the
+// @description following deployments and the drift checks, until the service is scaled again.
+// @description **Access policy**: authenticated
+// @tags stacks
+// @security ApiKeyAuth
+// @security jwt
+// @accept json
+// @produce json
+// @param id path int true "Stack identifier"
+// @param serviceName path string true "Name of the service in the stack file"
+// @param body body stackScalePayload true "Number of containers"
+// @success 200 {object} portainer.Stack "Success"
+// @failure 400 "Invalid request"
+// @failure 403 "Permission denied"
+// @failure 404 "Not found"
+// @failure 500 "Server error"
+// @router /stacks/{id}/services/{serviceName}/scale [post]
+func (handler *Handler) stackServiceScale(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	stackID, err := request.RetrieveNumericRouteVariableValue(r, "id")
+	if err != nil {
+		return httperror.BadRequest("Invalid stack identifier route variable", err)
+	}
+
+	serviceName, err := request.RetrieveRouteVariableValue(r, "serviceName")
+	if err != nil {
+		return httperror.BadRequest("Invalid service name route variable", err)
+	}
+
+	var payload stackScalePayload
+	if err := request.DecodeAndValidateJSONPayload(r, &payload); err != nil {
+		return httperror.BadRequest("Invalid request payload", err)
+	}
+
+	securityContext, err := security.RetrieveRestrictedRequestContext(r)
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve info from request context", err)
+	}
+
+	stack, err := handler.DataStore.Stack().Read(portainer.StackID(stackID))
+	if handler.DataStore.IsErrObjectNotFound(err) {
+		return httperror.NotFound("Unable to find a stack with the specified identifier inside the database", err)
+	} else if err != nil {
+		return httperror.InternalServerError("Unable to find a stack with the specified identifier inside the database", err)
+	}
+
+	if stack.Type != portainer.DockerComposeStack {
+		return httperror.BadRequest("Only the services of the Compose stacks can be scaled", errors.New("unsupported stack type"))
+	}
+
+	if stackutils.IsRelativePathStack(stack) {
+		return httperror.BadRequest("The services of the stacks deployed with a relative path cannot be scaled", errors.New("unsupported stack"))
+	}
+
+	if stack.Status != portainer.StackStatusActive {
+		return httperror.BadRequest("Only the services of the active stacks can be scaled", errors.New("the stack is not active"))
+	}
+
+	endpoint, err := handler.DataStore.Endpoint().Endpoint(stack.EndpointID)
+	if handler.DataStore.IsErrObjectNotFound(err) {
+		return httperror.NotFound("Unable to find an environment with the specified identifier inside the database", err)
+	} else if err != nil {
+		return httperror.InternalServerError("Unable to find an environment with the specified identifier inside the database", err)
+	}
+
+	if err := handler.requestBouncer.AuthorizedEndpointOperation(r, endpoint); err != nil {
+		return httperror.Forbidden("Permission denied to access environment", err)
+	}
+
+	if err := handler.requestBouncer.AuthorizedEndpointRoleOperation(r, endpoint, portainer.OperationPortainerStackUpdate); err != nil {
+		return httperror.Forbidden("Permission denied by your role on the environment", err)
+	}
+
+	resourceControl, err := handler.DataStore.ResourceControl().ResourceControlByResourceIDAndType(stackutils.ResourceControlID(stack.EndpointID, stack.Name), portainer.StackResourceControl)
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve a resource control associated to the stack", err)
+	}
+
+	access, err := handler.userCanAccessStack(securityContext, endpoint.ID, resourceControl)
+	if err != nil {
+		return httperror.InternalServerError("Unable to verify user authorizations to validate stack access", err)
+	}
+	if !access {
+		return httperror.Forbidden("Access denied to resource", httperrors.ErrResourceAccessDenied)
+	}
+
+	canManage, err := handler.userCanManageStacks(securityContext, endpoint)
+	if err != nil {
+		return httperror.InternalServerError("Unable to verify user authorizations to validate stack access", err)
+	}
+	if !canManage {
+		errMsg := "Stack management is disabled for non-admin users"
+		return httperror.Forbidden(errMsg, errors.New(errMsg))
+	}
+
+	user, err := handler.DataStore.User().Read(securityContext.UserID)
+	if err != nil {
+		return httperror.InternalServerError("Unable to load user information from the database", err)
+	}
+
+	registries, err := handler.DataStore.Registry().ReadAll()
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve registries from the database", err)
+	}
+
+	stack.Name = handler.ComposeStackManager.NormalizeStackName(stack.Name)
+
+	if err := handler.ComposeStackManager.Scale(r.Context(), stack, endpoint, serviceName, payload.Replicas, portainer.ComposeOptions{
+		Registries: security.FilterRegistries(registries, user, securityContext.UserMemberships, endpoint.ID),
+	}); err != nil {
+		if errors.Is(err, libstack.ErrServiceNotFound) {
+			return httperror.NotFound("Unable to find the service in the stack file", err)
+		} else if errors.Is(err, libstack.ErrServiceNotScalable) {
+			return httperror.BadRequest("Unable to scale the service", err)
+		}
+
+		return httperror.InternalServerError("Unable to scale the service", err)
+	}
+
+	scales := maps.Clone(stack.ServiceScales)
+	if scales == nil {
+		scales = make(map[string]int)
+	}
+	scales[serviceName] = payload.Replicas
+	stack.ServiceScales = scales
+
+	if err := handler.DataStore.Stack().Update(stack.ID, stack); err != nil {
+		return httperror.InternalServerError("Unable to persist the stack changes inside the database", err)
+	}
+
+	sanitizeStack(stack)
+
+	return response.JSON(w, stack)
+}
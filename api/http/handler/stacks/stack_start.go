@@ -7,6 +7,7 @@ import (
 	"net/http"
 
 	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/docker/quarantine"
 	httperrors "github.com/portainer/portainer/api/http/errors"
 	"github.com/portainer/portainer/api/http/security"
 	"github.com/portainer/portainer/api/stacks/deployments"
@@ -19,6 +20,8 @@ import (
 // @id StackStart
 // @summary Starts a stopped Stack
 // @description Starts a stopped Stack.
+// @description An inactive stack is deployed again, the services of a stack stopped with its resources kept are brought
+// @description back up as described by the stack file.
 // @description **Access policy**: authenticated
 // @tags stacks
 // @security ApiKeyAuth
@@ -71,6 +74,10 @@ func (handler *Handler) stackStart(w http.ResponseWriter, r *http.Request) *http
 		return httperror.Forbidden("Permission denied to access endpoint", err)
 	}
 
+	if err := handler.requestBouncer.AuthorizedEndpointRoleOperation(r, endpoint, portainer.OperationPortainerStackUpdate); err != nil {
+		return httperror.Forbidden("Permission denied by your role on the environment", err)
+	}
+
 	canManage, err := handler.userCanManageStacks(securityContext, endpoint)
 	if err != nil {
 		return httperror.InternalServerError("Unable to verify user authorizations to validate stack deletion", err)
@@ -102,11 +109,15 @@ func (handler *Handler) stackStart(w http.ResponseWriter, r *http.Request) *http
 		return httperror.Forbidden("Access denied to resource", httperrors.ErrResourceAccessDenied)
 	}
 
+	if err := quarantine.Check(endpoint); err != nil {
+		return httperror.Conflict("Unable to start stack", err)
+	}
+
 	if stack.Status == portainer.StackStatusActive {
 		return httperror.BadRequest("Stack is already active", errors.New("Stack is already active"))
 	}
 
-	if stack.AutoUpdate != nil && stack.AutoUpdate.Interval != "" {
+	if stack.AutoUpdate != nil && stack.AutoUpdate.Interval != "" && !stack.AutoUpdate.Paused {
 		deployments.StopAutoupdate(stack.ID, stack.AutoUpdate.JobID, handler.Scheduler)
 
 		jobID, e := deployments.StartAutoupdate(stack.ID, stack.AutoUpdate.Interval, handler.Scheduler, handler.StackDeployer, handler.DataStore, handler.GitService)
@@ -128,10 +139,7 @@ func (handler *Handler) stackStart(w http.ResponseWriter, r *http.Request) *http
 		return httperror.InternalServerError("Unable to update stack status", err)
 	}
 
-	if stack.GitConfig != nil && stack.GitConfig.Authentication != nil && stack.GitConfig.Authentication.Password != "" {
-		// sanitize password in the http response to minimise possible security leaks
-		stack.GitConfig.Authentication.Password = ""
-	}
+	sanitizeStack(stack)
 
 	return response.JSON(w, stack)
 }
@@ -18,12 +18,17 @@ import (
 // @id StackStop
 // @summary Stops a stopped Stack
 // @description Stops a stopped Stack.
+// @description With keepResources, the services of a Compose or Swarm stack are stopped while its containers, networks,
+// @description volumes, configs and secrets are kept: the containers of a Compose stack are stopped and the services of a
+// @description Swarm stack are scaled to zero. The stack is then stopped (status 3) and the stack start brings it back up.
+// @description Otherwise the stack is removed from the environment and becomes inactive (status 2).
 // @description **Access policy**: authenticated
 // @tags stacks
 // @security ApiKeyAuth
 // @security jwt
 // @param id path int true "Stack identifier"
 // @param endpointId query int true "Environment identifier"
+// @param keepResources query bool false "Stop the services of the stack without removing its resources"
 // @success 200 {object} portainer.Stack "Success"
 // @failure 400 "Invalid request"
 // @failure 403 "Permission denied"
@@ -57,6 +62,8 @@ func (handler *Handler) stackStop(w http.ResponseWriter, r *http.Request) *httpe
 		return httperror.BadRequest("Invalid query parameter: endpointId", err)
 	}
 
+	keepResources, _ := request.RetrieveBooleanQueryParameter(r, "keepResources", true)
+
 	endpoint, err := handler.DataStore.Endpoint().Endpoint(portainer.EndpointID(endpointID))
 	if handler.DataStore.IsErrObjectNotFound(err) {
 		return httperror.NotFound("Unable to find an environment with the specified identifier inside the database", err)
@@ -69,6 +76,10 @@ func (handler *Handler) stackStop(w http.ResponseWriter, r *http.Request) *httpe
 		return httperror.Forbidden("Permission denied to access environment", err)
 	}
 
+	if err := handler.requestBouncer.AuthorizedEndpointRoleOperation(r, endpoint, portainer.OperationPortainerStackUpdate); err != nil {
+		return httperror.Forbidden("Permission denied by your role on the environment", err)
+	}
+
 	resourceControl, err := handler.DataStore.ResourceControl().ResourceControlByResourceIDAndType(stackutils.ResourceControlID(stack.EndpointID, stack.Name), portainer.StackResourceControl)
 	if err != nil {
 		return httperror.InternalServerError("Unable to retrieve a resource control associated to the stack", err)
@@ -95,27 +106,35 @@ func (handler *Handler) stackStop(w http.ResponseWriter, r *http.Request) *httpe
 		return httperror.BadRequest("Stack is already inactive", errors.New("Stack is already inactive"))
 	}
 
+	if keepResources && stack.Status == portainer.StackStatusStopped {
+		return httperror.BadRequest("Stack is already stopped", errors.New("Stack is already stopped"))
+	}
+
 	// stop scheduler updates of the stack before stopping
 	if stack.AutoUpdate != nil && stack.AutoUpdate.JobID != "" {
 		deployments.StopAutoupdate(stack.ID, stack.AutoUpdate.JobID, handler.Scheduler)
 		stack.AutoUpdate.JobID = ""
 	}
 
-	err = handler.stopStack(stack, endpoint)
-	if err != nil {
-		return httperror.InternalServerError("Unable to stop stack", err)
-	}
+	if keepResources {
+		if err := handler.stopStackServices(stack, endpoint); err != nil {
+			return httperror.InternalServerError("Unable to stop stack", err)
+		}
+
+		stack.Status = portainer.StackStatusStopped
+	} else {
+		if err := handler.stopStack(stack, endpoint); err != nil {
+			return httperror.InternalServerError("Unable to stop stack", err)
+		}
 
-	stack.Status = portainer.StackStatusInactive
+		stack.Status = portainer.StackStatusInactive
+	}
 	err = handler.DataStore.Stack().Update(stack.ID, stack)
 	if err != nil {
 		return httperror.InternalServerError("Unable to update stack status", err)
 	}
 
-	if stack.GitConfig != nil && stack.GitConfig.Authentication != nil && stack.GitConfig.Authentication.Password != "" {
-		// sanitize password in the http response to minimise possible security leaks
-		stack.GitConfig.Authentication.Password = ""
-	}
+	sanitizeStack(stack)
 
 	return response.JSON(w, stack)
 }
@@ -142,3 +161,21 @@ func (handler *Handler) stopStack(stack *portainer.Stack, endpoint *portainer.En
 
 	return nil
 }
+
+// stopStackServices stops the services of a stack and keeps its resources
+func (handler *Handler) stopStackServices(stack *portainer.Stack, endpoint *portainer.Endpoint) error {
+	switch stack.Type {
+	case portainer.DockerComposeStack:
+		stack.Name = handler.ComposeStackManager.NormalizeStackName(stack.Name)
+	case portainer.DockerSwarmStack:
+		stack.Name = handler.SwarmStackManager.NormalizeStackName(stack.Name)
+	}
+
+	cli, err := handler.DockerClientFactory.CreateClient(endpoint, "", nil)
+	if err != nil {
+		return err
+	}
+	defer cli.Close()
+
+	return deployments.StopStackServices(context.TODO(), cli, stack)
+}
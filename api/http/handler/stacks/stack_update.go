@@ -6,10 +6,12 @@ import (
 	"time"
 
 	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/docker/quarantine"
 	httperrors "github.com/portainer/portainer/api/http/errors"
 	"github.com/portainer/portainer/api/http/security"
 	"github.com/portainer/portainer/api/stacks/deployments"
 	"github.com/portainer/portainer/api/stacks/stackutils"
+	"github.com/portainer/portainer/api/stacks/stackversions"
 	httperror "github.com/portainer/portainer/pkg/libhttp/error"
 	"github.com/portainer/portainer/pkg/libhttp/request"
 	"github.com/portainer/portainer/pkg/libhttp/response"
@@ -107,6 +109,14 @@ func (handler *Handler) stackUpdate(w http.ResponseWriter, r *http.Request) *htt
 		return httperror.Forbidden("Permission denied to access environment", err)
 	}
 
+	if err := handler.requestBouncer.AuthorizedEndpointRoleOperation(r, endpoint, portainer.OperationPortainerStackUpdate); err != nil {
+		return httperror.Forbidden("Permission denied by your role on the environment", err)
+	}
+
+	if err := quarantine.Check(endpoint); err != nil {
+		return httperror.Conflict("Unable to redeploy the stack", err)
+	}
+
 	securityContext, err := security.RetrieveRestrictedRequestContext(r)
 	if err != nil {
 		return httperror.InternalServerError("Unable to retrieve info from request context", err)
@@ -151,10 +161,9 @@ func (handler *Handler) stackUpdate(w http.ResponseWriter, r *http.Request) *htt
 		return httperror.InternalServerError("Unable to persist the stack changes inside the database", err)
 	}
 
-	if stack.GitConfig != nil && stack.GitConfig.Authentication != nil && stack.GitConfig.Authentication.Password != "" {
-		// Sanitize password in the http response to minimise possible security leaks
-		stack.GitConfig.Authentication.Password = ""
-	}
+	stackversions.RecordStack(handler.DataStore, stack, user.Username)
+
+	sanitizeStack(stack)
 
 	return response.JSON(w, stack)
 }
@@ -198,8 +207,14 @@ func (handler *Handler) updateComposeStack(r *http.Request, stack *portainer.Sta
 		stack.GitConfig = nil
 	}
 
+	return handler.redeployComposeStackContent(r, stack, endpoint, []byte(payload.StackFileContent), payload.PullImage)
+}
+
+// redeployComposeStackContent replaces the file of the compose stack and redeploys it, the previous file is restored
+// when the deployment fails
+func (handler *Handler) redeployComposeStackContent(r *http.Request, stack *portainer.Stack, endpoint *portainer.Endpoint, content []byte, pullImage bool) *httperror.HandlerError {
 	stackFolder := strconv.Itoa(int(stack.ID))
-	if _, err := handler.FileService.UpdateStoreStackFileFromBytes(stackFolder, stack.EntryPoint, []byte(payload.StackFileContent)); err != nil {
+	if _, err := handler.FileService.UpdateStoreStackFileFromBytes(stackFolder, stack.EntryPoint, content); err != nil {
 		if rollbackErr := handler.FileService.RollbackStackFile(stackFolder, stack.EntryPoint); rollbackErr != nil {
 			log.Warn().Err(rollbackErr).Msg("rollback stack file error")
 		}
@@ -219,7 +234,7 @@ func (handler *Handler) updateComposeStack(r *http.Request, stack *portainer.Sta
 		handler.DataStore,
 		handler.FileService,
 		handler.StackDeployer,
-		payload.PullImage,
+		pullImage,
 		false)
 	if err != nil {
 		if rollbackErr := handler.FileService.RollbackStackFile(stackFolder, stack.EntryPoint); rollbackErr != nil {
@@ -265,8 +280,14 @@ func (handler *Handler) updateSwarmStack(r *http.Request, stack *portainer.Stack
 		stack.GitConfig = nil
 	}
 
+	return handler.redeploySwarmStackContent(r, stack, endpoint, []byte(payload.StackFileContent), payload.Prune, payload.PullImage)
+}
+
+// redeploySwarmStackContent replaces the file of the swarm stack and redeploys it, the previous file is restored when
+// the deployment fails
+func (handler *Handler) redeploySwarmStackContent(r *http.Request, stack *portainer.Stack, endpoint *portainer.Endpoint, content []byte, prune, pullImage bool) *httperror.HandlerError {
 	stackFolder := strconv.Itoa(int(stack.ID))
-	if _, err := handler.FileService.UpdateStoreStackFileFromBytes(stackFolder, stack.EntryPoint, []byte(payload.StackFileContent)); err != nil {
+	if _, err := handler.FileService.UpdateStoreStackFileFromBytes(stackFolder, stack.EntryPoint, content); err != nil {
 		if rollbackErr := handler.FileService.RollbackStackFile(stackFolder, stack.EntryPoint); rollbackErr != nil {
 			log.Warn().Err(rollbackErr).Msg("rollback stack file error")
 		}
@@ -286,8 +307,8 @@ func (handler *Handler) updateSwarmStack(r *http.Request, stack *portainer.Stack
 		handler.DataStore,
 		handler.FileService,
 		handler.StackDeployer,
-		payload.Prune,
-		payload.PullImage)
+		prune,
+		pullImage)
 	if err != nil {
 		if rollbackErr := handler.FileService.RollbackStackFile(stackFolder, stack.EntryPoint); rollbackErr != nil {
 			log.Warn().Err(rollbackErr).Msg("rollback stack file error")
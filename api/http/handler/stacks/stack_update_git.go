@@ -27,9 +27,18 @@ type stackGitUpdatePayload struct {
 	RepositoryUsername       string
 	RepositoryPassword       string
 	TLSSkipVerify            bool
+	// Overlay directory of a kustomize Kubernetes stack, relative to the root of the repository. Left unchanged when
+	// omitted, removed when empty
+	KustomizeOverlay *string `example:"overlays/production"`
 }
 
 func (payload *stackGitUpdatePayload) Validate(r *http.Request) error {
+	if payload.KustomizeOverlay != nil {
+		if err := stackutils.ValidateKustomizeOverlay(*payload.KustomizeOverlay); err != nil {
+			return err
+		}
+	}
+
 	return update.ValidateAutoUpdateSettings(payload.AutoUpdate)
 }
 
@@ -94,6 +103,10 @@ func (handler *Handler) stackUpdateGit(w http.ResponseWriter, r *http.Request) *
 		return httperror.Forbidden("Permission denied to access environment", err)
 	}
 
+	if err := handler.requestBouncer.AuthorizedEndpointRoleOperation(r, endpoint, portainer.OperationPortainerStackUpdate); err != nil {
+		return httperror.Forbidden("Permission denied by your role on the environment", err)
+	}
+
 	securityContext, err := security.RetrieveRestrictedRequestContext(r)
 	if err != nil {
 		return httperror.InternalServerError("Unable to retrieve info from request context", err)
@@ -141,6 +154,10 @@ func (handler *Handler) stackUpdateGit(w http.ResponseWriter, r *http.Request) *
 		stack.Option = &portainer.StackOption{Prune: payload.Prune}
 	}
 
+	if stack.Type == portainer.KubernetesStack && payload.KustomizeOverlay != nil {
+		stack.KustomizeOverlay = *payload.KustomizeOverlay
+	}
+
 	if payload.RepositoryAuthentication {
 		password := payload.RepositoryPassword
 
@@ -162,7 +179,7 @@ func (handler *Handler) stackUpdateGit(w http.ResponseWriter, r *http.Request) *
 		stack.GitConfig.Authentication = nil
 	}
 
-	if payload.AutoUpdate != nil && payload.AutoUpdate.Interval != "" {
+	if payload.AutoUpdate != nil && payload.AutoUpdate.Interval != "" && !payload.AutoUpdate.Paused {
 		if jobID, err := deployments.StartAutoupdate(stack.ID, stack.AutoUpdate.Interval, handler.Scheduler, handler.StackDeployer, handler.DataStore, handler.GitService); err != nil {
 			return err
 		} else {
@@ -175,10 +192,7 @@ func (handler *Handler) stackUpdateGit(w http.ResponseWriter, r *http.Request) *
 		return httperror.InternalServerError("Unable to persist the stack changes inside the database", err)
 	}
 
-	if stack.GitConfig != nil && stack.GitConfig.Authentication != nil && stack.GitConfig.Authentication.Password != "" {
-		// sanitize password in the http response to minimise possible security leaks
-		stack.GitConfig.Authentication.Password = ""
-	}
+	sanitizeStack(stack)
 
 	return response.JSON(w, stack)
 }
@@ -0,0 +1,202 @@
+package stacks
+
+import (
+	"errors"
+	"net/http"
+	"slices"
+
+	portainer "github.com/portainer/portainer/api"
+	httperrors "github.com/portainer/portainer/api/http/errors"
+	"github.com/portainer/portainer/api/http/security"
+	"github.com/portainer/portainer/api/stacks/hooks"
+	"github.com/portainer/portainer/api/stacks/stackutils"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/request"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+)
+
+type stackUpdateHooksPayload struct {
+	// Hooks run, in order, before the stack is deployed
+	PreDeploy []portainer.StackHook
+	// Hooks run, in order, after the stack is deployed
+	PostDeploy []portainer.StackHook
+}
+
+func (payload *stackUpdateHooksPayload) Validate(r *http.Request) error {
+	return nil
+}
+
+// @id StackUpdateHooks
+// @summary Update the hooks of a stack
+// @description Replace the hooks run before and after each deployment of a stack. The hooks run on the next deployment.
+// @description An empty payload removes the hooks. The values of the headers and environment variables and the routing
+// @description keys are redacted in the responses, a redacted value keeps the value stored for the hook of the same name.
+// @description **Access policy**: administrator or environment administrator
+// @tags stacks
+// @security ApiKeyAuth
+// @security jwt
+// @accept json
+// @produce json
+// @param id path int true "Stack identifier"
+// @param body body stackUpdateHooksPayload true "Stack hooks"
+// @success 200 {object} portainer.Stack "Success"
+// @failure 400 "Invalid request"
+// @failure 403 "Permission denied"
+// @failure 404 "Stack not found"
+// @failure 500 "Server error"
+// @router /stacks/{id}/hooks [put]
+func (handler *Handler) stackUpdateHooks(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	stackID, err := request.RetrieveNumericRouteVariableValue(r, "id")
+	if err != nil {
+		return httperror.BadRequest("Invalid stack identifier route variable", err)
+	}
+
+	var payload stackUpdateHooksPayload
+	if err := request.DecodeAndValidateJSONPayload(r, &payload); err != nil {
+		return httperror.BadRequest("Invalid request payload", err)
+	}
+
+	stack, _, httpErr := handler.hooksStack(r, portainer.StackID(stackID))
+	if httpErr != nil {
+		return httpErr
+	}
+
+	var stackHooks *portainer.StackHooks
+	if len(payload.PreDeploy) > 0 || len(payload.PostDeploy) > 0 {
+		stackHooks = &portainer.StackHooks{PreDeploy: payload.PreDeploy, PostDeploy: payload.PostDeploy}
+	}
+
+	// the secrets of the hooks are redacted in the responses, the hooks sent back keep their stored values
+	if err := hooks.Restore(stackHooks, stack.Hooks); err != nil {
+		return httperror.BadRequest("Invalid stack hooks", err)
+	}
+
+	if err := hooks.Validate(stackHooks, stack.Type); err != nil {
+		return httperror.BadRequest("Invalid stack hooks", err)
+	}
+
+	stack.Hooks = stackHooks
+
+	if err := handler.DataStore.Stack().Update(stack.ID, stack); err != nil {
+		return httperror.InternalServerError("Unable to persist the stack changes inside the database", err)
+	}
+
+	sanitizeStack(stack)
+
+	return response.JSON(w, stack)
+}
+
+type stackTestHookPayload struct {
+	// HTTP, Teams or PagerDuty hook to send, it does not need to be saved
+	Hook portainer.StackHook
+}
+
+func (payload *stackTestHookPayload) Validate(r *http.Request) error {
+	if payload.Hook.Type == portainer.StackHookCommand {
+		return errors.New("Invalid hook type, the command hooks cannot be tested")
+	}
+
+	return nil
+}
+
+// @id StackTestHook
+// @summary Send the request of a hook
+// @description Renders the templates of an HTTP, Teams or PagerDuty hook for the stack and sends its request, without deploying the stack.
+// @description **Access policy**: administrator or environment administrator
+// @tags stacks
+// @security ApiKeyAuth
+// @security jwt
+// @accept json
+// @param id path int true "Stack identifier"
+// @param body body stackTestHookPayload true "Hook to test"
+// @success 204 "Success"
+// @failure 400 "Invalid request"
+// @failure 403 "Permission denied"
+// @failure 404 "Stack not found"
+// @failure 500 "The request of the hook failed"
+// @router /stacks/{id}/hooks/test [post]
+func (handler *Handler) stackTestHook(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	stackID, err := request.RetrieveNumericRouteVariableValue(r, "id")
+	if err != nil {
+		return httperror.BadRequest("Invalid stack identifier route variable", err)
+	}
+
+	var payload stackTestHookPayload
+	if err := request.DecodeAndValidateJSONPayload(r, &payload); err != nil {
+		return httperror.BadRequest("Invalid request payload", err)
+	}
+
+	stack, endpoint, httpErr := handler.hooksStack(r, portainer.StackID(stackID))
+	if httpErr != nil {
+		return httpErr
+	}
+
+	testHooks := &portainer.StackHooks{PreDeploy: []portainer.StackHook{payload.Hook}}
+
+	// a saved hook can be tested as returned by the API, with its redacted secrets
+	stored := &portainer.StackHooks{}
+	if stack.Hooks != nil {
+		stored.PreDeploy = slices.Concat(stack.Hooks.PreDeploy, stack.Hooks.PostDeploy)
+	}
+
+	if err := hooks.Restore(testHooks, stored); err != nil {
+		return httperror.BadRequest("Invalid stack hook", err)
+	}
+
+	if err := hooks.Validate(testHooks, stack.Type); err != nil {
+		return httperror.BadRequest("Invalid stack hook", err)
+	}
+
+	if err := hooks.NewRunner(handler.DockerClientFactory).Test(testHooks.PreDeploy[0], stack, endpoint); err != nil {
+		return httperror.InternalServerError("The request of the hook failed", err)
+	}
+
+	return response.Empty(w)
+}
+
+// hooksStack returns the stack whose hooks are managed, the hooks make Portainer call URLs and run containers
+// so only the administrators of its environment can manage them
+func (handler *Handler) hooksStack(r *http.Request, stackID portainer.StackID) (*portainer.Stack, *portainer.Endpoint, *httperror.HandlerError) {
+	stack, err := handler.DataStore.Stack().Read(stackID)
+	if handler.DataStore.IsErrObjectNotFound(err) {
+		return nil, nil, httperror.NotFound("Unable to find a stack with the specified identifier inside the database", err)
+	} else if err != nil {
+		return nil, nil, httperror.InternalServerError("Unable to find a stack with the specified identifier inside the database", err)
+	}
+
+	endpoint, err := handler.DataStore.Endpoint().Endpoint(stack.EndpointID)
+	if handler.DataStore.IsErrObjectNotFound(err) {
+		return nil, nil, httperror.NotFound("Unable to find an environment with the specified identifier inside the database", err)
+	} else if err != nil {
+		return nil, nil, httperror.InternalServerError("Unable to find an environment with the specified identifier inside the database", err)
+	}
+
+	if err := handler.requestBouncer.AuthorizedEndpointOperation(r, endpoint); err != nil {
+		return nil, nil, httperror.Forbidden("Permission denied to access environment", err)
+	}
+
+	if err := handler.requestBouncer.AuthorizedEndpointRoleOperation(r, endpoint, portainer.OperationPortainerStackUpdate); err != nil {
+		return nil, nil, httperror.Forbidden("Permission denied by your role on the environment", err)
+	}
+
+	securityContext, err := security.RetrieveRestrictedRequestContext(r)
+	if err != nil {
+		return nil, nil, httperror.InternalServerError("Unable to retrieve info from request context", err)
+	}
+
+	user, err := handler.DataStore.User().Read(securityContext.UserID)
+	if err != nil {
+		return nil, nil, httperror.InternalServerError("Unable to load user information from the database", err)
+	}
+
+	isAdminOrEndpointAdmin, err := stackutils.UserIsAdminOrEndpointAdmin(user, endpoint.ID)
+	if err != nil {
+		return nil, nil, httperror.InternalServerError("Unable to verify user authorizations", err)
+	}
+
+	if !isAdminOrEndpointAdmin {
+		return nil, nil, httperror.Forbidden("Only the administrators of the environment can update the stack hooks", httperrors.ErrResourceAccessDenied)
+	}
+
+	return stack, endpoint, nil
+}
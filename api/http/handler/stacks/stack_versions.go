@@ -0,0 +1,218 @@
+package stacks
+
+import (
+	"cmp"
+	"errors"
+	"net/http"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+	"github.com/portainer/portainer/api/docker/quarantine"
+	"github.com/portainer/portainer/api/http/security"
+	"github.com/portainer/portainer/api/stacks/stackversions"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/request"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+
+	"github.com/rs/zerolog/log"
+)
+
+// @id StackVersionList
+// @summary List the versions of a stack
+// @description List the content and environment variables a stack was deployed with, the most recent version first.
+// @description A version is added each time the stack is created, updated, redeployed from git or rolled back.
+// @description **Access policy**: authenticated
+// @tags stacks
+// @security ApiKeyAuth
+// @security jwt
+// @produce json
+// @param id path int true "Stack identifier"
+// @success 200 {array} portainer.StackVersion "Success"
+// @failure 400 "Invalid request"
+// @failure 403 "Permission denied"
+// @failure 404 "Stack not found"
+// @failure 500 "Server error"
+// @router /stacks/{id}/versions [get]
+func (handler *Handler) stackVersionList(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	stack, _, httpErr := handler.manageableStack(r)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	versions, err := handler.DataStore.StackVersion().VersionsByStackID(stack.ID)
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve the versions of the stack from the database", err)
+	}
+
+	return response.JSON(w, versions)
+}
+
+// @id StackVersionDiff
+// @summary Compare two versions of a stack
+// @description Compare the stack file and the environment variables of two versions of a stack.
+// @description By default, the latest version is compared with the previous one.
+// @description **Access policy**: authenticated
+// @tags stacks
+// @security ApiKeyAuth
+// @security jwt
+// @produce json
+// @param id path int true "Stack identifier"
+// @param from query int false "Number of the compared version, the version before the one compared to by default"
+// @param to query int false "Number of the version compared to, the latest version by default"
+// @success 200 {object} stackversions.Diff "Success"
+// @failure 400 "Invalid request"
+// @failure 403 "Permission denied"
+// @failure 404 "Stack or version not found"
+// @failure 500 "Server error"
+// @router /stacks/{id}/versions/diff [get]
+func (handler *Handler) stackVersionDiff(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	from, err := request.RetrieveNumericQueryParameter(r, "from", true)
+	if err != nil {
+		return httperror.BadRequest("Invalid query parameter: from", err)
+	}
+
+	to, err := request.RetrieveNumericQueryParameter(r, "to", true)
+	if err != nil {
+		return httperror.BadRequest("Invalid query parameter: to", err)
+	}
+
+	stack, _, httpErr := handler.manageableStack(r)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	var fromVersion, toVersion *portainer.StackVersion
+	err = handler.DataStore.ViewTx(func(tx dataservices.DataStoreTx) error {
+		versions, err := tx.StackVersion().VersionsByStackID(stack.ID)
+		if err != nil {
+			return err
+		} else if len(versions) == 0 {
+			return stackversions.ErrVersionNotFound
+		}
+
+		to = cmp.Or(to, versions[0].Version)
+		if toVersion, err = stackversions.Find(tx, stack.ID, to); err != nil {
+			return err
+		}
+
+		from = cmp.Or(from, to-1)
+		fromVersion, err = stackversions.Find(tx, stack.ID, from)
+
+		return err
+	})
+	if errors.Is(err, stackversions.ErrVersionNotFound) {
+		return httperror.NotFound("Unable to find the compared versions of the stack", err)
+	} else if err != nil {
+		return httperror.InternalServerError("Unable to retrieve the versions of the stack from the database", err)
+	}
+
+	diff, err := stackversions.NewDiff(fromVersion, toVersion)
+	if err != nil {
+		return httperror.InternalServerError("Unable to compare the versions of the stack", err)
+	}
+
+	return response.JSON(w, diff)
+}
+
+// @id StackVersionRollback
+// @summary Roll a stack back to one of its versions
+// @description Redeploy a stack with the stack file and the environment variables of one of its previous versions.
+// @description The rollback is added to the history of the stack as a new version.
+// @description The stacks deployed from a git repository are rolled back by tracking the reference of a previous commit instead.
+// @description **Access policy**: authenticated
+// @tags stacks
+// @security ApiKeyAuth
+// @security jwt
+// @produce json
+// @param id path int true "Stack identifier"
+// @param version path int true "Number of the version to roll back to"
+// @success 200 {object} portainer.Stack "Success"
+// @failure 400 "Invalid request"
+// @failure 403 "Permission denied"
+// @failure 404 "Stack or version not found"
+// @failure 409 "The environment is quarantined"
+// @failure 500 "Server error"
+// @router /stacks/{id}/versions/{version}/rollback [post]
+func (handler *Handler) stackVersionRollback(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	number, err := request.RetrieveNumericRouteVariableValue(r, "version")
+	if err != nil {
+		return httperror.BadRequest("Invalid stack version route variable", err)
+	}
+
+	stack, endpoint, httpErr := handler.manageableStack(r)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	if stack.GitConfig != nil {
+		return httperror.BadRequest("Unable to roll back a stack deployed from a git repository", errors.New("the stack is deployed from a git repository"))
+	}
+
+	if err := quarantine.Check(endpoint); err != nil {
+		return httperror.Conflict("Unable to redeploy the stack", err)
+	}
+
+	var version *portainer.StackVersion
+	if err := handler.DataStore.ViewTx(func(tx dataservices.DataStoreTx) (err error) {
+		version, err = stackversions.Find(tx, stack.ID, number)
+
+		return err
+	}); errors.Is(err, stackversions.ErrVersionNotFound) {
+		return httperror.NotFound("Unable to find the version of the stack", err)
+	} else if err != nil {
+		return httperror.InternalServerError("Unable to retrieve the versions of the stack from the database", err)
+	}
+
+	securityContext, err := security.RetrieveRestrictedRequestContext(r)
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve info from request context", err)
+	}
+
+	user, err := handler.DataStore.User().Read(securityContext.UserID)
+	if err != nil {
+		return httperror.InternalServerError("Unable to load user information from the database", err)
+	}
+
+	stack.Env = version.Env
+	content := []byte(version.StackFileContent)
+
+	switch stack.Type {
+	case portainer.DockerSwarmStack:
+		stack.Name = handler.SwarmStackManager.NormalizeStackName(stack.Name)
+		httpErr = handler.redeploySwarmStackContent(r, stack, endpoint, content, stack.Option != nil && stack.Option.Prune, false)
+	case portainer.DockerComposeStack:
+		stack.Name = handler.ComposeStackManager.NormalizeStackName(stack.Name)
+		httpErr = handler.redeployComposeStackContent(r, stack, endpoint, content, false)
+	case portainer.KubernetesStack:
+		httpErr = handler.redeployKubernetesStackContent(r, stack, endpoint, content, stack.Name)
+	default:
+		return httperror.BadRequest("Unsupported stack", errors.New("the stack type cannot be rolled back"))
+	}
+
+	if httpErr != nil {
+		return httpErr
+	}
+
+	stack.UpdatedBy = user.Username
+	stack.UpdateDate = time.Now().Unix()
+	stack.Status = portainer.StackStatusActive
+
+	if err := handler.DataStore.UpdateTx(func(tx dataservices.DataStoreTx) error {
+		if err := tx.Stack().Update(stack.ID, stack); err != nil {
+			return err
+		}
+
+		if _, err := stackversions.Record(tx, stack, user.Username, version.Version); err != nil {
+			log.Warn().Err(err).Int("stack_id", int(stack.ID)).Msg("unable to record the rollback of the stack")
+		}
+
+		return nil
+	}); err != nil {
+		return httperror.InternalServerError("Unable to persist the stack changes inside the database", err)
+	}
+
+	sanitizeStack(stack)
+
+	return response.JSON(w, stack)
+}
@@ -85,7 +85,7 @@ func (handler *Handler) updateKubernetesStack(r *http.Request, stack *portainer.
 			}
 		}
 
-		if payload.AutoUpdate != nil && payload.AutoUpdate.Interval != "" {
+		if payload.AutoUpdate != nil && payload.AutoUpdate.Interval != "" && !payload.AutoUpdate.Paused {
 			jobID, e := deployments.StartAutoupdate(stack.ID, stack.AutoUpdate.Interval, handler.Scheduler, handler.StackDeployer, handler.DataStore, handler.GitService)
 			if e != nil {
 				return e
@@ -102,6 +102,12 @@ func (handler *Handler) updateKubernetesStack(r *http.Request, stack *portainer.
 		return httperror.BadRequest("Invalid request payload", err)
 	}
 
+	return handler.redeployKubernetesStackContent(r, stack, endpoint, []byte(payload.StackFileContent), payload.StackName)
+}
+
+// redeployKubernetesStackContent deploys the manifest and replaces the file of the kubernetes stack once the
+// deployment succeeds
+func (handler *Handler) redeployKubernetesStackContent(r *http.Request, stack *portainer.Stack, endpoint *portainer.Endpoint, content []byte, stackName string) *httperror.HandlerError {
 	tokenData, err := security.RetrieveTokenData(r)
 	if err != nil {
 		return httperror.BadRequest("Failed to retrieve user token data", err)
@@ -110,12 +116,12 @@ func (handler *Handler) updateKubernetesStack(r *http.Request, stack *portainer.
 	tempFileDir, _ := os.MkdirTemp("", "kub_file_content")
 	defer os.RemoveAll(tempFileDir)
 
-	if err := filesystem.WriteToFile(filesystem.JoinPaths(tempFileDir, stack.EntryPoint), []byte(payload.StackFileContent)); err != nil {
+	if err := filesystem.WriteToFile(filesystem.JoinPaths(tempFileDir, stack.EntryPoint), content); err != nil {
 		return httperror.InternalServerError("Failed to persist deployment file in a temp directory", err)
 	}
 
-	if payload.StackName != stack.Name {
-		stack.Name = payload.StackName
+	if stackName != stack.Name {
+		stack.Name = stackName
 		if err := handler.DataStore.Stack().Update(stack.ID, stack); err != nil {
 			return httperror.InternalServerError("Failed to update stack name", err)
 		}
@@ -143,7 +149,7 @@ func (handler *Handler) updateKubernetesStack(r *http.Request, stack *portainer.
 	}
 
 	stackFolder := strconv.Itoa(int(stack.ID))
-	projectPath, err := handler.FileService.UpdateStoreStackFileFromBytes(stackFolder, stack.EntryPoint, []byte(payload.StackFileContent))
+	projectPath, err := handler.FileService.UpdateStoreStackFileFromBytes(stackFolder, stack.EntryPoint, content)
 	if err != nil {
 		if rollbackErr := handler.FileService.RollbackStackFile(stackFolder, stack.EntryPoint); rollbackErr != nil {
 			log.Warn().Err(rollbackErr).Msg("rollback stack file error")
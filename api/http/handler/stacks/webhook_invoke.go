@@ -3,23 +3,34 @@ package stacks
 import (
 	"errors"
 	"net/http"
+	"strings"
 
+	portainer "github.com/portainer/portainer/api"
 	"github.com/portainer/portainer/api/stacks/deployments"
 	httperror "github.com/portainer/portainer/pkg/libhttp/error"
 	"github.com/portainer/portainer/pkg/libhttp/request"
 	"github.com/portainer/portainer/pkg/libhttp/response"
 
 	"github.com/gofrs/uuid"
+	"github.com/segmentio/encoding/json"
 )
 
+// webhookPushPayload is the part of the push events of the git providers used to filter the pushes, GitHub, GitLab,
+// Gitea and Bitbucket Server all send the pushed reference
+type webhookPushPayload struct {
+	Ref string `json:"ref"`
+}
+
 // @id WebhookInvoke
 // @summary Webhook for triggering stack updates from git
+// @description The stack is redeployed when the tracked reference has a new commit.
+// @description When the body is the push event of a git provider, the pushes to other references are ignored.
 // @description **Access policy**: public
 // @tags stacks
 // @param webhookID path string true "Stack identifier"
 // @success 200 "Success"
 // @failure 400 "Invalid request"
-// @failure 409 "Autoupdate for the stack isn't available"
+// @failure 409 "Autoupdate for the stack isn't available or is paused"
 // @failure 500 "Server error"
 // @router /stacks/webhooks/{webhookID} [post]
 func (handler *Handler) webhookInvoke(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
@@ -38,18 +49,37 @@ func (handler *Handler) webhookInvoke(w http.ResponseWriter, r *http.Request) *h
 		return httperror.NewError(statusCode, "Unable to find the stack by webhook ID", err)
 	}
 
+	var push webhookPushPayload
+	if err := json.NewDecoder(r.Body).Decode(&push); err == nil && !pushedTrackedReference(stack, push.Ref) {
+		return response.Empty(w)
+	}
+
 	if err = deployments.RedeployWhenChanged(stack.ID, handler.StackDeployer, handler.DataStore, handler.GitService); err != nil {
 		var StackAuthorMissingErr *deployments.StackAuthorMissingErr
 		if errors.As(err, &StackAuthorMissingErr) {
 			return httperror.Conflict("Autoupdate for the stack isn't available", err)
 		}
 
+		if errors.Is(err, deployments.ErrAutoUpdatePaused) {
+			return httperror.Conflict("Autoupdate for the stack is paused", err)
+		}
+
 		return httperror.InternalServerError("Failed to update the stack", err)
 	}
 
 	return response.Empty(w)
 }
 
+// pushedTrackedReference returns whether the pushed reference is the one tracked by the stack, a push without
+// reference or to a stack tracking the default branch is always considered
+func pushedTrackedReference(stack *portainer.Stack, ref string) bool {
+	if ref == "" || stack.GitConfig == nil || stack.GitConfig.ReferenceName == "" {
+		return true
+	}
+
+	return strings.TrimPrefix(ref, "refs/heads/") == strings.TrimPrefix(stack.GitConfig.ReferenceName, "refs/heads/")
+}
+
 func retrieveUUIDRouteVariableValue(r *http.Request, name string) (uuid.UUID, error) {
 	webhookID, err := request.RetrieveRouteVariableValue(r, name)
 	if err != nil {
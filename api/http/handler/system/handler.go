@@ -8,6 +8,7 @@ import (
 	"github.com/portainer/portainer/api/http/security"
 	"github.com/portainer/portainer/api/internal/upgrade"
 	"github.com/portainer/portainer/api/platform"
+	"github.com/portainer/portainer/api/update"
 	httperror "github.com/portainer/portainer/pkg/libhttp/error"
 
 	"github.com/gorilla/mux"
@@ -20,6 +21,7 @@ type Handler struct {
 	dataStore       dataservices.DataStore
 	upgradeService  upgrade.Service
 	platformService platform.Service
+	updateService   *update.Service
 }
 
 // NewHandler creates a handler to manage status operations.
@@ -27,7 +29,8 @@ func NewHandler(bouncer security.BouncerService,
 	status *portainer.Status,
 	dataStore dataservices.DataStore,
 	platformService platform.Service,
-	upgradeService upgrade.Service) *Handler {
+	upgradeService upgrade.Service,
+	updateService *update.Service) *Handler {
 
 	h := &Handler{
 		Router:          mux.NewRouter(),
@@ -35,6 +38,7 @@ func NewHandler(bouncer security.BouncerService,
 		status:          status,
 		upgradeService:  upgradeService,
 		platformService: platformService,
+		updateService:   updateService,
 	}
 
 	router := h.PathPrefix("/system").Subrouter()
@@ -43,6 +47,8 @@ func NewHandler(bouncer security.BouncerService,
 	adminRouter.Use(bouncer.AdminAccess)
 
 	adminRouter.Handle("/upgrade", httperror.LoggerHandler(h.systemUpgrade)).Methods(http.MethodPost)
+	adminRouter.Handle("/updates", httperror.LoggerHandler(h.systemUpdatesInspect)).Methods(http.MethodGet)
+	adminRouter.Handle("/updates/upgrade", httperror.LoggerHandler(h.systemUpdatesUpgrade)).Methods(http.MethodPost)
 
 	authenticatedRouter := router.PathPrefix("/").Subrouter()
 	authenticatedRouter.Use(bouncer.AuthenticatedAccess)
@@ -50,6 +56,8 @@ func NewHandler(bouncer security.BouncerService,
 	authenticatedRouter.Handle("/version", httperror.LoggerHandler(h.version)).Methods(http.MethodGet)
 	authenticatedRouter.Handle("/nodes", httperror.LoggerHandler(h.systemNodesCount)).Methods(http.MethodGet)
 	authenticatedRouter.Handle("/info", httperror.LoggerHandler(h.systemInfo)).Methods(http.MethodGet)
+	authenticatedRouter.Handle("/status/endpoints", httperror.LoggerHandler(h.systemStatusEndpoints)).Methods(http.MethodGet)
+	authenticatedRouter.Handle("/status/stacks", httperror.LoggerHandler(h.systemStatusStacks)).Methods(http.MethodGet)
 
 	publicRouter := router.PathPrefix("/").Subrouter()
 	publicRouter.Use(bouncer.PublicAccess)
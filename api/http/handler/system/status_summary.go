@@ -0,0 +1,139 @@
+package system
+
+import (
+	"net/http"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/http/security"
+	"github.com/portainer/portainer/api/internal/authorization"
+	"github.com/portainer/portainer/api/internal/endpointutils"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+)
+
+type endpointStatusSummary struct {
+	ID   portainer.EndpointID `json:"Id" example:"1"`
+	Name string               `json:"Name" example:"my-environment"`
+	// Status of the environment (1 - up, 2 - down), the Edge environments are up while they check in
+	Status portainer.EndpointStatus `json:"Status" example:"1"`
+}
+
+type stackStatusSummary struct {
+	ID         portainer.StackID    `json:"Id" example:"1"`
+	Name       string               `json:"Name" example:"myStack"`
+	EndpointID portainer.EndpointID `json:"EndpointId" example:"1"`
+	// Status of the stack (1 - active, 2 - inactive)
+	Status portainer.StackStatus `json:"Status" example:"1"`
+}
+
+// @id systemStatusEndpoints
+// @summary List the status of the environments
+// @description Lists the identifier, name and status of the environments the user can access.
+// @description This endpoint can be accessed with the API keys of the status scope, made for status pages and dashboards.
+// @description **Access policy**: authenticated
+// @security ApiKeyAuth
+// @security jwt
+// @tags system
+// @produce json
+// @success 200 {array} endpointStatusSummary "Success"
+// @failure 500 "Server error"
+// @router /system/status/endpoints [get]
+func (handler *Handler) systemStatusEndpoints(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	securityContext, err := security.RetrieveRestrictedRequestContext(r)
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve info from request context", err)
+	}
+
+	endpoints, err := handler.dataStore.Endpoint().Endpoints()
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve environments from the database", err)
+	}
+
+	groups, err := handler.dataStore.EndpointGroup().ReadAll()
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve environment groups from the database", err)
+	}
+
+	settings, err := handler.dataStore.Settings().Settings()
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve settings from the database", err)
+	}
+
+	endpoints = security.FilterEndpoints(endpoints, groups, securityContext)
+
+	summaries := make([]endpointStatusSummary, 0, len(endpoints))
+	for _, endpoint := range endpoints {
+		status := endpoint.Status
+		if endpointutils.IsEdgeEndpoint(&endpoint) {
+			endpointutils.UpdateEdgeEndpointHeartbeat(&endpoint, settings)
+
+			status = portainer.EndpointStatusDown
+			if endpoint.Heartbeat {
+				status = portainer.EndpointStatusUp
+			}
+		}
+
+		summaries = append(summaries, endpointStatusSummary{
+			ID:     endpoint.ID,
+			Name:   endpoint.Name,
+			Status: status,
+		})
+	}
+
+	return response.JSON(w, summaries)
+}
+
+// @id systemStatusStacks
+// @summary List the status of the stacks
+// @description Lists the identifier, name, environment and status of the stacks the user can access.
+// @description This endpoint can be accessed with the API keys of the status scope, made for status pages and dashboards.
+// @description **Access policy**: authenticated
+// @security ApiKeyAuth
+// @security jwt
+// @tags system
+// @produce json
+// @success 200 {array} stackStatusSummary "Success"
+// @failure 500 "Server error"
+// @router /system/status/stacks [get]
+func (handler *Handler) systemStatusStacks(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	securityContext, err := security.RetrieveRestrictedRequestContext(r)
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve info from request context", err)
+	}
+
+	stacks, err := handler.dataStore.Stack().ReadAll()
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve stacks from the database", err)
+	}
+
+	if !securityContext.IsAdmin {
+		resourceControls, err := handler.dataStore.ResourceControl().ReadAll()
+		if err != nil {
+			return httperror.InternalServerError("Unable to retrieve resource controls from the database", err)
+		}
+
+		user, err := handler.dataStore.User().Read(securityContext.UserID)
+		if err != nil {
+			return httperror.InternalServerError("Unable to retrieve user information from the database", err)
+		}
+
+		userTeamIDs := make([]portainer.TeamID, 0, len(securityContext.UserMemberships))
+		for _, membership := range securityContext.UserMemberships {
+			userTeamIDs = append(userTeamIDs, membership.TeamID)
+		}
+
+		stacks = authorization.FilterAuthorizedStacks(authorization.DecorateStacks(stacks, resourceControls), user, userTeamIDs)
+	}
+
+	summaries := make([]stackStatusSummary, 0, len(stacks))
+	for _, stack := range stacks {
+		summaries = append(summaries, stackStatusSummary{
+			ID:         stack.ID,
+			Name:       stack.Name,
+			EndpointID: stack.EndpointID,
+			Status:     stack.Status,
+		})
+	}
+
+	return response.JSON(w, summaries)
+}
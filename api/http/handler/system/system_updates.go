@@ -0,0 +1,98 @@
+package system
+
+import (
+	"errors"
+	"net/http"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/update"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+)
+
+type updatesResponse struct {
+	// The running version
+	CurrentVersion string `json:"CurrentVersion" example:"2.25.0"`
+	// The release channel checked, empty when the checks are disabled
+	Channel string `json:"Channel" example:"stable"`
+	// The latest release of the channel
+	Release *update.Release `json:"Release,omitempty"`
+	// Whether the latest release of the channel is newer than the running version
+	UpdateAvailable bool `json:"UpdateAvailable" example:"true"`
+	// The upgrade in progress, until the upgraded instance passes its health check
+	Upgrade *update.Attempt `json:"Upgrade,omitempty"`
+}
+
+// @id systemUpdatesInspect
+// @summary Check the release channel for updates
+// @description Check the latest release of the configured release channel, once the signature of its metadata is verified
+// @description **Access policy**: administrator
+// @tags system
+// @security ApiKeyAuth
+// @security jwt
+// @produce json
+// @success 200 {object} updatesResponse "Success"
+// @failure 500 "Server error"
+// @failure 502 "Unable to retrieve or verify the release metadata"
+// @router /system/updates [get]
+func (handler *Handler) systemUpdatesInspect(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	settings, err := handler.dataStore.Settings().Settings()
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve the settings from the database", err)
+	}
+
+	attempt, err := handler.updateService.Attempt()
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve the upgrade in progress", err)
+	}
+
+	result := &updatesResponse{
+		CurrentVersion: portainer.APIVersion,
+		Channel:        settings.UpdateSettings.Channel,
+		Upgrade:        attempt,
+	}
+
+	if result.Channel == "" {
+		return response.JSON(w, result)
+	}
+
+	release, err := handler.updateService.Check()
+	if err != nil {
+		return httperror.NewError(http.StatusBadGateway, "Unable to check the release channel", err)
+	}
+
+	result.Release = release
+	result.UpdateAvailable = update.IsNewer(release, portainer.APIVersion)
+
+	return response.JSON(w, result)
+}
+
+// @id systemUpdatesUpgrade
+// @summary Upgrade Portainer to the latest release of the release channel
+// @description Back the data up and replace the Portainer container by one running the latest release of the release channel.
+// @description The upgraded instance is rolled back to the previous version and data when it does not pass its health check or keeps crashing before it.
+// @description The image of the release must be pinned to its sha256 digest.
+// @description Only available when Portainer runs under Docker with access to its own engine.
+// @description **Access policy**: administrator
+// @tags system
+// @security ApiKeyAuth
+// @security jwt
+// @produce json
+// @success 202 {object} update.Release "Upgrade started"
+// @failure 400 "No release channel is configured"
+// @failure 409 "Portainer is up to date or an upgrade is already in progress"
+// @failure 500 "Server error"
+// @router /system/updates/upgrade [post]
+func (handler *Handler) systemUpdatesUpgrade(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	release, err := handler.updateService.Upgrade()
+	switch {
+	case errors.Is(err, update.ErrChecksDisabled):
+		return httperror.BadRequest("Unable to upgrade Portainer", err)
+	case errors.Is(err, update.ErrNoUpdate), errors.Is(err, update.ErrUpgradeInProgress):
+		return httperror.Conflict("Unable to upgrade Portainer", err)
+	case err != nil:
+		return httperror.InternalServerError("Unable to upgrade Portainer", err)
+	}
+
+	return response.JSONWithStatus(w, release, http.StatusAccepted)
+}
@@ -6,8 +6,10 @@ import (
 	portainer "github.com/portainer/portainer/api"
 	"github.com/portainer/portainer/api/dataservices"
 	"github.com/portainer/portainer/api/http/security"
+	"github.com/portainer/portainer/api/internal/authorization"
 	"github.com/portainer/portainer/api/internal/endpointutils"
 	"github.com/portainer/portainer/api/kubernetes/cli"
+	"github.com/portainer/portainer/api/kubernetes/teamaccess"
 	httperror "github.com/portainer/portainer/pkg/libhttp/error"
 	"github.com/rs/zerolog/log"
 
@@ -17,8 +19,9 @@ import (
 // Handler is the HTTP handler used to handle team membership operations.
 type Handler struct {
 	*mux.Router
-	DataStore        dataservices.DataStore
-	K8sClientFactory *cli.ClientFactory
+	DataStore         dataservices.DataStore
+	K8sClientFactory  *cli.ClientFactory
+	TeamAccessService *teamaccess.Service
 }
 
 // NewHandler creates a handler to manage team membership operations.
@@ -37,7 +40,28 @@ func NewHandler(bouncer security.BouncerService) *Handler {
 	return h
 }
 
+// syncTeamNamespaceAccess updates the RoleBindings of the teams whose members changed in the Kubernetes environments
+func (handler *Handler) syncTeamNamespaceAccess(teamIDs ...portainer.TeamID) {
+	if handler.TeamAccessService == nil {
+		return
+	}
+
+	go handler.TeamAccessService.SyncTeams(teamIDs...)
+}
+
 func (handler *Handler) updateUserServiceAccounts(membership *portainer.TeamMembership) {
+	// the user keeps the access of its other teams, and no longer has the one of a team it left
+	memberships, err := authorization.EffectiveTeamMemberships(handler.DataStore, membership.UserID)
+	if err != nil {
+		log.Error().Err(err).Msgf("failed fetching team memberships for user %d", membership.UserID)
+		return
+	}
+
+	teamIDs := make([]int, 0, len(memberships))
+	for _, m := range memberships {
+		teamIDs = append(teamIDs, int(m.TeamID))
+	}
+
 	endpoints, err := handler.DataStore.Endpoint().EndpointsByTeamID(membership.TeamID)
 	if err != nil {
 		log.Error().Err(err).Msgf("failed fetching environments for team %d", membership.TeamID)
@@ -52,7 +76,6 @@ func (handler *Handler) updateUserServiceAccounts(membership *portainer.TeamMemb
 				log.Error().Err(err).Msgf("failed getting kube client for environment %d", endpoint.ID)
 				continue
 			}
-			teamIDs := []int{int(membership.TeamID)}
 			err = kubecli.SetupUserServiceAccount(int(membership.UserID), teamIDs, restrictDefaultNamespace)
 			if err != nil {
 				log.Error().Err(err).Msgf("failed setting-up service account for user %d", membership.UserID)
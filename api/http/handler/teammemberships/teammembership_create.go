@@ -92,6 +92,7 @@ func (handler *Handler) teamMembershipCreate(w http.ResponseWriter, r *http.Requ
 	}
 
 	defer handler.updateUserServiceAccounts(membership)
+	defer handler.syncTeamNamespaceAccess(membership.TeamID)
 
 	return response.JSON(w, membership)
 }
@@ -53,6 +53,7 @@ func (handler *Handler) teamMembershipDelete(w http.ResponseWriter, r *http.Requ
 	}
 
 	defer handler.updateUserServiceAccounts(membership)
+	defer handler.syncTeamNamespaceAccess(membership.TeamID)
 
 	return response.Empty(w)
 }
@@ -81,6 +81,8 @@ func (handler *Handler) teamMembershipUpdate(w http.ResponseWriter, r *http.Requ
 		return httperror.Forbidden("Permission denied to update the membership", httperrors.ErrResourceAccessDenied)
 	}
 
+	previousTeamID := membership.TeamID
+
 	membership.UserID = portainer.UserID(payload.UserID)
 	membership.TeamID = portainer.TeamID(payload.TeamID)
 	membership.Role = portainer.MembershipRole(payload.Role)
@@ -91,6 +93,7 @@ func (handler *Handler) teamMembershipUpdate(w http.ResponseWriter, r *http.Requ
 	}
 
 	defer handler.updateUserServiceAccounts(membership)
+	defer handler.syncTeamNamespaceAccess(previousTeamID, membership.TeamID)
 
 	return response.JSON(w, membership)
 }
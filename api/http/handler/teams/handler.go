@@ -5,6 +5,7 @@ import (
 
 	"github.com/portainer/portainer/api/dataservices"
 	"github.com/portainer/portainer/api/http/security"
+	"github.com/portainer/portainer/api/kubernetes/teamaccess"
 	httperror "github.com/portainer/portainer/pkg/libhttp/error"
 
 	"github.com/gorilla/mux"
@@ -13,7 +14,8 @@ import (
 // Handler is the HTTP handler used to handle team operations.
 type Handler struct {
 	*mux.Router
-	DataStore dataservices.DataStore
+	DataStore         dataservices.DataStore
+	TeamAccessService *teamaccess.Service
 }
 
 // NewHandler creates a handler to manage team operations.
@@ -37,6 +39,9 @@ func NewHandler(bouncer security.BouncerService) *Handler {
 	adminRouter.Handle("/teams/{id}", httperror.LoggerHandler(h.teamUpdate)).Methods(http.MethodPut)
 	adminRouter.Handle("/teams/{id}", httperror.LoggerHandler(h.teamDelete)).Methods(http.MethodDelete)
 	teamLeaderRouter.Handle("/teams/{id}/memberships", httperror.LoggerHandler(h.teamMemberships)).Methods(http.MethodGet)
+	teamLeaderRouter.Handle("/teams/{id}/helm/repositories", httperror.LoggerHandler(h.teamGetHelmRepos)).Methods(http.MethodGet)
+	teamLeaderRouter.Handle("/teams/{id}/helm/repositories", httperror.LoggerHandler(h.teamCreateHelmRepo)).Methods(http.MethodPost)
+	teamLeaderRouter.Handle("/teams/{id}/helm/repositories/{repositoryID}", httperror.LoggerHandler(h.teamDeleteHelmRepo)).Methods(http.MethodDelete)
 
 	return h
 }
@@ -6,6 +6,7 @@ import (
 
 	portainer "github.com/portainer/portainer/api"
 	"github.com/portainer/portainer/api/dataservices"
+	"github.com/portainer/portainer/api/internal/authorization"
 	httperror "github.com/portainer/portainer/pkg/libhttp/error"
 	"github.com/portainer/portainer/pkg/libhttp/request"
 	"github.com/portainer/portainer/pkg/libhttp/response"
@@ -16,6 +17,8 @@ type teamCreatePayload struct {
 	Name string `example:"developers" validate:"required"`
 	// TeamLeaders
 	TeamLeaders []portainer.UserID `example:"3,5"`
+	// Identifier of the parent team, whose access is inherited by the members of the team
+	ParentID portainer.TeamID `example:"1"`
 }
 
 func (payload *teamCreatePayload) Validate(r *http.Request) error {
@@ -28,7 +31,8 @@ func (payload *teamCreatePayload) Validate(r *http.Request) error {
 
 // @id TeamCreate
 // @summary Create a new team
-// @description Create a new team.
+// @description Create a new team. The members of a team inherit the environment access and the resource controls of
+// @description its parent team and of the ancestors of the parent.
 // @description **Access policy**: administrator
 // @tags teams
 // @security ApiKeyAuth
@@ -75,7 +79,18 @@ func createTeam(tx dataservices.DataStoreTx, payload teamCreatePayload) (*portai
 		return nil, httperror.Conflict("A team with the same name already exists", errors.New("Team already exists"))
 	}
 
-	team = &portainer.Team{Name: payload.Name}
+	if payload.ParentID != 0 {
+		teams, err := tx.Team().ReadAll()
+		if err != nil {
+			return nil, httperror.InternalServerError("Unable to retrieve teams from the database", err)
+		}
+
+		if err := authorization.ValidateTeamParent(teams, 0, payload.ParentID); err != nil {
+			return nil, httperror.BadRequest("Invalid parent team", err)
+		}
+	}
+
+	team = &portainer.Team{Name: payload.Name, ParentID: payload.ParentID}
 
 	if err := tx.Team().Create(team); err != nil {
 		return nil, httperror.InternalServerError("Unable to persist the team inside the database", err)
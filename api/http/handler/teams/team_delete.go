@@ -31,13 +31,30 @@ func (handler *Handler) teamDelete(w http.ResponseWriter, r *http.Request) *http
 		return httperror.BadRequest("Invalid team identifier route variable", err)
 	}
 
-	_, err = handler.DataStore.Team().Read(portainer.TeamID(teamID))
+	team, err := handler.DataStore.Team().Read(portainer.TeamID(teamID))
 	if handler.DataStore.IsErrObjectNotFound(err) {
 		return httperror.NotFound("Unable to find a team with the specified identifier inside the database", err)
 	} else if err != nil {
 		return httperror.InternalServerError("Unable to find a team with the specified identifier inside the database", err)
 	}
 
+	// the child teams move up to the parent of the deleted team and keep inheriting its ancestors
+	teams, err := handler.DataStore.Team().ReadAll()
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve teams from the database", err)
+	}
+
+	for _, child := range teams {
+		if child.ParentID != team.ID {
+			continue
+		}
+
+		child.ParentID = team.ParentID
+		if err := handler.DataStore.Team().Update(child.ID, &child); err != nil {
+			return httperror.InternalServerError("Unable to persist team changes inside the database", err)
+		}
+	}
+
 	err = handler.DataStore.Team().Delete(portainer.TeamID(teamID))
 	if err != nil {
 		return httperror.InternalServerError("Unable to delete the team from the database", err)
@@ -48,6 +65,21 @@ func (handler *Handler) teamDelete(w http.ResponseWriter, r *http.Request) *http
 		return httperror.InternalServerError("Unable to delete associated team memberships from the database", err)
 	}
 
+	repositories, err := handler.DataStore.HelmUserRepository().HelmUserRepositoryByTeamID(portainer.TeamID(teamID))
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve the Helm repositories of the team", err)
+	}
+
+	for _, repository := range repositories {
+		if err := handler.DataStore.HelmUserRepository().Delete(repository.ID); err != nil {
+			return httperror.InternalServerError("Unable to delete the Helm repositories of the team", err)
+		}
+	}
+
+	if handler.TeamAccessService != nil {
+		go handler.TeamAccessService.DeleteTeam(team.ID, team.ParentID)
+	}
+
 	// update default team if deleted team was default
 	err = handler.updateDefaultTeamIfDeleted(portainer.TeamID(teamID))
 	if err != nil {
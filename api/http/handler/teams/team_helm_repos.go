@@ -0,0 +1,213 @@
+package teams
+
+import (
+	"net/http"
+	"strings"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/helmrepositories"
+	"github.com/portainer/portainer/api/http/errors"
+	"github.com/portainer/portainer/api/http/security"
+	"github.com/portainer/portainer/pkg/libhelm"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/request"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+
+	pkgerrors "github.com/pkg/errors"
+)
+
+type teamHelmRepositoryCreatePayload struct {
+	URL string `json:"url" example:"https://charts.example.com"`
+	// Name of the repository shown with its charts
+	Name string `json:"name" example:"internal"`
+	// Authentication to a private repository with a username and a password
+	Authentication bool   `json:"authentication" example:"true"`
+	Username       string `json:"username" example:"charts"`
+	Password       string `json:"password" example:"secret"`
+	// TLS settings of the repository, the certificates and the key being PEM encoded
+	TLSSkipVerify bool   `json:"tlsSkipVerify" example:"false"`
+	TLSCACert     string `json:"tlsCACert"`
+	TLSCert       string `json:"tlsCert"`
+	TLSKey        string `json:"tlsKey"`
+}
+
+func (p *teamHelmRepositoryCreatePayload) Validate(_ *http.Request) error {
+	if p.URL == "" {
+		return libhelm.ValidateHelmRepositoryURL(p.URL, nil)
+	}
+
+	repository := p.repository()
+
+	return helmrepositories.Validate(&repository)
+}
+
+func (p *teamHelmRepositoryCreatePayload) repository() portainer.HelmUserRepository {
+	repository := portainer.HelmUserRepository{
+		Name:           p.Name,
+		URL:            helmrepositories.NormalizeURL(p.URL),
+		Authentication: p.Authentication,
+		TLSSkipVerify:  p.TLSSkipVerify,
+		TLSCACert:      p.TLSCACert,
+		TLSCert:        p.TLSCert,
+		TLSKey:         p.TLSKey,
+	}
+
+	if p.Authentication {
+		repository.Username = p.Username
+		repository.Password = p.Password
+	}
+
+	return repository
+}
+
+// @id TeamHelmRepositoryCreate
+// @summary Create a team helm repository
+// @description Create a helm repository used by the members of a team and of the teams below it, the private
+// @description repositories being reached with a username and a password or with a TLS client certificate. The password
+// @description and the TLS key are never returned.
+// @description **Access policy**: restricted, only administrators and the leaders of the team
+// @tags helm
+// @security ApiKeyAuth
+// @security jwt
+// @accept json
+// @produce json
+// @param id path int true "Team identifier"
+// @param payload body teamHelmRepositoryCreatePayload true "Helm Repository"
+// @success 200 {object} portainer.HelmUserRepository "Success"
+// @failure 400 "Invalid request"
+// @failure 403 "Permission denied"
+// @failure 404 "Team not found"
+// @failure 500 "Server error"
+// @router /teams/{id}/helm/repositories [post]
+func (handler *Handler) teamCreateHelmRepo(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	teamID, httpErr := handler.retrieveManagedTeamID(r)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	var payload teamHelmRepositoryCreatePayload
+	if err := request.DecodeAndValidateJSONPayload(r, &payload); err != nil {
+		return httperror.BadRequest("Invalid Helm repository", err)
+	}
+
+	record := payload.repository()
+	record.TeamID = teamID
+
+	records, err := handler.DataStore.HelmUserRepository().HelmUserRepositoryByTeamID(teamID)
+	if err != nil {
+		return httperror.InternalServerError("Unable to get team Helm repositories", err)
+	}
+
+	for _, existing := range records {
+		if strings.EqualFold(existing.URL, record.URL) {
+			errMsg := "Helm repo already registered for team"
+			return httperror.BadRequest(errMsg, pkgerrors.New(errMsg))
+		}
+	}
+
+	if err := handler.DataStore.HelmUserRepository().Create(&record); err != nil {
+		return httperror.InternalServerError("Unable to save a team Helm repository", err)
+	}
+
+	helmrepositories.HideSecrets(&record)
+
+	return response.JSON(w, record)
+}
+
+// @id TeamHelmRepositoriesList
+// @summary List the helm repositories of a team
+// @description **Access policy**: restricted, only administrators and the leaders of the team
+// @tags helm
+// @security ApiKeyAuth
+// @security jwt
+// @produce json
+// @param id path int true "Team identifier"
+// @success 200 {array} portainer.HelmUserRepository "Success"
+// @failure 400 "Invalid request"
+// @failure 403 "Permission denied"
+// @failure 404 "Team not found"
+// @failure 500 "Server error"
+// @router /teams/{id}/helm/repositories [get]
+func (handler *Handler) teamGetHelmRepos(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	teamID, httpErr := handler.retrieveManagedTeamID(r)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	records, err := handler.DataStore.HelmUserRepository().HelmUserRepositoryByTeamID(teamID)
+	if err != nil {
+		return httperror.InternalServerError("Unable to get team Helm repositories", err)
+	}
+
+	for i := range records {
+		helmrepositories.HideSecrets(&records[i])
+	}
+
+	return response.JSON(w, records)
+}
+
+// @id TeamHelmRepositoryDelete
+// @summary Delete a helm repository of a team
+// @description **Access policy**: restricted, only administrators and the leaders of the team
+// @tags helm
+// @security ApiKeyAuth
+// @security jwt
+// @param id path int true "Team identifier"
+// @param repositoryID path int true "Repository identifier"
+// @success 204 "Success"
+// @failure 400 "Invalid request"
+// @failure 403 "Permission denied"
+// @failure 404 "Team or repository not found"
+// @failure 500 "Server error"
+// @router /teams/{id}/helm/repositories/{repositoryID} [delete]
+func (handler *Handler) teamDeleteHelmRepo(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	teamID, httpErr := handler.retrieveManagedTeamID(r)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	repositoryID, err := request.RetrieveNumericRouteVariableValue(r, "repositoryID")
+	if err != nil {
+		return httperror.BadRequest("Invalid repository identifier route variable", err)
+	}
+
+	record, err := handler.DataStore.HelmUserRepository().Read(portainer.HelmUserRepositoryID(repositoryID))
+	if handler.DataStore.IsErrObjectNotFound(err) || (err == nil && record.TeamID != teamID) {
+		return httperror.NotFound("Unable to find a Helm repository of the team with the specified identifier", err)
+	} else if err != nil {
+		return httperror.InternalServerError("Unable to find a Helm repository with the specified identifier", err)
+	}
+
+	if err := handler.DataStore.HelmUserRepository().Delete(record.ID); err != nil {
+		return httperror.InternalServerError("Unable to delete the team Helm repository", err)
+	}
+
+	return response.Empty(w)
+}
+
+// retrieveManagedTeamID returns the identifier of the team of the request, which the user must be able to manage
+func (handler *Handler) retrieveManagedTeamID(r *http.Request) (portainer.TeamID, *httperror.HandlerError) {
+	id, err := request.RetrieveNumericRouteVariableValue(r, "id")
+	if err != nil {
+		return 0, httperror.BadRequest("Invalid team identifier route variable", err)
+	}
+
+	teamID := portainer.TeamID(id)
+
+	securityContext, err := security.RetrieveRestrictedRequestContext(r)
+	if err != nil {
+		return 0, httperror.InternalServerError("Unable to retrieve info from request context", err)
+	}
+
+	if !security.AuthorizedTeamManagement(teamID, securityContext) {
+		return 0, httperror.Forbidden("Access denied to team", errors.ErrResourceAccessDenied)
+	}
+
+	if _, err := handler.DataStore.Team().Read(teamID); handler.DataStore.IsErrObjectNotFound(err) {
+		return 0, httperror.NotFound("Unable to find a team with the specified identifier inside the database", err)
+	} else if err != nil {
+		return 0, httperror.InternalServerError("Unable to find a team with the specified identifier inside the database", err)
+	}
+
+	return teamID, nil
+}
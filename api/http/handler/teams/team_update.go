@@ -4,6 +4,7 @@ import (
 	"net/http"
 
 	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/internal/authorization"
 	httperror "github.com/portainer/portainer/pkg/libhttp/error"
 	"github.com/portainer/portainer/pkg/libhttp/request"
 	"github.com/portainer/portainer/pkg/libhttp/response"
@@ -12,6 +13,9 @@ import (
 type teamUpdatePayload struct {
 	// Name
 	Name string `example:"developers"`
+	// Identifier of the parent team, whose access is inherited by the members of the team. 0 detaches the team from
+	// its parent, the parent is left unchanged when omitted
+	ParentID *portainer.TeamID `example:"1"`
 }
 
 func (payload *teamUpdatePayload) Validate(r *http.Request) error {
@@ -20,7 +24,8 @@ func (payload *teamUpdatePayload) Validate(r *http.Request) error {
 
 // @id TeamUpdate
 // @summary Update a team
-// @description Update a team.
+// @description Update a team. Moving a team under another one makes its members inherit the environment access and
+// @description the resource controls of the new parent.
 // @description **Access policy**: administrator
 // @tags teams
 // @security ApiKeyAuth
@@ -58,9 +63,29 @@ func (handler *Handler) teamUpdate(w http.ResponseWriter, r *http.Request) *http
 		team.Name = payload.Name
 	}
 
+	previousParentID := team.ParentID
+
+	if payload.ParentID != nil {
+		teams, err := handler.DataStore.Team().ReadAll()
+		if err != nil {
+			return httperror.InternalServerError("Unable to retrieve teams from the database", err)
+		}
+
+		if err := authorization.ValidateTeamParent(teams, team.ID, *payload.ParentID); err != nil {
+			return httperror.BadRequest("Invalid parent team", err)
+		}
+
+		team.ParentID = *payload.ParentID
+	}
+
 	if err := handler.DataStore.Team().Update(team.ID, team); err != nil {
 		return httperror.NotFound("Unable to persist team changes inside the database", err)
 	}
 
+	// the members of the team inherit the access of its new ancestors and lose the one of its former ancestors
+	if team.ParentID != previousParentID && handler.TeamAccessService != nil {
+		go handler.TeamAccessService.SyncTeams(team.ID, previousParentID)
+	}
+
 	return response.JSON(w, team)
 }
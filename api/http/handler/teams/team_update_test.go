@@ -0,0 +1,100 @@
+package teams
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/datastore"
+	"github.com/portainer/portainer/api/internal/authorization"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTeamHierarchy(t *testing.T) {
+	is := require.New(t)
+
+	_, store := datastore.MustNewTestStore(t, true, false)
+
+	h := &Handler{
+		DataStore: store,
+	}
+
+	create := func(name string, parentID portainer.TeamID) *portainer.Team {
+		payload, err := json.Marshal(teamCreatePayload{Name: name, ParentID: parentID})
+		is.NoError(err)
+
+		rr := httptest.NewRecorder()
+		is.Nil(h.teamCreate(rr, httptest.NewRequest(http.MethodPost, "/teams", bytes.NewReader(payload))))
+
+		var team portainer.Team
+		is.NoError(json.NewDecoder(rr.Body).Decode(&team))
+
+		return &team
+	}
+
+	update := func(teamID, parentID portainer.TeamID) int {
+		payload, err := json.Marshal(teamUpdatePayload{ParentID: &parentID})
+		is.NoError(err)
+
+		req := httptest.NewRequest(http.MethodPut, "/teams/"+strconv.Itoa(int(teamID)), bytes.NewReader(payload))
+		req = mux.SetURLVars(req, map[string]string{"id": strconv.Itoa(int(teamID))})
+
+		if httpErr := h.teamUpdate(httptest.NewRecorder(), req); httpErr != nil {
+			return httpErr.StatusCode
+		}
+
+		return http.StatusOK
+	}
+
+	org := create("org", 0)
+	department := create("department", org.ID)
+	squad := create("squad", department.ID)
+	is.Equal(department.ID, squad.ParentID)
+
+	payload, err := json.Marshal(teamCreatePayload{Name: "orphan", ParentID: 100})
+	is.NoError(err)
+	httpErr := h.teamCreate(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/teams", bytes.NewReader(payload)))
+	is.NotNil(httpErr)
+	is.Equal(http.StatusBadRequest, httpErr.StatusCode)
+
+	// a team cannot be moved under one of its descendants
+	is.Equal(http.StatusBadRequest, update(org.ID, squad.ID))
+	is.Equal(http.StatusBadRequest, update(org.ID, org.ID))
+
+	// the members of the squad inherit the access of the department and of the org
+	is.NoError(store.TeamMembership().Create(&portainer.TeamMembership{UserID: 2, TeamID: squad.ID, Role: portainer.TeamLeader}))
+
+	memberships, err := authorization.EffectiveTeamMemberships(store, 2)
+	is.NoError(err)
+	is.Equal([]portainer.TeamMembership{
+		{ID: 1, UserID: 2, TeamID: squad.ID, Role: portainer.TeamLeader},
+		{UserID: 2, TeamID: department.ID, Role: portainer.TeamMember},
+		{UserID: 2, TeamID: org.ID, Role: portainer.TeamMember},
+	}, memberships)
+
+	memberships, err = authorization.EffectiveTeamMembershipsByTeamID(store, org.ID)
+	is.NoError(err)
+	is.Len(memberships, 1)
+
+	// the squad moves up to the org when the department is deleted
+	req := httptest.NewRequest(http.MethodDelete, "/teams/"+strconv.Itoa(int(department.ID)), nil)
+	req = mux.SetURLVars(req, map[string]string{"id": strconv.Itoa(int(department.ID))})
+	is.Nil(h.teamDelete(httptest.NewRecorder(), req))
+
+	squad, err = store.Team().Read(squad.ID)
+	is.NoError(err)
+	is.Equal(org.ID, squad.ParentID)
+
+	// detached from its parent
+	is.Equal(http.StatusOK, update(squad.ID, 0))
+
+	squad, err = store.Team().Read(squad.ID)
+	is.NoError(err)
+	is.Zero(squad.ParentID)
+}
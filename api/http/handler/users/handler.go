@@ -8,6 +8,8 @@ import (
 	"github.com/portainer/portainer/api/apikey"
 	"github.com/portainer/portainer/api/dataservices"
 	"github.com/portainer/portainer/api/http/security"
+	"github.com/portainer/portainer/api/kubernetes/teamaccess"
+	"github.com/portainer/portainer/api/webauthn"
 	httperror "github.com/portainer/portainer/pkg/libhttp/error"
 
 	"github.com/gorilla/mux"
@@ -23,6 +25,10 @@ var (
 
 func hideFields(user *portainer.User) {
 	user.Password = ""
+	user.OAuthRefreshToken = nil
+	user.OAuthIDToken = ""
+	user.SAMLNameID = ""
+	user.SAMLSessionIndex = ""
 }
 
 // Handler is the HTTP handler used to handle user operations.
@@ -35,6 +41,9 @@ type Handler struct {
 	passwordStrengthChecker security.PasswordStrengthChecker
 	AdminCreationDone       chan<- struct{}
 	FileService             portainer.FileService
+	JWTService              portainer.JWTService
+	webAuthnService         *webauthn.Service
+	TeamAccessService       *teamaccess.Service
 }
 
 // NewHandler creates a handler to manage user operations.
@@ -44,6 +53,7 @@ func NewHandler(bouncer security.BouncerService, rateLimiter *security.RateLimit
 		bouncer:                 bouncer,
 		apiKeyService:           apiKeyService,
 		passwordStrengthChecker: passwordStrengthChecker,
+		webAuthnService:         webauthn.NewService(),
 	}
 
 	adminRouter := h.NewRoute().Subrouter()
@@ -62,6 +72,8 @@ func NewHandler(bouncer security.BouncerService, rateLimiter *security.RateLimit
 	publicRouter.Use(bouncer.PublicAccess)
 
 	adminRouter.Handle("/users", httperror.LoggerHandler(h.userCreate)).Methods(http.MethodPost)
+	adminRouter.Handle("/users/import", httperror.LoggerHandler(h.userImport)).Methods(http.MethodPost)
+	adminRouter.Handle("/users/service_accounts", httperror.LoggerHandler(h.userServiceAccountCreate)).Methods(http.MethodPost)
 	restrictedRouter.Handle("/users", httperror.LoggerHandler(h.userList)).Methods(http.MethodGet)
 
 	authenticatedRouter.Handle("/users/me", httperror.LoggerHandler(h.userInspectMe)).Methods(http.MethodGet)
@@ -69,10 +81,22 @@ func NewHandler(bouncer security.BouncerService, rateLimiter *security.RateLimit
 	restrictedRouter.Handle("/users/{id}", httperror.LoggerHandler(h.userInspect)).Methods(http.MethodGet)
 	authenticatedRouter.Handle("/users/{id}", httperror.LoggerHandler(h.userUpdate)).Methods(http.MethodPut)
 	adminRouter.Handle("/users/{id}", httperror.LoggerHandler(h.userDelete)).Methods(http.MethodDelete)
+	adminRouter.Handle("/users/{id}/impersonate", httperror.LoggerHandler(h.userImpersonate)).Methods(http.MethodPost)
+	adminRouter.Handle("/users/{id}/service_account", httperror.LoggerHandler(h.userServiceAccountUpdate)).Methods(http.MethodPut)
 	restrictedRouter.Handle("/users/{id}/tokens", httperror.LoggerHandler(h.userGetAccessTokens)).Methods(http.MethodGet)
 	restrictedRouter.Handle("/users/{id}/tokens", rateLimiter.LimitAccess(httperror.LoggerHandler(h.userCreateAccessToken))).Methods(http.MethodPost)
 	restrictedRouter.Handle("/users/{id}/tokens/{keyID}", httperror.LoggerHandler(h.userRemoveAccessToken)).Methods(http.MethodDelete)
 	restrictedRouter.Handle("/users/{id}/memberships", httperror.LoggerHandler(h.userMemberships)).Methods(http.MethodGet)
+	restrictedRouter.Handle("/users/{id}/2fa", httperror.LoggerHandler(h.userTwoFactorInspect)).Methods(http.MethodGet)
+	restrictedRouter.Handle("/users/{id}/2fa", httperror.LoggerHandler(h.userTwoFactorEnroll)).Methods(http.MethodPost)
+	restrictedRouter.Handle("/users/{id}/2fa/confirm", rateLimiter.LimitAccess(httperror.LoggerHandler(h.userTwoFactorConfirm))).Methods(http.MethodPost)
+	restrictedRouter.Handle("/users/{id}/2fa", httperror.LoggerHandler(h.userTwoFactorDisable)).Methods(http.MethodDelete)
+	restrictedRouter.Handle("/users/{id}/webauthn/register/begin", httperror.LoggerHandler(h.userWebAuthnRegisterBegin)).Methods(http.MethodPost)
+	restrictedRouter.Handle("/users/{id}/webauthn/register/finish", rateLimiter.LimitAccess(httperror.LoggerHandler(h.userWebAuthnRegisterFinish))).Methods(http.MethodPost)
+	restrictedRouter.Handle("/users/{id}/webauthn/credentials", httperror.LoggerHandler(h.userWebAuthnCredentialList)).Methods(http.MethodGet)
+	restrictedRouter.Handle("/users/{id}/webauthn/credentials/{credentialID}", httperror.LoggerHandler(h.userWebAuthnCredentialDelete)).Methods(http.MethodDelete)
+	restrictedRouter.Handle("/users/{id}/sessions", httperror.LoggerHandler(h.userSessionList)).Methods(http.MethodGet)
+	restrictedRouter.Handle("/users/{id}/sessions/{sessionID}", httperror.LoggerHandler(h.userSessionRevoke)).Methods(http.MethodDelete)
 	authenticatedRouter.Handle("/users/{id}/passwd", rateLimiter.LimitAccess(httperror.LoggerHandler(h.userUpdatePassword))).Methods(http.MethodPut)
 
 	publicRouter.Handle("/users/admin/check", httperror.LoggerHandler(h.adminCheck)).Methods(http.MethodGet)
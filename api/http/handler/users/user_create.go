@@ -4,8 +4,10 @@ import (
 	"errors"
 	"net/http"
 	"strings"
+	"time"
 
 	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/authprovider"
 	"github.com/portainer/portainer/api/dataservices"
 	httperror "github.com/portainer/portainer/pkg/libhttp/error"
 	"github.com/portainer/portainer/pkg/libhttp/request"
@@ -17,6 +19,9 @@ type userCreatePayload struct {
 	Password string `validate:"required" example:"cg9Wgky3"`
 	// User role (1 for administrator account and 2 for regular account)
 	Role int `validate:"required" enums:"1,2" example:"2"`
+	// Authentication method the user is bound to (1 for internal, 2 for LDAP, 3 for OAuth or 4 for SAML).
+	// When omitted, users created with a password are bound to the internal authentication
+	AuthenticationMethod int `enums:"0,1,2,3,4" example:"1"`
 }
 
 func (payload *userCreatePayload) Validate(r *http.Request) error {
@@ -28,6 +33,10 @@ func (payload *userCreatePayload) Validate(r *http.Request) error {
 		return errors.New("Invalid role value. Value must be one of: 1 (administrator) or 2 (regular user)")
 	}
 
+	if payload.AuthenticationMethod != 0 && !authprovider.IsValidMethod(portainer.AuthenticationMethod(payload.AuthenticationMethod)) {
+		return errors.New("Invalid authentication method value. Value must be one of: 1 (internal), 2 (LDAP/AD), 3 (OAuth), 4 (SAML) or a registered provider")
+	}
+
 	return nil
 }
 
@@ -83,31 +92,18 @@ func (handler *Handler) createUser(tx dataservices.DataStoreTx, payload userCrea
 		return nil, httperror.Conflict("Another user with the same username already exists", errUserAlreadyExists)
 	}
 
-	user = &portainer.User{
-		Username: payload.Username,
-		Role:     portainer.UserRole(payload.Role),
-	}
-
 	settings, err := tx.Settings().Settings()
 	if err != nil {
 		return nil, httperror.InternalServerError("Unable to retrieve settings from the database", err)
 	}
 
-	// When LDAP/OAuth is on, can only add users without password
-	if (settings.AuthenticationMethod == portainer.AuthenticationLDAP || settings.AuthenticationMethod == portainer.AuthenticationOAuth) && payload.Password != "" {
-		errMsg := "a user with password can not be created when authentication method is Oauth or LDAP"
-		return nil, httperror.BadRequest(errMsg, errors.New(errMsg))
+	user, err = handler.newUser(settings, payload)
+	if err != nil {
+		return nil, err
 	}
 
-	if settings.AuthenticationMethod == portainer.AuthenticationInternal {
-		if !handler.passwordStrengthChecker.Check(payload.Password) {
-			return nil, httperror.BadRequest("Password does not meet the requirements", nil)
-		}
-
-		user.Password, err = handler.CryptoService.Hash(payload.Password)
-		if err != nil {
-			return nil, httperror.InternalServerError("Unable to hash user password", errCryptoHashFailure)
-		}
+	if err := handler.hashUserPassword(user); err != nil {
+		return nil, err
 	}
 
 	if err := tx.User().Create(user); err != nil {
@@ -118,3 +114,57 @@ func (handler *Handler) createUser(tx dataservices.DataStoreTx, payload userCrea
 
 	return user, nil
 }
+
+// newUser validates the payload of a user against the settings and returns the user to create, the password of
+// the users of the internal authentication being left in clear text
+func (handler *Handler) newUser(settings *portainer.Settings, payload userCreatePayload) (*portainer.User, error) {
+	user := &portainer.User{
+		Username: payload.Username,
+		Role:     portainer.UserRole(payload.Role),
+	}
+
+	method := portainer.AuthenticationMethod(payload.AuthenticationMethod)
+	if method == 0 && (payload.Password != "" || settings.AuthenticationMethod == portainer.AuthenticationInternal) {
+		method = portainer.AuthenticationInternal
+	}
+
+	if method != 0 && !settings.IsAuthenticationMethodEnabled(method) {
+		errMsg := "the authentication method of the user is not enabled"
+		return nil, httperror.BadRequest(errMsg, errors.New(errMsg))
+	}
+
+	// Only users of the internal authentication can have a password
+	if method != portainer.AuthenticationInternal && payload.Password != "" {
+		errMsg := "a user with password can not be created when authentication method is Oauth, LDAP or SAML"
+		return nil, httperror.BadRequest(errMsg, errors.New(errMsg))
+	}
+
+	user.AuthenticationMethod = method
+
+	if method == portainer.AuthenticationInternal {
+		if err := handler.passwordStrengthChecker.Validate(payload.Password, user.Username); err != nil {
+			return nil, httperror.BadRequest("Password does not meet the requirements", err)
+		}
+
+		user.Password = payload.Password
+	}
+
+	return user, nil
+}
+
+// hashUserPassword replaces the clear text password of a user of the internal authentication with its hash
+func (handler *Handler) hashUserPassword(user *portainer.User) error {
+	if user.AuthenticationMethod != portainer.AuthenticationInternal {
+		return nil
+	}
+
+	hash, err := handler.CryptoService.Hash(user.Password)
+	if err != nil {
+		return httperror.InternalServerError("Unable to hash user password", errCryptoHashFailure)
+	}
+
+	user.Password = hash
+	user.PasswordUpdatedAt = time.Now().Unix()
+
+	return nil
+}
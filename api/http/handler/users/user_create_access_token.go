@@ -18,6 +18,8 @@ import (
 type userAccessTokenCreatePayload struct {
 	Password    string `validate:"required" example:"password" json:"password"`
 	Description string `validate:"required" example:"github-api-key" json:"description"`
+	// Restricts the API key to the read-only status endpoints when set to "status"
+	Scope portainer.APIKeyScope `example:"status" json:"scope"`
 }
 
 func (payload *userAccessTokenCreatePayload) Validate(r *http.Request) error {
@@ -30,6 +32,9 @@ func (payload *userAccessTokenCreatePayload) Validate(r *http.Request) error {
 	if govalidator.MinStringLength(payload.Description, "128") {
 		return errors.New("invalid description: cannot be longer than 128 characters")
 	}
+	if payload.Scope != "" && payload.Scope != portainer.APIKeyScopeStatus {
+		return errors.New("invalid scope: only the status scope is supported")
+	}
 	return nil
 }
 
@@ -41,8 +46,10 @@ type accessTokenResponse struct {
 // @id UserGenerateAPIKey
 // @summary Generate an API key for a user
 // @description Generates an API key for a user.
-// @description Only the calling user can generate a token for themselves.
+// @description Only the calling user can generate a token for themselves, except the administrators who generate the
+// @description tokens of the service accounts.
 // @description Password is required only for internal authentication.
+// @description The API keys of the status scope can only access the read-only status endpoints of the environments and stacks.
 // @description **Access policy**: restricted
 // @tags users
 // @security jwt
@@ -84,15 +91,34 @@ func (handler *Handler) userCreateAccessToken(w http.ResponseWriter, r *http.Req
 		return httperror.InternalServerError("Unable to retrieve user authentication token", err)
 	}
 
-	if tokenData.ID != portainer.UserID(userID) {
-		return httperror.Forbidden("Permission denied to create user access token", httperrors.ErrUnauthorized)
+	if tokenData.ImpersonatorID != 0 {
+		return httperror.Forbidden(errImpersonationNotAllowed.Error(), errImpersonationNotAllowed)
 	}
 
 	user, err := handler.DataStore.User().Read(portainer.UserID(userID))
-	if err != nil {
+	if handler.DataStore.IsErrObjectNotFound(err) {
+		return httperror.NotFound("Unable to find a user with the specified identifier inside the database", err)
+	} else if err != nil {
 		return httperror.InternalServerError("Unable to find a user with the specified identifier inside the database", err)
 	}
 
+	if user.ServiceAccount {
+		if tokenData.Role != portainer.AdministratorRole {
+			return httperror.Forbidden("Permission denied to create user access token", httperrors.ErrUnauthorized)
+		}
+
+		rawAPIKey, apiKey, err := handler.apiKeyService.GenerateScopedApiKey(*user, payload.Description, payload.Scope)
+		if err != nil {
+			return httperror.InternalServerError("Internal Server Error", err)
+		}
+
+		return response.JSONWithStatus(w, accessTokenResponse{rawAPIKey, *apiKey}, http.StatusCreated)
+	}
+
+	if tokenData.ID != portainer.UserID(userID) {
+		return httperror.Forbidden("Permission denied to create user access token", httperrors.ErrUnauthorized)
+	}
+
 	internalAuth, err := handler.usesInternalAuthentication(portainer.UserID(userID))
 	if err != nil {
 		return httperror.InternalServerError("Unable to determine the authentication method", err)
@@ -110,7 +136,7 @@ func (handler *Handler) userCreateAccessToken(w http.ResponseWriter, r *http.Req
 		}
 	}
 
-	rawAPIKey, apiKey, err := handler.apiKeyService.GenerateApiKey(*user, payload.Description)
+	rawAPIKey, apiKey, err := handler.apiKeyService.GenerateScopedApiKey(*user, payload.Description, payload.Scope)
 	if err != nil {
 		return httperror.InternalServerError("Internal Server Error", err)
 	}
@@ -124,11 +150,16 @@ func (handler *Handler) usesInternalAuthentication(userid portainer.UserID) (boo
 		return true, nil
 	}
 
-	// otherwise determine the auth method from the settings
+	// otherwise determine the auth method from the user binding and the settings
 	settings, err := handler.DataStore.Settings().Settings()
 	if err != nil {
 		return false, fmt.Errorf("unable to retrieve the settings from the database: %w", err)
 	}
 
-	return settings.AuthenticationMethod == portainer.AuthenticationInternal, nil
+	user, err := handler.DataStore.User().Read(userid)
+	if err != nil {
+		return false, fmt.Errorf("unable to retrieve the user from the database: %w", err)
+	}
+
+	return settings.UserAuthenticationMethod(user) == portainer.AuthenticationInternal, nil
 }
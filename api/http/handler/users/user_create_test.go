@@ -21,6 +21,10 @@ func (m *mockPasswordStrengthChecker) Check(string) bool {
 	return true
 }
 
+func (m *mockPasswordStrengthChecker) Validate(string, string) error {
+	return nil
+}
+
 func TestConcurrentUserCreation(t *testing.T) {
 	_, store := datastore.MustNewTestStore(t, true, false)
 
@@ -6,6 +6,7 @@ import (
 
 	portainer "github.com/portainer/portainer/api"
 	"github.com/portainer/portainer/api/http/security"
+	"github.com/portainer/portainer/api/totp"
 	httperror "github.com/portainer/portainer/pkg/libhttp/error"
 	"github.com/portainer/portainer/pkg/libhttp/request"
 	"github.com/portainer/portainer/pkg/libhttp/response"
@@ -84,7 +85,12 @@ func (handler *Handler) deleteAdminUser(w http.ResponseWriter, user *portainer.U
 }
 
 func (handler *Handler) deleteUser(w http.ResponseWriter, user *portainer.User) *httperror.HandlerError {
-	err := handler.DataStore.User().Delete(user.ID)
+	memberships, err := handler.DataStore.TeamMembership().TeamMembershipsByUserID(user.ID)
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve user memberships from the database", err)
+	}
+
+	err = handler.DataStore.User().Delete(user.ID)
 	if err != nil {
 		return httperror.InternalServerError("Unable to remove user from the database", err)
 	}
@@ -94,6 +100,41 @@ func (handler *Handler) deleteUser(w http.ResponseWriter, user *portainer.User)
 		return httperror.InternalServerError("Unable to remove user memberships from the database", err)
 	}
 
+	if len(memberships) > 0 && handler.TeamAccessService != nil {
+		teamIDs := make([]portainer.TeamID, 0, len(memberships))
+		for _, membership := range memberships {
+			teamIDs = append(teamIDs, membership.TeamID)
+		}
+
+		go handler.TeamAccessService.SyncTeams(teamIDs...)
+	}
+
+	if err := totp.Disable(handler.DataStore, user.ID); err != nil {
+		return httperror.InternalServerError("Unable to remove the two-factor authentication of the user from the database", err)
+	}
+
+	credentials, err := handler.DataStore.WebAuthnCredential().CredentialsByUserID(user.ID)
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve the WebAuthn credentials of the user from the database", err)
+	}
+
+	for _, credential := range credentials {
+		if err := handler.DataStore.WebAuthnCredential().Delete(credential.ID); err != nil {
+			return httperror.InternalServerError("Unable to remove the WebAuthn credential from the database", err)
+		}
+	}
+
+	sessions, err := handler.DataStore.Session().SessionsByUserID(user.ID)
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve the sessions of the user from the database", err)
+	}
+
+	for _, session := range sessions {
+		if err := handler.DataStore.Session().Delete(session.ID); err != nil {
+			return httperror.InternalServerError("Unable to remove the session from the database", err)
+		}
+	}
+
 	// Remove all of the users persisted API keys
 	apiKeys, err := handler.apiKeyService.GetAPIKeys(user.ID)
 	if err != nil {
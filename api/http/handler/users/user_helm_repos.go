@@ -5,8 +5,10 @@ import (
 	"strings"
 
 	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/helmrepositories"
 	httperrors "github.com/portainer/portainer/api/http/errors"
 	"github.com/portainer/portainer/api/http/security"
+	"github.com/portainer/portainer/api/internal/authorization"
 	"github.com/portainer/portainer/pkg/libhelm"
 	httperror "github.com/portainer/portainer/pkg/libhttp/error"
 	"github.com/portainer/portainer/pkg/libhttp/request"
@@ -18,19 +20,58 @@ import (
 type helmUserRepositoryResponse struct {
 	GlobalRepository string                         `json:"GlobalRepository"`
 	UserRepositories []portainer.HelmUserRepository `json:"UserRepositories"`
+	// Repositories of the teams of the user, including the teams above them
+	TeamRepositories []portainer.HelmUserRepository `json:"TeamRepositories"`
 }
 
 type addHelmRepoUrlPayload struct {
 	URL string `json:"url"`
+	// Name of the repository shown with its charts
+	Name string `json:"name" example:"internal"`
+	// Authentication to a private repository with a username and a password
+	Authentication bool   `json:"authentication" example:"true"`
+	Username       string `json:"username" example:"charts"`
+	Password       string `json:"password" example:"secret"`
+	// TLS settings of the repository, the certificates and the key being PEM encoded
+	TLSSkipVerify bool   `json:"tlsSkipVerify" example:"false"`
+	TLSCACert     string `json:"tlsCACert"`
+	TLSCert       string `json:"tlsCert"`
+	TLSKey        string `json:"tlsKey"`
 }
 
 func (p *addHelmRepoUrlPayload) Validate(_ *http.Request) error {
-	return libhelm.ValidateHelmRepositoryURL(p.URL, nil)
+	if p.URL == "" {
+		return libhelm.ValidateHelmRepositoryURL(p.URL, nil)
+	}
+
+	repository := p.repository()
+
+	return helmrepositories.Validate(&repository)
+}
+
+func (p *addHelmRepoUrlPayload) repository() portainer.HelmUserRepository {
+	repository := portainer.HelmUserRepository{
+		Name:           p.Name,
+		URL:            helmrepositories.NormalizeURL(p.URL),
+		Authentication: p.Authentication,
+		TLSSkipVerify:  p.TLSSkipVerify,
+		TLSCACert:      p.TLSCACert,
+		TLSCert:        p.TLSCert,
+		TLSKey:         p.TLSKey,
+	}
+
+	if p.Authentication {
+		repository.Username = p.Username
+		repository.Password = p.Password
+	}
+
+	return repository
 }
 
 // @id HelmUserRepositoryCreate
 // @summary Create a user helm repository
-// @description Create a user helm repository.
+// @description Create a user helm repository, the private repositories being reached with a username and a password
+// @description or with a TLS client certificate. The password and the TLS key are never returned.
 // @description **Access policy**: authenticated
 // @tags helm
 // @security ApiKeyAuth
@@ -66,8 +107,8 @@ func (handler *Handler) userCreateHelmRepo(w http.ResponseWriter, r *http.Reques
 		return httperror.BadRequest("Invalid Helm repository URL", err)
 	}
 
-	// lowercase, remove trailing slash
-	p.URL = strings.TrimSuffix(strings.ToLower(p.URL), "/")
+	record := p.repository()
+	record.UserID = userID
 
 	records, err := handler.DataStore.HelmUserRepository().HelmUserRepositoryByUserID(userID)
 	if err != nil {
@@ -75,23 +116,20 @@ func (handler *Handler) userCreateHelmRepo(w http.ResponseWriter, r *http.Reques
 	}
 
 	// check if repo already exists - by doing case insensitive comparison
-	for _, record := range records {
-		if strings.EqualFold(record.URL, p.URL) {
+	for _, existing := range records {
+		if strings.EqualFold(existing.URL, record.URL) {
 			errMsg := "Helm repo already registered for user"
 			return httperror.BadRequest(errMsg, errors.New(errMsg))
 		}
 	}
 
-	record := portainer.HelmUserRepository{
-		UserID: userID,
-		URL:    p.URL,
-	}
-
 	err = handler.DataStore.HelmUserRepository().Create(&record)
 	if err != nil {
 		return httperror.InternalServerError("Unable to save a user Helm repository URL", err)
 	}
 
+	helmrepositories.HideSecrets(&record)
+
 	return response.JSON(w, record)
 }
 
@@ -135,9 +173,39 @@ func (handler *Handler) userGetHelmRepos(w http.ResponseWriter, r *http.Request)
 		return httperror.InternalServerError("Unable to get user Helm repositories", err)
 	}
 
+	memberships, err := authorization.EffectiveTeamMemberships(handler.DataStore, userID)
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve the team memberships of the user", err)
+	}
+
+	teamRepos := []portainer.HelmUserRepository{}
+	teamIDs := map[portainer.TeamID]bool{}
+	for _, membership := range memberships {
+		if teamIDs[membership.TeamID] {
+			continue
+		}
+		teamIDs[membership.TeamID] = true
+
+		repos, err := handler.DataStore.HelmUserRepository().HelmUserRepositoryByTeamID(membership.TeamID)
+		if err != nil {
+			return httperror.InternalServerError("Unable to get team Helm repositories", err)
+		}
+
+		teamRepos = append(teamRepos, repos...)
+	}
+
+	for i := range userRepos {
+		helmrepositories.HideSecrets(&userRepos[i])
+	}
+
+	for i := range teamRepos {
+		helmrepositories.HideSecrets(&teamRepos[i])
+	}
+
 	resp := helmUserRepositoryResponse{
 		GlobalRepository: settings.HelmRepositoryURL,
 		UserRepositories: userRepos,
+		TeamRepositories: teamRepos,
 	}
 
 	return response.JSON(w, resp)
@@ -0,0 +1,128 @@
+package users
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+	httperrors "github.com/portainer/portainer/api/http/errors"
+	"github.com/portainer/portainer/api/http/security"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/request"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	defaultImpersonationDuration = 15 * time.Minute
+	maxImpersonationDuration     = time.Hour
+)
+
+var errImpersonationNotAllowed = errors.New("This operation is not allowed while impersonating a user")
+
+type userImpersonatePayload struct {
+	// Duration of the impersonation, up to 1h. Defaults to 15m
+	Duration string `example:"15m"`
+}
+
+func (payload *userImpersonatePayload) Validate(r *http.Request) error {
+	if payload.Duration == "" {
+		return nil
+	}
+
+	duration, err := time.ParseDuration(payload.Duration)
+	if err != nil || duration <= 0 || duration > maxImpersonationDuration {
+		return errors.New("Invalid duration. Must be a duration like 15m, up to 1h")
+	}
+
+	return nil
+}
+
+type userImpersonateResponse struct {
+	// JWT token authenticating as the impersonated user
+	JWT string `json:"jwt" example:"abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"`
+	// Unix timestamp of the expiry of the token
+	ExpiresAt int64 `json:"ExpiresAt" example:"1700000900"`
+}
+
+// @id UserImpersonate
+// @summary Impersonate a user
+// @description Generates a short-lived token letting an administrator act as a non-administrator user, to reproduce
+// @description what the user sees. The session opened by the token is listed in the sessions of the user with the
+// @description identifier of the administrator, and the calls made with it are recorded in the audit logs.
+// @description The token cannot be used to manage the credentials of the user.
+// @description **Access policy**: administrator
+// @tags users
+// @security ApiKeyAuth
+// @security jwt
+// @accept json
+// @produce json
+// @param id path int true "User identifier"
+// @param body body userImpersonatePayload false "Impersonation details"
+// @success 200 {object} userImpersonateResponse "Success"
+// @failure 400 "Invalid request"
+// @failure 403 "Permission denied"
+// @failure 404 "User not found"
+// @failure 500 "Server error"
+// @router /users/{id}/impersonate [post]
+func (handler *Handler) userImpersonate(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	userID, err := request.RetrieveNumericRouteVariableValue(r, "id")
+	if err != nil {
+		return httperror.BadRequest("Invalid user identifier route variable", err)
+	}
+
+	var payload userImpersonatePayload
+	if r.ContentLength != 0 {
+		if err := request.DecodeAndValidateJSONPayload(r, &payload); err != nil {
+			return httperror.BadRequest("Invalid request payload", err)
+		}
+	}
+
+	tokenData, err := security.RetrieveTokenData(r)
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve user authentication token", err)
+	}
+
+	user, err := handler.DataStore.User().Read(portainer.UserID(userID))
+	if handler.DataStore.IsErrObjectNotFound(err) {
+		return httperror.NotFound("Unable to find a user with the specified identifier inside the database", err)
+	} else if err != nil {
+		return httperror.InternalServerError("Unable to find a user with the specified identifier inside the database", err)
+	}
+
+	if user.ID == tokenData.ID || user.Role == portainer.AdministratorRole {
+		return httperror.Forbidden("Only the non-administrator users can be impersonated", httperrors.ErrUnauthorized)
+	}
+
+	if user.ServiceAccount {
+		return httperror.Forbidden("Service accounts cannot be impersonated", httperrors.ErrUnauthorized)
+	}
+
+	duration := defaultImpersonationDuration
+	if payload.Duration != "" {
+		duration, _ = time.ParseDuration(payload.Duration)
+	}
+
+	token, expiresAt, err := handler.JWTService.GenerateImpersonationToken(&portainer.TokenData{
+		ID:             user.ID,
+		Username:       user.Username,
+		Role:           user.Role,
+		ImpersonatorID: tokenData.ID,
+	}, duration, security.StripAddrPort(r.RemoteAddr), r.UserAgent())
+	if err != nil {
+		return httperror.InternalServerError("Unable to generate the impersonation token", err)
+	}
+
+	log.Info().
+		Str("event", "user_impersonation").
+		Int("impersonator_id", int(tokenData.ID)).
+		Str("impersonator", tokenData.Username).
+		Int("user_id", int(user.ID)).
+		Str("user", user.Username).
+		Time("expires_at", expiresAt).
+		Msg("administrator started impersonating a user")
+
+	return response.JSON(w, userImpersonateResponse{JWT: token, ExpiresAt: expiresAt.Unix()})
+}
@@ -0,0 +1,381 @@
+package users
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/request"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+)
+
+const (
+	// maxImportRows is the number of users above which an import is rejected
+	maxImportRows = 5000
+	// maxImportSize is the size above which an import payload is rejected
+	maxImportSize = 10 * 1024 * 1024
+)
+
+// errImportRolledBack rolls the import transaction back when the import is a dry-run or has an invalid row
+var errImportRolledBack = errors.New("import rolled back")
+
+type userImportRow struct {
+	Username string `example:"bob"`
+	Password string `example:"cg9Wgky3"`
+	// User role (1 for administrator account and 2 for regular account)
+	Role int `enums:"1,2" example:"2"`
+	// Authentication method the user is bound to (1 for internal, 2 for LDAP, 3 for OAuth or 4 for SAML)
+	AuthenticationMethod int `enums:"0,1,2,3,4" example:"1"`
+	// Names of the teams the user is a member of, the missing teams are created
+	Teams []string `example:"developers"`
+	// Names of the teams the user leads, the missing teams are created
+	LeaderOf []string `example:"developers"`
+}
+
+type userImportPayload struct {
+	// Users to create
+	Users []userImportRow
+	// Names of the teams to create, in addition to the teams of the users
+	Teams []string `example:"operators"`
+}
+
+func (payload *userImportPayload) Validate(r *http.Request) error {
+	if len(payload.Users) == 0 && len(payload.Teams) == 0 {
+		return errors.New("Nothing to import")
+	}
+
+	if len(payload.Users) > maxImportRows {
+		return fmt.Errorf("Too many users. At most %d users can be imported at once", maxImportRows)
+	}
+
+	return nil
+}
+
+type userImportRowResult struct {
+	// Row of the user in the payload, starting at 1 and not counting the CSV header
+	Row      int    `json:"Row" example:"1"`
+	Username string `json:"Username" example:"bob"`
+	// Identifier of the created user, 0 for a dry-run or when the import failed
+	UserID portainer.UserID `json:"UserId,omitempty" example:"3"`
+	Error  string           `json:"Error,omitempty" example:"Another user with the same username already exists"`
+}
+
+type userImportResponse struct {
+	DryRun bool                  `json:"DryRun" example:"false"`
+	Users  []userImportRowResult `json:"Users"`
+	// Names of the teams the import creates
+	CreatedTeams []string `json:"CreatedTeams" example:"developers"`
+	// Number of invalid rows, nothing is created when there is any
+	Errors int `json:"Errors" example:"0"`
+}
+
+// @id UserImport
+// @summary Import users, teams and memberships
+// @description Create many users, their teams and their memberships in a single transaction: either every row is
+// @description imported or none is. The report lists the error of every invalid row.
+// @description The payload is either JSON or CSV (Content-Type: text/csv) with the header
+// @description username,password,role,authentication_method,teams,leader_of where role is admin or user (or 1 or 2),
+// @description and teams and leader_of are lists of team names separated by semicolons.
+// @description **Access policy**: administrator
+// @tags users
+// @security ApiKeyAuth
+// @security jwt
+// @accept json,text/csv
+// @produce json
+// @param dryRun query bool false "Validate the import without creating anything"
+// @param body body userImportPayload true "Users and teams to import"
+// @success 200 {object} userImportResponse "Success"
+// @failure 400 {object} userImportResponse "Invalid request or rows"
+// @failure 500 "Server error"
+// @router /users/import [post]
+func (handler *Handler) userImport(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	dryRun, _ := request.RetrieveBooleanQueryParameter(r, "dryRun", true)
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxImportSize)
+
+	payload, err := decodeImportPayload(r)
+	if err != nil {
+		return httperror.BadRequest("Invalid request payload", err)
+	}
+
+	settings, err := handler.DataStore.Settings().Settings()
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve settings from the database", err)
+	}
+
+	resp := &userImportResponse{DryRun: dryRun, Users: []userImportRowResult{}, CreatedTeams: []string{}}
+	users := make([]*portainer.User, len(payload.Users))
+
+	// the users are validated and their passwords hashed outside of the transaction, hashing being slow
+	for i, row := range payload.Users {
+		resp.Users = append(resp.Users, userImportRowResult{Row: i + 1, Username: row.Username})
+
+		users[i], err = handler.importedUser(settings, row)
+		if err == nil && !dryRun {
+			err = handler.hashUserPassword(users[i])
+		}
+
+		resp.setError(i, err)
+	}
+
+	err = handler.DataStore.UpdateTx(func(tx dataservices.DataStoreTx) error {
+		if err := importUsers(tx, payload, users, resp); err != nil {
+			return err
+		}
+
+		if dryRun || resp.Errors > 0 {
+			return errImportRolledBack
+		}
+
+		return nil
+	})
+	if err != nil && !errors.Is(err, errImportRolledBack) {
+		return httperror.InternalServerError("Unable to import the users", err)
+	}
+
+	if resp.Errors > 0 || dryRun {
+		// nothing was created
+		for i := range resp.Users {
+			resp.Users[i].UserID = 0
+		}
+	}
+
+	if resp.Errors > 0 {
+		return response.JSONWithStatus(w, resp, http.StatusBadRequest)
+	}
+
+	return response.JSON(w, resp)
+}
+
+func (handler *Handler) importedUser(settings *portainer.Settings, row userImportRow) (*portainer.User, error) {
+	payload := userCreatePayload{
+		Username:             row.Username,
+		Password:             row.Password,
+		Role:                 row.Role,
+		AuthenticationMethod: row.AuthenticationMethod,
+	}
+
+	if err := payload.Validate(nil); err != nil {
+		return nil, err
+	}
+
+	return handler.newUser(settings, payload)
+}
+
+// importUsers creates the valid users of an import, the missing teams and the memberships. The rows failing are
+// reported in resp
+func importUsers(tx dataservices.DataStoreTx, payload *userImportPayload, users []*portainer.User, resp *userImportResponse) error {
+	teams := make(map[string]*portainer.Team)
+
+	team := func(name string) (*portainer.Team, error) {
+		if team, ok := teams[name]; ok {
+			return team, nil
+		}
+
+		team, err := tx.Team().TeamByName(name)
+		if tx.IsErrObjectNotFound(err) {
+			team = &portainer.Team{Name: name}
+			if err := tx.Team().Create(team); err != nil {
+				return nil, err
+			}
+
+			resp.CreatedTeams = append(resp.CreatedTeams, name)
+		} else if err != nil {
+			return nil, err
+		}
+
+		teams[name] = team
+
+		return team, nil
+	}
+
+	for _, name := range payload.Teams {
+		if name = strings.TrimSpace(name); name != "" {
+			if _, err := team(name); err != nil {
+				return err
+			}
+		}
+	}
+
+	usernames := make(map[string]int)
+
+	for i, row := range payload.Users {
+		if users[i] == nil {
+			continue
+		}
+
+		if previous, ok := usernames[strings.ToLower(row.Username)]; ok {
+			resp.setError(i, fmt.Errorf("The username is already used by the row %d", previous))
+
+			continue
+		}
+
+		usernames[strings.ToLower(row.Username)] = i + 1
+
+		existing, err := tx.User().UserByUsername(row.Username)
+		if err != nil && !tx.IsErrObjectNotFound(err) {
+			return err
+		}
+
+		if existing != nil {
+			resp.setError(i, errors.New("Another user with the same username already exists"))
+
+			continue
+		}
+
+		if err := tx.User().Create(users[i]); err != nil {
+			return err
+		}
+
+		resp.Users[i].UserID = users[i].ID
+
+		memberships := make(map[string]portainer.MembershipRole)
+		for _, name := range row.Teams {
+			memberships[strings.TrimSpace(name)] = portainer.TeamMember
+		}
+
+		for _, name := range row.LeaderOf {
+			memberships[strings.TrimSpace(name)] = portainer.TeamLeader
+		}
+
+		for name, role := range memberships {
+			if name == "" {
+				continue
+			}
+
+			team, err := team(name)
+			if err != nil {
+				return err
+			}
+
+			if err := tx.TeamMembership().Create(&portainer.TeamMembership{UserID: users[i].ID, TeamID: team.ID, Role: role}); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (resp *userImportResponse) setError(i int, err error) {
+	if err == nil {
+		return
+	}
+
+	var httpErr *httperror.HandlerError
+	if errors.As(err, &httpErr) {
+		err = httpErr.Err
+		if httpErr.Message != "" {
+			err = fmt.Errorf("%s: %w", httpErr.Message, httpErr.Err)
+		}
+	}
+
+	resp.Users[i].Error = err.Error()
+	resp.Errors++
+}
+
+// decodeImportPayload decodes a JSON or CSV import payload
+func decodeImportPayload(r *http.Request) (*userImportPayload, error) {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType != "text/csv" {
+		var payload userImportPayload
+		if err := request.DecodeAndValidateJSONPayload(r, &payload); err != nil {
+			return nil, err
+		}
+
+		return &payload, nil
+	}
+
+	payload, err := parseImportCSV(r.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := payload.Validate(r); err != nil {
+		return nil, err
+	}
+
+	return payload, nil
+}
+
+// parseImportCSV parses the users of a CSV import, the columns being identified by the header
+func parseImportCSV(body io.Reader) (*userImportPayload, error) {
+	reader := csv.NewReader(body)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("unable to read the CSV header: %w", err)
+	}
+
+	columns := make(map[string]int)
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+
+	if _, ok := columns["username"]; !ok {
+		return nil, errors.New("the CSV header must have a username column")
+	}
+
+	payload := &userImportPayload{}
+
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+
+		field := func(name string) string {
+			if i, ok := columns[name]; ok && i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+
+			return ""
+		}
+
+		row := userImportRow{
+			Username: field("username"),
+			Password: field("password"),
+			Role:     int(portainer.StandardUserRole),
+			Teams:    splitTeams(field("teams")),
+			LeaderOf: splitTeams(field("leader_of")),
+		}
+
+		switch role := strings.ToLower(field("role")); role {
+		case "", "user", "2":
+		case "admin", "administrator", "1":
+			row.Role = int(portainer.AdministratorRole)
+		default:
+			return nil, fmt.Errorf("invalid role %q on line %d, must be admin or user", role, line)
+		}
+
+		if method := field("authentication_method"); method != "" {
+			if row.AuthenticationMethod, err = strconv.Atoi(method); err != nil {
+				return nil, fmt.Errorf("invalid authentication method %q on line %d", method, line)
+			}
+		}
+
+		payload.Users = append(payload.Users, row)
+	}
+
+	return payload, nil
+}
+
+func splitTeams(value string) []string {
+	if value == "" {
+		return nil
+	}
+
+	return strings.Split(value, ";")
+}
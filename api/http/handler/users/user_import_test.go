@@ -0,0 +1,101 @@
+package users
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/datastore"
+	"github.com/portainer/portainer/api/internal/testhelpers"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestUserImport(t *testing.T) {
+	is := require.New(t)
+
+	_, store := datastore.MustNewTestStore(t, true, false)
+
+	is.NoError(store.User().Create(&portainer.User{ID: 1, Username: "admin", Role: portainer.AdministratorRole}))
+	is.NoError(store.Team().Create(&portainer.Team{ID: 1, Name: "developers"}))
+
+	h := &Handler{
+		passwordStrengthChecker: &mockPasswordStrengthChecker{},
+		CryptoService:           testhelpers.NewCryptoService(),
+		DataStore:               store,
+	}
+
+	importCSV := func(query, body string) (int, userImportResponse) {
+		req := httptest.NewRequest(http.MethodPost, "/users/import"+query, strings.NewReader(body))
+		req.Header.Set("Content-Type", "text/csv")
+
+		rr := httptest.NewRecorder()
+		httpErr := h.userImport(rr, req)
+		is.Nil(httpErr)
+
+		var resp userImportResponse
+		is.NoError(json.NewDecoder(rr.Body).Decode(&resp))
+
+		return rr.Code, resp
+	}
+
+	csv := "username,password,role,teams,leader_of\n" +
+		"alice,secret,user,developers;operators,\n" +
+		"bob,secret,admin,,developers\n"
+
+	t.Run("dry-run creates nothing", func(t *testing.T) {
+		status, resp := importCSV("?dryRun=true", csv)
+		is.Equal(http.StatusOK, status)
+		is.True(resp.DryRun)
+		is.Zero(resp.Errors)
+		is.Equal([]string{"operators"}, resp.CreatedTeams)
+
+		users, err := store.User().ReadAll()
+		is.NoError(err)
+		is.Len(users, 1)
+	})
+
+	t.Run("invalid rows roll the import back", func(t *testing.T) {
+		status, resp := importCSV("", csv+"admin,secret,user,,\n"+"carol,secret,user,,\n"+"Carol,secret,user,,\n")
+		is.Equal(http.StatusBadRequest, status)
+		is.Equal(2, resp.Errors)
+		is.NotEmpty(resp.Users[2].Error, "the username is already taken")
+		is.Empty(resp.Users[3].Error)
+		is.Contains(resp.Users[4].Error, "row 4")
+
+		for _, user := range resp.Users {
+			is.Zero(user.UserID)
+		}
+
+		teams, err := store.Team().ReadAll()
+		is.NoError(err)
+		is.Len(teams, 1)
+	})
+
+	t.Run("valid rows are imported", func(t *testing.T) {
+		status, resp := importCSV("", csv)
+		is.Equal(http.StatusOK, status)
+		is.Zero(resp.Errors)
+
+		bob, err := store.User().UserByUsername("bob")
+		is.NoError(err)
+		is.Equal(portainer.AdministratorRole, bob.Role)
+		is.Equal(portainer.AuthenticationInternal, bob.AuthenticationMethod)
+		is.NotEqual("secret", bob.Password)
+
+		memberships, err := store.TeamMembership().TeamMembershipsByUserID(bob.ID)
+		is.NoError(err)
+		is.Len(memberships, 1)
+		is.Equal(portainer.TeamLeader, memberships[0].Role)
+
+		alice, err := store.User().UserByUsername("alice")
+		is.NoError(err)
+
+		memberships, err = store.TeamMembership().TeamMembershipsByUserID(alice.ID)
+		is.NoError(err)
+		is.Len(memberships, 2)
+	})
+}
@@ -268,4 +268,52 @@ func Test_userList(t *testing.T) {
 			is.Equal(userUnderTeamWithEndpointAccess.ID, resp[0].ID)
 		}
 	})
+
+	// Case 5: the user is under a child team and the parent team is given the endpoint access
+	//         the user inherits the endpoint access from the parent team
+	parentTeam := &portainer.Team{ID: 3, Name: "parent-team-with-endpoint-access"}
+	err = store.Team().Create(parentTeam)
+	is.NoError(err, "error creating team")
+
+	childTeam := &portainer.Team{ID: 4, Name: "child-team", ParentID: parentTeam.ID}
+	err = store.Team().Create(childTeam)
+	is.NoError(err, "error creating team")
+
+	userUnderChildTeam := &portainer.User{ID: 6, Username: "standard-user-under-child-team", Role: portainer.StandardUserRole, PortainerAuthorizations: authorization.DefaultPortainerAuthorizations()}
+	err = store.User().Create(userUnderChildTeam)
+	is.NoError(err, "error creating user")
+
+	childTeamMembership := &portainer.TeamMembership{ID: 3, UserID: userUnderChildTeam.ID, TeamID: childTeam.ID}
+	err = store.TeamMembership().Create(childTeamMembership)
+	is.NoError(err, "error creating team membership")
+
+	parentTeamAccessPolicies := make(portainer.TeamAccessPolicies, 0)
+	parentTeamAccessPolicies[parentTeam.ID] = portainer.AccessPolicy{RoleID: portainer.RoleID(userUnderChildTeam.Role)}
+
+	endpointWithParentTeamAccessPolicy := &portainer.Endpoint{ID: 5, TeamAccessPolicies: parentTeamAccessPolicies, GroupID: endpointGroupWithoutTeam.ID}
+	err = store.Endpoint().Create(endpointWithParentTeamAccessPolicy)
+	is.NoError(err, "error creating endpoint")
+	t.Run("admin user can list users who inherit endpoint access from a parent team", func(t *testing.T) {
+		params := url.Values{}
+		params.Add("environmentId", fmt.Sprintf("%d", endpointWithParentTeamAccessPolicy.ID))
+		req := httptest.NewRequest(http.MethodGet, "/users?"+params.Encode(), nil)
+		testhelpers.AddTestSecurityCookie(req, adminJWT)
+
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+
+		is.Equal(http.StatusOK, rr.Code)
+
+		body, err := io.ReadAll(rr.Body)
+		is.NoError(err, "ReadAll should not return error")
+
+		var resp []portainer.User
+		err = json.Unmarshal(body, &resp)
+		is.NoError(err, "response should be list json")
+
+		is.Len(resp, 1)
+		if len(resp) == 1 {
+			is.Equal(userUnderChildTeam.ID, resp[0].ID)
+		}
+	})
 }
@@ -0,0 +1,193 @@
+package users
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/request"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+)
+
+var errServiceAccountPassword = errors.New("Service accounts have no password, they are authenticated by their API keys")
+
+type serviceAccountCreatePayload struct {
+	Username string `validate:"required" example:"ci-deployer"`
+	// User role (1 for administrator account and 2 for regular account)
+	Role int `validate:"required" enums:"1,2" example:"2"`
+	// Restrict the API keys of the service account to EndpointIDs, implied by a non empty list
+	Bound bool `example:"true"`
+	// Environments the API keys of a bound service account are restricted to, none when empty
+	EndpointIDs []portainer.EndpointID `example:"1"`
+}
+
+func (payload *serviceAccountCreatePayload) Validate(r *http.Request) error {
+	if len(payload.Username) == 0 || strings.Contains(payload.Username, " ") {
+		return errors.New("Invalid username. Must not contain any whitespace")
+	}
+
+	if payload.Role != 1 && payload.Role != 2 {
+		return errors.New("Invalid role value. Value must be one of: 1 (administrator) or 2 (regular user)")
+	}
+
+	return nil
+}
+
+type serviceAccountUpdatePayload struct {
+	// Restrict the API keys of the service account to EndpointIDs, implied by a non empty list
+	Bound bool `example:"true"`
+	// Environments the API keys of a bound service account are restricted to, none when empty
+	EndpointIDs []portainer.EndpointID `example:"1"`
+}
+
+func (payload *serviceAccountUpdatePayload) Validate(r *http.Request) error {
+	return nil
+}
+
+// @id UserServiceAccountCreate
+// @summary Create a service account
+// @description Create a non-interactive user for automation. A service account has no password and cannot log in,
+// @description it is only authenticated by the API keys the administrators generate for it with POST /users/{id}/tokens.
+// @description Its API keys can be restricted to some environments.
+// @description **Access policy**: administrator
+// @tags users
+// @security ApiKeyAuth
+// @security jwt
+// @accept json
+// @produce json
+// @param body body serviceAccountCreatePayload true "Service account details"
+// @success 200 {object} portainer.User "Success"
+// @failure 400 "Invalid request"
+// @failure 404 "Environment not found"
+// @failure 409 "User already exists"
+// @failure 500 "Server error"
+// @router /users/service_accounts [post]
+func (handler *Handler) userServiceAccountCreate(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	var payload serviceAccountCreatePayload
+	if err := request.DecodeAndValidateJSONPayload(r, &payload); err != nil {
+		return httperror.BadRequest("Invalid request payload", err)
+	}
+
+	user := &portainer.User{
+		Username:                  payload.Username,
+		Role:                      portainer.UserRole(payload.Role),
+		ServiceAccount:            true,
+		ServiceAccountBound:       payload.Bound || len(payload.EndpointIDs) > 0,
+		ServiceAccountEndpointIDs: payload.EndpointIDs,
+	}
+
+	err := handler.DataStore.UpdateTx(func(tx dataservices.DataStoreTx) error {
+		if sameNameUser, err := tx.User().UserByUsername(payload.Username); err != nil && !tx.IsErrObjectNotFound(err) {
+			return httperror.InternalServerError("Unable to retrieve users from the database", err)
+		} else if sameNameUser != nil {
+			return httperror.Conflict("Another user with the same username already exists", errUserAlreadyExists)
+		}
+
+		if err := checkEndpointsExist(tx, payload.EndpointIDs); err != nil {
+			return err
+		}
+
+		if err := tx.User().Create(user); err != nil {
+			return httperror.InternalServerError("Unable to persist user inside the database", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		var httpErr *httperror.HandlerError
+		if errors.As(err, &httpErr) {
+			return httpErr
+		}
+
+		return httperror.InternalServerError("Unexpected error", err)
+	}
+
+	return response.JSON(w, user)
+}
+
+// @id UserServiceAccountUpdate
+// @summary Update the environments of a service account
+// @description Restrict the API keys of a service account to some environments, or to none of them when bound with an empty list.
+// @description The restriction is lifted when it is unbound with an empty list.
+// @description **Access policy**: administrator
+// @tags users
+// @security ApiKeyAuth
+// @security jwt
+// @accept json
+// @produce json
+// @param id path int true "User identifier"
+// @param body body serviceAccountUpdatePayload true "Service account details"
+// @success 200 {object} portainer.User "Success"
+// @failure 400 "Invalid request or the user is not a service account"
+// @failure 404 "User or environment not found"
+// @failure 500 "Server error"
+// @router /users/{id}/service_account [put]
+func (handler *Handler) userServiceAccountUpdate(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	userID, err := request.RetrieveNumericRouteVariableValue(r, "id")
+	if err != nil {
+		return httperror.BadRequest("Invalid user identifier route variable", err)
+	}
+
+	var payload serviceAccountUpdatePayload
+	if err := request.DecodeAndValidateJSONPayload(r, &payload); err != nil {
+		return httperror.BadRequest("Invalid request payload", err)
+	}
+
+	var user *portainer.User
+
+	err = handler.DataStore.UpdateTx(func(tx dataservices.DataStoreTx) error {
+		user, err = tx.User().Read(portainer.UserID(userID))
+		if tx.IsErrObjectNotFound(err) {
+			return httperror.NotFound("Unable to find a user with the specified identifier inside the database", err)
+		} else if err != nil {
+			return httperror.InternalServerError("Unable to find a user with the specified identifier inside the database", err)
+		}
+
+		if !user.ServiceAccount {
+			return httperror.BadRequest("The user is not a service account", errors.New("only the environments of the service accounts can be restricted"))
+		}
+
+		if err := checkEndpointsExist(tx, payload.EndpointIDs); err != nil {
+			return err
+		}
+
+		user.ServiceAccountBound = payload.Bound || len(payload.EndpointIDs) > 0
+		user.ServiceAccountEndpointIDs = payload.EndpointIDs
+
+		if err := tx.User().Update(user.ID, user); err != nil {
+			return httperror.InternalServerError("Unable to persist user changes inside the database", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		var httpErr *httperror.HandlerError
+		if errors.As(err, &httpErr) {
+			return httpErr
+		}
+
+		return httperror.InternalServerError("Unexpected error", err)
+	}
+
+	// the API keys cache holds the previous environments of the service account
+	handler.apiKeyService.InvalidateUserKeyCache(user.ID)
+
+	hideFields(user)
+
+	return response.JSON(w, user)
+}
+
+func checkEndpointsExist(tx dataservices.DataStoreTx, endpointIDs []portainer.EndpointID) error {
+	for _, endpointID := range endpointIDs {
+		if _, err := tx.Endpoint().Endpoint(endpointID); tx.IsErrObjectNotFound(err) {
+			return httperror.NotFound("Unable to find an environment with the specified identifier inside the database", err)
+		} else if err != nil {
+			return httperror.InternalServerError("Unable to find an environment with the specified identifier inside the database", err)
+		}
+	}
+
+	return nil
+}
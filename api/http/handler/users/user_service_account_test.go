@@ -0,0 +1,119 @@
+package users
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/apikey"
+	"github.com/portainer/portainer/api/datastore"
+	"github.com/portainer/portainer/api/http/security"
+	"github.com/portainer/portainer/api/internal/testhelpers"
+	"github.com/portainer/portainer/api/jwt"
+
+	"github.com/segmentio/encoding/json"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_userServiceAccount(t *testing.T) {
+	is := require.New(t)
+
+	_, store := datastore.MustNewTestStore(t, true, true)
+
+	adminUser := &portainer.User{ID: 1, Password: "password", Username: "admin", Role: portainer.AdministratorRole}
+	is.NoError(store.User().Create(adminUser))
+
+	user := &portainer.User{ID: 2, Username: "standard", Role: portainer.StandardUserRole}
+	is.NoError(store.User().Create(user))
+
+	is.NoError(store.Endpoint().Create(&portainer.Endpoint{ID: 1, Name: "production"}))
+
+	jwtService, err := jwt.NewService("1h", store)
+	is.NoError(err)
+	apiKeyService := apikey.NewAPIKeyService(store.APIKeyRepository(), store.User())
+	requestBouncer := security.NewRequestBouncer(store, jwtService, apiKeyService)
+	rateLimiter := security.NewRateLimiter(10, 1*time.Second, 1*time.Hour)
+	passwordChecker := security.NewPasswordStrengthChecker(store.SettingsService)
+
+	h := NewHandler(requestBouncer, rateLimiter, apiKeyService, passwordChecker)
+	h.DataStore = store
+	h.CryptoService = testhelpers.NewCryptoService()
+
+	adminJWT, _, _ := jwtService.GenerateToken(&portainer.TokenData{ID: adminUser.ID, Username: adminUser.Username, Role: adminUser.Role})
+	userJWT, _, _ := jwtService.GenerateToken(&portainer.TokenData{ID: user.ID, Username: user.Username, Role: user.Role})
+
+	do := func(method, url, token string, payload any) *httptest.ResponseRecorder {
+		body, err := json.Marshal(payload)
+		is.NoError(err)
+
+		req := httptest.NewRequest(method, url, bytes.NewBuffer(body))
+		testhelpers.AddTestSecurityCookie(req, token)
+
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+
+		return rr
+	}
+
+	rr := do(http.MethodPost, "/users/service_accounts", adminJWT, serviceAccountCreatePayload{Username: "ci", Role: 2, EndpointIDs: []portainer.EndpointID{1}})
+	is.Equal(http.StatusOK, rr.Code)
+
+	var serviceAccount portainer.User
+	is.NoError(json.NewDecoder(rr.Body).Decode(&serviceAccount))
+	is.True(serviceAccount.ServiceAccount)
+	is.True(serviceAccount.ServiceAccountBound)
+	is.Equal([]portainer.EndpointID{1}, serviceAccount.ServiceAccountEndpointIDs)
+
+	rr = do(http.MethodPost, "/users/service_accounts", adminJWT, serviceAccountCreatePayload{Username: "ci", Role: 2})
+	is.Equal(http.StatusConflict, rr.Code)
+
+	rr = do(http.MethodPost, "/users/service_accounts", adminJWT, serviceAccountCreatePayload{Username: "other", Role: 2, EndpointIDs: []portainer.EndpointID{2}})
+	is.Equal(http.StatusNotFound, rr.Code)
+
+	rr = do(http.MethodPost, "/users/service_accounts", userJWT, serviceAccountCreatePayload{Username: "other", Role: 2})
+	is.Equal(http.StatusForbidden, rr.Code)
+
+	tokensURL := "/users/" + strconv.Itoa(int(serviceAccount.ID)) + "/tokens"
+
+	// the administrators generate the API keys of the service accounts without any password
+	rr = do(http.MethodPost, tokensURL, adminJWT, userAccessTokenCreatePayload{Description: "ci-token"})
+	is.Equal(http.StatusCreated, rr.Code)
+
+	var resp accessTokenResponse
+	is.NoError(json.NewDecoder(rr.Body).Decode(&resp))
+	is.NotEmpty(resp.RawAPIKey)
+
+	rr = do(http.MethodPost, tokensURL, userJWT, userAccessTokenCreatePayload{Description: "ci-token"})
+	is.Equal(http.StatusForbidden, rr.Code)
+
+	_, apiKey, err := apiKeyService.GetDigestUserAndKey(apiKeyService.HashRaw(resp.RawAPIKey))
+	is.NoError(err)
+	is.Equal(serviceAccount.ID, apiKey.UserID)
+
+	rr = do(http.MethodPut, "/users/"+strconv.Itoa(int(serviceAccount.ID))+"/service_account", adminJWT, serviceAccountUpdatePayload{})
+	is.Equal(http.StatusOK, rr.Code)
+
+	updated, err := store.User().Read(serviceAccount.ID)
+	is.NoError(err)
+	is.False(updated.ServiceAccountBound)
+	is.Empty(updated.ServiceAccountEndpointIDs)
+
+	// bound with an empty list, the API keys of the service account have access to no environment
+	rr = do(http.MethodPut, "/users/"+strconv.Itoa(int(serviceAccount.ID))+"/service_account", adminJWT, serviceAccountUpdatePayload{Bound: true})
+	is.Equal(http.StatusOK, rr.Code)
+
+	updated, err = store.User().Read(serviceAccount.ID)
+	is.NoError(err)
+	is.True(updated.ServiceAccountBound)
+	is.Empty(updated.ServiceAccountEndpointIDs)
+
+	rr = do(http.MethodPut, "/users/2/service_account", adminJWT, serviceAccountUpdatePayload{})
+	is.Equal(http.StatusBadRequest, rr.Code)
+
+	rr = do(http.MethodPut, "/users/"+strconv.Itoa(int(serviceAccount.ID))+"/passwd", adminJWT, userUpdatePasswordPayload{Password: "password", NewPassword: "new-password"})
+	is.Equal(http.StatusBadRequest, rr.Code)
+}
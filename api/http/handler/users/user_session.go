@@ -0,0 +1,120 @@
+package users
+
+import (
+	"net/http"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/http/security"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/request"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+)
+
+type userSessionResponse struct {
+	portainer.Session
+	// Whether the session is the one the request is authenticated with
+	Current bool `json:"Current" example:"true"`
+}
+
+// @id UserSessionList
+// @summary List the active sessions of a user
+// @description Lists the sessions opened by the authentications of a user which are neither expired nor revoked.
+// @description Only the calling user or an administrator can list them.
+// @description **Access policy**: restricted
+// @tags users
+// @security ApiKeyAuth
+// @security jwt
+// @produce json
+// @param id path int true "User identifier"
+// @success 200 {array} userSessionResponse "Success"
+// @failure 400 "Invalid request"
+// @failure 403 "Permission denied"
+// @failure 404 "User not found"
+// @failure 500 "Server error"
+// @router /users/{id}/sessions [get]
+func (handler *Handler) userSessionList(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	user, httpErr := handler.securityUser(r, true)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	sessions, err := handler.DataStore.Session().SessionsByUserID(user.ID)
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve the sessions of the user from the database", err)
+	}
+
+	currentTokenID := handler.currentTokenID(r)
+	now := time.Now().Unix()
+
+	resp := make([]userSessionResponse, 0, len(sessions))
+	for _, session := range sessions {
+		if session.Revoked || (session.ExpiresAt != 0 && session.ExpiresAt < now) {
+			continue
+		}
+
+		current := session.TokenID == currentTokenID
+		session.TokenID = ""
+
+		resp = append(resp, userSessionResponse{Session: session, Current: current})
+	}
+
+	return response.JSON(w, resp)
+}
+
+// @id UserSessionRevoke
+// @summary Revoke a session of a user
+// @description Revokes a session, its token is rejected immediately.
+// @description Only the calling user or an administrator can revoke it.
+// @description **Access policy**: restricted
+// @tags users
+// @security ApiKeyAuth
+// @security jwt
+// @param id path int true "User identifier"
+// @param sessionID path int true "Session identifier"
+// @success 204 "Success"
+// @failure 400 "Invalid request"
+// @failure 403 "Permission denied"
+// @failure 404 "User or session not found"
+// @failure 500 "Server error"
+// @router /users/{id}/sessions/{sessionID} [delete]
+func (handler *Handler) userSessionRevoke(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	sessionID, err := request.RetrieveNumericRouteVariableValue(r, "sessionID")
+	if err != nil {
+		return httperror.BadRequest("Invalid session identifier route variable", err)
+	}
+
+	user, httpErr := handler.securityUser(r, true)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	session, err := handler.DataStore.Session().Read(portainer.SessionID(sessionID))
+	if handler.DataStore.IsErrObjectNotFound(err) || (err == nil && session.UserID != user.ID) {
+		return httperror.NotFound("Unable to find a session with the specified identifier inside the database", err)
+	} else if err != nil {
+		return httperror.InternalServerError("Unable to find a session with the specified identifier inside the database", err)
+	}
+
+	if err := handler.JWTService.RevokeSession(session.ID); err != nil {
+		return httperror.InternalServerError("Unable to revoke the session", err)
+	}
+
+	return response.Empty(w)
+}
+
+// currentTokenID returns the identifier of the JWT the request is authenticated with, it is empty
+// for the requests authenticated with an API key
+func (handler *Handler) currentTokenID(r *http.Request) string {
+	tokenData, err := security.RetrieveTokenData(r)
+	if err != nil || tokenData.Token == "" {
+		return ""
+	}
+
+	_, tokenID, _, err := handler.JWTService.ParseAndVerifyToken(tokenData.Token)
+	if err != nil {
+		return ""
+	}
+
+	return tokenID
+}
@@ -0,0 +1,237 @@
+package users
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+	httperrors "github.com/portainer/portainer/api/http/errors"
+	"github.com/portainer/portainer/api/http/security"
+	"github.com/portainer/portainer/api/totp"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/request"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+)
+
+type twoFactorStatusResponse struct {
+	// Whether the user confirmed the enrollment of its second factor
+	Enabled bool `json:"enabled" example:"true"`
+	// Unix timestamp of the confirmation of the enrollment
+	EnabledAt int64 `json:"enabledAt,omitempty" example:"1587399600"`
+	// Number of unused recovery codes
+	RecoveryCodesLeft int `json:"recoveryCodesLeft" example:"10"`
+	// Whether the settings require the user to use a second factor
+	Enforced bool `json:"enforced" example:"false"`
+}
+
+type twoFactorConfirmPayload struct {
+	// Code displayed by the authenticator application
+	Code string `validate:"required" example:"123456"`
+}
+
+type twoFactorConfirmResponse struct {
+	// Single-use recovery codes, they are only displayed once
+	RecoveryCodes []string `json:"recoveryCodes"`
+}
+
+func (payload *twoFactorConfirmPayload) Validate(r *http.Request) error {
+	if payload.Code == "" {
+		return errors.New("Invalid code")
+	}
+
+	return nil
+}
+
+// @id UserTwoFactorInspect
+// @summary Inspect the two-factor authentication of a user
+// @description Only the calling user or an administrator can inspect it.
+// @description **Access policy**: restricted
+// @tags users
+// @security ApiKeyAuth
+// @security jwt
+// @produce json
+// @param id path int true "User identifier"
+// @success 200 {object} twoFactorStatusResponse "Success"
+// @failure 400 "Invalid request"
+// @failure 403 "Permission denied"
+// @failure 404 "User not found"
+// @failure 500 "Server error"
+// @router /users/{id}/2fa [get]
+func (handler *Handler) userTwoFactorInspect(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	user, httpErr := handler.securityUser(r, true)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	settings, err := handler.DataStore.Settings().Settings()
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve settings from the database", err)
+	}
+
+	status := &twoFactorStatusResponse{Enforced: totp.IsEnforced(settings, user)}
+
+	configuration, err := handler.DataStore.TwoFactor().Read(user.ID)
+	if err != nil && !handler.DataStore.IsErrObjectNotFound(err) {
+		return httperror.InternalServerError("Unable to retrieve the two-factor authentication of the user from the database", err)
+	}
+
+	if err == nil && configuration.Enabled {
+		status.Enabled = true
+		status.EnabledAt = configuration.EnabledAt
+		status.RecoveryCodesLeft = len(configuration.RecoveryCodes)
+	}
+
+	return response.JSON(w, status)
+}
+
+// @id UserTwoFactorEnroll
+// @summary Start the enrollment of a second factor
+// @description Generates the TOTP secret to add to an authenticator application, the enrollment is then confirmed with a code.
+// @description Only the calling user can enroll its second factor.
+// @description **Access policy**: restricted
+// @tags users
+// @security ApiKeyAuth
+// @security jwt
+// @produce json
+// @param id path int true "User identifier"
+// @success 200 {object} totp.Enrollment "Success"
+// @failure 400 "Invalid request"
+// @failure 403 "Permission denied"
+// @failure 404 "User not found"
+// @failure 409 "Two-factor authentication already enabled"
+// @failure 500 "Server error"
+// @router /users/{id}/2fa [post]
+func (handler *Handler) userTwoFactorEnroll(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	user, httpErr := handler.securityUser(r, false)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	var enrollment *totp.Enrollment
+	err := handler.DataStore.UpdateTx(func(tx dataservices.DataStoreTx) error {
+		var err error
+		enrollment, err = totp.Enroll(tx, user)
+
+		return err
+	})
+	if errors.Is(err, totp.ErrAlreadyEnabled) {
+		return httperror.NewError(http.StatusConflict, "Two-factor authentication is already enabled", err)
+	} else if err != nil {
+		return httperror.InternalServerError("Unable to enroll the second factor", err)
+	}
+
+	return response.JSON(w, enrollment)
+}
+
+// @id UserTwoFactorConfirm
+// @summary Confirm the enrollment of a second factor
+// @description Enables the second factor of the user when the code is valid, and returns the recovery codes.
+// @description Only the calling user can confirm its enrollment.
+// @description **Access policy**: restricted
+// @tags users
+// @security ApiKeyAuth
+// @security jwt
+// @accept json
+// @produce json
+// @param id path int true "User identifier"
+// @param body body twoFactorConfirmPayload true "Code"
+// @success 200 {object} twoFactorConfirmResponse "Success"
+// @failure 400 "Invalid request"
+// @failure 403 "Permission denied"
+// @failure 404 "User not found"
+// @failure 409 "Two-factor authentication already enabled"
+// @failure 422 "Invalid code"
+// @failure 500 "Server error"
+// @router /users/{id}/2fa/confirm [post]
+func (handler *Handler) userTwoFactorConfirm(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	var payload twoFactorConfirmPayload
+	if err := request.DecodeAndValidateJSONPayload(r, &payload); err != nil {
+		return httperror.BadRequest("Invalid request payload", err)
+	}
+
+	user, httpErr := handler.securityUser(r, false)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	var recoveryCodes []string
+	err := handler.DataStore.UpdateTx(func(tx dataservices.DataStoreTx) error {
+		var err error
+		recoveryCodes, err = totp.Confirm(tx, user.ID, payload.Code, time.Now())
+
+		return err
+	})
+	switch {
+	case errors.Is(err, totp.ErrInvalidCode):
+		return httperror.NewError(http.StatusUnprocessableEntity, "Invalid two-factor authentication code", err)
+	case errors.Is(err, totp.ErrNotEnrolled):
+		return httperror.BadRequest("No pending two-factor authentication enrollment", err)
+	case errors.Is(err, totp.ErrAlreadyEnabled):
+		return httperror.NewError(http.StatusConflict, "Two-factor authentication is already enabled", err)
+	case err != nil:
+		return httperror.InternalServerError("Unable to confirm the second factor", err)
+	}
+
+	return response.JSON(w, &twoFactorConfirmResponse{RecoveryCodes: recoveryCodes})
+}
+
+// @id UserTwoFactorDisable
+// @summary Disable the two-factor authentication of a user
+// @description Removes the second factor of a user, an administrator can remove it for a user who lost its authenticator application.
+// @description Only the calling user or an administrator can disable it.
+// @description **Access policy**: restricted
+// @tags users
+// @security ApiKeyAuth
+// @security jwt
+// @param id path int true "User identifier"
+// @success 204 "Success"
+// @failure 400 "Invalid request"
+// @failure 403 "Permission denied"
+// @failure 404 "User not found"
+// @failure 500 "Server error"
+// @router /users/{id}/2fa [delete]
+func (handler *Handler) userTwoFactorDisable(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	user, httpErr := handler.securityUser(r, true)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	if err := totp.Disable(handler.DataStore, user.ID); err != nil {
+		return httperror.InternalServerError("Unable to remove the two-factor authentication of the user from the database", err)
+	}
+
+	return response.Empty(w)
+}
+
+// securityUser returns the user of the route, it must be the calling user unless allowAdmin is set
+// and the calling user is an administrator
+func (handler *Handler) securityUser(r *http.Request, allowAdmin bool) (*portainer.User, *httperror.HandlerError) {
+	userID, err := request.RetrieveNumericRouteVariableValue(r, "id")
+	if err != nil {
+		return nil, httperror.BadRequest("Invalid user identifier route variable", err)
+	}
+
+	tokenData, err := security.RetrieveTokenData(r)
+	if err != nil {
+		return nil, httperror.InternalServerError("Unable to retrieve user authentication token", err)
+	}
+
+	if tokenData.ImpersonatorID != 0 {
+		return nil, httperror.Forbidden(errImpersonationNotAllowed.Error(), errImpersonationNotAllowed)
+	}
+
+	if tokenData.ID != portainer.UserID(userID) && (!allowAdmin || tokenData.Role != portainer.AdministratorRole) {
+		return nil, httperror.Forbidden("Permission denied to manage the authentication of the user", httperrors.ErrUnauthorized)
+	}
+
+	user, err := handler.DataStore.User().Read(portainer.UserID(userID))
+	if handler.DataStore.IsErrObjectNotFound(err) {
+		return nil, httperror.NotFound("Unable to find a user with the specified identifier inside the database", err)
+	} else if err != nil {
+		return nil, httperror.InternalServerError("Unable to find a user with the specified identifier inside the database", err)
+	}
+
+	return user, nil
+}
@@ -8,6 +8,7 @@ import (
 	"time"
 
 	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/authprovider"
 	httperrors "github.com/portainer/portainer/api/http/errors"
 	"github.com/portainer/portainer/api/http/security"
 	httperror "github.com/portainer/portainer/pkg/libhttp/error"
@@ -29,6 +30,8 @@ type userUpdatePayload struct {
 
 	// User role (1 for administrator account and 2 for regular account)
 	Role int `validate:"required" enums:"1,2" example:"2"`
+	// Authentication method the user is bound to (0 to unbind, 1 for internal, 2 for LDAP, 3 for OAuth or 4 for SAML)
+	AuthenticationMethod *int `enums:"0,1,2,3,4" example:"2"`
 }
 
 func (payload *userUpdatePayload) Validate(r *http.Request) error {
@@ -40,6 +43,10 @@ func (payload *userUpdatePayload) Validate(r *http.Request) error {
 		return errors.New("invalid role value. Value must be one of: 1 (administrator) or 2 (regular user)")
 	}
 
+	if payload.AuthenticationMethod != nil && *payload.AuthenticationMethod != 0 && !authprovider.IsValidMethod(portainer.AuthenticationMethod(*payload.AuthenticationMethod)) {
+		return errors.New("invalid authentication method value. Value must be one of: 0 (unbound), 1 (internal), 2 (LDAP/AD), 3 (OAuth), 4 (SAML) or a registered provider")
+	}
+
 	return nil
 }
 
@@ -86,6 +93,10 @@ func (handler *Handler) userUpdate(w http.ResponseWriter, r *http.Request) *http
 		return httperror.Forbidden("Permission denied to update user to administrator role", httperrors.ErrResourceAccessDenied)
 	}
 
+	if tokenData.Role != portainer.AdministratorRole && payload.AuthenticationMethod != nil {
+		return httperror.Forbidden("Permission denied to update the authentication method of the user", httperrors.ErrResourceAccessDenied)
+	}
+
 	user, err := handler.DataStore.User().Read(portainer.UserID(userID))
 	if handler.DataStore.IsErrObjectNotFound(err) {
 		return httperror.NotFound("Unable to find a user with the specified identifier inside the database", err)
@@ -115,6 +126,10 @@ func (handler *Handler) userUpdate(w http.ResponseWriter, r *http.Request) *http
 		return httperror.BadRequest("Existing password field specified without new password field.", errors.New("To change the password, you must include both 'password' and 'newPassword' in your request"))
 	}
 
+	if payload.NewPassword != "" && user.ServiceAccount {
+		return httperror.BadRequest(errServiceAccountPassword.Error(), errServiceAccountPassword)
+	}
+
 	if payload.NewPassword != "" {
 		// Non-admins need to supply the previous password
 		if tokenData.Role != portainer.AdministratorRole {
@@ -123,8 +138,8 @@ func (handler *Handler) userUpdate(w http.ResponseWriter, r *http.Request) *http
 			}
 		}
 
-		if !handler.passwordStrengthChecker.Check(payload.NewPassword) {
-			return httperror.BadRequest("Password does not meet the minimum strength requirements", nil)
+		if err := handler.passwordStrengthChecker.Validate(payload.NewPassword, user.Username); err != nil {
+			return httperror.BadRequest("Password does not meet the minimum strength requirements", err)
 		}
 
 		user.Password, err = handler.CryptoService.Hash(payload.NewPassword)
@@ -132,6 +147,7 @@ func (handler *Handler) userUpdate(w http.ResponseWriter, r *http.Request) *http
 			return httperror.InternalServerError("Unable to hash user password", errCryptoHashFailure)
 		}
 		user.TokenIssueAt = time.Now().Unix()
+		user.PasswordUpdatedAt = user.TokenIssueAt
 	}
 
 	if payload.Theme != nil {
@@ -145,6 +161,11 @@ func (handler *Handler) userUpdate(w http.ResponseWriter, r *http.Request) *http
 		user.TokenIssueAt = time.Now().Unix()
 	}
 
+	if payload.AuthenticationMethod != nil && portainer.AuthenticationMethod(*payload.AuthenticationMethod) != user.AuthenticationMethod {
+		user.AuthenticationMethod = portainer.AuthenticationMethod(*payload.AuthenticationMethod)
+		user.TokenIssueAt = time.Now().Unix()
+	}
+
 	if err := handler.DataStore.User().Update(user.ID, user); err != nil {
 		return httperror.InternalServerError("Unable to persist user changes inside the database", err)
 	}
@@ -152,8 +173,7 @@ func (handler *Handler) userUpdate(w http.ResponseWriter, r *http.Request) *http
 	// remove all of the users persisted API keys
 	handler.apiKeyService.InvalidateUserKeyCache(user.ID)
 
-	// hide the password field in the response payload
-	user.Password = ""
+	hideFields(user)
 
 	return response.JSON(w, user)
 }
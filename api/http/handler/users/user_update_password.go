@@ -58,6 +58,10 @@ func (handler *Handler) userUpdatePassword(w http.ResponseWriter, r *http.Reques
 		return httperror.InternalServerError("Unable to retrieve user authentication token", err)
 	}
 
+	if tokenData.ImpersonatorID != 0 {
+		return httperror.Forbidden(errImpersonationNotAllowed.Error(), errImpersonationNotAllowed)
+	}
+
 	if tokenData.Role != portainer.AdministratorRole && tokenData.ID != portainer.UserID(userID) {
 		return httperror.Forbidden("Permission denied to update user", httperrors.ErrUnauthorized)
 	}
@@ -75,13 +79,17 @@ func (handler *Handler) userUpdatePassword(w http.ResponseWriter, r *http.Reques
 		return httperror.InternalServerError("Unable to find a user with the specified identifier inside the database", err)
 	}
 
+	if user.ServiceAccount {
+		return httperror.BadRequest(errServiceAccountPassword.Error(), errServiceAccountPassword)
+	}
+
 	err = handler.CryptoService.CompareHashAndData(user.Password, payload.Password)
 	if err != nil {
 		return httperror.Forbidden("Current password doesn't match", errors.New("Current password does not match the password provided. Please try again"))
 	}
 
-	if !handler.passwordStrengthChecker.Check(payload.NewPassword) {
-		return httperror.BadRequest("Password does not meet the minimum strength requirements", nil)
+	if err := handler.passwordStrengthChecker.Validate(payload.NewPassword, user.Username); err != nil {
+		return httperror.BadRequest("Password does not meet the minimum strength requirements", err)
 	}
 
 	user.Password, err = handler.CryptoService.Hash(payload.NewPassword)
@@ -90,6 +98,7 @@ func (handler *Handler) userUpdatePassword(w http.ResponseWriter, r *http.Reques
 	}
 
 	user.TokenIssueAt = time.Now().Unix()
+	user.PasswordUpdatedAt = user.TokenIssueAt
 
 	err = handler.DataStore.User().Update(user.ID, user)
 	if err != nil {
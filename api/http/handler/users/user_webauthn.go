@@ -0,0 +1,231 @@
+package users
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	portainer "github.com/portainer/portainer/api"
+	httperrors "github.com/portainer/portainer/api/http/errors"
+	"github.com/portainer/portainer/api/webauthn"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/request"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+)
+
+type webAuthnRegisterBeginResponse struct {
+	// Identifier of the ceremony, to send back with the attestation
+	SessionID string `json:"sessionId"`
+	// Options of navigator.credentials.create, the binary values are base64url encoded
+	PublicKey *webauthn.CreationOptions `json:"publicKey"`
+}
+
+type webAuthnRegisterFinishPayload struct {
+	// Identifier of the ceremony returned by /users/{id}/webauthn/register/begin
+	SessionID string `validate:"required"`
+	// Name given to the authenticator
+	Name string `validate:"required" example:"YubiKey"`
+	// Attestation returned by the authenticator
+	Credential webauthn.CredentialResponse
+}
+
+func (payload *webAuthnRegisterFinishPayload) Validate(r *http.Request) error {
+	if payload.SessionID == "" {
+		return errors.New("Invalid session identifier")
+	}
+
+	if strings.TrimSpace(payload.Name) == "" {
+		return errors.New("Invalid name")
+	}
+
+	return nil
+}
+
+func hideWebAuthnCredentialFields(credential *portainer.WebAuthnCredential) {
+	credential.PublicKey = nil
+}
+
+// @id UserWebAuthnRegisterBegin
+// @summary Start the registration of a security key
+// @description Returns the options of the credential requested to the authenticator.
+// @description Only the calling user can register its security keys.
+// @description **Access policy**: restricted
+// @tags users
+// @security ApiKeyAuth
+// @security jwt
+// @produce json
+// @param id path int true "User identifier"
+// @success 200 {object} webAuthnRegisterBeginResponse "Success"
+// @failure 400 "Invalid request"
+// @failure 403 "Permission denied or WebAuthn disabled"
+// @failure 404 "User not found"
+// @failure 500 "Server error"
+// @router /users/{id}/webauthn/register/begin [post]
+func (handler *Handler) userWebAuthnRegisterBegin(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	user, httpErr := handler.securityUser(r, false)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	settings, err := handler.DataStore.Settings().Settings()
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve settings from the database", err)
+	}
+
+	if !settings.WebAuthnSettings.Enabled {
+		return httperror.Forbidden("The security keys are disabled", httperrors.ErrUnauthorized)
+	}
+
+	credentials, err := handler.DataStore.WebAuthnCredential().CredentialsByUserID(user.ID)
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve the WebAuthn credentials of the user from the database", err)
+	}
+
+	rp := webauthn.RelyingPartyFromRequest(&settings.WebAuthnSettings, r)
+
+	sessionID, options, err := handler.webAuthnService.BeginRegistration(rp, &settings.WebAuthnSettings, user, credentials)
+	if errors.Is(err, webauthn.ErrTooManySessions) {
+		return httperror.NewError(http.StatusTooManyRequests, "Too many pending registrations", err)
+	} else if err != nil {
+		return httperror.InternalServerError("Unable to start the WebAuthn registration", err)
+	}
+
+	return response.JSON(w, &webAuthnRegisterBeginResponse{SessionID: sessionID, PublicKey: options})
+}
+
+// @id UserWebAuthnRegisterFinish
+// @summary Complete the registration of a security key
+// @description Verifies the attestation of the authenticator and registers its credential.
+// @description Only the calling user can register its security keys.
+// @description **Access policy**: restricted
+// @tags users
+// @security ApiKeyAuth
+// @security jwt
+// @accept json
+// @produce json
+// @param id path int true "User identifier"
+// @param body body webAuthnRegisterFinishPayload true "Attestation"
+// @success 200 {object} portainer.WebAuthnCredential "Success"
+// @failure 400 "Invalid request"
+// @failure 403 "Permission denied or WebAuthn disabled"
+// @failure 404 "User not found"
+// @failure 409 "Security key already registered"
+// @failure 422 "Invalid attestation"
+// @failure 500 "Server error"
+// @router /users/{id}/webauthn/register/finish [post]
+func (handler *Handler) userWebAuthnRegisterFinish(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	var payload webAuthnRegisterFinishPayload
+	if err := request.DecodeAndValidateJSONPayload(r, &payload); err != nil {
+		return httperror.BadRequest("Invalid request payload", err)
+	}
+
+	user, httpErr := handler.securityUser(r, false)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	settings, err := handler.DataStore.Settings().Settings()
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve settings from the database", err)
+	}
+
+	if !settings.WebAuthnSettings.Enabled {
+		return httperror.Forbidden("The security keys are disabled", httperrors.ErrUnauthorized)
+	}
+
+	credential, err := handler.webAuthnService.FinishRegistration(payload.SessionID, user.ID, &payload.Credential)
+	if errors.Is(err, webauthn.ErrInvalidSession) || errors.Is(err, webauthn.ErrVerification) {
+		return httperror.NewError(http.StatusUnprocessableEntity, "Invalid security key attestation", err)
+	} else if err != nil {
+		return httperror.InternalServerError("Unable to verify the WebAuthn attestation", err)
+	}
+
+	if _, err := handler.DataStore.WebAuthnCredential().CredentialByCredentialID(credential.CredentialID); err == nil {
+		return httperror.Conflict("The security key is already registered", errors.New("duplicate WebAuthn credential"))
+	} else if !handler.DataStore.IsErrObjectNotFound(err) {
+		return httperror.InternalServerError("Unable to retrieve the WebAuthn credentials from the database", err)
+	}
+
+	credential.Name = strings.TrimSpace(payload.Name)
+
+	if err := handler.DataStore.WebAuthnCredential().Create(credential); err != nil {
+		return httperror.InternalServerError("Unable to persist the WebAuthn credential inside the database", err)
+	}
+
+	hideWebAuthnCredentialFields(credential)
+
+	return response.JSON(w, credential)
+}
+
+// @id UserWebAuthnCredentialList
+// @summary List the security keys of a user
+// @description Only the calling user or an administrator can list them.
+// @description **Access policy**: restricted
+// @tags users
+// @security ApiKeyAuth
+// @security jwt
+// @produce json
+// @param id path int true "User identifier"
+// @success 200 {array} portainer.WebAuthnCredential "Success"
+// @failure 400 "Invalid request"
+// @failure 403 "Permission denied"
+// @failure 404 "User not found"
+// @failure 500 "Server error"
+// @router /users/{id}/webauthn/credentials [get]
+func (handler *Handler) userWebAuthnCredentialList(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	user, httpErr := handler.securityUser(r, true)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	credentials, err := handler.DataStore.WebAuthnCredential().CredentialsByUserID(user.ID)
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve the WebAuthn credentials of the user from the database", err)
+	}
+
+	for idx := range credentials {
+		hideWebAuthnCredentialFields(&credentials[idx])
+	}
+
+	return response.JSON(w, credentials)
+}
+
+// @id UserWebAuthnCredentialDelete
+// @summary Remove a security key of a user
+// @description Only the calling user or an administrator can remove it.
+// @description **Access policy**: restricted
+// @tags users
+// @security ApiKeyAuth
+// @security jwt
+// @param id path int true "User identifier"
+// @param credentialID path int true "Credential identifier"
+// @success 204 "Success"
+// @failure 400 "Invalid request"
+// @failure 403 "Permission denied"
+// @failure 404 "User or credential not found"
+// @failure 500 "Server error"
+// @router /users/{id}/webauthn/credentials/{credentialID} [delete]
+func (handler *Handler) userWebAuthnCredentialDelete(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	credentialID, err := request.RetrieveNumericRouteVariableValue(r, "credentialID")
+	if err != nil {
+		return httperror.BadRequest("Invalid credential identifier route variable", err)
+	}
+
+	user, httpErr := handler.securityUser(r, true)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	credential, err := handler.DataStore.WebAuthnCredential().Read(portainer.WebAuthnCredentialID(credentialID))
+	if handler.DataStore.IsErrObjectNotFound(err) || (err == nil && credential.UserID != user.ID) {
+		return httperror.NotFound("Unable to find a WebAuthn credential with the specified identifier inside the database", err)
+	} else if err != nil {
+		return httperror.InternalServerError("Unable to find a WebAuthn credential with the specified identifier inside the database", err)
+	}
+
+	if err := handler.DataStore.WebAuthnCredential().Delete(credential.ID); err != nil {
+		return httperror.InternalServerError("Unable to remove the WebAuthn credential from the database", err)
+	}
+
+	return response.Empty(w)
+}
@@ -3,9 +3,11 @@ package webhooks
 import (
 	"net/http"
 
+	portainer "github.com/portainer/portainer/api"
 	"github.com/portainer/portainer/api/dataservices"
 	dockerclient "github.com/portainer/portainer/api/docker/client"
 	"github.com/portainer/portainer/api/http/security"
+	"github.com/portainer/portainer/api/stacks/deployments"
 	httperror "github.com/portainer/portainer/pkg/libhttp/error"
 
 	"github.com/gorilla/mux"
@@ -17,6 +19,9 @@ type Handler struct {
 	requestBouncer      security.BouncerService
 	DataStore           dataservices.DataStore
 	DockerClientFactory *dockerclient.ClientFactory
+	FileService         portainer.FileService
+	GitService          portainer.GitService
+	StackDeployer       deployments.StackDeployer
 }
 
 // NewHandler creates a handler to manage webhooks operations.
@@ -33,6 +38,10 @@ func NewHandler(bouncer security.BouncerService) *Handler {
 		bouncer.AuthenticatedAccess(httperror.LoggerHandler(h.webhookList))).Methods(http.MethodGet)
 	h.Handle("/webhooks/{id}",
 		bouncer.AuthenticatedAccess(httperror.LoggerHandler(h.webhookDelete))).Methods(http.MethodDelete)
+	h.Handle("/webhooks/{id}/regenerate",
+		bouncer.AdminAccess(httperror.LoggerHandler(h.webhookRegenerate))).Methods(http.MethodPost)
+	h.Handle("/webhooks/{id}/logs",
+		bouncer.AdminAccess(httperror.LoggerHandler(h.webhookLogs))).Methods(http.MethodGet)
 	h.Handle("/webhooks/{token}",
 		bouncer.PublicAccess(httperror.LoggerHandler(h.webhookExecute))).Methods(http.MethodPost)
 
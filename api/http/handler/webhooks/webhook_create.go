@@ -3,6 +3,8 @@ package webhooks
 import (
 	"errors"
 	"net/http"
+	"strconv"
+	"time"
 
 	portainer "github.com/portainer/portainer/api"
 	"github.com/portainer/portainer/api/http/security"
@@ -15,11 +17,14 @@ import (
 )
 
 type webhookCreatePayload struct {
+	// Identifier of the service, or of the stack for the stack webhooks
 	ResourceID string
 	EndpointID portainer.EndpointID
 	RegistryID portainer.RegistryID
-	// Type of webhook (1 - service)
+	// Type of webhook (1 - service, 2 - stack)
 	WebhookType portainer.WebhookType
+	// Unix timestamp of the expiry of the webhook, it never expires when 0
+	ExpiresAt int64 `example:"1700086400"`
 }
 
 func (payload *webhookCreatePayload) Validate(r *http.Request) error {
@@ -29,13 +34,22 @@ func (payload *webhookCreatePayload) Validate(r *http.Request) error {
 	if payload.EndpointID == 0 {
 		return errors.New("Invalid EndpointID")
 	}
-	if payload.WebhookType != portainer.ServiceWebhook {
+	if payload.WebhookType != portainer.ServiceWebhook && payload.WebhookType != portainer.StackWebhook {
 		return errors.New("Invalid WebhookType")
 	}
+	if payload.WebhookType == portainer.StackWebhook {
+		if _, err := strconv.Atoi(payload.ResourceID); err != nil {
+			return errors.New("Invalid ResourceID, it must be the identifier of the stack")
+		}
+	}
+	if payload.ExpiresAt < 0 {
+		return errors.New("Invalid ExpiresAt")
+	}
 	return nil
 }
 
 // @summary Create a webhook
+// @description Create a webhook pulling the image of a service and updating it, or pulling the images of a stack and redeploying it.
 // @description **Access policy**: authenticated
 // @security ApiKeyAuth
 // @security jwt
@@ -74,6 +88,21 @@ func (handler *Handler) webhookCreate(w http.ResponseWriter, r *http.Request) *h
 		return httperror.Forbidden("Not authorized to create a webhook", errors.New("not authorized to create a webhook"))
 	}
 
+	if payload.WebhookType == portainer.StackWebhook {
+		stackID, _ := strconv.Atoi(payload.ResourceID)
+
+		stack, err := handler.DataStore.Stack().Read(portainer.StackID(stackID))
+		if handler.DataStore.IsErrObjectNotFound(err) {
+			return httperror.NotFound("Unable to find a stack with the specified identifier inside the database", err)
+		} else if err != nil {
+			return httperror.InternalServerError("Unable to find a stack with the specified identifier inside the database", err)
+		}
+
+		if stack.EndpointID != endpointID {
+			return httperror.BadRequest("Invalid request payload", errors.New("the stack is not deployed on the environment"))
+		}
+	}
+
 	if payload.RegistryID != 0 {
 		tokenData, err := security.RetrieveTokenData(r)
 		if err != nil {
@@ -92,11 +121,13 @@ func (handler *Handler) webhookCreate(w http.ResponseWriter, r *http.Request) *h
 	}
 
 	webhook = &portainer.Webhook{
-		Token:       token.String(),
-		ResourceID:  payload.ResourceID,
-		EndpointID:  endpointID,
-		RegistryID:  payload.RegistryID,
-		WebhookType: payload.WebhookType,
+		Token:          token.String(),
+		ResourceID:     payload.ResourceID,
+		EndpointID:     endpointID,
+		RegistryID:     payload.RegistryID,
+		WebhookType:    payload.WebhookType,
+		ExpiresAt:      payload.ExpiresAt,
+		TokenCreatedAt: time.Now().Unix(),
 	}
 
 	err = handler.DataStore.Webhook().Create(webhook)
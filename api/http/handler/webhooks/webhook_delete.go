@@ -41,5 +41,9 @@ func (handler *Handler) webhookDelete(w http.ResponseWriter, r *http.Request) *h
 		return httperror.InternalServerError("Unable to remove the webhook from the database", err)
 	}
 
+	if err := handler.DataStore.WebhookLog().DeleteByWebhookID(portainer.WebhookID(id)); err != nil {
+		return httperror.InternalServerError("Unable to remove the logs of the webhook from the database", err)
+	}
+
 	return response.Empty(w)
 }
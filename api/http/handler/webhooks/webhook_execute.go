@@ -4,25 +4,42 @@ import (
 	"context"
 	"errors"
 	"net/http"
+	"os"
+	"slices"
+	"strconv"
 	"strings"
+	"time"
 
 	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+	"github.com/portainer/portainer/api/docker/quarantine"
+	"github.com/portainer/portainer/api/filesystem"
+	"github.com/portainer/portainer/api/http/security"
 	"github.com/portainer/portainer/api/internal/registryutils"
+	"github.com/portainer/portainer/api/stacks/deployments"
+	"github.com/portainer/portainer/api/stacks/stackutils"
 	httperror "github.com/portainer/portainer/pkg/libhttp/error"
 	"github.com/portainer/portainer/pkg/libhttp/request"
 	"github.com/portainer/portainer/pkg/libhttp/response"
 
 	dockertypes "github.com/docker/docker/api/types"
 	"github.com/docker/docker/api/types/image"
+	"github.com/rs/zerolog/log"
 )
 
 // @summary Execute a webhook
-// @description Acts on a passed in token UUID to restart the docker service
+// @description Acts on a passed in token UUID to restart the docker service, or to pull the images of the stack and
+// @description redeploy it. The invocations are recorded in the logs of the webhook.
+// @description The stack webhooks accept env.NAME=value query parameters, which replace or add an environment variable
+// @description of the stack, and tag.SERVICE=tag query parameters, which replace the image tag of a service of a stack
+// @description that is not deployed from a git repository. The overrides are kept for the next deployments.
 // @description **Access policy**: public
 // @tags webhooks
 // @param id path string true "Webhook token"
+// @param tag query string false "Image tag of the service"
 // @success 202 "Webhook executed"
 // @failure 400
+// @failure 403 "The webhook is disabled or expired"
 // @failure 500
 // @router /webhooks/{id} [post]
 func (handler *Handler) webhookExecute(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
@@ -38,6 +55,56 @@ func (handler *Handler) webhookExecute(w http.ResponseWriter, r *http.Request) *
 		return httperror.InternalServerError("Unable to retrieve webhook from the database", err)
 	}
 
+	imageTag, _ := request.RetrieveQueryParameter(r, "tag", true)
+
+	start := time.Now()
+	httpErr := handler.executeWebhook(w, r, webhook, imageTag)
+
+	webhookLog := &portainer.WebhookLog{
+		WebhookID:  webhook.ID,
+		Timestamp:  start.Unix(),
+		CallerIP:   security.StripAddrPort(r.RemoteAddr),
+		Action:     webhookAction(webhook.WebhookType),
+		Tag:        imageTag,
+		StatusCode: http.StatusNoContent,
+		DurationMs: time.Since(start).Milliseconds(),
+	}
+
+	if httpErr != nil {
+		webhookLog.StatusCode = httpErr.StatusCode
+		webhookLog.Error = httpErr.Message
+		if httpErr.Err != nil {
+			webhookLog.Error += ": " + httpErr.Err.Error()
+		}
+	}
+
+	if err := handler.DataStore.WebhookLog().Create(webhookLog); err != nil {
+		log.Warn().Err(err).Int("webhook_id", int(webhook.ID)).Msg("unable to record the invocation of the webhook")
+	}
+
+	return httpErr
+}
+
+func webhookAction(webhookType portainer.WebhookType) string {
+	switch webhookType {
+	case portainer.ServiceWebhook:
+		return "service update"
+	case portainer.StackWebhook:
+		return "stack redeploy"
+	}
+
+	return "unknown"
+}
+
+func (handler *Handler) executeWebhook(w http.ResponseWriter, r *http.Request, webhook *portainer.Webhook, imageTag string) *httperror.HandlerError {
+	if webhook.Disabled {
+		return httperror.Forbidden("Unable to execute the webhook", errors.New("the webhook is disabled"))
+	}
+
+	if webhook.ExpiresAt != 0 && webhook.ExpiresAt <= time.Now().Unix() {
+		return httperror.Forbidden("Unable to execute the webhook", errors.New("the webhook expired"))
+	}
+
 	resourceID := webhook.ResourceID
 	endpointID := webhook.EndpointID
 	registryID := webhook.RegistryID
@@ -50,11 +117,19 @@ func (handler *Handler) webhookExecute(w http.ResponseWriter, r *http.Request) *
 		return httperror.InternalServerError("Unable to find an environment with the specified identifier inside the database", err)
 	}
 
-	imageTag, _ := request.RetrieveQueryParameter(r, "tag", true)
+	if err := quarantine.Check(endpoint); err != nil {
+		return httperror.Conflict("Unable to execute the webhook", err)
+	}
 
 	switch webhookType {
 	case portainer.ServiceWebhook:
 		return handler.executeServiceWebhook(w, endpoint, resourceID, registryID, imageTag)
+	case portainer.StackWebhook:
+		if imageTag != "" {
+			return httperror.BadRequest("Invalid query parameter: tag", errors.New("the image tags of a stack are set by service, with the tag.SERVICE query parameters"))
+		}
+
+		return handler.executeStackWebhook(w, r, endpoint, resourceID)
 	default:
 		return httperror.InternalServerError("Unsupported webhook type", errors.New("Webhooks for this resource are not currently supported"))
 	}
@@ -125,3 +200,121 @@ func (handler *Handler) executeServiceWebhook(
 
 	return response.Empty(w)
 }
+
+func (handler *Handler) executeStackWebhook(w http.ResponseWriter, r *http.Request, endpoint *portainer.Endpoint, resourceID string) *httperror.HandlerError {
+	stackID, err := strconv.Atoi(resourceID)
+	if err != nil {
+		return httperror.InternalServerError("Invalid stack identifier in the webhook", err)
+	}
+
+	stack, err := handler.DataStore.Stack().Read(portainer.StackID(stackID))
+	if handler.DataStore.IsErrObjectNotFound(err) {
+		return httperror.NotFound("Unable to find a stack with the specified identifier inside the database", err)
+	} else if err != nil {
+		return httperror.InternalServerError("Unable to find a stack with the specified identifier inside the database", err)
+	}
+
+	if stack.EndpointID != endpoint.ID {
+		return httperror.NotFound("Unable to find the stack in the environment of the webhook", errors.New("the stack belongs to another environment"))
+	}
+
+	env, tags := stackOverrides(r)
+
+	if len(tags) > 0 {
+		if stack.GitConfig != nil {
+			return httperror.BadRequest("Unable to override the image tags of a stack deployed from a git repository", errors.New("the stack is deployed from a git repository"))
+		}
+
+		content, err := os.ReadFile(filesystem.JoinPaths(stack.ProjectPath, stack.EntryPoint))
+		if err != nil {
+			return httperror.InternalServerError("Unable to read the stack file", err)
+		}
+
+		if content, err = stackutils.OverrideImageTags(content, tags); err != nil {
+			return httperror.BadRequest("Invalid image tag override", err)
+		}
+
+		if _, err := handler.FileService.UpdateStoreStackFileFromBytes(strconv.Itoa(int(stack.ID)), stack.EntryPoint, content); err != nil {
+			return httperror.InternalServerError("Unable to persist the updated stack file on disk", err)
+		}
+	}
+
+	// the overrides only remain once the stack is redeployed with them
+	previousEnv := stack.Env
+	rollback := func() {
+		if len(env) > 0 {
+			if err := handler.DataStore.UpdateTx(func(tx dataservices.DataStoreTx) error {
+				stack, err := tx.Stack().Read(stack.ID)
+				if err != nil {
+					return err
+				}
+
+				stack.Env = previousEnv
+
+				return tx.Stack().Update(stack.ID, stack)
+			}); err != nil {
+				log.Warn().Err(err).Int("stack_id", int(stack.ID)).Msg("unable to restore the environment variables of the stack")
+			}
+		}
+
+		if len(tags) > 0 {
+			if err := handler.FileService.RollbackStackFile(strconv.Itoa(int(stack.ID)), stack.EntryPoint); err != nil {
+				log.Warn().Err(err).Int("stack_id", int(stack.ID)).Msg("unable to restore the stack file")
+			}
+		}
+	}
+
+	if len(env) > 0 {
+		stack.Env = stackutils.MergeEnv(stack.Env, env)
+
+		if err := handler.DataStore.Stack().Update(stack.ID, stack); err != nil {
+			rollback()
+
+			return httperror.InternalServerError("Unable to persist the stack changes inside the database", err)
+		}
+	}
+
+	if err := deployments.RedeployWithPull(stack.ID, handler.StackDeployer, handler.DataStore, handler.GitService); err != nil {
+		rollback()
+
+		var stackAuthorMissingErr *deployments.StackAuthorMissingErr
+		if errors.As(err, &stackAuthorMissingErr) {
+			return httperror.Conflict("Unable to redeploy the stack", err)
+		}
+
+		return httperror.InternalServerError("Unable to redeploy the stack", err)
+	}
+
+	if len(tags) > 0 {
+		if err := handler.FileService.RemoveStackFileBackup(strconv.Itoa(int(stack.ID)), stack.EntryPoint); err != nil {
+			log.Warn().Err(err).Int("stack_id", int(stack.ID)).Msg("unable to remove the backup of the stack file")
+		}
+	}
+
+	return response.Empty(w)
+}
+
+// stackOverrides returns the environment variables set by the env.NAME query parameters and the image tags set by
+// the tag.SERVICE query parameters of a stack webhook invocation
+func stackOverrides(r *http.Request) ([]portainer.Pair, map[string]string) {
+	var env []portainer.Pair
+	tags := make(map[string]string)
+
+	for key, values := range r.URL.Query() {
+		if len(values) == 0 {
+			continue
+		}
+
+		if name, ok := strings.CutPrefix(key, "env."); ok && name != "" {
+			env = append(env, portainer.Pair{Name: name, Value: values[len(values)-1]})
+		} else if service, ok := strings.CutPrefix(key, "tag."); ok && service != "" {
+			tags[service] = values[len(values)-1]
+		}
+	}
+
+	slices.SortFunc(env, func(a, b portainer.Pair) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+
+	return env, tags
+}
@@ -0,0 +1,130 @@
+package webhooks
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/datastore"
+	gittypes "github.com/portainer/portainer/api/git/types"
+	"github.com/portainer/portainer/api/internal/testhelpers"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWebhookExecuteRejected(t *testing.T) {
+	is := require.New(t)
+
+	_, store := datastore.MustNewTestStore(t, true, false)
+
+	handler := NewHandler(testhelpers.NewTestRequestBouncer())
+	handler.DataStore = store
+
+	is.NoError(store.Webhook().Create(&portainer.Webhook{Token: "disabled", EndpointID: 1, WebhookType: portainer.ServiceWebhook, Disabled: true}))
+	is.NoError(store.Webhook().Create(&portainer.Webhook{Token: "expired", EndpointID: 1, WebhookType: portainer.ServiceWebhook, ExpiresAt: time.Now().Add(-time.Hour).Unix()}))
+
+	execute := func(token string) int {
+		req := httptest.NewRequest(http.MethodPost, "/webhooks/"+token+"?tag=v2", nil)
+		req.RemoteAddr = "10.0.0.1:51234"
+
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+
+		return rr.Code
+	}
+
+	is.Equal(http.StatusNotFound, execute("unknown"))
+	is.Equal(http.StatusForbidden, execute("disabled"))
+	is.Equal(http.StatusForbidden, execute("expired"))
+
+	webhookLogs, err := store.WebhookLog().WebhookLogsByWebhookID(1)
+	is.NoError(err)
+	is.Len(webhookLogs, 1)
+	is.Equal("10.0.0.1", webhookLogs[0].CallerIP)
+	is.Equal("service update", webhookLogs[0].Action)
+	is.Equal("v2", webhookLogs[0].Tag)
+	is.Equal(http.StatusForbidden, webhookLogs[0].StatusCode)
+	is.Contains(webhookLogs[0].Error, "disabled")
+
+	webhookLogs, err = store.WebhookLog().WebhookLogsByWebhookID(2)
+	is.NoError(err)
+	is.Len(webhookLogs, 1)
+	is.Contains(webhookLogs[0].Error, "expired")
+
+	// the logs are removed with their webhook
+	is.NoError(store.WebhookLog().DeleteByWebhookID(1))
+
+	webhookLogs, err = store.WebhookLog().ReadAll()
+	is.NoError(err)
+	is.Len(webhookLogs, 1)
+}
+
+func TestStackWebhookExecuteRejected(t *testing.T) {
+	is := require.New(t)
+
+	_, store := datastore.MustNewTestStore(t, true, false)
+
+	handler := NewHandler(testhelpers.NewTestRequestBouncer())
+	handler.DataStore = store
+
+	is.NoError(store.Endpoint().Create(&portainer.Endpoint{ID: 1}))
+	is.NoError(store.Stack().Create(&portainer.Stack{ID: 1, EndpointID: 2}))
+	is.NoError(store.Stack().Create(&portainer.Stack{ID: 2, EndpointID: 1, GitConfig: &gittypes.RepoConfig{URL: "https://github.com/portainer/portainer"}}))
+
+	is.NoError(store.Webhook().Create(&portainer.Webhook{Token: "missing", ResourceID: "3", EndpointID: 1, WebhookType: portainer.StackWebhook}))
+	is.NoError(store.Webhook().Create(&portainer.Webhook{Token: "other", ResourceID: "1", EndpointID: 1, WebhookType: portainer.StackWebhook}))
+	is.NoError(store.Webhook().Create(&portainer.Webhook{Token: "git", ResourceID: "2", EndpointID: 1, WebhookType: portainer.StackWebhook}))
+
+	execute := func(target string) int {
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, target, nil))
+
+		return rr.Code
+	}
+
+	is.Equal(http.StatusNotFound, execute("/webhooks/missing"))
+	is.Equal(http.StatusNotFound, execute("/webhooks/other"))
+	is.Equal(http.StatusBadRequest, execute("/webhooks/git?tag=v2"))
+	is.Equal(http.StatusBadRequest, execute("/webhooks/git?tag.web=v2"))
+
+	webhookLogs, err := store.WebhookLog().WebhookLogsByWebhookID(3)
+	is.NoError(err)
+	is.Len(webhookLogs, 2)
+	is.Equal("stack redeploy", webhookLogs[0].Action)
+}
+
+func TestStackWebhookExecuteFailure(t *testing.T) {
+	is := require.New(t)
+
+	_, store := datastore.MustNewTestStore(t, true, false)
+
+	handler := NewHandler(testhelpers.NewTestRequestBouncer())
+	handler.DataStore = store
+
+	env := []portainer.Pair{{Name: "LOG_LEVEL", Value: "info"}}
+
+	// the author of the stack is missing, its redeploy fails
+	is.NoError(store.Endpoint().Create(&portainer.Endpoint{ID: 1}))
+	is.NoError(store.Stack().Create(&portainer.Stack{ID: 1, EndpointID: 1, Env: env, CreatedBy: "deleted"}))
+	is.NoError(store.Webhook().Create(&portainer.Webhook{Token: "redeploy", ResourceID: "1", EndpointID: 1, WebhookType: portainer.StackWebhook}))
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/webhooks/redeploy?env.LOG_LEVEL=debug&env.DEBUG=1", nil))
+	is.Equal(http.StatusConflict, rr.Code)
+
+	stack, err := store.Stack().Read(1)
+	is.NoError(err)
+	is.Equal(env, stack.Env)
+}
+
+func TestStackOverrides(t *testing.T) {
+	is := require.New(t)
+
+	r := httptest.NewRequest(http.MethodPost, "/webhooks/token?env.PORT=8080&env.DEBUG=true&tag.web=1.27&tag.=x&env.=y&other=z", nil)
+
+	env, tags := stackOverrides(r)
+	is.Equal([]portainer.Pair{{Name: "DEBUG", Value: "true"}, {Name: "PORT", Value: "8080"}}, env)
+	is.Equal(map[string]string{"web": "1.27"}, tags)
+}
@@ -0,0 +1,50 @@
+package webhooks
+
+import (
+	"cmp"
+	"net/http"
+	"slices"
+
+	portainer "github.com/portainer/portainer/api"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/request"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+)
+
+// @summary List the invocations of a webhook
+// @description List the recorded invocations of a webhook, the most recent first: caller IP address, triggered
+// @description action, result and duration. Used to detect the use of a leaked webhook URL.
+// @description **Access policy**: administrator
+// @security ApiKeyAuth
+// @security jwt
+// @tags webhooks
+// @produce json
+// @param id path int true "Webhook id"
+// @success 200 {array} portainer.WebhookLog
+// @failure 400
+// @failure 404
+// @failure 500
+// @router /webhooks/{id}/logs [get]
+func (handler *Handler) webhookLogs(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	id, err := request.RetrieveNumericRouteVariableValue(r, "id")
+	if err != nil {
+		return httperror.BadRequest("Invalid webhook id", err)
+	}
+
+	if _, err := handler.DataStore.Webhook().Read(portainer.WebhookID(id)); handler.DataStore.IsErrObjectNotFound(err) {
+		return httperror.NotFound("Unable to find a webhook with the specified identifier inside the database", err)
+	} else if err != nil {
+		return httperror.InternalServerError("Unable to find a webhook with the specified identifier inside the database", err)
+	}
+
+	webhookLogs, err := handler.DataStore.WebhookLog().WebhookLogsByWebhookID(portainer.WebhookID(id))
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve the logs of the webhook from the database", err)
+	}
+
+	slices.SortFunc(webhookLogs, func(a, b portainer.WebhookLog) int {
+		return cmp.Compare(b.ID, a.ID)
+	})
+
+	return response.JSON(w, webhookLogs)
+}
@@ -0,0 +1,58 @@
+package webhooks
+
+import (
+	"net/http"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/request"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+
+	"github.com/gofrs/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+// @summary Regenerate the token of a webhook
+// @description Replace the token of a webhook, the URL with the previous token stops working at once.
+// @description Used to rotate a leaked webhook URL.
+// @description **Access policy**: administrator
+// @security ApiKeyAuth
+// @security jwt
+// @tags webhooks
+// @produce json
+// @param id path int true "Webhook id"
+// @success 200 {object} portainer.Webhook
+// @failure 400
+// @failure 404
+// @failure 500
+// @router /webhooks/{id}/regenerate [post]
+func (handler *Handler) webhookRegenerate(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	id, err := request.RetrieveNumericRouteVariableValue(r, "id")
+	if err != nil {
+		return httperror.BadRequest("Invalid webhook id", err)
+	}
+
+	webhook, err := handler.DataStore.Webhook().Read(portainer.WebhookID(id))
+	if handler.DataStore.IsErrObjectNotFound(err) {
+		return httperror.NotFound("Unable to find a webhook with the specified identifier inside the database", err)
+	} else if err != nil {
+		return httperror.InternalServerError("Unable to find a webhook with the specified identifier inside the database", err)
+	}
+
+	token, err := uuid.NewV4()
+	if err != nil {
+		return httperror.InternalServerError("Error creating unique token", err)
+	}
+
+	webhook.Token = token.String()
+	webhook.TokenCreatedAt = time.Now().Unix()
+
+	if err := handler.DataStore.Webhook().Update(webhook.ID, webhook); err != nil {
+		return httperror.InternalServerError("Unable to persist the webhook inside the database", err)
+	}
+
+	log.Info().Int("webhook_id", int(webhook.ID)).Msg("regenerated the token of the webhook")
+
+	return response.JSON(w, webhook)
+}
@@ -14,9 +14,17 @@ import (
 
 type webhookUpdatePayload struct {
 	RegistryID portainer.RegistryID
+	// Disable or enable the webhook, unchanged when omitted
+	Disabled *bool `example:"true"`
+	// Unix timestamp of the expiry of the webhook, 0 for no expiry, unchanged when omitted
+	ExpiresAt *int64 `example:"1700086400"`
 }
 
 func (payload *webhookUpdatePayload) Validate(r *http.Request) error {
+	if payload.ExpiresAt != nil && *payload.ExpiresAt < 0 {
+		return errors.New("Invalid expiry date")
+	}
+
 	return nil
 }
 
@@ -77,6 +85,14 @@ func (handler *Handler) webhookUpdate(w http.ResponseWriter, r *http.Request) *h
 
 	webhook.RegistryID = payload.RegistryID
 
+	if payload.Disabled != nil {
+		webhook.Disabled = *payload.Disabled
+	}
+
+	if payload.ExpiresAt != nil {
+		webhook.ExpiresAt = *payload.ExpiresAt
+	}
+
 	err = handler.DataStore.Webhook().Update(portainer.WebhookID(id), webhook)
 	if err != nil {
 		return httperror.InternalServerError("Unable to persist the webhook inside the database", err)
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/internal/immutable"
 	"github.com/portainer/portainer/api/ws"
 	httperror "github.com/portainer/portainer/pkg/libhttp/error"
 	"github.com/portainer/portainer/pkg/libhttp/request"
@@ -18,6 +19,7 @@ import (
 // @description If the nodeName query parameter is present, the request will be proxied to the underlying agent environment(endpoint).
 // @description If the nodeName query parameter is not specified, the request will be upgraded to the websocket protocol and
 // @description an AttachStart operation HTTP request will be created and hijacked.
+// @description The output of the session is recorded when the session recording is enabled in the settings.
 // @description **Access policy**: authenticated
 // @security ApiKeyAuth
 // @security jwt
@@ -59,12 +61,30 @@ func (handler *Handler) websocketAttach(w http.ResponseWriter, r *http.Request)
 		return httperror.Forbidden("Permission denied to access environment", err)
 	}
 
+	if err := handler.requestBouncer.AuthorizedEndpointRoleOperation(r, endpoint, portainer.OperationDockerContainerAttach); err != nil {
+		return httperror.Forbidden("Permission denied by your role on the environment", err)
+	}
+
+	if err := immutable.Check(endpoint); err != nil {
+		return httperror.Forbidden("The environment is in immutable mode", err)
+	}
+
 	params := &webSocketRequestParams{
 		endpoint: endpoint,
 		ID:       attachID,
 		nodeName: r.FormValue("nodeName"),
 	}
 
+	recorder, err := handler.recordSession(r, params, portainer.SessionRecordingAttach)
+	if err != nil {
+		return httperror.InternalServerError("Unable to start the recording of the session", err)
+	}
+
+	if recorder != nil {
+		defer recorder.Close()
+		w = recorder.ResponseWriter(w)
+	}
+
 	err = handler.handleAttachRequest(w, r, params)
 	if err != nil {
 		return httperror.InternalServerError("An error occurred during websocket attach operation", err)
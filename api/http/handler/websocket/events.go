@@ -0,0 +1,275 @@
+package websocket
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"slices"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+	consts "github.com/portainer/portainer/api/docker/consts"
+	"github.com/portainer/portainer/api/http/security"
+	"github.com/portainer/portainer/api/internal/authorization"
+	"github.com/portainer/portainer/api/internal/endpointutils"
+	"github.com/portainer/portainer/api/stacks/stackutils"
+	"github.com/portainer/portainer/api/ws"
+	endpointsutils "github.com/portainer/portainer/pkg/endpoints"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/request"
+
+	"github.com/docker/docker/api/types/events"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/gorilla/websocket"
+	"github.com/rs/zerolog/log"
+)
+
+// resourceControlsRefreshInterval is the interval at which the resource controls filtering the events of a
+// non-administrator user are reloaded
+const resourceControlsRefreshInterval = 30 * time.Second
+
+var eventTypes = []events.Type{
+	events.BuilderEventType,
+	events.ConfigEventType,
+	events.ContainerEventType,
+	events.DaemonEventType,
+	events.ImageEventType,
+	events.NetworkEventType,
+	events.NodeEventType,
+	events.PluginEventType,
+	events.SecretEventType,
+	events.ServiceEventType,
+	events.VolumeEventType,
+}
+
+// @summary Stream the Docker events of an environment
+// @description The request will be upgraded to the websocket protocol and the events of the Docker environment(endpoint)
+// @description will be sent as JSON messages until the connection is closed.
+// @description A non-administrator user only receives the events of the containers, services, volumes and networks
+// @description they can access, and the events of the images.
+// @description **Access policy**: authenticated
+// @security ApiKeyAuth
+// @security jwt
+// @tags websocket
+// @produce json
+// @param endpointId query int true "environment(endpoint) ID of the Docker environment(endpoint)"
+// @param type query []string false "only stream the events of these types, e.g. container" collectionFormat(multi)
+// @param label query []string false "only stream the events of the resources having these labels, as key or key=value" collectionFormat(multi)
+// @param token query string true "JWT token used for authentication against this environment(endpoint)"
+// @success 200
+// @failure 400
+// @failure 403
+// @failure 404
+// @failure 500
+// @router /websocket/events [get]
+func (handler *Handler) websocketEvents(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	endpointID, err := request.RetrieveNumericQueryParameter(r, "endpointId", false)
+	if err != nil {
+		return httperror.BadRequest("Invalid query parameter: endpointId", err)
+	}
+
+	eventFilters := filters.NewArgs()
+	for _, eventType := range r.URL.Query()["type"] {
+		if !slices.Contains(eventTypes, events.Type(eventType)) {
+			return httperror.BadRequest("Invalid query parameter: type", errors.New("unknown event type: "+eventType))
+		}
+
+		eventFilters.Add("type", eventType)
+	}
+
+	for _, label := range r.URL.Query()["label"] {
+		if label == "" {
+			return httperror.BadRequest("Invalid query parameter: label", errors.New("empty label"))
+		}
+
+		eventFilters.Add("label", label)
+	}
+
+	endpoint, err := handler.DataStore.Endpoint().Endpoint(portainer.EndpointID(endpointID))
+	if handler.DataStore.IsErrObjectNotFound(err) {
+		return httperror.NotFound("Unable to find an environment with the specified identifier inside the database", err)
+	} else if err != nil {
+		return httperror.InternalServerError("Unable to find an environment with the specified identifier inside the database", err)
+	}
+
+	if err := handler.requestBouncer.AuthorizedEndpointOperation(r, endpoint); err != nil {
+		return httperror.Forbidden("Permission denied to access environment", err)
+	}
+
+	if err := handler.requestBouncer.AuthorizedEndpointRoleOperation(r, endpoint, portainer.OperationDockerEvents); err != nil {
+		return httperror.Forbidden("Permission denied by your role on the environment", err)
+	}
+
+	if !endpointutils.IsDockerEndpoint(endpoint) || !endpointsutils.HasDirectConnectivity(endpoint) {
+		return httperror.BadRequest("The events can only be streamed from the Docker environments reachable by Portainer", errors.New("unsupported environment"))
+	}
+
+	tokenData, err := security.RetrieveTokenData(r)
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve user details from authentication token", err)
+	}
+
+	access := &eventAccess{
+		endpointID: endpoint.ID,
+		isAdmin:    tokenData.Role == portainer.AdministratorRole,
+		userID:     tokenData.ID,
+	}
+
+	if err := access.load(handler.DataStore); err != nil {
+		return httperror.InternalServerError("Unable to retrieve the resource controls of the user", err)
+	}
+
+	cli, err := handler.DockerClientFactory.CreateClient(endpoint, "", nil)
+	if err != nil {
+		return httperror.InternalServerError("Unable to create the Docker client", err)
+	}
+	defer cli.Close()
+
+	r.Header.Del("Origin")
+
+	websocketConn, err := handler.connectionUpgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return httperror.InternalServerError("An error occurred during websocket upgrade", err)
+	}
+	defer websocketConn.Close()
+
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+
+	// the messages of the client are only read to notice when the connection is closed
+	go func() {
+		for {
+			if _, _, err := websocketConn.ReadMessage(); err != nil {
+				cancel()
+
+				return
+			}
+		}
+	}()
+
+	messages, errs := cli.Events(ctx, events.ListOptions{Filters: eventFilters})
+
+	pingTicker := time.NewTicker(ws.PingPeriod)
+	defer pingTicker.Stop()
+
+	refreshTicker := time.NewTicker(resourceControlsRefreshInterval)
+	defer refreshTicker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+
+		case err := <-errs:
+			if ctx.Err() != nil {
+				return nil
+			}
+
+			log.Debug().Err(err).Int("endpoint_id", int(endpoint.ID)).Msg("the Docker events stream ended")
+
+			closeWebsocket(websocketConn, websocket.CloseInternalServerErr, "the Docker events stream ended")
+
+			return nil
+
+		case message := <-messages:
+			if !access.allowed(message) {
+				continue
+			}
+
+			websocketConn.SetWriteDeadline(time.Now().Add(ws.WriteWait))
+			if err := websocketConn.WriteJSON(message); err != nil {
+				return nil
+			}
+
+		case <-pingTicker.C:
+			if err := websocketConn.WriteControl(websocket.PingMessage, nil, time.Now().Add(ws.WriteWait)); err != nil {
+				return nil
+			}
+
+		case <-refreshTicker.C:
+			if err := access.load(handler.DataStore); err != nil {
+				log.Warn().Err(err).Msg("unable to reload the resource controls filtering the Docker events")
+			}
+		}
+	}
+}
+
+// eventAccess decides which events of an environment(endpoint) a user receives, from the resource controls of the
+// resources they are about
+type eventAccess struct {
+	endpointID       portainer.EndpointID
+	isAdmin          bool
+	userID           portainer.UserID
+	teamIDs          []portainer.TeamID
+	resourceControls []portainer.ResourceControl
+}
+
+func (access *eventAccess) load(dataStore dataservices.DataStore) error {
+	if access.isAdmin {
+		return nil
+	}
+
+	memberships, err := authorization.EffectiveTeamMemberships(dataStore, access.userID)
+	if err != nil {
+		return err
+	}
+
+	resourceControls, err := dataStore.ResourceControl().ReadAll()
+	if err != nil {
+		return err
+	}
+
+	access.teamIDs = make([]portainer.TeamID, 0, len(memberships))
+	for _, membership := range memberships {
+		access.teamIDs = append(access.teamIDs, membership.TeamID)
+	}
+
+	access.resourceControls = resourceControls
+
+	return nil
+}
+
+func (access *eventAccess) allowed(message events.Message) bool {
+	if access.isAdmin {
+		return true
+	}
+
+	var resourceType portainer.ResourceControlType
+
+	switch message.Type {
+	case events.ImageEventType:
+		return true
+	case events.ContainerEventType:
+		resourceType = portainer.ContainerResourceControl
+	case events.ServiceEventType:
+		resourceType = portainer.ServiceResourceControl
+	case events.VolumeEventType:
+		resourceType = portainer.VolumeResourceControl
+	case events.NetworkEventType:
+		resourceType = portainer.NetworkResourceControl
+	default:
+		return false
+	}
+
+	resourceControl := authorization.GetResourceControlByResourceIDAndType(message.Actor.ID, resourceType, access.resourceControls)
+
+	// the containers of a service and the resources of a stack inherit their resource control
+	attributes := message.Actor.Attributes
+	if resourceControl == nil && attributes[consts.SwarmServiceIDLabel] != "" {
+		resourceControl = authorization.GetResourceControlByResourceIDAndType(attributes[consts.SwarmServiceIDLabel], portainer.ServiceResourceControl, access.resourceControls)
+	}
+
+	if resourceControl == nil {
+		stackName := attributes[consts.SwarmStackNameLabel]
+		if stackName == "" {
+			stackName = attributes[consts.ComposeStackNameLabel]
+		}
+
+		if stackName != "" {
+			resourceControl = authorization.GetResourceControlByResourceIDAndType(stackutils.ResourceControlID(access.endpointID, stackName), portainer.StackResourceControl, access.resourceControls)
+		}
+	}
+
+	return resourceControl != nil && authorization.UserCanAccessResource(access.userID, access.teamIDs, resourceControl)
+}
@@ -0,0 +1,47 @@
+package websocket
+
+import (
+	"testing"
+
+	portainer "github.com/portainer/portainer/api"
+	consts "github.com/portainer/portainer/api/docker/consts"
+
+	"github.com/docker/docker/api/types/events"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEventAccessAllowed(t *testing.T) {
+	is := require.New(t)
+
+	access := &eventAccess{
+		endpointID: 1,
+		userID:     2,
+		teamIDs:    []portainer.TeamID{3},
+		resourceControls: []portainer.ResourceControl{
+			{ResourceID: "c1", Type: portainer.ContainerResourceControl, UserAccesses: []portainer.UserResourceAccess{{UserID: 2}}},
+			{ResourceID: "c2", Type: portainer.ContainerResourceControl, UserAccesses: []portainer.UserResourceAccess{{UserID: 4}}},
+			{ResourceID: "s1", Type: portainer.ServiceResourceControl, TeamAccesses: []portainer.TeamResourceAccess{{TeamID: 3}}},
+			{ResourceID: "1_web", Type: portainer.StackResourceControl, Public: true},
+		},
+	}
+
+	message := func(eventType events.Type, id string, attributes map[string]string) events.Message {
+		return events.Message{Type: eventType, Actor: events.Actor{ID: id, Attributes: attributes}}
+	}
+
+	is.True(access.allowed(message(events.ContainerEventType, "c1", nil)))
+	is.False(access.allowed(message(events.ContainerEventType, "c2", nil)))
+	is.False(access.allowed(message(events.ContainerEventType, "c3", nil)))
+
+	// the containers of a service and the resources of a stack inherit their resource control
+	is.True(access.allowed(message(events.ContainerEventType, "c3", map[string]string{consts.SwarmServiceIDLabel: "s1"})))
+	is.True(access.allowed(message(events.NetworkEventType, "n1", map[string]string{consts.ComposeStackNameLabel: "web"})))
+	is.False(access.allowed(message(events.VolumeEventType, "v1", map[string]string{consts.ComposeStackNameLabel: "api"})))
+
+	is.True(access.allowed(message(events.ImageEventType, "sha256:abc", nil)))
+	is.False(access.allowed(message(events.DaemonEventType, "daemon", nil)))
+
+	access.isAdmin = true
+	is.True(access.allowed(message(events.DaemonEventType, "daemon", nil)))
+	is.True(access.allowed(message(events.ContainerEventType, "c2", nil)))
+}
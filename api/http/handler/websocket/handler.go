@@ -3,9 +3,11 @@ package websocket
 import (
 	portainer "github.com/portainer/portainer/api"
 	"github.com/portainer/portainer/api/dataservices"
+	dockerclient "github.com/portainer/portainer/api/docker/client"
 	"github.com/portainer/portainer/api/http/proxy/factory/kubernetes"
 	"github.com/portainer/portainer/api/http/security"
 	"github.com/portainer/portainer/api/kubernetes/cli"
+	"github.com/portainer/portainer/api/sessionrecording"
 	httperror "github.com/portainer/portainer/pkg/libhttp/error"
 
 	"github.com/gorilla/mux"
@@ -18,7 +20,9 @@ type Handler struct {
 	DataStore                   dataservices.DataStore
 	SignatureService            portainer.DigitalSignatureService
 	ReverseTunnelService        portainer.ReverseTunnelService
+	DockerClientFactory         *dockerclient.ClientFactory
 	KubernetesClientFactory     *cli.ClientFactory
+	SessionRecordingService     *sessionrecording.Service
 	requestBouncer              security.BouncerService
 	connectionUpgrader          websocket.Upgrader
 	kubernetesTokenCacheManager *kubernetes.TokenCacheManager
@@ -36,6 +40,10 @@ func NewHandler(kubernetesTokenCacheManager *kubernetes.TokenCacheManager, bounc
 		bouncer.AuthenticatedAccess(httperror.LoggerHandler(h.websocketExec)))
 	h.PathPrefix("/websocket/attach").Handler(
 		bouncer.AuthenticatedAccess(httperror.LoggerHandler(h.websocketAttach)))
+	h.PathPrefix("/websocket/events").Handler(
+		bouncer.AuthenticatedAccess(httperror.LoggerHandler(h.websocketEvents)))
+	h.PathPrefix("/websocket/logs").Handler(
+		bouncer.AuthenticatedAccess(httperror.LoggerHandler(h.websocketLogs)))
 	h.PathPrefix("/websocket/pod").Handler(
 		bouncer.AuthenticatedAccess(httperror.LoggerHandler(h.websocketPodExec)))
 	h.PathPrefix("/websocket/kubernetes-shell").Handler(
@@ -0,0 +1,242 @@
+package websocket
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+	"github.com/portainer/portainer/api/docker/logs"
+	httperrors "github.com/portainer/portainer/api/http/errors"
+	"github.com/portainer/portainer/api/http/handler/docker/utils"
+	"github.com/portainer/portainer/api/http/security"
+	"github.com/portainer/portainer/api/internal/endpointutils"
+	"github.com/portainer/portainer/api/ws"
+	endpointsutils "github.com/portainer/portainer/pkg/endpoints"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/request"
+
+	"github.com/gorilla/websocket"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	defaultLogsTail = 100
+	// logsBufferSize is the number of lines read ahead of a slow client, the logs of the container are no longer read
+	// from the Docker daemon until the client catches up
+	logsBufferSize = 1000
+	// maxLogsBatchSize is the maximum number of lines sent in a single message
+	maxLogsBatchSize = 200
+)
+
+// @summary Follow the logs of a container
+// @description The request will be upgraded to the websocket protocol and the last lines of the logs of the container
+// @description will be sent, followed by the new ones until the container stops or the connection is closed.
+// @description Each message is a JSON array of lines, only holding the lines selected by the search.
+// @description The logs are read from the Docker daemon at the pace of the client.
+// @description **Access policy**: authenticated, with access to the container
+// @security ApiKeyAuth
+// @security jwt
+// @tags websocket
+// @produce json
+// @param endpointId query int true "environment(endpoint) ID of the Docker environment(endpoint)"
+// @param containerId query string true "Container identifier"
+// @param nodeName query string false "node name"
+// @param tail query int false "Number of lines read from the end of the logs before following them, 100 by default and -1 for all of them"
+// @param search query string false "Substring searched in the lines, ignoring the case, or regular expression when regex is set"
+// @param regex query boolean false "Whether the search is a regular expression"
+// @param since query int false "Only read the lines written after this Unix timestamp"
+// @param stdout query boolean false "Read the standard output, true by default"
+// @param stderr query boolean false "Read the standard error, true by default"
+// @param token query string true "JWT token used for authentication against this environment(endpoint)"
+// @success 200
+// @failure 400
+// @failure 403
+// @failure 404
+// @failure 500
+// @router /websocket/logs [get]
+func (handler *Handler) websocketLogs(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	endpointID, err := request.RetrieveNumericQueryParameter(r, "endpointId", false)
+	if err != nil {
+		return httperror.BadRequest("Invalid query parameter: endpointId", err)
+	}
+
+	containerID, err := request.RetrieveQueryParameter(r, "containerId", false)
+	if err != nil {
+		return httperror.BadRequest("Invalid query parameter: containerId", err)
+	}
+
+	options, filter, httpErr := utils.RetrieveLogsQueryParameters(r)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	options.Until = 0
+	options.Follow = true
+	options.Tail = defaultLogsTail
+
+	if r.FormValue("tail") != "" {
+		tail, err := request.RetrieveNumericQueryParameter(r, "tail", true)
+		if err != nil {
+			return httperror.BadRequest("Invalid query parameter: tail", err)
+		} else if tail < logs.TailAll {
+			return httperror.BadRequest("Invalid query parameter: tail", errors.New("negative number of lines"))
+		}
+
+		options.Tail = tail
+	}
+
+	endpoint, err := handler.DataStore.Endpoint().Endpoint(portainer.EndpointID(endpointID))
+	if handler.DataStore.IsErrObjectNotFound(err) {
+		return httperror.NotFound("Unable to find an environment with the specified identifier inside the database", err)
+	} else if err != nil {
+		return httperror.InternalServerError("Unable to find an environment with the specified identifier inside the database", err)
+	}
+
+	if err := handler.requestBouncer.AuthorizedEndpointOperation(r, endpoint); err != nil {
+		return httperror.Forbidden("Permission denied to access environment", err)
+	}
+
+	if err := handler.requestBouncer.AuthorizedEndpointRoleOperation(r, endpoint, portainer.OperationDockerContainerLogs); err != nil {
+		return httperror.Forbidden("Permission denied by your role on the environment", err)
+	}
+
+	if !endpointutils.IsDockerEndpoint(endpoint) || !endpointsutils.HasDirectConnectivity(endpoint) {
+		return httperror.BadRequest("The logs can only be followed on the Docker environments reachable by Portainer", errors.New("unsupported environment"))
+	}
+
+	securityContext, err := security.RetrieveRestrictedRequestContext(r)
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve user details from request context", err)
+	}
+
+	cli, err := handler.DockerClientFactory.CreateClient(endpoint, r.FormValue("nodeName"), nil)
+	if err != nil {
+		return httperror.InternalServerError("Unable to create the Docker client", err)
+	}
+	defer cli.Close()
+
+	container, err := cli.ContainerInspect(r.Context(), containerID)
+	if err != nil {
+		return httperror.NotFound("Unable to find the container", err)
+	}
+
+	var canAccess bool
+	if err := handler.DataStore.ViewTx(func(tx dataservices.DataStoreTx) error {
+		canAccess, err = utils.UserCanAccessContainer(tx, securityContext, endpoint.ID, &container)
+		return err
+	}); err != nil {
+		return httperror.InternalServerError("Unable to retrieve the resource controls", err)
+	} else if !canAccess {
+		return httperror.Forbidden("Permission denied to access the container", httperrors.ErrResourceAccessDenied)
+	}
+
+	r.Header.Del("Origin")
+
+	websocketConn, err := handler.connectionUpgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return httperror.InternalServerError("An error occurred during websocket upgrade", err)
+	}
+	defer websocketConn.Close()
+
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+
+	// the messages of the client are only read to notice when the connection is closed
+	go func() {
+		for {
+			if _, _, err := websocketConn.ReadMessage(); err != nil {
+				cancel()
+
+				return
+			}
+		}
+	}()
+
+	out, err := logs.Open(ctx, cli, container.ID, options)
+	if err != nil {
+		closeWebsocket(websocketConn, websocket.CloseInternalServerErr, "unable to read the logs of the container")
+
+		return nil
+	}
+	defer out.Close()
+
+	lines := make(chan logs.Line, logsBufferSize)
+	readErr := make(chan error, 1)
+
+	// the reader blocks while the buffer is full, which stops reading the logs from the Docker daemon
+	go func() {
+		defer close(lines)
+
+		readErr <- logs.Read(out, container.Config.Tty, filter, func(line logs.Line) error {
+			select {
+			case lines <- line:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		})
+	}()
+
+	pingTicker := time.NewTicker(ws.PingPeriod)
+	defer pingTicker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+
+		case line, ok := <-lines:
+			if !ok {
+				if err := <-readErr; err != nil && ctx.Err() == nil {
+					log.Debug().Err(err).Str("container_id", container.ID).Msg("unable to read the logs of the container")
+					closeWebsocket(websocketConn, websocket.CloseInternalServerErr, "unable to read the logs of the container")
+
+					return nil
+				}
+
+				closeWebsocket(websocketConn, websocket.CloseNormalClosure, "the container stopped")
+
+				return nil
+			}
+
+			batch := nextLogsBatch(line, lines)
+
+			websocketConn.SetWriteDeadline(time.Now().Add(ws.WriteWait))
+			if err := websocketConn.WriteJSON(batch); err != nil {
+				return nil
+			}
+
+		case <-pingTicker.C:
+			if err := websocketConn.WriteControl(websocket.PingMessage, nil, time.Now().Add(ws.WriteWait)); err != nil {
+				return nil
+			}
+		}
+	}
+}
+
+// nextLogsBatch groups a line with the ones already waiting to be sent
+func nextLogsBatch(line logs.Line, lines <-chan logs.Line) []logs.Line {
+	batch := []logs.Line{line}
+
+	for len(batch) < maxLogsBatchSize {
+		select {
+		case line, ok := <-lines:
+			if !ok {
+				return batch
+			}
+
+			batch = append(batch, line)
+		default:
+			return batch
+		}
+	}
+
+	return batch
+}
+
+func closeWebsocket(websocketConn *websocket.Conn, code int, reason string) {
+	websocketConn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(code, reason), time.Now().Add(ws.WriteWait))
+}
@@ -79,7 +79,7 @@ func (handler *Handler) doProxyWebsocketRequest(
 		proxyDialer.TLSClientConfig = tlsConfig
 	}
 
-	signature, err := handler.SignatureService.CreateSignature(portainer.PortainerAgentSignatureMessage)
+	signature, err := handler.SignatureService.CreateAgentSignature(params.endpoint.AgentSecret)
 	if err != nil {
 		return err
 	}
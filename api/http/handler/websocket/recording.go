@@ -0,0 +1,70 @@
+package websocket
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/http/security"
+	"github.com/portainer/portainer/api/sessionrecording"
+
+	"github.com/rs/zerolog/log"
+)
+
+// execInspectTimeout bounds the lookup of the container of an exec instance when its session is recorded
+const execInspectTimeout = 10 * time.Second
+
+// recordSession starts recording the console session of a request when the recording of the sessions is enabled,
+// the recorder is nil otherwise
+func (handler *Handler) recordSession(r *http.Request, params *webSocketRequestParams, recordingType portainer.SessionRecordingType) (*sessionrecording.Recorder, error) {
+	if handler.SessionRecordingService == nil {
+		return nil, nil
+	}
+
+	tokenData, err := security.RetrieveTokenData(r)
+	if err != nil {
+		return nil, err
+	}
+
+	recording := &portainer.SessionRecording{
+		Type:        recordingType,
+		EndpointID:  params.endpoint.ID,
+		ContainerID: params.ID,
+		UserID:      tokenData.ID,
+		Username:    tokenData.Username,
+	}
+
+	if recordingType == portainer.SessionRecordingExec {
+		recording.ExecID = params.ID
+		recording.ContainerID = handler.execContainerID(params)
+	}
+
+	title := fmt.Sprintf("%s %s by %s", recordingType, recording.ContainerID, tokenData.Username)
+
+	return handler.SessionRecordingService.Record(recording, title)
+}
+
+// execContainerID looks up the container of an exec instance, the recording is kept without it when it cannot be found
+func (handler *Handler) execContainerID(params *webSocketRequestParams) string {
+	cli, err := handler.DockerClientFactory.CreateClient(params.endpoint, params.nodeName, nil)
+	if err != nil {
+		log.Debug().Err(err).Msg("unable to create the Docker client to inspect the exec instance")
+
+		return ""
+	}
+	defer cli.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), execInspectTimeout)
+	defer cancel()
+
+	exec, err := cli.ContainerExecInspect(ctx, params.ID)
+	if err != nil {
+		log.Debug().Err(err).Str("exec_id", params.ID).Msg("unable to inspect the exec instance")
+
+		return ""
+	}
+
+	return exec.ContainerID
+}
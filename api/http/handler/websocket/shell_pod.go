@@ -5,13 +5,18 @@ import (
 
 	portainer "github.com/portainer/portainer/api"
 	"github.com/portainer/portainer/api/http/security"
+	"github.com/portainer/portainer/api/internal/immutable"
 	httperror "github.com/portainer/portainer/pkg/libhttp/error"
 	"github.com/portainer/portainer/pkg/libhttp/request"
+
+	"github.com/rs/zerolog/log"
 )
 
 // @summary Execute a websocket on kubectl shell pod
 // @description The request will be upgraded to the websocket protocol. The request will proxy input from the client to the pod via long-lived websocket connection.
-// @description **Access policy**: authenticated
+// @description The shell pod runs with the service account of the user, so that kubectl is bound to the permissions of the user in the cluster.
+// @description The session is recorded when the recording of the console sessions is enabled.
+// @description **Access policy**: authenticated, with the permission to exec into pods on the environment
 // @security ApiKeyAuth
 // @security jwt
 // @tags websocket
@@ -42,6 +47,18 @@ func (handler *Handler) websocketShellPodExec(w http.ResponseWriter, r *http.Req
 		return httperror.Forbidden("Permission denied to access environment", err)
 	}
 
+	if err := handler.requestBouncer.AuthorizedEndpointOperation(r, endpoint); err != nil {
+		return httperror.Forbidden("Permission denied to access environment", err)
+	}
+
+	if err := handler.requestBouncer.AuthorizedEndpointRoleOperation(r, endpoint, portainer.OperationK8sPodExec); err != nil {
+		return httperror.Forbidden("Permission denied by your role on the environment", err)
+	}
+
+	if err := immutable.Check(endpoint); err != nil {
+		return httperror.Forbidden("The environment is in immutable mode", err)
+	}
+
 	cli, err := handler.KubernetesClientFactory.GetPrivilegedKubeClient(endpoint)
 	if err != nil {
 		return httperror.InternalServerError("Unable to create Kubernetes client", err)
@@ -80,6 +97,24 @@ func (handler *Handler) websocketShellPodExec(w http.ResponseWriter, r *http.Req
 	*/
 	params := &webSocketRequestParams{
 		endpoint: endpoint,
+		ID:       shellPod.PodName,
+	}
+
+	log.Info().
+		Int("endpoint_id", int(endpoint.ID)).
+		Str("username", tokenData.Username).
+		Str("service_account", serviceAccount.Name).
+		Str("pod", shellPod.PodName).
+		Msg("kubectl shell session started")
+
+	recorder, err := handler.recordSession(r, params, portainer.SessionRecordingKubectlShell)
+	if err != nil {
+		return httperror.InternalServerError("Unable to start the recording of the session", err)
+	}
+
+	if recorder != nil {
+		defer recorder.Close()
+		w = recorder.ResponseWriter(w)
 	}
 
 	r.Header.Del("Origin")
@@ -0,0 +1,39 @@
+package websocket
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/apikey"
+	"github.com/portainer/portainer/api/datastore"
+	"github.com/portainer/portainer/api/http/security"
+	"github.com/portainer/portainer/api/jwt"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWebsocketShellPodExecRequiresEnvironmentAccess(t *testing.T) {
+	is := require.New(t)
+
+	_, store := datastore.MustNewTestStore(t, true, true)
+
+	is.NoError(store.User().Create(&portainer.User{ID: 2, Username: "standard", Role: portainer.StandardUserRole}))
+	is.NoError(store.Endpoint().Create(&portainer.Endpoint{ID: 1, Name: "cluster", Type: portainer.KubernetesLocalEnvironment, GroupID: 1}))
+
+	jwtService, err := jwt.NewService("1h", store)
+	is.NoError(err)
+
+	apiKeyService := apikey.NewAPIKeyService(store.APIKeyRepository(), store.User())
+
+	h := NewHandler(nil, security.NewRequestBouncer(store, jwtService, apiKeyService))
+	h.DataStore = store
+
+	r := httptest.NewRequest(http.MethodGet, "/websocket/kubernetes-shell?endpointId=1", nil)
+	r = r.WithContext(security.StoreTokenData(r, &portainer.TokenData{ID: 2, Username: "standard", Role: portainer.StandardUserRole}))
+
+	handlerErr := h.websocketShellPodExec(httptest.NewRecorder(), r)
+	is.NotNil(handlerErr)
+	is.Equal(http.StatusForbidden, handlerErr.StatusCode)
+}
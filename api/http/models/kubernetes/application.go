@@ -38,6 +38,8 @@ type K8sApplication struct {
 	Labels                  map[string]string                      `json:"Labels,omitempty"`
 	Resource                K8sApplicationResource                 `json:"Resource,omitempty"`
 	HorizontalPodAutoscaler *autoscalingv2.HorizontalPodAutoscaler `json:"HorizontalPodAutoscaler,omitempty"`
+	// Warning events of the last hour about the application, its pods and its replica sets, the most recent first
+	WarningEvents []K8sEvent `json:"WarningEvents,omitempty"`
 }
 
 type Metadata struct {
@@ -0,0 +1,50 @@
+package kubernetes
+
+import (
+	"errors"
+	"net/http"
+	"time"
+)
+
+type (
+	K8sCustomResourceDefinition struct {
+		Name  string `json:"Name" example:"certificates.cert-manager.io"`
+		Group string `json:"Group" example:"cert-manager.io"`
+		Kind  string `json:"Kind" example:"Certificate"`
+		// Resource name of the custom resources in the API
+		Plural string `json:"Plural" example:"certificates"`
+		// Namespaced or Cluster
+		Scope string `json:"Scope" example:"Namespaced"`
+		// Versions served by the API
+		Versions []string `json:"Versions" example:"v1"`
+		// Version the custom resources are read and applied with, the storage version when it is served
+		PreferredVersion string    `json:"PreferredVersion" example:"v1"`
+		CreationDate     time.Time `json:"CreationDate"`
+	}
+
+	K8sCustomResource struct {
+		Name         string            `json:"Name" example:"example-com"`
+		Namespace    string            `json:"Namespace,omitempty" example:"default"`
+		APIVersion   string            `json:"APIVersion" example:"cert-manager.io/v1"`
+		Kind         string            `json:"Kind" example:"Certificate"`
+		Labels       map[string]string `json:"Labels,omitempty"`
+		CreationDate time.Time         `json:"CreationDate"`
+		// Status of the Ready condition of the resource, True, False or Unknown, empty when it has none
+		Ready string `json:"Ready,omitempty" example:"True"`
+	}
+
+	K8sCustomResourceApplyPayload struct {
+		// YAML or JSON manifest of a single custom resource
+		Manifest string `json:"Manifest"`
+		// Validate the manifest against the schema of the custom resource definition without saving it
+		DryRun bool `json:"DryRun" example:"false"`
+	}
+)
+
+func (r *K8sCustomResourceApplyPayload) Validate(request *http.Request) error {
+	if r.Manifest == "" {
+		return errors.New("the manifest of the custom resource is required")
+	}
+
+	return nil
+}
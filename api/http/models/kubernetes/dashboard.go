@@ -9,5 +9,7 @@ type (
 		ConfigMapsCount   int64 `json:"configMapsCount"`
 		SecretsCount      int64 `json:"secretsCount"`
 		VolumesCount      int64 `json:"volumesCount"`
+		// CPU and memory used on the nodes, only set when the metrics API is available
+		Utilization *K8sUtilization `json:"utilization,omitempty"`
 	}
 )
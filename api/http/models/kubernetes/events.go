@@ -0,0 +1,30 @@
+package kubernetes
+
+import "time"
+
+type (
+	// K8sEvent is a Kubernetes Event, reporting what happened to an object of the cluster
+	K8sEvent struct {
+		Name      string `json:"Name"`
+		Namespace string `json:"Namespace"`
+		// Type of the event, Normal or Warning
+		Type    string `json:"Type" example:"Warning"`
+		Reason  string `json:"Reason" example:"BackOff"`
+		Message string `json:"Message" example:"Back-off restarting failed container"`
+		// Number of times the event occurred
+		Count int32 `json:"Count" example:"12"`
+		// Component which reported the event, such as the kubelet
+		Source         string                 `json:"Source,omitempty" example:"kubelet"`
+		FirstTimestamp time.Time              `json:"FirstTimestamp"`
+		LastTimestamp  time.Time              `json:"LastTimestamp"`
+		InvolvedObject K8sEventInvolvedObject `json:"InvolvedObject"`
+	}
+
+	// K8sEventInvolvedObject is the object an event is about
+	K8sEventInvolvedObject struct {
+		Kind      string `json:"Kind" example:"Pod"`
+		Name      string `json:"Name" example:"web-7d4b9c8f6d-x2x8z"`
+		Namespace string `json:"Namespace,omitempty"`
+		UID       string `json:"UID,omitempty"`
+	}
+)
@@ -2,8 +2,14 @@ package kubernetes
 
 import (
 	"errors"
+	"fmt"
+	"net"
 	"net/http"
+	"slices"
+	"strings"
 	"time"
+
+	"k8s.io/apimachinery/pkg/util/validation"
 )
 
 type (
@@ -18,6 +24,33 @@ type (
 
 	K8sIngressControllers []K8sIngressController
 
+	// K8sIngressClass is an IngressClass of a cluster
+	K8sIngressClass struct {
+		Name string `json:"Name" example:"nginx"`
+		// Controller implementing the class, such as k8s.io/ingress-nginx
+		Controller string `json:"Controller" example:"k8s.io/ingress-nginx"`
+		Type       string `json:"Type" example:"nginx"`
+		// Whether the class is used by the ingresses which do not set one
+		IsDefault bool `json:"IsDefault" example:"true"`
+		// Whether an ingress uses the class
+		Used bool `json:"Used" example:"false"`
+	}
+
+	// K8sDetectedIngressController is an ingress controller found running in a cluster
+	K8sDetectedIngressController struct {
+		Type      string `json:"Type" example:"nginx"`
+		Namespace string `json:"Namespace" example:"ingress-nginx"`
+		// Kind of the workload running the controller, Deployment or DaemonSet
+		Kind    string `json:"Kind" example:"Deployment"`
+		Name    string `json:"Name" example:"ingress-nginx-controller"`
+		Image   string `json:"Image" example:"registry.k8s.io/ingress-nginx/controller:v1.10.0"`
+		Version string `json:"Version" example:"v1.10.0"`
+		// Whether the controller has as many ready replicas as desired
+		Ready bool `json:"Ready" example:"true"`
+		// IngressClasses implemented by the controller, none meaning that the ingresses cannot select it
+		IngressClasses []string `json:"IngressClasses"`
+	}
+
 	K8sIngressInfo struct {
 		Name         string            `json:"Name"`
 		UID          string            `json:"UID"`
@@ -65,6 +98,91 @@ func (r K8sIngressInfo) Validate(request *http.Request) error {
 		return errors.New("missing ingress Namespace from the request payload")
 	}
 
+	if errs := validation.IsDNS1123Subdomain(r.Name); len(errs) > 0 {
+		return fmt.Errorf("invalid ingress name: %s", strings.Join(errs, ", "))
+	}
+
+	for _, host := range r.Hosts {
+		if err := validateIngressHost(host); err != nil {
+			return err
+		}
+	}
+
+	rules := map[string]bool{}
+	for _, path := range r.Paths {
+		if err := path.validate(); err != nil {
+			return err
+		}
+
+		if path.Host != "" && !slices.Contains(r.Hosts, path.Host) {
+			return fmt.Errorf("the host %s of the path %s is not one of the hosts of the ingress", path.Host, path.Path)
+		}
+
+		rule := path.Host + path.Path
+		if rules[rule] {
+			return fmt.Errorf("the path %s of the host %s is set more than once", path.Path, path.Host)
+		}
+
+		rules[rule] = true
+	}
+
+	for _, tls := range r.TLS {
+		if tls.SecretName != "" {
+			if errs := validation.IsDNS1123Subdomain(tls.SecretName); len(errs) > 0 {
+				return fmt.Errorf("invalid TLS secret name: %s", strings.Join(errs, ", "))
+			}
+		}
+
+		for _, host := range tls.Hosts {
+			if !slices.Contains(r.Hosts, host) {
+				return fmt.Errorf("the TLS host %s is not one of the hosts of the ingress", host)
+			}
+		}
+	}
+
+	return nil
+}
+
+func validateIngressHost(host string) error {
+	if host == "" {
+		return nil
+	}
+
+	if net.ParseIP(host) != nil {
+		return fmt.Errorf("the host %s must be a DNS name, not an IP address", host)
+	}
+
+	errs := validation.IsDNS1123Subdomain(host)
+	if strings.HasPrefix(host, "*.") {
+		errs = validation.IsWildcardDNS1123Subdomain(host)
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("invalid host %s: %s", host, strings.Join(errs, ", "))
+	}
+
+	return nil
+}
+
+func (p K8sIngressPath) validate() error {
+	if p.PathType != "ImplementationSpecific" && !strings.HasPrefix(p.Path, "/") {
+		return fmt.Errorf("the path %q must start with a slash", p.Path)
+	}
+
+	switch p.PathType {
+	case "", "Exact", "Prefix", "ImplementationSpecific":
+	default:
+		return fmt.Errorf("the type of the path %s must be Exact, Prefix or ImplementationSpecific", p.Path)
+	}
+
+	if p.ServiceName == "" {
+		return fmt.Errorf("the path %s has no service", p.Path)
+	}
+
+	if p.Port < 1 || p.Port > 65535 {
+		return fmt.Errorf("the service port of the path %s must be between 1 and 65535", p.Path)
+	}
+
 	return nil
 }
 
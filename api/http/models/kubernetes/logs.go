@@ -0,0 +1,13 @@
+package kubernetes
+
+import "time"
+
+// K8sLogLine is a line of the logs of a container of a pod
+type K8sLogLine struct {
+	// Time at which the line was written, zero when the kubelet did not record it
+	Timestamp time.Time `json:"Timestamp"`
+	Pod       string    `json:"Pod" example:"web-7d4b9c8f6d-x2x8z"`
+	Container string    `json:"Container" example:"web"`
+	// Content of the line, without its end
+	Text string `json:"Text" example:"Listening on port 8080"`
+}
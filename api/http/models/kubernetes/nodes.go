@@ -0,0 +1,140 @@
+package kubernetes
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+const (
+	K8sNodeDrainStatusDraining = "Draining"
+	K8sNodeDrainStatusDrained  = "Drained"
+	K8sNodeDrainStatusFailed   = "Failed"
+)
+
+type (
+	// K8sNodeUpdatePayload replaces the labels and the taints of a node, the ones managed by Kubernetes being kept.
+	// A null field leaves them unchanged
+	K8sNodeUpdatePayload struct {
+		Labels map[string]string `json:"Labels" example:"disktype:ssd"`
+		Taints []K8sNodeTaint    `json:"Taints"`
+	}
+
+	K8sNodeTaint struct {
+		Key   string `json:"Key" example:"dedicated"`
+		Value string `json:"Value" example:"gpu"`
+		// NoSchedule, PreferNoSchedule or NoExecute
+		Effect string `json:"Effect" example:"NoSchedule"`
+	}
+
+	// K8sNodeDrainPayload is the options of the drain of a node
+	K8sNodeDrainPayload struct {
+		// Seconds given to the pods to terminate, the grace period of every pod when null
+		GracePeriodSeconds *int64 `json:"GracePeriodSeconds" example:"30"`
+		// Seconds after which the drain fails when pods are left, 300 when 0
+		TimeoutSeconds int64 `json:"TimeoutSeconds" example:"300"`
+		// Leave the pods of the DaemonSets, the drain fails when the node runs some otherwise
+		IgnoreDaemonSets bool `json:"IgnoreDaemonSets" example:"true"`
+		// Evict the pods using emptyDir volumes, whose data is lost, the drain fails when the node runs some otherwise
+		DeleteEmptyDirData bool `json:"DeleteEmptyDirData" example:"false"`
+		// Evict the pods which are not managed by a controller, which are not recreated, the drain fails when the node
+		// runs some otherwise
+		Force bool `json:"Force" example:"false"`
+		// Delete the pods rather than evicting them, which bypasses their PodDisruptionBudgets
+		DisableEviction bool `json:"DisableEviction" example:"false"`
+	}
+
+	// K8sNodeDrainStatus is the progress of the drain of a node
+	K8sNodeDrainStatus struct {
+		Node string `json:"Node" example:"worker-1"`
+		// Draining, Drained or Failed
+		Status     string    `json:"Status" example:"Draining"`
+		StartedAt  time.Time `json:"StartedAt"`
+		FinishedAt time.Time `json:"FinishedAt,omitempty"`
+		// Number of pods to evict from the node
+		PodsTotal   int `json:"PodsTotal" example:"12"`
+		PodsEvicted int `json:"PodsEvicted" example:"5"`
+		// Pods still running on the node, as namespace/name
+		PendingPods []string `json:"PendingPods"`
+		Error       string   `json:"Error,omitempty"`
+	}
+)
+
+func (r *K8sNodeUpdatePayload) Validate(request *http.Request) error {
+	if r.Labels == nil && r.Taints == nil {
+		return errors.New("the labels or the taints of the node are required")
+	}
+
+	for key, value := range r.Labels {
+		if errs := validation.IsQualifiedName(key); len(errs) > 0 {
+			return fmt.Errorf("invalid label key %q: %s", key, strings.Join(errs, ", "))
+		}
+
+		if errs := validation.IsValidLabelValue(value); len(errs) > 0 {
+			return fmt.Errorf("invalid value of the label %q: %s", key, strings.Join(errs, ", "))
+		}
+
+		if IsK8sManagedNodeKey(key) {
+			return fmt.Errorf("the label %q is managed by Kubernetes", key)
+		}
+	}
+
+	seen := map[string]bool{}
+	for _, taint := range r.Taints {
+		if errs := validation.IsQualifiedName(taint.Key); len(errs) > 0 {
+			return fmt.Errorf("invalid taint key %q: %s", taint.Key, strings.Join(errs, ", "))
+		}
+
+		if taint.Value != "" {
+			if errs := validation.IsValidLabelValue(taint.Value); len(errs) > 0 {
+				return fmt.Errorf("invalid value of the taint %q: %s", taint.Key, strings.Join(errs, ", "))
+			}
+		}
+
+		switch corev1.TaintEffect(taint.Effect) {
+		case corev1.TaintEffectNoSchedule, corev1.TaintEffectPreferNoSchedule, corev1.TaintEffectNoExecute:
+		default:
+			return fmt.Errorf("the effect of the taint %q must be NoSchedule, PreferNoSchedule or NoExecute", taint.Key)
+		}
+
+		if IsK8sManagedNodeKey(taint.Key) {
+			return fmt.Errorf("the taint %q is managed by Kubernetes", taint.Key)
+		}
+
+		if seen[taint.Key+":"+taint.Effect] {
+			return fmt.Errorf("the taint %q is duplicated for the effect %s", taint.Key, taint.Effect)
+		}
+		seen[taint.Key+":"+taint.Effect] = true
+	}
+
+	return nil
+}
+
+func (r *K8sNodeDrainPayload) Validate(request *http.Request) error {
+	if r.GracePeriodSeconds != nil && *r.GracePeriodSeconds < 0 {
+		return errors.New("the grace period cannot be negative")
+	}
+
+	if r.TimeoutSeconds < 0 {
+		return errors.New("the timeout cannot be negative")
+	}
+
+	return nil
+}
+
+// IsK8sManagedNodeKey returns whether a label or a taint of a node is set by Kubernetes, the kubelet or the cloud
+// provider, the node roles excepted
+func IsK8sManagedNodeKey(key string) bool {
+	prefix, _, found := strings.Cut(key, "/")
+	if !found || prefix == "node-role.kubernetes.io" {
+		return false
+	}
+
+	return prefix == "kubernetes.io" || prefix == "k8s.io" ||
+		strings.HasSuffix(prefix, ".kubernetes.io") || strings.HasSuffix(prefix, ".k8s.io")
+}
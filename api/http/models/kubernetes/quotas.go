@@ -0,0 +1,138 @@
+package kubernetes
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// K8sNamespaceQuotas is the ResourceQuota and the LimitRange managed by Portainer in a namespace
+type K8sNamespaceQuotas struct {
+	// ResourceQuota of the namespace, none when null
+	ResourceQuota *K8sNamespaceResourceQuota `json:"ResourceQuota"`
+	// LimitRange of the namespace, none when null
+	LimitRange *K8sNamespaceLimitRange `json:"LimitRange"`
+	// Warnings about the quotas of the namespaces exceeding the capacity of the cluster
+	Warnings []string `json:"Warnings,omitempty"`
+}
+
+// K8sNamespaceResourceQuota is the hard limits of a namespace, keyed by resource name such as requests.cpu,
+// limits.memory, pods or persistentvolumeclaims
+type K8sNamespaceResourceQuota struct {
+	Hard map[string]string `json:"Hard" example:"limits.cpu:2,limits.memory:4Gi"`
+	// Usage of the namespace, ignored on update
+	Used map[string]string `json:"Used,omitempty"`
+}
+
+// K8sNamespaceLimitRange is the default and the bounds of the resources of the containers, the pods and the
+// persistent volume claims of a namespace
+type K8sNamespaceLimitRange struct {
+	Limits []K8sLimitRangeItem `json:"Limits"`
+}
+
+type K8sLimitRangeItem struct {
+	// Kind of object the limits apply to, Container, Pod or PersistentVolumeClaim
+	Type           string            `json:"Type" example:"Container"`
+	Max            map[string]string `json:"Max,omitempty"`
+	Min            map[string]string `json:"Min,omitempty"`
+	Default        map[string]string `json:"Default,omitempty"`
+	DefaultRequest map[string]string `json:"DefaultRequest,omitempty"`
+}
+
+func (r *K8sNamespaceQuotas) Validate(request *http.Request) error {
+	if r.ResourceQuota != nil {
+		if len(r.ResourceQuota.Hard) == 0 {
+			return errors.New("the resource quota must have at least one hard limit")
+		}
+
+		if _, err := ParseResourceList(r.ResourceQuota.Hard); err != nil {
+			return fmt.Errorf("invalid resource quota: %w", err)
+		}
+	}
+
+	if r.LimitRange != nil {
+		if len(r.LimitRange.Limits) == 0 {
+			return errors.New("the limit range must have at least one limit")
+		}
+
+		for _, item := range r.LimitRange.Limits {
+			if err := item.validate(); err != nil {
+				return fmt.Errorf("invalid %s limit: %w", item.Type, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+func (item K8sLimitRangeItem) validate() error {
+	switch corev1.LimitType(item.Type) {
+	case corev1.LimitTypeContainer, corev1.LimitTypePod, corev1.LimitTypePersistentVolumeClaim:
+	default:
+		return errors.New("the type must be Container, Pod or PersistentVolumeClaim")
+	}
+
+	if corev1.LimitType(item.Type) != corev1.LimitTypeContainer && (len(item.Default) > 0 || len(item.DefaultRequest) > 0) {
+		return errors.New("defaults can only be set on containers")
+	}
+
+	maximums, err := ParseResourceList(item.Max)
+	if err != nil {
+		return err
+	}
+
+	minimums, err := ParseResourceList(item.Min)
+	if err != nil {
+		return err
+	}
+
+	for _, values := range []map[string]string{item.Default, item.DefaultRequest} {
+		if _, err := ParseResourceList(values); err != nil {
+			return err
+		}
+	}
+
+	for name, minimum := range minimums {
+		if maximum, ok := maximums[name]; ok && minimum.Cmp(maximum) > 0 {
+			return fmt.Errorf("the minimum of %s is greater than its maximum", name)
+		}
+	}
+
+	return nil
+}
+
+// ParseResourceList converts resource names and quantities to a Kubernetes resource list
+func ParseResourceList(values map[string]string) (corev1.ResourceList, error) {
+	list := corev1.ResourceList{}
+	for name, value := range values {
+		quantity, err := resource.ParseQuantity(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid quantity %q for %s: %w", value, name, err)
+		}
+
+		if quantity.Sign() < 0 {
+			return nil, fmt.Errorf("the quantity of %s cannot be negative", name)
+		}
+
+		list[corev1.ResourceName(name)] = quantity
+	}
+
+	return list, nil
+}
+
+// FormatResourceList converts a Kubernetes resource list to resource names and quantities
+func FormatResourceList(list corev1.ResourceList) map[string]string {
+	if len(list) == 0 {
+		return nil
+	}
+
+	values := make(map[string]string, len(list))
+	for name, quantity := range list {
+		values[string(name)] = quantity.String()
+	}
+
+	return values
+}
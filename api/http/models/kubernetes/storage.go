@@ -0,0 +1,87 @@
+package kubernetes
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+const (
+	// K8sOrphanedVolumeReleased is a PersistentVolume whose claim was deleted and which was retained
+	K8sOrphanedVolumeReleased = "Released"
+	// K8sOrphanedVolumeUnclaimed is a PersistentVolume which is not bound to any claim
+	K8sOrphanedVolumeUnclaimed = "Unclaimed"
+	// K8sOrphanedVolumeLost is a PersistentVolumeClaim whose PersistentVolume was deleted
+	K8sOrphanedVolumeLost = "Lost"
+	// K8sOrphanedVolumeUnused is a bound PersistentVolumeClaim which is not mounted by any pod
+	K8sOrphanedVolumeUnused = "Unused"
+)
+
+type (
+	K8sStorageClassInfo struct {
+		Name                 string                               `json:"Name" example:"standard"`
+		Provisioner          string                               `json:"Provisioner" example:"ebs.csi.aws.com"`
+		Parameters           map[string]string                    `json:"Parameters,omitempty"`
+		ReclaimPolicy        corev1.PersistentVolumeReclaimPolicy `json:"ReclaimPolicy" example:"Delete"`
+		VolumeBindingMode    storagev1.VolumeBindingMode          `json:"VolumeBindingMode" example:"WaitForFirstConsumer"`
+		AllowVolumeExpansion bool                                 `json:"AllowVolumeExpansion" example:"true"`
+		IsDefault            bool                                 `json:"IsDefault" example:"true"`
+		CreationDate         time.Time                            `json:"CreationDate"`
+		// Number of PersistentVolumes provisioned with the storage class
+		PersistentVolumes int `json:"PersistentVolumes" example:"4"`
+	}
+
+	K8sPersistentVolumeInfo struct {
+		Name string `json:"Name" example:"pvc-0b5e8c5e"`
+		// Capacity in bytes
+		Capacity      int64                                `json:"Capacity" example:"10737418240"`
+		AccessModes   []corev1.PersistentVolumeAccessMode  `json:"AccessModes"`
+		ReclaimPolicy corev1.PersistentVolumeReclaimPolicy `json:"ReclaimPolicy" example:"Delete"`
+		StorageClass  string                               `json:"StorageClass" example:"standard"`
+		// Available, Bound, Released or Failed
+		Phase corev1.PersistentVolumePhase `json:"Phase" example:"Bound"`
+		// Claim bound to the volume, as namespace/name
+		Claim        string    `json:"Claim,omitempty" example:"default/data-db-0"`
+		CreationDate time.Time `json:"CreationDate"`
+	}
+
+	// K8sOrphanedVolume is a PersistentVolume or a PersistentVolumeClaim which holds storage without being used
+	K8sOrphanedVolume struct {
+		// PersistentVolume or PersistentVolumeClaim
+		Kind      string `json:"Kind" example:"PersistentVolume"`
+		Name      string `json:"Name" example:"pvc-0b5e8c5e"`
+		Namespace string `json:"Namespace,omitempty"`
+		// Released, Unclaimed, Lost or Unused
+		Reason       string    `json:"Reason" example:"Released"`
+		StorageClass string    `json:"StorageClass" example:"standard"`
+		Capacity     int64     `json:"Capacity" example:"10737418240"`
+		CreationDate time.Time `json:"CreationDate"`
+	}
+
+	K8sVolumeExpandPayload struct {
+		// New size of the volume, which must be greater than its current size
+		Size string `json:"Size" example:"20Gi"`
+	}
+)
+
+func (r *K8sVolumeExpandPayload) Validate(request *http.Request) error {
+	if r.Size == "" {
+		return errors.New("the size of the volume is required")
+	}
+
+	size, err := resource.ParseQuantity(r.Size)
+	if err != nil {
+		return fmt.Errorf("invalid size of the volume: %w", err)
+	}
+
+	if size.Sign() <= 0 {
+		return errors.New("the size of the volume must be positive")
+	}
+
+	return nil
+}
@@ -0,0 +1,54 @@
+package kubernetes
+
+type (
+	// K8sResourceUsage is an amount of CPU and memory
+	K8sResourceUsage struct {
+		// CPU in millicores
+		CPU int64 `json:"CPU" example:"250"`
+		// Memory in bytes
+		Memory int64 `json:"Memory" example:"268435456"`
+	}
+
+	// K8sUtilization is the CPU and memory used out of the ones available
+	K8sUtilization struct {
+		Usage         K8sResourceUsage `json:"Usage"`
+		Allocatable   K8sResourceUsage `json:"Allocatable"`
+		CPUPercent    float64          `json:"CPUPercent" example:"12.5"`
+		MemoryPercent float64          `json:"MemoryPercent" example:"40.2"`
+	}
+
+	// K8sNodeUsage is the utilization of a node reported by the metrics API
+	K8sNodeUsage struct {
+		Name string `json:"Name" example:"worker-1"`
+		K8sUtilization
+	}
+
+	// K8sPodUsage is the usage of a pod reported by the metrics API
+	K8sPodUsage struct {
+		Name      string           `json:"Name" example:"web-7d4b9c8f6d-x2x8z"`
+		Namespace string           `json:"Namespace" example:"default"`
+		NodeName  string           `json:"NodeName" example:"worker-1"`
+		Usage     K8sResourceUsage `json:"Usage"`
+	}
+)
+
+// Add adds the usage of other to the usage
+func (usage *K8sResourceUsage) Add(other K8sResourceUsage) {
+	usage.CPU += other.CPU
+	usage.Memory += other.Memory
+}
+
+// NewK8sUtilization returns the utilization of the allocatable resources
+func NewK8sUtilization(usage, allocatable K8sResourceUsage) K8sUtilization {
+	utilization := K8sUtilization{Usage: usage, Allocatable: allocatable}
+
+	if allocatable.CPU > 0 {
+		utilization.CPUPercent = float64(usage.CPU) * 100 / float64(allocatable.CPU)
+	}
+
+	if allocatable.Memory > 0 {
+		utilization.MemoryPercent = float64(usage.Memory) * 100 / float64(allocatable.Memory)
+	}
+
+	return utilization
+}
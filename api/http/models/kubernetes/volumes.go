@@ -30,6 +30,7 @@ type (
 		Name               string                              `json:"name"`
 		Namespace          string                              `json:"namespace"`
 		Storage            int64                               `json:"storage"`
+		Capacity           int64                               `json:"capacity"`
 		CreationDate       time.Time                           `json:"creationDate"`
 		AccessModes        []corev1.PersistentVolumeAccessMode `json:"accessModes,omitempty"`
 		VolumeName         string                              `json:"volumeName"`
@@ -10,13 +10,15 @@ import (
 type Transport struct {
 	httpTransport    *http.Transport
 	signatureService portainer.DigitalSignatureService
+	agentSecret      string
 }
 
 // NewTransport returns a new transport that can be used to send signed requests to a Portainer agent
-func NewTransport(signatureService portainer.DigitalSignatureService, httpTransport *http.Transport) *Transport {
+func NewTransport(signatureService portainer.DigitalSignatureService, httpTransport *http.Transport, agentSecret string) *Transport {
 	transport := &Transport{
 		httpTransport:    httpTransport,
 		signatureService: signatureService,
+		agentSecret:      agentSecret,
 	}
 
 	return transport
@@ -24,7 +26,7 @@ func NewTransport(signatureService portainer.DigitalSignatureService, httpTransp
 
 // RoundTrip is the implementation of the http.RoundTripper interface
 func (transport *Transport) RoundTrip(request *http.Request) (*http.Response, error) {
-	signature, err := transport.signatureService.CreateSignature(portainer.PortainerAgentSignatureMessage)
+	signature, err := transport.signatureService.CreateAgentSignature(transport.agentSecret)
 	if err != nil {
 		return nil, err
 	}
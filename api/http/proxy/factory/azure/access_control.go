@@ -32,7 +32,7 @@ func (transport *Transport) createAzureRequestContext(request *http.Request) (*a
 	if tokenData.Role != portainer.AdministratorRole {
 		context.isAdmin = false
 
-		teamMemberships, err := transport.dataStore.TeamMembership().TeamMembershipsByUserID(tokenData.ID)
+		teamMemberships, err := authorization.EffectiveTeamMemberships(transport.dataStore, tokenData.ID)
 		if err != nil {
 			return nil, err
 		}
@@ -1,6 +1,7 @@
 package factory
 
 import (
+	"crypto/tls"
 	"io"
 	"net/http"
 	"strings"
@@ -47,16 +48,21 @@ func (factory *ProxyFactory) newDockerHTTPProxy(endpoint *portainer.Endpoint) (h
 	}
 
 	endpointURL.Scheme = "http"
-	httpTransport := &http.Transport{}
 
-	if endpoint.TLSConfig.TLS || endpoint.TLSConfig.TLSSkipVerify {
-		config, err := crypto.CreateTLSConfigurationFromDisk(endpoint.TLSConfig.TLSCACertPath, endpoint.TLSConfig.TLSCertPath, endpoint.TLSConfig.TLSKeyPath, endpoint.TLSConfig.TLSSkipVerify)
-		if err != nil {
-			return nil, err
+	useTLS := endpoint.TLSConfig.TLS || endpoint.TLSConfig.TLSSkipVerify
+	if useTLS {
+		endpointURL.Scheme = "https"
+	}
+
+	httpTransport, err := factory.transports.get(endpoint, func() (*tls.Config, error) {
+		if !useTLS {
+			return nil, nil
 		}
 
-		httpTransport.TLSClientConfig = config
-		endpointURL.Scheme = "https"
+		return crypto.CreateTLSConfigurationFromDisk(endpoint.TLSConfig.TLSCACertPath, endpoint.TLSConfig.TLSCertPath, endpoint.TLSConfig.TLSKeyPath, endpoint.TLSConfig.TLSSkipVerify)
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	transportParameters := &docker.TransportParameters{
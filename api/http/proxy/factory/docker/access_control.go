@@ -324,7 +324,48 @@ func (transport *Transport) findResourceControl(resourceIdentifier string, resou
 		}
 	}
 
-	return transport.newResourceControlFromPortainerLabels(resourceLabelsObject, resourceIdentifier, resourceType)
+	resourceControl, err := transport.newResourceControlFromPortainerLabels(resourceLabelsObject, resourceIdentifier, resourceType)
+	if err != nil || resourceControl != nil {
+		return resourceControl, err
+	}
+
+	return transport.newResourceControlFromOwnershipRules(resourceLabelsObject, resourceIdentifier, resourceType)
+}
+
+// newResourceControlFromOwnershipRules persists the resource control of a resource matching one of the ownership
+// rules, so that the resources deployed outside of Portainer are visible to the teams owning them
+func (transport *Transport) newResourceControlFromOwnershipRules(labelsObject map[string]any, resourceID string, resourceType portainer.ResourceControlType) (*portainer.ResourceControl, error) {
+	settings, err := transport.dataStore.Settings().Settings()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(settings.OwnershipRules) == 0 {
+		return nil, nil
+	}
+
+	teams, err := transport.dataStore.Team().ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	labels := make(map[string]string, len(labelsObject))
+	for key, value := range labelsObject {
+		if value, ok := value.(string); ok {
+			labels[key] = value
+		}
+	}
+
+	resourceControl := authorization.NewResourceControlFromOwnershipRules(settings.OwnershipRules, teams, resourceID, resourceType, labels)
+	if resourceControl == nil {
+		return nil, nil
+	}
+
+	if err := transport.dataStore.ResourceControl().Create(resourceControl); err != nil {
+		return nil, err
+	}
+
+	return resourceControl, nil
 }
 
 func getStackResourceIDFromLabels(resourceLabelsObject map[string]string, endpointID portainer.EndpointID) string {
@@ -9,10 +9,13 @@ import (
 	"strings"
 
 	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+	"github.com/portainer/portainer/api/docker/gpu"
 	"github.com/portainer/portainer/api/http/proxy/factory/utils"
 	"github.com/portainer/portainer/api/http/security"
 	"github.com/portainer/portainer/api/internal/authorization"
 
+	"github.com/docker/docker/api/types/container"
 	"github.com/docker/docker/client"
 	"github.com/segmentio/encoding/json"
 )
@@ -176,6 +179,8 @@ func (transport *Transport) decorateContainerCreationOperation(request *http.Req
 			CapAdd     []string       `json:"CapAdd"`
 			CapDrop    []string       `json:"CapDrop"`
 			Binds      []string       `json:"Binds"`
+
+			DeviceRequests []container.DeviceRequest `json:"DeviceRequests"`
 		} `json:"HostConfig"`
 	}
 
@@ -193,19 +198,47 @@ func (transport *Transport) decorateContainerCreationOperation(request *http.Req
 		return nil, err
 	}
 
-	if !isAdminOrEndpointAdmin {
-		securitySettings, err := transport.fetchEndpointSecuritySettings()
-		if err != nil {
+	body, err := io.ReadAll(request.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	partialContainer := &PartialContainer{}
+	if err := json.Unmarshal(body, partialContainer); err != nil {
+		return nil, err
+	}
+
+	request.Body = io.NopCloser(bytes.NewBuffer(body))
+
+	if gpu.Requested(partialContainer.HostConfig.DeviceRequests) {
+		var validationErr error
+		if err := transport.dataStore.ViewTx(func(tx dataservices.DataStoreTx) error {
+			// the GPUs of the environment may have changed since the proxy was created
+			endpoint, err := tx.Endpoint().Endpoint(transport.endpoint.ID)
+			if err != nil || !endpoint.EnableGPUManagement {
+				return err
+			}
+
+			inventory, err := gpu.EndpointInventory(tx, endpoint)
+			if err != nil {
+				return err
+			}
+
+			validationErr = gpu.Validate(partialContainer.HostConfig.DeviceRequests, inventory)
+
+			return nil
+		}); err != nil {
 			return nil, err
 		}
 
-		body, err := io.ReadAll(request.Body)
-		if err != nil {
-			return nil, err
+		if validationErr != nil {
+			return utils.WriteErrorResponse(validationErr.Error(), http.StatusBadRequest)
 		}
+	}
 
-		partialContainer := &PartialContainer{}
-		if err := json.Unmarshal(body, partialContainer); err != nil {
+	if !isAdminOrEndpointAdmin {
+		securitySettings, err := transport.fetchEndpointSecuritySettings()
+		if err != nil {
 			return nil, err
 		}
 
@@ -236,8 +269,6 @@ func (transport *Transport) decorateContainerCreationOperation(request *http.Req
 				}
 			}
 		}
-
-		request.Body = io.NopCloser(bytes.NewBuffer(body))
 	}
 
 	response, err := transport.executeDockerRequest(request)
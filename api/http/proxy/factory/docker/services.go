@@ -6,17 +6,24 @@ import (
 	"errors"
 	"io"
 	"net/http"
+	"time"
 
 	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/docker/images"
 	"github.com/portainer/portainer/api/http/proxy/factory/utils"
 	"github.com/portainer/portainer/api/internal/authorization"
 
 	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/client"
+	"github.com/rs/zerolog/log"
 	"github.com/segmentio/encoding/json"
 )
 
-const serviceObjectIdentifier = "ID"
+const (
+	serviceObjectIdentifier = "ID"
+	// imagePlatformTimeout bounds the resolution of the platforms of the image of a created service
+	imagePlatformTimeout = 10 * time.Second
+)
 
 func getInheritedResourceControlFromServiceLabels(dockerClient *client.Client, endpointID portainer.EndpointID, serviceID string, resourceControls []portainer.ResourceControl) (*portainer.ResourceControl, error) {
 	service, _, err := dockerClient.ServiceInspectWithRaw(context.Background(), serviceID, types.ServiceInspectOptions{})
@@ -93,6 +100,7 @@ func (transport *Transport) decorateServiceCreationOperation(request *http.Reque
 	type PartialService struct {
 		TaskTemplate struct {
 			ContainerSpec struct {
+				Image  string
 				Mounts []struct {
 					Type string
 				}
@@ -104,39 +112,111 @@ func (transport *Transport) decorateServiceCreationOperation(request *http.Reque
 		StatusCode: http.StatusForbidden,
 	}
 
-	isAdminOrEndpointAdmin, err := transport.isAdminOrEndpointAdmin(request)
+	body, err := io.ReadAll(request.Body)
 	if err != nil {
 		return nil, err
 	}
+	request.Body = io.NopCloser(bytes.NewBuffer(body))
 
-	if isAdminOrEndpointAdmin {
-		return transport.replaceRegistryAuthenticationHeader(request)
+	partialService := &PartialService{}
+	if err := json.Unmarshal(body, partialService); err != nil {
+		return nil, err
 	}
 
-	securitySettings, err := transport.fetchEndpointSecuritySettings()
+	isAdminOrEndpointAdmin, err := transport.isAdminOrEndpointAdmin(request)
 	if err != nil {
 		return nil, err
 	}
 
-	body, err := io.ReadAll(request.Body)
+	if !isAdminOrEndpointAdmin {
+		securitySettings, err := transport.fetchEndpointSecuritySettings()
+		if err != nil {
+			return nil, err
+		}
+
+		if !securitySettings.AllowBindMountsForRegularUsers && (len(partialService.TaskTemplate.ContainerSpec.Mounts) > 0) {
+			for _, mount := range partialService.TaskTemplate.ContainerSpec.Mounts {
+				if mount.Type == "bind" {
+					return forbiddenResponse, errors.New("forbidden to use bind mounts")
+				}
+			}
+		}
+	}
+
+	// the platforms of the image are resolved while the service is created
+	warnings := make(chan []string, 1)
+	go func() {
+		warnings <- transport.imagePlatformWarnings(partialService.TaskTemplate.ContainerSpec.Image)
+	}()
+
+	response, err := transport.replaceRegistryAuthenticationHeader(request)
+	if err != nil || (response.StatusCode != http.StatusCreated && response.StatusCode != http.StatusOK) {
+		return response, err
+	}
+
+	return response, appendServiceWarnings(response, <-warnings)
+}
+
+// imagePlatformWarnings returns a warning for the nodes of the Swarm cluster whose platform has no variant of an
+// image, nil when the image or the nodes cannot be inspected
+func (transport *Transport) imagePlatformWarnings(imageName string) []string {
+	if imageName == "" {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), imagePlatformTimeout)
+	defer cancel()
+
+	image, err := images.ParseImage(images.ParseImageOptions{Name: imageName})
 	if err != nil {
-		return nil, err
+		log.Debug().Err(err).Str("image", imageName).Msg("unable to parse the image of the service")
+
+		return nil
 	}
 
-	partialService := &PartialService{}
-	if err := json.Unmarshal(body, partialService); err != nil {
-		return nil, err
+	cli, err := transport.dockerClientFactory.CreateClient(transport.endpoint, "", nil)
+	if err != nil {
+		log.Debug().Err(err).Msg("unable to create a Docker client")
+
+		return nil
 	}
+	defer cli.Close()
 
-	if !securitySettings.AllowBindMountsForRegularUsers && (len(partialService.TaskTemplate.ContainerSpec.Mounts) > 0) {
-		for _, mount := range partialService.TaskTemplate.ContainerSpec.Mounts {
-			if mount.Type == "bind" {
-				return forbiddenResponse, errors.New("forbidden to use bind mounts")
-			}
-		}
+	nodes, err := cli.NodeList(ctx, types.NodeListOptions{})
+	if err != nil {
+		log.Debug().Err(err).Msg("unable to retrieve the Swarm nodes")
+
+		return nil
 	}
 
-	request.Body = io.NopCloser(bytes.NewBuffer(body))
+	digestClient := images.NewClientWithRegistry(images.NewRegistryClient(transport.dataStore), transport.dockerClientFactory)
+
+	manifest, err := digestClient.RemoteManifest(ctx, image)
+	if err != nil {
+		log.Debug().Err(err).Str("image", imageName).Msg("unable to retrieve the image manifest")
+
+		return nil
+	}
+
+	return images.PlatformWarnings(manifest, nodes)
+}
+
+// appendServiceWarnings appends warnings to the ones of a service creation response, printed by the Docker CLI
+func appendServiceWarnings(response *http.Response, warnings []string) error {
+	if len(warnings) == 0 {
+		return nil
+	}
+
+	responseObject, err := utils.GetResponseAsJSONObject(response)
+	if err != nil {
+		return err
+	}
+
+	existing, _ := responseObject["Warnings"].([]any)
+	for _, warning := range warnings {
+		existing = append(existing, warning)
+	}
+	responseObject["Warnings"] = existing
 
-	return transport.replaceRegistryAuthenticationHeader(request)
+	return utils.RewriteResponse(response, responseObject, response.StatusCode)
 }
@@ -12,12 +12,15 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"time"
 
 	portainer "github.com/portainer/portainer/api"
 	"github.com/portainer/portainer/api/dataservices"
+	"github.com/portainer/portainer/api/docker/quarantine"
 	"github.com/portainer/portainer/api/http/proxy/factory/utils"
 	"github.com/portainer/portainer/api/http/security"
 	"github.com/portainer/portainer/api/internal/authorization"
+	"github.com/portainer/portainer/api/internal/immutable"
 
 	dockerclient "github.com/portainer/portainer/api/docker/client"
 	"github.com/rs/zerolog/log"
@@ -26,6 +29,13 @@ import (
 
 var apiVersionRe = regexp.MustCompile(`(/v[0-9]\.[0-9]*)?`)
 
+// libpodPathRe matches the requests to the libpod API of the Podman engines, versioned as v4.0.0 instead of v1.41
+var libpodPathRe = regexp.MustCompile(`^(/v[0-9]+(\.[0-9]+)*)?/libpod(/.*)$`)
+
+// libpodReadPathRe matches the libpod API reads open to the non-administrators, the pods listing and inspection and
+// the engine information
+var libpodReadPathRe = regexp.MustCompile(`^/(_ping|info|version|pods/json|pods/[^/]+/json)$`)
+
 type (
 	// Transport is a custom transport for Docker API reverse proxy. It allows
 	// interception of requests and rewriting of responses.
@@ -108,6 +118,12 @@ var prefixProxyFuncMap = map[string]func(*Transport, *http.Request, string) (*ht
 func (transport *Transport) ProxyDockerRequest(request *http.Request) (*http.Response, error) {
 	unversionedPath := apiVersionRe.ReplaceAllString(request.URL.Path, "")
 
+	libpodPath := ""
+	if matches := libpodPathRe.FindStringSubmatch(request.URL.Path); matches != nil {
+		libpodPath = matches[3]
+		unversionedPath = "/libpod" + libpodPath
+	}
+
 	if transport.endpoint.Type == portainer.AgentOnDockerEnvironment || transport.endpoint.Type == portainer.EdgeAgentOnDockerEnvironment {
 		signature, err := transport.signatureService.CreateSignature(portainer.PortainerAgentSignatureMessage)
 		if err != nil {
@@ -118,6 +134,25 @@ func (transport *Transport) ProxyDockerRequest(request *http.Request) (*http.Res
 		request.Header.Set(portainer.PortainerAgentSignatureHeader, signature)
 	}
 
+	operation := authorization.DockerOperationAuthorization(request.Method, unversionedPath)
+	if allowed, err := security.AuthorizedRequestRoleOperation(transport.dataStore, request, transport.endpoint, operation); err != nil {
+		return nil, err
+	} else if !allowed {
+		return utils.WriteAccessDeniedResponse()
+	}
+
+	if transport.endpoint.Quarantine != nil && quarantine.IsDeployRequest(request.Method, unversionedPath) {
+		return utils.WriteErrorResponse(quarantine.ErrQuarantined.Error(), http.StatusConflict)
+	}
+
+	if immutable.IsChangeRequest(request.Method) && immutable.Locked(transport.endpoint, time.Now()) {
+		return utils.WriteErrorResponse(immutable.ErrImmutable.Error(), http.StatusForbidden)
+	}
+
+	if libpodPath != "" {
+		return transport.proxyLibpodRequest(request, libpodPath)
+	}
+
 	prefix := strings.Split(strings.TrimPrefix(unversionedPath, "/"), "/")[0]
 
 	if proxyFunc := prefixProxyFuncMap[prefix]; proxyFunc != nil {
@@ -402,6 +437,20 @@ func (transport *Transport) proxyTaskRequest(request *http.Request, unversionedP
 	}
 }
 
+// proxyLibpodRequest proxies the requests to the libpod API of the Podman environments, the pods are not covered by
+// the resource controls so only their reads are open to the non-administrators
+func (transport *Transport) proxyLibpodRequest(request *http.Request, libpodPath string) (*http.Response, error) {
+	if transport.endpoint.ContainerEngine != portainer.ContainerEnginePodman {
+		return utils.WriteErrorResponse("the libpod API is only available on Podman environments", http.StatusNotFound)
+	}
+
+	if request.Method == http.MethodGet && libpodReadPathRe.MatchString(libpodPath) {
+		return transport.executeDockerRequest(request)
+	}
+
+	return transport.administratorOperation(request)
+}
+
 func (transport *Transport) proxyBuildRequest(request *http.Request, _ string) (*http.Response, error) {
 	if err := transport.updateDefaultGitBranch(request); err != nil {
 		return nil, err
@@ -525,7 +574,7 @@ func (transport *Transport) restrictedResourceOperation(request *http.Request, r
 		}
 	}
 
-	teamMemberships, err := transport.dataStore.TeamMembership().TeamMembershipsByUserID(tokenData.ID)
+	teamMemberships, err := authorization.EffectiveTeamMemberships(transport.dataStore, tokenData.ID)
 	if err != nil {
 		return nil, err
 	}
@@ -745,7 +794,7 @@ func (transport *Transport) createRegistryAccessContext(request *http.Request) (
 
 	accessContext.isAdmin = false
 
-	teamMemberships, err := transport.dataStore.TeamMembership().TeamMembershipsByUserID(tokenData.ID)
+	teamMemberships, err := authorization.EffectiveTeamMemberships(transport.dataStore, tokenData.ID)
 	if err != nil {
 		return nil, err
 	}
@@ -779,7 +828,7 @@ func (transport *Transport) createOperationContext(request *http.Request) (*rest
 
 	operationContext.isAdmin = false
 
-	teamMemberships, err := transport.dataStore.TeamMembership().TeamMembershipsByUserID(tokenData.ID)
+	teamMemberships, err := authorization.EffectiveTeamMemberships(transport.dataStore, tokenData.ID)
 	if err != nil {
 		return nil, err
 	}
@@ -24,6 +24,7 @@ type (
 		kubernetesTokenCacheManager *kubernetes.TokenCacheManager
 		gitService                  portainer.GitService
 		snapshotService             portainer.SnapshotService
+		transports                  *transportPool
 	}
 )
 
@@ -38,14 +39,27 @@ func NewProxyFactory(dataStore dataservices.DataStore, signatureService portaine
 		kubernetesTokenCacheManager: kubernetesTokenCacheManager,
 		gitService:                  gitService,
 		snapshotService:             snapshotService,
+		transports:                  newTransportPool(DefaultTransportSettings()),
 	}
 }
 
+// SetTransportSettings tunes the pooling of the upstream connections of the environment(endpoint) proxies
+func (factory *ProxyFactory) SetTransportSettings(settings TransportSettings) {
+	factory.transports.setSettings(settings)
+}
+
+// RemoveEndpointTransport closes the pooled upstream connections of an environment(endpoint)
+func (factory *ProxyFactory) RemoveEndpointTransport(endpointID portainer.EndpointID) {
+	factory.transports.remove(endpointID)
+}
+
 // NewEndpointProxy returns a new reverse proxy (filesystem based or HTTP) to an environment(endpoint) API server
 func (factory *ProxyFactory) NewEndpointProxy(endpoint *portainer.Endpoint) (http.Handler, error) {
 	switch endpoint.Type {
 	case portainer.AzureEnvironment:
 		return newAzureProxy(endpoint, factory.dataStore)
+	case portainer.NomadEnvironment:
+		return factory.newNomadProxy(endpoint)
 	case portainer.EdgeAgentOnKubernetesEnvironment, portainer.AgentOnKubernetesEnvironment, portainer.KubernetesLocalEnvironment:
 		return factory.newKubernetesProxy(endpoint)
 	}
@@ -1,7 +1,6 @@
 package kubernetes
 
 import (
-	"crypto/tls"
 	"net/http"
 	"strings"
 
@@ -15,13 +14,12 @@ type agentTransport struct {
 	signatureService portainer.DigitalSignatureService
 }
 
-// NewAgentTransport returns a new transport that can be used to send signed requests to a Portainer agent
-func NewAgentTransport(signatureService portainer.DigitalSignatureService, tlsConfig *tls.Config, tokenManager *tokenManager, endpoint *portainer.Endpoint, k8sClientFactory *cli.ClientFactory, dataStore dataservices.DataStore) *agentTransport {
+// NewAgentTransport returns a new transport that can be used to send signed requests to a Portainer agent through
+// the shared HTTP transport of the environment(endpoint)
+func NewAgentTransport(signatureService portainer.DigitalSignatureService, httpTransport *http.Transport, tokenManager *tokenManager, endpoint *portainer.Endpoint, k8sClientFactory *cli.ClientFactory, dataStore dataservices.DataStore) *agentTransport {
 	transport := &agentTransport{
 		baseTransport: newBaseTransport(
-			&http.Transport{
-				TLSClientConfig: tlsConfig,
-			},
+			httpTransport,
 			tokenManager,
 			endpoint,
 			k8sClientFactory,
@@ -49,7 +47,7 @@ func (transport *agentTransport) RoundTrip(request *http.Request) (*http.Respons
 		}
 	}
 
-	signature, err := transport.signatureService.CreateSignature(portainer.PortainerAgentSignatureMessage)
+	signature, err := transport.signatureService.CreateAgentSignature(transport.endpoint.AgentSecret)
 	if err != nil {
 		return nil, err
 	}
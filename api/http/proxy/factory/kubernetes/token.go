@@ -6,6 +6,7 @@ import (
 
 	portainer "github.com/portainer/portainer/api"
 	"github.com/portainer/portainer/api/dataservices"
+	"github.com/portainer/portainer/api/internal/authorization"
 	"github.com/rs/zerolog/log"
 )
 
@@ -46,7 +47,7 @@ func (manager *tokenManager) GetAdminServiceAccountToken() string {
 }
 
 func (manager *tokenManager) setupUserServiceAccounts(userID portainer.UserID, endpoint *portainer.Endpoint) error {
-	memberships, err := manager.dataStore.TeamMembership().TeamMembershipsByUserID(userID)
+	memberships, err := authorization.EffectiveTeamMemberships(manager.dataStore, userID)
 	if err != nil {
 		return err
 	}
@@ -77,7 +78,7 @@ func (manager *tokenManager) UpdateUserServiceAccountsForEndpoint(endpointID por
 		userIDs = append(userIDs, u)
 	}
 	for t := range endpoint.TeamAccessPolicies {
-		memberships, _ := manager.dataStore.TeamMembership().TeamMembershipsByTeamID(t)
+		memberships, _ := authorization.EffectiveTeamMembershipsByTeamID(manager.dataStore, t)
 		for _, membership := range memberships {
 			userIDs = append(userIDs, membership.UserID)
 		}
@@ -9,10 +9,14 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
+	"time"
 
 	portainer "github.com/portainer/portainer/api"
 	"github.com/portainer/portainer/api/dataservices"
+	"github.com/portainer/portainer/api/http/proxy/factory/utils"
 	"github.com/portainer/portainer/api/http/security"
+	"github.com/portainer/portainer/api/internal/authorization"
+	"github.com/portainer/portainer/api/internal/immutable"
 	"github.com/portainer/portainer/api/kubernetes/cli"
 
 	"github.com/pkg/errors"
@@ -43,6 +47,19 @@ func newBaseTransport(httpTransport *http.Transport, tokenManager *tokenManager,
 // proxyKubernetesRequest intercepts a Kubernetes API request and apply logic based
 // on the requested operation.
 func (transport *baseTransport) proxyKubernetesRequest(request *http.Request) (*http.Response, error) {
+	operation := authorization.KubernetesOperationAuthorization(request.Method, request.URL.Path)
+	if allowed, err := security.AuthorizedRequestRoleOperation(transport.dataStore, request, transport.endpoint, operation); err != nil {
+		return nil, err
+	} else if !allowed {
+		return utils.WriteAccessDeniedResponse()
+	}
+
+	// the access reviews are read-only requests despite their method
+	if immutable.IsChangeRequest(request.Method) && !strings.Contains(request.URL.Path, "/apis/authorization.k8s.io/") &&
+		immutable.Locked(transport.endpoint, time.Now()) {
+		return utils.WriteErrorResponse(immutable.ErrImmutable.Error(), http.StatusForbidden)
+	}
+
 	// URL path examples:
 	// http://localhost:9000/api/endpoints/3/kubernetes/api/v1/namespaces
 	// http://localhost:9000/api/endpoints/3/kubernetes/apis/apps/v1/namespaces/default/deployments
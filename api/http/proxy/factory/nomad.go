@@ -0,0 +1,34 @@
+package factory
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/url"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/crypto"
+	"github.com/portainer/portainer/api/http/proxy/factory/nomad"
+)
+
+func (factory *ProxyFactory) newNomadProxy(endpoint *portainer.Endpoint) (http.Handler, error) {
+	remoteURL, err := url.Parse(endpoint.URL)
+	if err != nil {
+		return nil, err
+	}
+
+	httpTransport, err := factory.transports.get(endpoint, func() (*tls.Config, error) {
+		if !endpoint.TLSConfig.TLS {
+			return nil, nil
+		}
+
+		return crypto.CreateTLSConfigurationFromDisk(endpoint.TLSConfig.TLSCACertPath, endpoint.TLSConfig.TLSCertPath, endpoint.TLSConfig.TLSKeyPath, endpoint.TLSConfig.TLSSkipVerify)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	proxy := newSingleHostReverseProxyWithHostHeader(remoteURL)
+	proxy.Transport = nomad.NewTransport(httpTransport, endpoint)
+
+	return proxy, nil
+}
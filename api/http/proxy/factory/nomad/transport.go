@@ -0,0 +1,82 @@
+package nomad
+
+import (
+	"net/http"
+	"path"
+	"strings"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/http/proxy/factory/utils"
+	"github.com/portainer/portainer/api/http/security"
+	"github.com/portainer/portainer/api/nomad"
+)
+
+var (
+	// deniedPaths are never proxied, they manage the cluster or expose its secrets such as the ACL tokens
+	deniedPaths = []string{"/v1/acl", "/v1/var", "/v1/vars", "/v1/operator", "/v1/agent"}
+	// readPaths are the parts of the API the non administrators can read
+	readPaths = []string{"/v1/jobs", "/v1/job", "/v1/allocations", "/v1/allocation", "/v1/nodes", "/v1/evaluations", "/v1/client/fs/logs"}
+)
+
+// Transport is an http.RoundTripper proxying the requests to the Nomad API with the ACL token of the
+// environment(endpoint), the non administrators can only read the jobs, allocations, nodes, evaluations and logs
+type Transport struct {
+	httpTransport *http.Transport
+	token         string
+}
+
+// NewTransport returns a new transport proxying the requests to the Nomad API of the environment(endpoint)
+func NewTransport(httpTransport *http.Transport, endpoint *portainer.Endpoint) *Transport {
+	transport := &Transport{httpTransport: httpTransport}
+
+	if endpoint.Nomad != nil {
+		transport.token = endpoint.Nomad.Token
+	}
+
+	return transport
+}
+
+// RoundTrip is the implementation of the http.RoundTripper interface
+func (transport *Transport) RoundTrip(request *http.Request) (*http.Response, error) {
+	tokenData, err := security.RetrieveTokenData(request)
+	if err != nil {
+		return nil, err
+	}
+
+	// the path is forwarded as checked, the escaped and relative forms cannot reach another part of the API
+	request.URL.Path = path.Clean("/" + request.URL.Path)
+	request.URL.RawPath = ""
+
+	if matchesAny(request.URL.Path, deniedPaths) {
+		return utils.WriteAccessDeniedResponse()
+	}
+
+	if tokenData.Role != portainer.AdministratorRole && (!isReadMethod(request.Method) || !matchesAny(request.URL.Path, readPaths)) {
+		return utils.WriteAccessDeniedResponse()
+	}
+
+	// the clients never choose the ACL token the requests are sent with
+	request.Header.Del(nomad.TokenHeader)
+	request.Header.Del("Authorization")
+
+	if transport.token != "" {
+		request.Header.Set(nomad.TokenHeader, transport.token)
+	}
+
+	return transport.httpTransport.RoundTrip(request)
+}
+
+func isReadMethod(method string) bool {
+	return method == http.MethodGet || method == http.MethodHead
+}
+
+// matchesAny returns true when the path is one of the prefixes or below one of them
+func matchesAny(p string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if p == prefix || strings.HasPrefix(p, prefix+"/") {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,59 @@
+package nomad
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/http/security"
+	"github.com/portainer/portainer/api/nomad"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransport_RoundTrip(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "acl-token", r.Header.Get(nomad.TokenHeader))
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	transport := NewTransport(&http.Transport{}, &portainer.Endpoint{Nomad: &portainer.NomadData{Token: "acl-token"}})
+
+	roundTrip := func(role portainer.UserRole, method, path string) int {
+		req := httptest.NewRequest(method, srv.URL+path, nil)
+		req.RequestURI = ""
+		req = req.WithContext(security.StoreTokenData(req, &portainer.TokenData{ID: 2, Role: role}))
+
+		resp, err := transport.RoundTrip(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		return resp.StatusCode
+	}
+
+	t.Run("denied paths", func(t *testing.T) {
+		for _, path := range []string{"/v1/acl/tokens", "/v1/acl/token/accessor", "/v1/var/secret", "/v1/vars", "/v1/operator/raft/configuration", "/v1/agent/self"} {
+			assert.Equal(t, http.StatusForbidden, roundTrip(portainer.StandardUserRole, http.MethodGet, path), path)
+			assert.Equal(t, http.StatusForbidden, roundTrip(portainer.AdministratorRole, http.MethodGet, path), path)
+		}
+	})
+
+	t.Run("read paths of the non administrators", func(t *testing.T) {
+		for _, path := range []string{"/v1/jobs", "/v1/job/web/allocations", "/v1/allocations", "/v1/allocation/id", "/v1/nodes", "/v1/evaluations", "/v1/client/fs/logs/id"} {
+			assert.Equal(t, http.StatusOK, roundTrip(portainer.StandardUserRole, http.MethodGet, path), path)
+		}
+
+		assert.Equal(t, http.StatusForbidden, roundTrip(portainer.StandardUserRole, http.MethodGet, "/v1/namespaces"))
+		assert.Equal(t, http.StatusForbidden, roundTrip(portainer.StandardUserRole, http.MethodPost, "/v1/jobs"))
+		assert.Equal(t, http.StatusForbidden, roundTrip(portainer.StandardUserRole, http.MethodGet, "/v1/jobs/../acl/tokens"))
+		assert.Equal(t, http.StatusForbidden, roundTrip(portainer.StandardUserRole, http.MethodGet, "/v1/jobs/%2e%2e/acl/tokens"))
+	})
+
+	t.Run("administrators", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, roundTrip(portainer.AdministratorRole, http.MethodPost, "/v1/jobs"))
+		assert.Equal(t, http.StatusOK, roundTrip(portainer.AdministratorRole, http.MethodGet, "/v1/namespaces"))
+	})
+}
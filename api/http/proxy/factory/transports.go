@@ -0,0 +1,130 @@
+package factory
+
+import (
+	"crypto/tls"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+)
+
+// TransportSettings tune the pooling of the upstream connections of the environment(endpoint) proxies
+type TransportSettings struct {
+	// Maximum number of idle connections kept across all the hosts of an environment(endpoint)
+	MaxIdleConns int
+	// Maximum number of idle connections kept per host
+	MaxIdleConnsPerHost int
+	// How long an idle connection is kept before being closed
+	IdleConnTimeout time.Duration
+	// Number of TLS sessions cached to resume the handshakes with the environment(endpoint), 0 disables the cache
+	TLSSessionCacheSize int
+}
+
+// DefaultTransportSettings returns the default pooling of the upstream connections
+func DefaultTransportSettings() TransportSettings {
+	return TransportSettings{
+		MaxIdleConns:        100,
+		MaxIdleConnsPerHost: 20,
+		IdleConnTimeout:     90 * time.Second,
+		TLSSessionCacheSize: 64,
+	}
+}
+
+// pooledTransport is a transport shared by the proxies of an environment(endpoint), with the URL and TLS
+// configuration it was created for
+type pooledTransport struct {
+	transport *http.Transport
+	url       string
+	tls       portainer.TLSConfiguration
+}
+
+// transportPool keeps one HTTP transport per environment(endpoint) so that the proxies recreated for it reuse its idle
+// connections and TLS sessions
+type transportPool struct {
+	mu         sync.Mutex
+	settings   TransportSettings
+	transports map[portainer.EndpointID]*pooledTransport
+}
+
+func newTransportPool(settings TransportSettings) *transportPool {
+	return &transportPool{
+		settings:   settings,
+		transports: make(map[portainer.EndpointID]*pooledTransport),
+	}
+}
+
+// get returns the transport of the environment(endpoint), it is replaced when the URL or the TLS configuration of the
+// environment(endpoint) changed. newTLSConfig is only called to create a transport and may return nil for plain HTTP
+func (pool *transportPool) get(endpoint *portainer.Endpoint, newTLSConfig func() (*tls.Config, error)) (*http.Transport, error) {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+
+	if pooled, ok := pool.transports[endpoint.ID]; ok {
+		if pooled.url == endpoint.URL && pooled.tls == endpoint.TLSConfig {
+			return pooled.transport, nil
+		}
+
+		pooled.transport.CloseIdleConnections()
+	}
+
+	tlsConfig, err := newTLSConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	transport := pool.newTransport(tlsConfig)
+	pool.transports[endpoint.ID] = &pooledTransport{
+		transport: transport,
+		url:       endpoint.URL,
+		tls:       endpoint.TLSConfig,
+	}
+
+	return transport, nil
+}
+
+func (pool *transportPool) newTransport(tlsConfig *tls.Config) *http.Transport {
+	transport := &http.Transport{
+		DialContext: (&net.Dialer{
+			Timeout:   30 * time.Second,
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+		MaxIdleConns:          pool.settings.MaxIdleConns,
+		MaxIdleConnsPerHost:   pool.settings.MaxIdleConnsPerHost,
+		IdleConnTimeout:       pool.settings.IdleConnTimeout,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: 1 * time.Second,
+		TLSClientConfig:       tlsConfig,
+	}
+
+	if tlsConfig != nil && pool.settings.TLSSessionCacheSize > 0 {
+		tlsConfig.ClientSessionCache = tls.NewLRUClientSessionCache(pool.settings.TLSSessionCacheSize)
+	}
+
+	return transport
+}
+
+// remove closes the idle connections of the transport of the environment(endpoint) and forgets it
+func (pool *transportPool) remove(endpointID portainer.EndpointID) {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+
+	if pooled, ok := pool.transports[endpointID]; ok {
+		pooled.transport.CloseIdleConnections()
+		delete(pool.transports, endpointID)
+	}
+}
+
+// setSettings applies the settings to the transports created from now on, the existing ones are dropped
+func (pool *transportPool) setSettings(settings TransportSettings) {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+
+	pool.settings = settings
+
+	for id, pooled := range pool.transports {
+		pooled.transport.CloseIdleConnections()
+		delete(pool.transports, id)
+	}
+}
@@ -0,0 +1,63 @@
+package factory
+
+import (
+	"crypto/tls"
+	"testing"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransportPool(t *testing.T) {
+	is := require.New(t)
+
+	pool := newTransportPool(TransportSettings{
+		MaxIdleConns:        10,
+		MaxIdleConnsPerHost: 5,
+		IdleConnTimeout:     time.Minute,
+		TLSSessionCacheSize: 8,
+	})
+
+	calls := 0
+	newTLSConfig := func() (*tls.Config, error) {
+		calls++
+
+		return &tls.Config{}, nil
+	}
+
+	endpoint := &portainer.Endpoint{ID: 1, URL: "tcp://agent:9001", TLSConfig: portainer.TLSConfiguration{TLS: true}}
+
+	transport, err := pool.get(endpoint, newTLSConfig)
+	is.NoError(err)
+	is.Equal(10, transport.MaxIdleConns)
+	is.Equal(5, transport.MaxIdleConnsPerHost)
+	is.Equal(time.Minute, transport.IdleConnTimeout)
+	is.NotNil(transport.TLSClientConfig.ClientSessionCache)
+
+	// the proxies recreated for the environment share its transport
+	same, err := pool.get(endpoint, newTLSConfig)
+	is.NoError(err)
+	is.Same(transport, same)
+	is.Equal(1, calls)
+
+	// a new transport is created when the environment moved
+	endpoint.URL = "tcp://agent-2:9001"
+	moved, err := pool.get(endpoint, newTLSConfig)
+	is.NoError(err)
+	is.NotSame(transport, moved)
+	is.Equal(2, calls)
+
+	pool.remove(endpoint.ID)
+	removed, err := pool.get(endpoint, newTLSConfig)
+	is.NoError(err)
+	is.NotSame(moved, removed)
+
+	// plain HTTP environments have no TLS configuration
+	plain, err := pool.get(&portainer.Endpoint{ID: 2, URL: "tcp://docker:2375"}, func() (*tls.Config, error) {
+		return nil, nil
+	})
+	is.NoError(err)
+	is.Nil(plain.TLSClientConfig)
+}
@@ -59,6 +59,11 @@ func getBody(body io.ReadCloser, contentType string, isGzip bool) (any, error) {
 }
 
 func marshal(contentType string, data any) ([]byte, error) {
+	// the responses created by the proxies have no content type yet, they are written as JSON
+	if contentType == "" {
+		return json.Marshal(data)
+	}
+
 	// Note: contentType can look like: "application/json" or "application/json; charset=utf-8"
 	mediaType, _, err := mime.ParseMediaType(contentType)
 	if err != nil {
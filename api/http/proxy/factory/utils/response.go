@@ -69,6 +69,14 @@ func WriteAccessDeniedResponse() (*http.Response, error) {
 	return response, err
 }
 
+// WriteErrorResponse will create a new response with the specified error message and status code
+func WriteErrorResponse(message string, statusCode int) (*http.Response, error) {
+	response := &http.Response{}
+	err := RewriteResponse(response, errorResponse{Message: message}, statusCode)
+
+	return response, err
+}
+
 // RewriteAccessDeniedResponse will overwrite the existing response with an access denied response
 func RewriteAccessDeniedResponse(response *http.Response) error {
 	return RewriteResponse(response, errorResponse{Message: "access denied to resource"}, http.StatusForbidden)
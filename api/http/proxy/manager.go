@@ -6,17 +6,25 @@ import (
 	"net/http"
 
 	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/chaos"
 	"github.com/portainer/portainer/api/dataservices"
 	dockerclient "github.com/portainer/portainer/api/docker/client"
 	"github.com/portainer/portainer/api/http/proxy/factory"
 	"github.com/portainer/portainer/api/http/proxy/factory/kubernetes"
 	"github.com/portainer/portainer/api/kubernetes/cli"
+	"github.com/portainer/portainer/pkg/featureflags"
 
 	cmap "github.com/orcaman/concurrent-map"
 )
 
 var ErrProxyFactoryNotInitialized = errors.New("proxy factory not initialized")
 
+// endpointProxy is a registered proxy, with the URL of the environment(endpoint) it was created for
+type endpointProxy struct {
+	handler http.Handler
+	url     string
+}
+
 // Manager represents a service used to manage proxies to environments (endpoints) and extensions.
 type Manager struct {
 	proxyFactory     *factory.ProxyFactory
@@ -36,6 +44,17 @@ func (manager *Manager) NewProxyFactory(dataStore dataservices.DataStore, signat
 	manager.proxyFactory = factory.NewProxyFactory(dataStore, signatureService, tunnelService, clientFactory, kubernetesClientFactory, kubernetesTokenCacheManager, gitService, snapshotService)
 }
 
+// SetTransportSettings tunes the pooling of the upstream connections of the environment(endpoint) proxies
+func (manager *Manager) SetTransportSettings(settings factory.TransportSettings) error {
+	if manager.proxyFactory == nil {
+		return ErrProxyFactoryNotInitialized
+	}
+
+	manager.proxyFactory.SetTransportSettings(settings)
+
+	return nil
+}
+
 // CreateAndRegisterEndpointProxy creates a new HTTP reverse proxy based on environment(endpoint) properties and adds it to the registered proxies.
 // It can also be used to create a new HTTP reverse proxy and replace an already registered proxy.
 func (manager *Manager) CreateAndRegisterEndpointProxy(endpoint *portainer.Endpoint) (http.Handler, error) {
@@ -48,7 +67,11 @@ func (manager *Manager) CreateAndRegisterEndpointProxy(endpoint *portainer.Endpo
 		return nil, err
 	}
 
-	manager.endpointProxies.Set(fmt.Sprint(endpoint.ID), proxy)
+	if featureflags.IsEnabled(chaos.Feature) {
+		proxy = chaos.DefaultRegistry.Middleware(endpoint.ID, proxy)
+	}
+
+	manager.endpointProxies.Set(fmt.Sprint(endpoint.ID), endpointProxy{handler: proxy, url: endpoint.URL})
 
 	return proxy, nil
 }
@@ -63,14 +86,15 @@ func (manager *Manager) CreateAgentProxyServer(endpoint *portainer.Endpoint) (*f
 	return manager.proxyFactory.NewAgentProxy(endpoint)
 }
 
-// GetEndpointProxy returns the proxy associated to a key
+// GetEndpointProxy returns the proxy associated to a key, nil when the URL of the environment(endpoint) changed
+// since the proxy was created, e.g. when it failed over to another agent replica
 func (manager *Manager) GetEndpointProxy(endpoint *portainer.Endpoint) http.Handler {
 	proxy, ok := manager.endpointProxies.Get(fmt.Sprint(endpoint.ID))
-	if !ok {
+	if !ok || proxy.(endpointProxy).url != endpoint.URL {
 		return nil
 	}
 
-	return proxy.(http.Handler)
+	return proxy.(endpointProxy).handler
 }
 
 // DeleteEndpointProxy deletes the proxy associated to a key
@@ -79,6 +103,10 @@ func (manager *Manager) GetEndpointProxy(endpoint *portainer.Endpoint) http.Hand
 func (manager *Manager) DeleteEndpointProxy(endpointID portainer.EndpointID) {
 	manager.endpointProxies.Remove(fmt.Sprint(endpointID))
 
+	if manager.proxyFactory != nil {
+		manager.proxyFactory.RemoveEndpointTransport(endpointID)
+	}
+
 	if manager.k8sClientFactory != nil {
 		manager.k8sClientFactory.RemoveKubeClient(endpointID)
 	}
@@ -4,6 +4,8 @@ import (
 	"net/http"
 
 	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+	"github.com/portainer/portainer/api/internal/authorization"
 )
 
 // IsAdmin returns true if the logged-in user is an admin
@@ -128,6 +130,51 @@ func AuthorizedEndpointAccess(endpoint *portainer.Endpoint, endpointGroup *porta
 	return true
 }
 
+// AuthorizedRequestRoleOperation returns whether the role of the user of a request on an environment(endpoint) grants
+// the authorization of an operation, the administrators are always authorized.
+func AuthorizedRequestRoleOperation(dataStore dataservices.DataStore, r *http.Request, endpoint *portainer.Endpoint, operation portainer.Authorization) (bool, error) {
+	tokenData, err := RetrieveTokenData(r)
+	if err != nil {
+		return false, err
+	}
+
+	if tokenData.Role == portainer.AdministratorRole {
+		return true, nil
+	}
+
+	var allowed bool
+	err = dataStore.ViewTx(func(tx dataservices.DataStoreTx) error {
+		allowed, err = AuthorizedEndpointRoleOperation(tx, endpoint, tokenData.ID, operation)
+
+		return err
+	})
+
+	return allowed, err
+}
+
+// AuthorizedEndpointRoleOperation returns whether the role of a non-administrator user on an environment(endpoint)
+// grants the authorization of an operation, the users without role on the environment are not restricted.
+func AuthorizedEndpointRoleOperation(tx dataservices.DataStoreTx, endpoint *portainer.Endpoint, userID portainer.UserID, operation portainer.Authorization) (bool, error) {
+	memberships, err := authorization.EffectiveTeamMemberships(tx, userID)
+	if err != nil {
+		return false, err
+	}
+
+	endpointGroup, err := tx.EndpointGroup().Read(endpoint.GroupID)
+	if err != nil {
+		return false, err
+	}
+
+	roles, err := tx.Role().ReadAll()
+	if err != nil {
+		return false, err
+	}
+
+	authorizations, restricted := authorization.EndpointRoleAuthorizations(endpoint, endpointGroup, userID, memberships, roles)
+
+	return !restricted || authorizations[operation], nil
+}
+
 // authorizedEndpointGroupAccess ensure that the user can access the specified environment(endpoint) group.
 // It will check if the user is part of the authorized users or part of a team that is
 // listed in the authorized teams.
@@ -2,6 +2,8 @@ package security
 
 import (
 	"net/http"
+	"slices"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -10,9 +12,11 @@ import (
 	"github.com/portainer/portainer/api/apikey"
 	"github.com/portainer/portainer/api/dataservices"
 	httperrors "github.com/portainer/portainer/api/http/errors"
+	"github.com/portainer/portainer/api/internal/authorization"
 	"github.com/portainer/portainer/pkg/featureflags"
 	httperror "github.com/portainer/portainer/pkg/libhttp/error"
 
+	"github.com/gorilla/mux"
 	"github.com/pkg/errors"
 	"github.com/rs/zerolog/log"
 )
@@ -20,6 +24,12 @@ import (
 const apiKeyHeader = "X-API-KEY"
 const jwtTokenHeader = "Authorization"
 
+// endpointRoutePrefixes are the route templates whose {id} variable identifies an environment(endpoint)
+var endpointRoutePrefixes = []string{"/endpoints/{id}", "/docker/{id}", "/kubernetes/{id}", "/open_amt/{id}"}
+
+// statusAPIKeyPaths are the read-only endpoints the API keys of the status scope can access
+var statusAPIKeyPaths = []string{"/system/status/endpoints", "/system/status/stacks"}
+
 type (
 	BouncerService interface {
 		PublicAccess(http.Handler) http.Handler
@@ -30,6 +40,7 @@ type (
 		EdgeComputeOperation(http.Handler) http.Handler
 
 		AuthorizedEndpointOperation(*http.Request, *portainer.Endpoint) error
+		AuthorizedEndpointRoleOperation(*http.Request, *portainer.Endpoint, portainer.Authorization) error
 		AuthorizedEdgeEndpointOperation(*http.Request, *portainer.Endpoint) error
 		CookieAuthLookup(*http.Request) (*portainer.TokenData, error)
 		JWTAuthLookup(*http.Request) (*portainer.TokenData, error)
@@ -54,6 +65,9 @@ type (
 		IsTeamLeader    bool
 		UserID          portainer.UserID
 		UserMemberships []portainer.TeamMembership
+		// EndpointsBound restricts a service account to EndpointIDs, an empty list gives access to no environment
+		EndpointsBound bool
+		EndpointIDs    []portainer.EndpointID
 	}
 
 	// tokenLookup looks up a token in the request
@@ -62,6 +76,7 @@ type (
 
 var (
 	ErrInvalidKey = errors.New("Invalid API key")
+	ErrKeyScope   = errors.New("the API key is not allowed to access this endpoint")
 	ErrRevokedJWT = errors.New("the JWT has been revoked")
 )
 
@@ -149,11 +164,15 @@ func (bouncer *RequestBouncer) AuthorizedEndpointOperation(r *http.Request, endp
 		return err
 	}
 
+	if tokenData.EndpointsBound && !slices.Contains(tokenData.EndpointIDs, endpoint.ID) {
+		return httperrors.ErrEndpointAccessDenied
+	}
+
 	if tokenData.Role == portainer.AdministratorRole {
 		return nil
 	}
 
-	memberships, err := bouncer.dataStore.TeamMembership().TeamMembershipsByUserID(tokenData.ID)
+	memberships, err := authorization.EffectiveTeamMemberships(bouncer.dataStore, tokenData.ID)
 	if err != nil {
 		return err
 	}
@@ -170,6 +189,20 @@ func (bouncer *RequestBouncer) AuthorizedEndpointOperation(r *http.Request, endp
 	return nil
 }
 
+// AuthorizedEndpointRoleOperation verifies that the role of the user on an environment(endpoint) grants the
+// authorization of an operation. The users without role on the environment are not restricted, their access to the
+// environment being verified by AuthorizedEndpointOperation
+func (bouncer *RequestBouncer) AuthorizedEndpointRoleOperation(r *http.Request, endpoint *portainer.Endpoint, operation portainer.Authorization) error {
+	allowed, err := AuthorizedRequestRoleOperation(bouncer.dataStore, r, endpoint, operation)
+	if err != nil {
+		return err
+	} else if !allowed {
+		return httperrors.ErrOperationNotAllowed
+	}
+
+	return nil
+}
+
 // AuthorizedEdgeEndpointOperation verifies that the request was received from a valid Edge environment(endpoint)
 func (bouncer *RequestBouncer) AuthorizedEdgeEndpointOperation(r *http.Request, endpoint *portainer.Endpoint) error {
 	if endpoint.Type != portainer.EdgeAgentOnKubernetesEnvironment && endpoint.Type != portainer.EdgeAgentOnDockerEnvironment {
@@ -233,6 +266,12 @@ func (bouncer *RequestBouncer) mwCheckPortainerAuthorizations(next http.Handler,
 			return
 		}
 
+		// the administrator service accounts bound to environments can only manage them
+		if administratorOnly && tokenData.EndpointsBound && !boundEndpointRequest(r, tokenData.EndpointIDs) {
+			httperror.WriteError(w, http.StatusForbidden, "Access denied", httperrors.ErrEndpointAccessDenied)
+			return
+		}
+
 		if tokenData.Role == portainer.AdministratorRole {
 			next.ServeHTTP(w, r)
 			return
@@ -256,6 +295,35 @@ func (bouncer *RequestBouncer) mwCheckPortainerAuthorizations(next http.Handler,
 	})
 }
 
+// boundEndpointRequest returns true when the request targets one of the environments(endpoints) of endpointIDs
+func boundEndpointRequest(r *http.Request, endpointIDs []portainer.EndpointID) bool {
+	vars := mux.Vars(r)
+
+	id, ok := vars["endpointId"]
+	if !ok {
+		route := mux.CurrentRoute(r)
+		if route == nil {
+			return false
+		}
+
+		template, err := route.GetPathTemplate()
+		if err != nil || !slices.ContainsFunc(endpointRoutePrefixes, func(prefix string) bool {
+			return template == prefix || strings.HasPrefix(template, prefix+"/")
+		}) {
+			return false
+		}
+
+		id = vars["id"]
+	}
+
+	endpointID, err := strconv.Atoi(id)
+	if err != nil {
+		return false
+	}
+
+	return slices.Contains(endpointIDs, portainer.EndpointID(endpointID))
+}
+
 // mwUpgradeToRestrictedRequest will enhance the current request with
 // a new RestrictedRequestContext object.
 func (bouncer *RequestBouncer) mwUpgradeToRestrictedRequest(next http.Handler) http.Handler {
@@ -266,7 +334,7 @@ func (bouncer *RequestBouncer) mwUpgradeToRestrictedRequest(next http.Handler) h
 			return
 		}
 
-		requestContext, err := bouncer.newRestrictedContextRequest(tokenData.ID, tokenData.Role)
+		requestContext, err := bouncer.newRestrictedContextRequest(tokenData)
 		if err != nil {
 			httperror.WriteError(w, http.StatusInternalServerError, "Unable to create restricted request context ", err)
 			return
@@ -303,7 +371,11 @@ func (bouncer *RequestBouncer) mwAuthenticateFirst(tokenLookups []tokenLookup, n
 
 		for _, lookup := range tokenLookups {
 			resultToken, err := lookup(r)
-			if err != nil {
+			if errors.Is(err, ErrKeyScope) {
+				httperror.WriteError(w, http.StatusForbidden, "Access denied to resource", err)
+
+				return
+			} else if err != nil {
 				httperror.WriteError(w, http.StatusUnauthorized, "Invalid JWT token", httperrors.ErrUnauthorized)
 
 				return
@@ -423,11 +495,21 @@ func (bouncer *RequestBouncer) apiKeyLookup(r *http.Request) (*portainer.TokenDa
 		return nil, ErrInvalidKey
 	}
 
+	if !apiKeyScopeAllows(apiKey.Scope, r) {
+		return nil, ErrKeyScope
+	}
+
 	tokenData := &portainer.TokenData{
 		ID:       user.ID,
 		Username: user.Username,
 		Role:     user.Role,
 	}
+
+	if user.ServiceAccount {
+		tokenData.EndpointsBound = user.ServiceAccountBound
+		tokenData.EndpointIDs = user.ServiceAccountEndpointIDs
+	}
+
 	if _, _, err := bouncer.jwtService.GenerateToken(tokenData); err != nil {
 		log.Debug().Err(err).Msg("Failed to generate token")
 		return nil, errors.New("failed to generate token")
@@ -522,6 +604,18 @@ func extractAPIKey(r *http.Request) (string, bool) {
 	return "", false
 }
 
+// apiKeyScopeAllows returns whether an API key of the given scope can access the requested endpoint
+func apiKeyScopeAllows(scope portainer.APIKeyScope, r *http.Request) bool {
+	switch scope {
+	case "":
+		return true
+	case portainer.APIKeyScopeStatus:
+		return r.Method == http.MethodGet && slices.Contains(statusAPIKeyPaths, strings.TrimPrefix(r.URL.Path, "/api"))
+	}
+
+	return false
+}
+
 // MWSecureHeaders provides secure headers middleware for handlers.
 func MWSecureHeaders(next http.Handler, hsts, csp bool) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -538,15 +632,17 @@ func MWSecureHeaders(next http.Handler, hsts, csp bool) http.Handler {
 	})
 }
 
-func (bouncer *RequestBouncer) newRestrictedContextRequest(userID portainer.UserID, userRole portainer.UserRole) (*RestrictedRequestContext, error) {
-	if userRole == portainer.AdministratorRole {
+func (bouncer *RequestBouncer) newRestrictedContextRequest(tokenData *portainer.TokenData) (*RestrictedRequestContext, error) {
+	if tokenData.Role == portainer.AdministratorRole {
 		return &RestrictedRequestContext{
-			IsAdmin: true,
-			UserID:  userID,
+			IsAdmin:        true,
+			UserID:         tokenData.ID,
+			EndpointsBound: tokenData.EndpointsBound,
+			EndpointIDs:    tokenData.EndpointIDs,
 		}, nil
 	}
 
-	memberships, err := bouncer.dataStore.TeamMembership().TeamMembershipsByUserID(userID)
+	memberships, err := authorization.EffectiveTeamMemberships(bouncer.dataStore, tokenData.ID)
 	if err != nil {
 		return nil, err
 	}
@@ -560,9 +656,11 @@ func (bouncer *RequestBouncer) newRestrictedContextRequest(userID portainer.User
 
 	return &RestrictedRequestContext{
 		IsAdmin:         false,
-		UserID:          userID,
+		UserID:          tokenData.ID,
 		IsTeamLeader:    isTeamLeader,
 		UserMemberships: memberships,
+		EndpointsBound:  tokenData.EndpointsBound,
+		EndpointIDs:     tokenData.EndpointIDs,
 	}, nil
 }
 
@@ -14,6 +14,7 @@ import (
 	"github.com/portainer/portainer/api/internal/testhelpers"
 	"github.com/portainer/portainer/api/jwt"
 
+	"github.com/gorilla/mux"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -381,6 +382,34 @@ func Test_apiKeyLookup(t *testing.T) {
 
 		is.True(apiKeyUpdated.LastUsed > apiKey.LastUsed)
 	})
+
+	t.Run("status api-key lookup only succeeds for the status endpoints", func(t *testing.T) {
+		rawAPIKey, apiKey, err := apiKeyService.GenerateScopedApiKey(*user, "test", portainer.APIKeyScopeStatus)
+		is.NoError(err)
+		defer apiKeyService.DeleteAPIKey(apiKey.ID)
+
+		req := httptest.NewRequest(http.MethodGet, "/api/system/status/endpoints", nil)
+		req.Header.Add("x-api-key", rawAPIKey)
+
+		token, err := bouncer.apiKeyLookup(req)
+		is.NoError(err)
+		is.Equal(user.ID, token.ID)
+
+		for _, target := range []string{"/api/endpoints", "/api/stacks/1", "/api/system/status/stacks/1"} {
+			req := httptest.NewRequest(http.MethodGet, target, nil)
+			req.Header.Add("x-api-key", rawAPIKey)
+
+			token, err := bouncer.apiKeyLookup(req)
+			is.ErrorIs(err, ErrKeyScope)
+			is.Nil(token)
+		}
+
+		req = httptest.NewRequest(http.MethodDelete, "/api/system/status/stacks", nil)
+		req.Header.Add("x-api-key", rawAPIKey)
+
+		_, err = bouncer.apiKeyLookup(req)
+		is.ErrorIs(err, ErrKeyScope)
+	})
 }
 
 func Test_ShouldSkipCSRFCheck(t *testing.T) {
@@ -527,3 +556,79 @@ func TestJWTRevocation(t *testing.T) {
 
 	require.Equal(t, 1, revokeLen())
 }
+
+func Test_serviceAccountBinding(t *testing.T) {
+	is := assert.New(t)
+
+	_, store := datastore.MustNewTestStore(t, true, true)
+
+	jwtService, err := jwt.NewService("1h", store)
+	require.NoError(t, err)
+	apiKeyService := apikey.NewAPIKeyService(store.APIKeyRepository(), store.User())
+	bouncer := NewRequestBouncer(store, jwtService, apiKeyService)
+
+	router := mux.NewRouter()
+	router.Handle("/endpoints/{id}/settings", bouncer.AdminAccess(testHandler200))
+	router.Handle("/users", bouncer.AdminAccess(testHandler200))
+
+	newAPIKey := func(user *portainer.User) string {
+		require.NoError(t, store.User().Create(user))
+
+		rawAPIKey, _, err := apiKeyService.GenerateApiKey(*user, "test")
+		require.NoError(t, err)
+
+		return rawAPIKey
+	}
+
+	do := func(rawAPIKey, target string) int {
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		req.Header.Add(apiKeyHeader, rawAPIKey)
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		return rr.Code
+	}
+
+	authorizedEndpoint := func(rawAPIKey string, endpointID portainer.EndpointID) error {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Add(apiKeyHeader, rawAPIKey)
+
+		tokenData, err := bouncer.apiKeyLookup(req)
+		require.NoError(t, err)
+
+		req = req.WithContext(StoreTokenData(req, tokenData))
+
+		return bouncer.AuthorizedEndpointOperation(req, &portainer.Endpoint{ID: endpointID})
+	}
+
+	unbound := newAPIKey(&portainer.User{Username: "unbound", Role: portainer.AdministratorRole, ServiceAccount: true})
+	is.Equal(http.StatusOK, do(unbound, "/users"))
+	is.Equal(http.StatusOK, do(unbound, "/endpoints/2/settings"))
+	is.NoError(authorizedEndpoint(unbound, 2))
+
+	bound := newAPIKey(&portainer.User{
+		Username:                  "bound",
+		Role:                      portainer.AdministratorRole,
+		ServiceAccount:            true,
+		ServiceAccountBound:       true,
+		ServiceAccountEndpointIDs: []portainer.EndpointID{1},
+	})
+	is.Equal(http.StatusOK, do(bound, "/endpoints/1/settings"))
+	is.Equal(http.StatusForbidden, do(bound, "/endpoints/2/settings"))
+	is.Equal(http.StatusForbidden, do(bound, "/users"))
+	is.NoError(authorizedEndpoint(bound, 1))
+	is.Error(authorizedEndpoint(bound, 2))
+
+	// a bound service account without any environment has access to none of them
+	boundToNone := newAPIKey(&portainer.User{
+		Username:            "none",
+		Role:                portainer.AdministratorRole,
+		ServiceAccount:      true,
+		ServiceAccountBound: true,
+	})
+	is.Equal(http.StatusForbidden, do(boundToNone, "/endpoints/1/settings"))
+	is.Equal(http.StatusForbidden, do(boundToNone, "/users"))
+	is.Error(authorizedEndpoint(boundToNone, 1))
+	is.Empty(FilterEndpoints([]portainer.Endpoint{{ID: 1}}, nil, &RestrictedRequestContext{IsAdmin: true, EndpointsBound: true}))
+}
@@ -16,13 +16,24 @@ type (
 const (
 	contextAuthenticationKey contextKey = iota
 	contextRestrictedRequest
+	contextTokenDataRecorder
 )
 
 // StoreTokenData stores a TokenData object inside the request context and returns the enhanced context.
 func StoreTokenData(request *http.Request, tokenData *portainer.TokenData) context.Context {
+	if recorder, ok := request.Context().Value(contextTokenDataRecorder).(**portainer.TokenData); ok {
+		*recorder = tokenData
+	}
+
 	return context.WithValue(request.Context(), contextAuthenticationKey, tokenData)
 }
 
+// WithTokenDataRecorder returns a context in which the TokenData of the authenticated request is also written to
+// recorder, letting the middlewares wrapping the router know the user once the request has been served.
+func WithTokenDataRecorder(ctx context.Context, recorder **portainer.TokenData) context.Context {
+	return context.WithValue(ctx, contextTokenDataRecorder, recorder)
+}
+
 // RetrieveTokenData returns the TokenData object stored in the request context.
 func RetrieveTokenData(request *http.Request) (*portainer.TokenData, error) {
 	contextData := request.Context().Value(contextAuthenticationKey)
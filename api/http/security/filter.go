@@ -1,6 +1,8 @@
 package security
 
 import (
+	"slices"
+
 	portainer "github.com/portainer/portainer/api"
 )
 
@@ -71,7 +73,8 @@ func FilterUsers(users []portainer.User, context *RestrictedRequestContext) []po
 }
 
 // FilterRegistries filters registries based on user role and team memberships.
-// Non administrator users only have access to authorized registries.
+// Non administrator users only have access to authorized registries, and use the robot account of their team for the
+// Harbor registries that have one.
 func FilterRegistries(registries []portainer.Registry, user *portainer.User, teamMemberships []portainer.TeamMembership, endpointID portainer.EndpointID) []portainer.Registry {
 	if user.Role == portainer.AdministratorRole {
 		return registries
@@ -80,6 +83,7 @@ func FilterRegistries(registries []portainer.Registry, user *portainer.User, tea
 	n := 0
 	for _, registry := range registries {
 		if AuthorizedRegistryAccess(&registry, user, teamMemberships, endpointID) {
+			useTeamRobotAccount(&registry, user, teamMemberships)
 			registries[n] = registry
 			n++
 		}
@@ -88,9 +92,48 @@ func FilterRegistries(registries []portainer.Registry, user *portainer.User, tea
 	return registries[:n]
 }
 
+// useTeamRobotAccount replaces the credentials of a Harbor registry with the robot account of the team of the user
+// with the lowest identifier that has one
+func useTeamRobotAccount(registry *portainer.Registry, user *portainer.User, teamMemberships []portainer.TeamMembership) {
+	if registry.Type != portainer.HarborRegistry || len(registry.Harbor.TeamRobots) == 0 {
+		return
+	}
+
+	var robot *portainer.HarborRobotAccount
+	var robotTeamID portainer.TeamID
+
+	for _, membership := range teamMemberships {
+		if membership.UserID != user.ID {
+			continue
+		}
+
+		if teamRobot, ok := registry.Harbor.TeamRobots[membership.TeamID]; ok && (robot == nil || membership.TeamID < robotTeamID) {
+			robot = &teamRobot
+			robotTeamID = membership.TeamID
+		}
+	}
+
+	if robot == nil {
+		return
+	}
+
+	registry.Authentication = true
+	registry.Username = robot.Name
+	registry.Password = robot.Secret
+	registry.AccessToken = ""
+	registry.AccessTokenExpiry = 0
+}
+
 // FilterEndpoints filters environments(endpoints) based on user role and team memberships.
 // Non administrator only have access to authorized environments(endpoints) (can be inherited via endpoint groups).
+// The service accounts bound to environments only have access to them.
 func FilterEndpoints(endpoints []portainer.Endpoint, groups []portainer.EndpointGroup, context *RestrictedRequestContext) []portainer.Endpoint {
+	if context.EndpointsBound {
+		endpoints = slices.DeleteFunc(endpoints, func(endpoint portainer.Endpoint) bool {
+			return !slices.Contains(context.EndpointIDs, endpoint.ID)
+		})
+	}
+
 	if context.IsAdmin {
 		return endpoints
 	}
@@ -1,22 +1,42 @@
 package security
 
 import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
 	portainer "github.com/portainer/portainer/api"
 
+	"github.com/pkg/errors"
 	"github.com/rs/zerolog/log"
 )
 
+// pwnedPasswordsRangeURL is the k-anonymity API of haveibeenpwned.com, it returns the suffixes of the SHA-1 hashes
+// of the breached passwords starting with the given prefix
+const pwnedPasswordsRangeURL = "https://api.pwnedpasswords.com/range/"
+
 type PasswordStrengthChecker interface {
 	Check(password string) bool
+	Validate(password, username string) error
 }
 
 type passwordStrengthChecker struct {
-	settings settingsService
+	settings          settingsService
+	client            *http.Client
+	pwnedPasswordsURL string
 }
 
 func NewPasswordStrengthChecker(settings settingsService) *passwordStrengthChecker {
 	return &passwordStrengthChecker{
-		settings: settings,
+		settings:          settings,
+		client:            &http.Client{Timeout: 5 * time.Second},
+		pwnedPasswordsURL: pwnedPasswordsRangeURL,
 	}
 }
 
@@ -29,7 +49,129 @@ func (c *passwordStrengthChecker) Check(password string) bool {
 		return true
 	}
 
-	return len(password) >= s.InternalAuthSettings.RequiredPasswordLength
+	return checkPasswordStrength(password, &s.InternalAuthSettings) == nil
+}
+
+// Validate returns the first requirement of the password policy the password of a user does not meet
+func (c *passwordStrengthChecker) Validate(password, username string) error {
+	s, err := c.settings.Settings()
+	if err != nil {
+		log.Warn().Err(err).Msg("failed to fetch Portainer settings to validate user password")
+
+		return nil
+	}
+
+	if err := checkPasswordStrength(password, &s.InternalAuthSettings); err != nil {
+		return err
+	}
+
+	policy := s.InternalAuthSettings.PasswordPolicy
+
+	if policy.DisallowUsername && username != "" && strings.Contains(strings.ToLower(password), strings.ToLower(username)) {
+		return errors.New("the password must not contain the username")
+	}
+
+	if policy.CheckBreached {
+		breached, err := c.isBreached(password)
+		if err != nil {
+			// the logins must not depend on the availability of haveibeenpwned.com
+			log.Warn().Err(err).Msg("unable to check whether the password appears in a known breach")
+		} else if breached {
+			return errors.New("the password appears in a known data breach")
+		}
+	}
+
+	return nil
+}
+
+func checkPasswordStrength(password string, settings *portainer.InternalAuthSettings) error {
+	if len(password) < settings.RequiredPasswordLength {
+		return fmt.Errorf("the password must be at least %d characters long", settings.RequiredPasswordLength)
+	}
+
+	var lower, upper, digit, symbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsDigit(r):
+			digit = true
+		default:
+			symbol = true
+		}
+	}
+
+	policy := settings.PasswordPolicy
+
+	switch {
+	case policy.RequireLowercase && !lower:
+		return errors.New("the password must contain a lowercase letter")
+	case policy.RequireUppercase && !upper:
+		return errors.New("the password must contain an uppercase letter")
+	case policy.RequireDigit && !digit:
+		return errors.New("the password must contain a digit")
+	case policy.RequireSymbol && !symbol:
+		return errors.New("the password must contain a symbol")
+	}
+
+	return nil
+}
+
+// isBreached looks the password up in the breaches known by haveibeenpwned.com, only the first 5 characters of its
+// SHA-1 hash are sent
+func (c *passwordStrengthChecker) isBreached(password string) (bool, error) {
+	sum := sha1.Sum([]byte(password))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+
+	req, err := http.NewRequest(http.MethodGet, c.pwnedPasswordsURL+hash[:5], nil)
+	if err != nil {
+		return false, err
+	}
+
+	// pads the response with fake suffixes so that its size does not reveal the prefix
+	req.Header.Set("Add-Padding", "true")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return false, fmt.Errorf("the breached passwords API returned the status %d", resp.StatusCode)
+	}
+
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		suffix, count, ok := strings.Cut(strings.TrimSpace(scanner.Text()), ":")
+		if !ok || suffix != hash[5:] {
+			continue
+		}
+
+		// the padding suffixes have a count of 0
+		n, err := strconv.Atoi(count)
+
+		return err == nil && n > 0, nil
+	}
+
+	return false, scanner.Err()
+}
+
+// IsPasswordExpired returns whether the password of an internal user is older than the maximum age of the policy.
+// The age of the passwords set before it was tracked is unknown, it is tracked from their next login
+func IsPasswordExpired(policy *portainer.PasswordPolicy, user *portainer.User) bool {
+	if policy.MaxAge == "" || user.PasswordUpdatedAt == 0 {
+		return false
+	}
+
+	maxAge, err := time.ParseDuration(policy.MaxAge)
+	if err != nil || maxAge <= 0 {
+		return false
+	}
+
+	return time.Since(time.Unix(user.PasswordUpdatedAt, 0)) > maxAge
 }
 
 type settingsService interface {
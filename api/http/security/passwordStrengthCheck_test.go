@@ -1,9 +1,17 @@
 package security
 
 import (
+	"crypto/sha1"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
 	portainer "github.com/portainer/portainer/api"
+
+	"github.com/stretchr/testify/require"
 )
 
 func TestStrengthCheck(t *testing.T) {
@@ -36,14 +44,66 @@ func TestStrengthCheck(t *testing.T) {
 	}
 }
 
+func TestPasswordPolicy(t *testing.T) {
+	is := require.New(t)
+
+	breached := "Portainer123!"
+	sum := sha1.Sum([]byte(breached))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+
+	var requestedPrefix string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestedPrefix = strings.TrimPrefix(r.URL.Path, "/")
+
+		w.Write([]byte("0018A45C4D1DEF81644B54AB7F969B88D65:0\r\n" + hash[5:] + ":42\r\n"))
+	}))
+	defer srv.Close()
+
+	checker := NewPasswordStrengthChecker(settingsStub{minLength: 8, policy: portainer.PasswordPolicy{
+		RequireLowercase: true,
+		RequireUppercase: true,
+		RequireDigit:     true,
+		RequireSymbol:    true,
+		DisallowUsername: true,
+		CheckBreached:    true,
+	}})
+	checker.pwnedPasswordsURL = srv.URL + "/"
+
+	is.ErrorContains(checker.Validate("Short1!", "bob"), "at least 8 characters")
+	is.ErrorContains(checker.Validate("PORTAINER123!", "bob"), "lowercase")
+	is.ErrorContains(checker.Validate("portainer123!", "bob"), "uppercase")
+	is.ErrorContains(checker.Validate("Portainer!!!", "bob"), "digit")
+	is.ErrorContains(checker.Validate("Portainer123", "bob"), "symbol")
+	is.ErrorContains(checker.Validate("Bob-Portainer1", "bob"), "username")
+	is.False(checker.Check("Portainer123"))
+
+	is.ErrorContains(checker.Validate(breached, "bob"), "breach")
+	is.Equal(hash[:5], requestedPrefix)
+
+	is.NoError(checker.Validate("Portainer456!", "bob"))
+}
+
+func TestIsPasswordExpired(t *testing.T) {
+	is := require.New(t)
+
+	policy := &portainer.PasswordPolicy{MaxAge: "24h"}
+
+	is.True(IsPasswordExpired(policy, &portainer.User{PasswordUpdatedAt: time.Now().Add(-25 * time.Hour).Unix()}))
+	is.False(IsPasswordExpired(policy, &portainer.User{PasswordUpdatedAt: time.Now().Add(-23 * time.Hour).Unix()}))
+	is.False(IsPasswordExpired(policy, &portainer.User{}))
+	is.False(IsPasswordExpired(&portainer.PasswordPolicy{}, &portainer.User{PasswordUpdatedAt: 1}))
+}
+
 type settingsStub struct {
 	minLength int
+	policy    portainer.PasswordPolicy
 }
 
 func (s settingsStub) Settings() (*portainer.Settings, error) {
 	return &portainer.Settings{
 		InternalAuthSettings: portainer.InternalAuthSettings{
 			RequiredPasswordLength: s.minLength,
+			PasswordPolicy:         s.policy,
 		},
 	}, nil
 }
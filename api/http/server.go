@@ -3,6 +3,7 @@ package http
 import (
 	"context"
 	"crypto/tls"
+	"net"
 	"net/http"
 	"path/filepath"
 	"time"
@@ -11,15 +12,29 @@ import (
 	portainer "github.com/portainer/portainer/api"
 	"github.com/portainer/portainer/api/adminmonitor"
 	"github.com/portainer/portainer/api/apikey"
+	"github.com/portainer/portainer/api/auditlog"
+	"github.com/portainer/portainer/api/cloud"
 	"github.com/portainer/portainer/api/crypto"
 	"github.com/portainer/portainer/api/dataservices"
 	"github.com/portainer/portainer/api/docker"
 	dockerclient "github.com/portainer/portainer/api/docker/client"
+	"github.com/portainer/portainer/api/docker/containerjobs"
+	"github.com/portainer/portainer/api/docker/containerstats"
+	"github.com/portainer/portainer/api/docker/imageupdates"
+	"github.com/portainer/portainer/api/docker/orphans"
+	"github.com/portainer/portainer/api/docker/prunepolicies"
+	"github.com/portainer/portainer/api/docker/quarantine"
+	"github.com/portainer/portainer/api/fleetreport"
+	"github.com/portainer/portainer/api/helmrepositories"
 	"github.com/portainer/portainer/api/http/csrf"
 	"github.com/portainer/portainer/api/http/handler"
+	"github.com/portainer/portainer/api/http/handler/auditlogs"
 	"github.com/portainer/portainer/api/http/handler/auth"
 	"github.com/portainer/portainer/api/http/handler/backup"
+	"github.com/portainer/portainer/api/http/handler/chaos"
+	containerjobshandler "github.com/portainer/portainer/api/http/handler/containerjobs"
 	"github.com/portainer/portainer/api/http/handler/customtemplates"
+	"github.com/portainer/portainer/api/http/handler/dashboards"
 	dockerhandler "github.com/portainer/portainer/api/http/handler/docker"
 	"github.com/portainer/portainer/api/http/handler/edgegroups"
 	"github.com/portainer/portainer/api/http/handler/edgejobs"
@@ -30,15 +45,21 @@ import (
 	"github.com/portainer/portainer/api/http/handler/endpointproxy"
 	"github.com/portainer/portainer/api/http/handler/endpoints"
 	"github.com/portainer/portainer/api/http/handler/file"
+	"github.com/portainer/portainer/api/http/handler/fixtures"
+	fleetstackshandler "github.com/portainer/portainer/api/http/handler/fleetstacks"
 	"github.com/portainer/portainer/api/http/handler/gitops"
 	"github.com/portainer/portainer/api/http/handler/helm"
 	"github.com/portainer/portainer/api/http/handler/hostmanagement/openamt"
 	kubehandler "github.com/portainer/portainer/api/http/handler/kubernetes"
 	"github.com/portainer/portainer/api/http/handler/ldap"
 	"github.com/portainer/portainer/api/http/handler/motd"
+	"github.com/portainer/portainer/api/http/handler/plugins"
+	prunepolicieshandler "github.com/portainer/portainer/api/http/handler/prunepolicies"
 	"github.com/portainer/portainer/api/http/handler/registries"
+	"github.com/portainer/portainer/api/http/handler/reports"
 	"github.com/portainer/portainer/api/http/handler/resourcecontrols"
 	"github.com/portainer/portainer/api/http/handler/roles"
+	"github.com/portainer/portainer/api/http/handler/sessionrecordings"
 	"github.com/portainer/portainer/api/http/handler/settings"
 	sslhandler "github.com/portainer/portainer/api/http/handler/ssl"
 	"github.com/portainer/portainer/api/http/handler/stacks"
@@ -64,10 +85,17 @@ import (
 	"github.com/portainer/portainer/api/internal/upgrade"
 	k8s "github.com/portainer/portainer/api/kubernetes"
 	"github.com/portainer/portainer/api/kubernetes/cli"
+	"github.com/portainer/portainer/api/kubernetes/teamaccess"
+	"github.com/portainer/portainer/api/lockout"
 	"github.com/portainer/portainer/api/pendingactions"
 	"github.com/portainer/portainer/api/platform"
+	"github.com/portainer/portainer/api/registrycredentials"
 	"github.com/portainer/portainer/api/scheduler"
+	"github.com/portainer/portainer/api/sessionrecording"
 	"github.com/portainer/portainer/api/stacks/deployments"
+	"github.com/portainer/portainer/api/stacks/drift"
+	"github.com/portainer/portainer/api/stacks/fleetstacks"
+	"github.com/portainer/portainer/api/update"
 	"github.com/portainer/portainer/pkg/libhelm"
 
 	"github.com/rs/zerolog/log"
@@ -95,6 +123,7 @@ type Server struct {
 	JWTService                  portainer.JWTService
 	LDAPService                 portainer.LDAPService
 	OAuthService                portainer.OAuthService
+	SAMLService                 portainer.SAMLService
 	SwarmStackManager           portainer.SwarmStackManager
 	ProxyManager                *proxy.Manager
 	KubernetesTokenCacheManager *kubernetes.TokenCacheManager
@@ -113,6 +142,16 @@ type Server struct {
 	AdminCreationDone           chan struct{}
 	PendingActionsService       *pendingactions.PendingActionsService
 	PlatformService             platform.Service
+	OrphanService               *orphans.Service
+	ImageUpdateService          *imageupdates.Service
+	ContainerJobService         *containerjobs.Service
+	PrunePolicyService          *prunepolicies.Service
+	SessionRecordingService     *sessionrecording.Service
+	QuarantineService           *quarantine.Service
+	FleetReportService          *fleetreport.Service
+	RegistryCredentialService   *registrycredentials.Service
+	FleetStacksService          *fleetstacks.Service
+	DriftService                *drift.Service
 }
 
 // Start starts the HTTP server
@@ -126,6 +165,12 @@ func (server *Server) Start() error {
 
 	passwordStrengthChecker := security.NewPasswordStrengthChecker(server.DataStore.Settings())
 
+	var auditLogHandler = auditlogs.NewHandler(requestBouncer, server.DataStore)
+
+	var reportHandler = reports.NewHandler(requestBouncer, server.FleetReportService)
+
+	var dashboardHandler = dashboards.NewHandler(requestBouncer, server.DataStore)
+
 	var authHandler = auth.NewHandler(requestBouncer, rateLimiter, passwordStrengthChecker)
 	authHandler.DataStore = server.DataStore
 	authHandler.CryptoService = server.CryptoService
@@ -134,6 +179,8 @@ func (server *Server) Start() error {
 	authHandler.ProxyManager = server.ProxyManager
 	authHandler.KubernetesTokenCacheManager = kubernetesTokenCacheManager
 	authHandler.OAuthService = server.OAuthService
+	authHandler.SAMLService = server.SAMLService
+	authHandler.LockoutService = lockout.NewService(server.DataStore)
 
 	adminMonitor := adminmonitor.New(5*time.Minute, server.DataStore, server.ShutdownCtx)
 	adminMonitor.Start()
@@ -169,6 +216,8 @@ func (server *Server) Start() error {
 	var edgeTemplatesHandler = edgetemplates.NewHandler(requestBouncer)
 	edgeTemplatesHandler.DataStore = server.DataStore
 
+	cloudProvisioner := cloud.NewProvisioner(server.ShutdownCtx, server.DataStore, server.KubernetesClientFactory)
+
 	var endpointHandler = endpoints.NewHandler(requestBouncer)
 	endpointHandler.DataStore = server.DataStore
 	endpointHandler.FileService = server.FileService
@@ -182,6 +231,10 @@ func (server *Server) Start() error {
 	endpointHandler.BindAddress = server.BindAddress
 	endpointHandler.BindAddressHTTPS = server.BindAddressHTTPS
 	endpointHandler.PendingActionsService = server.PendingActionsService
+	endpointHandler.OrphanService = server.OrphanService
+	endpointHandler.ImageUpdateService = server.ImageUpdateService
+	endpointHandler.QuarantineService = server.QuarantineService
+	endpointHandler.CloudProvisioner = cloudProvisioner
 
 	var endpointEdgeHandler = endpointedge.NewHandler(requestBouncer, server.DataStore, server.FileService, server.ReverseTunnelService)
 
@@ -195,19 +248,40 @@ func (server *Server) Start() error {
 	endpointProxyHandler.ProxyManager = server.ProxyManager
 	endpointProxyHandler.ReverseTunnelService = server.ReverseTunnelService
 
+	teamAccessService := teamaccess.NewService(server.DataStore, server.KubernetesClientFactory)
+
 	var kubernetesHandler = kubehandler.NewHandler(requestBouncer, server.AuthorizationService, server.DataStore, server.JWTService, server.KubeClusterAccessService, server.KubernetesClientFactory, nil)
+	kubernetesHandler.TeamAccessService = teamAccessService
 
 	containerService := docker.NewContainerService(server.DockerClientFactory, server.DataStore)
 
-	var dockerHandler = dockerhandler.NewHandler(requestBouncer, server.AuthorizationService, server.DataStore, server.DockerClientFactory, containerService)
+	containerStatsService := containerstats.NewService(server.ShutdownCtx, server.DockerClientFactory)
+
+	var dockerHandler = dockerhandler.NewHandler(requestBouncer, server.AuthorizationService, server.DataStore, server.DockerClientFactory, containerService, containerStatsService)
 
 	var fileHandler = file.NewHandler(filepath.Join(server.AssetsPath, "public"), adminMonitor.WasInstanceDisabled)
 
-	var endpointHelmHandler = helm.NewHandler(requestBouncer, server.DataStore, server.JWTService, server.KubernetesDeployer, server.HelmPackageManager, server.KubeClusterAccessService)
+	var fixturesHandler = fixtures.NewHandler(requestBouncer, server.DataStore, server.CryptoService)
+
+	var fleetStacksHandler = fleetstackshandler.NewHandler(requestBouncer, server.DataStore, server.FleetStacksService)
+	fleetStacksHandler.ComposeStackManager = server.ComposeStackManager
+
+	var chaosHandler = chaos.NewHandler(requestBouncer, server.DataStore)
+
+	var containerJobsHandler = containerjobshandler.NewHandler(requestBouncer, server.DataStore, server.ContainerJobService)
+
+	var prunePoliciesHandler = prunepolicieshandler.NewHandler(requestBouncer, server.DataStore, server.PrunePolicyService)
+
+	var sessionRecordingsHandler = sessionrecordings.NewHandler(requestBouncer, server.DataStore, server.SessionRecordingService)
+
+	helmRepositoryService := helmrepositories.NewService(server.DataStore, server.HelmPackageManager)
+	go helmRepositoryService.Start(server.ShutdownCtx)
+
+	var endpointHelmHandler = helm.NewHandler(requestBouncer, server.DataStore, server.JWTService, server.KubernetesDeployer, server.HelmPackageManager, server.KubeClusterAccessService, helmRepositoryService)
 
 	var gitOperationHandler = gitops.NewHandler(requestBouncer, server.DataStore, server.GitService, server.FileService)
 
-	var helmTemplatesHandler = helm.NewTemplateHandler(requestBouncer, server.HelmPackageManager)
+	var helmTemplatesHandler = helm.NewTemplateHandler(requestBouncer, server.HelmPackageManager, helmRepositoryService)
 
 	var ldapHandler = ldap.NewHandler(requestBouncer)
 	ldapHandler.DataStore = server.DataStore
@@ -216,11 +290,15 @@ func (server *Server) Start() error {
 
 	var motdHandler = motd.NewHandler(requestBouncer)
 
+	var pluginHandler = plugins.NewHandler(requestBouncer, server.DataStore)
+
 	var registryHandler = registries.NewHandler(requestBouncer)
 	registryHandler.DataStore = server.DataStore
 	registryHandler.FileService = server.FileService
 	registryHandler.ProxyManager = server.ProxyManager
+	registryHandler.DockerClientFactory = server.DockerClientFactory
 	registryHandler.K8sClientFactory = server.KubernetesClientFactory
+	registryHandler.CredentialService = server.RegistryCredentialService
 
 	var resourceControlHandler = resourcecontrols.NewHandler(requestBouncer)
 	resourceControlHandler.DataStore = server.DataStore
@@ -231,6 +309,8 @@ func (server *Server) Start() error {
 	settingsHandler.JWTService = server.JWTService
 	settingsHandler.LDAPService = server.LDAPService
 	settingsHandler.SnapshotService = server.SnapshotService
+	settingsHandler.OrphanService = server.OrphanService
+	settingsHandler.DriftService = server.DriftService
 
 	var sslHandler = sslhandler.NewHandler(requestBouncer)
 	sslHandler.SSLService = server.SSLService
@@ -251,6 +331,7 @@ func (server *Server) Start() error {
 	stackHandler.SwarmStackManager = server.SwarmStackManager
 	stackHandler.ComposeStackManager = server.ComposeStackManager
 	stackHandler.StackDeployer = server.StackDeployer
+	stackHandler.DriftService = server.DriftService
 
 	var storybookHandler = storybook.NewHandler(server.AssetsPath)
 
@@ -259,16 +340,29 @@ func (server *Server) Start() error {
 
 	var teamHandler = teams.NewHandler(requestBouncer)
 	teamHandler.DataStore = server.DataStore
+	teamHandler.TeamAccessService = teamAccessService
 
 	var teamMembershipHandler = teammemberships.NewHandler(requestBouncer)
 	teamMembershipHandler.DataStore = server.DataStore
 	teamMembershipHandler.K8sClientFactory = server.KubernetesClientFactory
+	teamMembershipHandler.TeamAccessService = teamAccessService
+
+	updateService := update.NewService(
+		server.DataStore,
+		server.UpgradeService,
+		server.PlatformService,
+		offlineGate,
+		server.FileService.GetDatastorePath(),
+		server.ShutdownTrigger,
+		localStatusURL(server.BindAddressHTTPS),
+	)
 
 	var systemHandler = system.NewHandler(requestBouncer,
 		server.Status,
 		server.DataStore,
 		server.PlatformService,
-		server.UpgradeService)
+		server.UpgradeService,
+		updateService)
 
 	var templatesHandler = templates.NewHandler(requestBouncer)
 	templatesHandler.DataStore = server.DataStore
@@ -280,62 +374,82 @@ func (server *Server) Start() error {
 
 	var userHandler = users.NewHandler(requestBouncer, rateLimiter, server.APIKeyService, passwordStrengthChecker)
 	userHandler.DataStore = server.DataStore
+	userHandler.TeamAccessService = teamAccessService
 	userHandler.CryptoService = server.CryptoService
 	userHandler.AdminCreationDone = server.AdminCreationDone
 	userHandler.FileService = server.FileService
+	userHandler.JWTService = server.JWTService
 
 	var websocketHandler = websocket.NewHandler(server.KubernetesTokenCacheManager, requestBouncer)
 	websocketHandler.DataStore = server.DataStore
 	websocketHandler.SignatureService = server.SignatureService
 	websocketHandler.ReverseTunnelService = server.ReverseTunnelService
+	websocketHandler.DockerClientFactory = server.DockerClientFactory
 	websocketHandler.KubernetesClientFactory = server.KubernetesClientFactory
+	websocketHandler.SessionRecordingService = server.SessionRecordingService
 
 	var webhookHandler = webhooks.NewHandler(requestBouncer)
 	webhookHandler.DataStore = server.DataStore
 	webhookHandler.DockerClientFactory = server.DockerClientFactory
+	webhookHandler.FileService = server.FileService
+	webhookHandler.GitService = server.GitService
+	webhookHandler.StackDeployer = server.StackDeployer
 
 	server.Handler = &handler.Handler{
-		RoleHandler:            roleHandler,
-		AuthHandler:            authHandler,
-		BackupHandler:          backupHandler,
-		CustomTemplatesHandler: customTemplatesHandler,
-		DockerHandler:          dockerHandler,
-		EdgeGroupsHandler:      edgeGroupsHandler,
-		EdgeJobsHandler:        edgeJobsHandler,
-		EdgeStacksHandler:      edgeStacksHandler,
-		EdgeTemplatesHandler:   edgeTemplatesHandler,
-		EndpointGroupHandler:   endpointGroupHandler,
-		EndpointHandler:        endpointHandler,
-		EndpointHelmHandler:    endpointHelmHandler,
-		EndpointEdgeHandler:    endpointEdgeHandler,
-		EndpointProxyHandler:   endpointProxyHandler,
-		GitOperationHandler:    gitOperationHandler,
-		FileHandler:            fileHandler,
-		LDAPHandler:            ldapHandler,
-		HelmTemplatesHandler:   helmTemplatesHandler,
-		KubernetesHandler:      kubernetesHandler,
-		MOTDHandler:            motdHandler,
-		OpenAMTHandler:         openAMTHandler,
-		RegistryHandler:        registryHandler,
-		ResourceControlHandler: resourceControlHandler,
-		SettingsHandler:        settingsHandler,
-		SSLHandler:             sslHandler,
-		StackHandler:           stackHandler,
-		StorybookHandler:       storybookHandler,
-		SystemHandler:          systemHandler,
-		TagHandler:             tagHandler,
-		TeamHandler:            teamHandler,
-		TeamMembershipHandler:  teamMembershipHandler,
-		TemplatesHandler:       templatesHandler,
-		UploadHandler:          uploadHandler,
-		UserHandler:            userHandler,
-		WebSocketHandler:       websocketHandler,
-		WebhookHandler:         webhookHandler,
+		RoleHandler:              roleHandler,
+		AuditLogHandler:          auditLogHandler,
+		AuthHandler:              authHandler,
+		BackupHandler:            backupHandler,
+		CustomTemplatesHandler:   customTemplatesHandler,
+		DashboardHandler:         dashboardHandler,
+		DockerHandler:            dockerHandler,
+		EdgeGroupsHandler:        edgeGroupsHandler,
+		EdgeJobsHandler:          edgeJobsHandler,
+		EdgeStacksHandler:        edgeStacksHandler,
+		EdgeTemplatesHandler:     edgeTemplatesHandler,
+		EndpointGroupHandler:     endpointGroupHandler,
+		EndpointHandler:          endpointHandler,
+		EndpointHelmHandler:      endpointHelmHandler,
+		EndpointEdgeHandler:      endpointEdgeHandler,
+		EndpointProxyHandler:     endpointProxyHandler,
+		GitOperationHandler:      gitOperationHandler,
+		FileHandler:              fileHandler,
+		FixturesHandler:          fixturesHandler,
+		FleetStacksHandler:       fleetStacksHandler,
+		ChaosHandler:             chaosHandler,
+		ContainerJobsHandler:     containerJobsHandler,
+		SessionRecordingsHandler: sessionRecordingsHandler,
+		LDAPHandler:              ldapHandler,
+		HelmTemplatesHandler:     helmTemplatesHandler,
+		KubernetesHandler:        kubernetesHandler,
+		MOTDHandler:              motdHandler,
+		OpenAMTHandler:           openAMTHandler,
+		PluginHandler:            pluginHandler,
+		PrunePoliciesHandler:     prunePoliciesHandler,
+		RegistryHandler:          registryHandler,
+		ReportHandler:            reportHandler,
+		ResourceControlHandler:   resourceControlHandler,
+		SettingsHandler:          settingsHandler,
+		SSLHandler:               sslHandler,
+		StackHandler:             stackHandler,
+		StorybookHandler:         storybookHandler,
+		SystemHandler:            systemHandler,
+		TagHandler:               tagHandler,
+		TeamHandler:              teamHandler,
+		TeamMembershipHandler:    teamMembershipHandler,
+		TemplatesHandler:         templatesHandler,
+		UploadHandler:            uploadHandler,
+		UserHandler:              userHandler,
+		WebSocketHandler:         websocketHandler,
+		WebhookHandler:           webhookHandler,
 	}
 
 	errorLogger := NewHTTPLogger()
 
-	handler := adminMonitor.WithRedirect(offlineGate.WaitingMiddleware(time.Minute, server.Handler))
+	auditLogService := auditlog.NewService(server.DataStore)
+	go auditLogService.Start(server.ShutdownCtx)
+
+	handler := adminMonitor.WithRedirect(offlineGate.WaitingMiddleware(time.Minute, auditLogService.Middleware(server.Handler)))
 
 	handler = middlewares.WithSlowRequestsLogger(handler)
 
@@ -377,6 +491,8 @@ func (server *Server) Start() error {
 
 	go shutdown(server.ShutdownCtx, httpsServer)
 	go snapshot.NewBackgroundSnapshotter(server.DataStore, server.ReverseTunnelService)
+	go updateService.VerifyUpgrade(server.ShutdownCtx)
+	go cloudProvisioner.Resume()
 
 	return httpsServer.ListenAndServeTLS("", "")
 }
@@ -395,3 +511,13 @@ func shutdown(shutdownCtx context.Context, httpServer *http.Server) {
 			Msg("failed to shut down the HTTP server")
 	}
 }
+
+// localStatusURL returns the URL of the status of the instance through the loopback interface
+func localStatusURL(bindAddress string) string {
+	_, port, err := net.SplitHostPort(bindAddress)
+	if err != nil {
+		port = "9443"
+	}
+
+	return "https://" + net.JoinHostPort("127.0.0.1", port) + "/api/system/status"
+}
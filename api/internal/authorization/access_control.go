@@ -152,7 +152,7 @@ func FilterAuthorizedCustomTemplates(customTemplates []portainer.CustomTemplate,
 	authorizedTemplates := make([]portainer.CustomTemplate, 0)
 
 	for _, customTemplate := range customTemplates {
-		if customTemplate.CreatedByUserID == user.ID || (customTemplate.ResourceControl != nil && UserCanAccessResource(user.ID, userTeamIDs, customTemplate.ResourceControl)) {
+		if UserCanAccessCustomTemplate(&customTemplate, customTemplate.ResourceControl, user.ID, userTeamIDs) {
 			authorizedTemplates = append(authorizedTemplates, customTemplate)
 		}
 	}
@@ -160,6 +160,12 @@ func FilterAuthorizedCustomTemplates(customTemplates []portainer.CustomTemplate,
 	return authorizedTemplates
 }
 
+// UserCanAccessCustomTemplate checks if a non-admin user can use a custom template: the user created it or is
+// authorized by its resource control
+func UserCanAccessCustomTemplate(customTemplate *portainer.CustomTemplate, resourceControl *portainer.ResourceControl, userID portainer.UserID, userTeamIDs []portainer.TeamID) bool {
+	return customTemplate.CreatedByUserID == userID || (resourceControl != nil && UserCanAccessResource(userID, userTeamIDs, resourceControl))
+}
+
 // UserCanAccessResource will valid that a user has permissions defined in the specified resource control
 // based on its identifier and the team(s) he is part of.
 func UserCanAccessResource(userID portainer.UserID, userTeamIDs []portainer.TeamID, resourceControl *portainer.ResourceControl) bool {
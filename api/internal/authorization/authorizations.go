@@ -153,6 +153,9 @@ func DefaultEndpointAuthorizationsForEndpointAdministratorRole() portainer.Autho
 		portainer.OperationPortainerWebhookList:               true,
 		portainer.OperationPortainerWebhookCreate:             true,
 		portainer.OperationPortainerWebhookDelete:             true,
+		portainer.OperationK8sResourceRead:                    true,
+		portainer.OperationK8sResourceWrite:                   true,
+		portainer.OperationK8sPodExec:                         true,
 		portainer.EndpointResourcesAccess:                     true,
 	}
 }
@@ -205,6 +208,7 @@ func DefaultEndpointAuthorizationsForHelpDeskRole(volumeBrowsingAuthorizations b
 		portainer.OperationPortainerStackInspect:      true,
 		portainer.OperationPortainerStackFile:         true,
 		portainer.OperationPortainerWebhookList:       true,
+		portainer.OperationK8sResourceRead:            true,
 		portainer.EndpointResourcesAccess:             true,
 	}
 
@@ -216,6 +220,37 @@ func DefaultEndpointAuthorizationsForHelpDeskRole(volumeBrowsingAuthorizations b
 	return authorizations
 }
 
+// DefaultEndpointAuthorizationsForOperatorRole returns the default environment(endpoint) authorizations
+// associated to the operator role, the authorizations of the helpdesk role and the ones to operate the
+// existing containers and services.
+func DefaultEndpointAuthorizationsForOperatorRole(volumeBrowsingAuthorizations bool) portainer.Authorizations {
+	authorizations := DefaultEndpointAuthorizationsForHelpDeskRole(volumeBrowsingAuthorizations)
+
+	for _, operation := range []portainer.Authorization{
+		portainer.OperationDockerContainerAttachWebsocket,
+		portainer.OperationDockerContainerKill,
+		portainer.OperationDockerContainerPause,
+		portainer.OperationDockerContainerUnpause,
+		portainer.OperationDockerContainerRestart,
+		portainer.OperationDockerContainerStart,
+		portainer.OperationDockerContainerStop,
+		portainer.OperationDockerContainerWait,
+		portainer.OperationDockerContainerResize,
+		portainer.OperationDockerContainerAttach,
+		portainer.OperationDockerContainerExec,
+		portainer.OperationDockerExecInspect,
+		portainer.OperationDockerExecStart,
+		portainer.OperationDockerExecResize,
+		portainer.OperationDockerServiceUpdate,
+		portainer.OperationK8sPodExec,
+		portainer.OperationPortainerWebsocketExec,
+	} {
+		authorizations[operation] = true
+	}
+
+	return authorizations
+}
+
 // DefaultEndpointAuthorizationsForStandardUserRole returns the default environment(endpoint) authorizations
 // associated to the standard user role.
 func DefaultEndpointAuthorizationsForStandardUserRole(volumeBrowsingAuthorizations bool) portainer.Authorizations {
@@ -326,6 +361,9 @@ func DefaultEndpointAuthorizationsForStandardUserRole(volumeBrowsingAuthorizatio
 		portainer.OperationDockerAgentList:                    true,
 		portainer.OperationDockerAgentHostInfo:                true,
 		portainer.OperationDockerAgentUndefined:               true,
+		portainer.OperationK8sResourceRead:                    true,
+		portainer.OperationK8sResourceWrite:                   true,
+		portainer.OperationK8sPodExec:                         true,
 		portainer.OperationPortainerResourceControlUpdate:     true,
 		portainer.OperationPortainerStackList:                 true,
 		portainer.OperationPortainerStackInspect:              true,
@@ -394,6 +432,7 @@ func DefaultEndpointAuthorizationsForReadOnlyUserRole(volumeBrowsingAuthorizatio
 		portainer.OperationDockerAgentPing:            true,
 		portainer.OperationDockerAgentList:            true,
 		portainer.OperationDockerAgentHostInfo:        true,
+		portainer.OperationK8sResourceRead:            true,
 		portainer.OperationPortainerStackList:         true,
 		portainer.OperationPortainerStackInspect:      true,
 		portainer.OperationPortainerStackFile:         true,
@@ -622,7 +661,7 @@ func (service *Service) getAuthorizations(tx dataservices.DataStoreTx, user *por
 		return endpointAuthorizations, nil
 	}
 
-	userMemberships, err := tx.TeamMembership().TeamMembershipsByUserID(user.ID)
+	userMemberships, err := EffectiveTeamMemberships(tx, user.ID)
 	if err != nil {
 		return endpointAuthorizations, err
 	}
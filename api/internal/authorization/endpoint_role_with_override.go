@@ -62,7 +62,7 @@ func (service *Service) getUserEndpointAccessWithPolicies(
 	endpoint *portainer.Endpoint,
 	endpointGroup *portainer.EndpointGroup,
 ) (bool, error) {
-	memberships, err := tx.TeamMembership().TeamMembershipsByUserID(userID)
+	memberships, err := EffectiveTeamMemberships(tx, userID)
 	if err != nil {
 		return false, err
 	}
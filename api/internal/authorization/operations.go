@@ -0,0 +1,314 @@
+package authorization
+
+import (
+	"net/http"
+	"strings"
+
+	portainer "github.com/portainer/portainer/api"
+)
+
+// DockerOperationAuthorization returns the authorization required by a request to the Docker API, path being the
+// request path without the API version
+func DockerOperationAuthorization(method, path string) portainer.Authorization {
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	resource, segments := segments[0], segments[1:]
+
+	// the last segment is the action, the segments before it the resource identifier, which can contain slashes for
+	// the images and plugins
+	action := ""
+	if len(segments) > 1 {
+		action = segments[len(segments)-1]
+	}
+
+	switch resource {
+	case "containers":
+		return dockerContainerOperation(method, segments, action)
+	case "exec":
+		return operationFromAction(action, map[string]portainer.Authorization{
+			"start":  portainer.OperationDockerExecStart,
+			"resize": portainer.OperationDockerExecResize,
+			"json":   portainer.OperationDockerExecInspect,
+		}, portainer.OperationDockerUndefined)
+	case "images":
+		return dockerImageOperation(method, segments, action)
+	case "commit":
+		return portainer.OperationDockerImageCommit
+	case "build":
+		return operationFromAction(strings.Join(segments, "/"), map[string]portainer.Authorization{
+			"":       portainer.OperationDockerImageBuild,
+			"prune":  portainer.OperationDockerBuildPrune,
+			"cancel": portainer.OperationDockerBuildCancel,
+		}, portainer.OperationDockerUndefined)
+	case "networks":
+		return dockerObjectOperation(method, segments, action, dockerObjectOperations{
+			list:    portainer.OperationDockerNetworkList,
+			inspect: portainer.OperationDockerNetworkInspect,
+			create:  portainer.OperationDockerNetworkCreate,
+			delete:  portainer.OperationDockerNetworkDelete,
+			actions: map[string]portainer.Authorization{
+				"connect":    portainer.OperationDockerNetworkConnect,
+				"disconnect": portainer.OperationDockerNetworkDisconnect,
+				"prune":      portainer.OperationDockerNetworkPrune,
+			},
+		})
+	case "volumes":
+		return dockerObjectOperation(method, segments, action, dockerObjectOperations{
+			list:    portainer.OperationDockerVolumeList,
+			inspect: portainer.OperationDockerVolumeInspect,
+			create:  portainer.OperationDockerVolumeCreate,
+			delete:  portainer.OperationDockerVolumeDelete,
+			actions: map[string]portainer.Authorization{
+				"prune": portainer.OperationDockerVolumePrune,
+			},
+		})
+	case "swarm":
+		if method == http.MethodGet && len(segments) == 0 {
+			return portainer.OperationDockerSwarmInspect
+		}
+
+		return operationFromAction(strings.Join(segments, "/"), map[string]portainer.Authorization{
+			"unlockkey": portainer.OperationDockerSwarmUnlockKey,
+			"init":      portainer.OperationDockerSwarmInit,
+			"join":      portainer.OperationDockerSwarmJoin,
+			"leave":     portainer.OperationDockerSwarmLeave,
+			"update":    portainer.OperationDockerSwarmUpdate,
+			"unlock":    portainer.OperationDockerSwarmUnlock,
+		}, portainer.OperationDockerUndefined)
+	case "nodes":
+		return dockerObjectOperation(method, segments, action, dockerObjectOperations{
+			list:    portainer.OperationDockerNodeList,
+			inspect: portainer.OperationDockerNodeInspect,
+			delete:  portainer.OperationDockerNodeDelete,
+			actions: map[string]portainer.Authorization{
+				"update": portainer.OperationDockerNodeUpdate,
+			},
+		})
+	case "services":
+		return dockerObjectOperation(method, segments, action, dockerObjectOperations{
+			list:    portainer.OperationDockerServiceList,
+			inspect: portainer.OperationDockerServiceInspect,
+			create:  portainer.OperationDockerServiceCreate,
+			delete:  portainer.OperationDockerServiceDelete,
+			actions: map[string]portainer.Authorization{
+				"update": portainer.OperationDockerServiceUpdate,
+				"logs":   portainer.OperationDockerServiceLogs,
+			},
+		})
+	case "secrets":
+		return dockerObjectOperation(method, segments, action, dockerObjectOperations{
+			list:    portainer.OperationDockerSecretList,
+			inspect: portainer.OperationDockerSecretInspect,
+			create:  portainer.OperationDockerSecretCreate,
+			delete:  portainer.OperationDockerSecretDelete,
+			actions: map[string]portainer.Authorization{
+				"update": portainer.OperationDockerSecretUpdate,
+			},
+		})
+	case "configs":
+		return dockerObjectOperation(method, segments, action, dockerObjectOperations{
+			list:    portainer.OperationDockerConfigList,
+			inspect: portainer.OperationDockerConfigInspect,
+			create:  portainer.OperationDockerConfigCreate,
+			delete:  portainer.OperationDockerConfigDelete,
+			actions: map[string]portainer.Authorization{
+				"update": portainer.OperationDockerConfigUpdate,
+			},
+		})
+	case "tasks":
+		return dockerObjectOperation(method, segments, action, dockerObjectOperations{
+			list:    portainer.OperationDockerTaskList,
+			inspect: portainer.OperationDockerTaskInspect,
+			actions: map[string]portainer.Authorization{
+				"logs": portainer.OperationDockerTaskLogs,
+			},
+		})
+	case "plugins":
+		return dockerObjectOperation(method, segments, action, dockerObjectOperations{
+			list:   portainer.OperationDockerPluginList,
+			create: portainer.OperationDockerPluginCreate,
+			delete: portainer.OperationDockerPluginDelete,
+			actions: map[string]portainer.Authorization{
+				"privileges": portainer.OperationDockerPluginPrivileges,
+				"pull":       portainer.OperationDockerPluginPull,
+				"json":       portainer.OperationDockerPluginInspect,
+				"enable":     portainer.OperationDockerPluginEnable,
+				"disable":    portainer.OperationDockerPluginDisable,
+				"push":       portainer.OperationDockerPluginPush,
+				"upgrade":    portainer.OperationDockerPluginUpgrade,
+				"set":        portainer.OperationDockerPluginSet,
+			},
+		})
+	case "session":
+		return portainer.OperationDockerSessionStart
+	case "distribution":
+		return portainer.OperationDockerDistributionInspect
+	case "_ping":
+		return portainer.OperationDockerPing
+	case "info":
+		return portainer.OperationDockerInfo
+	case "version":
+		return portainer.OperationDockerVersion
+	case "events":
+		return portainer.OperationDockerEvents
+	case "system":
+		return portainer.OperationDockerSystem
+	case "v2":
+		return dockerAgentOperation(segments)
+	}
+
+	return portainer.OperationDockerUndefined
+}
+
+func dockerContainerOperation(method string, segments []string, action string) portainer.Authorization {
+	switch {
+	case len(segments) == 0:
+		return portainer.OperationDockerUndefined
+	case len(segments) == 1:
+		switch {
+		case segments[0] == "json":
+			return portainer.OperationDockerContainerList
+		case segments[0] == "create":
+			return portainer.OperationDockerContainerCreate
+		case segments[0] == "prune":
+			return portainer.OperationDockerContainerPrune
+		case method == http.MethodDelete:
+			return portainer.OperationDockerContainerDelete
+		}
+
+		return portainer.OperationDockerUndefined
+	case action == "archive":
+		switch method {
+		case http.MethodHead:
+			return portainer.OperationDockerContainerArchiveInfo
+		case http.MethodPut:
+			return portainer.OperationDockerContainerPutContainerArchive
+		}
+
+		return portainer.OperationDockerContainerArchive
+	case action == "ws" && len(segments) > 2 && segments[len(segments)-2] == "attach":
+		return portainer.OperationDockerContainerAttachWebsocket
+	}
+
+	return operationFromAction(action, map[string]portainer.Authorization{
+		"json":    portainer.OperationDockerContainerInspect,
+		"top":     portainer.OperationDockerContainerTop,
+		"logs":    portainer.OperationDockerContainerLogs,
+		"changes": portainer.OperationDockerContainerChanges,
+		"export":  portainer.OperationDockerContainerExport,
+		"stats":   portainer.OperationDockerContainerStats,
+		"resize":  portainer.OperationDockerContainerResize,
+		"start":   portainer.OperationDockerContainerStart,
+		"stop":    portainer.OperationDockerContainerStop,
+		"restart": portainer.OperationDockerContainerRestart,
+		"kill":    portainer.OperationDockerContainerKill,
+		"update":  portainer.OperationDockerContainerUpdate,
+		"rename":  portainer.OperationDockerContainerRename,
+		"pause":   portainer.OperationDockerContainerPause,
+		"unpause": portainer.OperationDockerContainerUnpause,
+		"attach":  portainer.OperationDockerContainerAttach,
+		"wait":    portainer.OperationDockerContainerWait,
+		"exec":    portainer.OperationDockerContainerExec,
+	}, portainer.OperationDockerUndefined)
+}
+
+func dockerImageOperation(method string, segments []string, action string) portainer.Authorization {
+	if len(segments) == 1 {
+		switch segments[0] {
+		case "json":
+			return portainer.OperationDockerImageList
+		case "search":
+			return portainer.OperationDockerImageSearch
+		case "get":
+			return portainer.OperationDockerImageGetAll
+		case "load":
+			return portainer.OperationDockerImageLoad
+		case "create":
+			return portainer.OperationDockerImageCreate
+		case "prune":
+			return portainer.OperationDockerImagePrune
+		}
+	}
+
+	if method == http.MethodDelete && len(segments) > 0 {
+		return portainer.OperationDockerImageDelete
+	}
+
+	return operationFromAction(action, map[string]portainer.Authorization{
+		"get":     portainer.OperationDockerImageGet,
+		"history": portainer.OperationDockerImageHistory,
+		"json":    portainer.OperationDockerImageInspect,
+		"push":    portainer.OperationDockerImagePush,
+		"tag":     portainer.OperationDockerImageTag,
+	}, portainer.OperationDockerUndefined)
+}
+
+type dockerObjectOperations struct {
+	list    portainer.Authorization
+	inspect portainer.Authorization
+	create  portainer.Authorization
+	delete  portainer.Authorization
+	actions map[string]portainer.Authorization
+}
+
+// dockerObjectOperation returns the authorization of a request to the API of the objects following the
+// GET /{objects}, POST /{objects}/create, GET and DELETE /{objects}/{id} and /{objects}/{id}/{action} patterns
+func dockerObjectOperation(method string, segments []string, action string, operations dockerObjectOperations) portainer.Authorization {
+	var operation portainer.Authorization
+
+	switch {
+	case len(segments) == 0 && method == http.MethodGet:
+		operation = operations.list
+	case len(segments) > 0 && method == http.MethodDelete:
+		operation = operations.delete
+	case len(segments) == 1 && segments[0] == "create":
+		operation = operations.create
+	case action != "":
+		operation = operations.actions[action]
+	case len(segments) == 1 && operations.actions[segments[0]] != "":
+		operation = operations.actions[segments[0]]
+	case len(segments) == 1 && method == http.MethodGet:
+		operation = operations.inspect
+	}
+
+	if operation == "" {
+		return portainer.OperationDockerUndefined
+	}
+
+	return operation
+}
+
+func dockerAgentOperation(segments []string) portainer.Authorization {
+	return operationFromAction(strings.Join(segments, "/"), map[string]portainer.Authorization{
+		"ping":          portainer.OperationDockerAgentPing,
+		"agents":        portainer.OperationDockerAgentList,
+		"host/info":     portainer.OperationDockerAgentHostInfo,
+		"browse/ls":     portainer.OperationDockerAgentBrowseList,
+		"browse/get":    portainer.OperationDockerAgentBrowseGet,
+		"browse/delete": portainer.OperationDockerAgentBrowseDelete,
+		"browse/put":    portainer.OperationDockerAgentBrowsePut,
+		"browse/rename": portainer.OperationDockerAgentBrowseRename,
+	}, portainer.OperationDockerAgentUndefined)
+}
+
+func operationFromAction(action string, operations map[string]portainer.Authorization, undefined portainer.Authorization) portainer.Authorization {
+	if operation, ok := operations[action]; ok {
+		return operation
+	}
+
+	return undefined
+}
+
+// KubernetesOperationAuthorization returns the authorization required by a request to the Kubernetes API
+func KubernetesOperationAuthorization(method, path string) portainer.Authorization {
+	switch path[strings.LastIndex(path, "/")+1:] {
+	case "exec", "attach", "portforward":
+		return portainer.OperationK8sPodExec
+	}
+
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return portainer.OperationK8sResourceRead
+	}
+
+	return portainer.OperationK8sResourceWrite
+}
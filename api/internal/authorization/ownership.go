@@ -0,0 +1,69 @@
+package authorization
+
+import (
+	"errors"
+	"slices"
+	"strings"
+
+	portainer "github.com/portainer/portainer/api"
+)
+
+// ValidateOwnershipRule returns an error when an ownership rule cannot match any resource or cannot give the
+// ownership to anyone
+func ValidateOwnershipRule(rule portainer.ResourceOwnershipRule) error {
+	if strings.TrimSpace(rule.Label) == "" {
+		return errors.New("the label of the ownership rule is required")
+	}
+
+	if rule.Value != "" && len(rule.TeamIDs) == 0 && len(rule.UserIDs) == 0 {
+		return errors.New("an ownership rule matching a label value must give the ownership to teams or users")
+	}
+
+	return nil
+}
+
+// MatchOwnershipRules returns the users and teams given the ownership of a resource by the first rule matching its
+// labels. A rule without value gives the ownership to the teams named after the value of its label, the unknown team
+// names are ignored
+func MatchOwnershipRules(rules []portainer.ResourceOwnershipRule, labels map[string]string, teams []portainer.Team) ([]portainer.UserID, []portainer.TeamID, bool) {
+	for _, rule := range rules {
+		value, ok := labels[rule.Label]
+		if !ok || (rule.Value != "" && value != rule.Value) {
+			continue
+		}
+
+		userIDs := slices.Clone(rule.UserIDs)
+		teamIDs := slices.Clone(rule.TeamIDs)
+
+		if rule.Value == "" {
+			for _, name := range strings.Split(value, ",") {
+				i := slices.IndexFunc(teams, func(team portainer.Team) bool {
+					return strings.EqualFold(team.Name, strings.TrimSpace(name))
+				})
+
+				if i != -1 && !slices.Contains(teamIDs, teams[i].ID) {
+					teamIDs = append(teamIDs, teams[i].ID)
+				}
+			}
+		}
+
+		if len(userIDs) == 0 && len(teamIDs) == 0 {
+			continue
+		}
+
+		return userIDs, teamIDs, true
+	}
+
+	return nil, nil, false
+}
+
+// NewResourceControlFromOwnershipRules returns a restricted resource control for a resource matching an ownership
+// rule, nil when no rule matches its labels
+func NewResourceControlFromOwnershipRules(rules []portainer.ResourceOwnershipRule, teams []portainer.Team, resourceIdentifier string, resourceType portainer.ResourceControlType, labels map[string]string) *portainer.ResourceControl {
+	userIDs, teamIDs, ok := MatchOwnershipRules(rules, labels, teams)
+	if !ok {
+		return nil
+	}
+
+	return NewRestrictedResourceControl(resourceIdentifier, resourceType, userIDs, teamIDs)
+}
@@ -0,0 +1,53 @@
+package authorization
+
+import (
+	"testing"
+
+	portainer "github.com/portainer/portainer/api"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMatchOwnershipRules(t *testing.T) {
+	is := require.New(t)
+
+	rules := []portainer.ResourceOwnershipRule{
+		{Label: "com.example.env", Value: "production", UserIDs: []portainer.UserID{5}},
+		{Label: "io.portainer.team"},
+		{Label: "com.example.owner", Value: "platform", TeamIDs: []portainer.TeamID{4}},
+	}
+
+	userIDs, teamIDs, ok := MatchOwnershipRules(rules, map[string]string{"io.portainer.team": "Department, squad,unknown"}, testTeams)
+	is.True(ok)
+	is.Empty(userIDs)
+	is.Equal([]portainer.TeamID{2, 3}, teamIDs)
+
+	// the first matching rule applies
+	userIDs, teamIDs, ok = MatchOwnershipRules(rules, map[string]string{"com.example.env": "production", "io.portainer.team": "org"}, testTeams)
+	is.True(ok)
+	is.Equal([]portainer.UserID{5}, userIDs)
+	is.Empty(teamIDs)
+
+	// a rule naming only unknown teams does not match, the next one applies
+	_, teamIDs, ok = MatchOwnershipRules(rules, map[string]string{"io.portainer.team": "unknown", "com.example.owner": "platform"}, testTeams)
+	is.True(ok)
+	is.Equal([]portainer.TeamID{4}, teamIDs)
+
+	_, _, ok = MatchOwnershipRules(rules, map[string]string{"com.example.env": "staging"}, testTeams)
+	is.False(ok)
+
+	is.Nil(NewResourceControlFromOwnershipRules(rules, testTeams, "abc", portainer.ContainerResourceControl, nil))
+
+	resourceControl := NewResourceControlFromOwnershipRules(rules, testTeams, "abc", portainer.ContainerResourceControl, map[string]string{"io.portainer.team": "org"})
+	is.Equal("abc", resourceControl.ResourceID)
+	is.Equal([]portainer.TeamResourceAccess{{TeamID: 1, AccessLevel: portainer.ReadWriteAccessLevel}}, resourceControl.TeamAccesses)
+}
+
+func TestValidateOwnershipRule(t *testing.T) {
+	is := require.New(t)
+
+	is.NoError(ValidateOwnershipRule(portainer.ResourceOwnershipRule{Label: "io.portainer.team"}))
+	is.NoError(ValidateOwnershipRule(portainer.ResourceOwnershipRule{Label: "env", Value: "prod", TeamIDs: []portainer.TeamID{1}}))
+	is.Error(ValidateOwnershipRule(portainer.ResourceOwnershipRule{Label: " "}))
+	is.Error(ValidateOwnershipRule(portainer.ResourceOwnershipRule{Label: "env", Value: "prod"}))
+}
@@ -0,0 +1,117 @@
+package authorization
+
+import (
+	portainer "github.com/portainer/portainer/api"
+)
+
+// Identifiers of the default roles, the first four ones match the roles created by the former versions
+const (
+	EndpointAdministratorRoleID portainer.RoleID = iota + 1
+	HelpDeskRoleID
+	StandardUserRoleID
+	ReadOnlyUserRoleID
+	OperatorRoleID
+)
+
+// DefaultRoles returns the roles that can be granted to the users and teams on the environments and environment
+// groups. The role with the highest priority prevails when several roles apply to a user, the operator role comes
+// after the roles created by the former versions whose priorities are kept.
+func DefaultRoles(volumeBrowsingAuthorizations bool) []portainer.Role {
+	return []portainer.Role{
+		{
+			ID:             EndpointAdministratorRoleID,
+			Name:           "Environment administrator",
+			Description:    "Full control of all resources in an environment",
+			Authorizations: DefaultEndpointAuthorizationsForEndpointAdministratorRole(),
+			Priority:       1,
+		},
+		{
+			ID:             HelpDeskRoleID,
+			Name:           "Helpdesk",
+			Description:    "Read-only access of all resources in an environment",
+			Authorizations: DefaultEndpointAuthorizationsForHelpDeskRole(volumeBrowsingAuthorizations),
+			Priority:       2,
+		},
+		{
+			ID:             StandardUserRoleID,
+			Name:           "Standard user",
+			Description:    "Full control of assigned resources in an environment",
+			Authorizations: DefaultEndpointAuthorizationsForStandardUserRole(volumeBrowsingAuthorizations),
+			Priority:       3,
+		},
+		{
+			ID:             ReadOnlyUserRoleID,
+			Name:           "Read-only user",
+			Description:    "Read-only access of assigned resources in an environment",
+			Authorizations: DefaultEndpointAuthorizationsForReadOnlyUserRole(volumeBrowsingAuthorizations),
+			Priority:       4,
+		},
+		{
+			ID:             OperatorRoleID,
+			Name:           "Operator",
+			Description:    "Operational control of all existing resources in an environment",
+			Authorizations: DefaultEndpointAuthorizationsForOperatorRole(volumeBrowsingAuthorizations),
+			Priority:       5,
+		},
+	}
+}
+
+// EndpointRoleAuthorizations returns the authorizations granted to a user on an environment by the roles of its
+// access policies. The policies of the user prevail over the ones of its teams, and the policies of the environment
+// over the ones of its group. restricted is false when the access is not restricted by a role, the policies without
+// role granting the same access as before the roles were introduced.
+func EndpointRoleAuthorizations(
+	endpoint *portainer.Endpoint,
+	endpointGroup *portainer.EndpointGroup,
+	userID portainer.UserID,
+	memberships []portainer.TeamMembership,
+	roles []portainer.Role,
+) (authorizations portainer.Authorizations, restricted bool) {
+	levels := [][]portainer.RoleID{
+		userPolicyRoles(userID, endpoint.UserAccessPolicies),
+		userPolicyRoles(userID, endpointGroup.UserAccessPolicies),
+		teamPolicyRoles(memberships, endpoint.TeamAccessPolicies),
+		teamPolicyRoles(memberships, endpointGroup.TeamAccessPolicies),
+	}
+
+	for _, roleIDs := range levels {
+		if len(roleIDs) == 0 {
+			continue
+		}
+
+		for _, roleID := range roleIDs {
+			if roleID == 0 {
+				return nil, false
+			}
+		}
+
+		authorizations = getAuthorizationsFromRoles(roleIDs, roles)
+		if authorizations == nil {
+			authorizations = portainer.Authorizations{}
+		}
+
+		return authorizations, true
+	}
+
+	return nil, false
+}
+
+func userPolicyRoles(userID portainer.UserID, policies portainer.UserAccessPolicies) []portainer.RoleID {
+	if policy, ok := policies[userID]; ok {
+		return []portainer.RoleID{policy.RoleID}
+	}
+
+	return nil
+}
+
+func teamPolicyRoles(memberships []portainer.TeamMembership, policies portainer.TeamAccessPolicies) []portainer.RoleID {
+	var roleIDs []portainer.RoleID
+
+	for _, membership := range memberships {
+		if policy, ok := policies[membership.TeamID]; ok {
+			roleIDs = append(roleIDs, policy.RoleID)
+		}
+	}
+
+	return roleIDs
+}
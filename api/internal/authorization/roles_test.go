@@ -0,0 +1,88 @@
+package authorization
+
+import (
+	"net/http"
+	"testing"
+
+	portainer "github.com/portainer/portainer/api"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEndpointRoleAuthorizations(t *testing.T) {
+	is := require.New(t)
+
+	roles := DefaultRoles(false)
+	memberships := []portainer.TeamMembership{{UserID: 2, TeamID: 1}, {UserID: 2, TeamID: 2}}
+
+	endpoint := &portainer.Endpoint{
+		UserAccessPolicies: portainer.UserAccessPolicies{},
+		TeamAccessPolicies: portainer.TeamAccessPolicies{
+			1: {RoleID: ReadOnlyUserRoleID},
+			2: {RoleID: OperatorRoleID},
+		},
+	}
+	group := &portainer.EndpointGroup{
+		UserAccessPolicies: portainer.UserAccessPolicies{2: {RoleID: HelpDeskRoleID}},
+		TeamAccessPolicies: portainer.TeamAccessPolicies{},
+	}
+
+	// the policy of the user on the group prevails over the ones of its teams
+	authorizations, restricted := EndpointRoleAuthorizations(endpoint, group, 2, memberships, roles)
+	is.True(restricted)
+	is.True(authorizations[portainer.OperationDockerContainerList])
+	is.False(authorizations[portainer.OperationDockerContainerStart])
+
+	// the role of the teams with the highest priority prevails
+	delete(group.UserAccessPolicies, 2)
+	authorizations, restricted = EndpointRoleAuthorizations(endpoint, group, 2, memberships, roles)
+	is.True(restricted)
+	is.True(authorizations[portainer.OperationDockerContainerStart])
+	is.False(authorizations[portainer.OperationDockerContainerCreate])
+
+	// the policies without role are not restricted
+	endpoint.UserAccessPolicies[2] = portainer.AccessPolicy{}
+	_, restricted = EndpointRoleAuthorizations(endpoint, group, 2, memberships, roles)
+	is.False(restricted)
+
+	// an unknown role grants no authorization
+	endpoint.UserAccessPolicies[2] = portainer.AccessPolicy{RoleID: 42}
+	authorizations, restricted = EndpointRoleAuthorizations(endpoint, group, 2, memberships, roles)
+	is.True(restricted)
+	is.Empty(authorizations)
+}
+
+func TestOperationAuthorization(t *testing.T) {
+	is := require.New(t)
+
+	dockerCases := []struct {
+		method   string
+		path     string
+		expected portainer.Authorization
+	}{
+		{http.MethodGet, "/containers/json", portainer.OperationDockerContainerList},
+		{http.MethodPost, "/containers/abc/start", portainer.OperationDockerContainerStart},
+		{http.MethodDelete, "/containers/abc", portainer.OperationDockerContainerDelete},
+		{http.MethodPost, "/containers/abc/exec", portainer.OperationDockerContainerExec},
+		{http.MethodPost, "/exec/abc/start", portainer.OperationDockerExecStart},
+		{http.MethodGet, "/images/json", portainer.OperationDockerImageList},
+		{http.MethodDelete, "/images/registry.local/app:latest", portainer.OperationDockerImageDelete},
+		{http.MethodGet, "/networks", portainer.OperationDockerNetworkList},
+		{http.MethodGet, "/networks/abc", portainer.OperationDockerNetworkInspect},
+		{http.MethodPost, "/networks/abc/connect", portainer.OperationDockerNetworkConnect},
+		{http.MethodPost, "/volumes/create", portainer.OperationDockerVolumeCreate},
+		{http.MethodPost, "/services/abc/update", portainer.OperationDockerServiceUpdate},
+		{http.MethodDelete, "/plugins/abc", portainer.OperationDockerPluginDelete},
+		{http.MethodGet, "/swarm", portainer.OperationDockerSwarmInspect},
+		{http.MethodGet, "/v2/browse/ls", portainer.OperationDockerAgentBrowseList},
+		{http.MethodGet, "/unknown", portainer.OperationDockerUndefined},
+	}
+
+	for _, c := range dockerCases {
+		is.Equal(c.expected, DockerOperationAuthorization(c.method, c.path), "%s %s", c.method, c.path)
+	}
+
+	is.Equal(portainer.OperationK8sResourceRead, KubernetesOperationAuthorization(http.MethodGet, "/api/v1/namespaces/default/pods"))
+	is.Equal(portainer.OperationK8sResourceWrite, KubernetesOperationAuthorization(http.MethodDelete, "/api/v1/namespaces/default/pods/app"))
+	is.Equal(portainer.OperationK8sPodExec, KubernetesOperationAuthorization(http.MethodGet, "/api/v1/namespaces/default/pods/app/exec"))
+}
@@ -0,0 +1,136 @@
+package authorization
+
+import (
+	"errors"
+	"slices"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+)
+
+var (
+	// ErrTeamParentNotFound is returned when the parent of a team does not exist
+	ErrTeamParentNotFound = errors.New("the parent team does not exist")
+	// ErrTeamHierarchyCycle is returned when the parent of a team is the team itself or one of its descendants
+	ErrTeamHierarchyCycle = errors.New("a team cannot be a descendant of itself")
+)
+
+// TeamAncestors returns the identifiers of the ancestors of a team, from its parent up to the root of its hierarchy
+func TeamAncestors(teams []portainer.Team, teamID portainer.TeamID) []portainer.TeamID {
+	parents := make(map[portainer.TeamID]portainer.TeamID, len(teams))
+	for _, team := range teams {
+		parents[team.ID] = team.ParentID
+	}
+
+	ancestors := []portainer.TeamID{}
+	for parentID := parents[teamID]; parentID != 0; parentID = parents[parentID] {
+		// guards against a hierarchy corrupted outside of the API
+		if parentID == teamID || slices.Contains(ancestors, parentID) {
+			break
+		}
+
+		ancestors = append(ancestors, parentID)
+	}
+
+	return ancestors
+}
+
+// TeamDescendants returns the identifiers of the teams below a team in its hierarchy
+func TeamDescendants(teams []portainer.Team, teamID portainer.TeamID) []portainer.TeamID {
+	descendants := []portainer.TeamID{}
+	for _, team := range teams {
+		if team.ID != teamID && slices.Contains(TeamAncestors(teams, team.ID), teamID) {
+			descendants = append(descendants, team.ID)
+		}
+	}
+
+	return descendants
+}
+
+// ValidateTeamParent returns an error when the parent of a team does not exist or is the team itself or one of its
+// descendants. A zero parent detaches the team from its hierarchy
+func ValidateTeamParent(teams []portainer.Team, teamID, parentID portainer.TeamID) error {
+	if parentID == 0 {
+		return nil
+	}
+
+	if !slices.ContainsFunc(teams, func(team portainer.Team) bool { return team.ID == parentID }) {
+		return ErrTeamParentNotFound
+	}
+
+	if parentID == teamID || slices.Contains(TeamAncestors(teams, parentID), teamID) {
+		return ErrTeamHierarchyCycle
+	}
+
+	return nil
+}
+
+// InheritTeamMemberships adds to the memberships of a user the ones of the ancestors of its teams, so that the
+// access policies and resource controls of a team are inherited by the members of the teams below it. The inherited
+// memberships are regular memberships, leading a team does not make its members leaders of the ancestor teams
+func InheritTeamMemberships(teams []portainer.Team, memberships []portainer.TeamMembership) []portainer.TeamMembership {
+	effective := slices.Clone(memberships)
+
+	isMember := func(teamID portainer.TeamID) bool {
+		return slices.ContainsFunc(effective, func(membership portainer.TeamMembership) bool {
+			return membership.TeamID == teamID
+		})
+	}
+
+	for _, membership := range memberships {
+		for _, ancestorID := range TeamAncestors(teams, membership.TeamID) {
+			if isMember(ancestorID) {
+				continue
+			}
+
+			effective = append(effective, portainer.TeamMembership{
+				UserID: membership.UserID,
+				TeamID: ancestorID,
+				Role:   portainer.TeamMember,
+			})
+		}
+	}
+
+	return effective
+}
+
+// EffectiveTeamMemberships returns the memberships of a user, including the ones inherited from the ancestors of its
+// teams
+func EffectiveTeamMemberships(tx dataservices.DataStoreTx, userID portainer.UserID) ([]portainer.TeamMembership, error) {
+	memberships, err := tx.TeamMembership().TeamMembershipsByUserID(userID)
+	if err != nil || len(memberships) == 0 {
+		return memberships, err
+	}
+
+	teams, err := tx.Team().ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	return InheritTeamMemberships(teams, memberships), nil
+}
+
+// EffectiveTeamMembershipsByTeamID returns the memberships of a team and of the teams below it, whose members
+// inherit its access
+func EffectiveTeamMembershipsByTeamID(tx dataservices.DataStoreTx, teamID portainer.TeamID) ([]portainer.TeamMembership, error) {
+	memberships, err := tx.TeamMembership().TeamMembershipsByTeamID(teamID)
+	if err != nil {
+		return nil, err
+	}
+
+	teams, err := tx.Team().ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, descendantID := range TeamDescendants(teams, teamID) {
+		descendantMemberships, err := tx.TeamMembership().TeamMembershipsByTeamID(descendantID)
+		if err != nil {
+			return nil, err
+		}
+
+		memberships = append(memberships, descendantMemberships...)
+	}
+
+	return memberships, nil
+}
@@ -0,0 +1,56 @@
+package authorization
+
+import (
+	"testing"
+
+	portainer "github.com/portainer/portainer/api"
+
+	"github.com/stretchr/testify/require"
+)
+
+// org > department > squad, and a flat team
+var testTeams = []portainer.Team{
+	{ID: 1, Name: "org"},
+	{ID: 2, Name: "department", ParentID: 1},
+	{ID: 3, Name: "squad", ParentID: 2},
+	{ID: 4, Name: "flat"},
+}
+
+func TestTeamAncestors(t *testing.T) {
+	is := require.New(t)
+
+	is.Equal([]portainer.TeamID{2, 1}, TeamAncestors(testTeams, 3))
+	is.Empty(TeamAncestors(testTeams, 1))
+	is.Empty(TeamAncestors(testTeams, 4))
+
+	is.ElementsMatch([]portainer.TeamID{2, 3}, TeamDescendants(testTeams, 1))
+	is.Empty(TeamDescendants(testTeams, 3))
+
+	// a corrupted hierarchy does not loop forever
+	is.Equal([]portainer.TeamID{6}, TeamAncestors([]portainer.Team{{ID: 5, ParentID: 6}, {ID: 6, ParentID: 5}}, 5))
+}
+
+func TestValidateTeamParent(t *testing.T) {
+	is := require.New(t)
+
+	is.NoError(ValidateTeamParent(testTeams, 4, 3))
+	is.NoError(ValidateTeamParent(testTeams, 3, 0))
+	is.ErrorIs(ValidateTeamParent(testTeams, 4, 10), ErrTeamParentNotFound)
+	is.ErrorIs(ValidateTeamParent(testTeams, 1, 1), ErrTeamHierarchyCycle)
+	is.ErrorIs(ValidateTeamParent(testTeams, 1, 3), ErrTeamHierarchyCycle)
+}
+
+func TestInheritTeamMemberships(t *testing.T) {
+	is := require.New(t)
+
+	memberships := InheritTeamMemberships(testTeams, []portainer.TeamMembership{
+		{ID: 1, UserID: 2, TeamID: 3, Role: portainer.TeamLeader},
+		{ID: 2, UserID: 2, TeamID: 1, Role: portainer.TeamLeader},
+	})
+
+	is.Equal([]portainer.TeamMembership{
+		{ID: 1, UserID: 2, TeamID: 3, Role: portainer.TeamLeader},
+		{ID: 2, UserID: 2, TeamID: 1, Role: portainer.TeamLeader},
+		{UserID: 2, TeamID: 2, Role: portainer.TeamMember},
+	}, memberships)
+}
@@ -0,0 +1,260 @@
+// Package compliance compares the desired state of the edge environments, the edge stacks targeting them,
+// with the state reported by their agents
+package compliance
+
+import (
+	"fmt"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+	"github.com/portainer/portainer/api/internal/edge"
+	"github.com/portainer/portainer/api/internal/endpointutils"
+)
+
+// State represents the compliance state of an environment or of one of its edge stacks
+type State string
+
+const (
+	// StateInSync means that the environment runs the current version of all its edge stacks
+	StateInSync State = "InSync"
+	// StateDrifted means that at least one edge stack is not deployed in its current version yet
+	StateDrifted State = "Drifted"
+	// StateOffline means that the agent did not check in recently, its reported state cannot be trusted
+	StateOffline State = "Offline"
+	// StateError means that the deployment of at least one edge stack failed
+	StateError State = "Error"
+)
+
+// IsValid returns true when the state is one of the known states
+func (state State) IsValid() bool {
+	switch state {
+	case StateInSync, StateDrifted, StateOffline, StateError:
+		return true
+	}
+
+	return false
+}
+
+// StackCompliance represents the compliance of an edge stack on an environment
+type StackCompliance struct {
+	EdgeStackID portainer.EdgeStackID `json:"EdgeStackId" example:"1"`
+	Name        string                `json:"Name"`
+	// Version is the current version of the edge stack
+	Version int   `json:"Version" example:"3"`
+	State   State `json:"State" example:"InSync"`
+	// LastStatus is the last status reported by the agent for the current version, nil when nothing was reported yet
+	LastStatus *portainer.EdgeStackStatusType `json:"LastStatus,omitempty"`
+	// LastStatusDate is the unix timestamp of the last status
+	LastStatusDate int64  `json:"LastStatusDate,omitempty"`
+	Error          string `json:"Error,omitempty"`
+}
+
+// DeviceCompliance represents the compliance of an edge environment
+type DeviceCompliance struct {
+	EndpointID portainer.EndpointID `json:"EndpointId" example:"1"`
+	Name       string               `json:"Name"`
+	State      State                `json:"State" example:"Drifted"`
+	// LastCheckInDate is the unix timestamp of the last check in of the agent
+	LastCheckInDate int64             `json:"LastCheckInDate"`
+	Stacks          []StackCompliance `json:"Stacks"`
+}
+
+// Summary counts the environments of each state
+type Summary struct {
+	Total   int `json:"Total"`
+	InSync  int `json:"InSync"`
+	Drifted int `json:"Drifted"`
+	Offline int `json:"Offline"`
+	Error   int `json:"Error"`
+}
+
+func (summary *Summary) add(state State) {
+	summary.Total++
+
+	switch state {
+	case StateInSync:
+		summary.InSync++
+	case StateDrifted:
+		summary.Drifted++
+	case StateOffline:
+		summary.Offline++
+	case StateError:
+		summary.Error++
+	}
+}
+
+// Report represents the compliance of a set of edge environments
+type Report struct {
+	Summary Summary            `json:"Summary"`
+	Devices []DeviceCompliance `json:"Devices"`
+}
+
+// Filter returns a copy of the report only listing the devices in the given state, the summary is kept as is
+func (report Report) Filter(state State) Report {
+	filtered := Report{Summary: report.Summary, Devices: []DeviceCompliance{}}
+	for _, device := range report.Devices {
+		if device.State == state {
+			filtered.Devices = append(filtered.Devices, device)
+		}
+	}
+
+	return filtered
+}
+
+// Evaluator computes the compliance of the edge environments from a snapshot of the database
+type Evaluator struct {
+	settings       *portainer.Settings
+	endpoints      []portainer.Endpoint
+	endpointGroups []portainer.EndpointGroup
+	edgeGroups     []portainer.EdgeGroup
+	edgeStacks     []portainer.EdgeStack
+}
+
+// NewEvaluator loads the environments, groups and edge stacks required to compute the compliance
+func NewEvaluator(tx dataservices.DataStoreTx) (*Evaluator, error) {
+	settings, err := tx.Settings().Settings()
+	if err != nil {
+		return nil, fmt.Errorf("unable to retrieve the settings from the database: %w", err)
+	}
+
+	relationConfig, err := edge.FetchEndpointRelationsConfig(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	edgeStacks, err := tx.EdgeStack().EdgeStacks()
+	if err != nil {
+		return nil, fmt.Errorf("unable to retrieve edge stacks from the database: %w", err)
+	}
+
+	return &Evaluator{
+		settings:       settings,
+		endpoints:      relationConfig.Endpoints,
+		endpointGroups: relationConfig.EndpointGroups,
+		edgeGroups:     relationConfig.EdgeGroups,
+		edgeStacks:     edgeStacks,
+	}, nil
+}
+
+// EdgeGroupReport computes the compliance of the environments of an edge group
+func (evaluator *Evaluator) EdgeGroupReport(edgeGroup *portainer.EdgeGroup) Report {
+	endpointIDs := endpointutils.EndpointSet(edge.EdgeGroupRelatedEndpoints(edgeGroup, evaluator.endpoints, evaluator.endpointGroups))
+
+	return evaluator.report(func(endpoint *portainer.Endpoint) bool {
+		return endpointIDs[endpoint.ID]
+	})
+}
+
+// EndpointGroupReport computes the compliance of the edge environments of an environment group
+func (evaluator *Evaluator) EndpointGroupReport(endpointGroupID portainer.EndpointGroupID) Report {
+	return evaluator.report(func(endpoint *portainer.Endpoint) bool {
+		return endpoint.GroupID == endpointGroupID
+	})
+}
+
+func (evaluator *Evaluator) report(include func(endpoint *portainer.Endpoint) bool) Report {
+	report := Report{Devices: []DeviceCompliance{}}
+
+	for i := range evaluator.endpoints {
+		endpoint := &evaluator.endpoints[i]
+		if !endpointutils.IsEdgeEndpoint(endpoint) || !include(endpoint) {
+			continue
+		}
+
+		device := evaluator.Device(endpoint)
+		report.Summary.add(device.State)
+		report.Devices = append(report.Devices, device)
+	}
+
+	return report
+}
+
+// Device computes the compliance of an edge environment
+func (evaluator *Evaluator) Device(endpoint *portainer.Endpoint) DeviceCompliance {
+	device := DeviceCompliance{
+		EndpointID:      endpoint.ID,
+		Name:            endpoint.Name,
+		State:           StateInSync,
+		LastCheckInDate: endpoint.LastCheckInDate,
+		Stacks:          []StackCompliance{},
+	}
+
+	var endpointGroup portainer.EndpointGroup
+	for _, group := range evaluator.endpointGroups {
+		if group.ID == endpoint.GroupID {
+			endpointGroup = group
+
+			break
+		}
+	}
+
+	relatedStacks := edge.EndpointRelatedEdgeStacks(endpoint, &endpointGroup, evaluator.edgeGroups, evaluator.edgeStacks)
+
+	for i := range evaluator.edgeStacks {
+		stack := &evaluator.edgeStacks[i]
+
+		for _, stackID := range relatedStacks {
+			if stack.ID == stackID {
+				device.Stacks = append(device.Stacks, stackCompliance(stack, endpoint.ID))
+
+				break
+			}
+		}
+	}
+
+	// the heartbeat is computed on a copy to leave the snapshot untouched
+	heartbeat := *endpoint
+	endpointutils.UpdateEdgeEndpointHeartbeat(&heartbeat, evaluator.settings)
+	if !heartbeat.Heartbeat {
+		device.State = StateOffline
+
+		return device
+	}
+
+	for _, stack := range device.Stacks {
+		switch stack.State {
+		case StateError:
+			device.State = StateError
+		case StateDrifted:
+			if device.State == StateInSync {
+				device.State = StateDrifted
+			}
+		}
+	}
+
+	return device
+}
+
+func stackCompliance(stack *portainer.EdgeStack, endpointID portainer.EndpointID) StackCompliance {
+	compliance := StackCompliance{
+		EdgeStackID: stack.ID,
+		Name:        stack.Name,
+		Version:     stack.Version,
+		State:       StateDrifted,
+	}
+
+	// the statuses are reset whenever the stack is updated, they always relate to its current version
+	envStatus, ok := stack.Status[endpointID]
+	if !ok || len(envStatus.Status) == 0 {
+		return compliance
+	}
+
+	lastStatus := envStatus.Status[len(envStatus.Status)-1]
+	compliance.LastStatus = &lastStatus.Type
+	compliance.LastStatusDate = lastStatus.Time
+	compliance.Error = lastStatus.Error
+
+	switch lastStatus.Type {
+	case portainer.EdgeStackStatusError:
+		compliance.State = StateError
+	case portainer.EdgeStackStatusRunning, portainer.EdgeStackStatusCompleted, portainer.EdgeStackStatusRemoteUpdateSuccess:
+		compliance.State = StateInSync
+	case portainer.EdgeStackStatusImagesPulled:
+		// pulling the images is all that is expected until the stack is activated
+		if stack.PrePull.Enabled && !stack.PrePull.Activated {
+			compliance.State = StateInSync
+		}
+	}
+
+	return compliance
+}
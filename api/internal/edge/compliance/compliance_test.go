@@ -0,0 +1,136 @@
+package compliance
+
+import (
+	"testing"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newEvaluator(endpoints []portainer.Endpoint, edgeStacks []portainer.EdgeStack) *Evaluator {
+	return &Evaluator{
+		settings:       &portainer.Settings{EdgeAgentCheckinInterval: 5},
+		endpoints:      endpoints,
+		endpointGroups: []portainer.EndpointGroup{{ID: 1}, {ID: 2}},
+		edgeGroups: []portainer.EdgeGroup{
+			{ID: 1, Endpoints: []portainer.EndpointID{1, 2, 3, 4}},
+		},
+		edgeStacks: edgeStacks,
+	}
+}
+
+func newEdgeEndpoint(id portainer.EndpointID, groupID portainer.EndpointGroupID, lastCheckIn int64) portainer.Endpoint {
+	return portainer.Endpoint{
+		ID:              id,
+		Name:            "env",
+		Type:            portainer.EdgeAgentOnDockerEnvironment,
+		GroupID:         groupID,
+		LastCheckInDate: lastCheckIn,
+	}
+}
+
+func reported(types ...portainer.EdgeStackStatusType) portainer.EdgeStackStatus {
+	status := portainer.EdgeStackStatus{}
+	for _, t := range types {
+		status.Status = append(status.Status, portainer.EdgeStackDeploymentStatus{Type: t, Time: time.Now().Unix()})
+	}
+
+	return status
+}
+
+func TestEdgeGroupReport(t *testing.T) {
+	now := time.Now().Unix()
+
+	endpoints := []portainer.Endpoint{
+		newEdgeEndpoint(1, 1, now),
+		newEdgeEndpoint(2, 1, now),
+		newEdgeEndpoint(3, 2, now),
+		newEdgeEndpoint(4, 2, now-3600),
+		{ID: 5, Type: portainer.DockerEnvironment, GroupID: 1},
+	}
+
+	stacks := []portainer.EdgeStack{
+		{
+			ID:         1,
+			Name:       "stack",
+			Version:    2,
+			EdgeGroups: []portainer.EdgeGroupID{1},
+			Status: map[portainer.EndpointID]portainer.EdgeStackStatus{
+				1: reported(portainer.EdgeStackStatusAcknowledged, portainer.EdgeStackStatusRunning),
+				2: reported(portainer.EdgeStackStatusAcknowledged, portainer.EdgeStackStatusDeploying),
+				3: reported(portainer.EdgeStackStatusError),
+				4: reported(portainer.EdgeStackStatusRunning),
+			},
+		},
+	}
+
+	evaluator := newEvaluator(endpoints, stacks)
+
+	report := evaluator.EdgeGroupReport(&evaluator.edgeGroups[0])
+
+	assert.Equal(t, Summary{Total: 4, InSync: 1, Drifted: 1, Offline: 1, Error: 1}, report.Summary)
+	require.Len(t, report.Devices, 4)
+
+	states := map[portainer.EndpointID]State{}
+	for _, device := range report.Devices {
+		states[device.EndpointID] = device.State
+	}
+
+	assert.Equal(t, map[portainer.EndpointID]State{
+		1: StateInSync,
+		2: StateDrifted,
+		3: StateError,
+		4: StateOffline,
+	}, states)
+
+	filtered := report.Filter(StateError)
+	require.Len(t, filtered.Devices, 1)
+	assert.Equal(t, portainer.EndpointID(3), filtered.Devices[0].EndpointID)
+	assert.Equal(t, report.Summary, filtered.Summary)
+}
+
+func TestEndpointGroupReport(t *testing.T) {
+	now := time.Now().Unix()
+
+	endpoints := []portainer.Endpoint{
+		newEdgeEndpoint(1, 1, now),
+		newEdgeEndpoint(3, 2, now),
+		{ID: 5, Type: portainer.DockerEnvironment, GroupID: 1},
+	}
+
+	evaluator := newEvaluator(endpoints, nil)
+
+	report := evaluator.EndpointGroupReport(1)
+
+	// environments without edge stacks have nothing to drift from
+	assert.Equal(t, Summary{Total: 1, InSync: 1}, report.Summary)
+	require.Len(t, report.Devices, 1)
+	assert.Equal(t, portainer.EndpointID(1), report.Devices[0].EndpointID)
+	assert.Empty(t, report.Devices[0].Stacks)
+}
+
+func TestStackCompliance(t *testing.T) {
+	stack := &portainer.EdgeStack{
+		ID:      1,
+		Version: 1,
+		Status: map[portainer.EndpointID]portainer.EdgeStackStatus{
+			1: reported(portainer.EdgeStackStatusImagesPulled),
+			2: {},
+		},
+		PrePull: portainer.EdgeStackPrePullConfig{Enabled: true},
+	}
+
+	compliance := stackCompliance(stack, 1)
+	assert.Equal(t, StateInSync, compliance.State)
+	require.NotNil(t, compliance.LastStatus)
+	assert.Equal(t, portainer.EdgeStackStatusImagesPulled, *compliance.LastStatus)
+
+	assert.Equal(t, StateDrifted, stackCompliance(stack, 2).State)
+	assert.Equal(t, StateDrifted, stackCompliance(stack, 3).State)
+
+	stack.PrePull.Activated = true
+	assert.Equal(t, StateDrifted, stackCompliance(stack, 1).State)
+}
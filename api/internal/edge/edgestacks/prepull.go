@@ -0,0 +1,126 @@
+package edgestacks
+
+import (
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+)
+
+// PrePullReadiness represents the progress of the image pre-pull phase of an edge stack
+type PrePullReadiness struct {
+	// Total is the number of environments targeted by the stack
+	Total int
+	// Ready is the number of environments that reported the images as pulled
+	Ready int
+	// ReadyEndpoints is the list of environments that reported the images as pulled
+	ReadyEndpoints []portainer.EndpointID
+	// Threshold is the percentage of ready environments required to activate the stack
+	Threshold int
+	// ActivationDate is the unix timestamp before which the stack cannot be activated
+	ActivationDate int64
+	// Activated indicates whether the stack has been switched over
+	Activated bool
+}
+
+// ThresholdReached returns true when enough environments have pulled the images
+func (readiness PrePullReadiness) ThresholdReached() bool {
+	if readiness.Total == 0 {
+		return false
+	}
+
+	threshold := readiness.Threshold
+	if threshold <= 0 || threshold > 100 {
+		threshold = 100
+	}
+
+	return readiness.Ready*100 >= readiness.Total*threshold
+}
+
+// GetPrePullReadiness computes the pre-pull readiness of an edge stack from the statuses reported by the agents
+func GetPrePullReadiness(stack *portainer.EdgeStack) PrePullReadiness {
+	readiness := PrePullReadiness{
+		Total:          stack.NumDeployments,
+		Threshold:      stack.PrePull.ReadinessThreshold,
+		ActivationDate: stack.PrePull.ActivationDate,
+		Activated:      stack.PrePull.Activated,
+		ReadyEndpoints: []portainer.EndpointID{},
+	}
+
+	for endpointID, envStatus := range stack.Status {
+		for _, status := range envStatus.Status {
+			if status.Type == portainer.EdgeStackStatusImagesPulled {
+				readiness.ReadyEndpoints = append(readiness.ReadyEndpoints, endpointID)
+
+				break
+			}
+		}
+	}
+
+	readiness.Ready = len(readiness.ReadyEndpoints)
+
+	return readiness
+}
+
+// IsPrePullPending returns true when the agents must only pull the images of the stack
+func IsPrePullPending(stack *portainer.EdgeStack) bool {
+	return stack.PrePull.Enabled && !stack.PrePull.Activated
+}
+
+// ActivatePrePullIfReady switches the stack over from the pre-pull phase when the activation date
+// is reached and enough environments have pulled the images. It returns true when the stack was activated.
+// The stack version is bumped so that the agents fetch the stack again and deploy it
+func ActivatePrePullIfReady(stack *portainer.EdgeStack, now int64) bool {
+	if !IsPrePullPending(stack) {
+		return false
+	}
+
+	if stack.PrePull.ActivationDate > now {
+		return false
+	}
+
+	if !GetPrePullReadiness(stack).ThresholdReached() {
+		return false
+	}
+
+	ActivatePrePull(stack)
+
+	return true
+}
+
+// ActivatePrePull switches the stack over from the pre-pull phase regardless of its readiness
+func ActivatePrePull(stack *portainer.EdgeStack) {
+	stack.PrePull.Activated = true
+	stack.Version++
+}
+
+// ActivatePendingPrePulls activates the edge stacks in their pre-pull phase whose activation
+// date is reached and that have been pulled on enough environments
+func (service *Service) ActivatePendingPrePulls() error {
+	now := time.Now().Unix()
+
+	return service.dataStore.UpdateTx(func(tx dataservices.DataStoreTx) error {
+		edgeStacks, err := tx.EdgeStack().EdgeStacks()
+		if err != nil {
+			return errors.WithMessage(err, "unable to retrieve edge stacks from the database")
+		}
+
+		for i := range edgeStacks {
+			stack := &edgeStacks[i]
+			if !ActivatePrePullIfReady(stack, now) {
+				continue
+			}
+
+			if err := tx.EdgeStack().UpdateEdgeStack(stack.ID, stack); err != nil {
+				return errors.WithMessage(err, "unable to persist the edge stack inside the database")
+			}
+
+			log.Info().Int("stack_id", int(stack.ID)).Msg("edge stack activated after the image pre-pull phase")
+		}
+
+		return nil
+	})
+}
@@ -0,0 +1,86 @@
+package edgestacks
+
+import (
+	"testing"
+
+	portainer "github.com/portainer/portainer/api"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newPrePullStack(numDeployments int, pulled ...portainer.EndpointID) *portainer.EdgeStack {
+	stack := &portainer.EdgeStack{
+		Version:        1,
+		NumDeployments: numDeployments,
+		Status:         map[portainer.EndpointID]portainer.EdgeStackStatus{},
+		PrePull:        portainer.EdgeStackPrePullConfig{Enabled: true},
+	}
+
+	for _, endpointID := range pulled {
+		stack.Status[endpointID] = portainer.EdgeStackStatus{
+			EndpointID: endpointID,
+			Status: []portainer.EdgeStackDeploymentStatus{
+				{Type: portainer.EdgeStackStatusAcknowledged},
+				{Type: portainer.EdgeStackStatusImagesPulled},
+			},
+		}
+	}
+
+	return stack
+}
+
+func TestGetPrePullReadiness(t *testing.T) {
+	stack := newPrePullStack(3, 1, 2)
+	stack.Status[3] = portainer.EdgeStackStatus{
+		EndpointID: 3,
+		Status:     []portainer.EdgeStackDeploymentStatus{{Type: portainer.EdgeStackStatusAcknowledged}},
+	}
+
+	readiness := GetPrePullReadiness(stack)
+
+	assert.Equal(t, 3, readiness.Total)
+	assert.Equal(t, 2, readiness.Ready)
+	assert.ElementsMatch(t, []portainer.EndpointID{1, 2}, readiness.ReadyEndpoints)
+	assert.False(t, readiness.ThresholdReached())
+
+	readiness.Threshold = 60
+	assert.True(t, readiness.ThresholdReached())
+}
+
+func TestActivatePrePullIfReady(t *testing.T) {
+	t.Run("not all environments are ready", func(t *testing.T) {
+		stack := newPrePullStack(2, 1)
+
+		assert.False(t, ActivatePrePullIfReady(stack, 100))
+		assert.False(t, stack.PrePull.Activated)
+		assert.Equal(t, 1, stack.Version)
+	})
+
+	t.Run("activation date not reached", func(t *testing.T) {
+		stack := newPrePullStack(2, 1, 2)
+		stack.PrePull.ActivationDate = 200
+
+		assert.False(t, ActivatePrePullIfReady(stack, 100))
+		assert.True(t, ActivatePrePullIfReady(stack, 200))
+	})
+
+	t.Run("threshold reached", func(t *testing.T) {
+		stack := newPrePullStack(4, 1, 2)
+		stack.PrePull.ReadinessThreshold = 50
+
+		assert.True(t, ActivatePrePullIfReady(stack, 100))
+		assert.True(t, stack.PrePull.Activated)
+		assert.Equal(t, 2, stack.Version)
+		assert.False(t, IsPrePullPending(stack))
+
+		assert.False(t, ActivatePrePullIfReady(stack, 100), "an activated stack should not be activated again")
+		assert.Equal(t, 2, stack.Version)
+	})
+
+	t.Run("pre-pull disabled", func(t *testing.T) {
+		stack := newPrePullStack(1, 1)
+		stack.PrePull.Enabled = false
+
+		assert.False(t, ActivatePrePullIfReady(stack, 100))
+	})
+}
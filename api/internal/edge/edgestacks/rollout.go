@@ -0,0 +1,238 @@
+package edgestacks
+
+import (
+	"slices"
+
+	portainer "github.com/portainer/portainer/api"
+)
+
+// RolloutProgress represents the progress of the staggered rollout of the current version of an edge stack
+type RolloutProgress struct {
+	Version int                              `json:"Version" example:"2"`
+	Status  portainer.EdgeStackRolloutStatus `json:"Status" example:"running"`
+	// Batch is the number of the latest released batch
+	Batch int `json:"Batch" example:"2"`
+	// Batches is the number of batches of the rollout
+	Batches   int `json:"Batches" example:"5"`
+	Total     int `json:"Total" example:"10"`
+	Pending   int `json:"Pending" example:"6"`
+	Deploying int `json:"Deploying" example:"1"`
+	Deployed  int `json:"Deployed" example:"2"`
+	Failed    int `json:"Failed" example:"1"`
+	// Endpoints holds the rollout state of each of the environments
+	Endpoints map[portainer.EndpointID]portainer.EdgeStackRolloutEndpoint `json:"Endpoints"`
+}
+
+// Released returns the number of environments the version was released to
+func (progress RolloutProgress) Released() int {
+	return progress.Deploying + progress.Deployed + progress.Failed
+}
+
+// GetRolloutProgress computes the progress of the rollout of an edge stack
+func GetRolloutProgress(state *portainer.EdgeStackRolloutState) RolloutProgress {
+	progress := RolloutProgress{
+		Version:   state.Version,
+		Status:    state.Status,
+		Batch:     state.Batch,
+		Total:     len(state.Endpoints),
+		Endpoints: state.Endpoints,
+	}
+
+	for _, endpoint := range state.Endpoints {
+		progress.Batches = max(progress.Batches, endpoint.Batch)
+
+		switch endpoint.Status {
+		case portainer.EdgeStackRolloutEndpointDeploying:
+			progress.Deploying++
+		case portainer.EdgeStackRolloutEndpointDeployed:
+			progress.Deployed++
+		case portainer.EdgeStackRolloutEndpointFailed:
+			progress.Failed++
+		default:
+			progress.Pending++
+		}
+	}
+
+	return progress
+}
+
+// IsStaggeredRollout returns true when the rollout policy deploys the environments by batches
+func IsStaggeredRollout(policy portainer.EdgeStackRolloutPolicy) bool {
+	return policy.BatchPercentage > 0 && policy.BatchPercentage < 100
+}
+
+// StartRollout starts the rollout of the current version of an edge stack to its environments and releases the first
+// batch. The environments of the next batches keep the version released to them by the previous rollout, or have no
+// version when the stack was never released to them. Nothing is staged when the rollout policy of the stack deploys
+// all the environments at once
+func StartRollout(stack *portainer.EdgeStack, endpointIDs []portainer.EndpointID, now int64) {
+	previous := stack.RolloutState
+	stack.RolloutState = nil
+
+	if !IsStaggeredRollout(stack.Rollout) || len(endpointIDs) == 0 {
+		return
+	}
+
+	endpointIDs = slices.Clone(endpointIDs)
+	slices.Sort(endpointIDs)
+
+	batchSize := max((len(endpointIDs)*stack.Rollout.BatchPercentage+99)/100, 1)
+
+	state := &portainer.EdgeStackRolloutState{
+		Version:   stack.Version,
+		Status:    portainer.EdgeStackRolloutRunning,
+		BatchSize: batchSize,
+		Endpoints: make(map[portainer.EndpointID]portainer.EdgeStackRolloutEndpoint, len(endpointIDs)),
+	}
+
+	for i, endpointID := range endpointIDs {
+		// without a previous rollout, the previous version was deployed to all the environments at once
+		version := stack.Version - 1
+		if previous != nil {
+			version = EndpointVersion(previous, stack.Version-1, endpointID)
+		}
+
+		state.Endpoints[endpointID] = portainer.EdgeStackRolloutEndpoint{
+			Batch:     i/batchSize + 1,
+			Status:    portainer.EdgeStackRolloutEndpointPending,
+			Version:   version,
+			UpdatedAt: now,
+		}
+	}
+
+	releaseBatch(state, 1, now)
+
+	stack.RolloutState = state
+}
+
+// EndpointVersion returns the version of an edge stack released to an environment by a rollout, 0 when no version was
+// released to it yet. The environments that are not part of the rollout, such as the ones matched after its start,
+// get the current version of the stack
+func EndpointVersion(state *portainer.EdgeStackRolloutState, version int, endpointID portainer.EndpointID) int {
+	if state == nil {
+		return version
+	}
+
+	endpoint, ok := state.Endpoints[endpointID]
+	if !ok {
+		return version
+	}
+
+	return endpoint.Version
+}
+
+// RecordRolloutStatus records the deployment status reported by an environment released by the rollout of an edge
+// stack. The rollout is halted when the failure rate exceeds the threshold of the policy, and the next batch is
+// released once every environment of the current batch acknowledged its deployment. It returns true when the state of
+// the rollout changed
+func RecordRolloutStatus(stack *portainer.EdgeStack, endpointID portainer.EndpointID, status portainer.EdgeStackStatusType, now int64) bool {
+	state := stack.RolloutState
+	if state == nil {
+		return false
+	}
+
+	endpoint, ok := state.Endpoints[endpointID]
+	if !ok || endpoint.Status != portainer.EdgeStackRolloutEndpointDeploying {
+		return false
+	}
+
+	switch status {
+	case portainer.EdgeStackStatusRunning, portainer.EdgeStackStatusCompleted:
+		endpoint.Status = portainer.EdgeStackRolloutEndpointDeployed
+	case portainer.EdgeStackStatusError:
+		endpoint.Status = portainer.EdgeStackRolloutEndpointFailed
+	default:
+		return false
+	}
+
+	endpoint.UpdatedAt = now
+	state.Endpoints[endpointID] = endpoint
+
+	advanceRollout(stack.Rollout, state, now)
+
+	return true
+}
+
+// SyncRolloutEndpoints removes from the rollout of an edge stack the environments it no longer targets, which would
+// hold the release of the next batch
+func SyncRolloutEndpoints(stack *portainer.EdgeStack, endpointIDs []portainer.EndpointID, now int64) {
+	if stack.RolloutState == nil {
+		return
+	}
+
+	for endpointID := range stack.RolloutState.Endpoints {
+		if !slices.Contains(endpointIDs, endpointID) {
+			RemoveRolloutEndpoint(stack, endpointID, now)
+		}
+	}
+}
+
+// RemoveRolloutEndpoint removes an environment from the rollout of an edge stack, such as a deleted environment or an
+// environment that left the edge groups of the stack. It returns true when the state of the rollout changed
+func RemoveRolloutEndpoint(stack *portainer.EdgeStack, endpointID portainer.EndpointID, now int64) bool {
+	state := stack.RolloutState
+	if state == nil {
+		return false
+	}
+
+	if _, ok := state.Endpoints[endpointID]; !ok {
+		return false
+	}
+
+	delete(state.Endpoints, endpointID)
+
+	advanceRollout(stack.Rollout, state, now)
+
+	return true
+}
+
+// advanceRollout halts a running rollout when too many of its environments failed, or releases its next batch once
+// the current one is acknowledged
+func advanceRollout(policy portainer.EdgeStackRolloutPolicy, state *portainer.EdgeStackRolloutState, now int64) {
+	if state.Status != portainer.EdgeStackRolloutRunning {
+		return
+	}
+
+	progress := GetRolloutProgress(state)
+	if progress.Failed*100 > policy.FailureThreshold*progress.Released() {
+		state.Status = portainer.EdgeStackRolloutHalted
+
+		return
+	}
+
+	if progress.Deploying > 0 {
+		return
+	}
+
+	if progress.Pending == 0 {
+		state.Status = portainer.EdgeStackRolloutCompleted
+
+		return
+	}
+
+	// the next batch is the first one with pending environments, the batches of the removed environments are skipped
+	next := 0
+	for _, endpoint := range state.Endpoints {
+		if endpoint.Status == portainer.EdgeStackRolloutEndpointPending && (next == 0 || endpoint.Batch < next) {
+			next = endpoint.Batch
+		}
+	}
+
+	releaseBatch(state, next, now)
+}
+
+// releaseBatch releases the current version to the environments of a batch
+func releaseBatch(state *portainer.EdgeStackRolloutState, batch int, now int64) {
+	state.Batch = batch
+
+	for endpointID, endpoint := range state.Endpoints {
+		if endpoint.Batch != batch || endpoint.Status != portainer.EdgeStackRolloutEndpointPending {
+			continue
+		}
+
+		endpoint.Status = portainer.EdgeStackRolloutEndpointDeploying
+		endpoint.Version = state.Version
+		endpoint.UpdatedAt = now
+		state.Endpoints[endpointID] = endpoint
+	}
+}
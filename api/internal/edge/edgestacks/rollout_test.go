@@ -0,0 +1,131 @@
+package edgestacks
+
+import (
+	"testing"
+
+	portainer "github.com/portainer/portainer/api"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newRolloutStack(batchPercentage, failureThreshold int) *portainer.EdgeStack {
+	return &portainer.EdgeStack{
+		Version: 1,
+		Rollout: portainer.EdgeStackRolloutPolicy{BatchPercentage: batchPercentage, FailureThreshold: failureThreshold},
+	}
+}
+
+func endpointsOfBatch(state *portainer.EdgeStackRolloutState, status portainer.EdgeStackRolloutEndpointStatus) []portainer.EndpointID {
+	endpointIDs := []portainer.EndpointID{}
+	for endpointID, endpoint := range state.Endpoints {
+		if endpoint.Status == status {
+			endpointIDs = append(endpointIDs, endpointID)
+		}
+	}
+
+	return endpointIDs
+}
+
+func TestStartRollout(t *testing.T) {
+	stack := newRolloutStack(40, 0)
+
+	StartRollout(stack, []portainer.EndpointID{5, 1, 4, 2, 3}, 100)
+	require.NotNil(t, stack.RolloutState)
+
+	assert.Equal(t, 2, stack.RolloutState.BatchSize)
+	assert.Equal(t, 1, stack.RolloutState.Batch)
+	assert.ElementsMatch(t, []portainer.EndpointID{1, 2}, endpointsOfBatch(stack.RolloutState, portainer.EdgeStackRolloutEndpointDeploying))
+	assert.Equal(t, 3, stack.RolloutState.Endpoints[5].Batch)
+
+	// the stack is only sent to the released environments
+	assert.Equal(t, 1, EndpointVersion(stack.RolloutState, stack.Version, 1))
+	assert.Equal(t, 0, EndpointVersion(stack.RolloutState, stack.Version, 3))
+	assert.Equal(t, 1, EndpointVersion(stack.RolloutState, stack.Version, 6))
+
+	t.Run("the environments of the next batches keep their version", func(t *testing.T) {
+		stack.Version = 2
+		StartRollout(stack, []portainer.EndpointID{1, 2, 3, 4, 5}, 200)
+
+		assert.Equal(t, 2, EndpointVersion(stack.RolloutState, stack.Version, 1))
+		assert.Equal(t, 0, EndpointVersion(stack.RolloutState, stack.Version, 3))
+	})
+
+	t.Run("all the environments at once", func(t *testing.T) {
+		stack := newRolloutStack(0, 0)
+
+		StartRollout(stack, []portainer.EndpointID{1, 2}, 100)
+		assert.Nil(t, stack.RolloutState)
+		assert.Equal(t, 1, EndpointVersion(stack.RolloutState, stack.Version, 2))
+	})
+}
+
+func TestRecordRolloutStatus(t *testing.T) {
+	stack := newRolloutStack(50, 0)
+	StartRollout(stack, []portainer.EndpointID{1, 2, 3}, 100)
+
+	assert.False(t, RecordRolloutStatus(stack, 3, portainer.EdgeStackStatusRunning, 110), "pending environment")
+	assert.False(t, RecordRolloutStatus(stack, 1, portainer.EdgeStackStatusDeploying, 110))
+
+	assert.True(t, RecordRolloutStatus(stack, 1, portainer.EdgeStackStatusRunning, 110))
+	assert.Equal(t, 1, stack.RolloutState.Batch, "the batch is not acknowledged yet")
+
+	assert.True(t, RecordRolloutStatus(stack, 2, portainer.EdgeStackStatusRunning, 120))
+	assert.Equal(t, 2, stack.RolloutState.Batch)
+	assert.Equal(t, portainer.EdgeStackRolloutEndpointDeploying, stack.RolloutState.Endpoints[3].Status)
+	assert.Equal(t, 1, stack.RolloutState.Endpoints[3].Version)
+
+	assert.True(t, RecordRolloutStatus(stack, 3, portainer.EdgeStackStatusCompleted, 130))
+	assert.Equal(t, portainer.EdgeStackRolloutCompleted, stack.RolloutState.Status)
+
+	progress := GetRolloutProgress(stack.RolloutState)
+	assert.Equal(t, 3, progress.Deployed)
+	assert.Equal(t, 2, progress.Batches)
+}
+
+func TestRecordRolloutStatus_FailureThreshold(t *testing.T) {
+	t.Run("halted on the first failure", func(t *testing.T) {
+		stack := newRolloutStack(50, 0)
+		StartRollout(stack, []portainer.EndpointID{1, 2, 3, 4}, 100)
+
+		assert.True(t, RecordRolloutStatus(stack, 1, portainer.EdgeStackStatusError, 110))
+		assert.Equal(t, portainer.EdgeStackRolloutHalted, stack.RolloutState.Status)
+
+		// the released environments are still recorded, the next batch is never released
+		assert.True(t, RecordRolloutStatus(stack, 2, portainer.EdgeStackStatusRunning, 120))
+		assert.Equal(t, 1, stack.RolloutState.Batch)
+		assert.Equal(t, 0, EndpointVersion(stack.RolloutState, stack.Version, 3))
+	})
+
+	t.Run("failures below the threshold", func(t *testing.T) {
+		stack := newRolloutStack(50, 50)
+		StartRollout(stack, []portainer.EndpointID{1, 2, 3, 4}, 100)
+
+		RecordRolloutStatus(stack, 1, portainer.EdgeStackStatusError, 110)
+		RecordRolloutStatus(stack, 2, portainer.EdgeStackStatusRunning, 110)
+
+		assert.Equal(t, portainer.EdgeStackRolloutRunning, stack.RolloutState.Status)
+		assert.Equal(t, 2, stack.RolloutState.Batch)
+
+		RecordRolloutStatus(stack, 3, portainer.EdgeStackStatusError, 120)
+		assert.Equal(t, portainer.EdgeStackRolloutRunning, stack.RolloutState.Status, "2 failures out of 4 released environments")
+
+		RecordRolloutStatus(stack, 4, portainer.EdgeStackStatusError, 120)
+		assert.Equal(t, portainer.EdgeStackRolloutHalted, stack.RolloutState.Status, "3 failures out of 4 released environments")
+	})
+}
+
+func TestRemoveRolloutEndpoint(t *testing.T) {
+	stack := newRolloutStack(50, 0)
+	StartRollout(stack, []portainer.EndpointID{1, 2, 3, 4}, 100)
+
+	RecordRolloutStatus(stack, 1, portainer.EdgeStackStatusRunning, 110)
+
+	// the environment that left the stack no longer holds the rollout
+	assert.True(t, RemoveRolloutEndpoint(stack, 2, 120))
+	assert.Equal(t, 2, stack.RolloutState.Batch)
+	assert.False(t, RemoveRolloutEndpoint(stack, 2, 120))
+
+	SyncRolloutEndpoints(stack, []portainer.EndpointID{1, 3}, 130)
+	assert.Len(t, stack.RolloutState.Endpoints, 2)
+}
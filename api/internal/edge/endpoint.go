@@ -73,3 +73,38 @@ func EndpointInEdgeGroup(
 
 	return false, "", nil
 }
+
+// EffectiveBandwidthLimit returns the bandwidth limit, in bytes per second, that the agent of an edge environment
+// must apply to the transfers initiated by Portainer. When the environment belongs to several edge groups,
+// the most restrictive limit wins. 0 means unlimited
+func EffectiveBandwidthLimit(tx dataservices.DataStoreTx, endpoint *portainer.Endpoint) (int64, error) {
+	edgeGroups, err := tx.EdgeGroup().ReadAll()
+	if err != nil {
+		return 0, err
+	}
+
+	endpointGroup, err := tx.EndpointGroup().Read(endpoint.GroupID)
+	if tx.IsErrObjectNotFound(err) {
+		endpointGroup = &portainer.EndpointGroup{}
+	} else if err != nil {
+		return 0, err
+	}
+
+	return bandwidthLimit(endpoint, endpointGroup, edgeGroups), nil
+}
+
+func bandwidthLimit(endpoint *portainer.Endpoint, endpointGroup *portainer.EndpointGroup, edgeGroups []portainer.EdgeGroup) int64 {
+	var limit int64
+
+	for _, edgeGroup := range edgeGroups {
+		if edgeGroup.BandwidthLimit <= 0 || !edgeGroupRelatedToEndpoint(&edgeGroup, endpoint, endpointGroup) {
+			continue
+		}
+
+		if limit == 0 || edgeGroup.BandwidthLimit < limit {
+			limit = edgeGroup.BandwidthLimit
+		}
+	}
+
+	return limit
+}
@@ -0,0 +1,25 @@
+package edge
+
+import (
+	"testing"
+
+	portainer "github.com/portainer/portainer/api"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBandwidthLimit(t *testing.T) {
+	endpoint := &portainer.Endpoint{ID: 1, TagIDs: []portainer.TagID{1}}
+	endpointGroup := &portainer.EndpointGroup{ID: 1}
+
+	edgeGroups := []portainer.EdgeGroup{
+		{ID: 1, Endpoints: []portainer.EndpointID{1}},
+		{ID: 2, Endpoints: []portainer.EndpointID{1}, BandwidthLimit: 2048},
+		{ID: 3, Dynamic: true, TagIDs: []portainer.TagID{1}, BandwidthLimit: 1024},
+		{ID: 4, Endpoints: []portainer.EndpointID{2}, BandwidthLimit: 512},
+	}
+
+	assert.Equal(t, int64(1024), bandwidthLimit(endpoint, endpointGroup, edgeGroups))
+	assert.Equal(t, int64(2048), bandwidthLimit(endpoint, endpointGroup, edgeGroups[:2]))
+	assert.Equal(t, int64(0), bandwidthLimit(endpoint, endpointGroup, edgeGroups[:1]))
+}
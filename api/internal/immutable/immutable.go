@@ -0,0 +1,47 @@
+// Package immutable enforces the immutable deployment mode of the environments(endpoints), where the workloads only
+// change through the stacks deployed by Portainer unless break-glass access is active
+package immutable
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+)
+
+// MaxBreakGlassDuration bounds the time the immutable mode of an environment(endpoint) can be lifted for
+const MaxBreakGlassDuration = 24 * time.Hour
+
+// ErrImmutable is returned when a change outside of the stacks targets an environment(endpoint) in immutable mode
+var ErrImmutable = errors.New("the environment is in immutable mode, its workloads only change through stacks unless break-glass access is active")
+
+// BreakGlassActive returns true when the immutable mode of the environment(endpoint) is temporarily lifted
+func BreakGlassActive(endpoint *portainer.Endpoint, now time.Time) bool {
+	return endpoint.BreakGlass != nil && now.Unix() < endpoint.BreakGlass.ExpiresAt
+}
+
+// Locked returns true when the changes outside of the stacks are refused on the environment(endpoint), for every
+// user including the administrators
+func Locked(endpoint *portainer.Endpoint, now time.Time) bool {
+	return endpoint != nil && endpoint.Immutable && !BreakGlassActive(endpoint, now)
+}
+
+// IsChangeRequest returns true for the requests proxied to an environment(endpoint) which can change its workloads
+func IsChangeRequest(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return false
+	}
+
+	return true
+}
+
+// Check returns ErrImmutable when a change outside of the stacks is refused on the environment(endpoint)
+func Check(endpoint *portainer.Endpoint) error {
+	if Locked(endpoint, time.Now()) {
+		return ErrImmutable
+	}
+
+	return nil
+}
@@ -0,0 +1,39 @@
+package immutable
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocked(t *testing.T) {
+	is := require.New(t)
+
+	now := time.Unix(1700000000, 0)
+
+	is.False(Locked(nil, now))
+	is.False(Locked(&portainer.Endpoint{}, now))
+	is.True(Locked(&portainer.Endpoint{Immutable: true}, now))
+
+	endpoint := &portainer.Endpoint{Immutable: true, BreakGlass: &portainer.EndpointBreakGlass{ExpiresAt: now.Add(time.Hour).Unix()}}
+	is.False(Locked(endpoint, now))
+	is.True(BreakGlassActive(endpoint, now))
+
+	// expired break-glass access
+	is.True(Locked(endpoint, now.Add(2*time.Hour)))
+	is.ErrorIs(Check(&portainer.Endpoint{Immutable: true}), ErrImmutable)
+}
+
+func TestIsChangeRequest(t *testing.T) {
+	is := require.New(t)
+
+	is.False(IsChangeRequest(http.MethodGet))
+	is.False(IsChangeRequest(http.MethodHead))
+	is.True(IsChangeRequest(http.MethodPost))
+	is.True(IsChangeRequest(http.MethodDelete))
+	is.True(IsChangeRequest(http.MethodPatch))
+}
@@ -6,6 +6,7 @@ import (
 	portainer "github.com/portainer/portainer/api"
 	"github.com/portainer/portainer/api/dataservices"
 	"github.com/portainer/portainer/api/http/security"
+	"github.com/portainer/portainer/api/internal/authorization"
 )
 
 func hasPermission(
@@ -23,7 +24,7 @@ func hasPermission(
 		return true, err
 	}
 
-	teamMemberships, err := dataStore.TeamMembership().TeamMembershipsByUserID(userID)
+	teamMemberships, err := authorization.EffectiveTeamMemberships(dataStore, userID)
 	if err != nil {
 		return
 	}
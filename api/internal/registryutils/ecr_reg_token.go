@@ -1,37 +1,76 @@
 package registryutils
 
 import (
+	"strconv"
 	"time"
 
 	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/aws/awsauth"
 	"github.com/portainer/portainer/api/aws/ecr"
 	"github.com/portainer/portainer/api/dataservices"
 
 	"github.com/rs/zerolog/log"
+	"golang.org/x/sync/singleflight"
 )
 
+// regTokenRefreshMargin is how long before its expiry an ECR token is refreshed, so that a pull or a deployment does
+// not use a token that expires while it runs
+const regTokenRefreshMargin = 30 * time.Minute
+
+// regTokenGroup deduplicates the concurrent refreshes of the token of a registry
+var regTokenGroup singleflight.Group
+
+type regToken struct {
+	token  string
+	expiry int64
+}
+
 func isRegTokenValid(registry *portainer.Registry) (valid bool) {
-	return registry.AccessToken != "" && registry.AccessTokenExpiry > time.Now().Unix()
+	return registry.AccessToken != "" && registry.AccessTokenExpiry > time.Now().Add(regTokenRefreshMargin).Unix()
+}
+
+// ecrCredentials returns the AWS credentials of an ECR registry, its username and password being an access key
+func ecrCredentials(registry *portainer.Registry) awsauth.Credentials {
+	return awsauth.Credentials{
+		AccessKey:  registry.Username,
+		SecretKey:  registry.Password,
+		UseDefault: registry.Ecr.UseDefaultCredentials,
+		RoleARN:    registry.Ecr.RoleARN,
+		ExternalID: registry.Ecr.ExternalID,
+	}
 }
 
 func doGetRegToken(tx dataservices.DataStoreTx, registry *portainer.Registry) error {
-	ecrClient := ecr.NewService(registry.Username, registry.Password, registry.Ecr.Region)
-	accessToken, expiryAt, err := ecrClient.GetAuthorizationToken()
+	result, err, _ := regTokenGroup.Do(strconv.Itoa(int(registry.ID)), func() (any, error) {
+		ecrClient, err := ecr.NewService(ecrCredentials(registry), registry.Ecr.Region)
+		if err != nil {
+			return nil, err
+		}
+
+		accessToken, expiryAt, err := ecrClient.GetAuthorizationToken()
+		if err != nil {
+			return nil, err
+		}
+
+		return regToken{token: *accessToken, expiry: expiryAt.Unix()}, nil
+	})
 	if err != nil {
 		return err
 	}
 
-	registry.AccessToken = *accessToken
-	registry.AccessTokenExpiry = expiryAt.Unix()
+	token := result.(regToken)
+	registry.AccessToken = token.token
+	registry.AccessTokenExpiry = token.expiry
 
 	return tx.Registry().Update(registry.ID, registry)
 }
 
 func parseRegToken(registry *portainer.Registry) (username, password string, err error) {
-	return ecr.NewService(registry.Username, registry.Password, registry.Ecr.Region).
-		ParseAuthorizationToken(registry.AccessToken)
+	return ecr.ParseAuthorizationToken(registry.AccessToken)
 }
 
+// EnsureRegTokenValid refreshes the token of an ECR registry when it expires soon, the token being exchanged for the
+// AWS credentials of the registry
 func EnsureRegTokenValid(tx dataservices.DataStoreTx, registry *portainer.Registry) error {
 	if registry.Type != portainer.EcrRegistry {
 		return nil
@@ -43,13 +82,9 @@ func EnsureRegTokenValid(tx dataservices.DataStoreTx, registry *portainer.Regist
 		return nil
 	}
 
-	if err := doGetRegToken(tx, registry); err != nil {
-		log.Debug().Msg("refresh ECR token")
-
-		return err
-	}
+	log.Debug().Str("registry", registry.Name).Msg("refresh ECR token")
 
-	return nil
+	return doGetRegToken(tx, registry)
 }
 
 func GetRegEffectiveCredential(registry *portainer.Registry) (username, password string, err error) {
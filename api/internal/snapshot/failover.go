@@ -0,0 +1,52 @@
+package snapshot
+
+import (
+	"crypto/tls"
+	"slices"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/agent"
+
+	"github.com/rs/zerolog/log"
+)
+
+// agentFailover health checks the replicas of the agent of an environment(endpoint) whose URL is unreachable,
+// and fails its URL over to the first reachable one. It returns the version of the agent, the error of the
+// health check of the URL is returned when no replica is reachable
+func agentFailover(endpoint *portainer.Endpoint, tlsConfig *tls.Config, urlErr error) (string, error) {
+	for _, agentURL := range endpoint.AgentURLs {
+		if agentURL == endpoint.URL {
+			continue
+		}
+
+		_, version, err := agent.GetAgentVersionAndPlatform(agentURL, tlsConfig)
+		if err != nil {
+			log.Debug().
+				Str("endpoint", endpoint.Name).
+				Str("URL", agentURL).
+				Err(err).
+				Msg("agent replica unreachable")
+
+			continue
+		}
+
+		log.Warn().
+			Str("endpoint", endpoint.Name).
+			Str("from", endpoint.URL).
+			Str("to", agentURL).
+			Err(urlErr).
+			Msg("agent unreachable, failing over to another replica")
+
+		endpoint.URL = agentURL
+
+		return version, nil
+	}
+
+	return "", urlErr
+}
+
+// IsAgentFailover returns whether the URL of the environment(endpoint) was failed over to one of its agent replicas
+// by its snapshot, latest being the environment(endpoint) as currently stored
+func IsAgentFailover(latest, endpoint *portainer.Endpoint) bool {
+	return latest.URL != endpoint.URL && slices.Contains(latest.AgentURLs, endpoint.URL)
+}
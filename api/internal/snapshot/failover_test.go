@@ -0,0 +1,48 @@
+package snapshot
+
+import (
+	"crypto/tls"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	portainer "github.com/portainer/portainer/api"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAgentFailover(t *testing.T) {
+	is := require.New(t)
+
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(portainer.PortainerAgentHeader, "2.21.0")
+		w.Header().Set(portainer.HTTPResponseAgentPlatform, "1")
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	reachable := strings.TrimPrefix(srv.URL, "https://")
+	tlsConfig := &tls.Config{InsecureSkipVerify: true}
+	urlErr := errors.New("unreachable")
+
+	endpoint := &portainer.Endpoint{
+		URL:       "127.0.0.1:1",
+		AgentURLs: []string{"127.0.0.1:1", "127.0.0.1:2", reachable},
+	}
+
+	version, err := agentFailover(endpoint, tlsConfig, urlErr)
+	is.NoError(err)
+	is.Equal("2.21.0", version)
+	is.Equal(reachable, endpoint.URL)
+
+	is.True(IsAgentFailover(&portainer.Endpoint{URL: "127.0.0.1:1", AgentURLs: endpoint.AgentURLs}, endpoint))
+	is.False(IsAgentFailover(&portainer.Endpoint{URL: "127.0.0.1:1"}, endpoint))
+
+	endpoint = &portainer.Endpoint{URL: "127.0.0.1:1", AgentURLs: []string{"127.0.0.1:2"}}
+
+	_, err = agentFailover(endpoint, tlsConfig, urlErr)
+	is.ErrorIs(err, urlErr)
+	is.Equal("127.0.0.1:1", endpoint.URL)
+}
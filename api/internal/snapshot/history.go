@@ -0,0 +1,188 @@
+package snapshot
+
+import (
+	"slices"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+)
+
+// DiffVolume represents a volume added or removed between two snapshots
+type DiffVolume struct {
+	Name   string `json:"name"`
+	Driver string `json:"driver"`
+}
+
+// DiffImage represents an image added or removed between two snapshots
+type DiffImage struct {
+	ID   string   `json:"id"`
+	Tags []string `json:"tags"`
+}
+
+// SnapshotDiff represents the changes of an environment(endpoint) between two of its snapshots
+type SnapshotDiff struct {
+	EndpointID        portainer.EndpointID        `json:"endpointId"`
+	FromID            portainer.SnapshotHistoryID `json:"fromId"`
+	FromTime          int64                       `json:"fromTime"`
+	ToID              portainer.SnapshotHistoryID `json:"toId"`
+	ToTime            int64                       `json:"toTime"`
+	AddedContainers   []InventoryContainer        `json:"addedContainers"`
+	RemovedContainers []InventoryContainer        `json:"removedContainers"`
+	ImageChanges      []InventoryImageChange      `json:"imageChanges"`
+	AddedVolumes      []DiffVolume                `json:"addedVolumes"`
+	RemovedVolumes    []DiffVolume                `json:"removedVolumes"`
+	AddedImages       []DiffImage                 `json:"addedImages"`
+	RemovedImages     []DiffImage                 `json:"removedImages"`
+}
+
+// NewSnapshotDiff computes the changes of the containers, volumes and images of an environment(endpoint) between two
+// of its snapshots
+func NewSnapshotDiff(endpoint *portainer.Endpoint, from, to *portainer.SnapshotHistoryEntry) *SnapshotDiff {
+	delta := NewInventoryDelta(endpoint, from.Docker, to.Docker)
+
+	diff := &SnapshotDiff{
+		EndpointID:        endpoint.ID,
+		FromID:            from.ID,
+		FromTime:          from.Time,
+		ToID:              to.ID,
+		ToTime:            to.Time,
+		AddedContainers:   delta.Added,
+		RemovedContainers: delta.Removed,
+		ImageChanges:      delta.ImageChanges,
+	}
+
+	diff.AddedVolumes, diff.RemovedVolumes = diffByKey(snapshotVolumes(from.Docker), snapshotVolumes(to.Docker), func(v DiffVolume) string {
+		return v.Name
+	})
+
+	diff.AddedImages, diff.RemovedImages = diffByKey(snapshotImages(from.Docker), snapshotImages(to.Docker), func(i DiffImage) string {
+		return i.ID
+	})
+
+	return diff
+}
+
+func snapshotVolumes(snapshot *portainer.DockerSnapshot) []DiffVolume {
+	volumes := []DiffVolume{}
+	for _, v := range snapshot.SnapshotRaw.Volumes.Volumes {
+		if v != nil {
+			volumes = append(volumes, DiffVolume{Name: v.Name, Driver: v.Driver})
+		}
+	}
+
+	return volumes
+}
+
+func snapshotImages(snapshot *portainer.DockerSnapshot) []DiffImage {
+	images := []DiffImage{}
+	for _, i := range snapshot.SnapshotRaw.Images {
+		images = append(images, DiffImage{ID: i.ID, Tags: i.RepoTags})
+	}
+
+	return images
+}
+
+// diffByKey returns the elements of to missing from from, and the ones of from missing from to
+func diffByKey[T any](from, to []T, key func(T) string) (added, removed []T) {
+	fromKeys := make(map[string]bool, len(from))
+	for _, e := range from {
+		fromKeys[key(e)] = true
+	}
+
+	toKeys := make(map[string]bool, len(to))
+	for _, e := range to {
+		toKeys[key(e)] = true
+	}
+
+	added, removed = []T{}, []T{}
+
+	for _, e := range to {
+		if !fromKeys[key(e)] {
+			added = append(added, e)
+		}
+	}
+
+	for _, e := range from {
+		if !toKeys[key(e)] {
+			removed = append(removed, e)
+		}
+	}
+
+	return added, removed
+}
+
+// recordHistory adds the Docker snapshot to the history of the environment(endpoint) and removes its snapshots past
+// the size of the history
+func recordHistory(tx dataservices.DataStoreTx, endpointID portainer.EndpointID, dockerSnapshot *portainer.DockerSnapshot) error {
+	settings, err := tx.Settings().Settings()
+	if err != nil {
+		return err
+	}
+
+	size := settings.SnapshotHistorySize
+	if size <= 0 {
+		size = portainer.DefaultSnapshotHistorySize
+	}
+
+	if err := tx.SnapshotHistory().Create(&portainer.SnapshotHistoryEntry{
+		EndpointID: endpointID,
+		Time:       dockerSnapshot.Time,
+		Docker:     dockerSnapshot,
+	}); err != nil {
+		return err
+	}
+
+	entries, err := tx.SnapshotHistory().EntriesByEndpointID(endpointID)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries[min(size, len(entries)):] {
+		if err := tx.SnapshotHistory().Delete(entry.ID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// DeleteHistory removes the snapshots of the history of an environment(endpoint)
+func DeleteHistory(tx dataservices.DataStoreTx, endpointID portainer.EndpointID) error {
+	entries, err := tx.SnapshotHistory().EntriesByEndpointID(endpointID)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		if err := tx.SnapshotHistory().Delete(entry.ID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// FindHistoryEntry returns the snapshot of the history with the given identifier, or nil
+func FindHistoryEntry(entries []portainer.SnapshotHistoryEntry, id portainer.SnapshotHistoryID) *portainer.SnapshotHistoryEntry {
+	i := slices.IndexFunc(entries, func(e portainer.SnapshotHistoryEntry) bool {
+		return e.ID == id
+	})
+	if i < 0 {
+		return nil
+	}
+
+	return &entries[i]
+}
+
+// HistoryEntryAt returns the most recent snapshot of the history taken at or before the Unix timestamp, or nil. The
+// entries must be sorted from the most recent to the oldest one
+func HistoryEntryAt(entries []portainer.SnapshotHistoryEntry, timestamp int64) *portainer.SnapshotHistoryEntry {
+	i := slices.IndexFunc(entries, func(e portainer.SnapshotHistoryEntry) bool {
+		return e.Time <= timestamp
+	})
+	if i < 0 {
+		return nil
+	}
+
+	return &entries[i]
+}
@@ -0,0 +1,58 @@
+package snapshot
+
+import (
+	"testing"
+
+	portainer "github.com/portainer/portainer/api"
+
+	"github.com/docker/docker/api/types/image"
+	"github.com/docker/docker/api/types/volume"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewSnapshotDiff(t *testing.T) {
+	is := require.New(t)
+
+	endpoint := &portainer.Endpoint{ID: 1, Name: "production"}
+
+	from := &portainer.SnapshotHistoryEntry{ID: 1, Time: 100, Docker: &portainer.DockerSnapshot{SnapshotRaw: portainer.DockerSnapshotRaw{
+		Containers: []portainer.DockerContainerSnapshot{container("a", "web", "nginx:1.25"), container("b", "db", "postgres:16")},
+		Volumes:    volume.ListResponse{Volumes: []*volume.Volume{{Name: "data", Driver: "local"}, {Name: "cache", Driver: "local"}}},
+		Images:     []image.Summary{{ID: "sha256:nginx:1.25", RepoTags: []string{"nginx:1.25"}}},
+	}}}
+
+	to := &portainer.SnapshotHistoryEntry{ID: 2, Time: 200, Docker: &portainer.DockerSnapshot{SnapshotRaw: portainer.DockerSnapshotRaw{
+		Containers: []portainer.DockerContainerSnapshot{container("c", "web", "nginx:1.27"), container("d", "worker", "redis:7")},
+		Volumes:    volume.ListResponse{Volumes: []*volume.Volume{{Name: "data", Driver: "local"}, {Name: "logs", Driver: "local"}}},
+		Images:     []image.Summary{{ID: "sha256:nginx:1.27", RepoTags: []string{"nginx:1.27"}}},
+	}}}
+
+	diff := NewSnapshotDiff(endpoint, from, to)
+	is.Equal(portainer.SnapshotHistoryID(1), diff.FromID)
+	is.Equal(portainer.SnapshotHistoryID(2), diff.ToID)
+
+	is.Len(diff.AddedContainers, 1)
+	is.Equal("worker", diff.AddedContainers[0].Name)
+	is.Len(diff.RemovedContainers, 1)
+	is.Equal("db", diff.RemovedContainers[0].Name)
+	is.Len(diff.ImageChanges, 1)
+	is.Equal("nginx:1.27", diff.ImageChanges[0].Image)
+
+	is.Equal([]DiffVolume{{Name: "logs", Driver: "local"}}, diff.AddedVolumes)
+	is.Equal([]DiffVolume{{Name: "cache", Driver: "local"}}, diff.RemovedVolumes)
+	is.Equal("sha256:nginx:1.27", diff.AddedImages[0].ID)
+	is.Equal("sha256:nginx:1.25", diff.RemovedImages[0].ID)
+}
+
+func TestHistoryEntryAt(t *testing.T) {
+	is := require.New(t)
+
+	entries := []portainer.SnapshotHistoryEntry{{ID: 3, Time: 300}, {ID: 2, Time: 200}, {ID: 1, Time: 100}}
+
+	is.Equal(portainer.SnapshotHistoryID(2), HistoryEntryAt(entries, 250).ID)
+	is.Equal(portainer.SnapshotHistoryID(3), HistoryEntryAt(entries, 300).ID)
+	is.Nil(HistoryEntryAt(entries, 50))
+
+	is.Equal(int64(100), FindHistoryEntry(entries, 1).Time)
+	is.Nil(FindHistoryEntry(entries, 4))
+}
@@ -0,0 +1,163 @@
+package snapshot
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+
+	"github.com/segmentio/encoding/json"
+)
+
+const (
+	inventoryWebhookTimeout = 10 * time.Second
+
+	// InventorySignatureHeader is the header holding the HMAC-SHA256 signature of the inventory webhook requests
+	InventorySignatureHeader = "X-Portainer-Signature"
+)
+
+// InventoryContainer represents a container of an inventory delta
+type InventoryContainer struct {
+	ID      string `json:"id"`
+	Name    string `json:"name"`
+	Image   string `json:"image"`
+	ImageID string `json:"imageId"`
+	State   string `json:"state"`
+}
+
+// InventoryImageChange represents a container recreated from another image between two snapshots
+type InventoryImageChange struct {
+	Name            string `json:"name"`
+	ID              string `json:"id"`
+	PreviousImage   string `json:"previousImage"`
+	PreviousImageID string `json:"previousImageId"`
+	Image           string `json:"image"`
+	ImageID         string `json:"imageId"`
+}
+
+// InventoryDelta represents the changes of the containers of an environment(endpoint) between two snapshots.
+// The containers are matched by name, so that a recreated container is reported as an image change
+type InventoryDelta struct {
+	EndpointID   portainer.EndpointID   `json:"endpointId"`
+	EndpointName string                 `json:"endpointName"`
+	Time         int64                  `json:"time"`
+	Added        []InventoryContainer   `json:"added"`
+	Removed      []InventoryContainer   `json:"removed"`
+	ImageChanges []InventoryImageChange `json:"imageChanges"`
+}
+
+// IsEmpty returns whether the inventory did not change
+func (delta *InventoryDelta) IsEmpty() bool {
+	return len(delta.Added) == 0 && len(delta.Removed) == 0 && len(delta.ImageChanges) == 0
+}
+
+// NewInventoryDelta computes the inventory changes between the previous snapshot of an environment(endpoint),
+// nil for its first snapshot, and the current one
+func NewInventoryDelta(endpoint *portainer.Endpoint, previous, current *portainer.DockerSnapshot) *InventoryDelta {
+	delta := &InventoryDelta{
+		EndpointID:   endpoint.ID,
+		EndpointName: endpoint.Name,
+		Time:         current.Time,
+		Added:        []InventoryContainer{},
+		Removed:      []InventoryContainer{},
+		ImageChanges: []InventoryImageChange{},
+	}
+
+	previousContainers := map[string]InventoryContainer{}
+	if previous != nil {
+		for _, container := range previous.SnapshotRaw.Containers {
+			c := inventoryContainer(container)
+			previousContainers[c.Name] = c
+		}
+	}
+
+	for _, container := range current.SnapshotRaw.Containers {
+		c := inventoryContainer(container)
+
+		previousContainer, ok := previousContainers[c.Name]
+		if !ok {
+			delta.Added = append(delta.Added, c)
+
+			continue
+		}
+
+		delete(previousContainers, c.Name)
+
+		if previousContainer.ImageID != c.ImageID || previousContainer.Image != c.Image {
+			delta.ImageChanges = append(delta.ImageChanges, InventoryImageChange{
+				Name:            c.Name,
+				ID:              c.ID,
+				PreviousImage:   previousContainer.Image,
+				PreviousImageID: previousContainer.ImageID,
+				Image:           c.Image,
+				ImageID:         c.ImageID,
+			})
+		}
+	}
+
+	if previous != nil {
+		for _, container := range previous.SnapshotRaw.Containers {
+			c := inventoryContainer(container)
+			if _, ok := previousContainers[c.Name]; ok {
+				delta.Removed = append(delta.Removed, c)
+			}
+		}
+	}
+
+	return delta
+}
+
+func inventoryContainer(container portainer.DockerContainerSnapshot) InventoryContainer {
+	name := container.ID
+	if len(container.Names) > 0 {
+		name = strings.TrimPrefix(container.Names[0], "/")
+	}
+
+	return InventoryContainer{
+		ID:      container.ID,
+		Name:    name,
+		Image:   container.Image,
+		ImageID: container.ImageID,
+		State:   container.State,
+	}
+}
+
+// postInventoryDelta posts the inventory changes of an environment(endpoint) to the snapshot webhook
+func postInventoryDelta(client *http.Client, settings portainer.SnapshotWebhookSettings, delta *InventoryDelta) error {
+	body, err := json.Marshal(delta)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, settings.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	if settings.Secret != "" {
+		mac := hmac.New(sha256.New, []byte(settings.Secret))
+		mac.Write(body)
+
+		req.Header.Set(InventorySignatureHeader, "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("the snapshot webhook returned the status %d", resp.StatusCode)
+	}
+
+	return nil
+}
@@ -0,0 +1,81 @@
+package snapshot
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	portainer "github.com/portainer/portainer/api"
+
+	"github.com/docker/docker/api/types"
+	"github.com/segmentio/encoding/json"
+	"github.com/stretchr/testify/require"
+)
+
+func container(id, name, image string) portainer.DockerContainerSnapshot {
+	return portainer.DockerContainerSnapshot{Container: types.Container{ID: id, Names: []string{"/" + name}, Image: image, ImageID: "sha256:" + image, State: "running"}}
+}
+
+func TestNewInventoryDelta(t *testing.T) {
+	is := require.New(t)
+
+	endpoint := &portainer.Endpoint{ID: 1, Name: "production"}
+
+	previous := &portainer.DockerSnapshot{SnapshotRaw: portainer.DockerSnapshotRaw{Containers: []portainer.DockerContainerSnapshot{
+		container("a", "web", "nginx:1.25"),
+		container("b", "db", "postgres:16"),
+		container("c", "cache", "redis:7"),
+	}}}
+
+	current := &portainer.DockerSnapshot{Time: 10, SnapshotRaw: portainer.DockerSnapshotRaw{Containers: []portainer.DockerContainerSnapshot{
+		container("d", "web", "nginx:1.27"),
+		container("b", "db", "postgres:16"),
+		container("e", "worker", "app:2"),
+	}}}
+
+	delta := NewInventoryDelta(endpoint, previous, current)
+	is.False(delta.IsEmpty())
+	is.Equal(int64(10), delta.Time)
+	is.Equal([]InventoryContainer{{ID: "e", Name: "worker", Image: "app:2", ImageID: "sha256:app:2", State: "running"}}, delta.Added)
+	is.Equal([]InventoryContainer{{ID: "c", Name: "cache", Image: "redis:7", ImageID: "sha256:redis:7", State: "running"}}, delta.Removed)
+	is.Equal([]InventoryImageChange{{
+		Name:            "web",
+		ID:              "d",
+		PreviousImage:   "nginx:1.25",
+		PreviousImageID: "sha256:nginx:1.25",
+		Image:           "nginx:1.27",
+		ImageID:         "sha256:nginx:1.27",
+	}}, delta.ImageChanges)
+
+	is.True(NewInventoryDelta(endpoint, current, current).IsEmpty())
+	is.Len(NewInventoryDelta(endpoint, nil, current).Added, 3)
+}
+
+func TestPostInventoryDelta(t *testing.T) {
+	is := require.New(t)
+
+	var body []byte
+	var signature string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ = io.ReadAll(r.Body)
+		signature = r.Header.Get(InventorySignatureHeader)
+	}))
+	defer server.Close()
+
+	delta := &InventoryDelta{EndpointID: 1, Added: []InventoryContainer{{ID: "a", Name: "web"}}}
+	settings := portainer.SnapshotWebhookSettings{URL: server.URL, Secret: "secret"}
+
+	is.NoError(postInventoryDelta(server.Client(), settings, delta))
+
+	var got InventoryDelta
+	is.NoError(json.Unmarshal(body, &got))
+	is.Equal(delta.Added, got.Added)
+
+	mac := hmac.New(sha256.New, []byte("secret"))
+	mac.Write(body)
+	is.Equal("sha256="+hex.EncodeToString(mac.Sum(nil)), signature)
+}
@@ -0,0 +1,148 @@
+package snapshot
+
+import (
+	"fmt"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+	"github.com/portainer/portainer/api/docker/consts"
+	"github.com/portainer/portainer/api/internal/authorization"
+	"github.com/portainer/portainer/api/stacks/stackutils"
+)
+
+// applyOwnershipRules creates the resource controls of the containers and volumes of a snapshot which match one of
+// the ownership rules, so that the resources deployed outside of Portainer are visible to their teams without waiting
+// for them to be listed through the proxy. The resources of a stack or of a Swarm service inherit their access instead.
+// The resource controls of the containers of the previous snapshot which were removed outside of Portainer are deleted
+func applyOwnershipRules(tx dataservices.DataStoreTx, endpoint *portainer.Endpoint, previous, snapshot *portainer.DockerSnapshot) error {
+	if err := deleteRemovedContainersResourceControls(tx, previous, snapshot); err != nil {
+		return err
+	}
+
+	settings, err := tx.Settings().Settings()
+	if err != nil {
+		return err
+	}
+
+	if len(settings.OwnershipRules) == 0 {
+		return nil
+	}
+
+	teams, err := tx.Team().ReadAll()
+	if err != nil {
+		return err
+	}
+
+	resourceControls, err := tx.ResourceControl().ReadAll()
+	if err != nil {
+		return err
+	}
+
+	apply := func(resourceID string, resourceType portainer.ResourceControlType, labels map[string]string) error {
+		if authorization.GetResourceControlByResourceIDAndType(resourceID, resourceType, resourceControls) != nil || isInheritingAccess(endpoint, labels, resourceControls) {
+			return nil
+		}
+
+		resourceControl := authorization.NewResourceControlFromOwnershipRules(settings.OwnershipRules, teams, resourceID, resourceType, labels)
+		if resourceControl == nil {
+			return nil
+		}
+
+		return tx.ResourceControl().Create(resourceControl)
+	}
+
+	for _, container := range snapshot.SnapshotRaw.Containers {
+		if err := apply(container.ID, portainer.ContainerResourceControl, container.Labels); err != nil {
+			return err
+		}
+	}
+
+	if len(snapshot.SnapshotRaw.Volumes.Volumes) == 0 {
+		return nil
+	}
+
+	dockerID, err := FetchDockerID(*snapshot)
+	if err != nil {
+		return err
+	}
+
+	for _, volume := range snapshot.SnapshotRaw.Volumes.Volumes {
+		if volume == nil {
+			continue
+		}
+
+		if err := apply(fmt.Sprintf("%s_%s", volume.Name, dockerID), portainer.VolumeResourceControl, volume.Labels); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// deleteRemovedContainersResourceControls deletes the resource controls of the containers which are in the previous
+// snapshot but not in the current one. The resource controls are not bound to an environment(endpoint), the previous
+// snapshot scopes the deletion to the containers of this one
+func deleteRemovedContainersResourceControls(tx dataservices.DataStoreTx, previous, snapshot *portainer.DockerSnapshot) error {
+	containerIDs := removedContainerIDs(previous, snapshot)
+	if len(containerIDs) == 0 {
+		return nil
+	}
+
+	resourceControls, err := tx.ResourceControl().ReadAll()
+	if err != nil {
+		return err
+	}
+
+	for _, containerID := range containerIDs {
+		resourceControl := authorization.GetResourceControlByResourceIDAndType(containerID, portainer.ContainerResourceControl, resourceControls)
+		if resourceControl == nil {
+			continue
+		}
+
+		if err := tx.ResourceControl().Delete(resourceControl.ID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// removedContainerIDs returns the identifiers of the containers which are in the previous snapshot but not in the
+// current one
+func removedContainerIDs(previous, current *portainer.DockerSnapshot) []string {
+	if previous == nil {
+		return nil
+	}
+
+	containerIDs := make(map[string]struct{}, len(current.SnapshotRaw.Containers))
+	for _, container := range current.SnapshotRaw.Containers {
+		containerIDs[container.ID] = struct{}{}
+	}
+
+	removed := []string{}
+	for _, container := range previous.SnapshotRaw.Containers {
+		if _, ok := containerIDs[container.ID]; !ok {
+			removed = append(removed, container.ID)
+		}
+	}
+
+	return removed
+}
+
+// isInheritingAccess returns whether a resource inherits the access of its Swarm service or of its stack
+func isInheritingAccess(endpoint *portainer.Endpoint, labels map[string]string, resourceControls []portainer.ResourceControl) bool {
+	if labels[consts.SwarmServiceIDLabel] != "" {
+		return true
+	}
+
+	for _, label := range []string{consts.SwarmStackNameLabel, consts.ComposeStackNameLabel} {
+		if stackName := labels[label]; stackName != "" {
+			stackResourceID := stackutils.ResourceControlID(endpoint.ID, stackName)
+			if authorization.GetResourceControlByResourceIDAndType(stackResourceID, portainer.StackResourceControl, resourceControls) != nil {
+				return true
+			}
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,25 @@
+package snapshot
+
+import (
+	"testing"
+
+	portainer "github.com/portainer/portainer/api"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRemovedContainerIDs(t *testing.T) {
+	is := require.New(t)
+
+	previous := &portainer.DockerSnapshot{SnapshotRaw: portainer.DockerSnapshotRaw{
+		Containers: []portainer.DockerContainerSnapshot{container("a", "web", "nginx:1.25"), container("b", "db", "postgres:16")},
+	}}
+
+	current := &portainer.DockerSnapshot{SnapshotRaw: portainer.DockerSnapshotRaw{
+		Containers: []portainer.DockerContainerSnapshot{container("b", "db", "postgres:16"), container("c", "web", "nginx:1.27")},
+	}}
+
+	is.Equal([]string{"a"}, removedContainerIDs(previous, current))
+	is.Empty(removedContainerIDs(current, current))
+	is.Empty(removedContainerIDs(nil, current))
+}
@@ -4,14 +4,18 @@ import (
 	"context"
 	"crypto/tls"
 	"errors"
+	"net/http"
 	"time"
 
 	portainer "github.com/portainer/portainer/api"
 	"github.com/portainer/portainer/api/agent"
+	"github.com/portainer/portainer/api/chaos"
 	"github.com/portainer/portainer/api/crypto"
 	"github.com/portainer/portainer/api/dataservices"
+	"github.com/portainer/portainer/api/nomad"
 	"github.com/portainer/portainer/api/pendingactions"
 	endpointsutils "github.com/portainer/portainer/pkg/endpoints"
+	"github.com/portainer/portainer/pkg/featureflags"
 
 	"github.com/rs/zerolog/log"
 )
@@ -25,8 +29,10 @@ type Service struct {
 	snapshotIntervalInSeconds float64
 	dockerSnapshotter         portainer.DockerSnapshotter
 	kubernetesSnapshotter     portainer.KubernetesSnapshotter
+	nomadSnapshotter          *nomad.Snapshotter
 	shutdownCtx               context.Context
 	pendingActionsService     *pendingactions.PendingActionsService
+	webhookClient             *http.Client
 }
 
 // NewService creates a new instance of a service
@@ -49,8 +55,10 @@ func NewService(
 		snapshotIntervalInSeconds: interval,
 		dockerSnapshotter:         dockerSnapshotter,
 		kubernetesSnapshotter:     kubernetesSnapshotter,
+		nomadSnapshotter:          nomad.NewSnapshotter(),
 		shutdownCtx:               shutdownCtx,
 		pendingActionsService:     pendingActionsService,
+		webhookClient:             &http.Client{Timeout: inventoryWebhookTimeout},
 	}, nil
 }
 
@@ -70,7 +78,7 @@ func NewBackgroundSnapshotter(dataStore dataservices.DataStore, tunnelService po
 
 			s, err := tx.Snapshot().Read(e.ID)
 			if dataservices.IsErrObjectNotFound(err) ||
-				(err == nil && s.Docker == nil && s.Kubernetes == nil) {
+				(err == nil && s.Docker == nil && s.Kubernetes == nil && s.Nomad == nil) {
 				if err := tunnelService.Open(&e); err != nil {
 					log.Error().Err(err).Msg("could not open the tunnel")
 				}
@@ -137,6 +145,13 @@ func SupportDirectSnapshot(endpoint *portainer.Endpoint) bool {
 // SnapshotEndpoint will create a snapshot of the environment(endpoint) based on the environment(endpoint) type.
 // If the snapshot is a success, it will be associated to the environment(endpoint).
 func (service *Service) SnapshotEndpoint(endpoint *portainer.Endpoint) error {
+	// the simulated failures degrade the snapshots as the real ones would
+	if featureflags.IsEnabled(chaos.Feature) {
+		if err := chaos.DefaultRegistry.Apply(service.shutdownCtx, endpoint.ID); err != nil {
+			return err
+		}
+	}
+
 	if endpoint.Type == portainer.AgentOnDockerEnvironment || endpoint.Type == portainer.AgentOnKubernetesEnvironment {
 		var err error
 		var tlsConfig *tls.Config
@@ -149,6 +164,10 @@ func (service *Service) SnapshotEndpoint(endpoint *portainer.Endpoint) error {
 		}
 
 		_, version, err := agent.GetAgentVersionAndPlatform(endpoint.URL, tlsConfig)
+		if err != nil && endpoint.Type == portainer.AgentOnDockerEnvironment {
+			version, err = agentFailover(endpoint, tlsConfig, err)
+		}
+
 		if err != nil {
 			return err
 		}
@@ -161,6 +180,8 @@ func (service *Service) SnapshotEndpoint(endpoint *portainer.Endpoint) error {
 		return nil
 	case portainer.KubernetesLocalEnvironment, portainer.AgentOnKubernetesEnvironment, portainer.EdgeAgentOnKubernetesEnvironment:
 		return service.snapshotKubernetesEndpoint(endpoint)
+	case portainer.NomadEnvironment:
+		return service.snapshotNomadEndpoint(endpoint)
 	}
 
 	return service.snapshotDockerEndpoint(endpoint)
@@ -189,6 +210,15 @@ func (service *Service) snapshotKubernetesEndpoint(endpoint *portainer.Endpoint)
 	return nil
 }
 
+func (service *Service) snapshotNomadEndpoint(endpoint *portainer.Endpoint) error {
+	nomadSnapshot, err := service.nomadSnapshotter.CreateSnapshot(endpoint)
+	if err != nil {
+		return err
+	}
+
+	return service.dataStore.Snapshot().Create(&portainer.Snapshot{EndpointID: endpoint.ID, Nomad: nomadSnapshot})
+}
+
 func (service *Service) snapshotDockerEndpoint(endpoint *portainer.Endpoint) error {
 	dockerSnapshot, err := service.dockerSnapshotter.CreateSnapshot(endpoint)
 	if err != nil {
@@ -199,15 +229,71 @@ func (service *Service) snapshotDockerEndpoint(endpoint *portainer.Endpoint) err
 		return err
 	}
 
-	if dockerSnapshot != nil {
-		snapshot := &portainer.Snapshot{EndpointID: endpoint.ID, Docker: dockerSnapshot}
+	if dockerSnapshot == nil {
+		return nil
+	}
+
+	var previous *portainer.DockerSnapshot
+	if s, err := service.dataStore.Snapshot().Read(endpoint.ID); err == nil {
+		previous = s.Docker
+	}
 
-		return service.dataStore.Snapshot().Create(snapshot)
+	snapshot := &portainer.Snapshot{EndpointID: endpoint.ID, Docker: dockerSnapshot}
+	if err := service.dataStore.Snapshot().Create(snapshot); err != nil {
+		return err
+	}
+
+	service.sendInventoryDelta(endpoint, previous, dockerSnapshot)
+
+	// the history and the ownership are best effort, they must not mark the environment(endpoint) as down
+	if err := service.dataStore.UpdateTx(func(tx dataservices.DataStoreTx) error {
+		return recordHistory(tx, endpoint.ID, dockerSnapshot)
+	}); err != nil {
+		log.Warn().
+			Err(err).
+			Int("endpoint_id", int(endpoint.ID)).
+			Msg("unable to record the snapshot in the history of the environment")
+	}
+
+	if err := service.dataStore.UpdateTx(func(tx dataservices.DataStoreTx) error {
+		return applyOwnershipRules(tx, endpoint, previous, dockerSnapshot)
+	}); err != nil {
+		log.Warn().
+			Err(err).
+			Int("endpoint_id", int(endpoint.ID)).
+			Msg("unable to apply the ownership rules to the resources of the environment")
 	}
 
 	return nil
 }
 
+// sendInventoryDelta posts the inventory changes of the environment(endpoint) to the snapshot webhook when it is
+// configured, the failures are only logged so that they do not mark the environment(endpoint) as down
+func (service *Service) sendInventoryDelta(endpoint *portainer.Endpoint, previous, current *portainer.DockerSnapshot) {
+	settings, err := service.dataStore.Settings().Settings()
+	if err != nil {
+		log.Warn().Err(err).Msg("unable to retrieve the settings from the database")
+
+		return
+	}
+
+	if settings.SnapshotWebhookSettings.URL == "" {
+		return
+	}
+
+	delta := NewInventoryDelta(endpoint, previous, current)
+	if delta.IsEmpty() {
+		return
+	}
+
+	if err := postInventoryDelta(service.webhookClient, settings.SnapshotWebhookSettings, delta); err != nil {
+		log.Warn().
+			Err(err).
+			Int("endpoint_id", int(endpoint.ID)).
+			Msg("unable to send the inventory changes to the snapshot webhook")
+	}
+}
+
 func validateContainerEngineCompatibility(endpoint *portainer.Endpoint, dockerSnapshot *portainer.DockerSnapshot) error {
 	if endpoint.ContainerEngine == portainer.ContainerEngineDocker && dockerSnapshot.IsPodman {
 		err := errors.New("the Docker environment option doesn't support Podman environments. Please select the Podman option instead.")
@@ -300,6 +386,10 @@ func updateEndpointStatus(tx dataservices.DataStoreTx, endpoint *portainer.Endpo
 
 	latestEndpointReference.Agent.Version = endpoint.Agent.Version
 
+	if IsAgentFailover(latestEndpointReference, endpoint) {
+		latestEndpointReference.URL = endpoint.URL
+	}
+
 	if err := tx.Endpoint().UpdateEndpoint(latestEndpointReference.ID, latestEndpointReference); err != nil {
 		log.Debug().
 			Str("endpoint", endpoint.Name).
@@ -333,6 +423,9 @@ func FillSnapshotData(tx dataservices.DataStoreTx, endpoint *portainer.Endpoint)
 	if tx.IsErrObjectNotFound(err) {
 		endpoint.Snapshots = []portainer.DockerSnapshot{}
 		endpoint.Kubernetes.Snapshots = []portainer.KubernetesSnapshot{}
+		if endpoint.Nomad != nil {
+			endpoint.Nomad.Snapshots = []portainer.NomadSnapshot{}
+		}
 
 		return nil
 	} else if err != nil {
@@ -347,5 +440,9 @@ func FillSnapshotData(tx dataservices.DataStoreTx, endpoint *portainer.Endpoint)
 		endpoint.Kubernetes.Snapshots = []portainer.KubernetesSnapshot{*snapshot.Kubernetes}
 	}
 
+	if snapshot.Nomad != nil && endpoint.Nomad != nil {
+		endpoint.Nomad.Snapshots = []portainer.NomadSnapshot{*snapshot.Nomad}
+	}
+
 	return nil
 }
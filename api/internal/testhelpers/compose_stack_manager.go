@@ -36,3 +36,11 @@ func (manager *composeStackManager) Down(ctx context.Context, stack *portainer.S
 func (manager *composeStackManager) Pull(ctx context.Context, stack *portainer.Stack, endpoint *portainer.Endpoint, options portainer.ComposeOptions) error {
 	return nil
 }
+
+func (manager *composeStackManager) Build(ctx context.Context, stack *portainer.Stack, endpoint *portainer.Endpoint, options portainer.ComposeBuildOptions) error {
+	return nil
+}
+
+func (manager *composeStackManager) Scale(ctx context.Context, stack *portainer.Stack, endpoint *portainer.Endpoint, serviceName string, replicas int, options portainer.ComposeOptions) error {
+	return nil
+}
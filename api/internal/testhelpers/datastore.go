@@ -10,29 +10,48 @@ import (
 )
 
 type testDatastore struct {
+	auditLog                dataservices.AuditLogService
+	cloudCredential         dataservices.CloudCredentialService
+	containerJob            dataservices.ContainerJobService
+	containerJobRun         dataservices.ContainerJobRunService
 	customTemplate          dataservices.CustomTemplateService
+	dashboard               dataservices.DashboardService
 	edgeGroup               dataservices.EdgeGroupService
 	edgeJob                 dataservices.EdgeJobService
+	edgeJobExecution        dataservices.EdgeJobExecutionService
 	edgeStack               dataservices.EdgeStackService
 	endpoint                dataservices.EndpointService
 	endpointGroup           dataservices.EndpointGroupService
 	endpointRelation        dataservices.EndpointRelationService
+	fleetStack              dataservices.FleetStackService
 	helmUserRepository      dataservices.HelmUserRepositoryService
+	jwtSigningKey           dataservices.JWTSigningKeyService
+	loginAttempt            dataservices.LoginAttemptService
+	prunePolicy             dataservices.PrunePolicyService
+	pruneReport             dataservices.PruneReportService
+	registrationToken       dataservices.RegistrationTokenService
 	registry                dataservices.RegistryService
 	resourceControl         dataservices.ResourceControlService
 	apiKeyRepositoryService dataservices.APIKeyRepository
 	role                    dataservices.RoleService
+	session                 dataservices.SessionService
+	sessionRecording        dataservices.SessionRecordingService
 	sslSettings             dataservices.SSLSettingsService
 	settings                dataservices.SettingsService
 	snapshot                dataservices.SnapshotService
+	snapshotHistory         dataservices.SnapshotHistoryService
 	stack                   dataservices.StackService
+	stackVersion            dataservices.StackVersionService
 	tag                     dataservices.TagService
 	teamMembership          dataservices.TeamMembershipService
 	team                    dataservices.TeamService
 	tunnelServer            dataservices.TunnelServerService
+	twoFactor               dataservices.TwoFactorService
 	user                    dataservices.UserService
 	version                 dataservices.VersionService
+	webAuthnCredential      dataservices.WebAuthnCredentialService
 	webhook                 dataservices.WebhookService
+	webhookLog              dataservices.WebhookLogService
 	pendingActionsService   dataservices.PendingActionsService
 	connection              portainer.Connection
 }
@@ -47,39 +66,81 @@ func (d *testDatastore) ViewTx(func(dataservices.DataStoreTx) error) error   { r
 func (d *testDatastore) CheckCurrentEdition() error                         { return nil }
 func (d *testDatastore) MigrateData() error                                 { return nil }
 func (d *testDatastore) Rollback(force bool) error                          { return nil }
+func (d *testDatastore) AuditLog() dataservices.AuditLogService             { return d.auditLog }
 func (d *testDatastore) CustomTemplate() dataservices.CustomTemplateService { return d.customTemplate }
+func (d *testDatastore) Dashboard() dataservices.DashboardService           { return d.dashboard }
 func (d *testDatastore) EdgeGroup() dataservices.EdgeGroupService           { return d.edgeGroup }
 func (d *testDatastore) EdgeJob() dataservices.EdgeJobService               { return d.edgeJob }
 func (d *testDatastore) EdgeStack() dataservices.EdgeStackService           { return d.edgeStack }
 func (d *testDatastore) Endpoint() dataservices.EndpointService             { return d.endpoint }
 func (d *testDatastore) EndpointGroup() dataservices.EndpointGroupService   { return d.endpointGroup }
 
+func (d *testDatastore) EdgeJobExecution() dataservices.EdgeJobExecutionService {
+	return d.edgeJobExecution
+}
+
 func (d *testDatastore) EndpointRelation() dataservices.EndpointRelationService {
 	return d.endpointRelation
 }
 
+func (d *testDatastore) FleetStack() dataservices.FleetStackService { return d.fleetStack }
+
+func (d *testDatastore) CloudCredential() dataservices.CloudCredentialService {
+	return d.cloudCredential
+}
+func (d *testDatastore) ContainerJob() dataservices.ContainerJobService { return d.containerJob }
+func (d *testDatastore) ContainerJobRun() dataservices.ContainerJobRunService {
+	return d.containerJobRun
+}
+
 func (d *testDatastore) HelmUserRepository() dataservices.HelmUserRepositoryService {
 	return d.helmUserRepository
 }
+func (d *testDatastore) JWTSigningKey() dataservices.JWTSigningKeyService {
+	return d.jwtSigningKey
+}
+func (d *testDatastore) LoginAttempt() dataservices.LoginAttemptService { return d.loginAttempt }
+func (d *testDatastore) PrunePolicy() dataservices.PrunePolicyService   { return d.prunePolicy }
+func (d *testDatastore) PruneReport() dataservices.PruneReportService   { return d.pruneReport }
+func (d *testDatastore) RegistrationToken() dataservices.RegistrationTokenService {
+	return d.registrationToken
+}
 func (d *testDatastore) Registry() dataservices.RegistryService { return d.registry }
 func (d *testDatastore) ResourceControl() dataservices.ResourceControlService {
 	return d.resourceControl
 }
-func (d *testDatastore) Role() dataservices.RoleService { return d.role }
+func (d *testDatastore) Role() dataservices.RoleService       { return d.role }
+func (d *testDatastore) Session() dataservices.SessionService { return d.session }
+func (d *testDatastore) SessionRecording() dataservices.SessionRecordingService {
+	return d.sessionRecording
+}
 func (d *testDatastore) APIKeyRepository() dataservices.APIKeyRepository {
 	return d.apiKeyRepositoryService
 }
-func (d *testDatastore) Settings() dataservices.SettingsService             { return d.settings }
-func (d *testDatastore) Snapshot() dataservices.SnapshotService             { return d.snapshot }
+func (d *testDatastore) Settings() dataservices.SettingsService { return d.settings }
+func (d *testDatastore) Snapshot() dataservices.SnapshotService { return d.snapshot }
+func (d *testDatastore) SnapshotHistory() dataservices.SnapshotHistoryService {
+	return d.snapshotHistory
+}
+func (d *testDatastore) StackVersion() dataservices.StackVersionService {
+	return d.stackVersion
+}
 func (d *testDatastore) SSLSettings() dataservices.SSLSettingsService       { return d.sslSettings }
 func (d *testDatastore) Stack() dataservices.StackService                   { return d.stack }
 func (d *testDatastore) Tag() dataservices.TagService                       { return d.tag }
 func (d *testDatastore) TeamMembership() dataservices.TeamMembershipService { return d.teamMembership }
 func (d *testDatastore) Team() dataservices.TeamService                     { return d.team }
 func (d *testDatastore) TunnelServer() dataservices.TunnelServerService     { return d.tunnelServer }
+func (d *testDatastore) TwoFactor() dataservices.TwoFactorService           { return d.twoFactor }
 func (d *testDatastore) User() dataservices.UserService                     { return d.user }
 func (d *testDatastore) Version() dataservices.VersionService               { return d.version }
-func (d *testDatastore) Webhook() dataservices.WebhookService               { return d.webhook }
+func (d *testDatastore) WebAuthnCredential() dataservices.WebAuthnCredentialService {
+	return d.webAuthnCredential
+}
+func (d *testDatastore) Webhook() dataservices.WebhookService { return d.webhook }
+func (d *testDatastore) WebhookLog() dataservices.WebhookLogService {
+	return d.webhookLog
+}
 
 func (d *testDatastore) PendingActions() dataservices.PendingActionsService {
 	return d.pendingActionsService
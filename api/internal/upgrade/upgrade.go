@@ -29,6 +29,8 @@ const (
 
 type Service interface {
 	Upgrade(platform plf.ContainerPlatform, environment *portainer.Endpoint, licenseKey string) error
+	// SwitchImage replaces the Portainer container of a Docker platform by one running the image, at the given version
+	SwitchImage(platform plf.ContainerPlatform, environment *portainer.Endpoint, image, version string) error
 }
 
 type service struct {
@@ -79,3 +81,19 @@ func (service *service) Upgrade(platform plf.ContainerPlatform, environment *por
 	service.isUpdating = false
 	return fmt.Errorf("unsupported platform %s", platform)
 }
+
+func (service *service) SwitchImage(platform plf.ContainerPlatform, environment *portainer.Endpoint, image, version string) error {
+	log.Debug().
+		Str("platform", string(platform)).
+		Str("image", image).
+		Msg("Switching the Portainer image")
+
+	switch platform {
+	case plf.PlatformDockerStandalone:
+		return service.runDockerUpdater(environment, image, "", version, "standalone")
+	case plf.PlatformDockerSwarm:
+		return service.runDockerUpdater(environment, image, "", version, "swarm")
+	}
+
+	return fmt.Errorf("unsupported platform %s", platform)
+}
@@ -18,10 +18,6 @@ import (
 )
 
 func (service *service) upgradeDocker(environment *portainer.Endpoint, licenseKey, version string, envType string) error {
-	ctx := context.TODO()
-
-	templateName := filesystem.JoinPaths(service.assetsPath, "mustache-templates", mustacheUpgradeDockerTemplateFile)
-
 	portainerImagePrefix := os.Getenv(portainerImagePrefixEnvVar)
 	if portainerImagePrefix == "" {
 		portainerImagePrefix = "portainer/portainer-ee"
@@ -29,6 +25,15 @@ func (service *service) upgradeDocker(environment *portainer.Endpoint, licenseKe
 
 	image := fmt.Sprintf("%s:%s", portainerImagePrefix, version)
 
+	return service.runDockerUpdater(environment, image, licenseKey, version, envType)
+}
+
+// runDockerUpdater replaces the Portainer container by one running the image, through the updater
+func (service *service) runDockerUpdater(environment *portainer.Endpoint, image, licenseKey, version string, envType string) error {
+	ctx := context.TODO()
+
+	templateName := filesystem.JoinPaths(service.assetsPath, "mustache-templates", mustacheUpgradeDockerTemplateFile)
+
 	skipPullImageEnv := os.Getenv(skipPullImageEnvVar)
 	skipPullImage := skipPullImageEnv != ""
 
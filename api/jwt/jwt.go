@@ -3,6 +3,7 @@ package jwt
 import (
 	"errors"
 	"fmt"
+	"sync"
 	"time"
 
 	portainer "github.com/portainer/portainer/api"
@@ -21,16 +22,27 @@ const (
 
 	defaultScope    = scope("default")
 	kubeConfigScope = scope("kubeconfig")
+	twoFactorScope  = scope("twoFactor")
 )
 
 // scope represents JWT scopes that are supported in JWT claims.
 type scope string
 
-// Service represents a service for managing JWT tokens.
+// Service represents a service for managing JWT tokens. The tokens of the user sessions are signed with
+// an asymmetric key, rotated on an interval, the tokens of the other scopes with a secret.
 type Service struct {
 	secrets            map[scope][]byte
 	userSessionTimeout time.Duration
 	dataStore          dataservices.DataStore
+
+	// signingKeys holds the keys of the user sessions, the current one first
+	signingKeys []*signingKey
+	keysMu      sync.RWMutex
+
+	// revokedTokens holds the expiry of the JWTs of the revoked sessions, indexed by their identifier
+	revokedTokens     map[string]time.Time
+	revokedTokensMu   sync.Mutex
+	revokedTokensOnce sync.Once
 }
 
 type claims struct {
@@ -39,6 +51,7 @@ type claims struct {
 	Role                int    `json:"role"`
 	Scope               scope  `json:"scope"`
 	ForceChangePassword bool   `json:"forceChangePassword"`
+	ImpersonatorID      int    `json:"impersonatorId,omitempty"`
 	jwt.RegisteredClaims
 }
 
@@ -47,31 +60,44 @@ var (
 	errInvalidJWTToken  = errors.New("invalid JWT token")
 )
 
-// NewService initializes a new service. It will generate a random key that will be used to sign JWT tokens.
+// NewService initializes a new service. It loads the keys signing the tokens of the user sessions, or creates
+// them on the first start.
 func NewService(userSessionDuration string, dataStore dataservices.DataStore) (*Service, error) {
 	userSessionTimeout, err := time.ParseDuration(userSessionDuration)
 	if err != nil {
 		return nil, err
 	}
 
-	secret := apikey.GenerateRandomKey(keyLen)
-	if secret == nil {
-		return nil, errSecretGeneration
-	}
-
 	kubeSecret, err := getOrCreateKubeSecret(dataStore)
 	if err != nil {
 		return nil, err
 	}
 
-	return &Service{
-		map[scope][]byte{
-			defaultScope:    secret,
+	twoFactorSecret := apikey.GenerateRandomKey(keyLen)
+	if twoFactorSecret == nil {
+		return nil, errSecretGeneration
+	}
+
+	service := &Service{
+		secrets: map[scope][]byte{
 			kubeConfigScope: kubeSecret,
+			twoFactorScope:  twoFactorSecret,
 		},
-		userSessionTimeout,
-		dataStore,
-	}, nil
+		userSessionTimeout: userSessionTimeout,
+		dataStore:          dataStore,
+		revokedTokens:      make(map[string]time.Time),
+	}
+
+	settings, err := dataStore.Settings().Settings()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := service.loadSigningKeys(settings); err != nil {
+		return nil, fmt.Errorf("unable to load the JWT signing keys: %w", err)
+	}
+
+	return service, nil
 }
 
 func getOrCreateKubeSecret(dataStore dataservices.DataStore) ([]byte, error) {
@@ -114,14 +140,13 @@ func (service *Service) GenerateToken(data *portainer.TokenData) (string, time.T
 // ParseAndVerifyToken parses a JWT token and verify its validity. It returns an error if token is invalid.
 func (service *Service) ParseAndVerifyToken(token string) (*portainer.TokenData, string, time.Time, error) {
 	scope := parseScope(token)
-	secret := service.secrets[scope]
+	if scope == twoFactorScope {
+		// the tokens of a pending two-factor authentication do not give access to the API
+		return nil, "", time.Time{}, errInvalidJWTToken
+	}
 
 	parsedToken, err := jwt.ParseWithClaims(token, &claims{}, func(token *jwt.Token) (any, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-		}
-
-		return secret, nil
+		return service.verifyingKey(token, scope)
 	})
 	if err != nil || parsedToken == nil {
 		return nil, "", time.Time{}, errInvalidJWTToken
@@ -137,6 +162,10 @@ func (service *Service) ParseAndVerifyToken(token string) (*portainer.TokenData,
 		return nil, "", time.Time{}, errInvalidJWTToken
 	}
 
+	if service.isRevoked(cl.ID) {
+		return nil, "", time.Time{}, errInvalidJWTToken
+	}
+
 	if cl.ExpiresAt == nil {
 		cl.ExpiresAt = &jwt.NumericDate{}
 	}
@@ -147,9 +176,35 @@ func (service *Service) ParseAndVerifyToken(token string) (*portainer.TokenData,
 		Role:                portainer.UserRole(cl.Role),
 		Token:               token,
 		ForceChangePassword: cl.ForceChangePassword,
+		ImpersonatorID:      portainer.UserID(cl.ImpersonatorID),
 	}, cl.ID, cl.ExpiresAt.Time, nil
 }
 
+// verifyingKey returns the key verifying the signature of a token: the public key identified by its kid header
+// for the user sessions, the secret of the scope otherwise
+func (service *Service) verifyingKey(token *jwt.Token, scope scope) (any, error) {
+	if scope != defaultScope {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+
+		return service.secrets[scope], nil
+	}
+
+	kid, _ := token.Header["kid"].(string)
+
+	key := service.verificationKey(kid)
+	if key == nil {
+		return nil, fmt.Errorf("unknown or expired signing key: %s", kid)
+	}
+
+	if token.Method.Alg() != key.algorithm {
+		return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+	}
+
+	return key.private.Public(), nil
+}
+
 // Parse a JWT token, fallback to defaultScope if no scope is present in the JWT
 func parseScope(token string) scope {
 	unverifiedToken, _, _ := new(jwt.Parser).ParseUnverified(token, &claims{})
@@ -157,8 +212,8 @@ func parseScope(token string) scope {
 		return defaultScope
 	}
 
-	if cl, ok := unverifiedToken.Claims.(*claims); ok && cl.Scope == kubeConfigScope {
-		return kubeConfigScope
+	if cl, ok := unverifiedToken.Claims.(*claims); ok && (cl.Scope == kubeConfigScope || cl.Scope == twoFactorScope) {
+		return cl.Scope
 	}
 
 	return defaultScope
@@ -171,7 +226,7 @@ func (service *Service) SetUserSessionDuration(userSessionDuration time.Duration
 
 func (service *Service) generateSignedToken(data *portainer.TokenData, expiresAt time.Time, scope scope) (string, error) {
 	secret, found := service.secrets[scope]
-	if !found {
+	if !found && scope != defaultScope {
 		return "", fmt.Errorf("invalid scope: %v", scope)
 	}
 
@@ -180,7 +235,8 @@ func (service *Service) generateSignedToken(data *portainer.TokenData, expiresAt
 		return "", fmt.Errorf("failed fetching settings from db: %w", err)
 	}
 
-	if settings.IsDockerDesktopExtension {
+	// the impersonation tokens keep their short expiry
+	if settings.IsDockerDesktopExtension && scope != twoFactorScope && data.ImpersonatorID == 0 {
 		log.Info().Msg("detected docker desktop extension mode")
 		expiresAt = time.Now().Add(99 * year)
 	}
@@ -196,6 +252,7 @@ func (service *Service) generateSignedToken(data *portainer.TokenData, expiresAt
 		Role:                int(data.Role),
 		Scope:               scope,
 		ForceChangePassword: data.ForceChangePassword,
+		ImpersonatorID:      int(data.ImpersonatorID),
 		RegisteredClaims: jwt.RegisteredClaims{
 			ID:        uuid.String(),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
@@ -208,7 +265,17 @@ func (service *Service) generateSignedToken(data *portainer.TokenData, expiresAt
 		cl.RegisteredClaims.ExpiresAt = nil
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, cl)
+	if scope != defaultScope {
+		return jwt.NewWithClaims(jwt.SigningMethodHS256, cl).SignedString(secret)
+	}
+
+	key, err := service.currentSigningKey(settings)
+	if err != nil {
+		return "", fmt.Errorf("unable to retrieve the JWT signing key: %w", err)
+	}
+
+	token := jwt.NewWithClaims(key.method(), cl)
+	token.Header["kid"] = key.id
 
-	return token.SignedString(secret)
+	return token.SignedString(key.private)
 }
@@ -0,0 +1,302 @@
+package jwt
+
+import (
+	"cmp"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"fmt"
+	"math/big"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+
+	"github.com/gofrs/uuid"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// AlgorithmES256 is the ECDSA P-256 signing algorithm, used by default
+	AlgorithmES256 = "ES256"
+	// AlgorithmRS256 is the RSA PKCS #1 v1.5 signing algorithm
+	AlgorithmRS256 = "RS256"
+
+	defaultKeyRotationInterval = 30 * 24 * time.Hour
+	rsaKeyBits                 = 2048
+)
+
+// signingKey is a parsed key signing the JWTs of the user sessions
+type signingKey struct {
+	id        string
+	algorithm string
+	private   crypto.Signer
+	retiredAt time.Time
+}
+
+// IsValidAlgorithm returns whether the JWTs can be signed with the given algorithm
+func IsValidAlgorithm(algorithm string) bool {
+	return algorithm == AlgorithmES256 || algorithm == AlgorithmRS256
+}
+
+// KeyRotationInterval returns the interval after which the signing key is rotated, 0 when the rotation is disabled
+func KeyRotationInterval(settings *portainer.JWTSettings) (time.Duration, error) {
+	if settings.KeyRotationInterval == "" {
+		return defaultKeyRotationInterval, nil
+	}
+
+	return time.ParseDuration(settings.KeyRotationInterval)
+}
+
+func newSigningKey(algorithm string) (*portainer.JWTSigningKey, error) {
+	var private crypto.Signer
+	var err error
+
+	switch algorithm {
+	case AlgorithmES256:
+		private, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	case AlgorithmRS256:
+		private, err = rsa.GenerateKey(rand.Reader, rsaKeyBits)
+	default:
+		return nil, fmt.Errorf("unsupported signing algorithm: %s", algorithm)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	der, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := uuid.NewV4()
+	if err != nil {
+		return nil, err
+	}
+
+	return &portainer.JWTSigningKey{
+		ID:         id.String(),
+		Algorithm:  algorithm,
+		PrivateKey: der,
+		CreatedAt:  time.Now().Unix(),
+	}, nil
+}
+
+func parseSigningKey(key portainer.JWTSigningKey) (*signingKey, error) {
+	private, err := x509.ParsePKCS8PrivateKey(key.PrivateKey)
+	if err != nil {
+		return nil, err
+	}
+
+	signer, ok := private.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("unsupported private key of the signing key %s", key.ID)
+	}
+
+	k := &signingKey{id: key.ID, algorithm: key.Algorithm, private: signer}
+	if key.RetiredAt != 0 {
+		k.retiredAt = time.Unix(key.RetiredAt, 0)
+	}
+
+	return k, nil
+}
+
+func (key *signingKey) method() jwt.SigningMethod {
+	if key.algorithm == AlgorithmRS256 {
+		return jwt.SigningMethodRS256
+	}
+
+	return jwt.SigningMethodES256
+}
+
+func (key *signingKey) jsonWebKey() portainer.JSONWebKey {
+	jwk := portainer.JSONWebKey{
+		KeyID:     key.id,
+		Use:       "sig",
+		Algorithm: key.algorithm,
+	}
+
+	switch public := key.private.Public().(type) {
+	case *ecdsa.PublicKey:
+		ecdhKey, err := public.ECDH()
+		if err != nil {
+			return jwk
+		}
+
+		// uncompressed point: 0x04 || X || Y
+		point := ecdhKey.Bytes()
+		size := (len(point) - 1) / 2
+
+		jwk.KeyType = "EC"
+		jwk.Curve = public.Curve.Params().Name
+		jwk.X = base64.RawURLEncoding.EncodeToString(point[1 : 1+size])
+		jwk.Y = base64.RawURLEncoding.EncodeToString(point[1+size:])
+	case *rsa.PublicKey:
+		jwk.KeyType = "RSA"
+		jwk.N = base64.RawURLEncoding.EncodeToString(public.N.Bytes())
+		jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes())
+	}
+
+	return jwk
+}
+
+// currentSigningKey returns the key signing the new JWTs, it is rotated when its interval expired or
+// the signing algorithm changed
+func (service *Service) currentSigningKey(settings *portainer.Settings) (*signingKey, error) {
+	service.keysMu.Lock()
+	defer service.keysMu.Unlock()
+
+	if err := service.loadSigningKeys(settings); err != nil {
+		return nil, err
+	}
+
+	return service.signingKeys[0], nil
+}
+
+// loadSigningKeys parses the stored signing keys when they changed, and rotates them when needed
+func (service *Service) loadSigningKeys(settings *portainer.Settings) error {
+	keys, err := service.dataStore.JWTSigningKey().Keys()
+	if err != nil && !service.dataStore.IsErrObjectNotFound(err) {
+		return err
+	}
+
+	if len(keys) > 0 && (len(service.signingKeys) == 0 || service.signingKeys[0].id != keys[0].ID) {
+		if err := service.setSigningKeys(keys); err != nil {
+			return err
+		}
+	}
+
+	if rotationDue(settings, keys) {
+		return service.rotateSigningKey(settings)
+	}
+
+	return nil
+}
+
+// verificationKey returns the key with the given identifier when JWTs signed with it are still accepted
+func (service *Service) verificationKey(id string) *signingKey {
+	service.keysMu.RLock()
+	defer service.keysMu.RUnlock()
+
+	for _, key := range service.signingKeys {
+		if key.id != id {
+			continue
+		}
+
+		if service.isExpired(key) {
+			return nil
+		}
+
+		return key
+	}
+
+	return nil
+}
+
+// isExpired returns whether the tokens signed with a retired key have all expired
+func (service *Service) isExpired(key *signingKey) bool {
+	return !key.retiredAt.IsZero() && time.Since(key.retiredAt) > service.userSessionTimeout
+}
+
+// JSONWebKeys returns the public keys verifying the JWTs of the user sessions, as a JSON Web Key Set
+func (service *Service) JSONWebKeys() []portainer.JSONWebKey {
+	service.keysMu.RLock()
+	defer service.keysMu.RUnlock()
+
+	keys := make([]portainer.JSONWebKey, 0, len(service.signingKeys))
+	for _, key := range service.signingKeys {
+		if !service.isExpired(key) {
+			keys = append(keys, key.jsonWebKey())
+		}
+	}
+
+	return keys
+}
+
+func rotationDue(settings *portainer.Settings, keys []portainer.JWTSigningKey) bool {
+	if len(keys) == 0 || keys[0].Algorithm != cmp.Or(settings.JWTSettings.Algorithm, AlgorithmES256) {
+		return true
+	}
+
+	// the JWTs of the Docker Desktop extension do not expire, they would be invalidated by the rotation
+	interval, err := KeyRotationInterval(&settings.JWTSettings)
+	if err != nil || interval <= 0 || settings.IsDockerDesktopExtension {
+		return false
+	}
+
+	return time.Since(time.Unix(keys[0].CreatedAt, 0)) >= interval
+}
+
+// rotateSigningKey creates a new signing key and retires the current one, the retired keys are removed once
+// the JWTs they signed have expired
+func (service *Service) rotateSigningKey(settings *portainer.Settings) error {
+	algorithm := cmp.Or(settings.JWTSettings.Algorithm, AlgorithmES256)
+
+	var keys []portainer.JWTSigningKey
+	var key *portainer.JWTSigningKey
+
+	err := service.dataStore.UpdateTx(func(tx dataservices.DataStoreTx) error {
+		current, err := tx.JWTSigningKey().Keys()
+		if err != nil && !tx.IsErrObjectNotFound(err) {
+			return err
+		}
+
+		// the keys may have been rotated since they were read
+		if !rotationDue(settings, current) {
+			keys = current
+
+			return nil
+		}
+
+		if key, err = newSigningKey(algorithm); err != nil {
+			return err
+		}
+
+		now := time.Now()
+		keys = []portainer.JWTSigningKey{*key}
+
+		for _, k := range current {
+			if k.RetiredAt == 0 {
+				k.RetiredAt = now.Unix()
+			}
+
+			if now.Sub(time.Unix(k.RetiredAt, 0)) <= service.userSessionTimeout {
+				keys = append(keys, k)
+			}
+		}
+
+		return tx.JWTSigningKey().UpdateKeys(keys)
+	})
+	if err != nil {
+		return err
+	}
+
+	if key != nil {
+		log.Info().Str("kid", key.ID).Str("algorithm", algorithm).Msg("JWT signing key rotated")
+	}
+
+	return service.setSigningKeys(keys)
+}
+
+func (service *Service) setSigningKeys(keys []portainer.JWTSigningKey) error {
+	signingKeys := make([]*signingKey, 0, len(keys))
+
+	for _, key := range keys {
+		k, err := parseSigningKey(key)
+		if err != nil {
+			return err
+		}
+
+		signingKeys = append(signingKeys, k)
+	}
+
+	service.signingKeys = signingKeys
+
+	return nil
+}
@@ -0,0 +1,108 @@
+package jwt
+
+import (
+	"testing"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/datastore"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSigningKeyRotation(t *testing.T) {
+	is := require.New(t)
+
+	_, store := datastore.MustNewTestStore(t, true, false)
+
+	is.NoError(store.User().Create(&portainer.User{ID: 1}))
+
+	service, err := NewService("1h", store)
+	is.NoError(err)
+
+	keys, err := store.JWTSigningKey().Keys()
+	is.NoError(err)
+	is.Len(keys, 1)
+	is.Equal(AlgorithmES256, keys[0].Algorithm)
+
+	// a snapshot of the settings taken before the rotation
+	settings, err := store.Settings().Settings()
+	is.NoError(err)
+
+	tokenData := &portainer.TokenData{Username: "User", ID: 1, Role: 1}
+
+	previousToken, _, err := service.GenerateToken(tokenData)
+	is.NoError(err)
+
+	// the key is rotated once its interval expired
+	keys[0].CreatedAt = time.Now().Add(-defaultKeyRotationInterval).Unix()
+	is.NoError(store.JWTSigningKey().UpdateKeys(keys))
+
+	token, _, err := service.GenerateToken(tokenData)
+	is.NoError(err)
+
+	keys, err = store.JWTSigningKey().Keys()
+	is.NoError(err)
+	is.Len(keys, 2)
+	is.NotZero(keys[1].RetiredAt)
+
+	// the keys are read again before a rotation, they are not rotated twice
+	is.NoError(service.rotateSigningKey(settings))
+
+	rotatedKeys, err := store.JWTSigningKey().Keys()
+	is.NoError(err)
+	is.Equal(keys, rotatedKeys)
+
+	// the writers of the settings cannot drop the rotated keys
+	is.NoError(store.Settings().UpdateSettings(settings))
+
+	key, err := service.currentSigningKey(settings)
+	is.NoError(err)
+	is.Equal(keys[0].ID, key.id)
+
+	_, _, _, err = service.ParseAndVerifyToken(token)
+	is.NoError(err)
+
+	// the previous key is accepted during the session timeout
+	_, _, _, err = service.ParseAndVerifyToken(previousToken)
+	is.NoError(err)
+
+	jwks := service.JSONWebKeys()
+	is.Len(jwks, 2)
+	is.Equal("EC", jwks[0].KeyType)
+	is.Equal("P-256", jwks[0].Curve)
+	is.Equal(keys[0].ID, jwks[0].KeyID)
+
+	service.SetUserSessionDuration(0)
+
+	_, _, _, err = service.ParseAndVerifyToken(previousToken)
+	is.Error(err)
+}
+
+func TestSigningKeyAlgorithm(t *testing.T) {
+	is := require.New(t)
+
+	_, store := datastore.MustNewTestStore(t, true, false)
+
+	is.NoError(store.User().Create(&portainer.User{ID: 1}))
+
+	service, err := NewService("1h", store)
+	is.NoError(err)
+
+	settings, err := store.Settings().Settings()
+	is.NoError(err)
+
+	settings.JWTSettings.Algorithm = AlgorithmRS256
+	is.NoError(store.Settings().UpdateSettings(settings))
+
+	token, _, err := service.GenerateToken(&portainer.TokenData{Username: "User", ID: 1, Role: 1})
+	is.NoError(err)
+
+	_, _, _, err = service.ParseAndVerifyToken(token)
+	is.NoError(err)
+
+	jwks := service.JSONWebKeys()
+	is.Equal("RSA", jwks[0].KeyType)
+	is.Equal(AlgorithmRS256, jwks[0].Algorithm)
+	is.Equal("AQAB", jwks[0].E)
+}
@@ -8,12 +8,7 @@ import (
 
 // GenerateTokenForKubeconfig generates a new JWT token for Kubeconfig
 func (service *Service) GenerateTokenForKubeconfig(data *portainer.TokenData) (string, error) {
-	settings, err := service.dataStore.Settings().Settings()
-	if err != nil {
-		return "", err
-	}
-
-	expiryDuration, err := time.ParseDuration(settings.KubeconfigExpiry)
+	expiryDuration, err := service.kubeconfigExpiry()
 	if err != nil {
 		return "", err
 	}
@@ -26,3 +21,31 @@ func (service *Service) GenerateTokenForKubeconfig(data *portainer.TokenData) (s
 
 	return service.generateSignedToken(data, expiryAt, kubeConfigScope)
 }
+
+// GenerateTokenForKubeconfigWithTTL generates a new JWT token for Kubeconfig expiring after ttl, or after the expiry
+// of the kubeconfigs set in the settings when it is shorter. It returns the token and the time it expires at
+func (service *Service) GenerateTokenForKubeconfigWithTTL(data *portainer.TokenData, ttl time.Duration) (string, time.Time, error) {
+	expiryDuration, err := service.kubeconfigExpiry()
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	if expiryDuration > time.Duration(0) {
+		ttl = min(ttl, expiryDuration)
+	}
+
+	expiryAt := time.Now().Add(ttl)
+
+	token, err := service.generateSignedToken(data, expiryAt, kubeConfigScope)
+
+	return token, expiryAt, err
+}
+
+func (service *Service) kubeconfigExpiry() (time.Duration, error) {
+	settings, err := service.dataStore.Settings().Settings()
+	if err != nil {
+		return 0, err
+	}
+
+	return time.ParseDuration(settings.KubeconfigExpiry)
+}
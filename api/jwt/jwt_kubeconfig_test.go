@@ -2,6 +2,7 @@ package jwt
 
 import (
 	"testing"
+	"time"
 
 	portainer "github.com/portainer/portainer/api"
 	"github.com/portainer/portainer/api/dataservices"
@@ -94,3 +95,45 @@ func TestService_GenerateTokenForKubeconfig(t *testing.T) {
 		})
 	}
 }
+
+func TestService_GenerateTokenForKubeconfigWithTTL(t *testing.T) {
+	_, store := datastore.MustNewTestStore(t, true, false)
+
+	err := store.User().Create(&portainer.User{ID: 1})
+	assert.NoError(t, err)
+
+	service, err := NewService("24h", store)
+	assert.NoError(t, err)
+
+	tokenData := &portainer.TokenData{Username: "Joe", ID: 1, Role: 1}
+
+	testCases := []struct {
+		name             string
+		kubeconfigExpiry string
+		ttl              time.Duration
+		wantTTL          time.Duration
+	}{
+		{name: "no expiry in the settings", kubeconfigExpiry: "0", ttl: time.Hour, wantTTL: time.Hour},
+		{name: "shorter than the expiry of the settings", kubeconfigExpiry: "24h", ttl: time.Hour, wantTTL: time.Hour},
+		{name: "capped by the expiry of the settings", kubeconfigExpiry: "30m", ttl: time.Hour, wantTTL: 30 * time.Minute},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			settings, err := store.Settings().Settings()
+			assert.NoError(t, err)
+
+			settings.KubeconfigExpiry = tc.kubeconfigExpiry
+			err = store.Settings().UpdateSettings(settings)
+			assert.NoError(t, err)
+
+			token, expiresAt, err := service.GenerateTokenForKubeconfigWithTTL(tokenData, tc.ttl)
+			assert.NoError(t, err)
+			assert.WithinDuration(t, time.Now().Add(tc.wantTTL), expiresAt, 5*time.Second)
+
+			_, _, tokenExpiresAt, err := service.ParseAndVerifyToken(token)
+			assert.NoError(t, err)
+			assert.WithinDuration(t, expiresAt, tokenExpiresAt, time.Second)
+		})
+	}
+}
@@ -0,0 +1,196 @@
+package jwt
+
+import (
+	"errors"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/rs/zerolog/log"
+)
+
+// GenerateSessionToken generates a new JWT token and records the session it opens, so that it can be listed
+// and revoked
+func (service *Service) GenerateSessionToken(data *portainer.TokenData, ipAddress, userAgent string) (string, time.Time, error) {
+	token, expiryTime, err := service.GenerateToken(data)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	if err := service.recordSession(token, data, ipAddress, userAgent); err != nil {
+		return "", time.Time{}, err
+	}
+
+	return token, expiryTime, nil
+}
+
+// GenerateImpersonationToken generates a JWT token expiring after duration, letting the administrator identified
+// by data.ImpersonatorID act as the user. It records the session it opens, so that the user can see and revoke it
+func (service *Service) GenerateImpersonationToken(data *portainer.TokenData, duration time.Duration, ipAddress, userAgent string) (string, time.Time, error) {
+	if data.ImpersonatorID == 0 {
+		return "", time.Time{}, errors.New("the impersonation tokens require an impersonator")
+	}
+
+	expiryTime := time.Now().Add(duration)
+
+	token, err := service.generateSignedToken(data, expiryTime, defaultScope)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	if err := service.recordSession(token, data, ipAddress, userAgent); err != nil {
+		return "", time.Time{}, err
+	}
+
+	return token, expiryTime, nil
+}
+
+func (service *Service) recordSession(token string, data *portainer.TokenData, ipAddress, userAgent string) error {
+	cl := &claims{}
+	if _, _, err := new(jwt.Parser).ParseUnverified(token, cl); err != nil {
+		return err
+	}
+
+	session := &portainer.Session{
+		TokenID:        cl.ID,
+		UserID:         data.ID,
+		IPAddress:      ipAddress,
+		UserAgent:      userAgent,
+		CreatedAt:      time.Now().Unix(),
+		ImpersonatorID: data.ImpersonatorID,
+	}
+
+	if cl.ExpiresAt != nil {
+		session.ExpiresAt = cl.ExpiresAt.Unix()
+	}
+
+	return service.dataStore.UpdateTx(func(tx dataservices.DataStoreTx) error {
+		sessions, err := tx.Session().SessionsByUserID(data.ID)
+		if err != nil {
+			return err
+		}
+
+		if err := purgeExpiredSessions(tx, sessions); err != nil {
+			return err
+		}
+
+		return tx.Session().Create(session)
+	})
+}
+
+// RevokeSession revokes a session, its JWT is rejected from now on
+func (service *Service) RevokeSession(sessionID portainer.SessionID) error {
+	var session *portainer.Session
+
+	err := service.dataStore.UpdateTx(func(tx dataservices.DataStoreTx) error {
+		var err error
+		if session, err = tx.Session().Read(sessionID); err != nil {
+			return err
+		}
+
+		session.Revoked = true
+
+		return tx.Session().Update(session.ID, session)
+	})
+	if err != nil {
+		return err
+	}
+
+	service.addRevokedToken(session)
+
+	return nil
+}
+
+// RevokeToken revokes the session opened with the given JWT token, it does nothing when the token
+// does not belong to a session
+func (service *Service) RevokeToken(token string) error {
+	_, tokenID, _, err := service.ParseAndVerifyToken(token)
+	if err != nil {
+		return err
+	}
+
+	session, err := service.dataStore.Session().SessionByTokenID(tokenID)
+	if service.dataStore.IsErrObjectNotFound(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	return service.RevokeSession(session.ID)
+}
+
+// isRevoked returns whether the JWT with the given identifier belongs to a revoked session. The revoked
+// sessions are loaded from the database on the first call
+func (service *Service) isRevoked(tokenID string) bool {
+	service.revokedTokensOnce.Do(service.loadRevokedTokens)
+
+	service.revokedTokensMu.Lock()
+	defer service.revokedTokensMu.Unlock()
+
+	_, ok := service.revokedTokens[tokenID]
+
+	return ok
+}
+
+func (service *Service) loadRevokedTokens() {
+	err := service.dataStore.UpdateTx(func(tx dataservices.DataStoreTx) error {
+		sessions, err := tx.Session().ReadAll()
+		if err != nil {
+			return err
+		}
+
+		if err := purgeExpiredSessions(tx, sessions); err != nil {
+			return err
+		}
+
+		now := time.Now().Unix()
+		for i := range sessions {
+			if sessions[i].Revoked && (sessions[i].ExpiresAt == 0 || sessions[i].ExpiresAt >= now) {
+				service.addRevokedToken(&sessions[i])
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		log.Error().Err(err).Msg("unable to load the revoked sessions")
+	}
+}
+
+// addRevokedToken adds the JWT of a revoked session to the in-memory set, and removes the tokens
+// that expired from it
+func (service *Service) addRevokedToken(session *portainer.Session) {
+	service.revokedTokensMu.Lock()
+	defer service.revokedTokensMu.Unlock()
+
+	now := time.Now()
+	for tokenID, expiresAt := range service.revokedTokens {
+		if !expiresAt.IsZero() && expiresAt.Before(now) {
+			delete(service.revokedTokens, tokenID)
+		}
+	}
+
+	var expiresAt time.Time
+	if session.ExpiresAt != 0 {
+		expiresAt = time.Unix(session.ExpiresAt, 0)
+	}
+
+	service.revokedTokens[session.TokenID] = expiresAt
+}
+
+// purgeExpiredSessions removes the sessions whose JWT expired
+func purgeExpiredSessions(tx dataservices.DataStoreTx, sessions []portainer.Session) error {
+	now := time.Now().Unix()
+
+	for _, session := range sessions {
+		if session.ExpiresAt != 0 && session.ExpiresAt < now {
+			if err := tx.Session().Delete(session.ID); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,96 @@
+package jwt
+
+import (
+	"testing"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/datastore"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSessionRevocation(t *testing.T) {
+	is := require.New(t)
+
+	_, store := datastore.MustNewTestStore(t, true, false)
+
+	err := store.User().Create(&portainer.User{ID: 1})
+	is.NoError(err)
+
+	service, err := NewService("1h", store)
+	is.NoError(err)
+
+	tokenData := &portainer.TokenData{Username: "User", ID: 1, Role: 1}
+
+	first, _, err := service.GenerateSessionToken(tokenData, "10.0.0.1", "curl/8.0")
+	is.NoError(err)
+
+	second, _, err := service.GenerateSessionToken(tokenData, "10.0.0.2", "Mozilla/5.0")
+	is.NoError(err)
+
+	sessions, err := store.Session().SessionsByUserID(1)
+	is.NoError(err)
+	is.Len(sessions, 2)
+	is.Equal("10.0.0.1", sessions[0].IPAddress)
+	is.Equal("curl/8.0", sessions[0].UserAgent)
+	is.NotZero(sessions[0].ExpiresAt)
+
+	_, tokenID, _, err := service.ParseAndVerifyToken(first)
+	is.NoError(err)
+	is.Equal(sessions[0].TokenID, tokenID)
+
+	is.NoError(service.RevokeSession(sessions[0].ID))
+
+	_, _, _, err = service.ParseAndVerifyToken(first)
+	is.Error(err)
+
+	_, _, _, err = service.ParseAndVerifyToken(second)
+	is.NoError(err)
+
+	is.NoError(service.RevokeToken(second))
+
+	_, _, _, err = service.ParseAndVerifyToken(second)
+	is.Error(err)
+
+	// the revoked sessions are loaded from the database by a new service
+	restarted, err := NewService("1h", store)
+	is.NoError(err)
+
+	restarted.secrets = service.secrets
+
+	_, _, _, err = restarted.ParseAndVerifyToken(first)
+	is.Error(err)
+}
+
+func TestGenerateImpersonationToken(t *testing.T) {
+	is := require.New(t)
+
+	_, store := datastore.MustNewTestStore(t, true, false)
+
+	err := store.User().Create(&portainer.User{ID: 1, Username: "admin", Role: portainer.AdministratorRole})
+	is.NoError(err)
+
+	err = store.User().Create(&portainer.User{ID: 2, Username: "bob"})
+	is.NoError(err)
+
+	service, err := NewService("8h", store)
+	is.NoError(err)
+
+	_, _, err = service.GenerateImpersonationToken(&portainer.TokenData{ID: 2, Username: "bob"}, 15*time.Minute, "10.0.0.1", "curl/8.0")
+	is.Error(err, "the impersonator is required")
+
+	token, expiresAt, err := service.GenerateImpersonationToken(&portainer.TokenData{ID: 2, Username: "bob", Role: portainer.StandardUserRole, ImpersonatorID: 1}, 15*time.Minute, "10.0.0.1", "curl/8.0")
+	is.NoError(err)
+	is.WithinDuration(time.Now().Add(15*time.Minute), expiresAt, time.Minute)
+
+	tokenData, _, _, err := service.ParseAndVerifyToken(token)
+	is.NoError(err)
+	is.Equal(portainer.UserID(2), tokenData.ID)
+	is.Equal(portainer.UserID(1), tokenData.ImpersonatorID)
+
+	sessions, err := store.Session().SessionsByUserID(2)
+	is.NoError(err)
+	is.Len(sessions, 1)
+	is.Equal(portainer.UserID(1), sessions[0].ImpersonatorID)
+}
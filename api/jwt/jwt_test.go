@@ -6,7 +6,6 @@ import (
 
 	portainer "github.com/portainer/portainer/api"
 	"github.com/portainer/portainer/api/datastore"
-	"github.com/portainer/portainer/api/internal/testhelpers"
 
 	"github.com/golang-jwt/jwt/v4"
 	"github.com/stretchr/testify/assert"
@@ -14,7 +13,7 @@ import (
 )
 
 func TestGenerateSignedToken(t *testing.T) {
-	dataStore := testhelpers.NewDatastore(testhelpers.WithSettingsService(&portainer.Settings{}))
+	_, dataStore := datastore.MustNewTestStore(t, true, false)
 	svc, err := NewService("24h", dataStore)
 	assert.NoError(t, err, "failed to create a copy of service")
 
@@ -29,7 +28,7 @@ func TestGenerateSignedToken(t *testing.T) {
 	assert.NoError(t, err, "failed to generate a signed token")
 
 	parsedToken, err := jwt.ParseWithClaims(generatedToken, &claims{}, func(token *jwt.Token) (any, error) {
-		return svc.secrets[defaultScope], nil
+		return svc.verifyingKey(token, defaultScope)
 	})
 	assert.NoError(t, err, "failed to parse generated token")
 
@@ -43,7 +42,7 @@ func TestGenerateSignedToken(t *testing.T) {
 }
 
 func TestGenerateSignedToken_InvalidScope(t *testing.T) {
-	dataStore := testhelpers.NewDatastore(testhelpers.WithSettingsService(&portainer.Settings{}))
+	_, dataStore := datastore.MustNewTestStore(t, true, false)
 	svc, err := NewService("24h", dataStore)
 	assert.NoError(t, err, "failed to create a copy of service")
 
@@ -111,3 +110,38 @@ func TestExpiration(t *testing.T) {
 	_, _, _, err = service.ParseAndVerifyToken(tokenString)
 	require.Error(t, err)
 }
+
+func TestTwoFactorToken(t *testing.T) {
+	_, store := datastore.MustNewTestStore(t, true, false)
+
+	err := store.User().Create(&portainer.User{ID: 1})
+	require.NoError(t, err)
+
+	service, err := NewService("1h", store)
+	require.NoError(t, err)
+
+	expectedToken := &portainer.TokenData{
+		Username: "User",
+		ID:       1,
+		Role:     1,
+	}
+
+	tokenString, expiresAt, err := service.GenerateTwoFactorToken(expectedToken)
+	require.NoError(t, err)
+	require.WithinDuration(t, time.Now().Add(twoFactorTokenExpiry), expiresAt, time.Minute)
+
+	expectedToken.Token = tokenString
+
+	token, err := service.ParseAndVerifyTwoFactorToken(tokenString)
+	require.NoError(t, err)
+	require.Equal(t, expectedToken, token)
+
+	_, _, _, err = service.ParseAndVerifyToken(tokenString)
+	require.Error(t, err, "a two-factor token must not give access to the API")
+
+	sessionToken, _, err := service.GenerateToken(expectedToken)
+	require.NoError(t, err)
+
+	_, err = service.ParseAndVerifyTwoFactorToken(sessionToken)
+	require.Error(t, err, "a session token must not be accepted as a two-factor token")
+}
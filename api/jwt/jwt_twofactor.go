@@ -0,0 +1,54 @@
+package jwt
+
+import (
+	"fmt"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// twoFactorTokenExpiry is the time a user has to provide its second factor after a valid password
+const twoFactorTokenExpiry = 5 * time.Minute
+
+// GenerateTwoFactorToken generates a short-lived JWT token identifying a user whose password was verified
+// and who still has to provide its second factor. It does not give access to the API
+func (service *Service) GenerateTwoFactorToken(data *portainer.TokenData) (string, time.Time, error) {
+	expiryTime := time.Now().Add(twoFactorTokenExpiry)
+	token, err := service.generateSignedToken(data, expiryTime, twoFactorScope)
+
+	return token, expiryTime, err
+}
+
+// ParseAndVerifyTwoFactorToken parses a JWT token generated by GenerateTwoFactorToken and verify its validity
+func (service *Service) ParseAndVerifyTwoFactorToken(token string) (*portainer.TokenData, error) {
+	parsedToken, err := jwt.ParseWithClaims(token, &claims{}, func(token *jwt.Token) (any, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+
+		return service.secrets[twoFactorScope], nil
+	})
+	if err != nil || parsedToken == nil {
+		return nil, errInvalidJWTToken
+	}
+
+	cl, ok := parsedToken.Claims.(*claims)
+	if !ok || !parsedToken.Valid || cl.Scope != twoFactorScope || cl.ExpiresAt == nil {
+		return nil, errInvalidJWTToken
+	}
+
+	user, err := service.dataStore.User().Read(portainer.UserID(cl.UserID))
+	if err != nil || user.TokenIssueAt > cl.RegisteredClaims.IssuedAt.Unix() {
+		return nil, errInvalidJWTToken
+	}
+
+	return &portainer.TokenData{
+		ID:                  portainer.UserID(cl.UserID),
+		Username:            cl.Username,
+		Role:                portainer.UserRole(cl.Role),
+		Token:               token,
+		ForceChangePassword: cl.ForceChangePassword,
+	}, nil
+}
@@ -0,0 +1,173 @@
+package cli
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+const (
+	agentNamespace          = "portainer"
+	agentName               = "portainer-agent"
+	agentHeadlessName       = "portainer-agent-headless"
+	agentServiceAccountName = "portainer-sa-clusteradmin"
+	agentClusterRoleBinding = "portainer-crb-clusteradmin"
+	agentSecretName         = "portainer-agent-secret"
+	agentSecretKey          = "secret"
+	agentPort               = 9001
+	agentImage              = "portainer/agent"
+
+	loadBalancerPollInterval = 5 * time.Second
+)
+
+// DeployAgent deploys the Portainer agent in the cluster of the kubeconfig, exposed through a load balancer, and
+// returns the address of the agent once the load balancer is provisioned. The resources already deployed are kept.
+// The load balancer is public, the agent only accepts the requests signed with agentSecret
+func (factory *ClientFactory) DeployAgent(ctx context.Context, kubeconfig []byte, agentSecret string) (string, error) {
+	if agentSecret == "" {
+		return "", errors.New("the agent cannot be exposed without a secret")
+	}
+
+	kcl, err := factory.CreateKubeClientFromKubeConfig("", kubeconfig, true, nil)
+	if err != nil {
+		return "", err
+	}
+
+	labels := map[string]string{"app": agentName}
+
+	if err := ignoreAlreadyExists(kcl.cli.CoreV1().Namespaces().Create(ctx, &corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{Name: agentNamespace},
+	}, metav1.CreateOptions{})); err != nil {
+		return "", fmt.Errorf("unable to create the namespace of the agent: %w", err)
+	}
+
+	if err := ignoreAlreadyExists(kcl.cli.CoreV1().ServiceAccounts(agentNamespace).Create(ctx, &corev1.ServiceAccount{
+		ObjectMeta: metav1.ObjectMeta{Name: agentServiceAccountName, Namespace: agentNamespace},
+	}, metav1.CreateOptions{})); err != nil {
+		return "", fmt.Errorf("unable to create the service account of the agent: %w", err)
+	}
+
+	if err := ignoreAlreadyExists(kcl.cli.RbacV1().ClusterRoleBindings().Create(ctx, &rbacv1.ClusterRoleBinding{
+		ObjectMeta: metav1.ObjectMeta{Name: agentClusterRoleBinding},
+		RoleRef:    rbacv1.RoleRef{APIGroup: "rbac.authorization.k8s.io", Kind: "ClusterRole", Name: "cluster-admin"},
+		Subjects:   []rbacv1.Subject{{Kind: "ServiceAccount", Name: agentServiceAccountName, Namespace: agentNamespace}},
+	}, metav1.CreateOptions{})); err != nil {
+		return "", fmt.Errorf("unable to bind the service account of the agent: %w", err)
+	}
+
+	if err := ignoreAlreadyExists(kcl.cli.CoreV1().Secrets(agentNamespace).Create(ctx, &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: agentSecretName, Namespace: agentNamespace},
+		StringData: map[string]string{agentSecretKey: agentSecret},
+	}, metav1.CreateOptions{})); err != nil {
+		return "", fmt.Errorf("unable to create the secret of the agent: %w", err)
+	}
+
+	if err := ignoreAlreadyExists(kcl.cli.AppsV1().Deployments(agentNamespace).Create(ctx, &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: agentName, Namespace: agentNamespace},
+		Spec: appsv1.DeploymentSpec{
+			Selector: &metav1.LabelSelector{MatchLabels: labels},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: labels},
+				Spec: corev1.PodSpec{
+					ServiceAccountName: agentServiceAccountName,
+					Containers: []corev1.Container{{
+						Name:  agentName,
+						Image: agentImage + ":" + portainer.APIVersion,
+						Env: []corev1.EnvVar{
+							{Name: "LOG_LEVEL", Value: "INFO"},
+							{Name: "AGENT_CLUSTER_ADDR", Value: agentHeadlessName},
+							{Name: "AGENT_SECRET", ValueFrom: &corev1.EnvVarSource{
+								SecretKeyRef: &corev1.SecretKeySelector{
+									LocalObjectReference: corev1.LocalObjectReference{Name: agentSecretName},
+									Key:                  agentSecretKey,
+								},
+							}},
+							{Name: "KUBERNETES_POD_IP", ValueFrom: &corev1.EnvVarSource{
+								FieldRef: &corev1.ObjectFieldSelector{FieldPath: "status.podIP"},
+							}},
+						},
+						Ports: []corev1.ContainerPort{{ContainerPort: agentPort, Protocol: corev1.ProtocolTCP}},
+					}},
+				},
+			},
+		},
+	}, metav1.CreateOptions{})); err != nil {
+		return "", fmt.Errorf("unable to create the deployment of the agent: %w", err)
+	}
+
+	if err := ignoreAlreadyExists(kcl.cli.CoreV1().Services(agentNamespace).Create(ctx, &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: agentHeadlessName, Namespace: agentNamespace},
+		Spec: corev1.ServiceSpec{
+			ClusterIP: corev1.ClusterIPNone,
+			Selector:  labels,
+		},
+	}, metav1.CreateOptions{})); err != nil {
+		return "", fmt.Errorf("unable to create the headless service of the agent: %w", err)
+	}
+
+	if err := ignoreAlreadyExists(kcl.cli.CoreV1().Services(agentNamespace).Create(ctx, &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: agentName, Namespace: agentNamespace},
+		Spec: corev1.ServiceSpec{
+			Type:     corev1.ServiceTypeLoadBalancer,
+			Selector: labels,
+			Ports: []corev1.ServicePort{{
+				Name:       "http",
+				Protocol:   corev1.ProtocolTCP,
+				Port:       agentPort,
+				TargetPort: intstr.FromInt32(agentPort),
+			}},
+		},
+	}, metav1.CreateOptions{})); err != nil {
+		return "", fmt.Errorf("unable to create the load balancer of the agent: %w", err)
+	}
+
+	return kcl.waitLoadBalancerAddress(ctx, agentNamespace, agentName, agentPort)
+}
+
+// waitLoadBalancerAddress waits for the load balancer of the service to be provisioned and returns its address
+func (kcl *KubeClient) waitLoadBalancerAddress(ctx context.Context, namespace, name string, port int) (string, error) {
+	ticker := time.NewTicker(loadBalancerPollInterval)
+	defer ticker.Stop()
+
+	for {
+		service, err := kcl.cli.CoreV1().Services(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil && ctx.Err() == nil {
+			return "", err
+		}
+
+		if err == nil {
+			for _, ingress := range service.Status.LoadBalancer.Ingress {
+				if host := cmp.Or(ingress.IP, ingress.Hostname); host != "" {
+					return net.JoinHostPort(host, strconv.Itoa(port)), nil
+				}
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return "", fmt.Errorf("the load balancer of the agent was not provisioned: %w", ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
+
+func ignoreAlreadyExists[T any](_ T, err error) error {
+	if k8serrors.IsAlreadyExists(err) {
+		return nil
+	}
+
+	return err
+}
@@ -14,6 +14,7 @@ import (
 
 	"github.com/patrickmn/go-cache"
 	"github.com/pkg/errors"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
@@ -40,6 +41,7 @@ type (
 	// KubeClient represent a service used to execute Kubernetes operations
 	KubeClient struct {
 		cli                kubernetes.Interface
+		dynamic            dynamic.Interface
 		instanceID         string
 		mu                 sync.Mutex
 		IsKubeAdmin        bool
@@ -144,8 +146,14 @@ func (factory *ClientFactory) CreateKubeClientFromKubeConfig(clusterID string, k
 		return nil, fmt.Errorf("failed to create a new clientset for the given config: %w", err)
 	}
 
+	dynamicClient, err := dynamic.NewForConfig(clientConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create a new dynamic client for the given config: %w", err)
+	}
+
 	return &KubeClient{
 		cli:                cli,
+		dynamic:            dynamicClient,
 		instanceID:         factory.instanceID,
 		IsKubeAdmin:        IsKubeAdmin,
 		NonAdminNamespaces: NonAdminNamespaces,
@@ -153,13 +161,24 @@ func (factory *ClientFactory) CreateKubeClientFromKubeConfig(clusterID string, k
 }
 
 func (factory *ClientFactory) createCachedPrivilegedKubeClient(endpoint *portainer.Endpoint) (*KubeClient, error) {
-	cli, err := factory.CreateClient(endpoint)
+	config, err := factory.CreateConfig(endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	cli, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return nil, err
+	}
+
+	dynamicClient, err := dynamic.NewForConfig(config)
 	if err != nil {
 		return nil, err
 	}
 
 	return &KubeClient{
 		cli:        cli,
+		dynamic:    dynamicClient,
 		instanceID: factory.instanceID,
 	}, nil
 }
@@ -214,7 +233,7 @@ func (factory *ClientFactory) buildAgentConfig(endpoint *portainer.Endpoint) (*r
 	clientURL.WriteString(endpoint.URL)
 	clientURL.WriteString("/kubernetes")
 
-	signature, err := factory.signatureService.CreateSignature(portainer.PortainerAgentSignatureMessage)
+	signature, err := factory.signatureService.CreateAgentSignature(endpoint.AgentSecret)
 	if err != nil {
 		return nil, err
 	}
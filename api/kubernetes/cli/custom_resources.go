@@ -0,0 +1,329 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	models "github.com/portainer/portainer/api/http/models/kubernetes"
+
+	authorizationv1 "k8s.io/api/authorization/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	"k8s.io/client-go/dynamic"
+)
+
+// customResourceFieldManager is the field manager of the custom resources applied through Portainer
+const customResourceFieldManager = "portainer"
+
+var customResourceDefinitionsResource = schema.GroupVersionResource{
+	Group:    "apiextensions.k8s.io",
+	Version:  "v1",
+	Resource: "customresourcedefinitions",
+}
+
+// ErrInvalidCustomResource is returned when a manifest is not a single resource of the custom resource definition it
+// is applied to
+var ErrInvalidCustomResource = errors.New("invalid custom resource")
+
+// GetCustomResourceDefinitions returns the custom resource definitions installed in the cluster, sorted by name
+func (kcl *KubeClient) GetCustomResourceDefinitions(ctx context.Context) ([]models.K8sCustomResourceDefinition, error) {
+	dynamicClient, err := kcl.dynamicClient()
+	if err != nil {
+		return nil, err
+	}
+
+	list, err := dynamicClient.Resource(customResourceDefinitionsResource).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	crds := make([]models.K8sCustomResourceDefinition, 0, len(list.Items))
+	for _, item := range list.Items {
+		crds = append(crds, parseCustomResourceDefinition(item))
+	}
+
+	slices.SortFunc(crds, func(a, b models.K8sCustomResourceDefinition) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+
+	return crds, nil
+}
+
+// GetCustomResourceDefinition returns the custom resource definition with the given name, such as
+// certificates.cert-manager.io
+func (kcl *KubeClient) GetCustomResourceDefinition(ctx context.Context, name string) (models.K8sCustomResourceDefinition, error) {
+	dynamicClient, err := kcl.dynamicClient()
+	if err != nil {
+		return models.K8sCustomResourceDefinition{}, err
+	}
+
+	item, err := dynamicClient.Resource(customResourceDefinitionsResource).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return models.K8sCustomResourceDefinition{}, err
+	}
+
+	return parseCustomResourceDefinition(*item), nil
+}
+
+// FilterAccessibleCustomResourceDefinitions keeps the custom resource definitions whose resources the user of the
+// client can list in at least one of their namespaces, according to the RBAC rules of the user
+func (kcl *KubeClient) FilterAccessibleCustomResourceDefinitions(ctx context.Context, crds []models.K8sCustomResourceDefinition) ([]models.K8sCustomResourceDefinition, error) {
+	if kcl.IsKubeAdmin {
+		return crds, nil
+	}
+
+	listable := map[string]bool{}
+	for _, namespace := range kcl.NonAdminNamespaces {
+		review, err := kcl.cli.AuthorizationV1().SelfSubjectRulesReviews().Create(ctx, &authorizationv1.SelfSubjectRulesReview{
+			Spec: authorizationv1.SelfSubjectRulesReviewSpec{Namespace: namespace},
+		}, metav1.CreateOptions{})
+		if err != nil {
+			return nil, err
+		}
+
+		for _, rule := range review.Status.ResourceRules {
+			if !slices.Contains(rule.Verbs, "list") && !slices.Contains(rule.Verbs, "*") {
+				continue
+			}
+
+			for _, group := range rule.APIGroups {
+				for _, resource := range rule.Resources {
+					listable[group+"/"+resource] = true
+				}
+			}
+		}
+	}
+
+	accessible := []models.K8sCustomResourceDefinition{}
+	for _, crd := range crds {
+		if listable[crd.Group+"/"+crd.Plural] || listable[crd.Group+"/*"] || listable["*/"+crd.Plural] || listable["*/*"] {
+			accessible = append(accessible, crd)
+		}
+	}
+
+	return accessible, nil
+}
+
+// GetCustomResources returns the resources of a custom resource definition in a namespace, or in all the namespaces the
+// user has access to when namespace is empty, sorted by namespace and name
+func (kcl *KubeClient) GetCustomResources(ctx context.Context, crd models.K8sCustomResourceDefinition, namespace string) ([]models.K8sCustomResource, error) {
+	resourceClient, err := kcl.customResourceClient(crd)
+	if err != nil {
+		return nil, err
+	}
+
+	namespaces := []string{namespace}
+	switch {
+	case crd.Scope != "Namespaced":
+		namespaces = []string{""}
+	case namespace == "" && !kcl.IsKubeAdmin:
+		namespaces = kcl.NonAdminNamespaces
+	}
+
+	resources := []models.K8sCustomResource{}
+	for _, namespace := range namespaces {
+		list, err := resourceClient.Namespace(namespace).List(ctx, metav1.ListOptions{})
+		if err != nil {
+			// the user can be allowed to list them in some of their namespaces only
+			if len(namespaces) > 1 && k8serrors.IsForbidden(err) {
+				continue
+			}
+
+			return nil, err
+		}
+
+		for _, item := range list.Items {
+			resources = append(resources, parseCustomResource(item))
+		}
+	}
+
+	slices.SortFunc(resources, func(a, b models.K8sCustomResource) int {
+		return strings.Compare(a.Namespace+"/"+a.Name, b.Namespace+"/"+b.Name)
+	})
+
+	return resources, nil
+}
+
+// GetCustomResource returns a resource of a custom resource definition, without its managed fields
+func (kcl *KubeClient) GetCustomResource(ctx context.Context, crd models.K8sCustomResourceDefinition, namespace, name string) (*unstructured.Unstructured, error) {
+	resourceClient, err := kcl.customResourceClient(crd)
+	if err != nil {
+		return nil, err
+	}
+
+	if crd.Scope != "Namespaced" {
+		namespace = ""
+	}
+
+	item, err := resourceClient.Namespace(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	item.SetManagedFields(nil)
+
+	return item, nil
+}
+
+// ApplyCustomResource creates or updates a resource of a custom resource definition from its YAML or JSON manifest
+// with a server-side apply, which validates it against the schema of the definition. The resource is only validated
+// when dryRun is true
+func (kcl *KubeClient) ApplyCustomResource(ctx context.Context, crd models.K8sCustomResourceDefinition, manifest []byte, dryRun bool) (*unstructured.Unstructured, error) {
+	resourceClient, err := kcl.customResourceClient(crd)
+	if err != nil {
+		return nil, err
+	}
+
+	obj, err := decodeCustomResource(crd, manifest)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := obj.MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+
+	// the fields set by other managers, such as kubectl, are taken over as the manifest is the desired state
+	force := true
+	options := metav1.PatchOptions{
+		FieldManager:    customResourceFieldManager,
+		Force:           &force,
+		FieldValidation: metav1.FieldValidationStrict,
+	}
+
+	if dryRun {
+		options.DryRun = []string{metav1.DryRunAll}
+	}
+
+	applied, err := resourceClient.Namespace(obj.GetNamespace()).Patch(ctx, obj.GetName(), types.ApplyPatchType, data, options)
+	if err != nil {
+		return nil, err
+	}
+
+	applied.SetManagedFields(nil)
+
+	return applied, nil
+}
+
+// decodeCustomResource decodes a manifest and checks that it is a single resource of the custom resource definition
+func decodeCustomResource(crd models.K8sCustomResourceDefinition, manifest []byte) (*unstructured.Unstructured, error) {
+	decoder := utilyaml.NewYAMLOrJSONDecoder(bytes.NewReader(manifest), 4096)
+
+	obj := &unstructured.Unstructured{}
+	if err := decoder.Decode(&obj.Object); err != nil {
+		return nil, fmt.Errorf("%w: unable to parse the manifest: %w", ErrInvalidCustomResource, err)
+	}
+
+	var next map[string]any
+	if err := decoder.Decode(&next); !errors.Is(err, io.EOF) || len(next) > 0 {
+		return nil, fmt.Errorf("%w: the manifest must contain a single resource", ErrInvalidCustomResource)
+	}
+
+	gvk := obj.GroupVersionKind()
+	switch {
+	case obj.Object == nil:
+		return nil, fmt.Errorf("%w: the manifest is empty", ErrInvalidCustomResource)
+	case gvk.Group != crd.Group || gvk.Kind != crd.Kind:
+		return nil, fmt.Errorf("%w: the resource must be a %s of the group %s", ErrInvalidCustomResource, crd.Kind, crd.Group)
+	case !slices.Contains(crd.Versions, gvk.Version):
+		return nil, fmt.Errorf("%w: the version %q is not served, the served versions are %s", ErrInvalidCustomResource, gvk.Version, strings.Join(crd.Versions, ", "))
+	case obj.GetName() == "":
+		return nil, fmt.Errorf("%w: the name of the resource is required", ErrInvalidCustomResource)
+	case crd.Scope == "Namespaced" && obj.GetNamespace() == "":
+		return nil, fmt.Errorf("%w: the namespace of the resource is required", ErrInvalidCustomResource)
+	case crd.Scope != "Namespaced" && obj.GetNamespace() != "":
+		return nil, fmt.Errorf("%w: the resources of %s are not namespaced", ErrInvalidCustomResource, crd.Name)
+	}
+
+	return obj, nil
+}
+
+func (kcl *KubeClient) dynamicClient() (dynamic.Interface, error) {
+	if kcl.dynamic == nil {
+		return nil, errors.New("the dynamic client of the environment is not available")
+	}
+
+	return kcl.dynamic, nil
+}
+
+func (kcl *KubeClient) customResourceClient(crd models.K8sCustomResourceDefinition) (dynamic.NamespaceableResourceInterface, error) {
+	dynamicClient, err := kcl.dynamicClient()
+	if err != nil {
+		return nil, err
+	}
+
+	return dynamicClient.Resource(schema.GroupVersionResource{Group: crd.Group, Version: crd.PreferredVersion, Resource: crd.Plural}), nil
+}
+
+func parseCustomResourceDefinition(item unstructured.Unstructured) models.K8sCustomResourceDefinition {
+	group, _, _ := unstructured.NestedString(item.Object, "spec", "group")
+	kind, _, _ := unstructured.NestedString(item.Object, "spec", "names", "kind")
+	plural, _, _ := unstructured.NestedString(item.Object, "spec", "names", "plural")
+	scope, _, _ := unstructured.NestedString(item.Object, "spec", "scope")
+	versions, _, _ := unstructured.NestedSlice(item.Object, "spec", "versions")
+
+	crd := models.K8sCustomResourceDefinition{
+		Name:         item.GetName(),
+		Group:        group,
+		Kind:         kind,
+		Plural:       plural,
+		Scope:        scope,
+		Versions:     []string{},
+		CreationDate: item.GetCreationTimestamp().Time,
+	}
+
+	for _, version := range versions {
+		version, ok := version.(map[string]any)
+		if !ok {
+			continue
+		}
+
+		name, _, _ := unstructured.NestedString(version, "name")
+		served, _, _ := unstructured.NestedBool(version, "served")
+		storage, _, _ := unstructured.NestedBool(version, "storage")
+
+		if !served {
+			continue
+		}
+
+		crd.Versions = append(crd.Versions, name)
+		if storage || crd.PreferredVersion == "" {
+			crd.PreferredVersion = name
+		}
+	}
+
+	return crd
+}
+
+func parseCustomResource(item unstructured.Unstructured) models.K8sCustomResource {
+	resource := models.K8sCustomResource{
+		Name:         item.GetName(),
+		Namespace:    item.GetNamespace(),
+		APIVersion:   item.GetAPIVersion(),
+		Kind:         item.GetKind(),
+		Labels:       item.GetLabels(),
+		CreationDate: item.GetCreationTimestamp().Time,
+	}
+
+	conditions, _, _ := unstructured.NestedSlice(item.Object, "status", "conditions")
+	for _, condition := range conditions {
+		condition, ok := condition.(map[string]any)
+		if !ok || condition["type"] != "Ready" {
+			continue
+		}
+
+		resource.Ready, _, _ = unstructured.NestedString(condition, "status")
+	}
+
+	return resource
+}
@@ -0,0 +1,204 @@
+package cli
+
+import (
+	"context"
+	"testing"
+
+	models "github.com/portainer/portainer/api/http/models/kubernetes"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	kfake "k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+var certificatesResource = schema.GroupVersionResource{Group: "cert-manager.io", Version: "v1", Resource: "certificates"}
+
+func newTestCustomResourceDefinition(group, kind, plural, scope string) *unstructured.Unstructured {
+	return &unstructured.Unstructured{Object: map[string]any{
+		"apiVersion": "apiextensions.k8s.io/v1",
+		"kind":       "CustomResourceDefinition",
+		"metadata":   map[string]any{"name": plural + "." + group},
+		"spec": map[string]any{
+			"group": group,
+			"scope": scope,
+			"names": map[string]any{"kind": kind, "plural": plural},
+			"versions": []any{
+				map[string]any{"name": "v1alpha1", "served": false, "storage": false},
+				map[string]any{"name": "v1beta1", "served": true, "storage": false},
+				map[string]any{"name": "v1", "served": true, "storage": true},
+			},
+		},
+	}}
+}
+
+func newTestCertificate(namespace, name, ready string) *unstructured.Unstructured {
+	certificate := &unstructured.Unstructured{Object: map[string]any{
+		"apiVersion": "cert-manager.io/v1",
+		"kind":       "Certificate",
+		"metadata":   map[string]any{"name": name, "namespace": namespace},
+		"spec":       map[string]any{"secretName": name + "-tls"},
+	}}
+
+	if ready != "" {
+		certificate.Object["status"] = map[string]any{"conditions": []any{map[string]any{"type": "Ready", "status": ready}}}
+	}
+
+	return certificate
+}
+
+func newTestCustomResourcesClient(objects ...runtime.Object) *KubeClient {
+	objects = append(objects,
+		newTestCustomResourceDefinition("cert-manager.io", "Certificate", "certificates", "Namespaced"),
+		newTestCustomResourceDefinition("cert-manager.io", "ClusterIssuer", "clusterissuers", "Cluster"),
+	)
+
+	return &KubeClient{
+		cli: kfake.NewSimpleClientset(),
+		dynamic: dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{
+			customResourceDefinitionsResource: "CustomResourceDefinitionList",
+			certificatesResource:              "CertificateList",
+		}, objects...),
+		instanceID:  "instance",
+		IsKubeAdmin: true,
+	}
+}
+
+func Test_GetCustomResourceDefinitions(t *testing.T) {
+	k := newTestCustomResourcesClient()
+
+	crds, err := k.GetCustomResourceDefinitions(context.Background())
+	require.NoError(t, err)
+
+	require.Len(t, crds, 2)
+	assert.Equal(t, models.K8sCustomResourceDefinition{
+		Name:             "certificates.cert-manager.io",
+		Group:            "cert-manager.io",
+		Kind:             "Certificate",
+		Plural:           "certificates",
+		Scope:            "Namespaced",
+		Versions:         []string{"v1beta1", "v1"},
+		PreferredVersion: "v1",
+	}, crds[0])
+	assert.Equal(t, "Cluster", crds[1].Scope)
+
+	_, err = k.GetCustomResourceDefinition(context.Background(), "missing.cert-manager.io")
+	require.True(t, k8serrors.IsNotFound(err))
+}
+
+func Test_FilterAccessibleCustomResourceDefinitions(t *testing.T) {
+	k := newTestCustomResourcesClient()
+
+	crds, err := k.GetCustomResourceDefinitions(context.Background())
+	require.NoError(t, err)
+
+	fake := kfake.NewSimpleClientset()
+	fake.PrependReactor("create", "selfsubjectrulesreviews", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		review := action.(k8stesting.CreateAction).GetObject().(*authorizationv1.SelfSubjectRulesReview)
+		if review.Spec.Namespace == "tenant" {
+			review.Status.ResourceRules = []authorizationv1.ResourceRule{
+				{Verbs: []string{"get"}, APIGroups: []string{"cert-manager.io"}, Resources: []string{"clusterissuers"}},
+				{Verbs: []string{"get", "list"}, APIGroups: []string{"cert-manager.io"}, Resources: []string{"certificates"}},
+			}
+		}
+
+		return true, review, nil
+	})
+
+	user := &KubeClient{cli: fake, instanceID: "instance", NonAdminNamespaces: []string{"default", "tenant"}}
+
+	accessible, err := user.FilterAccessibleCustomResourceDefinitions(context.Background(), crds)
+	require.NoError(t, err)
+	require.Len(t, accessible, 1)
+	assert.Equal(t, "certificates.cert-manager.io", accessible[0].Name)
+}
+
+func Test_GetCustomResources(t *testing.T) {
+	k := newTestCustomResourcesClient(
+		newTestCertificate("tenant", "web", "True"),
+		newTestCertificate("default", "api", "False"),
+		newTestCertificate("private", "db", ""),
+	)
+
+	crd, err := k.GetCustomResourceDefinition(context.Background(), "certificates.cert-manager.io")
+	require.NoError(t, err)
+
+	resources, err := k.GetCustomResources(context.Background(), crd, "")
+	require.NoError(t, err)
+
+	require.Len(t, resources, 3)
+	assert.Equal(t, "default", resources[0].Namespace)
+	assert.Equal(t, "False", resources[0].Ready)
+	assert.Empty(t, resources[1].Ready)
+	assert.Equal(t, models.K8sCustomResource{Name: "web", Namespace: "tenant", APIVersion: "cert-manager.io/v1", Kind: "Certificate", Ready: "True"}, resources[2])
+
+	t.Run("limited to the namespaces of a non-admin user", func(t *testing.T) {
+		user := &KubeClient{cli: k.cli, dynamic: k.dynamic, instanceID: "instance", NonAdminNamespaces: []string{"default", "tenant"}}
+
+		resources, err := user.GetCustomResources(context.Background(), crd, "")
+		require.NoError(t, err)
+		assert.Len(t, resources, 2)
+	})
+}
+
+func Test_ApplyCustomResource(t *testing.T) {
+	k := newTestCustomResourcesClient(newTestCertificate("tenant", "web", "True"))
+
+	// the fake client cannot merge the unstructured objects, the applied object replaces the existing one
+	fake := k.dynamic.(*dynamicfake.FakeDynamicClient)
+	fake.PrependReactor("patch", "certificates", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		patch := action.(k8stesting.PatchAction)
+		if patch.GetPatchType() != types.ApplyPatchType {
+			return false, nil, nil
+		}
+
+		obj := &unstructured.Unstructured{}
+		if err := obj.UnmarshalJSON(patch.GetPatch()); err != nil {
+			return true, nil, err
+		}
+
+		return true, obj, fake.Tracker().Update(certificatesResource, obj, patch.GetNamespace())
+	})
+
+	crd, err := k.GetCustomResourceDefinition(context.Background(), "certificates.cert-manager.io")
+	require.NoError(t, err)
+
+	manifest := `
+apiVersion: cert-manager.io/v1
+kind: Certificate
+metadata:
+  name: web
+  namespace: tenant
+spec:
+  secretName: web-certificate
+`
+
+	applied, err := k.ApplyCustomResource(context.Background(), crd, []byte(manifest), false)
+	require.NoError(t, err)
+	assert.Equal(t, "web", applied.GetName())
+
+	resource, err := k.GetCustomResource(context.Background(), crd, "tenant", "web")
+	require.NoError(t, err)
+
+	secretName, _, _ := unstructured.NestedString(resource.Object, "spec", "secretName")
+	assert.Equal(t, "web-certificate", secretName)
+
+	for _, invalid := range []string{
+		"apiVersion: cert-manager.io/v1\nkind: Issuer\nmetadata:\n  name: web\n  namespace: tenant\n",
+		"apiVersion: cert-manager.io/v1alpha1\nkind: Certificate\nmetadata:\n  name: web\n  namespace: tenant\n",
+		"apiVersion: cert-manager.io/v1\nkind: Certificate\nmetadata:\n  name: web\n",
+		"apiVersion: cert-manager.io/v1\nkind: Certificate\nmetadata:\n  namespace: tenant\n",
+		"apiVersion: cert-manager.io/v1\nkind: Certificate\nmetadata:\n  name: web\n  namespace: tenant\n---\napiVersion: cert-manager.io/v1\nkind: Certificate\nmetadata:\n  name: api\n  namespace: tenant\n",
+		"apiVersion: [",
+	} {
+		_, err := k.ApplyCustomResource(context.Background(), crd, []byte(invalid), false)
+		require.ErrorIs(t, err, ErrInvalidCustomResource, invalid)
+	}
+}
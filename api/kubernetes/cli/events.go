@@ -0,0 +1,240 @@
+package cli
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	models "github.com/portainer/portainer/api/http/models/kubernetes"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+)
+
+const (
+	// recentWarningEventsPeriod is how far back the warning events of the applications are looked for
+	recentWarningEventsPeriod = time.Hour
+	// maxApplicationWarningEvents is the number of warning events kept for an application, the most recent ones
+	maxApplicationWarningEvents = 10
+)
+
+// EventFilters selects the events returned by GetEvents, the zero values matching every event
+type EventFilters struct {
+	Namespace string
+	// Kind, name and UID of the object the events are about
+	Kind string
+	Name string
+	UID  string
+	// Type of the events, Normal or Warning
+	Type string
+	// Range of time the events last occurred in
+	Since time.Time
+	Until time.Time
+}
+
+// GetEvents returns the events matching the filters in the namespaces the user has access to, the most recent first
+func (kcl *KubeClient) GetEvents(filters EventFilters) ([]models.K8sEvent, error) {
+	if !kcl.IsKubeAdmin && len(kcl.NonAdminNamespaces) == 0 {
+		return []models.K8sEvent{}, nil
+	}
+
+	selector := fields.Set{}
+	for field, value := range map[string]string{
+		"involvedObject.kind": filters.Kind,
+		"involvedObject.name": filters.Name,
+		"involvedObject.uid":  filters.UID,
+		"type":                filters.Type,
+	} {
+		if value != "" {
+			selector[field] = value
+		}
+	}
+
+	events, err := kcl.cli.CoreV1().Events(filters.Namespace).List(context.Background(), metav1.ListOptions{
+		FieldSelector: selector.AsSelector().String(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	nonAdminNamespaceSet := kcl.buildNonAdminNamespacesMap()
+
+	results := []models.K8sEvent{}
+	for _, event := range events.Items {
+		if _, ok := nonAdminNamespaceSet[event.Namespace]; !kcl.IsKubeAdmin && !ok {
+			continue
+		}
+
+		if filters.matches(event) {
+			results = append(results, parseEvent(event))
+		}
+	}
+
+	sortEvents(results)
+
+	return results, nil
+}
+
+// matches checks the filters in case the field selectors are not supported
+func (filters EventFilters) matches(event corev1.Event) bool {
+	lastSeen := eventLastSeen(event)
+
+	return (filters.Kind == "" || event.InvolvedObject.Kind == filters.Kind) &&
+		(filters.Name == "" || event.InvolvedObject.Name == filters.Name) &&
+		(filters.UID == "" || string(event.InvolvedObject.UID) == filters.UID) &&
+		(filters.Type == "" || event.Type == filters.Type) &&
+		(filters.Since.IsZero() || !lastSeen.Before(filters.Since)) &&
+		(filters.Until.IsZero() || !lastSeen.After(filters.Until))
+}
+
+// AddApplicationWarningEvents sets the warning events of the last hour on the applications, the events of their pods
+// and of their replica sets included, so that the reason why an application is not running can be shown
+func (kcl *KubeClient) AddApplicationWarningEvents(applications []models.K8sApplication) error {
+	namespaces := []string{}
+	for _, application := range applications {
+		if !slices.Contains(namespaces, application.ResourcePool) {
+			namespaces = append(namespaces, application.ResourcePool)
+		}
+	}
+
+	if len(namespaces) == 0 {
+		return nil
+	}
+
+	namespace := ""
+	if len(namespaces) == 1 {
+		namespace = namespaces[0]
+	}
+
+	events, err := kcl.cli.CoreV1().Events(namespace).List(context.Background(), metav1.ListOptions{
+		FieldSelector: fields.OneTermEqualSelector("type", corev1.EventTypeWarning).String(),
+	})
+	if err != nil {
+		return err
+	}
+
+	pods, err := kcl.cli.CoreV1().Pods(namespace).List(context.Background(), metav1.ListOptions{})
+	if err != nil {
+		return err
+	}
+
+	replicaSets, err := kcl.cli.AppsV1().ReplicaSets(namespace).List(context.Background(), metav1.ListOptions{})
+	if err != nil {
+		return err
+	}
+
+	// the identifier of an application is the UID of its workload, or of its pod for a bare pod
+	owners := map[string]string{}
+	for _, replicaSet := range replicaSets.Items {
+		owner := string(replicaSet.UID)
+		if len(replicaSet.OwnerReferences) > 0 {
+			owner = string(replicaSet.OwnerReferences[0].UID)
+		}
+
+		owners["ReplicaSet/"+replicaSet.Namespace+"/"+replicaSet.Name] = owner
+	}
+
+	for _, pod := range pods.Items {
+		owner := string(pod.UID)
+		if len(pod.OwnerReferences) > 0 {
+			reference := pod.OwnerReferences[0]
+
+			owner = string(reference.UID)
+			if replicaSetOwner, ok := owners["ReplicaSet/"+pod.Namespace+"/"+reference.Name]; ok && reference.Kind == "ReplicaSet" {
+				owner = replicaSetOwner
+			}
+		}
+
+		owners["Pod/"+pod.Namespace+"/"+pod.Name] = owner
+	}
+
+	since := time.Now().Add(-recentWarningEventsPeriod)
+
+	applicationEvents := map[string][]models.K8sEvent{}
+	for _, event := range events.Items {
+		if event.Type != corev1.EventTypeWarning || eventLastSeen(event).Before(since) {
+			continue
+		}
+
+		involved := event.InvolvedObject
+
+		applicationID, ok := owners[involved.Kind+"/"+event.Namespace+"/"+involved.Name]
+		if !ok {
+			applicationID = string(involved.UID)
+		}
+
+		applicationEvents[applicationID] = append(applicationEvents[applicationID], parseEvent(event))
+	}
+
+	for i, application := range applications {
+		events := applicationEvents[application.ID]
+		if len(events) == 0 {
+			continue
+		}
+
+		sortEvents(events)
+		applications[i].WarningEvents = events[:min(len(events), maxApplicationWarningEvents)]
+	}
+
+	return nil
+}
+
+func parseEvent(event corev1.Event) models.K8sEvent {
+	source := event.Source.Component
+	if source == "" {
+		source = event.ReportingController
+	}
+
+	firstTimestamp := event.FirstTimestamp.Time
+	if firstTimestamp.IsZero() {
+		firstTimestamp = event.EventTime.Time
+	}
+
+	count := event.Count
+	if event.Series != nil {
+		count = event.Series.Count
+	}
+
+	return models.K8sEvent{
+		Name:           event.Name,
+		Namespace:      event.Namespace,
+		Type:           event.Type,
+		Reason:         event.Reason,
+		Message:        event.Message,
+		Count:          count,
+		Source:         source,
+		FirstTimestamp: firstTimestamp,
+		LastTimestamp:  eventLastSeen(event),
+		InvolvedObject: models.K8sEventInvolvedObject{
+			Kind:      event.InvolvedObject.Kind,
+			Name:      event.InvolvedObject.Name,
+			Namespace: event.InvolvedObject.Namespace,
+			UID:       string(event.InvolvedObject.UID),
+		},
+	}
+}
+
+// eventLastSeen returns the last time an event occurred, which is set in different fields depending on the API
+// version the event was reported with
+func eventLastSeen(event corev1.Event) time.Time {
+	switch {
+	case event.Series != nil:
+		return event.Series.LastObservedTime.Time
+	case !event.LastTimestamp.IsZero():
+		return event.LastTimestamp.Time
+	case !event.EventTime.IsZero():
+		return event.EventTime.Time
+	case !event.FirstTimestamp.IsZero():
+		return event.FirstTimestamp.Time
+	}
+
+	return event.CreationTimestamp.Time
+}
+
+// sortEvents sorts events, the most recent first
+func sortEvents(events []models.K8sEvent) {
+	slices.SortStableFunc(events, func(a, b models.K8sEvent) int {
+		return b.LastTimestamp.Compare(a.LastTimestamp)
+	})
+}
@@ -0,0 +1,130 @@
+package cli
+
+import (
+	"testing"
+	"time"
+
+	models "github.com/portainer/portainer/api/http/models/kubernetes"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	kfake "k8s.io/client-go/kubernetes/fake"
+)
+
+func newTestEvent(namespace, name, eventType, kind, objectName string, lastSeen time.Time) *corev1.Event {
+	return &corev1.Event{
+		ObjectMeta:     metav1.ObjectMeta{Name: name, Namespace: namespace},
+		Type:           eventType,
+		Reason:         "BackOff",
+		InvolvedObject: corev1.ObjectReference{Kind: kind, Name: objectName, Namespace: namespace},
+		LastTimestamp:  metav1.NewTime(lastSeen),
+	}
+}
+
+func Test_GetEvents(t *testing.T) {
+	now := time.Now().Truncate(time.Second)
+
+	k := &KubeClient{
+		cli: kfake.NewSimpleClientset(
+			newTestEvent("tenant", "old", corev1.EventTypeNormal, "Pod", "web-1", now.Add(-2*time.Hour)),
+			newTestEvent("tenant", "recent", corev1.EventTypeWarning, "Pod", "web-1", now.Add(-time.Minute)),
+			newTestEvent("tenant", "deployment", corev1.EventTypeNormal, "Deployment", "web", now.Add(-30*time.Minute)),
+			newTestEvent("private", "other", corev1.EventTypeWarning, "Pod", "db-1", now),
+		),
+		instanceID:  "instance",
+		IsKubeAdmin: true,
+	}
+
+	names := func(events []models.K8sEvent) []string {
+		names := []string{}
+		for _, event := range events {
+			names = append(names, event.Name)
+		}
+
+		return names
+	}
+
+	testCases := []struct {
+		name    string
+		filters EventFilters
+		events  []string
+	}{
+		{name: "all the events, the most recent first", events: []string{"other", "recent", "deployment", "old"}},
+		{name: "by namespace", filters: EventFilters{Namespace: "tenant"}, events: []string{"recent", "deployment", "old"}},
+		{name: "by involved object", filters: EventFilters{Kind: "Pod", Name: "web-1"}, events: []string{"recent", "old"}},
+		{name: "by type", filters: EventFilters{Type: corev1.EventTypeWarning}, events: []string{"other", "recent"}},
+		{name: "by time range", filters: EventFilters{Since: now.Add(-time.Hour), Until: now.Add(-time.Minute)}, events: []string{"recent", "deployment"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			events, err := k.GetEvents(tc.filters)
+			require.NoError(t, err)
+			assert.Equal(t, tc.events, names(events))
+		})
+	}
+
+	t.Run("limited to the namespaces of a non-admin user", func(t *testing.T) {
+		k := &KubeClient{cli: k.cli, instanceID: "instance", NonAdminNamespaces: []string{"tenant"}}
+
+		events, err := k.GetEvents(EventFilters{Type: corev1.EventTypeWarning})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"recent"}, names(events))
+	})
+}
+
+func Test_AddApplicationWarningEvents(t *testing.T) {
+	now := time.Now()
+
+	deploymentUID := types.UID("deployment-uid")
+	replicaSetUID := types.UID("replicaset-uid")
+
+	replicaSet := &appsv1.ReplicaSet{ObjectMeta: metav1.ObjectMeta{
+		Name:            "web-7d4b9c8f6d",
+		Namespace:       "tenant",
+		UID:             replicaSetUID,
+		OwnerReferences: []metav1.OwnerReference{{Kind: "Deployment", Name: "web", UID: deploymentUID}},
+	}}
+
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+		Name:            "web-7d4b9c8f6d-x2x8z",
+		Namespace:       "tenant",
+		OwnerReferences: []metav1.OwnerReference{{Kind: "ReplicaSet", Name: replicaSet.Name, UID: replicaSetUID}},
+	}}
+
+	bare := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "debug", Namespace: "tenant", UID: "debug-uid"}}
+
+	k := &KubeClient{
+		cli: kfake.NewSimpleClientset(
+			replicaSet, pod, bare,
+			newTestEvent("tenant", "crashloop", corev1.EventTypeWarning, "Pod", pod.Name, now),
+			newTestEvent("tenant", "quota", corev1.EventTypeWarning, "ReplicaSet", replicaSet.Name, now.Add(-time.Minute)),
+			newTestEvent("tenant", "stale", corev1.EventTypeWarning, "Pod", pod.Name, now.Add(-2*time.Hour)),
+			newTestEvent("tenant", "pulled", corev1.EventTypeNormal, "Pod", pod.Name, now),
+			newTestEvent("tenant", "debug", corev1.EventTypeWarning, "Pod", bare.Name, now),
+		),
+		instanceID: "instance",
+	}
+
+	applications := []models.K8sApplication{
+		{ID: string(deploymentUID), Name: "web", Kind: "Deployment", ResourcePool: "tenant"},
+		{ID: "debug-uid", Name: "debug", Kind: "Pod", ResourcePool: "tenant"},
+		{ID: "idle-uid", Name: "idle", Kind: "Deployment", ResourcePool: "tenant"},
+	}
+
+	err := k.AddApplicationWarningEvents(applications)
+	require.NoError(t, err)
+
+	require.Len(t, applications[0].WarningEvents, 2)
+	assert.Equal(t, "crashloop", applications[0].WarningEvents[0].Name)
+	assert.Equal(t, "quota", applications[0].WarningEvents[1].Name)
+
+	require.Len(t, applications[1].WarningEvents, 1)
+	assert.Equal(t, "debug", applications[1].WarningEvents[0].Name)
+
+	assert.Empty(t, applications[2].WarningEvents)
+}
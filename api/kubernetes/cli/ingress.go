@@ -2,17 +2,24 @@ package cli
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"strings"
 
 	models "github.com/portainer/portainer/api/http/models/kubernetes"
 	"github.com/portainer/portainer/api/stacks/stackutils"
 	"github.com/rs/zerolog/log"
+	corev1 "k8s.io/api/core/v1"
 	netv1 "k8s.io/api/networking/v1"
 	k8serrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
+// ErrInvalidIngress is returned when an ingress cannot be applied to the cluster as is
+var ErrInvalidIngress = errors.New("invalid ingress")
+
+const defaultIngressClassAnnotation = "ingressclass.kubernetes.io/is-default-class"
+
 func (kcl *KubeClient) GetIngressControllers() (models.K8sIngressControllers, error) {
 	classeses, err := kcl.cli.NetworkingV1().IngressClasses().List(context.Background(), metav1.ListOptions{})
 	if err != nil {
@@ -42,6 +49,42 @@ func (kcl *KubeClient) GetIngressControllers() (models.K8sIngressControllers, er
 	return results, nil
 }
 
+// GetIngressClasses returns the IngressClasses of the cluster, whether they are the default class and whether an
+// ingress of any namespace uses them
+func (kcl *KubeClient) GetIngressClasses() ([]models.K8sIngressClass, error) {
+	ingressClasses, err := kcl.cli.NetworkingV1().IngressClasses().List(context.Background(), metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	ingresses, err := kcl.cli.NetworkingV1().Ingresses("").List(context.Background(), metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	usedClasses := make(map[string]struct{})
+	for _, ingress := range ingresses.Items {
+		if ingress.Spec.IngressClassName != nil {
+			usedClasses[*ingress.Spec.IngressClassName] = struct{}{}
+		}
+	}
+
+	results := []models.K8sIngressClass{}
+	for _, class := range ingressClasses.Items {
+		_, used := usedClasses[class.Name]
+
+		results = append(results, models.K8sIngressClass{
+			Name:       class.Name,
+			Controller: class.Spec.Controller,
+			Type:       ingressControllerType(class.Spec.Controller),
+			IsDefault:  class.Annotations[defaultIngressClassAnnotation] == "true",
+			Used:       used,
+		})
+	}
+
+	return results, nil
+}
+
 // fetchIngressClasses fetches all the ingress classes in a k8s endpoint.
 func (kcl *KubeClient) fetchIngressClasses() ([]models.K8sIngressController, error) {
 	ingressClasses, err := kcl.cli.NetworkingV1().IngressClasses().List(context.Background(), metav1.ListOptions{})
@@ -254,6 +297,10 @@ func (kcl *KubeClient) convertToK8sIngress(info models.K8sIngressInfo, owner str
 	rules := make(map[string][]netv1.HTTPIngressPath)
 	for _, path := range info.Paths {
 		pathType := netv1.PathType(path.PathType)
+		if pathType == "" {
+			pathType = netv1.PathTypePrefix
+		}
+
 		rules[path.Host] = append(rules[path.Host], netv1.HTTPIngressPath{
 			Path:     path.Path,
 			PathType: &pathType,
@@ -311,13 +358,84 @@ func (kcl *KubeClient) DeleteIngresses(reqs models.K8sIngressDeleteRequests) err
 }
 
 // UpdateIngress updates an existing ingress in a given namespace in a k8s endpoint.
+// The labels of the ingress, such as its owner, are kept.
 func (kcl *KubeClient) UpdateIngress(namespace string, info models.K8sIngressInfo) error {
+	existing, err := kcl.cli.NetworkingV1().Ingresses(namespace).Get(context.Background(), info.Name, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+
 	ingress := kcl.convertToK8sIngress(info, "")
-	_, err := kcl.cli.NetworkingV1().Ingresses(namespace).Update(context.Background(), &ingress, metav1.UpdateOptions{})
+	ingress.Labels = existing.Labels
+	ingress.ResourceVersion = existing.ResourceVersion
+
+	_, err = kcl.cli.NetworkingV1().Ingresses(namespace).Update(context.Background(), &ingress, metav1.UpdateOptions{})
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// ValidateIngress checks an ingress against the cluster before it is created or updated in a namespace: its
+// IngressClass must exist, its TLS secrets must be TLS secrets when they exist, as they can be issued after the
+// ingress, and none of its rules can be served by another ingress of the same class. The errors wrap
+// ErrInvalidIngress and do not name the ingresses of the other namespaces.
+func (kcl *KubeClient) ValidateIngress(namespace string, info models.K8sIngressInfo) error {
+	if info.ClassName != "" {
+		_, err := kcl.cli.NetworkingV1().IngressClasses().Get(context.Background(), info.ClassName, metav1.GetOptions{})
+		if k8serrors.IsNotFound(err) {
+			return fmt.Errorf("%w: the ingress class %s does not exist", ErrInvalidIngress, info.ClassName)
+		} else if err != nil {
+			return err
+		}
+	}
+
+	for _, tls := range info.TLS {
+		if tls.SecretName == "" {
+			continue
+		}
+
+		secret, err := kcl.cli.CoreV1().Secrets(namespace).Get(context.Background(), tls.SecretName, metav1.GetOptions{})
+		if k8serrors.IsNotFound(err) {
+			continue
+		} else if err != nil {
+			return err
+		}
+
+		if secret.Type != corev1.SecretTypeTLS {
+			return fmt.Errorf("%w: the secret %s is not a TLS secret", ErrInvalidIngress, tls.SecretName)
+		}
+	}
+
+	ingresses, err := kcl.cli.NetworkingV1().Ingresses("").List(context.Background(), metav1.ListOptions{})
 	if err != nil {
 		return err
 	}
 
+	for _, ingress := range ingresses.Items {
+		if ingress.Namespace == namespace && ingress.Name == info.Name {
+			continue
+		}
+
+		other := parseIngress(ingress)
+		if other.ClassName != info.ClassName {
+			continue
+		}
+
+		for _, path := range info.Paths {
+			if path.Host == "" {
+				continue
+			}
+
+			for _, otherPath := range other.Paths {
+				if otherPath.Host == path.Host && otherPath.Path == path.Path {
+					return fmt.Errorf("%w: the path %s of the host %s is already served by another ingress", ErrInvalidIngress, path.Path, path.Host)
+				}
+			}
+		}
+	}
+
 	return nil
 }
 
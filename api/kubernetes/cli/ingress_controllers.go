@@ -0,0 +1,146 @@
+package cli
+
+import (
+	"context"
+	"slices"
+	"strings"
+
+	models "github.com/portainer/portainer/api/http/models/kubernetes"
+
+	corev1 "k8s.io/api/core/v1"
+	netv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// knownIngressController is an ingress controller recognized by the repository of its image
+type knownIngressController struct {
+	Type string
+	// Repositories of the images of the controller, matched as the end of the image repository
+	Repositories []string
+	// Controllers set in the IngressClasses implemented by the controller
+	Controllers []string
+}
+
+var knownIngressControllers = []knownIngressController{
+	{Type: "nginx", Repositories: []string{"ingress-nginx/controller", "ingress-nginx/controller-chroot"}, Controllers: []string{"k8s.io/ingress-nginx"}},
+	{Type: "nginx", Repositories: []string{"nginx/nginx-ingress", "nginx/nginx-plus-ingress"}, Controllers: []string{"nginx.org/ingress-controller"}},
+	{Type: "traefik", Repositories: []string{"traefik"}, Controllers: []string{"traefik.io/ingress-controller"}},
+	{Type: "haproxy", Repositories: []string{"haproxytech/kubernetes-ingress", "jcmoraisjr/haproxy-ingress"}, Controllers: []string{"haproxy.org/ingress-controller/haproxy", "haproxy-ingress.github.io/controller"}},
+	{Type: "contour", Repositories: []string{"projectcontour/contour"}, Controllers: []string{"projectcontour.io/ingress-controller", "projectcontour.io/contour"}},
+	{Type: "kong", Repositories: []string{"kong/kubernetes-ingress-controller"}, Controllers: []string{"ingress-controllers.konghq.com/kong"}},
+	{Type: "alb", Repositories: []string{"eks/aws-load-balancer-controller"}, Controllers: []string{"ingress.k8s.aws/alb"}},
+}
+
+// DetectIngressControllers returns the ingress controllers running in the cluster, found from the images of its
+// Deployments and DaemonSets, with the IngressClasses they implement
+func (kcl *KubeClient) DetectIngressControllers() ([]models.K8sDetectedIngressController, error) {
+	deployments, err := kcl.cli.AppsV1().Deployments("").List(context.Background(), metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	daemonSets, err := kcl.cli.AppsV1().DaemonSets("").List(context.Background(), metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	ingressClasses, err := kcl.cli.NetworkingV1().IngressClasses().List(context.Background(), metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	detect := func(kind, namespace, name string, spec corev1.PodSpec, ready bool) []models.K8sDetectedIngressController {
+		results := []models.K8sDetectedIngressController{}
+		for _, container := range spec.Containers {
+			known, ok := findKnownIngressController(container.Image)
+			if !ok {
+				continue
+			}
+
+			classes := []string{}
+			for _, class := range ingressClasses.Items {
+				if slices.Contains(known.Controllers, class.Spec.Controller) {
+					classes = append(classes, class.Name)
+				}
+			}
+
+			results = append(results, models.K8sDetectedIngressController{
+				Type:           known.Type,
+				Namespace:      namespace,
+				Kind:           kind,
+				Name:           name,
+				Image:          container.Image,
+				Version:        imageTag(container.Image),
+				Ready:          ready,
+				IngressClasses: classes,
+			})
+		}
+
+		return results
+	}
+
+	results := []models.K8sDetectedIngressController{}
+	for _, deployment := range deployments.Items {
+		replicas := int32(1)
+		if deployment.Spec.Replicas != nil {
+			replicas = *deployment.Spec.Replicas
+		}
+
+		ready := replicas > 0 && deployment.Status.ReadyReplicas >= replicas
+		results = append(results, detect("Deployment", deployment.Namespace, deployment.Name, deployment.Spec.Template.Spec, ready)...)
+	}
+
+	for _, daemonSet := range daemonSets.Items {
+		ready := daemonSet.Status.DesiredNumberScheduled > 0 && daemonSet.Status.NumberReady >= daemonSet.Status.DesiredNumberScheduled
+		results = append(results, detect("DaemonSet", daemonSet.Namespace, daemonSet.Name, daemonSet.Spec.Template.Spec, ready)...)
+	}
+
+	return results, nil
+}
+
+// ingressControllerType returns the type of the controller set in an IngressClass
+func ingressControllerType(controller string) string {
+	for _, known := range knownIngressControllers {
+		if slices.Contains(known.Controllers, controller) {
+			return known.Type
+		}
+	}
+
+	return parseIngressClass(netv1.IngressClass{Spec: netv1.IngressClassSpec{Controller: controller}}).Type
+}
+
+func findKnownIngressController(image string) (knownIngressController, bool) {
+	repository := imageRepository(image)
+
+	for _, known := range knownIngressControllers {
+		for _, r := range known.Repositories {
+			if repository == r || strings.HasSuffix(repository, "/"+r) {
+				return known, true
+			}
+		}
+	}
+
+	return knownIngressController{}, false
+}
+
+// imageRepository returns the repository of an image, without its tag or its digest
+func imageRepository(image string) string {
+	image, _, _ = strings.Cut(image, "@")
+
+	if i := strings.LastIndex(image, ":"); i > strings.LastIndex(image, "/") {
+		image = image[:i]
+	}
+
+	return image
+}
+
+// imageTag returns the tag of an image, empty when it has none
+func imageTag(image string) string {
+	image, _, _ = strings.Cut(image, "@")
+
+	if i := strings.LastIndex(image, ":"); i > strings.LastIndex(image, "/") {
+		return image[i+1:]
+	}
+
+	return ""
+}
@@ -0,0 +1,187 @@
+package cli
+
+import (
+	"context"
+	"testing"
+
+	models "github.com/portainer/portainer/api/http/models/kubernetes"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	netv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kfake "k8s.io/client-go/kubernetes/fake"
+)
+
+func newTestIngress(namespace, name, className, host, path string) *netv1.Ingress {
+	pathType := netv1.PathTypePrefix
+
+	return &netv1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+		Spec: netv1.IngressSpec{
+			IngressClassName: &className,
+			Rules: []netv1.IngressRule{{
+				Host: host,
+				IngressRuleValue: netv1.IngressRuleValue{HTTP: &netv1.HTTPIngressRuleValue{Paths: []netv1.HTTPIngressPath{{
+					Path:     path,
+					PathType: &pathType,
+					Backend:  netv1.IngressBackend{Service: &netv1.IngressServiceBackend{Name: "web", Port: netv1.ServiceBackendPort{Number: 80}}},
+				}}}},
+			}},
+		},
+	}
+}
+
+func Test_ValidateIngress(t *testing.T) {
+	k := &KubeClient{
+		cli: kfake.NewSimpleClientset(
+			&netv1.IngressClass{ObjectMeta: metav1.ObjectMeta{Name: "nginx"}, Spec: netv1.IngressClassSpec{Controller: "k8s.io/ingress-nginx"}},
+			&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "tls", Namespace: "tenant"}, Type: corev1.SecretTypeTLS},
+			&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "opaque", Namespace: "tenant"}, Type: corev1.SecretTypeOpaque},
+			newTestIngress("private", "taken", "nginx", "example.com", "/taken"),
+			newTestIngress("tenant", "web", "nginx", "example.com", "/web"),
+		),
+		instanceID: "instance",
+	}
+
+	ingress := func(className, path, secretName string) models.K8sIngressInfo {
+		info := models.K8sIngressInfo{
+			Name:      "web",
+			Namespace: "tenant",
+			ClassName: className,
+			Hosts:     []string{"example.com"},
+			Paths:     []models.K8sIngressPath{{Host: "example.com", Path: path, PathType: "Prefix", ServiceName: "web", Port: 80}},
+		}
+
+		if secretName != "" {
+			info.TLS = []models.K8sIngressTLS{{Hosts: []string{"example.com"}, SecretName: secretName}}
+		}
+
+		return info
+	}
+
+	testCases := []struct {
+		name    string
+		ingress models.K8sIngressInfo
+		invalid bool
+	}{
+		{name: "valid", ingress: ingress("nginx", "/web", "tls")},
+		{name: "updating its own paths", ingress: ingress("nginx", "/web", "")},
+		{name: "TLS secret issued later", ingress: ingress("nginx", "/web", "issued-later")},
+		{name: "same path of another class", ingress: ingress("", "/taken", "")},
+		{name: "unknown class", ingress: ingress("traefik", "/web", ""), invalid: true},
+		{name: "secret which is not a TLS secret", ingress: ingress("nginx", "/web", "opaque"), invalid: true},
+		{name: "path served by another ingress", ingress: ingress("nginx", "/taken", ""), invalid: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := k.ValidateIngress("tenant", tc.ingress)
+			if tc.invalid {
+				require.ErrorIs(t, err, ErrInvalidIngress)
+				assert.NotContains(t, err.Error(), "private")
+				return
+			}
+
+			require.NoError(t, err)
+		})
+	}
+}
+
+func Test_UpdateIngress(t *testing.T) {
+	existing := newTestIngress("tenant", "web", "nginx", "example.com", "/web")
+	existing.Labels = map[string]string{"io.portainer.kubernetes.ingress.owner": "alice"}
+
+	k := &KubeClient{cli: kfake.NewSimpleClientset(existing), instanceID: "instance"}
+
+	err := k.UpdateIngress("tenant", models.K8sIngressInfo{
+		Name:      "web",
+		Namespace: "tenant",
+		Hosts:     []string{"example.org"},
+		Paths:     []models.K8sIngressPath{{Host: "example.org", Path: "/", ServiceName: "web", Port: 8080}},
+	})
+	require.NoError(t, err)
+
+	ingress, err := k.cli.NetworkingV1().Ingresses("tenant").Get(context.Background(), "web", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "alice", ingress.Labels["io.portainer.kubernetes.ingress.owner"])
+	require.Len(t, ingress.Spec.Rules, 1)
+	assert.Equal(t, "example.org", ingress.Spec.Rules[0].Host)
+
+	path := ingress.Spec.Rules[0].HTTP.Paths[0]
+	assert.Equal(t, netv1.PathTypePrefix, *path.PathType)
+	assert.Equal(t, int32(8080), path.Backend.Service.Port.Number)
+
+	t.Run("fails on a missing ingress", func(t *testing.T) {
+		err := k.UpdateIngress("tenant", models.K8sIngressInfo{Name: "missing", Namespace: "tenant"})
+		assert.Error(t, err)
+	})
+}
+
+func Test_GetIngressClasses(t *testing.T) {
+	k := &KubeClient{
+		cli: kfake.NewSimpleClientset(
+			&netv1.IngressClass{
+				ObjectMeta: metav1.ObjectMeta{Name: "nginx", Annotations: map[string]string{defaultIngressClassAnnotation: "true"}},
+				Spec:       netv1.IngressClassSpec{Controller: "k8s.io/ingress-nginx"},
+			},
+			&netv1.IngressClass{ObjectMeta: metav1.ObjectMeta{Name: "haproxy"}, Spec: netv1.IngressClassSpec{Controller: "haproxy.org/ingress-controller/haproxy"}},
+			newTestIngress("tenant", "web", "nginx", "example.com", "/"),
+		),
+		instanceID: "instance",
+	}
+
+	classes, err := k.GetIngressClasses()
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []models.K8sIngressClass{
+		{Name: "nginx", Controller: "k8s.io/ingress-nginx", Type: "nginx", IsDefault: true, Used: true},
+		{Name: "haproxy", Controller: "haproxy.org/ingress-controller/haproxy", Type: "haproxy"},
+	}, classes)
+}
+
+func Test_DetectIngressControllers(t *testing.T) {
+	replicas := int32(2)
+
+	podSpec := func(image string) corev1.PodTemplateSpec {
+		return corev1.PodTemplateSpec{Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "controller", Image: image}}}}
+	}
+
+	k := &KubeClient{
+		cli: kfake.NewSimpleClientset(
+			&appsv1.Deployment{
+				ObjectMeta: metav1.ObjectMeta{Name: "ingress-nginx-controller", Namespace: "ingress-nginx"},
+				Spec:       appsv1.DeploymentSpec{Replicas: &replicas, Template: podSpec("registry.k8s.io/ingress-nginx/controller:v1.10.0@sha256:42b3f0e5d0846876b1791cd3afeb5f1cbbe4259d6f35651dcc1b5c980925379c")},
+				Status:     appsv1.DeploymentStatus{ReadyReplicas: 2},
+			},
+			&appsv1.Deployment{
+				ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "tenant"},
+				Spec:       appsv1.DeploymentSpec{Template: podSpec("nginx:1.27")},
+			},
+			&appsv1.DaemonSet{
+				ObjectMeta: metav1.ObjectMeta{Name: "traefik", Namespace: "kube-system"},
+				Spec:       appsv1.DaemonSetSpec{Template: podSpec("docker.io/library/traefik:v3.1")},
+				Status:     appsv1.DaemonSetStatus{DesiredNumberScheduled: 3, NumberReady: 1},
+			},
+			&netv1.IngressClass{ObjectMeta: metav1.ObjectMeta{Name: "nginx"}, Spec: netv1.IngressClassSpec{Controller: "k8s.io/ingress-nginx"}},
+		),
+		instanceID: "instance",
+	}
+
+	controllers, err := k.DetectIngressControllers()
+	require.NoError(t, err)
+	require.Len(t, controllers, 2)
+
+	assert.Equal(t, "nginx", controllers[0].Type)
+	assert.Equal(t, "Deployment", controllers[0].Kind)
+	assert.Equal(t, "v1.10.0", controllers[0].Version)
+	assert.True(t, controllers[0].Ready)
+	assert.Equal(t, []string{"nginx"}, controllers[0].IngressClasses)
+
+	assert.Equal(t, "traefik", controllers[1].Type)
+	assert.Equal(t, "DaemonSet", controllers[1].Kind)
+	assert.Equal(t, "v3.1", controllers[1].Version)
+	assert.False(t, controllers[1].Ready)
+	assert.Empty(t, controllers[1].IngressClasses)
+}
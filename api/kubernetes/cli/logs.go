@@ -0,0 +1,301 @@
+package cli
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	models "github.com/portainer/portainer/api/http/models/kubernetes"
+
+	"github.com/rs/zerolog/log"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+const (
+	// logsReorderWindow is how long the followed lines are held so that the lines of the pods are interleaved by
+	// timestamp, the lines written by the pods within the window are sent in order
+	logsReorderWindow = 250 * time.Millisecond
+	// logsBufferSize is the number of lines read ahead of a slow client, the logs are no longer read from the pods
+	// until the client catches up
+	logsBufferSize = 1000
+	// maxLogLineSize is the size after which a line is truncated
+	maxLogLineSize = 256 * 1024
+)
+
+// ErrUnsupportedWorkloadKind is returned when the logs of a kind of workload cannot be read
+var ErrUnsupportedWorkloadKind = errors.New("the kind of workload must be Deployment, StatefulSet, DaemonSet or Pod")
+
+// WorkloadLogsOptions selects the lines read from the pods of a workload
+type WorkloadLogsOptions struct {
+	// Container of the pods, their first container when empty
+	Container string
+	// Read the logs of the previous instance of the container, which is useful when it is crash looping
+	Previous bool
+	// Number of lines read from the end of the logs of every pod, all of them when negative
+	TailLines int64
+	// Only read the lines written after this time, when set
+	SinceTime time.Time
+}
+
+// GetWorkloadPods returns the pods of a Deployment, a StatefulSet or a DaemonSet, or a single pod
+func (kcl *KubeClient) GetWorkloadPods(ctx context.Context, namespace, kind, name string) ([]corev1.Pod, error) {
+	if !kcl.IsKubeAdmin && !slices.Contains(kcl.NonAdminNamespaces, namespace) {
+		return nil, k8serrors.NewForbidden(schema.GroupResource{Resource: "pods"}, name, errors.New("the namespace is not accessible"))
+	}
+
+	var selector *metav1.LabelSelector
+
+	switch strings.ToLower(kind) {
+	case "deployment":
+		deployment, err := kcl.cli.AppsV1().Deployments(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return nil, err
+		}
+
+		selector = deployment.Spec.Selector
+	case "statefulset":
+		statefulSet, err := kcl.cli.AppsV1().StatefulSets(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return nil, err
+		}
+
+		selector = statefulSet.Spec.Selector
+	case "daemonset":
+		daemonSet, err := kcl.cli.AppsV1().DaemonSets(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return nil, err
+		}
+
+		selector = daemonSet.Spec.Selector
+	case "pod":
+		pod, err := kcl.cli.CoreV1().Pods(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return nil, err
+		}
+
+		return []corev1.Pod{*pod}, nil
+	default:
+		return nil, ErrUnsupportedWorkloadKind
+	}
+
+	podSelector, err := metav1.LabelSelectorAsSelector(selector)
+	if err != nil {
+		return nil, err
+	}
+
+	// an empty selector would select every pod of the namespace
+	if podSelector.Empty() {
+		return []corev1.Pod{}, nil
+	}
+
+	pods, err := kcl.cli.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{LabelSelector: podSelector.String()})
+	if err != nil {
+		return nil, err
+	}
+
+	slices.SortFunc(pods.Items, func(a, b corev1.Pod) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+
+	return pods.Items, nil
+}
+
+// GetWorkloadLogs returns the logs of the pods of a workload, interleaved by timestamp
+func (kcl *KubeClient) GetWorkloadLogs(ctx context.Context, namespace, kind, name string, options WorkloadLogsOptions) ([]models.K8sLogLine, error) {
+	pods, err := kcl.GetWorkloadPods(ctx, namespace, kind, name)
+	if err != nil {
+		return nil, err
+	}
+
+	lines := []models.K8sLogLine{}
+	for _, pod := range pods {
+		err := kcl.readPodLogs(ctx, pod, options, false, func(line models.K8sLogLine) error {
+			lines = append(lines, line)
+
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	sortLogLines(lines)
+
+	return lines, nil
+}
+
+// FollowWorkloadLogs reads the logs of the pods of a workload, and the new lines written by them, until the pods stop
+// or ctx is done. The lines are passed to fn in batches interleaved by timestamp, fn blocking the reading of the logs
+func (kcl *KubeClient) FollowWorkloadLogs(ctx context.Context, namespace, kind, name string, options WorkloadLogsOptions, fn func([]models.K8sLogLine) error) error {
+	pods, err := kcl.GetWorkloadPods(ctx, namespace, kind, name)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	lines := make(chan models.K8sLogLine, logsBufferSize)
+
+	var wg sync.WaitGroup
+	for _, pod := range pods {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			err := kcl.readPodLogs(ctx, pod, options, true, func(line models.K8sLogLine) error {
+				select {
+				case lines <- line:
+					return nil
+				case <-ctx.Done():
+					return ctx.Err()
+				}
+			})
+			if err != nil && ctx.Err() == nil {
+				log.Debug().Err(err).Str("namespace", pod.Namespace).Str("pod", pod.Name).Msg("unable to follow the logs of the pod")
+			}
+		}()
+	}
+
+	go func() {
+		wg.Wait()
+		close(lines)
+	}()
+
+	return interleaveLogLines(ctx, lines, logsReorderWindow, fn)
+}
+
+// readPodLogs reads the logs of a container of a pod, the pods without a previous instance of the container being
+// skipped when the previous logs are read
+func (kcl *KubeClient) readPodLogs(ctx context.Context, pod corev1.Pod, options WorkloadLogsOptions, follow bool, fn func(models.K8sLogLine) error) error {
+	container := options.Container
+	if container == "" && len(pod.Spec.Containers) > 0 {
+		container = pod.Spec.Containers[0].Name
+	}
+
+	logOptions := &corev1.PodLogOptions{
+		Container:  container,
+		Previous:   options.Previous,
+		Follow:     follow && !options.Previous,
+		Timestamps: true,
+	}
+
+	if options.TailLines >= 0 {
+		logOptions.TailLines = &options.TailLines
+	}
+
+	if !options.SinceTime.IsZero() {
+		sinceTime := metav1.NewTime(options.SinceTime)
+		logOptions.SinceTime = &sinceTime
+	}
+
+	stream, err := kcl.cli.CoreV1().Pods(pod.Namespace).GetLogs(pod.Name, logOptions).Stream(ctx)
+	if k8serrors.IsBadRequest(err) && options.Previous {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("unable to read the logs of the pod %s: %w", pod.Name, err)
+	}
+	defer stream.Close()
+
+	return scanLogLines(stream, pod.Name, container, fn)
+}
+
+// scanLogLines splits logs into lines, separating the timestamps added by the kubelet from their content
+func scanLogLines(r io.Reader, pod, container string, fn func(models.K8sLogLine) error) error {
+	reader := bufio.NewReaderSize(r, 64*1024)
+
+	for {
+		raw, err := reader.ReadString('\n')
+		if len(raw) > 0 {
+			if len(raw) > maxLogLineSize {
+				raw = raw[:maxLogLineSize]
+			}
+
+			if err := fn(parseLogLine(pod, container, strings.TrimRight(raw, "\r\n"))); err != nil {
+				return err
+			}
+		}
+
+		if errors.Is(err, io.EOF) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+	}
+}
+
+func parseLogLine(pod, container, raw string) models.K8sLogLine {
+	line := models.K8sLogLine{Pod: pod, Container: container, Text: raw}
+
+	timestamp, text, _ := strings.Cut(raw, " ")
+	if t, err := time.Parse(time.RFC3339Nano, timestamp); err == nil {
+		line.Timestamp = t
+		line.Text = text
+	}
+
+	return line
+}
+
+// interleaveLogLines holds the lines received during a window and passes them to fn sorted by timestamp, until
+// the lines are closed or ctx is done
+func interleaveLogLines(ctx context.Context, lines <-chan models.K8sLogLine, window time.Duration, fn func([]models.K8sLogLine) error) error {
+	ticker := time.NewTicker(window)
+	defer ticker.Stop()
+
+	batch := []models.K8sLogLine{}
+
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+
+		sortLogLines(batch)
+
+		err := fn(batch)
+		batch = []models.K8sLogLine{}
+
+		return err
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+
+		case line, ok := <-lines:
+			if !ok {
+				return flush()
+			}
+
+			batch = append(batch, line)
+
+			if len(batch) >= logsBufferSize {
+				if err := flush(); err != nil {
+					return err
+				}
+			}
+
+		case <-ticker.C:
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// sortLogLines sorts lines by timestamp, keeping the order of the lines of a pod
+func sortLogLines(lines []models.K8sLogLine) {
+	slices.SortStableFunc(lines, func(a, b models.K8sLogLine) int {
+		return a.Timestamp.Compare(b.Timestamp)
+	})
+}
@@ -0,0 +1,182 @@
+package cli
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	models "github.com/portainer/portainer/api/http/models/kubernetes"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kfake "k8s.io/client-go/kubernetes/fake"
+)
+
+func newTestLogsPod(name string, labels map[string]string) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "tenant", Labels: labels},
+		Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "web"}, {Name: "sidecar"}}},
+	}
+}
+
+func newTestLogsClient() *KubeClient {
+	selector := &metav1.LabelSelector{MatchLabels: map[string]string{"app": "web"}}
+
+	return &KubeClient{
+		cli: kfake.NewSimpleClientset(
+			&appsv1.Deployment{
+				ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "tenant"},
+				Spec:       appsv1.DeploymentSpec{Selector: selector},
+			},
+			&appsv1.StatefulSet{
+				ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: "tenant"},
+				Spec:       appsv1.StatefulSetSpec{Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "db"}}},
+			},
+			&appsv1.Deployment{
+				ObjectMeta: metav1.ObjectMeta{Name: "everything", Namespace: "tenant"},
+				Spec:       appsv1.DeploymentSpec{Selector: &metav1.LabelSelector{}},
+			},
+			newTestLogsPod("web-2", map[string]string{"app": "web"}),
+			newTestLogsPod("web-1", map[string]string{"app": "web"}),
+			newTestLogsPod("db-0", map[string]string{"app": "db"}),
+		),
+		instanceID:  "instance",
+		IsKubeAdmin: true,
+	}
+}
+
+func Test_GetWorkloadPods(t *testing.T) {
+	k := newTestLogsClient()
+
+	podNames := func(pods []corev1.Pod) []string {
+		names := []string{}
+		for _, pod := range pods {
+			names = append(names, pod.Name)
+		}
+
+		return names
+	}
+
+	testCases := []struct {
+		kind string
+		name string
+		pods []string
+	}{
+		{kind: "Deployment", name: "web", pods: []string{"web-1", "web-2"}},
+		{kind: "statefulset", name: "db", pods: []string{"db-0"}},
+		{kind: "Pod", name: "web-2", pods: []string{"web-2"}},
+		{kind: "Deployment", name: "everything", pods: []string{}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.kind+"/"+tc.name, func(t *testing.T) {
+			pods, err := k.GetWorkloadPods(context.Background(), "tenant", tc.kind, tc.name)
+			require.NoError(t, err)
+			assert.Equal(t, tc.pods, podNames(pods))
+		})
+	}
+
+	t.Run("unsupported kind", func(t *testing.T) {
+		_, err := k.GetWorkloadPods(context.Background(), "tenant", "CronJob", "backup")
+		require.ErrorIs(t, err, ErrUnsupportedWorkloadKind)
+	})
+
+	t.Run("missing workload", func(t *testing.T) {
+		_, err := k.GetWorkloadPods(context.Background(), "tenant", "Deployment", "missing")
+		require.True(t, k8serrors.IsNotFound(err))
+	})
+
+	t.Run("namespace not accessible to a non-admin user", func(t *testing.T) {
+		k := &KubeClient{cli: k.cli, instanceID: "instance", NonAdminNamespaces: []string{"other"}}
+
+		_, err := k.GetWorkloadPods(context.Background(), "tenant", "Deployment", "web")
+		require.True(t, k8serrors.IsForbidden(err))
+	})
+}
+
+func Test_GetWorkloadLogs(t *testing.T) {
+	k := newTestLogsClient()
+
+	lines, err := k.GetWorkloadLogs(context.Background(), "tenant", "Deployment", "web", WorkloadLogsOptions{TailLines: 100})
+	require.NoError(t, err)
+
+	// the fake client returns the same line for every pod, without timestamp
+	require.Len(t, lines, 2)
+	assert.Equal(t, models.K8sLogLine{Pod: "web-1", Container: "web", Text: "fake logs"}, lines[0])
+	assert.Equal(t, models.K8sLogLine{Pod: "web-2", Container: "web", Text: "fake logs"}, lines[1])
+
+	lines, err = k.GetWorkloadLogs(context.Background(), "tenant", "Deployment", "web", WorkloadLogsOptions{Container: "sidecar", TailLines: -1})
+	require.NoError(t, err)
+	require.Len(t, lines, 2)
+	assert.Equal(t, "sidecar", lines[0].Container)
+}
+
+func Test_FollowWorkloadLogs(t *testing.T) {
+	k := newTestLogsClient()
+
+	lines := []models.K8sLogLine{}
+	err := k.FollowWorkloadLogs(context.Background(), "tenant", "Deployment", "web", WorkloadLogsOptions{TailLines: 100}, func(batch []models.K8sLogLine) error {
+		lines = append(lines, batch...)
+
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Len(t, lines, 2)
+}
+
+func Test_scanLogLines(t *testing.T) {
+	logs := "2024-05-01T10:00:00.000000001Z started\n" +
+		"2024-05-01T10:00:01Z listening on port 8080\r\n" +
+		"no timestamp\n" +
+		"2024-05-01T10:00:02Z last line without end"
+
+	lines := []models.K8sLogLine{}
+	err := scanLogLines(strings.NewReader(logs), "web-1", "web", func(line models.K8sLogLine) error {
+		lines = append(lines, line)
+
+		return nil
+	})
+	require.NoError(t, err)
+
+	require.Len(t, lines, 4)
+	assert.Equal(t, time.Date(2024, 5, 1, 10, 0, 0, 1, time.UTC), lines[0].Timestamp)
+	assert.Equal(t, "started", lines[0].Text)
+	assert.Equal(t, "listening on port 8080", lines[1].Text)
+	assert.True(t, lines[2].Timestamp.IsZero())
+	assert.Equal(t, "no timestamp", lines[2].Text)
+	assert.Equal(t, "last line without end", lines[3].Text)
+	assert.Equal(t, "web-1", lines[3].Pod)
+}
+
+func Test_interleaveLogLines(t *testing.T) {
+	base := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+
+	lines := make(chan models.K8sLogLine, 10)
+	lines <- models.K8sLogLine{Pod: "web-1", Timestamp: base.Add(2 * time.Second), Text: "third"}
+	lines <- models.K8sLogLine{Pod: "web-2", Timestamp: base, Text: "first"}
+	lines <- models.K8sLogLine{Pod: "web-1", Timestamp: base.Add(time.Second), Text: "second"}
+	lines <- models.K8sLogLine{Pod: "web-2", Timestamp: base.Add(time.Second), Text: "second too"}
+	close(lines)
+
+	batches := [][]models.K8sLogLine{}
+	err := interleaveLogLines(context.Background(), lines, time.Hour, func(batch []models.K8sLogLine) error {
+		batches = append(batches, batch)
+
+		return nil
+	})
+	require.NoError(t, err)
+
+	require.Len(t, batches, 1)
+
+	texts := []string{}
+	for _, line := range batches[0] {
+		texts = append(texts, line.Text)
+	}
+
+	assert.Equal(t, []string{"first", "second", "second too", "third"}, texts)
+}
@@ -12,6 +12,7 @@ const (
 	portainerClusterAdminServiceAccountName = "portainer-sa-clusteradmin"
 	portainerUserServiceAccountPrefix       = "portainer-sa-user"
 	portainerRBPrefix                       = "portainer-rb"
+	portainerTeamRBPrefix                   = "portainer-rb-team"
 	portainerConfigMapName                  = "portainer-config"
 	portainerConfigMapAccessPoliciesKey     = "NamespaceAccessPolicies"
 	portainerShellPodPrefix                 = "portainer-pod-kubectl-shell"
@@ -29,6 +30,10 @@ func namespaceClusterRoleBindingName(namespace string, instanceID string) string
 	return fmt.Sprintf("%s-%s-%s", portainerRBPrefix, instanceID, namespace)
 }
 
+func teamRoleBindingName(teamID int, instanceID string) string {
+	return fmt.Sprintf("%s-%s-%d", portainerTeamRBPrefix, instanceID, teamID)
+}
+
 func userShellPodPrefix(serviceAccountName string) string {
 	return fmt.Sprintf("%s-%s-", portainerShellPodPrefix, serviceAccountName)
 }
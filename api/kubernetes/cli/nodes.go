@@ -0,0 +1,251 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	models "github.com/portainer/portainer/api/http/models/kubernetes"
+
+	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/retry"
+)
+
+// defaultNodeDrainTimeout is how long the pods are waited for to leave a drained node when no timeout is given
+const defaultNodeDrainTimeout = 5 * time.Minute
+
+// nodeDrainPollInterval is how often the pods blocked by a PodDisruptionBudget are evicted again, and the evicted
+// pods are checked for being gone
+var nodeDrainPollInterval = 2 * time.Second
+
+// ErrNodeDrainBlocked is returned when a node runs pods which cannot be evicted with the options of the drain
+var ErrNodeDrainBlocked = errors.New("the node runs pods which cannot be evicted")
+
+// CordonNode marks a node as unschedulable, or as schedulable again when cordon is false
+func (kcl *KubeClient) CordonNode(name string, cordon bool) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		node, err := kcl.cli.CoreV1().Nodes().Get(context.TODO(), name, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+
+		if node.Spec.Unschedulable == cordon {
+			return nil
+		}
+
+		node.Spec.Unschedulable = cordon
+
+		_, err = kcl.cli.CoreV1().Nodes().Update(context.TODO(), node, metav1.UpdateOptions{})
+
+		return err
+	})
+}
+
+// UpdateNode replaces the labels and the taints of a node, keeping the ones managed by Kubernetes
+func (kcl *KubeClient) UpdateNode(name string, payload models.K8sNodeUpdatePayload) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		node, err := kcl.cli.CoreV1().Nodes().Get(context.TODO(), name, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+
+		if payload.Labels != nil {
+			labels := map[string]string{}
+			for key, value := range node.Labels {
+				if models.IsK8sManagedNodeKey(key) {
+					labels[key] = value
+				}
+			}
+
+			for key, value := range payload.Labels {
+				labels[key] = value
+			}
+
+			node.Labels = labels
+		}
+
+		if payload.Taints != nil {
+			taints := []corev1.Taint{}
+			for _, taint := range node.Spec.Taints {
+				if models.IsK8sManagedNodeKey(taint.Key) {
+					taints = append(taints, taint)
+				}
+			}
+
+			for _, taint := range payload.Taints {
+				taints = append(taints, corev1.Taint{Key: taint.Key, Value: taint.Value, Effect: corev1.TaintEffect(taint.Effect)})
+			}
+
+			node.Spec.Taints = taints
+		}
+
+		_, err = kcl.cli.CoreV1().Nodes().Update(context.TODO(), node, metav1.UpdateOptions{})
+
+		return err
+	})
+}
+
+// GetNodePodsToDrain returns the pods evicted by the drain of a node, or ErrNodeDrainBlocked listing the pods
+// preventing the drain with these options
+func (kcl *KubeClient) GetNodePodsToDrain(ctx context.Context, name string, options models.K8sNodeDrainPayload) ([]corev1.Pod, error) {
+	if _, err := kcl.cli.CoreV1().Nodes().Get(ctx, name, metav1.GetOptions{}); err != nil {
+		return nil, err
+	}
+
+	pods, err := kcl.cli.CoreV1().Pods(metav1.NamespaceAll).List(ctx, metav1.ListOptions{
+		FieldSelector: fields.OneTermEqualSelector("spec.nodeName", name).String(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	podsToDrain := []corev1.Pod{}
+	problems := []string{}
+
+	for _, pod := range pods.Items {
+		// the field selector is not supported by every client
+		if pod.Spec.NodeName != name {
+			continue
+		}
+
+		// the static pods are managed by the kubelet and cannot be evicted
+		if _, ok := pod.Annotations[corev1.MirrorPodAnnotationKey]; ok {
+			continue
+		}
+
+		finished := pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed
+		controller := metav1.GetControllerOf(&pod)
+
+		switch {
+		case finished:
+		case controller != nil && controller.Kind == "DaemonSet":
+			if !options.IgnoreDaemonSets {
+				problems = append(problems, fmt.Sprintf("%s/%s is managed by a DaemonSet", pod.Namespace, pod.Name))
+			}
+
+			continue
+		case controller == nil && !options.Force:
+			problems = append(problems, fmt.Sprintf("%s/%s is not managed by a controller", pod.Namespace, pod.Name))
+
+			continue
+		case hasEmptyDirVolume(pod) && !options.DeleteEmptyDirData:
+			problems = append(problems, fmt.Sprintf("%s/%s uses an emptyDir volume", pod.Namespace, pod.Name))
+
+			continue
+		}
+
+		podsToDrain = append(podsToDrain, pod)
+	}
+
+	if len(problems) > 0 {
+		return nil, fmt.Errorf("%w: %s", ErrNodeDrainBlocked, strings.Join(problems, ", "))
+	}
+
+	return podsToDrain, nil
+}
+
+// DrainNode cordons a node and evicts its pods, waiting for them to be gone until the timeout of the drain. The
+// progress of the drain is passed to progress after every change
+func (kcl *KubeClient) DrainNode(ctx context.Context, name string, options models.K8sNodeDrainPayload, progress func(models.K8sNodeDrainStatus)) error {
+	timeout := defaultNodeDrainTimeout
+	if options.TimeoutSeconds > 0 {
+		timeout = time.Duration(options.TimeoutSeconds) * time.Second
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	if err := kcl.CordonNode(name, true); err != nil {
+		return fmt.Errorf("unable to cordon the node: %w", err)
+	}
+
+	pending, err := kcl.GetNodePodsToDrain(ctx, name, options)
+	if err != nil {
+		return err
+	}
+
+	status := models.K8sNodeDrainStatus{Node: name, PodsTotal: len(pending)}
+	evictionRequested := map[types.UID]bool{}
+
+	for {
+		left := []corev1.Pod{}
+
+		for _, pod := range pending {
+			if !evictionRequested[pod.UID] {
+				err := kcl.evictPod(ctx, pod, options)
+				switch {
+				case err == nil:
+					evictionRequested[pod.UID] = true
+				case k8serrors.IsNotFound(err):
+					status.PodsEvicted++
+
+					continue
+				// the eviction is refused while it would violate a PodDisruptionBudget
+				case k8serrors.IsTooManyRequests(err):
+				case ctx.Err() == nil:
+					return fmt.Errorf("unable to evict the pod %s/%s: %w", pod.Namespace, pod.Name, err)
+				}
+			}
+
+			current, err := kcl.cli.CoreV1().Pods(pod.Namespace).Get(ctx, pod.Name, metav1.GetOptions{})
+			if k8serrors.IsNotFound(err) || (err == nil && current.UID != pod.UID) {
+				status.PodsEvicted++
+
+				continue
+			} else if err != nil && ctx.Err() == nil {
+				return err
+			}
+
+			left = append(left, pod)
+		}
+
+		pending = left
+
+		status.PendingPods = []string{}
+		for _, pod := range pending {
+			status.PendingPods = append(status.PendingPods, pod.Namespace+"/"+pod.Name)
+		}
+
+		progress(status)
+
+		if len(pending) == 0 {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("timed out waiting for %d pods to leave the node", len(pending))
+		case <-time.After(nodeDrainPollInterval):
+		}
+	}
+}
+
+func (kcl *KubeClient) evictPod(ctx context.Context, pod corev1.Pod, options models.K8sNodeDrainPayload) error {
+	deleteOptions := metav1.DeleteOptions{GracePeriodSeconds: options.GracePeriodSeconds}
+
+	if options.DisableEviction {
+		return kcl.cli.CoreV1().Pods(pod.Namespace).Delete(ctx, pod.Name, deleteOptions)
+	}
+
+	return kcl.cli.CoreV1().Pods(pod.Namespace).EvictV1(ctx, &policyv1.Eviction{
+		ObjectMeta:    metav1.ObjectMeta{Name: pod.Name, Namespace: pod.Namespace},
+		DeleteOptions: &deleteOptions,
+	})
+}
+
+func hasEmptyDirVolume(pod corev1.Pod) bool {
+	for _, volume := range pod.Spec.Volumes {
+		if volume.EmptyDir != nil {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,211 @@
+package cli
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	models "github.com/portainer/portainer/api/http/models/kubernetes"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	kfake "k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func newTestNodePod(name, nodeName, controllerKind string) *corev1.Pod {
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "tenant", UID: types.UID(name)},
+		Spec:       corev1.PodSpec{NodeName: nodeName},
+		Status:     corev1.PodStatus{Phase: corev1.PodRunning},
+	}
+
+	if controllerKind != "" {
+		controller := true
+		pod.OwnerReferences = []metav1.OwnerReference{{Kind: controllerKind, Name: name + "-owner", Controller: &controller}}
+	}
+
+	return pod
+}
+
+func Test_CordonNode(t *testing.T) {
+	k := &KubeClient{
+		cli:        kfake.NewSimpleClientset(&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "worker-1"}}),
+		instanceID: "instance",
+	}
+
+	require.NoError(t, k.CordonNode("worker-1", true))
+
+	node, err := k.cli.CoreV1().Nodes().Get(context.Background(), "worker-1", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.True(t, node.Spec.Unschedulable)
+
+	require.NoError(t, k.CordonNode("worker-1", false))
+
+	node, err = k.cli.CoreV1().Nodes().Get(context.Background(), "worker-1", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.False(t, node.Spec.Unschedulable)
+
+	require.True(t, k8serrors.IsNotFound(k.CordonNode("missing", true)))
+}
+
+func Test_UpdateNode(t *testing.T) {
+	k := &KubeClient{
+		cli: kfake.NewSimpleClientset(&corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: "worker-1", Labels: map[string]string{
+				"kubernetes.io/hostname":         "worker-1",
+				"topology.kubernetes.io/zone":    "eu-west-1a",
+				"node-role.kubernetes.io/worker": "",
+				"disktype":                       "hdd",
+			}},
+			Spec: corev1.NodeSpec{Taints: []corev1.Taint{
+				{Key: "node.kubernetes.io/unreachable", Effect: corev1.TaintEffectNoExecute},
+				{Key: "dedicated", Value: "db", Effect: corev1.TaintEffectNoSchedule},
+			}},
+		}),
+		instanceID: "instance",
+	}
+
+	err := k.UpdateNode("worker-1", models.K8sNodeUpdatePayload{
+		Labels: map[string]string{"disktype": "ssd"},
+		Taints: []models.K8sNodeTaint{{Key: "dedicated", Value: "gpu", Effect: "NoSchedule"}},
+	})
+	require.NoError(t, err)
+
+	node, err := k.cli.CoreV1().Nodes().Get(context.Background(), "worker-1", metav1.GetOptions{})
+	require.NoError(t, err)
+
+	assert.Equal(t, map[string]string{
+		"kubernetes.io/hostname":      "worker-1",
+		"topology.kubernetes.io/zone": "eu-west-1a",
+		"disktype":                    "ssd",
+	}, node.Labels)
+	assert.Equal(t, []corev1.Taint{
+		{Key: "node.kubernetes.io/unreachable", Effect: corev1.TaintEffectNoExecute},
+		{Key: "dedicated", Value: "gpu", Effect: corev1.TaintEffectNoSchedule},
+	}, node.Spec.Taints)
+
+	// the taints are left unchanged when they are not given
+	require.NoError(t, k.UpdateNode("worker-1", models.K8sNodeUpdatePayload{Labels: map[string]string{}}))
+
+	node, err = k.cli.CoreV1().Nodes().Get(context.Background(), "worker-1", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Len(t, node.Labels, 2)
+	assert.Len(t, node.Spec.Taints, 2)
+}
+
+func Test_GetNodePodsToDrain(t *testing.T) {
+	mirror := newTestNodePod("static", "worker-1", "")
+	mirror.Annotations = map[string]string{corev1.MirrorPodAnnotationKey: "hash"}
+
+	finished := newTestNodePod("job", "worker-1", "")
+	finished.Status.Phase = corev1.PodSucceeded
+
+	cache := newTestNodePod("cache", "worker-1", "ReplicaSet")
+	cache.Spec.Volumes = []corev1.Volume{{Name: "tmp", VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}}}}
+
+	k := &KubeClient{
+		cli: kfake.NewSimpleClientset(
+			&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "worker-1"}},
+			newTestNodePod("web", "worker-1", "ReplicaSet"),
+			newTestNodePod("agent", "worker-1", "DaemonSet"),
+			newTestNodePod("debug", "worker-1", ""),
+			newTestNodePod("other", "worker-2", "ReplicaSet"),
+			mirror, finished, cache,
+		),
+		instanceID: "instance",
+	}
+
+	_, err := k.GetNodePodsToDrain(context.Background(), "worker-1", models.K8sNodeDrainPayload{})
+	require.ErrorIs(t, err, ErrNodeDrainBlocked)
+	assert.Contains(t, err.Error(), "tenant/agent is managed by a DaemonSet")
+	assert.Contains(t, err.Error(), "tenant/debug is not managed by a controller")
+	assert.Contains(t, err.Error(), "tenant/cache uses an emptyDir volume")
+
+	pods, err := k.GetNodePodsToDrain(context.Background(), "worker-1", models.K8sNodeDrainPayload{IgnoreDaemonSets: true, Force: true, DeleteEmptyDirData: true})
+	require.NoError(t, err)
+
+	names := []string{}
+	for _, pod := range pods {
+		names = append(names, pod.Name)
+	}
+
+	assert.ElementsMatch(t, []string{"web", "debug", "job", "cache"}, names)
+
+	_, err = k.GetNodePodsToDrain(context.Background(), "missing", models.K8sNodeDrainPayload{})
+	require.True(t, k8serrors.IsNotFound(err))
+}
+
+func Test_DrainNode(t *testing.T) {
+	nodeDrainPollInterval = 10 * time.Millisecond
+
+	fake := kfake.NewSimpleClientset(
+		&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "worker-1"}},
+		newTestNodePod("web", "worker-1", "ReplicaSet"),
+		newTestNodePod("db", "worker-1", "StatefulSet"),
+		newTestNodePod("agent", "worker-1", "DaemonSet"),
+	)
+
+	// the eviction of db is refused once, as it would violate its PodDisruptionBudget, and the evicted pods are deleted
+	refused := false
+	fake.PrependReactor("create", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		if action.GetSubresource() != "eviction" {
+			return false, nil, nil
+		}
+
+		eviction := action.(k8stesting.CreateAction).GetObject().(*policyv1.Eviction)
+		if eviction.Name == "db" && !refused {
+			refused = true
+
+			return true, nil, k8serrors.NewTooManyRequests("the disruption budget is exhausted", 1)
+		}
+
+		return true, nil, fake.Tracker().Delete(corev1.SchemeGroupVersion.WithResource("pods"), eviction.Namespace, eviction.Name)
+	})
+
+	k := &KubeClient{cli: fake, instanceID: "instance"}
+
+	statuses := []models.K8sNodeDrainStatus{}
+	err := k.DrainNode(context.Background(), "worker-1", models.K8sNodeDrainPayload{IgnoreDaemonSets: true}, func(status models.K8sNodeDrainStatus) {
+		statuses = append(statuses, status)
+	})
+	require.NoError(t, err)
+
+	node, err := fake.CoreV1().Nodes().Get(context.Background(), "worker-1", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.True(t, node.Spec.Unschedulable)
+
+	require.Len(t, statuses, 2)
+	assert.Equal(t, 2, statuses[0].PodsTotal)
+	assert.Equal(t, 1, statuses[0].PodsEvicted)
+	assert.Equal(t, []string{"tenant/db"}, statuses[0].PendingPods)
+	assert.Equal(t, 2, statuses[1].PodsEvicted)
+	assert.Empty(t, statuses[1].PendingPods)
+
+	_, err = fake.CoreV1().Pods("tenant").Get(context.Background(), "agent", metav1.GetOptions{})
+	require.NoError(t, err, "the pods of the DaemonSets are left")
+}
+
+func Test_DrainNodeTimeout(t *testing.T) {
+	nodeDrainPollInterval = 10 * time.Millisecond
+
+	fake := kfake.NewSimpleClientset(
+		&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "worker-1"}},
+		newTestNodePod("db", "worker-1", "StatefulSet"),
+	)
+
+	fake.PrependReactor("create", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		return action.GetSubresource() == "eviction", nil, k8serrors.NewTooManyRequests("the disruption budget is exhausted", 1)
+	})
+
+	k := &KubeClient{cli: fake, instanceID: "instance"}
+
+	err := k.DrainNode(context.Background(), "worker-1", models.K8sNodeDrainPayload{TimeoutSeconds: 1}, func(models.K8sNodeDrainStatus) {})
+	require.ErrorContains(t, err, "timed out waiting for 1 pods")
+}
@@ -0,0 +1,274 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+
+	portainer "github.com/portainer/portainer/api"
+	models "github.com/portainer/portainer/api/http/models/kubernetes"
+
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func portainerResourceQuotaName(namespace string) string {
+	return "portainer-rq-" + namespace
+}
+
+func portainerLimitRangeName(namespace string) string {
+	return "portainer-lr-" + namespace
+}
+
+// GetNamespaceQuotas returns the ResourceQuota, with its usage, and the LimitRange managed by Portainer in a namespace
+func (kcl *KubeClient) GetNamespaceQuotas(namespace string) (models.K8sNamespaceQuotas, error) {
+	quotas := models.K8sNamespaceQuotas{}
+
+	if _, err := kcl.cli.CoreV1().Namespaces().Get(context.TODO(), namespace, metav1.GetOptions{}); err != nil {
+		return quotas, err
+	}
+
+	resourceQuota, err := kcl.cli.CoreV1().ResourceQuotas(namespace).Get(context.TODO(), portainerResourceQuotaName(namespace), metav1.GetOptions{})
+	if err != nil && !k8serrors.IsNotFound(err) {
+		return quotas, err
+	} else if err == nil {
+		quotas.ResourceQuota = &models.K8sNamespaceResourceQuota{
+			Hard: models.FormatResourceList(resourceQuota.Spec.Hard),
+			Used: models.FormatResourceList(resourceQuota.Status.Used),
+		}
+	}
+
+	limitRange, err := kcl.cli.CoreV1().LimitRanges(namespace).Get(context.TODO(), portainerLimitRangeName(namespace), metav1.GetOptions{})
+	if err != nil && !k8serrors.IsNotFound(err) {
+		return quotas, err
+	} else if err == nil {
+		quotas.LimitRange = &models.K8sNamespaceLimitRange{Limits: []models.K8sLimitRangeItem{}}
+		for _, item := range limitRange.Spec.Limits {
+			quotas.LimitRange.Limits = append(quotas.LimitRange.Limits, models.K8sLimitRangeItem{
+				Type:           string(item.Type),
+				Max:            models.FormatResourceList(item.Max),
+				Min:            models.FormatResourceList(item.Min),
+				Default:        models.FormatResourceList(item.Default),
+				DefaultRequest: models.FormatResourceList(item.DefaultRequest),
+			})
+		}
+	}
+
+	return quotas, nil
+}
+
+// UpdateNamespaceQuotas replaces the ResourceQuota and the LimitRange managed by Portainer in a namespace, a nil
+// ResourceQuota or LimitRange removing it. The quotas are expected to be validated
+func (kcl *KubeClient) UpdateNamespaceQuotas(namespace string, quotas models.K8sNamespaceQuotas) error {
+	if _, err := kcl.cli.CoreV1().Namespaces().Get(context.TODO(), namespace, metav1.GetOptions{}); err != nil {
+		return err
+	}
+
+	if err := kcl.updateNamespaceResourceQuota(namespace, quotas.ResourceQuota); err != nil {
+		return fmt.Errorf("unable to update the resource quota: %w", err)
+	}
+
+	if err := kcl.updateNamespaceLimitRange(namespace, quotas.LimitRange); err != nil {
+		return fmt.Errorf("unable to update the limit range: %w", err)
+	}
+
+	return nil
+}
+
+func (kcl *KubeClient) updateNamespaceResourceQuota(namespace string, quota *models.K8sNamespaceResourceQuota) error {
+	name := portainerResourceQuotaName(namespace)
+
+	if quota == nil {
+		err := kcl.cli.CoreV1().ResourceQuotas(namespace).Delete(context.TODO(), name, metav1.DeleteOptions{})
+		if err != nil && !k8serrors.IsNotFound(err) {
+			return err
+		}
+
+		return nil
+	}
+
+	hard, err := models.ParseResourceList(quota.Hard)
+	if err != nil {
+		return err
+	}
+
+	resourceQuota, err := kcl.cli.CoreV1().ResourceQuotas(namespace).Get(context.TODO(), name, metav1.GetOptions{})
+	if k8serrors.IsNotFound(err) {
+		resourceQuota = &corev1.ResourceQuota{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: namespace,
+				Labels:    map[string]string{namespaceNameLabel: namespace},
+			},
+			Spec: corev1.ResourceQuotaSpec{Hard: hard},
+		}
+
+		_, err = kcl.cli.CoreV1().ResourceQuotas(namespace).Create(context.TODO(), resourceQuota, metav1.CreateOptions{})
+		return err
+	} else if err != nil {
+		return err
+	}
+
+	resourceQuota.Spec.Hard = hard
+
+	_, err = kcl.cli.CoreV1().ResourceQuotas(namespace).Update(context.TODO(), resourceQuota, metav1.UpdateOptions{})
+	return err
+}
+
+func (kcl *KubeClient) updateNamespaceLimitRange(namespace string, limits *models.K8sNamespaceLimitRange) error {
+	name := portainerLimitRangeName(namespace)
+
+	if limits == nil {
+		err := kcl.cli.CoreV1().LimitRanges(namespace).Delete(context.TODO(), name, metav1.DeleteOptions{})
+		if err != nil && !k8serrors.IsNotFound(err) {
+			return err
+		}
+
+		return nil
+	}
+
+	items := make([]corev1.LimitRangeItem, 0, len(limits.Limits))
+	for _, limit := range limits.Limits {
+		item := corev1.LimitRangeItem{Type: corev1.LimitType(limit.Type)}
+
+		for _, field := range []struct {
+			values map[string]string
+			list   *corev1.ResourceList
+		}{
+			{limit.Max, &item.Max},
+			{limit.Min, &item.Min},
+			{limit.Default, &item.Default},
+			{limit.DefaultRequest, &item.DefaultRequest},
+		} {
+			if len(field.values) == 0 {
+				continue
+			}
+
+			list, err := models.ParseResourceList(field.values)
+			if err != nil {
+				return err
+			}
+
+			*field.list = list
+		}
+
+		items = append(items, item)
+	}
+
+	limitRange, err := kcl.cli.CoreV1().LimitRanges(namespace).Get(context.TODO(), name, metav1.GetOptions{})
+	if k8serrors.IsNotFound(err) {
+		limitRange = &corev1.LimitRange{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: namespace,
+				Labels:    map[string]string{namespaceNameLabel: namespace},
+			},
+			Spec: corev1.LimitRangeSpec{Limits: items},
+		}
+
+		_, err = kcl.cli.CoreV1().LimitRanges(namespace).Create(context.TODO(), limitRange, metav1.CreateOptions{})
+		return err
+	} else if err != nil {
+		return err
+	}
+
+	limitRange.Spec.Limits = items
+
+	_, err = kcl.cli.CoreV1().LimitRanges(namespace).Update(context.TODO(), limitRange, metav1.UpdateOptions{})
+	return err
+}
+
+// GetNamespaceQuotaWarnings returns warnings when the CPU or the memory reserved by the resource quota of a namespace,
+// alone or with the resource quotas of the other namespaces, exceed the capacity of the cluster in the latest snapshot
+// of the environment. When the over-commit is disabled, the percentage of the capacity reserved for the system is
+// not available to the namespaces
+func (kcl *KubeClient) GetNamespaceQuotaWarnings(namespace string, hard map[string]string, endpoint *portainer.Endpoint) ([]string, error) {
+	if len(endpoint.Kubernetes.Snapshots) == 0 {
+		return nil, nil
+	}
+
+	quota, err := models.ParseResourceList(hard)
+	if err != nil {
+		return nil, err
+	}
+
+	resourceQuotas, err := kcl.cli.CoreV1().ResourceQuotas("").List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	others := []corev1.ResourceList{}
+	for _, resourceQuota := range resourceQuotas.Items {
+		if resourceQuota.Namespace != namespace {
+			others = append(others, resourceQuota.Spec.Hard)
+		}
+	}
+
+	snapshot := endpoint.Kubernetes.Snapshots[0]
+	configuration := endpoint.Kubernetes.Configuration
+
+	capacity := portainer.K8sNodeLimits{CPU: snapshot.TotalCPU * 1000, Memory: snapshot.TotalMemory}
+	if !configuration.EnableResourceOverCommit {
+		reservedPercent := float64(configuration.ResourceOverCommitPercentage) / 100.0
+		capacity.CPU -= int64(float64(capacity.CPU) * reservedPercent)
+		capacity.Memory -= int64(float64(capacity.Memory) * reservedPercent)
+	}
+
+	return quotaWarnings(quota, others, capacity), nil
+}
+
+// quotaWarnings compares the CPU, in millicores, and the memory, in bytes, reserved by resource quotas to a capacity
+func quotaWarnings(quota corev1.ResourceList, others []corev1.ResourceList, capacity portainer.K8sNodeLimits) []string {
+	warnings := []string{}
+
+	for _, r := range []struct {
+		name     string
+		value    func(quantity resource.Quantity) int64
+		format   func(value int64) string
+		capacity int64
+	}{
+		{"CPU", func(q resource.Quantity) int64 { return q.MilliValue() }, func(v int64) string { return resource.NewMilliQuantity(v, resource.DecimalSI).String() }, capacity.CPU},
+		{"memory", func(q resource.Quantity) int64 { return q.Value() }, func(v int64) string { return resource.NewQuantity(v, resource.BinarySI).String() }, capacity.Memory},
+	} {
+		quantity, ok := quotaQuantity(quota, r.name)
+		if !ok {
+			continue
+		}
+
+		own := r.value(quantity)
+		if own > r.capacity {
+			warnings = append(warnings, fmt.Sprintf("the %s quota of the namespace (%s) exceeds the %s available in the cluster", r.name, r.format(own), r.format(r.capacity)))
+			continue
+		}
+
+		total := own
+		for _, other := range others {
+			if quantity, ok := quotaQuantity(other, r.name); ok {
+				total += r.value(quantity)
+			}
+		}
+
+		if total > r.capacity {
+			warnings = append(warnings, fmt.Sprintf("the %s quotas of the namespaces (%s) exceed the %s available in the cluster", r.name, r.format(total), r.format(r.capacity)))
+		}
+	}
+
+	return warnings
+}
+
+// quotaQuantity returns the limit of a quota for the CPU or the memory, or its request when it has no limit
+func quotaQuantity(quota corev1.ResourceList, name string) (resource.Quantity, bool) {
+	names := []corev1.ResourceName{corev1.ResourceLimitsCPU, corev1.ResourceRequestsCPU, corev1.ResourceCPU}
+	if name == "memory" {
+		names = []corev1.ResourceName{corev1.ResourceLimitsMemory, corev1.ResourceRequestsMemory, corev1.ResourceMemory}
+	}
+
+	for _, resourceName := range names {
+		if quantity, ok := quota[resourceName]; ok {
+			return quantity, true
+		}
+	}
+
+	return resource.Quantity{}, false
+}
@@ -0,0 +1,115 @@
+package cli
+
+import (
+	"testing"
+
+	portainer "github.com/portainer/portainer/api"
+	models "github.com/portainer/portainer/api/http/models/kubernetes"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kfake "k8s.io/client-go/kubernetes/fake"
+)
+
+func Test_UpdateNamespaceQuotas(t *testing.T) {
+	k := &KubeClient{
+		cli:        kfake.NewSimpleClientset(&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "tenant"}}),
+		instanceID: "instance",
+	}
+
+	quotas := models.K8sNamespaceQuotas{
+		ResourceQuota: &models.K8sNamespaceResourceQuota{Hard: map[string]string{"limits.cpu": "2", "pods": "10"}},
+		LimitRange: &models.K8sNamespaceLimitRange{Limits: []models.K8sLimitRangeItem{
+			{Type: "Container", Default: map[string]string{"cpu": "500m"}, Max: map[string]string{"memory": "1Gi"}},
+		}},
+	}
+
+	err := k.UpdateNamespaceQuotas("tenant", quotas)
+	require.NoError(t, err)
+
+	got, err := k.GetNamespaceQuotas("tenant")
+	require.NoError(t, err)
+	require.NotNil(t, got.ResourceQuota)
+	assert.Equal(t, map[string]string{"limits.cpu": "2", "pods": "10"}, got.ResourceQuota.Hard)
+	require.NotNil(t, got.LimitRange)
+	assert.Equal(t, quotas.LimitRange.Limits, got.LimitRange.Limits)
+
+	t.Run("updates the existing objects", func(t *testing.T) {
+		quotas.ResourceQuota.Hard = map[string]string{"limits.memory": "4Gi"}
+
+		err := k.UpdateNamespaceQuotas("tenant", quotas)
+		require.NoError(t, err)
+
+		got, err := k.GetNamespaceQuotas("tenant")
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{"limits.memory": "4Gi"}, got.ResourceQuota.Hard)
+	})
+
+	t.Run("removes the objects which are not set", func(t *testing.T) {
+		err := k.UpdateNamespaceQuotas("tenant", models.K8sNamespaceQuotas{})
+		require.NoError(t, err)
+
+		got, err := k.GetNamespaceQuotas("tenant")
+		require.NoError(t, err)
+		assert.Nil(t, got.ResourceQuota)
+		assert.Nil(t, got.LimitRange)
+	})
+
+	t.Run("fails on a missing namespace", func(t *testing.T) {
+		err := k.UpdateNamespaceQuotas("missing", quotas)
+		assert.True(t, k8serrors.IsNotFound(err))
+	})
+}
+
+func Test_GetNamespaceQuotaWarnings(t *testing.T) {
+	otherQuota := &corev1.ResourceQuota{
+		ObjectMeta: metav1.ObjectMeta{Name: "portainer-rq-other", Namespace: "other"},
+		Spec: corev1.ResourceQuotaSpec{Hard: corev1.ResourceList{
+			corev1.ResourceLimitsCPU:      resource.MustParse("3"),
+			corev1.ResourceRequestsMemory: resource.MustParse("2Gi"),
+		}},
+	}
+
+	k := &KubeClient{
+		cli:        kfake.NewSimpleClientset(otherQuota),
+		instanceID: "instance",
+	}
+
+	endpoint := &portainer.Endpoint{}
+	endpoint.Kubernetes.Snapshots = []portainer.KubernetesSnapshot{{TotalCPU: 4, TotalMemory: 8 * 1024 * 1024 * 1024}}
+
+	testCases := []struct {
+		name       string
+		hard       map[string]string
+		overCommit bool
+		reserved   int
+		warnings   int
+	}{
+		{name: "within the capacity", hard: map[string]string{"limits.cpu": "1", "limits.memory": "2Gi"}, overCommit: true},
+		{name: "over the capacity with the other namespaces", hard: map[string]string{"limits.cpu": "2"}, overCommit: true, warnings: 1},
+		{name: "over the capacity alone", hard: map[string]string{"requests.cpu": "5", "memory": "10Gi"}, overCommit: true, warnings: 2},
+		{name: "over the capacity left by the system reserve", hard: map[string]string{"limits.cpu": "1"}, reserved: 20, warnings: 1},
+		{name: "without CPU or memory", hard: map[string]string{"pods": "100"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			endpoint.Kubernetes.Configuration.EnableResourceOverCommit = tc.overCommit
+			endpoint.Kubernetes.Configuration.ResourceOverCommitPercentage = tc.reserved
+
+			warnings, err := k.GetNamespaceQuotaWarnings("tenant", tc.hard, endpoint)
+			require.NoError(t, err)
+			assert.Len(t, warnings, tc.warnings, warnings)
+		})
+	}
+
+	t.Run("without snapshot", func(t *testing.T) {
+		warnings, err := k.GetNamespaceQuotaWarnings("tenant", map[string]string{"limits.cpu": "100"}, &portainer.Endpoint{})
+		require.NoError(t, err)
+		assert.Empty(t, warnings)
+	})
+}
@@ -7,6 +7,7 @@ import (
 
 	portainer "github.com/portainer/portainer/api"
 	"github.com/portainer/portainer/api/internal/registryutils"
+	"github.com/portainer/portainer/api/kubernetes/privateregistries"
 
 	"github.com/pkg/errors"
 	"github.com/segmentio/encoding/json"
@@ -101,5 +102,66 @@ func (cli *KubeClient) IsRegistrySecret(namespace, secretName string) (bool, err
 }
 
 func (*KubeClient) RegistrySecretName(registryID portainer.RegistryID) string {
-	return fmt.Sprintf("registry-%d", registryID)
+	return privateregistries.SecretName(registryID)
+}
+
+// RegistrySecretsSync reports the registry secrets updated and removed by a synchronization, by namespace
+type RegistrySecretsSync struct {
+	Updated map[string][]string
+	Removed map[string][]string
+	Errors  []string
+}
+
+// SyncRegistrySecrets recreates the registry secrets of the namespaces the registries are bound to on an environment,
+// and removes the registry secrets of Portainer from the namespaces they are not bound to anymore
+func (kcl *KubeClient) SyncRegistrySecrets(endpointID portainer.EndpointID, registries []portainer.Registry) (*RegistrySecretsSync, error) {
+	secrets, err := kcl.cli.CoreV1().Secrets(metav1.NamespaceAll).List(context.TODO(), metav1.ListOptions{LabelSelector: labelRegistryType})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed listing the registry secrets")
+	}
+
+	bound := make(map[string]bool)
+	for _, registry := range registries {
+		for _, namespace := range registry.RegistryAccesses[endpointID].Namespaces {
+			bound[namespace+"/"+kcl.RegistrySecretName(registry.ID)] = true
+		}
+	}
+
+	report := &RegistrySecretsSync{Updated: map[string][]string{}, Removed: map[string][]string{}}
+
+	for _, secret := range secrets.Items {
+		if _, ok := secret.Annotations[annotationRegistryID]; !ok || bound[secret.Namespace+"/"+secret.Name] {
+			continue
+		}
+
+		if err := kcl.cli.CoreV1().Secrets(secret.Namespace).Delete(context.TODO(), secret.Name, metav1.DeleteOptions{}); err != nil && !k8serrors.IsNotFound(err) {
+			report.Errors = append(report.Errors, fmt.Sprintf("unable to remove the secret %s of the namespace %s: %s", secret.Name, secret.Namespace, err))
+
+			continue
+		}
+
+		report.Removed[secret.Namespace] = append(report.Removed[secret.Namespace], secret.Name)
+	}
+
+	for i := range registries {
+		registry := &registries[i]
+
+		for _, namespace := range registry.RegistryAccesses[endpointID].Namespaces {
+			if err := kcl.DeleteRegistrySecret(registry.ID, namespace); err != nil {
+				report.Errors = append(report.Errors, fmt.Sprintf("unable to update the secret of the registry %s in the namespace %s: %s", registry.Name, namespace, err))
+
+				continue
+			}
+
+			if err := kcl.CreateRegistrySecret(registry, namespace); err != nil {
+				report.Errors = append(report.Errors, fmt.Sprintf("unable to update the secret of the registry %s in the namespace %s: %s", registry.Name, namespace, err))
+
+				continue
+			}
+
+			report.Updated[namespace] = append(report.Updated[namespace], kcl.RegistrySecretName(registry.ID))
+		}
+	}
+
+	return report, nil
 }
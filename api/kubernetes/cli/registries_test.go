@@ -0,0 +1,48 @@
+package cli
+
+import (
+	"context"
+	"testing"
+
+	portainer "github.com/portainer/portainer/api"
+
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kfake "k8s.io/client-go/kubernetes/fake"
+)
+
+func TestSyncRegistrySecrets(t *testing.T) {
+	is := require.New(t)
+
+	kcl := &KubeClient{cli: kfake.NewSimpleClientset(), instanceID: "test"}
+
+	registries := []portainer.Registry{
+		{ID: 1, Name: "harbor", URL: "harbor.example.com", Authentication: true, Username: "robot", Password: "secret", RegistryAccesses: portainer.RegistryAccesses{
+			1: {Namespaces: []string{"dev", "prod"}},
+			2: {Namespaces: []string{"other"}},
+		}},
+		{ID: 2, Name: "quay", URL: "quay.io"},
+	}
+
+	// the dev secret of the first registry is stale, the second registry is not bound to test anymore
+	is.NoError(kcl.CreateRegistrySecret(&portainer.Registry{ID: 1, URL: "harbor.example.com", Authentication: true, Username: "robot", Password: "old"}, "dev"))
+	is.NoError(kcl.CreateRegistrySecret(&registries[1], "test"))
+
+	_, err := kcl.cli.CoreV1().Secrets("test").Create(context.Background(), &v1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "registry-9"}}, metav1.CreateOptions{})
+	is.NoError(err)
+
+	report, err := kcl.SyncRegistrySecrets(1, registries)
+	is.NoError(err)
+	is.Empty(report.Errors)
+	is.Equal(map[string][]string{"dev": {"registry-1"}, "prod": {"registry-1"}}, report.Updated)
+	is.Equal(map[string][]string{"test": {"registry-2"}}, report.Removed)
+
+	secret, err := kcl.cli.CoreV1().Secrets("dev").Get(context.Background(), "registry-1", metav1.GetOptions{})
+	is.NoError(err)
+	is.Contains(string(secret.Data[secretDockerConfigKey]), `"password":"secret"`)
+
+	// the secrets not created by Portainer are kept
+	_, err = kcl.cli.CoreV1().Secrets("test").Get(context.Background(), "registry-9", metav1.GetOptions{})
+	is.NoError(err)
+}
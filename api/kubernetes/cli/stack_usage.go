@@ -0,0 +1,158 @@
+package cli
+
+import (
+	"context"
+	"slices"
+	"strconv"
+	"strings"
+
+	portainer "github.com/portainer/portainer/api"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	metricsapi "k8s.io/metrics/pkg/apis/metrics/v1beta1"
+	metricsv "k8s.io/metrics/pkg/client/clientset/versioned"
+)
+
+// stackWorkload is a workload deployed by a Kubernetes stack
+type stackWorkload struct {
+	name      string
+	namespace string
+	selector  *metav1.LabelSelector
+}
+
+// GetStackUsage aggregates the CPU and memory usage reported by the metrics server and the restarts of the containers
+// of the pods of the deployments, statefulsets and daemonsets of a Kubernetes stack, in total and per workload. The
+// workloads are found by the stack identifier label set on them when the stack is deployed
+func (kcl *KubeClient) GetStackUsage(ctx context.Context, metricsClient metricsv.Interface, stack *portainer.Stack) (*portainer.StackUsage, error) {
+	workloads, err := kcl.stackWorkloads(ctx, stack.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	stackUsage := &portainer.StackUsage{Services: []portainer.StackServiceUsage{}}
+	podMetrics := make(map[string]map[string]metricsapi.PodMetrics)
+	seen := make(map[types.UID]bool)
+
+	for _, workload := range workloads {
+		selector, err := metav1.LabelSelectorAsSelector(workload.selector)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid selector of the workload %s", workload.name)
+		}
+
+		pods, err := kcl.cli.CoreV1().Pods(workload.namespace).List(ctx, metav1.ListOptions{LabelSelector: selector.String()})
+		if err != nil {
+			return nil, errors.Wrapf(err, "unable to list the pods of the workload %s", workload.name)
+		}
+
+		metrics, ok := podMetrics[workload.namespace]
+		if !ok {
+			metrics, err = listPodMetrics(ctx, metricsClient, workload.namespace)
+			if err != nil {
+				return nil, err
+			}
+
+			podMetrics[workload.namespace] = metrics
+		}
+
+		service := portainer.StackServiceUsage{Name: workload.name}
+		for _, pod := range pods.Items {
+			// the selectors of two workloads can match the same pod
+			if seen[pod.UID] {
+				continue
+			}
+			seen[pod.UID] = true
+
+			service.Add(podUsage(pod, metrics[pod.Name]))
+		}
+
+		stackUsage.Add(service.StackResourceUsage)
+		stackUsage.Services = append(stackUsage.Services, service)
+	}
+
+	slices.SortFunc(stackUsage.Services, func(a, b portainer.StackServiceUsage) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+
+	return stackUsage, nil
+}
+
+// stackWorkloads returns the deployments, statefulsets and daemonsets of a stack in all the namespaces
+func (kcl *KubeClient) stackWorkloads(ctx context.Context, stackID portainer.StackID) ([]stackWorkload, error) {
+	listOptions := metav1.ListOptions{
+		LabelSelector: labels.SelectorFromSet(labels.Set{"io.portainer.kubernetes.application.stackid": strconv.Itoa(int(stackID))}).String(),
+	}
+
+	var workloads []stackWorkload
+
+	deployments, err := kcl.cli.AppsV1().Deployments(metav1.NamespaceAll).List(ctx, listOptions)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to list the deployments of the stack")
+	}
+
+	for _, deployment := range deployments.Items {
+		workloads = append(workloads, stackWorkload{name: deployment.Name, namespace: deployment.Namespace, selector: deployment.Spec.Selector})
+	}
+
+	statefulSets, err := kcl.cli.AppsV1().StatefulSets(metav1.NamespaceAll).List(ctx, listOptions)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to list the statefulsets of the stack")
+	}
+
+	for _, statefulSet := range statefulSets.Items {
+		workloads = append(workloads, stackWorkload{name: statefulSet.Name, namespace: statefulSet.Namespace, selector: statefulSet.Spec.Selector})
+	}
+
+	daemonSets, err := kcl.cli.AppsV1().DaemonSets(metav1.NamespaceAll).List(ctx, listOptions)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to list the daemonsets of the stack")
+	}
+
+	for _, daemonSet := range daemonSets.Items {
+		workloads = append(workloads, stackWorkload{name: daemonSet.Name, namespace: daemonSet.Namespace, selector: daemonSet.Spec.Selector})
+	}
+
+	return workloads, nil
+}
+
+// listPodMetrics returns the metrics of the pods of a namespace by pod name
+func listPodMetrics(ctx context.Context, metricsClient metricsv.Interface, namespace string) (map[string]metricsapi.PodMetrics, error) {
+	list, err := metricsClient.MetricsV1beta1().PodMetricses(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to retrieve the pod metrics, make sure the metrics server is installed")
+	}
+
+	metrics := make(map[string]metricsapi.PodMetrics, len(list.Items))
+	for _, podMetrics := range list.Items {
+		metrics[podMetrics.Name] = podMetrics
+	}
+
+	return metrics, nil
+}
+
+// podUsage returns the usage of the containers of a pod, the pods without metrics being the ones that are not running
+func podUsage(pod corev1.Pod, metrics metricsapi.PodMetrics) portainer.StackResourceUsage {
+	usage := portainer.StackResourceUsage{Containers: len(pod.Spec.Containers)}
+
+	for _, container := range pod.Spec.Containers {
+		usage.MemoryLimit += uint64(container.Resources.Limits.Memory().Value())
+	}
+
+	for _, status := range pod.Status.ContainerStatuses {
+		if status.State.Running != nil {
+			usage.Running++
+		}
+
+		usage.Restarts += int(status.RestartCount)
+	}
+
+	for _, container := range metrics.Containers {
+		usage.CPUPercent += float64(container.Usage.Cpu().MilliValue()) / 10
+		usage.MemoryUsage += uint64(container.Usage.Memory().Value())
+	}
+
+	return usage
+}
@@ -0,0 +1,101 @@
+package cli
+
+import (
+	"context"
+	"testing"
+
+	portainer "github.com/portainer/portainer/api"
+
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	kfake "k8s.io/client-go/kubernetes/fake"
+	metricsapi "k8s.io/metrics/pkg/apis/metrics/v1beta1"
+	metricsfake "k8s.io/metrics/pkg/client/clientset/versioned/fake"
+)
+
+func stackPod(name, app string, restarts int32) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "shop", UID: types.UID(name), Labels: map[string]string{"app": app}},
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{{
+				Name:      "main",
+				Resources: corev1.ResourceRequirements{Limits: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("1Ki")}},
+			}},
+		},
+		Status: corev1.PodStatus{
+			ContainerStatuses: []corev1.ContainerStatus{{
+				State:        corev1.ContainerState{Running: &corev1.ContainerStateRunning{}},
+				RestartCount: restarts,
+			}},
+		},
+	}
+}
+
+func stackPodMetrics(name, cpu, memory string) *metricsapi.PodMetrics {
+	return &metricsapi.PodMetrics{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "shop"},
+		Containers: []metricsapi.ContainerMetrics{{
+			Name:  "main",
+			Usage: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse(cpu), corev1.ResourceMemory: resource.MustParse(memory)},
+		}},
+	}
+}
+
+func TestGetStackUsage(t *testing.T) {
+	is := require.New(t)
+
+	stackLabels := map[string]string{"io.portainer.kubernetes.application.stackid": "1"}
+
+	kcl := &KubeClient{
+		cli: kfake.NewSimpleClientset(
+			&appsv1.Deployment{
+				ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "shop", Labels: stackLabels},
+				Spec:       appsv1.DeploymentSpec{Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "web"}}},
+			},
+			&appsv1.StatefulSet{
+				ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: "shop", Labels: stackLabels},
+				Spec:       appsv1.StatefulSetSpec{Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "db"}}},
+			},
+			&appsv1.Deployment{
+				ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: "shop", Labels: map[string]string{"io.portainer.kubernetes.application.stackid": "2"}},
+				Spec:       appsv1.DeploymentSpec{Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "other"}}},
+			},
+			stackPod("web-1", "web", 0),
+			stackPod("web-2", "web", 1),
+			stackPod("db-0", "db", 2),
+			stackPod("other-1", "other", 5),
+		),
+		instanceID: "test",
+	}
+
+	// the fake metrics client serves the pod metrics as the pods resource of the metrics API
+	metricsClient := metricsfake.NewSimpleClientset()
+	for _, podMetrics := range []*metricsapi.PodMetrics{
+		stackPodMetrics("web-1", "100m", "1Ki"),
+		stackPodMetrics("web-2", "50m", "2Ki"),
+		stackPodMetrics("other-1", "1", "1Mi"),
+	} {
+		is.NoError(metricsClient.Tracker().Create(metricsapi.SchemeGroupVersion.WithResource("pods"), podMetrics, "shop"))
+	}
+
+	usage, err := kcl.GetStackUsage(context.Background(), metricsClient, &portainer.Stack{ID: 1, Name: "shop", Type: portainer.KubernetesStack})
+	is.NoError(err)
+
+	is.Equal(3, usage.Containers)
+	is.Equal(3, usage.Running)
+	is.InDelta(15, usage.CPUPercent, 0.001)
+	is.Equal(uint64(3*1024), usage.MemoryUsage)
+	is.Equal(uint64(3*1024), usage.MemoryLimit)
+	is.Equal(3, usage.Restarts)
+
+	is.Len(usage.Services, 2)
+	is.Equal("db", usage.Services[0].Name)
+	is.Equal(2, usage.Services[0].Restarts)
+	is.Zero(usage.Services[0].CPUPercent)
+	is.Equal("web", usage.Services[1].Name)
+	is.Equal(2, usage.Services[1].Containers)
+}
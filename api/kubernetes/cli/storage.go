@@ -2,9 +2,33 @@ package cli
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
 
 	portainer "github.com/portainer/portainer/api"
+	models "github.com/portainer/portainer/api/http/models/kubernetes"
+
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/retry"
+)
+
+const (
+	defaultStorageClassAnnotation     = "storageclass.kubernetes.io/is-default-class"
+	defaultStorageClassBetaAnnotation = "storageclass.beta.kubernetes.io/is-default-class"
+)
+
+var (
+	// ErrVolumeExpansionNotAllowed is returned when the storage class of a volume does not allow its expansion
+	ErrVolumeExpansionNotAllowed = errors.New("the storage class of the volume does not allow volume expansion")
+	// ErrVolumeSizeNotIncreased is returned when the new size of a volume is not greater than its current size, as the
+	// volumes cannot be shrunk
+	ErrVolumeSizeNotIncreased = errors.New("the new size of the volume must be greater than its current size")
 )
 
 func (kcl *KubeClient) GetStorage() ([]portainer.KubernetesStorageClassConfig, error) {
@@ -35,3 +59,223 @@ func (kcl *KubeClient) GetStorage() ([]portainer.KubernetesStorageClassConfig, e
 
 	return storages, nil
 }
+
+// GetStorageClasses returns the storage classes of the cluster with the number of PersistentVolumes provisioned with
+// each of them, sorted by name
+func (kcl *KubeClient) GetStorageClasses() ([]models.K8sStorageClassInfo, error) {
+	storageClasses, err := kcl.cli.StorageV1().StorageClasses().List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	persistentVolumes, err := kcl.cli.CoreV1().PersistentVolumes().List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	volumeCounts := map[string]int{}
+	for _, persistentVolume := range persistentVolumes.Items {
+		volumeCounts[persistentVolume.Spec.StorageClassName]++
+	}
+
+	results := make([]models.K8sStorageClassInfo, 0, len(storageClasses.Items))
+	for _, storageClass := range storageClasses.Items {
+		// the API server defaults them, they are only missing from the objects of the fake clients
+		reclaimPolicy := corev1.PersistentVolumeReclaimDelete
+		if storageClass.ReclaimPolicy != nil {
+			reclaimPolicy = *storageClass.ReclaimPolicy
+		}
+
+		volumeBindingMode := storagev1.VolumeBindingImmediate
+		if storageClass.VolumeBindingMode != nil {
+			volumeBindingMode = *storageClass.VolumeBindingMode
+		}
+
+		results = append(results, models.K8sStorageClassInfo{
+			Name:                 storageClass.Name,
+			Provisioner:          storageClass.Provisioner,
+			Parameters:           storageClass.Parameters,
+			ReclaimPolicy:        reclaimPolicy,
+			VolumeBindingMode:    volumeBindingMode,
+			AllowVolumeExpansion: storageClass.AllowVolumeExpansion != nil && *storageClass.AllowVolumeExpansion,
+			IsDefault:            isDefaultStorageClass(storageClass),
+			CreationDate:         storageClass.CreationTimestamp.Time,
+			PersistentVolumes:    volumeCounts[storageClass.Name],
+		})
+	}
+
+	slices.SortFunc(results, func(a, b models.K8sStorageClassInfo) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+
+	return results, nil
+}
+
+// GetPersistentVolumes returns the PersistentVolumes of the cluster with their binding status, sorted by name
+func (kcl *KubeClient) GetPersistentVolumes() ([]models.K8sPersistentVolumeInfo, error) {
+	persistentVolumes, err := kcl.cli.CoreV1().PersistentVolumes().List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]models.K8sPersistentVolumeInfo, 0, len(persistentVolumes.Items))
+	for _, persistentVolume := range persistentVolumes.Items {
+		capacity := persistentVolume.Spec.Capacity[corev1.ResourceStorage]
+
+		info := models.K8sPersistentVolumeInfo{
+			Name:          persistentVolume.Name,
+			Capacity:      capacity.Value(),
+			AccessModes:   persistentVolume.Spec.AccessModes,
+			ReclaimPolicy: persistentVolume.Spec.PersistentVolumeReclaimPolicy,
+			StorageClass:  persistentVolume.Spec.StorageClassName,
+			Phase:         persistentVolume.Status.Phase,
+			CreationDate:  persistentVolume.CreationTimestamp.Time,
+		}
+
+		if claim := persistentVolume.Spec.ClaimRef; claim != nil {
+			info.Claim = claim.Namespace + "/" + claim.Name
+		}
+
+		results = append(results, info)
+	}
+
+	slices.SortFunc(results, func(a, b models.K8sPersistentVolumeInfo) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+
+	return results, nil
+}
+
+// ExpandVolume increases the storage requested by a PersistentVolumeClaim, which the storage provisioner then
+// applies to its volume. The storage class of the claim must allow volume expansion
+func (kcl *KubeClient) ExpandVolume(namespace, name string, size resource.Quantity) error {
+	if !kcl.IsKubeAdmin && !slices.Contains(kcl.NonAdminNamespaces, namespace) {
+		return k8serrors.NewForbidden(corev1.Resource("persistentvolumeclaims"), name, errors.New("the namespace is not accessible"))
+	}
+
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		claim, err := kcl.cli.CoreV1().PersistentVolumeClaims(namespace).Get(context.TODO(), name, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+
+		if claim.Spec.StorageClassName == nil || *claim.Spec.StorageClassName == "" {
+			return ErrVolumeExpansionNotAllowed
+		}
+
+		storageClass, err := kcl.cli.StorageV1().StorageClasses().Get(context.TODO(), *claim.Spec.StorageClassName, metav1.GetOptions{})
+		if k8serrors.IsNotFound(err) {
+			return ErrVolumeExpansionNotAllowed
+		} else if err != nil {
+			return err
+		}
+
+		if storageClass.AllowVolumeExpansion == nil || !*storageClass.AllowVolumeExpansion {
+			return ErrVolumeExpansionNotAllowed
+		}
+
+		if current := claim.Spec.Resources.Requests[corev1.ResourceStorage]; size.Cmp(current) <= 0 {
+			return fmt.Errorf("%w: %s", ErrVolumeSizeNotIncreased, current.String())
+		}
+
+		if claim.Spec.Resources.Requests == nil {
+			claim.Spec.Resources.Requests = corev1.ResourceList{}
+		}
+		claim.Spec.Resources.Requests[corev1.ResourceStorage] = size
+
+		_, err = kcl.cli.CoreV1().PersistentVolumeClaims(namespace).Update(context.TODO(), claim, metav1.UpdateOptions{})
+
+		return err
+	})
+}
+
+// GetOrphanedVolumes returns the PersistentVolumes which are released or not claimed, and the PersistentVolumeClaims
+// which lost their volume or are not mounted by any pod. They hold storage that is likely not needed anymore
+func (kcl *KubeClient) GetOrphanedVolumes() ([]models.K8sOrphanedVolume, error) {
+	persistentVolumes, err := kcl.cli.CoreV1().PersistentVolumes().List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	claims, err := kcl.cli.CoreV1().PersistentVolumeClaims(metav1.NamespaceAll).List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	pods, err := kcl.cli.CoreV1().Pods(metav1.NamespaceAll).List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	mountedClaims := map[string]bool{}
+	for _, pod := range pods.Items {
+		for _, volume := range pod.Spec.Volumes {
+			switch {
+			case volume.PersistentVolumeClaim != nil:
+				mountedClaims[pod.Namespace+"/"+volume.PersistentVolumeClaim.ClaimName] = true
+			// the claims of the generic ephemeral volumes are named after the pod and the volume
+			case volume.Ephemeral != nil:
+				mountedClaims[pod.Namespace+"/"+pod.Name+"-"+volume.Name] = true
+			}
+		}
+	}
+
+	orphans := []models.K8sOrphanedVolume{}
+
+	for _, persistentVolume := range persistentVolumes.Items {
+		reason := ""
+		switch persistentVolume.Status.Phase {
+		case corev1.VolumeReleased:
+			reason = models.K8sOrphanedVolumeReleased
+		case corev1.VolumeAvailable:
+			reason = models.K8sOrphanedVolumeUnclaimed
+		default:
+			continue
+		}
+
+		capacity := persistentVolume.Spec.Capacity[corev1.ResourceStorage]
+		orphans = append(orphans, models.K8sOrphanedVolume{
+			Kind:         "PersistentVolume",
+			Name:         persistentVolume.Name,
+			Reason:       reason,
+			StorageClass: persistentVolume.Spec.StorageClassName,
+			Capacity:     capacity.Value(),
+			CreationDate: persistentVolume.CreationTimestamp.Time,
+		})
+	}
+
+	for _, claim := range claims.Items {
+		reason := ""
+		switch {
+		case claim.Status.Phase == corev1.ClaimLost:
+			reason = models.K8sOrphanedVolumeLost
+		case claim.Status.Phase == corev1.ClaimBound && !mountedClaims[claim.Namespace+"/"+claim.Name]:
+			reason = models.K8sOrphanedVolumeUnused
+		default:
+			continue
+		}
+
+		storageClass := ""
+		if claim.Spec.StorageClassName != nil {
+			storageClass = *claim.Spec.StorageClassName
+		}
+
+		capacity := claim.Status.Capacity[corev1.ResourceStorage]
+		orphans = append(orphans, models.K8sOrphanedVolume{
+			Kind:         "PersistentVolumeClaim",
+			Name:         claim.Name,
+			Namespace:    claim.Namespace,
+			Reason:       reason,
+			StorageClass: storageClass,
+			Capacity:     capacity.Value(),
+			CreationDate: claim.CreationTimestamp.Time,
+		})
+	}
+
+	return orphans, nil
+}
+
+func isDefaultStorageClass(storageClass storagev1.StorageClass) bool {
+	return storageClass.Annotations[defaultStorageClassAnnotation] == "true" ||
+		storageClass.Annotations[defaultStorageClassBetaAnnotation] == "true"
+}
@@ -0,0 +1,173 @@
+package cli
+
+import (
+	"context"
+	"testing"
+
+	models "github.com/portainer/portainer/api/http/models/kubernetes"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	kfake "k8s.io/client-go/kubernetes/fake"
+)
+
+func newTestStorageClass(name string, allowVolumeExpansion bool) *storagev1.StorageClass {
+	return &storagev1.StorageClass{
+		ObjectMeta:           metav1.ObjectMeta{Name: name},
+		Provisioner:          "ebs.csi.aws.com",
+		AllowVolumeExpansion: &allowVolumeExpansion,
+	}
+}
+
+func newTestPersistentVolume(name string, phase corev1.PersistentVolumePhase, claim string) *corev1.PersistentVolume {
+	persistentVolume := &corev1.PersistentVolume{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Spec: corev1.PersistentVolumeSpec{
+			Capacity:         corev1.ResourceList{corev1.ResourceStorage: resource.MustParse("1Gi")},
+			StorageClassName: "standard",
+		},
+		Status: corev1.PersistentVolumeStatus{Phase: phase},
+	}
+
+	if claim != "" {
+		persistentVolume.Spec.ClaimRef = &corev1.ObjectReference{Namespace: "default", Name: claim}
+	}
+
+	return persistentVolume
+}
+
+func newTestClaim(name, storageClass string, phase corev1.PersistentVolumeClaimPhase) *corev1.PersistentVolumeClaim {
+	return &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+		Spec: corev1.PersistentVolumeClaimSpec{
+			StorageClassName: &storageClass,
+			Resources: corev1.VolumeResourceRequirements{
+				Requests: corev1.ResourceList{corev1.ResourceStorage: resource.MustParse("1Gi")},
+			},
+		},
+		Status: corev1.PersistentVolumeClaimStatus{Phase: phase},
+	}
+}
+
+func Test_GetStorageClasses(t *testing.T) {
+	standard := newTestStorageClass("standard", true)
+	standard.Annotations = map[string]string{defaultStorageClassAnnotation: "true"}
+
+	k := &KubeClient{
+		cli: kfake.NewSimpleClientset(
+			standard,
+			newTestStorageClass("archive", false),
+			newTestPersistentVolume("pv-1", corev1.VolumeBound, "data"),
+			newTestPersistentVolume("pv-2", corev1.VolumeAvailable, ""),
+		),
+		instanceID: "instance",
+	}
+
+	storageClasses, err := k.GetStorageClasses()
+	require.NoError(t, err)
+
+	require.Len(t, storageClasses, 2)
+	assert.Equal(t, "archive", storageClasses[0].Name)
+	assert.False(t, storageClasses[0].IsDefault)
+	assert.Equal(t, 0, storageClasses[0].PersistentVolumes)
+
+	assert.Equal(t, "standard", storageClasses[1].Name)
+	assert.True(t, storageClasses[1].IsDefault)
+	assert.True(t, storageClasses[1].AllowVolumeExpansion)
+	assert.Equal(t, corev1.PersistentVolumeReclaimDelete, storageClasses[1].ReclaimPolicy)
+	assert.Equal(t, 2, storageClasses[1].PersistentVolumes)
+}
+
+func Test_GetPersistentVolumes(t *testing.T) {
+	k := &KubeClient{
+		cli: kfake.NewSimpleClientset(
+			newTestPersistentVolume("pv-2", corev1.VolumeAvailable, ""),
+			newTestPersistentVolume("pv-1", corev1.VolumeBound, "data"),
+		),
+		instanceID: "instance",
+	}
+
+	persistentVolumes, err := k.GetPersistentVolumes()
+	require.NoError(t, err)
+
+	require.Len(t, persistentVolumes, 2)
+	assert.Equal(t, models.K8sPersistentVolumeInfo{
+		Name:         "pv-1",
+		Capacity:     1 << 30,
+		StorageClass: "standard",
+		Phase:        corev1.VolumeBound,
+		Claim:        "default/data",
+	}, persistentVolumes[0])
+	assert.Empty(t, persistentVolumes[1].Claim)
+}
+
+func Test_ExpandVolume(t *testing.T) {
+	objects := []runtime.Object{
+		newTestStorageClass("standard", true),
+		newTestStorageClass("archive", false),
+		newTestClaim("data", "standard", corev1.ClaimBound),
+		newTestClaim("backup", "archive", corev1.ClaimBound),
+	}
+
+	k := &KubeClient{cli: kfake.NewSimpleClientset(objects...), instanceID: "instance", IsKubeAdmin: true}
+
+	require.NoError(t, k.ExpandVolume("default", "data", resource.MustParse("2Gi")))
+
+	claim, err := k.cli.CoreV1().PersistentVolumeClaims("default").Get(context.Background(), "data", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "2Gi", claim.Spec.Resources.Requests.Storage().String())
+
+	require.ErrorIs(t, k.ExpandVolume("default", "data", resource.MustParse("1Gi")), ErrVolumeSizeNotIncreased)
+	require.ErrorIs(t, k.ExpandVolume("default", "backup", resource.MustParse("2Gi")), ErrVolumeExpansionNotAllowed)
+	require.True(t, k8serrors.IsNotFound(k.ExpandVolume("default", "missing", resource.MustParse("2Gi"))))
+
+	t.Run("limited to the namespaces of a non-admin user", func(t *testing.T) {
+		k := &KubeClient{cli: k.cli, instanceID: "instance", NonAdminNamespaces: []string{"tenant"}}
+
+		require.True(t, k8serrors.IsForbidden(k.ExpandVolume("default", "data", resource.MustParse("3Gi"))))
+	})
+}
+
+func Test_GetOrphanedVolumes(t *testing.T) {
+	k := &KubeClient{
+		cli: kfake.NewSimpleClientset(
+			newTestPersistentVolume("pv-bound", corev1.VolumeBound, "data"),
+			newTestPersistentVolume("pv-released", corev1.VolumeReleased, "deleted"),
+			newTestPersistentVolume("pv-available", corev1.VolumeAvailable, ""),
+			newTestClaim("data", "standard", corev1.ClaimBound),
+			newTestClaim("web-cache", "standard", corev1.ClaimBound),
+			newTestClaim("unused", "standard", corev1.ClaimBound),
+			newTestClaim("lost", "standard", corev1.ClaimLost),
+			newTestClaim("pending", "standard", corev1.ClaimPending),
+			&corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default"},
+				Spec: corev1.PodSpec{Volumes: []corev1.Volume{
+					{Name: "data", VolumeSource: corev1.VolumeSource{PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: "data"}}},
+					{Name: "cache", VolumeSource: corev1.VolumeSource{Ephemeral: &corev1.EphemeralVolumeSource{}}},
+				}},
+			},
+		),
+		instanceID: "instance",
+	}
+
+	orphans, err := k.GetOrphanedVolumes()
+	require.NoError(t, err)
+
+	reasons := map[string]string{}
+	for _, orphan := range orphans {
+		reasons[orphan.Kind+"/"+orphan.Name] = orphan.Reason
+	}
+
+	assert.Equal(t, map[string]string{
+		"PersistentVolume/pv-released":  models.K8sOrphanedVolumeReleased,
+		"PersistentVolume/pv-available": models.K8sOrphanedVolumeUnclaimed,
+		"PersistentVolumeClaim/unused":  models.K8sOrphanedVolumeUnused,
+		"PersistentVolumeClaim/lost":    models.K8sOrphanedVolumeLost,
+	}, reasons)
+}
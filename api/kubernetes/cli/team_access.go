@@ -0,0 +1,198 @@
+package cli
+
+import (
+	"context"
+	"slices"
+	"strconv"
+
+	portainer "github.com/portainer/portainer/api"
+
+	"github.com/pkg/errors"
+	rbacv1 "k8s.io/api/rbac/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+const (
+	teamIDLabel = "io.portainer.kubernetes.team.id"
+	// teamNamespaceRole is the cluster role granted to the members of a team in its namespaces, the same as the one
+	// granted to the users in the namespaces they have access to
+	teamNamespaceRole = "edit"
+)
+
+// GetNamespaceTeamAccess returns the teams having access to a namespace
+func (kcl *KubeClient) GetNamespaceTeamAccess(namespace string) ([]int, error) {
+	if _, err := kcl.cli.CoreV1().Namespaces().Get(context.TODO(), namespace, metav1.GetOptions{}); err != nil {
+		return nil, err
+	}
+
+	policies, err := kcl.GetNamespaceAccessPolicies()
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to fetch access policies")
+	}
+
+	return policyTeamIDs(policies[namespace]), nil
+}
+
+// UpdateNamespaceTeamAccess replaces the teams having access to a namespace in the namespace access policies and
+// returns the teams which had access to it before, whose RoleBindings must be synchronized as well
+func (kcl *KubeClient) UpdateNamespaceTeamAccess(namespace string, teamIDs []int) ([]int, error) {
+	if _, err := kcl.cli.CoreV1().Namespaces().Get(context.TODO(), namespace, metav1.GetOptions{}); err != nil {
+		return nil, err
+	}
+
+	kcl.mu.Lock()
+	defer kcl.mu.Unlock()
+
+	policies, err := kcl.GetNamespaceAccessPolicies()
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to fetch access policies")
+	}
+
+	if policies == nil {
+		policies = map[string]portainer.K8sNamespaceAccessPolicy{}
+	}
+
+	policy := policies[namespace]
+	previous := policyTeamIDs(policy)
+
+	policy.TeamAccessPolicies = portainer.TeamAccessPolicies{}
+	for _, teamID := range teamIDs {
+		policy.TeamAccessPolicies[portainer.TeamID(teamID)] = portainer.AccessPolicy{}
+	}
+
+	if policy.UserAccessPolicies == nil {
+		policy.UserAccessPolicies = portainer.UserAccessPolicies{}
+	}
+
+	policies[namespace] = policy
+
+	return previous, kcl.UpdateNamespaceAccessPolicies(policies)
+}
+
+func policyTeamIDs(policy portainer.K8sNamespaceAccessPolicy) []int {
+	teamIDs := make([]int, 0, len(policy.TeamAccessPolicies))
+	for teamID := range policy.TeamAccessPolicies {
+		teamIDs = append(teamIDs, int(teamID))
+	}
+
+	slices.Sort(teamIDs)
+
+	return teamIDs
+}
+
+// SyncTeamNamespaceAccess binds the service accounts of the members of a team to the edit cluster role in each
+// namespace the team has access to, and removes the RoleBinding of the team from the other namespaces. The service
+// accounts of the users are bound even if they do not exist yet, so that the cluster enforces the access of a user
+// as soon as its service account is created
+func (kcl *KubeClient) SyncTeamNamespaceAccess(teamID int, userIDs []int) error {
+	accessPolicies, err := kcl.GetNamespaceAccessPolicies()
+	if err != nil {
+		return err
+	}
+
+	namespaces, err := kcl.cli.CoreV1().Namespaces().List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return err
+	}
+
+	subjects := kcl.teamSubjects(userIDs)
+
+	for _, namespace := range namespaces.Items {
+		_, hasAccess := accessPolicies[namespace.Name].TeamAccessPolicies[portainer.TeamID(teamID)]
+		if !hasAccess || len(subjects) == 0 {
+			if err := kcl.deleteTeamRoleBinding(teamID, namespace.Name); err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		if err := kcl.ensureTeamRoleBinding(teamID, namespace.Name, subjects); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// DeleteTeamNamespaceAccess removes the RoleBindings of a team from all the namespaces
+func (kcl *KubeClient) DeleteTeamNamespaceAccess(teamID int) error {
+	selector := labels.SelectorFromSet(labels.Set{teamIDLabel: strconv.Itoa(teamID)}).String()
+
+	roleBindings, err := kcl.cli.RbacV1().RoleBindings("").List(context.TODO(), metav1.ListOptions{LabelSelector: selector})
+	if err != nil {
+		return err
+	}
+
+	for _, roleBinding := range roleBindings.Items {
+		err := kcl.cli.RbacV1().RoleBindings(roleBinding.Namespace).Delete(context.TODO(), roleBinding.Name, metav1.DeleteOptions{})
+		if err != nil && !k8serrors.IsNotFound(err) {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// teamSubjects returns the service accounts of the members of a team, sorted so that an unchanged team does not
+// update its RoleBindings
+func (kcl *KubeClient) teamSubjects(userIDs []int) []rbacv1.Subject {
+	userIDs = slices.Clone(userIDs)
+	slices.Sort(userIDs)
+	userIDs = slices.Compact(userIDs)
+
+	subjects := make([]rbacv1.Subject, 0, len(userIDs))
+	for _, userID := range userIDs {
+		subjects = append(subjects, rbacv1.Subject{
+			Kind:      "ServiceAccount",
+			Name:      UserServiceAccountName(userID, kcl.instanceID),
+			Namespace: portainerNamespace,
+		})
+	}
+
+	return subjects
+}
+
+func (kcl *KubeClient) ensureTeamRoleBinding(teamID int, namespace string, subjects []rbacv1.Subject) error {
+	roleBindingName := teamRoleBindingName(teamID, kcl.instanceID)
+
+	roleBinding, err := kcl.cli.RbacV1().RoleBindings(namespace).Get(context.TODO(), roleBindingName, metav1.GetOptions{})
+	if k8serrors.IsNotFound(err) {
+		roleBinding = &rbacv1.RoleBinding{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:   roleBindingName,
+				Labels: map[string]string{teamIDLabel: strconv.Itoa(teamID)},
+			},
+			Subjects: subjects,
+			RoleRef: rbacv1.RoleRef{
+				Kind: "ClusterRole",
+				Name: teamNamespaceRole,
+			},
+		}
+
+		_, err = kcl.cli.RbacV1().RoleBindings(namespace).Create(context.TODO(), roleBinding, metav1.CreateOptions{})
+		return err
+	} else if err != nil {
+		return err
+	}
+
+	if slices.Equal(roleBinding.Subjects, subjects) {
+		return nil
+	}
+
+	roleBinding.Subjects = subjects
+
+	_, err = kcl.cli.RbacV1().RoleBindings(namespace).Update(context.TODO(), roleBinding, metav1.UpdateOptions{})
+	return err
+}
+
+func (kcl *KubeClient) deleteTeamRoleBinding(teamID int, namespace string) error {
+	err := kcl.cli.RbacV1().RoleBindings(namespace).Delete(context.TODO(), teamRoleBindingName(teamID, kcl.instanceID), metav1.DeleteOptions{})
+	if err != nil && !k8serrors.IsNotFound(err) {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,122 @@
+package cli
+
+import (
+	"context"
+	"testing"
+
+	portainer "github.com/portainer/portainer/api"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	ktypes "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kfake "k8s.io/client-go/kubernetes/fake"
+)
+
+func newTeamAccessTestClient(t *testing.T) *KubeClient {
+	k := &KubeClient{
+		cli:        kfake.NewSimpleClientset(),
+		instanceID: "instance",
+	}
+
+	for _, name := range []string{"ns1", "ns2", portainerNamespace} {
+		_, err := k.cli.CoreV1().Namespaces().Create(context.Background(), &ktypes.Namespace{ObjectMeta: metav1.ObjectMeta{Name: name}}, metav1.CreateOptions{})
+		require.NoError(t, err)
+	}
+
+	config := &ktypes.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      portainerConfigMapName,
+			Namespace: portainerNamespace,
+		},
+		Data: map[string]string{
+			portainerConfigMapAccessPoliciesKey: `{"ns1":{"UserAccessPolicies":{"2":{"RoleId":0}}}}`,
+		},
+	}
+	_, err := k.cli.CoreV1().ConfigMaps(portainerNamespace).Create(context.Background(), config, metav1.CreateOptions{})
+	require.NoError(t, err)
+
+	return k
+}
+
+func Test_UpdateNamespaceTeamAccess(t *testing.T) {
+	k := newTeamAccessTestClient(t)
+
+	previous, err := k.UpdateNamespaceTeamAccess("ns1", []int{3, 1})
+	require.NoError(t, err)
+	assert.Empty(t, previous)
+
+	previous, err = k.UpdateNamespaceTeamAccess("ns1", []int{2})
+	require.NoError(t, err)
+	assert.Equal(t, []int{1, 3}, previous)
+
+	teamIDs, err := k.GetNamespaceTeamAccess("ns1")
+	require.NoError(t, err)
+	assert.Equal(t, []int{2}, teamIDs)
+
+	policies, err := k.GetNamespaceAccessPolicies()
+	require.NoError(t, err)
+	assert.Contains(t, policies["ns1"].UserAccessPolicies, portainer.UserID(2), "the user policies should be kept")
+
+	_, err = k.UpdateNamespaceTeamAccess("missing", []int{1})
+	assert.Error(t, err)
+}
+
+func Test_SyncTeamNamespaceAccess(t *testing.T) {
+	k := newTeamAccessTestClient(t)
+	name := teamRoleBindingName(1, k.instanceID)
+
+	_, err := k.UpdateNamespaceTeamAccess("ns1", []int{1})
+	require.NoError(t, err)
+
+	err = k.SyncTeamNamespaceAccess(1, []int{5, 4, 5})
+	require.NoError(t, err)
+
+	roleBinding, err := k.cli.RbacV1().RoleBindings("ns1").Get(context.Background(), name, metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, teamNamespaceRole, roleBinding.RoleRef.Name)
+	require.Len(t, roleBinding.Subjects, 2)
+	assert.Equal(t, UserServiceAccountName(4, k.instanceID), roleBinding.Subjects[0].Name)
+	assert.Equal(t, UserServiceAccountName(5, k.instanceID), roleBinding.Subjects[1].Name)
+
+	_, err = k.cli.RbacV1().RoleBindings("ns2").Get(context.Background(), name, metav1.GetOptions{})
+	assert.True(t, k8serrors.IsNotFound(err), "the team has no access to ns2")
+
+	t.Run("moves the binding with the access of the team", func(t *testing.T) {
+		_, err := k.UpdateNamespaceTeamAccess("ns1", nil)
+		require.NoError(t, err)
+		_, err = k.UpdateNamespaceTeamAccess("ns2", []int{1})
+		require.NoError(t, err)
+
+		err = k.SyncTeamNamespaceAccess(1, []int{4})
+		require.NoError(t, err)
+
+		_, err = k.cli.RbacV1().RoleBindings("ns1").Get(context.Background(), name, metav1.GetOptions{})
+		assert.True(t, k8serrors.IsNotFound(err))
+
+		roleBinding, err := k.cli.RbacV1().RoleBindings("ns2").Get(context.Background(), name, metav1.GetOptions{})
+		require.NoError(t, err)
+		require.Len(t, roleBinding.Subjects, 1)
+	})
+
+	t.Run("removes the binding of a team without members", func(t *testing.T) {
+		err := k.SyncTeamNamespaceAccess(1, nil)
+		require.NoError(t, err)
+
+		_, err = k.cli.RbacV1().RoleBindings("ns2").Get(context.Background(), name, metav1.GetOptions{})
+		assert.True(t, k8serrors.IsNotFound(err))
+	})
+
+	t.Run("deletes the bindings of a team", func(t *testing.T) {
+		err := k.SyncTeamNamespaceAccess(1, []int{4})
+		require.NoError(t, err)
+
+		err = k.DeleteTeamNamespaceAccess(1)
+		require.NoError(t, err)
+
+		roleBindings, err := k.cli.RbacV1().RoleBindings("").List(context.Background(), metav1.ListOptions{})
+		require.NoError(t, err)
+		assert.Empty(t, roleBindings.Items)
+	})
+}
@@ -0,0 +1,173 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+
+	models "github.com/portainer/portainer/api/http/models/kubernetes"
+
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	metricsv "k8s.io/metrics/pkg/client/clientset/versioned"
+)
+
+// ErrMetricsUnavailable is returned when the metrics API is not served by the cluster, which requires the metrics
+// server or the Prometheus adapter to be installed
+var ErrMetricsUnavailable = errors.New("the metrics API is not available, make sure the metrics server is installed")
+
+// metricsError replaces the errors returned when the metrics API is not registered, or when the service behind it is
+// not running, by ErrMetricsUnavailable
+func metricsError(err error) error {
+	if k8serrors.IsNotFound(err) || k8serrors.IsServiceUnavailable(err) || meta.IsNoMatchError(err) {
+		return ErrMetricsUnavailable
+	}
+
+	return err
+}
+
+// GetNodesUsage returns the CPU and memory used on the nodes out of their allocatable resources, sorted by node name
+func (kcl *KubeClient) GetNodesUsage(ctx context.Context, metricsClient metricsv.Interface) ([]models.K8sNodeUsage, error) {
+	nodes, err := kcl.cli.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	nodeMetrics, err := metricsClient.MetricsV1beta1().NodeMetricses().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, metricsError(err)
+	}
+
+	usages := make(map[string]models.K8sResourceUsage, len(nodeMetrics.Items))
+	for _, metrics := range nodeMetrics.Items {
+		usages[metrics.Name] = resourceUsage(metrics.Usage)
+	}
+
+	nodesUsage := make([]models.K8sNodeUsage, 0, len(nodes.Items))
+	for _, node := range nodes.Items {
+		nodesUsage = append(nodesUsage, models.K8sNodeUsage{
+			Name:           node.Name,
+			K8sUtilization: models.NewK8sUtilization(usages[node.Name], resourceUsage(node.Status.Allocatable)),
+		})
+	}
+
+	slices.SortFunc(nodesUsage, func(a, b models.K8sNodeUsage) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+
+	return nodesUsage, nil
+}
+
+// GetClusterUtilization returns the CPU and memory used on all the nodes out of their allocatable resources
+func (kcl *KubeClient) GetClusterUtilization(ctx context.Context, metricsClient metricsv.Interface) (*models.K8sUtilization, error) {
+	nodesUsage, err := kcl.GetNodesUsage(ctx, metricsClient)
+	if err != nil {
+		return nil, err
+	}
+
+	usage := models.K8sResourceUsage{}
+	allocatable := models.K8sResourceUsage{}
+	for _, node := range nodesUsage {
+		usage.Add(node.Usage)
+		allocatable.Add(node.Allocatable)
+	}
+
+	utilization := models.NewK8sUtilization(usage, allocatable)
+
+	return &utilization, nil
+}
+
+// GetPodsUsage returns the CPU and memory used by the pods of a namespace, or of all the namespaces the user has
+// access to when namespace is empty
+func (kcl *KubeClient) GetPodsUsage(ctx context.Context, metricsClient metricsv.Interface, namespace string) ([]models.K8sPodUsage, error) {
+	if !kcl.IsKubeAdmin && namespace != "" && !slices.Contains(kcl.NonAdminNamespaces, namespace) {
+		return nil, k8serrors.NewForbidden(corev1.Resource("pods"), "", errors.New("the namespace is not accessible"))
+	}
+
+	pods, err := kcl.cli.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	podMetrics, err := metricsClient.MetricsV1beta1().PodMetricses(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, metricsError(err)
+	}
+
+	usages := make(map[string]models.K8sResourceUsage, len(podMetrics.Items))
+	for _, metrics := range podMetrics.Items {
+		usage := models.K8sResourceUsage{}
+		for _, container := range metrics.Containers {
+			usage.Add(resourceUsage(container.Usage))
+		}
+
+		usages[metrics.Namespace+"/"+metrics.Name] = usage
+	}
+
+	nonAdminNamespaceSet := kcl.buildNonAdminNamespacesMap()
+
+	podsUsage := []models.K8sPodUsage{}
+	for _, pod := range pods.Items {
+		if _, ok := nonAdminNamespaceSet[pod.Namespace]; !kcl.IsKubeAdmin && !ok {
+			continue
+		}
+
+		// the pods without metrics are the ones that are not running
+		podsUsage = append(podsUsage, models.K8sPodUsage{
+			Name:      pod.Name,
+			Namespace: pod.Namespace,
+			NodeName:  pod.Spec.NodeName,
+			Usage:     usages[pod.Namespace+"/"+pod.Name],
+		})
+	}
+
+	slices.SortFunc(podsUsage, func(a, b models.K8sPodUsage) int {
+		return strings.Compare(a.Namespace+"/"+a.Name, b.Namespace+"/"+b.Name)
+	})
+
+	return podsUsage, nil
+}
+
+// GetNamespacesUsage returns the CPU and memory used by the pods of the namespaces, by namespace name
+func (kcl *KubeClient) GetNamespacesUsage(ctx context.Context, metricsClient metricsv.Interface, namespaces ...string) (map[string]models.K8sResourceUsage, error) {
+	// a single namespace is listed on its own rather than listing the metrics of the pods of the whole cluster
+	namespace := ""
+	if len(namespaces) == 1 {
+		namespace = namespaces[0]
+	}
+
+	podMetrics, err := metricsClient.MetricsV1beta1().PodMetricses(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, metricsError(err)
+	}
+
+	usages := make(map[string]models.K8sResourceUsage, len(namespaces))
+	for _, namespace := range namespaces {
+		usages[namespace] = models.K8sResourceUsage{}
+	}
+
+	for _, metrics := range podMetrics.Items {
+		usage, ok := usages[metrics.Namespace]
+		if !ok {
+			continue
+		}
+
+		for _, container := range metrics.Containers {
+			usage.Add(resourceUsage(container.Usage))
+		}
+
+		usages[metrics.Namespace] = usage
+	}
+
+	return usages, nil
+}
+
+func resourceUsage(resources corev1.ResourceList) models.K8sResourceUsage {
+	return models.K8sResourceUsage{
+		CPU:    resources.Cpu().MilliValue(),
+		Memory: resources.Memory().Value(),
+	}
+}
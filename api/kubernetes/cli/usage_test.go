@@ -0,0 +1,142 @@
+package cli
+
+import (
+	"context"
+	"testing"
+
+	models "github.com/portainer/portainer/api/http/models/kubernetes"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	kfake "k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+	metricsapi "k8s.io/metrics/pkg/apis/metrics/v1beta1"
+	metricsfake "k8s.io/metrics/pkg/client/clientset/versioned/fake"
+)
+
+func usageResources(cpu, memory string) corev1.ResourceList {
+	return corev1.ResourceList{corev1.ResourceCPU: resource.MustParse(cpu), corev1.ResourceMemory: resource.MustParse(memory)}
+}
+
+func newTestUsageClients(t *testing.T) (*KubeClient, *metricsfake.Clientset) {
+	k := &KubeClient{
+		cli: kfake.NewSimpleClientset(
+			&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "worker-2"}, Status: corev1.NodeStatus{Allocatable: usageResources("2", "2Gi")}},
+			&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "worker-1"}, Status: corev1.NodeStatus{Allocatable: usageResources("2", "2Gi")}},
+			&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "web-1", Namespace: "tenant"}, Spec: corev1.PodSpec{NodeName: "worker-1"}},
+			&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "web-2", Namespace: "tenant"}, Spec: corev1.PodSpec{NodeName: "worker-2"}},
+			&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "db-0", Namespace: "private"}, Spec: corev1.PodSpec{NodeName: "worker-1"}},
+		),
+		instanceID:  "instance",
+		IsKubeAdmin: true,
+	}
+
+	metricsClient := metricsfake.NewSimpleClientset()
+
+	for _, nodeMetrics := range []*metricsapi.NodeMetrics{
+		{ObjectMeta: metav1.ObjectMeta{Name: "worker-1"}, Usage: usageResources("500m", "1Gi")},
+		{ObjectMeta: metav1.ObjectMeta{Name: "worker-2"}, Usage: usageResources("1", "512Mi")},
+	} {
+		require.NoError(t, metricsClient.Tracker().Create(metricsapi.SchemeGroupVersion.WithResource("nodes"), nodeMetrics, ""))
+	}
+
+	for _, podMetrics := range []*metricsapi.PodMetrics{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "web-1", Namespace: "tenant"},
+			Containers: []metricsapi.ContainerMetrics{
+				{Name: "web", Usage: usageResources("100m", "100Mi")},
+				{Name: "sidecar", Usage: usageResources("10m", "10Mi")},
+			},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "db-0", Namespace: "private"},
+			Containers: []metricsapi.ContainerMetrics{{Name: "db", Usage: usageResources("200m", "1Gi")}},
+		},
+	} {
+		require.NoError(t, metricsClient.Tracker().Create(metricsapi.SchemeGroupVersion.WithResource("pods"), podMetrics, podMetrics.Namespace))
+	}
+
+	return k, metricsClient
+}
+
+func Test_GetNodesUsage(t *testing.T) {
+	k, metricsClient := newTestUsageClients(t)
+
+	nodesUsage, err := k.GetNodesUsage(context.Background(), metricsClient)
+	require.NoError(t, err)
+
+	require.Len(t, nodesUsage, 2)
+	assert.Equal(t, "worker-1", nodesUsage[0].Name)
+	assert.Equal(t, models.K8sResourceUsage{CPU: 500, Memory: 1 << 30}, nodesUsage[0].Usage)
+	assert.Equal(t, models.K8sResourceUsage{CPU: 2000, Memory: 2 << 30}, nodesUsage[0].Allocatable)
+	assert.InDelta(t, 25, nodesUsage[0].CPUPercent, 0.001)
+	assert.InDelta(t, 50, nodesUsage[0].MemoryPercent, 0.001)
+
+	utilization, err := k.GetClusterUtilization(context.Background(), metricsClient)
+	require.NoError(t, err)
+	assert.Equal(t, models.K8sResourceUsage{CPU: 1500, Memory: 1<<30 + 512<<20}, utilization.Usage)
+	assert.InDelta(t, 37.5, utilization.CPUPercent, 0.001)
+}
+
+func Test_GetPodsUsage(t *testing.T) {
+	k, metricsClient := newTestUsageClients(t)
+
+	podsUsage, err := k.GetPodsUsage(context.Background(), metricsClient, "")
+	require.NoError(t, err)
+
+	require.Len(t, podsUsage, 3)
+	assert.Equal(t, "private", podsUsage[0].Namespace)
+	assert.Equal(t, "web-1", podsUsage[1].Name)
+	assert.Equal(t, models.K8sResourceUsage{CPU: 110, Memory: 110 << 20}, podsUsage[1].Usage)
+	assert.Equal(t, "worker-1", podsUsage[1].NodeName)
+	// the pods which are not running have no metrics
+	assert.Equal(t, models.K8sResourceUsage{}, podsUsage[2].Usage)
+
+	t.Run("limited to the namespaces of a non-admin user", func(t *testing.T) {
+		k := &KubeClient{cli: k.cli, instanceID: "instance", NonAdminNamespaces: []string{"tenant"}}
+
+		podsUsage, err := k.GetPodsUsage(context.Background(), metricsClient, "")
+		require.NoError(t, err)
+		assert.Len(t, podsUsage, 2)
+
+		_, err = k.GetPodsUsage(context.Background(), metricsClient, "private")
+		require.True(t, k8serrors.IsForbidden(err))
+	})
+}
+
+func Test_GetNamespacesUsage(t *testing.T) {
+	k, metricsClient := newTestUsageClients(t)
+
+	usages, err := k.GetNamespacesUsage(context.Background(), metricsClient, "tenant", "empty")
+	require.NoError(t, err)
+
+	assert.Equal(t, map[string]models.K8sResourceUsage{
+		"tenant": {CPU: 110, Memory: 110 << 20},
+		"empty":  {},
+	}, usages)
+}
+
+func Test_MetricsUnavailable(t *testing.T) {
+	k, _ := newTestUsageClients(t)
+
+	for _, err := range []error{
+		k8serrors.NewNotFound(metricsapi.Resource("nodes"), ""),
+		k8serrors.NewServiceUnavailable("the server is currently unable to handle the request"),
+	} {
+		metricsClient := metricsfake.NewSimpleClientset()
+		metricsClient.PrependReactor("list", "*", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			return true, nil, err
+		})
+
+		_, usageErr := k.GetClusterUtilization(context.Background(), metricsClient)
+		require.ErrorIs(t, usageErr, ErrMetricsUnavailable)
+
+		_, usageErr = k.GetNamespacesUsage(context.Background(), metricsClient, "tenant")
+		require.ErrorIs(t, usageErr, ErrMetricsUnavailable)
+	}
+}
@@ -123,12 +123,14 @@ func parseVolume(persistentVolumeClaim *corev1.PersistentVolumeClaim, persistent
 // parsePersistentVolumeClaim parses the given persistent volume claim and returns a K8sPersistentVolumeClaim.
 func parsePersistentVolumeClaim(volume *corev1.PersistentVolumeClaim) models.K8sPersistentVolumeClaim {
 	storage := volume.Spec.Resources.Requests[corev1.ResourceStorage]
+	capacity := volume.Status.Capacity[corev1.ResourceStorage]
 	return models.K8sPersistentVolumeClaim{
 		ID:                 string(volume.UID),
 		Name:               volume.Name,
 		Namespace:          volume.Namespace,
 		CreationDate:       volume.CreationTimestamp.Time,
 		Storage:            storage.Value(),
+		Capacity:           capacity.Value(),
 		AccessModes:        volume.Spec.AccessModes,
 		VolumeName:         volume.Spec.VolumeName,
 		ResourcesRequests:  &volume.Spec.Resources.Requests,
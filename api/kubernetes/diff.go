@@ -0,0 +1,200 @@
+package kubernetes
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/pmezard/go-difflib/difflib"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	"sigs.k8s.io/yaml"
+)
+
+// DiffManifests returns the unified diff between the live state of resources and the state they would have once
+// applied, as returned by a server-side dry run, with the semantics of kubectl diff: every resource is compared as
+// YAML without its managed fields, the resources missing from the live state are created and the values of the
+// secrets are masked. The diff is empty when applying the resources would not change them
+func DiffManifests(live, merged []byte) (string, error) {
+	liveResources, err := decodeResources(live)
+	if err != nil {
+		return "", fmt.Errorf("unable to parse the live resources: %w", err)
+	}
+
+	mergedResources, err := decodeResources(merged)
+	if err != nil {
+		return "", fmt.Errorf("unable to parse the merged resources: %w", err)
+	}
+
+	liveByKey := make(map[string]*unstructured.Unstructured, len(liveResources))
+	for _, resource := range liveResources {
+		liveByKey[resourceKey(resource)] = resource
+	}
+
+	var diff strings.Builder
+	for _, mergedResource := range mergedResources {
+		liveResource := liveByKey[resourceKey(mergedResource)]
+
+		if mergedResource.GetKind() == "Secret" && mergedResource.GroupVersionKind().Group == "" {
+			maskSecretData(liveResource, mergedResource)
+		}
+
+		liveContent, err := resourceYAML(liveResource)
+		if err != nil {
+			return "", err
+		}
+
+		mergedContent, err := resourceYAML(mergedResource)
+		if err != nil {
+			return "", err
+		}
+
+		name := resourceFileName(mergedResource)
+
+		resourceDiff, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+			A:        splitLines(liveContent),
+			B:        splitLines(mergedContent),
+			FromFile: "live/" + name,
+			ToFile:   "merged/" + name,
+			Context:  3,
+		})
+		if err != nil {
+			return "", err
+		}
+
+		diff.WriteString(resourceDiff)
+	}
+
+	return diff.String(), nil
+}
+
+// decodeResources decodes the resources of a YAML or JSON content made of documents, the lists being expanded into
+// their items
+func decodeResources(content []byte) ([]*unstructured.Unstructured, error) {
+	decoder := utilyaml.NewYAMLOrJSONDecoder(bytes.NewReader(content), 4096)
+
+	var resources []*unstructured.Unstructured
+	for {
+		obj := &unstructured.Unstructured{}
+		if err := decoder.Decode(&obj.Object); err != nil {
+			if errors.Is(err, io.EOF) {
+				return resources, nil
+			}
+
+			return nil, err
+		}
+
+		if len(obj.Object) == 0 {
+			continue
+		}
+
+		if !obj.IsList() {
+			resources = append(resources, obj)
+			continue
+		}
+
+		if err := obj.EachListItem(func(item runtime.Object) error {
+			resources = append(resources, item.(*unstructured.Unstructured))
+			return nil
+		}); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// resourceKey identifies a resource regardless of the version it is read with
+func resourceKey(resource *unstructured.Unstructured) string {
+	gvk := resource.GroupVersionKind()
+
+	return strings.Join([]string{gvk.Group, gvk.Kind, resource.GetNamespace(), resource.GetName()}, "/")
+}
+
+// resourceFileName names a resource the way kubectl diff does, e.g. apps.v1.Deployment.default.web
+func resourceFileName(resource *unstructured.Unstructured) string {
+	gvk := resource.GroupVersionKind()
+
+	parts := []string{gvk.Version, gvk.Kind}
+	if gvk.Group != "" {
+		parts = append([]string{gvk.Group}, parts...)
+	}
+
+	if resource.GetNamespace() != "" {
+		parts = append(parts, resource.GetNamespace())
+	}
+
+	return strings.Join(append(parts, resource.GetName()), ".")
+}
+
+func resourceYAML(resource *unstructured.Unstructured) (string, error) {
+	if resource == nil {
+		return "", nil
+	}
+
+	resource.SetManagedFields(nil)
+
+	content, err := yaml.Marshal(resource.Object)
+	if err != nil {
+		return "", err
+	}
+
+	return string(content), nil
+}
+
+// maskSecretData replaces the values of a secret with *** in both of its states, the changed values being told apart
+// with a before and after suffix
+func maskSecretData(live, merged *unstructured.Unstructured) {
+	for _, field := range []string{"data", "stringData"} {
+		var liveData map[string]any
+		if live != nil {
+			liveData, _, _ = unstructured.NestedMap(live.Object, field)
+		}
+
+		mergedData, _, _ := unstructured.NestedMap(merged.Object, field)
+
+		for key, liveValue := range liveData {
+			mergedValue, ok := mergedData[key]
+			switch {
+			case !ok:
+				liveData[key] = "***"
+			case liveValue == mergedValue:
+				liveData[key], mergedData[key] = "***", "***"
+			default:
+				liveData[key], mergedData[key] = "*** (before)", "*** (after)"
+			}
+		}
+
+		for key := range mergedData {
+			if _, ok := liveData[key]; !ok {
+				mergedData[key] = "***"
+			}
+		}
+
+		if liveData != nil {
+			_ = unstructured.SetNestedMap(live.Object, liveData, field)
+		}
+
+		if mergedData != nil {
+			_ = unstructured.SetNestedMap(merged.Object, mergedData, field)
+		}
+	}
+}
+
+// splitLines splits a content into lines ending with a line break, the last line is terminated so that it stays on
+// its own in the diff
+func splitLines(content string) []string {
+	if content == "" {
+		return nil
+	}
+
+	lines := strings.SplitAfter(content, "\n")
+	if last := lines[len(lines)-1]; last == "" {
+		lines = lines[:len(lines)-1]
+	} else {
+		lines[len(lines)-1] = last + "\n"
+	}
+
+	return lines
+}
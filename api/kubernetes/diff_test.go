@@ -0,0 +1,107 @@
+package kubernetes
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiffManifests(t *testing.T) {
+	live := `
+apiVersion: v1
+kind: List
+items:
+- apiVersion: apps/v1
+  kind: Deployment
+  metadata:
+    name: web
+    namespace: default
+    managedFields:
+    - manager: kubectl
+  spec:
+    replicas: 1
+- apiVersion: v1
+  kind: Secret
+  metadata:
+    name: credentials
+    namespace: default
+  data:
+    password: b2xk
+    username: YWRtaW4=
+`
+
+	merged := `
+apiVersion: v1
+kind: List
+items:
+- apiVersion: apps/v1
+  kind: Deployment
+  metadata:
+    name: web
+    namespace: default
+    managedFields:
+    - manager: kubectl
+  spec:
+    replicas: 3
+- apiVersion: v1
+  kind: Secret
+  metadata:
+    name: credentials
+    namespace: default
+  data:
+    password: bmV3
+    username: YWRtaW4=
+- apiVersion: v1
+  kind: Service
+  metadata:
+    name: web
+    namespace: default
+  spec:
+    type: ClusterIP
+`
+
+	diff, err := DiffManifests([]byte(live), []byte(merged))
+	require.NoError(t, err)
+
+	assert.Equal(t, `--- live/apps.v1.Deployment.default.web
++++ merged/apps.v1.Deployment.default.web
+@@ -4,4 +4,4 @@
+   name: web
+   namespace: default
+ spec:
+-  replicas: 1
++  replicas: 3
+--- live/v1.Secret.default.credentials
++++ merged/v1.Secret.default.credentials
+@@ -1,6 +1,6 @@
+ apiVersion: v1
+ data:
+-  password: '*** (before)'
++  password: '*** (after)'
+   username: '***'
+ kind: Secret
+ metadata:
+--- live/v1.Service.default.web
++++ merged/v1.Service.default.web
+@@ -0,0 +1,7 @@
++apiVersion: v1
++kind: Service
++metadata:
++  name: web
++  namespace: default
++spec:
++  type: ClusterIP
+`, diff)
+
+	t.Run("unchanged resources", func(t *testing.T) {
+		diff, err := DiffManifests([]byte(live), []byte(live))
+		require.NoError(t, err)
+		assert.Empty(t, diff)
+	})
+
+	t.Run("invalid resources", func(t *testing.T) {
+		_, err := DiffManifests([]byte("kind: ["), []byte(merged))
+		require.Error(t, err)
+	})
+}
@@ -18,6 +18,7 @@ import (
 type KubeClusterAccessService interface {
 	IsSecure() bool
 	GetClusterDetails(hostURL string, endpointId portainer.EndpointID, isInternal bool) kubernetesClusterAccessData
+	GetCredentialURL(hostURL string) string
 }
 
 // KubernetesClusterAccess represents core details which can be used to generate KubeConfig file/data
@@ -122,3 +123,18 @@ func (service *kubeClusterAccessService) GetClusterDetails(hostURL string, endpo
 		CertificateAuthorityData: service.certificateAuthorityData,
 	}
 }
+
+// GetCredentialURL returns the URL of the Portainer API the kubeconfig credentials are renewed from by the exec
+// credential plugin of kubectl
+func (service *kubeClusterAccessService) GetCredentialURL(hostURL string) string {
+	if hostURL == "localhost" {
+		hostURL += service.httpsBindAddr
+	}
+
+	credentialURL, err := url.JoinPath("https://", hostURL, service.baseURL, "/api/kubernetes/credential")
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to create Kubeconfig credential URL")
+	}
+
+	return credentialURL
+}
@@ -103,6 +103,12 @@ func TestKubeClusterAccessService_GetKubeConfigInternal(t *testing.T) {
 		is.True(strings.Contains(clusterAccessDetails.ClusterServerURL, "api/endpoints/100/kubernetes"), "should contain environment proxy url")
 	})
 
+	t.Run("GetCredentialURL contains the base URL", func(t *testing.T) {
+		kcs := NewKubeClusterAccessService("/portainer", ":9443", "")
+		is.Equal("https://mysite.com/portainer/api/kubernetes/credential", kcs.GetCredentialURL("mysite.com"))
+		is.Equal("https://localhost:9443/portainer/api/kubernetes/credential", kcs.GetCredentialURL("localhost"))
+	})
+
 	t.Run("GetClusterDetails returns insecure cluster access config", func(t *testing.T) {
 		kcs := NewKubeClusterAccessService("/", ":9443", "")
 		clusterAccessDetails := kcs.GetClusterDetails("mysite.com", 1, true)
@@ -0,0 +1,12 @@
+package privateregistries
+
+import (
+	"fmt"
+
+	portainer "github.com/portainer/portainer/api"
+)
+
+// SecretName returns the name of the image pull secret of a registry in the namespaces it is bound to
+func SecretName(registryID portainer.RegistryID) string {
+	return fmt.Sprintf("registry-%d", registryID)
+}
@@ -0,0 +1,133 @@
+// Package teamaccess materializes the access of the Portainer teams to the Kubernetes namespaces as RoleBindings, so
+// that the namespace isolation of the teams is enforced by the clusters and not only by the Kubernetes proxy
+package teamaccess
+
+import (
+	"slices"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+	"github.com/portainer/portainer/api/internal/authorization"
+	"github.com/portainer/portainer/api/internal/endpointutils"
+	"github.com/portainer/portainer/api/kubernetes/cli"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Service synchronizes the RoleBindings of the teams in the Kubernetes environments
+type Service struct {
+	dataStore     dataservices.DataStore
+	clientFactory *cli.ClientFactory
+}
+
+// NewService creates a new team access service
+func NewService(dataStore dataservices.DataStore, clientFactory *cli.ClientFactory) *Service {
+	return &Service{
+		dataStore:     dataStore,
+		clientFactory: clientFactory,
+	}
+}
+
+// SyncTeams synchronizes the RoleBindings of teams in every Kubernetes environment, along with the ones of the teams
+// above them since their members inherit the access of the ancestors of their teams. It is meant to be called after
+// the memberships or the hierarchy of the teams changed
+func (service *Service) SyncTeams(teamIDs ...portainer.TeamID) {
+	teamIDs, err := service.withAncestors(teamIDs)
+	if err != nil {
+		log.Error().Err(err).Msg("unable to retrieve the teams to synchronize")
+		return
+	}
+
+	service.forEachKubeClient(func(endpoint *portainer.Endpoint, kcl portainer.KubeClient) {
+		if err := service.SyncTeamsInEnvironment(kcl, teamIDs...); err != nil {
+			log.Warn().Err(err).Int("environment_id", int(endpoint.ID)).Msg("unable to synchronize the namespace access of the teams")
+		}
+	})
+}
+
+// SyncTeamsInEnvironment synchronizes the RoleBindings of teams in a single Kubernetes environment, binding the
+// members of a team and of the teams below it
+func (service *Service) SyncTeamsInEnvironment(kcl portainer.KubeClient, teamIDs ...portainer.TeamID) error {
+	for _, teamID := range teamIDs {
+		userIDs, err := service.teamMembers(teamID)
+		if err != nil {
+			return err
+		}
+
+		if err := kcl.SyncTeamNamespaceAccess(int(teamID), userIDs); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// DeleteTeam removes the RoleBindings of a deleted team from every Kubernetes environment and synchronizes the ones
+// of its former ancestors, whose inherited members changed
+func (service *Service) DeleteTeam(teamID, parentID portainer.TeamID) {
+	service.forEachKubeClient(func(endpoint *portainer.Endpoint, kcl portainer.KubeClient) {
+		if err := kcl.DeleteTeamNamespaceAccess(int(teamID)); err != nil {
+			log.Warn().Err(err).Int("environment_id", int(endpoint.ID)).Int("team_id", int(teamID)).Msg("unable to remove the namespace access of the team")
+		}
+	})
+
+	if parentID != 0 {
+		service.SyncTeams(parentID)
+	}
+}
+
+func (service *Service) forEachKubeClient(fn func(endpoint *portainer.Endpoint, kcl portainer.KubeClient)) {
+	endpoints, err := service.dataStore.Endpoint().Endpoints()
+	if err != nil {
+		log.Error().Err(err).Msg("unable to retrieve the environments")
+		return
+	}
+
+	for i := range endpoints {
+		endpoint := &endpoints[i]
+		if !endpointutils.IsKubernetesEndpoint(endpoint) {
+			continue
+		}
+
+		kcl, err := service.clientFactory.GetPrivilegedKubeClient(endpoint)
+		if err != nil {
+			log.Warn().Err(err).Int("environment_id", int(endpoint.ID)).Msg("unable to create the Kubernetes client")
+			continue
+		}
+
+		fn(endpoint, kcl)
+	}
+}
+
+// withAncestors adds the ancestors of teams to them, the teams which no longer exist being kept so that their
+// RoleBindings are removed
+func (service *Service) withAncestors(teamIDs []portainer.TeamID) ([]portainer.TeamID, error) {
+	teams, err := service.dataStore.Team().ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	result := slices.Clone(teamIDs)
+	for _, teamID := range teamIDs {
+		result = append(result, authorization.TeamAncestors(teams, teamID)...)
+	}
+
+	slices.Sort(result)
+
+	return slices.Compact(result), nil
+}
+
+// teamMembers returns the users of a team and of the teams below it
+func (service *Service) teamMembers(teamID portainer.TeamID) ([]int, error) {
+	memberships, err := authorization.EffectiveTeamMembershipsByTeamID(service.dataStore, teamID)
+	if err != nil {
+		return nil, err
+	}
+
+	userIDs := make([]int, 0, len(memberships))
+	for _, membership := range memberships {
+		userIDs = append(userIDs, int(membership.UserID))
+	}
+
+	return userIDs, nil
+}
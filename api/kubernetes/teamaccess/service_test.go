@@ -0,0 +1,48 @@
+package teamaccess
+
+import (
+	"testing"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/datastore"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTeamMembersAndAncestors(t *testing.T) {
+	_, store := datastore.MustNewTestStore(t, true, false)
+
+	// org > dev > backend
+	for _, team := range []*portainer.Team{
+		{ID: 1, Name: "org"},
+		{ID: 2, Name: "dev", ParentID: 1},
+		{ID: 3, Name: "backend", ParentID: 2},
+		{ID: 4, Name: "ops"},
+	} {
+		require.NoError(t, store.Team().Create(team))
+	}
+
+	for _, membership := range []*portainer.TeamMembership{
+		{UserID: 10, TeamID: 1},
+		{UserID: 20, TeamID: 2},
+		{UserID: 30, TeamID: 3},
+		{UserID: 40, TeamID: 4},
+	} {
+		require.NoError(t, store.TeamMembership().Create(membership))
+	}
+
+	service := NewService(store, nil)
+
+	teamIDs, err := service.withAncestors([]portainer.TeamID{3, 2, 4})
+	require.NoError(t, err)
+	assert.Equal(t, []portainer.TeamID{1, 2, 3, 4}, teamIDs)
+
+	userIDs, err := service.teamMembers(2)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []int{20, 30}, userIDs, "the members of the teams below should be bound too")
+
+	userIDs, err = service.teamMembers(5)
+	require.NoError(t, err)
+	assert.Empty(t, userIDs, "a deleted team should have no members")
+}
@@ -68,6 +68,27 @@ func AddAppLabels(manifestYaml []byte, appLabels map[string]string) ([]byte, err
 	return bytes.Join(docs, []byte("---\n")), nil
 }
 
+// AddImagePullSecrets adds to the pod specs of the workloads of a manifest the image pull secrets returned for their
+// namespace and images, keeping the secrets they already reference. The resources without a namespace use the
+// default namespace.
+func AddImagePullSecrets(manifestYaml []byte, defaultNamespace string, pullSecrets func(namespace string, images []string) []string) ([]byte, error) {
+	if bytes.Equal(manifestYaml, []byte("")) {
+		return manifestYaml, nil
+	}
+
+	postProcessYaml := func(yamlDoc any) error {
+		addResourcePullSecrets(yamlDoc, defaultNamespace, pullSecrets)
+		return nil
+	}
+
+	docs, err := ExtractDocuments(manifestYaml, postProcessYaml)
+	if err != nil {
+		return nil, err
+	}
+
+	return bytes.Join(docs, []byte("---\n")), nil
+}
+
 // ExtractDocuments extracts all the documents from a yaml file
 // Optionally post-process each document with a function, which can modify the document in place.
 // Pass in nil for postProcessYaml to skip post-processing.
@@ -188,3 +209,113 @@ func addLabels(obj map[string]any, appLabels map[string]string) {
 	metadata["labels"] = labels
 	obj["metadata"] = metadata
 }
+
+func addResourcePullSecrets(yamlDoc any, defaultNamespace string, pullSecrets func(namespace string, images []string) []string) {
+	m, ok := yamlDoc.(map[string]any)
+	if !ok {
+		return
+	}
+
+	kind, ok := m["kind"].(string)
+	if ok && !strings.EqualFold(kind, "list") {
+		spec := podSpec(m, kind)
+		if spec == nil {
+			return
+		}
+
+		namespace := defaultNamespace
+		if metadata, ok := m["metadata"].(map[string]any); ok {
+			if ns, ok := metadata["namespace"].(string); ok && ns != "" {
+				namespace = ns
+			}
+		}
+
+		addPullSecrets(spec, pullSecrets(namespace, podImages(spec)))
+		return
+	}
+
+	for _, v := range m {
+		switch v := v.(type) {
+		case map[string]any:
+			addResourcePullSecrets(v, defaultNamespace, pullSecrets)
+		case []any:
+			for _, item := range v {
+				addResourcePullSecrets(item, defaultNamespace, pullSecrets)
+			}
+		}
+	}
+}
+
+// podSpec returns the pod spec of a workload, nil when the resource has none
+func podSpec(resource map[string]any, kind string) map[string]any {
+	var path []string
+
+	switch strings.ToLower(kind) {
+	case "pod":
+		path = []string{"spec"}
+	case "deployment", "statefulset", "daemonset", "replicaset", "replicationcontroller", "job":
+		path = []string{"spec", "template", "spec"}
+	case "cronjob":
+		path = []string{"spec", "jobTemplate", "spec", "template", "spec"}
+	default:
+		return nil
+	}
+
+	spec := resource
+	for _, key := range path {
+		next, ok := spec[key].(map[string]any)
+		if !ok {
+			return nil
+		}
+
+		spec = next
+	}
+
+	return spec
+}
+
+func podImages(spec map[string]any) []string {
+	var images []string
+
+	for _, key := range []string{"initContainers", "containers"} {
+		containers, _ := spec[key].([]any)
+		for _, c := range containers {
+			container, ok := c.(map[string]any)
+			if !ok {
+				continue
+			}
+
+			if image, ok := container["image"].(string); ok && image != "" {
+				images = append(images, image)
+			}
+		}
+	}
+
+	return images
+}
+
+func addPullSecrets(spec map[string]any, secrets []string) {
+	if len(secrets) == 0 {
+		return
+	}
+
+	pullSecrets, _ := spec["imagePullSecrets"].([]any)
+
+	names := make(map[string]bool, len(pullSecrets))
+	for _, s := range pullSecrets {
+		if ref, ok := s.(map[string]any); ok {
+			if name, ok := ref["name"].(string); ok {
+				names[name] = true
+			}
+		}
+	}
+
+	for _, secret := range secrets {
+		if !names[secret] {
+			pullSecrets = append(pullSecrets, map[string]any{"name": secret})
+			names[secret] = true
+		}
+	}
+
+	spec["imagePullSecrets"] = pullSecrets
+}
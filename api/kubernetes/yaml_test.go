@@ -1,6 +1,7 @@
 package kubernetes
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -711,3 +712,88 @@ kind: Namespace
 		})
 	}
 }
+
+func Test_AddImagePullSecrets(t *testing.T) {
+	input := `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  template:
+    spec:
+      imagePullSecrets:
+        - name: own
+      containers:
+        - name: web
+          image: harbor.example.com/library/web:1.0
+---
+apiVersion: batch/v1
+kind: CronJob
+metadata:
+  name: backup
+  namespace: ops
+spec:
+  jobTemplate:
+    spec:
+      template:
+        spec:
+          containers:
+            - name: backup
+              image: harbor.example.com/ops/backup
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: web
+`
+
+	want := `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  template:
+    spec:
+      containers:
+        - image: harbor.example.com/library/web:1.0
+          name: web
+      imagePullSecrets:
+        - name: own
+        - name: registry-1
+---
+apiVersion: batch/v1
+kind: CronJob
+metadata:
+  name: backup
+  namespace: ops
+spec:
+  jobTemplate:
+    spec:
+      template:
+        spec:
+          containers:
+            - image: harbor.example.com/ops/backup
+              name: backup
+          imagePullSecrets:
+            - name: registry-2
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: web
+`
+
+	var calls []string
+	result, err := AddImagePullSecrets([]byte(input), "dev", func(namespace string, images []string) []string {
+		calls = append(calls, namespace+" "+strings.Join(images, ","))
+
+		if namespace == "ops" {
+			return []string{"registry-2"}
+		}
+
+		return []string{"registry-1", "own"}
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, want, string(result))
+	assert.Equal(t, []string{"dev harbor.example.com/library/web:1.0", "ops harbor.example.com/ops/backup"}, calls)
+}
@@ -0,0 +1,187 @@
+// Package lockout protects the password logins against brute force and credential stuffing attacks. It tracks the
+// failed logins of every username and IP address, and locks them out for a period doubling with every failure past
+// a threshold
+package lockout
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// UsernameThreshold is the number of consecutive failed logins of a username before it is locked out
+	UsernameThreshold = 5
+	// IPAddressThreshold is the number of consecutive failed logins from an IP address before it is locked out,
+	// higher than the one of the usernames since many users can share an address
+	IPAddressThreshold = 20
+	// BaseDuration is the duration of the first lockout, it doubles with every following failure
+	BaseDuration = 30 * time.Second
+	// MaxDuration caps the duration of a lockout
+	MaxDuration = time.Hour
+	// ResetPeriod is the period without failure after which the failed logins are forgotten
+	ResetPeriod = 24 * time.Hour
+)
+
+// Service tracks the failed logins in the datastore
+type Service struct {
+	dataStore dataservices.DataStore
+	mu        sync.Mutex
+}
+
+// NewService creates a new lockout service
+func NewService(dataStore dataservices.DataStore) *Service {
+	return &Service{dataStore: dataStore}
+}
+
+// Check returns the remaining duration of the lockout of a username or an IP address, 0 when they can log in
+func (service *Service) Check(username, ipAddress string) (time.Duration, error) {
+	var remaining time.Duration
+
+	err := service.dataStore.ViewTx(func(tx dataservices.DataStoreTx) error {
+		now := time.Now()
+
+		for _, s := range subjects(username, ipAddress) {
+			attempt, err := tx.LoginAttempt().LoginAttemptBySubject(s.name, s.ipAddress)
+			if tx.IsErrObjectNotFound(err) {
+				continue
+			} else if err != nil {
+				return err
+			}
+
+			remaining = max(remaining, time.Unix(attempt.LockedUntil, 0).Sub(now))
+		}
+
+		return nil
+	})
+
+	return max(remaining, 0), err
+}
+
+// RecordFailure records a failed login of a username from an IP address, and locks them out when their threshold
+// is reached
+func (service *Service) RecordFailure(username, ipAddress string) error {
+	service.mu.Lock()
+	defer service.mu.Unlock()
+
+	return service.dataStore.UpdateTx(func(tx dataservices.DataStoreTx) error {
+		now := time.Now()
+
+		for _, s := range subjects(username, ipAddress) {
+			attempt, err := tx.LoginAttempt().LoginAttemptBySubject(s.name, s.ipAddress)
+			if tx.IsErrObjectNotFound(err) {
+				attempt = &portainer.LoginAttempt{Subject: s.name, IPAddress: s.ipAddress}
+			} else if err != nil {
+				return err
+			}
+
+			if now.Sub(time.Unix(attempt.LastFailure, 0)) > ResetPeriod {
+				attempt.Failures = 0
+			}
+
+			attempt.Failures++
+			attempt.LastFailure = now.Unix()
+
+			if duration := lockoutDuration(attempt.Failures, s.threshold); duration > 0 {
+				attempt.LockedUntil = now.Add(duration).Unix()
+
+				log.Warn().
+					Str("event", "login_lockout").
+					Str("subject", s.name).
+					Bool("ip_address", s.ipAddress).
+					Int("failures", attempt.Failures).
+					Dur("duration", duration).
+					Msg("logins locked out after repeated failures")
+			}
+
+			if attempt.ID == 0 {
+				err = tx.LoginAttempt().Create(attempt)
+			} else {
+				err = tx.LoginAttempt().Update(attempt.ID, attempt)
+			}
+
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// RecordSuccess forgets the failed logins of a username once it logged in. The failures of the IP address are
+// kept, so that an attacker cannot reset them by logging in with its own account
+func (service *Service) RecordSuccess(username string) error {
+	service.mu.Lock()
+	defer service.mu.Unlock()
+
+	return service.dataStore.UpdateTx(func(tx dataservices.DataStoreTx) error {
+		attempt, err := tx.LoginAttempt().LoginAttemptBySubject(strings.ToLower(username), false)
+		if tx.IsErrObjectNotFound(err) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		return tx.LoginAttempt().Delete(attempt.ID)
+	})
+}
+
+// Unlock lifts the lockout of a username or an IP address and forgets its failed logins
+func (service *Service) Unlock(id portainer.LoginAttemptID, unlockedBy portainer.UserID) error {
+	service.mu.Lock()
+	defer service.mu.Unlock()
+
+	return service.dataStore.UpdateTx(func(tx dataservices.DataStoreTx) error {
+		attempt, err := tx.LoginAttempt().Read(id)
+		if err != nil {
+			return err
+		}
+
+		log.Info().
+			Str("event", "login_unlock").
+			Str("subject", attempt.Subject).
+			Bool("ip_address", attempt.IPAddress).
+			Int("unlocked_by", int(unlockedBy)).
+			Msg("logins unlocked by an administrator")
+
+		return tx.LoginAttempt().Delete(id)
+	})
+}
+
+type subject struct {
+	name      string
+	ipAddress bool
+	threshold int
+}
+
+func subjects(username, ipAddress string) []subject {
+	s := []subject{{name: strings.ToLower(username), threshold: UsernameThreshold}}
+	if ipAddress != "" {
+		s = append(s, subject{name: ipAddress, ipAddress: true, threshold: IPAddressThreshold})
+	}
+
+	return s
+}
+
+// lockoutDuration returns the duration of the lockout following a failed login, 0 below the threshold
+func lockoutDuration(failures, threshold int) time.Duration {
+	if failures < threshold {
+		return 0
+	}
+
+	duration := BaseDuration
+	for range failures - threshold {
+		duration *= 2
+		if duration >= MaxDuration {
+			return MaxDuration
+		}
+	}
+
+	return duration
+}
@@ -0,0 +1,84 @@
+package lockout
+
+import (
+	"testing"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/datastore"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLockout(t *testing.T) {
+	is := require.New(t)
+
+	_, store := datastore.MustNewTestStore(t, true, false)
+
+	service := NewService(store)
+
+	for range UsernameThreshold - 1 {
+		is.NoError(service.RecordFailure("Admin", "10.0.0.1"))
+	}
+
+	remaining, err := service.Check("admin", "10.0.0.2")
+	is.NoError(err)
+	is.Zero(remaining)
+
+	is.NoError(service.RecordFailure("admin", "10.0.0.1"))
+
+	remaining, err = service.Check("ADMIN", "10.0.0.2")
+	is.NoError(err)
+	is.InDelta(BaseDuration.Seconds(), remaining.Seconds(), 1)
+
+	// the IP address is below its own threshold
+	remaining, err = service.Check("other", "10.0.0.1")
+	is.NoError(err)
+	is.Zero(remaining)
+
+	attempt, err := store.LoginAttempt().LoginAttemptBySubject("admin", false)
+	is.NoError(err)
+	is.Equal(UsernameThreshold, attempt.Failures)
+
+	is.NoError(service.Unlock(attempt.ID, 1))
+
+	remaining, err = service.Check("admin", "10.0.0.2")
+	is.NoError(err)
+	is.Zero(remaining)
+
+	// the failures of the IP address are kept after a successful login
+	is.NoError(service.RecordSuccess("admin"))
+
+	attempt, err = store.LoginAttempt().LoginAttemptBySubject("10.0.0.1", true)
+	is.NoError(err)
+	is.Equal(UsernameThreshold, attempt.Failures)
+}
+
+func TestLockoutDuration(t *testing.T) {
+	is := require.New(t)
+
+	is.Zero(lockoutDuration(UsernameThreshold-1, UsernameThreshold))
+	is.Equal(BaseDuration, lockoutDuration(UsernameThreshold, UsernameThreshold))
+	is.Equal(4*BaseDuration, lockoutDuration(UsernameThreshold+2, UsernameThreshold))
+	is.Equal(MaxDuration, lockoutDuration(UsernameThreshold+100, UsernameThreshold))
+}
+
+func TestLockoutReset(t *testing.T) {
+	is := require.New(t)
+
+	_, store := datastore.MustNewTestStore(t, true, false)
+
+	is.NoError(store.LoginAttempt().Create(&portainer.LoginAttempt{
+		Subject:     "admin",
+		Failures:    UsernameThreshold,
+		LastFailure: time.Now().Add(-ResetPeriod - time.Minute).Unix(),
+	}))
+
+	service := NewService(store)
+	is.NoError(service.RecordFailure("admin", ""))
+
+	attempt, err := store.LoginAttempt().LoginAttemptBySubject("admin", false)
+	is.NoError(err)
+	is.Equal(1, attempt.Failures)
+	is.Zero(attempt.LockedUntil)
+}
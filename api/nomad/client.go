@@ -0,0 +1,246 @@
+// Package nomad is a client of the HTTP API of the HashiCorp Nomad clusters, it snapshots their jobs, allocations and
+// nodes, and deploys the Nomad jobspecs of the stacks
+package nomad
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/crypto"
+
+	"github.com/segmentio/encoding/json"
+)
+
+const (
+	// TokenHeader is the header the Nomad API reads the ACL token from
+	TokenHeader = "X-Nomad-Token"
+
+	defaultTimeout = 30 * time.Second
+)
+
+// Client sends requests to the API of a Nomad cluster
+type Client struct {
+	httpClient *http.Client
+	url        string
+	token      string
+	region     string
+	namespace  string
+}
+
+// Job is the stub of a Nomad job returned by the job listing
+type Job struct {
+	ID        string `json:"ID"`
+	Name      string `json:"Name"`
+	Namespace string `json:"Namespace"`
+	Type      string `json:"Type"`
+	Status    string `json:"Status"`
+}
+
+// Allocation is the stub of a Nomad allocation returned by the allocation listing
+type Allocation struct {
+	ID           string `json:"ID"`
+	JobID        string `json:"JobID"`
+	NodeID       string `json:"NodeID"`
+	ClientStatus string `json:"ClientStatus"`
+}
+
+// Node is the stub of a Nomad client node returned by the node listing
+type Node struct {
+	ID                    string         `json:"ID"`
+	Name                  string         `json:"Name"`
+	Status                string         `json:"Status"`
+	SchedulingEligibility string         `json:"SchedulingEligibility"`
+	NodeResources         *NodeResources `json:"NodeResources"`
+}
+
+// NodeResources are the resources of a Nomad client node
+type NodeResources struct {
+	Cpu struct {
+		CpuShares int64 `json:"CpuShares"`
+	} `json:"Cpu"`
+	Memory struct {
+		MemoryMB int64 `json:"MemoryMB"`
+	} `json:"Memory"`
+}
+
+// RegisterResponse is the response of the Nomad API to a job registration
+type RegisterResponse struct {
+	EvalID   string `json:"EvalID"`
+	Warnings string `json:"Warnings"`
+}
+
+// NewClient returns a client of the Nomad API of the environment(endpoint)
+func NewClient(endpoint *portainer.Endpoint) (*Client, error) {
+	if endpoint.Type != portainer.NomadEnvironment {
+		return nil, errors.New("the environment is not a Nomad environment")
+	}
+
+	transport := &http.Transport{Proxy: http.ProxyFromEnvironment}
+
+	if endpoint.TLSConfig.TLS {
+		tlsConfig, err := crypto.CreateTLSConfigurationFromDisk(endpoint.TLSConfig.TLSCACertPath, endpoint.TLSConfig.TLSCertPath, endpoint.TLSConfig.TLSKeyPath, endpoint.TLSConfig.TLSSkipVerify)
+		if err != nil {
+			return nil, err
+		}
+
+		transport.TLSClientConfig = tlsConfig
+	}
+
+	client := &Client{
+		httpClient: &http.Client{Transport: transport, Timeout: defaultTimeout},
+		url:        strings.TrimSuffix(endpoint.URL, "/"),
+	}
+
+	if endpoint.Nomad != nil {
+		client.token = endpoint.Nomad.Token
+		client.region = endpoint.Nomad.Region
+		client.namespace = endpoint.Nomad.Namespace
+	}
+
+	return client, nil
+}
+
+// Version returns the version of the Nomad agent the client is connected to
+func (c *Client) Version(ctx context.Context) (string, error) {
+	var self struct {
+		Config struct {
+			Version struct {
+				Version string `json:"Version"`
+			} `json:"Version"`
+		} `json:"config"`
+	}
+
+	if err := c.do(ctx, http.MethodGet, "/v1/agent/self", nil, nil, &self); err != nil {
+		return "", err
+	}
+
+	return self.Config.Version.Version, nil
+}
+
+// Jobs returns the jobs of the namespace of the client
+func (c *Client) Jobs(ctx context.Context) ([]Job, error) {
+	var jobs []Job
+	if err := c.do(ctx, http.MethodGet, "/v1/jobs", nil, nil, &jobs); err != nil {
+		return nil, err
+	}
+
+	return jobs, nil
+}
+
+// Allocations returns the allocations of the namespace of the client
+func (c *Client) Allocations(ctx context.Context) ([]Allocation, error) {
+	var allocations []Allocation
+	if err := c.do(ctx, http.MethodGet, "/v1/allocations", nil, nil, &allocations); err != nil {
+		return nil, err
+	}
+
+	return allocations, nil
+}
+
+// Nodes returns the client nodes of the cluster along with their resources
+func (c *Client) Nodes(ctx context.Context) ([]Node, error) {
+	var nodes []Node
+	if err := c.do(ctx, http.MethodGet, "/v1/nodes", url.Values{"resources": {"true"}}, nil, &nodes); err != nil {
+		return nil, err
+	}
+
+	return nodes, nil
+}
+
+// ParseJob converts a HCL jobspec to the JSON job expected by the job registration
+func (c *Client) ParseJob(ctx context.Context, jobspec string) (map[string]any, error) {
+	payload := map[string]any{
+		"JobHCL":       jobspec,
+		"Canonicalize": true,
+	}
+
+	var job map[string]any
+	if err := c.do(ctx, http.MethodPost, "/v1/jobs/parse", nil, payload, &job); err != nil {
+		return nil, err
+	}
+
+	return job, nil
+}
+
+// RegisterJob creates or updates the job
+func (c *Client) RegisterJob(ctx context.Context, job map[string]any) (*RegisterResponse, error) {
+	var resp RegisterResponse
+	if err := c.do(ctx, http.MethodPost, "/v1/jobs", nil, map[string]any{"Job": job}, &resp); err != nil {
+		return nil, err
+	}
+
+	return &resp, nil
+}
+
+// DeregisterJob stops the job and purges it from the cluster
+func (c *Client) DeregisterJob(ctx context.Context, jobID string) error {
+	return c.do(ctx, http.MethodDelete, "/v1/job/"+url.PathEscape(jobID), url.Values{"purge": {"true"}}, nil, nil)
+}
+
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, payload, result any) error {
+	if query == nil {
+		query = url.Values{}
+	}
+
+	if c.region != "" {
+		query.Set("region", c.region)
+	}
+
+	if c.namespace != "" {
+		query.Set("namespace", c.namespace)
+	}
+
+	var body io.Reader
+	if payload != nil {
+		content, err := json.Marshal(payload)
+		if err != nil {
+			return err
+		}
+
+		body = bytes.NewReader(content)
+	}
+
+	reqURL := c.url + path
+	if len(query) > 0 {
+		reqURL += "?" + query.Encode()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, reqURL, body)
+	if err != nil {
+		return err
+	}
+
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	if c.token != "" {
+		req.Header.Set(TokenHeader, c.token)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+
+		return fmt.Errorf("the Nomad API responded to %s %s with %d: %s", method, path, resp.StatusCode, strings.TrimSpace(string(message)))
+	}
+
+	if result == nil {
+		return nil
+	}
+
+	return json.NewDecoder(resp.Body).Decode(result)
+}
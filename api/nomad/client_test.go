@@ -0,0 +1,103 @@
+package nomad
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	portainer "github.com/portainer/portainer/api"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateSnapshot(t *testing.T) {
+	is := require.New(t)
+
+	var token, namespace string
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/agent/self", func(w http.ResponseWriter, r *http.Request) {
+		token = r.Header.Get(TokenHeader)
+		namespace = r.URL.Query().Get("namespace")
+
+		w.Write([]byte(`{"config":{"Version":{"Version":"1.7.5"}}}`))
+	})
+	mux.HandleFunc("/v1/nodes", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[{"ID":"n1","Status":"ready","NodeResources":{"Cpu":{"CpuShares":4000},"Memory":{"MemoryMB":2048}}},{"ID":"n2","Status":"down"}]`))
+	})
+	mux.HandleFunc("/v1/jobs", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[{"ID":"web","Status":"running"},{"ID":"batch","Status":"dead"}]`))
+	})
+	mux.HandleFunc("/v1/allocations", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[{"ID":"a1","JobID":"web","ClientStatus":"running"},{"ID":"a2","JobID":"batch","ClientStatus":"complete"}]`))
+	})
+
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	client, err := NewClient(&portainer.Endpoint{
+		Type:  portainer.NomadEnvironment,
+		URL:   srv.URL,
+		Nomad: &portainer.NomadData{Token: "secret", Namespace: "apps"},
+	})
+	is.NoError(err)
+
+	snapshot, err := CreateSnapshot(context.Background(), client)
+	is.NoError(err)
+	is.Equal("secret", token)
+	is.Equal("apps", namespace)
+	is.Equal("1.7.5", snapshot.NomadVersion)
+	is.Equal(2, snapshot.NodeCount)
+	is.Equal(1, snapshot.ReadyNodeCount)
+	is.Equal(int64(4000), snapshot.TotalCPU)
+	is.Equal(int64(2048*1024*1024), snapshot.TotalMemory)
+	is.Equal(2, snapshot.JobCount)
+	is.Equal(1, snapshot.RunningJobCount)
+	is.Equal(2, snapshot.AllocationCount)
+	is.Equal(1, snapshot.RunningAllocationCount)
+}
+
+func TestDeployJob(t *testing.T) {
+	is := require.New(t)
+
+	var registered, deregistered string
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/jobs/parse", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"ID":"web","Name":"web"}`))
+	})
+	mux.HandleFunc("/v1/jobs", func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		registered = string(body)
+
+		w.Write([]byte(`{"EvalID":"e1"}`))
+	})
+	mux.HandleFunc("/v1/job/web", func(w http.ResponseWriter, r *http.Request) {
+		deregistered = r.Method + " " + r.URL.Query().Get("purge")
+
+		w.Write([]byte(`{"EvalID":"e2"}`))
+	})
+
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	client, err := NewClient(&portainer.Endpoint{Type: portainer.NomadEnvironment, URL: srv.URL})
+	is.NoError(err)
+
+	job, err := client.ParseJob(context.Background(), `job "web" {}`)
+	is.NoError(err)
+	is.Equal("web", job["ID"])
+
+	resp, err := client.RegisterJob(context.Background(), job)
+	is.NoError(err)
+	is.Equal("e1", resp.EvalID)
+	is.JSONEq(`{"Job":{"ID":"web","Name":"web"}}`, registered)
+
+	is.NoError(client.DeregisterJob(context.Background(), "web"))
+	is.Equal("DELETE true", deregistered)
+
+	_, err = NewClient(&portainer.Endpoint{Type: portainer.DockerEnvironment})
+	is.Error(err)
+}
@@ -0,0 +1,86 @@
+package nomad
+
+import (
+	"context"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+)
+
+const snapshotTimeout = 30 * time.Second
+
+// Snapshotter creates snapshots of the Nomad environments(endpoints)
+type Snapshotter struct{}
+
+// NewSnapshotter returns a snapshotter of the Nomad environments(endpoints)
+func NewSnapshotter() *Snapshotter {
+	return &Snapshotter{}
+}
+
+// CreateSnapshot creates a snapshot of the jobs, allocations and nodes of a Nomad environment(endpoint)
+func (s *Snapshotter) CreateSnapshot(endpoint *portainer.Endpoint) (*portainer.NomadSnapshot, error) {
+	client, err := NewClient(endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), snapshotTimeout)
+	defer cancel()
+
+	return CreateSnapshot(ctx, client)
+}
+
+// CreateSnapshot creates a snapshot of the Nomad cluster the client is connected to
+func CreateSnapshot(ctx context.Context, client *Client) (*portainer.NomadSnapshot, error) {
+	snapshot := &portainer.NomadSnapshot{Time: time.Now().Unix()}
+
+	version, err := client.Version(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot.NomadVersion = version
+
+	nodes, err := client.Nodes(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot.NodeCount = len(nodes)
+	for _, node := range nodes {
+		if node.Status == "ready" {
+			snapshot.ReadyNodeCount++
+		}
+
+		if node.NodeResources != nil {
+			snapshot.TotalCPU += node.NodeResources.Cpu.CpuShares
+			snapshot.TotalMemory += node.NodeResources.Memory.MemoryMB * 1024 * 1024
+		}
+	}
+
+	jobs, err := client.Jobs(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot.JobCount = len(jobs)
+	for _, job := range jobs {
+		if job.Status == "running" {
+			snapshot.RunningJobCount++
+		}
+	}
+
+	allocations, err := client.Allocations(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot.AllocationCount = len(allocations)
+	for _, allocation := range allocations {
+		if allocation.ClientStatus == "running" {
+			snapshot.RunningAllocationCount++
+		}
+	}
+
+	return snapshot, nil
+}
@@ -0,0 +1,167 @@
+package oauth
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	portainer "github.com/portainer/portainer/api"
+
+	"github.com/pkg/errors"
+)
+
+// ClaimRulesResult is what the claim rules matching a user grant to it
+type ClaimRulesResult struct {
+	// RoleManaged is true when at least one rule grants the administrator role, the role of the user
+	// then follows the rules
+	RoleManaged      bool
+	Admin            bool
+	TeamIDs          []portainer.TeamID
+	EndpointGroupIDs []portainer.EndpointGroupID
+}
+
+// ValidateClaimRules checks that the claim rules are well formed
+func ValidateClaimRules(rules []portainer.OAuthClaimRule) error {
+	for i, rule := range rules {
+		if strings.TrimSpace(rule.Claim) == "" {
+			return fmt.Errorf("claim rule %d: missing claim name", i)
+		}
+
+		switch rule.Operator {
+		case portainer.OAuthClaimRuleEquals, portainer.OAuthClaimRuleNotEquals, portainer.OAuthClaimRuleContains, portainer.OAuthClaimRuleExists:
+		case portainer.OAuthClaimRuleMatches:
+			if _, err := regexp.Compile(rule.Value); err != nil {
+				return errors.Wrapf(err, "claim rule %d: invalid regular expression", i)
+			}
+		default:
+			return fmt.Errorf("claim rule %d: invalid operator %q, must be one of: equals, notEquals, contains, matches or exists", i, rule.Operator)
+		}
+
+		if !rule.Admin && rule.TeamID == 0 && rule.EndpointGroupID == 0 {
+			return fmt.Errorf("claim rule %d: the rule must grant the administrator role, a team or an environment group", i)
+		}
+	}
+
+	return nil
+}
+
+// EvaluateClaimRules returns what the rules matching the claims grant
+func EvaluateClaimRules(rules []portainer.OAuthClaimRule, claims map[string]any) ClaimRulesResult {
+	result := ClaimRulesResult{}
+
+	for _, rule := range rules {
+		if rule.Admin {
+			result.RoleManaged = true
+		}
+
+		if !claimRuleMatches(rule, claims) {
+			continue
+		}
+
+		if rule.Admin {
+			result.Admin = true
+		}
+
+		if rule.TeamID != 0 && !slices.Contains(result.TeamIDs, rule.TeamID) {
+			result.TeamIDs = append(result.TeamIDs, rule.TeamID)
+		}
+
+		if rule.EndpointGroupID != 0 && !slices.Contains(result.EndpointGroupIDs, rule.EndpointGroupID) {
+			result.EndpointGroupIDs = append(result.EndpointGroupIDs, rule.EndpointGroupID)
+		}
+	}
+
+	return result
+}
+
+func claimRuleMatches(rule portainer.OAuthClaimRule, claims map[string]any) bool {
+	claim, ok := lookupClaim(claims, rule.Claim)
+
+	if rule.Operator == portainer.OAuthClaimRuleExists {
+		return ok
+	}
+
+	values := claimValues(claim)
+
+	if rule.Operator == portainer.OAuthClaimRuleNotEquals {
+		return !slices.Contains(values, rule.Value)
+	}
+
+	if !ok {
+		return false
+	}
+
+	var re *regexp.Regexp
+	if rule.Operator == portainer.OAuthClaimRuleMatches {
+		var err error
+		if re, err = regexp.Compile(rule.Value); err != nil {
+			return false
+		}
+	}
+
+	for _, value := range values {
+		switch rule.Operator {
+		case portainer.OAuthClaimRuleEquals:
+			if value == rule.Value {
+				return true
+			}
+		case portainer.OAuthClaimRuleContains:
+			if strings.Contains(value, rule.Value) {
+				return true
+			}
+		case portainer.OAuthClaimRuleMatches:
+			if re.MatchString(value) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// lookupClaim returns the claim with the given name, nested claims are separated by dots.
+// A claim whose name contains dots is found first
+func lookupClaim(claims map[string]any, name string) (any, bool) {
+	if value, ok := claims[name]; ok {
+		return value, true
+	}
+
+	head, tail, found := strings.Cut(name, ".")
+	if !found {
+		return nil, false
+	}
+
+	nested, ok := claims[head].(map[string]any)
+	if !ok {
+		return nil, false
+	}
+
+	return lookupClaim(nested, tail)
+}
+
+// claimValues converts a claim into the list of its string values
+func claimValues(claim any) []string {
+	switch v := claim.(type) {
+	case nil:
+		return nil
+	case string:
+		return []string{v}
+	case bool:
+		return []string{strconv.FormatBool(v)}
+	case float64:
+		return []string{strconv.FormatFloat(v, 'f', -1, 64)}
+	case []string:
+		return v
+	case []any:
+		values := make([]string, 0, len(v))
+		for _, item := range v {
+			values = append(values, claimValues(item)...)
+		}
+
+		return values
+	}
+
+	return []string{fmt.Sprint(claim)}
+}
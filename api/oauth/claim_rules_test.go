@@ -0,0 +1,97 @@
+package oauth
+
+import (
+	"slices"
+	"testing"
+
+	portainer "github.com/portainer/portainer/api"
+)
+
+func Test_EvaluateClaimRules(t *testing.T) {
+	rules := []portainer.OAuthClaimRule{
+		{Claim: "department", Operator: portainer.OAuthClaimRuleEquals, Value: "platform", Admin: true},
+		{Claim: "env", Operator: portainer.OAuthClaimRuleEquals, Value: "prod", EndpointGroupID: 2},
+		{Claim: "groups", Operator: portainer.OAuthClaimRuleEquals, Value: "devs", TeamID: 3},
+		{Claim: "realm_access.roles", Operator: portainer.OAuthClaimRuleMatches, Value: "^ops-", TeamID: 4},
+		{Claim: "email", Operator: portainer.OAuthClaimRuleContains, Value: "@example.com", TeamID: 3},
+	}
+
+	t.Run("grants the matching rules", func(t *testing.T) {
+		claims := map[string]any{
+			"department":   "platform",
+			"env":          "prod",
+			"groups":       []any{"devs", "qa"},
+			"realm_access": map[string]any{"roles": []any{"ops-admin"}},
+			"email":        "john@example.com",
+		}
+
+		result := EvaluateClaimRules(rules, claims)
+		if !result.RoleManaged || !result.Admin {
+			t.Errorf("expected the administrator role to be granted, got %+v", result)
+		}
+
+		if !slices.Equal(result.TeamIDs, []portainer.TeamID{3, 4}) {
+			t.Errorf("unexpected teams, got %v", result.TeamIDs)
+		}
+
+		if !slices.Equal(result.EndpointGroupIDs, []portainer.EndpointGroupID{2}) {
+			t.Errorf("unexpected environment groups, got %v", result.EndpointGroupIDs)
+		}
+	})
+
+	t.Run("does not grant the rules that do not match", func(t *testing.T) {
+		claims := map[string]any{
+			"department": "sales",
+			"groups":     []any{"qa"},
+		}
+
+		result := EvaluateClaimRules(rules, claims)
+		if !result.RoleManaged || result.Admin {
+			t.Errorf("expected the standard role to be enforced, got %+v", result)
+		}
+
+		if len(result.TeamIDs) != 0 || len(result.EndpointGroupIDs) != 0 {
+			t.Errorf("expected nothing to be granted, got %+v", result)
+		}
+	})
+
+	t.Run("negative and existence operators", func(t *testing.T) {
+		rules := []portainer.OAuthClaimRule{
+			{Claim: "contractor", Operator: portainer.OAuthClaimRuleNotEquals, Value: "true", TeamID: 1},
+			{Claim: "employee_id", Operator: portainer.OAuthClaimRuleExists, TeamID: 2},
+		}
+
+		result := EvaluateClaimRules(rules, map[string]any{"employee_id": float64(42)})
+		if result.RoleManaged || !slices.Equal(result.TeamIDs, []portainer.TeamID{1, 2}) {
+			t.Errorf("unexpected result, got %+v", result)
+		}
+
+		result = EvaluateClaimRules(rules, map[string]any{"contractor": true})
+		if len(result.TeamIDs) != 0 {
+			t.Errorf("expected nothing to be granted, got %+v", result)
+		}
+	})
+}
+
+func Test_ValidateClaimRules(t *testing.T) {
+	valid := []portainer.OAuthClaimRule{
+		{Claim: "department", Operator: portainer.OAuthClaimRuleMatches, Value: "^plat", Admin: true},
+	}
+
+	if err := ValidateClaimRules(valid); err != nil {
+		t.Errorf("expected the rules to be valid, got %s", err)
+	}
+
+	invalid := [][]portainer.OAuthClaimRule{
+		{{Operator: portainer.OAuthClaimRuleEquals, Value: "x", Admin: true}},
+		{{Claim: "a", Operator: "startsWith", Value: "x", Admin: true}},
+		{{Claim: "a", Operator: portainer.OAuthClaimRuleMatches, Value: "(", Admin: true}},
+		{{Claim: "a", Operator: portainer.OAuthClaimRuleEquals, Value: "x"}},
+	}
+
+	for _, rules := range invalid {
+		if err := ValidateClaimRules(rules); err == nil {
+			t.Errorf("expected the rules %+v to be invalid", rules)
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package oauth
+
+import (
+	"cmp"
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+
+	"github.com/pkg/errors"
+	"github.com/segmentio/encoding/json"
+)
+
+const discoveryPath = "/.well-known/openid-configuration"
+
+// ProviderMetadata is the part of the OpenID Connect discovery document used by Portainer
+type ProviderMetadata struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	UserinfoEndpoint      string `json:"userinfo_endpoint"`
+	EndSessionEndpoint    string `json:"end_session_endpoint"`
+}
+
+// Discover retrieves the OpenID Connect discovery document. The URI is either the issuer or the URL of the document
+func Discover(discoveryURI string) (*ProviderMetadata, error) {
+	if !strings.HasSuffix(discoveryURI, discoveryPath) {
+		discoveryURI = strings.TrimSuffix(discoveryURI, "/") + discoveryPath
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, discoveryURI, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to retrieve the discovery document")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("unable to retrieve the discovery document, status code %d", resp.StatusCode)
+	}
+
+	var metadata ProviderMetadata
+	if err := json.NewDecoder(resp.Body).Decode(&metadata); err != nil {
+		return nil, errors.Wrap(err, "invalid discovery document")
+	}
+
+	return &metadata, nil
+}
+
+// Apply fills the endpoints of the settings with the ones of the discovery document,
+// the endpoints missing from the document are kept
+func (metadata *ProviderMetadata) Apply(settings *portainer.OAuthSettings) {
+	settings.AuthorizationURI = cmp.Or(metadata.AuthorizationEndpoint, settings.AuthorizationURI)
+	settings.AccessTokenURI = cmp.Or(metadata.TokenEndpoint, settings.AccessTokenURI)
+	settings.ResourceURI = cmp.Or(metadata.UserinfoEndpoint, settings.ResourceURI)
+	settings.EndSessionURI = cmp.Or(metadata.EndSessionEndpoint, settings.EndSessionURI)
+}
+
+// LogoutURL returns the URL of the end session endpoint the user must be redirected to, with the id_token_hint
+// identifying its session. It is empty when RP-initiated logout is disabled
+func LogoutURL(settings *portainer.OAuthSettings, idToken, postLogoutRedirectURI string) (string, error) {
+	if !settings.RPInitiatedLogout || settings.EndSessionURI == "" {
+		return "", nil
+	}
+
+	logoutURL, err := url.Parse(settings.EndSessionURI)
+	if err != nil {
+		return "", errors.Wrap(err, "invalid end session URI")
+	}
+
+	query := logoutURL.Query()
+
+	if idToken != "" {
+		query.Set("id_token_hint", idToken)
+	}
+
+	if settings.ClientID != "" {
+		query.Set("client_id", settings.ClientID)
+	}
+
+	if postLogoutRedirectURI != "" {
+		query.Set("post_logout_redirect_uri", postLogoutRedirectURI)
+	}
+
+	logoutURL.RawQuery = query.Encode()
+
+	return logoutURL.String(), nil
+}
@@ -0,0 +1,94 @@
+package oauth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	portainer "github.com/portainer/portainer/api"
+)
+
+func Test_Discover(t *testing.T) {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/realms/main/.well-known/openid-configuration" {
+			w.WriteHeader(http.StatusNotFound)
+
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{
+			"issuer": "` + server.URL + `/realms/main",
+			"authorization_endpoint": "` + server.URL + `/auth",
+			"token_endpoint": "` + server.URL + `/token",
+			"end_session_endpoint": "` + server.URL + `/logout"
+		}`))
+	}))
+	defer server.Close()
+
+	for _, uri := range []string{server.URL + "/realms/main", server.URL + "/realms/main/.well-known/openid-configuration"} {
+		metadata, err := Discover(uri)
+		if err != nil {
+			t.Fatalf("unable to discover %s: %s", uri, err)
+		}
+
+		settings := &portainer.OAuthSettings{ResourceURI: "https://example.com/userinfo"}
+		metadata.Apply(settings)
+
+		if settings.AuthorizationURI != server.URL+"/auth" || settings.AccessTokenURI != server.URL+"/token" || settings.EndSessionURI != server.URL+"/logout" {
+			t.Errorf("unexpected endpoints, got %+v", settings)
+		}
+
+		if settings.ResourceURI != "https://example.com/userinfo" {
+			t.Errorf("expected the resource URI to be kept, got %s", settings.ResourceURI)
+		}
+	}
+
+	if _, err := Discover(server.URL + "/unknown"); err == nil {
+		t.Error("expected an error for a missing discovery document")
+	}
+}
+
+func Test_LogoutURL(t *testing.T) {
+	settings := &portainer.OAuthSettings{
+		ClientID:      "portainer",
+		EndSessionURI: "https://idp.example.com/logout?ui_locales=en",
+	}
+
+	logoutURL, err := LogoutURL(settings, "id-token", "https://portainer.example.com/")
+	if err != nil || logoutURL != "" {
+		t.Errorf("expected no URL when RP-initiated logout is disabled, got %q, %v", logoutURL, err)
+	}
+
+	settings.RPInitiatedLogout = true
+
+	logoutURL, err = LogoutURL(settings, "id-token", "https://portainer.example.com/")
+	if err != nil {
+		t.Fatalf("unable to build the logout URL: %s", err)
+	}
+
+	u, err := url.Parse(logoutURL)
+	if err != nil {
+		t.Fatalf("invalid logout URL %s: %s", logoutURL, err)
+	}
+
+	query := u.Query()
+	if u.Host != "idp.example.com" || u.Path != "/logout" {
+		t.Errorf("unexpected endpoint, got %s", logoutURL)
+	}
+
+	expected := map[string]string{
+		"id_token_hint":            "id-token",
+		"client_id":                "portainer",
+		"post_logout_redirect_uri": "https://portainer.example.com/",
+		"ui_locales":               "en",
+	}
+
+	for key, value := range expected {
+		if query.Get(key) != value {
+			t.Errorf("expected %s to be %q, got %q", key, value, query.Get(key))
+		}
+	}
+}
@@ -30,14 +30,79 @@ func NewService() *Service {
 // Authenticate takes an access code and exchanges it for an access token from portainer OAuthSettings token environment(endpoint).
 // On success, it will then return the username and token expiry time associated to authenticated user by fetching this information
 // from the resource server and matching it with the user identifier setting.
-func (*Service) Authenticate(code string, configuration *portainer.OAuthSettings) (string, error) {
+func (service *Service) Authenticate(code string, configuration *portainer.OAuthSettings) (string, error) {
+	username, _, err := service.AuthenticateWithRefreshToken(code, configuration)
+
+	return username, err
+}
+
+// AuthenticateWithRefreshToken behaves like Authenticate but also returns the refresh token issued by the
+// authorization server, if any, so that the session can later be renewed without user interaction.
+func (service *Service) AuthenticateWithRefreshToken(code string, configuration *portainer.OAuthSettings) (string, string, error) {
+	identity, err := service.AuthenticateIdentity(code, configuration)
+	if err != nil {
+		return "", "", err
+	}
+
+	return identity.Username, identity.RefreshToken, nil
+}
+
+// AuthenticateIdentity behaves like AuthenticateWithRefreshToken but also returns the claims of the user,
+// used to evaluate the claim rules
+func (*Service) AuthenticateIdentity(code string, configuration *portainer.OAuthSettings) (*portainer.OAuthIdentity, error) {
 	token, err := GetOAuthToken(code, configuration)
 	if err != nil {
 		log.Error().Err(err).Msg("failed retrieving oauth token")
 
-		return "", err
+		return nil, err
+	}
+
+	username, claims, err := getIdentityFromToken(token, configuration)
+	if err != nil {
+		return nil, err
+	}
+
+	return &portainer.OAuthIdentity{
+		Username:     username,
+		RefreshToken: token.RefreshToken,
+		IDToken:      rawIDToken(token),
+		Claims:       claims,
+	}, nil
+}
+
+// Refresh exchanges a refresh token for a new access token and returns the username associated to it.
+// It fails when the session of the user was terminated on the authorization server.
+// The returned refresh token is the one to persist, as some authorization servers rotate them.
+func (*Service) Refresh(refreshToken string, configuration *portainer.OAuthSettings) (string, string, error) {
+	if refreshToken == "" {
+		return "", "", errors.New("missing refresh token")
 	}
 
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	token, err := buildConfig(configuration).TokenSource(ctx, &oauth2.Token{RefreshToken: refreshToken}).Token()
+	if err != nil {
+		log.Debug().Err(err).Msg("failed refreshing oauth token")
+
+		return "", "", err
+	}
+
+	username, _, err := getIdentityFromToken(token, configuration)
+	if err != nil {
+		return "", "", err
+	}
+
+	// the authorization server is allowed to keep the same refresh token
+	newRefreshToken := token.RefreshToken
+	if newRefreshToken == "" {
+		newRefreshToken = refreshToken
+	}
+
+	return username, newRefreshToken, nil
+}
+
+func getIdentityFromToken(token *oauth2.Token, configuration *portainer.OAuthSettings) (string, map[string]any, error) {
 	idToken, err := GetIdToken(token)
 	if err != nil {
 		log.Error().Err(err).Msg("failed parsing id_token")
@@ -47,7 +112,7 @@ func (*Service) Authenticate(code string, configuration *portainer.OAuthSettings
 	if err != nil {
 		log.Error().Err(err).Msg("failed retrieving resource")
 
-		return "", err
+		return "", nil, err
 	}
 
 	maps.Copy(idToken, resource)
@@ -56,10 +121,10 @@ func (*Service) Authenticate(code string, configuration *portainer.OAuthSettings
 	if err != nil {
 		log.Error().Err(err).Msg("failed retrieving username")
 
-		return "", err
+		return "", nil, err
 	}
 
-	return username, nil
+	return username, idToken, nil
 }
 
 func GetOAuthToken(code string, configuration *portainer.OAuthSettings) (*oauth2.Token, error) {
@@ -76,6 +141,13 @@ func GetOAuthToken(code string, configuration *portainer.OAuthSettings) (*oauth2
 	return config.Exchange(ctx, unescapedCode)
 }
 
+// rawIDToken returns the id_token of the token response, empty when the provider did not issue one
+func rawIDToken(token *oauth2.Token) string {
+	idToken, _ := token.Extra("id_token").(string)
+
+	return idToken
+}
+
 // GetIdToken retrieves parsed id_token from the OAuth token response.
 // This is necessary for OAuth providers like Azure
 // that do not provide information about user groups on the user resource endpoint.
@@ -139,3 +139,50 @@ func Test_Authenticate(t *testing.T) {
 	})
 
 }
+
+func Test_Refresh(t *testing.T) {
+	authService := NewService()
+
+	config := &portainer.OAuthSettings{UserIdentifier: "username"}
+	srv, config := oauthtest.RunOAuthServer("valid-code", config)
+	defer srv.Close()
+
+	t.Run("should return the refresh token on authentication", func(t *testing.T) {
+		username, refreshToken, err := authService.AuthenticateWithRefreshToken("valid-code", config)
+		assert.NoError(t, err)
+		assert.Equal(t, "test-oauth-user", username)
+		assert.Equal(t, oauthtest.RefreshToken, refreshToken)
+	})
+
+	t.Run("should renew the session with a valid refresh token", func(t *testing.T) {
+		username, refreshToken, err := authService.Refresh(oauthtest.RefreshToken, config)
+		assert.NoError(t, err)
+		assert.Equal(t, "test-oauth-user", username)
+		assert.Equal(t, oauthtest.RefreshToken, refreshToken)
+	})
+
+	t.Run("should fail with an invalid refresh token", func(t *testing.T) {
+		_, _, err := authService.Refresh("invalid-refresh-token", config)
+		assert.Error(t, err)
+	})
+
+	t.Run("should fail without refresh token", func(t *testing.T) {
+		_, _, err := authService.Refresh("", config)
+		assert.Error(t, err)
+	})
+}
+
+func Test_RefreshTokenEncryption(t *testing.T) {
+	key := []byte("secret-key")
+
+	encrypted, err := EncryptRefreshToken("refresh-token", key)
+	assert.NoError(t, err)
+	assert.NotEqual(t, []byte("refresh-token"), encrypted)
+
+	decrypted, err := DecryptRefreshToken(encrypted, key)
+	assert.NoError(t, err)
+	assert.Equal(t, "refresh-token", decrypted)
+
+	_, err = DecryptRefreshToken(encrypted, []byte("another-key"))
+	assert.Error(t, err)
+}
@@ -12,7 +12,10 @@ import (
 	"github.com/segmentio/encoding/json"
 )
 
-const AccessToken = "test-token"
+const (
+	AccessToken  = "test-token"
+	RefreshToken = "test-refresh-token"
+)
 
 // OAuthRoutes is an OAuth 2.0 compliant handler
 func OAuthRoutes(code string, config *portainer.OAuthSettings) http.Handler {
@@ -38,18 +41,23 @@ func OAuthRoutes(code string, config *portainer.OAuthSettings) http.Handler {
 				return
 			}
 
-			reqCode := req.FormValue("code")
-			if reqCode != code {
+			if req.FormValue("grant_type") == "refresh_token" {
+				if req.FormValue("refresh_token") != RefreshToken {
+					w.WriteHeader(http.StatusUnauthorized)
+					return
+				}
+			} else if req.FormValue("code") != code {
 				w.WriteHeader(http.StatusUnauthorized)
 				return
 			}
 
 			w.WriteHeader(http.StatusOK)
 			json.NewEncoder(w).Encode(map[string]any{
-				"token_type":   "Bearer",
-				"expires_in":   86400,
-				"access_token": AccessToken,
-				"scope":        "groups",
+				"token_type":    "Bearer",
+				"expires_in":    86400,
+				"access_token":  AccessToken,
+				"refresh_token": RefreshToken,
+				"scope":         "groups",
 			})
 		},
 	).Methods(http.MethodPost)
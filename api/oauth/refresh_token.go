@@ -0,0 +1,30 @@
+package oauth
+
+import (
+	"github.com/portainer/portainer/pkg/libcrypto"
+
+	"github.com/pkg/errors"
+)
+
+// EncryptRefreshToken encrypts a refresh token so that it can be persisted
+func EncryptRefreshToken(refreshToken string, key []byte) ([]byte, error) {
+	if len(key) == 0 {
+		return nil, errors.New("missing refresh token encryption key")
+	}
+
+	return libcrypto.Encrypt([]byte(refreshToken), key)
+}
+
+// DecryptRefreshToken decrypts a refresh token persisted with EncryptRefreshToken
+func DecryptRefreshToken(encryptedRefreshToken []byte, key []byte) (string, error) {
+	if len(key) == 0 {
+		return "", errors.New("missing refresh token encryption key")
+	}
+
+	refreshToken, err := libcrypto.Decrypt(encryptedRefreshToken, key)
+	if err != nil {
+		return "", errors.Wrap(err, "unable to decrypt the refresh token")
+	}
+
+	return string(refreshToken), nil
+}
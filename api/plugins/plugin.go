@@ -0,0 +1,96 @@
+// Package plugins defines the interface implemented by the plugins extending the Portainer API. The routes of
+// a plugin are served under /api/plugins/<name>, they only see the Host interface to reach Portainer
+package plugins
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"slices"
+	"sync"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+)
+
+var nameRegexp = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,62}$`)
+
+// Host is the part of Portainer exposed to the plugins
+type Host interface {
+	// DataStore returns the data store of Portainer
+	DataStore() dataservices.DataStore
+	// TokenData returns the authenticated user of a request served by the plugin
+	TokenData(r *http.Request) (*portainer.TokenData, error)
+}
+
+// Plugin represents a plugin serving API routes
+type Plugin interface {
+	// Name is the name of the plugin, used in the URL of its routes
+	Name() string
+	// Handler returns the handler serving the routes of the plugin, the paths it receives are relative
+	// to /api/plugins/<name>. Only authenticated requests reach it
+	Handler(host Host) http.Handler
+}
+
+// Registry holds the registered plugins, indexed by name
+type Registry struct {
+	mu      sync.RWMutex
+	plugins map[string]Plugin
+}
+
+// NewRegistry returns a pointer to a new empty registry
+func NewRegistry() *Registry {
+	return &Registry{plugins: map[string]Plugin{}}
+}
+
+// Register adds a plugin, its name must be unique
+func (registry *Registry) Register(plugin Plugin) error {
+	name := plugin.Name()
+	if !nameRegexp.MatchString(name) {
+		return fmt.Errorf("invalid plugin name %q, it must only contain lowercase alphanumeric characters and dashes", name)
+	}
+
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+
+	if _, ok := registry.plugins[name]; ok {
+		return fmt.Errorf("the plugin %s is already registered", name)
+	}
+
+	registry.plugins[name] = plugin
+
+	return nil
+}
+
+// Plugins returns the registered plugins, sorted by name
+func (registry *Registry) Plugins() []Plugin {
+	registry.mu.RLock()
+	defer registry.mu.RUnlock()
+
+	names := make([]string, 0, len(registry.plugins))
+	for name := range registry.plugins {
+		names = append(names, name)
+	}
+
+	slices.Sort(names)
+
+	plugins := make([]Plugin, 0, len(names))
+	for _, name := range names {
+		plugins = append(plugins, registry.plugins[name])
+	}
+
+	return plugins
+}
+
+// defaultRegistry is the registry used by Portainer
+var defaultRegistry = NewRegistry()
+
+// Register adds a plugin to the default registry, out-of-tree plugins call it from an init function
+func Register(plugin Plugin) error {
+	return defaultRegistry.Register(plugin)
+}
+
+// Plugins returns the plugins of the default registry, sorted by name
+func Plugins() []Plugin {
+	return defaultRegistry.Plugins()
+}
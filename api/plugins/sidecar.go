@@ -0,0 +1,86 @@
+package plugins
+
+import (
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+)
+
+// Headers describing the authenticated user, set on the requests forwarded to the sidecars
+const (
+	UserIDHeader   = "X-Portainer-User-Id"
+	UsernameHeader = "X-Portainer-Username"
+	RoleHeader     = "X-Portainer-Role"
+)
+
+// sidecar is a plugin running in its own process, the requests are forwarded to it over HTTP
+type sidecar struct {
+	name   string
+	target *url.URL
+}
+
+// NewSidecar returns a plugin forwarding its requests to the service listening on rawURL
+func NewSidecar(name, rawURL string) (Plugin, error) {
+	target, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid URL for the plugin %s", name)
+	}
+
+	if target.Scheme != "http" && target.Scheme != "https" {
+		return nil, errors.Errorf("invalid URL for the plugin %s, the scheme must be http or https", name)
+	}
+
+	return &sidecar{name: name, target: target}, nil
+}
+
+func (plugin *sidecar) Name() string {
+	return plugin.name
+}
+
+func (plugin *sidecar) Handler(host Host) http.Handler {
+	proxy := httputil.NewSingleHostReverseProxy(plugin.target)
+
+	director := proxy.Director
+	proxy.Director = func(r *http.Request) {
+		director(r)
+
+		// the credentials of the user are never forwarded, the sidecar relies on the headers below
+		r.Header.Del("Authorization")
+		r.Header.Del("Cookie")
+		r.Header.Del("X-API-KEY")
+
+		query := r.URL.Query()
+		for key := range query {
+			if strings.EqualFold(key, "X-API-KEY") {
+				query.Del(key)
+			}
+		}
+		r.URL.RawQuery = query.Encode()
+
+		r.Header.Del(UserIDHeader)
+		r.Header.Del(UsernameHeader)
+		r.Header.Del(RoleHeader)
+
+		tokenData, err := host.TokenData(r)
+		if err != nil {
+			return
+		}
+
+		r.Header.Set(UserIDHeader, strconv.Itoa(int(tokenData.ID)))
+		r.Header.Set(UsernameHeader, tokenData.Username)
+		r.Header.Set(RoleHeader, strconv.Itoa(int(tokenData.Role)))
+	}
+
+	proxy.ErrorHandler = func(w http.ResponseWriter, r *http.Request, err error) {
+		log.Warn().Err(err).Str("plugin", plugin.name).Msg("unable to reach the plugin")
+
+		w.WriteHeader(http.StatusBadGateway)
+	}
+
+	return proxy
+}
@@ -0,0 +1,81 @@
+package plugins
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+
+	"github.com/stretchr/testify/require"
+)
+
+type testHost struct {
+	tokenData *portainer.TokenData
+}
+
+func (host *testHost) DataStore() dataservices.DataStore {
+	return nil
+}
+
+func (host *testHost) TokenData(r *http.Request) (*portainer.TokenData, error) {
+	return host.tokenData, nil
+}
+
+func TestSidecar_ForwardsTheUserWithoutItsCredentials(t *testing.T) {
+	is := require.New(t)
+
+	var received *http.Request
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer backend.Close()
+
+	plugin, err := NewSidecar("inventory", backend.URL)
+	is.NoError(err)
+
+	handler := plugin.Handler(&testHost{tokenData: &portainer.TokenData{ID: 3, Username: "alice", Role: portainer.StandardUserRole}})
+
+	req := httptest.NewRequest(http.MethodGet, "/assets?x-api-key=secret&page=2", nil)
+	req.Header.Set("Authorization", "Bearer token")
+	req.Header.Set("Cookie", "portainer_api_key=token")
+	req.Header.Set(UsernameHeader, "admin")
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	is.Equal(http.StatusNoContent, rr.Code)
+	is.NotNil(received)
+	is.Equal("/assets", received.URL.Path)
+	is.Equal("page=2", received.URL.RawQuery)
+	is.Empty(received.Header.Get("Authorization"))
+	is.Empty(received.Header.Get("Cookie"))
+	is.Equal("3", received.Header.Get(UserIDHeader))
+	is.Equal("alice", received.Header.Get(UsernameHeader))
+	is.Equal("2", received.Header.Get(RoleHeader))
+}
+
+func TestNewSidecar_InvalidURL(t *testing.T) {
+	_, err := NewSidecar("inventory", "ftp://localhost")
+	require.Error(t, err)
+}
+
+func TestRegistry(t *testing.T) {
+	is := require.New(t)
+
+	registry := NewRegistry()
+
+	b, _ := NewSidecar("b", "http://localhost:8001")
+	a, _ := NewSidecar("a", "http://localhost:8002")
+
+	is.NoError(registry.Register(b))
+	is.NoError(registry.Register(a))
+	is.Error(registry.Register(a), "a name can only be registered once")
+
+	invalid, _ := NewSidecar("Invalid/Name", "http://localhost:8003")
+	is.Error(registry.Register(invalid))
+
+	is.Equal([]Plugin{a, b}, registry.Plugins())
+}
@@ -0,0 +1,85 @@
+// Package registration handles the tokens the agents present to register themselves as new environments(endpoints) on
+// their first contact, instead of an administrator creating each environment before deploying its agent
+package registration
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+)
+
+// TokenPrefix is the prefix of the registration tokens, it tells them apart from the API keys
+const TokenPrefix = "prt_"
+
+// prefixLength is the number of characters of the tokens kept to identify them
+const prefixLength = 7
+
+var (
+	// ErrInvalidToken is returned when the token is unknown, expired or used up, the cause is not disclosed to the
+	// agents
+	ErrInvalidToken = errors.New("invalid registration token")
+	// ErrTokenExpired is returned when the token expired
+	ErrTokenExpired = errors.New("the registration token expired")
+	// ErrTokenUsedUp is returned when the token reached its maximum number of registrations
+	ErrTokenUsedUp = errors.New("the registration token reached its maximum number of registrations")
+)
+
+// NewToken generates a registration token, it returns the token itself once, only its digest is persisted
+func NewToken(name string, groupID portainer.EndpointGroupID, tagIDs []portainer.TagID, maxUses int, validity time.Duration, createdBy portainer.UserID, now time.Time) (string, *portainer.RegistrationToken, error) {
+	if maxUses < 0 {
+		return "", nil, errors.New("the maximum number of registrations cannot be negative")
+	}
+
+	if validity < 0 {
+		return "", nil, errors.New("the validity of the token cannot be negative")
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", nil, fmt.Errorf("unable to generate the registration token: %w", err)
+	}
+
+	raw := TokenPrefix + base64.RawURLEncoding.EncodeToString(secret)
+
+	token := &portainer.RegistrationToken{
+		Name:      name,
+		Digest:    Digest(raw),
+		Prefix:    raw[:prefixLength],
+		GroupID:   groupID,
+		TagIDs:    tagIDs,
+		MaxUses:   maxUses,
+		CreatedAt: now.Unix(),
+		CreatedBy: createdBy,
+	}
+
+	if validity > 0 {
+		token.ExpiresAt = now.Add(validity).Unix()
+	}
+
+	return raw, token, nil
+}
+
+// Digest returns the digest a registration token is looked up by
+func Digest(raw string) string {
+	digest := sha256.Sum256([]byte(raw))
+
+	return base64.StdEncoding.EncodeToString(digest[:])
+}
+
+// Check returns an error when the token cannot register one more environment(endpoint)
+func Check(token *portainer.RegistrationToken, now time.Time) error {
+	if token.ExpiresAt != 0 && token.ExpiresAt <= now.Unix() {
+		return ErrTokenExpired
+	}
+
+	if token.MaxUses > 0 && token.Uses >= token.MaxUses {
+		return ErrTokenUsedUp
+	}
+
+	return nil
+}
@@ -0,0 +1,46 @@
+package registration
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewToken(t *testing.T) {
+	is := require.New(t)
+
+	now := time.Unix(1_000_000, 0)
+
+	raw, token, err := NewToken("datacenter-1", 2, []portainer.TagID{1}, 1, time.Hour, 1, now)
+	is.NoError(err)
+	is.True(strings.HasPrefix(raw, TokenPrefix))
+	is.Equal(raw[:prefixLength], token.Prefix)
+	is.Equal(Digest(raw), token.Digest)
+	is.NotContains(token.Digest, raw)
+	is.Equal(now.Add(time.Hour).Unix(), token.ExpiresAt)
+
+	other, _, err := NewToken("datacenter-1", 2, nil, 1, 0, 1, now)
+	is.NoError(err)
+	is.NotEqual(raw, other)
+
+	_, _, err = NewToken("invalid", 1, nil, -1, 0, 1, now)
+	is.Error(err)
+}
+
+func TestCheck(t *testing.T) {
+	is := require.New(t)
+
+	now := time.Unix(1_000_000, 0)
+
+	is.NoError(Check(&portainer.RegistrationToken{}, now))
+	is.NoError(Check(&portainer.RegistrationToken{MaxUses: 2, Uses: 1, ExpiresAt: now.Unix() + 1}, now))
+	is.ErrorIs(Check(&portainer.RegistrationToken{ExpiresAt: now.Unix()}, now), ErrTokenExpired)
+	is.ErrorIs(Check(&portainer.RegistrationToken{MaxUses: 1, Uses: 1}, now), ErrTokenUsedUp)
+
+	// the tokens without a maximum number of registrations are never used up
+	is.NoError(Check(&portainer.RegistrationToken{Uses: 1000}, now))
+}
@@ -0,0 +1,132 @@
+package registrycredentials
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/aws/awsauth"
+
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+)
+
+const awsRoleSessionName = "portainer-registry-credentials"
+
+// awsSecretsManagerEndpoint returns the endpoint of AWS Secrets Manager in a region
+var awsSecretsManagerEndpoint = func(region string) string {
+	return "https://secretsmanager." + region + ".amazonaws.com/"
+}
+
+func validateAWSSecretsManager(source *portainer.AWSSecretsManagerSecretSource) error {
+	if source == nil {
+		return errors.New("the AWS Secrets Manager secret is required")
+	}
+
+	if source.Region == "" || source.SecretID == "" {
+		return errors.New("the region and the identifier of the AWS Secrets Manager secret are required")
+	}
+
+	if !source.UseDefaultCredentials && (source.AccessKeyID == "" || source.SecretAccessKey == "") {
+		return errors.New("an access key is required unless the default AWS credentials are used")
+	}
+
+	if source.ExternalID != "" && source.RoleARN == "" {
+		return errors.New("an external ID requires a role ARN")
+	}
+
+	return nil
+}
+
+// readAWSSecret reads a secret of AWS Secrets Manager with the GetSecretValue action, the secret string being a JSON
+// object
+func readAWSSecret(ctx context.Context, source *portainer.AWSSecretsManagerSecretSource) (map[string]any, error) {
+	if source == nil {
+		return nil, errors.New("the AWS Secrets Manager secret is not configured")
+	}
+
+	provider, err := awsauth.NewCredentialsProvider(awsauth.Credentials{
+		AccessKey:  source.AccessKeyID,
+		SecretKey:  source.SecretAccessKey,
+		UseDefault: source.UseDefaultCredentials,
+		RoleARN:    source.RoleARN,
+		ExternalID: source.ExternalID,
+	}, source.Region, awsRoleSessionName)
+	if err != nil {
+		return nil, err
+	}
+
+	creds, err := provider.Retrieve(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("unable to retrieve the AWS credentials: %w", err)
+	}
+
+	body, _ := json.Marshal(map[string]string{"SecretId": source.SecretID})
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, awsSecretsManagerEndpoint(source.Region), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", "secretsmanager.GetSecretValue")
+
+	hash := sha256.Sum256(body)
+	if err := v4.NewSigner().SignHTTP(ctx, creds, req, hex.EncodeToString(hash[:]), "secretsmanager", source.Region, time.Now()); err != nil {
+		return nil, fmt.Errorf("unable to sign the request: %w", err)
+	}
+
+	resp, err := (&http.Client{Timeout: requestTimeout}).Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("unable to reach AWS Secrets Manager: %w", err)
+	}
+	defer resp.Body.Close()
+
+	content, err := io.ReadAll(io.LimitReader(resp.Body, 1024*1024))
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		var awsErr struct {
+			Type    string `json:"__type"`
+			Message string `json:"message"`
+		}
+
+		if err := json.Unmarshal(content, &awsErr); err == nil && awsErr.Type != "" {
+			return nil, fmt.Errorf("AWS Secrets Manager error %d: %s: %s", resp.StatusCode, awsErr.Type, awsErr.Message)
+		}
+
+		return nil, fmt.Errorf("AWS Secrets Manager error %d", resp.StatusCode)
+	}
+
+	var value struct {
+		SecretString string `json:"SecretString"`
+		SecretBinary string `json:"SecretBinary"`
+	}
+	if err := json.Unmarshal(content, &value); err != nil {
+		return nil, fmt.Errorf("unable to decode the response of AWS Secrets Manager: %w", err)
+	}
+
+	secret := []byte(value.SecretString)
+	if value.SecretString == "" && value.SecretBinary != "" {
+		if secret, err = base64.StdEncoding.DecodeString(value.SecretBinary); err != nil {
+			return nil, fmt.Errorf("unable to decode the binary secret: %w", err)
+		}
+	}
+
+	var data map[string]any
+	if err := json.Unmarshal(secret, &data); err != nil {
+		return nil, errors.New("the secret is not a JSON object")
+	}
+
+	return data, nil
+}
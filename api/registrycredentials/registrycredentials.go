@@ -0,0 +1,130 @@
+// Package registrycredentials retrieves the credentials of the registries from external secret managers, HashiCorp
+// Vault and AWS Secrets Manager, so that they are rotated in the secret manager rather than in Portainer
+package registrycredentials
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+)
+
+const (
+	defaultUsernameKey = "username"
+	defaultPasswordKey = "password"
+	// defaultRefreshInterval is the interval between the refreshes of the credentials when the provider has none
+	defaultRefreshInterval = time.Hour
+	// minRefreshInterval is the shortest interval between the refreshes of the credentials of a registry
+	minRefreshInterval = 5 * time.Minute
+	requestTimeout     = 30 * time.Second
+)
+
+// Credentials are the credentials of a registry retrieved from a secret manager
+type Credentials struct {
+	Username string
+	Password string
+}
+
+// Validate checks the configuration of the credential provider of a registry
+func Validate(provider *portainer.RegistryCredentialProvider) error {
+	if provider.RefreshInterval != "" {
+		interval, err := time.ParseDuration(provider.RefreshInterval)
+		if err != nil {
+			return fmt.Errorf("invalid refresh interval: %w", err)
+		} else if interval < minRefreshInterval {
+			return fmt.Errorf("invalid refresh interval, it must be at least %s", minRefreshInterval)
+		}
+	}
+
+	switch provider.Type {
+	case portainer.VaultCredentialProvider:
+		return validateVault(provider.Vault)
+	case portainer.AWSSecretsManagerCredentialProvider:
+		return validateAWSSecretsManager(provider.AWSSecretsManager)
+	}
+
+	return errors.New("invalid credential provider type. Valid values are: vault, awsSecretsManager")
+}
+
+// Retrieve reads the secret of the credential provider of a registry and returns the credentials it holds
+func Retrieve(ctx context.Context, provider *portainer.RegistryCredentialProvider) (*Credentials, error) {
+	ctx, cancel := context.WithTimeout(ctx, requestTimeout)
+	defer cancel()
+
+	var secret map[string]any
+	var err error
+
+	switch provider.Type {
+	case portainer.VaultCredentialProvider:
+		secret, err = readVaultSecret(ctx, provider.Vault)
+	case portainer.AWSSecretsManagerCredentialProvider:
+		secret, err = readAWSSecret(ctx, provider.AWSSecretsManager)
+	default:
+		err = fmt.Errorf("unsupported credential provider type %q", provider.Type)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	usernameKey := cmp.Or(provider.UsernameKey, defaultUsernameKey)
+	passwordKey := cmp.Or(provider.PasswordKey, defaultPasswordKey)
+
+	username, ok := secret[usernameKey].(string)
+	if !ok || username == "" {
+		return nil, fmt.Errorf("the secret has no %s key", usernameKey)
+	}
+
+	password, ok := secret[passwordKey].(string)
+	if !ok || password == "" {
+		return nil, fmt.Errorf("the secret has no %s key", passwordKey)
+	}
+
+	return &Credentials{Username: username, Password: password}, nil
+}
+
+// MergeSecrets keeps the secrets of the current configuration of a provider that the new configuration leaves empty,
+// the secrets being hidden from the API responses
+func MergeSecrets(provider, current *portainer.RegistryCredentialProvider) {
+	if current == nil || provider.Type != current.Type {
+		return
+	}
+
+	if provider.Vault != nil && current.Vault != nil {
+		if provider.Vault.Token == "" && provider.Vault.RoleID == "" {
+			provider.Vault.Token = current.Vault.Token
+		}
+
+		if provider.Vault.RoleID != "" && provider.Vault.RoleID == current.Vault.RoleID {
+			provider.Vault.SecretID = cmp.Or(provider.Vault.SecretID, current.Vault.SecretID)
+		}
+	}
+
+	if provider.AWSSecretsManager != nil && current.AWSSecretsManager != nil && provider.AWSSecretsManager.AccessKeyID == current.AWSSecretsManager.AccessKeyID {
+		provider.AWSSecretsManager.SecretAccessKey = cmp.Or(provider.AWSSecretsManager.SecretAccessKey, current.AWSSecretsManager.SecretAccessKey)
+	}
+}
+
+// HideSecrets clears the secrets of the configuration of a provider
+func HideSecrets(provider *portainer.RegistryCredentialProvider) {
+	if provider == nil {
+		return
+	}
+
+	if provider.Vault != nil {
+		provider.Vault.Token = ""
+		provider.Vault.SecretID = ""
+	}
+
+	if provider.AWSSecretsManager != nil {
+		provider.AWSSecretsManager.SecretAccessKey = ""
+	}
+}
+
+func trimSlashes(path string) string {
+	return strings.Trim(path, "/")
+}
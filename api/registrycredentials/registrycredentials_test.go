@@ -0,0 +1,210 @@
+package registrycredentials
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/datastore"
+
+	"github.com/stretchr/testify/require"
+)
+
+// fakeVault serves a KV v1 and a KV v2 secret, readable with a token or after an AppRole login
+func fakeVault(t *testing.T, password *string) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v1/auth/approle/login" {
+			var body map[string]string
+			json.NewDecoder(r.Body).Decode(&body)
+
+			if body["role_id"] != "role" || body["secret_id"] != "secret" {
+				w.WriteHeader(http.StatusBadRequest)
+				json.NewEncoder(w).Encode(map[string]any{"errors": []string{"invalid role or secret ID"}})
+				return
+			}
+
+			json.NewEncoder(w).Encode(map[string]any{"auth": map[string]any{"client_token": "approle-token"}})
+			return
+		}
+
+		token := r.Header.Get("X-Vault-Token")
+		if token != "token" && token != "approle-token" {
+			w.WriteHeader(http.StatusForbidden)
+			json.NewEncoder(w).Encode(map[string]any{"errors": []string{"permission denied"}})
+			return
+		}
+
+		data := map[string]any{"username": "robot", "password": *password}
+
+		switch r.URL.Path {
+		case "/v1/secret/data/registries/harbor":
+			json.NewEncoder(w).Encode(map[string]any{"data": map[string]any{"data": data}})
+		case "/v1/kv/registries/harbor":
+			json.NewEncoder(w).Encode(map[string]any{"data": data})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(map[string]any{"errors": []string{}})
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func TestRetrieveVault(t *testing.T) {
+	is := require.New(t)
+
+	password := "s3cret"
+	server := fakeVault(t, &password)
+
+	provider := &portainer.RegistryCredentialProvider{
+		Type:  portainer.VaultCredentialProvider,
+		Vault: &portainer.VaultSecretSource{Address: server.URL, Path: "/registries/harbor", KVVersion: 2, Token: "token"},
+	}
+	is.NoError(Validate(provider))
+
+	creds, err := Retrieve(context.Background(), provider)
+	is.NoError(err)
+	is.Equal(&Credentials{Username: "robot", Password: "s3cret"}, creds)
+
+	provider.Vault = &portainer.VaultSecretSource{Address: server.URL, Mount: "kv", Path: "registries/harbor", KVVersion: 1, RoleID: "role", SecretID: "secret"}
+	creds, err = Retrieve(context.Background(), provider)
+	is.NoError(err)
+	is.Equal("robot", creds.Username)
+
+	provider.Vault.SecretID = "wrong"
+	_, err = Retrieve(context.Background(), provider)
+	is.ErrorContains(err, "invalid role or secret ID")
+
+	provider.Vault = &portainer.VaultSecretSource{Address: server.URL, Path: "registries/harbor", KVVersion: 2, Token: "token"}
+	provider.PasswordKey = "token"
+	_, err = Retrieve(context.Background(), provider)
+	is.ErrorContains(err, "the secret has no token key")
+}
+
+func TestRetrieveAWSSecretsManager(t *testing.T) {
+	is := require.New(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=AKIAEXAMPLE/") || r.Header.Get("X-Amz-Target") != "secretsmanager.GetSecretValue" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+
+		var body map[string]string
+		json.NewDecoder(r.Body).Decode(&body)
+
+		if body["SecretId"] != "registries/ecr" {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"__type": "ResourceNotFoundException", "message": "Secrets Manager can't find the specified secret."})
+			return
+		}
+
+		json.NewEncoder(w).Encode(map[string]string{"SecretString": `{"user":"AKIAREGISTRY","secret":"registry-secret"}`})
+	}))
+	t.Cleanup(server.Close)
+
+	endpoint := awsSecretsManagerEndpoint
+	awsSecretsManagerEndpoint = func(string) string { return server.URL }
+	t.Cleanup(func() { awsSecretsManagerEndpoint = endpoint })
+
+	provider := &portainer.RegistryCredentialProvider{
+		Type:              portainer.AWSSecretsManagerCredentialProvider,
+		AWSSecretsManager: &portainer.AWSSecretsManagerSecretSource{Region: "eu-west-1", SecretID: "registries/ecr", AccessKeyID: "AKIAEXAMPLE", SecretAccessKey: "example"},
+		UsernameKey:       "user",
+		PasswordKey:       "secret",
+	}
+	is.NoError(Validate(provider))
+
+	creds, err := Retrieve(context.Background(), provider)
+	is.NoError(err)
+	is.Equal(&Credentials{Username: "AKIAREGISTRY", Password: "registry-secret"}, creds)
+
+	provider.AWSSecretsManager.SecretID = "missing"
+	_, err = Retrieve(context.Background(), provider)
+	is.ErrorContains(err, "ResourceNotFoundException")
+}
+
+func TestValidate(t *testing.T) {
+	is := require.New(t)
+
+	vault := &portainer.VaultSecretSource{Address: "https://vault.example.com", Path: "registries/harbor", KVVersion: 2, Token: "token"}
+
+	is.NoError(Validate(&portainer.RegistryCredentialProvider{Type: portainer.VaultCredentialProvider, Vault: vault, RefreshInterval: "30m"}))
+	is.Error(Validate(&portainer.RegistryCredentialProvider{Type: portainer.VaultCredentialProvider, Vault: vault, RefreshInterval: "1m"}))
+	is.Error(Validate(&portainer.RegistryCredentialProvider{Type: portainer.VaultCredentialProvider}))
+	is.Error(Validate(&portainer.RegistryCredentialProvider{Type: "file", Vault: vault}))
+	is.Error(Validate(&portainer.RegistryCredentialProvider{Type: portainer.VaultCredentialProvider, Vault: &portainer.VaultSecretSource{Address: "vault:8200", Path: "harbor", KVVersion: 2, Token: "token"}}))
+	is.Error(Validate(&portainer.RegistryCredentialProvider{
+		Type:              portainer.AWSSecretsManagerCredentialProvider,
+		AWSSecretsManager: &portainer.AWSSecretsManagerSecretSource{Region: "eu-west-1", SecretID: "registries/ecr"},
+	}))
+}
+
+func TestServiceRefresh(t *testing.T) {
+	is := require.New(t)
+
+	_, store := datastore.MustNewTestStore(t, true, false)
+
+	password := "first"
+	server := fakeVault(t, &password)
+
+	registry := &portainer.Registry{
+		Name:                    "harbor",
+		Type:                    portainer.HarborRegistry,
+		URL:                     "harbor.example.com",
+		ManagementConfiguration: &portainer.RegistryManagementConfiguration{},
+	}
+	is.NoError(store.Registry().Create(registry))
+
+	service := NewService(store, nil)
+
+	provider := &portainer.RegistryCredentialProvider{
+		Type:  portainer.VaultCredentialProvider,
+		Vault: &portainer.VaultSecretSource{Address: server.URL, Path: "registries/harbor", KVVersion: 2, Token: "wrong"},
+	}
+	is.ErrorIs(service.Configure(context.Background(), registry, provider), ErrRetrieve)
+	is.Nil(registry.CredentialProvider)
+
+	provider.Vault.Token = "token"
+	is.NoError(service.Configure(context.Background(), registry, provider))
+
+	stored, err := store.Registry().Read(registry.ID)
+	is.NoError(err)
+	is.True(stored.Authentication)
+	is.Equal("first", stored.Password)
+	is.Equal("first", stored.ManagementConfiguration.Password)
+	is.Len(stored.CredentialProvider.Events, 1)
+
+	// the credentials are not refreshed before the interval elapses
+	password = "second"
+	is.NoError(service.RefreshIfDue())
+
+	stored, err = store.Registry().Read(registry.ID)
+	is.NoError(err)
+	is.Equal("first", stored.Password)
+
+	stored.CredentialProvider.LastRefresh -= 3600
+	is.NoError(store.Registry().Update(stored.ID, stored))
+	is.NoError(service.RefreshIfDue())
+
+	stored, err = store.Registry().Read(registry.ID)
+	is.NoError(err)
+	is.Equal("second", stored.Password)
+	is.Len(stored.CredentialProvider.Events, 2)
+	is.Equal(portainer.RegistryCredentialsRotated, stored.CredentialProvider.Events[1].Type)
+
+	// a failure keeps the last credentials
+	stored.CredentialProvider.Vault.Token = "revoked"
+	is.ErrorIs(service.Refresh(context.Background(), stored), ErrRetrieve)
+
+	stored, err = store.Registry().Read(registry.ID)
+	is.NoError(err)
+	is.Equal("second", stored.Password)
+	is.Contains(stored.CredentialProvider.LastError, "permission denied")
+	is.Equal(portainer.RegistryCredentialsRefreshFailed, stored.CredentialProvider.Events[2].Type)
+}
@@ -0,0 +1,237 @@
+package registrycredentials
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+	"github.com/portainer/portainer/api/internal/endpointutils"
+	"github.com/portainer/portainer/api/kubernetes/cli"
+
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// CheckInterval is the interval between the checks of whether the credentials of the registries are due a refresh
+	CheckInterval = time.Minute
+	// maxEvents is the number of rotation and failure events kept for a registry
+	maxEvents = 20
+)
+
+// ErrRetrieve is returned when the credentials of a registry cannot be retrieved from its secret manager
+var ErrRetrieve = errors.New("unable to retrieve the credentials")
+
+// Service refreshes the credentials of the registries sourced from a secret manager
+type Service struct {
+	dataStore        dataservices.DataStore
+	k8sClientFactory *cli.ClientFactory
+	mu               sync.Mutex
+}
+
+// NewService creates a new registry credentials service
+func NewService(dataStore dataservices.DataStore, k8sClientFactory *cli.ClientFactory) *Service {
+	return &Service{dataStore: dataStore, k8sClientFactory: k8sClientFactory}
+}
+
+// RefreshIfDue refreshes the credentials of the registries whose refresh interval elapsed since their last refresh
+func (service *Service) RefreshIfDue() error {
+	registries, err := service.dataStore.Registry().ReadAll()
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	for i := range registries {
+		registry := &registries[i]
+		if registry.CredentialProvider == nil || !isDue(registry.CredentialProvider, now) {
+			continue
+		}
+
+		// the failures are recorded on the registry
+		_ = service.Refresh(context.Background(), registry)
+	}
+
+	return nil
+}
+
+// Refresh retrieves the credentials of a registry from its secret manager and stores them, updating the registry
+// secrets of the Kubernetes namespaces using the registry when they were rotated. The rotations and the failures are
+// recorded as events of the registry
+func (service *Service) Refresh(ctx context.Context, registry *portainer.Registry) error {
+	if registry.CredentialProvider == nil {
+		return errors.New("the registry has no credential provider")
+	}
+
+	service.mu.Lock()
+	defer service.mu.Unlock()
+
+	provider := registry.CredentialProvider
+	provider.LastRefresh = time.Now().Unix()
+
+	creds, err := Retrieve(ctx, provider)
+	if err != nil {
+		service.recordFailure(registry, "unable to retrieve the credentials: "+err.Error())
+
+		return fmt.Errorf("%w: %w", ErrRetrieve, err)
+	}
+
+	return service.store(registry, creds)
+}
+
+// Configure sources the credentials of a registry from a secret manager, the configuration being only stored when the
+// credentials can be retrieved
+func (service *Service) Configure(ctx context.Context, registry *portainer.Registry, provider *portainer.RegistryCredentialProvider) error {
+	service.mu.Lock()
+	defer service.mu.Unlock()
+
+	if current := registry.CredentialProvider; current != nil {
+		provider.Events = current.Events
+	}
+	provider.LastRefresh = time.Now().Unix()
+	provider.LastError = ""
+
+	creds, err := Retrieve(ctx, provider)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrRetrieve, err)
+	}
+
+	registry.CredentialProvider = provider
+
+	return service.store(registry, creds)
+}
+
+// store saves the credentials retrieved for a registry and updates its Kubernetes registry secrets when they changed,
+// the failures to update the secrets being only recorded as the credentials are stored
+func (service *Service) store(registry *portainer.Registry, creds *Credentials) error {
+	provider := registry.CredentialProvider
+	provider.LastError = ""
+
+	rotated := Apply(registry, creds)
+	if rotated {
+		addEvent(provider, portainer.RegistryCredentialsRotated, "the credentials of the registry were rotated")
+	}
+
+	if err := service.dataStore.Registry().Update(registry.ID, registry); err != nil {
+		return err
+	}
+
+	if !rotated {
+		return nil
+	}
+
+	log.Info().Int("registry_id", int(registry.ID)).Msg("the credentials of the registry were rotated")
+
+	if err := service.updateKubernetesSecrets(registry); err != nil {
+		service.recordFailure(registry, err.Error())
+	}
+
+	return nil
+}
+
+// recordFailure stores a refresh failure as the last error and an event of a registry
+func (service *Service) recordFailure(registry *portainer.Registry, message string) {
+	registry.CredentialProvider.LastError = message
+	addEvent(registry.CredentialProvider, portainer.RegistryCredentialsRefreshFailed, message)
+
+	log.Warn().Int("registry_id", int(registry.ID)).Str("error", message).Msg("unable to refresh the credentials of the registry")
+
+	if err := service.dataStore.Registry().Update(registry.ID, registry); err != nil {
+		log.Warn().Err(err).Int("registry_id", int(registry.ID)).Msg("unable to persist the credential refresh failure")
+	}
+}
+
+// Apply sets the credentials of a registry and of its management configuration, and reports whether they changed
+func Apply(registry *portainer.Registry, creds *Credentials) bool {
+	rotated := !registry.Authentication || registry.Username != creds.Username || registry.Password != creds.Password
+
+	registry.Authentication = true
+	registry.Username = creds.Username
+	registry.Password = creds.Password
+
+	if registry.ManagementConfiguration != nil {
+		registry.ManagementConfiguration.Authentication = true
+		registry.ManagementConfiguration.Username = creds.Username
+		registry.ManagementConfiguration.Password = creds.Password
+	}
+
+	if rotated {
+		// the ECR token was exchanged for the previous credentials
+		registry.AccessToken = ""
+		registry.AccessTokenExpiry = 0
+	}
+
+	return rotated
+}
+
+// updateKubernetesSecrets recreates the registry secrets of the Kubernetes namespaces with access to a registry
+func (service *Service) updateKubernetesSecrets(registry *portainer.Registry) error {
+	if service.k8sClientFactory == nil {
+		return nil
+	}
+
+	var errs []error
+
+	for endpointID, access := range registry.RegistryAccesses {
+		if len(access.Namespaces) == 0 {
+			continue
+		}
+
+		endpoint, err := service.dataStore.Endpoint().Endpoint(endpointID)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("unable to find the environment %d: %w", endpointID, err))
+
+			continue
+		}
+
+		if !endpointutils.IsKubernetesEndpoint(endpoint) {
+			continue
+		}
+
+		kcl, err := service.k8sClientFactory.GetPrivilegedKubeClient(endpoint)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("unable to create the Kubernetes client of the environment %s: %w", endpoint.Name, err))
+
+			continue
+		}
+
+		for _, namespace := range access.Namespaces {
+			if err := kcl.DeleteRegistrySecret(registry.ID, namespace); err != nil {
+				errs = append(errs, fmt.Errorf("unable to update the registry secret of the namespace %s of the environment %s: %w", namespace, endpoint.Name, err))
+
+				continue
+			}
+
+			if err := kcl.CreateRegistrySecret(registry, namespace); err != nil {
+				errs = append(errs, fmt.Errorf("unable to update the registry secret of the namespace %s of the environment %s: %w", namespace, endpoint.Name, err))
+			}
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func isDue(provider *portainer.RegistryCredentialProvider, now time.Time) bool {
+	interval, err := time.ParseDuration(provider.RefreshInterval)
+	if err != nil || interval < minRefreshInterval {
+		interval = defaultRefreshInterval
+	}
+
+	return now.Sub(time.Unix(provider.LastRefresh, 0)) >= interval
+}
+
+// addEvent records an event of a credential provider, keeping the most recent ones
+func addEvent(provider *portainer.RegistryCredentialProvider, eventType portainer.RegistryCredentialEventType, message string) {
+	provider.Events = append(provider.Events, portainer.RegistryCredentialEvent{
+		Timestamp: time.Now().Unix(),
+		Type:      eventType,
+		Message:   message,
+	})
+
+	if len(provider.Events) > maxEvents {
+		provider.Events = provider.Events[len(provider.Events)-maxEvents:]
+	}
+}
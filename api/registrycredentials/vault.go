@@ -0,0 +1,149 @@
+package registrycredentials
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	portainer "github.com/portainer/portainer/api"
+)
+
+const defaultVaultMount = "secret"
+
+func validateVault(source *portainer.VaultSecretSource) error {
+	if source == nil {
+		return errors.New("the Vault secret is required")
+	}
+
+	if u, err := url.Parse(source.Address); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return errors.New("invalid Vault address, it must be an http or https URL")
+	}
+
+	if trimSlashes(source.Path) == "" {
+		return errors.New("the path of the Vault secret is required")
+	}
+
+	if source.KVVersion != 1 && source.KVVersion != 2 {
+		return errors.New("invalid KV secrets engine version. Valid values are: 1, 2")
+	}
+
+	if source.Token == "" && source.RoleID == "" {
+		return errors.New("a Vault token or an AppRole role ID is required")
+	}
+
+	if source.RoleID != "" && source.SecretID == "" {
+		return errors.New("the secret ID of the AppRole is required")
+	}
+
+	return nil
+}
+
+// readVaultSecret reads a secret of the KV secrets engine of Vault, authenticated with a token or with AppRole
+func readVaultSecret(ctx context.Context, source *portainer.VaultSecretSource) (map[string]any, error) {
+	if source == nil {
+		return nil, errors.New("the Vault secret is not configured")
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if source.TLSSkipVerify {
+		transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+	}
+	client := &http.Client{Transport: transport, Timeout: requestTimeout}
+
+	address := strings.TrimSuffix(source.Address, "/")
+
+	token := source.Token
+	if source.RoleID != "" {
+		var login struct {
+			Auth struct {
+				ClientToken string `json:"client_token"`
+			} `json:"auth"`
+		}
+
+		body, _ := json.Marshal(map[string]string{"role_id": source.RoleID, "secret_id": source.SecretID})
+		if err := vaultRequest(ctx, client, http.MethodPost, address+"/v1/auth/approle/login", source.Namespace, "", body, &login); err != nil {
+			return nil, fmt.Errorf("unable to log in to Vault with AppRole: %w", err)
+		}
+
+		token = login.Auth.ClientToken
+	}
+
+	mount := trimSlashes(cmp.Or(source.Mount, defaultVaultMount))
+	path := trimSlashes(source.Path)
+
+	if source.KVVersion == 1 {
+		var secret struct {
+			Data map[string]any `json:"data"`
+		}
+
+		if err := vaultRequest(ctx, client, http.MethodGet, address+"/v1/"+mount+"/"+path, source.Namespace, token, nil, &secret); err != nil {
+			return nil, err
+		}
+
+		return secret.Data, nil
+	}
+
+	var secret struct {
+		Data struct {
+			Data map[string]any `json:"data"`
+		} `json:"data"`
+	}
+
+	if err := vaultRequest(ctx, client, http.MethodGet, address+"/v1/"+mount+"/data/"+path, source.Namespace, token, nil, &secret); err != nil {
+		return nil, err
+	}
+
+	return secret.Data.Data, nil
+}
+
+func vaultRequest(ctx context.Context, client *http.Client, method, url, namespace, token string, body []byte, out any) error {
+	req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	if token != "" {
+		req.Header.Set("X-Vault-Token", token)
+	}
+
+	if namespace != "" {
+		req.Header.Set("X-Vault-Namespace", namespace)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("unable to reach Vault: %w", err)
+	}
+	defer resp.Body.Close()
+
+	content, err := io.ReadAll(io.LimitReader(resp.Body, 1024*1024))
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		var vaultErr struct {
+			Errors []string `json:"errors"`
+		}
+
+		if err := json.Unmarshal(content, &vaultErr); err == nil && len(vaultErr.Errors) > 0 {
+			return fmt.Errorf("vault error %d: %s", resp.StatusCode, strings.Join(vaultErr.Errors, ", "))
+		}
+
+		return fmt.Errorf("vault error %d", resp.StatusCode)
+	}
+
+	if err := json.Unmarshal(content, out); err != nil {
+		return fmt.Errorf("unable to decode the response of Vault: %w", err)
+	}
+
+	return nil
+}
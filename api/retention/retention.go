@@ -0,0 +1,330 @@
+// Package retention purges the records of the subsystems which grow with their use, such as the audit logs or the
+// sessions, past the age and the number limits of their retention policy
+package retention
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+
+	"github.com/rs/zerolog/log"
+)
+
+// PurgeInterval is the interval between the purges of the records past their retention
+const PurgeInterval = time.Hour
+
+const (
+	// CategoryAuditLogs is the category of the audit logs of the state-changing API calls
+	CategoryAuditLogs = "auditLogs"
+	// CategorySessions is the category of the expired user sessions, the active ones are never purged
+	CategorySessions = "sessions"
+	// CategoryLoginAttempts is the category of the failed logins tracked for the lockouts, the ones of the locked out
+	// usernames and IP addresses are never purged
+	CategoryLoginAttempts = "loginAttempts"
+	// CategoryWebhookLogs is the category of the recorded invocations of the webhooks
+	CategoryWebhookLogs = "webhookLogs"
+	// CategoryContainerJobRuns is the category of the runs of the container jobs, the running ones are never purged
+	CategoryContainerJobRuns = "containerJobRuns"
+	// CategoryEdgeJobExecutions is the category of the executions of the Edge jobs reported by the agents, with their
+	// collected logs
+	CategoryEdgeJobExecutions = "edgeJobExecutions"
+	// CategorySessionRecordings is the category of the recordings of the console sessions, the ones of the open
+	// sessions are never purged
+	CategorySessionRecordings = "sessionRecordings"
+	// CategoryPruneReports is the category of the reports of the prune policies, the running ones are never purged
+	CategoryPruneReports = "pruneReports"
+)
+
+// Record is a record which can be purged, identified in its category
+type Record struct {
+	ID int
+	// Unix timestamp the age of the record is computed from
+	Timestamp int64
+}
+
+type category struct {
+	name string
+	// defaultPolicy returns the policy of the category when the settings do not define one
+	defaultPolicy func(settings *portainer.Settings) portainer.RetentionPolicy
+	// records returns the records of the category which can be purged
+	records func(tx dataservices.DataStoreTx, now time.Time) ([]Record, error)
+	delete  func(tx dataservices.DataStoreTx, id int) error
+}
+
+var categories = []category{
+	{
+		name: CategoryAuditLogs,
+		defaultPolicy: func(settings *portainer.Settings) portainer.RetentionPolicy {
+			if settings.AuditLogSettings.RetentionDays <= 0 {
+				return portainer.RetentionPolicy{}
+			}
+
+			return portainer.RetentionPolicy{MaxAge: fmt.Sprintf("%dh", settings.AuditLogSettings.RetentionDays*24)}
+		},
+		records: func(tx dataservices.DataStoreTx, now time.Time) ([]Record, error) {
+			auditLogs, err := tx.AuditLog().ReadAll()
+
+			return toRecords(auditLogs, func(auditLog portainer.AuditLog) (int, int64, bool) {
+				return int(auditLog.ID), auditLog.Timestamp, true
+			}), err
+		},
+		delete: func(tx dataservices.DataStoreTx, id int) error {
+			return tx.AuditLog().Delete(portainer.AuditLogID(id))
+		},
+	},
+	{
+		name:          CategorySessions,
+		defaultPolicy: defaultPolicy(portainer.RetentionPolicy{MaxAge: "720h"}),
+		records: func(tx dataservices.DataStoreTx, now time.Time) ([]Record, error) {
+			sessions, err := tx.Session().ReadAll()
+
+			// the revocations are kept until the sessions expire
+			return toRecords(sessions, func(session portainer.Session) (int, int64, bool) {
+				return int(session.ID), session.ExpiresAt, session.ExpiresAt != 0 && session.ExpiresAt < now.Unix()
+			}), err
+		},
+		delete: func(tx dataservices.DataStoreTx, id int) error {
+			return tx.Session().Delete(portainer.SessionID(id))
+		},
+	},
+	{
+		name:          CategoryLoginAttempts,
+		defaultPolicy: defaultPolicy(portainer.RetentionPolicy{MaxAge: "168h"}),
+		records: func(tx dataservices.DataStoreTx, now time.Time) ([]Record, error) {
+			attempts, err := tx.LoginAttempt().ReadAll()
+
+			return toRecords(attempts, func(attempt portainer.LoginAttempt) (int, int64, bool) {
+				return int(attempt.ID), attempt.LastFailure, attempt.LockedUntil < now.Unix()
+			}), err
+		},
+		delete: func(tx dataservices.DataStoreTx, id int) error {
+			return tx.LoginAttempt().Delete(portainer.LoginAttemptID(id))
+		},
+	},
+	{
+		name:          CategoryWebhookLogs,
+		defaultPolicy: defaultPolicy(portainer.RetentionPolicy{MaxAge: "720h"}),
+		records: func(tx dataservices.DataStoreTx, now time.Time) ([]Record, error) {
+			webhookLogs, err := tx.WebhookLog().ReadAll()
+
+			return toRecords(webhookLogs, func(webhookLog portainer.WebhookLog) (int, int64, bool) {
+				return int(webhookLog.ID), webhookLog.Timestamp, true
+			}), err
+		},
+		delete: func(tx dataservices.DataStoreTx, id int) error {
+			return tx.WebhookLog().Delete(portainer.WebhookLogID(id))
+		},
+	},
+	{
+		name:          CategoryContainerJobRuns,
+		defaultPolicy: defaultPolicy(portainer.RetentionPolicy{MaxAge: "720h"}),
+		records: func(tx dataservices.DataStoreTx, now time.Time) ([]Record, error) {
+			runs, err := tx.ContainerJobRun().ReadAll()
+
+			return toRecords(runs, func(run portainer.ContainerJobRun) (int, int64, bool) {
+				return int(run.ID), run.StartedAt, run.Status != portainer.ContainerJobRunRunning
+			}), err
+		},
+		delete: func(tx dataservices.DataStoreTx, id int) error {
+			return tx.ContainerJobRun().Delete(portainer.ContainerJobRunID(id))
+		},
+	},
+	{
+		name:          CategoryEdgeJobExecutions,
+		defaultPolicy: defaultPolicy(portainer.RetentionPolicy{MaxAge: "720h"}),
+		records: func(tx dataservices.DataStoreTx, now time.Time) ([]Record, error) {
+			executions, err := tx.EdgeJobExecution().ReadAll()
+
+			return toRecords(executions, func(execution portainer.EdgeJobExecution) (int, int64, bool) {
+				return int(execution.ID), execution.StartedAt, true
+			}), err
+		},
+		delete: func(tx dataservices.DataStoreTx, id int) error {
+			return tx.EdgeJobExecution().Delete(portainer.EdgeJobExecutionID(id))
+		},
+	},
+	{
+		name:          CategoryPruneReports,
+		defaultPolicy: defaultPolicy(portainer.RetentionPolicy{MaxAge: "720h"}),
+		records: func(tx dataservices.DataStoreTx, now time.Time) ([]Record, error) {
+			reports, err := tx.PruneReport().ReadAll()
+
+			return toRecords(reports, func(report portainer.PruneReport) (int, int64, bool) {
+				return int(report.ID), report.StartedAt, report.Status != portainer.PruneReportRunning
+			}), err
+		},
+		delete: func(tx dataservices.DataStoreTx, id int) error {
+			return tx.PruneReport().Delete(portainer.PruneReportID(id))
+		},
+	},
+	{
+		name:          CategorySessionRecordings,
+		defaultPolicy: defaultPolicy(portainer.RetentionPolicy{MaxAge: "2160h"}),
+		records: func(tx dataservices.DataStoreTx, now time.Time) ([]Record, error) {
+			recordings, err := tx.SessionRecording().ReadAll()
+
+			// the files of the purged recordings are removed by the cleanup of the session recording service
+			return toRecords(recordings, func(recording portainer.SessionRecording) (int, int64, bool) {
+				return int(recording.ID), recording.StartedAt, recording.EndedAt != 0
+			}), err
+		},
+		delete: func(tx dataservices.DataStoreTx, id int) error {
+			return tx.SessionRecording().Delete(portainer.SessionRecordingID(id))
+		},
+	},
+}
+
+func defaultPolicy(policy portainer.RetentionPolicy) func(*portainer.Settings) portainer.RetentionPolicy {
+	return func(*portainer.Settings) portainer.RetentionPolicy {
+		return policy
+	}
+}
+
+// toRecords returns the records of the elements which can be purged
+func toRecords[T any](elements []T, record func(T) (id int, timestamp int64, purgeable bool)) []Record {
+	records := make([]Record, 0, len(elements))
+	for _, element := range elements {
+		if id, timestamp, ok := record(element); ok {
+			records = append(records, Record{ID: id, Timestamp: timestamp})
+		}
+	}
+
+	return records
+}
+
+// Categories returns the names of the categories of records a retention policy can be defined for
+func Categories() []string {
+	names := make([]string, 0, len(categories))
+	for _, category := range categories {
+		names = append(names, category.name)
+	}
+
+	return names
+}
+
+// ValidatePolicies returns an error when a policy is defined for an unknown category or has invalid limits
+func ValidatePolicies(policies map[string]portainer.RetentionPolicy) error {
+	for name, policy := range policies {
+		if !slices.Contains(Categories(), name) {
+			return fmt.Errorf("unknown retention category %q", name)
+		}
+
+		if policy.MaxEntries < 0 {
+			return fmt.Errorf("the maximum number of entries of the %s retention policy cannot be negative", name)
+		}
+
+		if policy.MaxAge == "" {
+			continue
+		}
+
+		if maxAge, err := time.ParseDuration(policy.MaxAge); err != nil || maxAge <= 0 {
+			return fmt.Errorf("the maximum age of the %s retention policy must be a positive duration, e.g. 720h", name)
+		}
+	}
+
+	return nil
+}
+
+// Expired returns the identifiers of the records older than the maximum age of the policy, and of the records past
+// its maximum number of entries, the oldest first
+func Expired(records []Record, policy portainer.RetentionPolicy, now time.Time) ([]int, error) {
+	records = slices.Clone(records)
+	slices.SortFunc(records, func(a, b Record) int {
+		return cmp.Or(cmp.Compare(a.Timestamp, b.Timestamp), cmp.Compare(a.ID, b.ID))
+	})
+
+	count := 0
+	if policy.MaxEntries > 0 && len(records) > policy.MaxEntries {
+		count = len(records) - policy.MaxEntries
+	}
+
+	if policy.MaxAge != "" {
+		maxAge, err := time.ParseDuration(policy.MaxAge)
+		if err != nil {
+			return nil, err
+		}
+
+		cutoff := now.Add(-maxAge).Unix()
+		for count < len(records) && records[count].Timestamp < cutoff {
+			count++
+		}
+	}
+
+	ids := make([]int, 0, count)
+	for _, record := range records[:count] {
+		ids = append(ids, record.ID)
+	}
+
+	return ids, nil
+}
+
+// Service purges the records of every category past their retention
+type Service struct {
+	dataStore dataservices.DataStore
+}
+
+// NewService creates a new retention service
+func NewService(dataStore dataservices.DataStore) *Service {
+	return &Service{dataStore: dataStore}
+}
+
+// Purge removes the records of every category past the retention policy of the settings, or past the default policy
+// of the category. A category failing to be purged does not prevent the purge of the others
+func (service *Service) Purge() error {
+	settings, err := service.dataStore.Settings().Settings()
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+
+	var errs []error
+	for _, category := range categories {
+		policy, ok := settings.RetentionPolicies[category.name]
+		if !ok {
+			policy = category.defaultPolicy(settings)
+		}
+
+		if policy.MaxAge == "" && policy.MaxEntries == 0 {
+			continue
+		}
+
+		var purged int
+		if err := service.dataStore.UpdateTx(func(tx dataservices.DataStoreTx) error {
+			records, err := category.records(tx, now)
+			if err != nil {
+				return err
+			}
+
+			ids, err := Expired(records, policy, now)
+			if err != nil {
+				return err
+			}
+
+			for _, id := range ids {
+				if err := category.delete(tx, id); err != nil {
+					return err
+				}
+			}
+
+			purged = len(ids)
+
+			return nil
+		}); err != nil {
+			errs = append(errs, fmt.Errorf("unable to purge the %s: %w", category.name, err))
+
+			continue
+		}
+
+		if purged > 0 {
+			log.Debug().Str("category", category.name).Int("count", purged).Msg("purged the records past their retention")
+		}
+	}
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,108 @@
+package retention
+
+import (
+	"testing"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/datastore"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestExpired(t *testing.T) {
+	is := require.New(t)
+
+	now := time.Unix(1_000_000, 0)
+	records := []Record{
+		{ID: 3, Timestamp: now.Add(-time.Hour).Unix()},
+		{ID: 1, Timestamp: now.Add(-72 * time.Hour).Unix()},
+		{ID: 2, Timestamp: now.Add(-48 * time.Hour).Unix()},
+		{ID: 4, Timestamp: now.Unix()},
+	}
+
+	ids, err := Expired(records, portainer.RetentionPolicy{MaxAge: "24h"}, now)
+	is.NoError(err)
+	is.Equal([]int{1, 2}, ids)
+
+	ids, err = Expired(records, portainer.RetentionPolicy{MaxEntries: 3}, now)
+	is.NoError(err)
+	is.Equal([]int{1}, ids)
+
+	// the most restrictive limit applies
+	ids, err = Expired(records, portainer.RetentionPolicy{MaxAge: "100h", MaxEntries: 1}, now)
+	is.NoError(err)
+	is.Equal([]int{1, 2, 3}, ids)
+
+	ids, err = Expired(records, portainer.RetentionPolicy{}, now)
+	is.NoError(err)
+	is.Empty(ids)
+}
+
+func TestValidatePolicies(t *testing.T) {
+	is := require.New(t)
+
+	is.NoError(ValidatePolicies(nil))
+	is.NoError(ValidatePolicies(map[string]portainer.RetentionPolicy{
+		CategoryAuditLogs: {MaxAge: "2160h", MaxEntries: 100000},
+		CategorySessions:  {},
+	}))
+	is.Error(ValidatePolicies(map[string]portainer.RetentionPolicy{"unknown": {MaxAge: "1h"}}))
+	is.Error(ValidatePolicies(map[string]portainer.RetentionPolicy{CategoryAuditLogs: {MaxAge: "90d"}}))
+	is.Error(ValidatePolicies(map[string]portainer.RetentionPolicy{CategoryAuditLogs: {MaxEntries: -1}}))
+}
+
+func TestPurge(t *testing.T) {
+	is := require.New(t)
+
+	_, store := datastore.MustNewTestStore(t, true, false)
+
+	now := time.Now()
+	for _, timestamp := range []time.Time{now.AddDate(0, 0, -100), now.AddDate(0, 0, -10), now} {
+		is.NoError(store.AuditLog().Create(&portainer.AuditLog{Timestamp: timestamp.Unix()}))
+	}
+
+	sessions := []portainer.Session{
+		// expired long ago
+		{TokenID: "a", ExpiresAt: now.AddDate(0, 0, -40).Unix()},
+		// revoked but not expired yet
+		{TokenID: "b", ExpiresAt: now.Add(time.Hour).Unix(), Revoked: true},
+		// never expires
+		{TokenID: "c"},
+	}
+	for i := range sessions {
+		is.NoError(store.Session().Create(&sessions[i]))
+	}
+
+	is.NoError(store.LoginAttempt().Create(&portainer.LoginAttempt{Subject: "admin", LastFailure: now.AddDate(0, 0, -30).Unix()}))
+	is.NoError(store.LoginAttempt().Create(&portainer.LoginAttempt{Subject: "bob", LastFailure: now.AddDate(0, 0, -30).Unix(), LockedUntil: now.Add(time.Hour).Unix()}))
+
+	// the audit logs fall back to the retention days of their settings, 90 by default
+	service := NewService(store)
+	is.NoError(service.Purge())
+
+	auditLogs, err := store.AuditLog().ReadAll()
+	is.NoError(err)
+	is.Len(auditLogs, 2)
+
+	remaining, err := store.Session().ReadAll()
+	is.NoError(err)
+	is.Len(remaining, 2)
+
+	attempts, err := store.LoginAttempt().ReadAll()
+	is.NoError(err)
+	is.Len(attempts, 1)
+	is.Equal("bob", attempts[0].Subject)
+
+	settings, err := store.Settings().Settings()
+	is.NoError(err)
+	settings.RetentionPolicies = map[string]portainer.RetentionPolicy{CategoryAuditLogs: {MaxEntries: 1}}
+	is.NoError(store.Settings().UpdateSettings(settings))
+
+	is.NoError(service.Purge())
+
+	auditLogs, err = store.AuditLog().ReadAll()
+	is.NoError(err)
+	is.Len(auditLogs, 1)
+	is.Equal(now.Unix(), auditLogs[0].Timestamp)
+}
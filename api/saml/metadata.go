@@ -0,0 +1,195 @@
+package saml
+
+import (
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"encoding/xml"
+
+	portainer "github.com/portainer/portainer/api"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	namespaceMetadata  = "urn:oasis:names:tc:SAML:2.0:metadata"
+	namespaceAssertion = "urn:oasis:names:tc:SAML:2.0:assertion"
+	namespaceProtocol  = "urn:oasis:names:tc:SAML:2.0:protocol"
+
+	bindingHTTPPost     = "urn:oasis:names:tc:SAML:2.0:bindings:HTTP-POST"
+	bindingHTTPRedirect = "urn:oasis:names:tc:SAML:2.0:bindings:HTTP-Redirect"
+
+	nameIDFormatUnspecified = "urn:oasis:names:tc:SAML:1.1:nameid-format:unspecified"
+)
+
+// IdPMetadata holds the parts of the identity provider metadata used by Portainer
+type IdPMetadata struct {
+	EntityID     string
+	SSOURL       string
+	SLOURL       string
+	Certificates []string
+}
+
+type spEntityDescriptor struct {
+	XMLName         xml.Name           `xml:"urn:oasis:names:tc:SAML:2.0:metadata EntityDescriptor"`
+	EntityID        string             `xml:"entityID,attr"`
+	SPSSODescriptor spSSODescriptorXML `xml:"SPSSODescriptor"`
+}
+
+type spSSODescriptorXML struct {
+	ProtocolSupportEnumeration string            `xml:"protocolSupportEnumeration,attr"`
+	AuthnRequestsSigned        bool              `xml:"AuthnRequestsSigned,attr"`
+	WantAssertionsSigned       bool              `xml:"WantAssertionsSigned,attr"`
+	SingleLogoutService        []endpointXML     `xml:"SingleLogoutService,omitempty"`
+	NameIDFormat               string            `xml:"NameIDFormat"`
+	AssertionConsumerService   []indexedEndpoint `xml:"AssertionConsumerService"`
+}
+
+type endpointXML struct {
+	Binding  string `xml:"Binding,attr"`
+	Location string `xml:"Location,attr"`
+}
+
+type indexedEndpoint struct {
+	Binding  string `xml:"Binding,attr"`
+	Location string `xml:"Location,attr"`
+	Index    int    `xml:"index,attr"`
+}
+
+// GenerateSPMetadata returns the metadata document describing Portainer as a service provider
+func GenerateSPMetadata(settings *portainer.SAMLSettings) ([]byte, error) {
+	descriptor := spEntityDescriptor{
+		EntityID: settings.EntityID,
+		SPSSODescriptor: spSSODescriptorXML{
+			ProtocolSupportEnumeration: namespaceProtocol,
+			WantAssertionsSigned:       true,
+			NameIDFormat:               nameIDFormatUnspecified,
+			AssertionConsumerService: []indexedEndpoint{
+				{Binding: bindingHTTPPost, Location: settings.ACSURL, Index: 0},
+			},
+		},
+	}
+
+	if settings.SLOURL != "" {
+		descriptor.SPSSODescriptor.SingleLogoutService = []endpointXML{
+			{Binding: bindingHTTPRedirect, Location: settings.SLOURL},
+		}
+	}
+
+	data, err := xml.MarshalIndent(descriptor, "", "  ")
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to generate the service provider metadata")
+	}
+
+	return append([]byte(xml.Header), data...), nil
+}
+
+// ParseIdPMetadata extracts the entity ID, the endpoints and the signing certificates of an identity provider
+// from its metadata document. Only the HTTP-Redirect binding is supported for the SSO and SLO endpoints
+func ParseIdPMetadata(data []byte) (*IdPMetadata, error) {
+	root, err := parseDocument(data)
+	if err != nil {
+		return nil, err
+	}
+
+	entity := root
+	if root.is(namespaceMetadata, "EntitiesDescriptor") {
+		entity = root.findChild(namespaceMetadata, "EntityDescriptor")
+	}
+
+	if entity == nil || !entity.is(namespaceMetadata, "EntityDescriptor") {
+		return nil, errors.New("the metadata does not contain an entity descriptor")
+	}
+
+	idp := entity.findChild(namespaceMetadata, "IDPSSODescriptor")
+	if idp == nil {
+		return nil, errors.New("the metadata does not describe an identity provider")
+	}
+
+	metadata := &IdPMetadata{EntityID: entity.attr("entityID")}
+	if metadata.EntityID == "" {
+		return nil, errors.New("the metadata has no entity ID")
+	}
+
+	for _, sso := range idp.findChildren(namespaceMetadata, "SingleSignOnService") {
+		if sso.attr("Binding") == bindingHTTPRedirect {
+			metadata.SSOURL = sso.attr("Location")
+
+			break
+		}
+	}
+
+	if metadata.SSOURL == "" {
+		return nil, errors.New("the identity provider does not support the HTTP-Redirect binding")
+	}
+
+	for _, slo := range idp.findChildren(namespaceMetadata, "SingleLogoutService") {
+		if slo.attr("Binding") == bindingHTTPRedirect {
+			metadata.SLOURL = slo.attr("Location")
+
+			break
+		}
+	}
+
+	for _, key := range idp.findChildren(namespaceMetadata, "KeyDescriptor") {
+		if use := key.attr("use"); use != "" && use != "signing" {
+			continue
+		}
+
+		keyInfo := key.findChild(namespaceDSig, "KeyInfo")
+		if keyInfo == nil {
+			continue
+		}
+
+		for _, data := range keyInfo.findChildren(namespaceDSig, "X509Data") {
+			for _, certificate := range data.findChildren(namespaceDSig, "X509Certificate") {
+				raw, err := base64.StdEncoding.DecodeString(compactBase64(certificate.text()))
+				if err != nil {
+					return nil, errors.Wrap(err, "unable to decode the identity provider certificate")
+				}
+
+				if _, err := x509.ParseCertificate(raw); err != nil {
+					return nil, errors.Wrap(err, "unable to parse the identity provider certificate")
+				}
+
+				metadata.Certificates = append(metadata.Certificates, string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: raw})))
+			}
+		}
+	}
+
+	if len(metadata.Certificates) == 0 {
+		return nil, errors.New("the metadata has no signing certificate")
+	}
+
+	return metadata, nil
+}
+
+// parseCertificates decodes the PEM encoded certificates of the identity provider
+func parseCertificates(certificates []string) ([]*x509.Certificate, error) {
+	var parsed []*x509.Certificate
+
+	for _, certificate := range certificates {
+		rest := []byte(certificate)
+
+		for {
+			var block *pem.Block
+			block, rest = pem.Decode(rest)
+			if block == nil {
+				break
+			}
+
+			c, err := x509.ParseCertificate(block.Bytes)
+			if err != nil {
+				return nil, errors.Wrap(err, "unable to parse the identity provider certificate")
+			}
+
+			parsed = append(parsed, c)
+		}
+	}
+
+	if len(parsed) == 0 {
+		return nil, errors.New("no identity provider certificate configured")
+	}
+
+	return parsed, nil
+}
@@ -0,0 +1,215 @@
+package saml
+
+import (
+	"bytes"
+	"compress/flate"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/xml"
+	"io"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	statusSuccess = "urn:oasis:names:tc:SAML:2.0:status:Success"
+
+	// maxInflatedMessageSize protects against decompression bombs in redirect binding messages
+	maxInflatedMessageSize = 1 << 20
+)
+
+type issuerXML struct {
+	XMLName xml.Name `xml:"urn:oasis:names:tc:SAML:2.0:assertion Issuer"`
+	Value   string   `xml:",chardata"`
+}
+
+type nameIDXML struct {
+	XMLName xml.Name `xml:"urn:oasis:names:tc:SAML:2.0:assertion NameID"`
+	Value   string   `xml:",chardata"`
+}
+
+type authnRequestXML struct {
+	XMLName                     xml.Name  `xml:"urn:oasis:names:tc:SAML:2.0:protocol AuthnRequest"`
+	ID                          string    `xml:"ID,attr"`
+	Version                     string    `xml:"Version,attr"`
+	IssueInstant                string    `xml:"IssueInstant,attr"`
+	Destination                 string    `xml:"Destination,attr"`
+	ProtocolBinding             string    `xml:"ProtocolBinding,attr"`
+	AssertionConsumerServiceURL string    `xml:"AssertionConsumerServiceURL,attr"`
+	Issuer                      issuerXML `xml:"Issuer"`
+}
+
+type logoutRequestXML struct {
+	XMLName      xml.Name  `xml:"urn:oasis:names:tc:SAML:2.0:protocol LogoutRequest"`
+	ID           string    `xml:"ID,attr"`
+	Version      string    `xml:"Version,attr"`
+	IssueInstant string    `xml:"IssueInstant,attr"`
+	Destination  string    `xml:"Destination,attr"`
+	Issuer       issuerXML `xml:"Issuer"`
+	NameID       nameIDXML `xml:"NameID"`
+	SessionIndex string    `xml:"urn:oasis:names:tc:SAML:2.0:protocol SessionIndex,omitempty"`
+}
+
+type statusCodeXML struct {
+	Value string `xml:"Value,attr"`
+}
+
+type statusXML struct {
+	StatusCode statusCodeXML `xml:"urn:oasis:names:tc:SAML:2.0:protocol StatusCode"`
+}
+
+type logoutResponseXML struct {
+	XMLName      xml.Name  `xml:"urn:oasis:names:tc:SAML:2.0:protocol LogoutResponse"`
+	ID           string    `xml:"ID,attr"`
+	Version      string    `xml:"Version,attr"`
+	IssueInstant string    `xml:"IssueInstant,attr"`
+	Destination  string    `xml:"Destination,attr"`
+	InResponseTo string    `xml:"InResponseTo,attr"`
+	Issuer       issuerXML `xml:"Issuer"`
+	Status       statusXML `xml:"urn:oasis:names:tc:SAML:2.0:protocol Status"`
+}
+
+// newID generates a message identifier, it must not start with a digit to be a valid xsd:ID
+func newID() (string, error) {
+	b := make([]byte, 20)
+	if _, err := rand.Read(b); err != nil {
+		return "", errors.Wrap(err, "unable to generate the message identifier")
+	}
+
+	return "_" + hex.EncodeToString(b), nil
+}
+
+func formatInstant(t time.Time) string {
+	return t.UTC().Format("2006-01-02T15:04:05Z")
+}
+
+// redirectURL encodes a message using the HTTP-Redirect binding: the message is deflated, base64 encoded
+// and appended to the destination query string
+func redirectURL(destination, parameter string, message any, relayState string) (string, error) {
+	data, err := xml.Marshal(message)
+	if err != nil {
+		return "", errors.Wrap(err, "unable to encode the SAML message")
+	}
+
+	var buf bytes.Buffer
+
+	w, err := flate.NewWriter(&buf, flate.BestCompression)
+	if err != nil {
+		return "", err
+	}
+
+	if _, err := w.Write(data); err != nil {
+		return "", err
+	}
+
+	if err := w.Close(); err != nil {
+		return "", err
+	}
+
+	u, err := url.Parse(destination)
+	if err != nil {
+		return "", errors.Wrap(err, "invalid identity provider URL")
+	}
+
+	query := u.Query()
+	query.Set(parameter, base64.StdEncoding.EncodeToString(buf.Bytes()))
+
+	if relayState != "" {
+		query.Set("RelayState", relayState)
+	}
+
+	u.RawQuery = query.Encode()
+
+	return u.String(), nil
+}
+
+// inflateRedirectMessage decodes a message received through the HTTP-Redirect binding
+func inflateRedirectMessage(encoded string) ([]byte, error) {
+	compressed, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to decode the SAML message")
+	}
+
+	data, err := io.ReadAll(io.LimitReader(flate.NewReader(bytes.NewReader(compressed)), maxInflatedMessageSize+1))
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to inflate the SAML message")
+	}
+
+	if len(data) > maxInflatedMessageSize {
+		return nil, errors.New("the SAML message is too large")
+	}
+
+	return data, nil
+}
+
+// verifyRedirectSignature validates the signature of a message received through the HTTP-Redirect binding
+// and returns the decoded message and relay state. The signed string is built from the raw query parameters,
+// as they were encoded by the identity provider, so the returned values are read from the same parameters
+func verifyRedirectSignature(rawQuery, parameter string, certificates []*x509.Certificate) (string, string, error) {
+	values := map[string]string{}
+
+	for _, part := range strings.Split(rawQuery, "&") {
+		key, value, _ := strings.Cut(part, "=")
+		if _, ok := values[key]; ok {
+			return "", "", errors.Errorf("the %s parameter is duplicated", key)
+		}
+
+		values[key] = value
+	}
+
+	rawSignature, ok := values["Signature"]
+	if !ok {
+		return "", "", errSignatureMissing
+	}
+
+	signed := parameter + "=" + values[parameter]
+	if relayState, ok := values["RelayState"]; ok {
+		signed += "&RelayState=" + relayState
+	}
+
+	signed += "&SigAlg=" + values["SigAlg"]
+
+	sigAlg, err := url.QueryUnescape(values["SigAlg"])
+	if err != nil {
+		return "", "", errors.Wrap(err, "invalid signature algorithm")
+	}
+
+	hashAlgorithm, err := signatureAlgorithm(sigAlg)
+	if err != nil {
+		return "", "", err
+	}
+
+	signatureValue, err := url.QueryUnescape(rawSignature)
+	if err != nil {
+		return "", "", errors.Wrap(err, "invalid signature")
+	}
+
+	signature, err := base64.StdEncoding.DecodeString(signatureValue)
+	if err != nil {
+		return "", "", errors.Wrap(err, "unable to decode the signature")
+	}
+
+	h := newHash(hashAlgorithm)
+	h.Write([]byte(signed))
+
+	if err := verifyWithCertificates(certificates, hashAlgorithm, h.Sum(nil), signature); err != nil {
+		return "", "", err
+	}
+
+	message, err := url.QueryUnescape(values[parameter])
+	if err != nil {
+		return "", "", errors.Wrap(err, "invalid SAML message")
+	}
+
+	relayState, err := url.QueryUnescape(values["RelayState"])
+	if err != nil {
+		return "", "", errors.Wrap(err, "invalid relay state")
+	}
+
+	return message, relayState, nil
+}
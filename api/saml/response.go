@@ -0,0 +1,281 @@
+package saml
+
+import (
+	"crypto/x509"
+	"encoding/base64"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	subjectConfirmationBearer = "urn:oasis:names:tc:SAML:2.0:cm:bearer"
+
+	// maxResponseSize is the maximum size of a decoded SAML response
+	maxResponseSize = 1 << 20
+)
+
+var errInvalidResponse = errors.New("invalid SAML response")
+
+// responseValidator holds the parameters used to validate a SAML response
+type responseValidator struct {
+	settings     *portainer.SAMLSettings
+	certificates []*x509.Certificate
+	now          time.Time
+	skew         time.Duration
+	// isPendingRequest returns whether the identifier is the one of a pending authentication request issued by
+	// Portainer, without consuming it
+	isPendingRequest func(id string) bool
+}
+
+type validatedAssertion struct {
+	id        string
+	expiresAt time.Time
+	// inResponseTo is the identifier of the authentication request, consumed once the response is accepted
+	inResponseTo string
+	assertion    portainer.SAMLAssertion
+}
+
+// validate checks a base64 encoded response received through the HTTP-POST binding. The identity is only read
+// from the elements covered by a valid signature
+func (v *responseValidator) validate(encodedResponse string) (*validatedAssertion, error) {
+	data, err := base64.StdEncoding.DecodeString(compactBase64(encodedResponse))
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to decode the SAML response")
+	}
+
+	if len(data) > maxResponseSize {
+		return nil, errors.New("the SAML response is too large")
+	}
+
+	response, err := parseDocument(data)
+	if err != nil {
+		return nil, err
+	}
+
+	if !response.is(namespaceProtocol, "Response") {
+		return nil, errors.Wrap(errInvalidResponse, "the document is not a SAML response")
+	}
+
+	if destination := response.attr("Destination"); destination != "" && destination != v.settings.ACSURL {
+		return nil, errors.Wrap(errInvalidResponse, "unexpected destination")
+	}
+
+	// unsolicited responses are refused, every response must answer an authentication request issued by Portainer
+	inResponseTo := response.attr("InResponseTo")
+	if inResponseTo == "" || !v.isPendingRequest(inResponseTo) {
+		return nil, errors.Wrap(errInvalidResponse, "the response does not match a pending authentication request")
+	}
+
+	if issuer := response.findChild(namespaceAssertion, "Issuer"); issuer != nil && issuer.text() != v.settings.IdPEntityID {
+		return nil, errors.Wrap(errInvalidResponse, "unexpected issuer")
+	}
+
+	if err := checkStatus(response); err != nil {
+		return nil, err
+	}
+
+	if len(response.findChildren(namespaceAssertion, "EncryptedAssertion")) > 0 {
+		return nil, errors.New("encrypted assertions are not supported")
+	}
+
+	assertions := response.findChildren(namespaceAssertion, "Assertion")
+	if len(assertions) != 1 {
+		return nil, errors.Wrap(errInvalidResponse, "the response must contain exactly one assertion")
+	}
+
+	assertion := assertions[0]
+
+	responseSigned, err := v.verifySignature(response)
+	if err != nil {
+		return nil, errors.WithMessage(err, "invalid response signature")
+	}
+
+	assertionSigned, err := v.verifySignature(assertion)
+	if err != nil {
+		return nil, errors.WithMessage(err, "invalid assertion signature")
+	}
+
+	if !responseSigned && !assertionSigned {
+		return nil, errors.Wrap(errInvalidResponse, "neither the response nor the assertion is signed")
+	}
+
+	return v.validateAssertion(assertion, inResponseTo)
+}
+
+// verifySignature returns true when the element carries a valid signature, and an error when the signature is invalid
+func (v *responseValidator) verifySignature(el *element) (bool, error) {
+	err := verifyElementSignature(el, v.certificates)
+	if errors.Is(err, errSignatureMissing) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+func checkStatus(response *element) error {
+	status := response.findChild(namespaceProtocol, "Status")
+	if status == nil {
+		return errors.Wrap(errInvalidResponse, "the response has no status")
+	}
+
+	code := status.findChild(namespaceProtocol, "StatusCode")
+	if code == nil {
+		return errors.Wrap(errInvalidResponse, "the response has no status code")
+	}
+
+	if value := code.attr("Value"); value != statusSuccess {
+		return errors.Errorf("the identity provider returned the status %q", value)
+	}
+
+	return nil
+}
+
+func (v *responseValidator) validateAssertion(assertion *element, inResponseTo string) (*validatedAssertion, error) {
+	id := assertion.attr("ID")
+	if id == "" {
+		return nil, errors.Wrap(errInvalidResponse, "the assertion has no identifier")
+	}
+
+	issuer := assertion.findChild(namespaceAssertion, "Issuer")
+	if issuer == nil || issuer.text() != v.settings.IdPEntityID {
+		return nil, errors.Wrap(errInvalidResponse, "unexpected assertion issuer")
+	}
+
+	expiresAt, err := v.checkConditions(assertion)
+	if err != nil {
+		return nil, err
+	}
+
+	subject := assertion.findChild(namespaceAssertion, "Subject")
+	if subject == nil {
+		return nil, errors.Wrap(errInvalidResponse, "the assertion has no subject")
+	}
+
+	nameID := subject.findChild(namespaceAssertion, "NameID")
+	if nameID == nil || nameID.text() == "" {
+		return nil, errors.Wrap(errInvalidResponse, "the assertion has no name identifier")
+	}
+
+	if err := v.checkSubjectConfirmation(subject, inResponseTo); err != nil {
+		return nil, err
+	}
+
+	result := &validatedAssertion{
+		id:           id,
+		expiresAt:    expiresAt,
+		inResponseTo: inResponseTo,
+		assertion: portainer.SAMLAssertion{
+			NameID:     nameID.text(),
+			Attributes: map[string][]string{},
+		},
+	}
+
+	if authn := assertion.findChild(namespaceAssertion, "AuthnStatement"); authn != nil {
+		result.assertion.SessionIndex = authn.attr("SessionIndex")
+	}
+
+	for _, statement := range assertion.findChildren(namespaceAssertion, "AttributeStatement") {
+		for _, attribute := range statement.findChildren(namespaceAssertion, "Attribute") {
+			name := attribute.attr("Name")
+
+			for _, value := range attribute.findChildren(namespaceAssertion, "AttributeValue") {
+				result.assertion.Attributes[name] = append(result.assertion.Attributes[name], value.text())
+			}
+		}
+	}
+
+	return result, nil
+}
+
+// checkConditions validates the validity period and the audience of the assertion, it returns the time after
+// which the assertion expires
+func (v *responseValidator) checkConditions(assertion *element) (time.Time, error) {
+	conditions := assertion.findChild(namespaceAssertion, "Conditions")
+	if conditions == nil {
+		return time.Time{}, errors.Wrap(errInvalidResponse, "the assertion has no conditions")
+	}
+
+	if notBefore := conditions.attr("NotBefore"); notBefore != "" {
+		t, err := time.Parse(time.RFC3339, notBefore)
+		if err != nil {
+			return time.Time{}, errors.Wrap(err, "invalid NotBefore condition")
+		}
+
+		if v.now.Add(v.skew).Before(t) {
+			return time.Time{}, errors.Wrap(errInvalidResponse, "the assertion is not yet valid")
+		}
+	}
+
+	notOnOrAfter := conditions.attr("NotOnOrAfter")
+	if notOnOrAfter == "" {
+		return time.Time{}, errors.Wrap(errInvalidResponse, "the assertion has no expiry")
+	}
+
+	expiresAt, err := time.Parse(time.RFC3339, notOnOrAfter)
+	if err != nil {
+		return time.Time{}, errors.Wrap(err, "invalid NotOnOrAfter condition")
+	}
+
+	if !v.now.Add(-v.skew).Before(expiresAt) {
+		return time.Time{}, errors.Wrap(errInvalidResponse, "the assertion has expired")
+	}
+
+	restrictions := conditions.findChildren(namespaceAssertion, "AudienceRestriction")
+	if len(restrictions) == 0 {
+		return time.Time{}, errors.Wrap(errInvalidResponse, "the assertion has no audience restriction")
+	}
+
+	// each audience restriction must be satisfied
+	for _, restriction := range restrictions {
+		found := false
+
+		for _, audience := range restriction.findChildren(namespaceAssertion, "Audience") {
+			if audience.text() == v.settings.EntityID {
+				found = true
+
+				break
+			}
+		}
+
+		if !found {
+			return time.Time{}, errors.Wrap(errInvalidResponse, "the assertion is not intended for this service provider")
+		}
+	}
+
+	return expiresAt.Add(v.skew), nil
+}
+
+func (v *responseValidator) checkSubjectConfirmation(subject *element, inResponseTo string) error {
+	for _, confirmation := range subject.findChildren(namespaceAssertion, "SubjectConfirmation") {
+		if confirmation.attr("Method") != subjectConfirmationBearer {
+			continue
+		}
+
+		data := confirmation.findChild(namespaceAssertion, "SubjectConfirmationData")
+		if data == nil {
+			continue
+		}
+
+		if data.attr("Recipient") != v.settings.ACSURL {
+			continue
+		}
+
+		if id := data.attr("InResponseTo"); id != "" && id != inResponseTo {
+			continue
+		}
+
+		notOnOrAfter, err := time.Parse(time.RFC3339, data.attr("NotOnOrAfter"))
+		if err != nil || !v.now.Add(-v.skew).Before(notOnOrAfter) {
+			continue
+		}
+
+		return nil
+	}
+
+	return errors.Wrap(errInvalidResponse, "the assertion has no valid bearer subject confirmation")
+}
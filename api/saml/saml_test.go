@@ -0,0 +1,283 @@
+package saml
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCanonicalize(t *testing.T) {
+	doc := `<root xmlns="urn:default" xmlns:a="urn:a" xmlns:unused="urn:unused"><a:child z="1" a:b="2" b="3"><empty/><!-- comment -->text &amp; &lt;more&gt;</a:child></root>`
+
+	root, err := parseDocument([]byte(doc))
+	require.NoError(t, err)
+
+	child := root.childElements()[0]
+
+	expected := `<a:child xmlns:a="urn:a" b="3" z="1" a:b="2"><empty xmlns="urn:default"></empty>text &amp; &lt;more&gt;</a:child>`
+	require.Equal(t, expected, string(canonicalize(child, nil, nil)))
+
+	expected = `<a:child xmlns="urn:default" xmlns:a="urn:a" b="3" z="1" a:b="2"><empty></empty>text &amp; &lt;more&gt;</a:child>`
+	require.Equal(t, expected, string(canonicalize(child, []string{"#default"}, nil)))
+}
+
+func TestParseDocumentRejectsDirectives(t *testing.T) {
+	_, err := parseDocument([]byte(`<!DOCTYPE foo [<!ENTITY x "y">]><foo>&x;</foo>`))
+	require.Error(t, err)
+}
+
+type testIdP struct {
+	key         *rsa.PrivateKey
+	certificate string
+	settings    *portainer.SAMLSettings
+}
+
+func newTestIdP(t *testing.T) *testIdP {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "idp"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+
+	certificate := string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))
+
+	return &testIdP{
+		key:         key,
+		certificate: certificate,
+		settings: &portainer.SAMLSettings{
+			EntityID:        "https://portainer.example.com",
+			ACSURL:          "https://portainer.example.com/api/auth/saml/acs",
+			SLOURL:          "https://portainer.example.com/api/auth/saml/slo",
+			IdPEntityID:     "https://idp.example.com",
+			IdPSSOURL:       "https://idp.example.com/sso",
+			IdPSLOURL:       "https://idp.example.com/slo",
+			IdPCertificates: []string{certificate},
+		},
+	}
+}
+
+// signElement inserts an enveloped signature as the first child of the element with the given ID
+func (idp *testIdP) signElement(t *testing.T, doc, id string) string {
+	root, err := parseDocument([]byte(doc))
+	require.NoError(t, err)
+
+	el := root.findByID(id)
+	require.NotNil(t, el)
+
+	digest := sha256.Sum256(canonicalize(el, nil, nil))
+
+	signedInfo := fmt.Sprintf(`<ds:SignedInfo><ds:CanonicalizationMethod Algorithm="%s"/><ds:SignatureMethod Algorithm="%s"/><ds:Reference URI="#%s"><ds:Transforms><ds:Transform Algorithm="%s"/><ds:Transform Algorithm="%s"/></ds:Transforms><ds:DigestMethod Algorithm="%s"/><ds:DigestValue>%s</ds:DigestValue></ds:Reference></ds:SignedInfo>`,
+		transformExcC14N, algorithmRSASHA256, id, transformEnveloped, transformExcC14N, digestSHA256, base64.StdEncoding.EncodeToString(digest[:]))
+
+	signedInfoDoc, err := parseDocument([]byte(`<ds:Signature xmlns:ds="` + namespaceDSig + `">` + signedInfo + `</ds:Signature>`))
+	require.NoError(t, err)
+
+	hashed := sha256.Sum256(canonicalize(signedInfoDoc.childElements()[0], nil, nil))
+
+	signature, err := rsa.SignPKCS1v15(rand.Reader, idp.key, crypto.SHA256, hashed[:])
+	require.NoError(t, err)
+
+	signatureXML := `<ds:Signature xmlns:ds="` + namespaceDSig + `">` + signedInfo + `<ds:SignatureValue>` + base64.StdEncoding.EncodeToString(signature) + `</ds:SignatureValue></ds:Signature>`
+
+	// the signature is inserted right after the start tag of the signed element
+	i := strings.Index(doc, `ID="`+id+`"`)
+	require.NotEqual(t, -1, i)
+
+	i += strings.Index(doc[i:], ">") + 1
+
+	return doc[:i] + signatureXML + doc[i:]
+}
+
+func (idp *testIdP) response(requestID, assertionID, nameID, audience string, now time.Time) string {
+	return fmt.Sprintf(`<samlp:Response xmlns:samlp="%[1]s" xmlns:saml="%[2]s" ID="_response" Version="2.0" Destination="%[3]s" InResponseTo="%[4]s"><saml:Issuer>%[5]s</saml:Issuer><samlp:Status><samlp:StatusCode Value="%[6]s"/></samlp:Status><saml:Assertion ID="%[7]s" Version="2.0"><saml:Issuer>%[5]s</saml:Issuer><saml:Subject><saml:NameID>%[8]s</saml:NameID><saml:SubjectConfirmation Method="%[9]s"><saml:SubjectConfirmationData Recipient="%[3]s" InResponseTo="%[4]s" NotOnOrAfter="%[10]s"/></saml:SubjectConfirmation></saml:Subject><saml:Conditions NotBefore="%[11]s" NotOnOrAfter="%[10]s"><saml:AudienceRestriction><saml:Audience>%[12]s</saml:Audience></saml:AudienceRestriction></saml:Conditions><saml:AuthnStatement SessionIndex="session-1"/><saml:AttributeStatement><saml:Attribute Name="groups"><saml:AttributeValue>developers</saml:AttributeValue><saml:AttributeValue>ops</saml:AttributeValue></saml:Attribute></saml:AttributeStatement></saml:Assertion></samlp:Response>`,
+		namespaceProtocol, namespaceAssertion, idp.settings.ACSURL, requestID, idp.settings.IdPEntityID, statusSuccess, assertionID, nameID,
+		subjectConfirmationBearer, formatInstant(now.Add(5*time.Minute)), formatInstant(now.Add(-time.Minute)), audience)
+}
+
+func pendingRequestID(t *testing.T, service *Service, settings *portainer.SAMLSettings) string {
+	loginURL, err := service.LoginURL(settings)
+	require.NoError(t, err)
+
+	u, err := url.Parse(loginURL)
+	require.NoError(t, err)
+
+	data, err := inflateRedirectMessage(u.Query().Get("SAMLRequest"))
+	require.NoError(t, err)
+
+	request, err := parseDocument(data)
+	require.NoError(t, err)
+	require.True(t, request.is(namespaceProtocol, "AuthnRequest"))
+
+	return request.attr("ID")
+}
+
+func encode(doc string) string {
+	return base64.StdEncoding.EncodeToString([]byte(doc))
+}
+
+func TestValidateResponse(t *testing.T) {
+	idp := newTestIdP(t)
+	service := NewService()
+	now := time.Now()
+
+	t.Run("valid signed assertion", func(t *testing.T) {
+		requestID := pendingRequestID(t, service, idp.settings)
+		doc := idp.signElement(t, idp.response(requestID, "_assertion1", "alice", idp.settings.EntityID, now), "_assertion1")
+
+		assertion, err := service.ValidateResponse(encode(doc), idp.settings)
+		require.NoError(t, err)
+		require.Equal(t, "alice", assertion.NameID)
+		require.Equal(t, "session-1", assertion.SessionIndex)
+		require.Equal(t, []string{"developers", "ops"}, assertion.Attributes["groups"])
+
+		// the response cannot be replayed
+		_, err = service.ValidateResponse(encode(doc), idp.settings)
+		require.Error(t, err)
+	})
+
+	t.Run("valid signed response", func(t *testing.T) {
+		requestID := pendingRequestID(t, service, idp.settings)
+		doc := idp.signElement(t, idp.response(requestID, "_assertion2", "bob", idp.settings.EntityID, now), "_response")
+
+		assertion, err := service.ValidateResponse(encode(doc), idp.settings)
+		require.NoError(t, err)
+		require.Equal(t, "bob", assertion.NameID)
+	})
+
+	t.Run("tampered assertion", func(t *testing.T) {
+		requestID := pendingRequestID(t, service, idp.settings)
+		doc := idp.signElement(t, idp.response(requestID, "_assertion3", "alice", idp.settings.EntityID, now), "_assertion3")
+		doc = strings.Replace(doc, "<saml:NameID>alice<", "<saml:NameID>admin<", 1)
+
+		_, err := service.ValidateResponse(encode(doc), idp.settings)
+		require.Error(t, err)
+	})
+
+	t.Run("unsigned response", func(t *testing.T) {
+		requestID := pendingRequestID(t, service, idp.settings)
+		doc := idp.response(requestID, "_assertion4", "alice", idp.settings.EntityID, now)
+
+		_, err := service.ValidateResponse(encode(doc), idp.settings)
+		require.Error(t, err)
+	})
+
+	t.Run("wrong audience", func(t *testing.T) {
+		requestID := pendingRequestID(t, service, idp.settings)
+		doc := idp.signElement(t, idp.response(requestID, "_assertion5", "alice", "https://other.example.com", now), "_assertion5")
+
+		_, err := service.ValidateResponse(encode(doc), idp.settings)
+		require.Error(t, err)
+	})
+
+	t.Run("unsolicited response", func(t *testing.T) {
+		doc := idp.signElement(t, idp.response("_unknown", "_assertion6", "alice", idp.settings.EntityID, now), "_assertion6")
+
+		_, err := service.ValidateResponse(encode(doc), idp.settings)
+		require.Error(t, err)
+	})
+
+	t.Run("invalid response before the valid one", func(t *testing.T) {
+		requestID := pendingRequestID(t, service, idp.settings)
+
+		forged := idp.response(requestID, "_assertion8", "admin", idp.settings.EntityID, now)
+		_, err := service.ValidateResponse(encode(forged), idp.settings)
+		require.Error(t, err)
+
+		// the request is still pending, the forged response did not consume it
+		doc := idp.signElement(t, idp.response(requestID, "_assertion9", "alice", idp.settings.EntityID, now), "_assertion9")
+		assertion, err := service.ValidateResponse(encode(doc), idp.settings)
+		require.NoError(t, err)
+		require.Equal(t, "alice", assertion.NameID)
+
+		// a second response to the same request is refused
+		doc = idp.signElement(t, idp.response(requestID, "_assertion10", "alice", idp.settings.EntityID, now), "_assertion10")
+		_, err = service.ValidateResponse(encode(doc), idp.settings)
+		require.Error(t, err)
+	})
+
+	t.Run("expired assertion", func(t *testing.T) {
+		requestID := pendingRequestID(t, service, idp.settings)
+		doc := idp.signElement(t, idp.response(requestID, "_assertion7", "alice", idp.settings.EntityID, now.Add(-time.Hour)), "_assertion7")
+
+		_, err := service.ValidateResponse(encode(doc), idp.settings)
+		require.Error(t, err)
+	})
+}
+
+func TestValidateLogoutRequest(t *testing.T) {
+	idp := newTestIdP(t)
+	service := NewService()
+
+	request := logoutRequestXML{
+		ID:           "_logout",
+		Version:      "2.0",
+		IssueInstant: formatInstant(time.Now()),
+		Destination:  idp.settings.SLOURL,
+		Issuer:       issuerXML{Value: idp.settings.IdPEntityID},
+		NameID:       nameIDXML{Value: "alice"},
+	}
+
+	u, err := redirectURL(idp.settings.SLOURL, "SAMLRequest", request, "state")
+	require.NoError(t, err)
+
+	parsed, err := url.Parse(u)
+	require.NoError(t, err)
+
+	signed := "SAMLRequest=" + url.QueryEscape(parsed.Query().Get("SAMLRequest")) + "&RelayState=state&SigAlg=" + url.QueryEscape(algorithmRSASHA256)
+	hashed := sha256.Sum256([]byte(signed))
+
+	signature, err := rsa.SignPKCS1v15(rand.Reader, idp.key, crypto.SHA256, hashed[:])
+	require.NoError(t, err)
+
+	rawQuery := signed + "&Signature=" + url.QueryEscape(base64.StdEncoding.EncodeToString(signature))
+
+	logoutRequest, err := service.ValidateLogoutRequest(rawQuery, idp.settings)
+	require.NoError(t, err)
+	require.Equal(t, "alice", logoutRequest.NameID)
+	require.Equal(t, "_logout", logoutRequest.ID)
+	require.Equal(t, "state", logoutRequest.RelayState)
+
+	_, err = service.ValidateLogoutRequest(strings.Replace(rawQuery, "RelayState=state", "RelayState=other", 1), idp.settings)
+	require.Error(t, err)
+
+	_, err = service.ValidateLogoutRequest(signed, idp.settings)
+	require.Error(t, err)
+}
+
+func TestParseIdPMetadata(t *testing.T) {
+	idp := newTestIdP(t)
+
+	block, _ := pem.Decode([]byte(idp.certificate))
+
+	metadata := fmt.Sprintf(`<md:EntityDescriptor xmlns:md="%s" xmlns:ds="%s" entityID="https://idp.example.com"><md:IDPSSODescriptor protocolSupportEnumeration="%s"><md:KeyDescriptor use="signing"><ds:KeyInfo><ds:X509Data><ds:X509Certificate>%s</ds:X509Certificate></ds:X509Data></ds:KeyInfo></md:KeyDescriptor><md:SingleLogoutService Binding="%s" Location="https://idp.example.com/slo"/><md:SingleSignOnService Binding="%s" Location="https://idp.example.com/sso-post"/><md:SingleSignOnService Binding="%s" Location="https://idp.example.com/sso"/></md:IDPSSODescriptor></md:EntityDescriptor>`,
+		namespaceMetadata, namespaceDSig, namespaceProtocol, base64.StdEncoding.EncodeToString(block.Bytes), bindingHTTPRedirect, bindingHTTPPost, bindingHTTPRedirect)
+
+	parsed, err := ParseIdPMetadata([]byte(metadata))
+	require.NoError(t, err)
+	require.Equal(t, "https://idp.example.com", parsed.EntityID)
+	require.Equal(t, "https://idp.example.com/sso", parsed.SSOURL)
+	require.Equal(t, "https://idp.example.com/slo", parsed.SLOURL)
+	require.Equal(t, []string{idp.certificate}, parsed.Certificates)
+}
@@ -0,0 +1,265 @@
+package saml
+
+import (
+	"sync"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// requestTTL is the time a user has to authenticate on the identity provider
+	requestTTL = 5 * time.Minute
+
+	defaultClockSkew = 60 * time.Second
+)
+
+// Service represents a service used to authenticate users against a SAML 2.0 identity provider
+type Service struct {
+	mu sync.Mutex
+	// pendingRequests holds the identifiers of the authentication requests waiting for a response
+	pendingRequests map[string]time.Time
+	// consumedAssertions holds the identifiers of the assertions already used, until they expire
+	consumedAssertions map[string]time.Time
+	now                func() time.Time
+}
+
+// NewService returns a pointer to a new instance of this service
+func NewService() *Service {
+	return &Service{
+		pendingRequests:    map[string]time.Time{},
+		consumedAssertions: map[string]time.Time{},
+		now:                time.Now,
+	}
+}
+
+// Metadata returns the service provider metadata to register Portainer on the identity provider
+func (service *Service) Metadata(settings *portainer.SAMLSettings) ([]byte, error) {
+	if settings.EntityID == "" || settings.ACSURL == "" {
+		return nil, errors.New("the SAML service provider is not configured")
+	}
+
+	return GenerateSPMetadata(settings)
+}
+
+// LoginURL creates an authentication request and returns the identity provider URL the user must be redirected to
+func (service *Service) LoginURL(settings *portainer.SAMLSettings) (string, error) {
+	if settings.IdPSSOURL == "" {
+		return "", errors.New("the SAML identity provider is not configured")
+	}
+
+	id, err := newID()
+	if err != nil {
+		return "", err
+	}
+
+	now := service.now()
+
+	request := authnRequestXML{
+		ID:                          id,
+		Version:                     "2.0",
+		IssueInstant:                formatInstant(now),
+		Destination:                 settings.IdPSSOURL,
+		ProtocolBinding:             bindingHTTPPost,
+		AssertionConsumerServiceURL: settings.ACSURL,
+		Issuer:                      issuerXML{Value: settings.EntityID},
+	}
+
+	u, err := redirectURL(settings.IdPSSOURL, "SAMLRequest", request, "")
+	if err != nil {
+		return "", err
+	}
+
+	service.mu.Lock()
+	defer service.mu.Unlock()
+
+	service.purge(now)
+	service.pendingRequests[id] = now.Add(requestTTL)
+
+	return u, nil
+}
+
+// ValidateResponse validates a response posted by the identity provider and returns the asserted identity
+func (service *Service) ValidateResponse(encodedResponse string, settings *portainer.SAMLSettings) (*portainer.SAMLAssertion, error) {
+	certificates, err := parseCertificates(settings.IdPCertificates)
+	if err != nil {
+		return nil, err
+	}
+
+	now := service.now()
+
+	validator := &responseValidator{
+		settings:         settings,
+		certificates:     certificates,
+		now:              now,
+		skew:             clockSkew(settings),
+		isPendingRequest: service.isPendingRequest,
+	}
+
+	result, err := validator.validate(encodedResponse)
+	if err != nil {
+		return nil, err
+	}
+
+	service.mu.Lock()
+	defer service.mu.Unlock()
+
+	service.purge(now)
+
+	if _, ok := service.consumedAssertions[result.id]; ok {
+		return nil, errors.New("the SAML assertion has already been used")
+	}
+
+	// the request is only consumed by a valid response, an invalid one cannot cancel the login of the user. It may
+	// have been consumed by another response since it was checked
+	if _, ok := service.pendingRequests[result.inResponseTo]; !ok {
+		return nil, errors.New("the SAML response does not match a pending authentication request")
+	}
+
+	delete(service.pendingRequests, result.inResponseTo)
+	service.consumedAssertions[result.id] = result.expiresAt
+
+	return &result.assertion, nil
+}
+
+// LogoutURL creates a logout request and returns the identity provider URL the user must be redirected to.
+// An empty URL is returned when the identity provider does not support single logout
+func (service *Service) LogoutURL(settings *portainer.SAMLSettings, nameID, sessionIndex string) (string, error) {
+	if settings.IdPSLOURL == "" || nameID == "" {
+		return "", nil
+	}
+
+	id, err := newID()
+	if err != nil {
+		return "", err
+	}
+
+	request := logoutRequestXML{
+		ID:           id,
+		Version:      "2.0",
+		IssueInstant: formatInstant(service.now()),
+		Destination:  settings.IdPSLOURL,
+		Issuer:       issuerXML{Value: settings.EntityID},
+		NameID:       nameIDXML{Value: nameID},
+		SessionIndex: sessionIndex,
+	}
+
+	return redirectURL(settings.IdPSLOURL, "SAMLRequest", request, "")
+}
+
+// ValidateLogoutRequest validates a logout request sent by the identity provider through the HTTP-Redirect binding.
+// The request must be signed
+func (service *Service) ValidateLogoutRequest(rawQuery string, settings *portainer.SAMLSettings) (*portainer.SAMLLogoutRequest, error) {
+	certificates, err := parseCertificates(settings.IdPCertificates)
+	if err != nil {
+		return nil, err
+	}
+
+	message, relayState, err := verifyRedirectSignature(rawQuery, "SAMLRequest", certificates)
+	if err != nil {
+		return nil, errors.WithMessage(err, "invalid logout request signature")
+	}
+
+	data, err := inflateRedirectMessage(message)
+	if err != nil {
+		return nil, err
+	}
+
+	request, err := parseDocument(data)
+	if err != nil {
+		return nil, err
+	}
+
+	if !request.is(namespaceProtocol, "LogoutRequest") {
+		return nil, errors.New("the document is not a SAML logout request")
+	}
+
+	if destination := request.attr("Destination"); destination != "" && destination != settings.SLOURL {
+		return nil, errors.New("unexpected logout request destination")
+	}
+
+	issuer := request.findChild(namespaceAssertion, "Issuer")
+	if issuer == nil || issuer.text() != settings.IdPEntityID {
+		return nil, errors.New("unexpected logout request issuer")
+	}
+
+	if notOnOrAfter := request.attr("NotOnOrAfter"); notOnOrAfter != "" {
+		t, err := time.Parse(time.RFC3339, notOnOrAfter)
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid NotOnOrAfter attribute")
+		}
+
+		if !service.now().Add(-clockSkew(settings)).Before(t) {
+			return nil, errors.New("the logout request has expired")
+		}
+	}
+
+	nameID := request.findChild(namespaceAssertion, "NameID")
+	if nameID == nil || nameID.text() == "" {
+		return nil, errors.New("the logout request has no name identifier")
+	}
+
+	return &portainer.SAMLLogoutRequest{
+		ID:         request.attr("ID"),
+		NameID:     nameID.text(),
+		RelayState: relayState,
+	}, nil
+}
+
+// LogoutResponseURL returns the identity provider URL acknowledging a logout request
+func (service *Service) LogoutResponseURL(settings *portainer.SAMLSettings, request *portainer.SAMLLogoutRequest) (string, error) {
+	if settings.IdPSLOURL == "" {
+		return "", errors.New("the SAML identity provider does not support single logout")
+	}
+
+	id, err := newID()
+	if err != nil {
+		return "", err
+	}
+
+	response := logoutResponseXML{
+		ID:           id,
+		Version:      "2.0",
+		IssueInstant: formatInstant(service.now()),
+		Destination:  settings.IdPSLOURL,
+		InResponseTo: request.ID,
+		Issuer:       issuerXML{Value: settings.EntityID},
+		Status:       statusXML{StatusCode: statusCodeXML{Value: statusSuccess}},
+	}
+
+	return redirectURL(settings.IdPSLOURL, "SAMLResponse", response, request.RelayState)
+}
+
+func (service *Service) isPendingRequest(id string) bool {
+	service.mu.Lock()
+	defer service.mu.Unlock()
+
+	expiresAt, ok := service.pendingRequests[id]
+
+	return ok && service.now().Before(expiresAt)
+}
+
+// purge removes the expired requests and assertions, the caller must hold the lock
+func (service *Service) purge(now time.Time) {
+	for id, expiresAt := range service.pendingRequests {
+		if !now.Before(expiresAt) {
+			delete(service.pendingRequests, id)
+		}
+	}
+
+	for id, expiresAt := range service.consumedAssertions {
+		if !now.Before(expiresAt) {
+			delete(service.consumedAssertions, id)
+		}
+	}
+}
+
+func clockSkew(settings *portainer.SAMLSettings) time.Duration {
+	if settings.AllowedClockSkew <= 0 {
+		return defaultClockSkew
+	}
+
+	return time.Duration(settings.AllowedClockSkew) * time.Second
+}
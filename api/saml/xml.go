@@ -0,0 +1,314 @@
+package saml
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const xmlNamespace = "http://www.w3.org/XML/1998/namespace"
+
+// element is a node of a parsed XML document that keeps the namespace prefixes and declarations
+// as they appear in the document, which is required to canonicalize signed fragments
+type element struct {
+	prefix   string
+	local    string
+	attrs    []xml.Attr
+	children []any // *element or string
+	parent   *element
+}
+
+func parseDocument(data []byte) (*element, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+
+	var root, current *element
+
+	for {
+		token, err := decoder.RawToken()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, errors.Wrap(err, "unable to parse the XML document")
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			el := &element{
+				prefix: t.Name.Space,
+				local:  t.Name.Local,
+				attrs:  append([]xml.Attr{}, t.Attr...),
+				parent: current,
+			}
+
+			if current == nil {
+				if root != nil {
+					return nil, errors.New("the XML document has several root elements")
+				}
+
+				root = el
+			} else {
+				current.children = append(current.children, el)
+			}
+
+			current = el
+
+		case xml.EndElement:
+			if current == nil || t.Name.Space != current.prefix || t.Name.Local != current.local {
+				return nil, errors.New("the XML document is not well formed")
+			}
+
+			current = current.parent
+
+		case xml.CharData:
+			if current != nil {
+				current.children = append(current.children, string(t))
+			}
+
+		case xml.Directive:
+			// DTDs are a common vector of XML attacks and are never part of a SAML message
+			return nil, errors.New("XML directives are not supported")
+		}
+	}
+
+	if root == nil || current != nil {
+		return nil, errors.New("the XML document is not well formed")
+	}
+
+	return root, nil
+}
+
+// lookupNamespace resolves a namespace prefix in the scope of the element
+func (el *element) lookupNamespace(prefix string) string {
+	if prefix == "xml" {
+		return xmlNamespace
+	}
+
+	for e := el; e != nil; e = e.parent {
+		for _, attr := range e.attrs {
+			if prefix == "" && attr.Name.Space == "" && attr.Name.Local == "xmlns" {
+				return attr.Value
+			}
+
+			if prefix != "" && attr.Name.Space == "xmlns" && attr.Name.Local == prefix {
+				return attr.Value
+			}
+		}
+	}
+
+	return ""
+}
+
+func (el *element) namespace() string {
+	return el.lookupNamespace(el.prefix)
+}
+
+func (el *element) is(namespace, local string) bool {
+	return el.local == local && el.namespace() == namespace
+}
+
+func (el *element) attr(name string) string {
+	for _, attr := range el.attrs {
+		if attr.Name.Space == "" && attr.Name.Local == name {
+			return attr.Value
+		}
+	}
+
+	return ""
+}
+
+func (el *element) childElements() []*element {
+	children := []*element{}
+
+	for _, child := range el.children {
+		if e, ok := child.(*element); ok {
+			children = append(children, e)
+		}
+	}
+
+	return children
+}
+
+func (el *element) findChildren(namespace, local string) []*element {
+	children := []*element{}
+
+	for _, child := range el.childElements() {
+		if child.is(namespace, local) {
+			children = append(children, child)
+		}
+	}
+
+	return children
+}
+
+func (el *element) findChild(namespace, local string) *element {
+	children := el.findChildren(namespace, local)
+	if len(children) == 0 {
+		return nil
+	}
+
+	return children[0]
+}
+
+func (el *element) text() string {
+	var sb strings.Builder
+
+	for _, child := range el.children {
+		if s, ok := child.(string); ok {
+			sb.WriteString(s)
+		}
+	}
+
+	return strings.TrimSpace(sb.String())
+}
+
+// findByID returns the element of the subtree whose ID attribute matches
+func (el *element) findByID(id string) *element {
+	if el.attr("ID") == id {
+		return el
+	}
+
+	for _, child := range el.childElements() {
+		if found := child.findByID(id); found != nil {
+			return found
+		}
+	}
+
+	return nil
+}
+
+// canonicalize serializes the element using the Exclusive XML Canonicalization algorithm (without comments).
+// The exclude element, if any, is omitted from the output, which implements the enveloped signature transform
+func canonicalize(el *element, inclusivePrefixes []string, exclude *element) []byte {
+	var buf bytes.Buffer
+
+	writeCanonical(&buf, el, map[string]string{}, inclusivePrefixes, exclude)
+
+	return buf.Bytes()
+}
+
+func writeCanonical(buf *bytes.Buffer, el *element, rendered map[string]string, inclusivePrefixes []string, exclude *element) {
+	utilized := map[string]bool{el.prefix: true}
+
+	var attrs []xml.Attr
+	for _, attr := range el.attrs {
+		if attr.Name.Space == "xmlns" || (attr.Name.Space == "" && attr.Name.Local == "xmlns") {
+			continue
+		}
+
+		if attr.Name.Space != "" {
+			utilized[attr.Name.Space] = true
+		}
+
+		attrs = append(attrs, attr)
+	}
+
+	for _, prefix := range inclusivePrefixes {
+		if prefix == "#default" {
+			prefix = ""
+		}
+
+		if el.lookupNamespace(prefix) != "" {
+			utilized[prefix] = true
+		}
+	}
+
+	scope := make(map[string]string, len(rendered))
+	for prefix, uri := range rendered {
+		scope[prefix] = uri
+	}
+
+	var prefixes []string
+	for prefix := range utilized {
+		if prefix == "xml" {
+			continue
+		}
+
+		uri := el.lookupNamespace(prefix)
+		if previous, ok := rendered[prefix]; (ok && previous == uri) || (!ok && prefix == "" && uri == "") {
+			continue
+		}
+
+		scope[prefix] = uri
+		prefixes = append(prefixes, prefix)
+	}
+
+	sort.Strings(prefixes)
+
+	sort.SliceStable(attrs, func(i, j int) bool {
+		nsi, nsj := el.lookupNamespace(attrs[i].Name.Space), el.lookupNamespace(attrs[j].Name.Space)
+		if attrs[i].Name.Space == "" {
+			nsi = ""
+		}
+
+		if attrs[j].Name.Space == "" {
+			nsj = ""
+		}
+
+		if nsi != nsj {
+			return nsi < nsj
+		}
+
+		return attrs[i].Name.Local < attrs[j].Name.Local
+	})
+
+	name := qualifiedName(el.prefix, el.local)
+
+	buf.WriteString("<" + name)
+
+	for _, prefix := range prefixes {
+		if prefix == "" {
+			buf.WriteString(` xmlns="`)
+		} else {
+			buf.WriteString(` xmlns:` + prefix + `="`)
+		}
+
+		buf.WriteString(escapeAttr(scope[prefix]))
+		buf.WriteString(`"`)
+	}
+
+	for _, attr := range attrs {
+		buf.WriteString(" " + qualifiedName(attr.Name.Space, attr.Name.Local) + `="` + escapeAttr(attr.Value) + `"`)
+	}
+
+	buf.WriteString(">")
+
+	for _, child := range el.children {
+		switch c := child.(type) {
+		case string:
+			buf.WriteString(escapeText(c))
+		case *element:
+			if c == exclude {
+				continue
+			}
+
+			writeCanonical(buf, c, scope, inclusivePrefixes, exclude)
+		}
+	}
+
+	buf.WriteString("</" + name + ">")
+}
+
+func qualifiedName(prefix, local string) string {
+	if prefix == "" {
+		return local
+	}
+
+	return prefix + ":" + local
+}
+
+var (
+	textEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", "\r", "&#xD;")
+	attrEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", `"`, "&quot;", "\t", "&#x9;", "\n", "&#xA;", "\r", "&#xD;")
+)
+
+func escapeText(s string) string {
+	return textEscaper.Replace(s)
+}
+
+func escapeAttr(s string) string {
+	return attrEscaper.Replace(s)
+}
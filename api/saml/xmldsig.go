@@ -0,0 +1,208 @@
+package saml
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/x509"
+	"encoding/base64"
+	"hash"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	namespaceDSig = "http://www.w3.org/2000/09/xmldsig#"
+
+	transformEnveloped = "http://www.w3.org/2000/09/xmldsig#enveloped-signature"
+	transformExcC14N   = "http://www.w3.org/2001/10/xml-exc-c14n#"
+
+	algorithmRSASHA1   = "http://www.w3.org/2000/09/xmldsig#rsa-sha1"
+	algorithmRSASHA256 = "http://www.w3.org/2001/04/xmldsig-more#rsa-sha256"
+	algorithmRSASHA512 = "http://www.w3.org/2001/04/xmldsig-more#rsa-sha512"
+
+	digestSHA1   = "http://www.w3.org/2000/09/xmldsig#sha1"
+	digestSHA256 = "http://www.w3.org/2001/04/xmlenc#sha256"
+	digestSHA512 = "http://www.w3.org/2001/04/xmlenc#sha512"
+)
+
+var (
+	errSignatureMissing = errors.New("the element is not signed")
+	errSignatureInvalid = errors.New("the signature of the element is invalid")
+)
+
+// verifyElementSignature validates the enveloped XML signature of an element against the trusted certificates.
+// Only the exclusive canonicalization algorithm is supported, which is what every SAML identity provider uses
+func verifyElementSignature(el *element, certificates []*x509.Certificate) error {
+	signatures := el.findChildren(namespaceDSig, "Signature")
+	if len(signatures) == 0 {
+		return errSignatureMissing
+	} else if len(signatures) > 1 {
+		return errors.New("the element has several signatures")
+	}
+
+	signature := signatures[0]
+
+	signedInfo := signature.findChild(namespaceDSig, "SignedInfo")
+	if signedInfo == nil {
+		return errors.New("the signature has no SignedInfo")
+	}
+
+	c14nMethod := signedInfo.findChild(namespaceDSig, "CanonicalizationMethod")
+	if c14nMethod == nil || c14nMethod.attr("Algorithm") != transformExcC14N {
+		return errors.New("unsupported canonicalization method")
+	}
+
+	references := signedInfo.findChildren(namespaceDSig, "Reference")
+	if len(references) != 1 {
+		return errors.New("the signature must have exactly one reference")
+	}
+
+	reference := references[0]
+
+	// the reference must point to the element itself, otherwise a signed element could be wrapped
+	// inside an unsigned one
+	id := el.attr("ID")
+	if id == "" || reference.attr("URI") != "#"+id {
+		return errors.New("the signature does not reference the signed element")
+	}
+
+	var inclusivePrefixes []string
+
+	transforms := reference.findChild(namespaceDSig, "Transforms")
+	if transforms == nil {
+		return errors.New("the signature reference has no transforms")
+	}
+
+	for _, transform := range transforms.findChildren(namespaceDSig, "Transform") {
+		switch transform.attr("Algorithm") {
+		case transformEnveloped:
+		case transformExcC14N:
+			inclusivePrefixes = inclusiveNamespaces(transform)
+		default:
+			return errors.Errorf("unsupported transform %q", transform.attr("Algorithm"))
+		}
+	}
+
+	digestMethod := reference.findChild(namespaceDSig, "DigestMethod")
+	if digestMethod == nil {
+		return errors.New("the signature reference has no digest method")
+	}
+
+	digestHash, err := digestAlgorithm(digestMethod.attr("Algorithm"))
+	if err != nil {
+		return err
+	}
+
+	digestValue := reference.findChild(namespaceDSig, "DigestValue")
+	if digestValue == nil {
+		return errors.New("the signature reference has no digest value")
+	}
+
+	expectedDigest, err := base64.StdEncoding.DecodeString(compactBase64(digestValue.text()))
+	if err != nil {
+		return errors.Wrap(err, "unable to decode the digest value")
+	}
+
+	h := newHash(digestHash)
+	h.Write(canonicalize(el, inclusivePrefixes, signature))
+
+	if !bytes.Equal(h.Sum(nil), expectedDigest) {
+		return errSignatureInvalid
+	}
+
+	signatureMethod := signedInfo.findChild(namespaceDSig, "SignatureMethod")
+	if signatureMethod == nil {
+		return errors.New("the signature has no signature method")
+	}
+
+	signatureHash, err := signatureAlgorithm(signatureMethod.attr("Algorithm"))
+	if err != nil {
+		return err
+	}
+
+	signatureValue := signature.findChild(namespaceDSig, "SignatureValue")
+	if signatureValue == nil {
+		return errors.New("the signature has no signature value")
+	}
+
+	rawSignature, err := base64.StdEncoding.DecodeString(compactBase64(signatureValue.text()))
+	if err != nil {
+		return errors.Wrap(err, "unable to decode the signature value")
+	}
+
+	h = newHash(signatureHash)
+	h.Write(canonicalize(signedInfo, inclusiveNamespaces(c14nMethod), nil))
+
+	return verifyWithCertificates(certificates, signatureHash, h.Sum(nil), rawSignature)
+}
+
+func verifyWithCertificates(certificates []*x509.Certificate, hashAlgorithm crypto.Hash, hashed, signature []byte) error {
+	for _, certificate := range certificates {
+		publicKey, ok := certificate.PublicKey.(*rsa.PublicKey)
+		if !ok {
+			continue
+		}
+
+		if rsa.VerifyPKCS1v15(publicKey, hashAlgorithm, hashed, signature) == nil {
+			return nil
+		}
+	}
+
+	return errSignatureInvalid
+}
+
+func inclusiveNamespaces(transform *element) []string {
+	inclusive := transform.findChild(transformExcC14N, "InclusiveNamespaces")
+	if inclusive == nil {
+		return nil
+	}
+
+	return strings.Fields(inclusive.attr("PrefixList"))
+}
+
+func digestAlgorithm(algorithm string) (crypto.Hash, error) {
+	switch algorithm {
+	case digestSHA1:
+		return crypto.SHA1, nil
+	case digestSHA256:
+		return crypto.SHA256, nil
+	case digestSHA512:
+		return crypto.SHA512, nil
+	}
+
+	return 0, errors.Errorf("unsupported digest algorithm %q", algorithm)
+}
+
+func signatureAlgorithm(algorithm string) (crypto.Hash, error) {
+	switch algorithm {
+	case algorithmRSASHA1:
+		return crypto.SHA1, nil
+	case algorithmRSASHA256:
+		return crypto.SHA256, nil
+	case algorithmRSASHA512:
+		return crypto.SHA512, nil
+	}
+
+	return 0, errors.Errorf("unsupported signature algorithm %q", algorithm)
+}
+
+func newHash(algorithm crypto.Hash) hash.Hash {
+	switch algorithm {
+	case crypto.SHA1:
+		return sha1.New()
+	case crypto.SHA512:
+		return sha512.New()
+	}
+
+	return sha256.New()
+}
+
+// compactBase64 removes the line breaks and indentation that identity providers add to base64 values
+func compactBase64(s string) string {
+	return strings.Join(strings.Fields(s), "")
+}
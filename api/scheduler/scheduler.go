@@ -95,6 +95,13 @@ func (s *Scheduler) StopJob(jobID string) error {
 // Returns job id that could be used to stop the given job.
 // When job run returns an error, that job won't be run again.
 func (s *Scheduler) StartJobEvery(duration time.Duration, job func() error) string {
+	return s.StartJobOnSchedule(cron.Every(duration), job)
+}
+
+// StartJobOnSchedule schedules a new job running at the times of a schedule, e.g. one parsed from a cron expression.
+// Returns job id that could be used to stop the given job.
+// When job run returns a permanent error, that job won't be run again.
+func (s *Scheduler) StartJobOnSchedule(schedule cron.Schedule, job func() error) string {
 	entryID := new(cron.EntryID)
 
 	cancelFn := func() {
@@ -119,7 +126,7 @@ func (s *Scheduler) StartJobEvery(duration time.Duration, job func() error) stri
 		log.Error().Err(err).Msg("job returned an error, it will be rescheduled")
 	})
 
-	*entryID = s.crontab.Schedule(cron.Every(duration), jobFn)
+	*entryID = s.crontab.Schedule(schedule, jobFn)
 
 	s.mu.Lock()
 	s.activeJobs[*entryID] = cancelFn
@@ -0,0 +1,137 @@
+package sessionrecording
+
+import (
+	"bytes"
+	"encoding/binary"
+)
+
+const (
+	// maxHandshakeSize bounds the HTTP response preceding the frames of a websocket connection
+	maxHandshakeSize = 64 * 1024
+	// maxFrameSize bounds the frames waiting to be complete, the frames are split by the write buffer of the
+	// websocket connection and are much smaller
+	maxFrameSize = 16 * 1024 * 1024
+)
+
+const (
+	continuationFrame = 0x0
+	textFrame         = 0x1
+	binaryFrame       = 0x2
+)
+
+// frameReader extracts the payload of the data messages from the bytes written on a websocket connection, starting
+// with the HTTP response of its handshake
+type frameReader struct {
+	onData func([]byte)
+
+	buf       []byte
+	handshake bool
+	// whether the continuation frames belong to a data message
+	inData bool
+	// set when the stream cannot be parsed, the rest of it is ignored
+	failed bool
+}
+
+func (reader *frameReader) Write(p []byte) {
+	if reader.failed {
+		return
+	}
+
+	reader.buf = append(reader.buf, p...)
+
+	if !reader.handshake {
+		end := bytes.Index(reader.buf, []byte("\r\n\r\n"))
+		if end < 0 {
+			reader.failed = len(reader.buf) > maxHandshakeSize
+			return
+		}
+
+		reader.buf = reader.buf[end+4:]
+		reader.handshake = true
+	}
+
+	rest := reader.buf
+	for {
+		size, ok := reader.readFrame(rest)
+		if !ok {
+			break
+		}
+
+		rest = rest[size:]
+	}
+
+	reader.buf = append(reader.buf[:0], rest...)
+}
+
+// readFrame reads the frame at the start of buf and returns its size, or false when the frame is not complete yet
+func (reader *frameReader) readFrame(buf []byte) (int, bool) {
+	if len(buf) < 2 {
+		return 0, false
+	}
+
+	opcode := buf[0] & 0x0f
+	// the messages compressed with the permessage-deflate extension are not recorded
+	compressed := buf[0]&0x40 != 0
+	masked := buf[1]&0x80 != 0
+	length := uint64(buf[1] & 0x7f)
+	offset := 2
+
+	switch length {
+	case 126:
+		if len(buf) < offset+2 {
+			return 0, false
+		}
+
+		length = uint64(binary.BigEndian.Uint16(buf[offset:]))
+		offset += 2
+	case 127:
+		if len(buf) < offset+8 {
+			return 0, false
+		}
+
+		length = binary.BigEndian.Uint64(buf[offset:])
+		offset += 8
+	}
+
+	if length > maxFrameSize {
+		reader.failed = true
+		return 0, false
+	}
+
+	var key []byte
+	if masked {
+		if len(buf) < offset+4 {
+			return 0, false
+		}
+
+		key = buf[offset : offset+4]
+		offset += 4
+	}
+
+	end := offset + int(length)
+	if len(buf) < end {
+		return 0, false
+	}
+
+	switch opcode {
+	case textFrame, binaryFrame:
+		reader.inData = !compressed
+	case continuationFrame:
+	default:
+		// the control frames can be sent between the frames of a message
+		return end, true
+	}
+
+	if reader.inData && length > 0 {
+		payload := bytes.Clone(buf[offset:end])
+		if key != nil {
+			for i := range payload {
+				payload[i] ^= key[i%4]
+			}
+		}
+
+		reader.onData(payload)
+	}
+
+	return end, true
+}
@@ -0,0 +1,164 @@
+package sessionrecording
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+)
+
+const (
+	// MaxRecordingSize is the size in bytes of the output recorded for a session, the rest of it is not recorded
+	MaxRecordingSize = 50 * 1024 * 1024
+	// the size of the terminals is not known on the server, the players adapt the recordings to their own size
+	defaultWidth  = 80
+	defaultHeight = 24
+)
+
+// castHeader is the header of a recording in the asciicast v2 format
+type castHeader struct {
+	Version   int               `json:"version"`
+	Width     int               `json:"width"`
+	Height    int               `json:"height"`
+	Timestamp int64             `json:"timestamp"`
+	Title     string            `json:"title,omitempty"`
+	Env       map[string]string `json:"env,omitempty"`
+}
+
+// Recorder records the output of a console session in the asciicast v2 format
+type Recorder struct {
+	service   *Service
+	recording *portainer.SessionRecording
+	file      io.WriteCloser
+	start     time.Time
+	frames    *frameReader
+
+	mu     sync.Mutex
+	closed bool
+	err    error
+}
+
+func newRecorder(service *Service, recording *portainer.SessionRecording, file io.WriteCloser, title string) (*Recorder, error) {
+	recorder := &Recorder{
+		service:   service,
+		recording: recording,
+		file:      file,
+		start:     time.Now(),
+	}
+
+	recorder.frames = &frameReader{onData: recorder.output}
+
+	header, err := json.Marshal(castHeader{
+		Version:   2,
+		Width:     defaultWidth,
+		Height:    defaultHeight,
+		Timestamp: recording.StartedAt,
+		Title:     title,
+		Env:       map[string]string{"TERM": "xterm"},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := recorder.write(append(header, '\n')); err != nil {
+		return nil, err
+	}
+
+	return recorder, nil
+}
+
+// ResponseWriter wraps the response writer of a websocket request so that the output sent on the connection once it
+// is upgraded is recorded
+func (recorder *Recorder) ResponseWriter(w http.ResponseWriter) http.ResponseWriter {
+	return &responseWriter{ResponseWriter: w, recorder: recorder}
+}
+
+// output records the data of a message sent to the client
+func (recorder *Recorder) output(data []byte) {
+	event, err := json.Marshal([]any{time.Since(recorder.start).Seconds(), "o", string(data)})
+	if err != nil {
+		return
+	}
+
+	recorder.write(append(event, '\n'))
+}
+
+func (recorder *Recorder) write(data []byte) error {
+	if recorder.closed || recorder.err != nil || recorder.recording.Truncated {
+		return recorder.err
+	}
+
+	if recorder.recording.Size+int64(len(data)) > MaxRecordingSize {
+		recorder.recording.Truncated = true
+
+		return nil
+	}
+
+	n, err := recorder.file.Write(data)
+	recorder.recording.Size += int64(n)
+	recorder.err = err
+
+	return err
+}
+
+// tap records the bytes written on the connection of the session
+func (recorder *Recorder) tap(p []byte) {
+	recorder.mu.Lock()
+	defer recorder.mu.Unlock()
+
+	recorder.frames.Write(p)
+}
+
+// Close ends the recording when the session ends
+func (recorder *Recorder) Close() error {
+	recorder.mu.Lock()
+	defer recorder.mu.Unlock()
+
+	if recorder.closed {
+		return nil
+	}
+
+	recorder.closed = true
+
+	return errors.Join(recorder.file.Close(), recorder.service.end(recorder.recording))
+}
+
+type responseWriter struct {
+	http.ResponseWriter
+	recorder *Recorder
+}
+
+func (w *responseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("the response writer does not support hijacking")
+	}
+
+	conn, brw, err := hijacker.Hijack()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	recordedConn := &recordedConn{Conn: conn, recorder: w.recorder}
+
+	return recordedConn, bufio.NewReadWriter(brw.Reader, bufio.NewWriterSize(recordedConn, brw.Writer.Size())), nil
+}
+
+// recordedConn records what is written on a connection
+type recordedConn struct {
+	net.Conn
+	recorder *Recorder
+}
+
+func (conn *recordedConn) Write(p []byte) (int, error) {
+	n, err := conn.Conn.Write(p)
+	conn.recorder.tap(p[:n])
+
+	return n, err
+}
@@ -0,0 +1,201 @@
+// Package sessionrecording records the output of the exec and attach console sessions opened on the containers, and
+// of the kubectl shell sessions, in the asciicast v2 format which can be replayed with the asciinema players
+package sessionrecording
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+
+	"github.com/rs/zerolog/log"
+)
+
+// CleanupInterval is the interval between the removals of the files of the deleted recordings
+const CleanupInterval = time.Hour
+
+const castExtension = ".cast"
+
+// ErrRecordingInProgress is returned when a recording whose session is still open is deleted
+var ErrRecordingInProgress = errors.New("the session of the recording is still open")
+
+// Service records the console sessions and manages their recordings
+type Service struct {
+	dataStore dataservices.DataStore
+	folder    string
+}
+
+// NewService creates a new session recording service storing the recordings in the folder of the file service
+func NewService(dataStore dataservices.DataStore, fileService portainer.FileService) *Service {
+	return &Service{
+		dataStore: dataStore,
+		folder:    fileService.GetSessionRecordingFolder(),
+	}
+}
+
+// Start ends the recordings interrupted by the last restart of Portainer and removes the files of the deleted
+// recordings
+func (service *Service) Start() error {
+	if err := os.MkdirAll(service.folder, 0700); err != nil {
+		return err
+	}
+
+	if err := service.dataStore.UpdateTx(func(tx dataservices.DataStoreTx) error {
+		recordings, err := tx.SessionRecording().ReadAll()
+		if err != nil {
+			return err
+		}
+
+		for _, recording := range recordings {
+			if recording.EndedAt != 0 {
+				continue
+			}
+
+			recording.EndedAt = recording.StartedAt
+			if info, err := os.Stat(service.path(recording.ID)); err == nil {
+				recording.EndedAt = info.ModTime().Unix()
+				recording.Size = info.Size()
+			}
+
+			if err := tx.SessionRecording().Update(recording.ID, &recording); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}); err != nil {
+		return fmt.Errorf("unable to end the interrupted recordings: %w", err)
+	}
+
+	return service.Cleanup()
+}
+
+// Record starts recording a session when the recording of the sessions is enabled in the settings, the recorder is
+// nil otherwise
+func (service *Service) Record(recording *portainer.SessionRecording, title string) (*Recorder, error) {
+	settings, err := service.dataStore.Settings().Settings()
+	if err != nil {
+		return nil, err
+	}
+
+	if !settings.EnableSessionRecording {
+		return nil, nil
+	}
+
+	recording.StartedAt = time.Now().Unix()
+	recording.EndedAt = 0
+
+	// the recording is created before its file, which is never seen without its recording by Cleanup
+	if err := service.dataStore.SessionRecording().Create(recording); err != nil {
+		return nil, err
+	}
+
+	file, err := os.OpenFile(service.path(recording.ID), os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		service.dataStore.SessionRecording().Delete(recording.ID)
+
+		return nil, err
+	}
+
+	recorder, err := newRecorder(service, recording, file, title)
+	if err != nil {
+		file.Close()
+		os.Remove(service.path(recording.ID))
+		service.dataStore.SessionRecording().Delete(recording.ID)
+
+		return nil, err
+	}
+
+	return recorder, nil
+}
+
+// end records the end of the session of a recording
+func (service *Service) end(recording *portainer.SessionRecording) error {
+	recording.EndedAt = time.Now().Unix()
+
+	return service.dataStore.UpdateTx(func(tx dataservices.DataStoreTx) error {
+		// the recordings deleted meanwhile are not recorded again
+		if _, err := tx.SessionRecording().Read(recording.ID); err != nil {
+			if service.dataStore.IsErrObjectNotFound(err) {
+				return nil
+			}
+
+			return err
+		}
+
+		return tx.SessionRecording().Update(recording.ID, recording)
+	})
+}
+
+// Open opens the asciicast file of a recording
+func (service *Service) Open(id portainer.SessionRecordingID) (*os.File, error) {
+	return os.Open(service.path(id))
+}
+
+// Delete removes a recording and its file, the recordings whose session is still open cannot be removed
+func (service *Service) Delete(id portainer.SessionRecordingID) error {
+	recording, err := service.dataStore.SessionRecording().Read(id)
+	if err != nil {
+		return err
+	}
+
+	if recording.EndedAt == 0 {
+		return ErrRecordingInProgress
+	}
+
+	if err := service.dataStore.SessionRecording().Delete(id); err != nil {
+		return err
+	}
+
+	if err := os.Remove(service.path(id)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	return nil
+}
+
+// Cleanup removes the files of the recordings which no longer exist, such as the ones purged by their retention policy
+func (service *Service) Cleanup() error {
+	// the files are listed before the recordings, so that the file of a recording created meanwhile is not removed
+	entries, err := os.ReadDir(service.folder)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+
+		return err
+	}
+
+	recordings, err := service.dataStore.SessionRecording().ReadAll()
+	if err != nil {
+		return err
+	}
+
+	exists := make(map[portainer.SessionRecordingID]bool, len(recordings))
+	for _, recording := range recordings {
+		exists[recording.ID] = true
+	}
+
+	for _, entry := range entries {
+		id, err := strconv.Atoi(strings.TrimSuffix(entry.Name(), castExtension))
+		if err != nil || !strings.HasSuffix(entry.Name(), castExtension) || exists[portainer.SessionRecordingID(id)] {
+			continue
+		}
+
+		if err := os.Remove(filepath.Join(service.folder, entry.Name())); err != nil {
+			log.Warn().Err(err).Str("file", entry.Name()).Msg("unable to remove the file of a deleted session recording")
+		}
+	}
+
+	return nil
+}
+
+func (service *Service) path(id portainer.SessionRecordingID) string {
+	return filepath.Join(service.folder, strconv.Itoa(int(id))+castExtension)
+}
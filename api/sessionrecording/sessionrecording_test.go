@@ -0,0 +1,199 @@
+package sessionrecording
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/datastore"
+
+	"github.com/stretchr/testify/require"
+)
+
+func frame(opcode byte, fin bool, payload []byte, key []byte) []byte {
+	first := opcode
+	if fin {
+		first |= 0x80
+	}
+
+	buf := []byte{first}
+
+	second := byte(0)
+	if key != nil {
+		second = 0x80
+	}
+
+	switch {
+	case len(payload) < 126:
+		buf = append(buf, second|byte(len(payload)))
+	default:
+		buf = append(buf, second|126, byte(len(payload)>>8), byte(len(payload)))
+	}
+
+	if key == nil {
+		return append(buf, payload...)
+	}
+
+	buf = append(buf, key...)
+	for i, b := range payload {
+		buf = append(buf, b^key[i%4])
+	}
+
+	return buf
+}
+
+func TestFrameReader(t *testing.T) {
+	is := require.New(t)
+
+	var messages []string
+	reader := &frameReader{onData: func(data []byte) {
+		messages = append(messages, string(data))
+	}}
+
+	stream := []byte("HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\n\r\n")
+	stream = append(stream, frame(textFrame, true, []byte("$ ls\r\n"), nil)...)
+	stream = append(stream, frame(binaryFrame, false, []byte("bin "), []byte{1, 2, 3, 4})...)
+	// a ping between the frames of a fragmented message is not recorded
+	stream = append(stream, frame(0x9, true, []byte("ping"), nil)...)
+	stream = append(stream, frame(continuationFrame, true, []byte("etc"), nil)...)
+	stream = append(stream, frame(textFrame, true, bytes.Repeat([]byte("a"), 300), nil)...)
+	stream = append(stream, frame(0x8, true, nil, nil)...)
+
+	// the stream is written in small chunks, the way the connection could split it
+	for len(stream) > 0 {
+		n := min(7, len(stream))
+		reader.Write(stream[:n])
+		stream = stream[n:]
+	}
+
+	is.False(reader.failed)
+	is.Equal([]string{"$ ls\r\n", "bin ", "etc", strings.Repeat("a", 300)}, messages)
+}
+
+func TestFrameReaderCompressed(t *testing.T) {
+	is := require.New(t)
+
+	var messages []string
+	reader := &frameReader{onData: func(data []byte) {
+		messages = append(messages, string(data))
+	}}
+
+	compressed := frame(textFrame, true, []byte("deflated"), nil)
+	compressed[0] |= 0x40
+
+	reader.Write([]byte("HTTP/1.1 101 Switching Protocols\r\n\r\n"))
+	reader.Write(compressed)
+	reader.Write(frame(textFrame, true, []byte("plain"), nil))
+
+	is.Equal([]string{"plain"}, messages)
+}
+
+func newTestService(t *testing.T) (*Service, *datastore.Store) {
+	_, store := datastore.MustNewTestStore(t, true, false)
+
+	return &Service{dataStore: store, folder: t.TempDir()}, store
+}
+
+func enableRecording(t *testing.T, store *datastore.Store) {
+	settings, err := store.Settings().Settings()
+	require.NoError(t, err)
+
+	settings.EnableSessionRecording = true
+	require.NoError(t, store.Settings().UpdateSettings(settings))
+}
+
+func TestRecord(t *testing.T) {
+	is := require.New(t)
+
+	service, store := newTestService(t)
+
+	// the sessions are not recorded until the recording is enabled
+	recorder, err := service.Record(&portainer.SessionRecording{Type: portainer.SessionRecordingExec}, "exec")
+	is.NoError(err)
+	is.Nil(recorder)
+
+	enableRecording(t, store)
+
+	recording := &portainer.SessionRecording{Type: portainer.SessionRecordingExec, EndpointID: 1, ContainerID: "abc", ExecID: "def", UserID: 1, Username: "admin"}
+	recorder, err = service.Record(recording, "exec abc by admin")
+	is.NoError(err)
+	is.NotNil(recorder)
+
+	recorder.tap([]byte("HTTP/1.1 101 Switching Protocols\r\n\r\n"))
+	recorder.tap(frame(textFrame, true, []byte("hello\r\n"), nil))
+
+	is.ErrorIs(service.Delete(recording.ID), ErrRecordingInProgress)
+
+	is.NoError(recorder.Close())
+	is.NoError(recorder.Close())
+
+	stored, err := store.SessionRecording().Read(recording.ID)
+	is.NoError(err)
+	is.NotZero(stored.EndedAt)
+	is.NotZero(stored.Size)
+	is.False(stored.Truncated)
+
+	file, err := service.Open(recording.ID)
+	is.NoError(err)
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+
+	is.True(scanner.Scan())
+	var header castHeader
+	is.NoError(json.Unmarshal(scanner.Bytes(), &header))
+	is.Equal(2, header.Version)
+	is.Equal("exec abc by admin", header.Title)
+
+	is.True(scanner.Scan())
+	var event []any
+	is.NoError(json.Unmarshal(scanner.Bytes(), &event))
+	is.Len(event, 3)
+	is.Equal("o", event[1])
+	is.Equal("hello\r\n", event[2])
+
+	is.False(scanner.Scan())
+
+	info, err := file.Stat()
+	is.NoError(err)
+	is.Equal(info.Size(), stored.Size)
+
+	is.NoError(service.Delete(recording.ID))
+	_, err = os.Stat(service.path(recording.ID))
+	is.True(os.IsNotExist(err))
+}
+
+func TestStartAndCleanup(t *testing.T) {
+	is := require.New(t)
+
+	service, store := newTestService(t)
+
+	interrupted := &portainer.SessionRecording{Type: portainer.SessionRecordingAttach, StartedAt: 100}
+	is.NoError(store.SessionRecording().Create(interrupted))
+	is.NoError(os.WriteFile(service.path(interrupted.ID), []byte("{}\n"), 0600))
+
+	// the files of the deleted recordings and the unrelated files
+	is.NoError(os.WriteFile(service.path(42), nil, 0600))
+	is.NoError(os.WriteFile(filepath.Join(service.folder, "notes.txt"), nil, 0600))
+
+	is.NoError(service.Start())
+
+	stored, err := store.SessionRecording().Read(interrupted.ID)
+	is.NoError(err)
+	is.NotZero(stored.EndedAt)
+	is.Equal(int64(3), stored.Size)
+
+	_, err = os.Stat(service.path(interrupted.ID))
+	is.NoError(err)
+
+	_, err = os.Stat(service.path(42))
+	is.True(os.IsNotExist(err))
+
+	_, err = os.Stat(filepath.Join(service.folder, "notes.txt"))
+	is.NoError(err)
+}
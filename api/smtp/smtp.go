@@ -0,0 +1,187 @@
+// Package smtp sends the emails of Portainer through the SMTP server of the settings
+package smtp
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/smtp"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+)
+
+const dialTimeout = 10 * time.Second
+
+// ErrNotConfigured is returned when an email is sent without SMTP server in the settings
+var ErrNotConfigured = errors.New("no SMTP server is configured")
+
+// Attachment represents a file attached to an email
+type Attachment struct {
+	Filename    string
+	ContentType string
+	Content     []byte
+}
+
+// Message represents an HTML email
+type Message struct {
+	To          []string
+	Subject     string
+	HTML        string
+	Attachments []Attachment
+}
+
+// Send sends an email through the SMTP server of the settings
+func Send(settings portainer.SMTPSettings, message Message) error {
+	if settings.Host == "" {
+		return ErrNotConfigured
+	}
+
+	if len(message.To) == 0 {
+		return errors.New("the email has no recipient")
+	}
+
+	body, err := encode(settings.From, message)
+	if err != nil {
+		return err
+	}
+
+	address := net.JoinHostPort(settings.Host, strconv.Itoa(settings.Port))
+	tlsConfig := &tls.Config{ServerName: settings.Host}
+
+	var conn net.Conn
+	if settings.TLS {
+		conn, err = tls.DialWithDialer(&net.Dialer{Timeout: dialTimeout}, "tcp", address, tlsConfig)
+	} else {
+		conn, err = net.DialTimeout("tcp", address, dialTimeout)
+	}
+	if err != nil {
+		return err
+	}
+
+	client, err := smtp.NewClient(conn, settings.Host)
+	if err != nil {
+		conn.Close()
+
+		return err
+	}
+	defer client.Close()
+
+	if !settings.TLS {
+		if ok, _ := client.Extension("STARTTLS"); ok {
+			if err := client.StartTLS(tlsConfig); err != nil {
+				return err
+			}
+		}
+	}
+
+	if settings.Username != "" {
+		if err := client.Auth(smtp.PlainAuth("", settings.Username, settings.Password, settings.Host)); err != nil {
+			return err
+		}
+	}
+
+	if err := client.Mail(settings.From); err != nil {
+		return err
+	}
+
+	for _, to := range message.To {
+		if err := client.Rcpt(to); err != nil {
+			return fmt.Errorf("the recipient %s was refused: %w", to, err)
+		}
+	}
+
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+
+	if _, err := w.Write(body); err != nil {
+		return err
+	}
+
+	if err := w.Close(); err != nil {
+		return err
+	}
+
+	return client.Quit()
+}
+
+// encode renders a message in the MIME format, the HTML body being followed by the attachments
+func encode(from string, message Message) ([]byte, error) {
+	var buf bytes.Buffer
+
+	writer := multipart.NewWriter(&buf)
+
+	fmt.Fprintf(&buf, "From: %s\r\n", from)
+	fmt.Fprintf(&buf, "To: %s\r\n", strings.Join(message.To, ", "))
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", message.Subject))
+	fmt.Fprintf(&buf, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	fmt.Fprintf(&buf, "MIME-Version: 1.0\r\n")
+	fmt.Fprintf(&buf, "Content-Type: multipart/mixed; boundary=%s\r\n\r\n", writer.Boundary())
+
+	part, err := writer.CreatePart(textproto.MIMEHeader{
+		"Content-Type":              {"text/html; charset=utf-8"},
+		"Content-Transfer-Encoding": {"quoted-printable"},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	qp := quotedprintable.NewWriter(part)
+	if _, err := qp.Write([]byte(message.HTML)); err != nil {
+		return nil, err
+	}
+
+	if err := qp.Close(); err != nil {
+		return nil, err
+	}
+
+	for _, attachment := range message.Attachments {
+		part, err := writer.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {attachment.ContentType},
+			"Content-Transfer-Encoding": {"base64"},
+			"Content-Disposition":       {mime.FormatMediaType("attachment", map[string]string{"filename": attachment.Filename})},
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		if err := writeBase64(part, attachment.Content); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// writeBase64 writes content in base64 with lines of 76 characters, as required by the MIME format
+func writeBase64(w io.Writer, content []byte) error {
+	encoded := base64.StdEncoding.EncodeToString(content)
+
+	for len(encoded) > 0 {
+		n := min(76, len(encoded))
+
+		if _, err := fmt.Fprintf(w, "%s\r\n", encoded[:n]); err != nil {
+			return err
+		}
+
+		encoded = encoded[n:]
+	}
+
+	return nil
+}
@@ -1,6 +1,7 @@
 package deployments
 
 import (
+	"errors"
 	"time"
 
 	portainer "github.com/portainer/portainer/api"
@@ -18,7 +19,11 @@ func StartAutoupdate(stackID portainer.StackID, interval string, scheduler *sche
 	}
 
 	jobID = scheduler.StartJobEvery(d, func() error {
-		return RedeployWhenChanged(stackID, stackDeployer, datastore, gitService)
+		if err := RedeployWhenChanged(stackID, stackDeployer, datastore, gitService); !errors.Is(err, ErrAutoUpdatePaused) {
+			return err
+		}
+
+		return nil
 	})
 
 	return jobID, nil
@@ -13,8 +13,10 @@ import (
 	"github.com/portainer/portainer/api/dataservices"
 	"github.com/portainer/portainer/api/git/update"
 	"github.com/portainer/portainer/api/http/security"
+	"github.com/portainer/portainer/api/internal/authorization"
 	"github.com/portainer/portainer/api/scheduler"
 	"github.com/portainer/portainer/api/stacks/stackutils"
+	"github.com/portainer/portainer/api/stacks/stackversions"
 
 	"github.com/pkg/errors"
 	"github.com/rs/zerolog/log"
@@ -30,6 +32,9 @@ func (e *StackAuthorMissingErr) Error() string {
 	return fmt.Sprintf("stack's %v author %s is missing", e.stackID, e.authorName)
 }
 
+// ErrAutoUpdatePaused is returned when the auto update of the stack is paused
+var ErrAutoUpdatePaused = errors.New("the auto update of the stack is paused")
+
 var singleflightGroup = &singleflight.Group{}
 
 // RedeployWhenChanged pull and redeploy the stack when git repo changed
@@ -42,20 +47,43 @@ func RedeployWhenChanged(stackID portainer.StackID, deployer StackDeployer, data
 		return errors.WithMessagef(err, "failed to get the stack %v", stackID)
 	}
 
+	if stack.AutoUpdate != nil && stack.AutoUpdate.Paused {
+		return ErrAutoUpdatePaused
+	}
+
 	// Webhook
 	if stack.AutoUpdate != nil && stack.AutoUpdate.Webhook != "" {
-		return redeployWhenChanged(stack, deployer, datastore, gitService, true)
+		return redeployWhenChanged(stack, deployer, datastore, gitService, true, false)
 	}
 
 	// Polling
 	_, err, _ = singleflightGroup.Do(strconv.Itoa(int(stackID)), func() (any, error) {
-		return nil, redeployWhenChanged(stack, deployer, datastore, gitService, false)
+		return nil, redeployWhenChanged(stack, deployer, datastore, gitService, false, false)
+	})
+
+	return err
+}
+
+// ForceRedeploy pulls and redeploys the git stack even when the tracked reference did not change, it is not affected
+// by a paused auto update
+func ForceRedeploy(stackID portainer.StackID, deployer StackDeployer, datastore dataservices.DataStore, gitService portainer.GitService) error {
+	stack, err := datastore.Stack().Read(stackID)
+	if err != nil {
+		return errors.WithMessagef(err, "failed to get the stack %v", stackID)
+	}
+
+	if stack.GitConfig == nil {
+		return errors.Errorf("the stack %v is not deployed from a git repository", stackID)
+	}
+
+	_, err, _ = singleflightGroup.Do(strconv.Itoa(int(stackID))+":force", func() (any, error) {
+		return nil, redeployWhenChanged(stack, deployer, datastore, gitService, false, true)
 	})
 
 	return err
 }
 
-func redeployWhenChanged(stack *portainer.Stack, deployer StackDeployer, datastore dataservices.DataStore, gitService portainer.GitService, webhook bool) error {
+func redeployWhenChanged(stack *portainer.Stack, deployer StackDeployer, datastore dataservices.DataStore, gitService portainer.GitService, webhook, force bool) error {
 	log.Debug().Int("stack_id", int(stack.ID)).Msg("redeploying stack")
 
 	if stack.GitConfig == nil {
@@ -95,7 +123,7 @@ func redeployWhenChanged(stack *portainer.Stack, deployer StackDeployer, datasto
 
 	if webhook {
 		go func() {
-			if err := redeployWhenChangedSecondStage(stack, deployer, datastore, gitService, user, endpoint); err != nil {
+			if err := redeployAndRecord(stack, deployer, datastore, gitService, user, endpoint, force); err != nil {
 				log.Error().Err(err).
 					Int("stack_id", int(stack.ID)).
 					Str("stack", stack.Name).
@@ -108,36 +136,122 @@ func redeployWhenChanged(stack *portainer.Stack, deployer StackDeployer, datasto
 		return nil
 	}
 
-	return redeployWhenChangedSecondStage(stack, deployer, datastore, gitService, user, endpoint)
+	return redeployAndRecord(stack, deployer, datastore, gitService, user, endpoint, force)
 }
 
-func redeployWhenChangedSecondStage(
+// redeployAndRecord runs the second stage of the redeployment and records its outcome in the auto update status of
+// the stack
+func redeployAndRecord(
 	stack *portainer.Stack,
 	deployer StackDeployer,
 	datastore dataservices.DataStore,
 	gitService portainer.GitService,
 	user *portainer.User,
 	endpoint *portainer.Endpoint,
+	force bool,
 ) error {
-	var gitCommitChangedOrForceUpdate bool
+	deployedCommit, err := redeployWhenChangedSecondStage(stack, deployer, datastore, gitService, user, endpoint, force)
 
-	if !stack.FromAppTemplate {
-		updated, newHash, err := update.UpdateGitObject(gitService, fmt.Sprintf("stack:%d", stack.ID), stack.GitConfig, false, false, stack.ProjectPath)
+	recordAutoUpdateStatus(datastore, stack.ID, deployedCommit, err)
+
+	return err
+}
+
+func recordAutoUpdateStatus(datastore dataservices.DataStore, stackID portainer.StackID, deployedCommit string, deployErr error) {
+	err := datastore.UpdateTx(func(tx dataservices.DataStoreTx) error {
+		stack, err := tx.Stack().Read(stackID)
 		if err != nil {
 			return err
 		}
 
+		status := &portainer.AutoUpdateStatus{CheckedAt: time.Now().Unix()}
+		if stack.AutoUpdateStatus != nil {
+			status.DeployedCommit = stack.AutoUpdateStatus.DeployedCommit
+			status.DeployedAt = stack.AutoUpdateStatus.DeployedAt
+		}
+
+		if deployErr != nil {
+			status.Error = deployErr.Error()
+		} else if deployedCommit != "" {
+			status.DeployedCommit = deployedCommit
+			status.DeployedAt = status.CheckedAt
+		}
+
+		stack.AutoUpdateStatus = status
+
+		return tx.Stack().Update(stack.ID, stack)
+	})
+	if err != nil {
+		log.Warn().Err(err).Int("stack_id", int(stackID)).Msg("unable to record the auto update status of the stack")
+	}
+}
+
+// redeployWhenChangedSecondStage returns the commit the stack was redeployed from, empty when the stack was not
+// redeployed
+func redeployWhenChangedSecondStage(
+	stack *portainer.Stack,
+	deployer StackDeployer,
+	datastore dataservices.DataStore,
+	gitService portainer.GitService,
+	user *portainer.User,
+	endpoint *portainer.Endpoint,
+	force bool,
+) (string, error) {
+	gitCommitChangedOrForceUpdate := force
+
+	if !stack.FromAppTemplate {
+		updated, newHash, err := update.UpdateGitObject(gitService, fmt.Sprintf("stack:%d", stack.ID), stack.GitConfig, force, false, stack.ProjectPath)
+		if err != nil {
+			return "", err
+		}
+
 		if updated {
 			stack.GitConfig.ConfigHash = newHash
 			stack.UpdateDate = time.Now().Unix()
-			gitCommitChangedOrForceUpdate = updated
+			gitCommitChangedOrForceUpdate = true
 		}
 	}
 
 	if !gitCommitChangedOrForceUpdate {
-		return nil
+		return "", nil
+	}
+
+	if err := redeployStack(stack, deployer, datastore, user, endpoint); err != nil {
+		return "", err
+	}
+
+	return stack.GitConfig.ConfigHash, nil
+}
+
+// RedeployWithPull redeploys the stack with the latest version of its images, on behalf of its author. The stacks
+// deployed from a git repository are pulled from their repository first
+func RedeployWithPull(stackID portainer.StackID, deployer StackDeployer, datastore dataservices.DataStore, gitService portainer.GitService) error {
+	stack, err := datastore.Stack().Read(stackID)
+	if err != nil {
+		return errors.WithMessagef(err, "failed to get the stack %v", stackID)
 	}
 
+	if stack.GitConfig != nil {
+		return ForceRedeploy(stackID, deployer, datastore, gitService)
+	}
+
+	endpoint, err := datastore.Endpoint().Endpoint(stack.EndpointID)
+	if err != nil {
+		return errors.WithMessagef(err, "failed to find the environment %v associated to the stack %v", stack.EndpointID, stack.ID)
+	}
+
+	author := cmp.Or(stack.UpdatedBy, stack.CreatedBy)
+
+	user, err := datastore.User().UserByUsername(author)
+	if err != nil {
+		return &StackAuthorMissingErr{int(stack.ID), author}
+	}
+
+	return redeployStack(stack, deployer, datastore, user, endpoint)
+}
+
+// redeployStack deploys the stack with the registries of the user, pulling its images, and records the deployment
+func redeployStack(stack *portainer.Stack, deployer StackDeployer, datastore dataservices.DataStore, user *portainer.User, endpoint *portainer.Endpoint) error {
 	registries, err := getUserRegistries(datastore, user, endpoint.ID)
 	if dataservices.IsErrObjectNotFound(err) {
 		return scheduler.NewPermanentError(err)
@@ -181,6 +295,8 @@ func redeployWhenChangedSecondStage(
 		return errors.WithMessagef(err, "failed to update the stack %v", stack.ID)
 	}
 
+	stackversions.RecordStack(datastore, stack, user.Username)
+
 	return nil
 }
 
@@ -194,7 +310,7 @@ func getUserRegistries(datastore dataservices.DataStore, user *portainer.User, e
 		return registries, nil
 	}
 
-	userMemberships, err := datastore.TeamMembership().TeamMembershipsByUserID(user.ID)
+	userMemberships, err := authorization.EffectiveTeamMemberships(datastore, user.ID)
 	if err != nil {
 		return nil, errors.WithMessagef(err, "failed to fetch memberships of the stack author [%s]", user.Username)
 	}
@@ -287,6 +287,81 @@ func Test_redeployWhenChanged(t *testing.T) {
 	})
 }
 
+func Test_redeployWhenChanged_autoUpdateControls(t *testing.T) {
+	is := require.New(t)
+
+	_, store := datastore.MustNewTestStore(t, true, true)
+
+	is.NoError(store.Endpoint().Create(&portainer.Endpoint{ID: 1}))
+	is.NoError(store.User().Create(&portainer.User{Username: "user", Role: portainer.AdministratorRole}))
+
+	stack := portainer.Stack{
+		ID:          1,
+		EndpointID:  1,
+		Type:        portainer.DockerComposeStack,
+		ProjectPath: t.TempDir(),
+		UpdatedBy:   "user",
+		GitConfig: &gittypes.RepoConfig{
+			URL:           "url",
+			ReferenceName: "ref",
+			ConfigHash:    "oldHash",
+		},
+		AutoUpdate: &portainer.AutoUpdateSettings{Interval: "5m", Paused: true},
+	}
+	is.NoError(store.Stack().Create(&stack))
+
+	// a paused stack is not redeployed
+	err := RedeployWhenChanged(1, &noopDeployer{}, store, testhelpers.NewGitService(nil, "newHash"))
+	is.ErrorIs(err, ErrAutoUpdatePaused)
+
+	refreshable, err := store.Stack().RefreshableStacks()
+	is.NoError(err)
+	is.Empty(refreshable)
+
+	// the commit did not change
+	stack.AutoUpdate.Paused = false
+	is.NoError(store.Stack().Update(stack.ID, &stack))
+
+	is.NoError(RedeployWhenChanged(1, &noopDeployer{}, store, testhelpers.NewGitService(nil, "oldHash")))
+
+	updated, err := store.Stack().Read(1)
+	is.NoError(err)
+	is.NotNil(updated.AutoUpdateStatus)
+	is.NotZero(updated.AutoUpdateStatus.CheckedAt)
+	is.Empty(updated.AutoUpdateStatus.DeployedCommit)
+
+	// a new commit is deployed
+	is.NoError(RedeployWhenChanged(1, &noopDeployer{}, store, testhelpers.NewGitService(nil, "newHash")))
+
+	updated, err = store.Stack().Read(1)
+	is.NoError(err)
+	is.Equal("newHash", updated.GitConfig.ConfigHash)
+	is.Equal("newHash", updated.AutoUpdateStatus.DeployedCommit)
+	is.NotZero(updated.AutoUpdateStatus.DeployedAt)
+
+	// the errors are recorded and keep the deployed commit
+	cloneErr := errors.New("failed to clone")
+	is.Error(ForceRedeploy(1, &noopDeployer{}, store, testhelpers.NewGitService(cloneErr, "newHash")))
+
+	updated, err = store.Stack().Read(1)
+	is.NoError(err)
+	is.Contains(updated.AutoUpdateStatus.Error, cloneErr.Error())
+	is.Equal("newHash", updated.AutoUpdateStatus.DeployedCommit)
+
+	// a forced redeployment ignores the paused auto update and the unchanged commit
+	updated.AutoUpdate.Paused = true
+	updated.AutoUpdateStatus.DeployedAt = 0
+	is.NoError(store.Stack().Update(updated.ID, updated))
+
+	is.NoError(ForceRedeploy(1, &noopDeployer{}, store, testhelpers.NewGitService(nil, "newHash")))
+
+	updated, err = store.Stack().Read(1)
+	is.NoError(err)
+	is.Empty(updated.AutoUpdateStatus.Error)
+	is.Equal("newHash", updated.AutoUpdateStatus.DeployedCommit)
+	is.NotZero(updated.AutoUpdateStatus.DeployedAt)
+}
+
 func Test_getUserRegistries(t *testing.T) {
 	_, store := datastore.MustNewTestStore(t, true, true)
 
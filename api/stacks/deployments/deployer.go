@@ -7,9 +7,12 @@ import (
 	portainer "github.com/portainer/portainer/api"
 	"github.com/portainer/portainer/api/dataservices"
 	dockerclient "github.com/portainer/portainer/api/docker/client"
+	"github.com/portainer/portainer/api/docker/quarantine"
 	k "github.com/portainer/portainer/api/kubernetes"
+	"github.com/portainer/portainer/api/stacks/hooks"
 
 	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
 )
 
 type BaseStackDeployer interface {
@@ -30,6 +33,7 @@ type stackDeployer struct {
 	kubernetesDeployer  portainer.KubernetesDeployer
 	ClientFactory       *dockerclient.ClientFactory
 	dataStore           dataservices.DataStore
+	hookRunner          *hooks.Runner
 }
 
 // NewStackDeployer inits a stackDeployer struct with a SwarmStackManager, a ComposeStackManager and a KubernetesDeployer
@@ -42,36 +46,40 @@ func NewStackDeployer(swarmStackManager portainer.SwarmStackManager, composeStac
 		kubernetesDeployer:  kubernetesDeployer,
 		ClientFactory:       clientFactory,
 		dataStore:           dataStore,
+		hookRunner:          hooks.NewRunner(clientFactory),
 	}
 }
-func (d *stackDeployer) DeploySwarmStack(stack *portainer.Stack, endpoint *portainer.Endpoint, registries []portainer.Registry, prune, pullImage bool) error {
-	d.lock.Lock()
-	defer d.lock.Unlock()
 
-	d.swarmStackManager.Login(registries, endpoint)
-	defer d.swarmStackManager.Logout(endpoint)
+// withHooks runs the deployment between the pre-deploy and the post-deploy hooks of the stack, unless the
+// environment is quarantined. Only the deployment holds the lock of the deployer, the hooks running for up to their
+// timeout without delaying the deployments of the other stacks. The hook runs replace the ones of the previous
+// deployment, they are persisted with the stack by the caller of a successful deployment and right away when the
+// deployment fails
+func (d *stackDeployer) withHooks(stack *portainer.Stack, endpoint *portainer.Endpoint, deploy func() error) error {
+	if err := quarantine.Check(endpoint); err != nil {
+		return err
+	}
 
-	return d.swarmStackManager.Deploy(stack, prune, pullImage, endpoint)
-}
+	stack.HookRuns = nil
+
+	if err := d.hookRunner.Run(hooks.PreDeploy, stack, endpoint); err != nil {
+		d.saveHookRuns(stack)
+
+		return err
+	}
 
-func (d *stackDeployer) DeployComposeStack(stack *portainer.Stack, endpoint *portainer.Endpoint, registries []portainer.Registry, forcePullImage, forceRecreate bool) error {
 	d.lock.Lock()
-	defer d.lock.Unlock()
+	err := deploy()
+	d.lock.Unlock()
 
-	options := portainer.ComposeOptions{Registries: registries}
+	if err != nil {
+		d.saveHookRuns(stack)
 
-	// --force-recreate doesn't pull updated images
-	if forcePullImage {
-		if err := d.composeStackManager.Pull(context.TODO(), stack, endpoint, options); err != nil {
-			return err
-		}
+		return err
 	}
 
-	if err := d.composeStackManager.Up(context.TODO(), stack, endpoint, portainer.ComposeUpOptions{
-		ComposeOptions: options,
-		ForceRecreate:  forceRecreate,
-	}); err != nil {
-		d.composeStackManager.Down(context.TODO(), stack, endpoint)
+	if err := d.hookRunner.Run(hooks.PostDeploy, stack, endpoint); err != nil {
+		d.saveHookRuns(stack)
 
 		return err
 	}
@@ -79,10 +87,58 @@ func (d *stackDeployer) DeployComposeStack(stack *portainer.Stack, endpoint *por
 	return nil
 }
 
-func (d *stackDeployer) DeployKubernetesStack(stack *portainer.Stack, endpoint *portainer.Endpoint, user *portainer.User) error {
-	d.lock.Lock()
-	defer d.lock.Unlock()
+// saveHookRuns persists the hook runs of a failed deployment, leaving the other fields of the stored stack untouched
+func (d *stackDeployer) saveHookRuns(stack *portainer.Stack) {
+	if d.dataStore == nil {
+		return
+	}
+
+	stored, err := d.dataStore.Stack().Read(stack.ID)
+	if err != nil {
+		// the stack is not created yet
+		return
+	}
+
+	stored.HookRuns = stack.HookRuns
+
+	if err := d.dataStore.Stack().Update(stored.ID, stored); err != nil {
+		log.Warn().Err(err).Str("stack", stack.Name).Msg("unable to save the hook runs of the stack")
+	}
+}
+func (d *stackDeployer) DeploySwarmStack(stack *portainer.Stack, endpoint *portainer.Endpoint, registries []portainer.Registry, prune, pullImage bool) error {
+	return d.withHooks(stack, endpoint, func() error {
+		d.swarmStackManager.Login(registries, endpoint)
+		defer d.swarmStackManager.Logout(endpoint)
+
+		return d.swarmStackManager.Deploy(stack, prune, pullImage, endpoint)
+	})
+}
+
+func (d *stackDeployer) DeployComposeStack(stack *portainer.Stack, endpoint *portainer.Endpoint, registries []portainer.Registry, forcePullImage, forceRecreate bool) error {
+	options := portainer.ComposeOptions{Registries: registries}
+
+	return d.withHooks(stack, endpoint, func() error {
+		// --force-recreate doesn't pull updated images
+		if forcePullImage {
+			if err := d.composeStackManager.Pull(context.TODO(), stack, endpoint, options); err != nil {
+				return err
+			}
+		}
+
+		if err := d.composeStackManager.Up(context.TODO(), stack, endpoint, portainer.ComposeUpOptions{
+			ComposeOptions: options,
+			ForceRecreate:  forceRecreate,
+		}); err != nil {
+			d.composeStackManager.Down(context.TODO(), stack, endpoint)
 
+			return err
+		}
+
+		return nil
+	})
+}
+
+func (d *stackDeployer) DeployKubernetesStack(stack *portainer.Stack, endpoint *portainer.Endpoint, user *portainer.User) error {
 	appLabels := k.KubeAppLabels{
 		StackID:   int(stack.ID),
 		StackName: stack.Name,
@@ -95,14 +151,16 @@ func (d *stackDeployer) DeployKubernetesStack(stack *portainer.Stack, endpoint *
 		appLabels.Kind = "git"
 	}
 
-	k8sDeploymentConfig, err := CreateKubernetesStackDeploymentConfig(stack, d.kubernetesDeployer, appLabels, user, endpoint)
-	if err != nil {
-		return errors.Wrap(err, "failed to create temp kub deployment files")
-	}
+	return d.withHooks(stack, endpoint, func() error {
+		k8sDeploymentConfig, err := CreateKubernetesStackDeploymentConfig(d.dataStore, stack, d.kubernetesDeployer, appLabels, user, endpoint)
+		if err != nil {
+			return errors.Wrap(err, "failed to create temp kub deployment files")
+		}
 
-	if err := k8sDeploymentConfig.Deploy(); err != nil {
-		return errors.Wrap(err, "failed to deploy kubernetes application")
-	}
+		if err := k8sDeploymentConfig.Deploy(); err != nil {
+			return errors.Wrap(err, "failed to deploy kubernetes application")
+		}
 
-	return nil
+		return nil
+	})
 }
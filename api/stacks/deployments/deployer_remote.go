@@ -7,11 +7,14 @@ import (
 	"io"
 	"math/rand"
 	"os"
+	"strconv"
 	"strings"
 	"time"
 
 	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/docker/quarantine"
 	"github.com/portainer/portainer/api/filesystem"
+	"github.com/portainer/portainer/api/stacks/stackutils"
 
 	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/api/types/container"
@@ -52,28 +55,27 @@ func (d *stackDeployer) DeployRemoteComposeStack(
 	forcePullImage bool,
 	forceRecreate bool,
 ) error {
-	d.lock.Lock()
-	defer d.lock.Unlock()
-
-	d.swarmStackManager.Login(registries, endpoint)
-	defer d.swarmStackManager.Logout(endpoint)
-
-	// --force-recreate doesn't pull updated images
-	if forcePullImage {
-		if err := d.composeStackManager.Pull(context.TODO(), stack, endpoint, portainer.ComposeOptions{}); err != nil {
-			return err
+	return d.withHooks(stack, endpoint, func() error {
+		d.swarmStackManager.Login(registries, endpoint)
+		defer d.swarmStackManager.Logout(endpoint)
+
+		// --force-recreate doesn't pull updated images
+		if forcePullImage {
+			if err := d.composeStackManager.Pull(context.TODO(), stack, endpoint, portainer.ComposeOptions{}); err != nil {
+				return err
+			}
 		}
-	}
 
-	return d.remoteStack(
-		stack,
-		endpoint,
-		OperationDeploy,
-		unpackerCmdBuilderOptions{
-			forceRecreate: forceRecreate,
-			registries:    registries,
-		},
-	)
+		return d.remoteStack(
+			stack,
+			endpoint,
+			OperationDeploy,
+			unpackerCmdBuilderOptions{
+				forceRecreate: forceRecreate,
+				registries:    registries,
+			},
+		)
+	})
 }
 
 // Undeploy a compose stack on remote environment using a https://github.com/portainer/compose-unpacker container
@@ -90,6 +92,10 @@ func (d *stackDeployer) StartRemoteComposeStack(
 	endpoint *portainer.Endpoint,
 	registries []portainer.Registry,
 ) error {
+	if err := quarantine.Check(endpoint); err != nil {
+		return err
+	}
+
 	return d.remoteStack(
 		stack,
 		endpoint,
@@ -113,17 +119,16 @@ func (d *stackDeployer) DeployRemoteSwarmStack(
 	prune bool,
 	pullImage bool,
 ) error {
-	d.lock.Lock()
-	defer d.lock.Unlock()
-
-	d.swarmStackManager.Login(registries, endpoint)
-	defer d.swarmStackManager.Logout(endpoint)
-
-	return d.remoteStack(stack, endpoint, OperationSwarmDeploy, unpackerCmdBuilderOptions{
-		pullImage:     pullImage,
-		prune:         prune,
-		forceRecreate: stack.AutoUpdate != nil && stack.AutoUpdate.ForceUpdate,
-		registries:    registries,
+	return d.withHooks(stack, endpoint, func() error {
+		d.swarmStackManager.Login(registries, endpoint)
+		defer d.swarmStackManager.Logout(endpoint)
+
+		return d.remoteStack(stack, endpoint, OperationSwarmDeploy, unpackerCmdBuilderOptions{
+			pullImage:     pullImage,
+			prune:         prune,
+			forceRecreate: stack.AutoUpdate != nil && stack.AutoUpdate.ForceUpdate,
+			registries:    registries,
+		})
 	})
 }
 
@@ -141,6 +146,10 @@ func (d *stackDeployer) StartRemoteSwarmStack(
 	endpoint *portainer.Endpoint,
 	registries []portainer.Registry,
 ) error {
+	if err := quarantine.Check(endpoint); err != nil {
+		return err
+	}
+
 	return d.remoteStack(
 		stack,
 		endpoint,
@@ -185,13 +194,23 @@ func (d *stackDeployer) remoteStack(stack *portainer.Stack, endpoint *portainer.
 		return errors.Wrap(err, "unable to get agent info")
 	}
 	// ensure the targetSocketBindHost is changed to podman for podman environments
-	targetSocketBindHost := getTargetSocketBindHost(info.OSType, endpoint.ContainerEngine)
+	targetSocketBindHost := getTargetSocketBindHost(info.OSType, endpoint.ContainerEngine, d.podmanSocketPath(endpoint))
 	targetSocketBindContainer := getTargetSocketBindContainer(info.OSType)
 
 	composeDestination := filesystem.JoinPaths(stack.ProjectPath, composePathPrefix)
+	if stackutils.IsRelativePathStack(stack) {
+		// the repository is cloned under the same path on the host and in the unpacker container, the relative paths
+		// of the bind mounts are resolved by compose against the clone and thus point to it on the host
+		composeDestination = filesystem.JoinPaths(stack.FilesystemPath, composePathPrefix, strconv.Itoa(int(stack.ID)))
+	}
 
 	opts.composeDestination = composeDestination
 
+	stack, err = stackutils.WithEndpointVariables(d.dataStore, stack, endpoint)
+	if err != nil {
+		return errors.WithMessage(err, "unable to merge the variables of the environment")
+	}
+
 	cmd, err := d.buildUnpackerCmdForStack(stack, operation, opts)
 	if err != nil {
 		return errors.Wrap(err, "unable to build command for unpacker")
@@ -329,13 +348,31 @@ func getUnpackerImage() string {
 	return image
 }
 
-func getTargetSocketBindHost(osType string, containerEngine string) string {
+// podmanSocketPath returns the socket of the Podman engine of an environment(endpoint) reported by its last snapshot,
+// it lives in the runtime directory of the user running Podman when it runs rootless
+func (d *stackDeployer) podmanSocketPath(endpoint *portainer.Endpoint) string {
+	if endpoint.ContainerEngine != portainer.ContainerEnginePodman {
+		return ""
+	}
+
+	snapshot, err := d.dataStore.Snapshot().Read(endpoint.ID)
+	if err != nil || snapshot.Docker == nil {
+		return ""
+	}
+
+	return snapshot.Docker.PodmanSocketPath
+}
+
+func getTargetSocketBindHost(osType string, containerEngine string, podmanSocketPath string) string {
 	targetSocketBind := "//./pipe/docker_engine"
 	if strings.EqualFold(osType, "linux") {
-		if containerEngine == portainer.ContainerEnginePodman {
-			targetSocketBind = "/run/podman/podman.sock"
-		} else {
+		switch {
+		case containerEngine != portainer.ContainerEnginePodman:
 			targetSocketBind = "/var/run/docker.sock"
+		case podmanSocketPath != "":
+			targetSocketBind = podmanSocketPath
+		default:
+			targetSocketBind = "/run/podman/podman.sock"
 		}
 	}
 	return targetSocketBind
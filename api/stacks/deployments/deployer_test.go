@@ -0,0 +1,50 @@
+package deployments
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/stacks/hooks"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithHooks_HooksRunOutsideOfTheLock(t *testing.T) {
+	is := require.New(t)
+
+	d := &stackDeployer{lock: &sync.Mutex{}, hookRunner: hooks.NewRunner(nil)}
+
+	var lockedDuringHooks []bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		locked := !d.lock.TryLock()
+		if !locked {
+			d.lock.Unlock()
+		}
+
+		lockedDuringHooks = append(lockedDuringHooks, locked)
+	}))
+	defer server.Close()
+
+	stack := &portainer.Stack{
+		ID:   1,
+		Name: "app",
+		Hooks: &portainer.StackHooks{
+			PreDeploy:  []portainer.StackHook{{Name: "pre", Type: portainer.StackHookHTTP, URL: server.URL}},
+			PostDeploy: []portainer.StackHook{{Name: "post", Type: portainer.StackHookHTTP, URL: server.URL}},
+		},
+	}
+
+	var lockedDuringDeploy bool
+	err := d.withHooks(stack, &portainer.Endpoint{ID: 1}, func() error {
+		lockedDuringDeploy = !d.lock.TryLock()
+
+		return nil
+	})
+	is.NoError(err)
+
+	is.True(lockedDuringDeploy)
+	is.Equal([]bool{false, false}, lockedDuringHooks)
+}
@@ -1,17 +1,27 @@
 package deployments
 
 import (
+	"cmp"
 	"fmt"
 	"os"
+	"slices"
+	"strings"
 
 	"github.com/pkg/errors"
 	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+	"github.com/portainer/portainer/api/docker/images"
 	"github.com/portainer/portainer/api/filesystem"
 	k "github.com/portainer/portainer/api/kubernetes"
+	"github.com/portainer/portainer/api/kubernetes/privateregistries"
 	"github.com/portainer/portainer/api/stacks/stackutils"
 )
 
+// kustomizeManifestFileName is the name of the manifest a kustomization is built into
+const kustomizeManifestFileName = "kustomize-build.yaml"
+
 type KubernetesStackDeploymentConfig struct {
+	dataStore          dataservices.DataStore
 	stack              *portainer.Stack
 	kubernetesDeployer portainer.KubernetesDeployer
 	appLabels          k.KubeAppLabels
@@ -20,9 +30,10 @@ type KubernetesStackDeploymentConfig struct {
 	output             string
 }
 
-func CreateKubernetesStackDeploymentConfig(stack *portainer.Stack, kubeDeployer portainer.KubernetesDeployer, appLabels k.KubeAppLabels, user *portainer.User, endpoint *portainer.Endpoint) (*KubernetesStackDeploymentConfig, error) {
+func CreateKubernetesStackDeploymentConfig(dataStore dataservices.DataStore, stack *portainer.Stack, kubeDeployer portainer.KubernetesDeployer, appLabels k.KubeAppLabels, user *portainer.User, endpoint *portainer.Endpoint) (*KubernetesStackDeploymentConfig, error) {
 
 	return &KubernetesStackDeploymentConfig{
+		dataStore:          dataStore,
 		stack:              stack,
 		kubernetesDeployer: kubeDeployer,
 		appLabels:          appLabels,
@@ -36,10 +47,6 @@ func (config *KubernetesStackDeploymentConfig) GetUsername() string {
 }
 
 func (config *KubernetesStackDeploymentConfig) Deploy() error {
-	fileNames := stackutils.GetStackFilePaths(config.stack, false)
-
-	manifestFilePaths := make([]string, 0, len(fileNames))
-
 	tmpDir, err := os.MkdirTemp("", "kub_deployment")
 	if err != nil {
 		return errors.Wrap(err, "failed to create temp kub deployment directory")
@@ -47,34 +54,129 @@ func (config *KubernetesStackDeploymentConfig) Deploy() error {
 
 	defer os.RemoveAll(tmpDir)
 
-	for _, fileName := range fileNames {
-		manifestFilePath := filesystem.JoinPaths(tmpDir, fileName)
-		manifestContent, err := os.ReadFile(filesystem.JoinPaths(config.stack.ProjectPath, fileName))
+	manifestFilePaths, err := WriteKubernetesManifests(config.dataStore, config.kubernetesDeployer, config.stack, config.endpoint, config.appLabels.ToMap(), tmpDir)
+	if err != nil {
+		return err
+	}
+
+	output, err := config.kubernetesDeployer.Deploy(config.user.ID, config.endpoint, manifestFilePaths, config.stack.Namespace)
+	if err != nil {
+		return fmt.Errorf("failed to deploy kubernete stack: %w", err)
+	}
+
+	config.output = output
+	return nil
+}
+
+// WriteKubernetesManifests writes the manifests of a Kubernetes stack to a directory and returns their paths. The
+// kustomization of a kustomize stack is built into a single manifest, the ${NAME} references to the variables of the
+// environment are replaced, the application labels are added when given and the workloads reference the image pull
+// secrets of the registries bound to their namespace
+func WriteKubernetesManifests(dataStore dataservices.DataStore, kubernetesDeployer portainer.KubernetesDeployer, stack *portainer.Stack, endpoint *portainer.Endpoint, appLabels map[string]string, dir string) ([]string, error) {
+	variables, err := stackutils.EndpointVariables(dataStore, endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	registries, err := dataStore.Registry().ReadAll()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to retrieve the registries")
+	}
+
+	namespace := cmp.Or(stack.Namespace, k.DefaultNamespace)
+	pullSecrets := registryPullSecrets(registries, endpoint.ID)
+
+	fileNames := stackutils.GetStackFilePaths(stack, false)
+	manifests := make(map[string][]byte, len(fileNames))
+
+	if stackutils.IsKustomizeStack(stack) {
+		buildDir, err := stackutils.KustomizeBuildDir(stack, variables)
 		if err != nil {
-			return errors.Wrap(err, "failed to read manifest file")
+			return nil, err
 		}
 
-		manifestContent, err = k.AddAppLabels(manifestContent, config.appLabels.ToMap())
+		content, err := kubernetesDeployer.Kustomize(buildDir)
 		if err != nil {
-			return errors.Wrap(err, "failed to add application labels")
+			return nil, err
 		}
 
+		// the additional files are deployed along with the built kustomization
+		fileNames[0] = kustomizeManifestFileName
+		manifests[kustomizeManifestFileName] = content
+	}
+
+	manifestFilePaths := make([]string, 0, len(fileNames))
+
+	for _, fileName := range fileNames {
+		manifestContent, ok := manifests[fileName]
+		if !ok {
+			manifestContent, err = os.ReadFile(filesystem.JoinPaths(stack.ProjectPath, fileName))
+			if err != nil {
+				return nil, errors.Wrap(err, "failed to read manifest file")
+			}
+		}
+
+		manifestContent = stackutils.ExpandVariables(manifestContent, variables)
+
+		if len(appLabels) > 0 {
+			manifestContent, err = k.AddAppLabels(manifestContent, appLabels)
+			if err != nil {
+				return nil, errors.Wrap(err, "failed to add application labels")
+			}
+		}
+
+		manifestContent, err = k.AddImagePullSecrets(manifestContent, namespace, pullSecrets)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to add the image pull secrets")
+		}
+
+		manifestFilePath := filesystem.JoinPaths(dir, fileName)
 		if err := filesystem.WriteToFile(manifestFilePath, manifestContent); err != nil {
-			return errors.Wrap(err, "failed to create temp manifest file")
+			return nil, errors.Wrap(err, "failed to create temp manifest file")
 		}
 
 		manifestFilePaths = append(manifestFilePaths, manifestFilePath)
 	}
 
-	output, err := config.kubernetesDeployer.Deploy(config.user.ID, config.endpoint, manifestFilePaths, config.stack.Namespace)
-	if err != nil {
-		return fmt.Errorf("failed to deploy kubernete stack: %w", err)
-	}
-
-	config.output = output
-	return nil
+	return manifestFilePaths, nil
 }
 
 func (config *KubernetesStackDeploymentConfig) GetResponse() string {
 	return config.output
 }
+
+// registryPullSecrets returns the image pull secrets of the registries bound to a namespace of an environment that
+// some of the images are pulled from
+func registryPullSecrets(registries []portainer.Registry, endpointID portainer.EndpointID) func(namespace string, images []string) []string {
+	return func(namespace string, imageNames []string) []string {
+		var secrets []string
+
+		for _, registry := range registries {
+			if !slices.Contains(registry.RegistryAccesses[endpointID].Namespaces, namespace) {
+				continue
+			}
+
+			if slices.ContainsFunc(imageNames, func(name string) bool { return isRegistryImage(&registry, name) }) {
+				secrets = append(secrets, privateregistries.SecretName(registry.ID))
+			}
+		}
+
+		return secrets
+	}
+}
+
+// isRegistryImage reports whether an image is pulled from a registry, the URL of the registry being either its domain
+// or a path prefix of the images, e.g. registry.gitlab.com/group
+func isRegistryImage(registry *portainer.Registry, name string) bool {
+	image, err := images.ParseImage(images.ParseImageOptions{Name: name})
+	if err != nil {
+		return false
+	}
+
+	url := strings.TrimSuffix(registry.URL, "/")
+	if _, after, ok := strings.Cut(url, "://"); ok {
+		url = after
+	}
+
+	return image.Domain == url || strings.HasPrefix(image.Domain+"/"+image.Path, url+"/")
+}
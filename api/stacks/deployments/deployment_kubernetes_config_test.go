@@ -0,0 +1,29 @@
+package deployments
+
+import (
+	"testing"
+
+	portainer "github.com/portainer/portainer/api"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegistryPullSecrets(t *testing.T) {
+	is := require.New(t)
+
+	registries := []portainer.Registry{
+		{ID: 1, URL: "harbor.example.com", RegistryAccesses: portainer.RegistryAccesses{1: {Namespaces: []string{"dev"}}}},
+		{ID: 2, URL: "registry.gitlab.com/team", RegistryAccesses: portainer.RegistryAccesses{1: {Namespaces: []string{"dev", "prod"}}}},
+		{ID: 3, URL: "docker.io", RegistryAccesses: portainer.RegistryAccesses{1: {Namespaces: []string{"prod"}}}},
+		{ID: 4, URL: "https://quay.io/", RegistryAccesses: portainer.RegistryAccesses{2: {Namespaces: []string{"dev"}}}},
+	}
+
+	pullSecrets := registryPullSecrets(registries, 1)
+
+	is.Equal([]string{"registry-1", "registry-2"}, pullSecrets("dev", []string{"harbor.example.com/library/web:1.0", "registry.gitlab.com/team/api"}))
+	is.Empty(pullSecrets("dev", []string{"registry.gitlab.com/other/api", "nginx"}))
+	is.Equal([]string{"registry-3"}, pullSecrets("prod", []string{"nginx:latest"}))
+	is.Empty(pullSecrets("dev", []string{"quay.io/prometheus/prometheus"}))
+
+	is.True(isRegistryImage(&registries[3], "quay.io/prometheus/prometheus"))
+}
@@ -0,0 +1,70 @@
+package deployments
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+
+	"github.com/pkg/errors"
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+	k "github.com/portainer/portainer/api/kubernetes"
+)
+
+// KubernetesPreview is the result of the preview of the deployment of a Kubernetes stack
+type KubernetesPreview struct {
+	// Manifests as they would be deployed, once built, expanded and labeled
+	Manifest string `json:"Manifest"`
+	// Unified diff between the live resources and the resources once applied, empty when nothing would change
+	Diff string `json:"Diff"`
+	// Whether deploying the stack would create or change resources
+	Changed bool `json:"Changed" example:"true"`
+}
+
+// PreviewKubernetesStack returns the manifests a Kubernetes stack would be deployed with, and the diff between the
+// live state of their resources and the state the server would apply. Nothing is changed in the cluster, the
+// resources are applied with a server-side dry run with the permissions of the user
+func PreviewKubernetesStack(dataStore dataservices.DataStore, kubernetesDeployer portainer.KubernetesDeployer, stack *portainer.Stack, appLabels k.KubeAppLabels, user *portainer.User, endpoint *portainer.Endpoint) (*KubernetesPreview, error) {
+	tmpDir, err := os.MkdirTemp("", "kub_preview")
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create temp kub preview directory")
+	}
+
+	defer os.RemoveAll(tmpDir)
+
+	manifestFilePaths, err := WriteKubernetesManifests(dataStore, kubernetesDeployer, stack, endpoint, appLabels.ToMap(), tmpDir)
+	if err != nil {
+		return nil, err
+	}
+
+	manifests := make([][]byte, 0, len(manifestFilePaths))
+	for _, manifestFilePath := range manifestFilePaths {
+		content, err := os.ReadFile(manifestFilePath)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to read manifest file")
+		}
+
+		manifests = append(manifests, bytes.TrimSuffix(content, []byte("\n")))
+	}
+
+	merged, err := kubernetesDeployer.DryRun(user.ID, endpoint, manifestFilePaths, stack.Namespace)
+	if err != nil {
+		return nil, fmt.Errorf("failed to validate the kubernetes manifests: %w", err)
+	}
+
+	live, err := kubernetesDeployer.Get(user.ID, endpoint, manifestFilePaths, stack.Namespace)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve the live kubernetes resources: %w", err)
+	}
+
+	diff, err := k.DiffManifests([]byte(live), []byte(merged))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to compare the kubernetes resources")
+	}
+
+	return &KubernetesPreview{
+		Manifest: string(bytes.Join(manifests, []byte("\n---\n"))) + "\n",
+		Diff:     diff,
+		Changed:  diff != "",
+	}, nil
+}
@@ -0,0 +1,80 @@
+package deployments
+
+import (
+	"context"
+	"fmt"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/docker/consts"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/api/types/swarm"
+	"github.com/pkg/errors"
+)
+
+// StackServicesClient is the part of the Docker client used to stop the services of a stack
+type StackServicesClient interface {
+	ContainerList(ctx context.Context, options container.ListOptions) ([]types.Container, error)
+	ContainerStop(ctx context.Context, containerID string, options container.StopOptions) error
+	ServiceList(ctx context.Context, options types.ServiceListOptions) ([]swarm.Service, error)
+	ServiceUpdate(ctx context.Context, serviceID string, version swarm.Version, service swarm.ServiceSpec, options types.ServiceUpdateOptions) (swarm.ServiceUpdateResponse, error)
+}
+
+// StopStackServices stops the services of a compose or Swarm stack and keeps its resources: the containers of a
+// compose stack are stopped and the services of a Swarm stack are scaled to zero. Deploying the stack again restores
+// its services. The global services cannot be scaled, a Swarm stack holding one is left untouched
+func StopStackServices(ctx context.Context, cli StackServicesClient, stack *portainer.Stack) error {
+	switch stack.Type {
+	case portainer.DockerComposeStack:
+		return stopComposeContainers(ctx, cli, stack.Name)
+	case portainer.DockerSwarmStack:
+		return scaleDownSwarmServices(ctx, cli, stack.Name)
+	}
+
+	return fmt.Errorf("unsupported stack type %d", stack.Type)
+}
+
+func stopComposeContainers(ctx context.Context, cli StackServicesClient, project string) error {
+	containers, err := cli.ContainerList(ctx, container.ListOptions{
+		Filters: filters.NewArgs(filters.Arg("label", consts.ComposeStackNameLabel+"="+project)),
+	})
+	if err != nil {
+		return errors.Wrap(err, "unable to list the containers of the stack")
+	}
+
+	for _, c := range containers {
+		if err := cli.ContainerStop(ctx, c.ID, container.StopOptions{}); err != nil {
+			return errors.Wrapf(err, "unable to stop the container %s", c.ID)
+		}
+	}
+
+	return nil
+}
+
+func scaleDownSwarmServices(ctx context.Context, cli StackServicesClient, namespace string) error {
+	services, err := cli.ServiceList(ctx, types.ServiceListOptions{
+		Filters: filters.NewArgs(filters.Arg("label", consts.SwarmStackNameLabel+"="+namespace)),
+	})
+	if err != nil {
+		return errors.Wrap(err, "unable to list the services of the stack")
+	}
+
+	for _, service := range services {
+		if service.Spec.Mode.Replicated == nil {
+			return fmt.Errorf("the service %s is not replicated and cannot be stopped without being removed", service.Spec.Name)
+		}
+	}
+
+	for _, service := range services {
+		replicas := uint64(0)
+		service.Spec.Mode.Replicated.Replicas = &replicas
+
+		if _, err := cli.ServiceUpdate(ctx, service.ID, service.Version, service.Spec, types.ServiceUpdateOptions{}); err != nil {
+			return errors.Wrapf(err, "unable to scale down the service %s", service.Spec.Name)
+		}
+	}
+
+	return nil
+}
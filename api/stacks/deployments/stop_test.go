@@ -0,0 +1,68 @@
+package deployments
+
+import (
+	"context"
+	"testing"
+
+	portainer "github.com/portainer/portainer/api"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/swarm"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeStackServicesClient struct {
+	containers []types.Container
+	services   []swarm.Service
+	stopped    []string
+	updated    map[string]swarm.ServiceSpec
+}
+
+func (cli *fakeStackServicesClient) ContainerList(ctx context.Context, options container.ListOptions) ([]types.Container, error) {
+	return cli.containers, nil
+}
+
+func (cli *fakeStackServicesClient) ContainerStop(ctx context.Context, containerID string, options container.StopOptions) error {
+	cli.stopped = append(cli.stopped, containerID)
+
+	return nil
+}
+
+func (cli *fakeStackServicesClient) ServiceList(ctx context.Context, options types.ServiceListOptions) ([]swarm.Service, error) {
+	return cli.services, nil
+}
+
+func (cli *fakeStackServicesClient) ServiceUpdate(ctx context.Context, serviceID string, version swarm.Version, service swarm.ServiceSpec, options types.ServiceUpdateOptions) (swarm.ServiceUpdateResponse, error) {
+	cli.updated[serviceID] = service
+
+	return swarm.ServiceUpdateResponse{}, nil
+}
+
+func TestStopStackServices(t *testing.T) {
+	is := require.New(t)
+
+	replicas := uint64(3)
+	cli := &fakeStackServicesClient{
+		containers: []types.Container{{ID: "web-1"}, {ID: "db-1"}},
+		services: []swarm.Service{
+			{ID: "web", Spec: swarm.ServiceSpec{Mode: swarm.ServiceMode{Replicated: &swarm.ReplicatedService{Replicas: &replicas}}}},
+		},
+		updated: map[string]swarm.ServiceSpec{},
+	}
+
+	is.NoError(StopStackServices(context.Background(), cli, &portainer.Stack{Name: "shop", Type: portainer.DockerComposeStack}))
+	is.Equal([]string{"web-1", "db-1"}, cli.stopped)
+
+	is.NoError(StopStackServices(context.Background(), cli, &portainer.Stack{Name: "shop", Type: portainer.DockerSwarmStack}))
+	is.Equal(uint64(0), *cli.updated["web"].Mode.Replicated.Replicas)
+
+	// a global service cannot be scaled down, no service is stopped
+	cli.updated = map[string]swarm.ServiceSpec{}
+	cli.services = append(cli.services, swarm.Service{ID: "agent", Spec: swarm.ServiceSpec{Mode: swarm.ServiceMode{Global: &swarm.GlobalService{}}}})
+
+	is.Error(StopStackServices(context.Background(), cli, &portainer.Stack{Name: "shop", Type: portainer.DockerSwarmStack}))
+	is.Empty(cli.updated)
+
+	is.Error(StopStackServices(context.Background(), cli, &portainer.Stack{Name: "shop", Type: portainer.KubernetesStack}))
+}
@@ -0,0 +1,127 @@
+package drift
+
+import (
+	"context"
+	"strings"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/docker/consts"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/api/types/swarm"
+	"github.com/pkg/errors"
+)
+
+const composeServiceLabel = "com.docker.compose.service"
+
+// DockerClient represents the calls to the Docker API used to read the live state of a stack
+type DockerClient interface {
+	ContainerList(ctx context.Context, options container.ListOptions) ([]types.Container, error)
+	ContainerInspect(ctx context.Context, containerID string) (types.ContainerJSON, error)
+	ImageInspectWithRaw(ctx context.Context, imageID string) (types.ImageInspect, []byte, error)
+	ServiceList(ctx context.Context, options types.ServiceListOptions) ([]swarm.Service, error)
+}
+
+// LiveServices returns the live state of the services of a compose or Swarm stack
+func LiveServices(ctx context.Context, cli DockerClient, stack *portainer.Stack) (map[string]ServiceState, error) {
+	switch stack.Type {
+	case portainer.DockerComposeStack:
+		return composeServices(ctx, cli, stack.Name)
+	case portainer.DockerSwarmStack:
+		return swarmServices(ctx, cli, stack.Name)
+	}
+
+	return nil, ErrUnsupportedStack
+}
+
+// composeServices reads the containers of a compose project. The environment and the image of a service are the ones
+// of its first container, its replicas are its running containers
+func composeServices(ctx context.Context, cli DockerClient, project string) (map[string]ServiceState, error) {
+	containers, err := cli.ContainerList(ctx, container.ListOptions{
+		All:     true,
+		Filters: filters.NewArgs(filters.Arg("label", consts.ComposeStackNameLabel+"="+project)),
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to list the containers of the stack")
+	}
+
+	services := make(map[string]ServiceState)
+	outdated := make(map[string]bool)
+
+	for _, c := range containers {
+		name := c.Labels[composeServiceLabel]
+		if name == "" {
+			continue
+		}
+
+		state, ok := services[name]
+		if !ok {
+			details, err := cli.ContainerInspect(ctx, c.ID)
+			if err != nil {
+				return nil, errors.Wrapf(err, "unable to inspect the container %s", c.ID)
+			} else if details.Config == nil {
+				return nil, errors.Errorf("the container %s has no configuration", c.ID)
+			}
+
+			state.Image = details.Config.Image
+			state.Env = parseContainerEnv(details.Config.Env)
+
+			if _, ok := outdated[state.Image]; !ok {
+				image, _, err := cli.ImageInspectWithRaw(ctx, state.Image)
+				// the image can be missing when it was removed after the container was created
+				outdated[state.Image] = err == nil && image.ID != details.Image
+			}
+
+			state.Outdated = outdated[state.Image]
+		}
+
+		if c.State == "running" {
+			state.Replicas++
+		}
+
+		services[name] = state
+	}
+
+	return services, nil
+}
+
+// swarmServices reads the services of a Swarm stack, named after the stack followed by the name of the service
+func swarmServices(ctx context.Context, cli DockerClient, namespace string) (map[string]ServiceState, error) {
+	list, err := cli.ServiceList(ctx, types.ServiceListOptions{
+		Filters: filters.NewArgs(filters.Arg("label", consts.SwarmStackNameLabel+"="+namespace)),
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to list the services of the stack")
+	}
+
+	services := make(map[string]ServiceState, len(list))
+	for _, service := range list {
+		state := ServiceState{}
+
+		if spec := service.Spec.TaskTemplate.ContainerSpec; spec != nil {
+			// the image of the spec is pinned to the digest it was resolved to by the deployment
+			state.Image, _, _ = strings.Cut(spec.Image, "@")
+			state.Env = parseContainerEnv(spec.Env)
+		}
+
+		if service.Spec.Mode.Replicated != nil && service.Spec.Mode.Replicated.Replicas != nil {
+			state.Replicas = int(*service.Spec.Mode.Replicated.Replicas)
+		}
+
+		services[strings.TrimPrefix(service.Spec.Name, namespace+"_")] = state
+	}
+
+	return services, nil
+}
+
+func parseContainerEnv(entries []string) map[string]string {
+	env := make(map[string]string, len(entries))
+	for _, entry := range entries {
+		name, value, _ := strings.Cut(entry, "=")
+		env[name] = value
+	}
+
+	return env
+}
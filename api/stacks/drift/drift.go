@@ -0,0 +1,273 @@
+// Package drift compares the stack file of the Docker stacks with the live state of their containers and services
+package drift
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+
+	portainer "github.com/portainer/portainer/api"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+// ChangeKind represents the kind of difference between the stack file and the live state of a service
+type ChangeKind string
+
+const (
+	// ChangeMissing represents a service of the stack file without any container or Swarm service
+	ChangeMissing ChangeKind = "missing"
+	// ChangeUnexpected represents a running service that is not in the stack file
+	ChangeUnexpected ChangeKind = "unexpected"
+	// ChangeImage represents a service running another image than the one of the stack file
+	ChangeImage ChangeKind = "image"
+	// ChangeDigest represents a container running an older image than the one its image reference points to
+	ChangeDigest ChangeKind = "digest"
+	// ChangeEnv represents an environment variable whose value differs from the stack file
+	ChangeEnv ChangeKind = "env"
+	// ChangeReplicas represents a service running another number of replicas than the one of the stack file
+	ChangeReplicas ChangeKind = "replicas"
+)
+
+// Change represents a difference between the stack file and the live state of a service
+type Change struct {
+	Service  string     `json:"Service" example:"web"`
+	Kind     ChangeKind `json:"Kind" example:"image"`
+	Name     string     `json:"Name,omitempty" example:"LOG_LEVEL"`
+	Expected string     `json:"Expected" example:"nginx:1.27"`
+	Actual   string     `json:"Actual" example:"nginx:1.25"`
+}
+
+// Report represents the drift of a stack
+type Report struct {
+	StackID   portainer.StackID `json:"StackId" example:"1"`
+	CheckedAt int64             `json:"CheckedAt" example:"1587399600"`
+	Drifted   bool              `json:"Drifted" example:"true"`
+	Changes   []Change          `json:"Changes"`
+}
+
+// ServiceSpec represents a service as defined by the stack file
+type ServiceSpec struct {
+	Image string
+	Env   map[string]string
+	// Number of replicas, 0 for the global Swarm services whose number of replicas depends on the nodes
+	Replicas int
+}
+
+// ServiceState represents the live state of a service
+type ServiceState struct {
+	Image    string
+	Env      map[string]string
+	Replicas int
+	// The container runs an older image than the one its image reference points to
+	Outdated bool
+}
+
+type composeFile struct {
+	Services map[string]composeService `yaml:"services"`
+}
+
+type composeService struct {
+	Image       string    `yaml:"image"`
+	Environment yaml.Node `yaml:"environment"`
+	Scale       *int      `yaml:"scale"`
+	Deploy      struct {
+		Mode     string `yaml:"mode"`
+		Replicas *int   `yaml:"replicas"`
+	} `yaml:"deploy"`
+}
+
+// ParseServices returns the services of a stack file, the variables of the file are replaced with the environment
+// variables of the stack
+func ParseServices(content []byte, env []portainer.Pair) (map[string]ServiceSpec, error) {
+	var file composeFile
+	if err := yaml.Unmarshal(content, &file); err != nil {
+		return nil, errors.Wrap(err, "unable to parse the stack file")
+	}
+
+	variables := make(map[string]string, len(env))
+	for _, pair := range env {
+		variables[pair.Name] = pair.Value
+	}
+
+	services := make(map[string]ServiceSpec, len(file.Services))
+	for name, service := range file.Services {
+		spec := ServiceSpec{
+			Image:    interpolate(service.Image, variables),
+			Env:      make(map[string]string),
+			Replicas: 1,
+		}
+
+		switch {
+		case service.Deploy.Mode == "global":
+			spec.Replicas = 0
+		case service.Deploy.Replicas != nil:
+			spec.Replicas = *service.Deploy.Replicas
+		case service.Scale != nil:
+			spec.Replicas = *service.Scale
+		}
+
+		if err := parseEnvironment(&service.Environment, variables, spec.Env); err != nil {
+			return nil, fmt.Errorf("invalid environment of the service %s: %w", name, err)
+		}
+
+		services[name] = spec
+	}
+
+	return services, nil
+}
+
+// parseEnvironment reads the environment of a service, as a mapping or a list of NAME=value entries. The variables
+// without a value take the value of the stack variable of the same name, they are ignored when it is not set
+func parseEnvironment(node *yaml.Node, variables map[string]string, env map[string]string) error {
+	switch node.Kind {
+	case 0:
+		return nil
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			name, value := node.Content[i].Value, node.Content[i+1]
+			if value.Tag == "!!null" {
+				if v, ok := variables[name]; ok {
+					env[name] = v
+				}
+
+				continue
+			}
+
+			env[name] = interpolate(value.Value, variables)
+		}
+	case yaml.SequenceNode:
+		for _, entry := range node.Content {
+			name, value, ok := strings.Cut(entry.Value, "=")
+			if !ok {
+				if v, ok := variables[name]; ok {
+					env[name] = v
+				}
+
+				continue
+			}
+
+			env[name] = interpolate(value, variables)
+		}
+	default:
+		return errors.New("the environment must be a mapping or a list")
+	}
+
+	return nil
+}
+
+// interpolate replaces the $NAME, ${NAME}, ${NAME:-default}, ${NAME-default} and ${NAME:?error} variables of a value,
+// $$ being a literal dollar sign
+func interpolate(value string, variables map[string]string) string {
+	return os.Expand(value, func(expression string) string {
+		if expression == "$" {
+			return "$"
+		}
+
+		i := strings.IndexFunc(expression, func(r rune) bool {
+			return r != '_' && !unicode.IsLetter(r) && !unicode.IsDigit(r)
+		})
+		if i < 0 {
+			return variables[expression]
+		}
+
+		name, operator := expression[:i], expression[i:]
+
+		switch {
+		case strings.HasPrefix(operator, ":-"):
+			if v := variables[name]; v != "" {
+				return v
+			}
+
+			return operator[2:]
+		case strings.HasPrefix(operator, "-"):
+			if v, ok := variables[name]; ok {
+				return v
+			}
+
+			return operator[1:]
+		}
+
+		// the missing required variables are reported by the deployment, not by the drift
+		return variables[name]
+	})
+}
+
+// ApplyScales replaces the replicas of the stack file by the ones of the services scaled on their own, the services
+// scaled to zero are no longer expected to run
+func ApplyScales(expected map[string]ServiceSpec, scales map[string]int) {
+	for name, replicas := range scales {
+		spec, ok := expected[name]
+		if !ok {
+			continue
+		}
+
+		if replicas == 0 {
+			delete(expected, name)
+
+			continue
+		}
+
+		spec.Replicas = replicas
+		expected[name] = spec
+	}
+}
+
+// Compare returns the differences between the services of the stack file and their live state, sorted by service
+func Compare(expected map[string]ServiceSpec, actual map[string]ServiceState) []Change {
+	changes := make([]Change, 0)
+
+	for _, name := range slices.Sorted(maps.Keys(expected)) {
+		spec := expected[name]
+
+		state, ok := actual[name]
+		if !ok {
+			changes = append(changes, Change{Service: name, Kind: ChangeMissing, Expected: spec.Image})
+
+			continue
+		}
+
+		if spec.Image != "" && normalizeImage(spec.Image) != normalizeImage(state.Image) {
+			changes = append(changes, Change{Service: name, Kind: ChangeImage, Expected: spec.Image, Actual: state.Image})
+		} else if state.Outdated {
+			changes = append(changes, Change{Service: name, Kind: ChangeDigest, Expected: spec.Image, Actual: state.Image})
+		}
+
+		for _, variable := range slices.Sorted(maps.Keys(spec.Env)) {
+			if value, ok := state.Env[variable]; !ok || value != spec.Env[variable] {
+				changes = append(changes, Change{Service: name, Kind: ChangeEnv, Name: variable, Expected: spec.Env[variable], Actual: value})
+			}
+		}
+
+		if spec.Replicas != 0 && spec.Replicas != state.Replicas {
+			changes = append(changes, Change{Service: name, Kind: ChangeReplicas, Expected: strconv.Itoa(spec.Replicas), Actual: strconv.Itoa(state.Replicas)})
+		}
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(actual)) {
+		if _, ok := expected[name]; !ok {
+			changes = append(changes, Change{Service: name, Kind: ChangeUnexpected, Actual: actual[name].Image})
+		}
+	}
+
+	return changes
+}
+
+// normalizeImage returns the image reference without its digest and with the default tag and registry, so that
+// nginx, nginx:latest and docker.io/library/nginx:latest are the same image
+func normalizeImage(image string) string {
+	image, _, _ = strings.Cut(image, "@")
+
+	if i := strings.LastIndex(image, ":"); i <= strings.LastIndex(image, "/") {
+		image += ":latest"
+	}
+
+	image = strings.TrimPrefix(image, "docker.io/")
+
+	return strings.TrimPrefix(image, "library/")
+}
@@ -0,0 +1,201 @@
+package drift
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/filesystem"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/swarm"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeDockerClient struct {
+	containers []types.Container
+	details    map[string]types.ContainerJSON
+	images     map[string]string
+	services   []swarm.Service
+}
+
+func (cli *fakeDockerClient) ContainerList(ctx context.Context, options container.ListOptions) ([]types.Container, error) {
+	return cli.containers, nil
+}
+
+func (cli *fakeDockerClient) ContainerInspect(ctx context.Context, containerID string) (types.ContainerJSON, error) {
+	return cli.details[containerID], nil
+}
+
+func (cli *fakeDockerClient) ImageInspectWithRaw(ctx context.Context, imageID string) (types.ImageInspect, []byte, error) {
+	return types.ImageInspect{ID: cli.images[imageID]}, nil, nil
+}
+
+func (cli *fakeDockerClient) ServiceList(ctx context.Context, options types.ServiceListOptions) ([]swarm.Service, error) {
+	return cli.services, nil
+}
+
+func newContainerJSON(image, imageID string, env ...string) types.ContainerJSON {
+	return types.ContainerJSON{
+		ContainerJSONBase: &types.ContainerJSONBase{Image: imageID},
+		Config:            &container.Config{Image: image, Env: env},
+	}
+}
+
+func TestParseServices(t *testing.T) {
+	is := require.New(t)
+
+	content := []byte(`
+services:
+  web:
+    image: nginx:${NGINX_VERSION:-1.27}
+    environment:
+      LOG_LEVEL: $LOG_LEVEL
+      PRICE: $$5
+      TOKEN:
+    deploy:
+      replicas: 3
+  worker:
+    image: ${REGISTRY-docker.io}/worker
+    environment:
+      - MODE=${MODE}
+      - DEBUG
+    scale: 2
+  agent:
+    image: portainer/agent
+    deploy:
+      mode: global
+`)
+
+	services, err := ParseServices(content, []portainer.Pair{
+		{Name: "LOG_LEVEL", Value: "debug"},
+		{Name: "TOKEN", Value: "secret"},
+		{Name: "MODE", Value: "batch"},
+	})
+	is.NoError(err)
+
+	is.Equal(map[string]ServiceSpec{
+		"web": {
+			Image:    "nginx:1.27",
+			Env:      map[string]string{"LOG_LEVEL": "debug", "PRICE": "$5", "TOKEN": "secret"},
+			Replicas: 3,
+		},
+		"worker": {
+			Image:    "docker.io/worker",
+			Env:      map[string]string{"MODE": "batch"},
+			Replicas: 2,
+		},
+		"agent": {
+			Image: "portainer/agent",
+			Env:   map[string]string{},
+		},
+	}, services)
+
+	_, err = ParseServices([]byte("services:\n  web:\n    environment: 3\n"), nil)
+	is.Error(err)
+}
+
+func TestCompare(t *testing.T) {
+	is := require.New(t)
+
+	expected := map[string]ServiceSpec{
+		"web":    {Image: "nginx", Env: map[string]string{"PORT": "80"}, Replicas: 2},
+		"worker": {Image: "worker:1.0", Env: map[string]string{}, Replicas: 1},
+		"agent":  {Image: "portainer/agent", Env: map[string]string{}},
+		"db":     {Image: "postgres:16", Env: map[string]string{}, Replicas: 1},
+	}
+	actual := map[string]ServiceState{
+		"web":    {Image: "docker.io/library/nginx:latest", Env: map[string]string{"PORT": "8080", "PATH": "/bin"}, Replicas: 1},
+		"worker": {Image: "worker:1.0", Replicas: 1, Outdated: true},
+		"agent":  {Image: "portainer/agent:latest", Replicas: 5},
+		"cache":  {Image: "redis"},
+	}
+
+	is.Equal([]Change{
+		{Service: "db", Kind: ChangeMissing, Expected: "postgres:16"},
+		{Service: "web", Kind: ChangeEnv, Name: "PORT", Expected: "80", Actual: "8080"},
+		{Service: "web", Kind: ChangeReplicas, Expected: "2", Actual: "1"},
+		{Service: "worker", Kind: ChangeDigest, Expected: "worker:1.0", Actual: "worker:1.0"},
+		{Service: "cache", Kind: ChangeUnexpected, Actual: "redis"},
+	}, Compare(expected, actual))
+}
+
+func TestApplyScales(t *testing.T) {
+	is := require.New(t)
+
+	expected := map[string]ServiceSpec{
+		"web":    {Image: "nginx", Replicas: 2},
+		"worker": {Image: "worker:1.0", Replicas: 1},
+		"db":     {Image: "postgres:16", Replicas: 1},
+	}
+
+	ApplyScales(expected, map[string]int{"web": 4, "worker": 0, "removed": 3})
+
+	is.Equal(map[string]ServiceSpec{
+		"web": {Image: "nginx", Replicas: 4},
+		"db":  {Image: "postgres:16", Replicas: 1},
+	}, expected)
+}
+
+func TestDetect(t *testing.T) {
+	is := require.New(t)
+
+	fileService, err := filesystem.NewService(t.TempDir(), "")
+	is.NoError(err)
+
+	stack := &portainer.Stack{
+		ID:          1,
+		Name:        "shop",
+		Type:        portainer.DockerComposeStack,
+		ProjectPath: t.TempDir(),
+		EntryPoint:  "docker-compose.yml",
+		Env:         []portainer.Pair{{Name: "VERSION", Value: "1.27"}},
+	}
+	is.NoError(os.WriteFile(filepath.Join(stack.ProjectPath, stack.EntryPoint), []byte("services:\n  web:\n    image: nginx:${VERSION}\n    scale: 2\n"), 0o600))
+
+	cli := &fakeDockerClient{
+		containers: []types.Container{
+			{ID: "1", State: "running", Labels: map[string]string{composeServiceLabel: "web"}},
+			{ID: "2", State: "running", Labels: map[string]string{composeServiceLabel: "web"}},
+		},
+		details: map[string]types.ContainerJSON{"1": newContainerJSON("nginx:1.27", "sha256:a")},
+		images:  map[string]string{"nginx:1.27": "sha256:a"},
+	}
+
+	report, err := Detect(context.Background(), cli, fileService, stack)
+	is.NoError(err)
+	is.False(report.Drifted)
+	is.Empty(report.Changes)
+
+	// the image reference was pulled again since the containers were created, one of them was stopped
+	cli.images["nginx:1.27"] = "sha256:b"
+	cli.containers[1].State = "exited"
+
+	report, err = Detect(context.Background(), cli, fileService, stack)
+	is.NoError(err)
+	is.True(report.Drifted)
+	is.Equal([]Change{
+		{Service: "web", Kind: ChangeDigest, Expected: "nginx:1.27", Actual: "nginx:1.27"},
+		{Service: "web", Kind: ChangeReplicas, Expected: "2", Actual: "1"},
+	}, report.Changes)
+
+	// the services of a Swarm stack are prefixed with the name of the stack
+	replicas := uint64(2)
+	stack.Type = portainer.DockerSwarmStack
+	cli.services = []swarm.Service{{Spec: swarm.ServiceSpec{
+		Annotations:  swarm.Annotations{Name: "shop_web"},
+		TaskTemplate: swarm.TaskSpec{ContainerSpec: &swarm.ContainerSpec{Image: "nginx:1.25@sha256:c"}},
+		Mode:         swarm.ServiceMode{Replicated: &swarm.ReplicatedService{Replicas: &replicas}},
+	}}}
+
+	report, err = Detect(context.Background(), cli, fileService, stack)
+	is.NoError(err)
+	is.Equal([]Change{{Service: "web", Kind: ChangeImage, Expected: "nginx:1.27", Actual: "nginx:1.25"}}, report.Changes)
+
+	stack.Type = portainer.KubernetesStack
+	_, err = Detect(context.Background(), cli, fileService, stack)
+	is.ErrorIs(err, ErrUnsupportedStack)
+}
@@ -0,0 +1,195 @@
+package drift
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+	dockerclient "github.com/portainer/portainer/api/docker/client"
+	"github.com/portainer/portainer/api/scheduler"
+	endpointsutils "github.com/portainer/portainer/pkg/endpoints"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+)
+
+// checkTimeout bounds the reading of the live state of a stack
+const checkTimeout = time.Minute
+
+var (
+	// ErrUnsupportedStack is returned when the drift of a stack that is not a compose or Swarm stack is requested
+	ErrUnsupportedStack = errors.New("the drift can only be detected for the compose and Swarm stacks")
+	// ErrNoConnectivity is returned when the live state of a stack cannot be read because Portainer cannot reach its
+	// environment(endpoint)
+	ErrNoConnectivity = errors.New("the environment cannot be reached by Portainer")
+)
+
+// Service detects the drift of the Docker stacks, on demand or on the schedule of the settings
+type Service struct {
+	dataStore     dataservices.DataStore
+	fileService   portainer.FileService
+	clientFactory *dockerclient.ClientFactory
+	scheduler     *scheduler.Scheduler
+
+	mu    sync.Mutex
+	jobID string
+}
+
+// NewService creates a new drift detection service
+func NewService(dataStore dataservices.DataStore, fileService portainer.FileService, clientFactory *dockerclient.ClientFactory, scheduler *scheduler.Scheduler) *Service {
+	return &Service{
+		dataStore:     dataStore,
+		fileService:   fileService,
+		clientFactory: clientFactory,
+		scheduler:     scheduler,
+	}
+}
+
+// Detect compares the stack file of a stack with the live state of its environment(endpoint) and records the result
+// on the stack
+func (service *Service) Detect(stack *portainer.Stack, endpoint *portainer.Endpoint) (*Report, error) {
+	if stack.Type != portainer.DockerComposeStack && stack.Type != portainer.DockerSwarmStack {
+		return nil, ErrUnsupportedStack
+	}
+
+	if !endpointsutils.HasDirectConnectivity(endpoint) {
+		return nil, ErrNoConnectivity
+	}
+
+	cli, err := service.clientFactory.CreateClient(endpoint, "", nil)
+	if err != nil {
+		return nil, err
+	}
+	defer cli.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), checkTimeout)
+	defer cancel()
+
+	report, err := Detect(ctx, cli, service.fileService, stack)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := service.dataStore.UpdateTx(func(tx dataservices.DataStoreTx) error {
+		// the stack is read again so that the changes made during the check are not overwritten
+		stack, err := tx.Stack().Read(stack.ID)
+		if err != nil {
+			return err
+		}
+
+		stack.Drift = &portainer.StackDriftStatus{
+			CheckedAt: report.CheckedAt,
+			Drifted:   report.Drifted,
+			Changes:   len(report.Changes),
+		}
+
+		return tx.Stack().Update(stack.ID, stack)
+	}); err != nil {
+		return nil, errors.Wrap(err, "unable to record the drift of the stack")
+	}
+
+	return report, nil
+}
+
+// Detect compares the stack file of a stack with the live state read through a Docker client
+func Detect(ctx context.Context, cli DockerClient, fileService portainer.FileService, stack *portainer.Stack) (*Report, error) {
+	content, err := fileService.GetFileContent(stack.ProjectPath, stack.EntryPoint)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to read the stack file")
+	}
+
+	expected, err := ParseServices(content, stack.Env)
+	if err != nil {
+		return nil, err
+	}
+
+	ApplyScales(expected, stack.ServiceScales)
+
+	actual, err := LiveServices(ctx, cli, stack)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := Compare(expected, actual)
+
+	return &Report{
+		StackID:   stack.ID,
+		CheckedAt: time.Now().Unix(),
+		Drifted:   len(changes) > 0,
+		Changes:   changes,
+	}, nil
+}
+
+// SetSchedule replaces the scheduled check with one running at the interval of the settings, an empty interval
+// disables it
+func (service *Service) SetSchedule(interval string) error {
+	service.mu.Lock()
+	defer service.mu.Unlock()
+
+	if service.jobID != "" {
+		if err := service.scheduler.StopJob(service.jobID); err != nil {
+			return err
+		}
+
+		service.jobID = ""
+	}
+
+	if interval == "" {
+		return nil
+	}
+
+	duration, err := time.ParseDuration(interval)
+	if err != nil {
+		return err
+	}
+
+	service.jobID = service.scheduler.StartJobEvery(duration, service.checkAll)
+
+	return nil
+}
+
+// checkAll detects the drift of the active compose and Swarm stacks of the reachable environments(endpoints)
+func (service *Service) checkAll() error {
+	stacks, err := service.dataStore.Stack().ReadAll()
+	if err != nil {
+		return err
+	}
+
+	checked, drifted := 0, 0
+
+	for i := range stacks {
+		if stacks[i].Status != portainer.StackStatusActive ||
+			(stacks[i].Type != portainer.DockerComposeStack && stacks[i].Type != portainer.DockerSwarmStack) {
+			continue
+		}
+
+		endpoint, err := service.dataStore.Endpoint().Endpoint(stacks[i].EndpointID)
+		if dataservices.IsErrObjectNotFound(err) {
+			continue
+		} else if err != nil {
+			return err
+		}
+
+		if !endpointsutils.HasDirectConnectivity(endpoint) || endpoint.Status != portainer.EndpointStatusUp {
+			continue
+		}
+
+		report, err := service.Detect(&stacks[i], endpoint)
+		if err != nil {
+			log.Warn().Err(err).Int("stack_id", int(stacks[i].ID)).Msg("unable to detect the drift of the stack")
+
+			continue
+		}
+
+		checked++
+		if report.Drifted {
+			drifted++
+		}
+	}
+
+	log.Info().Int("stacks", checked).Int("drifted", drifted).Msg("drift of the stacks checked")
+
+	return nil
+}
@@ -0,0 +1,411 @@
+// Package fleetstacks deploys the fleet stacks, which are compose stacks deployed to the regular environments
+// selected by their tags or their group, through staggered rollouts
+package fleetstacks
+
+import (
+	"context"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+	"github.com/portainer/portainer/api/filesystem"
+	"github.com/portainer/portainer/api/internal/endpointutils"
+	"github.com/portainer/portainer/api/stacks/deployments"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Health represents the aggregated state of the deployments of a fleet stack
+type Health struct {
+	Total     int `json:"Total" example:"10"`
+	Deployed  int `json:"Deployed" example:"7"`
+	Deploying int `json:"Deploying" example:"1"`
+	Pending   int `json:"Pending" example:"1"`
+	Failed    int `json:"Failed" example:"1"`
+}
+
+// NewHealth returns the aggregated state of the deployments of a fleet stack
+func NewHealth(fleetStack *portainer.FleetStack) Health {
+	health := Health{Total: len(fleetStack.Environments)}
+
+	for _, environment := range fleetStack.Environments {
+		switch environment.Status {
+		case portainer.FleetStackDeploymentDeployed:
+			health.Deployed++
+		case portainer.FleetStackDeploymentDeploying:
+			health.Deploying++
+		case portainer.FleetStackDeploymentFailed:
+			health.Failed++
+		default:
+			health.Pending++
+		}
+	}
+
+	return health
+}
+
+// TargetEndpoints returns the regular Docker environments selected by the tags or the groups of a fleet stack, sorted
+// by identifier. The Edge environments are deployed by the Edge stacks and are never selected
+func TargetEndpoints(tx dataservices.DataStoreTx, fleetStack *portainer.FleetStack) ([]portainer.Endpoint, error) {
+	endpoints, err := tx.Endpoint().Endpoints()
+	if err != nil {
+		return nil, err
+	}
+
+	endpointGroups, err := tx.EndpointGroup().ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	groupTags := make(map[portainer.EndpointGroupID][]portainer.TagID, len(endpointGroups))
+	for _, group := range endpointGroups {
+		groupTags[group.ID] = group.TagIDs
+	}
+
+	hasTag := func(tagIDs []portainer.TagID) bool {
+		return slices.ContainsFunc(tagIDs, func(tagID portainer.TagID) bool {
+			return slices.Contains(fleetStack.TagIDs, tagID)
+		})
+	}
+
+	targets := make([]portainer.Endpoint, 0)
+	for _, endpoint := range endpoints {
+		if !endpointutils.IsDockerEndpoint(&endpoint) || endpointutils.IsEdgeEndpoint(&endpoint) {
+			continue
+		}
+
+		if slices.Contains(fleetStack.EndpointGroupIDs, endpoint.GroupID) || hasTag(endpoint.TagIDs) || hasTag(groupTags[endpoint.GroupID]) {
+			targets = append(targets, endpoint)
+		}
+	}
+
+	slices.SortFunc(targets, func(a, b portainer.Endpoint) int {
+		return int(a.ID) - int(b.ID)
+	})
+
+	return targets, nil
+}
+
+// Service runs the rollouts of the fleet stacks, a single rollout runs at a time for a fleet stack
+type Service struct {
+	dataStore     dataservices.DataStore
+	fileService   portainer.FileService
+	stackDeployer deployments.StackDeployer
+	gitService    portainer.GitService
+
+	mu       sync.Mutex
+	rollouts map[portainer.FleetStackID]rollout
+}
+
+type rollout struct {
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// NewService creates a service running the rollouts of the fleet stacks
+func NewService(dataStore dataservices.DataStore, fileService portainer.FileService, stackDeployer deployments.StackDeployer, gitService portainer.GitService) *Service {
+	return &Service{
+		dataStore:     dataStore,
+		fileService:   fileService,
+		stackDeployer: stackDeployer,
+		gitService:    gitService,
+		rollouts:      make(map[portainer.FleetStackID]rollout),
+	}
+}
+
+// Rollout starts the rollout of the current version of a fleet stack to its environments in the background, the
+// rollout in progress for the fleet stack is stopped first
+func (service *Service) Rollout(fleetStackID portainer.FleetStackID) {
+	service.Stop(fleetStackID)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	r := rollout{cancel: cancel, done: make(chan struct{})}
+
+	service.mu.Lock()
+	service.rollouts[fleetStackID] = r
+	service.mu.Unlock()
+
+	go func() {
+		defer close(r.done)
+		defer cancel()
+
+		if err := service.rollout(ctx, fleetStackID); err != nil {
+			log.Error().Err(err).Int("fleet_stack_id", int(fleetStackID)).Msg("unable to roll out the fleet stack")
+		}
+
+		service.mu.Lock()
+		if current, ok := service.rollouts[fleetStackID]; ok && current.done == r.done {
+			delete(service.rollouts, fleetStackID)
+		}
+		service.mu.Unlock()
+	}()
+}
+
+// ResumeRollouts restarts the rollouts interrupted by a restart of Portainer
+func (service *Service) ResumeRollouts() error {
+	fleetStacks, err := service.dataStore.FleetStack().ReadAll()
+	if err != nil {
+		return err
+	}
+
+	for _, fleetStack := range fleetStacks {
+		if fleetStack.RolloutStatus == portainer.FleetStackRolloutRunning {
+			service.Rollout(fleetStack.ID)
+		}
+	}
+
+	return nil
+}
+
+// Stop stops the rollout in progress for a fleet stack and waits for the deployment in progress to finish
+func (service *Service) Stop(fleetStackID portainer.FleetStackID) {
+	service.mu.Lock()
+	r, ok := service.rollouts[fleetStackID]
+	service.mu.Unlock()
+
+	if !ok {
+		return
+	}
+
+	r.cancel()
+	<-r.done
+}
+
+func (service *Service) rollout(ctx context.Context, fleetStackID portainer.FleetStackID) error {
+	var pending []portainer.EndpointID
+	var fleetStack *portainer.FleetStack
+
+	if err := service.dataStore.UpdateTx(func(tx dataservices.DataStoreTx) (err error) {
+		if fleetStack, err = tx.FleetStack().Read(fleetStackID); err != nil {
+			return err
+		}
+
+		pending, err = plan(tx, fleetStack)
+
+		return err
+	}); err != nil {
+		return err
+	}
+
+	batchSize := fleetStack.Rollout.BatchSize
+	if batchSize <= 0 {
+		batchSize = max(len(pending), 1)
+	}
+
+	failures := 0
+	for i := 0; i < len(pending); i += batchSize {
+		if i > 0 {
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-time.After(time.Duration(fleetStack.Rollout.BatchInterval) * time.Second):
+			}
+		}
+
+		for _, endpointID := range pending[i:min(i+batchSize, len(pending))] {
+			if ctx.Err() != nil {
+				return nil
+			}
+
+			if err := service.deploy(fleetStack, endpointID); err != nil {
+				log.Warn().Err(err).Int("fleet_stack_id", int(fleetStack.ID)).Int("endpoint_id", int(endpointID)).Msg("unable to deploy the fleet stack")
+
+				failures++
+			}
+		}
+
+		if failures > fleetStack.Rollout.FailureThreshold {
+			return service.setRolloutStatus(fleetStack.ID, portainer.FleetStackRolloutHalted)
+		}
+	}
+
+	return service.setRolloutStatus(fleetStack.ID, portainer.FleetStackRolloutCompleted)
+}
+
+// plan updates the environments of a fleet stack with its current targets and returns the environments the current
+// version has to be deployed to. The stacks of the environments that are no longer selected are detached from the
+// fleet stack and keep running
+func plan(tx dataservices.DataStoreTx, fleetStack *portainer.FleetStack) ([]portainer.EndpointID, error) {
+	targets, err := TargetEndpoints(tx, fleetStack)
+	if err != nil {
+		return nil, err
+	}
+
+	environments := make(map[portainer.EndpointID]portainer.FleetStackEnvironment, len(targets))
+	pending := make([]portainer.EndpointID, 0, len(targets))
+
+	for _, endpoint := range targets {
+		environment := fleetStack.Environments[endpoint.ID]
+
+		if environment.Status != portainer.FleetStackDeploymentDeployed || environment.Version != fleetStack.Version {
+			environment.Status = portainer.FleetStackDeploymentPending
+			environment.UpdatedAt = time.Now().Unix()
+			pending = append(pending, endpoint.ID)
+		}
+
+		environments[endpoint.ID] = environment
+	}
+
+	for endpointID, environment := range fleetStack.Environments {
+		if _, ok := environments[endpointID]; ok || environment.StackID == 0 {
+			continue
+		}
+
+		if err := detach(tx, environment.StackID); err != nil {
+			return nil, err
+		}
+	}
+
+	fleetStack.Environments = environments
+	fleetStack.RolloutStatus = portainer.FleetStackRolloutRunning
+	if len(pending) == 0 {
+		fleetStack.RolloutStatus = portainer.FleetStackRolloutCompleted
+	}
+
+	return pending, tx.FleetStack().Update(fleetStack.ID, fleetStack)
+}
+
+// Detach detaches the stacks deployed by a fleet stack, which keep running as regular stacks
+func Detach(tx dataservices.DataStoreTx, fleetStack *portainer.FleetStack) error {
+	for _, environment := range fleetStack.Environments {
+		if environment.StackID == 0 {
+			continue
+		}
+
+		if err := detach(tx, environment.StackID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func detach(tx dataservices.DataStoreTx, stackID portainer.StackID) error {
+	stack, err := tx.Stack().Read(stackID)
+	if tx.IsErrObjectNotFound(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	stack.FleetStackID = 0
+
+	return tx.Stack().Update(stack.ID, stack)
+}
+
+// deploy deploys the current version of a fleet stack to one of its environments and records the outcome
+func (service *Service) deploy(fleetStack *portainer.FleetStack, endpointID portainer.EndpointID) error {
+	var stackID portainer.StackID
+	if err := service.updateEnvironment(fleetStack.ID, endpointID, func(e *portainer.FleetStackEnvironment) {
+		e.Status = portainer.FleetStackDeploymentDeploying
+		e.Error = ""
+		stackID = e.StackID
+	}); err != nil {
+		return err
+	}
+
+	stackID, err := service.deployStack(fleetStack, endpointID, stackID)
+
+	if updateErr := service.updateEnvironment(fleetStack.ID, endpointID, func(e *portainer.FleetStackEnvironment) {
+		e.StackID = stackID
+		e.Version = fleetStack.Version
+		e.Status = portainer.FleetStackDeploymentDeployed
+
+		if err != nil {
+			e.Status = portainer.FleetStackDeploymentFailed
+			e.Error = err.Error()
+		}
+	}); updateErr != nil {
+		return updateErr
+	}
+
+	return err
+}
+
+// deployStack creates or updates the stack of a fleet stack on an environment and redeploys it, it returns the
+// identifier of the stack
+func (service *Service) deployStack(fleetStack *portainer.FleetStack, endpointID portainer.EndpointID, stackID portainer.StackID) (portainer.StackID, error) {
+	var stack *portainer.Stack
+	if stackID != 0 {
+		var err error
+		if stack, err = service.dataStore.Stack().Read(stackID); service.dataStore.IsErrObjectNotFound(err) {
+			stack = nil
+		} else if err != nil {
+			return stackID, err
+		}
+	}
+
+	// the stack is created by the first deployment and again when it was removed
+	if stack == nil {
+		stack = &portainer.Stack{
+			ID:           portainer.StackID(service.dataStore.Stack().GetNextIdentifier()),
+			Name:         fleetStack.Name,
+			Type:         portainer.DockerComposeStack,
+			EndpointID:   endpointID,
+			EntryPoint:   filesystem.ComposeFileDefaultName,
+			Status:       portainer.StackStatusActive,
+			CreationDate: time.Now().Unix(),
+			CreatedBy:    fleetStack.CreatedBy,
+			FleetStackID: fleetStack.ID,
+		}
+
+		if err := service.dataStore.Stack().Create(stack); err != nil {
+			return 0, err
+		}
+	}
+
+	projectPath, err := service.fileService.StoreStackFileFromBytes(strconv.Itoa(int(stack.ID)), stack.EntryPoint, []byte(fleetStack.StackFileContent))
+	if err != nil {
+		return stack.ID, err
+	}
+
+	stack.ProjectPath = projectPath
+	stack.Env = slices.Clone(fleetStack.Env)
+	stack.UpdateDate = time.Now().Unix()
+	stack.UpdatedBy = fleetStack.UpdatedBy
+	if stack.UpdatedBy == "" {
+		stack.UpdatedBy = fleetStack.CreatedBy
+	}
+
+	if err := service.dataStore.Stack().Update(stack.ID, stack); err != nil {
+		return stack.ID, err
+	}
+
+	return stack.ID, deployments.RedeployWithPull(stack.ID, service.stackDeployer, service.dataStore, service.gitService)
+}
+
+func (service *Service) updateEnvironment(fleetStackID portainer.FleetStackID, endpointID portainer.EndpointID, update func(*portainer.FleetStackEnvironment)) error {
+	return service.dataStore.UpdateTx(func(tx dataservices.DataStoreTx) error {
+		fleetStack, err := tx.FleetStack().Read(fleetStackID)
+		if err != nil {
+			return err
+		}
+
+		environment := fleetStack.Environments[endpointID]
+		update(&environment)
+		environment.UpdatedAt = time.Now().Unix()
+
+		if fleetStack.Environments == nil {
+			fleetStack.Environments = make(map[portainer.EndpointID]portainer.FleetStackEnvironment)
+		}
+		fleetStack.Environments[endpointID] = environment
+
+		return tx.FleetStack().Update(fleetStack.ID, fleetStack)
+	})
+}
+
+func (service *Service) setRolloutStatus(fleetStackID portainer.FleetStackID, status portainer.FleetStackRolloutStatus) error {
+	return service.dataStore.UpdateTx(func(tx dataservices.DataStoreTx) error {
+		fleetStack, err := tx.FleetStack().Read(fleetStackID)
+		if err != nil {
+			return err
+		}
+
+		fleetStack.RolloutStatus = status
+
+		return tx.FleetStack().Update(fleetStack.ID, fleetStack)
+	})
+}
@@ -0,0 +1,135 @@
+package fleetstacks
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/datastore"
+	"github.com/portainer/portainer/api/filesystem"
+	"github.com/portainer/portainer/api/stacks/deployments"
+
+	"github.com/stretchr/testify/require"
+)
+
+type failingDeployer struct {
+	deployments.StackDeployer
+	failures map[portainer.EndpointID]bool
+	deployed []portainer.EndpointID
+}
+
+func (d *failingDeployer) DeployComposeStack(stack *portainer.Stack, endpoint *portainer.Endpoint, registries []portainer.Registry, forcePullImage, forceRecreate bool) error {
+	d.deployed = append(d.deployed, endpoint.ID)
+
+	if d.failures[endpoint.ID] {
+		return errors.New("deployment failed")
+	}
+
+	return nil
+}
+
+func TestTargetEndpoints(t *testing.T) {
+	is := require.New(t)
+
+	_, store := datastore.MustNewTestStore(t, true, false)
+
+	is.NoError(store.EndpointGroup().Create(&portainer.EndpointGroup{ID: 2, Name: "production", TagIDs: []portainer.TagID{2}}))
+	is.NoError(store.Endpoint().Create(&portainer.Endpoint{ID: 1, Type: portainer.AgentOnDockerEnvironment, GroupID: 1, TagIDs: []portainer.TagID{1}}))
+	is.NoError(store.Endpoint().Create(&portainer.Endpoint{ID: 2, Type: portainer.DockerEnvironment, GroupID: 2}))
+	is.NoError(store.Endpoint().Create(&portainer.Endpoint{ID: 3, Type: portainer.EdgeAgentOnDockerEnvironment, GroupID: 1, TagIDs: []portainer.TagID{1}}))
+	is.NoError(store.Endpoint().Create(&portainer.Endpoint{ID: 4, Type: portainer.AgentOnKubernetesEnvironment, GroupID: 1, TagIDs: []portainer.TagID{1}}))
+	is.NoError(store.Endpoint().Create(&portainer.Endpoint{ID: 5, Type: portainer.AgentOnDockerEnvironment, GroupID: 1}))
+
+	targetIDs := func(fleetStack *portainer.FleetStack) []portainer.EndpointID {
+		targets, err := TargetEndpoints(store, fleetStack)
+		is.NoError(err)
+
+		ids := make([]portainer.EndpointID, 0, len(targets))
+		for _, endpoint := range targets {
+			ids = append(ids, endpoint.ID)
+		}
+
+		return ids
+	}
+
+	// the Edge and Kubernetes environments are never selected
+	is.Equal([]portainer.EndpointID{1}, targetIDs(&portainer.FleetStack{TagIDs: []portainer.TagID{1}}))
+	// the tags of the group are inherited
+	is.Equal([]portainer.EndpointID{2}, targetIDs(&portainer.FleetStack{TagIDs: []portainer.TagID{2}}))
+	is.Equal([]portainer.EndpointID{1, 5}, targetIDs(&portainer.FleetStack{EndpointGroupIDs: []portainer.EndpointGroupID{1}}))
+	is.Empty(targetIDs(&portainer.FleetStack{TagIDs: []portainer.TagID{3}}))
+}
+
+func TestRollout(t *testing.T) {
+	is := require.New(t)
+
+	_, store := datastore.MustNewTestStore(t, true, false)
+
+	fileService, err := filesystem.NewService(t.TempDir(), "")
+	is.NoError(err)
+
+	is.NoError(store.User().Create(&portainer.User{ID: 1, Username: "admin", Role: portainer.AdministratorRole}))
+	for id := portainer.EndpointID(1); id <= 4; id++ {
+		is.NoError(store.Endpoint().Create(&portainer.Endpoint{ID: id, Type: portainer.AgentOnDockerEnvironment, GroupID: 1, TagIDs: []portainer.TagID{1}}))
+	}
+
+	fleetStack := &portainer.FleetStack{
+		Name:             "monitoring",
+		StackFileContent: "services:\n  web:\n    image: nginx\n",
+		TagIDs:           []portainer.TagID{1},
+		Rollout:          portainer.FleetStackRolloutSettings{BatchSize: 1},
+		Version:          1,
+		CreatedBy:        "admin",
+	}
+	is.NoError(store.FleetStack().Create(fleetStack))
+
+	deployer := &failingDeployer{failures: map[portainer.EndpointID]bool{2: true}}
+	service := NewService(store, fileService, deployer, nil)
+
+	// the rollout is halted by the failed deployment of the second environment
+	is.NoError(service.rollout(context.Background(), fleetStack.ID))
+	is.Equal([]portainer.EndpointID{1, 2}, deployer.deployed)
+
+	fleetStack, err = store.FleetStack().Read(fleetStack.ID)
+	is.NoError(err)
+	is.Equal(portainer.FleetStackRolloutHalted, fleetStack.RolloutStatus)
+	is.Equal(Health{Total: 4, Deployed: 1, Pending: 2, Failed: 1}, NewHealth(fleetStack))
+	is.Contains(fleetStack.Environments[2].Error, "deployment failed")
+
+	stack, err := store.Stack().Read(fleetStack.Environments[1].StackID)
+	is.NoError(err)
+	is.Equal("monitoring", stack.Name)
+	is.Equal(fleetStack.ID, stack.FleetStackID)
+	is.Equal(portainer.EndpointID(1), stack.EndpointID)
+
+	// the deployed environment is not deployed again, the failure is tolerated by the threshold
+	deployer.deployed = nil
+	fleetStack.Rollout.FailureThreshold = 1
+	is.NoError(store.FleetStack().Update(fleetStack.ID, fleetStack))
+
+	is.NoError(service.rollout(context.Background(), fleetStack.ID))
+	is.Equal([]portainer.EndpointID{2, 3, 4}, deployer.deployed)
+
+	fleetStack, err = store.FleetStack().Read(fleetStack.ID)
+	is.NoError(err)
+	is.Equal(portainer.FleetStackRolloutCompleted, fleetStack.RolloutStatus)
+	is.Equal(Health{Total: 4, Deployed: 3, Failed: 1}, NewHealth(fleetStack))
+
+	// the stack of an environment that is no longer selected is detached
+	endpoint, err := store.Endpoint().Endpoint(4)
+	is.NoError(err)
+	endpoint.TagIDs = nil
+	is.NoError(store.Endpoint().UpdateEndpoint(endpoint.ID, endpoint))
+
+	stackID := fleetStack.Environments[4].StackID
+	is.NoError(service.rollout(context.Background(), fleetStack.ID))
+
+	fleetStack, err = store.FleetStack().Read(fleetStack.ID)
+	is.NoError(err)
+	is.NotContains(fleetStack.Environments, portainer.EndpointID(4))
+
+	stack, err = store.Stack().Read(stackID)
+	is.NoError(err)
+	is.Zero(stack.FleetStackID)
+}
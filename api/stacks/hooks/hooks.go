@@ -0,0 +1,478 @@
+// Package hooks runs the hooks attached to the stacks before and after their deployments
+package hooks
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+	dockerclient "github.com/portainer/portainer/api/docker/client"
+	"github.com/portainer/portainer/api/docker/consts"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/api/types/image"
+	"github.com/docker/docker/pkg/stdcopy"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+	"github.com/segmentio/encoding/json"
+)
+
+const (
+	// DefaultTimeout is the timeout of the hooks that do not define one
+	DefaultTimeout = 60 * time.Second
+	// MaxTimeout is the longest timeout a hook can define
+	MaxTimeout = time.Hour
+	// maxOutputSize is the size of the end of the output of a hook kept in its run and its error
+	maxOutputSize = 4096
+	// composeServiceLabel and swarmServiceLabel label the containers of the services of the stacks
+	composeServiceLabel = "com.docker.compose.service"
+	swarmServiceLabel   = "com.docker.swarm.service.name"
+)
+
+// Phase represents the moment of the deployment a hook runs at
+type Phase string
+
+const (
+	PreDeploy  Phase = "preDeploy"
+	PostDeploy Phase = "postDeploy"
+)
+
+// Runner runs the hooks of the stacks
+type Runner struct {
+	clientFactory *dockerclient.ClientFactory
+	httpClient    *http.Client
+}
+
+// NewRunner returns a pointer to a new Runner, the client factory is used to run the command hooks
+func NewRunner(clientFactory *dockerclient.ClientFactory) *Runner {
+	return &Runner{
+		clientFactory: clientFactory,
+		httpClient:    &http.Client{},
+	}
+}
+
+// Validate checks that the hooks of a stack are well formed
+func Validate(hooks *portainer.StackHooks, stackType portainer.StackType) error {
+	if hooks == nil {
+		return nil
+	}
+
+	for _, hook := range append(hooks.PreDeploy, hooks.PostDeploy...) {
+		if strings.TrimSpace(hook.Name) == "" {
+			return errors.New("missing hook name")
+		}
+
+		switch hook.Type {
+		case portainer.StackHookHTTP, portainer.StackHookTeams, portainer.StackHookPagerDuty:
+			if hook.Type == portainer.StackHookPagerDuty {
+				if err := validatePagerDutyHook(hook); err != nil {
+					return fmt.Errorf("hook %s: %w", hook.Name, err)
+				}
+			}
+
+			u, err := url.Parse(hookURL(hook))
+			if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+				return fmt.Errorf("hook %s: invalid URL, it must be an http or https URL", hook.Name)
+			}
+
+			if err := validateTemplates(hook); err != nil {
+				return fmt.Errorf("hook %s: %w", hook.Name, err)
+			}
+		case portainer.StackHookCommand:
+			if stackType == portainer.KubernetesStack {
+				return fmt.Errorf("hook %s: command hooks are only supported for Docker stacks", hook.Name)
+			}
+
+			if hook.Service != "" {
+				if hook.Image != "" {
+					return fmt.Errorf("hook %s: a command hook runs either in a one-shot container or in a service, not both", hook.Name)
+				}
+
+				if len(hook.Command) == 0 {
+					return fmt.Errorf("hook %s: missing command", hook.Name)
+				}
+			} else if hook.Image == "" {
+				return fmt.Errorf("hook %s: missing image or service", hook.Name)
+			}
+		default:
+			return fmt.Errorf("hook %s: invalid type, must be 1 for an HTTP call, 2 for a command, 3 for Microsoft Teams or 4 for PagerDuty", hook.Name)
+		}
+
+		if hook.Timeout < 0 || time.Duration(hook.Timeout)*time.Second > MaxTimeout {
+			return fmt.Errorf("hook %s: the timeout must be between 0 and %d seconds", hook.Name, int(MaxTimeout.Seconds()))
+		}
+
+		switch hook.FailurePolicy {
+		case "", portainer.StackHookFailureAbort, portainer.StackHookFailureContinue:
+		default:
+			return fmt.Errorf("hook %s: invalid failure policy, must be abort or continue", hook.Name)
+		}
+	}
+
+	return nil
+}
+
+// Run runs, in order, the hooks of the stack for the given phase and appends their outcome to the hook runs of the
+// stack. It stops at the first failing hook unless its failure policy is to continue
+func (runner *Runner) Run(phase Phase, stack *portainer.Stack, endpoint *portainer.Endpoint) error {
+	if stack.Hooks == nil {
+		return nil
+	}
+
+	hooks := stack.Hooks.PreDeploy
+	if phase == PostDeploy {
+		hooks = stack.Hooks.PostDeploy
+	}
+
+	for _, hook := range hooks {
+		timeout := cmp.Or(time.Duration(hook.Timeout)*time.Second, DefaultTimeout)
+		startedAt := time.Now()
+
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		output, err := runner.runHook(ctx, phase, hook, stack, endpoint)
+		cancel()
+
+		run := portainer.StackHookRun{
+			Name:      hook.Name,
+			Phase:     string(phase),
+			StartedAt: startedAt.Unix(),
+			Duration:  time.Since(startedAt).Milliseconds(),
+			Output:    string(tail(output)),
+		}
+		if err != nil {
+			run.Error = err.Error()
+		}
+
+		stack.HookRuns = append(stack.HookRuns, run)
+
+		if err == nil {
+			continue
+		}
+
+		err = errors.Wrapf(err, "the %s hook %s failed", phase, hook.Name)
+
+		if hook.FailurePolicy == portainer.StackHookFailureContinue {
+			log.Warn().Err(err).Str("stack", stack.Name).Msg("ignoring the failure of the stack hook")
+
+			continue
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+// Test sends the request of an HTTP, Teams or PagerDuty hook for the given stack, so that its templates can be
+// checked against the receiving service without deploying the stack
+func (runner *Runner) Test(hook portainer.StackHook, stack *portainer.Stack, endpoint *portainer.Endpoint) error {
+	if hook.Type == portainer.StackHookCommand {
+		return errors.New("the command hooks cannot be tested")
+	}
+
+	timeout := cmp.Or(time.Duration(hook.Timeout)*time.Second, DefaultTimeout)
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	_, err := runner.runHTTPHook(ctx, PreDeploy, hook, stack, endpoint)
+
+	return err
+}
+
+// runHook runs a hook and returns its output
+func (runner *Runner) runHook(ctx context.Context, phase Phase, hook portainer.StackHook, stack *portainer.Stack, endpoint *portainer.Endpoint) ([]byte, error) {
+	log.Debug().
+		Str("stack", stack.Name).
+		Str("phase", string(phase)).
+		Str("hook", hook.Name).
+		Msg("running stack hook")
+
+	switch hook.Type {
+	case portainer.StackHookHTTP, portainer.StackHookTeams, portainer.StackHookPagerDuty:
+		return runner.runHTTPHook(ctx, phase, hook, stack, endpoint)
+	case portainer.StackHookCommand:
+		if hook.Service != "" {
+			return runner.runServiceCommandHook(ctx, phase, hook, stack, endpoint)
+		}
+
+		return runner.runCommandHook(ctx, phase, hook, stack, endpoint)
+	}
+
+	return nil, fmt.Errorf("unsupported hook type %d", hook.Type)
+}
+
+type deploymentEvent struct {
+	Phase      Phase                `json:"phase"`
+	StackID    portainer.StackID    `json:"stackId"`
+	StackName  string               `json:"stackName"`
+	EndpointID portainer.EndpointID `json:"endpointId"`
+}
+
+// runHTTPHook sends the request of an HTTP, Teams or PagerDuty hook and returns the body of the response
+func (runner *Runner) runHTTPHook(ctx context.Context, phase Phase, hook portainer.StackHook, stack *portainer.Stack, endpoint *portainer.Endpoint) ([]byte, error) {
+	data := newTemplateData(phase, hook, stack, endpoint)
+
+	body, contentType, err := requestPayload(hook, data)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, cmp.Or(hook.Method, http.MethodPost), hookURL(hook), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	for _, header := range hook.Headers {
+		value, err := render(header.Value, data)
+		if err != nil {
+			return nil, errors.Wrapf(err, "unable to render the template of the header %s", header.Name)
+		}
+
+		req.Header.Set(header.Name, value)
+	}
+
+	resp, err := runner.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	output, _ := io.ReadAll(io.LimitReader(resp.Body, maxOutputSize))
+	output = bytes.TrimSpace(output)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		// the body of the response is kept in the output of the run, the errors can be returned to the users testing a hook
+		return output, fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+
+	return output, nil
+}
+
+// requestPayload returns the body sent by a hook and its content type. The body of an HTTP hook is a Go template
+// of the deployment, the deployment is sent as JSON when it is empty
+func requestPayload(hook portainer.StackHook, data TemplateData) ([]byte, string, error) {
+	switch hook.Type {
+	case portainer.StackHookTeams:
+		body, err := teamsPayload(hook, data)
+
+		return body, "application/json", err
+	case portainer.StackHookPagerDuty:
+		body, err := pagerDutyPayload(hook, data)
+
+		return body, "application/json", err
+	}
+
+	if hook.Body != "" {
+		body, err := render(hook.Body, data)
+		if err != nil {
+			return nil, "", errors.Wrap(err, "unable to render the body template")
+		}
+
+		return []byte(body), "", nil
+	}
+
+	body, err := json.Marshal(deploymentEvent{Phase: data.Phase, StackID: data.StackID, StackName: data.StackName, EndpointID: data.EndpointID})
+
+	return body, "application/json", err
+}
+
+// hookURL returns the URL called by a hook, the PagerDuty hooks use the Events API by default
+func hookURL(hook portainer.StackHook) string {
+	if hook.Type == portainer.StackHookPagerDuty {
+		return cmp.Or(hook.URL, PagerDutyEventsURL)
+	}
+
+	return hook.URL
+}
+
+// runCommandHook runs the command of a hook in a one-shot container and returns the end of its output
+func (runner *Runner) runCommandHook(ctx context.Context, phase Phase, hook portainer.StackHook, stack *portainer.Stack, endpoint *portainer.Endpoint) ([]byte, error) {
+	if runner.clientFactory == nil {
+		return nil, errors.New("unable to run command hooks without a Docker client factory")
+	}
+
+	cli, err := runner.clientFactory.CreateClient(endpoint, "", nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to create Docker client")
+	}
+	defer cli.Close()
+
+	reader, err := cli.ImagePull(ctx, hook.Image, image.PullOptions{})
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to pull the hook image")
+	}
+	io.Copy(io.Discard, reader)
+	reader.Close()
+
+	created, err := cli.ContainerCreate(ctx, &container.Config{
+		Image:  hook.Image,
+		Cmd:    hook.Command,
+		Env:    commandEnv(phase, hook, stack, endpoint),
+		Labels: map[string]string{"io.portainer.stack.hook": hook.Name},
+	}, nil, nil, nil, "")
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to create the hook container")
+	}
+
+	// the container is removed even when the hook timed out
+	defer cli.ContainerRemove(context.Background(), created.ID, container.RemoveOptions{Force: true})
+
+	if err := cli.ContainerStart(ctx, created.ID, container.StartOptions{}); err != nil {
+		return nil, errors.Wrap(err, "unable to start the hook container")
+	}
+
+	var exitCode int64
+	var waitErr error
+
+	statusCh, errCh := cli.ContainerWait(ctx, created.ID, container.WaitConditionNotRunning)
+	select {
+	case err := <-errCh:
+		if errors.Is(err, context.DeadlineExceeded) || ctx.Err() != nil {
+			waitErr = errors.New("the hook timed out")
+		} else {
+			waitErr = errors.Wrap(err, "unable to wait for the hook container")
+		}
+	case status := <-statusCh:
+		exitCode = status.StatusCode
+	}
+
+	output := &bytes.Buffer{}
+	if logs, err := cli.ContainerLogs(context.Background(), created.ID, container.LogsOptions{ShowStdout: true, ShowStderr: true, Tail: "100"}); err == nil {
+		stdcopy.StdCopy(output, output, logs)
+		logs.Close()
+	}
+
+	out := tail(output.Bytes())
+
+	if waitErr != nil {
+		return out, waitErr
+	} else if exitCode != 0 {
+		return out, fmt.Errorf("the command exited with code %d: %s", exitCode, out)
+	}
+
+	return out, nil
+}
+
+// runServiceCommandHook executes the command of a hook in a running container of a service of the stack and
+// returns the end of its output
+func (runner *Runner) runServiceCommandHook(ctx context.Context, phase Phase, hook portainer.StackHook, stack *portainer.Stack, endpoint *portainer.Endpoint) ([]byte, error) {
+	if runner.clientFactory == nil {
+		return nil, errors.New("unable to run command hooks without a Docker client factory")
+	}
+
+	cli, err := runner.clientFactory.CreateClient(endpoint, "", nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to create Docker client")
+	}
+	defer cli.Close()
+
+	args := filters.NewArgs(filters.Arg("status", "running"))
+	if stack.Type == portainer.DockerSwarmStack {
+		args.Add("label", swarmServiceLabel+"="+stack.Name+"_"+hook.Service)
+	} else {
+		args.Add("label", consts.ComposeStackNameLabel+"="+stack.Name)
+		args.Add("label", composeServiceLabel+"="+hook.Service)
+	}
+
+	containers, err := cli.ContainerList(ctx, container.ListOptions{Filters: args})
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to list the containers of the service")
+	} else if len(containers) == 0 {
+		return nil, fmt.Errorf("the service %s has no running container", hook.Service)
+	}
+
+	exec, err := cli.ContainerExecCreate(ctx, containers[0].ID, container.ExecOptions{
+		Cmd:          hook.Command,
+		Env:          commandEnv(phase, hook, stack, endpoint),
+		AttachStdout: true,
+		AttachStderr: true,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to create the exec of the hook")
+	}
+
+	attached, err := cli.ContainerExecAttach(ctx, exec.ID, container.ExecAttachOptions{})
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to start the exec of the hook")
+	}
+	defer attached.Close()
+
+	output := &tailWriter{}
+	if _, err := stdcopy.StdCopy(output, output, attached.Reader); err != nil {
+		if ctx.Err() != nil {
+			return output.Bytes(), errors.New("the hook timed out")
+		}
+
+		return output.Bytes(), errors.Wrap(err, "unable to read the output of the hook")
+	}
+
+	inspect, err := cli.ContainerExecInspect(ctx, exec.ID)
+	if err != nil {
+		return output.Bytes(), errors.Wrap(err, "unable to inspect the exec of the hook")
+	}
+
+	out := output.Bytes()
+	if inspect.ExitCode != 0 {
+		return out, fmt.Errorf("the command exited with code %d: %s", inspect.ExitCode, out)
+	}
+
+	return out, nil
+}
+
+// commandEnv returns the environment variables of the command of a command hook
+func commandEnv(phase Phase, hook portainer.StackHook, stack *portainer.Stack, endpoint *portainer.Endpoint) []string {
+	env := []string{
+		"PORTAINER_HOOK_PHASE=" + string(phase),
+		"PORTAINER_STACK_ID=" + strconv.Itoa(int(stack.ID)),
+		"PORTAINER_STACK_NAME=" + stack.Name,
+		"PORTAINER_ENDPOINT_ID=" + strconv.Itoa(int(endpoint.ID)),
+	}
+
+	for _, pair := range hook.Env {
+		env = append(env, pair.Name+"="+pair.Value)
+	}
+
+	return env
+}
+
+// tail returns the end of the output of a hook
+func tail(output []byte) []byte {
+	output = bytes.TrimSpace(output)
+	if len(output) > maxOutputSize {
+		output = output[len(output)-maxOutputSize:]
+	}
+
+	return output
+}
+
+// tailWriter keeps the end of the output written to it
+type tailWriter struct {
+	buf []byte
+}
+
+func (w *tailWriter) Write(p []byte) (int, error) {
+	w.buf = append(w.buf, p...)
+	if len(w.buf) > 2*maxOutputSize {
+		w.buf = append(w.buf[:0], w.buf[len(w.buf)-maxOutputSize:]...)
+	}
+
+	return len(p), nil
+}
+
+func (w *tailWriter) Bytes() []byte {
+	return tail(w.buf)
+}
@@ -0,0 +1,232 @@
+package hooks
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	portainer "github.com/portainer/portainer/api"
+
+	"github.com/segmentio/encoding/json"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidate(t *testing.T) {
+	is := require.New(t)
+
+	valid := &portainer.StackHooks{
+		PreDeploy: []portainer.StackHook{
+			{Name: "migrate", Type: portainer.StackHookCommand, Image: "myapp:latest", Command: []string{"migrate"}},
+			{Name: "flush", Type: portainer.StackHookCommand, Service: "cache", Command: []string{"redis-cli", "flushall"}},
+		},
+		PostDeploy: []portainer.StackHook{
+			{Name: "warm", Type: portainer.StackHookHTTP, URL: "https://example.com/warm", FailurePolicy: portainer.StackHookFailureContinue},
+			{Name: "teams", Type: portainer.StackHookTeams, URL: "https://example.webhook.office.com/webhookb2/abc"},
+			{Name: "pagerduty", Type: portainer.StackHookPagerDuty, RoutingKey: "key", Severity: "warning"},
+		},
+	}
+	is.NoError(Validate(valid, portainer.DockerComposeStack))
+	is.NoError(Validate(nil, portainer.DockerComposeStack))
+
+	is.Error(Validate(valid, portainer.KubernetesStack), "command hooks are not supported for Kubernetes stacks")
+
+	invalid := []portainer.StackHook{
+		{Type: portainer.StackHookHTTP, URL: "https://example.com"},
+		{Name: "a", Type: portainer.StackHookHTTP, URL: "ftp://example.com"},
+		{Name: "a", Type: portainer.StackHookCommand},
+		{Name: "a", Type: portainer.StackHookCommand, Service: "web"},
+		{Name: "a", Type: portainer.StackHookCommand, Service: "web", Image: "myapp:latest", Command: []string{"migrate"}},
+		{Name: "a", Type: 9},
+		{Name: "a", Type: portainer.StackHookTeams},
+		{Name: "a", Type: portainer.StackHookPagerDuty},
+		{Name: "a", Type: portainer.StackHookPagerDuty, RoutingKey: "key", Severity: "fatal"},
+		{Name: "a", Type: portainer.StackHookPagerDuty, RoutingKey: "key", URL: "ftp://example.com"},
+		{Name: "a", Type: portainer.StackHookHTTP, URL: "https://example.com", Timeout: 7200},
+		{Name: "a", Type: portainer.StackHookHTTP, URL: "https://example.com", FailurePolicy: "retry"},
+		{Name: "a", Type: portainer.StackHookHTTP, URL: "https://example.com", Body: "{{ .StackName"},
+		{Name: "a", Type: portainer.StackHookHTTP, URL: "https://example.com", Body: "{{ .Unknown }}"},
+		{Name: "a", Type: portainer.StackHookHTTP, URL: "https://example.com", Headers: []portainer.Pair{{Name: "X-Stack", Value: "{{ .StackName | unknown }}"}}},
+	}
+
+	for _, hook := range invalid {
+		is.Error(Validate(&portainer.StackHooks{PreDeploy: []portainer.StackHook{hook}}, portainer.DockerComposeStack), "hook %+v", hook)
+	}
+}
+
+func TestRun_HTTPHooks(t *testing.T) {
+	is := require.New(t)
+
+	var events []deploymentEvent
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/fail" {
+			w.WriteHeader(http.StatusInternalServerError)
+			w.Write([]byte("cache unavailable"))
+
+			return
+		}
+
+		var event deploymentEvent
+		json.NewDecoder(r.Body).Decode(&event)
+		events = append(events, event)
+	}))
+	defer server.Close()
+
+	runner := NewRunner(nil)
+	endpoint := &portainer.Endpoint{ID: 2}
+
+	stack := &portainer.Stack{
+		ID:   1,
+		Name: "app",
+		Hooks: &portainer.StackHooks{
+			PreDeploy: []portainer.StackHook{
+				{Name: "ignored", Type: portainer.StackHookHTTP, URL: server.URL + "/fail", FailurePolicy: portainer.StackHookFailureContinue},
+				{Name: "notify", Type: portainer.StackHookHTTP, URL: server.URL + "/notify"},
+			},
+			PostDeploy: []portainer.StackHook{
+				{Name: "warm", Type: portainer.StackHookHTTP, URL: server.URL + "/fail"},
+				{Name: "notify", Type: portainer.StackHookHTTP, URL: server.URL + "/notify"},
+			},
+		},
+	}
+
+	is.NoError(runner.Run(PreDeploy, stack, endpoint))
+	is.Equal([]deploymentEvent{{Phase: PreDeploy, StackID: 1, StackName: "app", EndpointID: 2}}, events)
+
+	err := runner.Run(PostDeploy, stack, endpoint)
+	is.ErrorContains(err, "unexpected status code 500")
+	is.Len(events, 1, "the hooks following a failed hook must not run")
+
+	// the response of the remote service is only kept in the output of the run, the errors are returned to the users
+	is.NotContains(err.Error(), "cache unavailable")
+	is.EqualError(runner.Test(stack.Hooks.PostDeploy[0], stack, endpoint), "unexpected status code 500")
+
+	// the outcome of each hook is kept on the stack, including the ignored failures
+	is.Len(stack.HookRuns, 3)
+	for i, expected := range []struct{ name, phase, output string }{
+		{"ignored", "preDeploy", "cache unavailable"},
+		{"notify", "preDeploy", ""},
+		{"warm", "postDeploy", "cache unavailable"},
+	} {
+		run := stack.HookRuns[i]
+		is.Equal(expected.name, run.Name)
+		is.Equal(expected.phase, run.Phase)
+		is.Equal(expected.output, run.Output)
+		is.Equal(expected.output != "", run.Error != "", "hook %s", run.Name)
+	}
+
+	is.NoError(runner.Run(PreDeploy, &portainer.Stack{Name: "no-hooks"}, endpoint))
+}
+
+func TestRun_HTTPHookTemplates(t *testing.T) {
+	is := require.New(t)
+
+	var body, header string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		body = string(b)
+		header = r.Header.Get("X-Stack")
+	}))
+	defer server.Close()
+
+	hook := portainer.StackHook{
+		Name:    "slack",
+		Type:    portainer.StackHookHTTP,
+		URL:     server.URL,
+		Headers: []portainer.Pair{{Name: "X-Stack", Value: "{{ .StackName | upper }}"}},
+		Body:    `{"text": {{ printf "%s deployed on %s by %s" .StackName .EndpointName .HookName | json }}}`,
+	}
+
+	stack := &portainer.Stack{ID: 1, Name: `app "v2"`, Hooks: &portainer.StackHooks{PostDeploy: []portainer.StackHook{hook}}}
+	endpoint := &portainer.Endpoint{ID: 2, Name: "production"}
+
+	is.NoError(Validate(stack.Hooks, portainer.DockerComposeStack))
+	is.NoError(NewRunner(nil).Run(PostDeploy, stack, endpoint))
+
+	is.JSONEq(`{"text": "app \"v2\" deployed on production by slack"}`, body)
+	is.Equal(`APP "V2"`, header)
+
+	body = ""
+	is.NoError(NewRunner(nil).Test(hook, stack, endpoint))
+	is.NotEmpty(body)
+
+	is.Error(NewRunner(nil).Test(portainer.StackHook{Name: "migrate", Type: portainer.StackHookCommand, Image: "app"}, stack, endpoint))
+}
+
+func TestRun_NotificationHooks(t *testing.T) {
+	is := require.New(t)
+
+	bodies := map[string]map[string]any{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		is.Equal("application/json", r.Header.Get("Content-Type"))
+
+		var body map[string]any
+		is.NoError(json.NewDecoder(r.Body).Decode(&body))
+		bodies[r.URL.Path] = body
+	}))
+	defer server.Close()
+
+	stack := &portainer.Stack{ID: 4, Name: "app", Hooks: &portainer.StackHooks{PostDeploy: []portainer.StackHook{
+		{Name: "teams", Type: portainer.StackHookTeams, URL: server.URL + "/teams"},
+		{Name: "pagerduty", Type: portainer.StackHookPagerDuty, URL: server.URL + "/pagerduty", RoutingKey: "key", Body: "{{ .StackName }} is live"},
+	}}}
+	endpoint := &portainer.Endpoint{ID: 2, Name: "production"}
+
+	is.NoError(Validate(stack.Hooks, portainer.DockerComposeStack))
+	is.NoError(NewRunner(nil).Run(PostDeploy, stack, endpoint))
+
+	teams := bodies["/teams"]
+	is.Equal("message", teams["type"])
+	attachment := teams["attachments"].([]any)[0].(map[string]any)
+	is.Equal("application/vnd.microsoft.card.adaptive", attachment["contentType"])
+	card := attachment["content"].(map[string]any)
+	is.Equal("AdaptiveCard", card["type"])
+	is.Equal("Deployed the stack app on the environment production", card["body"].([]any)[0].(map[string]any)["text"])
+
+	pagerDuty := bodies["/pagerduty"]
+	is.Equal("key", pagerDuty["routing_key"])
+	is.Equal("trigger", pagerDuty["event_action"])
+	is.Equal("portainer-stack-4-pagerduty", pagerDuty["dedup_key"])
+	payload := pagerDuty["payload"].(map[string]any)
+	is.Equal("app is live", payload["summary"])
+	is.Equal("info", payload["severity"])
+	is.Equal("production", payload["source"])
+}
+
+func TestRedact(t *testing.T) {
+	is := require.New(t)
+
+	stored := &portainer.StackHooks{
+		PreDeploy: []portainer.StackHook{
+			{Name: "notify", Type: portainer.StackHookHTTP, URL: "https://hooks.example.com", Headers: []portainer.Pair{{Name: "Authorization", Value: "Bearer secret"}}},
+			{Name: "migrate", Type: portainer.StackHookCommand, Image: "app", Command: []string{"migrate"}, Env: []portainer.Pair{{Name: "DB_PASSWORD", Value: "secret"}}},
+		},
+		PostDeploy: []portainer.StackHook{
+			{Name: "page", Type: portainer.StackHookPagerDuty, URL: "https://events.pagerduty.com/v2/enqueue", RoutingKey: "secret"},
+		},
+	}
+
+	redacted := Redact(stored)
+	is.Nil(Redact(nil))
+
+	is.Equal([]portainer.Pair{{Name: "Authorization", Value: RedactedValue}}, redacted.PreDeploy[0].Headers)
+	is.Equal([]portainer.Pair{{Name: "DB_PASSWORD", Value: RedactedValue}}, redacted.PreDeploy[1].Env)
+	is.Equal(RedactedValue, redacted.PostDeploy[0].RoutingKey)
+	is.Equal("https://hooks.example.com", redacted.PreDeploy[0].URL)
+
+	// the stored hooks are left untouched
+	is.Equal("Bearer secret", stored.PreDeploy[0].Headers[0].Value)
+
+	// the hooks returned by the API are saved back with their stored secrets
+	redacted.PreDeploy[1].Env = append(redacted.PreDeploy[1].Env, portainer.Pair{Name: "DEBUG", Value: "1"})
+	is.NoError(Restore(redacted, stored))
+	is.Equal(stored.PreDeploy[0].Headers, redacted.PreDeploy[0].Headers)
+	is.Equal([]portainer.Pair{{Name: "DB_PASSWORD", Value: "secret"}, {Name: "DEBUG", Value: "1"}}, redacted.PreDeploy[1].Env)
+	is.Equal("secret", redacted.PostDeploy[0].RoutingKey)
+
+	// a redacted value without a stored value is refused
+	renamed := Redact(stored)
+	renamed.PreDeploy[0].Name = "other"
+	is.Error(Restore(renamed, stored))
+	is.Error(Restore(Redact(stored), nil))
+}
@@ -0,0 +1,154 @@
+package hooks
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strconv"
+
+	portainer "github.com/portainer/portainer/api"
+
+	"github.com/pkg/errors"
+	"github.com/segmentio/encoding/json"
+)
+
+// PagerDutyEventsURL is the endpoint of the PagerDuty Events API v2, used when a PagerDuty hook does not define a URL
+const PagerDutyEventsURL = "https://events.pagerduty.com/v2/enqueue"
+
+// pagerDutySeverities are the severities accepted by the PagerDuty Events API
+var pagerDutySeverities = []string{"critical", "error", "warning", "info"}
+
+// notificationText is the text of a Teams message or the summary of a PagerDuty event,
+// the body of the hook is used as its template when it is set
+func notificationText(hook portainer.StackHook, data TemplateData) (string, error) {
+	if hook.Body != "" {
+		text, err := render(hook.Body, data)
+
+		return text, errors.Wrap(err, "unable to render the body template")
+	}
+
+	action := "Deploying"
+	if data.Phase == PostDeploy {
+		action = "Deployed"
+	}
+
+	return fmt.Sprintf("%s the stack %s on the environment %s", action, data.StackName, data.EndpointName), nil
+}
+
+type adaptiveCardFact struct {
+	Title string `json:"title"`
+	Value string `json:"value"`
+}
+
+type adaptiveCardElement struct {
+	Type   string             `json:"type"`
+	Text   string             `json:"text,omitempty"`
+	Size   string             `json:"size,omitempty"`
+	Weight string             `json:"weight,omitempty"`
+	Wrap   bool               `json:"wrap,omitempty"`
+	Facts  []adaptiveCardFact `json:"facts,omitempty"`
+}
+
+type adaptiveCard struct {
+	Schema  string                `json:"$schema"`
+	Type    string                `json:"type"`
+	Version string                `json:"version"`
+	Body    []adaptiveCardElement `json:"body"`
+}
+
+type teamsAttachment struct {
+	ContentType string       `json:"contentType"`
+	Content     adaptiveCard `json:"content"`
+}
+
+type teamsMessage struct {
+	Type        string            `json:"type"`
+	Attachments []teamsAttachment `json:"attachments"`
+}
+
+// teamsPayload returns the message posted to a Teams incoming webhook, an Adaptive Card describing the deployment
+func teamsPayload(hook portainer.StackHook, data TemplateData) ([]byte, error) {
+	text, err := notificationText(hook, data)
+	if err != nil {
+		return nil, err
+	}
+
+	card := adaptiveCard{
+		Schema:  "http://adaptivecards.io/schemas/adaptive-card.json",
+		Type:    "AdaptiveCard",
+		Version: "1.4",
+		Body: []adaptiveCardElement{
+			{Type: "TextBlock", Text: text, Size: "Medium", Weight: "Bolder", Wrap: true},
+			{Type: "FactSet", Facts: []adaptiveCardFact{
+				{Title: "Stack", Value: data.StackName},
+				{Title: "Environment", Value: data.EndpointName},
+				{Title: "Phase", Value: string(data.Phase)},
+				{Title: "Hook", Value: data.HookName},
+			}},
+		},
+	}
+
+	return json.Marshal(teamsMessage{
+		Type:        "message",
+		Attachments: []teamsAttachment{{ContentType: "application/vnd.microsoft.card.adaptive", Content: card}},
+	})
+}
+
+type pagerDutyPayloadDetails struct {
+	Summary       string            `json:"summary"`
+	Source        string            `json:"source"`
+	Severity      string            `json:"severity"`
+	Timestamp     string            `json:"timestamp"`
+	Component     string            `json:"component"`
+	Group         string            `json:"group"`
+	Class         string            `json:"class"`
+	CustomDetails map[string]string `json:"custom_details"`
+}
+
+type pagerDutyEvent struct {
+	RoutingKey  string                  `json:"routing_key"`
+	EventAction string                  `json:"event_action"`
+	DedupKey    string                  `json:"dedup_key"`
+	Payload     pagerDutyPayloadDetails `json:"payload"`
+}
+
+// pagerDutyPayload returns the event sent to the PagerDuty Events API. The de-duplication key identifies the stack
+// and the hook, so that the events of successive deployments are grouped in a single alert
+func pagerDutyPayload(hook portainer.StackHook, data TemplateData) ([]byte, error) {
+	summary, err := notificationText(hook, data)
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(pagerDutyEvent{
+		RoutingKey:  hook.RoutingKey,
+		EventAction: "trigger",
+		DedupKey:    "portainer-stack-" + strconv.Itoa(int(data.StackID)) + "-" + data.HookName,
+		Payload: pagerDutyPayloadDetails{
+			Summary:   summary,
+			Source:    data.EndpointName,
+			Severity:  cmp.Or(hook.Severity, "info"),
+			Timestamp: data.Time.Format("2006-01-02T15:04:05.000Z07:00"),
+			Component: data.StackName,
+			Group:     "portainer",
+			Class:     "stack-" + string(data.Phase),
+			CustomDetails: map[string]string{
+				"stackId":    strconv.Itoa(int(data.StackID)),
+				"endpointId": strconv.Itoa(int(data.EndpointID)),
+				"phase":      string(data.Phase),
+			},
+		},
+	})
+}
+
+func validatePagerDutyHook(hook portainer.StackHook) error {
+	if hook.RoutingKey == "" {
+		return errors.New("missing routing key")
+	}
+
+	if hook.Severity != "" && !slices.Contains(pagerDutySeverities, hook.Severity) {
+		return errors.New("invalid severity, must be critical, error, warning or info")
+	}
+
+	return nil
+}
@@ -0,0 +1,121 @@
+package hooks
+
+import (
+	"fmt"
+	"slices"
+
+	portainer "github.com/portainer/portainer/api"
+)
+
+// RedactedValue replaces the values of the headers and environment variables and the routing keys of the hooks in
+// the responses of the API. A hook saved with it keeps the stored value
+const RedactedValue = "[REDACTED]"
+
+// Redact returns a copy of the hooks of a stack without their secrets
+func Redact(stackHooks *portainer.StackHooks) *portainer.StackHooks {
+	if stackHooks == nil {
+		return nil
+	}
+
+	return &portainer.StackHooks{
+		PreDeploy:  redactHooks(stackHooks.PreDeploy),
+		PostDeploy: redactHooks(stackHooks.PostDeploy),
+	}
+}
+
+func redactHooks(hooks []portainer.StackHook) []portainer.StackHook {
+	if hooks == nil {
+		return nil
+	}
+
+	redacted := make([]portainer.StackHook, len(hooks))
+	for i, hook := range hooks {
+		hook.Headers = redactPairs(hook.Headers)
+		hook.Env = redactPairs(hook.Env)
+		if hook.RoutingKey != "" {
+			hook.RoutingKey = RedactedValue
+		}
+
+		redacted[i] = hook
+	}
+
+	return redacted
+}
+
+func redactPairs(pairs []portainer.Pair) []portainer.Pair {
+	if pairs == nil {
+		return nil
+	}
+
+	redacted := make([]portainer.Pair, len(pairs))
+	for i, pair := range pairs {
+		redacted[i] = portainer.Pair{Name: pair.Name, Value: RedactedValue}
+	}
+
+	return redacted
+}
+
+// Restore replaces the redacted values of the hooks with the values of the stored hooks of the same phase and name,
+// so that the hooks returned by the API can be saved back. It fails when a redacted value has no stored value
+func Restore(stackHooks, stored *portainer.StackHooks) error {
+	if stackHooks == nil {
+		return nil
+	}
+
+	if stored == nil {
+		stored = &portainer.StackHooks{}
+	}
+
+	if err := restoreHooks(stackHooks.PreDeploy, stored.PreDeploy); err != nil {
+		return err
+	}
+
+	return restoreHooks(stackHooks.PostDeploy, stored.PostDeploy)
+}
+
+func restoreHooks(hooks, stored []portainer.StackHook) error {
+	for i := range hooks {
+		hook := &hooks[i]
+
+		var storedHook portainer.StackHook
+		if j := slices.IndexFunc(stored, func(h portainer.StackHook) bool { return h.Name == hook.Name }); j >= 0 {
+			storedHook = stored[j]
+		}
+
+		if hook.RoutingKey == RedactedValue {
+			if storedHook.RoutingKey == "" {
+				return fmt.Errorf("the routing key of the hook %s is redacted and has no stored value", hook.Name)
+			}
+
+			hook.RoutingKey = storedHook.RoutingKey
+		}
+
+		if name := restorePairs(hook.Headers, storedHook.Headers); name != "" {
+			return fmt.Errorf("the header %s of the hook %s is redacted and has no stored value", name, hook.Name)
+		}
+
+		if name := restorePairs(hook.Env, storedHook.Env); name != "" {
+			return fmt.Errorf("the environment variable %s of the hook %s is redacted and has no stored value", name, hook.Name)
+		}
+	}
+
+	return nil
+}
+
+// restorePairs restores the redacted values of the pairs and returns the name of the first one without stored value
+func restorePairs(pairs, stored []portainer.Pair) string {
+	for i := range pairs {
+		if pairs[i].Value != RedactedValue {
+			continue
+		}
+
+		j := slices.IndexFunc(stored, func(p portainer.Pair) bool { return p.Name == pairs[i].Name })
+		if j < 0 {
+			return pairs[i].Name
+		}
+
+		pairs[i].Value = stored[j].Value
+	}
+
+	return ""
+}
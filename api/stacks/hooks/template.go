@@ -0,0 +1,92 @@
+package hooks
+
+import (
+	"bytes"
+	"strings"
+	"text/template"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+
+	"github.com/pkg/errors"
+	"github.com/segmentio/encoding/json"
+)
+
+// TemplateData is the context of the body and header templates of the HTTP hooks
+type TemplateData struct {
+	Phase        Phase
+	StackID      portainer.StackID
+	StackName    string
+	EndpointID   portainer.EndpointID
+	EndpointName string
+	HookName     string
+	Time         time.Time
+}
+
+var templateFuncs = template.FuncMap{
+	// json encodes a value, strings are quoted and escaped so that they can be embedded in JSON payloads
+	"json": func(v any) (string, error) {
+		b, err := json.Marshal(v)
+
+		return string(b), err
+	},
+	"upper": strings.ToUpper,
+	"lower": strings.ToLower,
+}
+
+// sampleTemplateData is used to validate the templates when the hooks are saved
+var sampleTemplateData = TemplateData{
+	Phase:        PreDeploy,
+	StackID:      1,
+	StackName:    "stack",
+	EndpointID:   1,
+	EndpointName: "local",
+	HookName:     "hook",
+	Time:         time.Unix(0, 0).UTC(),
+}
+
+func newTemplateData(phase Phase, hook portainer.StackHook, stack *portainer.Stack, endpoint *portainer.Endpoint) TemplateData {
+	return TemplateData{
+		Phase:        phase,
+		StackID:      stack.ID,
+		StackName:    stack.Name,
+		EndpointID:   endpoint.ID,
+		EndpointName: endpoint.Name,
+		HookName:     hook.Name,
+		Time:         time.Now().UTC(),
+	}
+}
+
+// render executes a Go template, the text is returned as is when it is not a template
+func render(text string, data TemplateData) (string, error) {
+	if !strings.Contains(text, "{{") {
+		return text, nil
+	}
+
+	tmpl, err := template.New("hook").Funcs(templateFuncs).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", err
+	}
+
+	var b bytes.Buffer
+	if err := tmpl.Execute(&b, data); err != nil {
+		return "", err
+	}
+
+	return b.String(), nil
+}
+
+// validateTemplates checks that the body and the header templates of an HTTP hook parse and execute
+func validateTemplates(hook portainer.StackHook) error {
+	if _, err := render(hook.Body, sampleTemplateData); err != nil {
+		return errors.Wrap(err, "invalid body template")
+	}
+
+	for _, header := range hook.Headers {
+		if _, err := render(header.Value, sampleTemplateData); err != nil {
+			return errors.Wrapf(err, "invalid template of the header %s", header.Name)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,285 @@
+// Package stackarchive exports the stacks as portable archives and reads these archives back to import the stacks
+// into another environment(endpoint) or Portainer instance
+package stackarchive
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+
+	"github.com/pkg/errors"
+	"github.com/segmentio/encoding/json"
+)
+
+const (
+	// FormatVersion is the version of the format of the archives written by Export
+	FormatVersion = 1
+	// MaxSize bounds the extracted size of the imported archives
+	MaxSize = 10 << 20
+
+	manifestFileName = "manifest.json"
+	filesDirectory   = "files/"
+)
+
+// ErrInvalidArchive is returned when an imported archive cannot be read or does not describe a stack
+var ErrInvalidArchive = errors.New("invalid stack archive")
+
+// Manifest describes the stack of an archive
+type Manifest struct {
+	FormatVersion    int                    `json:"FormatVersion" example:"1"`
+	PortainerVersion string                 `json:"PortainerVersion" example:"2.24.0"`
+	ExportedAt       int64                  `json:"ExportedAt" example:"1587399600"`
+	Name             string                 `json:"Name" example:"myStack"`
+	Type             portainer.StackType    `json:"Type" example:"2"`
+	EntryPoint       string                 `json:"EntryPoint" example:"docker-compose.yml"`
+	AdditionalFiles  []string               `json:"AdditionalFiles,omitempty"`
+	Env              []portainer.Pair       `json:"Env"`
+	Namespace        string                 `json:"Namespace,omitempty" example:"default"`
+	Option           *portainer.StackOption `json:"Option,omitempty"`
+	Hooks            *portainer.StackHooks  `json:"Hooks,omitempty"`
+	// Repository of a git stack, for reference only: the imported stack is deployed from the files of the archive
+	GitSource *GitSource `json:"GitSource,omitempty"`
+	// The stack had a redeploy webhook, a webhook with a new token is created for the imported stack
+	Webhook bool `json:"Webhook" example:"false"`
+}
+
+// GitSource represents the repository a git stack was deployed from
+type GitSource struct {
+	URL           string `json:"URL" example:"https://github.com/portainer/portainer-compose"`
+	ReferenceName string `json:"ReferenceName" example:"refs/heads/main"`
+	CommitHash    string `json:"CommitHash" example:"bdc8f7b"`
+}
+
+// Archive represents the content of a stack archive
+type Archive struct {
+	Manifest Manifest
+	// Content of the stack files, by file name
+	Files map[string][]byte
+}
+
+// Export returns the archive of a stack, a gzipped tar file holding its manifest and its stack files. The files of
+// the git stacks are stored by their base name so that the archive can be deployed without the repository
+func Export(fileService portainer.FileService, stack *portainer.Stack, webhook bool) ([]byte, error) {
+	manifest := Manifest{
+		FormatVersion:    FormatVersion,
+		PortainerVersion: portainer.APIVersion,
+		ExportedAt:       time.Now().Unix(),
+		Name:             stack.Name,
+		Type:             stack.Type,
+		EntryPoint:       path.Base(stack.EntryPoint),
+		Env:              stack.Env,
+		Namespace:        stack.Namespace,
+		Option:           stack.Option,
+		Hooks:            stack.Hooks,
+		Webhook:          webhook,
+	}
+
+	if stack.GitConfig != nil {
+		manifest.GitSource = &GitSource{
+			URL:           stack.GitConfig.URL,
+			ReferenceName: stack.GitConfig.ReferenceName,
+			CommitHash:    stack.GitConfig.ConfigHash,
+		}
+	}
+
+	files := make(map[string][]byte)
+	for i, file := range append([]string{stack.EntryPoint}, stack.AdditionalFiles...) {
+		name := path.Base(file)
+		if _, ok := files[name]; ok {
+			return nil, fmt.Errorf("the stack has several files named %s", name)
+		}
+
+		content, err := fileService.GetFileContent(stack.ProjectPath, file)
+		if err != nil {
+			return nil, errors.Wrapf(err, "unable to read the stack file %s", file)
+		}
+
+		files[name] = content
+		if i > 0 {
+			manifest.AdditionalFiles = append(manifest.AdditionalFiles, name)
+		}
+	}
+
+	manifestContent, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	gzipWriter := gzip.NewWriter(&buf)
+	tarWriter := tar.NewWriter(gzipWriter)
+
+	if err := writeFile(tarWriter, manifestFileName, manifestContent); err != nil {
+		return nil, err
+	}
+
+	for _, name := range append([]string{manifest.EntryPoint}, manifest.AdditionalFiles...) {
+		if err := writeFile(tarWriter, filesDirectory+name, files[name]); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tarWriter.Close(); err != nil {
+		return nil, err
+	}
+
+	if err := gzipWriter.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func writeFile(tarWriter *tar.Writer, name string, content []byte) error {
+	if err := tarWriter.WriteHeader(&tar.Header{
+		Name:    name,
+		Mode:    0o600,
+		Size:    int64(len(content)),
+		ModTime: time.Now(),
+	}); err != nil {
+		return err
+	}
+
+	_, err := tarWriter.Write(content)
+
+	return err
+}
+
+// Read reads and validates a stack archive
+func Read(r io.Reader) (*Archive, error) {
+	gzipReader, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidArchive, err)
+	}
+	defer gzipReader.Close()
+
+	limited := &io.LimitedReader{R: gzipReader, N: MaxSize + 1}
+	tarReader := tar.NewReader(limited)
+
+	archive := &Archive{Files: make(map[string][]byte)}
+	hasManifest := false
+
+	for {
+		header, err := tarReader.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidArchive, err)
+		}
+
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		content, err := io.ReadAll(tarReader)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidArchive, err)
+		}
+
+		if limited.N <= 0 {
+			return nil, fmt.Errorf("%w: the archive exceeds %d bytes", ErrInvalidArchive, MaxSize)
+		}
+
+		switch name := header.Name; {
+		case name == manifestFileName:
+			if err := json.Unmarshal(content, &archive.Manifest); err != nil {
+				return nil, fmt.Errorf("%w: unable to parse the manifest", ErrInvalidArchive)
+			}
+
+			hasManifest = true
+		case strings.HasPrefix(name, filesDirectory):
+			name = strings.TrimPrefix(name, filesDirectory)
+			if !isFileName(name) {
+				return nil, fmt.Errorf("%w: invalid file name %s", ErrInvalidArchive, header.Name)
+			}
+
+			archive.Files[name] = content
+		}
+	}
+
+	if !hasManifest {
+		return nil, fmt.Errorf("%w: the archive has no manifest", ErrInvalidArchive)
+	}
+
+	if err := archive.validate(); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidArchive, err)
+	}
+
+	return archive, nil
+}
+
+func (archive *Archive) validate() error {
+	manifest := &archive.Manifest
+
+	if manifest.FormatVersion < 1 || manifest.FormatVersion > FormatVersion {
+		return fmt.Errorf("unsupported format version %d", manifest.FormatVersion)
+	}
+
+	if manifest.Name == "" {
+		return errors.New("the stack has no name")
+	}
+
+	if manifest.Type != portainer.DockerComposeStack && manifest.Type != portainer.DockerSwarmStack && manifest.Type != portainer.KubernetesStack {
+		return fmt.Errorf("unsupported stack type %d", manifest.Type)
+	}
+
+	for _, name := range append([]string{manifest.EntryPoint}, manifest.AdditionalFiles...) {
+		if !isFileName(name) {
+			return fmt.Errorf("invalid file name %s", name)
+		}
+
+		if _, ok := archive.Files[name]; !ok {
+			return fmt.Errorf("the file %s is missing", name)
+		}
+	}
+
+	return nil
+}
+
+// isFileName checks if a name is the name of a file of the root directory of the stack
+func isFileName(name string) bool {
+	return name != "" && name != "." && name != ".." && !strings.ContainsAny(name, `/\`)
+}
+
+// ConflictPolicy represents what an import does when the name of the stack is already used on the environment(endpoint)
+type ConflictPolicy string
+
+const (
+	// ConflictFail rejects the import
+	ConflictFail ConflictPolicy = "fail"
+	// ConflictRename imports the stack under its name followed by the first free number, e.g. myStack-2
+	ConflictRename ConflictPolicy = "rename"
+)
+
+// maxRenames bounds the names tried by ConflictRename
+const maxRenames = 100
+
+// ErrNameConflict is returned when the name of an imported stack is already used and the conflicts are not resolved
+var ErrNameConflict = errors.New("a stack with the same name already exists on the environment")
+
+// ResolveName returns the name an imported stack is created with according to the conflict policy
+func ResolveName(name string, policy ConflictPolicy, isUnique func(name string) (bool, error)) (string, error) {
+	candidate := name
+
+	for i := 2; ; i++ {
+		unique, err := isUnique(candidate)
+		if err != nil {
+			return "", err
+		} else if unique {
+			return candidate, nil
+		}
+
+		if policy != ConflictRename || i > maxRenames {
+			return "", ErrNameConflict
+		}
+
+		candidate = fmt.Sprintf("%s-%d", name, i)
+	}
+}
@@ -0,0 +1,129 @@
+package stackarchive
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"testing"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/filesystem"
+	gittypes "github.com/portainer/portainer/api/git/types"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newArchive(t *testing.T, files map[string]string) []byte {
+	var buf bytes.Buffer
+	gzipWriter := gzip.NewWriter(&buf)
+	tarWriter := tar.NewWriter(gzipWriter)
+
+	for name, content := range files {
+		require.NoError(t, writeFile(tarWriter, name, []byte(content)))
+	}
+
+	require.NoError(t, tarWriter.Close())
+	require.NoError(t, gzipWriter.Close())
+
+	return buf.Bytes()
+}
+
+func TestExportRead(t *testing.T) {
+	is := require.New(t)
+
+	fileService, err := filesystem.NewService(t.TempDir(), "")
+	is.NoError(err)
+
+	stack := &portainer.Stack{
+		Name:            "shop",
+		Type:            portainer.DockerComposeStack,
+		ProjectPath:     t.TempDir(),
+		EntryPoint:      "deploy/docker-compose.yml",
+		AdditionalFiles: []string{"deploy/prod.yml"},
+		Env:             []portainer.Pair{{Name: "PORT", Value: "8080"}},
+		GitConfig: &gittypes.RepoConfig{
+			URL:            "https://github.com/portainer/portainer-compose",
+			ReferenceName:  "refs/heads/main",
+			ConfigHash:     "bdc8f7b",
+			Authentication: &gittypes.GitAuthentication{Username: "admin", Password: "secret"},
+		},
+	}
+
+	is.NoError(os.MkdirAll(filepath.Join(stack.ProjectPath, "deploy"), 0o700))
+	is.NoError(os.WriteFile(filepath.Join(stack.ProjectPath, "deploy/docker-compose.yml"), []byte("services: {}\n"), 0o600))
+	is.NoError(os.WriteFile(filepath.Join(stack.ProjectPath, "deploy/prod.yml"), []byte("services:\n  web: {}\n"), 0o600))
+
+	content, err := Export(fileService, stack, true)
+	is.NoError(err)
+	is.NotContains(string(content), "secret")
+
+	archive, err := Read(bytes.NewReader(content))
+	is.NoError(err)
+
+	// the files of the git stacks are flattened
+	is.Equal("docker-compose.yml", archive.Manifest.EntryPoint)
+	is.Equal([]string{"prod.yml"}, archive.Manifest.AdditionalFiles)
+	is.Equal(map[string][]byte{
+		"docker-compose.yml": []byte("services: {}\n"),
+		"prod.yml":           []byte("services:\n  web: {}\n"),
+	}, archive.Files)
+
+	is.Equal("shop", archive.Manifest.Name)
+	is.Equal(portainer.DockerComposeStack, archive.Manifest.Type)
+	is.Equal(stack.Env, archive.Manifest.Env)
+	is.Equal(&GitSource{URL: stack.GitConfig.URL, ReferenceName: "refs/heads/main", CommitHash: "bdc8f7b"}, archive.Manifest.GitSource)
+	is.True(archive.Manifest.Webhook)
+
+	// the files with the same base name cannot be flattened
+	stack.AdditionalFiles = []string{"docker-compose.yml"}
+	is.NoError(os.WriteFile(filepath.Join(stack.ProjectPath, "docker-compose.yml"), []byte("services: {}\n"), 0o600))
+
+	_, err = Export(fileService, stack, false)
+	is.Error(err)
+}
+
+func TestReadInvalid(t *testing.T) {
+	is := require.New(t)
+
+	manifest := `{"FormatVersion": 1, "Name": "shop", "Type": 2, "EntryPoint": "docker-compose.yml"}`
+
+	for name, files := range map[string]map[string]string{
+		"no manifest":         {"files/docker-compose.yml": "services: {}"},
+		"missing stack file":  {"manifest.json": manifest},
+		"unsupported version": {"manifest.json": `{"FormatVersion": 2, "Name": "shop", "Type": 2, "EntryPoint": "docker-compose.yml"}`, "files/docker-compose.yml": ""},
+		"unsupported type":    {"manifest.json": `{"FormatVersion": 1, "Name": "shop", "Type": 4, "EntryPoint": "docker-compose.yml"}`, "files/docker-compose.yml": ""},
+		"nested file":         {"manifest.json": manifest, "files/docker-compose.yml": "", "files/../../etc/passwd": ""},
+	} {
+		_, err := Read(bytes.NewReader(newArchive(t, files)))
+		is.ErrorIs(err, ErrInvalidArchive, name)
+	}
+
+	_, err := Read(bytes.NewReader([]byte("services: {}")))
+	is.ErrorIs(err, ErrInvalidArchive)
+
+	archive, err := Read(bytes.NewReader(newArchive(t, map[string]string{"manifest.json": manifest, "files/docker-compose.yml": "services: {}"})))
+	is.NoError(err)
+	is.Equal("shop", archive.Manifest.Name)
+}
+
+func TestResolveName(t *testing.T) {
+	is := require.New(t)
+
+	used := map[string]bool{"shop": true, "shop-2": true}
+	isUnique := func(name string) (bool, error) {
+		return !used[name], nil
+	}
+
+	name, err := ResolveName("web", ConflictFail, isUnique)
+	is.NoError(err)
+	is.Equal("web", name)
+
+	_, err = ResolveName("shop", ConflictFail, isUnique)
+	is.ErrorIs(err, ErrNameConflict)
+
+	name, err = ResolveName("shop", ConflictRename, isUnique)
+	is.NoError(err)
+	is.Equal("shop-3", name)
+}
@@ -55,6 +55,7 @@ func (b *KubernetesStackGitBuilder) SetUniqueInfo(payload *StackPayload) GitMeth
 	b.stack.Namespace = payload.Namespace
 	b.stack.Name = payload.StackName
 	b.stack.EntryPoint = payload.ManifestFile
+	b.stack.KustomizeOverlay = payload.KustomizeOverlay
 	b.stack.CreatedBy = b.user.Username
 
 	return b
@@ -81,7 +82,7 @@ func (b *KubernetesStackGitBuilder) Deploy(payload *StackPayload, endpoint *port
 		Kind:      "git",
 	}
 
-	k8sDeploymentConfig, err := deployments.CreateKubernetesStackDeploymentConfig(b.stack, b.KuberneteDeployer, k8sAppLabel, b.user, endpoint)
+	k8sDeploymentConfig, err := deployments.CreateKubernetesStackDeploymentConfig(b.dataStore, b.stack, b.KuberneteDeployer, k8sAppLabel, b.user, endpoint)
 	if err != nil {
 		b.err = httperror.InternalServerError("failed to create temp kub deployment files", err)
 		return b
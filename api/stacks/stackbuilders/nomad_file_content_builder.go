@@ -0,0 +1,101 @@
+package stackbuilders
+
+import (
+	"context"
+	"strconv"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+	"github.com/portainer/portainer/api/filesystem"
+	"github.com/portainer/portainer/api/nomad"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+)
+
+type NomadStackFileContentBuilder struct {
+	FileContentMethodStackBuilder
+	User   *portainer.User
+	evalID string
+}
+
+// CreateNomadStackFileContentBuilder creates a builder for the Nomad stack that will be deployed by file content method
+func CreateNomadStackFileContentBuilder(dataStore dataservices.DataStore,
+	fileService portainer.FileService,
+	user *portainer.User) *NomadStackFileContentBuilder {
+
+	return &NomadStackFileContentBuilder{
+		FileContentMethodStackBuilder: FileContentMethodStackBuilder{
+			StackBuilder: CreateStackBuilder(dataStore, fileService, nil),
+		},
+		User: user,
+	}
+}
+
+func (b *NomadStackFileContentBuilder) SetGeneralInfo(payload *StackPayload, endpoint *portainer.Endpoint) FileContentMethodStackBuildProcess {
+	b.FileContentMethodStackBuilder.SetGeneralInfo(payload, endpoint)
+
+	return b
+}
+
+func (b *NomadStackFileContentBuilder) SetUniqueInfo(payload *StackPayload) FileContentMethodStackBuildProcess {
+	if b.hasError() {
+		return b
+	}
+
+	b.stack.Name = payload.StackName
+	b.stack.Type = portainer.NomadStack
+	b.stack.EntryPoint = filesystem.NomadJobFileDefaultName
+	b.stack.CreatedBy = b.User.Username
+
+	return b
+}
+
+func (b *NomadStackFileContentBuilder) SetFileContent(payload *StackPayload) FileContentMethodStackBuildProcess {
+	if b.hasError() {
+		return b
+	}
+
+	stackFolder := strconv.Itoa(int(b.stack.ID))
+	projectPath, err := b.fileService.StoreStackFileFromBytes(stackFolder, b.stack.EntryPoint, []byte(payload.StackFileContent))
+	if err != nil {
+		b.err = httperror.InternalServerError("Unable to persist Nomad jobspec on disk", err)
+		return b
+	}
+	b.stack.ProjectPath = projectPath
+
+	return b
+}
+
+func (b *NomadStackFileContentBuilder) Deploy(payload *StackPayload, endpoint *portainer.Endpoint) FileContentMethodStackBuildProcess {
+	if b.hasError() {
+		return b
+	}
+
+	client, err := nomad.NewClient(endpoint)
+	if err != nil {
+		b.err = httperror.InternalServerError("Unable to create a client of the Nomad API", err)
+		return b
+	}
+
+	ctx := context.TODO()
+
+	job, err := client.ParseJob(ctx, payload.StackFileContent)
+	if err != nil {
+		b.err = httperror.BadRequest("Unable to parse the Nomad jobspec", err)
+		return b
+	}
+
+	resp, err := client.RegisterJob(ctx, job)
+	if err != nil {
+		b.err = httperror.InternalServerError("Unable to register the Nomad job", err)
+		return b
+	}
+
+	b.evalID = resp.EvalID
+
+	return b
+}
+
+// GetResponse returns the identifier of the evaluation created by the job registration
+func (b *NomadStackFileContentBuilder) GetResponse() string {
+	return b.evalID
+}
@@ -4,6 +4,7 @@ import (
 	portainer "github.com/portainer/portainer/api"
 	"github.com/portainer/portainer/api/dataservices"
 	"github.com/portainer/portainer/api/stacks/deployments"
+	"github.com/portainer/portainer/api/stacks/stackversions"
 	httperror "github.com/portainer/portainer/pkg/libhttp/error"
 
 	"github.com/rs/zerolog/log"
@@ -43,6 +44,8 @@ func (b *StackBuilder) SaveStack() (*portainer.Stack, *httperror.HandlerError) {
 
 	b.doCleanUp = false
 
+	stackversions.RecordStack(b.dataStore, b.stack, b.stack.CreatedBy)
+
 	return b.stack, b.err
 }
 
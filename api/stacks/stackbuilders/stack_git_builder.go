@@ -122,7 +122,7 @@ func (b *GitMethodStackBuilder) SetAutoUpdate(payload *StackPayload) GitMethodSt
 		return b
 	}
 
-	if payload.AutoUpdate != nil && payload.AutoUpdate.Interval != "" {
+	if payload.AutoUpdate != nil && payload.AutoUpdate.Interval != "" && !payload.AutoUpdate.Paused {
 		jobID, err := deployments.StartAutoupdate(b.stack.ID,
 			b.stack.AutoUpdate.Interval,
 			b.scheduler,
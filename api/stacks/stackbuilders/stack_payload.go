@@ -27,12 +27,18 @@ type StackPayload struct {
 	Namespace string
 	// Path to the k8s Stack file. Used by k8s git repository method
 	ManifestFile string
+	// Overlay directory of a kustomize stack, relative to the root of the repository. Used by k8s git repository method
+	KustomizeOverlay string `example:"overlays/production"`
 	// URL to the k8s Stack file. Used by k8s git repository method
 	ManifestURL string
 	// Path to the Stack file inside the Git repository
 	ComposeFile string `example:"docker-compose.yml" default:"docker-compose.yml"`
 	// Applicable when deploying with multiple stack files
 	AdditionalFiles []string `example:"[nz.compose.yml, uat.compose.yml]"`
+	// Deploy from a clone of the repository kept on the environment. Used by compose git repository method
+	SupportRelativePath bool `example:"false"`
+	// Absolute path on the environment the repository is cloned under. Used by compose git repository method
+	FilesystemPath string `example:"/opt/portainer"`
 	// Git repository configuration of a stack
 	RepositoryConfigPayload
 }
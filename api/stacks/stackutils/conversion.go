@@ -0,0 +1,295 @@
+package stackutils
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	portainer "github.com/portainer/portainer/api"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+// swarmComposeFileVersion is the version set on the converted Swarm stack files, the version 2 of the compose file
+// format cannot be deployed on Swarm
+const swarmComposeFileVersion = "3.8"
+
+// composeOnlyServiceOptions are the service options ignored by Swarm, they are removed from the converted files
+var composeOnlyServiceOptions = []string{
+	"build",
+	"cgroup_parent",
+	"container_name",
+	"cpu_shares",
+	"cpu_quota",
+	"cpuset",
+	"depends_on",
+	"devices",
+	"external_links",
+	"ipc",
+	"links",
+	"network_mode",
+	"pid",
+	"privileged",
+	"security_opt",
+	"userns_mode",
+	"volumes_from",
+}
+
+// swarmOnlyDeployOptions are the deploy options ignored by Compose, they are removed from the converted files
+var swarmOnlyDeployOptions = []string{
+	"endpoint_mode",
+	"placement",
+	"rollback_config",
+	"update_config",
+}
+
+// resourceOptions maps the resource options of the Compose services to their deploy.resources counterpart
+var resourceOptions = []struct {
+	option string
+	kind   string
+	name   string
+}{
+	{option: "cpus", kind: "limits", name: "cpus"},
+	{option: "mem_limit", kind: "limits", name: "memory"},
+	{option: "mem_reservation", kind: "reservations", name: "memory"},
+}
+
+// ConvertStackFile converts the content of a stack file between the Compose and the Swarm formats, or from these
+// formats into Kubernetes manifests, stackType being the type of the target stack. The options that cannot be
+// converted are removed and reported in the warnings
+func ConvertStackFile(content []byte, stackType portainer.StackType) ([]byte, []string, error) {
+	if stackType == portainer.KubernetesStack {
+		return convertToKubernetes(content)
+	}
+
+	var document yaml.Node
+	if err := yaml.Unmarshal(content, &document); err != nil {
+		return nil, nil, errors.Wrap(err, "unable to parse the stack file")
+	}
+
+	if document.Kind != yaml.DocumentNode || len(document.Content) == 0 || document.Content[0].Kind != yaml.MappingNode {
+		return nil, nil, errors.New("the stack file must be a YAML mapping")
+	}
+
+	root := document.Content[0]
+
+	var warnings []string
+	switch stackType {
+	case portainer.DockerSwarmStack:
+		warnings = convertToSwarm(root)
+	case portainer.DockerComposeStack:
+		warnings = convertToCompose(root)
+	default:
+		return nil, nil, errors.New("stack files can only be converted to Swarm, Compose or Kubernetes stacks")
+	}
+
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+
+	if err := encoder.Encode(&document); err != nil {
+		return nil, nil, errors.Wrap(err, "unable to write the converted stack file")
+	}
+
+	if err := encoder.Close(); err != nil {
+		return nil, nil, errors.Wrap(err, "unable to write the converted stack file")
+	}
+
+	return buf.Bytes(), warnings, nil
+}
+
+func convertToSwarm(root *yaml.Node) []string {
+	var warnings []string
+
+	if version := mappingValue(root, "version"); version == nil || !strings.HasPrefix(version.Value, "3") {
+		setMappingValue(root, "version", stringNode(swarmComposeFileVersion))
+		warnings = append(warnings, fmt.Sprintf("the compose file version was set to %s, required by Swarm", swarmComposeFileVersion))
+	}
+
+	forEachService(root, func(name string, service *yaml.Node) {
+		for _, option := range composeOnlyServiceOptions {
+			if deleteMappingKey(service, option) {
+				warnings = append(warnings, fmt.Sprintf("service %q: %s is not supported by Swarm and was removed", name, option))
+			}
+		}
+
+		if replicas := takeMappingValue(service, "scale"); replicas != nil {
+			setMappingValue(deployNode(service), "replicas", replicas)
+		}
+
+		if restart := takeMappingValue(service, "restart"); restart != nil {
+			condition, maxAttempts, _ := strings.Cut(restart.Value, ":")
+
+			switch condition {
+			case "no":
+				condition = "none"
+			case "always":
+				condition = "any"
+			case "unless-stopped":
+				condition = "any"
+				warnings = append(warnings, fmt.Sprintf("service %q: the unless-stopped restart policy was replaced with the any condition", name))
+			}
+
+			restartPolicy := mappingNode()
+			setMappingValue(restartPolicy, "condition", scalarNode(condition))
+			if maxAttempts != "" {
+				setMappingValue(restartPolicy, "max_attempts", intNode(maxAttempts))
+			}
+
+			setMappingValue(deployNode(service), "restart_policy", restartPolicy)
+		}
+
+		for _, resource := range resourceOptions {
+			if value := takeMappingValue(service, resource.option); value != nil {
+				resources := childMapping(deployNode(service), "resources")
+				setMappingValue(childMapping(resources, resource.kind), resource.name, stringNode(value.Value))
+			}
+		}
+	})
+
+	forEachNetwork(root, func(name string, network *yaml.Node) {
+		if driver := mappingValue(network, "driver"); driver != nil && driver.Value == "bridge" {
+			driver.Value = "overlay"
+			warnings = append(warnings, fmt.Sprintf("network %q: the bridge driver was replaced with the overlay driver", name))
+		}
+	})
+
+	return warnings
+}
+
+func convertToCompose(root *yaml.Node) []string {
+	var warnings []string
+
+	forEachService(root, func(name string, service *yaml.Node) {
+		deploy := mappingValue(service, "deploy")
+		if deploy == nil || deploy.Kind != yaml.MappingNode {
+			return
+		}
+
+		for _, option := range swarmOnlyDeployOptions {
+			if deleteMappingKey(deploy, option) {
+				warnings = append(warnings, fmt.Sprintf("service %q: deploy.%s is not supported by Compose and was removed", name, option))
+			}
+		}
+
+		if mode := mappingValue(deploy, "mode"); mode != nil && mode.Value == "global" {
+			deleteMappingKey(deploy, "mode")
+			warnings = append(warnings, fmt.Sprintf("service %q: the global mode is not supported by Compose, a single container will be deployed", name))
+		}
+
+		if len(deploy.Content) == 0 {
+			deleteMappingKey(service, "deploy")
+		}
+	})
+
+	forEachNetwork(root, func(name string, network *yaml.Node) {
+		if driver := mappingValue(network, "driver"); driver != nil && driver.Value == "overlay" {
+			driver.Value = "bridge"
+			deleteMappingKey(network, "attachable")
+			warnings = append(warnings, fmt.Sprintf("network %q: the overlay driver was replaced with the bridge driver", name))
+		}
+	})
+
+	for _, kind := range []string{"secrets", "configs"} {
+		forEachEntry(root, kind, func(name string, entry *yaml.Node) {
+			if external := mappingValue(entry, "external"); external != nil && external.Value == "true" {
+				warnings = append(warnings, fmt.Sprintf("%s %q: the external %s are not supported by Compose", strings.TrimSuffix(kind, "s"), name, kind))
+			}
+		})
+	}
+
+	return warnings
+}
+
+func forEachService(root *yaml.Node, fn func(name string, service *yaml.Node)) {
+	forEachEntry(root, "services", fn)
+}
+
+func forEachNetwork(root *yaml.Node, fn func(name string, network *yaml.Node)) {
+	forEachEntry(root, "networks", fn)
+}
+
+// forEachEntry calls fn for every mapping under the key of the root mapping
+func forEachEntry(root *yaml.Node, key string, fn func(name string, entry *yaml.Node)) {
+	entries := mappingValue(root, key)
+	if entries == nil || entries.Kind != yaml.MappingNode {
+		return
+	}
+
+	for i := 0; i+1 < len(entries.Content); i += 2 {
+		if entry := entries.Content[i+1]; entry.Kind == yaml.MappingNode {
+			fn(entries.Content[i].Value, entry)
+		}
+	}
+}
+
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+
+	return nil
+}
+
+func setMappingValue(node *yaml.Node, key string, value *yaml.Node) {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			node.Content[i+1] = value
+			return
+		}
+	}
+
+	node.Content = append(node.Content, scalarNode(key), value)
+}
+
+// takeMappingValue removes a key from a mapping and returns its value
+func takeMappingValue(node *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			value := node.Content[i+1]
+			node.Content = append(node.Content[:i], node.Content[i+2:]...)
+
+			return value
+		}
+	}
+
+	return nil
+}
+
+func deleteMappingKey(node *yaml.Node, key string) bool {
+	return takeMappingValue(node, key) != nil
+}
+
+// childMapping returns the mapping under the key of a mapping, creating it when missing
+func childMapping(node *yaml.Node, key string) *yaml.Node {
+	child := mappingValue(node, key)
+	if child == nil || child.Kind != yaml.MappingNode {
+		child = mappingNode()
+		setMappingValue(node, key, child)
+	}
+
+	return child
+}
+
+func deployNode(service *yaml.Node) *yaml.Node {
+	return childMapping(service, "deploy")
+}
+
+func mappingNode() *yaml.Node {
+	return &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+}
+
+func scalarNode(value string) *yaml.Node {
+	return &yaml.Node{Kind: yaml.ScalarNode, Value: value}
+}
+
+func stringNode(value string) *yaml.Node {
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value}
+}
+
+func intNode(value string) *yaml.Node {
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!int", Value: value}
+}
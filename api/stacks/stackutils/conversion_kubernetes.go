@@ -0,0 +1,290 @@
+package stackutils
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/docker/cli/cli/compose/types"
+	"github.com/pkg/errors"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/yaml"
+)
+
+// kubernetesAppLabel is the label selecting the pods of the Deployment converted from a compose service
+const kubernetesAppLabel = "app.kubernetes.io/name"
+
+// kubernetesVolumeSize is the size requested by the PersistentVolumeClaims converted from the named volumes, which
+// do not have a size in compose files
+const kubernetesVolumeSize = "1Gi"
+
+var kubernetesNameInvalidCharacters = regexp.MustCompile(`[^a-z0-9-]+`)
+
+// convertToKubernetes converts a compose file into Kubernetes manifests: a Deployment for every service, a Service
+// for the services publishing ports and a PersistentVolumeClaim for every named volume
+func convertToKubernetes(content []byte) ([]byte, []string, error) {
+	config, err := loadComposeConfig(content)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "unable to parse the stack file")
+	}
+
+	var warnings []string
+	var objects []any
+
+	volumeNames := make([]string, 0, len(config.Volumes))
+	for name, volume := range config.Volumes {
+		if volume.External.External {
+			warnings = append(warnings, fmt.Sprintf("volume %q: the external volumes are expected to exist as PersistentVolumeClaims", name))
+			continue
+		}
+
+		volumeNames = append(volumeNames, name)
+	}
+
+	slices.Sort(volumeNames)
+
+	for _, name := range volumeNames {
+		objects = append(objects, &corev1.PersistentVolumeClaim{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "PersistentVolumeClaim"},
+			ObjectMeta: metav1.ObjectMeta{Name: kubernetesName(name)},
+			Spec: corev1.PersistentVolumeClaimSpec{
+				AccessModes: []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
+				Resources: corev1.VolumeResourceRequirements{
+					Requests: corev1.ResourceList{corev1.ResourceStorage: resource.MustParse(kubernetesVolumeSize)},
+				},
+			},
+		})
+	}
+
+	services := slices.Clone(config.Services)
+	slices.SortFunc(services, func(a, b types.ServiceConfig) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+
+	for _, service := range services {
+		if service.Image == "" {
+			return nil, nil, fmt.Errorf("service %q: an image is required, the images cannot be built on Kubernetes", service.Name)
+		}
+
+		deployment, serviceWarnings := convertServiceToDeployment(service)
+		warnings = append(warnings, serviceWarnings...)
+		objects = append(objects, deployment)
+
+		if len(service.Ports) > 0 {
+			objects = append(objects, convertServicePorts(service))
+		}
+	}
+
+	var buf bytes.Buffer
+	for i, object := range objects {
+		manifest, err := yaml.Marshal(object)
+		if err != nil {
+			return nil, nil, errors.Wrap(err, "unable to write the Kubernetes manifests")
+		}
+
+		if i > 0 {
+			buf.WriteString("---\n")
+		}
+
+		buf.Write(manifest)
+	}
+
+	return buf.Bytes(), warnings, nil
+}
+
+func convertServiceToDeployment(service types.ServiceConfig) (*appsv1.Deployment, []string) {
+	var warnings []string
+	warn := func(format string, args ...any) {
+		warnings = append(warnings, fmt.Sprintf("service %q: ", service.Name)+fmt.Sprintf(format, args...))
+	}
+
+	name := kubernetesName(service.Name)
+
+	container := corev1.Container{
+		Name:       name,
+		Image:      service.Image,
+		Command:    service.Entrypoint,
+		Args:       service.Command,
+		WorkingDir: service.WorkingDir,
+	}
+
+	envNames := make([]string, 0, len(service.Environment))
+	for envName := range service.Environment {
+		envNames = append(envNames, envName)
+	}
+
+	slices.Sort(envNames)
+
+	for _, envName := range envNames {
+		env := corev1.EnvVar{Name: envName}
+		if value := service.Environment[envName]; value != nil {
+			env.Value = *value
+		}
+
+		container.Env = append(container.Env, env)
+	}
+
+	for _, port := range service.Ports {
+		container.Ports = append(container.Ports, corev1.ContainerPort{
+			ContainerPort: int32(port.Target),
+			Protocol:      kubernetesProtocol(port.Protocol),
+		})
+	}
+
+	var volumes []corev1.Volume
+	for i, volume := range service.Volumes {
+		source := corev1.VolumeSource{}
+
+		switch {
+		case volume.Type == "volume" && volume.Source != "":
+			source.PersistentVolumeClaim = &corev1.PersistentVolumeClaimVolumeSource{ClaimName: kubernetesName(volume.Source)}
+		case volume.Type == "volume":
+			source.EmptyDir = &corev1.EmptyDirVolumeSource{}
+		case volume.Type == "tmpfs":
+			source.EmptyDir = &corev1.EmptyDirVolumeSource{Medium: corev1.StorageMediumMemory}
+		default:
+			warn("the %s mount of %s is not supported by Kubernetes and was removed", volume.Type, volume.Target)
+			continue
+		}
+
+		volumeName := fmt.Sprintf("%s-volume-%d", name, i)
+		volumes = append(volumes, corev1.Volume{Name: volumeName, VolumeSource: source})
+		container.VolumeMounts = append(container.VolumeMounts, corev1.VolumeMount{
+			Name:      volumeName,
+			MountPath: volume.Target,
+			ReadOnly:  volume.ReadOnly,
+		})
+	}
+
+	if limits := service.Deploy.Resources.Limits; limits != nil {
+		container.Resources.Limits = kubernetesResources(limits.NanoCPUs, limits.MemoryBytes)
+	}
+
+	if reservations := service.Deploy.Resources.Reservations; reservations != nil {
+		container.Resources.Requests = kubernetesResources(reservations.NanoCPUs, reservations.MemoryBytes)
+	}
+
+	if service.Privileged {
+		privileged := true
+		container.SecurityContext = &corev1.SecurityContext{Privileged: &privileged}
+	}
+
+	if service.Build.Context != "" {
+		warn("build is not supported by Kubernetes, the image %s must be pushed to a registry", service.Image)
+	}
+
+	if len(service.DependsOn) > 0 {
+		warn("depends_on is not supported by Kubernetes and was removed")
+	}
+
+	if len(service.Networks) > 0 {
+		warn("the networks are not supported by Kubernetes, the services can reach each other through their Service")
+	}
+
+	if len(service.Secrets) > 0 || len(service.Configs) > 0 {
+		warn("the secrets and configs are not converted, they must be created as Secrets and ConfigMaps")
+	}
+
+	if service.HealthCheck != nil {
+		warn("healthcheck is not converted, it must be replaced with probes")
+	}
+
+	if service.User != "" {
+		warn("user is not converted, it must be replaced with a security context")
+	}
+
+	if service.Restart != "" && service.Restart != "always" && service.Restart != "unless-stopped" {
+		warn("the %s restart policy is not supported by Deployments, the containers are always restarted", service.Restart)
+	}
+
+	replicas := int32(1)
+	if service.Deploy.Replicas != nil {
+		replicas = int32(*service.Deploy.Replicas)
+	}
+
+	if service.Deploy.Mode == "global" {
+		warn("the global mode is not supported by Deployments, it must be replaced with a DaemonSet")
+	}
+
+	labels := map[string]string{kubernetesAppLabel: name}
+
+	return &appsv1.Deployment{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "apps/v1", Kind: "Deployment"},
+		ObjectMeta: metav1.ObjectMeta{Name: name, Labels: labels},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &replicas,
+			Selector: &metav1.LabelSelector{MatchLabels: labels},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: labels},
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{container},
+					Volumes:    volumes,
+				},
+			},
+		},
+	}, warnings
+}
+
+// convertServicePorts creates the Service exposing the ports of a compose service, on their published port when set
+func convertServicePorts(service types.ServiceConfig) *corev1.Service {
+	name := kubernetesName(service.Name)
+
+	k8sService := &corev1.Service{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "Service"},
+		ObjectMeta: metav1.ObjectMeta{Name: name, Labels: map[string]string{kubernetesAppLabel: name}},
+		Spec: corev1.ServiceSpec{
+			Selector: map[string]string{kubernetesAppLabel: name},
+		},
+	}
+
+	for _, port := range service.Ports {
+		published := port.Published
+		if published == 0 {
+			published = port.Target
+		}
+
+		protocol := kubernetesProtocol(port.Protocol)
+
+		k8sService.Spec.Ports = append(k8sService.Spec.Ports, corev1.ServicePort{
+			Name:       fmt.Sprintf("%s-%d", strings.ToLower(string(protocol)), published),
+			Port:       int32(published),
+			TargetPort: intstr.FromInt32(int32(port.Target)),
+			Protocol:   protocol,
+		})
+	}
+
+	return k8sService
+}
+
+func kubernetesResources(cpus string, memory types.UnitBytes) corev1.ResourceList {
+	resources := corev1.ResourceList{}
+
+	if quantity, err := resource.ParseQuantity(cpus); cpus != "" && err == nil {
+		resources[corev1.ResourceCPU] = quantity
+	}
+
+	if memory > 0 {
+		resources[corev1.ResourceMemory] = *resource.NewQuantity(int64(memory), resource.BinarySI)
+	}
+
+	return resources
+}
+
+func kubernetesProtocol(protocol string) corev1.Protocol {
+	if protocol == "" {
+		return corev1.ProtocolTCP
+	}
+
+	return corev1.Protocol(strings.ToUpper(protocol))
+}
+
+// kubernetesName turns a compose service or volume name into a valid Kubernetes object name
+func kubernetesName(name string) string {
+	return strings.Trim(kubernetesNameInvalidCharacters.ReplaceAllString(strings.ToLower(name), "-"), "-")
+}
@@ -0,0 +1,195 @@
+package stackutils
+
+import (
+	"testing"
+
+	portainer "github.com/portainer/portainer/api"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_ConvertStackFile(t *testing.T) {
+	t.Run("compose to swarm", func(t *testing.T) {
+		content := `services:
+  web:
+    image: nginx
+    container_name: web
+    restart: on-failure:3
+    mem_limit: 512m
+    scale: 2
+networks:
+  front:
+    driver: bridge
+`
+
+		converted, warnings, err := ConvertStackFile([]byte(content), portainer.DockerSwarmStack)
+		require.NoError(t, err)
+
+		assert.Equal(t, `services:
+  web:
+    image: nginx
+    deploy:
+      replicas: 2
+      restart_policy:
+        condition: on-failure
+        max_attempts: 3
+      resources:
+        limits:
+          memory: 512m
+networks:
+  front:
+    driver: overlay
+version: "3.8"
+`, string(converted))
+
+		assert.Equal(t, []string{
+			"the compose file version was set to 3.8, required by Swarm",
+			`service "web": container_name is not supported by Swarm and was removed`,
+			`network "front": the bridge driver was replaced with the overlay driver`,
+		}, warnings)
+
+		require.NoError(t, IsValidStackFile(converted, &portainer.EndpointSecuritySettings{}))
+	})
+
+	t.Run("swarm to compose", func(t *testing.T) {
+		content := `version: "3.8"
+services:
+  agent:
+    image: portainer/agent
+    deploy:
+      mode: global
+      placement:
+        constraints: [node.platform.os == linux]
+secrets:
+  token:
+    external: true
+`
+
+		converted, warnings, err := ConvertStackFile([]byte(content), portainer.DockerComposeStack)
+		require.NoError(t, err)
+
+		assert.Equal(t, `version: "3.8"
+services:
+  agent:
+    image: portainer/agent
+secrets:
+  token:
+    external: true
+`, string(converted))
+
+		assert.Equal(t, []string{
+			`service "agent": deploy.placement is not supported by Compose and was removed`,
+			`service "agent": the global mode is not supported by Compose, a single container will be deployed`,
+			`secret "token": the external secrets are not supported by Compose`,
+		}, warnings)
+	})
+
+	t.Run("invalid stack file", func(t *testing.T) {
+		_, _, err := ConvertStackFile([]byte("- web"), portainer.DockerSwarmStack)
+		require.Error(t, err)
+	})
+}
+
+func Test_ConvertStackFileToKubernetes(t *testing.T) {
+	content := `version: "3.8"
+services:
+  web_app:
+    image: nginx
+    restart: on-failure
+    environment:
+      MODE: production
+    ports:
+      - "8080:80"
+    volumes:
+      - data:/usr/share/nginx/html
+      - ./conf:/etc/nginx/conf.d
+    deploy:
+      replicas: 2
+      resources:
+        limits:
+          memory: 256M
+volumes:
+  data:
+`
+
+	converted, warnings, err := ConvertStackFile([]byte(content), portainer.KubernetesStack)
+	require.NoError(t, err)
+
+	assert.Equal(t, `apiVersion: v1
+kind: PersistentVolumeClaim
+metadata:
+  creationTimestamp: null
+  name: data
+spec:
+  accessModes:
+  - ReadWriteOnce
+  resources:
+    requests:
+      storage: 1Gi
+status: {}
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  creationTimestamp: null
+  labels:
+    app.kubernetes.io/name: web-app
+  name: web-app
+spec:
+  replicas: 2
+  selector:
+    matchLabels:
+      app.kubernetes.io/name: web-app
+  strategy: {}
+  template:
+    metadata:
+      creationTimestamp: null
+      labels:
+        app.kubernetes.io/name: web-app
+    spec:
+      containers:
+      - env:
+        - name: MODE
+          value: production
+        image: nginx
+        name: web-app
+        ports:
+        - containerPort: 80
+          protocol: TCP
+        resources:
+          limits:
+            memory: 256Mi
+        volumeMounts:
+        - mountPath: /usr/share/nginx/html
+          name: web-app-volume-0
+      volumes:
+      - name: web-app-volume-0
+        persistentVolumeClaim:
+          claimName: data
+status: {}
+---
+apiVersion: v1
+kind: Service
+metadata:
+  creationTimestamp: null
+  labels:
+    app.kubernetes.io/name: web-app
+  name: web-app
+spec:
+  ports:
+  - name: tcp-8080
+    port: 8080
+    protocol: TCP
+    targetPort: 80
+  selector:
+    app.kubernetes.io/name: web-app
+status:
+  loadBalancer: {}
+`, string(converted))
+
+	assert.Equal(t, []string{
+		`service "web_app": the bind mount of /etc/nginx/conf.d is not supported by Kubernetes and was removed`,
+		`service "web_app": the on-failure restart policy is not supported by Deployments, the containers are always restarted`,
+	}, warnings)
+}
@@ -0,0 +1,72 @@
+package stackutils
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+// OverrideImageTags replaces the tag of the images of the services of a Compose or Swarm stack file, tags being the
+// new tags by service name. The digest of an image is removed along with its tag
+func OverrideImageTags(content []byte, tags map[string]string) ([]byte, error) {
+	if len(tags) == 0 {
+		return content, nil
+	}
+
+	var document yaml.Node
+	if err := yaml.Unmarshal(content, &document); err != nil {
+		return nil, errors.Wrap(err, "unable to parse the stack file")
+	}
+
+	if document.Kind != yaml.DocumentNode || len(document.Content) == 0 || document.Content[0].Kind != yaml.MappingNode {
+		return nil, errors.New("the stack file must be a YAML mapping")
+	}
+
+	overridden := make(map[string]bool, len(tags))
+	forEachService(document.Content[0], func(name string, service *yaml.Node) {
+		tag, ok := tags[name]
+		if !ok {
+			return
+		}
+
+		if image := mappingValue(service, "image"); image != nil && image.Kind == yaml.ScalarNode {
+			image.Value = ImageWithTag(image.Value, tag)
+			overridden[name] = true
+		}
+	})
+
+	for name := range tags {
+		if !overridden[name] {
+			return nil, fmt.Errorf("the stack has no service %q with an image", name)
+		}
+	}
+
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+
+	if err := encoder.Encode(&document); err != nil {
+		return nil, errors.Wrap(err, "unable to write the stack file")
+	}
+
+	if err := encoder.Close(); err != nil {
+		return nil, errors.Wrap(err, "unable to write the stack file")
+	}
+
+	return buf.Bytes(), nil
+}
+
+// ImageWithTag returns the image reference with the given tag, in place of its tag and digest
+func ImageWithTag(image, tag string) string {
+	image, _, _ = strings.Cut(image, "@")
+
+	// the colon of a registry port is followed by a slash
+	if i := strings.LastIndex(image, ":"); i > strings.LastIndex(image, "/") {
+		image = image[:i]
+	}
+
+	return image + ":" + tag
+}
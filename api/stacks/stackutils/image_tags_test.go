@@ -0,0 +1,39 @@
+package stackutils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_OverrideImageTags(t *testing.T) {
+	content := `services:
+  web:
+    image: registry.mydomain.tld:5000/acme/web:1.0
+  db:
+    # the database is upgraded by hand
+    image: postgres:16
+`
+
+	overridden, err := OverrideImageTags([]byte(content), map[string]string{"web": "1.1"})
+	require.NoError(t, err)
+
+	assert.Equal(t, `services:
+  web:
+    image: registry.mydomain.tld:5000/acme/web:1.1
+  db:
+    # the database is upgraded by hand
+    image: postgres:16
+`, string(overridden))
+
+	_, err = OverrideImageTags([]byte(content), map[string]string{"cache": "7"})
+	assert.Error(t, err)
+}
+
+func Test_ImageWithTag(t *testing.T) {
+	assert.Equal(t, "nginx:1.27", ImageWithTag("nginx", "1.27"))
+	assert.Equal(t, "nginx:1.27", ImageWithTag("nginx:1.25", "1.27"))
+	assert.Equal(t, "localhost:5000/nginx:1.27", ImageWithTag("localhost:5000/nginx", "1.27"))
+	assert.Equal(t, "nginx:1.27", ImageWithTag("nginx:1.25@sha256:0123456789abcdef", "1.27"))
+}
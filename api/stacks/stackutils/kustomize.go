@@ -0,0 +1,87 @@
+package stackutils
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"slices"
+	"strings"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/filesystem"
+)
+
+// kustomizationFileNames are the names kustomize recognizes for the kustomization of a directory
+var kustomizationFileNames = []string{"kustomization.yaml", "kustomization.yml", "Kustomization"}
+
+// KustomizationDir returns the directory of the kustomization of a Kubernetes git stack, relative to its project path.
+// The entry point of the stack is either a kustomization file or a directory holding one
+func KustomizationDir(stack *portainer.Stack) (string, bool) {
+	if stack.Type != portainer.KubernetesStack || !IsGitStack(stack) {
+		return "", false
+	}
+
+	entryPoint := path.Clean(strings.TrimSpace(stack.EntryPoint))
+	if slices.Contains(kustomizationFileNames, path.Base(entryPoint)) {
+		return path.Dir(entryPoint), true
+	}
+
+	for _, name := range kustomizationFileNames {
+		if exists, _ := filesystem.FileExists(filesystem.JoinPaths(stack.ProjectPath, entryPoint, name)); exists {
+			return entryPoint, true
+		}
+	}
+
+	return "", false
+}
+
+// IsKustomizeStack checks if a stack is a Kubernetes git stack deployed with kustomize
+func IsKustomizeStack(stack *portainer.Stack) bool {
+	_, ok := KustomizationDir(stack)
+
+	return ok
+}
+
+// ValidateKustomizeOverlay checks that an overlay is a relative path that stays inside the repository of the stack
+func ValidateKustomizeOverlay(overlay string) error {
+	if overlay == "" {
+		return nil
+	}
+
+	if path.IsAbs(overlay) || strings.HasPrefix(overlay, `\`) {
+		return errors.New("the kustomize overlay must be a relative path")
+	}
+
+	if slices.Contains(strings.FieldsFunc(overlay, func(r rune) bool { return r == '/' || r == '\\' }), "..") {
+		return errors.New("the kustomize overlay cannot reference a parent directory")
+	}
+
+	return nil
+}
+
+// KustomizeBuildDir returns the absolute directory kustomize builds for a stack: its overlay, whose ${NAME} references
+// to the variables of the environment(endpoint) are expanded, or the directory of its kustomization without overlay
+func KustomizeBuildDir(stack *portainer.Stack, variables []portainer.Pair) (string, error) {
+	dir, ok := KustomizationDir(stack)
+	if !ok {
+		return "", errors.New("the stack has no kustomization")
+	}
+
+	if stack.KustomizeOverlay == "" {
+		return filesystem.JoinPaths(stack.ProjectPath, dir), nil
+	}
+
+	overlay := string(ExpandVariables([]byte(stack.KustomizeOverlay), variables))
+	if err := ValidateKustomizeOverlay(overlay); err != nil {
+		return "", err
+	}
+
+	buildDir := filesystem.JoinPaths(stack.ProjectPath, overlay)
+
+	if info, err := os.Stat(buildDir); err != nil || !info.IsDir() {
+		return "", fmt.Errorf("the kustomize overlay %s is not a directory of the repository", overlay)
+	}
+
+	return buildDir, nil
+}
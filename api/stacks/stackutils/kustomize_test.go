@@ -0,0 +1,70 @@
+package stackutils
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	portainer "github.com/portainer/portainer/api"
+	gittypes "github.com/portainer/portainer/api/git/types"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_KustomizeBuildDir(t *testing.T) {
+	is := require.New(t)
+
+	projectPath := t.TempDir()
+	for _, dir := range []string{"base", "overlays/production", "overlays/staging"} {
+		is.NoError(os.MkdirAll(filepath.Join(projectPath, dir), 0o700))
+		is.NoError(os.WriteFile(filepath.Join(projectPath, dir, "kustomization.yaml"), []byte("resources: []\n"), 0o600))
+	}
+
+	stack := &portainer.Stack{
+		Type:        portainer.KubernetesStack,
+		ProjectPath: projectPath,
+		EntryPoint:  "base/kustomization.yaml",
+		GitConfig:   &gittypes.RepoConfig{URL: "https://github.com/portainer/k8s-manifests"},
+	}
+
+	dir, ok := KustomizationDir(stack)
+	is.True(ok)
+	is.Equal("base", dir)
+
+	buildDir, err := KustomizeBuildDir(stack, nil)
+	is.NoError(err)
+	is.Equal(filepath.Join(projectPath, "base"), buildDir)
+
+	// the entry point can be the directory of the kustomization
+	stack.EntryPoint = "base"
+	is.True(IsKustomizeStack(stack))
+
+	// the overlay is selected with the variables of the environment
+	stack.KustomizeOverlay = "overlays/${ENVIRONMENT}"
+	buildDir, err = KustomizeBuildDir(stack, []portainer.Pair{{Name: "ENVIRONMENT", Value: "staging"}})
+	is.NoError(err)
+	is.Equal(filepath.Join(projectPath, "overlays/staging"), buildDir)
+
+	_, err = KustomizeBuildDir(stack, []portainer.Pair{{Name: "ENVIRONMENT", Value: "../../etc"}})
+	is.Error(err)
+
+	_, err = KustomizeBuildDir(stack, nil)
+	is.Error(err, "the overlay must exist")
+
+	stack.EntryPoint = "base/deployment.yaml"
+	is.False(IsKustomizeStack(stack))
+
+	stack.EntryPoint = "base/kustomization.yaml"
+	stack.GitConfig = nil
+	is.False(IsKustomizeStack(stack), "only the git stacks are built with kustomize")
+}
+
+func Test_ValidateKustomizeOverlay(t *testing.T) {
+	is := require.New(t)
+
+	is.NoError(ValidateKustomizeOverlay(""))
+	is.NoError(ValidateKustomizeOverlay("overlays/production"))
+	is.Error(ValidateKustomizeOverlay("/etc"))
+	is.Error(ValidateKustomizeOverlay("overlays/../../etc"))
+	is.Error(ValidateKustomizeOverlay(`overlays\..\..\etc`))
+}
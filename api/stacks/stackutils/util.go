@@ -50,9 +50,8 @@ func IsGitStack(stack *portainer.Stack) bool {
 	return stack.GitConfig != nil && len(stack.GitConfig.URL) != 0
 }
 
-// IsRelativePathStack checks if the stack is a git stack or not
+// IsRelativePathStack checks if the stack is a git stack deployed from a clone of its repository kept on its
+// environment, so that the relative paths of its bind mounts resolve inside the repository
 func IsRelativePathStack(stack *portainer.Stack) bool {
-	// Always return false in CE
-	// This function is only for code consistency with EE
-	return false
+	return IsGitStack(stack) && stack.SupportRelativePath && stack.FilesystemPath != ""
 }
@@ -4,6 +4,7 @@ import (
 	"testing"
 
 	portainer "github.com/portainer/portainer/api"
+	gittypes "github.com/portainer/portainer/api/git/types"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -24,3 +25,14 @@ func Test_GetStackFilePaths(t *testing.T) {
 		assert.ElementsMatch(t, expected, GetStackFilePaths(stack, true))
 	})
 }
+
+func Test_IsRelativePathStack(t *testing.T) {
+	stack := &portainer.Stack{SupportRelativePath: true, FilesystemPath: "/opt/portainer"}
+	assert.False(t, IsRelativePathStack(stack), "only the git stacks are deployed from a clone on the environment")
+
+	stack.GitConfig = &gittypes.RepoConfig{URL: "https://github.com/portainer/portainer-compose"}
+	assert.True(t, IsRelativePathStack(stack))
+
+	stack.FilesystemPath = ""
+	assert.False(t, IsRelativePathStack(stack))
+}
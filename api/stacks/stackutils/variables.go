@@ -0,0 +1,108 @@
+package stackutils
+
+import (
+	"fmt"
+	"regexp"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+)
+
+var variableNameRegex = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// variableReferenceRegex matches the ${NAME} references of the Kubernetes manifests
+var variableReferenceRegex = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// ValidateVariables returns an error when a variable of an environment(endpoint) or of a group has an invalid or a
+// duplicated name
+func ValidateVariables(variables []portainer.Pair) error {
+	names := make(map[string]bool, len(variables))
+	for _, variable := range variables {
+		if !variableNameRegex.MatchString(variable.Name) {
+			return fmt.Errorf("invalid variable name %q", variable.Name)
+		}
+
+		if names[variable.Name] {
+			return fmt.Errorf("duplicated variable %q", variable.Name)
+		}
+
+		names[variable.Name] = true
+	}
+
+	return nil
+}
+
+// MergeEnv returns the variables of base overridden by the variables of override with the same name
+func MergeEnv(base, override []portainer.Pair) []portainer.Pair {
+	merged := make([]portainer.Pair, 0, len(base)+len(override))
+	index := make(map[string]int, len(base)+len(override))
+
+	for _, variables := range [][]portainer.Pair{base, override} {
+		for _, variable := range variables {
+			if i, ok := index[variable.Name]; ok {
+				merged[i].Value = variable.Value
+
+				continue
+			}
+
+			index[variable.Name] = len(merged)
+			merged = append(merged, variable)
+		}
+	}
+
+	return merged
+}
+
+// EndpointVariables returns the variables merged into the stacks deployed to an environment(endpoint): the variables
+// of its group, overridden by its own ones
+func EndpointVariables(tx dataservices.DataStoreTx, endpoint *portainer.Endpoint) ([]portainer.Pair, error) {
+	var groupVariables []portainer.Pair
+
+	group, err := tx.EndpointGroup().Read(endpoint.GroupID)
+	if err != nil && !tx.IsErrObjectNotFound(err) {
+		return nil, fmt.Errorf("unable to retrieve the group of the environment: %w", err)
+	} else if err == nil {
+		groupVariables = group.Variables
+	}
+
+	return MergeEnv(groupVariables, endpoint.Variables), nil
+}
+
+// WithEndpointVariables returns a copy of the stack whose env holds the variables of the environment(endpoint) it
+// is deployed to, overridden by its own env. The stack itself is left untouched
+func WithEndpointVariables(tx dataservices.DataStoreTx, stack *portainer.Stack, endpoint *portainer.Endpoint) (*portainer.Stack, error) {
+	variables, err := EndpointVariables(tx, endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(variables) == 0 {
+		return stack, nil
+	}
+
+	deployed := *stack
+	deployed.Env = MergeEnv(variables, stack.Env)
+
+	return &deployed, nil
+}
+
+// ExpandVariables replaces the ${NAME} references to the given variables in a manifest, the references to the other
+// variables are kept as they are
+func ExpandVariables(content []byte, variables []portainer.Pair) []byte {
+	if len(variables) == 0 {
+		return content
+	}
+
+	values := make(map[string]string, len(variables))
+	for _, variable := range variables {
+		values[variable.Name] = variable.Value
+	}
+
+	return variableReferenceRegex.ReplaceAllFunc(content, func(reference []byte) []byte {
+		if value, ok := values[string(reference[2:len(reference)-1])]; ok {
+			return []byte(value)
+		}
+
+		return reference
+	})
+}
@@ -0,0 +1,38 @@
+package stackutils
+
+import (
+	"testing"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ValidateVariables(t *testing.T) {
+	assert.NoError(t, ValidateVariables([]portainer.Pair{{Name: "REGION", Value: "eu-west-1"}, {Name: "_HOST2", Value: ""}}))
+	assert.Error(t, ValidateVariables([]portainer.Pair{{Name: "2REGION"}}))
+	assert.Error(t, ValidateVariables([]portainer.Pair{{Name: "INGRESS-HOST"}}))
+	assert.Error(t, ValidateVariables([]portainer.Pair{{Name: "REGION"}, {Name: "REGION"}}))
+}
+
+func Test_MergeEnv(t *testing.T) {
+	base := []portainer.Pair{{Name: "REGION", Value: "eu-west-1"}, {Name: "INGRESS_HOST", Value: "eu.example.com"}}
+	override := []portainer.Pair{{Name: "INGRESS_HOST", Value: "app.example.com"}, {Name: "REPLICAS", Value: "3"}}
+
+	expected := []portainer.Pair{
+		{Name: "REGION", Value: "eu-west-1"},
+		{Name: "INGRESS_HOST", Value: "app.example.com"},
+		{Name: "REPLICAS", Value: "3"},
+	}
+	assert.Equal(t, expected, MergeEnv(base, override))
+
+	// the given variables are left untouched
+	assert.Equal(t, "eu.example.com", base[1].Value)
+}
+
+func Test_ExpandVariables(t *testing.T) {
+	content := []byte("host: ${INGRESS_HOST}\nregion: ${REGION}\nimage: ${IMAGE}\nport: $PORT\n")
+	variables := []portainer.Pair{{Name: "INGRESS_HOST", Value: "app.example.com"}, {Name: "REGION", Value: "eu-west-1"}}
+
+	expected := "host: app.example.com\nregion: eu-west-1\nimage: ${IMAGE}\nport: $PORT\n"
+	assert.Equal(t, expected, string(ExpandVariables(content, variables)))
+}
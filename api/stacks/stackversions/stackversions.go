@@ -0,0 +1,208 @@
+// Package stackversions keeps the history of the content the stacks are deployed with, so that a stack can be
+// compared with or rolled back to one of its previous versions
+package stackversions
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+	"github.com/portainer/portainer/api/filesystem"
+
+	"github.com/pmezard/go-difflib/difflib"
+	"github.com/rs/zerolog/log"
+)
+
+// MaxVersions is the number of versions kept in the history of a stack, the oldest ones are removed first
+const MaxVersions = 20
+
+// ErrVersionNotFound is returned when the history of a stack does not have the requested version
+var ErrVersionNotFound = errors.New("the stack has no such version")
+
+// EnvChange represents an environment variable added, removed or changed between two versions of a stack
+type EnvChange struct {
+	Name string `json:"Name" example:"PORT"`
+	// Value in the compared version, empty when the variable is added
+	From string `json:"From" example:"8080"`
+	// Value in the version compared to, empty when the variable is removed
+	To string `json:"To" example:"9090"`
+}
+
+// Diff represents the changes between two versions of a stack
+type Diff struct {
+	From int `json:"From" example:"2"`
+	To   int `json:"To" example:"3"`
+	// Unified diff of the stack file, empty when it did not change
+	StackFileDiff string      `json:"StackFileDiff"`
+	EnvChanges    []EnvChange `json:"EnvChanges"`
+}
+
+// Record adds the current content of the stack to its history, unless it is the same as the latest version. The
+// content is read from the entry point of the stack, rollbackOf is the number of the version restored by a rollback
+func Record(tx dataservices.DataStoreTx, stack *portainer.Stack, username string, rollbackOf int) (*portainer.StackVersion, error) {
+	content, err := os.ReadFile(filesystem.JoinPaths(stack.ProjectPath, stack.EntryPoint))
+	if err != nil {
+		return nil, fmt.Errorf("unable to read the stack file: %w", err)
+	}
+
+	versions, err := tx.StackVersion().VersionsByStackID(stack.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	version := &portainer.StackVersion{
+		StackID:          stack.ID,
+		Version:          1,
+		StackFileContent: string(content),
+		Env:              slices.Clone(stack.Env),
+		RollbackOf:       rollbackOf,
+		CreatedAt:        time.Now().Unix(),
+		CreatedBy:        username,
+	}
+
+	if stack.GitConfig != nil {
+		version.GitCommit = stack.GitConfig.ConfigHash
+	}
+
+	if len(versions) > 0 {
+		latest := versions[0]
+		if latest.StackFileContent == version.StackFileContent && latest.GitCommit == version.GitCommit && slices.Equal(latest.Env, version.Env) {
+			return &latest, nil
+		}
+
+		version.Version = latest.Version + 1
+	}
+
+	if err := tx.StackVersion().Create(version); err != nil {
+		return nil, err
+	}
+
+	// the history keeps the new version and the most recent previous ones
+	for _, previous := range versions[min(len(versions), MaxVersions-1):] {
+		if err := tx.StackVersion().Delete(previous.ID); err != nil {
+			return nil, err
+		}
+	}
+
+	return version, nil
+}
+
+// RecordStack adds the current content of the stack to its history, a failure is only logged so that it does not
+// fail the deployment of the stack
+func RecordStack(dataStore dataservices.DataStore, stack *portainer.Stack, username string) {
+	if err := dataStore.UpdateTx(func(tx dataservices.DataStoreTx) error {
+		_, err := Record(tx, stack, username, 0)
+
+		return err
+	}); err != nil {
+		log.Warn().Err(err).Int("stack_id", int(stack.ID)).Msg("unable to record the version of the stack")
+	}
+}
+
+// Find returns the version of a stack with the given number
+func Find(tx dataservices.DataStoreTx, stackID portainer.StackID, number int) (*portainer.StackVersion, error) {
+	versions, err := tx.StackVersion().VersionsByStackID(stackID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, version := range versions {
+		if version.Version == number {
+			return &version, nil
+		}
+	}
+
+	return nil, ErrVersionNotFound
+}
+
+// RemoveHistory removes all the versions of a stack
+func RemoveHistory(tx dataservices.DataStoreTx, stackID portainer.StackID) error {
+	versions, err := tx.StackVersion().VersionsByStackID(stackID)
+	if err != nil {
+		return err
+	}
+
+	for _, version := range versions {
+		if err := tx.StackVersion().Delete(version.ID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// NewDiff returns the changes made to a stack from a version to another one
+func NewDiff(from, to *portainer.StackVersion) (*Diff, error) {
+	fileDiff, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        splitLines(from.StackFileContent),
+		B:        splitLines(to.StackFileContent),
+		FromFile: "version " + strconv.Itoa(from.Version),
+		ToFile:   "version " + strconv.Itoa(to.Version),
+		Context:  3,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &Diff{
+		From:          from.Version,
+		To:            to.Version,
+		StackFileDiff: fileDiff,
+		EnvChanges:    envChanges(from.Env, to.Env),
+	}, nil
+}
+
+func envChanges(from, to []portainer.Pair) []EnvChange {
+	values := func(pairs []portainer.Pair) map[string]string {
+		m := make(map[string]string, len(pairs))
+		for _, pair := range pairs {
+			m[pair.Name] = pair.Value
+		}
+
+		return m
+	}
+
+	fromValues, toValues := values(from), values(to)
+
+	changes := make([]EnvChange, 0)
+	for name, value := range fromValues {
+		if toValue, ok := toValues[name]; !ok || toValue != value {
+			changes = append(changes, EnvChange{Name: name, From: value, To: toValue})
+		}
+	}
+
+	for name, value := range toValues {
+		if _, ok := fromValues[name]; !ok {
+			changes = append(changes, EnvChange{Name: name, To: value})
+		}
+	}
+
+	slices.SortFunc(changes, func(a, b EnvChange) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+
+	return changes
+}
+
+// splitLines splits a content into lines ending with a line break, the last line is terminated so that it stays on
+// its own in the diff
+func splitLines(content string) []string {
+	if content == "" {
+		return nil
+	}
+
+	lines := strings.SplitAfter(content, "\n")
+	if last := lines[len(lines)-1]; last == "" {
+		lines = lines[:len(lines)-1]
+	} else {
+		lines[len(lines)-1] = last + "\n"
+	}
+
+	return lines
+}
@@ -0,0 +1,106 @@
+package stackversions
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+	"github.com/portainer/portainer/api/datastore"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecord(t *testing.T) {
+	is := require.New(t)
+
+	_, store := datastore.MustNewTestStore(t, true, false)
+
+	stack := &portainer.Stack{ID: 1, ProjectPath: t.TempDir(), EntryPoint: "docker-compose.yml"}
+
+	record := func(content string, env ...portainer.Pair) *portainer.StackVersion {
+		is.NoError(os.WriteFile(filepath.Join(stack.ProjectPath, stack.EntryPoint), []byte(content), 0o600))
+		stack.Env = env
+
+		var version *portainer.StackVersion
+		is.NoError(store.UpdateTx(func(tx dataservices.DataStoreTx) (err error) {
+			version, err = Record(tx, stack, "admin", 0)
+
+			return err
+		}))
+
+		return version
+	}
+
+	is.Equal(1, record("services: {}\n").Version)
+
+	// the same content is not recorded twice
+	is.Equal(1, record("services: {}\n").Version)
+
+	// a change of the environment variables is a new version
+	is.Equal(2, record("services: {}\n", portainer.Pair{Name: "PORT", Value: "8080"}).Version)
+
+	for i := range MaxVersions {
+		record("services: {}\n# " + strconv.Itoa(i) + "\n")
+	}
+
+	versions, err := store.StackVersion().VersionsByStackID(stack.ID)
+	is.NoError(err)
+	is.Len(versions, MaxVersions)
+	is.Equal(MaxVersions+2, versions[0].Version)
+	is.Equal(3, versions[MaxVersions-1].Version)
+
+	is.NoError(store.ViewTx(func(tx dataservices.DataStoreTx) error {
+		_, err := Find(tx, stack.ID, 1)
+		is.ErrorIs(err, ErrVersionNotFound)
+
+		version, err := Find(tx, stack.ID, 3)
+		is.NoError(err)
+		is.Equal("services: {}\n# 0\n", version.StackFileContent)
+
+		return nil
+	}))
+
+	is.NoError(store.UpdateTx(func(tx dataservices.DataStoreTx) error {
+		return RemoveHistory(tx, stack.ID)
+	}))
+
+	versions, err = store.StackVersion().VersionsByStackID(stack.ID)
+	is.NoError(err)
+	is.Empty(versions)
+}
+
+func TestNewDiff(t *testing.T) {
+	is := require.New(t)
+
+	from := &portainer.StackVersion{
+		Version:          1,
+		StackFileContent: "services:\n  web:\n    image: nginx:1.25\n",
+		Env:              []portainer.Pair{{Name: "PORT", Value: "8080"}, {Name: "DEBUG", Value: "true"}},
+	}
+	to := &portainer.StackVersion{
+		Version:          2,
+		StackFileContent: "services:\n  web:\n    image: nginx:1.27\n",
+		Env:              []portainer.Pair{{Name: "PORT", Value: "9090"}, {Name: "TZ", Value: "UTC"}},
+	}
+
+	diff, err := NewDiff(from, to)
+	is.NoError(err)
+	is.Equal(1, diff.From)
+	is.Equal(2, diff.To)
+	is.Contains(diff.StackFileDiff, "--- version 1")
+	is.Contains(diff.StackFileDiff, "-    image: nginx:1.25\n")
+	is.Contains(diff.StackFileDiff, "+    image: nginx:1.27\n")
+	is.Equal([]EnvChange{
+		{Name: "DEBUG", From: "true"},
+		{Name: "PORT", From: "8080", To: "9090"},
+		{Name: "TZ", To: "UTC"},
+	}, diff.EnvChanges)
+
+	diff, err = NewDiff(from, from)
+	is.NoError(err)
+	is.Empty(diff.StackFileDiff)
+	is.Empty(diff.EnvChanges)
+}
@@ -0,0 +1,199 @@
+// Package templatevariables validates the variables declared by the custom templates, describes them as a JSON
+// schema and substitutes their values into the template files when the templates are deployed
+package templatevariables
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+
+	portainer "github.com/portainer/portainer/api"
+)
+
+var (
+	nameRegex      = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_.-]*$`)
+	referenceRegex = regexp.MustCompile(`\{\{\s*([A-Za-z_][A-Za-z0-9_.-]*)\s*\}\}`)
+)
+
+// ValidateDefinitions checks that the variables of a custom template are well formed and that their default values
+// are valid
+func ValidateDefinitions(definitions []portainer.CustomTemplateVariableDefinition) error {
+	names := make(map[string]bool, len(definitions))
+
+	for _, definition := range definitions {
+		if definition.Name == "" {
+			return errors.New("variable name is required")
+		}
+
+		if !nameRegex.MatchString(definition.Name) {
+			return fmt.Errorf("invalid variable name %s, it must start with a letter or an underscore followed by letters, digits, _, . or -", definition.Name)
+		}
+
+		if names[definition.Name] {
+			return fmt.Errorf("the variable %s is declared more than once", definition.Name)
+		}
+		names[definition.Name] = true
+
+		if definition.Label == "" {
+			return errors.New("variable label is required")
+		}
+
+		switch definition.Type {
+		case "", portainer.CustomTemplateVariableString, portainer.CustomTemplateVariableNumber, portainer.CustomTemplateVariableBoolean:
+		default:
+			return fmt.Errorf("variable %s: invalid type, must be string, number or boolean", definition.Name)
+		}
+
+		if definition.Validation != "" {
+			if _, err := regexp.Compile(definition.Validation); err != nil {
+				return fmt.Errorf("variable %s: invalid validation regular expression: %w", definition.Name, err)
+			}
+		}
+
+		if definition.DefaultValue != "" {
+			if err := validateValue(definition, definition.DefaultValue); err != nil {
+				return fmt.Errorf("variable %s: invalid default value: %w", definition.Name, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// Resolve returns the value of each variable of a custom template: the given value or its default value. It fails
+// when a value is missing, is not valid or is given for an undeclared variable
+func Resolve(definitions []portainer.CustomTemplateVariableDefinition, values map[string]string) (map[string]string, error) {
+	resolved := make(map[string]string, len(definitions))
+
+	for _, definition := range definitions {
+		value, ok := values[definition.Name]
+		if !ok {
+			value = definition.DefaultValue
+		}
+
+		if value == "" && !ok {
+			return nil, fmt.Errorf("missing value for the variable %s", definition.Name)
+		}
+
+		if err := validateValue(definition, value); err != nil {
+			return nil, fmt.Errorf("invalid value for the variable %s: %w", definition.Name, err)
+		}
+
+		resolved[definition.Name] = value
+	}
+
+	for name := range values {
+		if _, ok := resolved[name]; !ok {
+			return nil, fmt.Errorf("the variable %s is not declared by the template", name)
+		}
+	}
+
+	return resolved, nil
+}
+
+func validateValue(definition portainer.CustomTemplateVariableDefinition, value string) error {
+	switch definition.Type {
+	case portainer.CustomTemplateVariableNumber:
+		if _, err := strconv.ParseFloat(value, 64); err != nil {
+			return errors.New("the value must be a number")
+		}
+	case portainer.CustomTemplateVariableBoolean:
+		if value != "true" && value != "false" {
+			return errors.New("the value must be true or false")
+		}
+	}
+
+	if definition.Validation != "" {
+		matched, err := regexp.MatchString(definition.Validation, value)
+		if err != nil {
+			return err
+		} else if !matched {
+			return fmt.Errorf("the value does not match %s", definition.Validation)
+		}
+	}
+
+	return nil
+}
+
+// Render replaces the {{ name }} references to the given variables in a template file, the references to the other
+// names are kept as they are
+func Render(content []byte, values map[string]string) []byte {
+	return referenceRegex.ReplaceAllFunc(content, func(reference []byte) []byte {
+		name := referenceRegex.FindSubmatch(reference)[1]
+		if value, ok := values[string(name)]; ok {
+			return []byte(value)
+		}
+
+		return reference
+	})
+}
+
+// Schema represents the variables of a custom template as a JSON schema
+type Schema struct {
+	Schema     string              `json:"$schema" example:"https://json-schema.org/draft/2020-12/schema"`
+	Type       string              `json:"type" example:"object"`
+	Properties map[string]Property `json:"properties"`
+	// Variables without default value
+	Required []string `json:"required"`
+	// Names of the variables in their declaration order
+	Order []string `json:"x-order"`
+}
+
+// Property represents a variable of a custom template in a JSON schema
+type Property struct {
+	Type        string `json:"type" example:"string"`
+	Title       string `json:"title" example:"My Variable"`
+	Description string `json:"description,omitempty" example:"Description"`
+	Default     any    `json:"default,omitempty"`
+	Pattern     string `json:"pattern,omitempty" example:"^[a-z0-9-]+$"`
+}
+
+// NewSchema returns the JSON schema of the variables of a custom template
+func NewSchema(definitions []portainer.CustomTemplateVariableDefinition) Schema {
+	schema := Schema{
+		Schema:     "https://json-schema.org/draft/2020-12/schema",
+		Type:       "object",
+		Properties: make(map[string]Property, len(definitions)),
+		Required:   []string{},
+		Order:      make([]string, 0, len(definitions)),
+	}
+
+	for _, definition := range definitions {
+		property := Property{
+			Type:        string(portainer.CustomTemplateVariableString),
+			Title:       definition.Label,
+			Description: definition.Description,
+			Pattern:     definition.Validation,
+		}
+
+		if definition.Type != "" {
+			property.Type = string(definition.Type)
+		}
+
+		if definition.DefaultValue != "" {
+			property.Default = typedValue(definition.Type, definition.DefaultValue)
+		} else {
+			schema.Required = append(schema.Required, definition.Name)
+		}
+
+		schema.Properties[definition.Name] = property
+		schema.Order = append(schema.Order, definition.Name)
+	}
+
+	return schema
+}
+
+// typedValue returns a valid value as the JSON type of its variable
+func typedValue(variableType portainer.CustomTemplateVariableType, value string) any {
+	switch variableType {
+	case portainer.CustomTemplateVariableNumber:
+		if number, err := strconv.ParseFloat(value, 64); err == nil {
+			return number
+		}
+	case portainer.CustomTemplateVariableBoolean:
+		return value == "true"
+	}
+
+	return value
+}
@@ -0,0 +1,80 @@
+package templatevariables
+
+import (
+	"testing"
+
+	portainer "github.com/portainer/portainer/api"
+
+	"github.com/segmentio/encoding/json"
+	"github.com/stretchr/testify/require"
+)
+
+var definitions = []portainer.CustomTemplateVariableDefinition{
+	{Name: "image", Label: "Image", DefaultValue: "nginx:latest"},
+	{Name: "replicas", Label: "Replicas", Type: portainer.CustomTemplateVariableNumber, DefaultValue: "2"},
+	{Name: "debug", Label: "Debug", Type: portainer.CustomTemplateVariableBoolean, DefaultValue: "false"},
+	{Name: "domain", Label: "Domain", Description: "Public domain", Validation: `^[a-z0-9.-]+$`},
+}
+
+func TestValidateDefinitions(t *testing.T) {
+	is := require.New(t)
+
+	is.NoError(ValidateDefinitions(definitions))
+	is.NoError(ValidateDefinitions(nil))
+
+	for _, definition := range []portainer.CustomTemplateVariableDefinition{
+		{Label: "Image"},
+		{Name: "image"},
+		{Name: "my image", Label: "Image"},
+		{Name: "image", Label: "Image", Type: "list"},
+		{Name: "image", Label: "Image", Validation: "[a-z"},
+		{Name: "replicas", Label: "Replicas", Type: portainer.CustomTemplateVariableNumber, DefaultValue: "two"},
+		{Name: "domain", Label: "Domain", Validation: `^[a-z]+$`, DefaultValue: "example.com"},
+	} {
+		is.Error(ValidateDefinitions([]portainer.CustomTemplateVariableDefinition{definition}), "definition %+v", definition)
+	}
+
+	is.Error(ValidateDefinitions(append(definitions, definitions[0])), "the names must be unique")
+}
+
+func TestResolveRender(t *testing.T) {
+	is := require.New(t)
+
+	values, err := Resolve(definitions, map[string]string{"domain": "shop.example.com", "debug": "true"})
+	is.NoError(err)
+	is.Equal(map[string]string{"image": "nginx:latest", "replicas": "2", "debug": "true", "domain": "shop.example.com"}, values)
+
+	content := []byte("image: {{ image }}\nreplicas: {{replicas}}\nhost: {{  domain }}\ndebug: {{ debug }}\nkept: {{ other }}\n")
+	is.Equal("image: nginx:latest\nreplicas: 2\nhost: shop.example.com\ndebug: true\nkept: {{ other }}\n", string(Render(content, values)))
+
+	for _, invalid := range []map[string]string{
+		{},
+		{"domain": "Shop.Example.com"},
+		{"domain": "example.com", "replicas": "many"},
+		{"domain": "example.com", "debug": "yes"},
+		{"domain": "example.com", "unknown": "value"},
+	} {
+		_, err := Resolve(definitions, invalid)
+		is.Error(err, "values %v", invalid)
+	}
+}
+
+func TestNewSchema(t *testing.T) {
+	is := require.New(t)
+
+	schema, err := json.Marshal(NewSchema(definitions))
+	is.NoError(err)
+
+	is.JSONEq(`{
+		"$schema": "https://json-schema.org/draft/2020-12/schema",
+		"type": "object",
+		"properties": {
+			"image": {"type": "string", "title": "Image", "default": "nginx:latest"},
+			"replicas": {"type": "number", "title": "Replicas", "default": 2},
+			"debug": {"type": "boolean", "title": "Debug", "default": false},
+			"domain": {"type": "string", "title": "Domain", "description": "Public domain", "pattern": "^[a-z0-9.-]+$"}
+		},
+		"required": ["domain"],
+		"x-order": ["image", "replicas", "debug", "domain"]
+	}`, string(schema))
+}
@@ -0,0 +1,207 @@
+package totp
+
+import (
+	"errors"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/apikey"
+	"github.com/portainer/portainer/api/dataservices"
+	"github.com/portainer/portainer/pkg/libcrypto"
+)
+
+const secretKeyLen = 32
+
+var (
+	ErrInvalidCode    = errors.New("invalid two-factor authentication code")
+	ErrNotEnrolled    = errors.New("two-factor authentication is not enrolled")
+	ErrAlreadyEnabled = errors.New("two-factor authentication is already enabled")
+)
+
+// Enrollment is the secret a user adds to its authenticator application
+type Enrollment struct {
+	// Base32 encoded secret, to type in the authenticator application
+	Secret string `json:"secret" example:"JBSWY3DPEHPK3PXP"`
+	// otpauth URI of the secret, to display as a QR code
+	ProvisioningURI string `json:"provisioningUri" example:"otpauth://totp/Portainer:admin?issuer=Portainer&secret=JBSWY3DPEHPK3PXP"`
+}
+
+// IsEnabled returns whether the user confirmed its enrollment
+func IsEnabled(tx dataservices.DataStoreTx, userID portainer.UserID) (bool, error) {
+	configuration, err := tx.TwoFactor().Read(userID)
+	if tx.IsErrObjectNotFound(err) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+
+	return configuration.Enabled, nil
+}
+
+// IsEnforced returns whether the settings require the user to use a second factor
+func IsEnforced(settings *portainer.Settings, user *portainer.User) bool {
+	return settings.TwoFactorSettings.EnforceForAdministrators && user.Role == portainer.AdministratorRole
+}
+
+// Enroll generates a new secret for the user. The second factor is only enabled once the user confirms
+// the enrollment with a valid code, a pending enrollment is replaced
+func Enroll(tx dataservices.DataStoreTx, user *portainer.User) (*Enrollment, error) {
+	configuration, err := tx.TwoFactor().Read(user.ID)
+	if err != nil && !tx.IsErrObjectNotFound(err) {
+		return nil, err
+	}
+
+	exists := err == nil
+	if exists && configuration.Enabled {
+		return nil, ErrAlreadyEnabled
+	}
+
+	secret, err := GenerateSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := getOrCreateSecretKey(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	encryptedSecret, err := libcrypto.Encrypt(secret, key)
+	if err != nil {
+		return nil, err
+	}
+
+	configuration = &portainer.TwoFactorConfiguration{UserID: user.ID, Secret: encryptedSecret}
+
+	if exists {
+		err = tx.TwoFactor().Update(user.ID, configuration)
+	} else {
+		err = tx.TwoFactor().Create(configuration)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &Enrollment{
+		Secret:          EncodeSecret(secret),
+		ProvisioningURI: ProvisioningURI(secret, user.Username),
+	}, nil
+}
+
+// Confirm enables the second factor of the user when the code matches its pending enrollment,
+// it returns the recovery codes, which are only displayed once
+func Confirm(tx dataservices.DataStoreTx, userID portainer.UserID, code string, now time.Time) ([]string, error) {
+	configuration, err := tx.TwoFactor().Read(userID)
+	if tx.IsErrObjectNotFound(err) {
+		return nil, ErrNotEnrolled
+	} else if err != nil {
+		return nil, err
+	}
+
+	if configuration.Enabled {
+		return nil, ErrAlreadyEnabled
+	}
+
+	if err := validateCode(tx, configuration, code, now); err != nil {
+		return nil, err
+	}
+
+	recoveryCodes, hashes, err := GenerateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+
+	configuration.Enabled = true
+	configuration.EnabledAt = now.Unix()
+	configuration.RecoveryCodes = hashes
+
+	if err := tx.TwoFactor().Update(userID, configuration); err != nil {
+		return nil, err
+	}
+
+	return recoveryCodes, nil
+}
+
+// Verify checks the code, or the recovery code when it is set, of a user whose second factor is enabled.
+// A recovery code can only be used once
+func Verify(tx dataservices.DataStoreTx, userID portainer.UserID, code, recoveryCode string, now time.Time) error {
+	configuration, err := tx.TwoFactor().Read(userID)
+	if tx.IsErrObjectNotFound(err) {
+		return ErrNotEnrolled
+	} else if err != nil {
+		return err
+	}
+
+	if !configuration.Enabled {
+		return ErrNotEnrolled
+	}
+
+	if recoveryCode != "" {
+		remaining, ok := ConsumeRecoveryCode(configuration.RecoveryCodes, recoveryCode)
+		if !ok {
+			return ErrInvalidCode
+		}
+
+		configuration.RecoveryCodes = remaining
+	} else if err := validateCode(tx, configuration, code, now); err != nil {
+		return err
+	}
+
+	return tx.TwoFactor().Update(userID, configuration)
+}
+
+// Disable removes the second factor of the user
+func Disable(tx dataservices.DataStoreTx, userID portainer.UserID) error {
+	if err := tx.TwoFactor().Delete(userID); err != nil && !tx.IsErrObjectNotFound(err) {
+		return err
+	}
+
+	return nil
+}
+
+// validateCode checks the code and records its step in the configuration
+func validateCode(tx dataservices.DataStoreTx, configuration *portainer.TwoFactorConfiguration, code string, now time.Time) error {
+	settings, err := tx.Settings().Settings()
+	if err != nil {
+		return err
+	}
+
+	secret, err := libcrypto.Decrypt(configuration.Secret, settings.TwoFactorSettings.SecretKey)
+	if err != nil {
+		return err
+	}
+
+	step, ok := Validate(secret, code, configuration.LastUsedStep, now)
+	if !ok {
+		return ErrInvalidCode
+	}
+
+	configuration.LastUsedStep = step
+
+	return nil
+}
+
+func getOrCreateSecretKey(tx dataservices.DataStoreTx) ([]byte, error) {
+	settings, err := tx.Settings().Settings()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(settings.TwoFactorSettings.SecretKey) > 0 {
+		return settings.TwoFactorSettings.SecretKey, nil
+	}
+
+	key := apikey.GenerateRandomKey(secretKeyLen)
+	if key == nil {
+		return nil, errors.New("unable to generate the two-factor secret encryption key")
+	}
+
+	settings.TwoFactorSettings.SecretKey = key
+
+	if err := tx.Settings().UpdateSettings(settings); err != nil {
+		return nil, err
+	}
+
+	return key, nil
+}
@@ -0,0 +1,153 @@
+// Package totp implements the time-based one-time passwords (RFC 6238) used as a second authentication factor
+package totp
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	// Issuer is the issuer displayed by the authenticator applications
+	Issuer = "Portainer"
+
+	digits    = 6
+	period    = 30
+	skew      = 1
+	secretLen = 20
+
+	recoveryCodeCount = 10
+	recoveryCodeLen   = 5
+)
+
+var encoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// GenerateSecret returns a new random TOTP secret
+func GenerateSecret() ([]byte, error) {
+	secret := make([]byte, secretLen)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, err
+	}
+
+	return secret, nil
+}
+
+// EncodeSecret returns the base32 representation of the secret, as typed in the authenticator applications
+func EncodeSecret(secret []byte) string {
+	return encoding.EncodeToString(secret)
+}
+
+// ProvisioningURI returns the otpauth URI of the secret, it is usually displayed as a QR code
+func ProvisioningURI(secret []byte, account string) string {
+	query := url.Values{}
+	query.Set("secret", EncodeSecret(secret))
+	query.Set("issuer", Issuer)
+	query.Set("algorithm", "SHA1")
+	query.Set("digits", fmt.Sprint(digits))
+	query.Set("period", fmt.Sprint(period))
+
+	u := url.URL{
+		Scheme:   "otpauth",
+		Host:     "totp",
+		Path:     "/" + Issuer + ":" + account,
+		RawQuery: query.Encode(),
+	}
+
+	return u.String()
+}
+
+// Code returns the code of the secret for the given time step
+func Code(secret []byte, step int64) string {
+	mac := hmac.New(sha1.New, secret)
+	binary.Write(mac, binary.BigEndian, step)
+	sum := mac.Sum(nil)
+
+	// dynamic truncation, RFC 4226 section 5.3
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	return fmt.Sprintf("%0*d", digits, value%1000000)
+}
+
+// Step returns the time step of the given time
+func Step(t time.Time) int64 {
+	return t.Unix() / period
+}
+
+// Validate checks the code against the steps around the given time. The matching step must be more recent
+// than lastUsedStep so that a code cannot be replayed, it is returned to be persisted as the new last used step
+func Validate(secret []byte, code string, lastUsedStep int64, now time.Time) (int64, bool) {
+	code = strings.TrimSpace(code)
+	if len(code) != digits {
+		return 0, false
+	}
+
+	current := Step(now)
+	for step := current - skew; step <= current+skew; step++ {
+		if step <= lastUsedStep {
+			continue
+		}
+
+		if subtle.ConstantTimeCompare([]byte(Code(secret, step)), []byte(code)) == 1 {
+			return step, true
+		}
+	}
+
+	return 0, false
+}
+
+// GenerateRecoveryCodes returns new single-use recovery codes along with the hashes to persist
+func GenerateRecoveryCodes() ([]string, []string, error) {
+	codes := make([]string, recoveryCodeCount)
+	hashes := make([]string, recoveryCodeCount)
+
+	for i := range codes {
+		raw := make([]byte, recoveryCodeLen)
+		if _, err := rand.Read(raw); err != nil {
+			return nil, nil, err
+		}
+
+		code := strings.ToLower(encoding.EncodeToString(raw))
+		codes[i] = code[:4] + "-" + code[4:]
+		hashes[i] = hashRecoveryCode(codes[i])
+	}
+
+	return codes, hashes, nil
+}
+
+// ConsumeRecoveryCode looks for the recovery code in the hashes, it returns the remaining hashes when it is found
+func ConsumeRecoveryCode(hashes []string, code string) ([]string, bool) {
+	hash := hashRecoveryCode(code)
+
+	found := -1
+	for i, h := range hashes {
+		if subtle.ConstantTimeCompare([]byte(h), []byte(hash)) == 1 {
+			found = i
+		}
+	}
+
+	if found < 0 {
+		return hashes, false
+	}
+
+	remaining := make([]string, 0, len(hashes)-1)
+	remaining = append(remaining, hashes[:found]...)
+
+	return append(remaining, hashes[found+1:]...), true
+}
+
+func hashRecoveryCode(code string) string {
+	code = strings.ToLower(strings.ReplaceAll(strings.TrimSpace(code), "-", ""))
+	sum := sha256.Sum256([]byte(code))
+
+	return hex.EncodeToString(sum[:])
+}
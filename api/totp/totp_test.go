@@ -0,0 +1,83 @@
+package totp
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// RFC 6238 appendix B test vectors, truncated to 6 digits
+func TestCode(t *testing.T) {
+	is := require.New(t)
+
+	secret := []byte("12345678901234567890")
+
+	for unix, expected := range map[int64]string{
+		59:         "287082",
+		1111111109: "081804",
+		1234567890: "005924",
+		2000000000: "279037",
+	} {
+		is.Equal(expected, Code(secret, Step(time.Unix(unix, 0))), "time %d", unix)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	is := require.New(t)
+
+	secret, err := GenerateSecret()
+	is.NoError(err)
+
+	now := time.Unix(1700000000, 0)
+	current := Step(now)
+
+	step, ok := Validate(secret, Code(secret, current), 0, now)
+	is.True(ok)
+	is.Equal(current, step)
+
+	_, ok = Validate(secret, Code(secret, current), current, now)
+	is.False(ok, "a code must not be accepted twice")
+
+	_, ok = Validate(secret, Code(secret, current-1), 0, now)
+	is.True(ok, "the previous code must be accepted to allow clock drift")
+
+	_, ok = Validate(secret, Code(secret, current-2), 0, now)
+	is.False(ok)
+
+	_, ok = Validate(secret, "abc", 0, now)
+	is.False(ok)
+}
+
+func TestProvisioningURI(t *testing.T) {
+	is := require.New(t)
+
+	uri, err := url.Parse(ProvisioningURI([]byte("12345678901234567890"), "admin"))
+	is.NoError(err)
+
+	is.Equal("otpauth", uri.Scheme)
+	is.Equal("totp", uri.Host)
+	is.Equal("/Portainer:admin", uri.Path)
+	is.Equal("GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ", uri.Query().Get("secret"))
+	is.Equal("Portainer", uri.Query().Get("issuer"))
+}
+
+func TestRecoveryCodes(t *testing.T) {
+	is := require.New(t)
+
+	codes, hashes, err := GenerateRecoveryCodes()
+	is.NoError(err)
+	is.Len(codes, recoveryCodeCount)
+	is.Len(hashes, recoveryCodeCount)
+
+	remaining, ok := ConsumeRecoveryCode(hashes, codes[3])
+	is.True(ok)
+	is.Len(remaining, recoveryCodeCount-1)
+
+	_, ok = ConsumeRecoveryCode(remaining, codes[3])
+	is.False(ok, "a recovery code must only be used once")
+
+	_, ok = ConsumeRecoveryCode(remaining, " "+codes[0][:4]+codes[0][5:]+" ")
+	is.True(ok, "the separator and the surrounding spaces are ignored")
+}
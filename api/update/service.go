@@ -0,0 +1,396 @@
+package update
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/backup"
+	"github.com/portainer/portainer/api/dataservices"
+	"github.com/portainer/portainer/api/http/client"
+	"github.com/portainer/portainer/api/http/offlinegate"
+	"github.com/portainer/portainer/api/internal/upgrade"
+	"github.com/portainer/portainer/api/platform"
+
+	"github.com/rs/zerolog/log"
+	"github.com/segmentio/encoding/json"
+)
+
+const (
+	// HealthCheckTimeout is how long an upgraded instance has to pass its health check before being rolled back
+	HealthCheckTimeout = 2 * time.Minute
+	// MaxStarts is how many times an upgraded instance can start without passing its health check before being
+	// rolled back, the previous starts having crashed
+	MaxStarts = 3
+
+	healthCheckInterval = 5 * time.Second
+	metadataTimeout     = 10
+	attemptFileName     = "upgrade_attempt.json"
+	imagePrefix         = "portainer/portainer-ce"
+)
+
+// Attempt represents an upgrade in progress, it is kept in the data directory until the upgraded instance passes its
+// health check
+type Attempt struct {
+	FromVersion string `json:"FromVersion"`
+	FromImage   string `json:"FromImage"`
+	ToVersion   string `json:"ToVersion"`
+	ToImage     string `json:"ToImage"`
+	// Path of the archive of the data backed up before the upgrade
+	BackupPath string `json:"BackupPath"`
+	StartedAt  int64  `json:"StartedAt"`
+	// Platform and environment of the Portainer container, the rollback switches the image without reading the data
+	Platform    platform.ContainerPlatform `json:"Platform"`
+	Environment *portainer.Endpoint        `json:"Environment,omitempty" swaggerignore:"true"`
+	// Number of starts of the upgraded instance
+	Starts int `json:"Starts"`
+}
+
+// Service checks the release channel and upgrades Portainer
+type Service struct {
+	dataStore       dataservices.DataStore
+	upgradeService  upgrade.Service
+	platformService platform.Service
+	gate            *offlinegate.OfflineGate
+	filestorePath   string
+	shutdownTrigger context.CancelFunc
+	statusURL       string
+	fetch           func(url string) ([]byte, error)
+	mu              sync.Mutex
+}
+
+// NewService returns a service checking the release channel and upgrading Portainer, the health of the upgraded
+// instance is checked on its status URL
+func NewService(
+	dataStore dataservices.DataStore,
+	upgradeService upgrade.Service,
+	platformService platform.Service,
+	gate *offlinegate.OfflineGate,
+	filestorePath string,
+	shutdownTrigger context.CancelFunc,
+	statusURL string,
+) *Service {
+	return &Service{
+		dataStore:       dataStore,
+		upgradeService:  upgradeService,
+		platformService: platformService,
+		gate:            gate,
+		filestorePath:   filestorePath,
+		shutdownTrigger: shutdownTrigger,
+		statusURL:       statusURL,
+		fetch: func(url string) ([]byte, error) {
+			return client.Get(url, metadataTimeout)
+		},
+	}
+}
+
+// Check returns the latest release of the configured channel, once its signature is verified
+func (service *Service) Check() (*Release, error) {
+	settings, err := service.dataStore.Settings().Settings()
+	if err != nil {
+		return nil, err
+	}
+
+	if settings.UpdateSettings.Channel == "" {
+		return nil, ErrChecksDisabled
+	}
+
+	body, err := service.fetch(MetadataURL(settings.UpdateSettings))
+	if err != nil {
+		return nil, fmt.Errorf("unable to retrieve the release metadata: %w", err)
+	}
+
+	var signed SignedRelease
+	if err := json.Unmarshal(body, &signed); err != nil {
+		return nil, fmt.Errorf("unable to parse the release metadata: %w", err)
+	}
+
+	release, err := Verify(settings.UpdateSettings.PublicKey, &signed)
+	if err != nil {
+		return nil, err
+	}
+
+	if release.Channel != settings.UpdateSettings.Channel {
+		return nil, fmt.Errorf("the release metadata are for the %q channel instead of %q", release.Channel, settings.UpdateSettings.Channel)
+	}
+
+	return release, nil
+}
+
+// Upgrade backs the data up and replaces the Portainer container by one running the latest release of the channel,
+// it is only supported when Portainer runs under Docker with access to its own engine
+func (service *Service) Upgrade() (*Release, error) {
+	service.mu.Lock()
+	defer service.mu.Unlock()
+
+	if attempt, err := service.readAttempt(); err != nil {
+		return nil, err
+	} else if attempt != nil {
+		return nil, ErrUpgradeInProgress
+	}
+
+	release, err := service.Check()
+	if err != nil {
+		return nil, err
+	}
+
+	if !IsNewer(release, portainer.APIVersion) {
+		return nil, ErrNoUpdate
+	}
+
+	containerPlatform, err := service.platformService.GetPlatform()
+	if err != nil {
+		return nil, err
+	}
+
+	if containerPlatform != platform.PlatformDockerStandalone && containerPlatform != platform.PlatformDockerSwarm {
+		return nil, fmt.Errorf("self-upgrades are not supported on the %s platform", containerPlatform)
+	}
+
+	environment, err := service.platformService.GetLocalEnvironment()
+	if err != nil {
+		return nil, err
+	}
+
+	backupPath, err := backup.CreateBackupArchive("", service.gate, service.dataStore, service.filestorePath)
+	if err != nil {
+		return nil, fmt.Errorf("unable to back the data up before the upgrade: %w", err)
+	}
+
+	attempt := &Attempt{
+		FromVersion: portainer.APIVersion,
+		FromImage:   imagePrefix + ":" + portainer.APIVersion,
+		ToVersion:   release.Version,
+		ToImage:     release.Image,
+		BackupPath:  backupPath,
+		StartedAt:   time.Now().Unix(),
+		Platform:    containerPlatform,
+		Environment: environment,
+	}
+
+	if err := service.writeAttempt(attempt); err != nil {
+		return nil, err
+	}
+
+	if err := service.upgradeService.SwitchImage(containerPlatform, environment, release.Image, release.Version); err != nil {
+		service.removeAttempt()
+
+		return nil, fmt.Errorf("unable to start the upgrade: %w", err)
+	}
+
+	log.Info().
+		Str("from_version", attempt.FromVersion).
+		Str("to_version", attempt.ToVersion).
+		Str("backup", backupPath).
+		Msg("upgrade started")
+
+	return release, nil
+}
+
+// Attempt returns the upgrade in progress, if any, without the environment of the Portainer container
+func (service *Service) Attempt() (*Attempt, error) {
+	attempt, err := service.readAttempt()
+	if attempt != nil {
+		attempt.Environment = nil
+	}
+
+	return attempt, err
+}
+
+// VerifyUpgrade is run at startup, it checks the health of the instance when it results from an upgrade and rolls it
+// back to the previous version and data when the check fails
+func (service *Service) VerifyUpgrade(ctx context.Context) {
+	attempt, err := service.readAttempt()
+	if err != nil {
+		log.Error().Err(err).Msg("unable to read the upgrade in progress")
+
+		return
+	} else if attempt == nil {
+		return
+	}
+
+	if attempt.ToVersion != portainer.APIVersion {
+		// the updater did not replace the instance
+		log.Warn().Str("version", attempt.ToVersion).Msg("the upgrade did not happen")
+		service.removeAttempt()
+
+		return
+	}
+
+	err = service.waitHealthy(ctx, HealthCheckTimeout)
+	if errors.Is(err, context.Canceled) {
+		return
+	} else if err == nil {
+		log.Info().Str("from_version", attempt.FromVersion).Str("to_version", attempt.ToVersion).Msg("upgrade completed")
+		service.removeAttempt()
+
+		return
+	}
+
+	log.Error().Err(err).Msg("the upgraded instance failed its health check, rolling back")
+
+	if err := service.rollback(attempt); err != nil {
+		log.Error().Err(err).Msg("unable to roll the upgrade back")
+	}
+}
+
+func (service *Service) waitHealthy(ctx context.Context, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	httpClient := &http.Client{
+		Timeout: healthCheckInterval,
+		// the instance checks itself through its local address
+		Transport: &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}},
+	}
+
+	ticker := time.NewTicker(healthCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, service.statusURL, nil)
+		if err != nil {
+			return err
+		}
+
+		resp, err := httpClient.Do(req)
+		if err == nil {
+			resp.Body.Close()
+
+			if resp.StatusCode == http.StatusOK {
+				return nil
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				return fmt.Errorf("no healthy status after %s", timeout)
+			}
+
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// rollback restores the data backed up before the upgrade, then switches back to the previous image and stops this
+// instance. The data are restored first, the previous version must never start on the data of the upgraded one
+func (service *Service) rollback(attempt *Attempt) error {
+	archive, err := os.Open(attempt.BackupPath)
+	if err != nil {
+		return fmt.Errorf("unable to open the backup of the upgrade: %w", err)
+	}
+	defer archive.Close()
+
+	// the instance is only stopped once the previous image is switched to
+	if err := backup.RestoreArchive(archive, "", service.filestorePath, service.gate, service.dataStore, func() {}); err != nil {
+		return err
+	}
+
+	if err := service.upgradeService.SwitchImage(attempt.Platform, attempt.Environment, attempt.FromImage, attempt.FromVersion); err != nil {
+		return err
+	}
+
+	service.removeAttempt()
+	service.shutdownTrigger()
+
+	return nil
+}
+
+// CrashedUpgrade is run at startup before the data are opened, as the upgraded version can crash while migrating
+// them. It counts the starts of the upgraded version and returns the upgrade in progress once the upgraded version
+// has started more than MaxStarts times without passing its health check
+func CrashedUpgrade(filestorePath string) (*Attempt, error) {
+	attempt, err := readAttempt(filestorePath)
+	if err != nil || attempt == nil || attempt.ToVersion != portainer.APIVersion {
+		return nil, err
+	}
+
+	attempt.Starts++
+	if err := writeAttempt(filestorePath, attempt); err != nil {
+		return nil, err
+	}
+
+	if attempt.Starts <= MaxStarts {
+		return nil, nil
+	}
+
+	return attempt, nil
+}
+
+// RollbackCrashedUpgrade restores the data backed up before an upgrade whose version keeps crashing, then switches
+// back to the previous image. It is run while the data are not opened
+func RollbackCrashedUpgrade(filestorePath string, attempt *Attempt, upgradeService upgrade.Service) error {
+	archive, err := os.Open(attempt.BackupPath)
+	if err != nil {
+		return fmt.Errorf("unable to open the backup of the upgrade: %w", err)
+	}
+	defer archive.Close()
+
+	if err := backup.RestoreFiles(archive, "", filestorePath); err != nil {
+		return err
+	}
+
+	if err := upgradeService.SwitchImage(attempt.Platform, attempt.Environment, attempt.FromImage, attempt.FromVersion); err != nil {
+		return err
+	}
+
+	removeAttempt(filestorePath)
+
+	return nil
+}
+
+func (service *Service) readAttempt() (*Attempt, error) {
+	return readAttempt(service.filestorePath)
+}
+
+func (service *Service) writeAttempt(attempt *Attempt) error {
+	return writeAttempt(service.filestorePath, attempt)
+}
+
+func (service *Service) removeAttempt() {
+	removeAttempt(service.filestorePath)
+}
+
+func attemptPath(filestorePath string) string {
+	return filepath.Join(filestorePath, attemptFileName)
+}
+
+func readAttempt(filestorePath string) (*Attempt, error) {
+	content, err := os.ReadFile(attemptPath(filestorePath))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	var attempt Attempt
+	if err := json.Unmarshal(content, &attempt); err != nil {
+		return nil, fmt.Errorf("unable to parse the upgrade in progress: %w", err)
+	}
+
+	return &attempt, nil
+}
+
+func writeAttempt(filestorePath string, attempt *Attempt) error {
+	content, err := json.Marshal(attempt)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(attemptPath(filestorePath), content, 0o600)
+}
+
+func removeAttempt(filestorePath string) {
+	if err := os.Remove(attemptPath(filestorePath)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		log.Warn().Err(err).Msg("unable to remove the upgrade in progress")
+	}
+}
@@ -0,0 +1,143 @@
+// Package update checks the release channels of Portainer and orchestrates its self-upgrades under Docker: the data
+// is backed up before the upgrade, then restored along with the previous version when the upgraded instance does not
+// pass its health check or keeps crashing before it
+package update
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	portainer "github.com/portainer/portainer/api"
+
+	"github.com/coreos/go-semver/semver"
+	"github.com/segmentio/encoding/json"
+)
+
+const (
+	// ChannelStable is the release channel of the short term support releases
+	ChannelStable = "stable"
+	// ChannelLTS is the release channel of the long term support releases
+	ChannelLTS = "lts"
+
+	// channelPlaceholder is replaced by the release channel in the URL of the release metadata
+	channelPlaceholder = "{channel}"
+)
+
+// imageDigestPattern matches the images pinned to the digest of their content, the image of a release cannot be
+// replaced behind its tag
+var imageDigestPattern = regexp.MustCompile(`^[^@\s]+@sha256:[0-9a-f]{64}$`)
+
+var (
+	// ErrChecksDisabled is returned when no release channel is configured
+	ErrChecksDisabled = errors.New("no release channel is configured")
+	// ErrInvalidSignature is returned when the release metadata are not signed by the configured key
+	ErrInvalidSignature = errors.New("invalid signature of the release metadata")
+	// ErrNoUpdate is returned when the release channel has no version newer than the running one
+	ErrNoUpdate = errors.New("Portainer is up to date")
+	// ErrUpgradeInProgress is returned when an upgrade is already in progress
+	ErrUpgradeInProgress = errors.New("an upgrade is already in progress")
+)
+
+// Release represents the latest release of a release channel
+type Release struct {
+	Channel string `json:"Channel" example:"stable"`
+	Version string `json:"Version" example:"2.26.0"`
+	// Image the release is deployed from, pinned to its digest
+	Image string `json:"Image" example:"portainer/portainer-ce:2.26.0@sha256:7f5c5e3a1c0e4b8d9a6f2e1d3c4b5a6978877665544332211ffeeddccbbaa998"`
+	// URL of the release notes
+	NotesURL string `json:"NotesURL,omitempty"`
+	// Unix timestamp of the publication of the release
+	PublishedAt int64 `json:"PublishedAt" example:"1587399600"`
+}
+
+// SignedRelease is the document served for a release channel: the base64 encoded JSON release metadata and their
+// base64 encoded Ed25519 signature
+type SignedRelease struct {
+	Metadata  string `json:"Metadata"`
+	Signature string `json:"Signature"`
+}
+
+// ValidateSettings returns an error when the release channel settings are invalid
+func ValidateSettings(settings portainer.UpdateSettings) error {
+	if settings.Channel == "" {
+		return nil
+	}
+
+	if settings.Channel != ChannelStable && settings.Channel != ChannelLTS {
+		return fmt.Errorf("invalid release channel %q, must be %s or %s", settings.Channel, ChannelStable, ChannelLTS)
+	}
+
+	if !strings.HasPrefix(settings.MetadataURL, "https://") {
+		return errors.New("the release metadata must be served over HTTPS")
+	}
+
+	_, err := parsePublicKey(settings.PublicKey)
+
+	return err
+}
+
+// MetadataURL returns the URL of the release metadata of the configured channel
+func MetadataURL(settings portainer.UpdateSettings) string {
+	return strings.ReplaceAll(settings.MetadataURL, channelPlaceholder, settings.Channel)
+}
+
+func parsePublicKey(encoded string) (ed25519.PublicKey, error) {
+	key, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil || len(key) != ed25519.PublicKeySize {
+		return nil, errors.New("the public key must be a base64 encoded Ed25519 public key")
+	}
+
+	return ed25519.PublicKey(key), nil
+}
+
+// Verify checks the signature of the release metadata and returns the release they describe
+func Verify(publicKey string, signed *SignedRelease) (*Release, error) {
+	key, err := parsePublicKey(publicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	metadata, err := base64.StdEncoding.DecodeString(signed.Metadata)
+	if err != nil {
+		return nil, fmt.Errorf("unable to decode the release metadata: %w", err)
+	}
+
+	signature, err := base64.StdEncoding.DecodeString(signed.Signature)
+	if err != nil || !ed25519.Verify(key, metadata, signature) {
+		return nil, ErrInvalidSignature
+	}
+
+	var release Release
+	if err := json.Unmarshal(metadata, &release); err != nil {
+		return nil, fmt.Errorf("unable to parse the release metadata: %w", err)
+	}
+
+	if _, err := semver.NewVersion(release.Version); err != nil {
+		return nil, fmt.Errorf("invalid version %q of the release: %w", release.Version, err)
+	}
+
+	if !imageDigestPattern.MatchString(release.Image) {
+		return nil, fmt.Errorf("the image %q of the release is not pinned to a sha256 digest", release.Image)
+	}
+
+	return &release, nil
+}
+
+// IsNewer returns whether the release is newer than the running version
+func IsNewer(release *Release, currentVersion string) bool {
+	current, err := semver.NewVersion(currentVersion)
+	if err != nil {
+		return false
+	}
+
+	latest, err := semver.NewVersion(release.Version)
+	if err != nil {
+		return false
+	}
+
+	return current.LessThan(*latest)
+}
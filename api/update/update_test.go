@@ -0,0 +1,215 @@
+package update
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"testing"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/archive"
+	"github.com/portainer/portainer/api/datastore"
+	"github.com/portainer/portainer/api/http/offlinegate"
+	"github.com/portainer/portainer/api/internal/upgrade"
+	"github.com/portainer/portainer/api/platform"
+
+	"github.com/segmentio/encoding/json"
+	"github.com/stretchr/testify/require"
+)
+
+const testDigest = "@sha256:7f5c5e3a1c0e4b8d9a6f2e1d3c4b5a6978877665544332211ffeeddccbbaa998"
+
+func signRelease(t *testing.T, key ed25519.PrivateKey, release Release) []byte {
+	metadata, err := json.Marshal(release)
+	require.NoError(t, err)
+
+	body, err := json.Marshal(SignedRelease{
+		Metadata:  base64.StdEncoding.EncodeToString(metadata),
+		Signature: base64.StdEncoding.EncodeToString(ed25519.Sign(key, metadata)),
+	})
+	require.NoError(t, err)
+
+	return body
+}
+
+func TestValidateSettings(t *testing.T) {
+	is := require.New(t)
+
+	publicKey, _, err := ed25519.GenerateKey(rand.Reader)
+	is.NoError(err)
+
+	encodedKey := base64.StdEncoding.EncodeToString(publicKey)
+
+	is.NoError(ValidateSettings(portainer.UpdateSettings{}))
+	is.NoError(ValidateSettings(portainer.UpdateSettings{Channel: ChannelLTS, MetadataURL: "https://releases.mydomain.tld/{channel}.json", PublicKey: encodedKey}))
+	is.Error(ValidateSettings(portainer.UpdateSettings{Channel: "beta", MetadataURL: "https://releases.mydomain.tld/{channel}.json", PublicKey: encodedKey}))
+	is.Error(ValidateSettings(portainer.UpdateSettings{Channel: ChannelStable, MetadataURL: "http://releases.mydomain.tld/{channel}.json", PublicKey: encodedKey}))
+	is.Error(ValidateSettings(portainer.UpdateSettings{Channel: ChannelStable, MetadataURL: "https://releases.mydomain.tld/{channel}.json", PublicKey: "invalid"}))
+}
+
+func TestCheck(t *testing.T) {
+	is := require.New(t)
+
+	_, store := datastore.MustNewTestStore(t, true, false)
+
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	is.NoError(err)
+
+	_, otherKey, err := ed25519.GenerateKey(rand.Reader)
+	is.NoError(err)
+
+	service := NewService(store, nil, nil, nil, t.TempDir(), nil, "")
+
+	_, err = service.Check()
+	is.ErrorIs(err, ErrChecksDisabled)
+
+	settings, err := store.Settings().Settings()
+	is.NoError(err)
+
+	settings.UpdateSettings = portainer.UpdateSettings{
+		Channel:     ChannelStable,
+		MetadataURL: "https://releases.mydomain.tld/{channel}.json",
+		PublicKey:   base64.StdEncoding.EncodeToString(publicKey),
+	}
+	is.NoError(store.Settings().UpdateSettings(settings))
+
+	var fetched string
+	body := signRelease(t, privateKey, Release{Channel: ChannelStable, Version: "99.0.0", Image: "portainer/portainer-ce:99.0.0" + testDigest})
+	service.fetch = func(url string) ([]byte, error) {
+		fetched = url
+
+		return body, nil
+	}
+
+	release, err := service.Check()
+	is.NoError(err)
+	is.Equal("https://releases.mydomain.tld/stable.json", fetched)
+	is.Equal("99.0.0", release.Version)
+	is.True(IsNewer(release, portainer.APIVersion))
+
+	// metadata signed by another key
+	body = signRelease(t, otherKey, Release{Channel: ChannelStable, Version: "99.0.0", Image: "portainer/portainer-ce:99.0.0" + testDigest})
+	_, err = service.Check()
+	is.ErrorIs(err, ErrInvalidSignature)
+
+	// image only pinned to its tag
+	body = signRelease(t, privateKey, Release{Channel: ChannelStable, Version: "99.0.0", Image: "portainer/portainer-ce:99.0.0"})
+	_, err = service.Check()
+	is.ErrorContains(err, "not pinned to a sha256 digest")
+
+	// metadata of another channel
+	body = signRelease(t, privateKey, Release{Channel: ChannelLTS, Version: "99.0.0", Image: "portainer/portainer-ce:99.0.0" + testDigest})
+	_, err = service.Check()
+	is.Error(err)
+
+	// an older release is not an update
+	body = signRelease(t, privateKey, Release{Channel: ChannelStable, Version: "1.0.0", Image: "portainer/portainer-ce:1.0.0" + testDigest})
+	release, err = service.Check()
+	is.NoError(err)
+	is.False(IsNewer(release, portainer.APIVersion))
+
+	_, err = service.Upgrade()
+	is.ErrorIs(err, ErrNoUpdate)
+}
+
+type fakeUpgradeService struct {
+	upgrade.Service
+	switched []string
+	onSwitch func()
+}
+
+func (service *fakeUpgradeService) SwitchImage(platform platform.ContainerPlatform, environment *portainer.Endpoint, image, version string) error {
+	service.onSwitch()
+	service.switched = append(service.switched, image)
+
+	return nil
+}
+
+// newUpgradeAttempt stores an upgrade in progress to the running version in the data directory, along with a backup
+// of the previous data
+func newUpgradeAttempt(t *testing.T, filestorePath string) *Attempt {
+	is := require.New(t)
+
+	backupDir := t.TempDir()
+	is.NoError(os.WriteFile(filepath.Join(backupDir, "portainer.db"), []byte("previous data"), 0o600))
+
+	backupPath, err := archive.TarGzDir(backupDir)
+	is.NoError(err)
+
+	is.NoError(os.WriteFile(filepath.Join(filestorePath, "portainer.db"), []byte("upgraded data"), 0o600))
+
+	attempt := &Attempt{
+		FromVersion: "2.0.0",
+		FromImage:   "portainer/portainer-ce:2.0.0",
+		ToVersion:   portainer.APIVersion,
+		BackupPath:  backupPath,
+		Platform:    platform.PlatformDockerStandalone,
+		Environment: &portainer.Endpoint{ID: 1, URL: "unix:///var/run/docker.sock"},
+	}
+	is.NoError(writeAttempt(filestorePath, attempt))
+
+	return attempt
+}
+
+// restoredBeforeSwitch checks that the previous data are restored when the previous image is switched to
+func restoredBeforeSwitch(t *testing.T, filestorePath string) func() {
+	return func() {
+		content, err := os.ReadFile(filepath.Join(filestorePath, "portainer.db"))
+		require.NoError(t, err)
+		require.Equal(t, "previous data", string(content))
+	}
+}
+
+func TestRollback(t *testing.T) {
+	is := require.New(t)
+
+	_, store := datastore.MustNewTestStore(t, true, false)
+
+	filestorePath := t.TempDir()
+	attempt := newUpgradeAttempt(t, filestorePath)
+
+	upgradeService := &fakeUpgradeService{onSwitch: restoredBeforeSwitch(t, filestorePath)}
+
+	shutdown := false
+	service := NewService(store, upgradeService, nil, offlinegate.NewOfflineGate(), filestorePath, func() {
+		is.Len(upgradeService.switched, 1)
+		shutdown = true
+	}, "")
+
+	is.NoError(service.rollback(attempt))
+	is.Equal([]string{"portainer/portainer-ce:2.0.0"}, upgradeService.switched)
+	is.True(shutdown)
+
+	attempt, err := service.Attempt()
+	is.NoError(err)
+	is.Nil(attempt)
+}
+
+func TestCrashedUpgrade(t *testing.T) {
+	is := require.New(t)
+
+	filestorePath := t.TempDir()
+	newUpgradeAttempt(t, filestorePath)
+
+	for range MaxStarts {
+		attempt, err := CrashedUpgrade(filestorePath)
+		is.NoError(err)
+		is.Nil(attempt)
+	}
+
+	attempt, err := CrashedUpgrade(filestorePath)
+	is.NoError(err)
+	is.NotNil(attempt)
+	is.Equal(MaxStarts+1, attempt.Starts)
+
+	upgradeService := &fakeUpgradeService{onSwitch: restoredBeforeSwitch(t, filestorePath)}
+
+	is.NoError(RollbackCrashedUpgrade(filestorePath, attempt, upgradeService))
+	is.Equal([]string{"portainer/portainer-ce:2.0.0"}, upgradeService.switched)
+
+	attempt, err = readAttempt(filestorePath)
+	is.NoError(err)
+	is.Nil(attempt)
+}
@@ -0,0 +1,108 @@
+package webauthn
+
+import (
+	"crypto/ecdsa"
+	"crypto/x509"
+)
+
+// verifyAttestation checks the attestation statement of a new credential. The "packed" and "fido-u2f" statements are
+// verified, the attestation certificates are not checked against a list of trusted authenticators. The other formats
+// are only accepted when no attestation was requested
+func verifyAttestation(format string, statement map[any]any, authData, clientDataHash []byte, data *authenticatorData, preference string) error {
+	credentialKey := data.credentialKey
+	signed := append(append([]byte(nil), authData...), clientDataHash...)
+
+	switch format {
+	case "none":
+		if len(statement) != 0 {
+			return verificationError("unexpected attestation statement")
+		}
+
+		if preference == AttestationDirect {
+			return verificationError("an attestation is required")
+		}
+
+		return nil
+	case "packed":
+		alg, _ := statement["alg"].(int64)
+		sig, _ := statement["sig"].([]byte)
+
+		certificate, found, err := firstCertificate(statement)
+		if err != nil {
+			return err
+		}
+
+		if !found {
+			// self attestation, the statement is signed by the credential
+			if alg != credentialKey.algorithm {
+				return verificationError("the self attestation algorithm does not match the credential")
+			}
+
+			if err := credentialKey.verify(signed, sig); err != nil {
+				return verificationError("invalid self attestation signature")
+			}
+
+			return nil
+		}
+
+		if err := verifyCertificateSignature(alg, certificate, signed, sig); err != nil {
+			return verificationError("invalid attestation signature")
+		}
+
+		return nil
+	case "fido-u2f":
+		sig, _ := statement["sig"].([]byte)
+
+		certificate, found, err := firstCertificate(statement)
+		if err != nil || !found {
+			return verificationError("missing attestation certificate")
+		}
+
+		key, ok := credentialKey.key.(*ecdsa.PublicKey)
+		if !ok {
+			return verificationError("the U2F credentials must use an EC2 P-256 key")
+		}
+
+		ecdhKey, err := key.ECDH()
+		if err != nil {
+			return verificationError("invalid credential public key")
+		}
+
+		// the U2F registration signs the application parameter, the challenge parameter, the key handle and the public key
+		signedU2F := []byte{0x00}
+		signedU2F = append(signedU2F, authData[:32]...)
+		signedU2F = append(signedU2F, clientDataHash...)
+		signedU2F = append(signedU2F, data.credentialID...)
+		signedU2F = append(signedU2F, ecdhKey.Bytes()...)
+
+		if err := verifyCertificateSignature(algES256, certificate, signedU2F, sig); err != nil {
+			return verificationError("invalid attestation signature")
+		}
+
+		return nil
+	}
+
+	if preference == AttestationNone {
+		return nil
+	}
+
+	return verificationError("unsupported attestation format " + format)
+}
+
+func firstCertificate(statement map[any]any) ([]byte, bool, error) {
+	x5c, ok := statement["x5c"].([]any)
+	if !ok || len(x5c) == 0 {
+		return nil, false, nil
+	}
+
+	der, ok := x5c[0].([]byte)
+	if !ok {
+		return nil, false, verificationError("invalid attestation certificate")
+	}
+
+	if _, err := x509.ParseCertificate(der); err != nil {
+		return nil, false, verificationError("invalid attestation certificate")
+	}
+
+	return der, true, nil
+}
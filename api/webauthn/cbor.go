@@ -0,0 +1,131 @@
+package webauthn
+
+import (
+	"encoding/binary"
+
+	"github.com/pkg/errors"
+)
+
+const maxCBORDepth = 16
+
+var errInvalidCBOR = errors.New("invalid CBOR data")
+
+// decodeCBOR decodes the first item of the data and returns the remaining bytes. Only the subset of CBOR (RFC 8949)
+// used by WebAuthn is supported: integers, byte and text strings, arrays, maps, tags and simple values
+func decodeCBOR(data []byte) (any, []byte, error) {
+	return decodeCBORItem(data, 0)
+}
+
+func decodeCBORItem(data []byte, depth int) (any, []byte, error) {
+	if depth > maxCBORDepth || len(data) == 0 {
+		return nil, nil, errInvalidCBOR
+	}
+
+	major := data[0] >> 5
+	info := data[0] & 0x1f
+	data = data[1:]
+
+	if major == 7 {
+		switch info {
+		case 20:
+			return false, data, nil
+		case 21:
+			return true, data, nil
+		case 22, 23:
+			return nil, data, nil
+		}
+
+		return nil, nil, errors.New("unsupported CBOR simple value")
+	}
+
+	var arg uint64
+	switch {
+	case info < 24:
+		arg = uint64(info)
+	case info == 24 && len(data) >= 1:
+		arg, data = uint64(data[0]), data[1:]
+	case info == 25 && len(data) >= 2:
+		arg, data = uint64(binary.BigEndian.Uint16(data)), data[2:]
+	case info == 26 && len(data) >= 4:
+		arg, data = uint64(binary.BigEndian.Uint32(data)), data[4:]
+	case info == 27 && len(data) >= 8:
+		arg, data = binary.BigEndian.Uint64(data), data[8:]
+	default:
+		// the indefinite lengths are not used by the authenticators
+		return nil, nil, errInvalidCBOR
+	}
+
+	switch major {
+	case 0:
+		if arg > 1<<63-1 {
+			return nil, nil, errInvalidCBOR
+		}
+
+		return int64(arg), data, nil
+	case 1:
+		if arg > 1<<63-1 {
+			return nil, nil, errInvalidCBOR
+		}
+
+		return -1 - int64(arg), data, nil
+	case 2, 3:
+		if arg > uint64(len(data)) {
+			return nil, nil, errInvalidCBOR
+		}
+
+		value := data[:arg]
+		if major == 3 {
+			return string(value), data[arg:], nil
+		}
+
+		return append([]byte(nil), value...), data[arg:], nil
+	case 4:
+		if arg > uint64(len(data)) {
+			return nil, nil, errInvalidCBOR
+		}
+
+		items := make([]any, 0, arg)
+		for range arg {
+			item, rest, err := decodeCBORItem(data, depth+1)
+			if err != nil {
+				return nil, nil, err
+			}
+
+			items, data = append(items, item), rest
+		}
+
+		return items, data, nil
+	case 5:
+		if arg > uint64(len(data)) {
+			return nil, nil, errInvalidCBOR
+		}
+
+		items := make(map[any]any, arg)
+		for range arg {
+			key, rest, err := decodeCBORItem(data, depth+1)
+			if err != nil {
+				return nil, nil, err
+			}
+
+			switch key.(type) {
+			case int64, string:
+			default:
+				return nil, nil, errors.New("unsupported CBOR map key")
+			}
+
+			value, rest, err := decodeCBORItem(rest, depth+1)
+			if err != nil {
+				return nil, nil, err
+			}
+
+			items[key], data = value, rest
+		}
+
+		return items, data, nil
+	case 6:
+		// the tags only give a meaning to the tagged item
+		return decodeCBORItem(data, depth+1)
+	}
+
+	return nil, nil, errInvalidCBOR
+}
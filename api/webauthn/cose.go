@@ -0,0 +1,139 @@
+package webauthn
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"math/big"
+
+	"github.com/pkg/errors"
+)
+
+// COSE algorithms (RFC 9053) supported for the credentials
+const (
+	algES256 = -7
+	algEdDSA = -8
+	algRS256 = -257
+)
+
+// supportedAlgorithms are offered to the authenticators in order of preference
+var supportedAlgorithms = []int64{algES256, algEdDSA, algRS256}
+
+// COSE key parameters
+const (
+	coseKeyType   = 1
+	coseAlgorithm = 3
+	coseCurve     = -1
+	coseX         = -2
+	coseY         = -3
+	coseRSAN      = -1
+	coseRSAE      = -2
+
+	coseKeyTypeOKP = 1
+	coseKeyTypeEC2 = 2
+	coseKeyTypeRSA = 3
+
+	coseCurveP256    = 1
+	coseCurveEd25519 = 6
+)
+
+// publicKey is the public key of a credential
+type publicKey struct {
+	algorithm int64
+	key       crypto.PublicKey
+}
+
+// parsePublicKey parses a COSE encoded public key and returns the bytes following it
+func parsePublicKey(data []byte) (*publicKey, []byte, error) {
+	item, rest, err := decodeCBOR(data)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "invalid credential public key")
+	}
+
+	params, ok := item.(map[any]any)
+	if !ok {
+		return nil, nil, errors.New("invalid credential public key")
+	}
+
+	keyType, _ := params[int64(coseKeyType)].(int64)
+	algorithm, _ := params[int64(coseAlgorithm)].(int64)
+
+	switch {
+	case keyType == coseKeyTypeEC2 && algorithm == algES256:
+		curve, _ := params[int64(coseCurve)].(int64)
+		x, _ := params[int64(coseX)].([]byte)
+		y, _ := params[int64(coseY)].([]byte)
+
+		if curve != coseCurveP256 || len(x) != 32 || len(y) != 32 {
+			return nil, nil, errors.New("invalid EC2 public key")
+		}
+
+		key := &ecdsa.PublicKey{Curve: elliptic.P256(), X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+		if !key.Curve.IsOnCurve(key.X, key.Y) {
+			return nil, nil, errors.New("invalid EC2 public key")
+		}
+
+		return &publicKey{algorithm: algorithm, key: key}, rest, nil
+	case keyType == coseKeyTypeOKP && algorithm == algEdDSA:
+		curve, _ := params[int64(coseCurve)].(int64)
+		x, _ := params[int64(coseX)].([]byte)
+
+		if curve != coseCurveEd25519 || len(x) != ed25519.PublicKeySize {
+			return nil, nil, errors.New("invalid OKP public key")
+		}
+
+		return &publicKey{algorithm: algorithm, key: ed25519.PublicKey(x)}, rest, nil
+	case keyType == coseKeyTypeRSA && algorithm == algRS256:
+		n, _ := params[int64(coseRSAN)].([]byte)
+		e, _ := params[int64(coseRSAE)].([]byte)
+
+		exponent := new(big.Int).SetBytes(e)
+		if len(n) < 256 || !exponent.IsInt64() || exponent.Int64() > 1<<31-1 {
+			return nil, nil, errors.New("invalid RSA public key")
+		}
+
+		return &publicKey{algorithm: algorithm, key: &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(exponent.Int64())}}, rest, nil
+	}
+
+	return nil, nil, errors.Errorf("unsupported public key type %d with algorithm %d", keyType, algorithm)
+}
+
+// verify checks the signature of the data
+func (key *publicKey) verify(data, signature []byte) error {
+	return verifySignature(key.algorithm, key.key, data, signature)
+}
+
+func verifySignature(algorithm int64, key crypto.PublicKey, data, signature []byte) error {
+	digest := sha256.Sum256(data)
+
+	switch k := key.(type) {
+	case *ecdsa.PublicKey:
+		if algorithm == algES256 && ecdsa.VerifyASN1(k, digest[:], signature) {
+			return nil
+		}
+	case ed25519.PublicKey:
+		if algorithm == algEdDSA && ed25519.Verify(k, data, signature) {
+			return nil
+		}
+	case *rsa.PublicKey:
+		if algorithm == algRS256 && rsa.VerifyPKCS1v15(k, crypto.SHA256, digest[:], signature) == nil {
+			return nil
+		}
+	}
+
+	return errors.New("invalid signature")
+}
+
+// verifyCertificateSignature checks a signature made with the key of an attestation certificate
+func verifyCertificateSignature(algorithm int64, der, data, signature []byte) error {
+	certificate, err := x509.ParseCertificate(der)
+	if err != nil {
+		return errors.Wrap(err, "invalid attestation certificate")
+	}
+
+	return verifySignature(algorithm, certificate.PublicKey, data, signature)
+}
@@ -0,0 +1,563 @@
+// Package webauthn implements the registration and the authentication ceremonies of WebAuthn (W3C Web Authentication),
+// letting the users log in with security keys and platform authenticators
+package webauthn
+
+import (
+	"bytes"
+	"cmp"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/binary"
+	"net"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+
+	"github.com/pkg/errors"
+	"github.com/segmentio/encoding/json"
+)
+
+const (
+	// SessionExpiry is the time a user has to complete a ceremony
+	SessionExpiry = 5 * time.Minute
+
+	challengeLen = 32
+	// maxSessions bounds the memory used by the pending ceremonies
+	maxSessions = 1000
+
+	AttestationNone     = "none"
+	AttestationIndirect = "indirect"
+	AttestationDirect   = "direct"
+
+	UserVerificationRequired    = "required"
+	UserVerificationPreferred   = "preferred"
+	UserVerificationDiscouraged = "discouraged"
+
+	flagUserPresent  = 0x01
+	flagUserVerified = 0x04
+	flagAttestedData = 0x40
+
+	// authenticatorDataMinLen is the length of the RP ID hash, the flags and the signature counter
+	authenticatorDataMinLen = 37
+)
+
+var (
+	// ErrInvalidSession is returned when the ceremony is unknown, expired or already completed
+	ErrInvalidSession = errors.New("invalid or expired WebAuthn session")
+	// ErrTooManySessions is returned when too many ceremonies are pending
+	ErrTooManySessions = errors.New("too many pending WebAuthn sessions")
+	// ErrVerification is returned when the response of the authenticator is rejected
+	ErrVerification = errors.New("WebAuthn verification failed")
+	// ErrUnknownCredential is returned when the credential used to log in is not registered
+	ErrUnknownCredential = errors.New("unknown WebAuthn credential")
+)
+
+var encoding = base64.RawURLEncoding
+
+// RelyingParty identifies Portainer to the authenticators
+type RelyingParty struct {
+	ID      string
+	Name    string
+	Origins []string
+}
+
+// RelyingPartyFromRequest returns the relying party defined by the settings, the host and the origin
+// of the request are used when they are not set
+func RelyingPartyFromRequest(settings *portainer.WebAuthnSettings, r *http.Request) RelyingParty {
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+
+	if proto := r.Header.Get("X-Forwarded-Proto"); proto != "" {
+		scheme = proto
+	}
+
+	host := r.Host
+	if forwardedHost := r.Header.Get("X-Forwarded-Host"); forwardedHost != "" {
+		host = forwardedHost
+	}
+
+	rp := RelyingParty{
+		ID:      settings.RelyingPartyID,
+		Name:    "Portainer",
+		Origins: settings.Origins,
+	}
+
+	if rp.ID == "" {
+		rp.ID = host
+		if hostname, _, err := net.SplitHostPort(host); err == nil {
+			rp.ID = hostname
+		}
+	}
+
+	if len(rp.Origins) == 0 {
+		rp.Origins = []string{scheme + "://" + host}
+	}
+
+	return rp
+}
+
+// CredentialDescriptor identifies a credential to the authenticators
+type CredentialDescriptor struct {
+	Type string `json:"type" example:"public-key"`
+	ID   string `json:"id"`
+}
+
+type relyingPartyEntity struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type userEntity struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	DisplayName string `json:"displayName"`
+}
+
+type credentialParameter struct {
+	Type string `json:"type"`
+	Alg  int64  `json:"alg"`
+}
+
+type authenticatorSelection struct {
+	ResidentKey      string `json:"residentKey"`
+	UserVerification string `json:"userVerification"`
+}
+
+// CreationOptions are the options of navigator.credentials.create, the binary values are base64url encoded
+type CreationOptions struct {
+	Challenge              string                 `json:"challenge"`
+	RP                     relyingPartyEntity     `json:"rp"`
+	User                   userEntity             `json:"user"`
+	PubKeyCredParams       []credentialParameter  `json:"pubKeyCredParams"`
+	Timeout                int64                  `json:"timeout"`
+	ExcludeCredentials     []CredentialDescriptor `json:"excludeCredentials"`
+	AuthenticatorSelection authenticatorSelection `json:"authenticatorSelection"`
+	Attestation            string                 `json:"attestation"`
+}
+
+// RequestOptions are the options of navigator.credentials.get, the binary values are base64url encoded
+type RequestOptions struct {
+	Challenge        string                 `json:"challenge"`
+	Timeout          int64                  `json:"timeout"`
+	RPID             string                 `json:"rpId"`
+	AllowCredentials []CredentialDescriptor `json:"allowCredentials"`
+	UserVerification string                 `json:"userVerification"`
+}
+
+// CredentialResponse is the PublicKeyCredential returned by the browser, the binary values are base64url encoded
+type CredentialResponse struct {
+	ID       string `json:"id"`
+	RawID    string `json:"rawId"`
+	Type     string `json:"type" example:"public-key"`
+	Response struct {
+		ClientDataJSON    string `json:"clientDataJSON"`
+		AttestationObject string `json:"attestationObject,omitempty"`
+		AuthenticatorData string `json:"authenticatorData,omitempty"`
+		Signature         string `json:"signature,omitempty"`
+		UserHandle        string `json:"userHandle,omitempty"`
+	} `json:"response"`
+}
+
+type session struct {
+	challenge        []byte
+	userID           portainer.UserID
+	registration     bool
+	relyingParty     RelyingParty
+	userVerification string
+	attestation      string
+	expiresAt        time.Time
+}
+
+// Service holds the pending ceremonies in memory
+type Service struct {
+	mu       sync.Mutex
+	sessions map[string]*session
+	now      func() time.Time
+}
+
+// NewService returns a pointer to a new instance of this service
+func NewService() *Service {
+	return &Service{
+		sessions: map[string]*session{},
+		now:      time.Now,
+	}
+}
+
+// BeginRegistration starts the registration of a new credential for the user, the credentials it already
+// registered are excluded so that an authenticator is only registered once
+func (service *Service) BeginRegistration(rp RelyingParty, settings *portainer.WebAuthnSettings, user *portainer.User, credentials []portainer.WebAuthnCredential) (string, *CreationOptions, error) {
+	s := &session{
+		userID:           user.ID,
+		registration:     true,
+		relyingParty:     rp,
+		userVerification: cmp.Or(settings.UserVerification, UserVerificationPreferred),
+		attestation:      cmp.Or(settings.Attestation, AttestationNone),
+	}
+
+	sessionID, err := service.createSession(s)
+	if err != nil {
+		return "", nil, err
+	}
+
+	options := &CreationOptions{
+		Challenge: encoding.EncodeToString(s.challenge),
+		RP:        relyingPartyEntity{ID: rp.ID, Name: rp.Name},
+		User: userEntity{
+			ID:          encoding.EncodeToString(UserHandle(user.ID)),
+			Name:        user.Username,
+			DisplayName: user.Username,
+		},
+		Timeout:            SessionExpiry.Milliseconds(),
+		ExcludeCredentials: descriptors(credentials),
+		AuthenticatorSelection: authenticatorSelection{
+			ResidentKey:      "preferred",
+			UserVerification: s.userVerification,
+		},
+		Attestation: s.attestation,
+	}
+
+	for _, alg := range supportedAlgorithms {
+		options.PubKeyCredParams = append(options.PubKeyCredParams, credentialParameter{Type: "public-key", Alg: alg})
+	}
+
+	return sessionID, options, nil
+}
+
+// FinishRegistration verifies the response of the authenticator and returns the credential to persist
+func (service *Service) FinishRegistration(sessionID string, userID portainer.UserID, response *CredentialResponse) (*portainer.WebAuthnCredential, error) {
+	s, err := service.consumeSession(sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !s.registration || s.userID != userID {
+		return nil, ErrInvalidSession
+	}
+
+	clientDataJSON, err := verifyClientData(s, response.Response.ClientDataJSON, "webauthn.create")
+	if err != nil {
+		return nil, err
+	}
+
+	attestationObject, err := encoding.DecodeString(response.Response.AttestationObject)
+	if err != nil {
+		return nil, verificationError("invalid attestation object encoding")
+	}
+
+	item, _, err := decodeCBOR(attestationObject)
+	if err != nil {
+		return nil, verificationError("invalid attestation object")
+	}
+
+	attestation, ok := item.(map[any]any)
+	if !ok {
+		return nil, verificationError("invalid attestation object")
+	}
+
+	format, _ := attestation["fmt"].(string)
+	statement, _ := attestation["attStmt"].(map[any]any)
+	authData, _ := attestation["authData"].([]byte)
+
+	data, err := parseAuthenticatorData(s, authData)
+	if err != nil {
+		return nil, err
+	}
+
+	if data.flags&flagAttestedData == 0 || data.credentialKey == nil {
+		return nil, verificationError("missing attested credential data")
+	}
+
+	clientDataHash := sha256.Sum256(clientDataJSON)
+	if err := verifyAttestation(format, statement, authData, clientDataHash[:], data, s.attestation); err != nil {
+		return nil, err
+	}
+
+	return &portainer.WebAuthnCredential{
+		UserID:            userID,
+		CredentialID:      data.credentialID,
+		PublicKey:         data.rawCredentialKey,
+		SignCount:         data.signCount,
+		AAGUID:            data.aaguid,
+		AttestationFormat: format,
+		CreatedAt:         service.now().Unix(),
+	}, nil
+}
+
+// BeginLogin starts an authentication. When userID is 0 the user is identified by the discoverable credential
+// it picks on its authenticator, the user verification is then required
+func (service *Service) BeginLogin(rp RelyingParty, settings *portainer.WebAuthnSettings, userID portainer.UserID, credentials []portainer.WebAuthnCredential) (string, *RequestOptions, error) {
+	s := &session{
+		userID:           userID,
+		relyingParty:     rp,
+		userVerification: cmp.Or(settings.UserVerification, UserVerificationPreferred),
+	}
+
+	if userID == 0 {
+		s.userVerification = UserVerificationRequired
+	}
+
+	sessionID, err := service.createSession(s)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return sessionID, &RequestOptions{
+		Challenge:        encoding.EncodeToString(s.challenge),
+		Timeout:          SessionExpiry.Milliseconds(),
+		RPID:             rp.ID,
+		AllowCredentials: descriptors(credentials),
+		UserVerification: s.userVerification,
+	}, nil
+}
+
+// FinishLogin verifies the assertion of the authenticator and returns the credential it used, with its signature
+// counter and last use updated. The user of the session is returned as well, it is 0 for a discoverable login
+func (service *Service) FinishLogin(sessionID string, response *CredentialResponse, lookup func(credentialID []byte) (*portainer.WebAuthnCredential, error)) (*portainer.WebAuthnCredential, portainer.UserID, error) {
+	s, err := service.consumeSession(sessionID)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if s.registration {
+		return nil, 0, ErrInvalidSession
+	}
+
+	credentialID, err := encoding.DecodeString(response.RawID)
+	if err != nil || len(credentialID) == 0 {
+		return nil, 0, verificationError("invalid credential identifier")
+	}
+
+	credential, err := lookup(credentialID)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if s.userID != 0 && credential.UserID != s.userID {
+		return nil, 0, ErrUnknownCredential
+	}
+
+	if response.Response.UserHandle != "" {
+		userHandle, err := encoding.DecodeString(response.Response.UserHandle)
+		if err != nil || !bytes.Equal(userHandle, UserHandle(credential.UserID)) {
+			return nil, 0, verificationError("the user handle does not match the credential")
+		}
+	} else if s.userID == 0 {
+		return nil, 0, verificationError("missing user handle")
+	}
+
+	clientDataJSON, err := verifyClientData(s, response.Response.ClientDataJSON, "webauthn.get")
+	if err != nil {
+		return nil, 0, err
+	}
+
+	authData, err := encoding.DecodeString(response.Response.AuthenticatorData)
+	if err != nil {
+		return nil, 0, verificationError("invalid authenticator data encoding")
+	}
+
+	data, err := parseAuthenticatorData(s, authData)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	signature, err := encoding.DecodeString(response.Response.Signature)
+	if err != nil {
+		return nil, 0, verificationError("invalid signature encoding")
+	}
+
+	key, _, err := parsePublicKey(credential.PublicKey)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	clientDataHash := sha256.Sum256(clientDataJSON)
+	if err := key.verify(append(authData, clientDataHash[:]...), signature); err != nil {
+		return nil, 0, verificationError(err.Error())
+	}
+
+	// a counter that does not increase reveals a cloned authenticator, the authenticators without counter always send 0
+	if (data.signCount != 0 || credential.SignCount != 0) && data.signCount <= credential.SignCount {
+		return nil, 0, verificationError("the signature counter did not increase, the authenticator may be cloned")
+	}
+
+	credential.SignCount = data.signCount
+	credential.LastUsedAt = service.now().Unix()
+
+	return credential, s.userID, nil
+}
+
+// UserHandle returns the user handle of a user, stored by the authenticators along the discoverable credentials
+func UserHandle(userID portainer.UserID) []byte {
+	return binary.BigEndian.AppendUint64(nil, uint64(userID))
+}
+
+func (service *Service) createSession(s *session) (string, error) {
+	service.mu.Lock()
+	defer service.mu.Unlock()
+
+	now := service.now()
+	for id, pending := range service.sessions {
+		if now.After(pending.expiresAt) {
+			delete(service.sessions, id)
+		}
+	}
+
+	if len(service.sessions) >= maxSessions {
+		return "", ErrTooManySessions
+	}
+
+	s.challenge = make([]byte, challengeLen)
+	if _, err := rand.Read(s.challenge); err != nil {
+		return "", err
+	}
+
+	id := make([]byte, challengeLen)
+	if _, err := rand.Read(id); err != nil {
+		return "", err
+	}
+
+	sessionID := encoding.EncodeToString(id)
+
+	s.expiresAt = now.Add(SessionExpiry)
+	service.sessions[sessionID] = s
+
+	return sessionID, nil
+}
+
+// consumeSession returns the pending session, a session can only be used once
+func (service *Service) consumeSession(sessionID string) (*session, error) {
+	service.mu.Lock()
+	defer service.mu.Unlock()
+
+	s, ok := service.sessions[sessionID]
+	if !ok {
+		return nil, ErrInvalidSession
+	}
+
+	delete(service.sessions, sessionID)
+
+	if service.now().After(s.expiresAt) {
+		return nil, ErrInvalidSession
+	}
+
+	return s, nil
+}
+
+type clientData struct {
+	Type      string `json:"type"`
+	Challenge string `json:"challenge"`
+	Origin    string `json:"origin"`
+}
+
+// verifyClientData checks the client data of the response against the session and returns its raw bytes
+func verifyClientData(s *session, encoded, ceremony string) ([]byte, error) {
+	raw, err := encoding.DecodeString(encoded)
+	if err != nil {
+		return nil, verificationError("invalid client data encoding")
+	}
+
+	var data clientData
+	if err := json.Unmarshal(raw, &data); err != nil {
+		return nil, verificationError("invalid client data")
+	}
+
+	if data.Type != ceremony {
+		return nil, verificationError("unexpected ceremony " + data.Type)
+	}
+
+	challenge, err := encoding.DecodeString(data.Challenge)
+	if err != nil || subtle.ConstantTimeCompare(challenge, s.challenge) != 1 {
+		return nil, verificationError("the challenge does not match")
+	}
+
+	if !slices.Contains(s.relyingParty.Origins, strings.TrimSuffix(data.Origin, "/")) {
+		return nil, verificationError("unexpected origin " + data.Origin)
+	}
+
+	return raw, nil
+}
+
+type authenticatorData struct {
+	flags            byte
+	signCount        uint32
+	aaguid           []byte
+	credentialID     []byte
+	credentialKey    *publicKey
+	rawCredentialKey []byte
+}
+
+// parseAuthenticatorData parses and checks the authenticator data against the session
+func parseAuthenticatorData(s *session, raw []byte) (*authenticatorData, error) {
+	if len(raw) < authenticatorDataMinLen {
+		return nil, verificationError("invalid authenticator data")
+	}
+
+	rpIDHash := sha256.Sum256([]byte(s.relyingParty.ID))
+	if subtle.ConstantTimeCompare(raw[:32], rpIDHash[:]) != 1 {
+		return nil, verificationError("the relying party does not match")
+	}
+
+	data := &authenticatorData{
+		flags:     raw[32],
+		signCount: binary.BigEndian.Uint32(raw[33:37]),
+	}
+
+	if data.flags&flagUserPresent == 0 {
+		return nil, verificationError("the user is not present")
+	}
+
+	if s.userVerification == UserVerificationRequired && data.flags&flagUserVerified == 0 {
+		return nil, verificationError("the user is not verified")
+	}
+
+	if data.flags&flagAttestedData == 0 {
+		return data, nil
+	}
+
+	rest := raw[authenticatorDataMinLen:]
+	if len(rest) < 18 {
+		return nil, verificationError("invalid attested credential data")
+	}
+
+	data.aaguid = append([]byte(nil), rest[:16]...)
+	idLen := int(binary.BigEndian.Uint16(rest[16:18]))
+	rest = rest[18:]
+
+	if idLen == 0 || idLen > 1023 || len(rest) < idLen {
+		return nil, verificationError("invalid credential identifier")
+	}
+
+	data.credentialID = append([]byte(nil), rest[:idLen]...)
+	rest = rest[idLen:]
+
+	key, remaining, err := parsePublicKey(rest)
+	if err != nil {
+		return nil, verificationError(err.Error())
+	}
+
+	data.credentialKey = key
+	data.rawCredentialKey = append([]byte(nil), rest[:len(rest)-len(remaining)]...)
+
+	return data, nil
+}
+
+func descriptors(credentials []portainer.WebAuthnCredential) []CredentialDescriptor {
+	result := make([]CredentialDescriptor, 0, len(credentials))
+	for _, credential := range credentials {
+		result = append(result, CredentialDescriptor{Type: "public-key", ID: encoding.EncodeToString(credential.CredentialID)})
+	}
+
+	return result
+}
+
+func verificationError(reason string) error {
+	return errors.Wrap(ErrVerification, reason)
+}
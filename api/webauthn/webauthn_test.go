@@ -0,0 +1,183 @@
+package webauthn
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"testing"
+
+	portainer "github.com/portainer/portainer/api"
+
+	"github.com/segmentio/encoding/json"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeAuthenticator signs the ceremonies like a security key holding a single ES256 credential
+type fakeAuthenticator struct {
+	key          *ecdsa.PrivateKey
+	credentialID []byte
+	signCount    uint32
+}
+
+func newFakeAuthenticator(t *testing.T) *fakeAuthenticator {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	return &fakeAuthenticator{key: key, credentialID: []byte("credential-1")}
+}
+
+func (a *fakeAuthenticator) coseKey() []byte {
+	x, y := make([]byte, 32), make([]byte, 32)
+	a.key.X.FillBytes(x)
+	a.key.Y.FillBytes(y)
+
+	// {1: 2, 3: -7, -1: 1, -2: x, -3: y}
+	b := []byte{0xa5, 0x01, 0x02, 0x03, 0x26, 0x20, 0x01, 0x21, 0x58, 0x20}
+	b = append(b, x...)
+	b = append(b, 0x22, 0x58, 0x20)
+
+	return append(b, y...)
+}
+
+func (a *fakeAuthenticator) authData(rpID string, flags byte, attested bool) []byte {
+	rpIDHash := sha256.Sum256([]byte(rpID))
+	data := append(rpIDHash[:], flags)
+	data = binary.BigEndian.AppendUint32(data, a.signCount)
+
+	if attested {
+		data = append(data, make([]byte, 16)...)
+		data = binary.BigEndian.AppendUint16(data, uint16(len(a.credentialID)))
+		data = append(data, a.credentialID...)
+		data = append(data, a.coseKey()...)
+	}
+
+	return data
+}
+
+func clientDataJSON(t *testing.T, ceremony, challenge, origin string) []byte {
+	data, err := json.Marshal(clientData{Type: ceremony, Challenge: challenge, Origin: origin})
+	require.NoError(t, err)
+
+	return data
+}
+
+func (a *fakeAuthenticator) register(t *testing.T, options *CreationOptions, origin string) *CredentialResponse {
+	authData := a.authData(options.RP.ID, flagUserPresent|flagUserVerified|flagAttestedData, true)
+
+	// {"fmt": "none", "attStmt": {}, "authData": authData}
+	attestationObject := []byte{0xa3, 0x63, 'f', 'm', 't', 0x64, 'n', 'o', 'n', 'e', 0x67, 'a', 't', 't', 'S', 't', 'm', 't', 0xa0}
+	attestationObject = append(attestationObject, 0x68, 'a', 'u', 't', 'h', 'D', 'a', 't', 'a', 0x59)
+	attestationObject = binary.BigEndian.AppendUint16(attestationObject, uint16(len(authData)))
+	attestationObject = append(attestationObject, authData...)
+
+	response := &CredentialResponse{RawID: encoding.EncodeToString(a.credentialID), Type: "public-key"}
+	response.Response.ClientDataJSON = encoding.EncodeToString(clientDataJSON(t, "webauthn.create", options.Challenge, origin))
+	response.Response.AttestationObject = encoding.EncodeToString(attestationObject)
+
+	return response
+}
+
+func (a *fakeAuthenticator) assert(t *testing.T, options *RequestOptions, origin string, userID portainer.UserID) *CredentialResponse {
+	a.signCount++
+
+	authData := a.authData(options.RPID, flagUserPresent|flagUserVerified, false)
+	clientData := clientDataJSON(t, "webauthn.get", options.Challenge, origin)
+	clientDataHash := sha256.Sum256(clientData)
+	digest := sha256.Sum256(append(authData, clientDataHash[:]...))
+
+	signature, err := ecdsa.SignASN1(rand.Reader, a.key, digest[:])
+	require.NoError(t, err)
+
+	response := &CredentialResponse{RawID: encoding.EncodeToString(a.credentialID), Type: "public-key"}
+	response.Response.ClientDataJSON = encoding.EncodeToString(clientData)
+	response.Response.AuthenticatorData = encoding.EncodeToString(authData)
+	response.Response.Signature = encoding.EncodeToString(signature)
+	response.Response.UserHandle = encoding.EncodeToString(UserHandle(userID))
+
+	return response
+}
+
+func TestRegistrationAndLogin(t *testing.T) {
+	is := require.New(t)
+
+	service := NewService()
+	authenticator := newFakeAuthenticator(t)
+	user := &portainer.User{ID: 3, Username: "alice"}
+	settings := &portainer.WebAuthnSettings{Enabled: true}
+	rp := RelyingParty{ID: "portainer.example.com", Name: "Portainer", Origins: []string{"https://portainer.example.com"}}
+
+	sessionID, creationOptions, err := service.BeginRegistration(rp, settings, user, nil)
+	is.NoError(err)
+	is.Equal("none", creationOptions.Attestation)
+
+	response := authenticator.register(t, creationOptions, "https://portainer.example.com")
+
+	_, err = service.FinishRegistration(sessionID, 4, response)
+	is.ErrorIs(err, ErrInvalidSession, "the session belongs to another user")
+
+	sessionID, creationOptions, err = service.BeginRegistration(rp, settings, user, nil)
+	is.NoError(err)
+
+	credential, err := service.FinishRegistration(sessionID, user.ID, authenticator.register(t, creationOptions, "https://portainer.example.com"))
+	is.NoError(err)
+	is.Equal(authenticator.credentialID, credential.CredentialID)
+	is.Equal("none", credential.AttestationFormat)
+
+	_, err = service.FinishRegistration(sessionID, user.ID, response)
+	is.ErrorIs(err, ErrInvalidSession, "a session can only be used once")
+
+	lookup := func(credentialID []byte) (*portainer.WebAuthnCredential, error) {
+		if string(credentialID) != string(credential.CredentialID) {
+			return nil, ErrUnknownCredential
+		}
+
+		copied := *credential
+
+		return &copied, nil
+	}
+
+	// passwordless login with a discoverable credential
+	sessionID, requestOptions, err := service.BeginLogin(rp, settings, 0, nil)
+	is.NoError(err)
+	is.Equal(UserVerificationRequired, requestOptions.UserVerification)
+
+	used, userID, err := service.FinishLogin(sessionID, authenticator.assert(t, requestOptions, "https://portainer.example.com", user.ID), lookup)
+	is.NoError(err)
+	is.Equal(portainer.UserID(0), userID)
+	is.Equal(user.ID, used.UserID)
+	is.Equal(uint32(1), used.SignCount)
+
+	credential.SignCount = used.SignCount
+
+	// second factor login
+	sessionID, requestOptions, err = service.BeginLogin(rp, settings, user.ID, []portainer.WebAuthnCredential{*credential})
+	is.NoError(err)
+	is.Len(requestOptions.AllowCredentials, 1)
+
+	_, _, err = service.FinishLogin(sessionID, authenticator.assert(t, requestOptions, "https://evil.example.com", user.ID), lookup)
+	is.ErrorIs(err, ErrVerification, "the origin must be checked")
+
+	sessionID, requestOptions, err = service.BeginLogin(rp, settings, user.ID, []portainer.WebAuthnCredential{*credential})
+	is.NoError(err)
+
+	authenticator.signCount = 0
+	_, _, err = service.FinishLogin(sessionID, authenticator.assert(t, requestOptions, "https://portainer.example.com", user.ID), lookup)
+	is.ErrorIs(err, ErrVerification, "a signature counter going backwards must be rejected")
+}
+
+func TestDecodeCBOR(t *testing.T) {
+	is := require.New(t)
+
+	// {"a": [1, -2, h'0102'], 10: true}
+	item, rest, err := decodeCBOR([]byte{0xa2, 0x61, 'a', 0x83, 0x01, 0x21, 0x42, 0x01, 0x02, 0x0a, 0xf5, 0xff})
+	is.NoError(err)
+	is.Equal([]byte{0xff}, rest)
+	is.Equal(map[any]any{"a": []any{int64(1), int64(-2), []byte{0x01, 0x02}}, int64(10): true}, item)
+
+	for _, invalid := range [][]byte{{}, {0x5a, 0xff, 0xff, 0xff, 0xff}, {0x9f}, {0x84, 0x01}} {
+		_, _, err := decodeCBOR(invalid)
+		is.Error(err, "%x", invalid)
+	}
+}
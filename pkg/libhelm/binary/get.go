@@ -1,6 +1,8 @@
 package binary
 
 import (
+	"strconv"
+
 	"github.com/pkg/errors"
 	"github.com/portainer/portainer/pkg/libhelm/options"
 )
@@ -19,6 +21,16 @@ func (hbpm *helmBinaryPackageManager) Get(getOpts options.GetOptions) ([]byte, e
 	if getOpts.Namespace != "" {
 		args = append(args, "--namespace", getOpts.Namespace)
 	}
+	if getOpts.Revision > 0 {
+		args = append(args, "--revision", strconv.Itoa(getOpts.Revision))
+	}
+	if getOpts.ReleaseResource == options.GetValues {
+		// the yaml output omits the "USER-SUPPLIED VALUES:" header of the default table output
+		args = append(args, "--output", "yaml")
+		if getOpts.AllValues {
+			args = append(args, "--all")
+		}
+	}
 
 	result, err := hbpm.runWithKubeConfig("get", args, getOpts.KubernetesClusterAccess, getOpts.Env)
 	if err != nil {
@@ -33,6 +33,32 @@ func (hbpm *helmBinaryPackageManager) runWithKubeConfig(command string, args []s
 	return hbpm.run(command, cmdArgs, env)
 }
 
+// repoAuthArgs returns the CLI arguments authenticating to the repository of a chart
+func repoAuthArgs(auth *options.RepositoryAuthentication) []string {
+	if auth == nil {
+		return nil
+	}
+
+	args := []string{}
+	if auth.Username != "" {
+		args = append(args, "--username", auth.Username, "--password", auth.Password)
+	}
+	if auth.CAFile != "" {
+		args = append(args, "--ca-file", auth.CAFile)
+	}
+	if auth.CertFile != "" {
+		args = append(args, "--cert-file", auth.CertFile)
+	}
+	if auth.KeyFile != "" {
+		args = append(args, "--key-file", auth.KeyFile)
+	}
+	if auth.InsecureSkipTLSVerify {
+		args = append(args, "--insecure-skip-tls-verify")
+	}
+
+	return args
+}
+
 // run will execute helm command against the provided Kubernetes cluster.
 // The endpointId and authToken are dynamic params (based on the user) that allow helm to execute commands
 // in the context of the current user against specified k8s cluster.
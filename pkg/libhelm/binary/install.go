@@ -20,6 +20,7 @@ func (hbpm *helmBinaryPackageManager) Install(installOpts options.InstallOptions
 		"--repo", installOpts.Repo,
 		"--output", "json",
 	}
+	args = append(args, repoAuthArgs(installOpts.RepoAuth)...)
 	if installOpts.Namespace != "" {
 		args = append(args, "--namespace", installOpts.Namespace)
 	}
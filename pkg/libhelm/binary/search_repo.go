@@ -41,6 +41,8 @@ type Entry struct {
 	Urls        []string     `yaml:"urls" json:"urls"`
 	Version     string       `yaml:"version" json:"version"`
 	Icon        string       `yaml:"icon" json:"icon,omitempty"`
+	// Repo is the URL of the repository of the chart, set when the charts of several repositories are merged
+	Repo string `yaml:"-" json:"repo,omitempty"`
 }
 
 // SearchRepo downloads the `index.yaml` file for specified repo, parses it and returns JSON to caller.
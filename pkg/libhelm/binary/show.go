@@ -19,6 +19,7 @@ func (hbpm *helmBinaryPackageManager) Show(showOpts options.ShowOptions) ([]byte
 		showOpts.Chart,
 		"--repo", showOpts.Repo,
 	}
+	args = append(args, repoAuthArgs(showOpts.RepoAuth)...)
 
 	result, err := hbpm.run("show", args, showOpts.Env)
 	if err != nil {
@@ -1,9 +1,11 @@
 package test
 
 import (
+	"strconv"
 	"strings"
 
 	"github.com/portainer/portainer/pkg/libhelm"
+	"github.com/portainer/portainer/pkg/libhelm/binary"
 	"github.com/portainer/portainer/pkg/libhelm/options"
 	"github.com/portainer/portainer/pkg/libhelm/release"
 
@@ -20,8 +22,9 @@ const (
 )
 
 const (
-	MockReleaseHooks    = "mock-release-hooks"
-	MockReleaseManifest = "mock-release-manifest"
+	MockReleaseHooks = "mock-release-hooks"
+	// MockReleaseManifest is a valid manifest, the handlers labeling the resources of the releases
+	MockReleaseManifest = "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: mock-release-manifest\n"
 	MockReleaseNotes    = "mock-release-notes"
 	MockReleaseValues   = "mock-release-values"
 )
@@ -42,6 +45,7 @@ func newMockReleaseElement(installOpts options.InstallOptions) *release.ReleaseE
 	return &release.ReleaseElement{
 		Name:       installOpts.Name,
 		Namespace:  installOpts.Namespace,
+		Revision:   "1",
 		Updated:    "date/time",
 		Status:     "deployed",
 		Chart:      installOpts.Chart,
@@ -50,9 +54,12 @@ func newMockReleaseElement(installOpts options.InstallOptions) *release.ReleaseE
 }
 
 func newMockRelease(re *release.ReleaseElement) *release.Release {
+	revision, _ := strconv.Atoi(re.Revision)
+
 	return &release.Release{
 		Name:      re.Name,
 		Namespace: re.Namespace,
+		Version:   revision,
 	}
 }
 
@@ -114,6 +121,76 @@ func (hpm *helmMockPackageManager) Uninstall(uninstallOpts options.UninstallOpti
 	return nil
 }
 
+// History of a helm release, one revision per install, upgrade or rollback (not thread safe)
+func (hpm *helmMockPackageManager) History(historyOpts options.HistoryOptions) ([]release.ReleaseHistoryElement, error) {
+	rel := findMockRelease(historyOpts.Name, historyOpts.Namespace)
+	if rel == nil {
+		return nil, errors.New("release: not found")
+	}
+
+	revision, _ := strconv.Atoi(rel.Revision)
+
+	history := []release.ReleaseHistoryElement{}
+	for i := 1; i <= revision; i++ {
+		status := "superseded"
+		if i == revision {
+			status = rel.Status
+		}
+
+		history = append(history, release.ReleaseHistoryElement{Revision: i, Updated: rel.Updated, Status: status, Chart: rel.Chart, AppVersion: rel.AppVersion})
+	}
+
+	if historyOpts.Max > 0 && len(history) > historyOpts.Max {
+		history = history[len(history)-historyOpts.Max:]
+	}
+
+	return history, nil
+}
+
+// Upgrade a helm release (not thread safe)
+func (hpm *helmMockPackageManager) Upgrade(upgradeOpts options.UpgradeOptions) (*release.Release, error) {
+	rel := findMockRelease(upgradeOpts.Name, upgradeOpts.Namespace)
+	if rel == nil {
+		return nil, errors.New("release: not found")
+	}
+
+	rel.Chart = upgradeOpts.Chart
+	bumpMockRevision(rel)
+
+	return newMockRelease(rel), nil
+}
+
+// Rollback a helm release (not thread safe)
+func (hpm *helmMockPackageManager) Rollback(rollbackOpts options.RollbackOptions) error {
+	rel := findMockRelease(rollbackOpts.Name, rollbackOpts.Namespace)
+	if rel == nil {
+		return errors.New("release: not found")
+	}
+
+	if revision, _ := strconv.Atoi(rel.Revision); rollbackOpts.Revision >= revision {
+		return errors.New("release has no revision " + strconv.Itoa(rollbackOpts.Revision))
+	}
+
+	bumpMockRevision(rel)
+
+	return nil
+}
+
+func findMockRelease(name, namespace string) *release.ReleaseElement {
+	for i, rel := range mockCharts {
+		if rel.Name == name && rel.Namespace == namespace {
+			return &mockCharts[i]
+		}
+	}
+
+	return nil
+}
+
+func bumpMockRevision(rel *release.ReleaseElement) {
+	revision, _ := strconv.Atoi(rel.Revision)
+	rel.Revision = strconv.Itoa(revision + 1)
+}
+
 // List a helm chart (not thread safe)
 func (hpm *helmMockPackageManager) List(listOpts options.ListOptions) ([]release.ReleaseElement, error) {
 	return mockCharts, nil
@@ -146,7 +223,7 @@ func (hbpm *helmMockPackageManager) SearchRepo(searchRepoOpts options.SearchRepo
 	// Always return the same repo data no matter what
 	reader := strings.NewReader(mockPortainerIndex)
 
-	var file release.File
+	var file binary.File
 	err := yaml.NewDecoder(reader).Decode(&file)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to decode index file")
@@ -0,0 +1,57 @@
+package binary
+
+import (
+	"github.com/portainer/portainer/pkg/libhelm/options"
+	"github.com/portainer/portainer/pkg/libhelm/release"
+
+	"github.com/pkg/errors"
+	"github.com/segmentio/encoding/json"
+)
+
+var errRequiredUpgradeOptions = errors.New("release name, chart and repo are required")
+
+// Upgrade runs `helm upgrade` with specified upgrade options.
+// The upgrade options translate to CLI arguments which are passed in to the helm binary when executing upgrade.
+func (hbpm *helmBinaryPackageManager) Upgrade(upgradeOpts options.UpgradeOptions) (*release.Release, error) {
+	if upgradeOpts.Name == "" || upgradeOpts.Chart == "" || upgradeOpts.Repo == "" {
+		return nil, errRequiredUpgradeOptions
+	}
+
+	args := []string{
+		upgradeOpts.Name,
+		upgradeOpts.Chart,
+		"--repo", upgradeOpts.Repo,
+		"--output", "json",
+	}
+	args = append(args, repoAuthArgs(upgradeOpts.RepoAuth)...)
+	if upgradeOpts.Namespace != "" {
+		args = append(args, "--namespace", upgradeOpts.Namespace)
+	}
+	if upgradeOpts.Version != "" {
+		args = append(args, "--version", upgradeOpts.Version)
+	}
+	if upgradeOpts.ValuesFile != "" {
+		args = append(args, "--values", upgradeOpts.ValuesFile)
+	}
+	if upgradeOpts.ReuseValues {
+		args = append(args, "--reuse-values")
+	}
+	if upgradeOpts.Wait {
+		args = append(args, "--wait")
+	}
+	if upgradeOpts.PostRenderer != "" {
+		args = append(args, "--post-renderer", upgradeOpts.PostRenderer)
+	}
+
+	result, err := hbpm.runWithKubeConfig("upgrade", args, upgradeOpts.KubernetesClusterAccess, upgradeOpts.Env)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to run helm upgrade on specified args")
+	}
+
+	response := &release.Release{}
+	if err := json.Unmarshal(result, &response); err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal helm upgrade response to Release struct")
+	}
+
+	return response, nil
+}
@@ -13,9 +13,13 @@ const (
 )
 
 type GetOptions struct {
-	Name                    string
-	Namespace               string
-	ReleaseResource         releaseResource
+	Name            string
+	Namespace       string
+	ReleaseResource releaseResource
+	// Revision of the release, the latest one when 0
+	Revision int
+	// AllValues returns the computed values instead of the user supplied ones with GetValues
+	AllValues               bool
 	KubernetesClusterAccess *KubernetesClusterAccess
 
 	Env []string
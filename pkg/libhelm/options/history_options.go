@@ -0,0 +1,12 @@
+package options
+
+// HistoryOptions are portainer supported options for `helm history`
+type HistoryOptions struct {
+	Name      string
+	Namespace string
+	// Max is the maximum number of revisions returned, helm defaults to 256 when 0
+	Max                     int
+	KubernetesClusterAccess *KubernetesClusterAccess
+
+	Env []string
+}
@@ -5,6 +5,7 @@ type InstallOptions struct {
 	Chart                   string
 	Namespace               string
 	Repo                    string
+	RepoAuth                *RepositoryAuthentication
 	Wait                    bool
 	ValuesFile              string
 	PostRenderer            string
@@ -0,0 +1,13 @@
+package options
+
+// RepositoryAuthentication is the authentication to a private chart repository, passed to the helm commands using a
+// chart with the --repo flag
+type RepositoryAuthentication struct {
+	Username string
+	Password string
+	// Files of the PEM encoded certificates and key
+	CAFile                string
+	CertFile              string
+	KeyFile               string
+	InsecureSkipTLSVerify bool
+}
@@ -0,0 +1,13 @@
+package options
+
+// RollbackOptions are portainer supported options for `helm rollback`
+type RollbackOptions struct {
+	Name      string
+	Namespace string
+	// Revision to roll back to, the previous one when 0
+	Revision                int
+	Wait                    bool
+	KubernetesClusterAccess *KubernetesClusterAccess
+
+	Env []string
+}
@@ -0,0 +1,21 @@
+package options
+
+// UpgradeOptions are portainer supported options for `helm upgrade`
+type UpgradeOptions struct {
+	Name      string
+	Chart     string
+	Namespace string
+	Repo      string
+	RepoAuth  *RepositoryAuthentication
+	// Version of the chart, the latest one when empty
+	Version    string
+	ValuesFile string
+	// ReuseValues merges the values of the previous revision with the ones of the values file
+	ReuseValues             bool
+	Wait                    bool
+	PostRenderer            string
+	KubernetesClusterAccess *KubernetesClusterAccess
+
+	// Optional environment vars to pass when running helm
+	Env []string
+}
@@ -21,6 +21,7 @@ import (
 	"github.com/docker/cli/cli/flags"
 	"github.com/docker/compose/v2/pkg/api"
 	"github.com/docker/compose/v2/pkg/compose"
+	"github.com/docker/docker/api/types/container"
 	"github.com/docker/docker/registry"
 	"github.com/rs/zerolog/log"
 )
@@ -33,10 +34,11 @@ func withCli(
 	ctx context.Context,
 	options libstack.Options,
 	cliFn func(context.Context, *command.DockerCli) error,
+	cliOpts ...command.CLIOption,
 ) error {
 	ctx = context.Background()
 
-	cli, err := command.NewDockerCli()
+	cli, err := command.NewDockerCli(cliOpts...)
 	if err != nil {
 		return fmt.Errorf("unable to create a Docker client: %w", err)
 	}
@@ -71,6 +73,7 @@ func withComposeService(
 	filePaths []string,
 	options libstack.Options,
 	composeFn func(api.Service, *types.Project) error,
+	cliOpts ...command.CLIOption,
 ) error {
 	return withCli(ctx, options, func(ctx context.Context, cli *command.DockerCli) error {
 		composeService := compose.NewComposeService(cli)
@@ -124,16 +127,25 @@ func withComposeService(
 		}
 
 		return composeFn(composeService, project)
-	})
+	}, cliOpts...)
 }
 
 // Deploy creates and starts containers
 func (c *ComposeDeployer) Deploy(ctx context.Context, filePaths []string, options libstack.DeployOptions) error {
 	return withComposeService(ctx, filePaths, options.Options, func(composeService api.Service, project *types.Project) error {
 		addServiceLabels(project, false, options.EdgeStackID)
+		applyScales(project, options.Scales)
 
 		project = project.WithoutUnnecessaryResources()
 
+		if options.ValidateDeviceRequests != nil {
+			for _, service := range project.Services {
+				if err := options.ValidateDeviceRequests(deviceRequests(service)); err != nil {
+					return fmt.Errorf("invalid device requests for the service %s: %w", service.Name, err)
+				}
+			}
+		}
+
 		var opts api.UpOptions
 		if options.ForceRecreate {
 			opts.Create.Recreate = api.RecreateForce
@@ -159,6 +171,28 @@ func (c *ComposeDeployer) Deploy(ctx context.Context, filePaths []string, option
 	})
 }
 
+// Build builds the images of the services that have a build section, writing the build output to options.Output
+func (c *ComposeDeployer) Build(ctx context.Context, filePaths []string, options libstack.BuildOptions) error {
+	var cliOpts []command.CLIOption
+	if options.Output != nil {
+		cliOpts = append(cliOpts, command.WithCombinedStreams(options.Output))
+	}
+
+	if err := withComposeService(ctx, filePaths, options.Options, func(composeService api.Service, project *types.Project) error {
+		return composeService.Build(ctx, project, api.BuildOptions{
+			Progress: "plain",
+			Pull:     options.Pull,
+			NoCache:  options.NoCache,
+		})
+	}, cliOpts...); err != nil {
+		return fmt.Errorf("compose build operation failed: %w", err)
+	}
+
+	log.Info().Msg("Stack build successful")
+
+	return nil
+}
+
 // Run runs the given service just once, without considering dependencies
 func (c *ComposeDeployer) Run(ctx context.Context, filePaths []string, serviceName string, options libstack.RunOptions) error {
 	return withComposeService(ctx, filePaths, options.Options, func(composeService api.Service, project *types.Project) error {
@@ -196,6 +230,32 @@ func (c *ComposeDeployer) Run(ctx context.Context, filePaths []string, serviceNa
 	})
 }
 
+// Scale creates or removes the containers of a service so that it runs the given number of them, the other services
+// are left untouched. Wraps `docker compose up --scale`
+func (c *ComposeDeployer) Scale(ctx context.Context, filePaths []string, serviceName string, replicas int, options libstack.Options) error {
+	if err := withComposeService(ctx, filePaths, options, func(composeService api.Service, project *types.Project) error {
+		service, ok := project.Services[serviceName]
+		if !ok {
+			return fmt.Errorf("%w: %s", libstack.ErrServiceNotFound, serviceName)
+		}
+
+		if service.ContainerName != "" && replicas > 1 {
+			return fmt.Errorf("%w: the service %s has a container name", libstack.ErrServiceNotScalable, serviceName)
+		}
+
+		addServiceLabels(project, false, 0)
+		applyScales(project, map[string]int{serviceName: replicas})
+
+		return composeService.Scale(ctx, project, api.ScaleOptions{Services: []string{serviceName}})
+	}); err != nil {
+		return fmt.Errorf("compose scale operation failed: %w", err)
+	}
+
+	log.Info().Msg("Stack scale successful")
+
+	return nil
+}
+
 // Remove stops and removes containers
 func (c *ComposeDeployer) Remove(ctx context.Context, projectName string, filePaths []string, options libstack.RemoveOptions) error {
 	if err := withCli(ctx, options.Options, func(ctx context.Context, cli *command.DockerCli) error {
@@ -318,6 +378,51 @@ func addServiceLabels(project *types.Project, oneOff bool, edgeStackID portainer
 	}
 }
 
+// applyScales overrides the number of containers of the services of a project, the scales of the services it does not
+// define are ignored
+func applyScales(project *types.Project, scales map[string]int) {
+	for name, replicas := range scales {
+		service, ok := project.Services[name]
+		if !ok {
+			continue
+		}
+
+		service.SetScale(replicas)
+		project.Services[name] = service
+	}
+}
+
+// deviceRequests returns the device requests of the containers of a service, from its GPUs and the devices it reserves
+func deviceRequests(service types.ServiceConfig) []container.DeviceRequest {
+	requests := []container.DeviceRequest{}
+
+	for _, gpus := range service.Gpus {
+		requests = append(requests, container.DeviceRequest{
+			Driver:       gpus.Driver,
+			Count:        int(gpus.Count),
+			DeviceIDs:    gpus.IDs,
+			Capabilities: [][]string{append(slices.Clone(gpus.Capabilities), "gpu")},
+			Options:      gpus.Options,
+		})
+	}
+
+	if service.Deploy == nil || service.Deploy.Resources.Reservations == nil {
+		return requests
+	}
+
+	for _, device := range service.Deploy.Resources.Reservations.Devices {
+		requests = append(requests, container.DeviceRequest{
+			Driver:       device.Driver,
+			Count:        int(device.Count),
+			DeviceIDs:    device.IDs,
+			Capabilities: [][]string{device.Capabilities},
+			Options:      device.Options,
+		})
+	}
+
+	return requests
+}
+
 func parseEnvironment(options libstack.Options) (map[string]string, error) {
 	env := make(map[string]string)
 
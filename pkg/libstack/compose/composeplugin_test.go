@@ -11,6 +11,7 @@ import (
 
 	"github.com/portainer/portainer/pkg/libstack"
 
+	"github.com/compose-spec/compose-go/v2/types"
 	"github.com/stretchr/testify/require"
 )
 
@@ -349,3 +350,35 @@ networks:
 		})
 	}
 }
+
+func TestScaleErrors(t *testing.T) {
+	dir := t.TempDir()
+
+	filePath := createFile(t, dir, "docker-compose.yml", `services:
+  web:
+    image: nginx:latest
+  db:
+    image: postgres:16
+    container_name: db`)
+
+	deployer := NewComposeDeployer()
+
+	err := deployer.Scale(context.Background(), []string{filePath}, "worker", 2, libstack.Options{ProjectName: "scaletest"})
+	require.ErrorIs(t, err, libstack.ErrServiceNotFound)
+
+	err = deployer.Scale(context.Background(), []string{filePath}, "db", 2, libstack.Options{ProjectName: "scaletest"})
+	require.ErrorIs(t, err, libstack.ErrServiceNotScalable)
+}
+
+func TestApplyScales(t *testing.T) {
+	project := &types.Project{Services: types.Services{
+		"web":    {Name: "web"},
+		"worker": {Name: "worker"},
+	}}
+
+	applyScales(project, map[string]int{"web": 3, "removed": 2})
+
+	require.Equal(t, 3, *project.Services["web"].Scale)
+	require.Nil(t, project.Services["worker"].Scale)
+	require.NotContains(t, project.Services, "removed")
+}
@@ -2,10 +2,13 @@ package libstack
 
 import (
 	"context"
+	"errors"
+	"io"
 
 	portainer "github.com/portainer/portainer/api"
 
 	configtypes "github.com/docker/cli/cli/config/types"
+	"github.com/docker/docker/api/types/container"
 )
 
 type Deployer interface {
@@ -16,13 +19,25 @@ type Deployer interface {
 	// if projectName is supplied filePaths will be ignored
 	Remove(ctx context.Context, projectName string, filePaths []string, options RemoveOptions) error
 	Pull(ctx context.Context, filePaths []string, options Options) error
+	// Build builds the images of the services that have a build section
+	Build(ctx context.Context, filePaths []string, options BuildOptions) error
 	Run(ctx context.Context, filePaths []string, serviceName string, options RunOptions) error
+	// Scale sets the number of containers of a service without redeploying the other services
+	Scale(ctx context.Context, filePaths []string, serviceName string, replicas int, options Options) error
 	Validate(ctx context.Context, filePaths []string, options Options) error
 	WaitForStatus(ctx context.Context, name string, status Status) WaitResult
 	Config(ctx context.Context, filePaths []string, options Options) ([]byte, error)
 	GetExistingEdgeStacks(ctx context.Context) ([]EdgeStack, error)
 }
 
+var (
+	// ErrServiceNotFound is returned when a service is not defined by the stack files
+	ErrServiceNotFound = errors.New("service not found")
+	// ErrServiceNotScalable is returned when a service cannot run more than one container, such as the services with a
+	// container name
+	ErrServiceNotScalable = errors.New("service cannot be scaled")
+)
+
 type Status string
 
 const (
@@ -69,6 +84,10 @@ type DeployOptions struct {
 	AbortOnContainerExit bool
 	RemoveOrphans        bool
 	EdgeStackID          portainer.EdgeStackID
+	// Scales overrides the number of containers of the services, by service name
+	Scales map[string]int
+	// ValidateDeviceRequests checks the device requests of each service, such as its GPUs, before the deployment
+	ValidateDeviceRequests func(requests []container.DeviceRequest) error
 }
 
 type RunOptions struct {
@@ -81,6 +100,16 @@ type RunOptions struct {
 	Detached bool
 }
 
+type BuildOptions struct {
+	Options
+	// Output receives the build output, the standard streams are used when it is nil
+	Output io.Writer
+	// Pull always attempts to pull a newer version of the base images
+	Pull bool
+	// NoCache disables the use of the build cache
+	NoCache bool
+}
+
 type RemoveOptions struct {
 	Options
 
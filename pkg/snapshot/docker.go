@@ -10,6 +10,7 @@ import (
 
 	portainer "github.com/portainer/portainer/api"
 	"github.com/portainer/portainer/api/docker/consts"
+	"github.com/portainer/portainer/api/docker/gpu"
 	edgeutils "github.com/portainer/portainer/pkg/edge"
 	networkingutils "github.com/portainer/portainer/pkg/networking"
 
@@ -24,7 +25,19 @@ import (
 	"github.com/segmentio/encoding/json"
 )
 
+// CreateDockerSnapshot creates the snapshot of the Docker or Podman engine a local Docker client is connected to
 func CreateDockerSnapshot(cli *client.Client) (*portainer.DockerSnapshot, error) {
+	libpod, err := NewLibpodClient(cli, "http", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return CreateDockerSnapshotWithLibpod(cli, libpod)
+}
+
+// CreateDockerSnapshotWithLibpod creates the snapshot of a Docker or Podman engine, the pods and the rootless mode of
+// the Podman engines are retrieved from their libpod API
+func CreateDockerSnapshotWithLibpod(cli *client.Client, libpod *LibpodClient) (*portainer.DockerSnapshot, error) {
 	if _, err := cli.Ping(context.Background()); err != nil {
 		return nil, err
 	}
@@ -35,6 +48,28 @@ func CreateDockerSnapshot(cli *client.Client) (*portainer.DockerSnapshot, error)
 		log.Warn().Err(err).Msg("unable to snapshot engine information")
 	}
 
+	if err := dockerSnapshotVersion(dockerSnapshot, cli); err != nil {
+		log.Warn().Err(err).Msg("unable to snapshot engine version")
+	}
+
+	// the version components do not name Podman for all its releases, the libpod API is only served by Podman
+	if !dockerSnapshot.IsPodman {
+		dockerSnapshot.IsPodman = libpod.Ping(context.Background())
+	}
+
+	if dockerSnapshot.IsPodman {
+		// Podman has no Swarm mode
+		dockerSnapshot.Swarm = false
+
+		if err := podmanSnapshotInfo(dockerSnapshot, libpod); err != nil {
+			log.Warn().Err(err).Msg("unable to snapshot Podman information")
+		}
+
+		if err := podmanSnapshotPods(dockerSnapshot, libpod); err != nil {
+			log.Warn().Err(err).Msg("unable to snapshot Podman pods")
+		}
+	}
+
 	if dockerSnapshot.Swarm {
 		if err := dockerSnapshotSwarmServices(dockerSnapshot, cli); err != nil {
 			log.Warn().Err(err).Msg("unable to snapshot Swarm services")
@@ -61,10 +96,6 @@ func CreateDockerSnapshot(cli *client.Client) (*portainer.DockerSnapshot, error)
 		log.Warn().Err(err).Msg("unable to snapshot networks")
 	}
 
-	if err := dockerSnapshotVersion(dockerSnapshot, cli); err != nil {
-		log.Warn().Err(err).Msg("unable to snapshot engine version")
-	}
-
 	dockerSnapshot.Time = time.Now().Unix()
 
 	return dockerSnapshot, nil
@@ -80,6 +111,7 @@ func dockerSnapshotInfo(snapshot *portainer.DockerSnapshot, cli *client.Client)
 	snapshot.DockerVersion = info.ServerVersion
 	snapshot.TotalCPU = info.NCPU
 	snapshot.TotalMemory = info.MemTotal
+	snapshot.Gpus = gpu.FromGenericResources(info.GenericResources)
 	snapshot.SnapshotRaw.Info = info
 
 	return nil
@@ -170,7 +202,7 @@ func dockerSnapshotContainers(snapshot *portainer.DockerSnapshot, cli *client.Cl
 		var gpuOptions *_container.DeviceRequest
 
 		for _, deviceRequest := range response.HostConfig.Resources.DeviceRequests {
-			if deviceRequest.Driver == "nvidia" || deviceRequest.Capabilities[0][0] == "gpu" {
+			if gpu.IsRequest(deviceRequest) {
 				gpuOptions = &deviceRequest
 			}
 		}
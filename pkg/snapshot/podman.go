@@ -0,0 +1,147 @@
+package snapshot
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	portainer "github.com/portainer/portainer/api"
+
+	"github.com/docker/docker/client"
+	"github.com/segmentio/encoding/json"
+)
+
+// libpodAPIVersion is the version of the libpod API the requests are sent to, its ping, info and pods endpoints did
+// not change since Podman 4
+const libpodAPIVersion = "v4.0.0"
+
+// libpodAPIVersionHeader is the header of the answers of the libpod API to its pings
+const libpodAPIVersionHeader = "Libpod-Api-Version"
+
+// LibpodClient sends requests to the libpod API, served by the Podman engines next to their Docker compatible API
+type LibpodClient struct {
+	HTTPClient *http.Client
+	// URL is the base URL of the engine
+	URL string
+	// Headers are added to each request, such as the signature of the requests forwarded by an agent
+	Headers map[string]string
+}
+
+// LibpodInfo is the part of the information of a Podman engine kept in the snapshots
+type LibpodInfo struct {
+	Host struct {
+		RemoteSocket struct {
+			Path   string `json:"path"`
+			Exists bool   `json:"exists"`
+		} `json:"remoteSocket"`
+		Security struct {
+			Rootless bool `json:"rootless"`
+		} `json:"security"`
+	} `json:"host"`
+}
+
+// NewLibpodClient returns a client of the libpod API of the engine a Docker client is connected to, through the same
+// transport. The scheme is used for the engines reached over TCP
+func NewLibpodClient(cli *client.Client, scheme string, headers map[string]string) (*LibpodClient, error) {
+	hostURL, err := client.ParseHostURL(cli.DaemonHost())
+	if err != nil {
+		return nil, err
+	}
+
+	host := hostURL.Host
+	if hostURL.Scheme == "unix" || hostURL.Scheme == "npipe" {
+		// the transport dials the local socket whatever the host of the requests
+		host = client.DummyHost
+		scheme = "http"
+	}
+
+	return &LibpodClient{
+		HTTPClient: cli.HTTPClient(),
+		URL:        scheme + "://" + host + hostURL.Path,
+		Headers:    headers,
+	}, nil
+}
+
+func (c *LibpodClient) get(ctx context.Context, path string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.URL+"/"+libpodAPIVersion+"/libpod"+path, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	for name, value := range c.Headers {
+		req.Header.Set(name, value)
+	}
+
+	return c.HTTPClient.Do(req)
+}
+
+func (c *LibpodClient) getJSON(ctx context.Context, path string, v any) error {
+	resp, err := c.get(ctx, path)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status code %d from the libpod API", resp.StatusCode)
+	}
+
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+// Ping returns whether the engine serves the libpod API, which tells the Podman engines apart from the Docker ones
+func (c *LibpodClient) Ping(ctx context.Context) bool {
+	resp, err := c.get(ctx, "/_ping")
+	if err != nil {
+		return false
+	}
+	resp.Body.Close()
+
+	return resp.StatusCode == http.StatusOK && resp.Header.Get(libpodAPIVersionHeader) != ""
+}
+
+// Info returns the information of the Podman engine
+func (c *LibpodClient) Info(ctx context.Context) (*LibpodInfo, error) {
+	var info LibpodInfo
+	if err := c.getJSON(ctx, "/info", &info); err != nil {
+		return nil, err
+	}
+
+	return &info, nil
+}
+
+// Pods returns the pods of the Podman engine
+func (c *LibpodClient) Pods(ctx context.Context) ([]portainer.PodmanPod, error) {
+	var pods []portainer.PodmanPod
+	if err := c.getJSON(ctx, "/pods/json", &pods); err != nil {
+		return nil, err
+	}
+
+	return pods, nil
+}
+
+func podmanSnapshotInfo(snapshot *portainer.DockerSnapshot, libpod *LibpodClient) error {
+	info, err := libpod.Info(context.Background())
+	if err != nil {
+		return err
+	}
+
+	snapshot.PodmanRootless = info.Host.Security.Rootless
+	if info.Host.RemoteSocket.Exists {
+		snapshot.PodmanSocketPath = info.Host.RemoteSocket.Path
+	}
+
+	return nil
+}
+
+func podmanSnapshotPods(snapshot *portainer.DockerSnapshot, libpod *LibpodClient) error {
+	pods, err := libpod.Pods(context.Background())
+	if err != nil {
+		return err
+	}
+
+	snapshot.PodCount = len(pods)
+	snapshot.SnapshotRaw.Pods = pods
+
+	return nil
+}
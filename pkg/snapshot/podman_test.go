@@ -0,0 +1,64 @@
+package snapshot
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	portainer "github.com/portainer/portainer/api"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLibpodSnapshot(t *testing.T) {
+	is := require.New(t)
+
+	var signature string
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v4.0.0/libpod/_ping", func(w http.ResponseWriter, r *http.Request) {
+		signature = r.Header.Get(portainer.PortainerAgentSignatureHeader)
+
+		w.Header().Set(libpodAPIVersionHeader, "4.9.3")
+		w.Write([]byte("OK"))
+	})
+	mux.HandleFunc("/v4.0.0/libpod/info", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"host":{"remoteSocket":{"exists":true,"path":"/run/user/1000/podman/podman.sock"},"security":{"rootless":true}}}`))
+	})
+	mux.HandleFunc("/v4.0.0/libpod/pods/json", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[{"Id":"a1","Name":"web","Status":"Running","InfraId":"c0","Containers":[{"Id":"c0","Names":"a1-infra","Status":"running"},{"Id":"c1","Names":"nginx","Status":"running"}]}]`))
+	})
+
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	libpod := &LibpodClient{
+		HTTPClient: srv.Client(),
+		URL:        srv.URL,
+		Headers:    map[string]string{portainer.PortainerAgentSignatureHeader: "signature"},
+	}
+
+	is.True(libpod.Ping(context.Background()))
+	is.Equal("signature", signature)
+
+	snapshot := &portainer.DockerSnapshot{}
+	is.NoError(podmanSnapshotInfo(snapshot, libpod))
+	is.True(snapshot.PodmanRootless)
+	is.Equal("/run/user/1000/podman/podman.sock", snapshot.PodmanSocketPath)
+
+	is.NoError(podmanSnapshotPods(snapshot, libpod))
+	is.Equal(1, snapshot.PodCount)
+	is.Equal("web", snapshot.SnapshotRaw.Pods[0].Name)
+	is.Len(snapshot.SnapshotRaw.Pods[0].Containers, 2)
+}
+
+func TestLibpodPingDocker(t *testing.T) {
+	// the Docker engines do not know the libpod API
+	srv := httptest.NewServer(http.NotFoundHandler())
+	defer srv.Close()
+
+	libpod := &LibpodClient{HTTPClient: srv.Client(), URL: srv.URL}
+
+	require.False(t, libpod.Ping(context.Background()))
+}